@@ -10,11 +10,6 @@
 //	go run ./cmd/gen-provider-matrix              # rewrite the file in place
 //	go run ./cmd/gen-provider-matrix -check       # exit non-zero if regen needed
 //	go run ./cmd/gen-provider-matrix -output FILE # write to a different file
-//
-// AllMusic is intentionally excluded: the adapter exists in
-// internal/provider/allmusic but is not part of AllProviderNames() in normal
-// builds. The renderer also filters it defensively in case the registry order
-// changes; see project_allmusic_cleanup / issue #1275.
 package main
 
 import (
@@ -87,9 +82,7 @@ func run(outPath string, checkOnly bool) error {
 }
 
 // renderMatrix returns the Markdown table body (without surrounding markers)
-// for the providers in names, looked up in caps. The AllMusic adapter is
-// defensively skipped (it is not part of AllProviderNames in normal builds;
-// see project_allmusic_cleanup / issue #1275). A provider that appears in
+// for the providers in names, looked up in caps. A provider that appears in
 // names but lacks a capability declaration in caps is treated as a hard error
 // so drift between AllProviderNames() and ProviderCapabilities() fails the
 // `make generate-docs` / `-check` pipeline loudly instead of silently dropping
@@ -99,9 +92,6 @@ func renderMatrix(names []provider.ProviderName, caps map[provider.ProviderName]
 	b.WriteString("| Provider | Tier | Sign-up | Rate limit | Mirror | Metadata fields | Image types |\n")
 	b.WriteString("|---|---|---|---|---|---|---|\n")
 	for _, name := range names {
-		if name == provider.NameAllMusic {
-			continue
-		}
 		c, ok := caps[name]
 		if !ok {
 			return "", fmt.Errorf("missing capability declaration for provider %q in ProviderCapabilities()", name)
@@ -21,18 +21,15 @@ func TestRenderMatrix_HappyPath(t *testing.T) {
 
 	// Every in-use provider gets a row keyed by its DisplayName.
 	for _, name := range provider.AllProviderNames() {
-		if name == provider.NameAllMusic {
-			continue
-		}
 		needle := "| " + name.DisplayName() + " |"
 		if !strings.Contains(got, needle) {
 			t.Errorf("expected row for %s; output was:\n%s", name.DisplayName(), got)
 		}
 	}
 
-	// AllMusic must never appear (skip-entirely policy from the spec).
-	if strings.Contains(got, "AllMusic") {
-		t.Errorf("AllMusic should be excluded from generated matrix; got:\n%s", got)
+	// AllMusic is keyless and scraped from public pages at 1 req/s.
+	if !strings.Contains(got, "AllMusic | Free | Not required | 1/sec |") {
+		t.Errorf("AllMusic row not rendered as expected; got:\n%s", got)
 	}
 
 	// Spot-check a few representative renderings.
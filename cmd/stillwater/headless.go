@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sydlexius/stillwater/internal/headless"
+)
+
+// runHeadless runs a headless job subcommand (scan, rules run, fetch,
+// export-settings, import-settings, report compliance) and returns the
+// process exit code. See internal/headless for the commands themselves.
+//
+// It builds the services through the same phases as run(), so a headless job
+// sees the same configuration, migrations and wiring as the server, but it
+// never calls startListeners: no HTTP listener, scheduler or watcher starts.
+// Logs go to stderr so stdout carries only the command's document.
+func runHeadless(name string, args []string) int {
+	// Parse before touching the database: a typo or -h must not migrate a
+	// database or print startup logs.
+	cmd, err := headless.Parse(name, args, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return headless.ExitOK
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return headless.ExitError
+	}
+
+	a := newApplication(WithLogConsole(os.Stderr))
+	if err := a.loadConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return headless.ExitError
+	}
+	if err := a.setupLogging(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return headless.ExitError
+	}
+	defer a.logManager.Close() //nolint:errcheck // Close error not actionable on cleanup
+
+	if err := a.openStorage(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return headless.ExitError
+	}
+	defer func() {
+		if err := a.db.Close(); err != nil {
+			a.logger.Error("closing database", "error", err)
+		}
+	}()
+
+	if err := a.wireSecurity(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return headless.ExitError
+	}
+	if err := a.buildServices(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return headless.ExitError
+	}
+	// LIFO: the scanner stops before the bus, so a scan canceled by Ctrl-C
+	// can still publish its final events; the bus drains the durable ones
+	// into the outbox for the server to deliver.
+	defer a.eventBus.Stop()
+	defer a.scannerService.Shutdown()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	return cmd.Run(ctx, headless.Deps{
+		Scanner:   a.scannerService,
+		Artists:   a.artistService,
+		Libraries: a.libraryService,
+		Rules:     a.ruleService,
+		Engine:    a.ruleEngine,
+		Pipeline:  a.pipeline,
+		Refresher: a.router,
+		Settings:  a.settingsIOService,
+		Getenv:    os.Getenv,
+		Stdin:     os.Stdin,
+	}, os.Stdout)
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -19,8 +20,10 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/sydlexius/stillwater/internal/album"
 	"github.com/sydlexius/stillwater/internal/api"
 	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/artwork"
 	"github.com/sydlexius/stillwater/internal/auth"
 	"github.com/sydlexius/stillwater/internal/backup"
 	"github.com/sydlexius/stillwater/internal/cli"
@@ -32,6 +35,7 @@ import (
 	"github.com/sydlexius/stillwater/internal/encryption"
 	"github.com/sydlexius/stillwater/internal/event"
 	"github.com/sydlexius/stillwater/internal/filesystem"
+	"github.com/sydlexius/stillwater/internal/headless"
 	"github.com/sydlexius/stillwater/internal/i18n"
 	img "github.com/sydlexius/stillwater/internal/image"
 	"github.com/sydlexius/stillwater/internal/imagebridge"
@@ -43,6 +47,7 @@ import (
 	"github.com/sydlexius/stillwater/internal/nfo"
 	"github.com/sydlexius/stillwater/internal/platform"
 	"github.com/sydlexius/stillwater/internal/provider"
+	"github.com/sydlexius/stillwater/internal/provider/allmusic"
 	"github.com/sydlexius/stillwater/internal/provider/audiodb"
 	"github.com/sydlexius/stillwater/internal/provider/deezer"
 	"github.com/sydlexius/stillwater/internal/provider/discogs"
@@ -85,6 +90,15 @@ func main() {
 				os.Exit(1)
 			}
 			return
+		case "decrypt-backup":
+			if err := decryptBackup(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if headless.IsCommand(os.Args[1]) {
+			os.Exit(runHeadless(os.Args[1], os.Args[2:]))
 		}
 	}
 
@@ -129,7 +143,7 @@ func dispatchFlagCommand(cliFlags cli.Flags, stderr io.Writer) (handled bool, er
 		if cliFlags.NewPassword != "" {
 			_, _ = fmt.Fprintln(stderr, "warning: --new-password exposes the password in process arguments; consider using the interactive prompt instead")
 		}
-		return true, resetPassword(cliFlags.Username, cliFlags.NewPassword)
+		return true, resetPassword(cliFlags.Username, cliFlags.NewPassword, cliFlags.ClearTwoFactor)
 	}
 
 	if cliFlags.LockDamageDryRun {
@@ -166,6 +180,7 @@ type Application struct {
 	artistService       *artist.Service
 	historyService      *artist.HistoryService
 	libraryService      *library.Service
+	albumService        *album.Service
 	defaultLibID        string
 	platformService     *platform.Service
 	connectionService   *connection.Service
@@ -180,6 +195,7 @@ type Application struct {
 	providerRegistry    *provider.Registry
 	webSearchRegistry   *provider.WebSearchRegistry
 	orchestrator        *provider.Orchestrator
+	responseCache       *provider.ResponseCache
 	scraperService      *scraper.Service
 	nfoSnapshotService  *nfo.SnapshotService
 	nfoSettingsService  *nfo.NFOSettingsService
@@ -194,6 +210,7 @@ type Application struct {
 	webhookService      *webhook.Service
 	webhookDispatcher   *webhook.Dispatcher
 	backupService       *backup.Service
+	artworkRevisions    *artwork.Store
 	maintenanceService  *maintenance.Service
 	lockSyncService     *connection.LockSync
 	settingsIOService   *settingsio.Service
@@ -213,6 +230,10 @@ type Application struct {
 	// that will never close. See drainLockDamageRepair.
 	lockDamageRepairDone chan struct{}
 
+	// logConsole is where console logs go; nil means stdout. Headless
+	// subcommands point it at stderr so stdout carries only their output.
+	logConsole io.Writer
+
 	// Testing seams: override these via functional options before calling run phases.
 	encKeyResolver func(cfg *config.Config, logger *slog.Logger) (string, error)
 	dbOpener       func(path string) (*sql.DB, error)
@@ -231,6 +252,11 @@ func WithDBOpener(fn func(path string) (*sql.DB, error)) Option {
 	return func(a *Application) { a.dbOpener = fn }
 }
 
+// WithLogConsole sends console logs to w instead of stdout.
+func WithLogConsole(w io.Writer) Option {
+	return func(a *Application) { a.logConsole = w }
+}
+
 // newApplication creates an Application with production defaults.
 func newApplication(opts ...Option) *Application {
 	a := &Application{
@@ -347,8 +373,9 @@ func (a *Application) setupLogging() error {
 		return errors.New("setupLogging: loadConfig must run first")
 	}
 	logCfg := logging.Config{
-		Level:  a.cfg.Logging.Level,
-		Format: a.cfg.Logging.Format,
+		Level:   a.cfg.Logging.Level,
+		Format:  a.cfg.Logging.Format,
+		Console: a.logConsole,
 	}
 	logManager, logger := logging.NewManager(logCfg)
 	a.logManager = logManager
@@ -557,7 +584,9 @@ func (a *Application) buildServices() error {
 		return err
 	}
 
-	wireInfraServices(ctx, a, db, cfg, logger)
+	if err := wireInfraServices(ctx, a, db, cfg, logger); err != nil {
+		return err
+	}
 	// The locked-field damage repair (#3075) needs the artist service and
 	// history repository, both built by wireAuth above; wireInfraServices
 	// cannot pass them to NewService because it runs with only db and cfg in
@@ -611,9 +640,11 @@ func (a *Application) buildServices() error {
 		ConnectionService:  a.connectionService,
 		ScraperService:     a.scraperService,
 		LibraryService:     a.libraryService,
+		AlbumService:       a.albumService,
 		WebhookService:     a.webhookService,
 		WebhookDispatcher:  a.webhookDispatcher,
 		BackupService:      a.backupService,
+		ArtworkRevisions:   a.artworkRevisions,
 		LogManager:         a.logManager,
 		MaintenanceService: a.maintenanceService,
 		SettingsIOService:  a.settingsIOService,
@@ -658,32 +689,132 @@ func (a *Application) buildServices() error {
 
 // wireEventBus initializes the event bus and starts it. The corresponding
 // Stop is deferred in run() immediately after buildServices returns.
+//
+// The outbox is installed before Start so no durable event is published onto
+// the in-memory path first. If it cannot be read the bus still runs, with
+// every event in memory as before, so a database problem degrades delivery
+// instead of blocking startup.
 func wireEventBus(a *Application, logger *slog.Logger) {
 	a.eventBus = event.NewBus(logger, 256)
+	if err := a.eventBus.SetOutbox(event.NewOutbox(a.db)); err != nil {
+		logger.Error("event outbox unavailable; durable events will be delivered in memory only", "error", err)
+	}
 	go a.eventBus.Start()
-	a.webhookService = webhook.NewService(a.db)
+	a.webhookService = webhook.NewService(a.db).WithEncryptor(a.encryptor)
 	a.webhookDispatcher = webhook.NewDispatcher(a.webhookService, logger)
 }
 
 // wireInfraServices wires backup, maintenance, settingsIO, and updater
-// services that depend only on db and cfg.
-func wireInfraServices(ctx context.Context, a *Application, db *sql.DB, cfg *config.Config, logger *slog.Logger) {
+// services that depend only on db and cfg. It fails only when a configured
+// backup key cannot be loaded.
+func wireInfraServices(ctx context.Context, a *Application, db *sql.DB, cfg *config.Config, logger *slog.Logger) error {
 	backupDir := cfg.Backup.Path
 	if backupDir == "" {
 		backupDir = filepath.Join(filepath.Dir(cfg.Database.Path), "backups")
 	}
-	a.backupService = backup.NewService(db, backupDir, cfg.Backup.RetentionCount, logger)
+	backupKey, err := loadBackupKey(cfg.Backup, logger)
+	if err != nil {
+		return err
+	}
+	a.backupService = backup.NewService(db, backupDir, cfg.Backup.RetentionCount, logger).WithKey(backupKey)
 	if dbRetention := getDBIntSetting(ctx, db, "backup_retention_count", 0); dbRetention > 0 {
 		a.backupService.SetRetention(dbRetention)
 	}
 	if dbMaxAge := getDBIntSetting(ctx, db, "backup_max_age_days", -1); dbMaxAge >= 0 {
 		a.backupService.SetMaxAgeDays(dbMaxAge)
 	}
+	// Artwork revision history lives beside the database: its rows are in it,
+	// and the content-addressed objects they point at are only meaningful
+	// together with them. Installed as the image package's recorder so every
+	// protected image write feeds it.
+	a.artworkRevisions = artwork.NewStore(db, filepath.Join(filepath.Dir(cfg.Database.Path), "artwork-revisions"), logger)
+	a.artworkRevisions.SetKeep(getDBIntSetting(ctx, db, "artwork.revisions.keep", artwork.DefaultKeep))
+	if dbMaxAge := getDBIntSetting(ctx, db, "artwork.revisions.max_age_days", -1); dbMaxAge >= 0 {
+		a.artworkRevisions.SetMaxAgeDays(dbMaxAge)
+	}
+	img.SetRevisionRecorder(a.artworkRevisions)
 	a.maintenanceService = maintenance.NewService(db, cfg.Database.Path, a.imageCacheDir, logger)
 	a.settingsIOService = settingsio.NewService(db, a.providerSettings, a.connectionService, a.platformService, a.webhookService).
 		WithRuleService(a.ruleService).
 		WithScraperService(a.scraperService)
+	a.backupService.WithSettingsExporter(a.settingsIOService).WithImageCacheDir(a.imageCacheDir)
+	if cfg.Backup.Archive {
+		a.backupService.ScheduleArchives(backup.ArchiveOptions{Artwork: cfg.Backup.ArchiveArtwork})
+	}
+	wireBackupTargets(a.backupService, cfg.Backup, logger)
 	a.updaterService = updater.NewService(db, logger)
+	return nil
+}
+
+// loadBackupKey returns the key backups are encrypted with, or nil when
+// neither SW_BACKUP_PASSPHRASE nor SW_BACKUP_KEY_FILE is set. A key file that
+// cannot be read or parsed is fatal, as SW_ENCRYPTION_KEY_FILE is: carrying
+// on would write plaintext backups the operator asked to have encrypted.
+func loadBackupKey(cfg config.BackupConfig, logger *slog.Logger) (*backup.Key, error) {
+	if cfg.Passphrase != "" {
+		return backup.PassphraseKey(cfg.Passphrase), nil
+	}
+	if cfg.KeyFile == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading SW_BACKUP_KEY_FILE %s: %w", cfg.KeyFile, err)
+	}
+	key, err := backup.ParseKeyFile(data)
+	if err != nil {
+		return nil, fmt.Errorf("SW_BACKUP_KEY_FILE %s: %w", cfg.KeyFile, err)
+	}
+	warnIfKeyFileTooOpen(cfg.KeyFile, logger)
+	return key, nil
+}
+
+// wireBackupTargets adds the configured off-box backup targets. A target that
+// cannot be built (an unreadable key file, a bad endpoint) is logged and left
+// out: local backups still run, and the operator sees the error at startup
+// rather than on the first push.
+func wireBackupTargets(svc *backup.Service, cfg config.BackupConfig, logger *slog.Logger) {
+	if s3 := cfg.S3; s3.Enabled() {
+		t, err := backup.NewS3Target(backup.S3Config{
+			Endpoint:  s3.Endpoint,
+			Region:    s3.Region,
+			Bucket:    s3.Bucket,
+			Prefix:    s3.Prefix,
+			AccessKey: s3.AccessKey,
+			SecretKey: s3.SecretKey,
+			PathStyle: s3.PathStyle,
+		})
+		if err != nil {
+			logger.Error("S3 backup target disabled", "error", err)
+		} else {
+			svc.AddTarget(t, s3.Retention)
+		}
+	}
+	if sftp := cfg.SFTP; sftp.Enabled() {
+		var key []byte
+		if sftp.KeyFile != "" {
+			var err error
+			key, err = os.ReadFile(sftp.KeyFile) //nolint:gosec // G304: operator-configured key path.
+			if err != nil {
+				logger.Error("SFTP backup target disabled", "error", fmt.Errorf("reading SW_BACKUP_SFTP_KEY_FILE: %w", err))
+				return
+			}
+		}
+		t, err := backup.NewSFTPTarget(backup.SFTPConfig{
+			Addr:               net.JoinHostPort(sftp.Host, strconv.Itoa(sftp.Port)),
+			User:               sftp.User,
+			Password:           sftp.Password,
+			PrivateKey:         key,
+			HostKeyFingerprint: sftp.HostKey,
+			KnownHostsFile:     sftp.KnownHostsFile,
+			Dir:                sftp.Path,
+		})
+		if err != nil {
+			logger.Error("SFTP backup target disabled", "error", err)
+			return
+		}
+		svc.AddTarget(t, sftp.Retention)
+	}
 }
 
 // wireEventSubscriptions connects the event bus to the webhook dispatcher,
@@ -692,10 +823,10 @@ func wireInfraServices(ctx context.Context, a *Application, db *sql.DB, cfg *con
 func wireEventSubscriptions(a *Application) {
 	// event.WebhookEventTypes is the single source of truth for the
 	// webhook-eligible event set; the API validates subscriptions and openapi
-	// documents the enum against the same list (#2009 #6).
-	for _, eventType := range event.WebhookEventTypes() {
-		a.eventBus.Subscribe(eventType, a.webhookDispatcher.HandleEvent)
-	}
+	// documents the enum against the same list (#2009 #6). The dispatcher
+	// reads them from the outbox under its own cursor, so events published
+	// during a burst or while the process was down still reach webhooks.
+	a.eventBus.SubscribeDurable("webhooks", event.WebhookEventTypes(), a.webhookDispatcher.HandleEvent)
 	a.scannerService.SetEventBus(a.eventBus)
 	a.bulkExecutor.SetEventBus(a.eventBus)
 	if fsCache := a.ruleEngine.FSCache(); fsCache != nil {
@@ -707,6 +838,37 @@ func wireEventSubscriptions(a *Application) {
 			})
 		}
 	}
+	// Sign-in lockouts are published for webhooks; the audit log entry is
+	// written by the auth service itself.
+	a.authService.WithLockoutNotifier(func(l auth.Lockout) {
+		a.logger.Warn("sign-in locked out", "scope", l.Scope, "key", l.Key,
+			"failures", l.Failures, "until", l.Until, "ip", l.IP)
+		a.eventBus.Publish(event.Event{
+			Type: event.SecurityLockout,
+			Data: map[string]any{
+				"scope":        l.Scope,
+				"key":          l.Key,
+				"user_id":      l.UserID,
+				"failures":     l.Failures,
+				"locked_until": l.Until.UTC().Format(time.RFC3339),
+				"ip_address":   l.IP,
+				"message":      l.Message(),
+			},
+		})
+	})
+	// Failed off-box backup copies are already logged by the backup service;
+	// the event carries them to the browser and to webhooks.
+	a.backupService.WithPushFailureNotifier(func(f backup.PushFailure) {
+		a.eventBus.Publish(event.Event{
+			Type: event.BackupPushFailed,
+			Data: map[string]any{
+				"target":   f.Target,
+				"filename": f.Filename,
+				"stage":    f.Stage,
+				"message":  f.Message(),
+			},
+		})
+	})
 	a.healthSub = rule.NewHealthSubscriber(a.ruleEngine, a.artistService, a.logger)
 	a.eventBus.Subscribe(event.ArtistUpdated, a.healthSub.HandleEvent)
 	a.dirtySub = rule.NewDirtySubscriber(a.artistService, a.logger)
@@ -754,7 +916,8 @@ func resolveRuleSchedule(a *Application, db *sql.DB, logger *slog.Logger) {
 
 // wireAuth wires library, artist, history, platform, connection, and the
 // authentication service / registry (plus any external auth provider
-// configured in the settings table -- emby / jellyfin). All failure modes
+// configured in the settings table -- emby / jellyfin -- or the environment --
+// forward auth / LDAP). All failure modes
 // today are log-and-degrade: external auth provider construction Warns on
 // error and skips that provider, so the function never returns a non-nil
 // error and the signature has no error return (per unparam).
@@ -772,12 +935,22 @@ func (a *Application) wireAuth(ctx context.Context) {
 	a.historyService = artist.NewHistoryService(db)
 	a.artistService.SetHistoryService(a.historyService)
 
+	// --- Albums ---
+	a.albumService = album.NewService(db)
+
 	// --- Platform / Connection ---
 	a.platformService = platform.NewService(db)
 	a.connectionService = connection.NewService(db, a.encryptor)
 
 	// --- Auth ---
-	a.authService = auth.NewService(db)
+	lockout := cfg.Auth.Lockout
+	a.authService = auth.NewService(db).WithEncryptor(a.encryptor).WithLockoutPolicy(auth.LockoutPolicy{
+		UserThreshold: lockout.UserThreshold,
+		IPThreshold:   lockout.IPThreshold,
+		BaseDuration:  time.Duration(lockout.LockMinutes) * time.Minute,
+		MaxDuration:   time.Duration(lockout.MaxLockMinutes) * time.Minute,
+		Window:        time.Duration(lockout.WindowMinutes) * time.Minute,
+	})
 	a.authRegistry = auth.NewRegistry()
 	a.authRegistry.Register(auth.NewLocalProvider(db))
 	authMethod := getDBStringSetting(ctx, db, "auth.method", "local")
@@ -798,10 +971,55 @@ func (a *Application) wireAuth(ctx context.Context) {
 			}
 		}
 	}
+	// Forward auth is configured from the environment, not settings: it
+	// trusts a request header, so turning it on belongs to whoever controls
+	// the proxy and SW_TRUSTED_PROXIES.
+	if fwd := cfg.Auth.Forward; fwd.Enabled() {
+		p, err := auth.NewForwardAuthProvider(auth.ForwardAuthConfig{
+			UserHeader:     fwd.UserHeader,
+			GroupsHeader:   fwd.GroupsHeader,
+			TrustedProxies: cfg.Server.TrustedProxies,
+			AdminGroups:    fwd.AdminGroups,
+			UserGroups:     fwd.UserGroups,
+			DefaultRole:    fwd.DefaultRole,
+			AutoProvision:  fwd.AutoProvision,
+		})
+		if err != nil {
+			logger.Warn("failed to create forward auth provider", "error", err)
+		} else {
+			a.authRegistry.Register(p)
+			logger.Info("forward authentication enabled", "user_header", fwd.UserHeader, "groups_header", fwd.GroupsHeader)
+		}
+	}
+	if dir := cfg.Auth.LDAP; dir.Enabled() {
+		p, err := auth.NewLDAPProvider(auth.LDAPConfig{
+			URL:                   dir.URL,
+			StartTLS:              dir.StartTLS,
+			InsecureSkipTLSVerify: dir.SkipTLSVerify,
+			BindDN:                dir.BindDN,
+			BindPassword:          dir.BindPassword,
+			BaseDN:                dir.BaseDN,
+			UserFilter:            dir.UserFilter,
+			UsernameAttribute:     dir.UsernameAttribute,
+			DisplayNameAttribute:  dir.DisplayNameAttribute,
+			GroupBaseDN:           dir.GroupBaseDN,
+			GroupFilter:           dir.GroupFilter,
+			AdminGroups:           dir.AdminGroups,
+			UserGroups:            dir.UserGroups,
+			DefaultRole:           dir.DefaultRole,
+			AutoProvision:         dir.AutoProvision,
+		})
+		if err != nil {
+			logger.Warn("failed to create ldap auth provider", "error", err)
+		} else {
+			a.authRegistry.Register(p)
+			logger.Info("ldap authentication enabled", "url", dir.URL, "base_dn", dir.BaseDN)
+		}
+	}
 }
 
 // wireProviders wires the metadata provider registry (MusicBrainz, Fanart.tv,
-// and the remaining nine adapters), the web-search registry, the orchestrator,
+// and the remaining ten adapters), the web-search registry, the orchestrator,
 // and the scraper service that backs the orchestrator's executor.
 func (a *Application) wireProviders(ctx context.Context) error {
 	db := a.db
@@ -851,6 +1069,7 @@ func (a *Application) wireProviders(ctx context.Context) error {
 	a.providerRegistry.Register(deezer.New(a.rateLimiters, logger))
 	a.providerRegistry.Register(wikipedia.New(a.rateLimiters, a.providerSettings, logger))
 	a.providerRegistry.Register(genius.New(a.rateLimiters, a.providerSettings, logger))
+	a.providerRegistry.Register(allmusic.New(a.rateLimiters, a.providerSettings, logger))
 	a.providerRegistry.Register(spotify.New(a.rateLimiters, a.providerSettings, logger))
 
 	a.webSearchRegistry = provider.NewWebSearchRegistry()
@@ -858,12 +1077,28 @@ func (a *Application) wireProviders(ctx context.Context) error {
 
 	a.orchestrator = provider.NewOrchestrator(a.providerRegistry, a.providerSettings, logger, aimdCtrl)
 
+	// Persistent provider response cache, shared by the orchestrator and the
+	// scraper executor. A settings failure is logged and the cache runs on
+	// its defaults: a cold cache only costs provider calls, which is what
+	// every install did before the cache existed. Expired entries are
+	// pruned by the loop startListeners starts.
+	responseCache := provider.NewResponseCache(db, logger)
+	a.responseCache = responseCache
+	if err := responseCache.LoadSettings(ctx, a.providerSettings); err != nil {
+		logger.Warn("failed to load provider cache settings", "error", err)
+	}
+	if responseCache.Offline() {
+		logger.Warn("provider cache is in offline mode: providers will not be contacted")
+	}
+	a.orchestrator.SetResponseCache(responseCache)
+
 	// --- Scraper ---
 	a.scraperService = scraper.NewService(db, logger)
 	if err := a.scraperService.SeedDefaults(ctx); err != nil {
 		return fmt.Errorf("seeding default scraper config: %w", err)
 	}
 	scraperExecutor := scraper.NewExecutor(a.scraperService, a.providerRegistry, a.providerSettings, logger, aimdCtrl)
+	scraperExecutor.SetResponseCache(responseCache)
 	a.orchestrator.SetExecutor(scraperExecutor)
 
 	return nil
@@ -901,6 +1136,7 @@ func (a *Application) wireRuleEngine(ctx context.Context, logger *slog.Logger) e
 	a.scannerService.SetDefaultLibraryID(a.defaultLibID)
 	a.scannerService.SetLibraryLister(a.libraryService)
 	a.scannerService.SetMtimeFastPath(cfg.Scanner.MtimeFastPath)
+	a.scannerService.SetTagIdentity(cfg.Scanner.TagIdentity)
 
 	// --- NFO ---
 	a.nfoSnapshotService = nfo.NewSnapshotService(db)
@@ -945,6 +1181,10 @@ func (a *Application) wireRuleEngine(ctx context.Context, logger *slog.Logger) e
 	// natural home for this orchestration.
 	a.artistService.SetPlatformRenameSyncer(a.publisher)
 
+	// Renames in a library sorted into letter buckets move the directory to
+	// the bucket of its new name; the library service knows each layout.
+	a.artistService.SetDirectoryPlacer(a.libraryService)
+
 	// Wire the post-merge platform refresher so Service.MergeAndReconcile
 	// re-indexes the survivor and evicts stale loser items on Emby/Jellyfin
 	// (library scan) and Lidarr (survivor artist refresh) after a merge
@@ -1074,6 +1314,11 @@ func (a *Application) wireRuleEngine(ctx context.Context, logger *slog.Logger) e
 	// capability path, so the release-group fetcher is injected directly here.
 	// Same fail-open contract as the metadata fixer's gate above.
 	a.bulkExecutor.SetAlbumGate(artist.NewFilesystemAlbumSource(), releaseGroupFetcher)
+	// Durable undo: each artist a bulk fetch-metadata job changes gets an undo
+	// record tagged with the job, so the whole run can be reverted from
+	// POST /api/v1/bulk/jobs/{id}/undo. The router builds its own store over
+	// the same tables for the single-fix path.
+	a.bulkExecutor.SetUndoStore(rule.NewUndoStore(db, a.artistService))
 
 	// Overlay UI-persisted operational settings (#1746, #1753) now that the
 	// scanner and rule pipeline are wired. Env-wins; see applyPersistedOpsSettings.
@@ -1336,10 +1581,19 @@ func (a *Application) startListeners() error {
 		}
 	}()
 
+	// Provider cache pruner: drops expired entries hourly (skipped while the
+	// cache is offline, which still serves them).
+	go a.responseCache.StartPruner(ctx, time.Hour)
+
 	// Backup scheduler.
 	if cfg.Backup.Enabled {
 		go a.backupService.StartScheduler(ctx, time.Duration(cfg.Backup.IntervalHours)*time.Hour)
 	}
+	// Backup verifier. It runs with automated backups off too: manual and
+	// fetched backups rot the same way.
+	if cfg.Backup.VerifyHours > 0 {
+		go a.backupService.StartVerifier(ctx, time.Duration(cfg.Backup.VerifyHours)*time.Hour)
+	}
 
 	// Maintenance scheduler (interval from DB settings, defaults to daily).
 	{
@@ -1445,11 +1699,20 @@ func (a *Application) startListeners() error {
 
 	// Filesystem watcher for libraries with fs_watch enabled.
 	{
-		scanFn := func(ctx context.Context) error {
-			_, err := a.scannerService.Run(ctx)
+		scanFn := func(ctx context.Context, dirs []string) error {
+			var err error
+			if dirs == nil {
+				_, err = a.scannerService.Run(ctx)
+			} else {
+				_, err = a.scannerService.RunDirs(ctx, dirs)
+			}
+			if errors.Is(err, scanner.ErrScanInProgress) {
+				return fmt.Errorf("%w: %w", watcher.ErrScanBusy, err)
+			}
 			return err
 		}
 		watcherService := watcher.NewService(scanFn, a.libraryService, a.eventBus, logger, a.probeCache, a.expectedWrites)
+		watcherService.SetReconcileInterval(time.Duration(cfg.Scanner.ReconcileHours) * time.Hour)
 		go watcherService.Start(ctx)
 	}
 
@@ -1666,6 +1929,13 @@ func applyPersistedOpsSettings(ctx context.Context, a *Application, logger *slog
 		logger.Info("applied persisted scanner.mtime_fast_path override", "enabled", enabled)
 	}
 
+	// scanner.tag_identity -- same shape as scanner.mtime_fast_path.
+	if !envSet("SW_SCANNER_TAG_IDENTITY") && dbSettingPresent(ctx, db, logger, "scanner.tag_identity") {
+		enabled := getDBBoolSetting(ctx, db, "scanner.tag_identity", cfg.Scanner.TagIdentity)
+		a.scannerService.SetTagIdentity(enabled)
+		logger.Info("applied persisted scanner.tag_identity override", "enabled", enabled)
+	}
+
 	// rule_engine.artist_workers -- validated 1..64 by the API. Presence-gated
 	// like the scanner keys so a present-but-malformed row (or a DB read error)
 	// is warn-logged rather than silently reverting to the default: the boot
@@ -2065,10 +2335,65 @@ func resetCredentials() error {
 	return nil
 }
 
-// resetPassword updates the password for a user in the database.
-// It opens the database, runs migrations, prompts for a password if needed,
-// then delegates to resetPasswordDB.
-func resetPassword(username, password string) error {
+// decryptBackup writes the plaintext of an encrypted backup, for restoring a
+// database snapshot by hand or reading an archive with other tools. The key
+// is the server's: SW_BACKUP_PASSPHRASE or SW_BACKUP_KEY_FILE.
+func decryptBackup(args []string) error {
+	fs := flag.NewFlagSet("decrypt-backup", flag.ContinueOnError)
+	input := fs.String("input", "", "encrypted backup to read")
+	output := fs.String("output", "", "file to write the plaintext to (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *input == "" {
+		return errors.New("--input is required")
+	}
+
+	configPath := os.Getenv("SW_CONFIG_PATH")
+	if configPath == "" {
+		configPath = "/config/config.toml"
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	key, err := loadBackupKey(cfg.Backup, slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return errors.New("set SW_BACKUP_PASSPHRASE or SW_BACKUP_KEY_FILE to the key the backup was made with")
+	}
+
+	in, err := os.Open(*input)
+	if err != nil {
+		return err
+	}
+	defer in.Close() //nolint:errcheck // Read-only handle; close error not actionable
+	if *output == "" {
+		return key.Decrypt(os.Stdout, in)
+	}
+	out, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	err = key.Decrypt(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// Half a database is worse than none: it looks restorable.
+		_ = os.Remove(*output)
+		return err
+	}
+	return nil
+}
+
+// resetPassword updates the password for a user in the database, and with
+// clearTwoFactor also removes their two-factor enrollment. It opens the
+// database, runs migrations, prompts for a password if needed, then
+// delegates to resetPasswordDB.
+func resetPassword(username, password string, clearTwoFactor bool) error {
 	configPath := os.Getenv("SW_CONFIG_PATH")
 	if configPath == "" {
 		configPath = "/config/config.toml"
@@ -2094,12 +2419,16 @@ func resetPassword(username, password string) error {
 		}
 	}
 
-	return resetPasswordDB(context.Background(), db, username, password)
+	return resetPasswordDB(context.Background(), db, username, password, clearTwoFactor)
 }
 
-// resetPasswordDB performs the password reset against an already-open database.
+// resetPasswordDB performs the password reset against an already-open database
+// and signs out the user's existing sessions. clearTwoFactor also removes the
+// user's TOTP secret and recovery codes, for an operator locked out by a lost
+// authenticator; a two-factor policy that covers the user enrolls them again
+// at their next login.
 // Accessible from tests in the same package.
-func resetPasswordDB(ctx context.Context, db *sql.DB, username, password string) error {
+func resetPasswordDB(ctx context.Context, db *sql.DB, username, password string, clearTwoFactor bool) error {
 	if username == "" {
 		if err := db.QueryRowContext(ctx,
 			"SELECT username FROM users WHERE role = 'admin' LIMIT 1").Scan(&username); err != nil {
@@ -2139,6 +2468,34 @@ func resetPasswordDB(ctx context.Context, db *sql.DB, username, password string)
 	}
 
 	fmt.Printf("Password for user '%s' has been reset successfully.\n", username)
+
+	var userID string
+	if err := db.QueryRowContext(ctx,
+		"SELECT id FROM users WHERE username = ?", username).Scan(&userID); err != nil {
+		return fmt.Errorf("querying user: %w", err)
+	}
+	// A reset usually follows a lost or leaked password, so whoever is
+	// signed in with the old one is signed out.
+	svc := auth.NewService(db)
+	revoked, err := svc.RevokeUserSessions(ctx, userID, "")
+	if err != nil {
+		return fmt.Errorf("signing out existing sessions: %w", err)
+	}
+	if revoked > 0 {
+		fmt.Printf("Signed out %d existing session(s) for user '%s'.\n", revoked, username)
+	}
+
+	// The owner may have locked themselves out before asking for the reset.
+	if err := svc.UnlockUser(ctx, userID, ""); err != nil {
+		return fmt.Errorf("lifting sign-in lockout: %w", err)
+	}
+
+	if clearTwoFactor {
+		if err := svc.ResetTwoFactor(ctx, userID); err != nil {
+			return fmt.Errorf("clearing two-factor authentication: %w", err)
+		}
+		fmt.Printf("Two-factor authentication for user '%s' has been cleared.\n", username)
+	}
 	return nil
 }
 
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/sydlexius/stillwater/internal/auth"
 	"github.com/sydlexius/stillwater/internal/config"
@@ -99,19 +100,69 @@ func TestResetPasswordWithExplicitUser(t *testing.T) {
 	ctx := context.Background()
 	insertUser(t, ctx, db, "alice", "oldpass", "admin")
 
-	if err := resetPasswordDB(ctx, db, "alice", "newpass"); err != nil {
+	if err := resetPasswordDB(ctx, db, "alice", "newpass", false); err != nil {
 		t.Fatalf("resetPasswordDB: %v", err)
 	}
 	assertPassword(t, ctx, db, "alice", "newpass")
 	assertPasswordWrong(t, ctx, db, "alice", "oldpass")
 }
 
+func TestResetPasswordSignsOutSessions(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	insertUser(t, ctx, db, "alice", "oldpass", "admin")
+	insertUser(t, ctx, db, "bob", "oldpass", "admin")
+	svc := auth.NewService(db)
+	for _, id := range []string{"test-id-alice", "test-id-alice", "test-id-bob"} {
+		if _, err := svc.CreateSession(ctx, id); err != nil {
+			t.Fatalf("CreateSession(%s): %v", id, err)
+		}
+	}
+
+	if err := resetPasswordDB(ctx, db, "alice", "newpass", false); err != nil {
+		t.Fatalf("resetPasswordDB: %v", err)
+	}
+
+	var alice, bob int
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sessions WHERE user_id = 'test-id-alice'").Scan(&alice); err != nil {
+		t.Fatalf("counting sessions: %v", err)
+	}
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sessions WHERE user_id = 'test-id-bob'").Scan(&bob); err != nil {
+		t.Fatalf("counting sessions: %v", err)
+	}
+	if alice != 0 || bob != 1 {
+		t.Errorf("sessions after reset: alice=%d bob=%d, want 0 and 1", alice, bob)
+	}
+}
+
+func TestResetPasswordLiftsLockout(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	insertUser(t, ctx, db, "alice", "oldpass", "admin")
+	svc := auth.NewService(db).WithLockoutPolicy(auth.LockoutPolicy{
+		UserThreshold: 1, BaseDuration: time.Hour, MaxDuration: time.Hour, Window: time.Hour,
+	})
+	if err := svc.RecordLoginFailure(ctx, "alice"); err != nil {
+		t.Fatalf("RecordLoginFailure: %v", err)
+	}
+	if err := svc.CheckLogin(ctx, "alice"); err == nil {
+		t.Fatal("alice is not locked out")
+	}
+
+	if err := resetPasswordDB(ctx, db, "alice", "newpass", false); err != nil {
+		t.Fatalf("resetPasswordDB: %v", err)
+	}
+	if err := svc.CheckLogin(ctx, "alice"); err != nil {
+		t.Errorf("CheckLogin after reset: %v, want the lockout lifted", err)
+	}
+}
+
 func TestResetPasswordDefaultsToFirstAdmin(t *testing.T) {
 	db := openTestDB(t)
 	ctx := context.Background()
 	insertUser(t, ctx, db, "admin", "oldpass", "admin")
 
-	if err := resetPasswordDB(ctx, db, "", "newpass"); err != nil {
+	if err := resetPasswordDB(ctx, db, "", "newpass", false); err != nil {
 		t.Fatalf("resetPasswordDB: %v", err)
 	}
 	assertPassword(t, ctx, db, "admin", "newpass")
@@ -121,7 +172,7 @@ func TestResetPasswordUserNotFound(t *testing.T) {
 	db := openTestDB(t)
 	ctx := context.Background()
 
-	err := resetPasswordDB(ctx, db, "ghost", "pass")
+	err := resetPasswordDB(ctx, db, "ghost", "pass", false)
 	if err == nil {
 		t.Fatal("expected error for missing user, got nil")
 	}
@@ -131,7 +182,7 @@ func TestResetPasswordNoAdminUsers(t *testing.T) {
 	db := openTestDB(t)
 	ctx := context.Background()
 
-	err := resetPasswordDB(ctx, db, "", "pass")
+	err := resetPasswordDB(ctx, db, "", "pass", false)
 	if err == nil {
 		t.Fatal("expected error when no admin users exist, got nil")
 	}
@@ -142,13 +193,47 @@ func TestResetPasswordNonAdminUser(t *testing.T) {
 	ctx := context.Background()
 	insertUser(t, ctx, db, "viewer", "oldpass", "viewer")
 
-	if err := resetPasswordDB(ctx, db, "viewer", "newpass"); err != nil {
+	if err := resetPasswordDB(ctx, db, "viewer", "newpass", false); err != nil {
 		t.Fatalf("resetPasswordDB: %v", err)
 	}
 	assertPassword(t, ctx, db, "viewer", "newpass")
 	assertPasswordWrong(t, ctx, db, "viewer", "oldpass")
 }
 
+func TestResetPasswordClearsTwoFactor(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	insertUser(t, ctx, db, "alice", "oldpass", "admin")
+	if _, err := db.ExecContext(ctx, `
+		UPDATE users SET totp_secret = 'sealed', totp_enabled = 1 WHERE username = 'alice'
+	`); err != nil {
+		t.Fatalf("enrolling: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO user_recovery_codes (id, user_id, code_hash) VALUES ('rc-1', 'test-id-alice', 'hash')
+	`); err != nil {
+		t.Fatalf("inserting recovery code: %v", err)
+	}
+
+	if err := resetPasswordDB(ctx, db, "alice", "newpass", true); err != nil {
+		t.Fatalf("resetPasswordDB: %v", err)
+	}
+	assertPassword(t, ctx, db, "alice", "newpass")
+
+	var secret string
+	var enabled, codes int
+	if err := db.QueryRowContext(ctx,
+		"SELECT totp_secret, totp_enabled FROM users WHERE username = 'alice'").Scan(&secret, &enabled); err != nil {
+		t.Fatalf("querying two-factor state: %v", err)
+	}
+	if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM user_recovery_codes").Scan(&codes); err != nil {
+		t.Fatalf("counting recovery codes: %v", err)
+	}
+	if secret != "" || enabled != 0 || codes != 0 {
+		t.Errorf("two-factor state after clear: secret=%q enabled=%d codes=%d, want all cleared", secret, enabled, codes)
+	}
+}
+
 func TestBuildTLSStatus(t *testing.T) {
 	t.Parallel()
 	cases := []struct {
@@ -312,7 +312,7 @@ func TestResetPassword_ChangesPassword(t *testing.T) {
 	t.Setenv("SW_CONFIG_PATH", filepath.Join(t.TempDir(), "nonexistent.toml"))
 	t.Setenv("SW_DB_PATH", dbPath)
 
-	if err := resetPassword("admin", "resetpw123"); err != nil {
+	if err := resetPassword("admin", "resetpw123", false); err != nil {
 		t.Fatalf("resetPassword: %v", err)
 	}
 
@@ -0,0 +1,103 @@
+package album
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/provider"
+)
+
+// ReleaseGroupMatch pairs one album with the MusicBrainz release group its
+// title matched.
+type ReleaseGroupMatch struct {
+	AlbumID      string `json:"album_id"`
+	AlbumTitle   string `json:"album_title"`
+	ReleaseGroup string `json:"release_group_id"`
+	RemoteTitle  string `json:"remote_title"`
+}
+
+// MatchResult is the outcome of MatchReleaseGroups.
+type MatchResult struct {
+	// Comparison is the CompareAlbums result over every album title, so the
+	// caller sees the same match percentage the identify flow shows.
+	Comparison artist.AlbumComparison `json:"comparison"`
+	// Applied lists the albums whose release-group ID was written.
+	Applied []ReleaseGroupMatch `json:"applied"`
+	// Ambiguous lists album titles that matched more than one release group
+	// (a reissue sharing the original's title, say). They are left alone:
+	// guessing between two groups is how a wrong ID gets written.
+	Ambiguous []string `json:"ambiguous,omitempty"`
+}
+
+// MatchReleaseGroups matches an artist's albums against its MusicBrainz
+// release groups and records the release-group ID on each album that matched
+// exactly one group.
+//
+// Matching is artist.CompareAlbums -- the same normalized-title comparison the
+// identify flow uses to score a candidate MBID -- so an album matches here if
+// and only if it counts as a match there. Only albums WITHOUT a release-group
+// ID are written: an ID already present came from album.nfo or an earlier match
+// and is not second-guessed by a title comparison. The write goes through
+// Update, so locked albums and a locked musicbrainz_release_group_id field are
+// skipped. An empty release_type is filled from the group's primary type in the
+// same write.
+func (s *Service) MatchReleaseGroups(ctx context.Context, artistID string, groups []provider.ReleaseGroupInfo) (MatchResult, error) {
+	var res MatchResult
+
+	albums, err := s.ListByArtist(ctx, artistID)
+	if err != nil {
+		return res, err
+	}
+
+	titles := make([]string, 0, len(albums))
+	for _, al := range albums {
+		titles = append(titles, al.Title)
+	}
+	remoteTitles := make([]string, 0, len(groups))
+	byNorm := make(map[string][]provider.ReleaseGroupInfo, len(groups))
+	for _, g := range groups {
+		remoteTitles = append(remoteTitles, g.Title)
+		norm := artist.NormalizeAlbumName(g.Title)
+		byNorm[norm] = append(byNorm[norm], g)
+	}
+	res.Comparison = artist.CompareAlbums(titles, remoteTitles)
+
+	for i := range albums {
+		al := &albums[i]
+		if al.MusicBrainzReleaseGroupID != "" || al.Locked || al.IsFieldLocked(FieldMusicBrainzReleaseGroupID) {
+			continue
+		}
+		candidates := byNorm[artist.NormalizeAlbumName(al.Title)]
+		switch len(candidates) {
+		case 0:
+			continue
+		case 1:
+		default:
+			res.Ambiguous = append(res.Ambiguous, al.Title)
+			continue
+		}
+		g := candidates[0]
+		if g.ID == "" {
+			continue
+		}
+		al.MusicBrainzReleaseGroupID = g.ID
+		if al.ReleaseType == "" && g.PrimaryType != "" {
+			al.ReleaseType = strings.ToLower(g.PrimaryType)
+		}
+		if err := s.Update(ctx, al); err != nil {
+			if errors.Is(err, ErrLocked) {
+				continue
+			}
+			return res, err
+		}
+		res.Applied = append(res.Applied, ReleaseGroupMatch{
+			AlbumID:      al.ID,
+			AlbumTitle:   al.Title,
+			ReleaseGroup: g.ID,
+			RemoteTitle:  g.Title,
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,77 @@
+package album
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/provider"
+)
+
+func TestMatchReleaseGroups(t *testing.T) {
+	s := NewService(newTestDB(t))
+	ctx := context.Background()
+	ar := seedArtist(t, s, "/music/Nirvana")
+
+	albums := map[string]*Album{
+		"nevermind": {Title: "Nevermind (Deluxe Edition)"},
+		"bleach":    {Title: "Bleach"},
+		"utero":     {Title: "In Utero", MusicBrainzReleaseGroupID: "existing-rg"},
+		"unplugged": {Title: "MTV Unplugged in New York", LockedFields: []string{FieldMusicBrainzReleaseGroupID}},
+		"incest":    {Title: "Incesticide"},
+	}
+	for key, al := range albums {
+		al.ArtistID = ar.ID
+		al.Path = "/music/Nirvana/" + key
+		if err := s.Create(ctx, al); err != nil {
+			t.Fatalf("Create %s: %v", key, err)
+		}
+	}
+
+	groups := []provider.ReleaseGroupInfo{
+		{ID: "rg-nevermind", Title: "Nevermind", PrimaryType: "Album"},
+		{ID: "rg-bleach", Title: "Bleach", PrimaryType: "Album"},
+		{ID: "rg-utero", Title: "In Utero", PrimaryType: "Album"},
+		{ID: "rg-unplugged", Title: "MTV Unplugged in New York", PrimaryType: "Album"},
+		// Two groups normalizing to the same title: the album is ambiguous.
+		{ID: "rg-incest-1", Title: "Incesticide", PrimaryType: "Album"},
+		{ID: "rg-incest-2", Title: "Incesticide (Remastered)", PrimaryType: "Compilation"},
+	}
+
+	res, err := s.MatchReleaseGroups(ctx, ar.ID, groups)
+	if err != nil {
+		t.Fatalf("MatchReleaseGroups: %v", err)
+	}
+
+	var applied []string
+	for _, m := range res.Applied {
+		applied = append(applied, m.ReleaseGroup)
+	}
+	slices.Sort(applied)
+	if !slices.Equal(applied, []string{"rg-bleach", "rg-nevermind"}) {
+		t.Errorf("applied = %v, want [rg-bleach rg-nevermind]", applied)
+	}
+	if !slices.Equal(res.Ambiguous, []string{"Incesticide"}) {
+		t.Errorf("ambiguous = %v, want [Incesticide]", res.Ambiguous)
+	}
+
+	for key, want := range map[string]string{
+		"nevermind": "rg-nevermind",
+		"utero":     "existing-rg",
+		"unplugged": "",
+		"incest":    "",
+	} {
+		got, err := s.GetByID(ctx, albums[key].ID)
+		if err != nil {
+			t.Fatalf("GetByID %s: %v", key, err)
+		}
+		if got.MusicBrainzReleaseGroupID != want {
+			t.Errorf("%s release group = %q, want %q", key, got.MusicBrainzReleaseGroupID, want)
+		}
+	}
+
+	got, _ := s.GetByID(ctx, albums["bleach"].ID)
+	if got.ReleaseType != "album" {
+		t.Errorf("bleach release_type = %q, want album", got.ReleaseType)
+	}
+}
@@ -0,0 +1,152 @@
+// Package album defines the album domain model: one row per album directory
+// under an artist directory, its album.nfo mapping, per-album artwork, field
+// locks, and MusicBrainz release-group matching.
+package album
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when an album record does not exist.
+var ErrNotFound = errors.New("album not found")
+
+// ErrLocked is returned by Update when the album carries the whole-album lock.
+// Automated writers treat it as "skip this album", never as a failure.
+var ErrLocked = errors.New("album is locked")
+
+// ErrUnknownField is returned when a field-lock operation names a field that is
+// not in LockableFields.
+var ErrUnknownField = errors.New("unknown album field")
+
+// Album is one album directory and the metadata Stillwater manages for it.
+type Album struct {
+	ID                        string     `json:"id"`
+	ArtistID                  string     `json:"artist_id"`
+	Title                     string     `json:"title"`
+	Path                      string     `json:"path"`
+	Year                      string     `json:"year"`
+	ReleaseDate               string     `json:"release_date"`
+	OriginalReleaseDate       string     `json:"original_release_date"`
+	ReleaseType               string     `json:"release_type"`
+	Label                     string     `json:"label"`
+	Genres                    []string   `json:"genres"`
+	Styles                    []string   `json:"styles"`
+	Moods                     []string   `json:"moods"`
+	Review                    string     `json:"review"`
+	MusicBrainzReleaseGroupID string     `json:"musicbrainz_release_group_id"`
+	MusicBrainzAlbumID        string     `json:"musicbrainz_album_id"`
+	NFOExists                 bool       `json:"nfo_exists"`
+	ThumbExists               bool       `json:"thumb_exists"`
+	DiscArtExists             bool       `json:"discart_exists"`
+	Locked                    bool       `json:"locked"`
+	LockedFields              []string   `json:"locked_fields,omitempty"`
+	LastScannedAt             *time.Time `json:"last_scanned_at,omitempty"`
+	CreatedAt                 time.Time  `json:"created_at"`
+	UpdatedAt                 time.Time  `json:"updated_at"`
+}
+
+// Lockable album field names. These are the JSON names of the Album fields
+// they protect, so a locked_fields entry reads the same as the API payload.
+const (
+	FieldTitle                     = "title"
+	FieldYear                      = "year"
+	FieldReleaseDate               = "release_date"
+	FieldOriginalReleaseDate       = "original_release_date"
+	FieldReleaseType               = "release_type"
+	FieldLabel                     = "label"
+	FieldGenres                    = "genres"
+	FieldStyles                    = "styles"
+	FieldMoods                     = "moods"
+	FieldReview                    = "review"
+	FieldMusicBrainzReleaseGroupID = "musicbrainz_release_group_id"
+	FieldMusicBrainzAlbumID        = "musicbrainz_album_id"
+)
+
+// lockableFields is the display-ordered set of fields a lock can pin.
+var lockableFields = []string{
+	FieldTitle, FieldYear, FieldReleaseDate, FieldOriginalReleaseDate,
+	FieldReleaseType, FieldLabel, FieldGenres, FieldStyles, FieldMoods,
+	FieldReview, FieldMusicBrainzReleaseGroupID, FieldMusicBrainzAlbumID,
+}
+
+// LockableFields returns the album field names that accept a field lock.
+func LockableFields() []string {
+	return slices.Clone(lockableFields)
+}
+
+// IsLockableField reports whether field names a lockable album field.
+func IsLockableField(field string) bool {
+	return slices.Contains(lockableFields, field)
+}
+
+// IsFieldLocked reports whether the album pins field. Matching is
+// case-insensitive, as it is for artist field locks.
+func (a *Album) IsFieldLocked(field string) bool {
+	for _, f := range a.LockedFields {
+		if strings.EqualFold(f, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// copyField copies one lockable field from src to dst. Unknown names are
+// ignored.
+func copyField(dst, src *Album, field string) {
+	switch strings.ToLower(field) {
+	case FieldTitle:
+		dst.Title = src.Title
+	case FieldYear:
+		dst.Year = src.Year
+	case FieldReleaseDate:
+		dst.ReleaseDate = src.ReleaseDate
+	case FieldOriginalReleaseDate:
+		dst.OriginalReleaseDate = src.OriginalReleaseDate
+	case FieldReleaseType:
+		dst.ReleaseType = src.ReleaseType
+	case FieldLabel:
+		dst.Label = src.Label
+	case FieldGenres:
+		dst.Genres = slices.Clone(src.Genres)
+	case FieldStyles:
+		dst.Styles = slices.Clone(src.Styles)
+	case FieldMoods:
+		dst.Moods = slices.Clone(src.Moods)
+	case FieldReview:
+		dst.Review = src.Review
+	case FieldMusicBrainzReleaseGroupID:
+		dst.MusicBrainzReleaseGroupID = src.MusicBrainzReleaseGroupID
+	case FieldMusicBrainzAlbumID:
+		dst.MusicBrainzAlbumID = src.MusicBrainzAlbumID
+	}
+}
+
+// Per-album artwork types.
+const (
+	ImageThumb   = "thumb"   // front cover
+	ImageDiscArt = "discart" // disc / CD art
+)
+
+// imageFilenames lists the filenames probed for each artwork type, in
+// preference order. These are the names Kodi, Emby and Jellyfin all read from
+// an album directory; unlike artist artwork there is no per-platform profile
+// to consult.
+var imageFilenames = map[string][]string{
+	ImageThumb:   {"folder.jpg", "cover.jpg", "front.jpg"},
+	ImageDiscArt: {"discart.png", "cdart.png", "disc.png"},
+}
+
+// ImageFilenames returns the probe list for an artwork type, or nil for an
+// unknown type.
+func ImageFilenames(imageType string) []string {
+	return slices.Clone(imageFilenames[imageType])
+}
+
+// IsValidImageType reports whether imageType is a per-album artwork type.
+func IsValidImageType(imageType string) bool {
+	_, ok := imageFilenames[imageType]
+	return ok
+}
@@ -0,0 +1,131 @@
+package album
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/filesystem"
+	"github.com/sydlexius/stillwater/internal/nfo"
+)
+
+// NFOFilename is the per-album NFO Kodi, Emby and Jellyfin read from an album
+// directory.
+const NFOFilename = "album.nfo"
+
+// ReadNFO reads album.nfo from an album directory. A missing file returns
+// (nil, nil): "no NFO" is an ordinary state for an album, not an error. Any
+// other read or parse failure is returned so the caller can tell an unreadable
+// NFO from an absent one.
+func ReadNFO(dir string) (*nfo.AlbumNFO, error) {
+	f, err := os.Open(filepath.Join(dir, NFOFilename)) //nolint:gosec // G304: dir is an album directory under a configured library root
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", NFOFilename, err)
+	}
+	defer f.Close() //nolint:errcheck // read-only handle
+
+	n, err := nfo.ParseAlbum(f)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s in %s: %w", NFOFilename, dir, err)
+	}
+	return n, nil
+}
+
+// WriteNFO writes the album's album.nfo, atomically. The existing file, when
+// there is one, is read first and used as the base so that its track listing,
+// thumbs and any elements Stillwater does not model survive the rewrite; only
+// the managed fields are replaced. artistName fills <artistdesc>.
+func WriteNFO(a *Album, artistName string, lockData bool) error {
+	if a.Path == "" {
+		return fmt.Errorf("album %s has no path", a.ID)
+	}
+	base, err := ReadNFO(a.Path)
+	if err != nil {
+		// Refusing is the safe direction: writing over an NFO we could not
+		// read would drop whatever it held that we do not model.
+		return fmt.Errorf("reading existing %s before write: %w", NFOFilename, err)
+	}
+	n := ToNFO(a, artistName, base)
+	n.LockData = lockData
+
+	var buf bytes.Buffer
+	if err := nfo.WriteAlbum(&buf, n); err != nil {
+		return fmt.Errorf("serializing %s: %w", NFOFilename, err)
+	}
+	if err := filesystem.WriteFileAtomic(filepath.Join(a.Path, NFOFilename), buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", NFOFilename, err)
+	}
+	return nil
+}
+
+// ToNFO maps an album onto an AlbumNFO. When base is non-nil its unmodeled
+// content (thumbs, extra elements) is carried over; base itself is not
+// modified.
+func ToNFO(a *Album, artistName string, base *nfo.AlbumNFO) *nfo.AlbumNFO {
+	n := &nfo.AlbumNFO{}
+	if base != nil {
+		n.Thumbs = slices.Clone(base.Thumbs)
+		n.ExtraElements = slices.Clone(base.ExtraElements)
+	}
+	n.Title = a.Title
+	n.MusicBrainzAlbumID = a.MusicBrainzAlbumID
+	n.MusicBrainzReleaseGroupID = a.MusicBrainzReleaseGroupID
+	n.ArtistDesc = artistName
+	n.Genres = slices.Clone(a.Genres)
+	n.Styles = slices.Clone(a.Styles)
+	n.Moods = slices.Clone(a.Moods)
+	n.Review = a.Review
+	n.ReleaseType = a.ReleaseType
+	n.ReleaseDate = a.ReleaseDate
+	n.OriginalReleaseDate = a.OriginalReleaseDate
+	n.Label = a.Label
+	n.Year = a.Year
+	n.Stillwater = &nfo.StillwaterMeta{
+		Version: nfo.StillwaterVersion,
+		Written: time.Now().UTC().Format(time.RFC3339),
+	}
+	return n
+}
+
+// applyNFO copies album.nfo values onto an album, skipping fields the album
+// pins. Empty NFO values never blank a stored one: an NFO that omits an element
+// says nothing about it. It reports whether anything changed.
+func applyNFO(a *Album, n *nfo.AlbumNFO) bool {
+	changed := false
+	setString := func(field string, dst *string, v string) {
+		if v == "" || *dst == v || a.IsFieldLocked(field) {
+			return
+		}
+		*dst = v
+		changed = true
+	}
+	setSlice := func(field string, dst *[]string, v []string) {
+		if len(v) == 0 || slices.Equal(*dst, v) || a.IsFieldLocked(field) {
+			return
+		}
+		*dst = slices.Clone(v)
+		changed = true
+	}
+
+	setString(FieldTitle, &a.Title, n.Title)
+	setString(FieldYear, &a.Year, n.Year)
+	setString(FieldReleaseDate, &a.ReleaseDate, n.ReleaseDate)
+	setString(FieldOriginalReleaseDate, &a.OriginalReleaseDate, n.OriginalReleaseDate)
+	setString(FieldReleaseType, &a.ReleaseType, n.ReleaseType)
+	setString(FieldLabel, &a.Label, n.Label)
+	setString(FieldReview, &a.Review, n.Review)
+	setString(FieldMusicBrainzReleaseGroupID, &a.MusicBrainzReleaseGroupID, n.MusicBrainzReleaseGroupID)
+	setString(FieldMusicBrainzAlbumID, &a.MusicBrainzAlbumID, n.MusicBrainzAlbumID)
+	setSlice(FieldGenres, &a.Genres, n.Genres)
+	setSlice(FieldStyles, &a.Styles, n.Styles)
+	setSlice(FieldMoods, &a.Moods, n.Moods)
+	return changed
+}
@@ -0,0 +1,169 @@
+package album
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+	img "github.com/sydlexius/stillwater/internal/image"
+)
+
+// SyncResult summarizes one Sync pass over an artist directory.
+type SyncResult struct {
+	Added     int `json:"added"`
+	Updated   int `json:"updated"`
+	Removed   int `json:"removed"`
+	Unchanged int `json:"unchanged"`
+	// Problems lists per-album issues that did not stop the pass: an
+	// unreadable album.nfo, an artwork probe that could not tell whether a
+	// file exists. The affected album keeps its previously stored state for
+	// whatever could not be read.
+	Problems []string `json:"problems,omitempty"`
+}
+
+// Sync reconciles the albums table with the album directories under the
+// artist's path: new directories become rows, vanished directories are
+// deleted, and every surviving row has its album.nfo re-imported and its
+// artwork re-probed.
+//
+// The directory listing goes through artist.FilesystemAlbumSource rather than
+// ListLocalAlbums because the removal half of this method is destructive.
+// ListLocalAlbums reports an unreadable artist directory (an unmounted share,
+// a permission error) as "no albums", which here would delete every album row
+// the artist has. The album source reports it as EvidenceUnknown with an
+// error, and Sync returns that error having touched nothing.
+//
+// album.nfo import is an automated write: it goes through Update, so a locked
+// album keeps its metadata and pinned fields keep their values. A NEW album
+// whose NFO carries <lockdata>true</lockdata> is created locked, which is how
+// the artist scanner treats an imported artist.nfo lock.
+func (s *Service) Sync(ctx context.Context, a *artist.Artist) (SyncResult, error) {
+	var res SyncResult
+
+	set, err := artist.NewFilesystemAlbumSource().LocalAlbums(ctx, a)
+	if err != nil {
+		return res, fmt.Errorf("listing album directories: %w", err)
+	}
+	if set.Evidence == artist.EvidenceUnknown {
+		return res, fmt.Errorf("album directories for %s could not be determined", a.Path)
+	}
+
+	stored, err := s.ListByArtist(ctx, a.ID)
+	if err != nil {
+		return res, err
+	}
+	byPath := make(map[string]*Album, len(stored))
+	for i := range stored {
+		byPath[stored[i].Path] = &stored[i]
+	}
+
+	seen := make(map[string]bool, len(set.Titles))
+	for _, name := range set.Titles {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		dir := filepath.Join(a.Path, name)
+		seen[dir] = true
+
+		if existing, ok := byPath[dir]; ok {
+			changed, err := s.syncExisting(ctx, existing, &res)
+			if err != nil {
+				return res, err
+			}
+			if changed {
+				res.Updated++
+			} else {
+				res.Unchanged++
+			}
+			continue
+		}
+		if err := s.syncNew(ctx, a.ID, name, dir, &res); err != nil {
+			return res, err
+		}
+		res.Added++
+	}
+
+	for path, al := range byPath {
+		if seen[path] {
+			continue
+		}
+		if err := s.Delete(ctx, al.ID); err != nil && !errors.Is(err, ErrNotFound) {
+			return res, err
+		}
+		res.Removed++
+	}
+	return res, nil
+}
+
+// syncNew creates the row for a newly seen album directory. The directory name
+// is the title until album.nfo says otherwise.
+func (s *Service) syncNew(ctx context.Context, artistID, name, dir string, res *SyncResult) error {
+	al := &Album{ArtistID: artistID, Title: name, Path: dir}
+	n, err := ReadNFO(dir)
+	if err != nil {
+		res.Problems = append(res.Problems, err.Error())
+	}
+	if n != nil {
+		al.NFOExists = true
+		applyNFO(al, n)
+		al.Locked = n.LockData
+	}
+	probeArtwork(ctx, al, res)
+	now := time.Now().UTC()
+	al.LastScannedAt = &now
+	return s.Create(ctx, al)
+}
+
+// syncExisting re-imports album.nfo and re-probes artwork for a known album.
+// It reports whether any metadata changed.
+func (s *Service) syncExisting(ctx context.Context, stored *Album, res *SyncResult) (bool, error) {
+	al := *stored
+	n, err := ReadNFO(al.Path)
+	switch {
+	case err != nil:
+		// Unreadable is not absent: keep the stored NFOExists.
+		res.Problems = append(res.Problems, err.Error())
+	case n == nil:
+		al.NFOExists = false
+	default:
+		al.NFOExists = true
+	}
+	probeArtwork(ctx, &al, res)
+	now := time.Now().UTC()
+	al.LastScannedAt = &now
+
+	changed := n != nil && !al.Locked && applyNFO(&al, n)
+	if !changed {
+		return false, s.setScanState(ctx, &al)
+	}
+	if err := s.Update(ctx, &al); err != nil {
+		if errors.Is(err, ErrLocked) {
+			return false, s.setScanState(ctx, &al)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// probeArtwork sets the artwork flags from the album directory. A probe that
+// cannot tell (a stat error other than not-exist) leaves the flag as it was;
+// clearing it would report artwork as missing on the strength of a read that
+// never happened.
+func probeArtwork(ctx context.Context, al *Album, res *SyncResult) {
+	for _, t := range []string{ImageThumb, ImageDiscArt} {
+		_, found, err := img.FindExistingImageStrict(ctx, al.Path, ImageFilenames(t))
+		if err != nil {
+			res.Problems = append(res.Problems, fmt.Sprintf("probing %s artwork in %s: %v", t, al.Path, err))
+			continue
+		}
+		switch t {
+		case ImageThumb:
+			al.ThumbExists = found
+		case ImageDiscArt:
+			al.DiscArtExists = found
+		}
+	}
+}
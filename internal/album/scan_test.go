@@ -0,0 +1,189 @@
+package album
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const nevermindNFO = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<album>
+  <title>Nevermind</title>
+  <musicbrainzreleasegroupid>1b022e01-4da6-387b-8658-8678046e4cef</musicbrainzreleasegroupid>
+  <genre>Grunge</genre>
+  <year>1991</year>
+  <label>DGC</label>
+</album>
+`
+
+func mkAlbumDir(t *testing.T, root, name string, files map[string]string) string {
+	t.Helper()
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for f, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, f), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestSync_ImportsAlbums(t *testing.T) {
+	s := NewService(newTestDB(t))
+	ctx := context.Background()
+	root := t.TempDir()
+	ar := seedArtist(t, s, root)
+
+	nevermind := mkAlbumDir(t, root, "Nevermind (1991)", map[string]string{
+		NFOFilename:  nevermindNFO,
+		"folder.jpg": "jpeg",
+	})
+	mkAlbumDir(t, root, "Bleach", map[string]string{"cdart.png": "png"})
+
+	res, err := s.Sync(ctx, ar)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if res.Added != 2 || res.Removed != 0 {
+		t.Fatalf("Sync result = %+v, want 2 added", res)
+	}
+
+	got, err := s.GetByPath(ctx, nevermind)
+	if err != nil {
+		t.Fatalf("GetByPath: %v", err)
+	}
+	if got.Title != "Nevermind" || got.Year != "1991" || got.Label != "DGC" {
+		t.Errorf("NFO not imported: %+v", got)
+	}
+	if got.MusicBrainzReleaseGroupID != "1b022e01-4da6-387b-8658-8678046e4cef" {
+		t.Errorf("release group = %q", got.MusicBrainzReleaseGroupID)
+	}
+	if !got.NFOExists || !got.ThumbExists || got.DiscArtExists {
+		t.Errorf("flags nfo=%v thumb=%v discart=%v, want true/true/false",
+			got.NFOExists, got.ThumbExists, got.DiscArtExists)
+	}
+
+	bleach, err := s.GetByPath(ctx, filepath.Join(root, "Bleach"))
+	if err != nil {
+		t.Fatalf("GetByPath(Bleach): %v", err)
+	}
+	if bleach.Title != "Bleach" || bleach.NFOExists || !bleach.DiscArtExists {
+		t.Errorf("Bleach = %+v, want directory-name title and disc art only", bleach)
+	}
+
+	// A second pass with nothing changed on disk writes no metadata.
+	res, err = s.Sync(ctx, ar)
+	if err != nil {
+		t.Fatalf("second Sync: %v", err)
+	}
+	if res.Added != 0 || res.Updated != 0 || res.Unchanged != 2 {
+		t.Errorf("second Sync result = %+v, want 2 unchanged", res)
+	}
+}
+
+func TestSync_RemovesVanishedAlbums(t *testing.T) {
+	s := NewService(newTestDB(t))
+	ctx := context.Background()
+	root := t.TempDir()
+	ar := seedArtist(t, s, root)
+	gone := mkAlbumDir(t, root, "In Utero", nil)
+	mkAlbumDir(t, root, "Bleach", nil)
+
+	if _, err := s.Sync(ctx, ar); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if err := os.RemoveAll(gone); err != nil {
+		t.Fatal(err)
+	}
+	res, err := s.Sync(ctx, ar)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if res.Removed != 1 {
+		t.Errorf("Removed = %d, want 1", res.Removed)
+	}
+	list, _ := s.ListByArtist(ctx, ar.ID)
+	if len(list) != 1 || list[0].Title != "Bleach" {
+		t.Errorf("albums after removal = %+v", list)
+	}
+}
+
+// TestSync_UnreadableArtistDirKeepsAlbums guards the destructive half of
+// Sync: an artist directory that cannot be listed must not read as "no albums".
+func TestSync_UnreadableArtistDirKeepsAlbums(t *testing.T) {
+	s := NewService(newTestDB(t))
+	ctx := context.Background()
+	root := t.TempDir()
+	ar := seedArtist(t, s, root)
+	mkAlbumDir(t, root, "Bleach", nil)
+	if _, err := s.Sync(ctx, ar); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	ar.Path = filepath.Join(root, "unmounted")
+	if _, err := s.Sync(ctx, ar); err == nil {
+		t.Fatal("Sync over a missing artist directory: want error")
+	}
+	if list, _ := s.ListByArtist(ctx, ar.ID); len(list) != 1 {
+		t.Errorf("albums = %d, want the stored album kept", len(list))
+	}
+}
+
+func TestSync_LockedAlbumKeepsMetadata(t *testing.T) {
+	s := NewService(newTestDB(t))
+	ctx := context.Background()
+	root := t.TempDir()
+	ar := seedArtist(t, s, root)
+	dir := mkAlbumDir(t, root, "Nevermind", nil)
+
+	if _, err := s.Sync(ctx, ar); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	al, _ := s.GetByPath(ctx, dir)
+	if err := s.SetLock(ctx, al.ID, true); err != nil {
+		t.Fatalf("SetLock: %v", err)
+	}
+
+	mkAlbumDir(t, root, "Nevermind", map[string]string{NFOFilename: nevermindNFO, "cover.jpg": "jpeg"})
+	res, err := s.Sync(ctx, ar)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if res.Updated != 0 {
+		t.Errorf("Updated = %d, want 0 for a locked album", res.Updated)
+	}
+	got, _ := s.GetByID(ctx, al.ID)
+	if got.Year != "" || got.Label != "" {
+		t.Errorf("locked album metadata overwritten: %+v", got)
+	}
+	// Scan state still reflects the disk: locks protect metadata, not facts.
+	if !got.NFOExists || !got.ThumbExists {
+		t.Errorf("scan flags nfo=%v thumb=%v, want both true", got.NFOExists, got.ThumbExists)
+	}
+}
+
+func TestWriteNFO_PreservesUnmodeledContent(t *testing.T) {
+	dir := t.TempDir()
+	existing := `<album><title>Old</title><track><position>1</position><title>Smells Like Teen Spirit</title></track></album>`
+	if err := os.WriteFile(filepath.Join(dir, NFOFilename), []byte(existing), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	al := &Album{ID: "a1", Title: "Nevermind", Path: dir, Year: "1991", Genres: []string{"Grunge"}}
+	if err := WriteNFO(al, "Nirvana", false); err != nil {
+		t.Fatalf("WriteNFO: %v", err)
+	}
+	n, err := ReadNFO(dir)
+	if err != nil || n == nil {
+		t.Fatalf("ReadNFO: %v, %v", n, err)
+	}
+	if n.Title != "Nevermind" || n.ArtistDesc != "Nirvana" || n.Year != "1991" {
+		t.Errorf("managed fields = %+v", n)
+	}
+	if len(n.ExtraElements) != 1 {
+		t.Errorf("ExtraElements = %d, want the <track> element kept", len(n.ExtraElements))
+	}
+}
@@ -0,0 +1,311 @@
+package album
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/dbutil"
+)
+
+const albumColumns = `id, artist_id, title, path, year, release_date, original_release_date,
+	release_type, label, genres, styles, moods, review,
+	musicbrainz_release_group_id, musicbrainz_album_id,
+	nfo_exists, thumb_exists, discart_exists, locked, locked_fields,
+	last_scanned_at, created_at, updated_at`
+
+// Service provides album data operations.
+type Service struct {
+	db *sql.DB
+}
+
+// NewService creates an album service.
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// Create inserts a new album. ArtistID, Title and Path are required.
+func (s *Service) Create(ctx context.Context, a *Album) error {
+	if a.ArtistID == "" {
+		return fmt.Errorf("album artist_id is required")
+	}
+	if strings.TrimSpace(a.Title) == "" {
+		return fmt.Errorf("album title is required")
+	}
+	if a.Path == "" {
+		return fmt.Errorf("album path is required")
+	}
+	if a.ID == "" {
+		a.ID = uuid.New().String()
+	}
+	now := time.Now().UTC()
+	a.CreatedAt = now
+	a.UpdatedAt = now
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO albums (`+albumColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		a.ID, a.ArtistID, a.Title, a.Path, a.Year, a.ReleaseDate, a.OriginalReleaseDate,
+		a.ReleaseType, a.Label,
+		artist.MarshalStringSlice(a.Genres), artist.MarshalStringSlice(a.Styles), artist.MarshalStringSlice(a.Moods),
+		a.Review, a.MusicBrainzReleaseGroupID, a.MusicBrainzAlbumID,
+		dbutil.BoolToInt(a.NFOExists), dbutil.BoolToInt(a.ThumbExists), dbutil.BoolToInt(a.DiscArtExists),
+		dbutil.BoolToInt(a.Locked), artist.MarshalStringSlice(a.LockedFields),
+		dbutil.FormatNullableTime(a.LastScannedAt),
+		now.Format(time.RFC3339), now.Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("creating album: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an album by primary key. Returns ErrNotFound when absent.
+func (s *Service) GetByID(ctx context.Context, id string) (*Album, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+albumColumns+` FROM albums WHERE id = ?`, id)
+	a, err := scanAlbum(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting album by id: %w", err)
+	}
+	return a, nil
+}
+
+// GetByPath retrieves an album by directory path. Returns ErrNotFound when
+// absent.
+func (s *Service) GetByPath(ctx context.Context, path string) (*Album, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+albumColumns+` FROM albums WHERE path = ?`, path)
+	a, err := scanAlbum(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting album by path: %w", err)
+	}
+	return a, nil
+}
+
+// ListByArtist returns an artist's albums ordered by year, then title.
+func (s *Service) ListByArtist(ctx context.Context, artistID string) ([]Album, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+albumColumns+` FROM albums WHERE artist_id = ? ORDER BY year, title COLLATE NOCASE`,
+		artistID)
+	if err != nil {
+		return nil, fmt.Errorf("listing albums: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck // Close error not actionable on cleanup
+
+	var albums []Album
+	for rows.Next() {
+		a, err := scanAlbum(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning album: %w", err)
+		}
+		albums = append(albums, *a)
+	}
+	return albums, rows.Err()
+}
+
+// Update persists an AUTOMATED write (scan import, release-group matching, a
+// future provider refresh). It is the album counterpart of the artist lock
+// chokepoint (internal/artist/lockguard.go) and follows the same two rules:
+//
+//   - The lock set comes from the STORED row, not from the incoming struct. A
+//     caller that forgot to populate LockedFields must not thereby switch off
+//     the operator's protection.
+//   - A whole-album lock refuses the write with ErrLocked; per-field locks
+//     restore the stored value of each pinned field and let the rest through.
+//
+// The lock columns themselves are pinned to their stored values. Locks are
+// changed only through SetLock and the field-lock methods.
+func (s *Service) Update(ctx context.Context, a *Album) error {
+	stored, err := s.GetByID(ctx, a.ID)
+	if err != nil {
+		return err
+	}
+	if stored.Locked {
+		return ErrLocked
+	}
+	for _, f := range stored.LockedFields {
+		copyField(a, stored, f)
+	}
+	a.Locked = stored.Locked
+	a.LockedFields = stored.LockedFields
+	return s.write(ctx, a)
+}
+
+// Edit persists an OPERATOR write. Locks gate automated writers, not the
+// operator who set them, so field values are written as given; the lock
+// columns are still pinned to the stored row so an edit payload cannot clear
+// a lock as a side effect.
+func (s *Service) Edit(ctx context.Context, a *Album) error {
+	stored, err := s.GetByID(ctx, a.ID)
+	if err != nil {
+		return err
+	}
+	a.Locked = stored.Locked
+	a.LockedFields = stored.LockedFields
+	return s.write(ctx, a)
+}
+
+// write is the whole-row UPDATE shared by Update and Edit.
+func (s *Service) write(ctx context.Context, a *Album) error {
+	a.UpdatedAt = time.Now().UTC()
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE albums SET
+			title = ?, year = ?, release_date = ?, original_release_date = ?,
+			release_type = ?, label = ?, genres = ?, styles = ?, moods = ?, review = ?,
+			musicbrainz_release_group_id = ?, musicbrainz_album_id = ?,
+			nfo_exists = ?, thumb_exists = ?, discart_exists = ?,
+			locked = ?, locked_fields = ?, last_scanned_at = ?, updated_at = ?
+		WHERE id = ?
+	`,
+		a.Title, a.Year, a.ReleaseDate, a.OriginalReleaseDate,
+		a.ReleaseType, a.Label,
+		artist.MarshalStringSlice(a.Genres), artist.MarshalStringSlice(a.Styles), artist.MarshalStringSlice(a.Moods),
+		a.Review, a.MusicBrainzReleaseGroupID, a.MusicBrainzAlbumID,
+		dbutil.BoolToInt(a.NFOExists), dbutil.BoolToInt(a.ThumbExists), dbutil.BoolToInt(a.DiscArtExists),
+		dbutil.BoolToInt(a.Locked), artist.MarshalStringSlice(a.LockedFields),
+		dbutil.FormatNullableTime(a.LastScannedAt), a.UpdatedAt.Format(time.RFC3339),
+		a.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("updating album: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// setScanState records what the last scan saw on disk: NFO presence, artwork
+// presence and the scan time. These are observations, not metadata, so they
+// are written even for a locked album.
+func (s *Service) setScanState(ctx context.Context, a *Album) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE albums SET nfo_exists = ?, thumb_exists = ?, discart_exists = ?, last_scanned_at = ?
+		WHERE id = ?
+	`,
+		dbutil.BoolToInt(a.NFOExists), dbutil.BoolToInt(a.ThumbExists), dbutil.BoolToInt(a.DiscArtExists),
+		dbutil.FormatNullableTime(a.LastScannedAt), a.ID)
+	if err != nil {
+		return fmt.Errorf("recording album scan state: %w", err)
+	}
+	return nil
+}
+
+// Delete removes an album row. The album directory is not touched.
+func (s *Service) Delete(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM albums WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("deleting album: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetLock sets or clears the whole-album lock.
+func (s *Service) SetLock(ctx context.Context, id string, locked bool) error {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE albums SET locked = ?, updated_at = ? WHERE id = ?`,
+		dbutil.BoolToInt(locked), time.Now().UTC().Format(time.RFC3339), id)
+	if err != nil {
+		return fmt.Errorf("setting album lock: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AddLockedField pins one field. Adding a field that is already locked is a
+// no-op; a name outside LockableFields returns ErrUnknownField.
+func (s *Service) AddLockedField(ctx context.Context, id, field string) error {
+	field = strings.ToLower(strings.TrimSpace(field))
+	if !IsLockableField(field) {
+		return fmt.Errorf("%w: %q", ErrUnknownField, field)
+	}
+	a, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if a.IsFieldLocked(field) {
+		return nil
+	}
+	return s.setLockedFields(ctx, id, append(a.LockedFields, field))
+}
+
+// RemoveLockedField unpins one field. Missing entries are silently ignored.
+func (s *Service) RemoveLockedField(ctx context.Context, id, field string) error {
+	a, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	target := strings.TrimSpace(field)
+	kept := make([]string, 0, len(a.LockedFields))
+	for _, f := range a.LockedFields {
+		if strings.EqualFold(f, target) {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return s.setLockedFields(ctx, id, kept)
+}
+
+func (s *Service) setLockedFields(ctx context.Context, id string, fields []string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE albums SET locked_fields = ?, updated_at = ? WHERE id = ?`,
+		artist.MarshalStringSlice(fields), time.Now().UTC().Format(time.RFC3339), id)
+	if err != nil {
+		return fmt.Errorf("setting album locked fields: %w", err)
+	}
+	return nil
+}
+
+// scanAlbum reads one albums row in albumColumns order.
+func scanAlbum(row interface{ Scan(...any) error }) (*Album, error) {
+	var (
+		a                                     Album
+		genres, styles, moods, lockedFields   string
+		nfoExists, thumbExists, discArtExists int
+		locked                                int
+		lastScannedAt                         sql.NullString
+		createdAt, updatedAt                  string
+	)
+	err := row.Scan(
+		&a.ID, &a.ArtistID, &a.Title, &a.Path, &a.Year, &a.ReleaseDate, &a.OriginalReleaseDate,
+		&a.ReleaseType, &a.Label, &genres, &styles, &moods, &a.Review,
+		&a.MusicBrainzReleaseGroupID, &a.MusicBrainzAlbumID,
+		&nfoExists, &thumbExists, &discArtExists, &locked, &lockedFields,
+		&lastScannedAt, &createdAt, &updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	a.Genres = artist.UnmarshalStringSlice(genres)
+	a.Styles = artist.UnmarshalStringSlice(styles)
+	a.Moods = artist.UnmarshalStringSlice(moods)
+	a.LockedFields = artist.UnmarshalStringSlice(lockedFields)
+	a.NFOExists = dbutil.IntToBool(nfoExists)
+	a.ThumbExists = dbutil.IntToBool(thumbExists)
+	a.DiscArtExists = dbutil.IntToBool(discArtExists)
+	a.Locked = dbutil.IntToBool(locked)
+	if lastScannedAt.Valid {
+		if t, ok := dbutil.ParseTimeOK(lastScannedAt.String); ok {
+			a.LastScannedAt = &t
+		}
+	}
+	a.CreatedAt = dbutil.ParseTime(createdAt)
+	a.UpdatedAt = dbutil.ParseTime(updatedAt)
+	return &a, nil
+}
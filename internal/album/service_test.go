@@ -0,0 +1,161 @@
+package album
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+)
+
+// seedArtist creates an artist row the albums can hang off.
+func seedArtist(t *testing.T, s *Service, path string) *artist.Artist {
+	t.Helper()
+	a := &artist.Artist{Name: "Nirvana", Path: path}
+	if err := artist.NewService(s.db).Create(context.Background(), a); err != nil {
+		t.Fatalf("creating artist: %v", err)
+	}
+	return a
+}
+
+func TestService_CreateGetList(t *testing.T) {
+	s := NewService(newTestDB(t))
+	ctx := context.Background()
+	ar := seedArtist(t, s, "/music/Nirvana")
+
+	for _, al := range []*Album{
+		{ArtistID: ar.ID, Title: "Nevermind", Path: "/music/Nirvana/Nevermind", Year: "1991", Genres: []string{"Grunge"}},
+		{ArtistID: ar.ID, Title: "Bleach", Path: "/music/Nirvana/Bleach", Year: "1989"},
+	} {
+		if err := s.Create(ctx, al); err != nil {
+			t.Fatalf("Create %s: %v", al.Title, err)
+		}
+	}
+
+	list, err := s.ListByArtist(ctx, ar.ID)
+	if err != nil {
+		t.Fatalf("ListByArtist: %v", err)
+	}
+	if len(list) != 2 || list[0].Title != "Bleach" {
+		t.Fatalf("ListByArtist = %+v, want Bleach first (year order)", list)
+	}
+
+	got, err := s.GetByPath(ctx, "/music/Nirvana/Nevermind")
+	if err != nil {
+		t.Fatalf("GetByPath: %v", err)
+	}
+	if !slices.Equal(got.Genres, []string{"Grunge"}) {
+		t.Errorf("Genres = %v", got.Genres)
+	}
+
+	if _, err := s.GetByID(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetByID(missing) err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestService_CreateValidates(t *testing.T) {
+	s := NewService(newTestDB(t))
+	if err := s.Create(context.Background(), &Album{Title: "x", Path: "/x"}); err == nil {
+		t.Error("Create without artist_id: want error")
+	}
+}
+
+func TestService_AlbumsCascadeWithArtist(t *testing.T) {
+	s := NewService(newTestDB(t))
+	ctx := context.Background()
+	ar := seedArtist(t, s, "/music/Nirvana")
+	al := &Album{ArtistID: ar.ID, Title: "Nevermind", Path: "/music/Nirvana/Nevermind"}
+	if err := s.Create(ctx, al); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := artist.NewService(s.db).Delete(ctx, ar.ID); err != nil {
+		t.Fatalf("deleting artist: %v", err)
+	}
+	if _, err := s.GetByID(ctx, al.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("album survived artist delete: err = %v", err)
+	}
+}
+
+func TestService_UpdateHonorsLocks(t *testing.T) {
+	s := NewService(newTestDB(t))
+	ctx := context.Background()
+	ar := seedArtist(t, s, "/music/Nirvana")
+	al := &Album{ArtistID: ar.ID, Title: "Nevermind", Path: "/music/Nirvana/Nevermind", Label: "DGC", Year: "1991"}
+	if err := s.Create(ctx, al); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := s.AddLockedField(ctx, al.ID, "Label"); err != nil {
+		t.Fatalf("AddLockedField: %v", err)
+	}
+
+	// The incoming struct claims no locks; the stored row's locks still win.
+	upd := *al
+	upd.LockedFields = nil
+	upd.Label = "Geffen"
+	upd.Year = "1992"
+	if err := s.Update(ctx, &upd); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, _ := s.GetByID(ctx, al.ID)
+	if got.Label != "DGC" {
+		t.Errorf("locked Label = %q, want DGC", got.Label)
+	}
+	if got.Year != "1992" {
+		t.Errorf("unlocked Year = %q, want 1992", got.Year)
+	}
+	if !slices.Equal(got.LockedFields, []string{FieldLabel}) {
+		t.Errorf("LockedFields = %v, want [label]", got.LockedFields)
+	}
+
+	// The operator edit path writes through the field lock.
+	edit := *got
+	edit.Label = "Geffen"
+	if err := s.Edit(ctx, &edit); err != nil {
+		t.Fatalf("Edit: %v", err)
+	}
+	if got, _ := s.GetByID(ctx, al.ID); got.Label != "Geffen" || !got.IsFieldLocked(FieldLabel) {
+		t.Errorf("after Edit: Label = %q, locked = %v", got.Label, got.IsFieldLocked(FieldLabel))
+	}
+
+	// A whole-album lock refuses automated writes outright.
+	if err := s.SetLock(ctx, al.ID, true); err != nil {
+		t.Fatalf("SetLock: %v", err)
+	}
+	if err := s.Update(ctx, &upd); !errors.Is(err, ErrLocked) {
+		t.Errorf("Update on locked album err = %v, want ErrLocked", err)
+	}
+}
+
+func TestService_FieldLocks(t *testing.T) {
+	s := NewService(newTestDB(t))
+	ctx := context.Background()
+	ar := seedArtist(t, s, "/music/Nirvana")
+	al := &Album{ArtistID: ar.ID, Title: "Nevermind", Path: "/music/Nirvana/Nevermind"}
+	if err := s.Create(ctx, al); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if err := s.AddLockedField(ctx, al.ID, "biography"); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("AddLockedField(biography) err = %v, want ErrUnknownField", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := s.AddLockedField(ctx, al.ID, FieldGenres); err != nil {
+			t.Fatalf("AddLockedField: %v", err)
+		}
+	}
+	got, _ := s.GetByID(ctx, al.ID)
+	if !slices.Equal(got.LockedFields, []string{FieldGenres}) {
+		t.Errorf("LockedFields = %v, want one genres entry", got.LockedFields)
+	}
+	if err := s.RemoveLockedField(ctx, al.ID, "GENRES"); err != nil {
+		t.Fatalf("RemoveLockedField: %v", err)
+	}
+	got, _ = s.GetByID(ctx, al.ID)
+	if len(got.LockedFields) != 0 {
+		t.Errorf("LockedFields = %v, want none", got.LockedFields)
+	}
+	if err := s.AddLockedField(ctx, "missing", FieldGenres); !errors.Is(err, ErrNotFound) {
+		t.Errorf("AddLockedField(missing) err = %v, want ErrNotFound", err)
+	}
+}
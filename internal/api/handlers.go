@@ -9,6 +9,7 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -277,6 +278,9 @@ func (r *Router) handleLogin(w http.ResponseWriter, req *http.Request) {
 	// If the registry is configured and the provider is registered, use the new path.
 	if r.authRegistry != nil {
 		if provider, ok := r.authRegistry.Get(providerType); ok {
+			if r.loginLocked(w, req, body.Username) {
+				return
+			}
 			creds := auth.Credentials{
 				Username: body.Username,
 				Password: body.Password,
@@ -284,6 +288,9 @@ func (r *Router) handleLogin(w http.ResponseWriter, req *http.Request) {
 			identity, err := provider.Authenticate(req.Context(), creds)
 			if err != nil {
 				r.logger.Warn("authentication failed", "provider", providerType, "error", err)
+				if errors.Is(err, auth.ErrInvalidCredentials) {
+					r.recordLoginFailure(req, body.Username)
+				}
 				if providerType == "local" {
 					writeFormError(w, req, http.StatusUnauthorized, "Invalid username or password.")
 				} else if errors.Is(err, auth.ErrInvalidCredentials) {
@@ -295,7 +302,7 @@ func (r *Router) handleLogin(w http.ResponseWriter, req *http.Request) {
 				}
 				return
 			}
-			r.completeLogin(w, req, provider, identity)
+			r.completeLogin(w, req, provider, identity, body.Username)
 			return
 		}
 	}
@@ -310,9 +317,62 @@ func (r *Router) handleLogin(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// loginLocked answers 429 with Retry-After and returns true while the
+// username or the client address is locked out after too many failed
+// passwords. The password is not checked, so a locked-out guess learns
+// nothing.
+func (r *Router) loginLocked(w http.ResponseWriter, req *http.Request, username string) bool {
+	err := r.authService.CheckLogin(req.Context(), username)
+	if err == nil {
+		return false
+	}
+	var locked *auth.LockedError
+	if errors.As(err, &locked) {
+		r.writeLoginLocked(w, req, username, locked)
+		return true
+	}
+	// Failing open keeps sign-in working when the counters cannot be read;
+	// the password is still checked.
+	r.logger.Error("failed to check login lockout", "error", err)
+	return false
+}
+
+// writeLoginLocked answers a sign-in refused by a lockout.
+func (r *Router) writeLoginLocked(w http.ResponseWriter, req *http.Request, username string, locked *auth.LockedError) {
+	retry := locked.RetryAfter()
+	r.logger.Warn("login refused: locked out", "username", username, "scope", locked.Scope,
+		"ip", auth.ClientInfoFromContext(req.Context()).IP, "retry_after", retry)
+	w.Header().Set("Retry-After", strconv.Itoa(int(retry/time.Second)))
+	minutes := int((retry + time.Minute - 1) / time.Minute)
+	msg := "Too many failed sign-in attempts. Try again in 1 minute."
+	if minutes > 1 {
+		msg = fmt.Sprintf("Too many failed sign-in attempts. Try again in %d minutes.", minutes)
+	}
+	writeFormError(w, req, http.StatusTooManyRequests, msg)
+}
+
+// recordLoginFailure counts a rejected password towards the lockout of the
+// username and the client address.
+func (r *Router) recordLoginFailure(req *http.Request, username string) {
+	if err := r.authService.RecordLoginFailure(req.Context(), username); err != nil {
+		r.logger.Error("failed to record login failure", "error", err)
+	}
+}
+
+// recordLoginSuccess clears the username's failure count after a successful
+// sign-in.
+func (r *Router) recordLoginSuccess(req *http.Request, username string) {
+	if err := r.authService.RecordLoginSuccess(req.Context(), username); err != nil {
+		r.logger.Error("failed to clear login failures", "error", err)
+	}
+}
+
 // completeLogin is the post-authentication flow shared by all providers.
-// It looks up or auto-provisions the user, then creates a session.
-func (r *Router) completeLogin(w http.ResponseWriter, req *http.Request, provider auth.Authenticator, identity *auth.Identity) {
+// It looks up or auto-provisions the user, then creates a session. username
+// is the name the sign-in is counted under for the lockout; its failure
+// count is cleared once a session is created, not while a second factor is
+// still outstanding.
+func (r *Router) completeLogin(w http.ResponseWriter, req *http.Request, provider auth.Authenticator, identity *auth.Identity, username string) {
 	ctx := req.Context()
 
 	var user *auth.User
@@ -379,6 +439,13 @@ func (r *Router) completeLogin(w http.ResponseWriter, req *http.Request, provide
 		}
 	}
 
+	// A local account with two-factor authentication (or one the policy
+	// requires to enroll) answers with a challenge instead of a session.
+	if identity.ProviderType == "local" && !r.beginSecondFactor(w, req, user.ID) {
+		return
+	}
+	r.recordLoginSuccess(req, username)
+
 	token, err := r.authService.CreateSession(ctx, user.ID)
 	if err != nil {
 		r.logger.Error("failed to create session", "user_id", user.ID, "error", err)
@@ -503,6 +570,16 @@ func (r *Router) redirectWithError(w http.ResponseWriter, req *http.Request, msg
 // Used as a legacy fallback when the auth registry is not configured.
 func (r *Router) handleLoginLocal(w http.ResponseWriter, req *http.Request, username, password string) {
 	token, err := r.authService.Login(req.Context(), username, password)
+	var sf *auth.SecondFactorRequiredError
+	if errors.As(err, &sf) {
+		r.writeSecondFactorChallenge(w, req, sf)
+		return
+	}
+	var locked *auth.LockedError
+	if errors.As(err, &locked) {
+		r.writeLoginLocked(w, req, username, locked)
+		return
+	}
 	if err != nil {
 		r.logger.Warn("local login failed", "username", username)
 		writeFormError(w, req, http.StatusUnauthorized, "Invalid username or password.")
@@ -525,16 +602,21 @@ func (r *Router) handleLoginFederated(w http.ResponseWriter, req *http.Request,
 		return
 	}
 
+	if r.loginLocked(w, req, username) {
+		return
+	}
 	result, err := r.authenticateByName(req.Context(), authMethod, serverURL, username, password)
 	if err != nil {
 		r.logger.Warn("federated login failed", "method", authMethod, "error", err)
 		if errors.Is(err, connEmby.ErrInvalidCredentials) || errors.Is(err, connJellyfin.ErrInvalidCredentials) {
+			r.recordLoginFailure(req, username)
 			writeFormError(w, req, http.StatusUnauthorized, fmt.Sprintf("Invalid %s credentials.", authMethodDisplayName(authMethod)))
 			return
 		}
 		writeFormError(w, req, http.StatusBadGateway, fmt.Sprintf("Cannot connect to %s server. Please verify the server is running.", authMethodDisplayName(authMethod)))
 		return
 	}
+	r.recordLoginSuccess(req, username)
 
 	fedResult := auth.FederatedAuthResult{
 		AccessToken: result.AccessToken,
@@ -616,6 +698,11 @@ func (r *Router) renderLoginPage(w http.ResponseWriter, req *http.Request) {
 	if returnTo == r.basePath+"/" {
 		returnTo = ""
 	}
+	// Behind an SSO proxy with forward auth on, the visitor has already
+	// signed in there; skip the form.
+	if r.redirectToForwardLogin(w, req, returnTo) {
+		return
+	}
 	renderTempl(w, req, templates.LoginPage(r.assets(), providers, oidcInfo, returnTo))
 }
 
@@ -655,6 +742,13 @@ func (r *Router) enabledAuthProviders(ctx context.Context) []auth.Authenticator
 		r.getStringSetting(ctx, "auth.providers.oidc.client_id", "") != "" {
 		providers = append(providers, syntheticProvider{providerType: "oidc"})
 	}
+	// LDAP is configured from the environment, so the registry is the
+	// source of truth rather than a settings toggle.
+	if r.authRegistry != nil {
+		if _, ok := r.authRegistry.Get("ldap"); ok {
+			providers = append(providers, syntheticProvider{providerType: "ldap"})
+		}
+	}
 
 	return providers
 }
@@ -727,6 +821,10 @@ func authMethodDisplayName(method string) string {
 		return "Emby"
 	case "jellyfin":
 		return "Jellyfin"
+	case "forward":
+		return "reverse proxy"
+	case "ldap":
+		return "LDAP"
 	default:
 		return method
 	}
@@ -0,0 +1,356 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/sydlexius/stillwater/internal/album"
+	"github.com/sydlexius/stillwater/internal/artist"
+	img "github.com/sydlexius/stillwater/internal/image"
+)
+
+// albumUpdateRequest is the PATCH body for an album. Every field is a pointer
+// so an omitted key leaves the stored value alone rather than blanking it.
+type albumUpdateRequest struct {
+	Title                     *string   `json:"title"`
+	Year                      *string   `json:"year"`
+	ReleaseDate               *string   `json:"release_date"`
+	OriginalReleaseDate       *string   `json:"original_release_date"`
+	ReleaseType               *string   `json:"release_type"`
+	Label                     *string   `json:"label"`
+	Genres                    *[]string `json:"genres"`
+	Styles                    *[]string `json:"styles"`
+	Moods                     *[]string `json:"moods"`
+	Review                    *string   `json:"review"`
+	MusicBrainzReleaseGroupID *string   `json:"musicbrainz_release_group_id"`
+	MusicBrainzAlbumID        *string   `json:"musicbrainz_album_id"`
+	Locked                    *bool     `json:"locked"`
+}
+
+// apply copies the supplied fields onto a.
+func (b *albumUpdateRequest) apply(a *album.Album) {
+	setString := func(dst *string, v *string) {
+		if v != nil {
+			*dst = strings.TrimSpace(*v)
+		}
+	}
+	setSlice := func(dst *[]string, v *[]string) {
+		if v != nil {
+			*dst = *v
+		}
+	}
+	setString(&a.Title, b.Title)
+	setString(&a.Year, b.Year)
+	setString(&a.ReleaseDate, b.ReleaseDate)
+	setString(&a.OriginalReleaseDate, b.OriginalReleaseDate)
+	setString(&a.ReleaseType, b.ReleaseType)
+	setString(&a.Label, b.Label)
+	setString(&a.Review, b.Review)
+	setString(&a.MusicBrainzReleaseGroupID, b.MusicBrainzReleaseGroupID)
+	setString(&a.MusicBrainzAlbumID, b.MusicBrainzAlbumID)
+	setSlice(&a.Genres, b.Genres)
+	setSlice(&a.Styles, b.Styles)
+	setSlice(&a.Moods, b.Moods)
+}
+
+// requireAlbumService writes a 503 and returns false when the router was built
+// without an album service (tests, or a partially wired router).
+func (r *Router) requireAlbumService(w http.ResponseWriter, req *http.Request) bool {
+	if r.albumService == nil {
+		writeError(w, req, http.StatusServiceUnavailable, "album service is not configured")
+		return false
+	}
+	return true
+}
+
+// loadAlbum resolves the {id} path value to an album, writing the 404/500
+// response itself when it cannot.
+func (r *Router) loadAlbum(w http.ResponseWriter, req *http.Request) (*album.Album, bool) {
+	id, ok := RequirePathParam(w, req, "id")
+	if !ok {
+		return nil, false
+	}
+	al, err := r.albumService.GetByID(req.Context(), id)
+	if err != nil {
+		if errors.Is(err, album.ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "album not found"})
+			return nil, false
+		}
+		r.logger.Error("loading album", "id", id, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return nil, false
+	}
+	return al, true
+}
+
+// handleListArtistAlbums lists the albums recorded for an artist.
+// GET /api/v1/artists/{id}/albums
+func (r *Router) handleListArtistAlbums(w http.ResponseWriter, req *http.Request) {
+	if !r.requireAlbumService(w, req) {
+		return
+	}
+	artistID, ok := RequirePathParam(w, req, "id")
+	if !ok {
+		return
+	}
+	if _, err := r.artistService.GetByID(req.Context(), artistID); err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "artist not found"})
+		return
+	}
+	albums, err := r.albumService.ListByArtist(req.Context(), artistID)
+	if err != nil {
+		r.logger.Error("listing albums", "artist_id", artistID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if albums == nil {
+		albums = []album.Album{}
+	}
+	writeJSON(w, http.StatusOK, albums)
+}
+
+// handleScanArtistAlbums reconciles the albums table with the artist's album
+// directories (album.nfo import, artwork probe) and then, when the artist has a
+// MusicBrainz ID and the MusicBrainz provider is available, matches the albums
+// against the artist's release groups.
+//
+// The match step is best-effort: a MusicBrainz failure is reported in the
+// response as match_error and does not fail the request, because the sync half
+// has already been committed and is useful on its own. An artist-level lock
+// skips matching (it is a provider query feeding an automated write) but not
+// the sync, which only records what is on disk.
+// POST /api/v1/artists/{id}/albums/scan
+func (r *Router) handleScanArtistAlbums(w http.ResponseWriter, req *http.Request) {
+	if !r.requireAlbumService(w, req) {
+		return
+	}
+	artistID, ok := RequirePathParam(w, req, "id")
+	if !ok {
+		return
+	}
+	a, err := r.artistService.GetByID(req.Context(), artistID)
+	if err != nil {
+		if errors.Is(err, artist.ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "artist not found"})
+			return
+		}
+		r.logger.Error("loading artist for album scan", "artist_id", artistID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if a.Path == "" {
+		writeError(w, req, http.StatusBadRequest, "artist has no filesystem path")
+		return
+	}
+
+	syncRes, err := r.albumService.Sync(req.Context(), a)
+	if err != nil {
+		r.logger.Warn("album sync failed", "artist_id", artistID, "path", a.Path, "error", err)
+		writeError(w, req, http.StatusUnprocessableEntity, "album directories could not be read")
+		return
+	}
+
+	resp := map[string]any{"sync": syncRes}
+	if fetcher := r.resolveMBAdapter(); fetcher != nil && a.MusicBrainzID != "" && !a.Locked {
+		groups, err := fetcher.GetReleaseGroups(req.Context(), a.MusicBrainzID)
+		if err != nil {
+			r.logger.Warn("fetching release groups for album match",
+				"artist_id", artistID, "mbid", a.MusicBrainzID, "error", err)
+			resp["match_error"] = "MusicBrainz fetch failed"
+		} else {
+			matchRes, err := r.albumService.MatchReleaseGroups(req.Context(), artistID, groups)
+			if err != nil {
+				r.logger.Error("matching album release groups", "artist_id", artistID, "error", err)
+				resp["match_error"] = "release-group matching failed"
+			} else {
+				resp["match"] = matchRes
+			}
+		}
+	}
+
+	albums, err := r.albumService.ListByArtist(req.Context(), artistID)
+	if err != nil {
+		r.logger.Error("listing albums after scan", "artist_id", artistID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if albums == nil {
+		albums = []album.Album{}
+	}
+	resp["albums"] = albums
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleGetAlbum returns one album.
+// GET /api/v1/albums/{id}
+func (r *Router) handleGetAlbum(w http.ResponseWriter, req *http.Request) {
+	if !r.requireAlbumService(w, req) {
+		return
+	}
+	al, ok := r.loadAlbum(w, req)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, al)
+}
+
+// handleUpdateAlbum applies an operator edit to an album and writes it back to
+// album.nfo.
+//
+// This is the operator path (album.Service.Edit): field locks do not block it,
+// exactly as artist field locks do not block a manual artist edit. The NFO
+// write-back is what keeps the edit: Sync re-imports album.nfo, and an edit
+// that reached only the database would be reverted by the next scan. A failed
+// NFO write is reported as nfo_error alongside the saved album rather than
+// failing the request, because the database change has already landed.
+// PATCH /api/v1/albums/{id}
+func (r *Router) handleUpdateAlbum(w http.ResponseWriter, req *http.Request) {
+	if !r.requireAlbumService(w, req) {
+		return
+	}
+	al, ok := r.loadAlbum(w, req)
+	if !ok {
+		return
+	}
+
+	var body albumUpdateRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeError(w, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	body.apply(al)
+	if strings.TrimSpace(al.Title) == "" {
+		writeError(w, req, http.StatusBadRequest, "title must not be empty")
+		return
+	}
+
+	if err := r.albumService.Edit(req.Context(), al); err != nil {
+		r.logger.Error("updating album", "id", al.ID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if body.Locked != nil && *body.Locked != al.Locked {
+		if err := r.albumService.SetLock(req.Context(), al.ID, *body.Locked); err != nil {
+			r.logger.Error("setting album lock", "id", al.ID, "error", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+			return
+		}
+	}
+
+	updated, err := r.albumService.GetByID(req.Context(), al.ID)
+	if err != nil {
+		r.logger.Error("getting album after update", "id", al.ID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	resp := map[string]any{"album": updated}
+	artistName := ""
+	if a, err := r.artistService.GetByID(req.Context(), updated.ArtistID); err == nil {
+		artistName = a.Name
+	}
+	if err := album.WriteNFO(updated, artistName, updated.Locked); err != nil {
+		r.logger.Warn("writing album.nfo after edit", "id", updated.ID, "path", updated.Path, "error", err)
+		resp["nfo_error"] = err.Error()
+	} else if !updated.NFOExists {
+		updated.NFOExists = true
+		if err := r.albumService.Edit(req.Context(), updated); err != nil {
+			r.logger.Warn("recording album.nfo presence", "id", updated.ID, "error", err)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleLockAlbumField pins one album field against automated writes.
+// POST /api/v1/albums/{id}/field-locks/{field}
+func (r *Router) handleLockAlbumField(w http.ResponseWriter, req *http.Request) {
+	r.setAlbumFieldLock(w, req, true)
+}
+
+// handleUnlockAlbumField removes a single album field lock.
+// DELETE /api/v1/albums/{id}/field-locks/{field}
+func (r *Router) handleUnlockAlbumField(w http.ResponseWriter, req *http.Request) {
+	r.setAlbumFieldLock(w, req, false)
+}
+
+func (r *Router) setAlbumFieldLock(w http.ResponseWriter, req *http.Request, lock bool) {
+	if !r.requireAlbumService(w, req) {
+		return
+	}
+	id, ok := RequirePathParam(w, req, "id")
+	if !ok {
+		return
+	}
+	field, ok := RequirePathParam(w, req, "field")
+	if !ok {
+		return
+	}
+
+	var err error
+	if lock {
+		err = r.albumService.AddLockedField(req.Context(), id, field)
+	} else {
+		err = r.albumService.RemoveLockedField(req.Context(), id, field)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, album.ErrNotFound):
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "album not found"})
+		case errors.Is(err, album.ErrUnknownField):
+			writeError(w, req, http.StatusBadRequest, "unknown album field: "+field)
+		default:
+			r.logger.Error("changing album field lock", "id", id, "field", field, "lock", lock, "error", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		}
+		return
+	}
+
+	updated, err := r.albumService.GetByID(req.Context(), id)
+	if err != nil {
+		r.logger.Error("getting album after field lock", "id", id, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// handleServeAlbumImage serves an album's cover (thumb) or disc art from the
+// album directory.
+// GET /api/v1/albums/{id}/images/{type}/file
+func (r *Router) handleServeAlbumImage(w http.ResponseWriter, req *http.Request) {
+	if !r.requireAlbumService(w, req) {
+		return
+	}
+	imageType := req.PathValue("type")
+	if !album.IsValidImageType(imageType) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid image type"})
+		return
+	}
+	al, ok := r.loadAlbum(w, req)
+	if !ok {
+		return
+	}
+
+	// Serving is read-only: unlike the artist image route this never clears
+	// an exists flag on a miss. The album flags are owned by Sync, which
+	// probes every album in one pass and keeps a flag when a stat fails.
+	filePath, found, err := img.FindExistingImageStrict(req.Context(), al.Path, album.ImageFilenames(imageType))
+	if err != nil {
+		r.logger.Warn("serve album image: stat error probing album dir",
+			slog.String("album_id", al.ID),
+			slog.String("image_type", imageType),
+			slog.String("error", err.Error()))
+		http.NotFound(w, req)
+		return
+	}
+	if !found {
+		http.NotFound(w, req)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeFile(w, req, filePath)
+}
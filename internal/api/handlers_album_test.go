@@ -0,0 +1,219 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/album"
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/provider"
+)
+
+// albumTestRouter builds a Router with an album service over the migrated test
+// DB and one artist whose path is a temp directory holding a "Nevermind" album.
+func albumTestRouter(t *testing.T) (*Router, *artist.Artist, string) {
+	t.Helper()
+	db := newTestDB(t)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	artistSvc := artist.NewService(db)
+	settings := provider.NewSettingsService(db, nil)
+
+	r := NewRouter(RouterDeps{
+		SessionSecret:    testSessionSecret,
+		ArtistService:    artistSvc,
+		AlbumService:     album.NewService(db),
+		ProviderSettings: settings,
+		Orchestrator:     provider.NewOrchestrator(provider.NewRegistry(), settings, logger, nil),
+		DB:               db,
+		Logger:           logger,
+		StaticFS:         os.DirFS("../../web/static"),
+	})
+
+	root := t.TempDir()
+	albumDir := filepath.Join(root, "Nevermind")
+	if err := os.MkdirAll(albumDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(albumDir, "folder.jpg"), []byte("jpeg"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	a := &artist.Artist{Name: "Nirvana", Path: root}
+	if err := artistSvc.Create(context.Background(), a); err != nil {
+		t.Fatalf("creating artist: %v", err)
+	}
+	return r, a, albumDir
+}
+
+// scanAlbums runs the scan handler and returns the synced albums.
+func scanAlbums(t *testing.T, r *Router, artistID string) []album.Album {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/artists/"+artistID+"/albums/scan", nil)
+	req.SetPathValue("id", artistID)
+	w := httptest.NewRecorder()
+	r.handleScanArtistAlbums(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("scan status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Sync   album.SyncResult `json:"sync"`
+		Albums []album.Album    `json:"albums"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding scan response: %v", err)
+	}
+	if resp.Sync.Added+resp.Sync.Unchanged+resp.Sync.Updated != len(resp.Albums) {
+		t.Errorf("sync %+v does not account for %d albums", resp.Sync, len(resp.Albums))
+	}
+	return resp.Albums
+}
+
+func TestHandleScanAndListArtistAlbums(t *testing.T) {
+	r, a, _ := albumTestRouter(t)
+	albums := scanAlbums(t, r, a.ID)
+	if len(albums) != 1 || albums[0].Title != "Nevermind" || !albums[0].ThumbExists {
+		t.Fatalf("scanned albums = %+v", albums)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/artists/"+a.ID+"/albums", nil)
+	req.SetPathValue("id", a.ID)
+	w := httptest.NewRecorder()
+	r.handleListArtistAlbums(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("list status = %d", w.Code)
+	}
+	var listed []album.Album
+	if err := json.NewDecoder(w.Body).Decode(&listed); err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 {
+		t.Errorf("listed %d albums, want 1", len(listed))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/artists/missing/albums", nil)
+	req.SetPathValue("id", "missing")
+	w = httptest.NewRecorder()
+	r.handleListArtistAlbums(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown artist status = %d, want 404", w.Code)
+	}
+}
+
+func TestHandleGetAlbum(t *testing.T) {
+	r, a, _ := albumTestRouter(t)
+	al := scanAlbums(t, r, a.ID)[0]
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/albums/"+al.ID, nil)
+	req.SetPathValue("id", al.ID)
+	w := httptest.NewRecorder()
+	r.handleGetAlbum(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"title":"Nevermind"`) {
+		t.Errorf("get status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/albums/missing", nil)
+	req.SetPathValue("id", "missing")
+	w = httptest.NewRecorder()
+	r.handleGetAlbum(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("missing album status = %d, want 404", w.Code)
+	}
+}
+
+// TestHandleUpdateAlbum_WritesNFO checks that an operator edit lands in
+// album.nfo, so the next scan re-imports the edit instead of reverting it.
+func TestHandleUpdateAlbum_WritesNFO(t *testing.T) {
+	r, a, albumDir := albumTestRouter(t)
+	al := scanAlbums(t, r, a.ID)[0]
+
+	body := `{"year":"1991","label":"DGC","genres":["Grunge","Alternative Rock"]}`
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/albums/"+al.ID, strings.NewReader(body))
+	req.SetPathValue("id", al.ID)
+	w := httptest.NewRecorder()
+	r.handleUpdateAlbum(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "nfo_error") {
+		t.Fatalf("unexpected nfo_error: %s", w.Body.String())
+	}
+
+	n, err := album.ReadNFO(albumDir)
+	if err != nil || n == nil {
+		t.Fatalf("ReadNFO: %v, %v", n, err)
+	}
+	if n.Year != "1991" || n.Label != "DGC" || n.ArtistDesc != "Nirvana" {
+		t.Errorf("album.nfo = %+v", n)
+	}
+
+	albums := scanAlbums(t, r, a.ID)
+	if albums[0].Label != "DGC" || !albums[0].NFOExists {
+		t.Errorf("after rescan: %+v", albums[0])
+	}
+}
+
+func TestHandleAlbumFieldLocks(t *testing.T) {
+	r, a, _ := albumTestRouter(t)
+	al := scanAlbums(t, r, a.ID)[0]
+
+	lockReq := func(method, field string) *http.Request {
+		req := httptest.NewRequest(method, "/api/v1/albums/"+al.ID+"/field-locks/"+field, nil)
+		req.SetPathValue("id", al.ID)
+		req.SetPathValue("field", field)
+		return req
+	}
+
+	w := httptest.NewRecorder()
+	r.handleLockAlbumField(w, lockReq(http.MethodPost, "genres"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("lock status = %d, body = %s", w.Code, w.Body.String())
+	}
+	got, _ := r.albumService.GetByID(context.Background(), al.ID)
+	if !got.IsFieldLocked(album.FieldGenres) {
+		t.Error("genres not locked")
+	}
+	w = httptest.NewRecorder()
+	r.handleLockAlbumField(w, lockReq(http.MethodPost, "biography"))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unknown field status = %d, want 400", w.Code)
+	}
+	w = httptest.NewRecorder()
+	r.handleUnlockAlbumField(w, lockReq(http.MethodDelete, "genres"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("unlock status = %d", w.Code)
+	}
+	got, _ = r.albumService.GetByID(context.Background(), al.ID)
+	if got.IsFieldLocked(album.FieldGenres) {
+		t.Error("genres still locked after unlock")
+	}
+}
+
+func TestHandleServeAlbumImage(t *testing.T) {
+	r, a, _ := albumTestRouter(t)
+	al := scanAlbums(t, r, a.ID)[0]
+
+	serve := func(imageType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/albums/"+al.ID+"/images/"+imageType+"/file", nil)
+		req.SetPathValue("id", al.ID)
+		req.SetPathValue("type", imageType)
+		w := httptest.NewRecorder()
+		r.handleServeAlbumImage(w, req)
+		return w
+	}
+
+	if w := serve("thumb"); w.Code != http.StatusOK || w.Body.String() != "jpeg" {
+		t.Errorf("thumb status = %d, body = %q", w.Code, w.Body.String())
+	}
+	if w := serve("discart"); w.Code != http.StatusNotFound {
+		t.Errorf("discart status = %d, want 404", w.Code)
+	}
+	if w := serve("fanart"); w.Code != http.StatusBadRequest {
+		t.Errorf("fanart status = %d, want 400", w.Code)
+	}
+}
@@ -1,17 +1,30 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 
+	"github.com/sydlexius/stillwater/internal/album"
 	"github.com/sydlexius/stillwater/internal/api/middleware"
+	"github.com/sydlexius/stillwater/internal/artist"
 	"github.com/sydlexius/stillwater/internal/auth"
+	"github.com/sydlexius/stillwater/internal/library"
 )
 
 // handleCreateAPIToken generates a new API token.
 // POST /api/v1/auth/tokens
+//
+// scopes is a comma-separated list of coarse (read, write, webhook, admin)
+// and resource ("artists:write", "rules:run", ...) scopes; see auth.ScopesAllow
+// for what each grants. library_ids optionally restricts the token to those
+// libraries. A token may not mint a token wider than itself: when the caller
+// authenticated with a token, every requested scope must be one the caller's
+// token holds, and a library-restricted caller can only create tokens
+// restricted to a subset of its libraries.
 func (r *Router) handleCreateAPIToken(w http.ResponseWriter, req *http.Request) {
 	userID := middleware.UserIDFromContext(req.Context())
 	if userID == "" {
@@ -20,8 +33,9 @@ func (r *Router) handleCreateAPIToken(w http.ResponseWriter, req *http.Request)
 	}
 
 	var body struct {
-		Name   string `json:"name"`
-		Scopes string `json:"scopes"`
+		Name       string   `json:"name"`
+		Scopes     string   `json:"scopes"`
+		LibraryIDs []string `json:"library_ids"`
 	}
 	if !DecodeJSON(w, req, &body) {
 		return
@@ -35,52 +49,101 @@ func (r *Router) handleCreateAPIToken(w http.ResponseWriter, req *http.Request)
 	if body.Scopes == "" {
 		body.Scopes = "read"
 	}
+	scopes, err := auth.NormalizeScopes(body.Scopes)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	body.Scopes = scopes
+	callerIsToken := middleware.AuthMethodFromContext(req.Context()) == "api_token"
 	callerRole := middleware.RoleFromContext(req.Context())
-	// Cap at the comma count + 1 (max possible split entries); empty
-	// segments and invalid scopes shrink the final slice.
-	normalizedScopes := make([]string, 0, strings.Count(body.Scopes, ",")+1)
-	for _, s := range strings.Split(body.Scopes, ",") {
-		trimmed := strings.TrimSpace(s)
-		if trimmed == "" {
-			continue
-		}
-		scope := auth.TokenScope(trimmed)
-		if !auth.ValidScopes[scope] {
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid scope: " + string(scope)})
-			return
-		}
+	for _, scope := range auth.ParseScopes(body.Scopes) {
 		// Operators may not create admin-scoped tokens (scope ceiling).
 		if scope == auth.ScopeAdmin && callerRole != "administrator" {
 			writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden: operator role cannot create admin-scoped tokens"})
 			return
 		}
-		normalizedScopes = append(normalizedScopes, trimmed)
+		if callerIsToken && !middleware.HasScope(req.Context(), string(scope)) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden: token cannot grant scope " + string(scope)})
+			return
+		}
 	}
-	if len(normalizedScopes) == 0 {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "no valid scopes provided"})
-		return
+
+	libraryIDs := auth.ParseLibraryIDs(strings.Join(body.LibraryIDs, ","))
+	if callerLibs := middleware.TokenLibraryIDsFromContext(req.Context()); len(callerLibs) > 0 {
+		if len(libraryIDs) == 0 {
+			libraryIDs = callerLibs
+		}
+		for _, id := range libraryIDs {
+			if !slices.Contains(callerLibs, id) {
+				writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden: token cannot grant library " + id})
+				return
+			}
+		}
+	}
+	if len(libraryIDs) > 0 {
+		ok, err := r.librariesExist(req.Context(), libraryIDs)
+		if err != nil {
+			r.logger.Error("checking token libraries", "error", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create token"})
+			return
+		}
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "library_ids: unknown library"})
+			return
+		}
 	}
-	body.Scopes = strings.Join(normalizedScopes, ",")
 
-	plaintext, id, err := r.authService.CreateAPIToken(req.Context(), userID, body.Name, body.Scopes)
+	plaintext, id, err := r.authService.CreateAPITokenWithOptions(req.Context(), userID, body.Name, body.Scopes,
+		auth.APITokenOptions{LibraryIDs: libraryIDs})
 	if err != nil {
 		r.logger.Error("creating api token", "error", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create token"})
 		return
 	}
 
-	// Best-effort audit log entry for token creation.
-	if logErr := r.authService.WriteAuditLog(req.Context(), "token_created", id, body.Name, userID, ""); logErr != nil {
+	// Best-effort audit log entry for token creation. The grant is recorded
+	// so a security review can see what a token could do even after it is
+	// deleted (deletion anonymizes the name but keeps the detail).
+	detail := tokenAuditDetail(body.Scopes, libraryIDs)
+	if logErr := r.authService.WriteAuditLog(req.Context(), "token_created", id, body.Name, userID, detail); logErr != nil {
 		r.logger.Warn("failed to write audit log for token creation", "error", logErr)
 	}
 
 	writeJSON(w, http.StatusCreated, map[string]string{
-		"id":    id,
-		"token": plaintext,
-		"name":  body.Name,
+		"id":     id,
+		"token":  plaintext,
+		"name":   body.Name,
+		"scopes": body.Scopes,
 	})
 }
 
+// tokenAuditDetail formats a token's grant for the audit_log detail column.
+func tokenAuditDetail(scopes string, libraryIDs []string) string {
+	detail := "scopes=" + scopes
+	if len(libraryIDs) > 0 {
+		detail += " libraries=" + strings.Join(libraryIDs, ",")
+	}
+	return detail
+}
+
+// librariesExist reports whether every id names an existing library.
+func (r *Router) librariesExist(ctx context.Context, ids []string) (bool, error) {
+	if r.libraryService == nil {
+		return false, nil
+	}
+	libs, err := r.libraryService.List(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range ids {
+		if !slices.ContainsFunc(libs, func(l library.Library) bool { return l.ID == id }) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 // handleListAPITokens lists all tokens for the authenticated user.
 // GET /api/v1/auth/tokens
 func (r *Router) handleListAPITokens(w http.ResponseWriter, req *http.Request) {
@@ -140,7 +203,7 @@ func (r *Router) handleRevokeAPIToken(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	if logErr := r.authService.WriteAuditLog(req.Context(), "token_revoked", tokenID, tok.Name, userID, ""); logErr != nil {
+	if logErr := r.authService.WriteAuditLog(req.Context(), "token_revoked", tokenID, tok.Name, userID, tokenAuditDetail(tok.Scopes, tok.LibraryIDs)); logErr != nil {
 		r.logger.Warn("failed to write audit log for token revocation", "error", logErr)
 	}
 
@@ -177,3 +240,46 @@ func (r *Router) handleDeleteAPIToken(w http.ResponseWriter, req *http.Request)
 	r.logger.Info("api token permanently deleted", slog.String("token_id", tokenID), slog.String("user_id", userID))
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// ArtistLibraryIDs returns the libraries the artist belongs to. It implements
+// middleware.LibraryResolver for library-restricted API tokens.
+func (r *Router) ArtistLibraryIDs(ctx context.Context, artistID string) ([]string, error) {
+	if r.artistService == nil {
+		return nil, middleware.ErrScopeTargetNotFound
+	}
+	a, err := r.artistService.GetByID(ctx, artistID, artist.HydrateOpts{})
+	if errors.Is(err, artist.ErrNotFound) {
+		return nil, middleware.ErrScopeTargetNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	memberships, err := r.artistService.LibrariesForArtist(ctx, a.ID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(memberships)+1)
+	if a.LibraryID != "" {
+		ids = append(ids, a.LibraryID)
+	}
+	for _, m := range memberships {
+		ids = append(ids, m.LibraryID)
+	}
+	return ids, nil
+}
+
+// AlbumLibraryIDs returns the libraries of the album's artist. It implements
+// middleware.LibraryResolver for library-restricted API tokens.
+func (r *Router) AlbumLibraryIDs(ctx context.Context, albumID string) ([]string, error) {
+	if r.albumService == nil {
+		return nil, middleware.ErrScopeTargetNotFound
+	}
+	al, err := r.albumService.GetByID(ctx, albumID)
+	if errors.Is(err, album.ErrNotFound) {
+		return nil, middleware.ErrScopeTargetNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.ArtistLibraryIDs(ctx, al.ArtistID)
+}
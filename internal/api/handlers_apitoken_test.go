@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/sydlexius/stillwater/internal/api/middleware"
 	"github.com/sydlexius/stillwater/internal/auth"
+	"github.com/sydlexius/stillwater/internal/library"
 	"github.com/sydlexius/stillwater/internal/nfo"
 	"github.com/sydlexius/stillwater/internal/rule"
 )
@@ -308,3 +310,108 @@ func TestDeleteRevokedToken_DirectlyWithoutArchiving(t *testing.T) {
 		t.Error("expected token to be deleted, but GetAPIToken returned nil error")
 	}
 }
+
+// createTokenRequest posts body to handleCreateAPIToken with the given
+// request context decorator and returns the recorder.
+func createTokenRequest(t *testing.T, r *Router, body string, withCtx func(*http.Request) *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/tokens", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = withCtx(req)
+	w := httptest.NewRecorder()
+	r.handleCreateAPIToken(w, req)
+	return w
+}
+
+func TestCreateAPIToken_ResourceScopesAndLibraries(t *testing.T) {
+	t.Parallel()
+	r, authSvc, userID := testRouterWithAuth(t)
+	r.libraryService = library.NewService(r.db)
+	lib := &library.Library{Name: "Tagged", Path: t.TempDir(), Type: "regular", Source: "manual"}
+	if err := r.libraryService.Create(context.Background(), lib); err != nil {
+		t.Fatalf("creating library: %v", err)
+	}
+	asAdmin := func(req *http.Request) *http.Request { return withAdminCtx(req, userID) }
+
+	w := createTokenRequest(t, r, `{"name":"tagger","scopes":"artists:write, images:write","library_ids":["`+lib.ID+`"]}`, asAdmin)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if resp["scopes"] != "artists:write,images:write" {
+		t.Errorf("scopes = %q, want normalized artists:write,images:write", resp["scopes"])
+	}
+
+	grant, err := authSvc.ValidateAPITokenGrant(context.Background(), resp["token"])
+	if err != nil {
+		t.Fatalf("validating new token: %v", err)
+	}
+	if len(grant.LibraryIDs) != 1 || grant.LibraryIDs[0] != lib.ID {
+		t.Errorf("LibraryIDs = %v, want [%s]", grant.LibraryIDs, lib.ID)
+	}
+
+	// The audit entry records what the token may do.
+	var detail string
+	if err := r.db.QueryRow(`SELECT detail FROM audit_log WHERE action = 'token_created' AND token_id = ?`, resp["id"]).Scan(&detail); err != nil {
+		t.Fatalf("querying audit log: %v", err)
+	}
+	if want := "scopes=artists:write,images:write libraries=" + lib.ID; detail != want {
+		t.Errorf("audit detail = %q, want %q", detail, want)
+	}
+
+	w = createTokenRequest(t, r, `{"name":"bad","scopes":"artists:write","library_ids":["no-such-library"]}`, asAdmin)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unknown library: expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	w = createTokenRequest(t, r, `{"name":"bad","scopes":"artists:delete"}`, asAdmin)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unknown scope: expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// TestCreateAPIToken_TokenCannotWidenItself verifies that a token-authenticated
+// caller can only mint tokens within its own scopes and libraries.
+func TestCreateAPIToken_TokenCannotWidenItself(t *testing.T) {
+	t.Parallel()
+	r, _, userID := testRouterWithAuth(t)
+	r.libraryService = library.NewService(r.db)
+	lib := &library.Library{Name: "Tagged", Path: t.TempDir(), Type: "regular", Source: "manual"}
+	if err := r.libraryService.Create(context.Background(), lib); err != nil {
+		t.Fatalf("creating library: %v", err)
+	}
+	asToken := func(req *http.Request) *http.Request {
+		ctx := middleware.WithTestTokenGrant(req.Context(), &auth.TokenGrant{
+			UserID: userID, Scopes: "settings:write,artists:write", LibraryIDs: []string{lib.ID},
+		})
+		return req.WithContext(middleware.WithTestRole(ctx, "administrator"))
+	}
+
+	w := createTokenRequest(t, r, `{"name":"wider","scopes":"images:write"}`, asToken)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("scope outside the caller's: expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+	w = createTokenRequest(t, r, `{"name":"other-lib","scopes":"artists:read","library_ids":["lib-other"]}`, asToken)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("library outside the caller's: expected 403, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Without library_ids the new token inherits the caller's restriction.
+	w = createTokenRequest(t, r, `{"name":"narrower","scopes":"artists:read"}`, asToken)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("narrower token: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	tok, err := r.authService.GetAPIToken(context.Background(), resp["id"], userID)
+	if err != nil {
+		t.Fatalf("getting token: %v", err)
+	}
+	if len(tok.LibraryIDs) != 1 || tok.LibraryIDs[0] != lib.ID {
+		t.Errorf("LibraryIDs = %v, want the caller's [%s]", tok.LibraryIDs, lib.ID)
+	}
+}
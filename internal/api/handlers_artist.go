@@ -651,6 +651,25 @@ func buildPlatformArtistURL(conn *connection.Connection, platformArtistID string
 		return u
 	case connection.TypeLidarr:
 		return base + "/artist/" + url.PathEscape(platformArtistID)
+	case connection.TypePlex:
+		// Plex web addresses items by server and metadata key, and has no
+		// server-less form: without the machine identifier the link opens
+		// the web client home instead of a broken details page.
+		serverID := conn.GetPlatformServerID()
+		if serverID == "" {
+			return base + "/web/index.html"
+		}
+		return base + "/web/index.html#!/server/" + url.PathEscape(serverID) +
+			"/details?key=" + url.QueryEscape("/library/metadata/"+platformArtistID)
+	case connection.TypeSubsonic:
+		// Navidrome's web UI route. Other Subsonic servers have no common
+		// artist page; the link lands on their home page via the unknown
+		// fragment, which is no worse than the bare base URL.
+		return base + "/app/#/artist/" + url.PathEscape(platformArtistID) + "/show"
+	case connection.TypeKodi:
+		// Chorus2, the web interface Kodi ships with, routes artists by
+		// library ID.
+		return base + "/#artist/" + url.PathEscape(platformArtistID)
 	default:
 		return base
 	}
@@ -72,6 +72,44 @@ func TestBuildPlatformArtistURL_ServerIDPlumbing(t *testing.T) {
 			id:      "x",
 			wantSub: []string{"serverId=id+with+spaces%26%2B"},
 		},
+		{
+			name: "plex with server id",
+			conn: &connection.Connection{
+				Type: connection.TypePlex,
+				URL:  "http://plex.local:32400/",
+				Plex: &connection.PlexConfig{PlatformServerID: "machine-1"},
+			},
+			id:      "42",
+			wantSub: []string{"http://plex.local:32400/web/index.html#!/server/machine-1/details?key=%2Flibrary%2Fmetadata%2F42"},
+		},
+		{
+			name: "plex without server id falls back to the web client",
+			conn: &connection.Connection{
+				Type: connection.TypePlex,
+				URL:  "http://plex.local:32400",
+			},
+			id:      "42",
+			wantSub: []string{"http://plex.local:32400/web/index.html"},
+			notWant: []string{"details", "metadata"},
+		},
+		{
+			name: "subsonic links into the Navidrome web UI",
+			conn: &connection.Connection{
+				Type: connection.TypeSubsonic,
+				URL:  "http://navidrome.local:4533/",
+			},
+			id:      "ar 1",
+			wantSub: []string{"http://navidrome.local:4533/app/#/artist/ar%201/show"},
+		},
+		{
+			name: "kodi links into the Chorus2 web interface",
+			conn: &connection.Connection{
+				Type: connection.TypeKodi,
+				URL:  "http://kodi.local:8080",
+			},
+			id:      "17",
+			wantSub: []string{"http://kodi.local:8080/#artist/17"},
+		},
 	}
 
 	for _, tc := range cases {
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/artwork"
+	img "github.com/sydlexius/stillwater/internal/image"
+)
+
+// handleListImageRevisions returns the recorded revisions of an artist's image
+// type, newest first. ?slot= narrows a fanart listing to one slot (fanart,
+// fanart1, backdrop2...); without it every fanart slot is listed.
+// GET /api/v1/artists/{id}/images/{type}/revisions
+func (r *Router) handleListImageRevisions(w http.ResponseWriter, req *http.Request) {
+	a, imageType, ok := r.revisionArtistAndType(w, req)
+	if !ok {
+		return
+	}
+	revs, err := r.artworkRevisions.List(req.Context(), a.ID, imageType, req.URL.Query().Get("slot"))
+	if err != nil {
+		r.logger.Error("listing artwork revisions", slog.String("artist_id", a.ID), slog.String("type", imageType), slog.String("error", err.Error()))
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to list revisions"})
+		return
+	}
+	if revs == nil {
+		revs = []artwork.Revision{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"revisions": revs,
+		"keep":      r.artworkRevisions.Keep(),
+	})
+}
+
+// handleServeImageRevision serves a revision's image bytes for preview. The
+// bytes behind a revision ID never change, so the response is cacheable
+// indefinitely.
+// GET /api/v1/artists/{id}/images/{type}/revisions/{revisionId}/file
+func (r *Router) handleServeImageRevision(w http.ResponseWriter, req *http.Request) {
+	a, imageType, ok := r.revisionArtistAndType(w, req)
+	if !ok {
+		return
+	}
+	rev, data, ok := r.loadImageRevision(w, req, a, imageType)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", http.DetectContentType(data))
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	w.Header().Set("ETag", `"`+rev.ContentHash+`"`)
+	http.ServeContent(w, req, "", rev.CreatedAt, bytes.NewReader(data))
+}
+
+// handleRestoreImageRevision writes a revision back as the artist's current
+// image for its slot. It is an ordinary protected write -- the image being
+// replaced is backed up (and itself recorded as a revision first), and a
+// failed save is rolled back -- so a restore is as undoable as the edit it
+// reverses. The bytes are written exactly as recorded, provenance included.
+// POST /api/v1/artists/{id}/images/{type}/revisions/{revisionId}/restore
+func (r *Router) handleRestoreImageRevision(w http.ResponseWriter, req *http.Request) {
+	// Destructive (overwrites the current image): fail CLOSED if the gate
+	// cannot be evaluated, like handleImageRevert.
+	if !r.gateImageWriteStrict(w, req) {
+		return
+	}
+	a, imageType, ok := r.revisionArtistAndType(w, req)
+	if !ok {
+		return
+	}
+	if !r.requireImageDir(w, req, a) {
+		return
+	}
+	rev, data, ok := r.loadImageRevision(w, req, a, imageType)
+	if !ok {
+		return
+	}
+
+	dir := r.imageDir(a)
+	ctx := img.WithRevisionContext(req.Context(), a.ID, "restore")
+
+	if imageType == "fanart" {
+		// The primary slot is written under every configured fanart name, as a
+		// fanart upload would; a numbered slot is its own single file. Save
+		// coerces the extension to the bytes' real format.
+		naming := []string{rev.Slot + ".jpg"}
+		if rev.Slot == img.RevisionSlot("fanart", r.getActiveFanartPrimary(ctx)) {
+			naming, _ = r.getActiveNamingAndSymlinks(ctx, "fanart")
+		}
+		if _, err := r.saveFanartSlotProtected(ctx, dir, naming, data, nil); err != nil {
+			r.logger.Error("restoring fanart revision", slog.String("artist_id", a.ID), slog.String("revision_id", rev.ID), slog.String("error", err.Error()))
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to restore revision"})
+			return
+		}
+		r.updateArtistFanartCount(ctx, a)
+	} else {
+		naming, useSymlinks := r.getActiveNamingAndSymlinks(ctx, imageType)
+		if err := img.BackupSingleSlot(ctx, dir, imageType, naming); err != nil {
+			r.logger.Error("backing up image before revision restore; aborting", slog.String("artist_id", a.ID), slog.String("type", imageType), slog.String("error", err.Error()))
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to preserve current image; restore aborted"})
+			return
+		}
+		if r.expectedWrites != nil {
+			expectedPaths := img.ExpectedPaths(dir, naming)
+			r.expectedWrites.AddAll(expectedPaths)
+			defer r.expectedWrites.RemoveAll(expectedPaths)
+		}
+		res := r.saveSingleSlotWithRollback(ctx, dir, imageType, naming, useSymlinks, nil, data)
+		if res.SaveErr != nil {
+			r.logger.Error("restoring image revision", slog.String("artist_id", a.ID), slog.String("revision_id", rev.ID),
+				slog.String("error", res.SaveErr.Error()), slog.Any("restore_error", res.RestoreErr))
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to restore revision"})
+			return
+		}
+		r.updateArtistImageFlag(ctx, a, imageType)
+	}
+
+	warnings := r.revertSideEffects(ctx, a, imageType)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":        "restored",
+		"type":          imageType,
+		"slot":          rev.Slot,
+		"revision_id":   rev.ID,
+		"sync_warnings": warnings,
+	})
+}
+
+// revisionArtistAndType resolves the artist and image type every revision
+// endpoint is scoped to, writing the error response itself when it cannot.
+func (r *Router) revisionArtistAndType(w http.ResponseWriter, req *http.Request) (*artist.Artist, string, bool) {
+	if r.artworkRevisions == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "artwork revisions not available"})
+		return nil, "", false
+	}
+	artistID, ok := RequirePathParam(w, req, "id")
+	if !ok {
+		return nil, "", false
+	}
+	imageType := req.PathValue("type")
+	if !validImageTypes[imageType] {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid image type"})
+		return nil, "", false
+	}
+	a, err := r.artistService.GetByID(req.Context(), artistID)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "artist not found"})
+		return nil, "", false
+	}
+	return a, imageType, true
+}
+
+// loadImageRevision fetches the {revisionId} revision and its bytes. A
+// revision of another artist or image type is reported as not found rather
+// than served: the path scopes the ID.
+func (r *Router) loadImageRevision(w http.ResponseWriter, req *http.Request, a *artist.Artist, imageType string) (*artwork.Revision, []byte, bool) {
+	revisionID, ok := RequirePathParam(w, req, "revisionId")
+	if !ok {
+		return nil, nil, false
+	}
+	rev, err := r.artworkRevisions.Get(req.Context(), revisionID)
+	if err != nil && !errors.Is(err, artwork.ErrNotFound) {
+		r.logger.Error("loading artwork revision", slog.String("revision_id", revisionID), slog.String("error", err.Error()))
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return nil, nil, false
+	}
+	if err != nil || rev.ArtistID != a.ID || rev.ImageType != imageType {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "revision not found"})
+		return nil, nil, false
+	}
+	data, err := r.artworkRevisions.Content(rev)
+	if errors.Is(err, artwork.ErrContentMissing) {
+		writeJSON(w, http.StatusGone, map[string]string{"error": "revision content is no longer available"})
+		return nil, nil, false
+	}
+	if err != nil {
+		r.logger.Error("reading artwork revision", slog.String("revision_id", revisionID), slog.String("error", err.Error()))
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return nil, nil, false
+	}
+	return rev, data, true
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/artwork"
+	img "github.com/sydlexius/stillwater/internal/image"
+)
+
+// revisionTestRouter returns a router with an artwork revision store and an
+// artist whose folder.jpg holds the image it returns.
+func revisionTestRouter(t *testing.T) (*Router, *artist.Artist, []byte) {
+	t.Helper()
+	r, artistSvc := testRouterWithPlatform(t)
+	r.artworkRevisions = artwork.NewStore(r.db, t.TempDir(), r.logger)
+
+	dir := t.TempDir()
+	a := &artist.Artist{Name: "Revision Artist", SortName: "Revision Artist", Path: dir}
+	if err := artistSvc.Create(context.Background(), a); err != nil {
+		t.Fatalf("creating artist: %v", err)
+	}
+	canonical := filepath.Join(dir, "folder.jpg")
+	writeJPEG(t, canonical, 64, 64)
+	current, err := os.ReadFile(canonical)
+	if err != nil {
+		t.Fatalf("reading canonical: %v", err)
+	}
+	return r, a, current
+}
+
+// recordThumb stores data as a thumb revision of a.
+func recordThumb(t *testing.T, r *Router, a *artist.Artist, action string, data []byte) *artwork.Revision {
+	t.Helper()
+	rev, _, err := r.artworkRevisions.Record(context.Background(), img.RevisionInput{
+		ArtistID: a.ID, ImageType: "thumb", Slot: "thumb", Action: action, Data: data, Format: img.FormatJPEG,
+	})
+	if err != nil {
+		t.Fatalf("recording revision: %v", err)
+	}
+	return rev
+}
+
+func TestHandleListImageRevisions(t *testing.T) {
+	t.Parallel()
+	r, a, current := revisionTestRouter(t)
+
+	older := filepath.Join(t.TempDir(), "older.jpg")
+	writeJPEG(t, older, 32, 32)
+	olderData, _ := os.ReadFile(older)
+	recordThumb(t, r, a, "upload", olderData)
+	recordThumb(t, r, a, "crop", current)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/artists/"+a.ID+"/images/thumb/revisions", nil)
+	req.SetPathValue("id", a.ID)
+	req.SetPathValue("type", "thumb")
+	w := httptest.NewRecorder()
+	r.handleListImageRevisions(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Revisions []artwork.Revision `json:"revisions"`
+		Keep      int                `json:"keep"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+	if len(resp.Revisions) != 2 || resp.Revisions[0].Action != "crop" || resp.Revisions[1].Action != "upload" {
+		t.Fatalf("revisions = %+v, want crop then upload", resp.Revisions)
+	}
+	if resp.Keep != artwork.DefaultKeep {
+		t.Errorf("keep = %d, want %d", resp.Keep, artwork.DefaultKeep)
+	}
+}
+
+func TestHandleServeImageRevision(t *testing.T) {
+	t.Parallel()
+	r, a, current := revisionTestRouter(t)
+	rev := recordThumb(t, r, a, "upload", current)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/artists/"+a.ID+"/images/thumb/revisions/"+rev.ID+"/file", nil)
+	req.SetPathValue("id", a.ID)
+	req.SetPathValue("type", "thumb")
+	req.SetPathValue("revisionId", rev.ID)
+	w := httptest.NewRecorder()
+	r.handleServeImageRevision(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/jpeg" {
+		t.Errorf("Content-Type = %q, want image/jpeg", ct)
+	}
+	if !bytes.Equal(w.Body.Bytes(), current) {
+		t.Error("served bytes differ from the recorded revision")
+	}
+
+	// The path scopes the revision ID: the same ID under another type is 404.
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/artists/"+a.ID+"/images/logo/revisions/"+rev.ID+"/file", nil)
+	req.SetPathValue("id", a.ID)
+	req.SetPathValue("type", "logo")
+	req.SetPathValue("revisionId", rev.ID)
+	w = httptest.NewRecorder()
+	r.handleServeImageRevision(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status under the wrong type = %d, want 404", w.Code)
+	}
+}
+
+// TestHandleRestoreImageRevision restores an older thumb over the current one
+// and checks the restored file is byte-identical to the revision.
+func TestHandleRestoreImageRevision(t *testing.T) {
+	t.Parallel()
+	r, a, current := revisionTestRouter(t)
+
+	older := filepath.Join(t.TempDir(), "older.jpg")
+	writeJPEG(t, older, 96, 96)
+	olderData, _ := os.ReadFile(older)
+	rev := recordThumb(t, r, a, "upload", olderData)
+	recordThumb(t, r, a, "crop", current)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/artists/"+a.ID+"/images/thumb/revisions/"+rev.ID+"/restore", nil)
+	req.SetPathValue("id", a.ID)
+	req.SetPathValue("type", "thumb")
+	req.SetPathValue("revisionId", rev.ID)
+	w := httptest.NewRecorder()
+	r.handleRestoreImageRevision(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+	if body["status"] != "restored" || body["revision_id"] != rev.ID {
+		t.Errorf("body = %v, want status restored for %s", body, rev.ID)
+	}
+	got, err := os.ReadFile(filepath.Join(a.Path, "folder.jpg"))
+	if err != nil {
+		t.Fatalf("reading restored thumb: %v", err)
+	}
+	if !bytes.Equal(got, olderData) {
+		t.Error("restored thumb differs from the revision's bytes")
+	}
+	// The image the restore replaced is in the one-deep backup, so the
+	// ordinary revert still undoes the restore.
+	if _, err := os.Stat(filepath.Join(a.Path, img.BackupDirName, "thumb")); err != nil {
+		t.Errorf("no pre-restore backup: %v", err)
+	}
+}
+
+func TestHandleRestoreImageRevision_ContentMissing(t *testing.T) {
+	t.Parallel()
+	r, a, current := revisionTestRouter(t)
+	storeDir := t.TempDir()
+	r.artworkRevisions = artwork.NewStore(r.db, storeDir, r.logger)
+	rev := recordThumb(t, r, a, "upload", current)
+
+	// Drop the object behind the row.
+	object := filepath.Join(storeDir, "objects", rev.ContentHash[:2], rev.ContentHash)
+	if err := os.Remove(object); err != nil {
+		t.Fatalf("removing object: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/artists/"+a.ID+"/images/thumb/revisions/"+rev.ID+"/restore", nil)
+	req.SetPathValue("id", a.ID)
+	req.SetPathValue("type", "thumb")
+	req.SetPathValue("revisionId", rev.ID)
+	w := httptest.NewRecorder()
+	r.handleRestoreImageRevision(w, req)
+
+	if w.Code != http.StatusGone {
+		t.Fatalf("status = %d, want 410; body: %s", w.Code, w.Body.String())
+	}
+	if got, _ := os.ReadFile(filepath.Join(a.Path, "folder.jpg")); !bytes.Equal(got, current) {
+		t.Error("current thumb changed although the restore failed")
+	}
+}
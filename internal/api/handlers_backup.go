@@ -1,27 +1,63 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"html"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/sydlexius/stillwater/internal/api/middleware"
 	"github.com/sydlexius/stillwater/internal/backup"
+	"github.com/sydlexius/stillwater/internal/settingsio"
 )
 
-// handleBackupCreate triggers a new database backup.
+// handleBackupCreate triggers a new backup: a database snapshot, or with
+// archive set a full archive (see backup.Service.Archive), which carries the
+// artwork when artwork is set and the settings envelope when a passphrase is
+// given. The options come as a JSON body or form values; no body makes a
+// database snapshot.
 // POST /api/v1/settings/backup
 func (r *Router) handleBackupCreate(w http.ResponseWriter, req *http.Request) {
-	info, err := r.backupService.Backup(req.Context())
+	var body struct {
+		Archive    bool   `json:"archive"`
+		Artwork    bool   `json:"artwork"`
+		Passphrase string `json:"passphrase"`
+	}
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(io.LimitReader(req.Body, 1<<20)).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			writeError(w, req, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	} else if req.Body != nil {
+		req.Body = http.MaxBytesReader(w, req.Body, 1<<20)
+		body.Archive, _ = parseBoolStrict(req.FormValue("archive"))
+		body.Artwork, _ = parseBoolStrict(req.FormValue("artwork"))
+		body.Passphrase = req.FormValue("passphrase")
+	}
+
+	var info *backup.BackupInfo
+	var err error
+	if body.Archive {
+		info, err = r.backupService.Archive(req.Context(), backup.ArchiveOptions{Artwork: body.Artwork, Passphrase: body.Passphrase})
+	} else {
+		info, err = r.backupService.Backup(req.Context())
+	}
 	if err != nil {
-		r.logger.Error("backup failed", "error", err)
+		r.logger.Error("backup failed", "archive", body.Archive, "error", err)
 		http.Error(w, `{"error":"backup failed"}`, http.StatusInternalServerError)
 		return
 	}
+	// Copy it off-box as the scheduler does. An upload can take minutes, so
+	// it outlives the request; failures reach the operator through the
+	// backup service's push-failure notifier.
+	go func() { _ = r.backupService.Push(context.WithoutCancel(req.Context()), info.Filename) }()
 
 	if req.Header.Get("HX-Request") == "true" {
 		// Return the updated backup list for HTMX swap
@@ -115,6 +151,129 @@ func (r *Router) handleBackupDownload(w http.ResponseWriter, req *http.Request)
 	http.ServeFile(w, req, path)
 }
 
+// handleBackupRestore puts back the artwork and NFO snapshots in a full
+// archive, for every artist, the artists of library_id, or artist_id. When
+// the whole archive is restored and a passphrase is given, the settings
+// envelope it carries is imported as well. Every entry involved is checked
+// against the archive's manifest first.
+// POST /api/v1/settings/backup/{filename}/restore
+func (r *Router) handleBackupRestore(w http.ResponseWriter, req *http.Request) {
+	filename, ok := RequirePathParam(w, req, "filename")
+	if !ok {
+		return
+	}
+	if !backup.IsValidBackupFilename(filename) {
+		writeError(w, req, http.StatusBadRequest, "invalid filename")
+		return
+	}
+	var body struct {
+		backup.RestoreOptions
+		Passphrase string `json:"passphrase"`
+	}
+	if req.Body != nil {
+		if err := json.NewDecoder(io.LimitReader(req.Body, 1<<20)).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			writeError(w, req, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+	wholeArchive := body.LibraryID == "" && body.ArtistID == ""
+	if body.Passphrase != "" && !wholeArchive {
+		writeError(w, req, http.StatusBadRequest, "settings are only restored with the whole archive")
+		return
+	}
+	if body.Passphrase != "" && r.settingsIOService == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "settings import not available"})
+		return
+	}
+
+	result, err := r.backupService.RestoreArchive(req.Context(), filename, body.RestoreOptions)
+	if err != nil {
+		r.writeRestoreArchiveErr(w, req, filename, err)
+		return
+	}
+
+	resp := map[string]any{"status": "restored", "result": result}
+	if body.Passphrase != "" {
+		env, err := r.backupService.ArchiveSettings(filename)
+		if err != nil {
+			r.writeRestoreArchiveErr(w, req, filename, err)
+			return
+		}
+		imported, err := r.settingsIOService.ImportWithOptions(req.Context(), env, body.Passphrase, settingsio.ImportOptions{
+			ImportingAdminUserID: middleware.UserIDFromContext(req.Context()),
+		})
+		if err != nil {
+			status, msg := classifyRestoreError(err)
+			r.logger.Error("restoring settings from backup archive", "filename", filename, "error", err)
+			writeError(w, req, status, msg)
+			return
+		}
+		resp["settings"] = imported
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleBackupVerify checks one backup now instead of waiting for the verify
+// job: its SHA-256 against the checksum ledger, decryption when it is
+// encrypted, and the database's integrity check. A corrupt backup is a
+// successful check; the result carries status "corrupt" and the problem.
+// POST /api/v1/settings/backup/{filename}/verify
+func (r *Router) handleBackupVerify(w http.ResponseWriter, req *http.Request) {
+	filename, ok := RequirePathParam(w, req, "filename")
+	if !ok {
+		return
+	}
+	if !backup.IsValidBackupFilename(filename) {
+		writeError(w, req, http.StatusBadRequest, "invalid filename")
+		return
+	}
+
+	info, err := r.backupService.Verify(req.Context(), filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			writeError(w, req, http.StatusNotFound, "backup not found")
+			return
+		}
+		r.logger.Error("verifying backup", "filename", filename, "error", err)
+		writeError(w, req, http.StatusInternalServerError, "verification failed")
+		return
+	}
+
+	if req.Header.Get("HX-Request") == "true" {
+		backups, listErr := r.backupService.ListBackups()
+		if listErr != nil {
+			r.logger.Error("listing backups after verify", "error", listErr)
+			writeError(w, req, http.StatusInternalServerError, "listing backups failed")
+			return
+		}
+		r.renderBackupList(w, backups)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, info)
+}
+
+// writeRestoreArchiveErr maps a backup archive restore failure to a response.
+func (r *Router) writeRestoreArchiveErr(w http.ResponseWriter, req *http.Request, filename string, err error) {
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		writeError(w, req, http.StatusNotFound, "backup not found")
+	case errors.Is(err, backup.ErrNotArchive):
+		writeError(w, req, http.StatusBadRequest, "only a full backup archive can be restored here")
+	case errors.Is(err, backup.ErrNotInArchive):
+		writeError(w, req, http.StatusNotFound, "not in this backup archive")
+	case errors.Is(err, backup.ErrEncrypted), errors.Is(err, backup.ErrWrongKey):
+		r.logger.Error("opening encrypted backup archive", "filename", filename, "error", err)
+		writeError(w, req, http.StatusUnprocessableEntity, "backup is encrypted with a key this server does not have")
+	case errors.Is(err, backup.ErrCorruptArchive):
+		r.logger.Error("backup archive failed verification", "filename", filename, "error", err)
+		writeError(w, req, http.StatusUnprocessableEntity, "backup archive is corrupt; nothing was restored")
+	default:
+		r.logger.Error("restoring backup archive", "filename", filename, "error", err)
+		writeError(w, req, http.StatusInternalServerError, "restore failed")
+	}
+}
+
 func (r *Router) renderBackupList(w http.ResponseWriter, backups []backup.BackupInfo) {
 	w.Header().Set("Content-Type", "text/html")
 	if len(backups) == 0 {
@@ -125,14 +284,16 @@ func (r *Router) renderBackupList(w http.ResponseWriter, backups []backup.Backup
 	for _, b := range backups {
 		out += fmt.Sprintf(
 			`<tr class="border-t border-gray-200 dark:border-gray-700">`+
-				`<td class="py-2">%s</td>`+
+				`<td class="py-2">%s%s</td>`+
 				`<td class="py-2">%s</td>`+
 				`<td class="py-2">%s</td>`+
 				`<td class="py-2 text-right">`+
 				`<a href="%s/api/v1/settings/backup/%s" class="text-blue-600 dark:text-blue-400 hover:underline mr-3">Download</a>`+
+				`<button type="button" class="text-blue-600 dark:text-blue-400 hover:underline mr-3" hx-post="%s/api/v1/settings/backup/%s/verify" hx-target="#backup-list" hx-swap="innerHTML">Verify</button>`+
 				`<button type="button" class="text-red-600 dark:text-red-400 hover:underline" hx-delete="%s/api/v1/settings/backup/%s" hx-target="#backup-list" hx-swap="innerHTML" hx-confirm="Delete backup %s?">Delete</button>`+
 				`</td></tr>`,
-			b.Filename, formatBytes(b.Size), b.CreatedAt.Format(time.DateTime),
+			b.Filename, backupBadges(b), formatBytes(b.Size), b.CreatedAt.Format(time.DateTime),
+			html.EscapeString(r.basePath), b.Filename,
 			html.EscapeString(r.basePath), b.Filename,
 			html.EscapeString(r.basePath), b.Filename, b.Filename,
 		)
@@ -141,6 +302,29 @@ func (r *Router) renderBackupList(w http.ResponseWriter, backups []backup.Backup
 	w.Write([]byte(out)) //nolint:errcheck // Best-effort write to HTTP response; client disconnect mid-write is not actionable
 }
 
+// backupBadges renders the markers after a backup's filename in the list:
+// whether it is encrypted, and the result of its last verification. A
+// corrupt backup's problem is the badge's tooltip.
+func backupBadges(b backup.BackupInfo) string {
+	const badge = ` <span class="ml-2 rounded px-1.5 py-0.5 text-xs %s"%s>%s</span>`
+	var out string
+	if b.Encrypted {
+		out += fmt.Sprintf(badge, "bg-gray-100 text-gray-700 dark:bg-gray-700 dark:text-gray-300", "", "Encrypted")
+	}
+	switch b.Status {
+	case backup.StatusOK:
+		var title string
+		if b.VerifiedAt != nil {
+			title = fmt.Sprintf(` title="Verified %s"`, b.VerifiedAt.Format(time.DateTime))
+		}
+		out += fmt.Sprintf(badge, "bg-green-100 text-green-800 dark:bg-green-900 dark:text-green-200", title, "Verified")
+	case backup.StatusCorrupt:
+		out += fmt.Sprintf(badge, "bg-red-100 text-red-800 dark:bg-red-900 dark:text-red-200",
+			fmt.Sprintf(` title="%s"`, html.EscapeString(b.Problem)), "Corrupt")
+	}
+	return out
+}
+
 func formatBytes(b int64) string {
 	const (
 		kb = 1024
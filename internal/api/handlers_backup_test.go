@@ -416,3 +416,117 @@ func TestFormatBytes(t *testing.T) {
 		}
 	}
 }
+
+func TestHandleBackupArchiveAndRestore(t *testing.T) {
+	t.Parallel()
+	r, backupSvc := testRouterWithBackup(t)
+
+	artistDir := t.TempDir()
+	original := filepath.Join(artistDir, ".sw-backup", "thumb", "folder.jpg")
+	if err := os.MkdirAll(filepath.Dir(original), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(original, []byte("original artwork"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mustExec(t, r.db, `INSERT INTO artists (id, name, path) VALUES ('a1', 'Artist', ?)`, artistDir)
+
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/settings/backup",
+		strings.NewReader(`{"archive":true,"artwork":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.handleBackupCreate(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create: status = %d; body: %s", w.Code, w.Body.String())
+	}
+	var info backup.BackupInfo
+	if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if info.Kind != backup.KindArchive || !strings.HasSuffix(info.Filename, ".zip") {
+		t.Fatalf("created %+v, want a .zip archive", info)
+	}
+
+	if err := os.Remove(original); err != nil {
+		t.Fatal(err)
+	}
+	restore := func(filename, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodPost,
+			"/api/v1/settings/backup/"+filename+"/restore", strings.NewReader(body))
+		req.SetPathValue("filename", filename)
+		w := httptest.NewRecorder()
+		r.handleBackupRestore(w, req)
+		return w
+	}
+
+	w = restore(info.Filename, `{"artist_id":"a1"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("restore: status = %d; body: %s", w.Code, w.Body.String())
+	}
+	if data, err := os.ReadFile(original); err != nil || string(data) != "original artwork" {
+		t.Errorf("artwork not restored: %q, %v", data, err)
+	}
+
+	if w = restore(info.Filename, `{"artist_id":"missing"}`); w.Code != http.StatusNotFound {
+		t.Errorf("unknown artist: status = %d, want 404", w.Code)
+	}
+	if w = restore(info.Filename, `{"artist_id":"a1","passphrase":"secret"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("scoped settings restore: status = %d, want 400", w.Code)
+	}
+
+	snapshot, err := backupSvc.Backup(context.Background())
+	if err != nil {
+		t.Fatalf("creating snapshot: %v", err)
+	}
+	if w = restore(snapshot.Filename, `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("database snapshot: status = %d, want 400", w.Code)
+	}
+}
+
+func TestHandleBackupVerify(t *testing.T) {
+	t.Parallel()
+	r, backupSvc := testRouterWithBackup(t)
+
+	info, err := backupSvc.Backup(context.Background())
+	if err != nil {
+		t.Fatalf("creating test backup: %v", err)
+	}
+	verify := func(filename string, htmx bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequestWithContext(context.Background(), http.MethodPost,
+			"/api/v1/settings/backup/"+filename+"/verify", nil)
+		req.SetPathValue("filename", filename)
+		if htmx {
+			req.Header.Set("HX-Request", "true")
+		}
+		w := httptest.NewRecorder()
+		r.handleBackupVerify(w, req)
+		return w
+	}
+
+	w := verify(info.Filename, false)
+	if w.Code != http.StatusOK {
+		t.Fatalf("verify: status = %d; body: %s", w.Code, w.Body.String())
+	}
+	var got backup.BackupInfo
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if got.Status != backup.StatusOK {
+		t.Errorf("status = %q, want ok", got.Status)
+	}
+
+	if err := os.Truncate(filepath.Join(backupSvc.BackupDir(), info.Filename), info.Size/2); err != nil {
+		t.Fatal(err)
+	}
+	w = verify(info.Filename, true)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Corrupt") {
+		t.Errorf("verify truncated: status = %d, want the list with a Corrupt badge; body: %s", w.Code, w.Body.String())
+	}
+
+	if w = verify("stillwater-20000101-000000.db", false); w.Code != http.StatusNotFound {
+		t.Errorf("missing backup: status = %d, want 404", w.Code)
+	}
+	if w = verify("../etc/passwd", false); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid filename: status = %d, want 400", w.Code)
+	}
+}
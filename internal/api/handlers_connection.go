@@ -14,7 +14,10 @@ import (
 	"github.com/sydlexius/stillwater/internal/connection"
 	"github.com/sydlexius/stillwater/internal/connection/emby"
 	"github.com/sydlexius/stillwater/internal/connection/jellyfin"
+	"github.com/sydlexius/stillwater/internal/connection/kodi"
 	"github.com/sydlexius/stillwater/internal/connection/lidarr"
+	"github.com/sydlexius/stillwater/internal/connection/plex"
+	"github.com/sydlexius/stillwater/internal/connection/subsonic"
 	"github.com/sydlexius/stillwater/web/templates"
 )
 
@@ -160,6 +163,12 @@ func (r *Router) testConnectionDirect(ctx context.Context, connType, url, apiKey
 		return jellyfin.New(url, apiKey, "", r.logger).TestConnection(testCtx)
 	case connection.TypeLidarr:
 		return lidarr.New(url, apiKey, r.logger).TestConnection(testCtx)
+	case connection.TypePlex:
+		return plex.New(url, apiKey, r.logger).TestConnection(testCtx)
+	case connection.TypeSubsonic:
+		return subsonic.New(url, apiKey, r.logger).TestConnection(testCtx)
+	case connection.TypeKodi:
+		return kodi.New(url, apiKey, r.logger).TestConnection(testCtx)
 	default:
 		return nil
 	}
@@ -469,9 +478,9 @@ func (r *Router) handleUpdateConnection(w http.ResponseWriter, req *http.Request
 	if body.Type != "" && body.Type != existing.Type {
 		// Reject an unknown type HERE, before it can reach the feature-toggle
 		// gate below. Otherwise a body pairing a bogus type with a toggle
-		// (`{"type":"plex","feature_image_write":true}`) is answered
-		// "feature_image_write is not supported for plex connections" -- which
-		// blames the toggle and implicitly treats "plex" as a real connection
+		// (`{"type":"sonarr","feature_image_write":true}`) is answered
+		// "feature_image_write is not supported for sonarr connections" -- which
+		// blames the toggle and implicitly treats "sonarr" as a real connection
 		// type, masking the actual input error (#2975 review).
 		//
 		// Validate() rejects the same value later in the write path, but by
@@ -480,7 +489,7 @@ func (r *Router) handleUpdateConnection(w http.ResponseWriter, req *http.Request
 		if !connection.IsValidType(body.Type) {
 			unlock()
 			writeFormError(w, req, http.StatusBadRequest,
-				"type must be one of: emby, jellyfin, lidarr")
+				"type must be one of: emby, jellyfin, lidarr, plex, subsonic, kodi")
 			return
 		}
 		// A type change invalidates the platform-specific config carried from
@@ -488,6 +497,7 @@ func (r *Router) handleUpdateConnection(w http.ResponseWriter, req *http.Request
 		// matching the new type (#1686).
 		existing.Type = body.Type
 		existing.Lidarr, existing.Emby, existing.Jellyfin = nil, nil, nil
+		existing.Plex, existing.Subsonic, existing.Kodi = nil, nil, nil
 	}
 	if body.URL != "" {
 		existing.URL = body.URL
@@ -765,6 +775,58 @@ func (p *lidarrProber) Probe(ctx context.Context, id string, conn *connection.Co
 	return result
 }
 
+// plexProber tests a Plex connection. Plex has no platform user to resolve;
+// the server's machineIdentifier is recorded for deep links. There is no
+// drift check: Plex reads no NFO and saves no artwork into the library, so it
+// has no settings that could write over Stillwater's files.
+type plexProber struct {
+	logger *slog.Logger
+}
+
+func (p *plexProber) Probe(ctx context.Context, _ string, conn *connection.Connection) *connectionProbeResult {
+	result := &connectionProbeResult{PlatformName: "plex"}
+	client := plex.New(conn.URL, conn.APIKey, p.logger)
+	result.TestErr = client.TestConnection(ctx)
+	if result.TestErr != nil {
+		return result
+	}
+	if sid, sidErr := client.GetServerID(ctx); sidErr != nil {
+		p.logger.Warn("could not resolve plex platform server id", "error", sidErr)
+	} else {
+		result.HasServerID = true
+		result.PlatformServerID = sid
+	}
+	return result
+}
+
+// subsonicProber tests a Subsonic connection with ping, which checks the
+// credentials as well as reachability. There is no user ID to resolve (the
+// username is part of the credentials), no server ID the web UI's links need,
+// and no settings that could write over Stillwater's files.
+type subsonicProber struct {
+	logger *slog.Logger
+}
+
+func (p *subsonicProber) Probe(ctx context.Context, _ string, conn *connection.Connection) *connectionProbeResult {
+	result := &connectionProbeResult{PlatformName: "subsonic"}
+	result.TestErr = subsonic.New(conn.URL, conn.APIKey, p.logger).TestConnection(ctx)
+	return result
+}
+
+// kodiProber tests a Kodi connection with JSONRPC.Version, which the web
+// server only answers once the basic-auth credentials pass. Kodi has no user
+// or server ID to resolve, and it writes into the artist folder only when the
+// operator runs its library export by hand, so there are no settings to read.
+type kodiProber struct {
+	logger *slog.Logger
+}
+
+func (p *kodiProber) Probe(ctx context.Context, _ string, conn *connection.Connection) *connectionProbeResult {
+	result := &connectionProbeResult{PlatformName: "kodi"}
+	result.TestErr = kodi.New(conn.URL, conn.APIKey, p.logger).TestConnection(ctx)
+	return result
+}
+
 // newConnectionProber returns the connectionProber for connType, or an error
 // if the type is not supported.
 func (r *Router) newConnectionProber(connType string) (connectionProber, error) {
@@ -775,6 +837,12 @@ func (r *Router) newConnectionProber(connType string) (connectionProber, error)
 		return &jellyfinProber{logger: r.logger}, nil
 	case connection.TypeLidarr:
 		return &lidarrProber{logger: r.logger}, nil
+	case connection.TypePlex:
+		return &plexProber{logger: r.logger}, nil
+	case connection.TypeSubsonic:
+		return &subsonicProber{logger: r.logger}, nil
+	case connection.TypeKodi:
+		return &kodiProber{logger: r.logger}, nil
 	default:
 		return nil, errors.New("unsupported connection type: " + connType)
 	}
@@ -857,18 +925,20 @@ func (r *Router) handleTestConnection(w http.ResponseWriter, req *http.Request)
 // Presence is what disqualifies a field, not its value: `false` asserts a
 // stored setting just as much as `true` does. Every offending field is returned
 // at once so a caller fixes the whole body in one round trip.
+//
+// Subsonic owns the trigger-refresh toggle alone (see
+// connection.SupportsTriggerRefresh), so the check is per toggle rather than
+// all-or-nothing.
 func unsupportedFeatureFields(connType string, imageWrite, metadataPush, triggerRefresh *bool) []string {
-	if connection.SupportsFeatureToggles(connType) {
-		return nil
-	}
+	full := connection.SupportsFeatureToggles(connType)
 	var fields []string
-	if imageWrite != nil {
+	if imageWrite != nil && !full {
 		fields = append(fields, "feature_image_write")
 	}
-	if metadataPush != nil {
+	if metadataPush != nil && !full {
 		fields = append(fields, "feature_metadata_push")
 	}
-	if triggerRefresh != nil {
+	if triggerRefresh != nil && !connection.SupportsTriggerRefresh(connType) {
 		fields = append(fields, "feature_trigger_refresh")
 	}
 	return fields
@@ -1249,6 +1319,34 @@ func (r *Router) handleGetPlatformSettings(w http.ResponseWriter, req *http.Requ
 			"note":               "Lidarr metadata consumers are a global setting, not per-library.",
 		})
 
+	case connection.TypePlex:
+		// Plex has no NFO or artwork writers to report: it keeps its metadata
+		// in its own database and never writes into the library folders.
+		writeJSON(w, http.StatusOK, map[string]any{
+			"connection_type": conn.Type,
+			"libraries":       []any{},
+			"note":            "Plex reads no NFO and writes nothing into library folders; Stillwater pushes to it through the API and locks the fields it writes.",
+		})
+
+	case connection.TypeSubsonic:
+		// Navidrome and the other Subsonic servers read artwork from the
+		// artist folder and keep everything else in their own database; none
+		// writes NFO files or images into the library.
+		writeJSON(w, http.StatusOK, map[string]any{
+			"connection_type": conn.Type,
+			"libraries":       []any{},
+			"note":            "Subsonic servers read artist images from the artist folder and write nothing into it; Stillwater starts a scan after it saves artwork.",
+		})
+
+	case connection.TypeKodi:
+		// Kodi writes into the artist folder only when the operator runs a
+		// library export by hand; scans read artist.nfo but never rewrite it.
+		writeJSON(w, http.StatusOK, map[string]any{
+			"connection_type": conn.Type,
+			"libraries":       []any{},
+			"note":            "Kodi reads artist.nfo and artwork from the artist folder and writes into it only on a manual library export; Stillwater pushes through JSON-RPC and rescans the artist folder after it writes.",
+		})
+
 	default:
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported connection type"})
 	}
@@ -1331,6 +1429,15 @@ func (r *Router) handleDisablePlatformSettings(w http.ResponseWriter, req *http.
 		}
 		writeJSON(w, http.StatusOK, map[string]string{"status": "disabled"})
 
+	case connection.TypePlex:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Plex has no metadata writers to disable"})
+
+	case connection.TypeSubsonic:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Subsonic servers have no metadata writers to disable"})
+
+	case connection.TypeKodi:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Kodi has no metadata writers to disable"})
+
 	default:
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported connection type"})
 	}
@@ -1428,6 +1535,50 @@ func (r *Router) handleGetPlatformSummary(w http.ResponseWriter, req *http.Reque
 			"has_conflicts":   hasConflicts,
 		})
 
+	case connection.TypePlex:
+		// Every Plex music library is managed: there is no writer that could
+		// conflict with Stillwater's files (see handleGetPlatformSettings).
+		libs, libsErr := plex.New(conn.URL, conn.APIKey, r.logger).GetMusicLibraries(summaryCtx)
+		if libsErr != nil {
+			r.logger.Error("reading plex libraries for summary", "connection_id", id, "error", libsErr)
+			writeJSON(w, http.StatusBadGateway, map[string]string{"error": "could not read platform settings"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"total_libraries":   len(libs),
+			"managed_libraries": len(libs),
+			"has_conflicts":     false,
+		})
+
+	case connection.TypeSubsonic:
+		// As for Plex, every music folder is managed: nothing on the server
+		// writes into the library.
+		folders, foldersErr := subsonic.New(conn.URL, conn.APIKey, r.logger).GetMusicFolders(summaryCtx)
+		if foldersErr != nil {
+			r.logger.Error("reading subsonic music folders for summary", "connection_id", id, "error", foldersErr)
+			writeJSON(w, http.StatusBadGateway, map[string]string{"error": "could not read platform settings"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"total_libraries":   len(folders),
+			"managed_libraries": len(folders),
+			"has_conflicts":     false,
+		})
+
+	case connection.TypeKodi:
+		// Every music source is managed, as for Plex and Subsonic.
+		sources, sourcesErr := kodi.New(conn.URL, conn.APIKey, r.logger).GetSources(summaryCtx)
+		if sourcesErr != nil {
+			r.logger.Error("reading kodi music sources for summary", "connection_id", id, "error", sourcesErr)
+			writeJSON(w, http.StatusBadGateway, map[string]string{"error": "could not read platform settings"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"total_libraries":   len(sources),
+			"managed_libraries": len(sources),
+			"has_conflicts":     false,
+		})
+
 	default:
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported connection type"})
 	}
@@ -235,7 +235,8 @@ func TestHandleUpdateConnection_TypeChangeToLidarrRejectsFeatureToggles(t *testi
 // #2975 review finding: an unknown type paired with a feature toggle was
 // answered "feature_image_write is not supported for plex connections", which
 // blames the toggle and implicitly treats "plex" as a real connection type,
-// masking the actual input error.
+// masking the actual input error. Plex has since become a real type, so the
+// cases use a type Stillwater has never had.
 //
 // The sub-cases are asserted SEPARATELY because they failed differently before
 // the fix -- with a toggle it was a misleading 400, without one it was a 500
@@ -248,8 +249,8 @@ func TestHandleUpdateConnection_InvalidTypeReportsTheTypeNotTheToggle(t *testing
 		name string
 		body map[string]any
 	}{
-		{"with a feature toggle", map[string]any{"type": "plex", "feature_image_write": true}},
-		{"type alone", map[string]any{"type": "plex"}},
+		{"with a feature toggle", map[string]any{"type": "sonarr", "feature_image_write": true}},
+		{"type alone", map[string]any{"type": "sonarr"}},
 	}
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
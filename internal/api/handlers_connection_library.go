@@ -13,6 +13,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime/debug"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,7 +22,10 @@ import (
 	"github.com/sydlexius/stillwater/internal/connection"
 	"github.com/sydlexius/stillwater/internal/connection/emby"
 	"github.com/sydlexius/stillwater/internal/connection/jellyfin"
+	"github.com/sydlexius/stillwater/internal/connection/kodi"
 	"github.com/sydlexius/stillwater/internal/connection/lidarr"
+	"github.com/sydlexius/stillwater/internal/connection/plex"
+	"github.com/sydlexius/stillwater/internal/connection/subsonic"
 	"github.com/sydlexius/stillwater/internal/dbutil"
 	img "github.com/sydlexius/stillwater/internal/image"
 	"github.com/sydlexius/stillwater/internal/library"
@@ -136,6 +140,71 @@ func (r *Router) handleDiscoverLibraries(w http.ResponseWriter, req *http.Reques
 			discovered = append(discovered, d)
 		}
 
+	case connection.TypePlex:
+		client := plex.New(conn.URL, conn.APIKey, r.logger)
+		sections, libErr := client.GetMusicLibraries(req.Context())
+		if libErr != nil {
+			r.logger.Error("discovering plex libraries", "error", libErr)
+			writeJSON(w, http.StatusBadGateway, map[string]string{"error": "failed to discover libraries from " + conn.Type})
+			return
+		}
+		for i := range sections {
+			s := &sections[i]
+			d := discoveredLibrary{ExternalID: s.Key, Name: s.Title}
+			existing, lookupErr := r.libraryService.GetByConnectionAndExternalID(req.Context(), connID, s.Key)
+			if lookupErr != nil {
+				r.logger.Error("checking existing library", "external_id", s.Key, "error", lookupErr)
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to check existing library"})
+				return
+			}
+			d.Imported = existing != nil
+			discovered = append(discovered, d)
+		}
+
+	case connection.TypeSubsonic:
+		client := subsonic.New(conn.URL, conn.APIKey, r.logger)
+		folders, libErr := client.GetMusicFolders(req.Context())
+		if libErr != nil {
+			r.logger.Error("discovering subsonic libraries", "error", libErr)
+			writeJSON(w, http.StatusBadGateway, map[string]string{"error": "failed to discover libraries from " + conn.Type})
+			return
+		}
+		for i := range folders {
+			f := &folders[i]
+			id := string(f.ID)
+			d := discoveredLibrary{ExternalID: id, Name: f.Name}
+			existing, lookupErr := r.libraryService.GetByConnectionAndExternalID(req.Context(), connID, id)
+			if lookupErr != nil {
+				r.logger.Error("checking existing library", "external_id", id, "error", lookupErr)
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to check existing library"})
+				return
+			}
+			d.Imported = existing != nil
+			discovered = append(discovered, d)
+		}
+
+	case connection.TypeKodi:
+		client := kodi.New(conn.URL, conn.APIKey, r.logger)
+		sources, libErr := client.GetSources(req.Context())
+		if libErr != nil {
+			r.logger.Error("discovering kodi libraries", "error", libErr)
+			writeJSON(w, http.StatusBadGateway, map[string]string{"error": "failed to discover libraries from " + conn.Type})
+			return
+		}
+		for i := range sources {
+			src := &sources[i]
+			id := strconv.Itoa(src.SourceID)
+			d := discoveredLibrary{ExternalID: id, Name: src.Label}
+			existing, lookupErr := r.libraryService.GetByConnectionAndExternalID(req.Context(), connID, id)
+			if lookupErr != nil {
+				r.logger.Error("checking existing library", "external_id", id, "error", lookupErr)
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to check existing library"})
+				return
+			}
+			d.Imported = existing != nil
+			discovered = append(discovered, d)
+		}
+
 	case connection.TypeLidarr:
 		// Lidarr is a read-only metadata source (MBID seeding); Stillwater does
 		// not import libraries from Lidarr connections, so return an empty list.
@@ -394,6 +463,18 @@ func (r *Router) runPopulate(ctx context.Context, conn *connection.Connection, l
 		client := lidarr.New(conn.URL, conn.APIKey, r.logger)
 		popErr = r.populateFromLidarrCtx(ctx, client, lib, &result)
 
+	case connection.TypePlex:
+		client := plex.New(conn.URL, conn.APIKey, r.logger)
+		popErr = r.populateFromPlexCtx(ctx, client, conn, lib, &result)
+
+	case connection.TypeSubsonic:
+		client := subsonic.New(conn.URL, conn.APIKey, r.logger)
+		popErr = r.populateFromSubsonicCtx(ctx, client, lib, &result)
+
+	case connection.TypeKodi:
+		client := kodi.New(conn.URL, conn.APIKey, r.logger)
+		popErr = r.populateFromKodiCtx(ctx, client, lib, &result)
+
 	default:
 		popErr = fmt.Errorf("unsupported connection type: %s", conn.Type)
 	}
@@ -546,6 +627,18 @@ func (r *Router) runLibraryScan(ctx context.Context, conn *connection.Connection
 		client := lidarr.New(conn.URL, conn.APIKey, r.logger)
 		mapped, scanErr = r.scanFromLidarr(ctx, client, lib)
 
+	case connection.TypePlex:
+		client := plex.New(conn.URL, conn.APIKey, r.logger)
+		mapped, scanErr = r.scanFromPlex(ctx, client, conn, lib)
+
+	case connection.TypeSubsonic:
+		client := subsonic.New(conn.URL, conn.APIKey, r.logger)
+		mapped, scanErr = r.scanFromSubsonic(ctx, client, lib)
+
+	case connection.TypeKodi:
+		client := kodi.New(conn.URL, conn.APIKey, r.logger)
+		mapped, scanErr = r.scanFromKodi(ctx, client, lib)
+
 	default:
 		scanErr = fmt.Errorf("unsupported connection type: %s", conn.Type)
 	}
@@ -962,6 +1055,313 @@ func (r *Router) populateFromLidarrCtx(ctx context.Context, client *lidarr.Clien
 	return nil
 }
 
+// populateFromPlexCtx pages through a Plex music section and creates or
+// attaches a local artist for each Plex artist, recording the rating key as
+// the platform ID.
+//
+// Plex reports each artist's folder (Location), which none of the other peers
+// do reliably, so an existing artist is matched by that folder first, unmapped
+// into the host namespace through the connection's path mappings. Only when no
+// local artist owns the folder does the usual MBID-then-name dedup run; the
+// MBID comes from the artist's mbid:// Guid, which Plex only reports when its
+// agent matched one. Plex keeps a single poster and background per artist, so
+// the image download is fed synthetic tags for whichever of the two is set.
+//
+//nolint:gocognit // Same paginated per-artist accounting as the Emby variant, plus the folder match that runs ahead of the MBID/name dedup.
+func (r *Router) populateFromPlexCtx(ctx context.Context, client *plex.Client, conn *connection.Connection, lib *library.Library, result *populateResult) error {
+	manualLibs := r.manualLibraries(ctx)
+	startIndex := 0
+	pageSize := 100
+	for {
+		resp, err := client.GetArtists(ctx, lib.ExternalID, startIndex, pageSize)
+		if err != nil {
+			return fmt.Errorf("fetching artists from plex: %w", err)
+		}
+		r.publishPopulateProgress(lib, result.Total, resp.MediaContainer.TotalSize)
+
+		for i := range resp.MediaContainer.Metadata {
+			item := &resp.MediaContainer.Metadata[i]
+			result.Total++
+			mbid := item.MusicBrainzID()
+			imageTags, backdropTags := plexImageTags(item)
+
+			existing := r.plexArtistByPath(ctx, conn, item)
+			if existing == nil {
+				var skip bool
+				existing, skip = r.dedupeForImport(ctx, mbid, item.Title, "plex", result)
+				if skip {
+					continue
+				}
+			}
+
+			if existing != nil {
+				if mbid != "" && existing.MusicBrainzID == "" {
+					existing.MusicBrainzID = mbid
+					if err := r.artistService.Update(ctx, existing); err != nil {
+						r.logger.Warn("backfilling mbid from plex", "name", existing.Name, "error", err)
+					}
+				}
+				// Divergence-aware stable set, as for Emby (#2344).
+				if outcome, setErr := r.artistService.SetPlatformIDStable(ctx, existing.ID, lib.ConnectionID, item.RatingKey); setErr != nil {
+					r.logger.Warn("storing plex platform id", "name", existing.Name, "error", setErr)
+				} else {
+					r.logPlatformIDDivergence(outcome, existing.Name, "plex", item.RatingKey)
+				}
+				if memErr := r.artistService.AddLibraryMembership(ctx, existing.ID, lib.ID, "plex"); memErr != nil {
+					r.logger.Warn("adding plex library membership", "name", existing.Name, "error", memErr)
+				}
+				r.backfillPlatformIDToManualLibs(ctx, mbid, item.Title, lib.ConnectionID, item.RatingKey, existing.ID, manualLibs)
+				r.downloadPlatformImages(ctx, client, item.RatingKey, imageTags, backdropTags, existing, "plex", result)
+				result.Skipped++
+				continue
+			}
+
+			sortName := item.Title
+			if item.TitleSort != "" {
+				sortName = item.TitleSort
+			}
+			a := &artist.Artist{
+				Name:          item.Title,
+				SortName:      sortName,
+				MusicBrainzID: mbid,
+				LibraryID:     lib.ID,
+				Biography:     item.Summary,
+				Genres:        plexTagNames(item.Genres),
+				Styles:        plexTagNames(item.Styles),
+				Moods:         plexTagNames(item.Moods),
+				Path:          validatedArtistPath(conn.UnmapArtistPath(item.Path()), lib.Path),
+			}
+			if err := r.artistService.Create(ctx, a); err != nil {
+				r.logger.Warn("creating artist from plex", "name", item.Title, "error", err)
+				result.Skipped++
+				continue
+			}
+			result.Created++
+
+			// Initial artist_libraries membership is recorded by
+			// artist.Service.Create via AddDerivingSource.
+			if outcome, setErr := r.artistService.SetPlatformIDStable(ctx, a.ID, lib.ConnectionID, item.RatingKey); setErr != nil {
+				r.logger.Warn("storing plex platform id", "name", a.Name, "error", setErr)
+			} else {
+				r.logPlatformIDDivergence(outcome, a.Name, "plex", item.RatingKey)
+			}
+			r.backfillPlatformIDToManualLibs(ctx, mbid, item.Title, lib.ConnectionID, item.RatingKey, a.ID, manualLibs)
+
+			r.downloadPlatformImages(ctx, client, item.RatingKey, imageTags, backdropTags, a, "plex", result)
+		}
+
+		r.publishPopulateProgress(lib, result.Total, resp.MediaContainer.TotalSize)
+
+		startIndex += pageSize
+		if startIndex >= resp.MediaContainer.TotalSize {
+			break
+		}
+	}
+	return nil
+}
+
+// populateFromSubsonicCtx creates or attaches a local artist for each artist
+// in a Subsonic music folder, recording the server's artist ID as the platform
+// ID. getArtists returns the whole folder in one response, so there is no
+// paging; progress ticks per artist instead of per page.
+//
+// Matching is the usual MBID-then-name dedup; the MBID is an OpenSubsonic
+// addition and is empty on servers without it. Nothing beyond the name and
+// MBID is imported: Navidrome fills biographies and images from its own
+// agents or from the artist folder Stillwater writes, so pulling them back
+// would only echo the server's copy of Stillwater's data (or Last.fm's).
+// Neither is downloaded for the same reason.
+func (r *Router) populateFromSubsonicCtx(ctx context.Context, client *subsonic.Client, lib *library.Library, result *populateResult) error {
+	manualLibs := r.manualLibraries(ctx)
+	artists, err := client.GetArtists(ctx, lib.ExternalID)
+	if err != nil {
+		return fmt.Errorf("fetching artists from subsonic: %w", err)
+	}
+	for i := range artists {
+		r.publishPopulateProgress(lib, result.Total, len(artists))
+		sa := &artists[i]
+		result.Total++
+		pid := string(sa.ID)
+
+		existing, skip := r.dedupeForImport(ctx, sa.MusicBrainzID, sa.Name, "subsonic", result)
+		if skip {
+			continue
+		}
+
+		if existing != nil {
+			if sa.MusicBrainzID != "" && existing.MusicBrainzID == "" {
+				existing.MusicBrainzID = sa.MusicBrainzID
+				if err := r.artistService.Update(ctx, existing); err != nil {
+					r.logger.Warn("backfilling mbid from subsonic", "name", existing.Name, "error", err)
+				}
+			}
+			// Divergence-aware stable set, as for Emby (#2344).
+			if outcome, setErr := r.artistService.SetPlatformIDStable(ctx, existing.ID, lib.ConnectionID, pid); setErr != nil {
+				r.logger.Warn("storing subsonic platform id", "name", existing.Name, "error", setErr)
+			} else {
+				r.logPlatformIDDivergence(outcome, existing.Name, "subsonic", pid)
+			}
+			if memErr := r.artistService.AddLibraryMembership(ctx, existing.ID, lib.ID, "subsonic"); memErr != nil {
+				r.logger.Warn("adding subsonic library membership", "name", existing.Name, "error", memErr)
+			}
+			r.backfillPlatformIDToManualLibs(ctx, sa.MusicBrainzID, sa.Name, lib.ConnectionID, pid, existing.ID, manualLibs)
+			result.Skipped++
+			continue
+		}
+
+		sortName := sa.Name
+		if sa.SortName != "" {
+			sortName = sa.SortName
+		}
+		a := &artist.Artist{
+			Name:          sa.Name,
+			SortName:      sortName,
+			MusicBrainzID: sa.MusicBrainzID,
+			LibraryID:     lib.ID,
+		}
+		if err := r.artistService.Create(ctx, a); err != nil {
+			r.logger.Warn("creating artist from subsonic", "name", sa.Name, "error", err)
+			result.Skipped++
+			continue
+		}
+		result.Created++
+
+		// Initial artist_libraries membership is recorded by
+		// artist.Service.Create via AddDerivingSource.
+		if outcome, setErr := r.artistService.SetPlatformIDStable(ctx, a.ID, lib.ConnectionID, pid); setErr != nil {
+			r.logger.Warn("storing subsonic platform id", "name", a.Name, "error", setErr)
+		} else {
+			r.logPlatformIDDivergence(outcome, a.Name, "subsonic", pid)
+		}
+		r.backfillPlatformIDToManualLibs(ctx, sa.MusicBrainzID, sa.Name, lib.ConnectionID, pid, a.ID, manualLibs)
+	}
+
+	r.publishPopulateProgress(lib, result.Total, len(artists))
+	return nil
+}
+
+// populateFromKodiCtx creates or attaches a local artist for each album
+// artist in a Kodi music source, recording Kodi's artist ID as the platform
+// ID. The whole source is listed before the loop (Kodi has no source filter
+// for artists, see kodi.Client.GetArtists), so progress ticks per artist.
+//
+// Kodi's JSON-RPC API exposes no artist folder, so matching is MBID-then-name
+// only, and only the name, sort name and MBID are imported. Kodi's
+// biographies and artwork come from its own scrapers or from the artist.nfo
+// and images Stillwater writes into the folder; pulling them back would echo
+// one of those, and asking a player-class box for every artist's details
+// would make the import slow for nothing.
+func (r *Router) populateFromKodiCtx(ctx context.Context, client *kodi.Client, lib *library.Library, result *populateResult) error {
+	manualLibs := r.manualLibraries(ctx)
+	artists, err := client.GetArtists(ctx, lib.ExternalID)
+	if err != nil {
+		return fmt.Errorf("fetching artists from kodi: %w", err)
+	}
+	for i := range artists {
+		r.publishPopulateProgress(lib, result.Total, len(artists))
+		ka := &artists[i]
+		result.Total++
+		pid := strconv.Itoa(ka.ArtistID)
+		mbid := ka.MusicBrainzID()
+
+		existing, skip := r.dedupeForImport(ctx, mbid, ka.Name, "kodi", result)
+		if skip {
+			continue
+		}
+
+		if existing != nil {
+			if mbid != "" && existing.MusicBrainzID == "" {
+				existing.MusicBrainzID = mbid
+				if err := r.artistService.Update(ctx, existing); err != nil {
+					r.logger.Warn("backfilling mbid from kodi", "name", existing.Name, "error", err)
+				}
+			}
+			// Divergence-aware stable set, as for Emby (#2344).
+			if outcome, setErr := r.artistService.SetPlatformIDStable(ctx, existing.ID, lib.ConnectionID, pid); setErr != nil {
+				r.logger.Warn("storing kodi platform id", "name", existing.Name, "error", setErr)
+			} else {
+				r.logPlatformIDDivergence(outcome, existing.Name, "kodi", pid)
+			}
+			if memErr := r.artistService.AddLibraryMembership(ctx, existing.ID, lib.ID, "kodi"); memErr != nil {
+				r.logger.Warn("adding kodi library membership", "name", existing.Name, "error", memErr)
+			}
+			r.backfillPlatformIDToManualLibs(ctx, mbid, ka.Name, lib.ConnectionID, pid, existing.ID, manualLibs)
+			result.Skipped++
+			continue
+		}
+
+		sortName := ka.Name
+		if ka.SortName != "" {
+			sortName = ka.SortName
+		}
+		a := &artist.Artist{
+			Name:          ka.Name,
+			SortName:      sortName,
+			MusicBrainzID: mbid,
+			LibraryID:     lib.ID,
+		}
+		if err := r.artistService.Create(ctx, a); err != nil {
+			r.logger.Warn("creating artist from kodi", "name", ka.Name, "error", err)
+			result.Skipped++
+			continue
+		}
+		result.Created++
+
+		// Initial artist_libraries membership is recorded by
+		// artist.Service.Create via AddDerivingSource.
+		if outcome, setErr := r.artistService.SetPlatformIDStable(ctx, a.ID, lib.ConnectionID, pid); setErr != nil {
+			r.logger.Warn("storing kodi platform id", "name", a.Name, "error", setErr)
+		} else {
+			r.logPlatformIDDivergence(outcome, a.Name, "kodi", pid)
+		}
+		r.backfillPlatformIDToManualLibs(ctx, mbid, ka.Name, lib.ConnectionID, pid, a.ID, manualLibs)
+	}
+
+	r.publishPopulateProgress(lib, result.Total, len(artists))
+	return nil
+}
+
+// plexArtistByPath returns the local artist whose directory is the folder Plex
+// reports for item, translated back into the host namespace, or nil when Plex
+// reported no folder, no artist owns it, or the lookup failed (logged).
+func (r *Router) plexArtistByPath(ctx context.Context, conn *connection.Connection, item *plex.Artist) *artist.Artist {
+	p := conn.UnmapArtistPath(item.Path())
+	if p == "" {
+		return nil
+	}
+	a, err := r.artistService.GetByPath(ctx, p)
+	if err != nil {
+		r.logger.Warn("plex artist path lookup", "name", item.Title, "path", p, "error", err)
+		return nil
+	}
+	return a
+}
+
+// plexImageTags adapts a Plex artist's poster and background to the
+// ImageTags/BackdropImageTags shape downloadPlatformImages takes. The values
+// only need to be non-empty: the download itself goes through the client by
+// rating key.
+func plexImageTags(item *plex.Artist) (map[string]string, []string) {
+	tags := map[string]string{"Primary": item.Thumb}
+	var backdrops []string
+	if item.Art != "" {
+		backdrops = []string{item.Art}
+	}
+	return tags, backdrops
+}
+
+// plexTagNames flattens a Plex tag list to its names.
+func plexTagNames(tags []plex.Tag) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		out = append(out, t.Tag)
+	}
+	return out
+}
+
 // validatedArtistPath returns the resolved item path only when it exists on
 // disk as a directory and falls under libraryPath. Returns empty string if
 // libraryPath is empty (pathless library), itemPath is empty, itemPath does
@@ -1108,7 +1508,7 @@ func (p *platformImagePipeline) downloadNamedImage(ctx context.Context, stillwat
 	// banner -- see platformToStillwaterType), never fanart, so #2565's
 	// fanart-gated check would be a no-op here anyway. Backdrops arriving from a
 	// platform go through downloadBackdrop, which #2613 already wired.
-	if _, err := r.processAndSaveImage(ctx, nil, p.dir, stillwaterType, data, meta); err != nil {
+	if _, err := r.processAndSaveImage(img.WithRevisionContext(ctx, a.ID, "import"), nil, p.dir, stillwaterType, data, meta); err != nil {
 		r.logger.Warn("saving downloaded image", "artist", a.Name, "type", stillwaterType, "error", err)
 		return
 	}
@@ -1387,7 +1787,7 @@ func (p *platformImagePipeline) downloadBackdrop(ctx context.Context, i int, pri
 	// An import that lands on an existing slot overwrites the user's image, so it
 	// takes the same backup + rollback protection as every other destructive fanart
 	// write (#2413).
-	saved, saveErr := r.saveFanartSlotProtected(ctx, p.dir, []string{filename}, converted, meta)
+	saved, saveErr := r.saveFanartSlotProtected(img.WithRevisionContext(ctx, a.ID, "import"), p.dir, []string{filename}, converted, meta)
 	if saveErr != nil {
 		r.logger.Warn("saving backdrop", "artist", a.Name, "index", i, "error", saveErr)
 		return backdropSkipped
@@ -1803,6 +2203,94 @@ func (r *Router) scanFromLidarr(ctx context.Context, client *lidarr.Client, lib
 	return mapped, nil
 }
 
+// scanFromPlex pages through a Plex music section and resolves each artist to
+// a local artist row, storing the rating key as the platform ID. Like
+// populateFromPlexCtx it matches by the folder Plex reports before falling
+// back to MBID and name. It returns the number of artists it mapped and, like
+// scanFromEmby, never writes local image-existence state (#2637).
+func (r *Router) scanFromPlex(ctx context.Context, client *plex.Client, conn *connection.Connection, lib *library.Library) (int, error) {
+	manualLibs := r.manualLibraries(ctx)
+	mapped := 0
+	startIndex := 0
+	pageSize := 100
+	for {
+		resp, err := client.GetArtists(ctx, lib.ExternalID, startIndex, pageSize)
+		if err != nil {
+			return mapped, fmt.Errorf("fetching artists from plex: %w", err)
+		}
+
+		for i := range resp.MediaContainer.Metadata {
+			item := &resp.MediaContainer.Metadata[i]
+			mbid := item.MusicBrainzID()
+			if a := r.plexArtistByPath(ctx, conn, item); a != nil {
+				outcome, setErr := r.artistService.SetPlatformIDStable(ctx, a.ID, lib.ConnectionID, item.RatingKey)
+				if setErr != nil {
+					r.logger.Warn("storing platform id during scan", "name", a.Name, "platform", "plex", "error", setErr)
+					continue
+				}
+				r.logPlatformIDDivergence(outcome, a.Name, "plex", item.RatingKey)
+				r.backfillPlatformIDToManualLibs(ctx, mbid, item.Title, lib.ConnectionID, item.RatingKey, a.ID, manualLibs)
+				mapped++
+				continue
+			}
+			if a := r.resolveAndBackfillPlatformID(ctx, mbid, item.Title,
+				lib.ConnectionID, item.RatingKey, lib, manualLibs); a != nil {
+				mapped++
+			}
+		}
+
+		startIndex += pageSize
+		if startIndex >= resp.MediaContainer.TotalSize {
+			break
+		}
+	}
+	return mapped, nil
+}
+
+// scanFromSubsonic resolves each artist in a Subsonic music folder to a local
+// artist row by MBID and name, storing the server's artist ID as the platform
+// ID. It returns the number of artists it mapped and, like scanFromEmby, never
+// writes local image-existence state (#2637).
+func (r *Router) scanFromSubsonic(ctx context.Context, client *subsonic.Client, lib *library.Library) (int, error) {
+	manualLibs := r.manualLibraries(ctx)
+	artists, err := client.GetArtists(ctx, lib.ExternalID)
+	if err != nil {
+		return 0, fmt.Errorf("fetching artists from subsonic: %w", err)
+	}
+
+	mapped := 0
+	for i := range artists {
+		sa := &artists[i]
+		if a := r.resolveAndBackfillPlatformID(ctx, sa.MusicBrainzID, sa.Name,
+			lib.ConnectionID, string(sa.ID), lib, manualLibs); a != nil {
+			mapped++
+		}
+	}
+	return mapped, nil
+}
+
+// scanFromKodi resolves each album artist in a Kodi music source to a local
+// artist row by MBID and name, storing Kodi's artist ID as the platform ID.
+// It returns the number of artists it mapped and, like scanFromEmby, never
+// writes local image-existence state (#2637).
+func (r *Router) scanFromKodi(ctx context.Context, client *kodi.Client, lib *library.Library) (int, error) {
+	manualLibs := r.manualLibraries(ctx)
+	artists, err := client.GetArtists(ctx, lib.ExternalID)
+	if err != nil {
+		return 0, fmt.Errorf("fetching artists from kodi: %w", err)
+	}
+
+	mapped := 0
+	for i := range artists {
+		ka := &artists[i]
+		if a := r.resolveAndBackfillPlatformID(ctx, ka.MusicBrainzID(), ka.Name,
+			lib.ConnectionID, strconv.Itoa(ka.ArtistID), lib, manualLibs); a != nil {
+			mapped++
+		}
+	}
+	return mapped, nil
+}
+
 // checkSyncMtimeEvidence performs Tier 2 shared-FS detection after a library
 // sync. It compares the filesystem mtime of image files in each artist's
 // directory against that artist's own newest last_written_at timestamp (not a
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/connection"
+	"github.com/sydlexius/stillwater/internal/connection/kodi"
+	"github.com/sydlexius/stillwater/internal/library"
+)
+
+// kodiArtistServer is a fake Kodi JSON-RPC endpoint whose AudioLibrary.GetArtists
+// returns artists (a JSON array) as a single page.
+func kodiArtistServer(t *testing.T, artists string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+		}
+		if r.URL.Path != "/jsonrpc" || json.NewDecoder(r.Body).Decode(&req) != nil || req.Method != "AudioLibrary.GetArtists" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"artists":` + artists + `,"limits":{"start":0,"end":3,"total":3}}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// kodiConnectionAndLibrary creates a Kodi connection plus an imported library
+// for its music source 1. Kodi reports no artist folders, so the library has
+// no path.
+func kodiConnectionAndLibrary(t *testing.T, r *Router, srvURL string) (*connection.Connection, *library.Library) {
+	t.Helper()
+	ctx := context.Background()
+	conn := &connection.Connection{
+		Name:    "Living Room",
+		Type:    connection.TypeKodi,
+		URL:     srvURL,
+		APIKey:  "kodi:secret",
+		Enabled: true,
+		Status:  "ok",
+	}
+	if err := r.connectionService.Create(ctx, conn); err != nil {
+		t.Fatalf("creating connection: %v", err)
+	}
+	lib := &library.Library{
+		Name:         "Kodi Music",
+		Type:         library.TypeRegular,
+		Source:       library.SourceKodi,
+		ConnectionID: conn.ID,
+		ExternalID:   "1",
+	}
+	if err := r.libraryService.Create(ctx, lib); err != nil {
+		t.Fatalf("creating library: %v", err)
+	}
+	return conn, lib
+}
+
+func newTestKodiClient(srv *httptest.Server) *kodi.Client {
+	return kodi.NewWithHTTPClient(srv.URL, "kodi:secret", srv.Client(),
+		slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
+}
+
+// TestPopulateFromKodi_CreatesAndMatches covers both import arms and the
+// source filter: an artist matched by MBID is attached rather than
+// duplicated, an unknown one is created, and an artist with no songs in the
+// library's source is left out.
+func TestPopulateFromKodi_CreatesAndMatches(t *testing.T) {
+	t.Parallel()
+	srv := kodiArtistServer(t, `[
+		{"artistid":5,"artist":"Björk","sortname":"bjork","musicbrainzartistid":["87c5dedd-371d-4571-9e1c-45f6e0ed3fce"],"sourceid":[1]},
+		{"artistid":6,"artist":"Radiohead (UK)","musicbrainzartistid":"a74b1b7f-71a5-4011-9441-d0b5e4122711","sourceid":[1,2]},
+		{"artistid":7,"artist":"Elsewhere","sourceid":[2]}
+	]`)
+
+	r := testRouterForLibraryOps(t)
+	ctx := context.Background()
+	conn, lib := kodiConnectionAndLibrary(t, r, srv.URL)
+
+	existing := &artist.Artist{Name: "Radiohead", SortName: "Radiohead", MusicBrainzID: "a74b1b7f-71a5-4011-9441-d0b5e4122711"}
+	if err := r.artistService.Create(ctx, existing); err != nil {
+		t.Fatalf("creating artist: %v", err)
+	}
+
+	var result populateResult
+	if err := r.populateFromKodiCtx(ctx, newTestKodiClient(srv), lib, &result); err != nil {
+		t.Fatalf("populateFromKodiCtx: %v", err)
+	}
+	if result.Total != 2 || result.Created != 1 {
+		t.Fatalf("total/created = %d/%d, want 2/1", result.Total, result.Created)
+	}
+
+	created, err := r.artistService.GetByMBID(ctx, "87c5dedd-371d-4571-9e1c-45f6e0ed3fce")
+	if err != nil || created == nil {
+		t.Fatalf("looking up created artist: %v", err)
+	}
+	if created.Name != "Björk" || created.SortName != "bjork" {
+		t.Errorf("artist = %q/%q, want the Kodi name and sort name", created.Name, created.SortName)
+	}
+	if pid, _ := r.artistService.GetPlatformID(ctx, created.ID, conn.ID); pid != "5" {
+		t.Errorf("created platform id = %q, want 5", pid)
+	}
+	if pid, _ := r.artistService.GetPlatformID(ctx, existing.ID, conn.ID); pid != "6" {
+		t.Errorf("matched platform id = %q, want 6", pid)
+	}
+	assertArtistInLibrary(t, r, ctx, existing.ID, lib.ID)
+}
+
+func TestScanFromKodi_MapsByMBIDThenName(t *testing.T) {
+	t.Parallel()
+	srv := kodiArtistServer(t, `[
+		{"artistid":1,"artist":"Other Spelling","musicbrainzartistid":["8f6bd1e4-fbe1-4f50-aa9b-94c450ec0f11"],"sourceid":[1]},
+		{"artistid":2,"artist":"Massive Attack","sourceid":[1]},
+		{"artistid":3,"artist":"Unknown To Stillwater","sourceid":[1]}
+	]`)
+
+	r := testRouterForLibraryOps(t)
+	ctx := context.Background()
+	conn, lib := kodiConnectionAndLibrary(t, r, srv.URL)
+
+	byMBID := &artist.Artist{Name: "Portishead", SortName: "Portishead", MusicBrainzID: "8f6bd1e4-fbe1-4f50-aa9b-94c450ec0f11"}
+	byName := &artist.Artist{Name: "Massive Attack", SortName: "Massive Attack"}
+	for _, a := range []*artist.Artist{byMBID, byName} {
+		if err := r.artistService.Create(ctx, a); err != nil {
+			t.Fatalf("creating artist %s: %v", a.Name, err)
+		}
+	}
+
+	mapped, err := r.scanFromKodi(ctx, newTestKodiClient(srv), lib)
+	if err != nil {
+		t.Fatalf("scanFromKodi: %v", err)
+	}
+	if mapped != 2 {
+		t.Errorf("mapped = %d, want 2", mapped)
+	}
+	if pid, _ := r.artistService.GetPlatformID(ctx, byMBID.ID, conn.ID); pid != "1" {
+		t.Errorf("MBID-matched platform id = %q, want 1", pid)
+	}
+	if pid, _ := r.artistService.GetPlatformID(ctx, byName.ID, conn.ID); pid != "2" {
+		t.Errorf("name-matched platform id = %q, want 2", pid)
+	}
+}
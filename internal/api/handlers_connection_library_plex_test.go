@@ -0,0 +1,197 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/connection"
+	"github.com/sydlexius/stillwater/internal/connection/plex"
+	"github.com/sydlexius/stillwater/internal/library"
+)
+
+// plexSectionServer is a fake Plex server whose music section 3 lists the
+// artists in body.
+func plexSectionServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/library/sections/3/all" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// plexConnectionAndLibrary creates a Plex connection whose path mapping
+// translates /data/music on the Plex side to hostRoot, plus an imported
+// library for its section 3.
+func plexConnectionAndLibrary(t *testing.T, r *Router, srvURL, hostRoot string) (*connection.Connection, *library.Library) {
+	t.Helper()
+	ctx := context.Background()
+	conn := &connection.Connection{
+		Name:         "Plex",
+		Type:         connection.TypePlex,
+		URL:          srvURL,
+		APIKey:       "token",
+		Enabled:      true,
+		Status:       "ok",
+		PathMappings: []connection.PathMapping{{HostPrefix: hostRoot, PlatformPrefix: "/data/music"}},
+	}
+	if err := r.connectionService.Create(ctx, conn); err != nil {
+		t.Fatalf("creating connection: %v", err)
+	}
+	lib := &library.Library{
+		Name:         "Plex Music",
+		Path:         hostRoot,
+		Type:         library.TypeRegular,
+		Source:       library.SourcePlex,
+		ConnectionID: conn.ID,
+		ExternalID:   "3",
+	}
+	if err := r.libraryService.Create(ctx, lib); err != nil {
+		t.Fatalf("creating library: %v", err)
+	}
+	return conn, lib
+}
+
+func newTestPlexClient(srv *httptest.Server) *plex.Client {
+	return plex.NewWithHTTPClient(srv.URL, "token", srv.Client(),
+		slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
+}
+
+func TestPopulateFromPlex_ImportsMetadataFields(t *testing.T) {
+	t.Parallel()
+	hostRoot, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("resolving temp dir symlinks: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(hostRoot, "Bjork"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	srv := plexSectionServer(t, `{"MediaContainer":{"totalSize":1,"Metadata":[{
+		"ratingKey":"101","title":"Björk","titleSort":"Bjork","summary":"Icelandic singer.",
+		"Genre":[{"tag":"Electronic"}],"Style":[{"tag":"Art Pop"}],"Mood":[{"tag":"Quirky"}],
+		"Guid":[{"id":"mbid://87c5dedd-371d-4571-9e1c-45f6e0ed3fce"}],
+		"Location":[{"path":"/data/music/Bjork"}]
+	}]}}`)
+
+	r := testRouterForLibraryOps(t)
+	ctx := context.Background()
+	conn, lib := plexConnectionAndLibrary(t, r, srv.URL, hostRoot)
+
+	var result populateResult
+	if err := r.populateFromPlexCtx(ctx, newTestPlexClient(srv), conn, lib, &result); err != nil {
+		t.Fatalf("populateFromPlexCtx: %v", err)
+	}
+	if result.Created != 1 {
+		t.Fatalf("created = %d, want 1", result.Created)
+	}
+
+	a, err := r.artistService.GetByMBID(ctx, "87c5dedd-371d-4571-9e1c-45f6e0ed3fce")
+	if err != nil || a == nil {
+		t.Fatalf("looking up artist: %v", err)
+	}
+	if a.Name != "Björk" || a.SortName != "Bjork" || a.Biography != "Icelandic singer." {
+		t.Errorf("artist = %q/%q/%q, want the Plex title, sort title and summary", a.Name, a.SortName, a.Biography)
+	}
+	if len(a.Genres) != 1 || len(a.Styles) != 1 || len(a.Moods) != 1 {
+		t.Errorf("tags = %v/%v/%v, want one genre, style and mood", a.Genres, a.Styles, a.Moods)
+	}
+	// The Plex folder is translated back through the path mapping.
+	if want := filepath.Join(hostRoot, "Bjork"); a.Path != want {
+		t.Errorf("path = %q, want %q", a.Path, want)
+	}
+	if pid, _ := r.artistService.GetPlatformID(ctx, a.ID, conn.ID); pid != "101" {
+		t.Errorf("platform id = %q, want the rating key 101", pid)
+	}
+	assertArtistInLibrary(t, r, ctx, a.ID, lib.ID)
+}
+
+// TestPopulateFromPlex_MatchesExistingArtistByPath covers the folder match: the
+// Plex title and MBID agree with nothing local, but the folder Plex reports
+// (once unmapped) is the existing artist's directory, so the existing row is
+// attached instead of a duplicate being created.
+func TestPopulateFromPlex_MatchesExistingArtistByPath(t *testing.T) {
+	t.Parallel()
+	hostRoot, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("resolving temp dir symlinks: %v", err)
+	}
+	artistDir := filepath.Join(hostRoot, "Radiohead")
+	if err := os.Mkdir(artistDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	srv := plexSectionServer(t, fmt.Sprintf(`{"MediaContainer":{"totalSize":1,"Metadata":[{
+		"ratingKey":"42","title":"Radiohead (UK)","Location":[{"path":%q}]
+	}]}}`, "/data/music/Radiohead"))
+
+	r := testRouterForLibraryOps(t)
+	ctx := context.Background()
+	conn, lib := plexConnectionAndLibrary(t, r, srv.URL, hostRoot)
+
+	existing := &artist.Artist{Name: "Radiohead", SortName: "Radiohead", Path: artistDir}
+	if err := r.artistService.Create(ctx, existing); err != nil {
+		t.Fatalf("creating artist: %v", err)
+	}
+
+	var result populateResult
+	if err := r.populateFromPlexCtx(ctx, newTestPlexClient(srv), conn, lib, &result); err != nil {
+		t.Fatalf("populateFromPlexCtx: %v", err)
+	}
+	if result.Created != 0 {
+		t.Fatalf("created = %d, want 0: the folder match should attach the existing artist", result.Created)
+	}
+	if pid, _ := r.artistService.GetPlatformID(ctx, existing.ID, conn.ID); pid != "42" {
+		t.Errorf("platform id = %q, want 42", pid)
+	}
+	assertArtistInLibrary(t, r, ctx, existing.ID, lib.ID)
+}
+
+func TestScanFromPlex_MapsByPathThenName(t *testing.T) {
+	t.Parallel()
+	hostRoot, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("resolving temp dir symlinks: %v", err)
+	}
+	srv := plexSectionServer(t, `{"MediaContainer":{"totalSize":3,"Metadata":[
+		{"ratingKey":"1","title":"Plex Spelling","Location":[{"path":"/data/music/Portishead"}]},
+		{"ratingKey":"2","title":"Massive Attack"},
+		{"ratingKey":"3","title":"Unknown To Stillwater"}
+	]}}`)
+
+	r := testRouterForLibraryOps(t)
+	ctx := context.Background()
+	conn, lib := plexConnectionAndLibrary(t, r, srv.URL, hostRoot)
+
+	byPath := &artist.Artist{Name: "Portishead", SortName: "Portishead", Path: filepath.Join(hostRoot, "Portishead")}
+	byName := &artist.Artist{Name: "Massive Attack", SortName: "Massive Attack"}
+	for _, a := range []*artist.Artist{byPath, byName} {
+		if err := r.artistService.Create(ctx, a); err != nil {
+			t.Fatalf("creating artist %s: %v", a.Name, err)
+		}
+	}
+
+	mapped, err := r.scanFromPlex(ctx, newTestPlexClient(srv), conn, lib)
+	if err != nil {
+		t.Fatalf("scanFromPlex: %v", err)
+	}
+	if mapped != 2 {
+		t.Errorf("mapped = %d, want 2", mapped)
+	}
+	if pid, _ := r.artistService.GetPlatformID(ctx, byPath.ID, conn.ID); pid != "1" {
+		t.Errorf("path-matched platform id = %q, want 1", pid)
+	}
+	if pid, _ := r.artistService.GetPlatformID(ctx, byName.ID, conn.ID); pid != "2" {
+		t.Errorf("name-matched platform id = %q, want 2", pid)
+	}
+}
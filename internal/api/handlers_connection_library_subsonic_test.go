@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/connection"
+	"github.com/sydlexius/stillwater/internal/connection/subsonic"
+	"github.com/sydlexius/stillwater/internal/library"
+)
+
+// subsonicFolderServer is a fake Subsonic server whose music folder 1 lists
+// the artist index in artists (the members of the "artists" object).
+func subsonicFolderServer(t *testing.T, artists string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/getArtists.view" || r.URL.Query().Get("musicFolderId") != "1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"subsonic-response":{"status":"ok","version":"1.16.1","artists":` + artists + `}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// subsonicConnectionAndLibrary creates a Subsonic connection plus an imported
+// library for its music folder 1. Subsonic reports no artist folders, so the
+// library has no path.
+func subsonicConnectionAndLibrary(t *testing.T, r *Router, srvURL string) (*connection.Connection, *library.Library) {
+	t.Helper()
+	ctx := context.Background()
+	conn := &connection.Connection{
+		Name:    "Navidrome",
+		Type:    connection.TypeSubsonic,
+		URL:     srvURL,
+		APIKey:  "alice:secret",
+		Enabled: true,
+		Status:  "ok",
+	}
+	if err := r.connectionService.Create(ctx, conn); err != nil {
+		t.Fatalf("creating connection: %v", err)
+	}
+	lib := &library.Library{
+		Name:         "Navidrome Music",
+		Type:         library.TypeRegular,
+		Source:       library.SourceSubsonic,
+		ConnectionID: conn.ID,
+		ExternalID:   "1",
+	}
+	if err := r.libraryService.Create(ctx, lib); err != nil {
+		t.Fatalf("creating library: %v", err)
+	}
+	return conn, lib
+}
+
+func newTestSubsonicClient(srv *httptest.Server) *subsonic.Client {
+	return subsonic.NewWithHTTPClient(srv.URL, "alice:secret", srv.Client(),
+		slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
+}
+
+// TestPopulateFromSubsonic_CreatesAndMatches covers both import arms: an
+// artist matched by MBID is attached (and its Subsonic ID recorded) rather
+// than duplicated, and an unknown one is created from the name, sort name
+// and MBID Subsonic reports.
+func TestPopulateFromSubsonic_CreatesAndMatches(t *testing.T) {
+	t.Parallel()
+	srv := subsonicFolderServer(t, `{"index":[
+		{"name":"B","artist":[{"id":"ar-1","name":"Björk","sortName":"bjork","musicBrainzId":"87c5dedd-371d-4571-9e1c-45f6e0ed3fce"}]},
+		{"name":"R","artist":[{"id":"ar-2","name":"Radiohead (UK)","musicBrainzId":"a74b1b7f-71a5-4011-9441-d0b5e4122711"}]}
+	]}`)
+
+	r := testRouterForLibraryOps(t)
+	ctx := context.Background()
+	conn, lib := subsonicConnectionAndLibrary(t, r, srv.URL)
+
+	existing := &artist.Artist{Name: "Radiohead", SortName: "Radiohead", MusicBrainzID: "a74b1b7f-71a5-4011-9441-d0b5e4122711"}
+	if err := r.artistService.Create(ctx, existing); err != nil {
+		t.Fatalf("creating artist: %v", err)
+	}
+
+	var result populateResult
+	if err := r.populateFromSubsonicCtx(ctx, newTestSubsonicClient(srv), lib, &result); err != nil {
+		t.Fatalf("populateFromSubsonicCtx: %v", err)
+	}
+	if result.Total != 2 || result.Created != 1 {
+		t.Fatalf("total/created = %d/%d, want 2/1", result.Total, result.Created)
+	}
+
+	created, err := r.artistService.GetByMBID(ctx, "87c5dedd-371d-4571-9e1c-45f6e0ed3fce")
+	if err != nil || created == nil {
+		t.Fatalf("looking up created artist: %v", err)
+	}
+	if created.Name != "Björk" || created.SortName != "bjork" {
+		t.Errorf("artist = %q/%q, want the Subsonic name and sort name", created.Name, created.SortName)
+	}
+	if pid, _ := r.artistService.GetPlatformID(ctx, created.ID, conn.ID); pid != "ar-1" {
+		t.Errorf("created platform id = %q, want ar-1", pid)
+	}
+	if pid, _ := r.artistService.GetPlatformID(ctx, existing.ID, conn.ID); pid != "ar-2" {
+		t.Errorf("matched platform id = %q, want ar-2", pid)
+	}
+	assertArtistInLibrary(t, r, ctx, existing.ID, lib.ID)
+}
+
+func TestScanFromSubsonic_MapsByMBIDThenName(t *testing.T) {
+	t.Parallel()
+	srv := subsonicFolderServer(t, `{"index":[{"name":"M","artist":[
+		{"id":"ar-1","name":"Other Spelling","musicBrainzId":"8f6bd1e4-fbe1-4f50-aa9b-94c450ec0f11"},
+		{"id":"ar-2","name":"Massive Attack"},
+		{"id":"ar-3","name":"Unknown To Stillwater"}
+	]}]}`)
+
+	r := testRouterForLibraryOps(t)
+	ctx := context.Background()
+	conn, lib := subsonicConnectionAndLibrary(t, r, srv.URL)
+
+	byMBID := &artist.Artist{Name: "Portishead", SortName: "Portishead", MusicBrainzID: "8f6bd1e4-fbe1-4f50-aa9b-94c450ec0f11"}
+	byName := &artist.Artist{Name: "Massive Attack", SortName: "Massive Attack"}
+	for _, a := range []*artist.Artist{byMBID, byName} {
+		if err := r.artistService.Create(ctx, a); err != nil {
+			t.Fatalf("creating artist %s: %v", a.Name, err)
+		}
+	}
+
+	mapped, err := r.scanFromSubsonic(ctx, newTestSubsonicClient(srv), lib)
+	if err != nil {
+		t.Fatalf("scanFromSubsonic: %v", err)
+	}
+	if mapped != 2 {
+		t.Errorf("mapped = %d, want 2", mapped)
+	}
+	if pid, _ := r.artistService.GetPlatformID(ctx, byMBID.ID, conn.ID); pid != "ar-1" {
+		t.Errorf("MBID-matched platform id = %q, want ar-1", pid)
+	}
+	if pid, _ := r.artistService.GetPlatformID(ctx, byName.ID, conn.ID); pid != "ar-2" {
+		t.Errorf("name-matched platform id = %q, want ar-2", pid)
+	}
+}
@@ -30,8 +30,9 @@ type FixAllProgress struct {
 }
 
 // handleFixViolation applies the recommended fix for a single violation.
-// On success, registers an undo entry in the in-memory UndoStore and returns
-// its ID so the caller can present an Undo button to the user.
+// On success, saves an undo record in the UndoStore and returns its ID so the
+// caller can present an Undo button to the user. The record outlives the
+// button: it stays revertable from the artist's undo history.
 // POST /api/v1/notifications/{id}/fix
 func (r *Router) handleFixViolation(w http.ResponseWriter, req *http.Request) {
 	id, ok := RequirePathParam(w, req, "id")
@@ -45,7 +46,7 @@ func (r *Router) handleFixViolation(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	// Capture pre-fix state so the fix can be reverted within the undo window.
+	// Capture pre-fix state so the fix can be reverted later.
 	// Short-circuit when undo is disabled (undoStore is nil).
 	var pfs *preFixState
 	if r.undoStore != nil {
@@ -77,27 +78,10 @@ func (r *Router) handleFixViolation(w http.ResponseWriter, req *http.Request) {
 		"message": fr.Message,
 	}
 
-	// Register an undo entry when the fix succeeded and we have pre-fix state.
+	// Save an undo record when the fix succeeded and changed something the
+	// pre-fix capture covers.
 	if fr.Fixed && r.undoStore != nil && pfs != nil {
-		revert := pfs.revert
-		// Directory renames: freeze the post-fix path NOW rather than lazily
-		// at revert time, so a second rename during the undo window cannot
-		// corrupt the revert target.
-		if pfs.dirRenameOldPath != "" {
-			current, aErr := r.artistService.GetByID(req.Context(), pfs.artistID)
-			switch {
-			case aErr != nil:
-				r.logger.Warn("undo: could not freeze post-fix path for directory rename; undo unavailable",
-					"artist_id", pfs.artistID, "error", aErr)
-			case current.Path == pfs.dirRenameOldPath:
-				r.logger.Debug("undo: directory path unchanged after fix; no revert needed",
-					"artist_id", pfs.artistID)
-			default:
-				revert = rule.DirectoryRenameRevert(pfs.dirRenameOldPath, current.Path)
-			}
-		}
-		if revert != nil {
-			undoID := r.undoStore.Register(id, revert)
+		if undoID := r.saveFixUndo(req.Context(), id, pfs, fr.Message); undoID != "" {
 			resp["undo_id"] = undoID
 			resp["undo_expires_in"] = int(rule.UndoWindowDuration.Seconds())
 		}
@@ -137,22 +121,19 @@ func (r *Router) handleFixViolation(w http.ResponseWriter, req *http.Request) {
 	writeJSON(w, http.StatusOK, resp)
 }
 
-// preFixState holds the pre-fix snapshot context needed to register an undo.
-// For most rules, revert contains the full revert function. For directory
-// renames, dirRenameOldPath is set instead and revert is nil; the caller
-// MUST populate revert by freezing both old and new paths after the fix
-// succeeds (see handleFixViolation). This avoids lazy path resolution that
-// could be corrupted by a second rename during the undo window.
+// preFixState holds the pre-fix capture needed to save an undo record.
+// The post-fix side (changed fields, new file hashes, a renamed directory) is
+// taken by saveFixUndo once the fix has run, so both ends of a directory
+// rename are frozen at fix time rather than resolved lazily at revert time.
 type preFixState struct {
-	revert           rule.RevertFunc
-	dirRenameOldPath string
-	artistID         string
+	capture  *rule.UndoCapture
+	artistID string
+	ruleID   string
 }
 
 // capturePreFixState loads the violation and the associated artist, then
-// builds a preFixState that can restore pre-fix state. Returns nil when no
-// relevant files are found (e.g. for pure metadata fixes that do not touch
-// the filesystem).
+// captures the artist's fields and the files the rule's fixer is expected to
+// touch. Returns nil when the violation or artist cannot be loaded.
 //
 // Errors during snapshot capture are logged and silently ignored so that a
 // snapshot failure does not block the fix itself.
@@ -173,26 +154,47 @@ func (r *Router) capturePreFixState(ctx context.Context, violationID string) *pr
 			"artist_id", rv.ArtistID, "error", err)
 		return nil
 	}
-	if a.Path == "" {
-		// Pathless artists have no on-disk files to snapshot.
-		return nil
+	// Pathless artists have no on-disk files to snapshot, and a directory
+	// rename needs no file snapshot: the capture records the path itself.
+	var snaps []rule.FileSnapshot
+	if a.Path != "" && rv.RuleID != rule.RuleDirectoryNameMismatch {
+		snaps = captureFilesForRule(ctx, a, rv.RuleID, r)
 	}
-
-	// Directory rename: capture the old path now. The post-fix path is
-	// frozen in handleFixViolation after FixViolation succeeds, so that
-	// a second rename during the undo window cannot corrupt the target.
-	if rv.RuleID == rule.RuleDirectoryNameMismatch {
-		return &preFixState{
-			dirRenameOldPath: a.Path,
-			artistID:         a.ID,
-		}
+	return &preFixState{
+		capture:  rule.CaptureUndo(a, snaps),
+		artistID: a.ID,
+		ruleID:   rv.RuleID,
 	}
+}
 
-	snaps := captureFilesForRule(ctx, a, rv.RuleID, r)
-	if len(snaps) == 0 {
-		return nil
+// saveFixUndo diffs the artist against its pre-fix capture and saves the
+// result as an undo record, returning its ID. It returns "" when the fix
+// changed nothing the capture covers, or when the record could not be built
+// or saved; like the capture, a failure here never fails the fix.
+func (r *Router) saveFixUndo(ctx context.Context, violationID string, pfs *preFixState, summary string) string {
+	after, err := r.artistService.GetByID(ctx, pfs.artistID)
+	if err != nil {
+		r.logger.Warn("undo: could not load artist after fix; undo unavailable",
+			"artist_id", pfs.artistID, "error", err)
+		return ""
+	}
+	rec, err := pfs.capture.Finish(after)
+	if err != nil {
+		r.logger.Warn("undo: could not capture post-fix state; undo unavailable",
+			"artist_id", pfs.artistID, "error", err)
+		return ""
+	}
+	if rec == nil {
+		return ""
+	}
+	rec.ViolationID = violationID
+	rec.RuleID = pfs.ruleID
+	rec.Summary = summary
+	if err := r.undoStore.Save(ctx, rec); err != nil {
+		r.logger.Warn("undo: could not save undo record", "artist_id", pfs.artistID, "error", err)
+		return ""
 	}
-	return &preFixState{revert: rule.MultiFileRevert(snaps)}
+	return rec.ID
 }
 
 // captureFilesForRule resolves the file paths that a given rule's fixer is
@@ -255,7 +257,11 @@ func captureImageFiles(ctx context.Context, dir, imageType string, r *Router) []
 	return snaps
 }
 
-// handleUndoFix reverts a recently applied fix using the registered undo entry.
+// handleUndoFix reverts an applied change using its durable undo record:
+// the one a fix just returned, or any record from an artist's or bulk job's
+// undo history. 410 means the record is gone (pruned, never existed) or was
+// already reverted; 409 means a later change touched the same field, file or
+// directory, and nothing was modified.
 // POST /api/v1/fix-undo/{undoId}
 func (r *Router) handleUndoFix(w http.ResponseWriter, req *http.Request) {
 	undoID, ok := RequirePathParam(w, req, "undoId")
@@ -268,30 +274,33 @@ func (r *Router) handleUndoFix(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	entry, ok := r.undoStore.Pop(undoID)
-	if !ok {
-		// Either the undo ID was never registered, was already used, or expired.
-		writeError(w, req, http.StatusGone, "undo window expired or undo already applied")
-		return
-	}
-
 	// Use a context that survives client disconnect but has a bounded
-	// deadline. Pop() already consumed the single-use token, so if the
-	// client disconnects mid-revert there is no retry path. WithoutCancel
-	// prevents premature cancellation; the 30s timeout prevents hangs on
-	// stuck filesystems or DB connections during server shutdown.
+	// deadline, so a revert the client walks away from still finishes (or
+	// releases its claim) instead of stopping between two files. The 30s
+	// timeout prevents hangs on stuck filesystems or DB connections during
+	// server shutdown.
 	ctx, cancel := context.WithTimeout(context.WithoutCancel(req.Context()), 30*time.Second)
 	defer cancel()
 
-	if err := entry.Revert(ctx); err != nil {
-		r.logger.Error("undo fix failed", "violation_id", entry.ViolationID, "error", err)
-		writeError(w, req, http.StatusInternalServerError, "failed to revert fix")
+	entry, err := r.undoStore.Revert(ctx, undoID)
+	if err != nil {
+		var conflict *rule.UndoConflictError
+		switch {
+		case errors.Is(err, rule.ErrUndoNotFound), errors.Is(err, rule.ErrUndoReverted):
+			writeError(w, req, http.StatusGone, "undo record not found or already reverted")
+		case errors.As(err, &conflict):
+			writeError(w, req, http.StatusConflict, conflict.Error())
+		default:
+			r.logger.Error("undo fix failed", "undo_id", undoID, "error", err)
+			writeError(w, req, http.StatusInternalServerError, "failed to revert fix")
+		}
 		return
 	}
 
-	// Reopen the violation so it appears as fixable again.
+	// Reopen the violation so it appears as fixable again. Bulk job records
+	// have no violation to reopen.
 	var reopenFailed bool
-	if r.ruleService != nil {
+	if r.ruleService != nil && entry.ViolationID != "" {
 		if reopenErr := r.ruleService.ReopenViolation(ctx, entry.ViolationID); reopenErr != nil {
 			r.logger.Error("undo: failed to reopen violation after successful revert",
 				"id", entry.ViolationID, "error", reopenErr)
@@ -299,7 +308,7 @@ func (r *Router) handleUndoFix(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 
-	r.logger.Info("fix reverted", "violation_id", entry.ViolationID)
+	r.logger.Info("fix reverted", "undo_id", entry.ID, "violation_id", entry.ViolationID, "artist_id", entry.ArtistID)
 
 	// Undoing a fix restores pre-fix state, changing health scores.
 	r.InvalidateHealthCache()
@@ -15,12 +15,49 @@ import (
 	"github.com/sydlexius/stillwater/internal/rule"
 )
 
-// noopRevert is a RevertFunc that records whether it was called.
-type noopRevert struct{ called bool }
+// seedUndoRecord creates an artist, gives it a biography, and saves the undo
+// record that clears the biography again: the shape a metadata fix leaves
+// behind. It returns the record ID and the artist.
+func seedUndoRecord(t *testing.T, r *Router, svc *artist.Service, name, violationID string) (string, *artist.Artist) {
+	t.Helper()
+	ctx := context.Background()
+	a := addTestArtist(t, svc, name)
+	if _, err := svc.UpdateField(ctx, a.ID, "biography", "Written by the fix."); err != nil {
+		t.Fatalf("setting biography: %v", err)
+	}
+	rec := &rule.UndoRecord{
+		ArtistID:    a.ID,
+		ViolationID: violationID,
+		Summary:     "test fix",
+		Fields:      []rule.FieldChange{{Field: "biography", NewValue: "Written by the fix."}},
+	}
+	if err := r.undoStore.Save(ctx, rec); err != nil {
+		t.Fatalf("saving undo record: %v", err)
+	}
+	return rec.ID, a
+}
+
+// biographyOf returns the stored biography of artist id.
+func biographyOf(t *testing.T, svc *artist.Service, id string) string {
+	t.Helper()
+	a, err := svc.GetByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("loading artist: %v", err)
+	}
+	return a.Biography
+}
 
-func (n *noopRevert) fn(_ context.Context) error {
-	n.called = true
-	return nil
+// writeBiographyFix returns a fixViolationFn that behaves like a metadata
+// fix: it writes a biography to the violation's artist, so the handler has
+// a change to record for undo. svc is read when the fix runs, letting the
+// stub be built before the router that owns the service.
+func writeBiographyFix(svc **artist.Service, artistID *string) func(context.Context, string) (*rule.FixResult, error) {
+	return func(ctx context.Context, _ string) (*rule.FixResult, error) {
+		if _, err := (*svc).UpdateField(ctx, *artistID, "biography", "Fetched biography."); err != nil {
+			return nil, err
+		}
+		return &rule.FixResult{RuleID: rule.RuleBioExists, Fixed: true, Message: "biography fetched"}, nil
+	}
 }
 
 func TestHandleFixViolation_Success(t *testing.T) {
@@ -338,11 +375,9 @@ func TestHandleFixAll_Completion(t *testing.T) {
 func TestHandleUndoFix_Success(t *testing.T) {
 	t.Parallel()
 	stub := &stubPipeline{}
-	r, _ := testRouterWithStubPipeline(t, stub)
+	r, artistSvc := testRouterWithStubPipeline(t, stub)
 
-	// Register a noop undo entry directly into the store.
-	nr := &noopRevert{}
-	undoID := r.undoStore.Register("v-undo-1", nr.fn)
+	undoID, a := seedUndoRecord(t, r, artistSvc, "Undo Success Artist", "v-undo-1")
 
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/fix-undo/"+undoID, nil)
 	req.SetPathValue("undoId", undoID)
@@ -353,8 +388,8 @@ func TestHandleUndoFix_Success(t *testing.T) {
 	if w.Code != http.StatusOK {
 		t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusOK, w.Body.String())
 	}
-	if !nr.called {
-		t.Error("expected revert function to have been called")
+	if got := biographyOf(t, artistSvc, a.ID); got != "" {
+		t.Errorf("biography after undo = %q, want it cleared", got)
 	}
 
 	var resp map[string]any
@@ -366,15 +401,16 @@ func TestHandleUndoFix_Success(t *testing.T) {
 	}
 }
 
-func TestHandleUndoFix_Expired(t *testing.T) {
+// TestHandleUndoFix_SurvivesRestart checks that an undo record saved by one
+// store instance is revertable through another, as after a server restart.
+// The old in-memory store lost every token on restart.
+func TestHandleUndoFix_SurvivesRestart(t *testing.T) {
 	t.Parallel()
 	stub := &stubPipeline{}
-	r, _ := testRouterWithStubPipeline(t, stub)
+	r, artistSvc := testRouterWithStubPipeline(t, stub)
 
-	// Register an entry and then expire it manually.
-	nr := &noopRevert{}
-	undoID := r.undoStore.Register("v-undo-exp", nr.fn)
-	r.undoStore.ForceExpire(undoID)
+	undoID, a := seedUndoRecord(t, r, artistSvc, "Undo Restart Artist", "v-undo-restart")
+	r.undoStore = rule.NewUndoStore(r.db, artistSvc)
 
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/fix-undo/"+undoID, nil)
 	req.SetPathValue("undoId", undoID)
@@ -382,11 +418,38 @@ func TestHandleUndoFix_Expired(t *testing.T) {
 
 	r.handleUndoFix(w, req)
 
-	if w.Code != http.StatusGone {
-		t.Errorf("status = %d, want %d (Gone)", w.Code, http.StatusGone)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusOK, w.Body.String())
 	}
-	if nr.called {
-		t.Error("expected revert function NOT to have been called for expired entry")
+	if got := biographyOf(t, artistSvc, a.ID); got != "" {
+		t.Errorf("biography after undo = %q, want it cleared", got)
+	}
+}
+
+// TestHandleUndoFix_ConflictWithLaterChange checks that a record is refused
+// with 409, and nothing is written, once the field it restores was changed
+// again after the fix.
+func TestHandleUndoFix_ConflictWithLaterChange(t *testing.T) {
+	t.Parallel()
+	stub := &stubPipeline{}
+	r, artistSvc := testRouterWithStubPipeline(t, stub)
+
+	undoID, a := seedUndoRecord(t, r, artistSvc, "Undo Conflict Artist", "v-undo-conflict")
+	if _, err := artistSvc.UpdateField(context.Background(), a.ID, "biography", "Edited by hand."); err != nil {
+		t.Fatalf("editing biography: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/fix-undo/"+undoID, nil)
+	req.SetPathValue("undoId", undoID)
+	w := httptest.NewRecorder()
+
+	r.handleUndoFix(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusConflict, w.Body.String())
+	}
+	if got := biographyOf(t, artistSvc, a.ID); got != "Edited by hand." {
+		t.Errorf("biography after refused undo = %q, want the later edit kept", got)
 	}
 }
 
@@ -409,10 +472,9 @@ func TestHandleUndoFix_NotFound(t *testing.T) {
 func TestHandleUndoFix_AlreadyUsed(t *testing.T) {
 	t.Parallel()
 	stub := &stubPipeline{}
-	r, _ := testRouterWithStubPipeline(t, stub)
+	r, artistSvc := testRouterWithStubPipeline(t, stub)
 
-	nr := &noopRevert{}
-	undoID := r.undoStore.Register("v-undo-used", nr.fn)
+	undoID, _ := seedUndoRecord(t, r, artistSvc, "Undo Used Artist", "v-undo-used")
 
 	// First call: succeeds.
 	req1 := httptest.NewRequest(http.MethodPost, "/api/v1/fix-undo/"+undoID, nil)
@@ -423,7 +485,7 @@ func TestHandleUndoFix_AlreadyUsed(t *testing.T) {
 		t.Fatalf("first undo: status = %d, want %d", w1.Code, http.StatusOK)
 	}
 
-	// Second call: undo already consumed.
+	// Second call: record already reverted.
 	req2 := httptest.NewRequest(http.MethodPost, "/api/v1/fix-undo/"+undoID, nil)
 	req2.SetPathValue("undoId", undoID)
 	w2 := httptest.NewRecorder()
@@ -436,13 +498,12 @@ func TestHandleUndoFix_AlreadyUsed(t *testing.T) {
 func TestHandleUndoFix_ConcurrentSameID(t *testing.T) {
 	t.Parallel()
 	// Two goroutines calling undo with the same ID concurrently: exactly one
-	// should get 200 and the other should get 410. The UndoStore.Pop is
-	// atomic (mutex-protected), so this verifies no double-revert.
+	// should get 200 and the other should get 410. The revert claims the
+	// record with a conditional UPDATE, so this verifies no double-revert.
 	stub := &stubPipeline{}
-	r, _ := testRouterWithStubPipeline(t, stub)
+	r, artistSvc := testRouterWithStubPipeline(t, stub)
 
-	nr := &noopRevert{}
-	undoID := r.undoStore.Register("v-concurrent-undo", nr.fn)
+	undoID, _ := seedUndoRecord(t, r, artistSvc, "Undo Concurrent Artist", "v-concurrent-undo")
 
 	var wg sync.WaitGroup
 	codes := make([]int, 2)
@@ -482,8 +543,8 @@ func TestHandleUndoFix_ConcurrentSameID(t *testing.T) {
 
 func TestHandleFixViolation_NoUndo_PathlessArtist(t *testing.T) {
 	t.Parallel()
-	// A fix that succeeds for a pathless artist (no on-disk directory) should
-	// not return undo_id because there are no files to snapshot or revert.
+	// A fix that succeeds for a pathless artist (no on-disk directory) and
+	// changes no field should not return undo_id: there is nothing to revert.
 	stub := &stubPipeline{
 		fixViolationFn: func(_ context.Context, _ string) (*rule.FixResult, error) {
 			return &rule.FixResult{RuleID: "nfo_exists", Fixed: true, Message: "NFO created"}, nil
@@ -549,16 +610,15 @@ func TestHandleFixViolation_NoUndo_PathlessArtist(t *testing.T) {
 
 func TestHandleFixViolation_ReturnsUndoID(t *testing.T) {
 	t.Parallel()
-	// A fix that succeeds for an artist with a path should return undo_id
-	// and undo_expires_in in the response.
-	stub := &stubPipeline{
-		fixViolationFn: func(_ context.Context, _ string) (*rule.FixResult, error) {
-			return &rule.FixResult{RuleID: "nfo_exists", Fixed: true, Message: "NFO created"}, nil
-		},
-	}
+	// A fix that changes the artist should return undo_id and
+	// undo_expires_in in the response, and the record should revert it.
+	var artistSvc *artist.Service
+	var artistID string
+	stub := &stubPipeline{fixViolationFn: writeBiographyFix(&artistSvc, &artistID)}
 	r, artistSvc := testRouterWithStubPipeline(t, stub)
 
 	a := addTestArtist(t, artistSvc, "Undo Test Artist")
+	artistID = a.ID
 
 	// Seed a violation for the artist so capturePreFixState can look it up.
 	v := &rule.RuleViolation{
@@ -597,14 +657,23 @@ func TestHandleFixViolation_ReturnsUndoID(t *testing.T) {
 	if resp["status"] != "fixed" {
 		t.Errorf("status = %v, want fixed", resp["status"])
 	}
-	if resp["undo_id"] == nil {
-		t.Error("expected undo_id to be present for path-bearing artist fix")
+	undoID, _ := resp["undo_id"].(string)
+	if undoID == "" {
+		t.Fatal("expected undo_id to be present for a fix that changed the artist")
 	}
 	if resp["undo_expires_in"] == nil {
-		t.Error("expected undo_expires_in to be present for path-bearing artist fix")
+		t.Error("expected undo_expires_in to be present alongside undo_id")
 	} else if resp["undo_expires_in"] != float64(int(rule.UndoWindowDuration.Seconds())) {
 		t.Errorf("undo_expires_in = %v, want %v", resp["undo_expires_in"], rule.UndoWindowDuration.Seconds())
 	}
+
+	rec, err := r.undoStore.Get(context.Background(), undoID)
+	if err != nil {
+		t.Fatalf("loading undo record: %v", err)
+	}
+	if rec.ViolationID != violationID || len(rec.Fields) != 1 || rec.Fields[0].Field != "biography" {
+		t.Errorf("undo record = %+v, want one biography change for the violation", rec)
+	}
 }
 
 // TestHandleFixViolation_HTMX_FailureCarriesMessage pins the user-facing
@@ -657,14 +726,13 @@ func TestHandleFixViolation_HTMX_FailureCarriesMessage(t *testing.T) {
 // and does NOT set the HX-Trigger header (to avoid destroying the toast).
 func TestHandleFixViolation_HTMX_WithUndo(t *testing.T) {
 	t.Parallel()
-	stub := &stubPipeline{
-		fixViolationFn: func(_ context.Context, _ string) (*rule.FixResult, error) {
-			return &rule.FixResult{RuleID: "nfo_exists", Fixed: true, Message: "NFO created"}, nil
-		},
-	}
+	var artistSvc *artist.Service
+	var artistID string
+	stub := &stubPipeline{fixViolationFn: writeBiographyFix(&artistSvc, &artistID)}
 	r, artistSvc := testRouterWithStubPipeline(t, stub)
 
 	a := addTestArtist(t, artistSvc, "HTMX Undo Artist")
+	artistID = a.ID
 
 	v := &rule.RuleViolation{
 		RuleID:     rule.RuleNFOExists,
@@ -711,7 +779,7 @@ func TestHandleFixViolation_HTMX_WithUndo(t *testing.T) {
 }
 
 // TestHandleFixViolation_HTMX_NoUndo verifies that when a fix is applied
-// via HTMX and there is no undo entry (pathless artist), the response sets
+// via HTMX and there is no undo record (the fix changed nothing), the response sets
 // HX-Trigger to refresh the queue and returns an empty body.
 func TestHandleFixViolation_HTMX_NoUndo(t *testing.T) {
 	t.Parallel()
@@ -722,7 +790,8 @@ func TestHandleFixViolation_HTMX_NoUndo(t *testing.T) {
 	}
 	r, artistSvc := testRouterWithStubPipeline(t, stub)
 
-	// Create a pathless artist so no undo entry is registered.
+	// Create a pathless artist; the stub fix changes nothing, so no undo
+	// record is saved.
 	a := &artist.Artist{
 		Name:     "Pathless HTMX Artist",
 		SortName: "Pathless HTMX Artist",
@@ -778,10 +847,9 @@ func TestHandleFixViolation_HTMX_NoUndo(t *testing.T) {
 func TestHandleUndoFix_HTMX(t *testing.T) {
 	t.Parallel()
 	stub := &stubPipeline{}
-	r, _ := testRouterWithStubPipeline(t, stub)
+	r, artistSvc := testRouterWithStubPipeline(t, stub)
 
-	nr := &noopRevert{}
-	undoID := r.undoStore.Register("v-undo-htmx", nr.fn)
+	undoID, a := seedUndoRecord(t, r, artistSvc, "HTMX Revert Artist", "v-undo-htmx")
 
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/fix-undo/"+undoID, nil)
 	req.SetPathValue("undoId", undoID)
@@ -793,8 +861,8 @@ func TestHandleUndoFix_HTMX(t *testing.T) {
 	if w.Code != http.StatusOK {
 		t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusOK, w.Body.String())
 	}
-	if !nr.called {
-		t.Error("expected revert function to have been called")
+	if got := biographyOf(t, artistSvc, a.ID); got != "" {
+		t.Errorf("biography after undo = %q, want it cleared", got)
 	}
 
 	// HX-Trigger must be set to refresh the action queue.
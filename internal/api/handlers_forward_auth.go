@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/sydlexius/stillwater/internal/auth"
+)
+
+// forwardAuthProvider returns the forward-auth provider when one is
+// registered (SW_AUTH_FORWARD_USER_HEADER is set), otherwise nil.
+func (r *Router) forwardAuthProvider() *auth.ForwardAuthProvider {
+	if r.authRegistry == nil {
+		return nil
+	}
+	provider, ok := r.authRegistry.Get("forward")
+	if !ok {
+		return nil
+	}
+	fp, _ := provider.(*auth.ForwardAuthProvider)
+	return fp
+}
+
+// redirectToForwardLogin sends a visitor who reached the login page with a
+// proxy-asserted identity on to handleForwardLogin, carrying the page they
+// wanted. It returns false, writing nothing, when forward auth is off, the
+// request carries no identity from a trusted proxy, or the login page is
+// showing an error (a failed forward login lands there, and redirecting
+// again would loop).
+func (r *Router) redirectToForwardLogin(w http.ResponseWriter, req *http.Request, returnTo string) bool {
+	provider := r.forwardAuthProvider()
+	if provider == nil || !provider.HasIdentity(req) || req.URL.Query().Has("error") {
+		return false
+	}
+	target := r.basePath + "/api/v1/auth/forward/login"
+	if returnTo != "" {
+		target += "?return_url=" + url.QueryEscape(returnTo)
+	}
+	http.Redirect(w, req, target, http.StatusFound)
+	return true
+}
+
+// handleForwardLogin signs in the user a reverse proxy has already
+// authenticated, from the configured user header, then follows the standard
+// lookup/provisioning flow and redirects to return_url. The provider refuses
+// the header unless the request came directly from a trusted proxy.
+// GET /api/v1/auth/forward/login
+func (r *Router) handleForwardLogin(w http.ResponseWriter, req *http.Request) {
+	provider := r.forwardAuthProvider()
+	if provider == nil {
+		r.redirectWithError(w, req, "Forward authentication is not configured.")
+		return
+	}
+
+	identity, err := provider.Authenticate(req.Context(), provider.Credentials(req))
+	if err != nil {
+		r.logger.Warn("forward authentication failed", "remote_addr", req.RemoteAddr, "error", err)
+		r.redirectWithError(w, req, "Your reverse proxy did not identify you. Please sign in.")
+		return
+	}
+
+	r.completeLoginRedirect(w, req, provider, identity)
+}
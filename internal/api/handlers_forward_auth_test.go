@@ -0,0 +1,173 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/auth"
+)
+
+// testRouterWithForwardAuth returns a router with a forward-auth provider
+// trusting 10.0.0.0/8 and mapping the "admins" group to administrator.
+func testRouterWithForwardAuth(t *testing.T, autoProvision bool) *Router {
+	t.Helper()
+	r, _, _ := testRouterWithAuth(t)
+	p, err := auth.NewForwardAuthProvider(auth.ForwardAuthConfig{
+		UserHeader:     "Remote-User",
+		GroupsHeader:   "Remote-Groups",
+		TrustedProxies: []string{"10.0.0.0/8"},
+		AdminGroups:    []string{"admins"},
+		AutoProvision:  autoProvision,
+	})
+	if err != nil {
+		t.Fatalf("NewForwardAuthProvider: %v", err)
+	}
+	registry := auth.NewRegistry()
+	registry.Register(auth.NewLocalProvider(r.db))
+	registry.Register(p)
+	r.authRegistry = registry
+	return r
+}
+
+// forwardRequest builds a request as the proxy would forward it.
+func forwardRequest(target, remoteAddr, user, groups string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.RemoteAddr = remoteAddr
+	if user != "" {
+		req.Header.Set("Remote-User", user)
+	}
+	if groups != "" {
+		req.Header.Set("Remote-Groups", groups)
+	}
+	return req
+}
+
+func TestHandleForwardLogin_AutoProvisionsFromTrustedProxy(t *testing.T) {
+	t.Parallel()
+	r := testRouterWithForwardAuth(t, true)
+
+	w := httptest.NewRecorder()
+	r.handleForwardLogin(w, forwardRequest("/api/v1/auth/forward/login?return_url=/reports", "10.0.0.5:41000", "alice", "users|admins"))
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("status = %d, want 302: %s", w.Code, w.Body.String())
+	}
+	if loc := w.Header().Get("Location"); loc != "/reports" {
+		t.Errorf("Location = %q, want /reports", loc)
+	}
+	if !hasSessionCookie(w) {
+		t.Fatal("expected a session cookie")
+	}
+	var role string
+	if err := r.db.QueryRow("SELECT role FROM users WHERE auth_provider = 'forward' AND provider_id = 'alice'").Scan(&role); err != nil {
+		t.Fatalf("querying provisioned user: %v", err)
+	}
+	if role != "administrator" {
+		t.Errorf("role = %q, want administrator (admins group)", role)
+	}
+
+	// A second sign-in reuses the account.
+	w = httptest.NewRecorder()
+	r.handleForwardLogin(w, forwardRequest("/api/v1/auth/forward/login", "10.0.0.5:41000", "alice", "admins"))
+	if w.Code != http.StatusFound || !hasSessionCookie(w) {
+		t.Fatalf("second sign-in: status = %d, session = %v", w.Code, hasSessionCookie(w))
+	}
+	var n int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM users WHERE auth_provider = 'forward'").Scan(&n); err != nil {
+		t.Fatalf("counting users: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("forward users = %d, want 1", n)
+	}
+}
+
+func TestHandleForwardLogin_RefusesUntrustedPeer(t *testing.T) {
+	t.Parallel()
+	r := testRouterWithForwardAuth(t, true)
+
+	w := httptest.NewRecorder()
+	r.handleForwardLogin(w, forwardRequest("/api/v1/auth/forward/login", "192.168.1.20:41000", "alice", "admins"))
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("status = %d, want 302", w.Code)
+	}
+	if loc := w.Header().Get("Location"); !strings.Contains(loc, "error=") {
+		t.Errorf("Location = %q, want a login error redirect", loc)
+	}
+	if hasSessionCookie(w) {
+		t.Error("untrusted peer must not get a session")
+	}
+	var n int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM users WHERE auth_provider = 'forward'").Scan(&n); err != nil {
+		t.Fatalf("counting users: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("forward users = %d, want 0", n)
+	}
+}
+
+func TestHandleForwardLogin_UnknownUserWithoutAutoProvision(t *testing.T) {
+	t.Parallel()
+	r := testRouterWithForwardAuth(t, false)
+
+	w := httptest.NewRecorder()
+	r.handleForwardLogin(w, forwardRequest("/api/v1/auth/forward/login", "10.0.0.5:41000", "mallory", ""))
+
+	if w.Code != http.StatusFound || hasSessionCookie(w) {
+		t.Fatalf("status = %d, session = %v; want an error redirect without a session", w.Code, hasSessionCookie(w))
+	}
+	if loc := w.Header().Get("Location"); !strings.Contains(loc, "error=") {
+		t.Errorf("Location = %q, want a login error redirect", loc)
+	}
+}
+
+func TestHandleForwardLogin_NotConfigured(t *testing.T) {
+	t.Parallel()
+	r, _, _ := testRouterWithAuth(t)
+
+	w := httptest.NewRecorder()
+	r.handleForwardLogin(w, forwardRequest("/api/v1/auth/forward/login", "10.0.0.5:41000", "alice", ""))
+
+	if w.Code != http.StatusFound || hasSessionCookie(w) {
+		t.Fatalf("status = %d, session = %v; want an error redirect without a session", w.Code, hasSessionCookie(w))
+	}
+}
+
+func TestRenderLoginPage_RedirectsProxiedVisitor(t *testing.T) {
+	t.Parallel()
+	r := testRouterWithForwardAuth(t, true)
+
+	tests := []struct {
+		name       string
+		target     string
+		remoteAddr string
+		user       string
+		wantLoc    string // empty means the login form renders
+	}{
+		{name: "trusted proxy with user", target: "/reports/duplicates", remoteAddr: "10.0.0.5:41000", user: "alice", wantLoc: "/api/v1/auth/forward/login?return_url=%2Freports%2Fduplicates"},
+		{name: "root keeps no return url", target: "/", remoteAddr: "10.0.0.5:41000", user: "alice", wantLoc: "/api/v1/auth/forward/login"},
+		{name: "untrusted peer", target: "/", remoteAddr: "192.168.1.20:41000", user: "alice"},
+		{name: "no header", target: "/", remoteAddr: "10.0.0.5:41000"},
+		{name: "error shown after a failed forward login", target: "/?error=nope", remoteAddr: "10.0.0.5:41000", user: "alice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.renderLoginPage(w, forwardRequest(tt.target, tt.remoteAddr, tt.user, ""))
+			if tt.wantLoc == "" {
+				if w.Code != http.StatusOK {
+					t.Errorf("status = %d, want 200 (login form)", w.Code)
+				}
+				return
+			}
+			if w.Code != http.StatusFound {
+				t.Fatalf("status = %d, want 302", w.Code)
+			}
+			if loc := w.Header().Get("Location"); loc != tt.wantLoc {
+				t.Errorf("Location = %q, want %q", loc, tt.wantLoc)
+			}
+		})
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/sydlexius/stillwater/internal/connection"
 	"github.com/sydlexius/stillwater/internal/connection/emby"
 	"github.com/sydlexius/stillwater/internal/connection/jellyfin"
+	"github.com/sydlexius/stillwater/internal/connection/kodi"
 	"github.com/sydlexius/stillwater/internal/event"
 	img "github.com/sydlexius/stillwater/internal/image"
 	"github.com/sydlexius/stillwater/internal/provider"
@@ -375,7 +376,7 @@ func (r *Router) handleImageUpload(w http.ResponseWriter, req *http.Request) {
 
 	// Fanart: append as next numbered file when fanart already exists.
 	if imageType == "fanart" && a.FanartExists {
-		saved, saveErr := r.processAndAppendFanart(req.Context(), r.newImageWriteScope(a), r.imageDir(a), data, uploadMeta)
+		saved, saveErr := r.processAndAppendFanart(img.WithRevisionContext(req.Context(), a.ID, "upload"), r.newImageWriteScope(a), r.imageDir(a), data, uploadMeta)
 		if saveErr != nil {
 			r.logger.Error("appending fanart upload", "artist_id", artistID, "error", saveErr)
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save image"})
@@ -395,7 +396,7 @@ func (r *Router) handleImageUpload(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	saved, err := r.processAndSaveImage(req.Context(), r.newImageWriteScope(a), r.imageDir(a), imageType, data, uploadMeta)
+	saved, err := r.processAndSaveImage(img.WithRevisionContext(req.Context(), a.ID, "upload"), r.newImageWriteScope(a), r.imageDir(a), imageType, data, uploadMeta)
 	if err != nil {
 		r.logger.Error("saving uploaded image", "artist_id", artistID, "error", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save image"})
@@ -465,7 +466,7 @@ func (r *Router) handleImageCropFanartSlot(w http.ResponseWriter, req *http.Requ
 	// collision check. imgData is written RAW here -- this path has no
 	// ConvertFormat step of its own (the crop already produced encoded bytes) --
 	// so imgData is exactly what the verdict must hash and what lands on disk.
-	saved, saveErr := r.saveFanartSlotChecked(req.Context(), r.newImageWriteScope(a), dir, []string{targetName}, imgData, slotMeta)
+	saved, saveErr := r.saveFanartSlotChecked(img.WithRevisionContext(req.Context(), a.ID, "crop"), r.newImageWriteScope(a), dir, []string{targetName}, imgData, slotMeta)
 	if saveErr != nil {
 		r.logger.Error("saving cropped fanart slot",
 			slog.String("artist_id", artistID), slog.Int("slot", slot), slog.String("error", saveErr.Error()))
@@ -513,7 +514,7 @@ func (r *Router) handleImageFetchFanartSlot(w http.ResponseWriter, req *http.Req
 	// ConvertFormat. So data -- not a conversion of it -- is both what the verdict
 	// hashes and what lands on disk, because the helper takes a single slice and
 	// uses it for both.
-	saved, saveErr := r.saveFanartSlotChecked(req.Context(), r.newImageWriteScope(a), dir, []string{targetName}, data, slotMeta)
+	saved, saveErr := r.saveFanartSlotChecked(img.WithRevisionContext(req.Context(), a.ID, "fetch"), r.newImageWriteScope(a), dir, []string{targetName}, data, slotMeta)
 	if saveErr != nil {
 		r.logger.Error("saving fetched fanart slot",
 			slog.String("artist_id", artistID), slog.Int("slot", slot), slog.String("error", saveErr.Error()))
@@ -705,7 +706,7 @@ func (r *Router) handleImageFetch(w http.ResponseWriter, req *http.Request) {
 
 	// Fanart: append as next numbered file when fanart already exists.
 	if imageType == "fanart" && a.FanartExists {
-		saved, saveErr := r.processAndAppendFanart(req.Context(), r.newImageWriteScope(a), r.imageDir(a), data, fetchMeta)
+		saved, saveErr := r.processAndAppendFanart(img.WithRevisionContext(req.Context(), a.ID, "fetch"), r.newImageWriteScope(a), r.imageDir(a), data, fetchMeta)
 		if saveErr != nil {
 			r.logger.Error("appending fanart image", "artist_id", artistID, "error", saveErr)
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save image"})
@@ -724,7 +725,7 @@ func (r *Router) handleImageFetch(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	saved, err := r.processAndSaveImage(req.Context(), r.newImageWriteScope(a), r.imageDir(a), imageType, data, fetchMeta)
+	saved, err := r.processAndSaveImage(img.WithRevisionContext(req.Context(), a.ID, "fetch"), r.newImageWriteScope(a), r.imageDir(a), imageType, data, fetchMeta)
 	if err != nil {
 		r.logger.Error("saving fetched image", "artist_id", artistID, "error", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save image"})
@@ -1045,7 +1046,7 @@ func (r *Router) handleImageCrop(w http.ResponseWriter, req *http.Request) {
 	// single-slot overwrite (recrop-of-primary and all non-fanart types).
 	if body.Type == "fanart" && a.FanartExists && body.Append {
 		appendMeta := &img.ExifMeta{Source: artist.ImageSourceUser, Mode: "user", Fetched: time.Now().UTC()}
-		saved, saveErr := r.processAndAppendFanart(req.Context(), r.newImageWriteScope(a), r.imageDir(a), imgData, appendMeta)
+		saved, saveErr := r.processAndAppendFanart(img.WithRevisionContext(req.Context(), a.ID, "crop"), r.newImageWriteScope(a), r.imageDir(a), imgData, appendMeta)
 		if saveErr != nil {
 			r.logger.Error("appending cropped fanart", "artist_id", artistID, "error", saveErr)
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save image"})
@@ -1079,7 +1080,7 @@ func (r *Router) handleImageCrop(w http.ResponseWriter, req *http.Request) {
 	cropMeta.Mode = "user"
 	cropMeta.DHash = "" // Force recomputation from the cropped image data.
 
-	saved, err := r.processAndSaveImage(req.Context(), r.newImageWriteScope(a), r.imageDir(a), body.Type, imgData, cropMeta)
+	saved, err := r.processAndSaveImage(img.WithRevisionContext(req.Context(), a.ID, "crop"), r.newImageWriteScope(a), r.imageDir(a), body.Type, imgData, cropMeta)
 	if err != nil {
 		r.logger.Error("saving cropped image", "artist_id", artistID, "error", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to save image"})
@@ -1116,10 +1117,15 @@ type saveOrRestoreResult struct {
 // pre-edit backup written by an earlier BackupSingleSlot call so a save
 // failure never leaves the artist without an image (#2339). Callers must have
 // already backed up the original; fanart (multi-slot, no single-slot backup)
-// must not use this helper.
-func (r *Router) saveSingleSlotWithRollback(dir, imageType string, naming []string, useSymlinks bool, meta *img.ExifMeta, data []byte) saveOrRestoreResult {
+// must not use this helper. A successful save is recorded in the artwork
+// revision history under the artist and action ctx is tagged with (see
+// img.WithRevisionContext).
+func (r *Router) saveSingleSlotWithRollback(ctx context.Context, dir, imageType string, naming []string, useSymlinks bool, meta *img.ExifMeta, data []byte) saveOrRestoreResult {
 	saved, err := img.Save(dir, imageType, data, naming, useSymlinks, meta, r.logger)
 	if err == nil {
+		if len(saved) > 0 {
+			img.RecordSavedRevision(ctx, dir, imageType, saved[0])
+		}
 		return saveOrRestoreResult{Saved: saved}
 	}
 	// The save failed after a successful backup: restore the original rather
@@ -1245,7 +1251,7 @@ func (r *Router) processAndSaveImage(ctx context.Context, scope *imageWriteScope
 	// (append writes a new numbered file)" -- append-path reasoning in the OVERWRITE
 	// path. A failed fanart overwrite does NOT leave the primary untouched: the
 	// conflicting-format cleanup has already deleted it (#2413).
-	res := r.saveSingleSlotWithRollback(dir, imageType, naming, useSymlinks, meta, converted)
+	res := r.saveSingleSlotWithRollback(ctx, dir, imageType, naming, useSymlinks, meta, converted)
 	if res.SaveErr != nil {
 		// A first-ever upload for this slot has no prior original, so the
 		// earlier BackupSingleSlot call was a no-op and RestoreSingleSlot
@@ -2132,6 +2138,8 @@ func (r *Router) deleteImageFromPlatforms(ctx context.Context, a *artist.Artist,
 			deleter = emby.New(conn.URL, conn.APIKey, conn.GetPlatformUserID(), r.logger)
 		case connection.TypeJellyfin:
 			deleter = jellyfin.New(conn.URL, conn.APIKey, conn.GetPlatformUserID(), r.logger)
+		case connection.TypeKodi:
+			deleter = kodi.New(conn.URL, conn.APIKey, r.logger)
 		default:
 			r.logger.Warn("unsupported connection type for image delete sync", "type", conn.Type)
 			warnings = append(warnings, truncateWarning(fmt.Sprintf("%s: unsupported connection type %q", conn.Name, conn.Type)))
@@ -2425,7 +2433,7 @@ func (r *Router) handleLogoTrim(w http.ResponseWriter, req *http.Request) {
 	// failure (transient stat error or write error) ABORT the trim with 500 and
 	// do NOT call Save, so the original logo is never destroyed without a
 	// recoverable backup.
-	if bErr := img.BackupSingleSlot(req.Context(), r.imageDir(a), "logo", patterns); bErr != nil {
+	if bErr := img.BackupSingleSlot(img.WithRevisionContext(req.Context(), a.ID, "trim"), r.imageDir(a), "logo", patterns); bErr != nil {
 		r.logger.Error("backing up logo before trim; aborting",
 			slog.String("artist_id", artistID), slog.String("error", bErr.Error()))
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to preserve pre-trim original; trim aborted"})
@@ -2433,7 +2441,7 @@ func (r *Router) handleLogoTrim(w http.ResponseWriter, req *http.Request) {
 	}
 
 	_, useSymlinks := r.getActiveNamingAndSymlinks(req.Context(), "logo")
-	res := r.saveSingleSlotWithRollback(r.imageDir(a), "logo", patterns, useSymlinks, trimMeta, trimmed)
+	res := r.saveSingleSlotWithRollback(img.WithRevisionContext(req.Context(), a.ID, "trim"), r.imageDir(a), "logo", patterns, useSymlinks, trimMeta, trimmed)
 	if res.SaveErr != nil {
 		if res.RestoreErr != nil {
 			// Worst case: the save failed AND the automatic rollback also
@@ -2542,7 +2550,12 @@ func (r *Router) handleImageRevert(w http.ResponseWriter, req *http.Request) {
 	// are rebuilt and the post-edit format (e.g. a jpg written over a png crop)
 	// is cleaned up. The backup is keyed by image TYPE, so a format-changing edit
 	// is still revertible (#1837).
+	//
+	// RestoreSingleSlot works below the revision-history hooks, so the image the
+	// revert replaces and the one it puts back are recorded here.
 	naming, useSymlinks := r.getActiveNamingAndSymlinks(req.Context(), imageType)
+	revCtx := img.WithRevisionContext(req.Context(), a.ID, "revert")
+	img.RecordCurrentRevision(revCtx, dir, imageType, naming)
 	if restoreErr := img.RestoreSingleSlot(dir, imageType, naming, useSymlinks, nil, r.logger); restoreErr != nil {
 		if os.IsNotExist(restoreErr) {
 			writeJSON(w, http.StatusNotFound, map[string]string{"error": "no backup to revert"})
@@ -2552,6 +2565,9 @@ func (r *Router) handleImageRevert(w http.ResponseWriter, req *http.Request) {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to revert image"})
 		return
 	}
+	if restored, found := img.FindExistingImage(revCtx, dir, naming); found {
+		img.RecordSavedRevision(revCtx, dir, imageType, restored)
+	}
 	// DB bookkeeping (exists flag, low-res, placeholder) is intentionally
 	// best-effort on the revert path: setArtistImageFlag logs any Update failure
 	// at Warn and the on-disk file is already the source of truth, so a stale
@@ -2706,6 +2722,7 @@ func (r *Router) processAndAppendFanart(ctx context.Context, scope *imageWriteSc
 		// fire for artwork that is not on disk.
 		return nil, fmt.Errorf("saving: produced no files in %s", dir)
 	}
+	img.RecordSavedRevision(ctx, dir, "fanart", saved[0])
 
 	// The save is confirmed (no error, at least one file written), so the image the
 	// collision was detected on genuinely exists. Only now is it correct to raise
@@ -3110,7 +3127,7 @@ func (r *Router) handleFanartBatchFetch(w http.ResponseWriter, req *http.Request
 			continue
 		}
 		batchMeta := &img.ExifMeta{Source: artist.ImageSourceUser, Fetched: time.Now().UTC(), URL: u, Mode: "user"}
-		saved, saveErr := r.processAndAppendFanart(req.Context(), collisionScope, r.imageDir(a), data, batchMeta)
+		saved, saveErr := r.processAndAppendFanart(img.WithRevisionContext(req.Context(), a.ID, "fetch"), collisionScope, r.imageDir(a), data, batchMeta)
 		if saveErr != nil {
 			r.logger.Error("saving fanart image", "url", u, "error", saveErr)
 			errMsgs = append(errMsgs, fmt.Sprintf("save failed: %s", u))
@@ -3170,7 +3170,7 @@ func TestSaveSingleSlotWithRollback_SaveFailsRestoreSucceeds(t *testing.T) {
 	// stand-in for a save failure that occurs after a successful backup.
 	badData := []byte("not a valid image")
 
-	res := r.saveSingleSlotWithRollback(dir, "logo", patterns, useSymlinks, nil, badData)
+	res := r.saveSingleSlotWithRollback(context.Background(), dir, "logo", patterns, useSymlinks, nil, badData)
 
 	if res.SaveErr == nil {
 		t.Fatal("expected SaveErr to be set for invalid image data")
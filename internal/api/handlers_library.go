@@ -52,9 +52,10 @@ func (r *Router) handleGetLibrary(w http.ResponseWriter, req *http.Request) {
 // POST /api/v1/libraries
 func (r *Router) handleCreateLibrary(w http.ResponseWriter, req *http.Request) {
 	var body struct {
-		Name string `json:"name"`
-		Path string `json:"path"`
-		Type string `json:"type"`
+		Name   string `json:"name"`
+		Path   string `json:"path"`
+		Type   string `json:"type"`
+		Layout string `json:"layout"`
 	}
 	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
 		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
@@ -65,6 +66,7 @@ func (r *Router) handleCreateLibrary(w http.ResponseWriter, req *http.Request) {
 		body.Name = req.FormValue("name")
 		body.Path = req.FormValue("path")
 		body.Type = req.FormValue("type")
+		body.Layout = req.FormValue("layout")
 	}
 	if body.Name == "" {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name is required"})
@@ -91,10 +93,17 @@ func (r *Router) handleCreateLibrary(w http.ResponseWriter, req *http.Request) {
 	}
 	body.Path = cleanPath
 
+	layout, err := library.ValidateLayout(body.Layout)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
 	lib := &library.Library{
-		Name: body.Name,
-		Path: body.Path,
-		Type: body.Type,
+		Name:   body.Name,
+		Path:   body.Path,
+		Type:   body.Type,
+		Layout: layout,
 	}
 	if err := r.libraryService.Create(req.Context(), lib); err != nil {
 		msg := err.Error()
@@ -122,12 +131,13 @@ func (r *Router) handleUpdateLibrary(w http.ResponseWriter, req *http.Request) {
 	}
 
 	var body struct {
-		Name           string `json:"name"`
-		Path           string `json:"path"`
-		Type           string `json:"type"`
-		FSWatch        *int   `json:"fs_watch"`
-		FSPollInterval *int   `json:"fs_poll_interval"`
-		NFOLockData    *bool  `json:"nfo_lock_data"`
+		Name           string  `json:"name"`
+		Path           string  `json:"path"`
+		Type           string  `json:"type"`
+		FSWatch        *int    `json:"fs_watch"`
+		FSPollInterval *int    `json:"fs_poll_interval"`
+		NFOLockData    *bool   `json:"nfo_lock_data"`
+		Layout         *string `json:"layout"`
 	}
 	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
 		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
@@ -159,6 +169,9 @@ func (r *Router) handleUpdateLibrary(w http.ResponseWriter, req *http.Request) {
 			}
 			body.NFOLockData = &v
 		}
+		if vs, ok := req.PostForm["layout"]; ok && len(vs) > 0 {
+			body.Layout = &vs[0]
+		}
 	}
 
 	if body.Name != "" {
@@ -201,6 +214,14 @@ func (r *Router) handleUpdateLibrary(w http.ResponseWriter, req *http.Request) {
 	if body.NFOLockData != nil {
 		existing.NFOLockData = *body.NFOLockData
 	}
+	if body.Layout != nil {
+		layout, err := library.ValidateLayout(*body.Layout)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		existing.Layout = layout
+	}
 
 	if err := r.libraryService.Update(req.Context(), existing); err != nil {
 		r.logger.Error("updating library", "error", err)
@@ -376,6 +376,52 @@ func TestHandleUpdateLibrary(t *testing.T) {
 	}
 }
 
+func TestHandleUpdateLibrary_Layout(t *testing.T) {
+	t.Parallel()
+	r, libSvc, _ := testRouterWithLibrary(t)
+
+	lib := &library.Library{Name: "Music", Path: t.TempDir(), Type: "regular"}
+	if err := libSvc.Create(context.Background(), lib); err != nil {
+		t.Fatalf("creating library: %v", err)
+	}
+
+	put := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/libraries/"+lib.ID, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.SetPathValue("id", lib.ID)
+		w := httptest.NewRecorder()
+		r.handleUpdateLibrary(w, req)
+		return w
+	}
+
+	if w := put(`{"layout":"{artist}/{letter}"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid layout: status = %d, want %d; body: %s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+	w := put(`{"layout":"/{letter}/{artist}/"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var updated library.Library
+	if err := json.NewDecoder(w.Body).Decode(&updated); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if updated.Layout != library.LayoutLetterFirst {
+		t.Errorf("layout = %q, want %q", updated.Layout, library.LayoutLetterFirst)
+	}
+
+	// Omitting the field keeps the layout.
+	if w := put(`{"name":"Renamed"}`); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	got, err := libSvc.GetByID(context.Background(), lib.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Layout != library.LayoutLetterFirst {
+		t.Errorf("layout after an update without it = %q, want %q", got.Layout, library.LayoutLetterFirst)
+	}
+}
+
 func TestHandleUpdateLibrary_InvalidPath(t *testing.T) {
 	t.Parallel()
 	r, libSvc, _ := testRouterWithLibrary(t)
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/auth"
+)
+
+func TestHandleLogin_LockoutAndUnlock(t *testing.T) {
+	t.Parallel()
+	r, authSvc, adminID := testRouterWithAuth(t)
+	authSvc.WithLockoutPolicy(auth.LockoutPolicy{UserThreshold: 2, BaseDuration: time.Minute, MaxDuration: time.Hour, Window: 15 * time.Minute})
+
+	for i := range 2 {
+		w := httptest.NewRecorder()
+		r.handleLogin(w, jsonPost("/api/v1/auth/login", `{"username":"admin","password":"wrong"}`))
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status = %d, want 401", i+1, w.Code)
+		}
+	}
+
+	// The right password is refused while the account is locked.
+	w := httptest.NewRecorder()
+	r.handleLogin(w, jsonPost("/api/v1/auth/login", `{"username":"admin","password":"password"}`))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("locked login: status = %d, want 429; body: %s", w.Code, w.Body.String())
+	}
+	if secs, err := strconv.Atoi(w.Header().Get("Retry-After")); err != nil || secs <= 0 || secs > 61 {
+		t.Errorf("Retry-After = %q, want up to a minute", w.Header().Get("Retry-After"))
+	}
+	if hasSessionCookie(w) {
+		t.Error("locked login set a session cookie")
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+adminID+"/account/lockout", nil)
+	req.SetPathValue("id", adminID)
+	req = withAdminCtx(req, adminID)
+	w = httptest.NewRecorder()
+	r.handleUnlockUser(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("unlock: status = %d, want 204; body: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.handleLogin(w, jsonPost("/api/v1/auth/login", `{"username":"admin","password":"password"}`))
+	if w.Code != http.StatusOK {
+		t.Errorf("login after unlock: status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	if err := authSvc.CheckLogin(context.Background(), "admin"); err != nil {
+		t.Errorf("CheckLogin after unlock: %v", err)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/users/missing/account/lockout", nil)
+	req.SetPathValue("id", "missing")
+	req = withAdminCtx(req, adminID)
+	w = httptest.NewRecorder()
+	r.handleUnlockUser(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown user: status = %d, want 404", w.Code)
+	}
+}
+
+// TestLoginSecondFactor_WrongCodesLockOut checks that wrong authentication
+// codes lock the account like wrong passwords, even when each guess comes
+// with a fresh challenge from a correct password.
+func TestLoginSecondFactor_WrongCodesLockOut(t *testing.T) {
+	t.Parallel()
+	r, authSvc, adminID := testRouterWithTwoFactor(t)
+	authSvc.WithLockoutPolicy(auth.LockoutPolicy{UserThreshold: 3, BaseDuration: time.Minute, MaxDuration: time.Hour, Window: 15 * time.Minute})
+	ctx := context.Background()
+	e, err := authSvc.BeginTOTPEnrollment(ctx, adminID)
+	if err != nil {
+		t.Fatalf("BeginTOTPEnrollment: %v", err)
+	}
+	if _, err := authSvc.ConfirmTOTPEnrollment(ctx, adminID, currentTOTP(t, e.Secret)); err != nil {
+		t.Fatalf("ConfirmTOTPEnrollment: %v", err)
+	}
+
+	login := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.handleLogin(w, jsonPost("/api/v1/auth/login", `{"username":"admin","password":"password"}`))
+		return w
+	}
+	var challenge string
+	for i := range 3 {
+		w := login()
+		var resp struct {
+			Challenge string `json:"challenge"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Challenge == "" {
+			t.Fatalf("login %d: status = %d, want a challenge (%v)", i+1, w.Code, err)
+		}
+		challenge = resp.Challenge
+
+		w = httptest.NewRecorder()
+		r.handleLoginSecondFactor(w, jsonPost("/api/v1/auth/login/2fa", `{"challenge":"`+challenge+`","code":"000000"}`))
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("wrong code %d: status = %d, want 401", i+1, w.Code)
+		}
+	}
+
+	if w := login(); w.Code != http.StatusTooManyRequests {
+		t.Errorf("login after wrong codes: status = %d, want 429; body: %s", w.Code, w.Body.String())
+	}
+	w := httptest.NewRecorder()
+	r.handleLoginSecondFactor(w, jsonPost("/api/v1/auth/login/2fa", `{"challenge":"`+challenge+`","code":"000000"}`))
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("code while locked: status = %d, want 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("locked second step sent no Retry-After")
+	}
+}
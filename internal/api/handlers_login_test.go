@@ -396,6 +396,51 @@ func TestFederatedLoginFlow_SessionValid(t *testing.T) {
 	}
 }
 
+// TestLoginPage_LDAPButtonFollowsRegistry verifies that the LDAP sign-in
+// button appears only when an LDAP provider is registered (it is configured
+// from the environment, not a settings toggle) and that a login posted to it
+// reaches the provider.
+func TestLoginPage_LDAPButtonFollowsRegistry(t *testing.T) {
+	t.Parallel()
+	r, _, _ := testRouterWithAuth(t)
+	const ldapButton = "value='ldap'"
+
+	w := httptest.NewRecorder()
+	r.renderLoginPage(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if strings.Contains(w.Body.String(), ldapButton) {
+		t.Fatal("LDAP button rendered without an LDAP provider")
+	}
+
+	registry := auth.NewRegistry()
+	registry.Register(auth.NewLocalProvider(r.db))
+	registry.Register(&stubFederatedProvider{
+		providerType: "ldap",
+		identity:     &auth.Identity{ProviderID: "alice", DisplayName: "Alice", ProviderType: "ldap", IsAdmin: true},
+	})
+	r.authRegistry = registry
+
+	w = httptest.NewRecorder()
+	r.renderLoginPage(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !strings.Contains(w.Body.String(), ldapButton) {
+		t.Fatal("LDAP button missing with an LDAP provider registered")
+	}
+
+	loginReq := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"username":"alice","password":"pw","provider":"ldap"}`))
+	loginReq.Header.Set("Content-Type", "application/json")
+	loginW := httptest.NewRecorder()
+	r.handleLogin(loginW, loginReq)
+	if loginW.Code != http.StatusOK {
+		t.Fatalf("ldap login: expected 200, got %d: %s", loginW.Code, loginW.Body.String())
+	}
+	var role string
+	if err := r.db.QueryRow("SELECT role FROM users WHERE auth_provider = 'ldap' AND provider_id = 'alice'").Scan(&role); err != nil {
+		t.Fatalf("querying provisioned user: %v", err)
+	}
+	if role != "administrator" {
+		t.Errorf("role = %q, want administrator", role)
+	}
+}
+
 // --- Flow 4: Role Enforcement ---
 
 // TestRoleEnforcementFlow_OperatorForbiddenOnAdminRoutes verifies that an
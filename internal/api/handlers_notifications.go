@@ -403,7 +403,7 @@ func (r *Router) handleApplyViolationCandidate(w http.ResponseWriter, req *http.
 		collisionResult = collisionScope.collisionVerdict(req.Context(), data)
 	}
 
-	saved, err := rule.SaveImageFromData(req.Context(), a, body.ImageType, data, naming, useSymlinks, candidateMeta, r.platformService, r.logger)
+	saved, err := rule.SaveImageFromData(img.WithRevisionContext(req.Context(), a.ID, "apply_candidate"), a, body.ImageType, data, naming, useSymlinks, candidateMeta, r.platformService, r.logger)
 	if err != nil {
 		r.logger.Error("applying image candidate", "artist_id", a.ID, "image_type", body.ImageType, "error", err)
 		writeError(w, req, http.StatusInternalServerError, "failed to apply image candidate")
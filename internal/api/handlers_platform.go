@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/sydlexius/stillwater/internal/api/middleware"
+	"github.com/sydlexius/stillwater/internal/auth"
 	"github.com/sydlexius/stillwater/internal/filesystem"
 	"github.com/sydlexius/stillwater/internal/library"
 	"github.com/sydlexius/stillwater/internal/platform"
@@ -386,6 +387,7 @@ func (r *Router) buildSettingsData(req *http.Request, userID string, loadUsers b
 	var usersTabData templates.UsersTabData
 	usersTabData.MultiUserEnabled = multiUserEnabled
 	usersTabData.CallerID = userID
+	usersTabData.Libraries = libs
 	if multiUserEnabled && loadUsers {
 		if users, err := r.authService.ListUsers(req.Context()); err == nil {
 			usersTabData.Users = users
@@ -415,6 +417,7 @@ func (r *Router) buildSettingsData(req *http.Request, userID string, loadUsers b
 	authProvidersData := templates.AuthProvidersData{
 		BasePath:              r.basePath,
 		LocalEnabled:          r.getBoolSetting(req.Context(), "auth.providers.local.enabled", true),
+		LocalTwoFactorPolicy:  r.getStringSetting(req.Context(), "auth.two_factor.required", auth.TwoFactorPolicyOff),
 		EmbyEnabled:           r.getBoolSetting(req.Context(), "auth.providers.emby.enabled", false),
 		EmbyAutoProvision:     r.getBoolSetting(req.Context(), "auth.providers.emby.auto_provision", false),
 		EmbyGuardRail:         r.getStringSetting(req.Context(), "auth.providers.emby.guard_rail", "admin"),
@@ -626,6 +629,9 @@ var authProviderDefaults = []struct {
 	// source can be exported (the toggle is currently disabled in the UI but
 	// the storage shape is symmetric).
 	{"auth.providers.local.enabled", "true"},
+	// Two-factor policy for local accounts; "off" leaves enrollment to each
+	// user.
+	{"auth.two_factor.required", "off"},
 
 	// Emby provider.
 	{"auth.providers.emby.enabled", "false"},
@@ -8,6 +8,8 @@ import (
 	"github.com/sydlexius/stillwater/internal/connection"
 	"github.com/sydlexius/stillwater/internal/connection/emby"
 	"github.com/sydlexius/stillwater/internal/connection/jellyfin"
+	"github.com/sydlexius/stillwater/internal/connection/kodi"
+	"github.com/sydlexius/stillwater/internal/connection/subsonic"
 	"github.com/sydlexius/stillwater/web/templates"
 )
 
@@ -193,6 +195,10 @@ func (r *Router) newStateGetter(conn *connection.Connection) (connection.ArtistS
 		return emby.New(conn.URL, conn.APIKey, conn.GetPlatformUserID(), r.logger), nil
 	case connection.TypeJellyfin:
 		return jellyfin.New(conn.URL, conn.APIKey, conn.GetPlatformUserID(), r.logger), nil
+	case connection.TypeSubsonic:
+		return subsonic.New(conn.URL, conn.APIKey, r.logger), nil
+	case connection.TypeKodi:
+		return kodi.New(conn.URL, conn.APIKey, r.logger), nil
 	default:
 		return nil, errUnsupportedConnectionType
 	}
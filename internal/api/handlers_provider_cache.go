@@ -0,0 +1,185 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/provider"
+)
+
+// providerCacheTTLEntry is one row of the TTL table returned by
+// handleProviderCacheStats: the TTL in force and the built-in default it
+// overrides (equal when no override is stored).
+type providerCacheTTLEntry struct {
+	Provider          provider.ProviderName  `json:"provider"`
+	Endpoint          provider.CacheEndpoint `json:"endpoint"`
+	TTLSeconds        int64                  `json:"ttl_seconds"`
+	DefaultTTLSeconds int64                  `json:"default_ttl_seconds"`
+}
+
+// providerCache returns the response cache wired into the orchestrator, or nil
+// when none is configured (tests, or an orchestrator-less router).
+func (r *Router) providerCache() *provider.ResponseCache {
+	if r.orchestrator == nil {
+		return nil
+	}
+	return r.orchestrator.ResponseCache()
+}
+
+// handleProviderCacheStats returns hit/miss metrics, the entry count, the
+// offline flag and the TTL in force for every provider endpoint.
+// GET /api/v1/providers/cache
+func (r *Router) handleProviderCacheStats(w http.ResponseWriter, req *http.Request) {
+	cache := r.providerCache()
+	if cache == nil {
+		writeError(w, req, http.StatusServiceUnavailable, "provider cache is not configured")
+		return
+	}
+	stats, err := cache.Stats(req.Context())
+	if err != nil {
+		r.logger.Error("reading provider cache stats", "error", err)
+		writeError(w, req, http.StatusInternalServerError, "failed to read provider cache stats")
+		return
+	}
+
+	var ttls []providerCacheTTLEntry
+	for _, name := range provider.AllProviderNames() {
+		for _, endpoint := range provider.CacheEndpoints() {
+			ttls = append(ttls, providerCacheTTLEntry{
+				Provider:          name,
+				Endpoint:          endpoint,
+				TTLSeconds:        int64(cache.TTL(name, endpoint) / time.Second),
+				DefaultTTLSeconds: int64(provider.DefaultCacheTTL(name, endpoint) / time.Second),
+			})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"offline":   stats.Offline,
+		"entries":   stats.Entries,
+		"endpoints": stats.Endpoints,
+		"ttls":      ttls,
+	})
+}
+
+// handleSetProviderCacheOffline switches the response cache's offline mode.
+// While offline, provider lookups are answered from cache only (expired
+// entries included) and a miss fails without contacting the provider.
+// PUT /api/v1/providers/cache/offline
+func (r *Router) handleSetProviderCacheOffline(w http.ResponseWriter, req *http.Request) {
+	cache := r.providerCache()
+	if cache == nil {
+		writeError(w, req, http.StatusServiceUnavailable, "provider cache is not configured")
+		return
+	}
+	var body struct {
+		Offline *bool `json:"offline"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil || body.Offline == nil {
+		writeError(w, req, http.StatusBadRequest, "offline (boolean) is required")
+		return
+	}
+	if err := r.providerSettings.SetCacheOffline(req.Context(), *body.Offline); err != nil {
+		r.logger.Error("storing provider cache offline flag", "error", err)
+		writeError(w, req, http.StatusInternalServerError, "failed to update setting")
+		return
+	}
+	cache.SetOffline(*body.Offline)
+	r.logger.Info("provider cache offline mode changed", "offline", *body.Offline)
+	writeJSON(w, http.StatusOK, map[string]bool{"offline": *body.Offline})
+}
+
+// handleSetProviderCacheTTL stores a TTL override for one provider endpoint.
+// A ttl_seconds of 0 removes the override and restores the built-in default.
+// The new TTL applies to entries written from now on; existing entries keep
+// the expiry they were stored with.
+// PUT /api/v1/providers/{name}/cache-ttl
+func (r *Router) handleSetProviderCacheTTL(w http.ResponseWriter, req *http.Request) {
+	name := provider.ProviderName(req.PathValue("name"))
+	if !isValidProviderName(name) {
+		writeError(w, req, http.StatusBadRequest, "unknown provider")
+		return
+	}
+	cache := r.providerCache()
+	if cache == nil {
+		writeError(w, req, http.StatusServiceUnavailable, "provider cache is not configured")
+		return
+	}
+	var body struct {
+		Endpoint   provider.CacheEndpoint `json:"endpoint"`
+		TTLSeconds int64                  `json:"ttl_seconds"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		writeError(w, req, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !provider.IsValidCacheEndpoint(body.Endpoint) {
+		writeError(w, req, http.StatusBadRequest, "endpoint must be one of artist, images, search")
+		return
+	}
+	if body.TTLSeconds < 0 {
+		writeError(w, req, http.StatusBadRequest, "ttl_seconds must not be negative")
+		return
+	}
+
+	ttl := time.Duration(body.TTLSeconds) * time.Second
+	if err := r.providerSettings.SetCacheTTL(req.Context(), name, body.Endpoint, ttl); err != nil {
+		r.logger.Error("storing provider cache TTL", "provider", name, "endpoint", body.Endpoint, "error", err)
+		writeError(w, req, http.StatusInternalServerError, "failed to update setting")
+		return
+	}
+	cache.SetTTL(name, body.Endpoint, ttl)
+
+	writeJSON(w, http.StatusOK, providerCacheTTLEntry{
+		Provider:          name,
+		Endpoint:          body.Endpoint,
+		TTLSeconds:        int64(cache.TTL(name, body.Endpoint) / time.Second),
+		DefaultTTLSeconds: int64(provider.DefaultCacheTTL(name, body.Endpoint) / time.Second),
+	})
+}
+
+// handleClearProviderCache deletes every cached provider response.
+// DELETE /api/v1/providers/cache
+func (r *Router) handleClearProviderCache(w http.ResponseWriter, req *http.Request) {
+	cache := r.providerCache()
+	if cache == nil {
+		writeError(w, req, http.StatusServiceUnavailable, "provider cache is not configured")
+		return
+	}
+	n, err := cache.Clear(req.Context())
+	if err != nil {
+		r.logger.Error("clearing provider cache", "error", err)
+		writeError(w, req, http.StatusInternalServerError, "failed to clear provider cache")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int64{"removed": n})
+}
+
+// handleInvalidateArtistProviderCache drops every cached provider response
+// looked up by this artist's MBID, name or provider IDs, so the next refresh
+// goes back to the providers. Use it after fixing data upstream.
+// DELETE /api/v1/artists/{id}/provider-cache
+func (r *Router) handleInvalidateArtistProviderCache(w http.ResponseWriter, req *http.Request) {
+	id, ok := RequirePathParam(w, req, "id")
+	if !ok {
+		return
+	}
+	cache := r.providerCache()
+	if cache == nil {
+		writeError(w, req, http.StatusServiceUnavailable, "provider cache is not configured")
+		return
+	}
+	a, err := r.artistService.GetByID(req.Context(), id)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "artist not found"})
+		return
+	}
+	n, err := cache.InvalidateArtist(req.Context(), a.MusicBrainzID, a.Name, a.ProviderIDMap())
+	if err != nil {
+		r.logger.Error("invalidating provider cache for artist", "artist_id", id, "error", err)
+		writeError(w, req, http.StatusInternalServerError, "failed to invalidate provider cache")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int64{"removed": n})
+}
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/provider"
+)
+
+// providerCacheTestRouter builds a Router whose orchestrator carries a
+// ResponseCache over the migrated test DB.
+func providerCacheTestRouter(t *testing.T) (*Router, *artist.Service, *provider.ResponseCache) {
+	t.Helper()
+	db := newTestDB(t)
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	artistSvc := artist.NewService(db)
+	settings := provider.NewSettingsService(db, nil)
+	orch := provider.NewOrchestrator(provider.NewRegistry(), settings, logger, nil)
+	cache := provider.NewResponseCache(db, logger)
+	orch.SetResponseCache(cache)
+
+	r := NewRouter(RouterDeps{
+		SessionSecret:    testSessionSecret,
+		ArtistService:    artistSvc,
+		ProviderSettings: settings,
+		Orchestrator:     orch,
+		DB:               db,
+		Logger:           logger,
+		StaticFS:         os.DirFS("../../web/static"),
+	})
+	return r, artistSvc, cache
+}
+
+// seedProviderCacheRow inserts one unexpired cache entry directly; the
+// handlers under test only read, count and delete rows.
+func seedProviderCacheRow(t *testing.T, r *Router, prov, endpoint, key string) {
+	t.Helper()
+	_, err := r.db.ExecContext(context.Background(),
+		`INSERT INTO provider_response_cache (provider, endpoint, lookup_key, payload, fetched_at, expires_at)
+		 VALUES (?, ?, ?, '{}', '2026-01-01T00:00:00Z', '2099-01-01T00:00:00Z')`,
+		prov, endpoint, key)
+	if err != nil {
+		t.Fatalf("seeding provider cache row: %v", err)
+	}
+}
+
+func TestHandleProviderCacheStats(t *testing.T) {
+	r, _, _ := providerCacheTestRouter(t)
+	seedProviderCacheRow(t, r, "musicbrainz", "artist", "mbid-1")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/providers/cache", nil)
+	w := httptest.NewRecorder()
+	r.handleProviderCacheStats(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Offline bool                    `json:"offline"`
+		Entries int                     `json:"entries"`
+		TTLs    []providerCacheTTLEntry `json:"ttls"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if resp.Offline || resp.Entries != 1 {
+		t.Errorf("offline=%v entries=%d, want false, 1", resp.Offline, resp.Entries)
+	}
+	want := len(provider.AllProviderNames()) * len(provider.CacheEndpoints())
+	if len(resp.TTLs) != want {
+		t.Errorf("ttls has %d rows, want %d", len(resp.TTLs), want)
+	}
+}
+
+func TestHandleProviderCacheStats_NoCache(t *testing.T) {
+	r, _, _ := providerCacheTestRouter(t)
+	r.orchestrator = nil
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/providers/cache", nil)
+	w := httptest.NewRecorder()
+	r.handleProviderCacheStats(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", w.Code)
+	}
+}
+
+func TestHandleSetProviderCacheOffline(t *testing.T) {
+	r, _, cache := providerCacheTestRouter(t)
+	ctx := context.Background()
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/providers/cache/offline", strings.NewReader(`{"offline":true}`))
+	w := httptest.NewRecorder()
+	r.handleSetProviderCacheOffline(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	if !cache.Offline() {
+		t.Error("cache not switched offline")
+	}
+	stored, err := r.providerSettings.GetCacheOffline(ctx)
+	if err != nil || !stored {
+		t.Errorf("persisted offline = %v, %v; want true", stored, err)
+	}
+
+	// A body without the flag is rejected rather than read as false.
+	req = httptest.NewRequest(http.MethodPut, "/api/v1/providers/cache/offline", strings.NewReader(`{}`))
+	w = httptest.NewRecorder()
+	r.handleSetProviderCacheOffline(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("missing flag: status = %d, want 400", w.Code)
+	}
+	if !cache.Offline() {
+		t.Error("rejected request changed the offline flag")
+	}
+}
+
+func TestHandleSetProviderCacheTTL(t *testing.T) {
+	r, _, cache := providerCacheTestRouter(t)
+
+	put := func(name, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/providers/"+name+"/cache-ttl", strings.NewReader(body))
+		req.SetPathValue("name", name)
+		w := httptest.NewRecorder()
+		r.handleSetProviderCacheTTL(w, req)
+		return w
+	}
+
+	w := put("lastfm", `{"endpoint":"search","ttl_seconds":3600}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	var entry providerCacheTTLEntry
+	if err := json.NewDecoder(w.Body).Decode(&entry); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if entry.TTLSeconds != 3600 || entry.DefaultTTLSeconds != 86400 {
+		t.Errorf("entry = %+v, want ttl 3600 over default 86400", entry)
+	}
+	if got := cache.TTL(provider.NameLastFM, provider.CacheEndpointSearch); got.Seconds() != 3600 {
+		t.Errorf("live TTL = %v, want 1h", got)
+	}
+
+	// Zero restores the default.
+	if w := put("lastfm", `{"endpoint":"search","ttl_seconds":0}`); w.Code != http.StatusOK {
+		t.Fatalf("reset: status = %d", w.Code)
+	}
+	if got := cache.TTL(provider.NameLastFM, provider.CacheEndpointSearch); got != provider.DefaultCacheTTL(provider.NameLastFM, provider.CacheEndpointSearch) {
+		t.Errorf("TTL after reset = %v, want default", got)
+	}
+
+	for _, tc := range []struct{ name, body string }{
+		{"nope", `{"endpoint":"search","ttl_seconds":60}`},
+		{"lastfm", `{"endpoint":"albums","ttl_seconds":60}`},
+		{"lastfm", `{"endpoint":"search","ttl_seconds":-1}`},
+	} {
+		if w := put(tc.name, tc.body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s %s: status = %d, want 400", tc.name, tc.body, w.Code)
+		}
+	}
+}
+
+func TestHandleClearProviderCache(t *testing.T) {
+	r, _, _ := providerCacheTestRouter(t)
+	seedProviderCacheRow(t, r, "musicbrainz", "artist", "mbid-1")
+	seedProviderCacheRow(t, r, "discogs", "search", "radiohead")
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/providers/cache", nil)
+	w := httptest.NewRecorder()
+	r.handleClearProviderCache(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	var resp map[string]int64
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if resp["removed"] != 2 {
+		t.Errorf("removed = %d, want 2", resp["removed"])
+	}
+}
+
+func TestHandleInvalidateArtistProviderCache(t *testing.T) {
+	r, artistSvc, _ := providerCacheTestRouter(t)
+	a := &artist.Artist{Name: "Radiohead", MusicBrainzID: "mbid-1", DiscogsID: "3840", Path: "/music/Radiohead"}
+	if err := artistSvc.Create(context.Background(), a); err != nil {
+		t.Fatalf("creating artist: %v", err)
+	}
+	seedProviderCacheRow(t, r, "musicbrainz", "artist", "mbid-1")
+	seedProviderCacheRow(t, r, "discogs", "artist", "3840")
+	seedProviderCacheRow(t, r, "discogs", "search", "radiohead")
+	seedProviderCacheRow(t, r, "musicbrainz", "artist", "mbid-other")
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/artists/"+a.ID+"/provider-cache", nil)
+	req.SetPathValue("id", a.ID)
+	w := httptest.NewRecorder()
+	r.handleInvalidateArtistProviderCache(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	var resp map[string]int64
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if resp["removed"] != 3 {
+		t.Errorf("removed = %d, want 3 (unrelated artist kept)", resp["removed"])
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/artists/missing/provider-cache", nil)
+	req.SetPathValue("id", "missing")
+	w = httptest.NewRecorder()
+	r.handleInvalidateArtistProviderCache(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown artist: status = %d, want 404", w.Code)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/sydlexius/stillwater/internal/connection"
 	"github.com/sydlexius/stillwater/internal/connection/emby"
 	"github.com/sydlexius/stillwater/internal/connection/jellyfin"
+	"github.com/sydlexius/stillwater/internal/connection/kodi"
 	img "github.com/sydlexius/stillwater/internal/image"
 	"github.com/sydlexius/stillwater/internal/publish"
 )
@@ -415,6 +416,8 @@ func (r *Router) handleDeletePushImage(w http.ResponseWriter, req *http.Request)
 		deleter = emby.New(conn.URL, conn.APIKey, conn.GetPlatformUserID(), r.logger)
 	case connection.TypeJellyfin:
 		deleter = jellyfin.New(conn.URL, conn.APIKey, conn.GetPlatformUserID(), r.logger)
+	case connection.TypeKodi:
+		deleter = kodi.New(conn.URL, conn.APIKey, r.logger)
 	default:
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "connection type does not support image delete"})
 		return
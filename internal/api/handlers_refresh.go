@@ -4,6 +4,7 @@ import (
 	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -272,11 +273,12 @@ func collectFailedProviderDisplayNames(statuses []provider.ProviderSearchStatus)
 // Per-field locks are untouched: this is provider-ID plumbing, not a field
 // merge, and ApplyMetadata still reads a.LockedFields on every call.
 func discardRepudiatedProviderIDs(a *artist.Artist, keepDiscogsID string) bool {
-	changed := a.AudioDBID != "" || a.WikidataID != "" || a.DeezerID != "" || a.SpotifyID != ""
+	changed := a.AudioDBID != "" || a.WikidataID != "" || a.DeezerID != "" || a.SpotifyID != "" || a.AllMusicID != ""
 	a.AudioDBID = ""
 	a.WikidataID = ""
 	a.DeezerID = ""
 	a.SpotifyID = ""
+	a.AllMusicID = ""
 	// Only when this request did not supply a replacement: a Discogs pick is
 	// the operator's own choice for THIS identity and must survive.
 	if keepDiscogsID == "" {
@@ -529,29 +531,39 @@ func (r *Router) handleRefreshLink(w http.ResponseWriter, req *http.Request) {
 	writeJSON(w, http.StatusOK, resp)
 }
 
-// refreshArtistForBulk performs the bulk-action equivalent of
-// handleArtistRefresh for a single artist (#2283) and reports the outcome as a
-// bulkOutcome. It lives here, beside executeRefreshCtx and the follow-up
-// helpers it reuses, so the two refresh paths stay visibly in sync.
+// ErrRefreshNoMBID and ErrRefreshLocked are RefreshArtist's refusals: the
+// artist needs a MusicBrainz link first, or the operator locked it.
+var (
+	ErrRefreshNoMBID = errors.New("artist has no MusicBrainz ID; identify it before refreshing")
+	ErrRefreshLocked = errors.New("artist is locked")
+)
+
+// RefreshArtist refreshes one artist from the providers with nobody to answer
+// questions: the provider fetch and merge (executeRefreshCtx), the
+// language-promoted name, an ArtistUpdated event, and the post-refresh rule
+// pass. It is the shared body of the bulk refresh action and the headless
+// `stillwater fetch` command.
 //
 // Differences from the interactive handler, all forced by the absence of a
 // user to answer questions:
 //
 //   - No MusicBrainz ID: the handler answers with the disambiguation search UI.
-//     Bulk has nowhere to render it and must not guess a link, so the artist is
-//     counted as Skipped and the run continues.
+//     There is nowhere to render it here and a link must not be guessed, so
+//     this returns ErrRefreshNoMBID.
 //   - A failed provider-name write is a warning in the handler (HX-Trigger
 //     toast) on an otherwise successful refresh. Here it is logged and the
-//     artist still counts as Succeeded, because the metadata refresh itself
-//     committed.
-//   - InvalidateHealthCache is NOT called per artist; runBulkAction invalidates
-//     once when the whole run finishes.
+//     refresh still succeeds, because the metadata refresh itself committed.
+//   - InvalidateHealthCache is NOT called; a bulk run invalidates once when
+//     the whole run finishes.
 //
-// The lock/exclusion gate lives in applyBulkAction, not here, so every
-// pipeline-touching bulk action shares one statement of that rule.
-func (r *Router) refreshArtistForBulk(ctx context.Context, a *artist.Artist) bulkOutcome {
+// A locked artist is refused with ErrRefreshLocked. Bulk actions gate locks
+// and exclusions earlier, in applyBulkAction, so for them this is a backstop.
+func (r *Router) RefreshArtist(ctx context.Context, a *artist.Artist) (*provider.FetchResult, error) {
+	if a.Locked {
+		return nil, ErrRefreshLocked
+	}
 	if a.MusicBrainzID == "" {
-		return bulkOutcomeSkipped
+		return nil, ErrRefreshNoMBID
 	}
 
 	// executeRefreshCtx re-runs injectMetadataLanguages on this context even
@@ -562,14 +574,14 @@ func (r *Router) refreshArtistForBulk(ctx context.Context, a *artist.Artist) bul
 	result, err := r.executeRefreshCtx(ctx, a)
 	if err != nil {
 		// executeRefreshCtx already logged the underlying cause.
-		return bulkOutcomeFailed
+		return nil, err
 	}
 
 	// result.Metadata carries any language-promoted name; applyProviderName
 	// is a no-op on nil metadata, which is the correct behavior when the
 	// providers returned nothing to promote.
 	if r.applyProviderName(ctx, a, result.Metadata) {
-		r.logger.Warn("bulk refresh: provider name update failed", "artist_id", a.ID)
+		r.logger.Warn("refresh: provider name update failed", "artist_id", a.ID)
 	}
 
 	if r.eventBus != nil {
@@ -581,6 +593,23 @@ func (r *Router) refreshArtistForBulk(ctx context.Context, a *artist.Artist) bul
 
 	r.runRulesAfterRefresh(ctx, a)
 
+	return result, nil
+}
+
+// refreshArtistForBulk performs the bulk-action equivalent of
+// handleArtistRefresh for a single artist (#2283) and reports the outcome as a
+// bulkOutcome. It lives here, beside RefreshArtist and the follow-up helpers
+// it reuses, so the refresh paths stay visibly in sync. An artist RefreshArtist
+// refuses (no MusicBrainz ID, locked) is counted as Skipped and the run
+// continues.
+func (r *Router) refreshArtistForBulk(ctx context.Context, a *artist.Artist) bulkOutcome {
+	_, err := r.RefreshArtist(ctx, a)
+	switch {
+	case errors.Is(err, ErrRefreshNoMBID), errors.Is(err, ErrRefreshLocked):
+		return bulkOutcomeSkipped
+	case err != nil:
+		return bulkOutcomeFailed
+	}
 	return bulkOutcomeSucceeded
 }
 
@@ -99,7 +99,7 @@ func assertSecondaryIDsDiscarded(t *testing.T, db *sql.DB, artistID, afterWhat s
 
 // seedReidentifyTarget creates an artist carrying a full provider identity --
 // a MusicBrainz ID, a Discogs ID with a fetched_at stamp, and every secondary
-// modeled ID -- plus an orphan-provider fetched_at row (allmusic), and asserts
+// modeled ID -- plus an orphan-provider fetched_at row (genius), and asserts
 // every one of those rows exists. Those preconditions are what keep the "row
 // is gone" and "row survived" assertions below from passing against a database
 // that never had the row in the first place.
@@ -123,8 +123,8 @@ func seedReidentifyTarget(t *testing.T, db *sql.DB, svc *artist.Service, name, p
 	if err := svc.Create(ctx, a); err != nil {
 		t.Fatalf("Create: %v", err)
 	}
-	if err := svc.UpdateProviderFetchedAt(ctx, a.ID, string(provider.NameAllMusic)); err != nil {
-		t.Fatalf("stamp allmusic orphan: %v", err)
+	if err := svc.UpdateProviderFetchedAt(ctx, a.ID, string(provider.NameGenius)); err != nil {
+		t.Fatalf("stamp genius orphan: %v", err)
 	}
 
 	if exists, pid := reidentifyProviderRow(t, db, a.ID, string(provider.NameDiscogs)); !exists || pid != "99" {
@@ -133,8 +133,8 @@ func seedReidentifyTarget(t *testing.T, db *sql.DB, svc *artist.Service, name, p
 	if exists, pid := reidentifyProviderRow(t, db, a.ID, string(provider.NameMusicBrainz)); !exists || pid != "mbid-seed" {
 		t.Fatalf("precondition: musicbrainz row exists=%v provider_id=%q, want exists=true provider_id=%q", exists, pid, "mbid-seed")
 	}
-	if exists, _ := reidentifyProviderRow(t, db, a.ID, string(provider.NameAllMusic)); !exists {
-		t.Fatalf("precondition: allmusic orphan row missing before the flow starts")
+	if exists, _ := reidentifyProviderRow(t, db, a.ID, string(provider.NameGenius)); !exists {
+		t.Fatalf("precondition: genius orphan row missing before the flow starts")
 	}
 	for prov, want := range seededSecondaryIDs {
 		if exists, pid := reidentifyProviderRow(t, db, a.ID, string(prov)); !exists || pid != want {
@@ -197,8 +197,8 @@ func TestHandleReidentify_ClearIDsPersistsNothing(t *testing.T) {
 	if exists, pid := reidentifyProviderRow(t, db, a.ID, string(provider.NameDiscogs)); !exists || pid != "99" {
 		t.Errorf("discogs row after abandoned re-identify: exists=%v provider_id=%q, want exists=true provider_id=%q (#2714)", exists, pid, "99")
 	}
-	if exists, _ := reidentifyProviderRow(t, db, a.ID, string(provider.NameAllMusic)); !exists {
-		t.Errorf("allmusic orphan row destroyed by an abandoned re-identify")
+	if exists, _ := reidentifyProviderRow(t, db, a.ID, string(provider.NameGenius)); !exists {
+		t.Errorf("genius orphan row destroyed by an abandoned re-identify")
 	}
 	assertSecondaryIDsSurvive(t, db, a.ID, "an abandoned re-identify")
 
@@ -280,8 +280,8 @@ func TestHandleRefreshLink_MusicBrainzPickReplacesMBIDAndDiscardsStaleIDs(t *tes
 	// The orphan row must SURVIVE the write (#2725). The discard is scoped to
 	// modeled provider identities; it is not a blanket delete of every row for
 	// this artist, and that boundary is unchanged by #2894.
-	if exists, _ := reidentifyProviderRow(t, db, a.ID, string(provider.NameAllMusic)); !exists {
-		t.Errorf("allmusic orphan row destroyed by re-identify; scoped delete boundary is wrong (#2725)")
+	if exists, _ := reidentifyProviderRow(t, db, a.ID, string(provider.NameGenius)); !exists {
+		t.Errorf("genius orphan row destroyed by re-identify; scoped delete boundary is wrong (#2725)")
 	}
 
 	reloaded, err := svc.GetByID(context.Background(), a.ID)
@@ -333,8 +333,8 @@ func TestHandleRefreshLink_DiscogsPickDiscardsRepudiatedMBID(t *testing.T) {
 	assertSecondaryIDsDiscarded(t, db, a.ID, "a Discogs re-identify pick")
 	// The orphan row must SURVIVE the delete (#2725). This is the path where a
 	// modeled row really is deleted, so the boundary is live here.
-	if exists, _ := reidentifyProviderRow(t, db, a.ID, string(provider.NameAllMusic)); !exists {
-		t.Errorf("allmusic orphan row destroyed by the re-identify discard; scoped delete boundary is wrong (#2725)")
+	if exists, _ := reidentifyProviderRow(t, db, a.ID, string(provider.NameGenius)); !exists {
+		t.Errorf("genius orphan row destroyed by the re-identify discard; scoped delete boundary is wrong (#2725)")
 	}
 
 	reloaded, err := svc.GetByID(context.Background(), a.ID)
@@ -404,8 +404,8 @@ func TestHandleRefreshLink_DiscardRequiresIntentAndReplacement(t *testing.T) {
 				t.Errorf("musicbrainz row: exists=%v provider_id=%q, want exists=true provider_id=%q; an identity was discarded without both the re-identify intent and a replacement", exists, pid, "mbid-seed")
 			}
 			assertSecondaryIDsSurvive(t, db, a.ID, "a non-destructive link")
-			if exists, _ := reidentifyProviderRow(t, db, a.ID, string(provider.NameAllMusic)); !exists {
-				t.Errorf("allmusic orphan row destroyed on a non-destructive link")
+			if exists, _ := reidentifyProviderRow(t, db, a.ID, string(provider.NameGenius)); !exists {
+				t.Errorf("genius orphan row destroyed on a non-destructive link")
 			}
 
 			// The link the operator DID make must still be honored, so this
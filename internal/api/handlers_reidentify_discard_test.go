@@ -241,8 +241,8 @@ func TestHandleReIdentifyWizardAccept_DiscardsRepudiatedIDsAndCorrectsOrigin(t *
 	}
 	// The scoped-delete boundary from #2725 is unchanged: an orphan-provider
 	// fetched_at row is not a modeled identity and must survive.
-	if exists, _ := reidentifyProviderRow(t, db, a.ID, string(provider.NameAllMusic)); !exists {
-		t.Errorf("allmusic orphan row destroyed by the wizard discard; scoped delete boundary is wrong (#2725)")
+	if exists, _ := reidentifyProviderRow(t, db, a.ID, string(provider.NameGenius)); !exists {
+		t.Errorf("genius orphan row destroyed by the wizard discard; scoped delete boundary is wrong (#2725)")
 	}
 
 	// OUTCOME: the field the operator actually complained about.
@@ -159,7 +159,9 @@ func (r *Router) handleListRules(w http.ResponseWriter, req *http.Request) {
 	})
 }
 
-// handleUpdateRule updates a rule's enabled state and config.
+// handleUpdateRule updates a rule's enabled state and config. A custom rule
+// also accepts its definition fields (name, description, category,
+// expression, message); sending those for a built-in rule is a 400.
 // PUT /api/v1/rules/{id}
 func (r *Router) handleUpdateRule(w http.ResponseWriter, req *http.Request) {
 	ruleID, ok := RequirePathParam(w, req, "id")
@@ -182,10 +184,25 @@ func (r *Router) handleUpdateRule(w http.ResponseWriter, req *http.Request) {
 		Enabled        *bool            `json:"enabled"`
 		AutomationMode *string          `json:"automation_mode"`
 		Config         *rule.RuleConfig `json:"config"`
+		// The remaining fields apply to custom rules only; a built-in
+		// rule's definition is seeded from code on every start.
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		Category    *string `json:"category"`
+		Expression  *string `json:"expression"`
+		Message     *string `json:"message"`
 	}
 	if !DecodeJSON(w, req, &body) {
 		return
 	}
+	definitionChange := body.Name != nil || body.Description != nil || body.Category != nil ||
+		body.Expression != nil || body.Message != nil
+	if definitionChange && !existing.Custom {
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "name, description, category, expression and message can only be changed on custom rules",
+		})
+		return
+	}
 
 	if body.Enabled != nil {
 		// Prevent enabling a filesystem-dependent rule when no local library exists.
@@ -218,23 +235,37 @@ func (r *Router) handleUpdateRule(w http.ResponseWriter, req *http.Request) {
 		existing.Config = *body.Config
 	}
 
-	if err := r.ruleService.Update(req.Context(), existing); err != nil {
+	if existing.Custom {
+		if body.Name != nil {
+			existing.Name = *body.Name
+		}
+		if body.Description != nil {
+			existing.Description = *body.Description
+		}
+		if body.Category != nil {
+			existing.Category = rule.RuleCategory(*body.Category)
+		}
+		if body.Expression != nil {
+			existing.Expression = *body.Expression
+		}
+		if body.Message != nil {
+			existing.Message = *body.Message
+		}
+		err = r.ruleService.UpdateCustom(req.Context(), existing)
+	} else {
+		err = r.ruleService.Update(req.Context(), existing)
+	}
+	if err != nil {
+		if errors.Is(err, rule.ErrInvalidCustomRule) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
 		r.logger.Error("updating rule", "rule_id", ruleID, "error", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to update rule"})
 		return
 	}
 
-	// Invalidate the engine's rule list cache so the next evaluation reflects
-	// the updated rule immediately rather than waiting for the TTL to expire.
-	if r.ruleEngine != nil {
-		r.ruleEngine.InvalidateRuleCache()
-	}
-
-	// Also invalidate the health report cache since rule changes affect
-	// health scores (e.g. enabling/disabling a rule changes which violations
-	// are counted).
-	r.InvalidateHealthCache()
-
+	r.afterRuleMutation()
 	writeJSON(w, http.StatusOK, existing)
 }
 
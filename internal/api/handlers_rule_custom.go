@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/sydlexius/stillwater/internal/rule"
+	"github.com/sydlexius/stillwater/internal/ruleexpr"
+)
+
+// customRuleBody is the request shape for creating a custom rule. It mirrors
+// the fields of rule.Rule an operator may set; everything else (ID,
+// timestamps, Custom) is assigned by the service.
+type customRuleBody struct {
+	Name           string           `json:"name"`
+	Description    string           `json:"description"`
+	Category       string           `json:"category"`
+	Expression     string           `json:"expression"`
+	Message        string           `json:"message"`
+	Enabled        *bool            `json:"enabled"`
+	AutomationMode string           `json:"automation_mode"`
+	Config         *rule.RuleConfig `json:"config"`
+}
+
+// handleCreateRule creates an operator-defined rule from an expression over
+// the artist record (see rule.CustomRuleSchema). The rule is enabled unless
+// the body says otherwise, and starts evaluating on the next Run Rules pass.
+// POST /api/v1/rules
+func (r *Router) handleCreateRule(w http.ResponseWriter, req *http.Request) {
+	var body customRuleBody
+	if !DecodeJSON(w, req, &body) {
+		return
+	}
+	nr := &rule.Rule{
+		Name:           body.Name,
+		Description:    body.Description,
+		Category:       rule.RuleCategory(body.Category),
+		Expression:     body.Expression,
+		Message:        body.Message,
+		Enabled:        body.Enabled == nil || *body.Enabled,
+		AutomationMode: body.AutomationMode,
+	}
+	if body.Config != nil {
+		nr.Config = *body.Config
+	}
+	if err := r.ruleService.CreateCustom(req.Context(), nr); err != nil {
+		if errors.Is(err, rule.ErrInvalidCustomRule) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		r.logger.Error("creating custom rule", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to create rule"})
+		return
+	}
+	r.afterRuleMutation()
+	writeJSON(w, http.StatusCreated, nr)
+}
+
+// handleDeleteRule deletes a custom rule together with its violations and
+// results. Built-in rules cannot be deleted (409); disable them instead.
+// DELETE /api/v1/rules/{id}
+func (r *Router) handleDeleteRule(w http.ResponseWriter, req *http.Request) {
+	ruleID, ok := RequirePathParam(w, req, "id")
+	if !ok {
+		return
+	}
+	if err := r.ruleService.DeleteCustom(req.Context(), ruleID); err != nil {
+		switch {
+		case errors.Is(err, rule.ErrNotFound):
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "rule not found"})
+		case errors.Is(err, rule.ErrNotCustom):
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "built-in rules cannot be deleted; disable the rule instead"})
+		default:
+			r.logger.Error("deleting custom rule", "rule_id", ruleID, "error", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to delete rule"})
+		}
+		return
+	}
+	r.afterRuleMutation()
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// handleValidateRuleExpression compiles an expression without saving it, so
+// the rule editor can report a mistake (with its column) as the operator
+// types rather than on save. An invalid expression is a 200 with valid=false:
+// the request itself was fine.
+// POST /api/v1/rules/validate-expression
+func (r *Router) handleValidateRuleExpression(w http.ResponseWriter, req *http.Request) {
+	var body struct {
+		Expression string `json:"expression"`
+	}
+	if !DecodeJSON(w, req, &body) {
+		return
+	}
+	prog, err := rule.CompileExpression(body.Expression)
+	if err != nil {
+		resp := map[string]any{"valid": false, "error": err.Error()}
+		var ce *ruleexpr.Error
+		if errors.As(err, &ce) {
+			resp["column"] = ce.Pos
+		}
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"valid": true, "fields": prog.Fields()})
+}
+
+// afterRuleMutation drops the caches a rule change invalidates: the engine's
+// rule list (so the next evaluation sees the change immediately rather than
+// after the TTL) and the health report (whose denominator is the rule set).
+func (r *Router) afterRuleMutation() {
+	if r.ruleEngine != nil {
+		r.ruleEngine.InvalidateRuleCache()
+	}
+	r.InvalidateHealthCache()
+}
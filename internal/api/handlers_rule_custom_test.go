@@ -0,0 +1,136 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/rule"
+)
+
+func TestHandleCreateRule_CreatesCustomRule(t *testing.T) {
+	t.Parallel()
+	r, _ := testRouter(t)
+
+	body := strings.NewReader(`{"name":"No birth date","expression":"type == \"Person\" && born == \"\"","message":"{name} has no birth date"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/rules", body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.handleCreateRule(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	var created rule.Rule
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if !created.Custom || !created.Enabled || !strings.HasPrefix(created.ID, rule.CustomRuleIDPrefix) {
+		t.Errorf("created = %+v, want an enabled custom rule", created)
+	}
+	if _, err := r.ruleService.GetByID(context.Background(), created.ID); err != nil {
+		t.Errorf("GetByID(created): %v", err)
+	}
+}
+
+func TestHandleCreateRule_InvalidExpressionReturns400(t *testing.T) {
+	t.Parallel()
+	r, _ := testRouter(t)
+
+	body := strings.NewReader(`{"name":"typo","expression":"brn == \"\"","message":"m"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/rules", body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.handleCreateRule(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "brn") {
+		t.Errorf("body = %s, want it to name the unknown field", w.Body.String())
+	}
+}
+
+func TestHandleUpdateRule_DefinitionFieldsRejectedForBuiltin(t *testing.T) {
+	t.Parallel()
+	r, _ := testRouter(t)
+
+	ruleID := firstRuleID(t, r.ruleService)
+	body := strings.NewReader(`{"expression":"born == \"\""}`)
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/rules/"+ruleID, body)
+	req.Header.Set("Content-Type", "application/json")
+	req.SetPathValue("id", ruleID)
+	w := httptest.NewRecorder()
+
+	r.handleUpdateRule(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d; body: %s", w.Code, http.StatusBadRequest, w.Body.String())
+	}
+}
+
+func TestHandleDeleteRule(t *testing.T) {
+	t.Parallel()
+	r, _ := testRouter(t)
+	ctx := context.Background()
+
+	del := func(id string) int {
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/rules/"+id, nil)
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		r.handleDeleteRule(w, req)
+		return w.Code
+	}
+
+	if code := del(firstRuleID(t, r.ruleService)); code != http.StatusConflict {
+		t.Errorf("delete built-in: status = %d, want %d", code, http.StatusConflict)
+	}
+	if code := del(rule.CustomRuleIDPrefix + "missing"); code != http.StatusNotFound {
+		t.Errorf("delete missing: status = %d, want %d", code, http.StatusNotFound)
+	}
+
+	cr := &rule.Rule{Name: "n", Expression: `born == ""`, Message: "m", Enabled: true}
+	if err := r.ruleService.CreateCustom(ctx, cr); err != nil {
+		t.Fatalf("CreateCustom: %v", err)
+	}
+	if code := del(cr.ID); code != http.StatusOK {
+		t.Errorf("delete custom: status = %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestHandleValidateRuleExpression(t *testing.T) {
+	t.Parallel()
+	r, _ := testRouter(t)
+
+	validate := func(expr string) map[string]any {
+		b, _ := json.Marshal(map[string]string{"expression": expr})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/rules/validate-expression", strings.NewReader(string(b)))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.handleValidateRuleExpression(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+		var resp map[string]any
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		return resp
+	}
+
+	if resp := validate(`type == "Person" && brn == ""`); resp["valid"] != false || resp["column"] != float64(21) {
+		t.Errorf("invalid expression: resp = %v, want valid=false column=21", resp)
+	}
+	resp := validate(`len(genres) > 8`)
+	if resp["valid"] != true {
+		t.Fatalf("valid expression: resp = %v", resp)
+	}
+	if fields, _ := resp["fields"].([]any); len(fields) != 1 || fields[0] != "genres" {
+		t.Errorf("fields = %v, want [genres]", resp["fields"])
+	}
+}
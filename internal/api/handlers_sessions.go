@@ -0,0 +1,185 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/sydlexius/stillwater/internal/api/middleware"
+	"github.com/sydlexius/stillwater/internal/auth"
+	"github.com/sydlexius/stillwater/web/templates"
+)
+
+// currentSessionToken returns the caller's session cookie, or "" for API
+// token callers, so the lists can flag (and "sign out others" can keep) the
+// session making the request.
+func currentSessionToken(req *http.Request) string {
+	if middleware.AuthMethodFromContext(req.Context()) == "api_token" {
+		return ""
+	}
+	cookie, err := req.Cookie("session")
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// writeOwnSessions answers with the caller's sessions: the drawer card for
+// HTMX, JSON otherwise.
+func (r *Router) writeOwnSessions(w http.ResponseWriter, req *http.Request, userID string) {
+	sessions, err := r.authService.ListSessions(req.Context(), userID, currentSessionToken(req))
+	if err != nil {
+		r.logger.Error("failed to list sessions", "user_id", userID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An internal error occurred. Please try again."})
+		return
+	}
+	w.Header().Set("Vary", "HX-Request")
+	if req.Header.Get("HX-Request") == "true" {
+		renderTempl(w, req, templates.SessionsCard(sessions))
+		return
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+// writeAllSessions answers with every user's sessions: the administrator
+// list for HTMX, JSON otherwise.
+func (r *Router) writeAllSessions(w http.ResponseWriter, req *http.Request) {
+	sessions, err := r.authService.ListAllSessions(req.Context(), currentSessionToken(req))
+	if err != nil {
+		r.logger.Error("failed to list all sessions", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An internal error occurred. Please try again."})
+		return
+	}
+	w.Header().Set("Vary", "HX-Request")
+	if req.Header.Get("HX-Request") == "true" {
+		renderTempl(w, req, templates.UserSessionRows(sessions))
+		return
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+// handleListSessions returns the caller's active sessions with the client
+// each was created from.
+// GET /api/v1/auth/sessions
+func (r *Router) handleListSessions(w http.ResponseWriter, req *http.Request) {
+	userID := middleware.UserIDFromContext(req.Context())
+	if userID == "" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	r.writeOwnSessions(w, req, userID)
+}
+
+// handleRevokeSession signs out one of the caller's sessions. Revoking the
+// session making the request works, but leaves a dead cookie behind;
+// /auth/logout is the way to sign out of this browser.
+// DELETE /api/v1/auth/sessions/{id}
+func (r *Router) handleRevokeSession(w http.ResponseWriter, req *http.Request) {
+	userID := middleware.UserIDFromContext(req.Context())
+	if userID == "" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	id := req.PathValue("id")
+	if err := r.authService.RevokeSession(req.Context(), id, userID); err != nil {
+		if errors.Is(err, auth.ErrSessionNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "Session not found."})
+			return
+		}
+		r.logger.Error("failed to revoke session", "user_id", userID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An internal error occurred. Please try again."})
+		return
+	}
+	r.logger.Info("session revoked", "user_id", userID)
+	if req.Header.Get("HX-Request") == "true" {
+		r.writeOwnSessions(w, req, userID)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRevokeSessions signs out all of the caller's sessions, or all but
+// the current one with ?except_current=true.
+// DELETE /api/v1/auth/sessions
+func (r *Router) handleRevokeSessions(w http.ResponseWriter, req *http.Request) {
+	userID := middleware.UserIDFromContext(req.Context())
+	if userID == "" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	except := ""
+	if req.URL.Query().Get("except_current") == "true" {
+		except = currentSessionToken(req)
+	}
+	n, err := r.authService.RevokeUserSessions(req.Context(), userID, except)
+	if err != nil {
+		r.logger.Error("failed to revoke sessions", "user_id", userID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An internal error occurred. Please try again."})
+		return
+	}
+	r.logger.Info("sessions revoked", "user_id", userID, "count", n)
+	if req.Header.Get("HX-Request") == "true" {
+		if except == "" {
+			// The caller's own session went too; send the browser to login.
+			w.Header().Set("HX-Redirect", strings.TrimRight(r.basePath, "/")+"/")
+			writeJSON(w, http.StatusOK, map[string]any{"revoked": n})
+			return
+		}
+		r.writeOwnSessions(w, req, userID)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"revoked": n})
+}
+
+// handleListAllSessions returns every user's active sessions.
+// GET /api/v1/users/sessions (admin only)
+func (r *Router) handleListAllSessions(w http.ResponseWriter, req *http.Request) {
+	r.writeAllSessions(w, req)
+}
+
+// handleAdminRevokeSession signs out any user's session.
+// DELETE /api/v1/users/sessions/{id} (admin only)
+func (r *Router) handleAdminRevokeSession(w http.ResponseWriter, req *http.Request) {
+	id := req.PathValue("id")
+	if err := r.authService.RevokeSession(req.Context(), id, ""); err != nil {
+		if errors.Is(err, auth.ErrSessionNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "Session not found."})
+			return
+		}
+		r.logger.Error("failed to revoke session", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An internal error occurred. Please try again."})
+		return
+	}
+	r.logger.Info("session revoked by administrator", "admin_id", middleware.UserIDFromContext(req.Context()))
+	if req.Header.Get("HX-Request") == "true" {
+		r.writeAllSessions(w, req)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRevokeUserSessions signs out every session of one user, for an
+// account that may be compromised.
+// DELETE /api/v1/users/{id}/account/sessions (admin only)
+func (r *Router) handleRevokeUserSessions(w http.ResponseWriter, req *http.Request) {
+	id := req.PathValue("id")
+	if _, err := r.authService.GetUserByID(req.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "User not found."})
+			return
+		}
+		r.logger.Error("failed to look up user for session revocation", "user_id", id, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An internal error occurred. Please try again."})
+		return
+	}
+	n, err := r.authService.RevokeUserSessions(req.Context(), id, "")
+	if err != nil {
+		r.logger.Error("failed to revoke user sessions", "user_id", id, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An internal error occurred. Please try again."})
+		return
+	}
+	r.logger.Info("user sessions revoked by administrator",
+		"user_id", id, "count", n, "admin_id", middleware.UserIDFromContext(req.Context()))
+	writeJSON(w, http.StatusOK, map[string]any{"revoked": n})
+}
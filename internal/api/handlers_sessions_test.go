@@ -0,0 +1,185 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/auth"
+)
+
+// newClientSession creates a session for userID as if signed in from ip.
+func newClientSession(t *testing.T, authSvc *auth.Service, userID, ip string) string {
+	t.Helper()
+	ctx := auth.WithClientInfo(context.Background(), auth.ClientInfo{IP: ip, UserAgent: "Mozilla/5.0 Firefox/140.0"})
+	token, err := authSvc.CreateSession(ctx, userID)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	return token
+}
+
+func withSessionCookie(req *http.Request, token string) *http.Request {
+	req.AddCookie(&http.Cookie{Name: "session", Value: token})
+	return req
+}
+
+func decodeSessions(t *testing.T, w *httptest.ResponseRecorder) []auth.Session {
+	t.Helper()
+	var sessions []auth.Session
+	if err := json.NewDecoder(w.Body).Decode(&sessions); err != nil {
+		t.Fatalf("decoding sessions: %v; body: %s", err, w.Body.String())
+	}
+	return sessions
+}
+
+func TestSessionSelfService(t *testing.T) {
+	t.Parallel()
+	r, authSvc, adminID := testRouterWithAuth(t)
+	current := newClientSession(t, authSvc, adminID, "192.0.2.1")
+	newClientSession(t, authSvc, adminID, "192.0.2.2")
+	newClientSession(t, authSvc, adminID, "192.0.2.3")
+
+	w := httptest.NewRecorder()
+	r.handleListSessions(w, withSessionCookie(withUserCtx(httptest.NewRequest(http.MethodGet, "/api/v1/auth/sessions", nil), adminID), current))
+	if w.Code != http.StatusOK {
+		t.Fatalf("list: status = %d, body: %s", w.Code, w.Body.String())
+	}
+	// testRouterWithAuth has already signed the admin in once.
+	sessions := decodeSessions(t, w)
+	if len(sessions) != 4 {
+		t.Fatalf("got %d sessions, want 4", len(sessions))
+	}
+	var other string
+	for _, s := range sessions {
+		if s.Current != (s.IPAddress == "192.0.2.1") {
+			t.Errorf("session from %s: current = %v", s.IPAddress, s.Current)
+		}
+		if s.IPAddress == "192.0.2.2" {
+			other = s.ID
+		}
+	}
+
+	req := withUserCtx(httptest.NewRequest(http.MethodDelete, "/api/v1/auth/sessions/"+other, nil), adminID)
+	req.SetPathValue("id", other)
+	w = httptest.NewRecorder()
+	r.handleRevokeSession(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("revoke: status = %d, body: %s", w.Code, w.Body.String())
+	}
+
+	req = withUserCtx(httptest.NewRequest(http.MethodDelete, "/api/v1/auth/sessions/"+other, nil), adminID)
+	req.SetPathValue("id", other)
+	w = httptest.NewRecorder()
+	r.handleRevokeSession(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("revoke twice: status = %d, want 404", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.handleRevokeSessions(w, withSessionCookie(withUserCtx(httptest.NewRequest(http.MethodDelete, "/api/v1/auth/sessions?except_current=true", nil), adminID), current))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"revoked":2`) {
+		t.Fatalf("revoke others: status = %d, body: %s", w.Code, w.Body.String())
+	}
+	if _, err := authSvc.ValidateSession(context.Background(), current); err != nil {
+		t.Errorf("current session was signed out: %v", err)
+	}
+}
+
+func TestSessionSelfService_CannotRevokeOthers(t *testing.T) {
+	t.Parallel()
+	r, authSvc, adminID := testRouterWithAuth(t)
+	other, err := authSvc.CreateLocalUser(context.Background(), "carol", "password123", "Carol", "operator", "")
+	if err != nil {
+		t.Fatalf("CreateLocalUser: %v", err)
+	}
+	token := newClientSession(t, authSvc, other.ID, "192.0.2.9")
+	sessions, err := authSvc.ListSessions(context.Background(), other.ID, "")
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+
+	req := withUserCtx(httptest.NewRequest(http.MethodDelete, "/api/v1/auth/sessions/"+sessions[0].ID, nil), adminID)
+	req.SetPathValue("id", sessions[0].ID)
+	w := httptest.NewRecorder()
+	r.handleRevokeSession(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", w.Code)
+	}
+	if _, err := authSvc.ValidateSession(context.Background(), token); err != nil {
+		t.Errorf("another user's session was revoked: %v", err)
+	}
+}
+
+func TestSessionAdminView(t *testing.T) {
+	t.Parallel()
+	r, authSvc, adminID := testRouterWithAuth(t)
+	other, err := authSvc.CreateLocalUser(context.Background(), "carol", "password123", "Carol", "operator", "")
+	if err != nil {
+		t.Fatalf("CreateLocalUser: %v", err)
+	}
+	newClientSession(t, authSvc, adminID, "192.0.2.1")
+	carol := newClientSession(t, authSvc, other.ID, "192.0.2.2")
+	newClientSession(t, authSvc, other.ID, "192.0.2.3")
+
+	w := httptest.NewRecorder()
+	r.handleListAllSessions(w, withAdminCtx(httptest.NewRequest(http.MethodGet, "/api/v1/users/sessions", nil), adminID))
+	sessions := decodeSessions(t, w)
+	if len(sessions) != 4 {
+		t.Fatalf("got %d sessions, want 4 (with the admin's test login)", len(sessions))
+	}
+	var carolSession string
+	for _, s := range sessions {
+		if s.Username == "carol" && s.IPAddress == "192.0.2.3" {
+			carolSession = s.ID
+		}
+	}
+
+	req := withAdminCtx(httptest.NewRequest(http.MethodDelete, "/api/v1/users/sessions/"+carolSession, nil), adminID)
+	req.SetPathValue("id", carolSession)
+	w = httptest.NewRecorder()
+	r.handleAdminRevokeSession(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("admin revoke: status = %d, body: %s", w.Code, w.Body.String())
+	}
+
+	req = withAdminCtx(httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+other.ID+"/account/sessions", nil), adminID)
+	req.SetPathValue("id", other.ID)
+	w = httptest.NewRecorder()
+	r.handleRevokeUserSessions(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"revoked":1`) {
+		t.Fatalf("revoke user sessions: status = %d, body: %s", w.Code, w.Body.String())
+	}
+	if _, err := authSvc.ValidateSession(context.Background(), carol); err == nil {
+		t.Error("carol's session still validates")
+	}
+
+	req = withAdminCtx(httptest.NewRequest(http.MethodDelete, "/api/v1/users/missing/account/sessions", nil), adminID)
+	req.SetPathValue("id", "missing")
+	w = httptest.NewRecorder()
+	r.handleRevokeUserSessions(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown user: status = %d, want 404", w.Code)
+	}
+}
+
+func TestSessionsCard_HTMX(t *testing.T) {
+	t.Parallel()
+	r, authSvc, adminID := testRouterWithAuth(t)
+	current := newClientSession(t, authSvc, adminID, "192.0.2.1")
+	newClientSession(t, authSvc, adminID, "192.0.2.2")
+
+	req := withSessionCookie(withUserCtx(httptest.NewRequest(http.MethodGet, "/api/v1/auth/sessions", nil), adminID), current)
+	req.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+	r.handleListSessions(w, req)
+	body := w.Body.String()
+	for _, want := range []string{"192.0.2.1", "192.0.2.2", "Firefox", "except_current=true"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("card is missing %q", want)
+		}
+	}
+}
@@ -169,6 +169,18 @@ func (r *Router) applyLiveSettingSideEffects(body map[string]string) {
 			r.backupService.SetMaxAgeDays(n)
 		}
 	}
+	if r.artworkRevisions != nil {
+		if v, ok := body["artwork.revisions.keep"]; ok {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				r.artworkRevisions.SetKeep(n)
+			}
+		}
+		if v, ok := body["artwork.revisions.max_age_days"]; ok {
+			if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+				r.artworkRevisions.SetMaxAgeDays(n)
+			}
+		}
+	}
 	if v, ok := body["scanner.exclusions"]; ok && !r.opsSettingEnvPinned("scanner.exclusions", "SW_SCANNER_EXCLUSIONS") {
 		if r.scannerService == nil {
 			r.logger.Warn("persisted but not applied live: scanner service unavailable", "key", "scanner.exclusions")
@@ -183,6 +195,13 @@ func (r *Router) applyLiveSettingSideEffects(body map[string]string) {
 			r.scannerService.SetMtimeFastPath(v == "true" || v == "1")
 		}
 	}
+	if v, ok := body["scanner.tag_identity"]; ok && !r.opsSettingEnvPinned("scanner.tag_identity", "SW_SCANNER_TAG_IDENTITY") {
+		if r.scannerService == nil {
+			r.logger.Warn("persisted but not applied live: scanner service unavailable", "key", "scanner.tag_identity")
+		} else {
+			r.scannerService.SetTagIdentity(v == "true" || v == "1")
+		}
+	}
 	if v, ok := body["rule_engine.artist_workers"]; ok && !r.opsSettingEnvPinned("rule_engine.artist_workers", "SW_RULE_ENGINE_ARTIST_WORKERS") {
 		if r.pipeline == nil {
 			r.logger.Warn("persisted but not applied live: rule pipeline unavailable", "key", "rule_engine.artist_workers")
@@ -8,9 +8,11 @@ import (
 	"html"
 	"io"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/sydlexius/stillwater/internal/backup"
 	"github.com/sydlexius/stillwater/internal/settingsio"
 )
 
@@ -37,10 +39,16 @@ import (
 // Rate-limiting is wired at the router (loginRL) so brute-forcing the
 // passphrase against this endpoint is throttled the same way as login.
 //
+// Instead of an uploaded export, the form may name a full backup archive
+// on an off-box backup target as remote=<target>:<filename> (see
+// handleSetupRestoreRemote). The archive is downloaded and checked against
+// its checksum, and the settings it carries are restored, so a rebuilt host
+// can come back without first copying a file onto it.
+//
 // On success the response carries an HX-Redirect to the root path so the
 // user signs in with the restored credentials.
 //
-// POST /api/v1/setup/restore (multipart form: file, passphrase)
+// POST /api/v1/setup/restore (multipart form: file or remote, passphrase)
 func (r *Router) handleSetupRestore(w http.ResponseWriter, req *http.Request) {
 	// Serialize the entire HasUsers -> onboarding probe -> Import ->
 	// onboarding flip sequence. The handler is unauthenticated and
@@ -56,7 +64,7 @@ func (r *Router) handleSetupRestore(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	envelope, passphrase, status, msg, ok := parseRestoreInput(w, req)
+	envelope, passphrase, status, msg, ok := r.parseRestoreInput(w, req)
 	if !ok {
 		r.writeRestoreErr(w, req, status, msg)
 		return
@@ -193,9 +201,11 @@ func (r *Router) checkRestoreGates(req *http.Request) (int, string, bool) {
 
 // parseRestoreInput pulls the envelope and passphrase out of the
 // multipart form, enforcing the size cap and scrubbing the passphrase
-// from the parsed form maps. Returns (envelope, passphrase, 0, "",
-// true) on success or (nil, "", status, msg, false) on the first
-// failure so the handler can write the matching response.
+// from the parsed form maps. The envelope comes from the uploaded file,
+// or from a remote backup archive when the remote field is set. Returns
+// (envelope, passphrase, 0, "", true) on success or (nil, "", status,
+// msg, false) on the first failure so the handler can write the matching
+// response.
 //
 // The body is wrapped in http.MaxBytesReader BEFORE ParseMultipartForm
 // so an attacker on this unauthenticated endpoint cannot push a
@@ -208,7 +218,7 @@ func (r *Router) checkRestoreGates(req *http.Request) (int, string, bool) {
 // in place as belt-and-suspenders for any path that bypasses
 // ParseMultipartForm (e.g. a future client that posts raw multipart
 // without the framing the helper expects).
-func parseRestoreInput(w http.ResponseWriter, req *http.Request) (*settingsio.Envelope, string, int, string, bool) {
+func (r *Router) parseRestoreInput(w http.ResponseWriter, req *http.Request) (*settingsio.Envelope, string, int, string, bool) {
 	req.Body = http.MaxBytesReader(w, req.Body, maxImportSize+1)
 	if err := req.ParseMultipartForm(maxImportSize); err != nil {
 		var maxErr *http.MaxBytesError
@@ -235,6 +245,13 @@ func parseRestoreInput(w http.ResponseWriter, req *http.Request) (*settingsio.En
 	if passphrase == "" {
 		return nil, "", http.StatusBadRequest, "Passphrase is required.", false
 	}
+	if remote := req.FormValue("remote"); remote != "" {
+		envelope, status, msg := r.fetchRemoteEnvelope(req, remote)
+		if envelope == nil {
+			return nil, "", status, msg, false
+		}
+		return envelope, passphrase, 0, "", true
+	}
 	file, _, err := req.FormFile("file")
 	if err != nil {
 		return nil, "", http.StatusBadRequest, "Backup file is required.", false
@@ -254,6 +271,91 @@ func parseRestoreInput(w http.ResponseWriter, req *http.Request) (*settingsio.En
 	return &envelope, passphrase, 0, "", true
 }
 
+// fetchRemoteEnvelope downloads the full backup archive named by remote
+// ("<target>:<filename>") into the local backup directory and returns the
+// settings envelope it carries. On failure it returns a nil envelope with
+// the status and message to answer with.
+func (r *Router) fetchRemoteEnvelope(req *http.Request, remote string) (*settingsio.Envelope, int, string) {
+	if r.backupService == nil {
+		return nil, http.StatusServiceUnavailable, "Restore from a backup target is not available on this server."
+	}
+	target, filename, ok := strings.Cut(remote, ":")
+	if !ok || !strings.HasSuffix(strings.TrimSuffix(filename, backup.EncryptedSuffix), ".zip") || !backup.IsValidBackupFilename(filename) {
+		return nil, http.StatusBadRequest, "Choose a full backup archive to restore from."
+	}
+	info, err := r.backupService.FetchRemote(req.Context(), target, filename)
+	if err != nil {
+		r.logger.Error("restore: fetching remote backup", "target", target, "filename", filename, "error", err)
+		switch {
+		case errors.Is(err, backup.ErrUnknownTarget):
+			return nil, http.StatusBadRequest, "That backup target is not configured."
+		case errors.Is(err, os.ErrNotExist):
+			return nil, http.StatusNotFound, "That backup is no longer on the target."
+		case errors.Is(err, backup.ErrChecksumMismatch), errors.Is(err, backup.ErrNoChecksum):
+			return nil, http.StatusUnprocessableEntity, "The backup could not be verified against its checksum."
+		default:
+			return nil, http.StatusBadGateway, "Downloading the backup failed: see server logs for details."
+		}
+	}
+	envelope, err := r.backupService.ArchiveSettings(info.Filename)
+	if err != nil {
+		r.logger.Error("restore: reading settings from remote backup", "filename", info.Filename, "error", err)
+		switch {
+		case errors.Is(err, backup.ErrNotInArchive):
+			return nil, http.StatusBadRequest, "This archive was made without a passphrase and holds no settings."
+		case errors.Is(err, backup.ErrEncrypted), errors.Is(err, backup.ErrWrongKey):
+			return nil, http.StatusUnprocessableEntity, "This backup is encrypted. Set SW_BACKUP_PASSPHRASE or SW_BACKUP_KEY_FILE to the key it was made with and restart."
+		case errors.Is(err, backup.ErrCorruptArchive):
+			return nil, http.StatusUnprocessableEntity, "The backup archive is corrupt."
+		default:
+			return nil, http.StatusInternalServerError, "Reading the backup archive failed: see server logs for details."
+		}
+	}
+	return envelope, 0, ""
+}
+
+// handleSetupRestoreRemote lists the full backup archives on the
+// configured backup targets, for the restore form's remote picker. It is
+// behind the same gates as handleSetupRestore. HTMX callers get <option>
+// elements whose values are the form's remote field; others get JSON.
+//
+// GET /api/v1/setup/restore/remote
+func (r *Router) handleSetupRestoreRemote(w http.ResponseWriter, req *http.Request) {
+	if status, msg, ok := r.checkRestoreGates(req); !ok {
+		r.writeRestoreErr(w, req, status, msg)
+		return
+	}
+	var archives []backup.RemoteBackup
+	if r.backupService != nil {
+		remote, err := r.backupService.ListRemote(req.Context())
+		if err != nil {
+			r.logger.Error("restore: listing backup targets", "error", err)
+			r.writeRestoreErr(w, req, http.StatusBadGateway, "Listing the backup targets failed: see server logs for details.")
+			return
+		}
+		for _, b := range remote {
+			if b.Kind == backup.KindArchive {
+				archives = append(archives, b)
+			}
+		}
+	}
+	if !acceptsJSON(req) && req.Header.Get("HX-Request") == "true" {
+		w.Header().Set("Content-Type", "text/html")
+		var sb strings.Builder
+		for _, b := range archives {
+			value := b.Target + ":" + b.Filename
+			label := fmt.Sprintf("%s: %s (%s)", b.Target, b.CreatedAt.Local().Format("2006-01-02 15:04"), formatBytes(b.Size))
+			fmt.Fprintf(&sb, `<option value="%s">%s</option>`, html.EscapeString(value), html.EscapeString(label))
+		}
+		_, _ = io.WriteString(w, sb.String())
+		return
+	}
+	if archives == nil {
+		archives = []backup.RemoteBackup{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"backups": archives})
+}
+
 // classifyRestoreError maps a settingsio.Import error to a user-facing
 // status + message. Never echoes the raw error string -- in particular
 // the passphrase must not land in any user-facing message.
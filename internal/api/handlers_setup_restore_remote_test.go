@@ -0,0 +1,182 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/backup"
+)
+
+// memBackupTarget is an in-memory backup.Target standing in for S3 or SFTP.
+type memBackupTarget struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func (m *memBackupTarget) Name() string { return "mem" }
+
+func (m *memBackupTarget) Put(_ context.Context, name string, r io.Reader, _ int64) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[name] = data
+	return nil
+}
+
+func (m *memBackupTarget) Get(_ context.Context, name string, w io.Writer) error {
+	m.mu.Lock()
+	data, ok := m.files[name]
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%s: %w", name, os.ErrNotExist)
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+func (m *memBackupTarget) List(context.Context) ([]backup.RemoteObject, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var objects []backup.RemoteObject
+	for name, data := range m.files {
+		objects = append(objects, backup.RemoteObject{Name: name, Size: int64(len(data))})
+	}
+	return objects, nil
+}
+
+func (m *memBackupTarget) Delete(_ context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, name)
+	return nil
+}
+
+// pushedArchive makes a full archive with settings on a source instance
+// holding an admin, pushes it to a fresh in-memory target, and returns the
+// target and the archive's filename.
+func pushedArchive(t *testing.T, passphrase string) (*memBackupTarget, string) {
+	t.Helper()
+	ctx := context.Background()
+	_, sioSvc, db := settingsIOTestDeps(t)
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO users (id, username, display_name, password_hash, role,
+		                   auth_provider, is_active, is_protected, created_at)
+		VALUES ('u-alice', 'alice', 'Alice', 'bcrypt$alice-hash', 'administrator',
+		        'local', 1, 0, '2026-01-01T00:00:00Z')
+	`); err != nil {
+		t.Fatalf("seeding source admin: %v", err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	target := &memBackupTarget{files: make(map[string][]byte)}
+	svc := backup.NewService(db, t.TempDir(), 7, logger).WithSettingsExporter(sioSvc).AddTarget(target, 7)
+	info, err := svc.Archive(ctx, backup.ArchiveOptions{Passphrase: passphrase})
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if err := svc.Push(ctx, info.Filename); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	return target, info.Filename
+}
+
+func remoteRestoreRequest(t *testing.T, remote, passphrase string) *http.Request {
+	t.Helper()
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	_ = mw.WriteField("passphrase", passphrase)
+	_ = mw.WriteField("remote", remote)
+	if err := mw.Close(); err != nil {
+		t.Fatalf("closing multipart writer: %v", err)
+	}
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/api/v1/setup/restore", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// TestRestoreOOBE_FromRemoteTarget restores a fresh install from a full
+// archive held on a backup target: the picker lists it, and posting its
+// remote reference restores the archived users.
+func TestRestoreOOBE_FromRemoteTarget(t *testing.T) {
+	t.Parallel()
+	const passphrase = "remote-pass-1"
+	target, filename := pushedArchive(t, passphrase)
+
+	r, _, db := settingsIOTestDeps(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r.backupService = backup.NewService(db, t.TempDir(), 7, logger).AddTarget(target, 7)
+
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodGet, "/api/v1/setup/restore/remote", nil)
+	req.Header.Set("HX-Request", "true")
+	w := httptest.NewRecorder()
+	r.handleSetupRestoreRemote(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `value="mem:`+filename+`"`) {
+		t.Fatalf("remote list = %d %s, want an option for %s", w.Code, w.Body.String(), filename)
+	}
+
+	w = httptest.NewRecorder()
+	r.handleSetupRestore(w, remoteRestoreRequest(t, "mem:"+filename, passphrase))
+	if w.Code != http.StatusOK {
+		t.Fatalf("restore status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	var id string
+	if err := db.QueryRowContext(context.Background(),
+		`SELECT id FROM users WHERE username = 'alice'`).Scan(&id); err != nil || id != "u-alice" {
+		t.Fatalf("restored alice = %q, %v; want u-alice", id, err)
+	}
+}
+
+func TestRestoreOOBE_FromRemoteTarget_Errors(t *testing.T) {
+	t.Parallel()
+	const passphrase = "remote-pass-2"
+	target, filename := pushedArchive(t, passphrase)
+
+	for _, tc := range []struct {
+		name   string
+		remote string
+		mutate func()
+		want   int
+	}{
+		{"unknown target", "nas:" + filename, nil, http.StatusBadRequest},
+		{"database snapshot", "mem:stillwater-20260101-000000.db", nil, http.StatusBadRequest},
+		{"missing archive", "mem:stillwater-20200101-000000.zip", nil, http.StatusNotFound},
+		{"corrupt download", "mem:" + filename, func() {
+			target.mu.Lock()
+			defer target.mu.Unlock()
+			data := bytes.Clone(target.files[filename])
+			data[len(data)/2] ^= 0xff
+			target.files[filename] = data
+		}, http.StatusUnprocessableEntity},
+	} {
+		r, _, db := settingsIOTestDeps(t)
+		logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+		r.backupService = backup.NewService(db, t.TempDir(), 7, logger).AddTarget(target, 7)
+		if tc.mutate != nil {
+			tc.mutate()
+		}
+		w := httptest.NewRecorder()
+		req := remoteRestoreRequest(t, tc.remote, passphrase)
+		req.Header.Set("Accept", "application/json")
+		r.handleSetupRestore(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s: status = %d, want %d; body: %s", tc.name, w.Code, tc.want, w.Body.String())
+		}
+		var resp map[string]string
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp["error"] == "" {
+			t.Errorf("%s: body %s is not a JSON error", tc.name, w.Body.String())
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
@@ -337,6 +338,19 @@ func buildConnectionPushFailedMsg(data map[string]any) string {
 	return base
 }
 
+// buildBackupPushFailedMsg returns the server-composed push-failure message
+// (see cmd/stillwater wireEventSubscriptions), falling back to the target
+// name when it is missing.
+func buildBackupPushFailedMsg(data map[string]any) string {
+	if msg := strVal(data, "message"); msg != "" {
+		return msg
+	}
+	if target := strVal(data, "target"); target != "" {
+		return "Backup could not be copied to " + target
+	}
+	return "A backup could not be copied off-box"
+}
+
 // buildBackdropCollisionMsg returns the server-composed collision message. The
 // notifier (internal/collision) builds the full sentence -- naming the colliding
 // artist and the similarity -- and places it on Data["message"], so the hub just
@@ -389,6 +403,10 @@ var sseEventMappings = []sseEventMapping{
 	// error_class + artist_name fields; the raw transport error is
 	// deliberately NOT in Data. See internal/publish/notifier.go.
 	{event.ConnectionPushFailed, "Platform push failed", buildConnectionPushFailedMsg},
+	// BackupPushFailed Data carries target, filename, stage and a
+	// pre-composed message; the raw error is only logged. See
+	// internal/backup/target.go.
+	{event.BackupPushFailed, "Backup push failed", buildBackupPushFailedMsg},
 	// BackdropCollision Data carries the dest + colliding artist ids/names,
 	// similarity %, distinct-artist count, and a pre-composed message; the client
 	// renders a warning toast linking the colliding artist. See internal/collision.
@@ -479,6 +497,10 @@ func (r *Router) handleSSEStream(w http.ResponseWriter, req *http.Request) {
 		r.logger.Error("failed to clear write deadline for SSE stream", "err", err)
 	}
 
+	// A caller restricted to specific libraries (by its API token or its
+	// account's grant) only sees events about those libraries.
+	filter := r.newSSELibraryFilter(req)
+
 	// Register this client with the hub. Register BEFORE computing replay so
 	// that any event broadcast during replay still lands on the live channel
 	// (no gap); the replay boundary then suppresses the duplicate.
@@ -517,6 +539,9 @@ func (r *Router) handleSSEStream(w http.ResponseWriter, req *http.Request) {
 	}
 	// Replay missed events; each retains its original id and type.
 	for _, evt := range replay {
+		if !filter.allows(evt) {
+			continue
+		}
 		if err := writeSSEEvent(w, evt.Type, evt, r.logger); err != nil {
 			return
 		}
@@ -545,6 +570,9 @@ func (r *Router) handleSSEStream(w http.ResponseWriter, req *http.Request) {
 					continue
 				}
 			}
+			if !filter.allows(evt) {
+				continue
+			}
 			if err := writeSSEEvent(w, evt.Type, evt, r.logger); err != nil {
 				// Write failed -- client likely disconnected.
 				return
@@ -561,6 +589,62 @@ func (r *Router) handleSSEStream(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// sseFilterCacheMax bounds the per-stream artist-to-libraries cache. A long-
+// lived stream during a large scan would otherwise remember every artist it
+// has seen; clearing it costs one lookup per artist on the next events.
+const sseFilterCacheMax = 1000
+
+// sseLibraryFilter drops events about libraries the stream's caller may not
+// see (see middleware.LibraryVisible). An event names its library through a
+// "library_id" field or, more often, an "artist_id" whose libraries are
+// looked up through the Router's LibraryResolver and cached for the life of
+// the stream. Events that name neither (settings changes, progress of a
+// server-wide operation) are delivered. A nil filter allows everything.
+type sseLibraryFilter struct {
+	ctx      context.Context
+	resolver middleware.LibraryResolver
+	artists  map[string][]string
+}
+
+// newSSELibraryFilter returns the filter for a stream request, or nil when
+// the caller is not restricted to any library.
+func (r *Router) newSSELibraryFilter(req *http.Request) *sseLibraryFilter {
+	ctx := req.Context()
+	if len(middleware.TokenLibraryIDsFromContext(ctx)) == 0 && len(middleware.UserLibraryIDsFromContext(ctx)) == 0 {
+		return nil
+	}
+	return &sseLibraryFilter{ctx: ctx, resolver: r, artists: make(map[string][]string)}
+}
+
+// allows reports whether evt may be sent on the stream. An artist that can
+// no longer be resolved (deleted, or a lookup error) hides the event: the
+// filter fails closed.
+func (f *sseLibraryFilter) allows(evt SSEEvent) bool {
+	if f == nil {
+		return true
+	}
+	if id, _ := evt.Data["library_id"].(string); id != "" {
+		return middleware.LibraryVisible(f.ctx, []string{id})
+	}
+	artistID, _ := evt.Data["artist_id"].(string)
+	if artistID == "" {
+		return true
+	}
+	libraries, ok := f.artists[artistID]
+	if !ok {
+		var err error
+		libraries, err = f.resolver.ArtistLibraryIDs(f.ctx, artistID)
+		if err != nil {
+			return false
+		}
+		if len(f.artists) >= sseFilterCacheMax {
+			clear(f.artists)
+		}
+		f.artists[artistID] = libraries
+	}
+	return len(libraries) > 0 && middleware.LibraryVisible(f.ctx, libraries)
+}
+
 // writeSSEEvent writes a single SSE event to the response writer.
 // Returns an error if JSON marshaling or writing fails. Write errors
 // typically indicate the client has disconnected.
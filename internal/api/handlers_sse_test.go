@@ -644,3 +644,66 @@ func TestSSEHub_BroadcastsConnectionPushFailed(t *testing.T) {
 		t.Fatal("did not receive connection.push_failed event within timeout")
 	}
 }
+
+// stubLibraryResolver maps artist ids to libraries for sseLibraryFilter tests
+// and counts lookups so the per-stream cache can be observed.
+type stubLibraryResolver struct {
+	artists map[string][]string
+	lookups int
+}
+
+func (s *stubLibraryResolver) ArtistLibraryIDs(_ context.Context, artistID string) ([]string, error) {
+	s.lookups++
+	libs, ok := s.artists[artistID]
+	if !ok {
+		return nil, middleware.ErrScopeTargetNotFound
+	}
+	return libs, nil
+}
+
+func (s *stubLibraryResolver) AlbumLibraryIDs(context.Context, string) ([]string, error) {
+	return nil, middleware.ErrScopeTargetNotFound
+}
+
+// TestSSELibraryFilter pins the activity stream filter for a caller granted
+// one library: events about that library pass, events about another library
+// or an unknown artist are dropped, and events naming no library pass.
+func TestSSELibraryFilter(t *testing.T) {
+	resolver := &stubLibraryResolver{artists: map[string][]string{
+		"a-mine":  {"lib-1"},
+		"a-other": {"lib-2"},
+		"a-both":  {"lib-2", "lib-1"},
+	}}
+	ctx := middleware.WithTestUserLibraries(context.Background(), []string{"lib-1"})
+	f := &sseLibraryFilter{ctx: ctx, resolver: resolver, artists: make(map[string][]string)}
+
+	cases := []struct {
+		name string
+		data map[string]any
+		want bool
+	}{
+		{"own artist", map[string]any{"artist_id": "a-mine"}, true},
+		{"other library's artist", map[string]any{"artist_id": "a-other"}, false},
+		{"artist in both", map[string]any{"artist_id": "a-both"}, true},
+		{"unknown artist", map[string]any{"artist_id": "a-gone"}, false},
+		{"own library", map[string]any{"library_id": "lib-1"}, true},
+		{"other library", map[string]any{"library_id": "lib-2", "artist_id": "a-mine"}, false},
+		{"no library", map[string]any{"status": "ok"}, true},
+	}
+	for _, tc := range cases {
+		if got := f.allows(SSEEvent{Type: "test", Data: tc.data}); got != tc.want {
+			t.Errorf("%s: allows = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	before := resolver.lookups
+	f.allows(SSEEvent{Type: "test", Data: map[string]any{"artist_id": "a-mine"}})
+	if resolver.lookups != before {
+		t.Errorf("repeat artist looked up again; lookups %d -> %d", before, resolver.lookups)
+	}
+
+	var unrestricted *sseLibraryFilter
+	if !unrestricted.allows(SSEEvent{Type: "test", Data: map[string]any{"artist_id": "a-other"}}) {
+		t.Error("nil filter must allow every event")
+	}
+}
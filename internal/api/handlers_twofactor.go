@@ -0,0 +1,342 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sydlexius/stillwater/internal/api/middleware"
+	"github.com/sydlexius/stillwater/internal/auth"
+	"github.com/sydlexius/stillwater/web/templates"
+)
+
+// writeSecondFactorChallenge answers a correct password on an account that
+// needs a second step. HTMX gets the code form (and, for a forced
+// enrollment, the secret to add to an app) in place of the login result;
+// API callers get the challenge to post to /api/v1/auth/login/2fa. No
+// session cookie is set.
+func (r *Router) writeSecondFactorChallenge(w http.ResponseWriter, req *http.Request, sf *auth.SecondFactorRequiredError) {
+	if req.Header.Get("HX-Request") == "true" {
+		// return_url is carried through as given; the second step
+		// sanitizes it before redirecting.
+		renderTempl(w, req, templates.LoginSecondFactor(sf.Challenge, sf.Enrollment, req.FormValue("return_url")))
+		return
+	}
+	resp := map[string]any{
+		"status":    "second_factor_required",
+		"challenge": sf.Challenge,
+	}
+	if sf.Enrollment != nil {
+		resp["enrollment"] = sf.Enrollment
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// beginSecondFactor runs the second-step check for a local user who has just
+// proved their password. It returns true when the login may go on to create
+// a session; otherwise it has written the challenge or an error.
+func (r *Router) beginSecondFactor(w http.ResponseWriter, req *http.Request, userID string) bool {
+	err := r.authService.BeginSecondFactor(req.Context(), userID)
+	if err == nil {
+		return true
+	}
+	var sf *auth.SecondFactorRequiredError
+	if errors.As(err, &sf) {
+		r.writeSecondFactorChallenge(w, req, sf)
+		return false
+	}
+	r.logger.Error("failed to start two-factor login", "user_id", userID, "error", err)
+	writeFormError(w, req, http.StatusInternalServerError, "An internal error occurred. Please try again.")
+	return false
+}
+
+// handleLoginSecondFactor completes a login that answered with a challenge,
+// using an authentication code or a recovery code, and sets the session
+// cookie. When the step confirmed a forced enrollment the new recovery codes
+// are returned once: HTMX shows them with a link on to the app instead of
+// redirecting.
+// POST /api/v1/auth/login/2fa (public)
+func (r *Router) handleLoginSecondFactor(w http.ResponseWriter, req *http.Request) {
+	var body struct {
+		Challenge string `json:"challenge"`
+		Code      string `json:"code"`
+		ReturnURL string `json:"return_url"`
+	}
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+		if !DecodeJSON(w, req, &body) {
+			return
+		}
+	} else {
+		req.Body = http.MaxBytesReader(w, req.Body, 1<<20)
+		body.Challenge = req.FormValue("challenge")
+		body.Code = req.FormValue("code")
+		body.ReturnURL = req.FormValue("return_url")
+	}
+	if body.Challenge == "" || strings.TrimSpace(body.Code) == "" {
+		writeFormError(w, req, http.StatusBadRequest, "Enter the code from your authenticator app.")
+		return
+	}
+
+	// Wrong codes count towards the same lockout as wrong passwords, so
+	// a known password does not buy unlimited guesses across challenges.
+	res, err := r.authService.CompleteSecondFactor(req.Context(), body.Challenge, body.Code)
+	var locked *auth.LockedError
+	switch {
+	case errors.As(err, &locked):
+		r.writeLoginLocked(w, req, "", locked)
+		return
+	case errors.Is(err, auth.ErrInvalidSecondFactor):
+		r.logger.Warn("two-factor login failed: invalid code", "error", err)
+		writeFormError(w, req, http.StatusUnauthorized, "Invalid authentication code.")
+		return
+	case errors.Is(err, auth.ErrLoginChallengeInvalid):
+		const msg = "Your sign-in has expired. Please sign in again."
+		if req.Header.Get("HX-Request") == "true" {
+			w.Header().Set("HX-Redirect", r.basePath+"/?error="+url.QueryEscape(msg))
+		}
+		writeFormError(w, req, http.StatusUnauthorized, msg)
+		return
+	case err != nil:
+		r.logger.Error("two-factor login failed", "error", err)
+		writeFormError(w, req, http.StatusInternalServerError, "An internal error occurred. Please try again.")
+		return
+	}
+
+	r.setSessionCookie(w, req, res.Token)
+	dest := sanitizeReturnTo(body.ReturnURL, r.basePath)
+	if len(res.RecoveryCodes) > 0 {
+		if req.Header.Get("HX-Request") == "true" {
+			w.Header().Set("HX-Retarget", "#login-result")
+			w.Header().Set("HX-Reswap", "innerHTML")
+			renderTempl(w, req, templates.LoginRecoveryCodes(res.RecoveryCodes, dest))
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"status": "ok", "recovery_codes": res.RecoveryCodes})
+		return
+	}
+	w.Header().Set("HX-Redirect", dest)
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// twoFactorCaller returns the signed-in user's ID for the self-service
+// two-factor routes, which only a browser session may call: an API token
+// must not be able to enroll, or turn off, its owner's second factor.
+func twoFactorCaller(w http.ResponseWriter, req *http.Request) (string, bool) {
+	userID := middleware.UserIDFromContext(req.Context())
+	if userID == "" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return "", false
+	}
+	if middleware.AuthMethodFromContext(req.Context()) == "api_token" {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "Two-factor authentication can only be managed from a signed-in session."})
+		return "", false
+	}
+	return userID, true
+}
+
+// decodeTwoFactorCode reads the "code" field from a JSON or form body.
+func decodeTwoFactorCode(w http.ResponseWriter, req *http.Request) (string, bool) {
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+		var body struct {
+			Code string `json:"code"`
+		}
+		if !DecodeJSON(w, req, &body) {
+			return "", false
+		}
+		return body.Code, true
+	}
+	req.Body = http.MaxBytesReader(w, req.Body, 1<<20)
+	return req.FormValue("code"), true
+}
+
+// writeTwoFactorError answers a failed self-service two-factor request.
+func (r *Router) writeTwoFactorError(w http.ResponseWriter, req *http.Request, userID string, err error) {
+	switch {
+	case errors.Is(err, auth.ErrInvalidSecondFactor):
+		writeFormError(w, req, http.StatusUnauthorized, "Invalid authentication code.")
+	case errors.Is(err, auth.ErrTwoFactorEnabled):
+		writeFormError(w, req, http.StatusConflict, "Two-factor authentication is already enabled.")
+	case errors.Is(err, auth.ErrTwoFactorNotEnabled):
+		writeFormError(w, req, http.StatusConflict, "Two-factor authentication is not enabled. Start the setup again.")
+	case errors.Is(err, auth.ErrTwoFactorRequired):
+		writeFormError(w, req, http.StatusForbidden, "Your administrator requires two-factor authentication for this account.")
+	case errors.Is(err, auth.ErrTwoFactorLocalOnly):
+		writeFormError(w, req, http.StatusBadRequest, "Two-factor authentication is only available for local accounts.")
+	case errors.Is(err, auth.ErrNoEncryptor):
+		r.logger.Error("two-factor authentication needs the encryption key", "user_id", userID)
+		writeFormError(w, req, http.StatusServiceUnavailable, "Two-factor authentication is not available on this server.")
+	default:
+		r.logger.Error("two-factor request failed", "user_id", userID, "error", err)
+		writeFormError(w, req, http.StatusInternalServerError, "An internal error occurred. Please try again.")
+	}
+}
+
+// handleGetTwoFactor returns the caller's two-factor status; HTMX gets the
+// account card.
+// GET /api/v1/auth/2fa
+func (r *Router) handleGetTwoFactor(w http.ResponseWriter, req *http.Request) {
+	userID, ok := twoFactorCaller(w, req)
+	if !ok {
+		return
+	}
+	st, err := r.authService.GetTwoFactorStatus(req.Context(), userID)
+	if err != nil {
+		r.writeTwoFactorError(w, req, userID, err)
+		return
+	}
+	if req.Header.Get("HX-Request") == "true" {
+		renderTempl(w, req, templates.TwoFactorCard(*st))
+		return
+	}
+	writeJSON(w, http.StatusOK, st)
+}
+
+// handleEnrollTwoFactor starts enrollment and returns the new secret and its
+// otpauth URI, shown once.
+// POST /api/v1/auth/2fa/enroll
+func (r *Router) handleEnrollTwoFactor(w http.ResponseWriter, req *http.Request) {
+	userID, ok := twoFactorCaller(w, req)
+	if !ok {
+		return
+	}
+	enrollment, err := r.authService.BeginTOTPEnrollment(req.Context(), userID)
+	if err != nil {
+		r.writeTwoFactorError(w, req, userID, err)
+		return
+	}
+	if req.Header.Get("HX-Request") == "true" {
+		retargetTwoFactorCard(w)
+		renderTempl(w, req, templates.TwoFactorEnroll(enrollment))
+		return
+	}
+	writeJSON(w, http.StatusOK, enrollment)
+}
+
+// handleConfirmTwoFactor enables the pending secret with a code from the
+// app and returns the recovery codes, shown once.
+// POST /api/v1/auth/2fa/confirm
+func (r *Router) handleConfirmTwoFactor(w http.ResponseWriter, req *http.Request) {
+	userID, ok := twoFactorCaller(w, req)
+	if !ok {
+		return
+	}
+	code, ok := decodeTwoFactorCode(w, req)
+	if !ok {
+		return
+	}
+	codes, err := r.authService.ConfirmTOTPEnrollment(req.Context(), userID, code)
+	if err != nil {
+		r.writeTwoFactorError(w, req, userID, err)
+		return
+	}
+	r.writeRecoveryCodes(w, req, codes)
+}
+
+// handleRegenerateRecoveryCodes replaces the caller's recovery codes after
+// checking a current code.
+// POST /api/v1/auth/2fa/recovery-codes
+func (r *Router) handleRegenerateRecoveryCodes(w http.ResponseWriter, req *http.Request) {
+	userID, ok := twoFactorCaller(w, req)
+	if !ok {
+		return
+	}
+	code, ok := decodeTwoFactorCode(w, req)
+	if !ok {
+		return
+	}
+	codes, err := r.authService.RegenerateRecoveryCodes(req.Context(), userID, code)
+	if err != nil {
+		r.writeTwoFactorError(w, req, userID, err)
+		return
+	}
+	r.writeRecoveryCodes(w, req, codes)
+}
+
+// handleDisableTwoFactor turns off the caller's two-factor authentication
+// after checking a current code, unless the policy requires it.
+// POST /api/v1/auth/2fa/disable
+func (r *Router) handleDisableTwoFactor(w http.ResponseWriter, req *http.Request) {
+	userID, ok := twoFactorCaller(w, req)
+	if !ok {
+		return
+	}
+	code, ok := decodeTwoFactorCode(w, req)
+	if !ok {
+		return
+	}
+	if err := r.authService.DisableTOTP(req.Context(), userID, code); err != nil {
+		r.writeTwoFactorError(w, req, userID, err)
+		return
+	}
+	if req.Header.Get("HX-Request") == "true" {
+		st, err := r.authService.GetTwoFactorStatus(req.Context(), userID)
+		if err != nil {
+			r.writeTwoFactorError(w, req, userID, err)
+			return
+		}
+		retargetTwoFactorCard(w)
+		renderTempl(w, req, templates.TwoFactorCard(*st))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (r *Router) writeRecoveryCodes(w http.ResponseWriter, req *http.Request, codes []string) {
+	if req.Header.Get("HX-Request") == "true" {
+		retargetTwoFactorCard(w)
+		renderTempl(w, req, templates.TwoFactorRecoveryCodes(codes))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"recovery_codes": codes})
+}
+
+// retargetTwoFactorCard points an HTMX success response at the whole
+// account card. The self-service forms target their inline error slot, so
+// only errors land there.
+func retargetTwoFactorCard(w http.ResponseWriter) {
+	w.Header().Set("HX-Retarget", "#sw-two-factor")
+	w.Header().Set("HX-Reswap", "innerHTML")
+}
+
+// handleResetUserTwoFactor removes another user's two-factor enrollment and
+// recovery codes, for a user who lost their authenticator. Under a policy
+// that covers them, their next login enrolls them again. HTMX gets the
+// updated user row.
+// DELETE /api/v1/users/{id}/account/2fa (admin only)
+func (r *Router) handleResetUserTwoFactor(w http.ResponseWriter, req *http.Request) {
+	id := req.PathValue("id")
+	if id == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "User ID is required."})
+		return
+	}
+	if _, err := r.authService.GetUserByID(req.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "User not found."})
+			return
+		}
+		r.logger.Error("failed to look up user for two-factor reset", "user_id", id, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An internal error occurred. Please try again."})
+		return
+	}
+	if err := r.authService.ResetTwoFactor(req.Context(), id); err != nil {
+		r.logger.Error("failed to reset two-factor authentication", "user_id", id, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An internal error occurred. Please try again."})
+		return
+	}
+	r.logger.Info("two-factor authentication reset by administrator",
+		"user_id", id, "admin_id", middleware.UserIDFromContext(req.Context()))
+
+	if req.Header.Get("HX-Request") == "true" {
+		u, err := r.authService.GetUserByID(req.Context(), id)
+		if err != nil {
+			r.logger.Error("failed to reload user after two-factor reset", "user_id", id, "error", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An internal error occurred. Please try again."})
+			return
+		}
+		r.renderUserTableRows(w, req, []auth.User{*u})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,310 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // G505: RFC 6238 TOTP is defined over HMAC-SHA1.
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/api/middleware"
+	"github.com/sydlexius/stillwater/internal/auth"
+	"github.com/sydlexius/stillwater/internal/encryption"
+)
+
+// testRouterWithTwoFactor is testRouterWithAuth with an encryptor on the
+// auth service, so TOTP secrets can be stored.
+func testRouterWithTwoFactor(t *testing.T) (*Router, *auth.Service, string) {
+	t.Helper()
+	r, authSvc, adminID := testRouterWithAuth(t)
+	enc, _, err := encryption.NewEncryptor("")
+	if err != nil {
+		t.Fatalf("NewEncryptor: %v", err)
+	}
+	authSvc.WithEncryptor(enc)
+	return r, authSvc, adminID
+}
+
+// currentTOTP computes the RFC 6238 code for a base32 secret at now.
+func currentTOTP(t *testing.T, secret string) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatalf("decoding secret: %v", err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(time.Now().Unix()/30)) //nolint:gosec // G115: Unix time is positive.
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	off := sum[len(sum)-1] & 0x0f
+	v := binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", v%1_000_000)
+}
+
+func jsonPost(target, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func hasSessionCookie(w *httptest.ResponseRecorder) bool {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "session" && c.Value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLoginSecondFactor_EnrolledUser(t *testing.T) {
+	t.Parallel()
+	r, authSvc, adminID := testRouterWithTwoFactor(t)
+	ctx := context.Background()
+
+	e, err := authSvc.BeginTOTPEnrollment(ctx, adminID)
+	if err != nil {
+		t.Fatalf("BeginTOTPEnrollment: %v", err)
+	}
+	codes, err := authSvc.ConfirmTOTPEnrollment(ctx, adminID, currentTOTP(t, e.Secret))
+	if err != nil {
+		t.Fatalf("ConfirmTOTPEnrollment: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	r.handleLogin(w, jsonPost("/api/v1/auth/login", `{"username":"admin","password":"password"}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: status = %d, body: %s", w.Code, w.Body.String())
+	}
+	if hasSessionCookie(w) {
+		t.Fatal("login set a session cookie before the second factor")
+	}
+	var challenge struct {
+		Status    string `json:"status"`
+		Challenge string `json:"challenge"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&challenge); err != nil {
+		t.Fatalf("decoding login response: %v", err)
+	}
+	if challenge.Status != "second_factor_required" || challenge.Challenge == "" {
+		t.Fatalf("login response = %+v, want a second-factor challenge", challenge)
+	}
+
+	w = httptest.NewRecorder()
+	r.handleLoginSecondFactor(w, jsonPost("/api/v1/auth/login/2fa", `{"challenge":"`+challenge.Challenge+`","code":"not-a-code"}`))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong code: status = %d, want 401", w.Code)
+	}
+
+	// A recovery code completes the login; the TOTP code used to confirm the
+	// enrollment above would be refused as a replay within the same step.
+	w = httptest.NewRecorder()
+	r.handleLoginSecondFactor(w, jsonPost("/api/v1/auth/login/2fa", `{"challenge":"`+challenge.Challenge+`","code":"`+codes[0]+`"}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("recovery code: status = %d, body: %s", w.Code, w.Body.String())
+	}
+	if !hasSessionCookie(w) {
+		t.Error("completed second factor did not set a session cookie")
+	}
+
+	// The challenge is single use.
+	w = httptest.NewRecorder()
+	r.handleLoginSecondFactor(w, jsonPost("/api/v1/auth/login/2fa", `{"challenge":"`+challenge.Challenge+`","code":"`+codes[1]+`"}`))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("reused challenge: status = %d, want 401", w.Code)
+	}
+}
+
+func TestLoginSecondFactor_PolicyForcesEnrollment(t *testing.T) {
+	t.Parallel()
+	r, _, _ := testRouterWithTwoFactor(t)
+	if _, err := r.db.Exec(`INSERT INTO settings (key, value, updated_at) VALUES (?, ?, datetime('now'))`,
+		auth.TwoFactorPolicySetting, auth.TwoFactorPolicyEveryone); err != nil {
+		t.Fatalf("setting policy: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	r.handleLogin(w, jsonPost("/api/v1/auth/login", `{"username":"admin","password":"password"}`))
+	var resp struct {
+		Challenge  string               `json:"challenge"`
+		Enrollment *auth.TOTPEnrollment `json:"enrollment"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding login response: %v", err)
+	}
+	if resp.Enrollment == nil || resp.Enrollment.Secret == "" {
+		t.Fatalf("login response has no enrollment under the everyone policy")
+	}
+
+	w = httptest.NewRecorder()
+	r.handleLoginSecondFactor(w, jsonPost("/api/v1/auth/login/2fa", `{"challenge":"`+resp.Challenge+`","code":"`+currentTOTP(t, resp.Enrollment.Secret)+`"}`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("confirming enrollment: status = %d, body: %s", w.Code, w.Body.String())
+	}
+	var done struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&done); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(done.RecoveryCodes) == 0 {
+		t.Error("forced enrollment returned no recovery codes")
+	}
+	if !hasSessionCookie(w) {
+		t.Error("forced enrollment did not set a session cookie")
+	}
+}
+
+func TestTwoFactorSelfService_Lifecycle(t *testing.T) {
+	t.Parallel()
+	r, _, adminID := testRouterWithTwoFactor(t)
+
+	w := httptest.NewRecorder()
+	r.handleEnrollTwoFactor(w, withUserCtx(jsonPost("/api/v1/auth/2fa/enroll", ""), adminID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("enroll: status = %d, body: %s", w.Code, w.Body.String())
+	}
+	var e auth.TOTPEnrollment
+	if err := json.NewDecoder(w.Body).Decode(&e); err != nil {
+		t.Fatalf("decoding enrollment: %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	r.handleConfirmTwoFactor(w, withUserCtx(jsonPost("/api/v1/auth/2fa/confirm", `{"code":"000000x"}`), adminID))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("confirm with a bad code: status = %d, want 401", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.handleConfirmTwoFactor(w, withUserCtx(jsonPost("/api/v1/auth/2fa/confirm", `{"code":"`+currentTOTP(t, e.Secret)+`"}`), adminID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("confirm: status = %d, body: %s", w.Code, w.Body.String())
+	}
+	var issued struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&issued); err != nil {
+		t.Fatalf("decoding recovery codes: %v", err)
+	}
+	if len(issued.RecoveryCodes) == 0 {
+		t.Fatal("confirm returned no recovery codes")
+	}
+
+	w = httptest.NewRecorder()
+	r.handleGetTwoFactor(w, withUserCtx(httptest.NewRequest(http.MethodGet, "/api/v1/auth/2fa", nil), adminID))
+	var st auth.TwoFactorStatus
+	if err := json.NewDecoder(w.Body).Decode(&st); err != nil {
+		t.Fatalf("decoding status: %v", err)
+	}
+	if !st.Enabled || st.RecoveryCodesRemaining != len(issued.RecoveryCodes) {
+		t.Errorf("status = %+v, want enabled with %d recovery codes", st, len(issued.RecoveryCodes))
+	}
+
+	// The confirming TOTP code is spent for its time step, so the follow-up
+	// calls prove possession with recovery codes.
+	w = httptest.NewRecorder()
+	r.handleRegenerateRecoveryCodes(w, withUserCtx(jsonPost("/api/v1/auth/2fa/recovery-codes", `{"code":"`+issued.RecoveryCodes[0]+`"}`), adminID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("regenerate: status = %d, body: %s", w.Code, w.Body.String())
+	}
+	var replaced struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&replaced); err != nil {
+		t.Fatalf("decoding new recovery codes: %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	r.handleDisableTwoFactor(w, withUserCtx(jsonPost("/api/v1/auth/2fa/disable", `{"code":"`+issued.RecoveryCodes[1]+`"}`), adminID))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("disable with a replaced recovery code: status = %d, want 401", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.handleDisableTwoFactor(w, withUserCtx(jsonPost("/api/v1/auth/2fa/disable", `{"code":"`+replaced.RecoveryCodes[0]+`"}`), adminID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("disable: status = %d, body: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestTwoFactorSelfService_RefusesAPIToken(t *testing.T) {
+	t.Parallel()
+	r, _, adminID := testRouterWithTwoFactor(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/2fa/enroll", nil)
+	req = req.WithContext(middleware.WithTestTokenGrant(req.Context(), &auth.TokenGrant{UserID: adminID, Scopes: "admin"}))
+	w := httptest.NewRecorder()
+	r.handleEnrollTwoFactor(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", w.Code)
+	}
+}
+
+func TestHandleResetUserTwoFactor(t *testing.T) {
+	t.Parallel()
+	r, authSvc, adminID := testRouterWithTwoFactor(t)
+	ctx := context.Background()
+
+	e, err := authSvc.BeginTOTPEnrollment(ctx, adminID)
+	if err != nil {
+		t.Fatalf("BeginTOTPEnrollment: %v", err)
+	}
+	if _, err := authSvc.ConfirmTOTPEnrollment(ctx, adminID, currentTOTP(t, e.Secret)); err != nil {
+		t.Fatalf("ConfirmTOTPEnrollment: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+adminID+"/account/2fa", nil)
+	req.SetPathValue("id", adminID)
+	req = withAdminCtx(req, adminID)
+	w := httptest.NewRecorder()
+	r.handleResetUserTwoFactor(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204; body: %s", w.Code, w.Body.String())
+	}
+
+	st, err := authSvc.GetTwoFactorStatus(ctx, adminID)
+	if err != nil {
+		t.Fatalf("GetTwoFactorStatus: %v", err)
+	}
+	if st.Enabled {
+		t.Error("two-factor still enabled after reset")
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/users/missing/account/2fa", nil)
+	req.SetPathValue("id", "missing")
+	req = withAdminCtx(req, adminID)
+	w = httptest.NewRecorder()
+	r.handleResetUserTwoFactor(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown user: status = %d, want 404", w.Code)
+	}
+}
+
+func TestHandleRegister_TwoFactorPolicySkipsAutoLogin(t *testing.T) {
+	t.Parallel()
+	r, authSvc, adminID := testRouterWithTwoFactor(t)
+	if _, err := r.db.Exec(`INSERT INTO settings (key, value, updated_at) VALUES (?, ?, datetime('now'))`,
+		auth.TwoFactorPolicySetting, auth.TwoFactorPolicyEveryone); err != nil {
+		t.Fatalf("setting policy: %v", err)
+	}
+	invite, err := authSvc.CreateInvite(context.Background(), "operator", adminID, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("creating invite: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	r.handleRegister(w, jsonPost("/api/v1/users/register", `{"code":"`+invite.Code+`","username":"newuser","password":"securepassword123"}`))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("register: status = %d, body: %s", w.Code, w.Body.String())
+	}
+	if hasSessionCookie(w) {
+		t.Error("registration signed in a user the policy requires to enroll first")
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/api/middleware"
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/rule"
+)
+
+// handleListArtistUndo returns an artist's undo records, newest first. Each
+// applied record can be reverted through POST /api/v1/fix-undo/{undoId}.
+// GET /api/v1/artists/{id}/undo
+func (r *Router) handleListArtistUndo(w http.ResponseWriter, req *http.Request) {
+	if r.undoStore == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "undo not available"})
+		return
+	}
+	artistID, ok := RequirePathParam(w, req, "id")
+	if !ok {
+		return
+	}
+	if _, err := r.artistService.GetByID(req.Context(), artistID); err != nil {
+		if errors.Is(err, artist.ErrNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "artist not found"})
+			return
+		}
+		r.logger.Error("failed to verify artist for undo history", "artist_id", artistID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	r.writeUndoList(w, req, rule.UndoFilter{ArtistID: artistID})
+}
+
+// handleListBulkJobUndo returns the undo records a bulk job produced, one per
+// artist it changed, newest first.
+// GET /api/v1/bulk/jobs/{id}/undo
+func (r *Router) handleListBulkJobUndo(w http.ResponseWriter, req *http.Request) {
+	if r.undoStore == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "undo not available"})
+		return
+	}
+	jobID, ok := RequirePathParam(w, req, "id")
+	if !ok {
+		return
+	}
+	r.writeUndoList(w, req, rule.UndoFilter{BulkJobID: jobID})
+}
+
+// writeUndoList applies the usual limit/offset paging to filter and writes
+// the page.
+func (r *Router) writeUndoList(w http.ResponseWriter, req *http.Request, filter rule.UndoFilter) {
+	userID := middleware.UserIDFromContext(req.Context())
+	filter.Limit = r.getUserPageSize(req.Context(), userID, intQuery(req, "limit", 0))
+	filter.Offset = max(intQuery(req, "offset", 0), 0)
+
+	records, total, err := r.undoStore.List(req.Context(), filter)
+	if err != nil {
+		r.logger.Error("listing undo records", "artist_id", filter.ArtistID, "bulk_job_id", filter.BulkJobID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if records == nil {
+		records = []rule.UndoRecord{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"records": records,
+		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+	})
+}
+
+// handleRevertBulkJob reverts every still-applied undo record of a bulk job.
+// Records that conflict with a later change are skipped and counted, so one
+// artist edited since the job does not block unwinding the rest. Runs to
+// completion even if the client disconnects; a job of several hundred artists
+// takes seconds, not minutes, because each revert is a handful of row writes
+// and at most one NFO rewrite.
+// POST /api/v1/bulk/jobs/{id}/undo
+func (r *Router) handleRevertBulkJob(w http.ResponseWriter, req *http.Request) {
+	if r.undoStore == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "undo not available"})
+		return
+	}
+	jobID, ok := RequirePathParam(w, req, "id")
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(req.Context()), 10*time.Minute)
+	defer cancel()
+
+	result, err := r.undoStore.RevertBulkJob(ctx, jobID)
+	if err != nil {
+		r.logger.Error("reverting bulk job", "job_id", jobID, "reverted", result.Reverted, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "failed to revert bulk job"})
+		return
+	}
+	r.logger.Info("bulk job reverted", "job_id", jobID,
+		"reverted", result.Reverted, "conflicts", result.Conflicts, "failed", result.Failed)
+	if result.Reverted > 0 {
+		r.InvalidateHealthCache()
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/rule"
+)
+
+func TestHandleListArtistUndo(t *testing.T) {
+	t.Parallel()
+	r, artistSvc := testRouterWithStubPipeline(t, &stubPipeline{})
+
+	undoID, a := seedUndoRecord(t, r, artistSvc, "Undo List Artist", "v-list")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/artists/"+a.ID+"/undo", nil)
+	req.SetPathValue("id", a.ID)
+	w := httptest.NewRecorder()
+
+	r.handleListArtistUndo(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var resp struct {
+		Records []rule.UndoRecord `json:"records"`
+		Total   int               `json:"total"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+	if resp.Total != 1 || len(resp.Records) != 1 || resp.Records[0].ID != undoID {
+		t.Fatalf("resp = %+v, want the one seeded record", resp)
+	}
+	if resp.Records[0].Status != rule.UndoStatusApplied {
+		t.Errorf("status = %q, want %q", resp.Records[0].Status, rule.UndoStatusApplied)
+	}
+}
+
+func TestHandleListArtistUndo_ArtistNotFound(t *testing.T) {
+	t.Parallel()
+	r, _ := testRouterWithStubPipeline(t, &stubPipeline{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/artists/missing/undo", nil)
+	req.SetPathValue("id", "missing")
+	w := httptest.NewRecorder()
+
+	r.handleListArtistUndo(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+// TestHandleListBulkJobUndo checks that a bulk job's listing holds the
+// records that job produced and no others, and honors the offset.
+func TestHandleListBulkJobUndo(t *testing.T) {
+	t.Parallel()
+	r, artistSvc := testRouterWithStubPipeline(t, &stubPipeline{})
+	ctx := context.Background()
+	const jobID = "job-list"
+
+	want := make(map[string]bool)
+	for i, name := range []string{"Bulk List A", "Bulk List B", "Bulk List Other"} {
+		a := addTestArtist(t, artistSvc, name)
+		rec := &rule.UndoRecord{
+			ArtistID:  a.ID,
+			BulkJobID: jobID,
+			Fields:    []rule.FieldChange{{Field: "biography", NewValue: "Bulk biography."}},
+		}
+		if i == 2 {
+			rec.BulkJobID = "job-other"
+		}
+		if err := r.undoStore.Save(ctx, rec); err != nil {
+			t.Fatalf("saving undo record: %v", err)
+		}
+		if rec.BulkJobID == jobID {
+			want[rec.ID] = true
+		}
+	}
+	// A single fix made outside any job is not listed either.
+	seedUndoRecord(t, r, artistSvc, "Bulk List Single", "v-bulk-list")
+
+	list := func(query string) (records []rule.UndoRecord, total int) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/bulk/jobs/"+jobID+"/undo"+query, nil)
+		req.SetPathValue("id", jobID)
+		w := httptest.NewRecorder()
+
+		r.handleListBulkJobUndo(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+		var resp struct {
+			Records []rule.UndoRecord `json:"records"`
+			Total   int               `json:"total"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decoding: %v", err)
+		}
+		return resp.Records, resp.Total
+	}
+
+	records, total := list("")
+	if total != 2 || len(records) != 2 {
+		t.Fatalf("got %d of %d records, want 2 of 2", len(records), total)
+	}
+	for _, rec := range records {
+		if !want[rec.ID] || rec.BulkJobID != jobID || rec.Status != rule.UndoStatusApplied {
+			t.Errorf("record = %+v, want an applied record of %s", rec, jobID)
+		}
+	}
+
+	if records, total = list("?offset=1"); total != 2 || len(records) != 1 {
+		t.Errorf("offset 1: got %d of %d records, want 1 of 2", len(records), total)
+	}
+}
+
+// TestHandleRevertBulkJob checks that reverting a job restores every artist
+// it changed except one edited since, which is counted as a conflict.
+func TestHandleRevertBulkJob(t *testing.T) {
+	t.Parallel()
+	r, artistSvc := testRouterWithStubPipeline(t, &stubPipeline{})
+	ctx := context.Background()
+	const jobID = "job-revert"
+
+	var artistIDs []string
+	for _, name := range []string{"Bulk Undo A", "Bulk Undo B", "Bulk Undo C"} {
+		a := addTestArtist(t, artistSvc, name)
+		if _, err := artistSvc.UpdateField(ctx, a.ID, "biography", "Bulk biography."); err != nil {
+			t.Fatalf("setting biography: %v", err)
+		}
+		rec := &rule.UndoRecord{
+			ArtistID:  a.ID,
+			BulkJobID: jobID,
+			Fields:    []rule.FieldChange{{Field: "biography", NewValue: "Bulk biography."}},
+		}
+		if err := r.undoStore.Save(ctx, rec); err != nil {
+			t.Fatalf("saving undo record: %v", err)
+		}
+		artistIDs = append(artistIDs, a.ID)
+	}
+	if _, err := artistSvc.UpdateField(ctx, artistIDs[2], "biography", "Edited by hand."); err != nil {
+		t.Fatalf("editing biography: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/bulk/jobs/"+jobID+"/undo", nil)
+	req.SetPathValue("id", jobID)
+	w := httptest.NewRecorder()
+
+	r.handleRevertBulkJob(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var result rule.UndoBatchResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+	if result.Reverted != 2 || result.Conflicts != 1 || result.Failed != 0 {
+		t.Errorf("result = %+v, want 2 reverted and 1 conflict", result)
+	}
+	if got := biographyOf(t, artistSvc, artistIDs[0]); got != "" {
+		t.Errorf("biography of reverted artist = %q, want it cleared", got)
+	}
+	if got := biographyOf(t, artistSvc, artistIDs[2]); got != "Edited by hand." {
+		t.Errorf("biography of conflicting artist = %q, want the later edit kept", got)
+	}
+}
@@ -15,23 +15,30 @@ import (
 
 	"github.com/sydlexius/stillwater/internal/api/middleware"
 	"github.com/sydlexius/stillwater/internal/auth"
+	"github.com/sydlexius/stillwater/internal/library"
 	"github.com/sydlexius/stillwater/web/templates"
 )
 
-// handleCreateInvite generates a new single-use invite link.
+// handleCreateInvite generates a new single-use invite link. library_ids
+// optionally restricts the account the invite creates to those libraries.
 // POST /api/v1/users/invites (admin only)
 func (r *Router) handleCreateInvite(w http.ResponseWriter, req *http.Request) {
 	var body struct {
-		Role      string `json:"role"`
-		ExpiresIn string `json:"expires_in"`
+		Role       string   `json:"role"`
+		ExpiresIn  string   `json:"expires_in"`
+		LibraryIDs []string `json:"library_ids"`
 	}
 	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body."})
 		return
 	}
 
-	if body.Role != "administrator" && body.Role != "operator" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Role must be administrator or operator."})
+	if !auth.ValidRole(body.Role) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Role must be administrator, operator, or viewer."})
+		return
+	}
+	libraryIDs, ok := r.validateUserLibraries(w, req, body.Role, body.LibraryIDs)
+	if !ok {
 		return
 	}
 
@@ -42,7 +49,8 @@ func (r *Router) handleCreateInvite(w http.ResponseWriter, req *http.Request) {
 	}
 
 	callerID := middleware.UserIDFromContext(req.Context())
-	invite, err := r.authService.CreateInvite(req.Context(), body.Role, callerID, dur)
+	invite, err := r.authService.CreateInviteWithOptions(req.Context(), body.Role, callerID, dur,
+		auth.InviteOptions{LibraryIDs: libraryIDs})
 	if err != nil {
 		r.logger.Error("failed to create invite", "error", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An internal error occurred. Please try again."})
@@ -167,7 +175,13 @@ func (r *Router) handleRegister(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	// Auto-login: create a session for the new user.
+	// Auto-login: create a session for the new user, unless the two-factor
+	// policy covers their role. Then they sign in normally and the login
+	// walks them through enrollment before any session exists.
+	if policy, perr := r.authService.TwoFactorPolicy(req.Context()); perr != nil || auth.TwoFactorRequiredFor(policy, user.Role) {
+		writeJSON(w, http.StatusCreated, user)
+		return
+	}
 	token, err := r.authService.CreateSession(req.Context(), user.ID)
 	if err != nil {
 		// User was created successfully; failure to auto-login is non-fatal.
@@ -257,7 +271,8 @@ func (r *Router) handleGetUser(w http.ResponseWriter, req *http.Request) {
 	writeJSON(w, http.StatusOK, user)
 }
 
-// handleUpdateUser changes a user's role.
+// handleUpdateUser changes a user's role, library grant, or both. An absent
+// field is left as it is; an empty library_ids list lifts the restriction.
 // PATCH /api/v1/users/{id} (admin only)
 func (r *Router) handleUpdateUser(w http.ResponseWriter, req *http.Request) {
 	id := req.PathValue("id")
@@ -267,38 +282,81 @@ func (r *Router) handleUpdateUser(w http.ResponseWriter, req *http.Request) {
 	}
 
 	var body struct {
-		Role string `json:"role"`
+		Role       string    `json:"role"`
+		LibraryIDs *[]string `json:"library_ids"`
 	}
 	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body."})
 		return
 	}
 
-	if body.Role != "administrator" && body.Role != "operator" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Role must be administrator or operator."})
+	if body.Role == "" && body.LibraryIDs == nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Provide a role, library_ids, or both."})
+		return
+	}
+	if body.Role != "" && !auth.ValidRole(body.Role) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Role must be administrator, operator, or viewer."})
 		return
 	}
 
-	if err := r.authService.UpdateUserRole(req.Context(), id, body.Role); err != nil {
-		switch {
-		case errors.Is(err, auth.ErrProtectedUser):
-			r.logger.Warn("blocked role change of protected bootstrap admin", "user_id", id)
-			writeJSON(w, http.StatusConflict, map[string]string{"error": "The bootstrap administrator account role cannot be changed."})
-		case errors.Is(err, auth.ErrLastAdmin):
-			r.logger.Warn("blocked downgrade of last active administrator", "user_id", id)
-			writeJSON(w, http.StatusConflict, map[string]string{"error": "Cannot downgrade the last active administrator."})
-		case errors.Is(err, sql.ErrNoRows):
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": "User not found."})
-		default:
-			r.logger.Error("failed to update user role", "user_id", id, "error", err)
+	var libraryIDs []string
+	if body.LibraryIDs != nil {
+		// Validate against the role the user will have once this request
+		// is applied.
+		role := body.Role
+		if role == "" {
+			current, err := r.authService.GetUserByID(req.Context(), id)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					writeJSON(w, http.StatusNotFound, map[string]string{"error": "User not found."})
+					return
+				}
+				r.logger.Error("failed to get user for library update", "user_id", id, "error", err)
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An internal error occurred. Please try again."})
+				return
+			}
+			role = current.Role
+		}
+		var ok bool
+		if libraryIDs, ok = r.validateUserLibraries(w, req, role, *body.LibraryIDs); !ok {
+			return
+		}
+	}
+
+	if body.Role != "" {
+		if err := r.authService.UpdateUserRole(req.Context(), id, body.Role); err != nil {
+			switch {
+			case errors.Is(err, auth.ErrProtectedUser):
+				r.logger.Warn("blocked role change of protected bootstrap admin", "user_id", id)
+				writeJSON(w, http.StatusConflict, map[string]string{"error": "The bootstrap administrator account role cannot be changed."})
+			case errors.Is(err, auth.ErrLastAdmin):
+				r.logger.Warn("blocked downgrade of last active administrator", "user_id", id)
+				writeJSON(w, http.StatusConflict, map[string]string{"error": "Cannot downgrade the last active administrator."})
+			case errors.Is(err, sql.ErrNoRows):
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "User not found."})
+			default:
+				r.logger.Error("failed to update user role", "user_id", id, "error", err)
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An internal error occurred. Please try again."})
+			}
+			return
+		}
+	}
+
+	if body.LibraryIDs != nil {
+		if err := r.authService.SetUserLibraries(req.Context(), id, libraryIDs); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "User not found."})
+				return
+			}
+			r.logger.Error("failed to update user libraries", "user_id", id, "error", err)
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An internal error occurred. Please try again."})
+			return
 		}
-		return
 	}
 
 	user, err := r.authService.GetUserByID(req.Context(), id)
 	if err != nil {
-		r.logger.Error("failed to fetch user after role update", "user_id", id, "error", err)
+		r.logger.Error("failed to fetch user after update", "user_id", id, "error", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An internal error occurred. Please try again."})
 		return
 	}
@@ -306,6 +364,32 @@ func (r *Router) handleUpdateUser(w http.ResponseWriter, req *http.Request) {
 	writeJSON(w, http.StatusOK, user)
 }
 
+// validateUserLibraries checks a requested library grant for an account with
+// the given role, writing a 400 and returning false when it is unusable.
+// Administrators cannot be restricted, so a grant for one is refused rather
+// than silently ignored; an empty grant is always accepted.
+func (r *Router) validateUserLibraries(w http.ResponseWriter, req *http.Request, role string, ids []string) ([]string, bool) {
+	libraryIDs := auth.ParseLibraryIDs(strings.Join(ids, ","))
+	if len(libraryIDs) == 0 {
+		return nil, true
+	}
+	if role == "administrator" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Administrators cannot be restricted to libraries."})
+		return nil, false
+	}
+	exist, err := r.librariesExist(req.Context(), libraryIDs)
+	if err != nil {
+		r.logger.Error("failed to check libraries for user grant", "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An internal error occurred. Please try again."})
+		return nil, false
+	}
+	if !exist {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "library_ids: unknown library"})
+		return nil, false
+	}
+	return libraryIDs, true
+}
+
 // handleDeactivateUser deactivates a user account and invalidates all their sessions.
 // DELETE /api/v1/users/{id} (admin only)
 func (r *Router) handleDeactivateUser(w http.ResponseWriter, req *http.Request) {
@@ -391,6 +475,41 @@ func (r *Router) handleDeleteUser(w http.ResponseWriter, req *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// handleUnlockUser lifts a sign-in lockout of a user before it runs out, and
+// clears the user's failed-password count. Unlocking a user who is not locked
+// succeeds. Address lockouts are not lifted: they expire on their own.
+// DELETE /api/v1/users/{id}/account/lockout (admin only)
+func (r *Router) handleUnlockUser(w http.ResponseWriter, req *http.Request) {
+	id := req.PathValue("id")
+	if id == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "User ID is required."})
+		return
+	}
+	adminID := middleware.UserIDFromContext(req.Context())
+	if err := r.authService.UnlockUser(req.Context(), id, adminID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "User not found."})
+			return
+		}
+		r.logger.Error("failed to unlock user", "user_id", id, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An internal error occurred. Please try again."})
+		return
+	}
+	r.logger.Info("sign-in lockout lifted by administrator", "user_id", id, "admin_id", adminID)
+
+	if req.Header.Get("HX-Request") == "true" {
+		u, err := r.authService.GetUserByID(req.Context(), id)
+		if err != nil {
+			r.logger.Error("failed to reload user after unlock", "user_id", id, "error", err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "An internal error occurred. Please try again."})
+			return
+		}
+		r.renderUserTableRows(w, req, []auth.User{*u})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // parseDuration parses a duration string. It supports standard Go duration
 // syntax (e.g. "24h") as well as a shorthand suffix "d" for whole days
 // (e.g. "7d" equals 168h).
@@ -418,10 +537,11 @@ func parseDuration(s string) (time.Duration, error) {
 // disable itself for the signed-in admin's own row.
 func (r *Router) renderUserTableRows(w http.ResponseWriter, req *http.Request, users []auth.User) {
 	callerID := middleware.UserIDFromContext(req.Context())
+	libraries := r.grantLibraries(req)
 	var buf bytes.Buffer
 	for i := range users {
 		u := &users[i]
-		if err := templates.UserTableRowFragment(*u, callerID).Render(req.Context(), &buf); err != nil {
+		if err := templates.UserTableRowFragment(*u, callerID, libraries).Render(req.Context(), &buf); err != nil {
 			r.logger.Error("rendering user table row", "user_id", u.ID, "error", err)
 			http.Error(w, "Failed to render user list", http.StatusInternalServerError)
 			return
@@ -431,6 +551,21 @@ func (r *Router) renderUserTableRows(w http.ResponseWriter, req *http.Request, u
 	_, _ = w.Write(buf.Bytes())
 }
 
+// grantLibraries lists the libraries the user and invite rows name in their
+// library grants. A failure only degrades the rows to raw library IDs and
+// hides the grant editor, so it is logged rather than failing the render.
+func (r *Router) grantLibraries(req *http.Request) []library.Library {
+	if r.libraryService == nil {
+		return nil
+	}
+	libs, err := r.libraryService.List(req.Context())
+	if err != nil {
+		r.logger.Warn("listing libraries for user grants", "error", err)
+		return nil
+	}
+	return libs
+}
+
 // renderInviteRows writes invite entries as HTML fragments for HTMX.
 // Pre-renders to a buffer so partial failures return a 500 instead of truncated HTML.
 func (r *Router) renderInviteRows(w http.ResponseWriter, req *http.Request, invites []auth.Invite) {
@@ -439,9 +574,10 @@ func (r *Router) renderInviteRows(w http.ResponseWriter, req *http.Request, invi
 		_, _ = w.Write([]byte(`<p class="text-sm text-gray-500 dark:text-gray-400 italic">No pending invites.</p>`))
 		return
 	}
+	libraries := r.grantLibraries(req)
 	var buf bytes.Buffer
 	for _, inv := range invites {
-		if err := templates.InviteRowFragment(inv).Render(req.Context(), &buf); err != nil {
+		if err := templates.InviteRowFragment(inv, libraries).Render(req.Context(), &buf); err != nil {
 			r.logger.Error("rendering invite row", "invite_id", inv.ID, "error", err)
 			http.Error(w, "Failed to render invite list", http.StatusInternalServerError)
 			return
@@ -11,6 +11,7 @@ import (
 
 	"github.com/sydlexius/stillwater/internal/api/middleware"
 	"github.com/sydlexius/stillwater/internal/auth"
+	"github.com/sydlexius/stillwater/internal/library"
 )
 
 // withAdminCtx adds both user ID and administrator role to the request context.
@@ -159,7 +160,7 @@ func TestHandleUpdateUser_InvalidRole(t *testing.T) {
 	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
 		t.Fatalf("decoding error response: %v", err)
 	}
-	if !strings.Contains(resp["error"], "administrator or operator") {
+	if !strings.Contains(resp["error"], "administrator, operator, or viewer") {
 		t.Errorf("error = %q, want message about valid roles", resp["error"])
 	}
 }
@@ -196,6 +197,94 @@ func TestHandleUpdateUser_ValidRole(t *testing.T) {
 	}
 }
 
+// TestHandleUpdateUser_LibraryGrant pins the library_ids update: a viewer can
+// be restricted to known libraries, an unknown library is refused, and an
+// administrator cannot be restricted at all.
+func TestHandleUpdateUser_LibraryGrant(t *testing.T) {
+	t.Parallel()
+	r, authSvc, adminID := testRouterWithAuth(t)
+	r.libraryService = library.NewService(r.db)
+	lib := &library.Library{Name: "Kids", Path: t.TempDir(), Type: "regular", Source: "manual"}
+	if err := r.libraryService.Create(context.Background(), lib); err != nil {
+		t.Fatalf("creating library: %v", err)
+	}
+	member, err := authSvc.CreateLocalUser(context.Background(), "kid", "password123", "Kid", "operator", adminID)
+	if err != nil {
+		t.Fatalf("creating user: %v", err)
+	}
+
+	patch := func(id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/"+id, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.SetPathValue("id", id)
+		req = withAdminCtx(req, adminID)
+		w := httptest.NewRecorder()
+		r.handleUpdateUser(w, req)
+		return w
+	}
+
+	w := patch(member.ID, `{"role":"viewer","library_ids":["`+lib.ID+`"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("update user: status = %d, want %d; body: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	var user auth.User
+	if err := json.NewDecoder(w.Body).Decode(&user); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if user.Role != "viewer" || len(user.LibraryIDs) != 1 || user.LibraryIDs[0] != lib.ID {
+		t.Errorf("user = role %q libraries %v, want viewer restricted to %s", user.Role, user.LibraryIDs, lib.ID)
+	}
+
+	if w := patch(member.ID, `{"library_ids":["no-such-library"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("unknown library: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w := patch(adminID, `{"library_ids":["`+lib.ID+`"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("restricting an administrator: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	// An empty list lifts the restriction.
+	if w := patch(member.ID, `{"library_ids":[]}`); w.Code != http.StatusOK {
+		t.Fatalf("clearing libraries: status = %d; body: %s", w.Code, w.Body.String())
+	}
+	ids, err := authSvc.UserLibraryIDs(context.Background(), member.ID)
+	if err != nil {
+		t.Fatalf("UserLibraryIDs: %v", err)
+	}
+	if len(ids) != 0 {
+		t.Errorf("libraries after clearing = %v, want none", ids)
+	}
+}
+
+// TestHandleCreateInvite_ViewerWithLibraries pins the invite flow: the
+// invite records the role and library grant it will hand to the account.
+func TestHandleCreateInvite_ViewerWithLibraries(t *testing.T) {
+	t.Parallel()
+	r, authSvc, adminID := testRouterWithAuth(t)
+	r.libraryService = library.NewService(r.db)
+	lib := &library.Library{Name: "Kids", Path: t.TempDir(), Type: "regular", Source: "manual"}
+	if err := r.libraryService.Create(context.Background(), lib); err != nil {
+		t.Fatalf("creating library: %v", err)
+	}
+
+	body := `{"role":"viewer","expires_in":"24h","library_ids":["` + lib.ID + `"]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/invites", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req = withAdminCtx(req, adminID)
+	w := httptest.NewRecorder()
+	r.handleCreateInvite(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create invite: status = %d, want %d; body: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+
+	invites, err := authSvc.ListPendingInvites(context.Background())
+	if err != nil {
+		t.Fatalf("listing invites: %v", err)
+	}
+	if len(invites) != 1 || invites[0].Role != "viewer" || len(invites[0].LibraryIDs) != 1 || invites[0].LibraryIDs[0] != lib.ID {
+		t.Errorf("invites = %+v, want one viewer invite restricted to %s", invites, lib.ID)
+	}
+}
+
 func TestHandleUpdateUser_BootstrapAdmin(t *testing.T) {
 	t.Parallel()
 	r, authSvc, adminID := testRouterWithAuth(t)
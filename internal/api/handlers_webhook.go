@@ -2,6 +2,8 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
@@ -63,6 +65,7 @@ func (r *Router) handleCreateWebhook(w http.ResponseWriter, req *http.Request) {
 		Type    string   `json:"type"`
 		Events  []string `json:"events"`
 		Enabled bool     `json:"enabled"`
+		Secret  string   `json:"secret"`
 	}
 	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
 		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
@@ -80,6 +83,9 @@ func (r *Router) handleCreateWebhook(w http.ResponseWriter, req *http.Request) {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown webhook event type: " + bad})
 		return
 	}
+	if !validWebhookSecret(w, body.Secret) {
+		return
+	}
 
 	wh := &webhook.Webhook{
 		Name:    body.Name,
@@ -96,6 +102,18 @@ func (r *Router) handleCreateWebhook(w http.ResponseWriter, req *http.Request) {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
+	if body.Secret != "" {
+		if err := r.webhookService.SetSecret(req.Context(), wh.ID, body.Secret); err != nil {
+			// Do not leave behind an unsigned webhook the operator asked to
+			// have signed.
+			if delErr := r.webhookService.Delete(req.Context(), wh.ID); delErr != nil {
+				r.logger.Error("removing webhook after secret failure", "id", wh.ID, "error", delErr)
+			}
+			r.writeWebhookSecretError(w, err)
+			return
+		}
+		wh.HasSecret = true
+	}
 	writeJSON(w, http.StatusCreated, wh)
 }
 
@@ -118,10 +136,16 @@ func (r *Router) handleUpdateWebhook(w http.ResponseWriter, req *http.Request) {
 		Type    string   `json:"type"`
 		Events  []string `json:"events"`
 		Enabled *bool    `json:"enabled"`
+		// Secret replaces the signing secret; "" removes it. Absent leaves
+		// it unchanged.
+		Secret *string `json:"secret"`
 	}
 	if !DecodeJSON(w, req, &body) {
 		return
 	}
+	if body.Secret != nil && !validWebhookSecret(w, *body.Secret) {
+		return
+	}
 
 	if body.Name != "" {
 		existing.Name = body.Name
@@ -143,6 +167,13 @@ func (r *Router) handleUpdateWebhook(w http.ResponseWriter, req *http.Request) {
 		existing.Enabled = *body.Enabled
 	}
 
+	if body.Secret != nil {
+		if err := r.webhookService.SetSecret(req.Context(), existing.ID, *body.Secret); err != nil {
+			r.writeWebhookSecretError(w, err)
+			return
+		}
+		existing.HasSecret = *body.Secret != ""
+	}
 	if err := r.webhookService.Update(req.Context(), existing); err != nil {
 		r.logger.Error("updating webhook", "error", err)
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
@@ -151,6 +182,28 @@ func (r *Router) handleUpdateWebhook(w http.ResponseWriter, req *http.Request) {
 	writeJSON(w, http.StatusOK, existing)
 }
 
+// validWebhookSecret rejects a signing secret shorter than
+// webhook.MinSecretLength, writing the 400 itself. "" is valid: no secret.
+func validWebhookSecret(w http.ResponseWriter, secret string) bool {
+	if secret != "" && len(secret) < webhook.MinSecretLength {
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"error": fmt.Sprintf("secret must be at least %d characters", webhook.MinSecretLength),
+		})
+		return false
+	}
+	return true
+}
+
+// writeWebhookSecretError reports a failure to store a signing secret.
+func (r *Router) writeWebhookSecretError(w http.ResponseWriter, err error) {
+	if errors.Is(err, webhook.ErrSecretStorageUnavailable) {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "webhook secret storage not available"})
+		return
+	}
+	r.logger.Error("storing webhook secret", "error", err)
+	writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+}
+
 // handleDeleteWebhook removes a webhook by ID.
 // DELETE /api/v1/webhooks/{id}
 func (r *Router) handleDeleteWebhook(w http.ResponseWriter, req *http.Request) {
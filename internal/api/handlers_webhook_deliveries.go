@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/sydlexius/stillwater/internal/webhook"
+)
+
+// maxDeliveryPageSize caps ?limit= on the delivery log listing; each row
+// carries its full request body.
+const maxDeliveryPageSize = 200
+
+// handleListWebhookDeliveries returns a page of a webhook's delivery log,
+// newest first. ?status= filters to pending, succeeded or failed.
+// GET /api/v1/webhooks/{id}/deliveries
+func (r *Router) handleListWebhookDeliveries(w http.ResponseWriter, req *http.Request) {
+	id, ok := RequirePathParam(w, req, "id")
+	if !ok {
+		return
+	}
+	if _, err := r.webhookService.GetByID(req.Context(), id); err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "webhook not found"})
+		return
+	}
+
+	status := req.URL.Query().Get("status")
+	switch status {
+	case "", webhook.DeliveryPending, webhook.DeliverySucceeded, webhook.DeliveryFailed:
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "status must be pending, succeeded or failed"})
+		return
+	}
+	limit := intQuery(req, "limit", 50)
+	if limit < 1 || limit > maxDeliveryPageSize {
+		limit = 50
+	}
+	offset := max(intQuery(req, "offset", 0), 0)
+
+	deliveries, total, err := r.webhookService.ListDeliveries(req.Context(), id, status, limit, offset)
+	if err != nil {
+		r.logger.Error("listing webhook deliveries", "webhook_id", id, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if deliveries == nil {
+		deliveries = []webhook.Delivery{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"deliveries": deliveries,
+		"total":      total,
+		"limit":      limit,
+		"offset":     offset,
+	})
+}
+
+// handleReplayWebhookDelivery re-sends one failed delivery with its original
+// body. The replay is sent in the background; the response is its (pending)
+// delivery record, which the delivery log then tracks.
+// POST /api/v1/webhooks/{id}/deliveries/{deliveryId}/replay
+func (r *Router) handleReplayWebhookDelivery(w http.ResponseWriter, req *http.Request) {
+	id, ok := RequirePathParam(w, req, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := RequirePathParam(w, req, "deliveryId")
+	if !ok {
+		return
+	}
+	replay, err := r.webhookDispatcher.Replay(req.Context(), id, deliveryID)
+	if err != nil {
+		r.writeReplayError(w, id, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, replay)
+}
+
+// handleReplayFailedWebhookDeliveries replays, oldest first, every failed
+// delivery of a webhook that has not since been delivered by a replay -- the
+// events a receiver missed while it was down.
+// POST /api/v1/webhooks/{id}/deliveries/replay-failed
+func (r *Router) handleReplayFailedWebhookDeliveries(w http.ResponseWriter, req *http.Request) {
+	id, ok := RequirePathParam(w, req, "id")
+	if !ok {
+		return
+	}
+	replays, err := r.webhookDispatcher.ReplayFailed(req.Context(), id)
+	if err != nil {
+		r.writeReplayError(w, id, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]any{
+		"replayed":   len(replays),
+		"deliveries": replays,
+	})
+}
+
+// writeReplayError maps a webhook.Dispatcher replay error to its response.
+func (r *Router) writeReplayError(w http.ResponseWriter, webhookID string, err error) {
+	switch {
+	case errors.Is(err, webhook.ErrWebhookNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "webhook not found"})
+	case errors.Is(err, webhook.ErrDeliveryNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "delivery not found"})
+	case errors.Is(err, webhook.ErrWebhookDisabled), errors.Is(err, webhook.ErrDeliveryNotFailed):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		r.logger.Error("replaying webhook deliveries", "webhook_id", webhookID, "error", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+	}
+}
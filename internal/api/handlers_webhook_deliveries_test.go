@@ -0,0 +1,257 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/encryption"
+	"github.com/sydlexius/stillwater/internal/event"
+	"github.com/sydlexius/stillwater/internal/webhook"
+)
+
+// deliveryTestRouter returns a router whose dispatcher sends to a local
+// receiver that answers 503 while down is set, plus a webhook subscribed to
+// scan.completed pointing at it.
+func deliveryTestRouter(t *testing.T, down *atomic.Bool) (*Router, *webhook.Webhook) {
+	t.Helper()
+	r, _ := testRouter(t)
+	enc, _, err := encryption.NewEncryptor("")
+	if err != nil {
+		t.Fatalf("creating encryptor: %v", err)
+	}
+	r.webhookService = webhook.NewService(r.db).WithEncryptor(enc)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	r.webhookDispatcher = webhook.NewDispatcherWithHTTPClient(r.webhookService, srv.Client(), r.logger)
+	r.webhookDispatcher.DisableBackoffForTest()
+
+	wh := &webhook.Webhook{Name: "receiver", URL: srv.URL, Type: webhook.TypeGeneric, Events: []string{"scan.completed"}, Enabled: true}
+	if err := r.webhookService.Create(context.Background(), wh); err != nil {
+		t.Fatalf("creating webhook: %v", err)
+	}
+	return r, wh
+}
+
+func drainDispatcher(t *testing.T, r *Router) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.webhookDispatcher.Drain(ctx); err != nil {
+		t.Fatalf("draining dispatcher: %v", err)
+	}
+}
+
+type deliveryPage struct {
+	Deliveries []webhook.Delivery `json:"deliveries"`
+	Total      int                `json:"total"`
+}
+
+func listDeliveries(t *testing.T, r *Router, webhookID, query string) deliveryPage {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/webhooks/"+webhookID+"/deliveries"+query, nil)
+	req.SetPathValue("id", webhookID)
+	w := httptest.NewRecorder()
+	r.handleListWebhookDeliveries(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("list status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	var page deliveryPage
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+	return page
+}
+
+// TestWebhookDeliveries_ListAndReplay drives the receiver-outage story end to
+// end through the API: a delivery fails, shows up in the failed listing, and
+// a replay after the receiver recovers succeeds.
+func TestWebhookDeliveries_ListAndReplay(t *testing.T) {
+	t.Parallel()
+	var down atomic.Bool
+	down.Store(true)
+	r, wh := deliveryTestRouter(t, &down)
+
+	r.webhookDispatcher.HandleEvent(event.Event{Type: event.ScanCompleted, Timestamp: time.Now().UTC()})
+	drainDispatcher(t, r)
+
+	failed := listDeliveries(t, r, wh.ID, "?status=failed")
+	if failed.Total != 1 || failed.Deliveries[0].ResponseStatus != http.StatusServiceUnavailable {
+		t.Fatalf("failed deliveries = %+v, want one 503", failed)
+	}
+	orig := failed.Deliveries[0]
+
+	down.Store(false)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/"+wh.ID+"/deliveries/"+orig.ID+"/replay", nil)
+	req.SetPathValue("id", wh.ID)
+	req.SetPathValue("deliveryId", orig.ID)
+	w := httptest.NewRecorder()
+	r.handleReplayWebhookDelivery(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("replay status = %d, want 202; body: %s", w.Code, w.Body.String())
+	}
+	var replay webhook.Delivery
+	if err := json.NewDecoder(w.Body).Decode(&replay); err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+	if replay.ReplayOf != orig.ID {
+		t.Errorf("replay_of = %q, want %q", replay.ReplayOf, orig.ID)
+	}
+	drainDispatcher(t, r)
+
+	succeeded := listDeliveries(t, r, wh.ID, "?status=succeeded")
+	if succeeded.Total != 1 || succeeded.Deliveries[0].ID != replay.ID {
+		t.Errorf("succeeded deliveries = %+v, want the replay", succeeded)
+	}
+	if all := listDeliveries(t, r, wh.ID, ""); all.Total != 2 {
+		t.Errorf("total deliveries = %d, want 2", all.Total)
+	}
+
+	// Replaying a delivery that did not fail is refused.
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/"+wh.ID+"/deliveries/"+replay.ID+"/replay", nil)
+	req.SetPathValue("id", wh.ID)
+	req.SetPathValue("deliveryId", replay.ID)
+	w = httptest.NewRecorder()
+	r.handleReplayWebhookDelivery(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("replaying a succeeded delivery: status = %d, want 409", w.Code)
+	}
+}
+
+func TestHandleReplayFailedWebhookDeliveries(t *testing.T) {
+	t.Parallel()
+	var down atomic.Bool
+	down.Store(true)
+	r, wh := deliveryTestRouter(t, &down)
+
+	for range 2 {
+		r.webhookDispatcher.HandleEvent(event.Event{Type: event.ScanCompleted, Timestamp: time.Now().UTC()})
+	}
+	drainDispatcher(t, r)
+	down.Store(false)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/"+wh.ID+"/deliveries/replay-failed", nil)
+	req.SetPathValue("id", wh.ID)
+	w := httptest.NewRecorder()
+	r.handleReplayFailedWebhookDeliveries(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202; body: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Replayed int `json:"replayed"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+	if resp.Replayed != 2 {
+		t.Errorf("replayed = %d, want 2", resp.Replayed)
+	}
+	drainDispatcher(t, r)
+	if got := listDeliveries(t, r, wh.ID, "?status=succeeded"); got.Total != 2 {
+		t.Errorf("succeeded deliveries = %d, want 2", got.Total)
+	}
+}
+
+func TestWebhookDeliveries_NotFound(t *testing.T) {
+	t.Parallel()
+	var down atomic.Bool
+	r, wh := deliveryTestRouter(t, &down)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/webhooks/missing/deliveries", nil)
+	req.SetPathValue("id", "missing")
+	w := httptest.NewRecorder()
+	r.handleListWebhookDeliveries(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("list for unknown webhook: status = %d, want 404", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/"+wh.ID+"/deliveries/missing/replay", nil)
+	req.SetPathValue("id", wh.ID)
+	req.SetPathValue("deliveryId", "missing")
+	w = httptest.NewRecorder()
+	r.handleReplayWebhookDelivery(w, req)
+	if w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), "delivery not found") {
+		t.Errorf("replay of unknown delivery: status = %d, body %s; want 404 delivery not found", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/webhooks/"+wh.ID+"/deliveries?status=bogus", nil)
+	req.SetPathValue("id", wh.ID)
+	w = httptest.NewRecorder()
+	r.handleListWebhookDeliveries(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bogus status filter: status = %d, want 400", w.Code)
+	}
+}
+
+// TestHandleCreateWebhook_Secret: a secret given at creation is stored (the
+// response reports has_secret without echoing it), and a too-short one is
+// rejected before anything is created.
+func TestHandleCreateWebhook_Secret(t *testing.T) {
+	t.Parallel()
+	var down atomic.Bool
+	r, _ := deliveryTestRouter(t, &down)
+
+	body := `{"name":"signed","url":"https://example.test/hook","events":["artist.new"],"enabled":true,"secret":"a-long-enough-secret"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.handleCreateWebhook(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want 201; body: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "a-long-enough-secret") {
+		t.Error("response echoes the secret")
+	}
+	var created webhook.Webhook
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("decoding: %v", err)
+	}
+	if stored, err := r.webhookService.GetByID(context.Background(), created.ID); err != nil || !stored.HasSecret {
+		t.Errorf("stored webhook has_secret = %v (err %v), want true", stored != nil && stored.HasSecret, err)
+	}
+
+	body = `{"name":"weak","url":"https://example.test/weak","secret":"short"}`
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/webhooks", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.handleCreateWebhook(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("short secret: status = %d, want 400", w.Code)
+	}
+	if existing, _ := r.webhookService.GetByNameAndURL(context.Background(), "weak", "https://example.test/weak"); existing != nil {
+		t.Error("webhook created despite the rejected secret")
+	}
+}
+
+func TestHandleUpdateWebhook_ClearsSecret(t *testing.T) {
+	t.Parallel()
+	var down atomic.Bool
+	r, wh := deliveryTestRouter(t, &down)
+	if err := r.webhookService.SetSecret(context.Background(), wh.ID, "a-long-enough-secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/webhooks/"+wh.ID, strings.NewReader(`{"secret":""}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.SetPathValue("id", wh.ID)
+	w := httptest.NewRecorder()
+	r.handleUpdateWebhook(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200; body: %s", w.Code, w.Body.String())
+	}
+	if stored, _ := r.webhookService.GetByID(context.Background(), wh.ID); stored.HasSecret {
+		t.Error("secret still set after update with an empty secret")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/sydlexius/stillwater/internal/auth"
@@ -15,15 +16,18 @@ const (
 	userIDKey      contextKey = "userID"
 	authMethodKey  contextKey = "authMethod"
 	tokenScopesKey contextKey = "tokenScopes"
+	tokenGrantKey  contextKey = "tokenGrant"
 	userRoleKey    contextKey = "userRole"
+	userLibsKey    contextKey = "userLibraries"
 )
 
 // AuthProvider defines the authentication operations required by middleware.
 // This interface is satisfied by *auth.Service and enables testing with stubs.
 type AuthProvider interface {
 	ValidateSession(ctx context.Context, token string) (string, error)
-	ValidateAPIToken(ctx context.Context, token string) (userID string, scopes string, err error)
+	ValidateAPITokenGrant(ctx context.Context, token string) (*auth.TokenGrant, error)
 	GetUserRole(ctx context.Context, userID string) (string, error)
+	UserLibraryIDs(ctx context.Context, userID string) ([]string, error)
 }
 
 // OptionalAuth returns middleware that populates the user context if a valid
@@ -36,7 +40,8 @@ func OptionalAuth(authService AuthProvider) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if token := extractToken(r); token != "" {
 				if strings.HasPrefix(token, auth.APITokenPrefix) {
-					if userID, scopes, err := authService.ValidateAPIToken(r.Context(), token); err == nil {
+					if grant, err := authService.ValidateAPITokenGrant(r.Context(), token); err == nil {
+						userID := grant.UserID
 						role, roleErr := authService.GetUserRole(r.Context(), userID)
 						if roleErr != nil {
 							slog.Warn("failed to get user role for API token", "user_id", userID, "error", roleErr)
@@ -48,11 +53,7 @@ func OptionalAuth(authService AuthProvider) func(http.Handler) http.Handler {
 							next.ServeHTTP(w, r)
 							return
 						}
-						ctx := context.WithValue(r.Context(), userIDKey, userID)
-						ctx = context.WithValue(ctx, authMethodKey, "api_token")
-						ctx = context.WithValue(ctx, tokenScopesKey, scopes)
-						ctx = context.WithValue(ctx, userRoleKey, role)
-						next.ServeHTTP(w, r.WithContext(ctx))
+						next.ServeHTTP(w, r.WithContext(withTokenGrant(r.Context(), grant, role)))
 						return
 					}
 				} else {
@@ -82,7 +83,22 @@ func OptionalAuth(authService AuthProvider) func(http.Handler) http.Handler {
 }
 
 // Auth returns middleware that requires a valid session or API token.
-func Auth(authService AuthProvider) func(http.Handler) http.Handler {
+//
+// An API token must also hold the scope the matched route requires and, when
+// it is restricted to specific libraries, target one of them (see
+// requiredScope and libraryTarget). The route is read from r.Pattern, so Auth
+// must run inside the ServeMux handler, as wrapAuth does. Sessions are not
+// scope-checked: the role checks (RequireAdmin and the handlers' own) govern
+// them.
+//
+// Whatever the credential, the account's own limits apply on top (see
+// authorizeUser): a viewer cannot make changes, and an operator or viewer
+// with a library grant can only change content in those libraries.
+func Auth(authService AuthProvider, opts ...AuthOption) func(http.Handler) http.Handler {
+	var cfg authConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			token := extractToken(r)
@@ -92,11 +108,12 @@ func Auth(authService AuthProvider) func(http.Handler) http.Handler {
 			}
 
 			if strings.HasPrefix(token, auth.APITokenPrefix) {
-				userID, scopes, err := authService.ValidateAPIToken(r.Context(), token)
+				grant, err := authService.ValidateAPITokenGrant(r.Context(), token)
 				if err != nil {
 					http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
 					return
 				}
+				userID := grant.UserID
 				role, roleErr := authService.GetUserRole(r.Context(), userID)
 				if roleErr != nil {
 					slog.Error("failed to get user role for API token", "user_id", userID, "error", roleErr)
@@ -107,11 +124,18 @@ func Auth(authService AuthProvider) func(http.Handler) http.Handler {
 					http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
 					return
 				}
-				ctx := context.WithValue(r.Context(), userIDKey, userID)
-				ctx = context.WithValue(ctx, authMethodKey, "api_token")
-				ctx = context.WithValue(ctx, tokenScopesKey, scopes)
-				ctx = context.WithValue(ctx, userRoleKey, role)
-				next.ServeHTTP(w, r.WithContext(ctx))
+				libraries, libErr := authService.UserLibraryIDs(r.Context(), userID)
+				if libErr != nil {
+					slog.Error("failed to get user libraries for API token", "user_id", userID, "error", libErr)
+					http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+					return
+				}
+				ctx := withTokenGrant(r.Context(), grant, role)
+				r = r.WithContext(context.WithValue(ctx, userLibsKey, libraries))
+				if !authorizeToken(w, r, grant, &cfg) || !authorizeUser(w, r, role, libraries, &cfg) {
+					return
+				}
+				next.ServeHTTP(w, r)
 				return
 			}
 
@@ -131,15 +155,36 @@ func Auth(authService AuthProvider) func(http.Handler) http.Handler {
 				http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
 				return
 			}
+			libraries, libErr := authService.UserLibraryIDs(r.Context(), userID)
+			if libErr != nil {
+				slog.Error("failed to get user libraries for session", "user_id", userID, "error", libErr)
+				http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+				return
+			}
 
 			ctx := context.WithValue(r.Context(), userIDKey, userID)
 			ctx = context.WithValue(ctx, authMethodKey, "session")
 			ctx = context.WithValue(ctx, userRoleKey, role)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			ctx = context.WithValue(ctx, userLibsKey, libraries)
+			r = r.WithContext(ctx)
+			if !authorizeUser(w, r, role, libraries, &cfg) {
+				return
+			}
+			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// withTokenGrant returns ctx carrying an API token's identity, scopes and
+// library restriction.
+func withTokenGrant(ctx context.Context, grant *auth.TokenGrant, role string) context.Context {
+	ctx = context.WithValue(ctx, userIDKey, grant.UserID)
+	ctx = context.WithValue(ctx, authMethodKey, "api_token")
+	ctx = context.WithValue(ctx, tokenScopesKey, grant.Scopes)
+	ctx = context.WithValue(ctx, tokenGrantKey, grant)
+	return context.WithValue(ctx, userRoleKey, role)
+}
+
 // UserIDFromContext extracts the authenticated user ID from the context.
 func UserIDFromContext(ctx context.Context) string {
 	if v, ok := ctx.Value(userIDKey).(string); ok {
@@ -164,7 +209,44 @@ func TokenScopesFromContext(ctx context.Context) string {
 	return ""
 }
 
-// RoleFromContext returns the authenticated user's role ("administrator" or "operator").
+// TokenLibraryIDsFromContext returns the libraries an API token is
+// restricted to, or nil for session auth and unrestricted tokens.
+func TokenLibraryIDsFromContext(ctx context.Context) []string {
+	if g, ok := ctx.Value(tokenGrantKey).(*auth.TokenGrant); ok {
+		return g.LibraryIDs
+	}
+	return nil
+}
+
+// UserLibraryIDsFromContext returns the libraries the authenticated user is
+// granted, or nil for administrators and unrestricted users. It is the
+// account's grant, independent of any API token restriction (see
+// TokenLibraryIDsFromContext).
+func UserLibraryIDsFromContext(ctx context.Context) []string {
+	if v, ok := ctx.Value(userLibsKey).([]string); ok {
+		return v
+	}
+	return nil
+}
+
+// LibraryVisible reports whether content belonging to any of libraryIDs may
+// be shown to the caller: each restriction in force (the API token's and the
+// user's own grant) must allow at least one of them. Content that names no
+// library is always visible.
+func LibraryVisible(ctx context.Context, libraryIDs []string) bool {
+	if len(libraryIDs) == 0 {
+		return true
+	}
+	for _, allowed := range [][]string{TokenLibraryIDsFromContext(ctx), UserLibraryIDsFromContext(ctx)} {
+		if len(allowed) > 0 && !slices.ContainsFunc(libraryIDs, func(id string) bool { return slices.Contains(allowed, id) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// RoleFromContext returns the authenticated user's role ("administrator",
+// "operator" or "viewer").
 func RoleFromContext(ctx context.Context) string {
 	if v, ok := ctx.Value(userRoleKey).(string); ok {
 		return v
@@ -172,20 +254,15 @@ func RoleFromContext(ctx context.Context) string {
 	return ""
 }
 
-// HasScope checks if the current auth context includes the given scope.
-// Session auth has all scopes. Admin scope grants all permissions.
+// HasScope checks if the current auth context includes the given scope,
+// directly or by implication (see auth.ScopesAllow). Session auth has all
+// scopes. Admin scope grants all permissions.
 func HasScope(ctx context.Context, scope string) bool {
 	method := AuthMethodFromContext(ctx)
 	if method == "session" {
 		return true
 	}
-	scopes := TokenScopesFromContext(ctx)
-	for _, s := range strings.Split(scopes, ",") {
-		if strings.TrimSpace(s) == scope || strings.TrimSpace(s) == string(auth.ScopeAdmin) {
-			return true
-		}
-	}
-	return false
+	return auth.ScopesAllow(TokenScopesFromContext(ctx), auth.TokenScope(scope))
 }
 
 // RequireScope returns middleware that checks for a specific token scope.
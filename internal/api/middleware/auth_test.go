@@ -6,13 +6,17 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/sydlexius/stillwater/internal/auth"
 )
 
 // mockAuthProvider is a test stub implementing AuthProvider.
 type mockAuthProvider struct {
 	validateSessionFn  func(ctx context.Context, token string) (string, error)
 	validateAPITokenFn func(ctx context.Context, token string) (string, string, error)
+	validateGrantFn    func(ctx context.Context, token string) (*auth.TokenGrant, error)
 	getUserRoleFn      func(ctx context.Context, userID string) (string, error)
+	userLibrariesFn    func(ctx context.Context, userID string) ([]string, error)
 }
 
 func (m *mockAuthProvider) ValidateSession(ctx context.Context, token string) (string, error) {
@@ -22,11 +26,21 @@ func (m *mockAuthProvider) ValidateSession(ctx context.Context, token string) (s
 	return "", errors.New("not configured")
 }
 
-func (m *mockAuthProvider) ValidateAPIToken(ctx context.Context, token string) (string, string, error) {
+// ValidateAPITokenGrant wraps validateAPITokenFn so tests that only care
+// about user and scopes stay terse; validateGrantFn takes precedence when a
+// test needs a library restriction.
+func (m *mockAuthProvider) ValidateAPITokenGrant(ctx context.Context, token string) (*auth.TokenGrant, error) {
+	if m.validateGrantFn != nil {
+		return m.validateGrantFn(ctx, token)
+	}
 	if m.validateAPITokenFn != nil {
-		return m.validateAPITokenFn(ctx, token)
+		userID, scopes, err := m.validateAPITokenFn(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		return &auth.TokenGrant{UserID: userID, Scopes: scopes}, nil
 	}
-	return "", "", errors.New("not configured")
+	return nil, errors.New("not configured")
 }
 
 func (m *mockAuthProvider) GetUserRole(ctx context.Context, userID string) (string, error) {
@@ -36,6 +50,15 @@ func (m *mockAuthProvider) GetUserRole(ctx context.Context, userID string) (stri
 	return "", errors.New("not configured")
 }
 
+// UserLibraryIDs defaults to an unrestricted user so tests that predate
+// library grants need no extra wiring.
+func (m *mockAuthProvider) UserLibraryIDs(ctx context.Context, userID string) ([]string, error) {
+	if m.userLibrariesFn != nil {
+		return m.userLibrariesFn(ctx, userID)
+	}
+	return nil, nil
+}
+
 // --- Auth middleware tests ---
 
 func TestAuth_ValidSession(t *testing.T) {
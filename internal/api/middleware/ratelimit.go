@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"golang.org/x/time/rate"
+
+	"github.com/sydlexius/stillwater/internal/auth"
 )
 
 type ipLimiter struct {
@@ -54,7 +56,8 @@ func NewLoginRateLimiter(ctx context.Context, trustedProxies []string) *LoginRat
 }
 
 // Middleware returns an HTTP middleware that rate-limits requests by client IP.
-// Allows 5 requests per minute with a burst of 5.
+// Allows 5 requests per minute with a burst of 5, and records the client IP
+// and User-Agent on the request context (auth.WithClientInfo).
 func (rl *LoginRateLimiter) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ip := rl.clientIP(r)
@@ -63,7 +66,10 @@ func (rl *LoginRateLimiter) Middleware(next http.Handler) http.Handler {
 			http.Error(w, `{"error":"too many requests"}`, http.StatusTooManyRequests)
 			return
 		}
-		next.ServeHTTP(w, r)
+		// Every route that creates a session sits behind this limiter, so
+		// it is where the resolved client address reaches CreateSession.
+		ctx := auth.WithClientInfo(r.Context(), auth.ClientInfo{IP: ip, UserAgent: r.UserAgent()})
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
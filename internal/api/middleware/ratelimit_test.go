@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/sydlexius/stillwater/internal/auth"
 )
 
 func TestRateLimiter_AllowsBurst(t *testing.T) {
@@ -88,6 +90,29 @@ func TestRateLimiter_DifferentIPsIndependent(t *testing.T) {
 	}
 }
 
+// TestRateLimiter_RecordsClientInfo checks the limiter hands the resolved
+// client address, not the proxy's, to the session code.
+func TestRateLimiter_RecordsClientInfo(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rl := NewLoginRateLimiter(ctx, []string{"10.0.0.0/8"})
+
+	var got auth.ClientInfo
+	handler := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = auth.ClientInfoFromContext(r.Context())
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.RemoteAddr = "10.0.0.5:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.10")
+	req.Header.Set("User-Agent", "Mozilla/5.0 Firefox/140.0")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got.IP != "203.0.113.10" || got.UserAgent != "Mozilla/5.0 Firefox/140.0" {
+		t.Errorf("ClientInfo = %+v, want the forwarded IP and the User-Agent", got)
+	}
+}
+
 func TestRateLimiter_NilContext(t *testing.T) {
 	t.Parallel()
 	// Should not panic with nil context
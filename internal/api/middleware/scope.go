@@ -0,0 +1,322 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/sydlexius/stillwater/internal/auth"
+)
+
+// ErrScopeTargetNotFound is returned by a LibraryResolver when the artist or
+// album named in the path does not exist. The request is then passed to the
+// handler, which answers 404 as it would for any caller.
+var ErrScopeTargetNotFound = errors.New("scope target not found")
+
+// LibraryResolver maps the artist or album a request targets to the
+// libraries it belongs to, so a token or user restricted to specific
+// libraries can be checked before the handler runs. *api.Router implements
+// it.
+type LibraryResolver interface {
+	ArtistLibraryIDs(ctx context.Context, artistID string) ([]string, error)
+	AlbumLibraryIDs(ctx context.Context, albumID string) ([]string, error)
+}
+
+// AuthOption configures Auth.
+type AuthOption func(*authConfig)
+
+type authConfig struct {
+	resolver LibraryResolver
+}
+
+// WithLibraryResolver lets Auth enforce per-library token and user
+// restrictions on artist and album routes. Without it a restricted token or
+// user is refused on every route that targets an artist or album.
+func WithLibraryResolver(resolver LibraryResolver) AuthOption {
+	return func(c *authConfig) { c.resolver = resolver }
+}
+
+// scopeAny marks routes every authenticated token may call whatever its
+// scopes: they only concern the caller's own identity and UI preferences.
+const scopeAny auth.TokenScope = ""
+
+// scopeRoute maps an API path prefix (the route pattern after /api/v1, with
+// its {wildcards} left in) to the resource whose scope guards it. The action
+// comes from the method: GET and HEAD need "<resource>:read", anything else
+// "<resource>:write". Prefixes match whole path segments and the table is
+// searched in order, so a more specific prefix must come first.
+//
+// global marks routes that do not expose or change library content, so a
+// token restricted to specific libraries may still call them. Everything
+// else is library-bound, and a restricted token must name an allowed library
+// (see libraryTarget).
+type scopeRoute struct {
+	prefix   string
+	resource string
+	global   bool
+}
+
+var scopeRoutes = []scopeRoute{
+	// Identity, own tokens and own UI preferences.
+	{prefix: "/auth/me", global: true},
+	{prefix: "/auth/logout", global: true},
+	{prefix: "/auth/2fa", global: true},
+	{prefix: "/preferences", global: true},
+
+	// Images: artwork on artists and albums, and the image reports.
+	{prefix: "/artists/{id}/images", resource: "images"},
+	{prefix: "/artists/{id}/image-locks", resource: "images"},
+	{prefix: "/artists/{id}/push/images", resource: "images"},
+	{prefix: "/artists/{id}/platform-backdrops", resource: "images"},
+	{prefix: "/albums/{id}/images", resource: "images"},
+	{prefix: "/images", resource: "images"},
+	{prefix: "/bulk/fetch-images", resource: "images"},
+	{prefix: "/reports/backdrop-duplicates", resource: "images"},
+	{prefix: "/reports/platform-backdrop-duplicates", resource: "images"},
+	{prefix: "/reports/phash-mismatch", resource: "images"},
+	{prefix: "/reports/duplicate-images", resource: "images"},
+
+	// Rules. Definitions are global; results and violations are library
+	// content. The run routes are classified in requiredScope.
+	{prefix: "/rules/{id}/results", resource: "rules"},
+	{prefix: "/rules", resource: "rules", global: true},
+	{prefix: "/notifications", resource: "rules"},
+	{prefix: "/violations", resource: "rules"},
+
+	// Libraries and the filesystem they live on.
+	{prefix: "/libraries", resource: "libraries"},
+	{prefix: "/scanner", resource: "libraries"},
+	{prefix: "/filesystem", resource: "libraries"},
+	{prefix: "/shared-filesystem", resource: "libraries"},
+	{prefix: "/foreign-files", resource: "libraries"},
+	{prefix: "/foreign-file-allowlist", resource: "libraries"},
+
+	{prefix: "/connections", resource: "connections", global: true},
+
+	// Server configuration, accounts and token management.
+	{prefix: "/auth/tokens", resource: "settings", global: true},
+	{prefix: "/auth/sessions", resource: "settings", global: true},
+	{prefix: "/users", resource: "settings", global: true},
+	{prefix: "/settings", resource: "settings", global: true},
+	{prefix: "/providers", resource: "settings", global: true},
+	{prefix: "/scraper", resource: "settings", global: true},
+	{prefix: "/platforms", resource: "settings", global: true},
+	{prefix: "/webhooks", resource: "settings", global: true},
+	{prefix: "/logs", resource: "settings", global: true},
+	{prefix: "/updates", resource: "settings", global: true},
+	{prefix: "/config", resource: "settings", global: true},
+	{prefix: "/onboarding", resource: "settings", global: true},
+
+	// Artist and album metadata, and everything derived from it.
+	{prefix: "/artists", resource: "artists"},
+	{prefix: "/albums", resource: "artists"},
+	{prefix: "/bulk", resource: "artists"},
+	{prefix: "/history", resource: "artists"},
+	{prefix: "/fix-undo", resource: "artists"},
+	{prefix: "/conflicts", resource: "artists"},
+	{prefix: "/reports", resource: "artists"},
+	// The activity stream spans libraries but drops events about libraries
+	// the caller may not see (see handleSSEStream), so it is global.
+	{prefix: "/events", resource: "artists", global: true},
+}
+
+// runRoutes are the routes that evaluate rules and apply their fixes. They
+// need auth.ScopeRulesRun rather than a write scope.
+var runRoutes = []string{
+	"POST /rules/run-all",
+	"POST /rules/{id}/run",
+	"POST /artists/{id}/run-rules",
+}
+
+// readOnlyPosts are POST routes that change nothing and are classified as
+// reads of their resource.
+var readOnlyPosts = []string{
+	"POST /rules/validate-expression",
+}
+
+// libraryFilteredRoutes are collection routes whose handler honors a
+// library_id query parameter. A library-restricted token may call them when
+// library_id names one of its libraries; any other collection route would
+// return every library's content and is refused.
+var libraryFilteredRoutes = []string{
+	"GET /artists",
+	"GET /artists/matching-ids",
+	"GET /reports/compliance",
+	"GET /reports/compliance/export",
+	"GET /reports/metadata-completeness",
+}
+
+// apiRoute splits the matched route pattern into its method and its path
+// below /api/v1 (so the base path does not matter). ok is false for routes
+// outside the API, such as the HTML pages.
+func apiRoute(r *http.Request) (method, path string, ok bool) {
+	method, pattern, found := strings.Cut(r.Pattern, " ")
+	if !found {
+		method, pattern = r.Method, r.Pattern
+	}
+	_, path, ok = strings.Cut(pattern, "/api/v1")
+	if !ok || (path != "" && !strings.HasPrefix(path, "/")) {
+		return method, "", false
+	}
+	return method, path, true
+}
+
+// lookupScopeRoute returns the first scopeRoutes entry covering path.
+func lookupScopeRoute(path string) (scopeRoute, bool) {
+	for _, sr := range scopeRoutes {
+		if path == sr.prefix || strings.HasPrefix(path, sr.prefix+"/") {
+			return sr, true
+		}
+	}
+	return scopeRoute{}, false
+}
+
+// requiredScope returns the token scope the matched route requires and
+// whether the route is library-bound. Routes outside the resource map (the
+// HTML pages, and any API route not yet listed) fall back to the coarse
+// auth.ScopeRead or auth.ScopeWrite by method, which resource-scoped tokens
+// do not satisfy: a route must be classified before a narrow token can
+// reach it.
+func requiredScope(r *http.Request) (scope auth.TokenScope, libraryBound bool) {
+	method, path, ok := apiRoute(r)
+	if ok && strings.HasPrefix(path, "/webhooks/inbound/") {
+		return auth.ScopeWebhook, false
+	}
+	var sr scopeRoute
+	if ok {
+		sr, ok = lookupScopeRoute(path)
+	}
+	if !ok {
+		if isReadMethod(method) {
+			return auth.ScopeRead, true
+		}
+		return auth.ScopeWrite, true
+	}
+	if sr.resource == "" {
+		return scopeAny, false
+	}
+
+	route := method + " " + path
+	switch {
+	case slices.Contains(runRoutes, route):
+		// run-all touches every library; per-artist runs are checked
+		// against the artist's libraries like any other artist route.
+		return auth.ScopeRulesRun, true
+	case sr.prefix == "/notifications" && !isReadMethod(method):
+		// Fixing, resolving or dismissing a violation is acting on rule
+		// results, not editing the rules.
+		return auth.ScopeRulesRun, true
+	case isReadMethod(method) || slices.Contains(readOnlyPosts, route):
+		return auth.TokenScope(sr.resource + ":read"), !sr.global
+	}
+	return auth.TokenScope(sr.resource + ":write"), !sr.global
+}
+
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+// authorizeToken enforces the token's scopes and library restriction on the
+// matched route, writing a 403 and returning false when the token may not
+// call it. r must carry the auth context Auth just built.
+func authorizeToken(w http.ResponseWriter, r *http.Request, grant *auth.TokenGrant, cfg *authConfig) bool {
+	scope, libraryBound := requiredScope(r)
+	if scope != scopeAny && !auth.ScopesAllow(grant.Scopes, scope) {
+		writeForbidden(w, "forbidden: missing scope "+string(scope))
+		return false
+	}
+	if !grant.Restricted() || !libraryBound {
+		return true
+	}
+	return authorizeLibraries(w, r, grant.LibraryIDs, cfg, "forbidden: token is not allowed for this library")
+}
+
+// authorizeUser enforces the account's own limits, whatever the credential.
+//
+// A viewer gets the reach of a coarse read token: any route a "read" token
+// could call, plus the scopeAny routes (logout, own preferences), and
+// nothing that changes state.
+//
+// A library grant (libraries non-empty) limits what the user may change, not
+// what they may browse: reads pass, and a change must target one of the
+// granted libraries, so a change that spans libraries (run-all, bulk jobs,
+// scans) is refused. This is looser than a restricted token, which cannot
+// read outside its libraries either, because the HTML pages a person uses
+// are not library-aware; the activity stream is filtered instead (see
+// LibraryVisible).
+func authorizeUser(w http.ResponseWriter, r *http.Request, role string, libraries []string, cfg *authConfig) bool {
+	scope, libraryBound := requiredScope(r)
+	isRead := scope == scopeAny || auth.ScopesAllow(string(auth.ScopeRead), scope)
+	if role == "viewer" && !isRead {
+		writeForbidden(w, "forbidden: viewers cannot make changes")
+		return false
+	}
+	if len(libraries) == 0 || !libraryBound || isRead {
+		return true
+	}
+	return authorizeLibraries(w, r, libraries, cfg, "forbidden: you are not assigned to this library")
+}
+
+// authorizeLibraries lets a library-bound request through when the library
+// it targets (see libraryTarget) is one of allowed, and otherwise writes a
+// 403 with msg. A target that does not exist is left to the handler's 404.
+func authorizeLibraries(w http.ResponseWriter, r *http.Request, allowed []string, cfg *authConfig, msg string) bool {
+	libraries, err := libraryTarget(r, cfg.resolver)
+	if errors.Is(err, ErrScopeTargetNotFound) {
+		return true
+	}
+	if err != nil {
+		slog.Error("resolving library for restricted request", "pattern", r.Pattern, "error", err)
+		http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+		return false
+	}
+	for _, id := range libraries {
+		if slices.Contains(allowed, id) {
+			return true
+		}
+	}
+	writeForbidden(w, msg)
+	return false
+}
+
+// libraryTarget returns the libraries a library-bound request touches: the
+// libraries of the artist or album in the path, the library in the path, or
+// the library_id filter of a libraryFilteredRoutes collection. It returns
+// nil when the request names no single library (a whole-server route such
+// as run-all or a bulk job), which a restricted token is refused.
+func libraryTarget(r *http.Request, resolver LibraryResolver) ([]string, error) {
+	method, path, ok := apiRoute(r)
+	if !ok {
+		return nil, nil
+	}
+	switch {
+	case strings.HasPrefix(path, "/artists/{id}"):
+		if resolver == nil {
+			return nil, nil
+		}
+		return resolver.ArtistLibraryIDs(r.Context(), r.PathValue("id"))
+	case strings.HasPrefix(path, "/albums/{id}"):
+		if resolver == nil {
+			return nil, nil
+		}
+		return resolver.AlbumLibraryIDs(r.Context(), r.PathValue("id"))
+	case strings.HasPrefix(path, "/libraries/{id}"):
+		return []string{r.PathValue("id")}, nil
+	case strings.HasPrefix(path, "/libraries/{libId}"):
+		return []string{r.PathValue("libId")}, nil
+	case slices.Contains(libraryFilteredRoutes, method+" "+path):
+		if id := r.URL.Query().Get("library_id"); id != "" {
+			return []string{id}, nil
+		}
+	}
+	return nil, nil
+}
+
+func writeForbidden(w http.ResponseWriter, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	_, _ = w.Write([]byte(`{"error":"` + msg + `"}`))
+}
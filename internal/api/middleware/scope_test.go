@@ -0,0 +1,314 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/auth"
+)
+
+// stubLibraryResolver maps artist and album IDs to their libraries.
+type stubLibraryResolver struct {
+	artists map[string][]string
+	albums  map[string][]string
+}
+
+func (s *stubLibraryResolver) ArtistLibraryIDs(_ context.Context, id string) ([]string, error) {
+	libs, ok := s.artists[id]
+	if !ok {
+		return nil, ErrScopeTargetNotFound
+	}
+	return libs, nil
+}
+
+func (s *stubLibraryResolver) AlbumLibraryIDs(_ context.Context, id string) ([]string, error) {
+	libs, ok := s.albums[id]
+	if !ok {
+		return nil, ErrScopeTargetNotFound
+	}
+	return libs, nil
+}
+
+// scopeTestMux registers a handful of real route patterns behind Auth, under
+// a base path, the way Router.Handler does.
+func scopeTestMux(grant *auth.TokenGrant) http.Handler {
+	return authTestMux(&mockAuthProvider{
+		validateGrantFn: func(_ context.Context, token string) (*auth.TokenGrant, error) {
+			return grant, nil
+		},
+		getUserRoleFn: func(_ context.Context, _ string) (string, error) {
+			return "administrator", nil
+		},
+	})
+}
+
+// sessionTestMux is scopeTestMux for a session user with the given role and
+// library grant.
+func sessionTestMux(role string, libraries []string) http.Handler {
+	return authTestMux(&mockAuthProvider{
+		validateSessionFn: func(_ context.Context, _ string) (string, error) { return "user-1", nil },
+		getUserRoleFn:     func(_ context.Context, _ string) (string, error) { return role, nil },
+		userLibrariesFn:   func(_ context.Context, _ string) ([]string, error) { return libraries, nil },
+	})
+}
+
+func authTestMux(mock *mockAuthProvider) http.Handler {
+	resolver := &stubLibraryResolver{
+		artists: map[string][]string{"artist-a": {"lib-a"}, "artist-b": {"lib-b"}, "artist-ab": {"lib-b", "lib-a"}},
+		albums:  map[string][]string{"album-a": {"lib-a"}},
+	}
+	authMw := Auth(mock, WithLibraryResolver(resolver))
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	mux := http.NewServeMux()
+	for _, pattern := range []string{
+		"GET /sw/api/v1/artists",
+		"GET /sw/api/v1/artists/{id}",
+		"PATCH /sw/api/v1/artists/{id}/fields/{field}",
+		"POST /sw/api/v1/artists/{id}/images/upload",
+		"POST /sw/api/v1/artists/{id}/run-rules",
+		"GET /sw/api/v1/albums/{id}",
+		"GET /sw/api/v1/rules",
+		"PUT /sw/api/v1/rules/{id}",
+		"POST /sw/api/v1/rules/run-all",
+		"POST /sw/api/v1/rules/validate-expression",
+		"GET /sw/api/v1/connections",
+		"PUT /sw/api/v1/settings",
+		"GET /sw/api/v1/libraries/{id}",
+		"POST /sw/api/v1/bulk/fetch-metadata",
+		"POST /sw/api/v1/webhooks/inbound/lidarr",
+		"GET /sw/api/v1/auth/me",
+		"POST /sw/api/v1/auth/logout",
+		"POST /sw/api/v1/auth/2fa/enroll",
+		"DELETE /sw/api/v1/auth/sessions/{id}",
+		"PATCH /sw/api/v1/preferences",
+		"GET /sw/artists",
+	} {
+		mux.Handle(pattern, authMw(ok))
+	}
+	return mux
+}
+
+func scopeRequest(t *testing.T, h http.Handler, method, target string) int {
+	t.Helper()
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Authorization", "Bearer sw_test")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func sessionRequest(t *testing.T, h http.Handler, method, target string) int {
+	t.Helper()
+	req := httptest.NewRequest(method, target, nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s"})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestAuth_TokenScopes(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		scopes string
+		method string
+		target string
+		want   int
+	}{
+		{"artists read on list", "artists:read", http.MethodGet, "/sw/api/v1/artists", http.StatusOK},
+		{"artists read cannot edit", "artists:read", http.MethodPatch, "/sw/api/v1/artists/artist-a/fields/name", http.StatusForbidden},
+		{"artists write edits", "artists:write", http.MethodPatch, "/sw/api/v1/artists/artist-a/fields/name", http.StatusOK},
+		{"artists write is not images", "artists:write", http.MethodPost, "/sw/api/v1/artists/artist-a/images/upload", http.StatusForbidden},
+		{"images write uploads", "images:write", http.MethodPost, "/sw/api/v1/artists/artist-a/images/upload", http.StatusOK},
+		{"album is artist metadata", "artists:read", http.MethodGet, "/sw/api/v1/albums/album-a", http.StatusOK},
+		{"rules run runs all", "rules:run", http.MethodPost, "/sw/api/v1/rules/run-all", http.StatusOK},
+		{"rules run per artist", "rules:run", http.MethodPost, "/sw/api/v1/artists/artist-a/run-rules", http.StatusOK},
+		{"rules run reads rules", "rules:run", http.MethodGet, "/sw/api/v1/rules", http.StatusOK},
+		{"rules run cannot edit", "rules:run", http.MethodPut, "/sw/api/v1/rules/r1", http.StatusForbidden},
+		{"rules write cannot edit artists", "rules:write", http.MethodPatch, "/sw/api/v1/artists/artist-a/fields/name", http.StatusForbidden},
+		{"validate expression is a read", "rules:read", http.MethodPost, "/sw/api/v1/rules/validate-expression", http.StatusOK},
+		{"connections read", "connections:read", http.MethodGet, "/sw/api/v1/connections", http.StatusOK},
+		{"connections read is not settings", "connections:read", http.MethodPut, "/sw/api/v1/settings", http.StatusForbidden},
+		{"settings write", "settings:write", http.MethodPut, "/sw/api/v1/settings", http.StatusOK},
+		{"settings read cannot revoke sessions", "settings:read", http.MethodDelete, "/sw/api/v1/auth/sessions/s1", http.StatusForbidden},
+		{"settings write revokes sessions", "settings:write", http.MethodDelete, "/sw/api/v1/auth/sessions/s1", http.StatusOK},
+		{"any token reads itself", "images:read", http.MethodGet, "/sw/api/v1/auth/me", http.StatusOK},
+		{"resource scope cannot reach pages", "artists:read", http.MethodGet, "/sw/artists", http.StatusForbidden},
+		{"coarse read reaches pages", "read", http.MethodGet, "/sw/artists", http.StatusOK},
+		{"coarse read cannot write", "read", http.MethodPatch, "/sw/api/v1/artists/artist-a/fields/name", http.StatusForbidden},
+		{"coarse write writes", "write", http.MethodPut, "/sw/api/v1/settings", http.StatusOK},
+		{"webhook token is only a webhook token", "webhook", http.MethodGet, "/sw/api/v1/artists", http.StatusForbidden},
+		{"webhook token receives webhooks", "webhook", http.MethodPost, "/sw/api/v1/webhooks/inbound/lidarr", http.StatusOK},
+		{"write token cannot receive webhooks", "read,write", http.MethodPost, "/sw/api/v1/webhooks/inbound/lidarr", http.StatusForbidden},
+		{"admin does everything", "admin", http.MethodPost, "/sw/api/v1/webhooks/inbound/lidarr", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			h := scopeTestMux(&auth.TokenGrant{UserID: "user-1", Scopes: tt.scopes})
+			if got := scopeRequest(t, h, tt.method, tt.target); got != tt.want {
+				t.Errorf("%s %s with %q: status = %d, want %d", tt.method, tt.target, tt.scopes, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuth_TokenLibraryRestriction(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   int
+	}{
+		{"own artist", http.MethodGet, "/sw/api/v1/artists/artist-a", http.StatusOK},
+		{"other library's artist", http.MethodGet, "/sw/api/v1/artists/artist-b", http.StatusForbidden},
+		{"artist in both libraries", http.MethodPatch, "/sw/api/v1/artists/artist-ab/fields/name", http.StatusOK},
+		{"unknown artist reaches the handler", http.MethodGet, "/sw/api/v1/artists/missing", http.StatusOK},
+		{"own album", http.MethodGet, "/sw/api/v1/albums/album-a", http.StatusOK},
+		{"own library", http.MethodGet, "/sw/api/v1/libraries/lib-a", http.StatusOK},
+		{"other library", http.MethodGet, "/sw/api/v1/libraries/lib-b", http.StatusForbidden},
+		{"list filtered to own library", http.MethodGet, "/sw/api/v1/artists?library_id=lib-a", http.StatusOK},
+		{"list filtered to other library", http.MethodGet, "/sw/api/v1/artists?library_id=lib-b", http.StatusForbidden},
+		{"unfiltered list", http.MethodGet, "/sw/api/v1/artists", http.StatusForbidden},
+		{"run rules on own artist", http.MethodPost, "/sw/api/v1/artists/artist-a/run-rules", http.StatusOK},
+		{"run-all spans libraries", http.MethodPost, "/sw/api/v1/rules/run-all", http.StatusForbidden},
+		{"bulk job spans libraries", http.MethodPost, "/sw/api/v1/bulk/fetch-metadata", http.StatusForbidden},
+		{"rule definitions are global", http.MethodGet, "/sw/api/v1/rules", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			h := scopeTestMux(&auth.TokenGrant{UserID: "user-1", Scopes: "write", LibraryIDs: []string{"lib-a"}})
+			if got := scopeRequest(t, h, tt.method, tt.target); got != tt.want {
+				t.Errorf("%s %s: status = %d, want %d", tt.method, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuth_SessionSkipsScopeChecks(t *testing.T) {
+	t.Parallel()
+	mock := &mockAuthProvider{
+		validateSessionFn: func(_ context.Context, _ string) (string, error) { return "user-1", nil },
+		getUserRoleFn:     func(_ context.Context, _ string) (string, error) { return "operator", nil },
+	}
+	mux := http.NewServeMux()
+	mux.Handle("PUT /api/v1/settings", Auth(mock)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/settings", nil)
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s"})
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want 200", rec.Code)
+	}
+}
+
+func TestAuth_ViewerCannotMutate(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   int
+	}{
+		{"browses artists", http.MethodGet, "/sw/api/v1/artists/artist-b", http.StatusOK},
+		{"browses pages", http.MethodGet, "/sw/artists", http.StatusOK},
+		{"reads rules", http.MethodGet, "/sw/api/v1/rules", http.StatusOK},
+		{"validates an expression", http.MethodPost, "/sw/api/v1/rules/validate-expression", http.StatusOK},
+		{"logs out", http.MethodPost, "/sw/api/v1/auth/logout", http.StatusOK},
+		{"enrolls in two-factor", http.MethodPost, "/sw/api/v1/auth/2fa/enroll", http.StatusOK},
+		{"keeps own preferences", http.MethodPatch, "/sw/api/v1/preferences", http.StatusOK},
+		{"cannot edit an artist", http.MethodPatch, "/sw/api/v1/artists/artist-a/fields/name", http.StatusForbidden},
+		{"cannot upload images", http.MethodPost, "/sw/api/v1/artists/artist-a/images/upload", http.StatusForbidden},
+		{"cannot run rules", http.MethodPost, "/sw/api/v1/rules/run-all", http.StatusForbidden},
+		{"cannot change settings", http.MethodPut, "/sw/api/v1/settings", http.StatusForbidden},
+		{"cannot receive webhooks", http.MethodPost, "/sw/api/v1/webhooks/inbound/lidarr", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := sessionRequest(t, sessionTestMux("viewer", nil), tt.method, tt.target); got != tt.want {
+				t.Errorf("%s %s: status = %d, want %d", tt.method, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuth_ViewerTokenCannotMutate(t *testing.T) {
+	t.Parallel()
+	// A token keeps its scopes when its owner is demoted to viewer, but the
+	// role still caps it.
+	h := authTestMux(&mockAuthProvider{
+		validateGrantFn: func(_ context.Context, _ string) (*auth.TokenGrant, error) {
+			return &auth.TokenGrant{UserID: "user-1", Scopes: "write"}, nil
+		},
+		getUserRoleFn: func(_ context.Context, _ string) (string, error) { return "viewer", nil },
+	})
+	if got := scopeRequest(t, h, http.MethodGet, "/sw/api/v1/artists/artist-a"); got != http.StatusOK {
+		t.Errorf("read: status = %d, want 200", got)
+	}
+	if got := scopeRequest(t, h, http.MethodPatch, "/sw/api/v1/artists/artist-a/fields/name"); got != http.StatusForbidden {
+		t.Errorf("write: status = %d, want 403", got)
+	}
+}
+
+func TestAuth_UserLibraryGrant(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   int
+	}{
+		{"edits own library's artist", http.MethodPatch, "/sw/api/v1/artists/artist-a/fields/name", http.StatusOK},
+		{"cannot edit other library's artist", http.MethodPatch, "/sw/api/v1/artists/artist-b/fields/name", http.StatusForbidden},
+		{"edits artist shared with own library", http.MethodPatch, "/sw/api/v1/artists/artist-ab/fields/name", http.StatusOK},
+		{"browses other library's artist", http.MethodGet, "/sw/api/v1/artists/artist-b", http.StatusOK},
+		{"browses unfiltered list", http.MethodGet, "/sw/api/v1/artists", http.StatusOK},
+		{"runs rules on own artist", http.MethodPost, "/sw/api/v1/artists/artist-a/run-rules", http.StatusOK},
+		{"cannot run rules on other artist", http.MethodPost, "/sw/api/v1/artists/artist-b/run-rules", http.StatusForbidden},
+		{"cannot run all rules", http.MethodPost, "/sw/api/v1/rules/run-all", http.StatusForbidden},
+		{"cannot start a bulk job", http.MethodPost, "/sw/api/v1/bulk/fetch-metadata", http.StatusForbidden},
+		{"global settings are not library content", http.MethodPut, "/sw/api/v1/settings", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := sessionRequest(t, sessionTestMux("operator", []string{"lib-a"}), tt.method, tt.target); got != tt.want {
+				t.Errorf("%s %s: status = %d, want %d", tt.method, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLibraryVisible(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	if !LibraryVisible(ctx, []string{"lib-b"}) {
+		t.Error("an unrestricted caller should see every library")
+	}
+
+	user := WithTestUserLibraries(ctx, []string{"lib-a"})
+	if !LibraryVisible(user, []string{"lib-b", "lib-a"}) {
+		t.Error("content in any granted library should be visible")
+	}
+	if LibraryVisible(user, []string{"lib-b"}) {
+		t.Error("content in another library should be hidden")
+	}
+	if !LibraryVisible(user, nil) {
+		t.Error("content that names no library should be visible")
+	}
+
+	// Token and user restrictions both apply.
+	both := WithTestTokenGrant(user, &auth.TokenGrant{UserID: "user-1", Scopes: "read", LibraryIDs: []string{"lib-b"}})
+	if LibraryVisible(both, []string{"lib-a"}) || LibraryVisible(both, []string{"lib-b"}) {
+		t.Error("content must pass both the token and the user restriction")
+	}
+}
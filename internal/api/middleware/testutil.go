@@ -1,6 +1,10 @@
 package middleware
 
-import "context"
+import (
+	"context"
+
+	"github.com/sydlexius/stillwater/internal/auth"
+)
 
 // WithTestUserID injects a user ID into the context. This is intended for
 // handler-level unit tests that call handler methods directly (bypassing the
@@ -23,3 +27,20 @@ func WithTestRole(ctx context.Context, role string) context.Context {
 func WithTestUXChannel(ctx context.Context, ch UXChannel) context.Context {
 	return context.WithValue(ctx, uxChannelKey, ch)
 }
+
+// WithTestTokenGrant injects API token auth (method, scopes and library
+// restriction) into the context. This is intended for handler-level unit
+// tests that need to simulate a token caller without going through Auth.
+func WithTestTokenGrant(ctx context.Context, grant *auth.TokenGrant) context.Context {
+	ctx = context.WithValue(ctx, userIDKey, grant.UserID)
+	ctx = context.WithValue(ctx, authMethodKey, "api_token")
+	ctx = context.WithValue(ctx, tokenScopesKey, grant.Scopes)
+	return context.WithValue(ctx, tokenGrantKey, grant)
+}
+
+// WithTestUserLibraries injects a user's library grant into the context. This
+// is intended for handler-level unit tests that need to simulate a
+// library-restricted operator or viewer without going through Auth.
+func WithTestUserLibraries(ctx context.Context, libraryIDs []string) context.Context {
+	return context.WithValue(ctx, userLibsKey, libraryIDs)
+}
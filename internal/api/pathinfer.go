@@ -10,7 +10,9 @@ import (
 	"github.com/sydlexius/stillwater/internal/connection"
 	"github.com/sydlexius/stillwater/internal/connection/emby"
 	"github.com/sydlexius/stillwater/internal/connection/jellyfin"
+	"github.com/sydlexius/stillwater/internal/connection/kodi"
 	"github.com/sydlexius/stillwater/internal/connection/lidarr"
+	"github.com/sydlexius/stillwater/internal/connection/plex"
 )
 
 // lidarrArtistLister is the narrow read surface path-mapping inference needs
@@ -70,6 +72,8 @@ var mediaArtistListerFactory = func(conn *connection.Connection, logger *slog.Lo
 		return embyArtistLister{c: emby.New(conn.URL, conn.APIKey, conn.GetPlatformUserID(), logger), logger: logger}
 	case connection.TypeJellyfin:
 		return jellyfinArtistLister{c: jellyfin.New(conn.URL, conn.APIKey, conn.GetPlatformUserID(), logger), logger: logger}
+	case connection.TypePlex:
+		return plexArtistLister{c: plex.New(conn.URL, conn.APIKey, logger), logger: logger}
 	default:
 		return nil
 	}
@@ -157,10 +161,52 @@ func (l jellyfinArtistLister) ListArtistPaths(ctx context.Context) ([]platformAr
 	return out, nil
 }
 
+type plexArtistLister struct {
+	c      *plex.Client
+	logger *slog.Logger
+}
+
+// ListArtistPaths reports each Plex artist's first Location, which is its
+// folder as the Plex server sees it; artists the agent matched to MusicBrainz
+// carry the MBID from their mbid:// Guid.
+func (l plexArtistLister) ListArtistPaths(ctx context.Context) ([]platformArtistPath, error) {
+	sections, err := l.c.GetMusicLibraries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing plex music libraries: %w", err)
+	}
+	var out []platformArtistPath
+	for i := range sections {
+		page := 0
+		for start := 0; ; start += mediaArtistPageSize {
+			if page >= mediaArtistPageCap {
+				l.logger.Error("plex artist enumeration truncated: page cap reached",
+					"section", sections[i].Key, "page_cap", mediaArtistPageCap)
+				break
+			}
+			page++
+			resp, err := l.c.GetArtists(ctx, sections[i].Key, start, mediaArtistPageSize)
+			if err != nil {
+				return nil, fmt.Errorf("listing plex artists: %w", err)
+			}
+			items := resp.MediaContainer.Metadata
+			if len(items) == 0 {
+				break
+			}
+			for j := range items {
+				out = append(out, platformArtistPath{MBID: items[j].MusicBrainzID(), Path: items[j].Path()})
+			}
+			if start+len(items) >= resp.MediaContainer.TotalSize {
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
 // listPlatformArtistPaths enumerates the peer's artists as (MBID, platform path)
 // records, dispatching on connection type. Lidarr goes through the pre-existing
-// lidarrArtistLister seam (kept so #2329's tests keep working); Emby and
-// Jellyfin go through mediaArtistLister. Returns nil for an unknown type.
+// lidarrArtistLister seam (kept so #2329's tests keep working); Emby,
+// Jellyfin and Plex go through mediaArtistLister. Returns nil for an unknown type.
 func (r *Router) listPlatformArtistPaths(ctx context.Context, conn *connection.Connection) ([]platformArtistPath, error) {
 	switch conn.Type {
 	case connection.TypeLidarr:
@@ -177,7 +223,7 @@ func (r *Router) listPlatformArtistPaths(ctx context.Context, conn *connection.C
 			out = append(out, platformArtistPath{MBID: a.ForeignArtistID, Path: a.Path})
 		}
 		return out, nil
-	case connection.TypeEmby, connection.TypeJellyfin:
+	case connection.TypeEmby, connection.TypeJellyfin, connection.TypePlex:
 		lister := mediaArtistListerFactory(conn, r.logger)
 		if lister == nil {
 			return nil, nil
@@ -273,6 +319,10 @@ var platformRootListerFactory = func(conn *connection.Connection, logger *slog.L
 		return embyRoots{emby.New(conn.URL, conn.APIKey, conn.GetPlatformUserID(), logger)}
 	case connection.TypeJellyfin:
 		return jellyfinRoots{jellyfin.New(conn.URL, conn.APIKey, conn.GetPlatformUserID(), logger)}
+	case connection.TypePlex:
+		return plexRoots{plex.New(conn.URL, conn.APIKey, logger)}
+	case connection.TypeKodi:
+		return kodiRoots{kodi.New(conn.URL, conn.APIKey, logger)}
 	default:
 		return nil
 	}
@@ -321,6 +371,38 @@ func (l jellyfinRoots) ListRoots(ctx context.Context) ([]string, error) {
 	return out, nil
 }
 
+type plexRoots struct{ c *plex.Client }
+
+func (l plexRoots) ListRoots(ctx context.Context) ([]string, error) {
+	sections, err := l.c.GetMusicLibraries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for i := range sections {
+		for _, loc := range sections[i].Locations {
+			out = append(out, loc.Path)
+		}
+	}
+	return out, nil
+}
+
+// kodiRoots lists the folders behind Kodi's music sources. Kodi reports no
+// artist paths, so root pairing is the only evidence inference gets from it.
+type kodiRoots struct{ c *kodi.Client }
+
+func (l kodiRoots) ListRoots(ctx context.Context) ([]string, error) {
+	sources, err := l.c.GetSources(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for i := range sources {
+		out = append(out, sources[i].Paths()...)
+	}
+	return out, nil
+}
+
 // listPlatformRoots asks the peer for its own roots. Returns (nil, nil) for a
 // connection type with no root surface.
 func (r *Router) listPlatformRoots(ctx context.Context, conn *connection.Connection) ([]string, error) {
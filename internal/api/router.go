@@ -11,8 +11,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/sydlexius/stillwater/internal/album"
 	"github.com/sydlexius/stillwater/internal/api/middleware"
 	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/artwork"
 	"github.com/sydlexius/stillwater/internal/auth"
 	"github.com/sydlexius/stillwater/internal/backup"
 	"github.com/sydlexius/stillwater/internal/collision"
@@ -65,9 +67,14 @@ type RouterDeps struct {
 	ConnectionService  *connection.Service
 	ScraperService     *scraper.Service
 	LibraryService     *library.Service
+	AlbumService       *album.Service
 	WebhookService     *webhook.Service
 	WebhookDispatcher  *webhook.Dispatcher
 	BackupService      *backup.Service
+	// ArtworkRevisions serves the artwork revision history endpoints. nil
+	// disables them (503); the image writes themselves are recorded through
+	// img.SetRevisionRecorder, not through the Router.
+	ArtworkRevisions   *artwork.Store
 	LogManager         *logging.Manager
 	MaintenanceService *maintenance.Service
 	SettingsIOService  *settingsio.Service
@@ -139,9 +146,11 @@ type Router struct {
 	connectionService  *connection.Service
 	scraperService     *scraper.Service
 	libraryService     *library.Service
+	albumService       *album.Service
 	webhookService     *webhook.Service
 	webhookDispatcher  *webhook.Dispatcher
 	backupService      *backup.Service
+	artworkRevisions   *artwork.Store
 	logManager         *logging.Manager
 	maintenanceService *maintenance.Service
 	settingsIOService  *settingsio.Service
@@ -436,9 +445,11 @@ func NewRouter(deps RouterDeps) *Router {
 		connectionService:        deps.ConnectionService,
 		scraperService:           deps.ScraperService,
 		libraryService:           deps.LibraryService,
+		albumService:             deps.AlbumService,
 		webhookService:           deps.WebhookService,
 		webhookDispatcher:        deps.WebhookDispatcher,
 		backupService:            deps.BackupService,
+		artworkRevisions:         deps.ArtworkRevisions,
 		logManager:               deps.LogManager,
 		maintenanceService:       deps.MaintenanceService,
 		settingsIOService:        deps.SettingsIOService,
@@ -463,7 +474,6 @@ func NewRouter(deps RouterDeps) *Router {
 		fanartInvalidator:        deps.ArtistService,
 		libraryOps:               make(map[string]*LibraryOpResult),
 		discographyFetchInFlight: make(map[string]bool),
-		undoStore:                rule.NewUndoStore(),
 		i18nBundle:               deps.I18nBundle,
 		reIdentifyWizardStore:    newReIdentifyWizardStore(),
 		webhookShutdownCtx:       webhookCtx,
@@ -474,6 +484,12 @@ func NewRouter(deps RouterDeps) *Router {
 		musicLibraryPath:         deps.MusicLibraryPath,
 	}
 
+	// Undo records are durable (migration 033), so the store needs the
+	// database and the artist service its field reverts write through.
+	if deps.DB != nil && deps.ArtistService != nil {
+		r.undoStore = rule.NewUndoStore(deps.DB, deps.ArtistService)
+	}
+
 	// Auto-init the SSE hub if not provided by the caller, so the /events/stream
 	// endpoint is always functional even when main.go does not wire one.
 	if r.sseHub == nil {
@@ -576,16 +592,26 @@ func (r *Router) ConflictGate() *conflict.Gate {
 // Handler returns the fully configured HTTP handler with middleware applied.
 // The provided context controls the lifecycle of background goroutines (e.g. rate limiter cleanup).
 func (r *Router) Handler(ctx context.Context) http.Handler {
-	authMw := middleware.Auth(r.authService)
+	authMw := middleware.Auth(r.authService, middleware.WithLibraryResolver(r))
 	optAuthMw := middleware.OptionalAuth(r.authService)
 	csrf := middleware.NewCSRF(r.sessionSecret)
 	loginRL := middleware.NewLoginRateLimiter(ctx, r.trustedProxies)
 	requireMultiUser := middleware.RequireMultiUser(r.getStringSetting)
 
-	// Start periodic expiry of stale undo entries so the store does not grow unbounded.
+	// Release stale undo claims and start pruning undo records past
+	// rule.UndoRetention so the table does not grow unbounded.
 	if r.undoStore != nil {
 		r.undoStore.StartCleanup(ctx)
 	}
+	// Apply the artwork revision retention policy hourly and sweep the
+	// objects it (or an artist deletion) left unreferenced.
+	if r.artworkRevisions != nil {
+		r.artworkRevisions.StartCleanup(ctx)
+	}
+	// Drop outbound webhook delivery records past webhook.DeliveryRetention.
+	if r.webhookDispatcher != nil {
+		r.webhookDispatcher.StartDeliveryCleanup(ctx)
+	}
 	mux := http.NewServeMux()
 	bp := r.basePath
 
@@ -595,6 +621,10 @@ func (r *Router) Handler(ctx context.Context) http.Handler {
 	mux.HandleFunc("GET "+bp+"/api/v1/docs", r.handleAPIDocs)
 	mux.HandleFunc("GET "+bp+"/api/v1/docs/openapi.yaml", r.handleOpenAPISpec)
 	mux.Handle("POST "+bp+"/api/v1/auth/login", loginRL.Middleware(http.HandlerFunc(r.handleLogin)))
+	// Second login step for accounts with two-factor authentication. The
+	// challenge from /auth/login stands in for a session, so it is
+	// CSRF-exempt and throttled like the password step.
+	mux.Handle("POST "+bp+"/api/v1/auth/login/2fa", loginRL.Middleware(http.HandlerFunc(r.handleLoginSecondFactor)))
 	mux.Handle("POST "+bp+"/api/v1/auth/setup", loginRL.Middleware(http.HandlerFunc(r.handleSetup)))
 	// Pre-admin restore-from-backup (#1114). Same rate-limiter as login/setup
 	// so brute-forcing the passphrase is throttled identically. The handler
@@ -603,10 +633,16 @@ func (r *Router) Handler(ctx context.Context) http.Handler {
 	// CSRF-exempt for the same reason as /auth/setup: no session to attach
 	// a token to.
 	mux.Handle("POST "+bp+"/api/v1/setup/restore", loginRL.Middleware(http.HandlerFunc(r.handleSetupRestore)))
+	// The restore form's picker of archives on off-box backup targets. Same
+	// gates and limiter: every call reaches out to the targets.
+	mux.Handle("GET "+bp+"/api/v1/setup/restore/remote", loginRL.Middleware(http.HandlerFunc(r.handleSetupRestoreRemote)))
 	mux.Handle("POST "+bp+"/api/v1/users/register", loginRL.Middleware(requireMultiUser(r.handleRegister)))
 	// OIDC authentication flow (public, rate-limited)
 	mux.Handle("GET "+bp+"/api/v1/auth/oidc/login", loginRL.Middleware(http.HandlerFunc(r.handleOIDCLogin)))
 	mux.Handle("GET "+bp+"/api/v1/auth/oidc/callback", loginRL.Middleware(http.HandlerFunc(r.handleOIDCCallback)))
+	// Forward authentication (public, rate-limited): the login page hands a
+	// visitor the SSO proxy already identified to this route.
+	mux.Handle("GET "+bp+"/api/v1/auth/forward/login", loginRL.Middleware(http.HandlerFunc(r.handleForwardLogin)))
 	mux.HandleFunc("GET "+bp+"/register", requireMultiUser(r.handleRegisterPage))
 	mux.Handle("GET "+bp+"/static/", r.staticAssets.Handler(bp))
 	mux.HandleFunc("GET "+bp+"/", wrapOptionalAuth(r.handleIndex, optAuthMw))
@@ -614,6 +650,16 @@ func (r *Router) Handler(ctx context.Context) http.Handler {
 	// Protected routes (auth required)
 	mux.HandleFunc("POST "+bp+"/api/v1/auth/logout", wrapAuth(r.handleLogout, authMw))
 	mux.HandleFunc("GET "+bp+"/api/v1/auth/me", wrapAuth(r.handleMe, authMw))
+	// Two-factor self-service (session only; the handlers refuse API tokens)
+	mux.HandleFunc("GET "+bp+"/api/v1/auth/2fa", wrapAuth(r.handleGetTwoFactor, authMw))
+	mux.HandleFunc("POST "+bp+"/api/v1/auth/2fa/enroll", wrapAuth(r.handleEnrollTwoFactor, authMw))
+	mux.HandleFunc("POST "+bp+"/api/v1/auth/2fa/confirm", wrapAuth(r.handleConfirmTwoFactor, authMw))
+	mux.HandleFunc("POST "+bp+"/api/v1/auth/2fa/disable", wrapAuth(r.handleDisableTwoFactor, authMw))
+	mux.HandleFunc("POST "+bp+"/api/v1/auth/2fa/recovery-codes", wrapAuth(r.handleRegenerateRecoveryCodes, authMw))
+	// Session management: the caller's own login sessions
+	mux.HandleFunc("GET "+bp+"/api/v1/auth/sessions", wrapAuth(r.handleListSessions, authMw))
+	mux.HandleFunc("DELETE "+bp+"/api/v1/auth/sessions", wrapAuth(r.handleRevokeSessions, authMw))
+	mux.HandleFunc("DELETE "+bp+"/api/v1/auth/sessions/{id}", wrapAuth(r.handleRevokeSession, authMw))
 	// API token routes
 	mux.HandleFunc("POST "+bp+"/api/v1/auth/tokens", wrapAuth(r.handleCreateAPIToken, authMw))
 	mux.HandleFunc("GET "+bp+"/api/v1/auth/tokens", wrapAuth(r.handleListAPITokens, authMw))
@@ -638,6 +684,15 @@ func (r *Router) Handler(ctx context.Context) http.Handler {
 	// `/auth/tokens/{id}/permanent` precedent for revoke-vs-delete). See
 	// issue #1170.
 	mux.HandleFunc("DELETE "+bp+"/api/v1/users/{id}/account/permanent", wrapAuth(requireMultiUser(middleware.RequireAdmin(r.handleDeleteUser)), authMw))
+	// Two-factor reset for a user who lost their authenticator; same 4-segment
+	// shape as the permanent delete above, for the same reason.
+	mux.HandleFunc("DELETE "+bp+"/api/v1/users/{id}/account/2fa", wrapAuth(requireMultiUser(middleware.RequireAdmin(r.handleResetUserTwoFactor)), authMw))
+	mux.HandleFunc("DELETE "+bp+"/api/v1/users/{id}/account/lockout", wrapAuth(requireMultiUser(middleware.RequireAdmin(r.handleUnlockUser)), authMw))
+	// Sessions across all users. The literal /users/sessions routes sit
+	// beside /users/invites; signing out one user uses the 4-segment shape.
+	mux.HandleFunc("GET "+bp+"/api/v1/users/sessions", wrapAuth(requireMultiUser(middleware.RequireAdmin(r.handleListAllSessions)), authMw))
+	mux.HandleFunc("DELETE "+bp+"/api/v1/users/sessions/{id}", wrapAuth(requireMultiUser(middleware.RequireAdmin(r.handleAdminRevokeSession)), authMw))
+	mux.HandleFunc("DELETE "+bp+"/api/v1/users/{id}/account/sessions", wrapAuth(requireMultiUser(middleware.RequireAdmin(r.handleRevokeUserSessions)), authMw))
 	mux.HandleFunc("GET "+bp+"/api/v1/artists", wrapAuth(r.handleListArtists, authMw))
 	mux.HandleFunc("GET "+bp+"/api/v1/artists/badge", wrapAuth(r.handleArtistsBadge, authMw))
 	mux.HandleFunc("GET "+bp+"/api/v1/artists/locked", wrapAuth(r.handleListLockedArtists, authMw))
@@ -759,6 +814,9 @@ func (r *Router) Handler(ctx context.Context) http.Handler {
 	mux.HandleFunc("PUT "+bp+"/api/v1/webhooks/{id}", wrapAuth(middleware.RequireAdmin(r.handleUpdateWebhook), authMw))
 	mux.HandleFunc("DELETE "+bp+"/api/v1/webhooks/{id}", wrapAuth(middleware.RequireAdmin(r.handleDeleteWebhook), authMw))
 	mux.HandleFunc("POST "+bp+"/api/v1/webhooks/{id}/test", wrapAuth(middleware.RequireAdmin(r.handleTestWebhook), authMw))
+	mux.HandleFunc("GET "+bp+"/api/v1/webhooks/{id}/deliveries", wrapAuth(middleware.RequireAdmin(r.handleListWebhookDeliveries), authMw))
+	mux.HandleFunc("POST "+bp+"/api/v1/webhooks/{id}/deliveries/replay-failed", wrapAuth(middleware.RequireAdmin(r.handleReplayFailedWebhookDeliveries), authMw))
+	mux.HandleFunc("POST "+bp+"/api/v1/webhooks/{id}/deliveries/{deliveryId}/replay", wrapAuth(middleware.RequireAdmin(r.handleReplayWebhookDelivery), authMw))
 	// Settings routes (all require admin)
 	mux.HandleFunc("GET "+bp+"/api/v1/settings", wrapAuth(middleware.RequireAdmin(r.handleGetSettings), authMw))
 	mux.HandleFunc("PUT "+bp+"/api/v1/settings", wrapAuth(middleware.RequireAdmin(r.handleUpdateSettings), authMw))
@@ -770,6 +828,8 @@ func (r *Router) Handler(ctx context.Context) http.Handler {
 	mux.HandleFunc("GET "+bp+"/api/v1/settings/backup/history", wrapAuth(middleware.RequireAdmin(r.handleBackupHistory), authMw))
 	mux.HandleFunc("DELETE "+bp+"/api/v1/settings/backup/{filename}", wrapAuth(middleware.RequireAdmin(r.handleBackupDelete), authMw))
 	mux.HandleFunc("GET "+bp+"/api/v1/settings/backup/{filename}", wrapAuth(middleware.RequireAdmin(r.handleBackupDownload), authMw))
+	mux.HandleFunc("POST "+bp+"/api/v1/settings/backup/{filename}/restore", wrapAuth(middleware.RequireAdmin(r.handleBackupRestore), authMw))
+	mux.HandleFunc("POST "+bp+"/api/v1/settings/backup/{filename}/verify", wrapAuth(middleware.RequireAdmin(r.handleBackupVerify), authMw))
 	// Logging routes (admin only)
 	mux.HandleFunc("GET "+bp+"/api/v1/settings/logging", wrapAuth(middleware.RequireAdmin(r.handleGetLogging), authMw))
 	mux.HandleFunc("PUT "+bp+"/api/v1/settings/logging", wrapAuth(middleware.RequireAdmin(r.handleUpdateLogging), authMw))
@@ -824,6 +884,10 @@ func (r *Router) Handler(ctx context.Context) http.Handler {
 	mux.HandleFunc("PUT "+bp+"/api/v1/providers/priorities/{field}/{provider}/toggle", wrapAuth(middleware.RequireAdmin(r.handleToggleFieldProvider), authMw))
 	mux.HandleFunc("PUT "+bp+"/api/v1/providers/{name}/mirror", wrapAuth(middleware.RequireAdmin(r.handleSetMirror), authMw))
 	mux.HandleFunc("DELETE "+bp+"/api/v1/providers/{name}/mirror", wrapAuth(middleware.RequireAdmin(r.handleDeleteMirror), authMw))
+	mux.HandleFunc("GET "+bp+"/api/v1/providers/cache", wrapAuth(middleware.RequireAdmin(r.handleProviderCacheStats), authMw))
+	mux.HandleFunc("DELETE "+bp+"/api/v1/providers/cache", wrapAuth(middleware.RequireAdmin(r.handleClearProviderCache), authMw))
+	mux.HandleFunc("PUT "+bp+"/api/v1/providers/cache/offline", wrapAuth(middleware.RequireAdmin(r.handleSetProviderCacheOffline), authMw))
+	mux.HandleFunc("PUT "+bp+"/api/v1/providers/{name}/cache-ttl", wrapAuth(middleware.RequireAdmin(r.handleSetProviderCacheTTL), authMw))
 	mux.HandleFunc("POST "+bp+"/api/v1/providers/search", wrapAuth(r.handleProviderSearch, authMw))
 	mux.HandleFunc("POST "+bp+"/api/v1/providers/fetch", wrapAuth(r.handleProviderFetch, authMw))
 	// Web search provider routes (toggle requires admin)
@@ -840,7 +904,10 @@ func (r *Router) Handler(ctx context.Context) http.Handler {
 
 	// Rule routes (config/enable requires admin; execution/evaluate/fix are operator-accessible)
 	mux.HandleFunc("GET "+bp+"/api/v1/rules", wrapAuth(r.handleListRules, authMw))
+	mux.HandleFunc("POST "+bp+"/api/v1/rules", wrapAuth(middleware.RequireAdmin(r.handleCreateRule), authMw))
+	mux.HandleFunc("POST "+bp+"/api/v1/rules/validate-expression", wrapAuth(r.handleValidateRuleExpression, authMw))
 	mux.HandleFunc("PUT "+bp+"/api/v1/rules/{id}", wrapAuth(middleware.RequireAdmin(r.handleUpdateRule), authMw))
+	mux.HandleFunc("DELETE "+bp+"/api/v1/rules/{id}", wrapAuth(middleware.RequireAdmin(r.handleDeleteRule), authMw))
 	mux.HandleFunc("GET "+bp+"/api/v1/rules/{id}/results", wrapAuth(r.handleRuleResults, authMw))
 	mux.HandleFunc("POST "+bp+"/api/v1/rules/{id}/run", wrapAuth(r.handleRunRule, authMw))
 	mux.HandleFunc("POST "+bp+"/api/v1/rules/run-all", wrapAuth(r.handleRunAllRules, authMw))
@@ -871,6 +938,8 @@ func (r *Router) Handler(ctx context.Context) http.Handler {
 	mux.HandleFunc("GET "+bp+"/api/v1/bulk/jobs", wrapAuth(r.handleBulkJobList, authMw))
 	mux.HandleFunc("GET "+bp+"/api/v1/bulk/jobs/{id}", wrapAuth(r.handleBulkJobStatus, authMw))
 	mux.HandleFunc("POST "+bp+"/api/v1/bulk/jobs/{id}/cancel", wrapAuth(r.handleBulkJobCancel, authMw))
+	mux.HandleFunc("GET "+bp+"/api/v1/bulk/jobs/{id}/undo", wrapAuth(r.handleListBulkJobUndo, authMw))
+	mux.HandleFunc("POST "+bp+"/api/v1/bulk/jobs/{id}/undo", wrapAuth(r.handleRevertBulkJob, authMw))
 
 	// Bulk identify routes
 	mux.HandleFunc("POST "+bp+"/api/v1/artists/bulk-identify", wrapAuth(r.handleBulkIdentify, authMw))
@@ -927,6 +996,7 @@ func (r *Router) Handler(ctx context.Context) http.Handler {
 
 	// History routes
 	mux.HandleFunc("GET "+bp+"/api/v1/artists/{id}/history", wrapAuth(r.handleListArtistHistory, authMw))
+	mux.HandleFunc("GET "+bp+"/api/v1/artists/{id}/undo", wrapAuth(r.handleListArtistUndo, authMw))
 	mux.HandleFunc("GET "+bp+"/artists/{id}/history/tab", wrapOptionalAuth(r.handleArtistHistoryTab, optAuthMw))
 	mux.HandleFunc("GET "+bp+"/artists/{id}/violations/tab", wrapOptionalAuth(r.handleArtistViolationsTab, optAuthMw))
 	mux.HandleFunc("GET "+bp+"/artists/{id}/discography/tab", wrapOptionalAuth(r.handleArtistDiscographyTab, optAuthMw))
@@ -952,6 +1022,15 @@ func (r *Router) Handler(ctx context.Context) http.Handler {
 	// Refresh and disambiguation routes
 	mux.HandleFunc("POST "+bp+"/api/v1/artists/{id}/rename-directory", wrapAuth(r.handleArtistRenameDirectory, authMw))
 	mux.HandleFunc("POST "+bp+"/api/v1/artists/{id}/refresh", wrapAuth(r.handleArtistRefresh, authMw))
+	mux.HandleFunc("DELETE "+bp+"/api/v1/artists/{id}/provider-cache", wrapAuth(r.handleInvalidateArtistProviderCache, authMw))
+	// Album routes: per-album metadata, album.nfo write-back and artwork.
+	mux.HandleFunc("GET "+bp+"/api/v1/artists/{id}/albums", wrapAuth(r.handleListArtistAlbums, authMw))
+	mux.HandleFunc("POST "+bp+"/api/v1/artists/{id}/albums/scan", wrapAuth(r.handleScanArtistAlbums, authMw))
+	mux.HandleFunc("GET "+bp+"/api/v1/albums/{id}", wrapAuth(r.handleGetAlbum, authMw))
+	mux.HandleFunc("PATCH "+bp+"/api/v1/albums/{id}", wrapAuth(r.handleUpdateAlbum, authMw))
+	mux.HandleFunc("POST "+bp+"/api/v1/albums/{id}/field-locks/{field}", wrapAuth(r.handleLockAlbumField, authMw))
+	mux.HandleFunc("DELETE "+bp+"/api/v1/albums/{id}/field-locks/{field}", wrapAuth(r.handleUnlockAlbumField, authMw))
+	mux.HandleFunc("GET "+bp+"/api/v1/albums/{id}/images/{type}/file", wrapAuth(r.handleServeAlbumImage, authMw))
 	mux.HandleFunc("POST "+bp+"/api/v1/artists/{id}/refresh/search", wrapAuth(r.handleRefreshSearch, authMw))
 	mux.HandleFunc("POST "+bp+"/api/v1/artists/{id}/refresh/link", wrapAuth(r.handleRefreshLink, authMw))
 	mux.HandleFunc("POST "+bp+"/api/v1/artists/{id}/reidentify", wrapAuth(r.handleReidentify, authMw))
@@ -991,6 +1070,11 @@ func (r *Router) Handler(ctx context.Context) http.Handler {
 	mux.HandleFunc("POST "+bp+"/api/v1/artists/{id}/images/stage", wrapAuth(r.handleImageStage, authMw))
 	mux.HandleFunc("POST "+bp+"/api/v1/artists/{id}/images/logo/trim", wrapAuth(r.handleLogoTrim, authMw))
 	mux.HandleFunc("POST "+bp+"/api/v1/artists/{id}/images/{type}/revert", wrapAuth(r.handleImageRevert, authMw))
+	// Artwork revision history (every recorded version of a slot, not just the
+	// one-deep .sw-backup original that revert restores).
+	mux.HandleFunc("GET "+bp+"/api/v1/artists/{id}/images/{type}/revisions", wrapAuth(r.handleListImageRevisions, authMw))
+	mux.HandleFunc("GET "+bp+"/api/v1/artists/{id}/images/{type}/revisions/{revisionId}/file", wrapAuth(r.handleServeImageRevision, authMw))
+	mux.HandleFunc("POST "+bp+"/api/v1/artists/{id}/images/{type}/revisions/{revisionId}/restore", wrapAuth(r.handleRestoreImageRevision, authMw))
 	// Multi-fanart routes
 	mux.HandleFunc("GET "+bp+"/api/v1/artists/{id}/images/fanart/list", wrapAuth(r.handleFanartList, authMw))
 	mux.HandleFunc("GET "+bp+"/api/v1/artists/{id}/images/fanart/{index}/file", wrapAuth(r.handleServeFanartByIndex, authMw))
@@ -1234,6 +1318,7 @@ func (r *Router) Handler(ctx context.Context) http.Handler {
 	// from loginRL on the route.
 	csrfExempt := []string{
 		bp + "/api/v1/auth/login",
+		bp + "/api/v1/auth/login/2fa",
 		bp + "/api/v1/auth/setup",
 		bp + "/api/v1/setup/restore",
 	}
@@ -21,7 +21,8 @@ type FieldName string
 // lockable-field vocabulary. The string values match the lowercase keys
 // historically stored in the database. When adding a field, keep this
 // constant set and any validation/canonicalization paths (notably the
-// platform-side canonicalizer maps in internal/connection/emby) in sync.
+// platform-side canonicalizer maps in internal/connection/emby and
+// internal/connection/plex) in sync.
 const (
 	FieldArtistName     FieldName = "name"
 	FieldSortName       FieldName = "sort_name"
@@ -66,6 +67,7 @@ const (
 	FieldWikidataID    FieldName = "wikidata_id"
 	FieldDeezerID      FieldName = "deezer_id"
 	FieldSpotifyID     FieldName = "spotify_id"
+	FieldAllMusicID    FieldName = "allmusic_id"
 )
 
 // AllLockableFields enumerates every field name that may legitimately appear
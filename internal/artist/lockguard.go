@@ -429,7 +429,7 @@ func restoreLockedField(stored, incoming *Artist, field string) (rejectedLen int
 //     query filters on -- so the artist surfaces to the operator as unverified
 //     work of their own.
 //
-// deezer_id, spotify_id and musicbrainz_id carry no fetched-at column, so their
+// deezer_id, spotify_id, allmusic_id and musicbrainz_id carry no fetched-at column, so their
 // timestamp arms are absent rather than forgotten.
 func restoreProviderIDCompanions(stored, incoming *Artist, field string) {
 	switch field {
@@ -516,7 +516,7 @@ func setFieldOnArtist(a *Artist, field, value string, slice []string) {
 		a.SortName = value
 	case "disambiguation":
 		a.Disambiguation = value
-	case "musicbrainz_id", "audiodb_id", "discogs_id", "wikidata_id", "deezer_id", "spotify_id":
+	case "musicbrainz_id", "audiodb_id", "discogs_id", "wikidata_id", "deezer_id", "spotify_id", "allmusic_id":
 		applyProviderFieldToArtist(a, providerFieldMap[field], value)
 	}
 }
@@ -1128,14 +1128,14 @@ func TestClonedTime_CopiesByValue(t *testing.T) {
 func TestProviderIDFieldNamesMatchProviderFieldMap(t *testing.T) {
 	for _, f := range []FieldName{
 		FieldMusicBrainzID, FieldAudioDBID, FieldDiscogsID,
-		FieldWikidataID, FieldDeezerID, FieldSpotifyID,
+		FieldWikidataID, FieldDeezerID, FieldSpotifyID, FieldAllMusicID,
 	} {
 		if _, ok := providerFieldMap[string(f)]; !ok {
 			t.Errorf("FieldName %q is not a providerFieldMap key; a lock check using it would never match", f)
 		}
 	}
-	if len(providerFieldMap) != 6 {
-		t.Errorf("providerFieldMap has %d entries, want 6; a new provider ID needs a FieldName constant and a lock check in the link handlers", len(providerFieldMap))
+	if len(providerFieldMap) != 7 {
+		t.Errorf("providerFieldMap has %d entries, want 7; a new provider ID needs a FieldName constant and a lock check in the link handlers", len(providerFieldMap))
 	}
 }
 
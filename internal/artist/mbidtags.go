@@ -0,0 +1,224 @@
+package artist
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/sydlexius/stillwater/internal/audiotag"
+	"github.com/sydlexius/stillwater/internal/provider"
+)
+
+// This file turns the MusicBrainz artist IDs a tagger (MusicBrainz Picard,
+// beets) embedded in an artist's audio files into identity evidence.
+//
+// An embedded ID is a different kind of evidence from a name-search hit. A
+// search hit is Stillwater guessing which artist a directory name refers to;
+// an embedded ID is a tagger's record of which MusicBrainz release the files
+// were matched against, usually with a human confirming the release. That is
+// why a tag consensus may be adopted without a provider call at all. It is NOT
+// why a single tag may: a compilation track filed in the wrong directory, or a
+// collaboration tagged with both artists, carries a perfectly valid ID for
+// someone else. The gates below exist for those cases.
+
+// TagMinAgreeingTracks is the number of sampled tracks that must carry the same
+// artist ID before the ID is adopted. One tagged track is one release's word
+// for it; two releases agreeing is a pattern. The single exception is a
+// directory whose ONLY audio is one tagged track -- there is no second release
+// to ask, and requiring one would make tags useless for single-release artists.
+const TagMinAgreeingTracks = 2
+
+// TagEvidence summarizes the identity claims embedded in a sample of an artist
+// directory's audio files. Build it with TagEvidenceFrom or ReadTagEvidence.
+type TagEvidence struct {
+	// Sampled is the number of audio files whose tags were read.
+	Sampled int
+	// Votes counts, per lowercased MBID, the sampled tracks that attribute
+	// themselves to this artist AND carry exactly that one artist ID.
+	Votes map[string]int
+	// Collaborations counts name-matched tracks carrying more than one
+	// artist ID. They are not votes: which of the IDs is this artist is
+	// exactly the question the tag cannot answer.
+	Collaborations int
+	// Foreign counts tracks tagged with a different artist's name. Their IDs
+	// are not this artist's and are ignored.
+	Foreign int
+	// SortNames counts the sort names carried by name-matched tracks.
+	SortNames map[string]int
+}
+
+// ReadTagEvidence samples the audio files under dir (see audiotag.SampleDir)
+// and summarizes their identity tags for the artist called artistName. An
+// unreadable directory returns the error; a directory without tagged audio
+// returns empty evidence, which Consensus rejects.
+func ReadTagEvidence(ctx context.Context, artistName, dir string) (TagEvidence, error) {
+	if dir == "" {
+		return TagEvidence{}, fmt.Errorf("no artist path recorded")
+	}
+	files, err := audiotag.SampleDir(ctx, dir, audiotag.DefaultSampleSize)
+	if err != nil {
+		return TagEvidence{}, fmt.Errorf("sampling audio tags in %s: %w", dir, err)
+	}
+	tags := make([]audiotag.Tags, len(files))
+	for i, f := range files {
+		tags[i] = f.Tags
+	}
+	return TagEvidenceFrom(artistName, tags), nil
+}
+
+// TagEvidenceFrom summarizes already-read tags for the artist called
+// artistName.
+//
+// Each track is judged on its album-artist identity when it has one (an album
+// artist ID, or an album artist name) and on its track-artist identity
+// otherwise: on an artist's own album the two agree, and on a guest
+// appearance the album artist is the one whose directory the file belongs in.
+// A track whose name (or sort name, for a directory named "Beatles, The")
+// does not clear MBIDMinNameSimilarity against artistName is Foreign. A track
+// with no name at all cannot be attributed and is ignored.
+func TagEvidenceFrom(artistName string, tags []audiotag.Tags) TagEvidence {
+	ev := TagEvidence{Sampled: len(tags), Votes: map[string]int{}, SortNames: map[string]int{}}
+	for _, t := range tags {
+		name, ids, sortName := t.Artist, t.ArtistMBIDs, t.ArtistSort
+		if t.AlbumArtist != "" || len(t.AlbumArtistMBIDs) > 0 {
+			name, ids, sortName = t.AlbumArtist, t.AlbumArtistMBIDs, t.AlbumArtistSort
+			if name == "" {
+				name = t.Artist
+			}
+			if len(ids) == 0 {
+				ids = t.ArtistMBIDs
+			}
+		}
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		if !tagNameMatches(artistName, name, sortName) {
+			ev.Foreign++
+			continue
+		}
+		if sortName != "" {
+			ev.SortNames[sortName]++
+		}
+
+		var valid []string
+		for _, id := range ids {
+			id = strings.ToLower(strings.TrimSpace(id))
+			if IsValidMBID(id) && !slices.Contains(valid, id) {
+				valid = append(valid, id)
+			}
+		}
+		switch len(valid) {
+		case 0:
+		case 1:
+			ev.Votes[valid[0]]++
+		default:
+			ev.Collaborations++
+		}
+	}
+	return ev
+}
+
+// tagNameMatches reports whether a track's tagged artist belongs to the
+// directory's artist, by name or by sort name.
+func tagNameMatches(artistName, name, sortName string) bool {
+	if provider.NameSimilarity(artistName, name) >= MBIDMinNameSimilarity {
+		return true
+	}
+	return sortName != "" && provider.NameSimilarity(artistName, sortName) >= MBIDMinNameSimilarity
+}
+
+// Consensus returns the MBID the sampled tracks agree on, or the reason there
+// is none. It applies three gates:
+//
+//   - at least one track must carry an ID attributed to this artist;
+//   - every such track must carry the SAME ID -- two IDs for one name is the
+//     same-name-different-artist case the search ambiguity margin exists for,
+//     and tags have no score to break the tie with;
+//   - at least TagMinAgreeingTracks tracks must agree, unless the directory's
+//     only sampled track carried the ID.
+func (e TagEvidence) Consensus() (string, *MBIDRejection) {
+	if len(e.Votes) == 0 {
+		return "", &MBIDRejection{Reason: fmt.Sprintf(
+			"none of %d sampled tracks carries a MusicBrainz artist ID for this artist", e.Sampled)}
+	}
+	if len(e.Votes) > 1 {
+		return "", &MBIDRejection{Reason: fmt.Sprintf(
+			"sampled tracks disagree on the MusicBrainz artist ID: %s", e.describeVotes())}
+	}
+	var mbid string
+	var votes int
+	for id, n := range e.Votes {
+		mbid, votes = id, n
+	}
+	if votes < TagMinAgreeingTracks && !(e.Sampled == 1 && votes == 1) {
+		return "", &MBIDRejection{Reason: fmt.Sprintf(
+			"only %d of %d sampled tracks carries MusicBrainz artist ID %s, below the %d-track floor",
+			votes, e.Sampled, mbid, TagMinAgreeingTracks)}
+	}
+	return mbid, nil
+}
+
+// Contradicts reports whether the tags argue against adopting mbid: at least
+// one sampled track attributes a DIFFERENT MusicBrainz ID to this artist and
+// none attributes mbid. Tracks carrying no ID never contradict anything.
+func (e TagEvidence) Contradicts(mbid string) bool {
+	if len(e.Votes) == 0 {
+		return false
+	}
+	return e.Votes[strings.ToLower(strings.TrimSpace(mbid))] == 0
+}
+
+// CheckCandidate applies the tag evidence to a search candidate the other gates
+// already passed. It returns nil when the tags are silent or agree, and a
+// rejection when they name a different artist.
+func (e TagEvidence) CheckCandidate(best *provider.ArtistSearchResult) *MBIDRejection {
+	if best == nil || !e.Contradicts(best.MusicBrainzID) {
+		return nil
+	}
+	return &MBIDRejection{Reason: fmt.Sprintf(
+		"top hit %q (%s) is contradicted by embedded tags naming %s",
+		best.Name, best.MusicBrainzID, e.describeVotes())}
+}
+
+// SortName returns the sort name every name-matched track agrees on, or "" when
+// they disagree or carry none.
+func (e TagEvidence) SortName() string {
+	if len(e.SortNames) != 1 {
+		return ""
+	}
+	for s := range e.SortNames {
+		return s
+	}
+	return ""
+}
+
+// Describe renders the evidence for a provenance message.
+func (e TagEvidence) Describe() string {
+	var agreeing int
+	for _, n := range e.Votes {
+		agreeing += n
+	}
+	return fmt.Sprintf("%d of %d sampled tracks agree", agreeing, e.Sampled)
+}
+
+// describeVotes lists the voted IDs with their counts, highest first, so log
+// and provenance messages are stable.
+func (e TagEvidence) describeVotes() string {
+	ids := make([]string, 0, len(e.Votes))
+	for id := range e.Votes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if e.Votes[ids[i]] != e.Votes[ids[j]] {
+			return e.Votes[ids[i]] > e.Votes[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = fmt.Sprintf("%s (%d)", id, e.Votes[id])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,114 @@
+package artist
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/audiotag"
+	"github.com/sydlexius/stillwater/internal/provider"
+)
+
+const (
+	tagMBIDRadiohead = "a74b1b7f-71a5-4011-9441-d0b5e4122711"
+	tagMBIDOther     = "8538e728-ca0b-4321-b7e5-cff6565dd4c0"
+)
+
+// ownTrack is a track from the artist's own album, tagged the way Picard tags
+// it: album artist and track artist agree, each carrying the same ID.
+func ownTrack(mbid string) audiotag.Tags {
+	return audiotag.Tags{
+		Artist: "Radiohead", AlbumArtist: "Radiohead", AlbumArtistSort: "Radiohead",
+		ArtistMBIDs: []string{mbid}, AlbumArtistMBIDs: []string{mbid},
+	}
+}
+
+func TestTagEvidenceConsensusAdoptsAgreement(t *testing.T) {
+	ev := TagEvidenceFrom("Radiohead", []audiotag.Tags{
+		ownTrack(tagMBIDRadiohead),
+		ownTrack(strings.ToUpper(tagMBIDRadiohead)), // IDs compare case-insensitively
+		{Artist: "Radiohead"},                       // untagged: absent evidence
+	})
+	mbid, rej := ev.Consensus()
+	if rej != nil {
+		t.Fatalf("Consensus rejected: %s", rej.Reason)
+	}
+	if mbid != tagMBIDRadiohead {
+		t.Errorf("Consensus = %q, want %q", mbid, tagMBIDRadiohead)
+	}
+	if got := ev.Describe(); got != "2 of 3 sampled tracks agree" {
+		t.Errorf("Describe = %q", got)
+	}
+}
+
+func TestTagEvidenceConsensusRejectsDisagreement(t *testing.T) {
+	ev := TagEvidenceFrom("Radiohead", []audiotag.Tags{
+		ownTrack(tagMBIDRadiohead), ownTrack(tagMBIDRadiohead), ownTrack(tagMBIDOther),
+	})
+	if _, rej := ev.Consensus(); rej == nil || !strings.Contains(rej.Reason, "disagree") {
+		t.Fatalf("Consensus = %v, want a disagreement rejection", rej)
+	}
+}
+
+// TestTagEvidenceConsensusFloor pins the two-track floor and its single
+// exception: one tagged track among several is not enough, but a directory
+// whose only audio is one tagged track is.
+func TestTagEvidenceConsensusFloor(t *testing.T) {
+	ev := TagEvidenceFrom("Radiohead", []audiotag.Tags{ownTrack(tagMBIDRadiohead), {Artist: "Radiohead"}})
+	if _, rej := ev.Consensus(); rej == nil {
+		t.Error("one tagged track of two was adopted, want the floor to reject it")
+	}
+	ev = TagEvidenceFrom("Radiohead", []audiotag.Tags{ownTrack(tagMBIDRadiohead)})
+	if mbid, rej := ev.Consensus(); rej != nil || mbid != tagMBIDRadiohead {
+		t.Errorf("single-track directory: Consensus = %q, %v; want %q", mbid, rej, tagMBIDRadiohead)
+	}
+}
+
+// TestTagEvidenceIgnoresForeignAndCollaborations covers the two ways a valid
+// ID can belong to someone else: a track by another artist filed in this
+// directory, and a collaboration carrying several artist IDs.
+func TestTagEvidenceIgnoresForeignAndCollaborations(t *testing.T) {
+	ev := TagEvidenceFrom("Radiohead", []audiotag.Tags{
+		{Artist: "Portishead", AlbumArtist: "Portishead", AlbumArtistMBIDs: []string{tagMBIDOther}},
+		{Artist: "Radiohead", ArtistMBIDs: []string{tagMBIDRadiohead, tagMBIDOther}},
+		ownTrack(tagMBIDRadiohead),
+		ownTrack(tagMBIDRadiohead),
+	})
+	if ev.Foreign != 1 || ev.Collaborations != 1 {
+		t.Errorf("Foreign=%d Collaborations=%d, want 1 and 1", ev.Foreign, ev.Collaborations)
+	}
+	if mbid, rej := ev.Consensus(); rej != nil || mbid != tagMBIDRadiohead {
+		t.Errorf("Consensus = %q, %v; want %q", mbid, rej, tagMBIDRadiohead)
+	}
+}
+
+// TestTagEvidenceMatchesSortName covers a directory named in sort order: the
+// tagged name does not match it, the tagged sort name does.
+func TestTagEvidenceMatchesSortName(t *testing.T) {
+	track := audiotag.Tags{
+		AlbumArtist: "The Beatles", AlbumArtistSort: "Beatles, The",
+		AlbumArtistMBIDs: []string{tagMBIDOther},
+	}
+	ev := TagEvidenceFrom("Beatles, The", []audiotag.Tags{track, track})
+	if ev.Foreign != 0 {
+		t.Fatalf("Foreign = %d, want 0", ev.Foreign)
+	}
+	if got := ev.SortName(); got != "Beatles, The" {
+		t.Errorf("SortName = %q", got)
+	}
+	if mbid, _ := ev.Consensus(); mbid != tagMBIDOther {
+		t.Errorf("Consensus = %q, want %q", mbid, tagMBIDOther)
+	}
+}
+
+func TestTagEvidenceCheckCandidate(t *testing.T) {
+	ev := TagEvidenceFrom("Radiohead", []audiotag.Tags{ownTrack(tagMBIDRadiohead)})
+	if rej := ev.CheckCandidate(&provider.ArtistSearchResult{Name: "Radiohead", MusicBrainzID: tagMBIDRadiohead}); rej != nil {
+		t.Errorf("agreeing candidate rejected: %s", rej.Reason)
+	}
+	if rej := ev.CheckCandidate(&provider.ArtistSearchResult{Name: "Radiohead", MusicBrainzID: tagMBIDOther}); rej == nil {
+		t.Error("contradicted candidate passed, want a rejection")
+	}
+	if rej := (TagEvidence{}).CheckCandidate(&provider.ArtistSearchResult{MusicBrainzID: tagMBIDOther}); rej != nil {
+		t.Errorf("silent tags rejected a candidate: %s", rej.Reason)
+	}
+}
@@ -66,6 +66,7 @@ type MetadataUpdate struct {
 	WikidataID     string
 	DeezerID       string
 	SpotifyID      string
+	AllMusicID     string
 	Biography      string
 	Genres         []string
 	Styles         []string
@@ -283,6 +284,12 @@ var mergeStrFields = []strField{
 		dst:   func(a *Artist) *string { return &a.SpotifyID },
 		modes: [4]fieldMode{modeFillEmpty, modeFillEmpty, modeNonEmpty, modeUnconditional},
 	},
+	{
+		name:  "allmusic_id",
+		get:   func(u *MetadataUpdate) string { return u.AllMusicID },
+		dst:   func(a *Artist) *string { return &a.AllMusicID },
+		modes: [4]fieldMode{modeFillEmpty, modeFillEmpty, modeNonEmpty, modeUnconditional},
+	},
 	// YearsActive: non-empty overwrite in OverwriteAttempted; fill-empty in
 	// FillEmpty; unconditional for NFOImport and SnapshotRestore.
 	{
@@ -811,6 +818,7 @@ func FetchResultToUpdate(result *provider.FetchResult) *MetadataUpdate {
 		WikidataID:     m.WikidataID,
 		DeezerID:       m.DeezerID,
 		SpotifyID:      m.SpotifyID,
+		AllMusicID:     m.AllMusicID,
 		Biography:      m.Biography,
 		Genres:         m.Genres,
 		Styles:         m.Styles,
@@ -26,6 +26,7 @@ type Artist struct {
 	WikidataID        string     `json:"wikidata_id"`
 	DeezerID          string     `json:"deezer_id"`
 	SpotifyID         string     `json:"spotify_id"`
+	AllMusicID        string     `json:"allmusic_id"`
 	Genres            []string   `json:"genres"`
 	Styles            []string   `json:"styles"`
 	Moods             []string   `json:"moods"`
@@ -115,6 +116,14 @@ const (
 	// operator-facing re-review query filters on.
 	SourceMachinePicked = "machine-picked"
 
+	// SourceEmbeddedTags marks an MBID adopted from the MusicBrainz artist IDs
+	// a tagger embedded in the artist's own audio files (see mbidtags.go). It
+	// is kept apart from SourceMachinePicked because the evidence is of a
+	// different kind -- a tagger's release match, not a name search -- and an
+	// operator re-reviewing machine guesses should not have to wade through
+	// the tag-derived IDs to find them.
+	SourceEmbeddedTags = "embedded-tags"
+
 	// SourceOperatorConfirmed marks an MBID a human set or approved. Nothing
 	// writes it yet -- the operator-facing field-edit path does not record
 	// identifier provenance today -- but the machine-picked marker is only
@@ -178,14 +187,15 @@ type DiscographyAlbum struct {
 // artist's ID for that provider is unknown. FetchMetadata falls back to the
 // MBID in that case. FetchImages falls back to the MBID for providers that can
 // accept one (AudioDB, see provider.ProviderAcceptsMBID) and skips the rest
-// (Discogs, Deezer and Spotify have no MusicBrainz lookup endpoint), reporting
-// each skip so the operator sees it (issue #2457).
+// (Discogs, Deezer, Spotify and AllMusic have no MusicBrainz lookup endpoint),
+// reporting each skip so the operator sees it (issue #2457).
 func (a *Artist) ProviderIDMap() map[provider.ProviderName]string {
 	return map[provider.ProviderName]string{
-		provider.NameAudioDB: a.AudioDBID,
-		provider.NameDiscogs: a.DiscogsID,
-		provider.NameDeezer:  a.DeezerID,
-		provider.NameSpotify: a.SpotifyID,
+		provider.NameAudioDB:  a.AudioDBID,
+		provider.NameDiscogs:  a.DiscogsID,
+		provider.NameDeezer:   a.DeezerID,
+		provider.NameSpotify:  a.SpotifyID,
+		provider.NameAllMusic: a.AllMusicID,
 	}
 }
 
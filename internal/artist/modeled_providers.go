@@ -8,7 +8,7 @@ import "github.com/sydlexius/stillwater/internal/provider"
 // artist_provider_ids rows the Artist struct can faithfully represent, so they
 // are the only rows UpsertAll is allowed to delete-and-replace.
 //
-// Every other provider (allmusic, duckduckgo, fanarttv, genius, wikipedia) has
+// Every other provider (duckduckgo, fanarttv, genius, wikipedia) has
 // no struct field: it can carry a fetched_at bookkeeping row written directly
 // via UpdateProviderFetchedAt, but a round-trip through the Artist struct would
 // silently drop it. Scoping UpsertAll's DELETE to this list (rather than an
@@ -27,5 +27,6 @@ var modeledProviders = []provider.ProviderName{
 	provider.NameWikidata,
 	provider.NameDeezer,
 	provider.NameSpotify,
+	provider.NameAllMusic,
 	provider.NameLastFM,
 }
@@ -34,7 +34,7 @@ func queryProviderRow(t *testing.T, db *sql.DB, artistID, prov string) (exists b
 
 // TestUpsertAll_OrphanProvidersSurviveUpdate is the #2725 repro inverted to
 // green. Providers with a writable fetched_at row but no Artist struct field
-// (allmusic, duckduckgo, fanarttv, genius, wikipedia) must NOT be destroyed by
+// (duckduckgo, fanarttv, genius, wikipedia) must NOT be destroyed by
 // an ordinary Update. Before the scoped-delete fix, UpsertAll opened with an
 // unconditional "DELETE ... WHERE artist_id = ?", so every Update wiped these
 // rows because extractProviderIDs never re-emits them.
@@ -50,7 +50,6 @@ func TestUpsertAll_OrphanProvidersSurviveUpdate(t *testing.T) {
 	ctx := context.Background()
 
 	orphans := []string{
-		string(provider.NameAllMusic),
 		string(provider.NameDuckDuckGo),
 		string(provider.NameFanartTV),
 		string(provider.NameGenius),
@@ -132,9 +131,9 @@ func TestUpsertAll_ModeledClearRemovesRow(t *testing.T) {
 		t.Fatalf("Update (populate discogs): %v", err)
 	}
 
-	// Stamp an orphan (allmusic) directly so it coexists with the modeled row.
-	if err := svc.UpdateProviderFetchedAt(ctx, a.ID, string(provider.NameAllMusic)); err != nil {
-		t.Fatalf("UpdateProviderFetchedAt(allmusic): %v", err)
+	// Stamp an orphan (genius) directly so it coexists with the modeled row.
+	if err := svc.UpdateProviderFetchedAt(ctx, a.ID, string(provider.NameGenius)); err != nil {
+		t.Fatalf("UpdateProviderFetchedAt(genius): %v", err)
 	}
 
 	// PRECONDITION: both rows present. Non-vacuous -- if discogs is missing the
@@ -142,8 +141,8 @@ func TestUpsertAll_ModeledClearRemovesRow(t *testing.T) {
 	if exists, pid, _ := queryProviderRow(t, db, a.ID, string(provider.NameDiscogs)); !exists || pid != "42" {
 		t.Fatalf("precondition: discogs row exists=%v provider_id=%q, want exists=true provider_id=%q", exists, pid, "42")
 	}
-	if exists, _, _ := queryProviderRow(t, db, a.ID, string(provider.NameAllMusic)); !exists {
-		t.Fatalf("precondition: allmusic orphan row missing before clear")
+	if exists, _, _ := queryProviderRow(t, db, a.ID, string(provider.NameGenius)); !exists {
+		t.Fatalf("precondition: genius orphan row missing before clear")
 	}
 
 	// Clear the modeled discogs fields exactly as handleReidentify's clear_ids
@@ -159,8 +158,8 @@ func TestUpsertAll_ModeledClearRemovesRow(t *testing.T) {
 		t.Errorf("discogs row still present after clear; scoped delete failed to remove a modeled provider")
 	}
 	// The orphan row must SURVIVE the clear (scope boundary).
-	if exists, _, _ := queryProviderRow(t, db, a.ID, string(provider.NameAllMusic)); !exists {
-		t.Errorf("allmusic orphan row destroyed by a modeled-provider clear; delete scope is too wide")
+	if exists, _, _ := queryProviderRow(t, db, a.ID, string(provider.NameGenius)); !exists {
+		t.Errorf("genius orphan row destroyed by a modeled-provider clear; delete scope is too wide")
 	}
 }
 
@@ -177,7 +176,7 @@ func TestModeledProvidersMatchExtractEmitSet(t *testing.T) {
 
 	// Populate every struct-modeled provider field so extractProviderIDs emits
 	// its full set. A field left empty here would silently shrink the emit set
-	// and mask a real divergence, so all seven are set to non-empty values.
+	// and mask a real divergence, so all eight are set to non-empty values.
 	fetched := time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC)
 	a := &Artist{
 		MusicBrainzID:       "mbid",
@@ -189,6 +188,7 @@ func TestModeledProvidersMatchExtractEmitSet(t *testing.T) {
 		WikidataIDFetchedAt: &fetched,
 		DeezerID:            "deezer",
 		SpotifyID:           "spotify",
+		AllMusicID:          "allmusic",
 		LastFMFetchedAt:     &fetched,
 	}
 
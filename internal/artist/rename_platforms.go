@@ -1,6 +1,10 @@
 package artist
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+)
 
 // PlatformRemapResult records the outcome of a single per-connection path
 // remap attempt after Service.RenameDirectory has moved the on-disk directory.
@@ -61,3 +65,45 @@ type PlatformRenameSyncer interface {
 func (s *Service) SetPlatformRenameSyncer(syncer PlatformRenameSyncer) {
 	s.platformSyncer = syncer
 }
+
+// DirectoryPlacer decides which directory an artist directory belongs in,
+// for libraries that sort artist directories into buckets such as A/ABBA.
+// Implemented by library.Service; the indirection keeps the artist package
+// free of library imports.
+//
+// ArtistDirParent returns the directory the artist directory at artistPath
+// belongs in once renamed to dirName. For a flat library, or a path no
+// library owns, that is the directory it is in now.
+type DirectoryPlacer interface {
+	ArtistDirParent(ctx context.Context, artistPath, dirName string) (string, error)
+}
+
+// SetDirectoryPlacer attaches a placer to the Service. With none, or nil,
+// RenameDirectory keeps every directory in its current parent.
+func (s *Service) SetDirectoryPlacer(p DirectoryPlacer) {
+	s.dirPlacer = p
+}
+
+// RenamedDirPath returns the path RenameDirectory moves the artist directory
+// at artistPath to for newDirName: beside it, or in the bucket directory the
+// library layout puts newDirName in. Callers probing the destination before
+// a rename use it so they probe the same path.
+func (s *Service) RenamedDirPath(ctx context.Context, artistPath, newDirName string) (string, error) {
+	parent, err := s.renameParent(ctx, artistPath, newDirName)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(parent, newDirName), nil
+}
+
+// renameParent returns the directory a renamed artist directory goes in.
+func (s *Service) renameParent(ctx context.Context, artistPath, newDirName string) (string, error) {
+	if s.dirPlacer == nil {
+		return filepath.Dir(artistPath), nil
+	}
+	parent, err := s.dirPlacer.ArtistDirParent(ctx, artistPath, newDirName)
+	if err != nil {
+		return "", fmt.Errorf("placing renamed directory: %w", err)
+	}
+	return filepath.Clean(parent), nil
+}
@@ -219,6 +219,11 @@ type Service struct {
 	// platform refresh (MergeAndReconcile then records a manual-refresh
 	// warning rather than calling out).
 	mergeRefresher PlatformMergeRefresher
+
+	// dirPlacer, when non-nil, picks the bucket directory a renamed artist
+	// directory moves to in a library with a nested layout (A/ABBA). Wired
+	// via SetDirectoryPlacer at startup; nil keeps renames in place.
+	dirPlacer DirectoryPlacer
 }
 
 // SetHistoryService attaches a HistoryService to the artist Service so that
@@ -970,7 +975,10 @@ var (
 //
 // newDirName must be a single path segment (no separators) and may not be "."
 // or "..". The new path is computed by replacing the leaf of the artist's
-// current Path with newDirName, preserving the parent directory.
+// current Path with newDirName, preserving the parent directory -- unless the
+// library sorts artists into letter buckets and newDirName starts with a
+// different letter, in which case the directory moves to that letter's bucket
+// (see SetDirectoryPlacer).
 //
 // On success, the artist row's path column is updated to the new path. The
 // in-memory Artist passed in to the caller is not mutated; callers should
@@ -1024,7 +1032,13 @@ func (s *Service) RenameDirectory(ctx context.Context, artistID, newDirName stri
 		return "", nil, ErrRenameNoPath
 	}
 
-	parent := filepath.Dir(a.Path)
+	// The parent is the current one, except in a library sorted into letter
+	// buckets, where a new first letter moves the directory to its bucket
+	// (B/Beatles -> T/The Beatles).
+	parent, err := s.renameParent(ctx, a.Path, newDirName)
+	if err != nil {
+		return "", nil, err
+	}
 	newPath = filepath.Clean(filepath.Join(parent, newDirName))
 
 	// Defense-in-depth: confirm the joined path is still a direct child of
@@ -1078,6 +1092,13 @@ func (s *Service) RenameDirectory(ctx context.Context, artistID, newDirName stri
 			return fmt.Errorf("checking destination %q: %w", newPath, statErr)
 		}
 
+		// A new letter bucket is created on first use. It is left behind,
+		// empty, if the rename fails, which the scanner ignores.
+		if parent != filepath.Dir(oldPath) {
+			if err := os.MkdirAll(parent, 0o755); err != nil { //nolint:gosec // G301: bucket directories are library directories, which other tools read
+				return fmt.Errorf("creating %q: %w", parent, err)
+			}
+		}
 		if err := filesystem.RenameDirAtomic(oldPath, newPath); err != nil {
 			return fmt.Errorf("renaming %q to %q: %w", oldPath, newPath, err)
 		}
@@ -1674,17 +1695,17 @@ func (s *Service) ClearField(ctx context.Context, id, field string) (bool, error
 }
 
 // UpdateProviderField sets a single provider ID field (musicbrainz_id,
-// audiodb_id, discogs_id, wikidata_id, deezer_id, or spotify_id) on the artist.
-// It re-fetches the artist, applies the field update, and calls Update so
+// audiodb_id, discogs_id, wikidata_id, deezer_id, spotify_id, or allmusic_id)
+// on the artist. It re-fetches the artist, applies the field update, and calls Update so
 // that all provider IDs in the normalized table are written consistently.
 //
 // The VALUE is validated here, not only at the API boundary (#3037). On this
 // write path the musicbrainz_id shape rule ran only in handleFieldUpdate, so
 // the method's guarantee depended on each caller checking first -- and the
 // other production caller, the provider_id_missing rule fixer in
-// internal/rule, does not (it writes discogs_id, deezer_id and spotify_id,
-// which carry no rule today). Validating here makes the rule a property of the
-// method rather than of its callers. A refusal comes back as a
+// internal/rule, does not (it writes discogs_id, deezer_id, spotify_id and
+// allmusic_id, which carry no rule today). Validating here makes the rule a
+// property of the method rather than of its callers. A refusal comes back as a
 // *FieldValidationError, matchable with errors.Is(err, ErrInvalidFieldValue).
 //
 // ClearProviderField is unaffected: it calls through with "", which
@@ -1793,6 +1814,8 @@ func applyProviderFieldToArtist(a *Artist, providerName, value string) {
 		a.DeezerID = value
 	case "spotify":
 		a.SpotifyID = value
+	case "allmusic":
+		a.AllMusicID = value
 	}
 }
 
@@ -1882,6 +1905,8 @@ func FieldValueFromArtist(a *Artist, field string) string {
 		return a.DeezerID
 	case "spotify_id":
 		return a.SpotifyID
+	case "allmusic_id":
+		return a.AllMusicID
 	default:
 		return ""
 	}
@@ -2439,6 +2464,8 @@ func applyProviderIDs(a *Artist, ids []ProviderID) {
 			a.DeezerID = p.ProviderID
 		case "spotify":
 			a.SpotifyID = p.ProviderID
+		case "allmusic":
+			a.AllMusicID = p.ProviderID
 		case "lastfm":
 			a.LastFMFetchedAt = p.FetchedAt
 		}
@@ -2468,6 +2495,9 @@ func extractProviderIDs(a *Artist) []ProviderID {
 	if a.SpotifyID != "" {
 		ids = append(ids, ProviderID{Provider: "spotify", ProviderID: a.SpotifyID})
 	}
+	if a.AllMusicID != "" {
+		ids = append(ids, ProviderID{Provider: "allmusic", ProviderID: a.AllMusicID})
+	}
 	if a.LastFMFetchedAt != nil {
 		ids = append(ids, ProviderID{Provider: "lastfm", ProviderID: "", FetchedAt: a.LastFMFetchedAt})
 	}
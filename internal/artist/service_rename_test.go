@@ -79,6 +79,43 @@ func TestRenameDirectory_Happy(t *testing.T) {
 	}
 }
 
+// bucketPlacer places every renamed directory in root/<first byte of name>,
+// like a library sorted into letter buckets.
+type bucketPlacer struct{ root string }
+
+func (p bucketPlacer) ArtistDirParent(_ context.Context, _, dirName string) (string, error) {
+	return filepath.Join(p.root, dirName[:1]), nil
+}
+
+func TestRenameDirectory_MovesToPlacedBucket(t *testing.T) {
+	t.Parallel()
+	svc, a, root := renameTestArtist(t, "lib-rename-bucket")
+	svc.SetDirectoryPlacer(bucketPlacer{root: root})
+	ctx := context.Background()
+
+	want := filepath.Join(root, "N", "New Name")
+	if probe, err := svc.RenamedDirPath(ctx, a.Path, "New Name"); err != nil || probe != want {
+		t.Errorf("RenamedDirPath = %q, %v; want %q", probe, err, want)
+	}
+	got, _, err := svc.RenameDirectory(ctx, a.ID, "New Name")
+	if err != nil {
+		t.Fatalf("RenameDirectory: %v", err)
+	}
+	if got != want {
+		t.Errorf("newPath = %q, want %q", got, want)
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Errorf("expected the directory in its new bucket: %v", err)
+	}
+	after, err := svc.GetByID(ctx, a.ID)
+	if err != nil {
+		t.Fatalf("post-rename GetByID: %v", err)
+	}
+	if after.Path != want {
+		t.Errorf("post-rename Path = %q, want %q", after.Path, want)
+	}
+}
+
 // TestRenameDirectory_PreservesProviderIDs is the regression test for the
 // CR finding on hydrated load: a non-hydrated GetByID flowed into s.update
 // would silently wipe artist_provider_ids AND artist_images via
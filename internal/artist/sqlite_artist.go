@@ -41,6 +41,7 @@ var providerFieldMap = map[string]string{
 	"wikidata_id":    "wikidata",
 	"deezer_id":      "deezer",
 	"spotify_id":     "spotify",
+	"allmusic_id":    "allmusic",
 }
 
 // sliceFields are fields that store JSON arrays in the database.
@@ -130,6 +130,9 @@ func (r *sqliteMembershipRepo) AddDerivingSource(ctx context.Context, artistID,
 				WHEN c.type = 'emby'     THEN 'emby'
 				WHEN c.type = 'jellyfin' THEN 'jellyfin'
 				WHEN c.type = 'lidarr'   THEN 'lidarr'
+				WHEN c.type = 'plex'     THEN 'plex'
+				WHEN c.type = 'subsonic' THEN 'subsonic'
+				WHEN c.type = 'kodi' THEN 'kodi'
 				ELSE 'filesystem'
 			END,
 			datetime('now')
@@ -114,7 +114,7 @@ func (r *sqliteProviderIDRepo) UpsertAll(ctx context.Context, artistID string, i
 	defer tx.Rollback() //nolint:errcheck // Rollback after commit success is a no-op; on error path the original error is what callers act on
 
 	// Scope the delete-and-replace to the struct-modeled providers only
-	// (modeledProviders). Rows for orphan providers such as allmusic or
+	// (modeledProviders). Rows for orphan providers such as genius or
 	// fanarttv carry only fetched_at bookkeeping, have no Artist struct field,
 	// and would never be re-inserted below -- an unconditional delete here
 	// destroyed them on every ordinary Update (#2725). The IN-list is built
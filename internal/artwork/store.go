@@ -0,0 +1,516 @@
+// Package artwork keeps the revision history of every artist image slot.
+//
+// image.BackupSingleSlot and BackupSlot keep ONE pre-edit original per slot,
+// which is enough to undo the last crop but not the one before it: every
+// further edit overwrites the backup. Store keeps the last N versions of each
+// slot instead, fanart slots included, each with the provenance it carried
+// (image.ExifMeta), when it was recorded and the action that produced it, so an
+// operator who notices weeks later that a fixer swapped a good logo for a worse
+// one can still put the good one back.
+//
+// Storage is split in two:
+//
+//	artwork_revisions   one row per version (migration 034): who, what, when.
+//	<dir>/objects/      the bytes, content-addressed by SHA-256 as
+//	                    objects/<h[:2]>/<h>. A version identical to one already
+//	                    held -- the same image restored, or the same fanart on
+//	                    two artists -- costs a row and no extra disk.
+//
+// Store implements image.RevisionRecorder; the image write primitives notify
+// it, so callers only tag their context (image.WithRevisionContext).
+package artwork
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sydlexius/stillwater/internal/dbutil"
+	"github.com/sydlexius/stillwater/internal/filesystem"
+	img "github.com/sydlexius/stillwater/internal/image"
+)
+
+// DefaultKeep is how many revisions are kept per slot unless the
+// artwork.revisions.keep setting says otherwise.
+const DefaultKeep = 10
+
+// MaxKeep bounds the artwork.revisions.keep setting. Every revision of a
+// 4K backdrop is several megabytes on disk.
+const MaxKeep = 100
+
+// ErrNotFound is returned for a revision ID that was never recorded or has
+// been pruned.
+var ErrNotFound = errors.New("artwork revision not found")
+
+// ErrContentMissing is returned when a revision's row exists but its bytes
+// are gone from the object store (removed by hand, or a restore from a
+// database backup older than the objects directory).
+var ErrContentMissing = errors.New("artwork revision content missing")
+
+// hashPattern matches an object name: a lowercase hex SHA-256.
+var hashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// Revision is one recorded version of an artist image slot.
+type Revision struct {
+	ID          string     `json:"id"`
+	ArtistID    string     `json:"artist_id"`
+	ImageType   string     `json:"image_type"`
+	Slot        string     `json:"slot"`
+	ContentHash string     `json:"content_hash"`
+	SizeBytes   int64      `json:"size_bytes"`
+	Format      string     `json:"format"`
+	Source      string     `json:"source,omitempty"`
+	URL         string     `json:"url,omitempty"`
+	DHash       string     `json:"dhash,omitempty"`
+	Rule        string     `json:"rule,omitempty"`
+	Mode        string     `json:"mode,omitempty"`
+	FetchedAt   *time.Time `json:"fetched_at,omitempty"`
+	Action      string     `json:"action"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// Store records and serves artwork revisions.
+type Store struct {
+	db         *sql.DB
+	objectsDir string
+	logger     *slog.Logger
+
+	mu         sync.RWMutex
+	keep       int
+	maxAgeDays int
+
+	// writeMu serializes Record against itself and against the object sweep.
+	// Record writes the blob before the row that references it; a sweep
+	// running between the two would see an unreferenced blob and delete it.
+	writeMu sync.Mutex
+}
+
+// NewStore creates a Store whose objects live under dir (created on first
+// write), keeping DefaultKeep revisions per slot and pruning none by age.
+func NewStore(db *sql.DB, dir string, logger *slog.Logger) *Store {
+	return &Store{
+		db:         db,
+		objectsDir: filepath.Join(dir, "objects"),
+		logger:     logger.With(slog.String("component", "artwork-revisions")),
+		keep:       DefaultKeep,
+	}
+}
+
+// SetKeep updates how many revisions are kept per slot. Values below 1 are
+// raised to 1, so a slot always keeps the revision matching its current
+// image. The new limit applies from the next write to each slot, and to every
+// slot at the next cleanup pass.
+func (s *Store) SetKeep(n int) {
+	n = max(n, 1)
+	s.mu.Lock()
+	s.keep = n
+	s.mu.Unlock()
+	s.logger.Info("artwork revision retention updated", slog.Int("keep", n))
+}
+
+// SetMaxAgeDays updates the age after which revisions are pruned; 0 disables
+// age-based pruning. A slot's newest revision is never pruned by age.
+func (s *Store) SetMaxAgeDays(days int) {
+	days = max(days, 0)
+	s.mu.Lock()
+	s.maxAgeDays = days
+	s.mu.Unlock()
+	s.logger.Info("artwork revision max age updated", slog.Int("days", days))
+}
+
+// Keep returns the current per-slot revision limit.
+func (s *Store) Keep() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.keep
+}
+
+// MaxAgeDays returns the current max age in days.
+func (s *Store) MaxAgeDays() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.maxAgeDays
+}
+
+// RecordRevision implements image.RevisionRecorder. A failure is logged and
+// swallowed: the write that produced the revision has already succeeded, and
+// losing one history entry must not turn it into an error. The record
+// outlives a canceled request for the same reason.
+func (s *Store) RecordRevision(ctx context.Context, in img.RevisionInput) {
+	if _, _, err := s.Record(context.WithoutCancel(ctx), in); err != nil {
+		s.logger.Warn("recording artwork revision",
+			slog.String("artist_id", in.ArtistID),
+			slog.String("type", in.ImageType),
+			slog.String("slot", in.Slot),
+			slog.String("action", in.Action),
+			slog.Any("error", err))
+	}
+}
+
+// Record stores in as the newest revision of its slot and prunes the slot to
+// the retention limit. When the slot's newest revision already holds the same
+// bytes nothing is stored and Record returns that revision with created false:
+// the pre-edit image a backup observes is usually the one the previous edit
+// recorded as its result.
+func (s *Store) Record(ctx context.Context, in img.RevisionInput) (rev *Revision, created bool, err error) {
+	if in.ArtistID == "" || in.ImageType == "" || in.Slot == "" || len(in.Data) == 0 {
+		return nil, false, fmt.Errorf("incomplete artwork revision (artist %q, type %q, slot %q, %d bytes)",
+			in.ArtistID, in.ImageType, in.Slot, len(in.Data))
+	}
+	hash := img.ContentHash(in.Data)
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	latest, err := s.latest(ctx, in.ArtistID, in.ImageType, in.Slot)
+	if err != nil {
+		return nil, false, err
+	}
+	if latest != nil && latest.ContentHash == hash {
+		return latest, false, nil
+	}
+
+	if err := s.writeObject(hash, in.Data); err != nil {
+		return nil, false, err
+	}
+
+	rev = &Revision{
+		ID:          uuid.New().String(),
+		ArtistID:    in.ArtistID,
+		ImageType:   in.ImageType,
+		Slot:        in.Slot,
+		ContentHash: hash,
+		SizeBytes:   int64(len(in.Data)),
+		Format:      in.Format,
+		Action:      in.Action,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if m := in.Meta; m != nil {
+		rev.Source, rev.URL, rev.DHash, rev.Rule, rev.Mode = m.Source, m.URL, m.DHash, m.Rule, m.Mode
+		if !m.Fetched.IsZero() {
+			fetched := m.Fetched.UTC()
+			rev.FetchedAt = &fetched
+		}
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO artwork_revisions (id, artist_id, image_type, slot, content_hash, size_bytes, format,
+			source, url, dhash, rule, mode, fetched_at, action, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, rev.ID, rev.ArtistID, rev.ImageType, rev.Slot, rev.ContentHash, rev.SizeBytes, rev.Format,
+		rev.Source, rev.URL, rev.DHash, rev.Rule, rev.Mode, dbutil.FormatNullableTime(rev.FetchedAt),
+		rev.Action, rev.CreatedAt.Format(revisionTimeLayout)); err != nil {
+		return nil, false, fmt.Errorf("inserting artwork revision: %w", err)
+	}
+
+	if err := s.pruneSlot(ctx, in.ArtistID, in.ImageType, in.Slot); err != nil {
+		// The revision is stored; an over-long history is tidied by the next
+		// cleanup pass.
+		s.logger.Warn("pruning artwork revisions", slog.String("artist_id", in.ArtistID),
+			slog.String("type", in.ImageType), slog.String("slot", in.Slot), slog.Any("error", err))
+	}
+	return rev, true, nil
+}
+
+// List returns an artist's revisions of imageType, newest first. slot narrows
+// the listing to one fanart slot; "" lists every slot of the type.
+func (s *Store) List(ctx context.Context, artistID, imageType, slot string) ([]Revision, error) {
+	query := `SELECT ` + revisionColumns + ` FROM artwork_revisions WHERE artist_id = ? AND image_type = ?`
+	args := []any{artistID, imageType}
+	if slot != "" {
+		query += ` AND slot = ?`
+		args = append(args, slot)
+	}
+	query += ` ORDER BY created_at DESC, id`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("listing artwork revisions: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var revs []Revision
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revs = append(revs, *rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating artwork revisions: %w", err)
+	}
+	return revs, nil
+}
+
+// Get returns one revision.
+func (s *Store) Get(ctx context.Context, id string) (*Revision, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+revisionColumns+` FROM artwork_revisions WHERE id = ?`, id)
+	rev, err := scanRevision(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	return rev, err
+}
+
+// Content returns the bytes of rev.
+func (s *Store) Content(rev *Revision) ([]byte, error) {
+	path, err := s.objectPath(rev.ContentHash)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path) //nolint:gosec // path is built from a validated hex digest under objectsDir
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrContentMissing, rev.ID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading artwork revision %s: %w", rev.ID, err)
+	}
+	return data, nil
+}
+
+// Prune applies the retention policy to every slot -- the per-slot limit
+// (which Record already enforces for the slot it writes, but a lowered
+// setting only reaches idle slots here) and the max age -- then deletes the
+// objects no remaining revision references, including those orphaned by
+// artist deletion. It returns how many revisions were removed.
+func (s *Store) Prune(ctx context.Context) (int64, error) {
+	keep, maxAge := s.Keep(), s.MaxAgeDays()
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM artwork_revisions WHERE id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY artist_id, image_type, slot ORDER BY created_at DESC, id) AS rn
+				FROM artwork_revisions
+			) WHERE rn > ?
+		)`, keep)
+	if err != nil {
+		return 0, fmt.Errorf("pruning artwork revisions by count: %w", err)
+	}
+	removed, _ := res.RowsAffected()
+
+	if maxAge > 0 {
+		cutoff := time.Now().UTC().AddDate(0, 0, -maxAge)
+		res, err := s.db.ExecContext(ctx, `
+			DELETE FROM artwork_revisions WHERE created_at < ? AND id IN (
+				SELECT id FROM (
+					SELECT id, ROW_NUMBER() OVER (PARTITION BY artist_id, image_type, slot ORDER BY created_at DESC, id) AS rn
+					FROM artwork_revisions
+				) WHERE rn > 1
+			)`, cutoff.Format(revisionTimeLayout))
+		if err != nil {
+			return removed, fmt.Errorf("pruning artwork revisions by age: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		removed += n
+	}
+
+	if err := s.sweepObjects(ctx); err != nil {
+		return removed, err
+	}
+	return removed, nil
+}
+
+// StartCleanup launches a background goroutine that runs Prune once an hour.
+// It returns immediately; the goroutine stops when ctx is canceled.
+func (s *Store) StartCleanup(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := s.Prune(ctx)
+				if err != nil {
+					s.logger.Warn("artwork revision cleanup failed", slog.Any("error", err))
+					continue
+				}
+				if n > 0 {
+					s.logger.Info("pruned artwork revisions", slog.Int64("count", n))
+				}
+			}
+		}
+	}()
+}
+
+// latest returns the newest revision of a slot, nil when it has none.
+func (s *Store) latest(ctx context.Context, artistID, imageType, slot string) (*Revision, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+revisionColumns+` FROM artwork_revisions
+		WHERE artist_id = ? AND image_type = ? AND slot = ?
+		ORDER BY created_at DESC, id LIMIT 1
+	`, artistID, imageType, slot)
+	rev, err := scanRevision(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return rev, err
+}
+
+// pruneSlot trims one slot to the retention limit and removes the objects
+// that leaves unreferenced. Callers hold writeMu.
+func (s *Store) pruneSlot(ctx context.Context, artistID, imageType, slot string) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, content_hash FROM artwork_revisions
+		WHERE artist_id = ? AND image_type = ? AND slot = ?
+		ORDER BY created_at DESC, id LIMIT -1 OFFSET ?
+	`, artistID, imageType, slot, s.Keep())
+	if err != nil {
+		return fmt.Errorf("selecting revisions beyond retention: %w", err)
+	}
+	var ids, hashes []string
+	for rows.Next() {
+		var id, hash string
+		if err := rows.Scan(&id, &hash); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("scanning revision beyond retention: %w", err)
+		}
+		ids = append(ids, id)
+		hashes = append(hashes, hash)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating revisions beyond retention: %w", err)
+	}
+
+	for _, id := range ids {
+		if _, err := s.db.ExecContext(ctx, `DELETE FROM artwork_revisions WHERE id = ?`, id); err != nil {
+			return fmt.Errorf("deleting revision %s: %w", id, err)
+		}
+	}
+	for _, hash := range hashes {
+		var refs int
+		if err := s.db.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM artwork_revisions WHERE content_hash = ?`, hash).Scan(&refs); err != nil {
+			return fmt.Errorf("counting references to %s: %w", hash, err)
+		}
+		if refs == 0 {
+			s.removeObject(hash)
+		}
+	}
+	return nil
+}
+
+// sweepObjects deletes every object no revision references. Callers hold
+// writeMu.
+func (s *Store) sweepObjects(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT DISTINCT content_hash FROM artwork_revisions`)
+	if err != nil {
+		return fmt.Errorf("listing referenced artwork objects: %w", err)
+	}
+	referenced := make(map[string]bool)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("scanning referenced artwork object: %w", err)
+		}
+		referenced[hash] = true
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating referenced artwork objects: %w", err)
+	}
+
+	err = filepath.WalkDir(s.objectsDir, func(path string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if errors.Is(walkErr, os.ErrNotExist) {
+				return nil
+			}
+			return walkErr
+		}
+		if d.IsDir() {
+			return nil
+		}
+		// Only content objects are swept. WriteFileAtomic's in-flight temp
+		// files and anything an operator dropped here are left alone.
+		if name := d.Name(); hashPattern.MatchString(name) && !referenced[name] {
+			s.removeObject(name)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("sweeping artwork objects: %w", err)
+	}
+	return nil
+}
+
+// objectPath returns where the object for hash lives.
+func (s *Store) objectPath(hash string) (string, error) {
+	if !hashPattern.MatchString(hash) {
+		return "", fmt.Errorf("invalid artwork object hash %q", hash)
+	}
+	return filepath.Join(s.objectsDir, hash[:2], hash), nil
+}
+
+// writeObject stores data under hash unless an object by that name already
+// exists; content addressing makes an existing object the same bytes.
+func (s *Store) writeObject(hash string, data []byte) error {
+	path, err := s.objectPath(hash)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("creating artwork object directory: %w", err)
+	}
+	if err := filesystem.WriteFileAtomic(path, data, 0o640); err != nil {
+		return fmt.Errorf("writing artwork object: %w", err)
+	}
+	return nil
+}
+
+// removeObject deletes the object for hash, logging rather than failing: a
+// leftover object only costs disk until the next sweep.
+func (s *Store) removeObject(hash string) {
+	path, err := s.objectPath(hash)
+	if err != nil {
+		return
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		s.logger.Warn("removing artwork object", slog.String("hash", hash), slog.Any("error", err))
+	}
+}
+
+// revisionTimeLayout is RFC 3339 with fixed-width nanoseconds, so created_at
+// orders lexically: RFC3339Nano trims trailing zeros, and a bulk fix can
+// record several revisions within one second.
+const revisionTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+const revisionColumns = `id, artist_id, image_type, slot, content_hash, size_bytes, format,
+	source, url, dhash, rule, mode, fetched_at, action, created_at`
+
+func scanRevision(row interface{ Scan(...any) error }) (*Revision, error) {
+	var rev Revision
+	var fetchedAt sql.NullString
+	var createdAt string
+	if err := row.Scan(&rev.ID, &rev.ArtistID, &rev.ImageType, &rev.Slot, &rev.ContentHash, &rev.SizeBytes,
+		&rev.Format, &rev.Source, &rev.URL, &rev.DHash, &rev.Rule, &rev.Mode, &fetchedAt,
+		&rev.Action, &createdAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("scanning artwork revision: %w", err)
+	}
+	rev.CreatedAt = dbutil.ParseTime(createdAt)
+	if fetchedAt.Valid && strings.TrimSpace(fetchedAt.String) != "" {
+		t := dbutil.ParseTime(fetchedAt.String)
+		rev.FetchedAt = &t
+	}
+	return &rev, nil
+}
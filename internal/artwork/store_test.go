@@ -0,0 +1,297 @@
+package artwork
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/database"
+	img "github.com/sydlexius/stillwater/internal/image"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("opening test db: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrating test db: %v", err)
+	}
+	if err := database.EnableForeignKeys(db); err != nil {
+		t.Fatalf("enabling foreign keys: %v", err)
+	}
+	if _, err := db.ExecContext(context.Background(),
+		`INSERT INTO artists (id, name, sort_name, path) VALUES ('a1', 'A', 'A', '/music/A')`); err != nil {
+		t.Fatalf("inserting artist: %v", err)
+	}
+	return db
+}
+
+func newTestStore(t *testing.T) (*Store, *sql.DB) {
+	t.Helper()
+	db := setupTestDB(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewStore(db, t.TempDir(), logger), db
+}
+
+func input(slot, action string, data []byte) img.RevisionInput {
+	return img.RevisionInput{ArtistID: "a1", ImageType: "fanart", Slot: slot, Action: action, Data: data, Format: "jpeg"}
+}
+
+func objectCount(t *testing.T, s *Store) int {
+	t.Helper()
+	n := 0
+	err := filepath.WalkDir(s.objectsDir, func(_ string, d os.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walking objects: %v", err)
+	}
+	return n
+}
+
+func TestRecordAndContent(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+	fetched := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	in := input("fanart", "crop", []byte("first image"))
+	in.Meta = &img.ExifMeta{Source: "fanarttv", URL: "https://example.com/f.jpg", Rule: "fanart_min_res", Mode: "auto", Fetched: fetched}
+	rev, created, err := s.Record(ctx, in)
+	if err != nil || !created {
+		t.Fatalf("Record: rev=%v created=%v err=%v", rev, created, err)
+	}
+
+	got, err := s.Get(ctx, rev.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Action != "crop" || got.Source != "fanarttv" || got.Rule != "fanart_min_res" || got.SizeBytes != int64(len("first image")) {
+		t.Errorf("Get = %+v, want the recorded action and provenance", got)
+	}
+	if got.FetchedAt == nil || !got.FetchedAt.Equal(fetched) {
+		t.Errorf("FetchedAt = %v, want %v", got.FetchedAt, fetched)
+	}
+	if got.URL != in.Meta.URL || got.Mode != "auto" {
+		t.Errorf("URL, Mode = %q, %q; want the recorded provenance", got.URL, got.Mode)
+	}
+
+	data, err := s.Content(got)
+	if err != nil {
+		t.Fatalf("Content: %v", err)
+	}
+	if string(data) != "first image" {
+		t.Errorf("Content = %q, want %q", data, "first image")
+	}
+}
+
+// TestRecordSkipsUnchangedSlot checks that recording the bytes a slot already
+// holds as its newest revision adds nothing, while the same bytes coming back
+// after a different version do.
+func TestRecordSkipsUnchangedSlot(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	a, _, _ := s.Record(ctx, input("fanart", "upload", []byte("A")))
+	same, created, err := s.Record(ctx, input("fanart", img.RevisionActionObserved, []byte("A")))
+	if err != nil || created || same.ID != a.ID {
+		t.Fatalf("re-recording newest bytes: created=%v id=%v err=%v, want the existing revision", created, same.ID, err)
+	}
+	if _, created, _ := s.Record(ctx, input("fanart", "crop", []byte("B"))); !created {
+		t.Fatal("new bytes not recorded")
+	}
+	if _, created, _ := s.Record(ctx, input("fanart", "restore", []byte("A"))); !created {
+		t.Fatal("restored bytes not recorded")
+	}
+
+	revs, err := s.List(ctx, "a1", "fanart", "fanart")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(revs) != 3 || revs[0].Action != "restore" || revs[2].Action != "upload" {
+		t.Fatalf("List = %+v, want restore, crop, upload", revs)
+	}
+	// A and its restore share one object.
+	if n := objectCount(t, s); n != 2 {
+		t.Errorf("objects = %d, want 2", n)
+	}
+}
+
+func TestListSlots(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	_, _, _ = s.Record(ctx, input("fanart", "upload", []byte("primary")))
+	_, _, _ = s.Record(ctx, input("fanart1", "upload", []byte("second")))
+
+	all, err := s.List(ctx, "a1", "fanart", "")
+	if err != nil || len(all) != 2 {
+		t.Fatalf("List(all slots) = %d revisions, err %v; want 2", len(all), err)
+	}
+	one, err := s.List(ctx, "a1", "fanart", "fanart1")
+	if err != nil || len(one) != 1 || one[0].Slot != "fanart1" {
+		t.Fatalf("List(fanart1) = %+v, err %v; want the one fanart1 revision", one, err)
+	}
+}
+
+func TestRecordEnforcesKeep(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+	s.SetKeep(3)
+
+	for _, b := range []string{"1", "2", "3", "4", "5"} {
+		if _, _, err := s.Record(ctx, input("fanart", "crop", []byte(b))); err != nil {
+			t.Fatalf("Record %s: %v", b, err)
+		}
+	}
+	// Another slot is not affected by this one's history.
+	_, _, _ = s.Record(ctx, input("fanart1", "crop", []byte("other")))
+
+	revs, err := s.List(ctx, "a1", "fanart", "fanart")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(revs) != 3 {
+		t.Fatalf("revisions = %d, want 3", len(revs))
+	}
+	if data, _ := s.Content(&revs[2]); string(data) != "3" {
+		t.Errorf("oldest kept = %q, want %q", data, "3")
+	}
+	if n := objectCount(t, s); n != 4 {
+		t.Errorf("objects = %d, want 4 (pruned revisions' objects removed)", n)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	s, db := newTestStore(t)
+	ctx := context.Background()
+
+	old, _, _ := s.Record(ctx, input("fanart", "upload", []byte("old")))
+	newest, _, _ := s.Record(ctx, input("fanart", "crop", []byte("new")))
+	lone, _, _ := s.Record(ctx, input("fanart1", "upload", []byte("lone")))
+	longAgo := time.Now().UTC().AddDate(0, 0, -90).Format(revisionTimeLayout)
+	for _, id := range []string{old.ID, lone.ID} {
+		if _, err := db.ExecContext(ctx, `UPDATE artwork_revisions SET created_at = ? WHERE id = ?`, longAgo, id); err != nil {
+			t.Fatalf("backdating: %v", err)
+		}
+	}
+
+	// An artist deleted since leaves its objects behind until a sweep.
+	if _, err := db.ExecContext(ctx,
+		`INSERT INTO artists (id, name, sort_name, path) VALUES ('a2', 'B', 'B', '/music/B')`); err != nil {
+		t.Fatalf("inserting artist: %v", err)
+	}
+	gone := input("fanart", "upload", []byte("deleted artist"))
+	gone.ArtistID = "a2"
+	_, _, _ = s.Record(ctx, gone)
+	if _, err := db.ExecContext(ctx, `DELETE FROM artists WHERE id = 'a2'`); err != nil {
+		t.Fatalf("deleting artist: %v", err)
+	}
+
+	s.SetMaxAgeDays(30)
+	n, err := s.Prune(ctx)
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("pruned = %d, want 1", n)
+	}
+	if _, err := s.Get(ctx, old.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("old revision: err = %v, want ErrNotFound", err)
+	}
+	// The newest revision of a slot survives any age.
+	for _, id := range []string{newest.ID, lone.ID} {
+		if _, err := s.Get(ctx, id); err != nil {
+			t.Errorf("Get(%s) after prune: %v", id, err)
+		}
+	}
+	if c := objectCount(t, s); c != 2 {
+		t.Errorf("objects = %d, want 2 (aged and orphaned objects swept)", c)
+	}
+}
+
+func TestContentMissing(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	rev, _, _ := s.Record(ctx, input("fanart", "upload", []byte("vanishing")))
+	path, _ := s.objectPath(rev.ContentHash)
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("removing object: %v", err)
+	}
+	if _, err := s.Content(rev); !errors.Is(err, ErrContentMissing) {
+		t.Errorf("Content: err = %v, want ErrContentMissing", err)
+	}
+}
+
+// TestImageWritesFeedStore checks the wiring end to end: installed as the
+// image package's recorder, the store receives both the image a protected
+// fanart save replaces and the one it writes.
+func TestImageWritesFeedStore(t *testing.T) {
+	s, _ := newTestStore(t)
+	img.SetRevisionRecorder(s)
+	t.Cleanup(func() { img.SetRevisionRecorder(nil) })
+
+	dir := t.TempDir()
+	original := []byte("\xff\xd8\xff\xe0original fanart")
+	if err := os.WriteFile(filepath.Join(dir, "fanart.jpg"), original, 0o600); err != nil {
+		t.Fatalf("writing original: %v", err)
+	}
+	replacement := makeJPEG(t)
+
+	ctx := img.WithRevisionContext(context.Background(), "a1", "crop")
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if _, err := img.SaveSlotProtected(ctx, dir, "fanart", []string{"fanart.jpg"}, replacement, false, nil, logger); err != nil {
+		t.Fatalf("SaveSlotProtected: %v", err)
+	}
+
+	revs, err := s.List(context.Background(), "a1", "fanart", "fanart")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(revs) != 2 {
+		t.Fatalf("revisions = %+v, want the observed original and the crop", revs)
+	}
+	if revs[0].Action != "crop" || revs[1].Action != img.RevisionActionObserved {
+		t.Errorf("actions = %q, %q; want crop, observed", revs[0].Action, revs[1].Action)
+	}
+	if data, _ := s.Content(&revs[1]); string(data) != string(original) {
+		t.Error("observed revision does not hold the original bytes")
+	}
+}
+
+func makeJPEG(t *testing.T) []byte {
+	t.Helper()
+	m := image.NewRGBA(image.Rect(0, 0, 64, 36))
+	for x := range 64 {
+		for y := range 36 {
+			m.Set(x, y, color.RGBA{R: uint8(x * 4), G: uint8(y * 7), B: 90, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, m, nil); err != nil {
+		t.Fatalf("encoding jpeg: %v", err)
+	}
+	return buf.Bytes()
+}
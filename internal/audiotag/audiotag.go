@@ -0,0 +1,195 @@
+// Package audiotag reads the handful of embedded audio tags Stillwater uses as
+// identity evidence: the MusicBrainz artist IDs, artist and album-artist names,
+// and their sort names that taggers such as MusicBrainz Picard write into
+// ID3v2 (MP3), Vorbis comments (FLAC, Ogg Vorbis, Opus) and MP4 ilst atoms
+// (M4A/ALAC/AAC).
+//
+// It is deliberately not a general tag library. Only the fields in Tags are
+// decoded; everything else -- cover art in particular, which is most of a tag's
+// bytes -- is skipped by seeking past it rather than read. Every length field
+// read from a file is bounds-checked against a hard cap before anything is
+// allocated, because the files come from a library share Stillwater does not
+// control and a corrupt or hostile header must cost a skipped file, not the
+// process.
+package audiotag
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsupported is returned by ReadFile for a file whose container is not one
+// of the formats this package decodes (or that is not audio at all).
+var ErrUnsupported = errors.New("unsupported audio container")
+
+// maxTagBytes caps how much of any single tag block (an ID3v2 tag, a Vorbis
+// comment packet, an MP4 ilst item) is read into memory. Identity fields are a
+// few hundred bytes; anything this large is embedded artwork or corruption, and
+// in both cases skipping it loses nothing this package reads.
+const maxTagBytes = 4 << 20
+
+// Tags holds the identity-relevant tags of one audio file. Multi-valued fields
+// keep every value the file carries, in file order; a collaboration track
+// tagged with two artist IDs reports both, and deciding what that means is the
+// caller's business.
+type Tags struct {
+	Artist           string   `json:"artist,omitempty"`
+	AlbumArtist      string   `json:"album_artist,omitempty"`
+	ArtistSort       string   `json:"artist_sort,omitempty"`
+	AlbumArtistSort  string   `json:"album_artist_sort,omitempty"`
+	ArtistMBIDs      []string `json:"artist_mbids,omitempty"`
+	AlbumArtistMBIDs []string `json:"album_artist_mbids,omitempty"`
+}
+
+// IsZero reports whether no identity tag was found.
+func (t Tags) IsZero() bool {
+	return t.Artist == "" && t.AlbumArtist == "" && t.ArtistSort == "" &&
+		t.AlbumArtistSort == "" && len(t.ArtistMBIDs) == 0 && len(t.AlbumArtistMBIDs) == 0
+}
+
+// audioExtensions lists the file extensions IsAudioFile accepts. The container
+// is still sniffed from the file's magic bytes; the extension only decides
+// which files are worth opening.
+var audioExtensions = map[string]bool{
+	".mp3":  true,
+	".flac": true,
+	".ogg":  true,
+	".oga":  true,
+	".opus": true,
+	".m4a":  true,
+	".m4b":  true,
+	".mp4":  true,
+}
+
+// IsAudioFile reports whether name has an extension ReadFile can decode.
+func IsAudioFile(name string) bool {
+	return audioExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// ReadFile reads the identity tags from the audio file at path. A file in a
+// supported container that simply carries no tags returns zero Tags and a nil
+// error; ErrUnsupported means the container was not recognized.
+func ReadFile(path string) (Tags, error) {
+	f, err := os.Open(path) //nolint:gosec // G304: path is an audio file under a configured library root
+	if err != nil {
+		return Tags{}, err
+	}
+	defer f.Close() //nolint:errcheck // read-only handle
+	return Read(f)
+}
+
+// Read sniffs the container from the first bytes of r and decodes its tags.
+func Read(r io.ReadSeeker) (Tags, error) {
+	var head [12]byte
+	n, err := io.ReadFull(r, head[:])
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		if errors.Is(err, io.EOF) {
+			return Tags{}, ErrUnsupported
+		}
+		return Tags{}, err
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return Tags{}, err
+	}
+
+	switch {
+	case n >= 3 && bytes.Equal(head[:3], []byte("ID3")):
+		t, after, err := readID3v2(r)
+		if err != nil {
+			return Tags{}, err
+		}
+		// A FLAC file may be (non-standardly) prefixed by an ID3v2 tag. The
+		// Vorbis comment is authoritative there, so prefer it when present.
+		if after >= 0 {
+			if _, err := r.Seek(after, io.SeekStart); err == nil {
+				var magic [4]byte
+				if _, err := io.ReadFull(r, magic[:]); err == nil && string(magic[:]) == "fLaC" {
+					if ft, err := readFLACBlocks(r); err == nil && !ft.IsZero() {
+						return ft, nil
+					}
+				}
+			}
+		}
+		return t, nil
+	case n >= 4 && string(head[:4]) == "fLaC":
+		if _, err := r.Seek(4, io.SeekStart); err != nil {
+			return Tags{}, err
+		}
+		return readFLACBlocks(r)
+	case n >= 4 && string(head[:4]) == "OggS":
+		return readOgg(bufio.NewReader(r))
+	case n >= 8 && string(head[4:8]) == "ftyp":
+		return readMP4(r)
+	}
+	return Tags{}, fmt.Errorf("%w (magic %q)", ErrUnsupported, head[:min(n, 4)])
+}
+
+// addUnique appends the non-empty, trimmed values of vals to dst, skipping
+// duplicates.
+func addUnique(dst []string, vals ...string) []string {
+	for _, v := range vals {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		dup := false
+		for _, have := range dst {
+			if strings.EqualFold(have, v) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			dst = append(dst, v)
+		}
+	}
+	return dst
+}
+
+// splitIDs splits a MusicBrainz-ID tag value into its individual IDs. Picard
+// joins multiple IDs with "/" in ID3v2.3 (which has no native multi-value
+// separator) and taggers vary between "/", ";" and NUL elsewhere. A UUID never
+// contains any of these, so splitting on all of them is safe.
+func splitIDs(v string) []string {
+	return strings.FieldsFunc(v, func(r rune) bool {
+		return r == '/' || r == ';' || r == 0 || r == ',' || r == ' '
+	})
+}
+
+// setField maps a normalized tag key (upper case, Vorbis-comment spelling) to a
+// Tags field. Unknown keys are ignored. Single-valued fields keep the first
+// value seen.
+func (t *Tags) setField(key, value string) {
+	value = strings.TrimSpace(strings.TrimRight(value, "\x00"))
+	if value == "" {
+		return
+	}
+	switch key {
+	case "ARTIST":
+		if t.Artist == "" {
+			t.Artist = value
+		}
+	case "ALBUMARTIST", "ALBUM ARTIST":
+		if t.AlbumArtist == "" {
+			t.AlbumArtist = value
+		}
+	case "ARTISTSORT":
+		if t.ArtistSort == "" {
+			t.ArtistSort = value
+		}
+	case "ALBUMARTISTSORT":
+		if t.AlbumArtistSort == "" {
+			t.AlbumArtistSort = value
+		}
+	case "MUSICBRAINZ_ARTISTID":
+		t.ArtistMBIDs = addUnique(t.ArtistMBIDs, splitIDs(value)...)
+	case "MUSICBRAINZ_ALBUMARTISTID":
+		t.AlbumArtistMBIDs = addUnique(t.AlbumArtistMBIDs, splitIDs(value)...)
+	}
+}
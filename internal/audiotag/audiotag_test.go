@@ -0,0 +1,371 @@
+package audiotag
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"unicode/utf16"
+)
+
+const (
+	nirvanaMBID = "5b11f4ce-a62d-471e-81fc-a69a8278c7da"
+	foofMBID    = "67f66c07-6e61-4026-ade5-7e782fad3a5d"
+)
+
+// --- fixture builders -------------------------------------------------------
+
+func id3Frame(major byte, id string, payload []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString(id)
+	size := uint32(len(payload))
+	if major == 4 {
+		b.Write(syncsafeBytes(size))
+	} else {
+		_ = binary.Write(&b, binary.BigEndian, size)
+	}
+	b.Write([]byte{0, 0})
+	b.Write(payload)
+	return b.Bytes()
+}
+
+func id3Text(enc byte, values ...string) []byte {
+	var b bytes.Buffer
+	b.WriteByte(enc)
+	for i, v := range values {
+		if i > 0 {
+			b.WriteByte(0)
+		}
+		b.WriteString(v)
+	}
+	return b.Bytes()
+}
+
+// id3UTF16 encodes a text payload as UTF-16 with a little-endian BOM, the
+// encoding ID3v2.3 writers use for anything outside Latin-1.
+func id3UTF16(v string) []byte {
+	b := []byte{1, 0xff, 0xfe}
+	for _, u := range utf16.Encode([]rune(v)) {
+		b = binary.LittleEndian.AppendUint16(b, u)
+	}
+	return b
+}
+
+func syncsafeBytes(n uint32) []byte {
+	return []byte{byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+}
+
+func id3Tag(major byte, frames ...[]byte) []byte {
+	var body bytes.Buffer
+	for _, f := range frames {
+		body.Write(f)
+	}
+	body.Write(make([]byte, 64)) // padding
+	var b bytes.Buffer
+	b.WriteString("ID3")
+	b.Write([]byte{major, 0, 0})
+	b.Write(syncsafeBytes(uint32(body.Len())))
+	b.Write(body.Bytes())
+	return b.Bytes()
+}
+
+func vorbisComment(entries ...string) []byte {
+	var b bytes.Buffer
+	le := func(n int) { _ = binary.Write(&b, binary.LittleEndian, uint32(n)) }
+	le(len("test vendor"))
+	b.WriteString("test vendor")
+	le(len(entries))
+	for _, e := range entries {
+		le(len(e))
+		b.WriteString(e)
+	}
+	return b.Bytes()
+}
+
+func flacBlock(last bool, typ byte, body []byte) []byte {
+	h := typ
+	if last {
+		h |= 0x80
+	}
+	n := len(body)
+	return append([]byte{h, byte(n >> 16), byte(n >> 8), byte(n)}, body...)
+}
+
+// oggPage lays out one page holding the given complete packets.
+func oggPage(serial, seq uint32, packets ...[]byte) []byte {
+	var segs []byte
+	var body bytes.Buffer
+	for _, p := range packets {
+		segs = append(segs, lacing(len(p))...)
+		body.Write(p)
+	}
+	return oggRawPage(serial, seq, segs, body.Bytes())
+}
+
+// lacing returns the segment table entries for a packet of n bytes that ends
+// on this page.
+func lacing(n int) []byte {
+	var segs []byte
+	for n >= 255 {
+		segs = append(segs, 255)
+		n -= 255
+	}
+	return append(segs, byte(n))
+}
+
+func oggRawPage(serial, seq uint32, segs, body []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("OggS")
+	b.Write([]byte{0, 0})
+	b.Write(make([]byte, 8)) // granule
+	_ = binary.Write(&b, binary.LittleEndian, serial)
+	_ = binary.Write(&b, binary.LittleEndian, seq)
+	b.Write(make([]byte, 4)) // CRC, not checked
+	b.WriteByte(byte(len(segs)))
+	b.Write(segs)
+	b.Write(body)
+	return b.Bytes()
+}
+
+func atom(typ string, children ...[]byte) []byte {
+	body := bytes.Join(children, nil)
+	b := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(b, uint32(8+len(body)))
+	copy(b[4:], typ)
+	return append(b, body...)
+}
+
+func mp4Data(v string) []byte {
+	return atom("data", append([]byte{0, 0, 0, 1, 0, 0, 0, 0}, v...))
+}
+
+func mp4Freeform(name, value string) []byte {
+	return atom("----",
+		atom("mean", append([]byte{0, 0, 0, 0}, "com.apple.iTunes"...)),
+		atom("name", append([]byte{0, 0, 0, 0}, name...)),
+		mp4Data(value))
+}
+
+func readBytes(t *testing.T, b []byte) Tags {
+	t.Helper()
+	tags, err := Read(bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	return tags
+}
+
+// --- container tests --------------------------------------------------------
+
+func TestRead_ID3v23(t *testing.T) {
+	artwork := make([]byte, 256<<10)
+	tag := id3Tag(3,
+		id3Frame(3, "APIC", artwork),
+		id3Frame(3, "TPE1", id3Text(0, "Nirvana")),
+		id3Frame(3, "TPE2", id3UTF16("Nirvana")),
+		id3Frame(3, "TSOP", id3Text(3, "Nirvana")),
+		id3Frame(3, "TXXX", id3Text(3, "MusicBrainz Artist Id", nirvanaMBID+"/"+foofMBID)),
+		id3Frame(3, "TXXX", id3Text(3, "MusicBrainz Album Artist Id", nirvanaMBID)),
+		id3Frame(3, "TXXX", id3Text(3, "ALBUMARTISTSORT", "Nirvana")),
+	)
+	got := readBytes(t, append(tag, 0xff, 0xfb, 0x90, 0x00))
+
+	want := Tags{
+		Artist:           "Nirvana",
+		AlbumArtist:      "Nirvana",
+		ArtistSort:       "Nirvana",
+		AlbumArtistSort:  "Nirvana",
+		ArtistMBIDs:      []string{nirvanaMBID, foofMBID},
+		AlbumArtistMBIDs: []string{nirvanaMBID},
+	}
+	if !tagsEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestRead_ID3v24MultiValue(t *testing.T) {
+	tag := id3Tag(4,
+		id3Frame(4, "TPE2", id3Text(3, "The Beatles")),
+		id3Frame(4, "TSO2", id3Text(3, "Beatles, The")),
+		id3Frame(4, "TXXX", id3Text(3, "MusicBrainz Album Artist Id", nirvanaMBID, foofMBID)),
+	)
+	got := readBytes(t, tag)
+	if got.AlbumArtist != "The Beatles" || got.AlbumArtistSort != "Beatles, The" {
+		t.Errorf("names = %+v", got)
+	}
+	if !slices.Equal(got.AlbumArtistMBIDs, []string{nirvanaMBID, foofMBID}) {
+		t.Errorf("AlbumArtistMBIDs = %v", got.AlbumArtistMBIDs)
+	}
+}
+
+func TestRead_ID3v2TruncatedFrame(t *testing.T) {
+	tag := id3Tag(3, id3Frame(3, "TPE1", id3Text(0, "Nirvana")))
+	// Claim a frame far larger than the tag: reading must stop, not panic.
+	bad := id3Frame(3, "TPE2", id3Text(0, "x"))
+	binary.BigEndian.PutUint32(bad[4:8], 1<<30)
+	tag = append(tag[:len(tag)-64], bad...)
+	got := readBytes(t, tag)
+	if got.Artist != "Nirvana" || got.AlbumArtist != "" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestRead_FLAC(t *testing.T) {
+	var b bytes.Buffer
+	b.WriteString("fLaC")
+	b.Write(flacBlock(false, 0, make([]byte, 34)))     // STREAMINFO
+	b.Write(flacBlock(false, 6, make([]byte, 100000))) // PICTURE, skipped
+	b.Write(flacBlock(true, flacBlockVorbisComment, vorbisComment(
+		"ARTIST=Nirvana",
+		"albumartist=Nirvana",
+		"ARTISTSORT=Nirvana",
+		"MUSICBRAINZ_ARTISTID="+nirvanaMBID,
+		"MUSICBRAINZ_ALBUMARTISTID="+nirvanaMBID,
+		"NOEQUALSIGN",
+	)))
+	got := readBytes(t, b.Bytes())
+	if got.Artist != "Nirvana" || got.AlbumArtist != "Nirvana" || got.ArtistSort != "Nirvana" {
+		t.Errorf("names = %+v", got)
+	}
+	if !slices.Equal(got.ArtistMBIDs, []string{nirvanaMBID}) || !slices.Equal(got.AlbumArtistMBIDs, []string{nirvanaMBID}) {
+		t.Errorf("mbids = %+v", got)
+	}
+}
+
+func TestRead_FLACTruncatedComment(t *testing.T) {
+	vc := vorbisComment("ARTIST=Nirvana", "MUSICBRAINZ_ARTISTID="+nirvanaMBID)
+	// Declare more entries than present: the decoded head must survive.
+	binary.LittleEndian.PutUint32(vc[4+len("test vendor"):], 50)
+	var b bytes.Buffer
+	b.WriteString("fLaC")
+	b.Write(flacBlock(true, flacBlockVorbisComment, vc))
+	got := readBytes(t, b.Bytes())
+	if got.Artist != "Nirvana" || len(got.ArtistMBIDs) != 1 {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestRead_OggVorbisAndOpus(t *testing.T) {
+	comment := vorbisComment("ALBUMARTIST=Nirvana", "MUSICBRAINZ_ALBUMARTISTID="+nirvanaMBID)
+
+	vorbis := append(oggPage(7, 0, []byte("\x01vorbis-ident")),
+		oggPage(7, 1, append([]byte("\x03vorbis"), comment...), []byte("\x05vorbis-setup"))...)
+	if got := readBytes(t, vorbis); got.AlbumArtist != "Nirvana" || len(got.AlbumArtistMBIDs) != 1 {
+		t.Errorf("vorbis: got %+v", got)
+	}
+
+	// A comment packet larger than a page's 255 segments spans two pages.
+	bigComment := vorbisComment("ALBUMARTIST=Nirvana", "MUSICBRAINZ_ALBUMARTISTID="+nirvanaMBID,
+		"METADATA_BLOCK_PICTURE="+string(make([]byte, 70000)))
+	packet := append([]byte("OpusTags"), bigComment...)
+	first, rest := packet[:255*255], packet[255*255:]
+	opus := bytes.Join([][]byte{
+		oggPage(9, 0, []byte("OpusHead")),
+		// 255 full segments: the packet continues on the next page.
+		oggRawPage(9, 1, bytes.Repeat([]byte{255}, 255), first),
+		oggRawPage(9, 2, lacing(len(rest)), rest),
+	}, nil)
+	if got := readBytes(t, opus); got.AlbumArtist != "Nirvana" {
+		t.Errorf("opus: got %+v", got)
+	}
+}
+
+func TestRead_MP4MoovAfterMdat(t *testing.T) {
+	ilst := atom("ilst",
+		atom("\xa9ART", mp4Data("Nirvana")),
+		atom("aART", mp4Data("Nirvana")),
+		atom("soaa", mp4Data("Nirvana")),
+		atom("covr", mp4Data(string(make([]byte, 50000)))),
+		mp4Freeform("MusicBrainz Artist Id", nirvanaMBID),
+		mp4Freeform("MusicBrainz Album Artist Id", nirvanaMBID),
+	)
+	meta := atom("meta", append([]byte{0, 0, 0, 0}, ilst...))
+	file := bytes.Join([][]byte{
+		atom("ftyp", []byte("M4A \x00\x00\x00\x00")),
+		atom("mdat", make([]byte, 4096)),
+		atom("moov", atom("mvhd", make([]byte, 100)), atom("udta", meta)),
+	}, nil)
+
+	got := readBytes(t, file)
+	want := Tags{
+		Artist:           "Nirvana",
+		AlbumArtist:      "Nirvana",
+		AlbumArtistSort:  "Nirvana",
+		ArtistMBIDs:      []string{nirvanaMBID},
+		AlbumArtistMBIDs: []string{nirvanaMBID},
+	}
+	if !tagsEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestRead_Unsupported(t *testing.T) {
+	for name, b := range map[string][]byte{
+		"empty": nil,
+		"wav":   []byte("RIFF\x00\x00\x00\x00WAVEfmt "),
+	} {
+		if _, err := Read(bytes.NewReader(b)); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("%s: err = %v, want ErrUnsupported", name, err)
+		}
+	}
+}
+
+// --- sampling ---------------------------------------------------------------
+
+func TestSampleDir_SpreadsAcrossAlbums(t *testing.T) {
+	root := t.TempDir()
+	flac := func(artist string) []byte {
+		var b bytes.Buffer
+		b.WriteString("fLaC")
+		b.Write(flacBlock(true, flacBlockVorbisComment, vorbisComment("ALBUMARTIST="+artist)))
+		return b.Bytes()
+	}
+	write := func(rel string, data []byte) {
+		p := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("Bleach/01.flac", flac("Bleach"))
+	write("Bleach/02.flac", flac("Bleach-2"))
+	write("In Utero/CD1/01.flac", flac("In Utero"))
+	write("Nevermind/cover.jpg", []byte("jpeg"))
+	write("Nevermind/01.flac", flac("Nevermind"))
+	write("Unplugged/01.mp3", []byte("not really an mp3"))
+	write(".hidden/01.flac", flac("hidden"))
+
+	got, err := SampleDir(context.Background(), root, 10)
+	if err != nil {
+		t.Fatalf("SampleDir: %v", err)
+	}
+	var names []string
+	for _, ft := range got {
+		names = append(names, ft.Tags.AlbumArtist)
+	}
+	// The undecodable mp3 is skipped; hidden directories are never sampled.
+	if !slices.Equal(names, []string{"Bleach", "In Utero", "Nevermind"}) {
+		t.Errorf("sampled %v", names)
+	}
+
+	got, err = SampleDir(context.Background(), root, 1)
+	if err != nil || len(got) != 1 {
+		t.Errorf("limit 1: got %d files, err %v", len(got), err)
+	}
+
+	if _, err := SampleDir(context.Background(), filepath.Join(root, "missing"), 0); err == nil {
+		t.Error("missing dir: want error")
+	}
+}
+
+func tagsEqual(a, b Tags) bool {
+	return a.Artist == b.Artist && a.AlbumArtist == b.AlbumArtist &&
+		a.ArtistSort == b.ArtistSort && a.AlbumArtistSort == b.AlbumArtistSort &&
+		slices.Equal(a.ArtistMBIDs, b.ArtistMBIDs) && slices.Equal(a.AlbumArtistMBIDs, b.AlbumArtistMBIDs)
+}
@@ -0,0 +1,309 @@
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// id3FrameKeys maps the ID3v2 text frames this package reads onto the
+// Vorbis-comment keys setField understands. v2.2 uses three-character IDs; the
+// v2.2 sort frames are the iTunes extensions Picard also writes.
+var id3FrameKeys = map[string]string{
+	"TPE1": "ARTIST",
+	"TPE2": "ALBUMARTIST",
+	"TSOP": "ARTISTSORT",
+	"TSO2": "ALBUMARTISTSORT",
+	"TP1":  "ARTIST",
+	"TP2":  "ALBUMARTIST",
+	"TSP":  "ARTISTSORT",
+	"TS2":  "ALBUMARTISTSORT",
+}
+
+// id3UserTextKeys maps TXXX descriptions (upper-cased) onto setField keys.
+// Picard writes the MusicBrainz IDs under the spaced descriptions; the bare
+// spellings are what foobar2000 and some converters produce.
+var id3UserTextKeys = map[string]string{
+	"MUSICBRAINZ ARTIST ID":       "MUSICBRAINZ_ARTISTID",
+	"MUSICBRAINZ_ARTISTID":        "MUSICBRAINZ_ARTISTID",
+	"MUSICBRAINZ ALBUM ARTIST ID": "MUSICBRAINZ_ALBUMARTISTID",
+	"MUSICBRAINZ_ALBUMARTISTID":   "MUSICBRAINZ_ALBUMARTISTID",
+	"ALBUMARTISTSORT":             "ALBUMARTISTSORT",
+	"ARTISTSORT":                  "ARTISTSORT",
+	"ALBUM ARTIST":                "ALBUMARTIST",
+	"ALBUMARTIST":                 "ALBUMARTIST",
+}
+
+// readID3v2 decodes an ID3v2 tag starting at the current offset (which must be
+// the "ID3" magic). It returns the tags and the file offset just past the tag,
+// so the caller can look for a container behind it.
+//
+// Frames are read one at a time and frames this package does not want are
+// skipped with Seek, so a tag holding several megabytes of APIC artwork costs a
+// few small reads. The exception is a v2.2/v2.3 tag with the whole-tag
+// unsynchronisation flag: its frame boundaries are only visible after the
+// unsynchronisation is undone, so it is read whole -- and skipped when it
+// exceeds maxTagBytes.
+func readID3v2(r io.ReadSeeker) (Tags, int64, error) {
+	var t Tags
+	var hdr [10]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return t, -1, fmt.Errorf("reading ID3v2 header: %w", err)
+	}
+	major, flags := hdr[3], hdr[5]
+	size, ok := syncsafe(hdr[6:10])
+	if !ok {
+		return t, -1, errors.New("malformed ID3v2 header size")
+	}
+	end := int64(10) + int64(size)
+	if major == 4 && flags&0x10 != 0 {
+		end += 10 // footer
+	}
+	if major < 2 || major > 4 {
+		// An unknown major version may lay frames out differently; reading
+		// it as one of the known ones would produce garbage, not tags.
+		return t, end, nil
+	}
+
+	var body io.ReadSeeker = r
+	start, limit := int64(10), int64(10)+int64(size)
+	if major < 4 && flags&0x80 != 0 {
+		if size > maxTagBytes {
+			return t, end, nil
+		}
+		buf := make([]byte, size)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return t, end, fmt.Errorf("reading ID3v2 tag: %w", err)
+		}
+		buf = unsynchronise(buf)
+		body = bytes.NewReader(buf)
+		start, limit = 0, int64(len(buf))
+	}
+
+	pos := start
+	if flags&0x40 != 0 {
+		if major == 2 {
+			// v2.2 used this bit for whole-tag compression, which no
+			// tagger ever implemented consistently.
+			return t, end, nil
+		}
+		var ext [4]byte
+		if _, err := io.ReadFull(body, ext[:]); err != nil {
+			return t, end, fmt.Errorf("reading ID3v2 extended header: %w", err)
+		}
+		var skip int64
+		if major == 4 {
+			n, ok := syncsafe(ext[:])
+			if !ok || n < 4 {
+				return t, end, errors.New("malformed ID3v2 extended header")
+			}
+			skip = int64(n) - 4
+		} else {
+			skip = int64(binary.BigEndian.Uint32(ext[:]))
+		}
+		pos += 4 + skip
+		if _, err := body.Seek(pos, io.SeekStart); err != nil {
+			return t, end, err
+		}
+	}
+
+	idLen, hdrLen := 4, 10
+	if major == 2 {
+		idLen, hdrLen = 3, 6
+	}
+	fh := make([]byte, hdrLen)
+	for pos+int64(hdrLen) <= limit {
+		if _, err := io.ReadFull(body, fh); err != nil {
+			break
+		}
+		if fh[0] == 0 {
+			break // padding
+		}
+		id := string(fh[:idLen])
+		var frameSize uint32
+		var frameFlags uint16
+		switch major {
+		case 2:
+			frameSize = uint32(fh[3])<<16 | uint32(fh[4])<<8 | uint32(fh[5])
+		case 3:
+			frameSize = binary.BigEndian.Uint32(fh[4:8])
+			frameFlags = binary.BigEndian.Uint16(fh[8:10])
+		case 4:
+			n, ok := syncsafe(fh[4:8])
+			if !ok {
+				// iTunes has shipped v2.4 tags with plain big-endian
+				// frame sizes; accept them rather than stop here.
+				n = binary.BigEndian.Uint32(fh[4:8])
+			}
+			frameSize = n
+			frameFlags = binary.BigEndian.Uint16(fh[8:10])
+		}
+		pos += int64(hdrLen)
+		next := pos + int64(frameSize)
+		if next > limit {
+			break
+		}
+
+		key, userText := id3FrameKeys[id], id == "TXXX" || id == "TXX"
+		if (key != "" || userText) && frameSize <= maxTagBytes {
+			data := make([]byte, frameSize)
+			if _, err := io.ReadFull(body, data); err != nil {
+				break
+			}
+			if payload, ok := id3FrameData(major, frameFlags, data); ok && len(payload) > 0 {
+				values := decodeID3Text(payload[0], payload[1:])
+				if userText {
+					if len(values) >= 2 {
+						if k := id3UserTextKeys[strings.ToUpper(strings.TrimSpace(values[0]))]; k != "" {
+							for _, v := range values[1:] {
+								t.setField(k, v)
+							}
+						}
+					}
+				} else {
+					for _, v := range values {
+						t.setField(key, v)
+					}
+				}
+			}
+		}
+		pos = next
+		if _, err := body.Seek(pos, io.SeekStart); err != nil {
+			break
+		}
+	}
+	return t, end, nil
+}
+
+// id3FrameData strips the per-frame wrapping described by the frame flags and
+// reports whether the payload is readable. Compressed and encrypted frames are
+// not: identity frames are never written that way in practice, and inflating
+// attacker-sized zlib streams is not a risk worth taking for them.
+func id3FrameData(major byte, flags uint16, data []byte) ([]byte, bool) {
+	switch major {
+	case 3:
+		if flags&0x00c0 != 0 { // compression, encryption
+			return nil, false
+		}
+		if flags&0x0020 != 0 { // grouping identity
+			if len(data) < 1 {
+				return nil, false
+			}
+			data = data[1:]
+		}
+	case 4:
+		if flags&0x000c != 0 { // compression, encryption
+			return nil, false
+		}
+		if flags&0x0040 != 0 { // grouping identity
+			if len(data) < 1 {
+				return nil, false
+			}
+			data = data[1:]
+		}
+		if flags&0x0001 != 0 { // data length indicator
+			if len(data) < 4 {
+				return nil, false
+			}
+			data = data[4:]
+		}
+		if flags&0x0002 != 0 {
+			data = unsynchronise(data)
+		}
+	}
+	return data, true
+}
+
+// decodeID3Text decodes an ID3v2 text payload in the given encoding and splits
+// it on NUL terminators, which is both how v2.4 separates multiple values and
+// how TXXX separates its description from its value.
+func decodeID3Text(enc byte, b []byte) []string {
+	var parts []string
+	switch enc {
+	case 1, 2: // UTF-16 with BOM, UTF-16BE
+		for len(b) >= 2 {
+			i := 0
+			for ; i+1 < len(b); i += 2 {
+				if b[i] == 0 && b[i+1] == 0 {
+					break
+				}
+			}
+			parts = append(parts, decodeUTF16(b[:i], enc == 2))
+			if i+2 > len(b) {
+				break
+			}
+			b = b[i+2:]
+		}
+	default: // 0 ISO-8859-1, 3 UTF-8
+		for _, p := range bytes.Split(b, []byte{0}) {
+			if enc == 0 {
+				parts = append(parts, latin1(p))
+			} else {
+				parts = append(parts, string(p))
+			}
+		}
+	}
+	// Drop the empty trailing element a terminated final value leaves.
+	for len(parts) > 0 && parts[len(parts)-1] == "" {
+		parts = parts[:len(parts)-1]
+	}
+	return parts
+}
+
+func decodeUTF16(b []byte, bigEndian bool) string {
+	if len(b) >= 2 {
+		switch {
+		case b[0] == 0xfe && b[1] == 0xff:
+			bigEndian, b = true, b[2:]
+		case b[0] == 0xff && b[1] == 0xfe:
+			bigEndian, b = false, b[2:]
+		}
+	}
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		if bigEndian {
+			u[i] = binary.BigEndian.Uint16(b[2*i:])
+		} else {
+			u[i] = binary.LittleEndian.Uint16(b[2*i:])
+		}
+	}
+	return string(utf16.Decode(u))
+}
+
+func latin1(b []byte) string {
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
+
+// syncsafe decodes a four-byte ID3v2 syncsafe integer. It reports false when a
+// byte has its high bit set, which a syncsafe integer never does.
+func syncsafe(b []byte) (uint32, bool) {
+	var n uint32
+	for _, c := range b[:4] {
+		if c&0x80 != 0 {
+			return 0, false
+		}
+		n = n<<7 | uint32(c)
+	}
+	return n, true
+}
+
+// unsynchronise reverses ID3v2 unsynchronisation: every 0xFF 0x00 pair becomes
+// 0xFF.
+func unsynchronise(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		out = append(out, b[i])
+		if b[i] == 0xff && i+1 < len(b) && b[i+1] == 0 {
+			i++
+		}
+	}
+	return out
+}
@@ -0,0 +1,203 @@
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// mp4ItemKeys maps the standard iTunes ilst item atoms this package reads onto
+// setField keys.
+var mp4ItemKeys = map[string]string{
+	"\xa9ART": "ARTIST",
+	"aART":    "ALBUMARTIST",
+	"soar":    "ARTISTSORT",
+	"soaa":    "ALBUMARTISTSORT",
+}
+
+// mp4FreeformKeys maps "----" freeform item names (in the com.apple.iTunes
+// namespace, upper-cased) onto setField keys. These are the spellings Picard
+// writes.
+var mp4FreeformKeys = map[string]string{
+	"MUSICBRAINZ ARTIST ID":       "MUSICBRAINZ_ARTISTID",
+	"MUSICBRAINZ ALBUM ARTIST ID": "MUSICBRAINZ_ALBUMARTISTID",
+}
+
+// maxMP4Atoms bounds every atom walk, for the same reason maxFLACBlocks does.
+const maxMP4Atoms = 4096
+
+// mp4Atom is one atom header: its type and the offsets of its payload.
+type mp4Atom struct {
+	typ        string
+	start, end int64
+}
+
+// readMP4 decodes the iTunes-style metadata at moov/udta/meta/ilst. The walk
+// seeks from atom to atom, so the mdat payload (the audio) and the covr item
+// (the artwork) are never read, and a moov atom placed after mdat costs one
+// seek rather than a read of the whole file.
+func readMP4(r io.ReadSeeker) (Tags, error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return Tags{}, err
+	}
+	moov, err := findAtom(r, 0, size, "moov")
+	if err != nil || moov == nil {
+		return Tags{}, err
+	}
+	udta, err := findAtom(r, moov.start, moov.end, "udta")
+	if err != nil || udta == nil {
+		return Tags{}, err
+	}
+	meta, err := findAtom(r, udta.start, udta.end, "meta")
+	if err != nil || meta == nil {
+		return Tags{}, err
+	}
+	// meta is a full box in ISO files (version and flags precede its
+	// children) but a plain container in QuickTime-flavored ones; try both.
+	ilst, err := findAtom(r, meta.start+4, meta.end, "ilst")
+	if err != nil || ilst == nil {
+		ilst, err = findAtom(r, meta.start, meta.end, "ilst")
+	}
+	if err != nil || ilst == nil {
+		return Tags{}, err
+	}
+
+	var t Tags
+	err = eachAtom(r, ilst.start, ilst.end, func(item mp4Atom) error {
+		key := mp4ItemKeys[item.typ]
+		if key == "" && item.typ != "----" {
+			return nil
+		}
+		if item.end-item.start > maxTagBytes {
+			return nil
+		}
+		buf := make([]byte, item.end-item.start)
+		if _, err := r.Seek(item.start, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return err
+		}
+		if item.typ == "----" {
+			name, values := parseMP4Freeform(buf)
+			if k := mp4FreeformKeys[strings.ToUpper(name)]; k != "" {
+				for _, v := range values {
+					t.setField(k, v)
+				}
+			}
+			return nil
+		}
+		for _, v := range mp4DataValues(buf) {
+			t.setField(key, v)
+		}
+		return nil
+	})
+	return t, err
+}
+
+// findAtom returns the first direct child of type typ within [start, end), or
+// nil when there is none.
+func findAtom(r io.ReadSeeker, start, end int64, typ string) (*mp4Atom, error) {
+	var found *mp4Atom
+	errStop := errors.New("stop")
+	err := eachAtom(r, start, end, func(a mp4Atom) error {
+		if a.typ == typ {
+			found = &a
+			return errStop
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStop) {
+		return nil, err
+	}
+	return found, nil
+}
+
+// eachAtom calls fn for every atom header within [start, end). fn may move the
+// read offset; eachAtom seeks to each header itself.
+func eachAtom(r io.ReadSeeker, start, end int64, fn func(mp4Atom) error) error {
+	var hdr [16]byte
+	pos := start
+	for i := 0; i < maxMP4Atoms && pos+8 <= end; i++ {
+		if _, err := r.Seek(pos, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(r, hdr[:8]); err != nil {
+			return fmt.Errorf("reading MP4 atom header: %w", err)
+		}
+		size := int64(binary.BigEndian.Uint32(hdr[:4]))
+		typ := string(hdr[4:8])
+		headerLen := int64(8)
+		switch size {
+		case 0: // extends to the end of the enclosing range
+			size = end - pos
+		case 1: // 64-bit extended size
+			if _, err := io.ReadFull(r, hdr[8:16]); err != nil {
+				return fmt.Errorf("reading MP4 extended atom size: %w", err)
+			}
+			ext := binary.BigEndian.Uint64(hdr[8:16])
+			if ext > uint64(end-pos) {
+				return fmt.Errorf("MP4 atom %q overruns its container", typ)
+			}
+			size = int64(ext)
+			headerLen = 16
+		}
+		if size < headerLen || pos+size > end {
+			return fmt.Errorf("MP4 atom %q has invalid size %d", typ, size)
+		}
+		if err := fn(mp4Atom{typ: typ, start: pos + headerLen, end: pos + size}); err != nil {
+			return err
+		}
+		pos += size
+	}
+	return nil
+}
+
+// mp4DataValues returns the text payloads of the "data" children in an ilst
+// item's body. Each data atom carries a 4-byte type indicator and a 4-byte
+// locale before the value.
+func mp4DataValues(b []byte) []string {
+	var out []string
+	walkMP4Bytes(b, func(typ string, body []byte) {
+		if typ == "data" && len(body) >= 8 {
+			out = append(out, string(body[8:]))
+		}
+	})
+	return out
+}
+
+// parseMP4Freeform decodes a "----" item: its "name" child and its "data"
+// values. The "mean" namespace is not checked; Picard and iTunes both use
+// com.apple.iTunes and no other writer reuses these names.
+func parseMP4Freeform(b []byte) (name string, values []string) {
+	walkMP4Bytes(b, func(typ string, body []byte) {
+		switch typ {
+		case "name":
+			if len(body) >= 4 {
+				name = string(body[4:])
+			}
+		case "data":
+			if len(body) >= 8 {
+				values = append(values, string(bytes.TrimRight(body[8:], "\x00")))
+			}
+		}
+	})
+	return name, values
+}
+
+// walkMP4Bytes iterates the atoms laid out back to back in b, stopping at the
+// first malformed header.
+func walkMP4Bytes(b []byte, fn func(typ string, body []byte)) {
+	for i := 0; i < maxMP4Atoms && len(b) >= 8; i++ {
+		size := binary.BigEndian.Uint32(b[:4])
+		if size < 8 || uint64(size) > uint64(len(b)) {
+			return
+		}
+		fn(string(b[4:8]), b[8:size])
+		b = b[size:]
+	}
+}
@@ -0,0 +1,115 @@
+package audiotag
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultSampleSize is how many tracks SampleDir reads per artist directory
+// when the caller passes zero. Identity tags are per-release, so five tracks
+// from five different albums say far more than fifty tracks from one; the
+// sampler spreads across album directories for that reason.
+const DefaultSampleSize = 5
+
+// FileTags pairs a sampled file with the tags read from it.
+type FileTags struct {
+	Path string `json:"path"`
+	Tags Tags   `json:"tags"`
+}
+
+// SampleDir reads the tags of up to limit audio files under an artist directory:
+// loose tracks in the directory itself first, then the first track (in name
+// order) of each album directory, descending one further level for multi-disc
+// layouts ("Album/CD1/01.flac"). Files that cannot be read or decoded are
+// skipped; they are absent evidence, not contrary evidence.
+//
+// Only an unreadable artist directory is an error. A directory with no audio
+// at all returns an empty slice and a nil error.
+func SampleDir(ctx context.Context, dir string, limit int) ([]FileTags, error) {
+	if limit <= 0 {
+		limit = DefaultSampleSize
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// os.ReadDir returns entries sorted by name, so both lists are in name
+	// order and the sample is stable from one scan to the next.
+	var candidates []string
+	var subdirs []string
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		switch {
+		case e.IsDir():
+			subdirs = append(subdirs, filepath.Join(dir, name))
+		case IsAudioFile(name):
+			candidates = append(candidates, filepath.Join(dir, name))
+		}
+	}
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	for _, sub := range subdirs {
+		if len(candidates) >= limit {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if f := firstAudioFile(sub, 1); f != "" {
+			candidates = append(candidates, f)
+		}
+	}
+
+	out := make([]FileTags, 0, len(candidates))
+	for _, path := range candidates {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		t, err := ReadFile(path)
+		if err != nil {
+			continue
+		}
+		out = append(out, FileTags{Path: path, Tags: t})
+	}
+	return out, nil
+}
+
+// firstAudioFile returns the first audio file (in name order) in dir, looking
+// depth levels of subdirectory deeper when dir itself holds none. It returns
+// "" when nothing is found or dir cannot be read.
+func firstAudioFile(dir string, depth int) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	var subdirs []string
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		if e.IsDir() {
+			subdirs = append(subdirs, filepath.Join(dir, name))
+			continue
+		}
+		if IsAudioFile(name) {
+			return filepath.Join(dir, name)
+		}
+	}
+	if depth <= 0 {
+		return ""
+	}
+	for _, sub := range subdirs {
+		if f := firstAudioFile(sub, depth-1); f != "" {
+			return f
+		}
+	}
+	return ""
+}
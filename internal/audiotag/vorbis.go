@@ -0,0 +1,183 @@
+package audiotag
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// flacBlockVorbisComment is the FLAC metadata block type holding the Vorbis
+// comment.
+const flacBlockVorbisComment = 4
+
+// maxFLACBlocks bounds the metadata-block walk. Real files carry a handful;
+// the cap keeps a corrupt chain of zero-length blocks from looping forever.
+const maxFLACBlocks = 1024
+
+// readFLACBlocks walks FLAC metadata blocks from the current offset (just past
+// the "fLaC" magic) and decodes the Vorbis comment block. PICTURE, SEEKTABLE
+// and PADDING blocks are skipped with Seek.
+func readFLACBlocks(r io.ReadSeeker) (Tags, error) {
+	var hdr [4]byte
+	for i := 0; i < maxFLACBlocks; i++ {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			return Tags{}, fmt.Errorf("reading FLAC block header: %w", err)
+		}
+		last := hdr[0]&0x80 != 0
+		blockType := hdr[0] & 0x7f
+		length := int64(hdr[1])<<16 | int64(hdr[2])<<8 | int64(hdr[3])
+
+		if blockType == flacBlockVorbisComment {
+			if length > maxTagBytes {
+				return Tags{}, nil
+			}
+			buf := make([]byte, length)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return Tags{}, fmt.Errorf("reading FLAC Vorbis comment: %w", err)
+			}
+			return parseVorbisComment(buf), nil
+		}
+		if last {
+			return Tags{}, nil
+		}
+		if _, err := r.Seek(length, io.SeekCurrent); err != nil {
+			return Tags{}, err
+		}
+	}
+	return Tags{}, errors.New("too many FLAC metadata blocks")
+}
+
+// parseVorbisComment decodes a Vorbis comment structure (vendor string, then a
+// count of KEY=value entries, all little-endian length-prefixed). A structure
+// that ends early -- a truncated packet, a lying length -- yields the entries
+// decoded up to that point rather than nothing: each entry stands alone, so a
+// bad tail does not make the good head wrong.
+func parseVorbisComment(b []byte) Tags {
+	var t Tags
+	next := func() ([]byte, bool) {
+		if len(b) < 4 {
+			return nil, false
+		}
+		n := binary.LittleEndian.Uint32(b)
+		b = b[4:]
+		if uint64(n) > uint64(len(b)) {
+			return nil, false
+		}
+		v := b[:n]
+		b = b[n:]
+		return v, true
+	}
+
+	if _, ok := next(); !ok { // vendor
+		return t
+	}
+	if len(b) < 4 {
+		return t
+	}
+	count := binary.LittleEndian.Uint32(b)
+	b = b[4:]
+	for i := uint32(0); i < count; i++ {
+		entry, ok := next()
+		if !ok {
+			break
+		}
+		key, value, found := bytes.Cut(entry, []byte{'='})
+		if !found {
+			continue
+		}
+		t.setField(strings.ToUpper(string(key)), string(value))
+	}
+	return t
+}
+
+// Ogg framing constants.
+const (
+	oggPageHeaderLen = 27
+	// maxOggPages bounds how far into the stream the comment packet is
+	// looked for. It is always the second packet, which starts on the
+	// second page; the slack covers a comment packet spanning pages because
+	// of embedded artwork.
+	maxOggPages = 512
+)
+
+// readOgg decodes the comment header of an Ogg Vorbis or Opus stream. The
+// comment header is the stream's second packet; packets are reassembled from
+// page segments until it is complete. Only the first logical stream is
+// followed.
+func readOgg(r io.Reader) (Tags, error) {
+	var (
+		hdr     [oggPageHeaderLen]byte
+		serial  uint32
+		packet  []byte
+		packets int
+		tooBig  bool
+	)
+	for page := 0; page < maxOggPages; page++ {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return Tags{}, nil
+			}
+			return Tags{}, fmt.Errorf("reading Ogg page header: %w", err)
+		}
+		if string(hdr[:4]) != "OggS" {
+			return Tags{}, errors.New("lost Ogg page sync")
+		}
+		pageSerial := binary.LittleEndian.Uint32(hdr[14:18])
+		if page == 0 {
+			serial = pageSerial
+		}
+		segTable := make([]byte, hdr[26])
+		if _, err := io.ReadFull(r, segTable); err != nil {
+			return Tags{}, fmt.Errorf("reading Ogg segment table: %w", err)
+		}
+		var bodyLen int
+		for _, s := range segTable {
+			bodyLen += int(s)
+		}
+		body := make([]byte, bodyLen)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return Tags{}, fmt.Errorf("reading Ogg page body: %w", err)
+		}
+		if pageSerial != serial {
+			continue
+		}
+
+		off := 0
+		for _, s := range segTable {
+			if !tooBig {
+				packet = append(packet, body[off:off+int(s)]...)
+				if len(packet) > maxTagBytes {
+					tooBig, packet = true, nil
+				}
+			}
+			off += int(s)
+			if s == 255 {
+				continue // packet continues in the next segment
+			}
+			packets++
+			if packets == 2 {
+				if tooBig {
+					return Tags{}, nil
+				}
+				return parseOggCommentPacket(packet), nil
+			}
+			packet, tooBig = packet[:0], false
+		}
+	}
+	return Tags{}, nil
+}
+
+// parseOggCommentPacket strips the codec-specific comment header prefix and
+// decodes the Vorbis comment behind it.
+func parseOggCommentPacket(p []byte) Tags {
+	switch {
+	case bytes.HasPrefix(p, []byte("\x03vorbis")):
+		return parseVorbisComment(p[7:])
+	case bytes.HasPrefix(p, []byte("OpusTags")):
+		return parseVorbisComment(p[8:])
+	}
+	return Tags{}
+}
@@ -9,10 +9,14 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
+
+	"github.com/sydlexius/stillwater/internal/dbutil"
+	"github.com/sydlexius/stillwater/internal/encryption"
 )
 
 // dummyHash holds a bcrypt hash computed once at DefaultCost, used by Login to
@@ -67,12 +71,27 @@ const (
 	ScopeAdmin   TokenScope = "admin"
 )
 
-// ValidScopes contains all valid token scope values.
+// ValidScopes contains all valid token scope values: the coarse scopes above
+// and the resource scopes in scope.go.
 var ValidScopes = map[TokenScope]bool{
 	ScopeRead:    true,
 	ScopeWrite:   true,
 	ScopeWebhook: true,
 	ScopeAdmin:   true,
+
+	ScopeArtistsRead:      true,
+	ScopeArtistsWrite:     true,
+	ScopeImagesRead:       true,
+	ScopeImagesWrite:      true,
+	ScopeRulesRead:        true,
+	ScopeRulesWrite:       true,
+	ScopeRulesRun:         true,
+	ScopeLibrariesRead:    true,
+	ScopeLibrariesWrite:   true,
+	ScopeConnectionsRead:  true,
+	ScopeConnectionsWrite: true,
+	ScopeSettingsRead:     true,
+	ScopeSettingsWrite:    true,
 }
 
 // TokenStatus represents the lifecycle state of an API token.
@@ -89,6 +108,7 @@ type APIToken struct {
 	ID         string      `json:"id"`
 	Name       string      `json:"name"`
 	Scopes     string      `json:"scopes"`
+	LibraryIDs []string    `json:"library_ids,omitempty"`
 	UserID     string      `json:"user_id"`
 	Status     TokenStatus `json:"status"`
 	CreatedAt  string      `json:"created_at"`
@@ -109,12 +129,15 @@ type AuditEntry struct {
 
 // Service provides authentication operations.
 type Service struct {
-	db *sql.DB
+	db        *sql.DB
+	encryptor *encryption.Encryptor // TOTP secrets; see WithEncryptor
+	lockout   LockoutPolicy         // see WithLockoutPolicy
+	onLockout func(Lockout)         // see WithLockoutNotifier
 }
 
-// NewService creates an auth service.
+// NewService creates an auth service with the default lockout policy.
 func NewService(db *sql.DB) *Service {
-	return &Service{db: db}
+	return &Service{db: db, lockout: DefaultLockoutPolicy()}
 }
 
 // Setup creates the initial admin account if no users exist.
@@ -149,7 +172,16 @@ func (s *Service) Setup(ctx context.Context, username, password string) (bool, e
 
 // Login authenticates a user by username/password and returns a session token.
 // Credential validation happens here; session creation is delegated to CreateSession.
+// When the account uses two-factor authentication, or the policy requires it,
+// no session is created: Login returns a *SecondFactorRequiredError and the
+// caller finishes with CompleteSecondFactor. While the username or the client
+// address is locked out (see LockoutPolicy), Login returns a *LockedError
+// without checking the password.
 func (s *Service) Login(ctx context.Context, username, password string) (string, error) {
+	if err := s.CheckLogin(ctx, username); err != nil {
+		return "", err
+	}
+
 	var id, hash string
 	err := s.db.QueryRowContext(ctx, `
 		SELECT id, password_hash FROM users WHERE username = ?
@@ -160,23 +192,38 @@ func (s *Service) Login(ctx context.Context, username, password string) (string,
 		// Without this, an attacker could distinguish "user exists" from "user
 		// unknown" by response latency (username enumeration; #2171).
 		_ = bcrypt.CompareHashAndPassword(dummyHash, PrehashPassword(password))
-		return "", errors.New("invalid credentials")
+		return "", s.loginFailed(ctx, username)
 	}
 	if err != nil {
 		return "", fmt.Errorf("querying user: %w", err)
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(hash), PrehashPassword(password)); err != nil {
-		return "", errors.New("invalid credentials")
+		return "", s.loginFailed(ctx, username)
+	}
+	// A challenge leaves the failure count alone: the login only succeeds
+	// once CompleteSecondFactor accepts the code.
+	if err := s.BeginSecondFactor(ctx, id); err != nil {
+		return "", err
+	}
+	if err := s.RecordLoginSuccess(ctx, username); err != nil {
+		return "", err
 	}
-
 	return s.CreateSession(ctx, id)
 }
 
+// loginFailed counts a failed Login and returns ErrInvalidCredentials, joined
+// with any error from counting it.
+func (s *Service) loginFailed(ctx context.Context, username string) error {
+	return errors.Join(ErrInvalidCredentials, s.RecordLoginFailure(ctx, username))
+}
+
 // CreateSession creates a new session for the given user ID and returns the token.
 // This is used after authentication when the caller has already validated the identity.
 // Also stamps users.last_login so the inactive-users admin filter can find stale
 // accounts; the stamp is best-effort and a failure does not roll back the session.
+// The client IP and User-Agent are taken from the context (see WithClientInfo)
+// so the session list can show where each login came from.
 func (s *Service) CreateSession(ctx context.Context, userID string) (string, error) {
 	token, err := generateToken()
 	if err != nil {
@@ -184,13 +231,15 @@ func (s *Service) CreateSession(ctx context.Context, userID string) (string, err
 	}
 
 	now := time.Now().UTC()
+	created := now.Format(time.RFC3339)
 	expiresAt := now.Add(sessionDuration).Format(time.RFC3339)
+	client := ClientInfoFromContext(ctx)
 	// Store only the hash at rest (parity with API tokens); the raw token is
 	// returned to the caller so setSessionCookie can write the usable cookie.
 	_, err = s.db.ExecContext(ctx, `
-		INSERT INTO sessions (id, user_id, expires_at)
-		VALUES (?, ?, ?)
-	`, hashToken(token), userID, expiresAt)
+		INSERT INTO sessions (id, public_id, user_id, user_agent, ip_address, created_at, last_seen_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, hashToken(token), uuid.New().String(), userID, truncateUserAgent(client.UserAgent), client.IP, created, created, expiresAt)
 	if err != nil {
 		return "", fmt.Errorf("creating session: %w", err)
 	}
@@ -205,11 +254,13 @@ func (s *Service) CreateSession(ctx context.Context, userID string) (string, err
 }
 
 // ValidateSession checks if a session token is valid and returns the user ID.
+// It also refreshes the session's last_seen_at, at most once a minute.
 func (s *Service) ValidateSession(ctx context.Context, token string) (string, error) {
 	var userID, expiresAt string
+	var lastSeen sql.NullString
 	err := s.db.QueryRowContext(ctx, `
-		SELECT user_id, expires_at FROM sessions WHERE id = ?
-	`, hashToken(token)).Scan(&userID, &expiresAt)
+		SELECT user_id, expires_at, last_seen_at FROM sessions WHERE id = ?
+	`, hashToken(token)).Scan(&userID, &expiresAt, &lastSeen)
 	if errors.Is(err, sql.ErrNoRows) {
 		return "", errors.New("invalid session")
 	}
@@ -227,6 +278,13 @@ func (s *Service) ValidateSession(ctx context.Context, token string) (string, er
 		return "", errors.New("session expired")
 	}
 
+	// Best-effort, like api_tokens.last_used_at.
+	now := time.Now().UTC()
+	if seen, ok := dbutil.ParseTimeOK(lastSeen.String); !ok || now.Sub(seen) >= lastSeenInterval {
+		_, _ = s.db.ExecContext(ctx,
+			`UPDATE sessions SET last_seen_at = ? WHERE id = ?`, now.Format(time.RFC3339), hashToken(token))
+	}
+
 	return userID, nil
 }
 
@@ -236,11 +294,18 @@ func (s *Service) Logout(ctx context.Context, token string) error {
 	return err
 }
 
-// CleanExpiredSessions removes all expired sessions.
+// CleanExpiredSessions removes all expired sessions, and the expired
+// two-factor login challenges that never became one.
 func (s *Service) CleanExpiredSessions(ctx context.Context) error {
-	_, err := s.db.ExecContext(ctx, `
+	now := time.Now().UTC().Format(time.RFC3339)
+	if _, err := s.db.ExecContext(ctx, `
 		DELETE FROM sessions WHERE expires_at < ?
-	`, time.Now().UTC().Format(time.RFC3339))
+	`, now); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM login_challenges WHERE expires_at < ?
+	`, now)
 	return err
 }
 
@@ -253,9 +318,22 @@ func (s *Service) HasUsers(ctx context.Context) (bool, error) {
 	return count > 0, nil
 }
 
+// APITokenOptions are the optional restrictions of a new API token.
+type APITokenOptions struct {
+	// LibraryIDs limits the token to these libraries; empty means every
+	// library. The caller validates that the libraries exist.
+	LibraryIDs []string
+}
+
 // CreateAPIToken generates a new API token with the given scopes.
 // Returns the plaintext token (shown once) and the token ID.
 func (s *Service) CreateAPIToken(ctx context.Context, userID, name string, scopes string) (plaintext, id string, err error) {
+	return s.CreateAPITokenWithOptions(ctx, userID, name, scopes, APITokenOptions{})
+}
+
+// CreateAPITokenWithOptions is CreateAPIToken with the optional library
+// restriction.
+func (s *Service) CreateAPITokenWithOptions(ctx context.Context, userID, name, scopes string, opts APITokenOptions) (plaintext, id string, err error) {
 	raw, err := generateToken()
 	if err != nil {
 		return "", "", fmt.Errorf("generating api token: %w", err)
@@ -267,11 +345,12 @@ func (s *Service) CreateAPIToken(ctx context.Context, userID, name string, scope
 
 	id = uuid.New().String()
 	now := time.Now().UTC().Format(time.RFC3339)
+	libraryIDs := strings.Join(ParseLibraryIDs(strings.Join(opts.LibraryIDs, ",")), ",")
 
 	_, err = s.db.ExecContext(ctx, `
-		INSERT INTO api_tokens (id, name, token_hash, scopes, user_id, created_at, status)
-		VALUES (?, ?, ?, ?, ?, ?, 'active')
-	`, id, name, tokenHash, scopes, userID, now)
+		INSERT INTO api_tokens (id, name, token_hash, scopes, library_ids, user_id, created_at, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 'active')
+	`, id, name, tokenHash, scopes, libraryIDs, userID, now)
 	if err != nil {
 		return "", "", fmt.Errorf("inserting api token: %w", err)
 	}
@@ -283,36 +362,49 @@ func (s *Service) CreateAPIToken(ctx context.Context, userID, name string, scope
 // Only tokens with status "active" are considered valid.
 // Updates last_used_at on successful validation.
 func (s *Service) ValidateAPIToken(ctx context.Context, token string) (userID string, scopes string, err error) {
+	grant, err := s.ValidateAPITokenGrant(ctx, token)
+	if err != nil {
+		return "", "", err
+	}
+	return grant.UserID, grant.Scopes, nil
+}
+
+// ValidateAPITokenGrant is ValidateAPIToken returning the whole grant,
+// including the token's library restriction. The auth middleware uses this
+// form; a caller that ignored LibraryIDs would widen a restricted token.
+func (s *Service) ValidateAPITokenGrant(ctx context.Context, token string) (*TokenGrant, error) {
 	hash := sha256.Sum256([]byte(token))
 	tokenHash := hex.EncodeToString(hash[:])
 
-	var status string
-	err = s.db.QueryRowContext(ctx, `
-		SELECT user_id, scopes, status FROM api_tokens WHERE token_hash = ?
-	`, tokenHash).Scan(&userID, &scopes, &status)
+	var grant TokenGrant
+	var status, libraryIDs string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT user_id, scopes, library_ids, status FROM api_tokens WHERE token_hash = ?
+	`, tokenHash).Scan(&grant.UserID, &grant.Scopes, &libraryIDs, &status)
 	if errors.Is(err, sql.ErrNoRows) {
-		return "", "", errors.New("invalid api token")
+		return nil, errors.New("invalid api token")
 	}
 	if err != nil {
-		return "", "", fmt.Errorf("querying api token: %w", err)
+		return nil, fmt.Errorf("querying api token: %w", err)
 	}
 
 	if status != string(TokenStatusActive) {
-		return "", "", fmt.Errorf("api token is %s", status)
+		return nil, fmt.Errorf("api token is %s", status)
 	}
+	grant.LibraryIDs = ParseLibraryIDs(libraryIDs)
 
 	// Best-effort update of last_used_at using the caller's context.
 	now := time.Now().UTC().Format(time.RFC3339)
 	_, _ = s.db.ExecContext(ctx,
 		`UPDATE api_tokens SET last_used_at = ? WHERE token_hash = ?`, now, tokenHash)
 
-	return userID, scopes, nil
+	return &grant, nil
 }
 
 // ListAPITokens returns all tokens for a user (never exposes the hash).
 func (s *Service) ListAPITokens(ctx context.Context, userID string) ([]APIToken, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, name, scopes, user_id,
+		SELECT id, name, scopes, library_ids, user_id,
 		       CASE WHEN status = 'archived' THEN 'revoked' ELSE status END AS status,
 		       created_at, last_used_at, revoked_at
 		FROM api_tokens WHERE user_id = ?
@@ -326,9 +418,11 @@ func (s *Service) ListAPITokens(ctx context.Context, userID string) ([]APIToken,
 	var tokens []APIToken
 	for rows.Next() {
 		var t APIToken
-		if err := rows.Scan(&t.ID, &t.Name, &t.Scopes, &t.UserID, &t.Status, &t.CreatedAt, &t.LastUsedAt, &t.RevokedAt); err != nil {
+		var libraryIDs string
+		if err := rows.Scan(&t.ID, &t.Name, &t.Scopes, &libraryIDs, &t.UserID, &t.Status, &t.CreatedAt, &t.LastUsedAt, &t.RevokedAt); err != nil {
 			return nil, fmt.Errorf("scanning api token: %w", err)
 		}
+		t.LibraryIDs = ParseLibraryIDs(libraryIDs)
 		tokens = append(tokens, t)
 	}
 	return tokens, rows.Err()
@@ -435,16 +529,18 @@ func (s *Service) WriteAuditLog(ctx context.Context, action, tokenID, tokenName,
 // GetAPIToken returns a single token by ID for the given user.
 func (s *Service) GetAPIToken(ctx context.Context, id, userID string) (*APIToken, error) {
 	var t APIToken
+	var libraryIDs string
 	err := s.db.QueryRowContext(ctx, `
-		SELECT id, name, scopes, user_id, status, created_at, last_used_at, revoked_at
+		SELECT id, name, scopes, library_ids, user_id, status, created_at, last_used_at, revoked_at
 		FROM api_tokens WHERE id = ? AND user_id = ?
-	`, id, userID).Scan(&t.ID, &t.Name, &t.Scopes, &t.UserID, &t.Status, &t.CreatedAt, &t.LastUsedAt, &t.RevokedAt)
+	`, id, userID).Scan(&t.ID, &t.Name, &t.Scopes, &libraryIDs, &t.UserID, &t.Status, &t.CreatedAt, &t.LastUsedAt, &t.RevokedAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrTokenNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("getting api token: %w", err)
 	}
+	t.LibraryIDs = ParseLibraryIDs(libraryIDs)
 	return &t, nil
 }
 
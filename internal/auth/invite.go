@@ -39,13 +39,29 @@ type Invite struct {
 	RedeemedBy *string `json:"redeemed_by,omitempty"`
 	RedeemedAt *string `json:"redeemed_at,omitempty"`
 	CreatedAt  string  `json:"created_at"`
+	// LibraryIDs is the library grant the redeemed account starts with;
+	// empty means every library.
+	LibraryIDs []string `json:"library_ids,omitempty"`
+}
+
+// InviteOptions carries the optional parts of an invite.
+type InviteOptions struct {
+	// LibraryIDs restricts the account the invite creates to these
+	// libraries (see SetUserLibraries). Empty leaves it unrestricted.
+	LibraryIDs []string
 }
 
 // CreateInvite generates a new invitation with the given role and expiry duration.
 // The invite code has the format "sw_inv_" followed by 32 hex characters.
 func (s *Service) CreateInvite(ctx context.Context, role, createdBy string, expiresIn time.Duration) (*Invite, error) {
-	if role != "administrator" && role != "operator" {
-		return nil, fmt.Errorf("invalid role %q: must be administrator or operator", role)
+	return s.CreateInviteWithOptions(ctx, role, createdBy, expiresIn, InviteOptions{})
+}
+
+// CreateInviteWithOptions is CreateInvite with a library grant for the account
+// the invite creates. The grant is applied when the invite is redeemed.
+func (s *Service) CreateInviteWithOptions(ctx context.Context, role, createdBy string, expiresIn time.Duration, opts InviteOptions) (*Invite, error) {
+	if !ValidRole(role) {
+		return nil, fmt.Errorf("invalid role %q: must be administrator, operator, or viewer", role)
 	}
 
 	code, err := generateInviteCode()
@@ -58,21 +74,24 @@ func (s *Service) CreateInvite(ctx context.Context, role, createdBy string, expi
 	expiresAt := now.Add(expiresIn).Format(time.RFC3339)
 	createdAt := now.Format(time.RFC3339)
 
+	libraryIDs := ParseLibraryIDs(strings.Join(opts.LibraryIDs, ","))
+
 	_, err = s.db.ExecContext(ctx, `
-		INSERT INTO invites (id, code, role, created_by, expires_at, created_at)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, id, code, role, createdBy, expiresAt, createdAt)
+		INSERT INTO invites (id, code, role, created_by, expires_at, created_at, library_ids)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, id, code, role, createdBy, expiresAt, createdAt, strings.Join(libraryIDs, ","))
 	if err != nil {
 		return nil, fmt.Errorf("creating invite: %w", err)
 	}
 
 	return &Invite{
-		ID:        id,
-		Code:      code,
-		Role:      role,
-		CreatedBy: createdBy,
-		ExpiresAt: expiresAt,
-		CreatedAt: createdAt,
+		ID:         id,
+		Code:       code,
+		Role:       role,
+		CreatedBy:  createdBy,
+		ExpiresAt:  expiresAt,
+		CreatedAt:  createdAt,
+		LibraryIDs: libraryIDs,
 	}, nil
 }
 
@@ -83,12 +102,14 @@ func (s *Service) GetInviteByCode(ctx context.Context, code string) (*Invite, er
 	var redeemedBy sql.NullString
 	var redeemedAt sql.NullString
 
+	var libraryIDs string
+
 	err := s.db.QueryRowContext(ctx, `
-		SELECT id, code, role, created_by, expires_at, redeemed_by, redeemed_at, created_at
+		SELECT id, code, role, created_by, expires_at, redeemed_by, redeemed_at, created_at, library_ids
 		FROM invites WHERE code = ?
 	`, code).Scan(
 		&inv.ID, &inv.Code, &inv.Role, &inv.CreatedBy, &inv.ExpiresAt,
-		&redeemedBy, &redeemedAt, &inv.CreatedAt,
+		&redeemedBy, &redeemedAt, &inv.CreatedAt, &libraryIDs,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrInviteNotFound
@@ -104,6 +125,8 @@ func (s *Service) GetInviteByCode(ctx context.Context, code string) (*Invite, er
 		inv.RedeemedAt = &redeemedAt.String
 	}
 
+	inv.LibraryIDs = ParseLibraryIDs(libraryIDs)
+
 	if inv.RedeemedAt != nil {
 		return nil, ErrInviteRedeemed
 	}
@@ -127,7 +150,7 @@ func (s *Service) ListPendingInvites(ctx context.Context) ([]Invite, error) {
 	now := time.Now().UTC().Format(time.RFC3339)
 
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, code, role, created_by, expires_at, redeemed_by, redeemed_at, created_at
+		SELECT id, code, role, created_by, expires_at, redeemed_by, redeemed_at, created_at, library_ids
 		FROM invites
 		WHERE redeemed_at IS NULL AND expires_at > ?
 		ORDER BY created_at DESC
@@ -142,13 +165,15 @@ func (s *Service) ListPendingInvites(ctx context.Context) ([]Invite, error) {
 		var inv Invite
 		var redeemedBy sql.NullString
 		var redeemedAt sql.NullString
+		var libraryIDs string
 
 		if err := rows.Scan(
 			&inv.ID, &inv.Code, &inv.Role, &inv.CreatedBy, &inv.ExpiresAt,
-			&redeemedBy, &redeemedAt, &inv.CreatedAt,
+			&redeemedBy, &redeemedAt, &inv.CreatedAt, &libraryIDs,
 		); err != nil {
 			return nil, fmt.Errorf("scanning invite: %w", err)
 		}
+		inv.LibraryIDs = ParseLibraryIDs(libraryIDs)
 
 		if redeemedBy.Valid {
 			inv.RedeemedBy = &redeemedBy.String
@@ -252,13 +277,14 @@ func (s *Service) ClaimInviteAndRegister(ctx context.Context, code, username, pa
 		// 1. Look up and validate the invite inside the transaction.
 		var inv Invite
 		var redeemedAt sql.NullString
+		var libraryIDs string
 
 		err := conn.QueryRowContext(ctx, `
-			SELECT id, code, role, created_by, expires_at, redeemed_at, created_at
+			SELECT id, code, role, created_by, expires_at, redeemed_at, created_at, library_ids
 			FROM invites WHERE code = ?
 		`, code).Scan(
 			&inv.ID, &inv.Code, &inv.Role, &inv.CreatedBy, &inv.ExpiresAt,
-			&redeemedAt, &inv.CreatedAt,
+			&redeemedAt, &inv.CreatedAt, &libraryIDs,
 		)
 		if errors.Is(err, sql.ErrNoRows) {
 			return ErrInviteNotFound
@@ -280,7 +306,8 @@ func (s *Service) ClaimInviteAndRegister(ctx context.Context, code, username, pa
 			return ErrInviteExpired
 		}
 
-		// 2. Create the local user inside the transaction.
+		// 2. Create the local user inside the transaction, with the
+		// invite's library grant.
 		userID = uuid.New().String()
 		now := time.Now().UTC().Format(time.RFC3339)
 
@@ -291,9 +318,9 @@ func (s *Service) ClaimInviteAndRegister(ctx context.Context, code, username, pa
 
 		_, err = conn.ExecContext(ctx, `
 			INSERT INTO users (id, username, display_name, password_hash, role, auth_provider, provider_id,
-			                   is_active, invited_by, created_at, updated_at)
-			VALUES (?, ?, ?, ?, ?, 'local', '', 1, ?, ?, ?)
-		`, userID, username, displayName, string(hash), inv.Role, invitedByVal, now, now)
+			                   is_active, invited_by, created_at, updated_at, library_ids)
+			VALUES (?, ?, ?, ?, ?, 'local', '', 1, ?, ?, ?, ?)
+		`, userID, username, displayName, string(hash), inv.Role, invitedByVal, now, now, libraryIDs)
 		if err != nil {
 			errLower := strings.ToLower(err.Error())
 			if strings.Contains(errLower, "unique constraint") && strings.Contains(errLower, "username") {
@@ -346,13 +373,14 @@ func (s *Service) ClaimInviteAndRegisterFederated(ctx context.Context, code stri
 	err := s.withImmediateTx(ctx, func(conn *sql.Conn) error {
 		var inv Invite
 		var redeemedAt sql.NullString
+		var libraryIDs string
 
 		err := conn.QueryRowContext(ctx, `
-			SELECT id, code, role, created_by, expires_at, redeemed_at, created_at
+			SELECT id, code, role, created_by, expires_at, redeemed_at, created_at, library_ids
 			FROM invites WHERE code = ?
 		`, code).Scan(
 			&inv.ID, &inv.Code, &inv.Role, &inv.CreatedBy, &inv.ExpiresAt,
-			&redeemedAt, &inv.CreatedAt,
+			&redeemedAt, &inv.CreatedAt, &libraryIDs,
 		)
 		if errors.Is(err, sql.ErrNoRows) {
 			return ErrInviteNotFound
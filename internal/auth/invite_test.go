@@ -271,3 +271,37 @@ func TestRevokeInvite_NotFound(t *testing.T) {
 		t.Errorf("RevokeInvite nonexistent = %v, want ErrInviteNotFound", err)
 	}
 }
+
+func TestClaimInviteAndRegister_LibraryGrant(t *testing.T) {
+	t.Parallel()
+	svc, adminID := setupInviteTest(t)
+	ctx := context.Background()
+
+	inv, err := svc.CreateInviteWithOptions(ctx, "viewer", adminID, 24*time.Hour,
+		InviteOptions{LibraryIDs: []string{"lib-a", "lib-a", " lib-b "}})
+	if err != nil {
+		t.Fatalf("CreateInviteWithOptions: %v", err)
+	}
+	if strings.Join(inv.LibraryIDs, ",") != "lib-a,lib-b" {
+		t.Errorf("invite LibraryIDs = %v, want [lib-a lib-b]", inv.LibraryIDs)
+	}
+
+	pending, err := svc.ListPendingInvites(ctx)
+	if err != nil {
+		t.Fatalf("ListPendingInvites: %v", err)
+	}
+	if len(pending) != 1 || strings.Join(pending[0].LibraryIDs, ",") != "lib-a,lib-b" {
+		t.Errorf("pending invites = %+v", pending)
+	}
+
+	user, err := svc.ClaimInviteAndRegister(ctx, inv.Code, "family", "password123", "Family")
+	if err != nil {
+		t.Fatalf("ClaimInviteAndRegister: %v", err)
+	}
+	if user.Role != "viewer" {
+		t.Errorf("Role = %q, want viewer", user.Role)
+	}
+	if strings.Join(user.LibraryIDs, ",") != "lib-a,lib-b" {
+		t.Errorf("user LibraryIDs = %v, want [lib-a lib-b]", user.LibraryIDs)
+	}
+}
@@ -0,0 +1,320 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sydlexius/stillwater/internal/dbutil"
+)
+
+// Lockout scopes: a username as typed at the login form, or a client address.
+const (
+	LockoutScopeUser = "user"
+	LockoutScopeIP   = "ip"
+)
+
+// lockedUntilColumn selects a user's active lockout expiry (NULL when the
+// account is not locked) for the users column lists in user.go. The key is
+// compared case-sensitively, like the username lookup at login.
+const lockedUntilColumn = `(SELECT locked_until FROM login_failures
+		       WHERE scope = 'user' AND key = users.username
+		       AND locked_until > strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))`
+
+// LockoutPolicy configures brute-force protection for password sign-in.
+// Failures are counted per username and per client address; once a counter
+// reaches its threshold, every further failure locks that username or address
+// for BaseDuration, doubled for each failure past the threshold and capped at
+// MaxDuration. A counter starts over once Window has passed since its last
+// failure (or since its lockout ended). A zero threshold turns that counter
+// off.
+type LockoutPolicy struct {
+	UserThreshold int
+	IPThreshold   int
+	BaseDuration  time.Duration
+	MaxDuration   time.Duration
+	Window        time.Duration
+}
+
+// DefaultLockoutPolicy returns the policy a Service starts with: five
+// failures per username or twenty per address, a one-minute first lockout,
+// at most an hour, and a fifteen-minute window.
+func DefaultLockoutPolicy() LockoutPolicy {
+	return LockoutPolicy{
+		UserThreshold: 5,
+		IPThreshold:   20,
+		BaseDuration:  time.Minute,
+		MaxDuration:   time.Hour,
+		Window:        15 * time.Minute,
+	}
+}
+
+// lockDuration is how long the failures-th failure locks for, given the
+// threshold it was counted against.
+func (p LockoutPolicy) lockDuration(failures, threshold int) time.Duration {
+	d := p.BaseDuration
+	for i := threshold; i < failures && d < p.MaxDuration; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxDuration)
+}
+
+// Lockout describes a username or address that has just been locked.
+type Lockout struct {
+	Scope string
+	// Key is the username or client address.
+	Key string
+	// UserID is the account locked, when Scope is LockoutScopeUser and an
+	// account by that name exists.
+	UserID   string
+	Failures int
+	Until    time.Time
+	// IP is the client address of the failure that caused the lockout.
+	IP string
+}
+
+// Message is a one-line description of the lockout for logs and
+// notifications.
+func (l Lockout) Message() string {
+	what := "username " + l.Key
+	if l.Scope == LockoutScopeIP {
+		what = "address " + l.Key
+	}
+	msg := fmt.Sprintf("Sign-in for %s locked until %s after %d failed attempts",
+		what, l.Until.UTC().Format(time.RFC3339), l.Failures)
+	if l.Scope == LockoutScopeUser && l.IP != "" {
+		msg += " (last from " + l.IP + ")"
+	}
+	return msg
+}
+
+// LockedError is returned by Login, CompleteSecondFactor and CheckLogin while
+// the username or the client address is locked out. The password, or code,
+// is not checked.
+type LockedError struct {
+	Scope string
+	Until time.Time
+}
+
+func (e *LockedError) Error() string {
+	return "too many failed sign-in attempts"
+}
+
+// RetryAfter is how long until the lockout ends, rounded up to a second.
+func (e *LockedError) RetryAfter() time.Duration {
+	d := time.Until(e.Until)
+	if d <= 0 {
+		return time.Second
+	}
+	return d.Truncate(time.Second) + time.Second
+}
+
+// WithLockoutPolicy replaces the brute-force protection policy and returns
+// the service for chaining.
+func (s *Service) WithLockoutPolicy(p LockoutPolicy) *Service {
+	s.lockout = p
+	return s
+}
+
+// WithLockoutNotifier sets a function called once for each new lockout, after
+// it is stored, and returns the service for chaining. It runs on the login
+// request, so it must not block.
+func (s *Service) WithLockoutNotifier(fn func(Lockout)) *Service {
+	s.onLockout = fn
+	return s
+}
+
+// lockoutKeys returns the counters a sign-in for username touches: the
+// username, and the client address from the context (see WithClientInfo).
+// Counters whose threshold is zero, and an empty username or address, are
+// left out.
+func (s *Service) lockoutKeys(ctx context.Context, username string) [][2]string {
+	var keys [][2]string
+	if s.lockout.UserThreshold > 0 && username != "" {
+		keys = append(keys, [2]string{LockoutScopeUser, username})
+	}
+	if ip := ClientInfoFromContext(ctx).IP; s.lockout.IPThreshold > 0 && ip != "" {
+		keys = append(keys, [2]string{LockoutScopeIP, ip})
+	}
+	return keys
+}
+
+func (s *Service) lockoutThreshold(scope string) int {
+	if scope == LockoutScopeIP {
+		return s.lockout.IPThreshold
+	}
+	return s.lockout.UserThreshold
+}
+
+// CheckLogin returns a *LockedError when username or the client address is
+// locked out. Call it before checking a password.
+func (s *Service) CheckLogin(ctx context.Context, username string) error {
+	now := time.Now().UTC()
+	var locked *LockedError
+	for _, k := range s.lockoutKeys(ctx, username) {
+		var until sql.NullString
+		err := s.db.QueryRowContext(ctx, `
+			SELECT locked_until FROM login_failures WHERE scope = ? AND key = ?
+		`, k[0], k[1]).Scan(&until)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("checking login lockout: %w", err)
+		}
+		t, ok := dbutil.ParseTimeOK(until.String)
+		if !until.Valid || !ok || !t.After(now) {
+			continue
+		}
+		if locked == nil || t.After(locked.Until) {
+			locked = &LockedError{Scope: k[0], Until: t}
+		}
+	}
+	if locked != nil {
+		return locked
+	}
+	return nil
+}
+
+// RecordLoginFailure counts a failed password, or authentication code, for
+// username against the username and the client address, locking either one
+// that reaches its threshold. Each new lockout is written to the audit log
+// and passed to the lockout notifier.
+func (s *Service) RecordLoginFailure(ctx context.Context, username string) error {
+	now := time.Now().UTC()
+	ip := ClientInfoFromContext(ctx).IP
+	var errs []error
+	for _, k := range s.lockoutKeys(ctx, username) {
+		lockout, err := s.countFailure(ctx, k[0], k[1], now)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if lockout == nil {
+			continue
+		}
+		lockout.IP = ip
+		if s.onLockout != nil {
+			s.onLockout(*lockout)
+		}
+		if err := s.auditLockout(ctx, *lockout); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// countFailure adds one failure to a counter and locks it when it is at or
+// past its threshold. It returns the new lockout, or nil.
+func (s *Service) countFailure(ctx context.Context, scope, key string, now time.Time) (*Lockout, error) {
+	// A counter whose last failure, or lockout, ended before the window
+	// starts over. Measuring from the end of the lockout keeps the doubling
+	// going for an attacker who waits out each lockout.
+	cutoff := now.Add(-s.lockout.Window).Format(time.RFC3339)
+	var failures int
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO login_failures (scope, key, failures, last_failure_at)
+		VALUES (?, ?, 1, ?)
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failures = CASE WHEN max(last_failure_at, COALESCE(locked_until, '')) < ?
+			                THEN 1 ELSE failures + 1 END,
+			locked_until = CASE WHEN max(last_failure_at, COALESCE(locked_until, '')) < ?
+			                    THEN NULL ELSE locked_until END,
+			last_failure_at = excluded.last_failure_at
+		RETURNING failures
+	`, scope, key, now.Format(time.RFC3339), cutoff, cutoff).Scan(&failures)
+	if err != nil {
+		return nil, fmt.Errorf("counting login failure: %w", err)
+	}
+
+	threshold := s.lockoutThreshold(scope)
+	if failures < threshold {
+		return nil, nil
+	}
+	until := now.Add(s.lockout.lockDuration(failures, threshold))
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE login_failures SET locked_until = ? WHERE scope = ? AND key = ?
+	`, until.Format(time.RFC3339), scope, key); err != nil {
+		return nil, fmt.Errorf("locking %s login: %w", scope, err)
+	}
+
+	lockout := &Lockout{Scope: scope, Key: key, Failures: failures, Until: until}
+	if scope == LockoutScopeUser {
+		err := s.db.QueryRowContext(ctx, `SELECT id FROM users WHERE username = ?`, key).Scan(&lockout.UserID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("looking up locked user: %w", err)
+		}
+	}
+	return lockout, nil
+}
+
+// auditLockout records a lockout. An address, or a username no account
+// holds, is recorded with no user.
+func (s *Service) auditLockout(ctx context.Context, l Lockout) error {
+	var userID any
+	if l.UserID != "" {
+		userID = l.UserID
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO audit_log (id, action, token_id, token_name, user_id, detail, target_user_id, created_at)
+		VALUES (?, 'login.lockout', NULL, '', ?, ?, ?, ?)
+	`, uuid.New().String(), userID, l.Message(), userID, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("writing lockout audit entry: %w", err)
+	}
+	return nil
+}
+
+// RecordLoginSuccess clears the failure count of username after a successful
+// sign-in: a correct password, and a correct code when a second factor is
+// asked for. The address counter is kept: one valid account must not let an
+// address go on guessing the passwords of others.
+func (s *Service) RecordLoginSuccess(ctx context.Context, username string) error {
+	if _, err := s.db.ExecContext(ctx, `
+		DELETE FROM login_failures WHERE scope = 'user' AND key = ?
+	`, username); err != nil {
+		return fmt.Errorf("clearing login failures: %w", err)
+	}
+	return nil
+}
+
+// UnlockUser lifts the lockout of userID and clears its failure count. The
+// unlock is audited as done by actorID; an empty actorID (the command line)
+// records none. Returns an error wrapping sql.ErrNoRows if the user does not
+// exist. Unlocking an account that is not locked is not an error.
+func (s *Service) UnlockUser(ctx context.Context, userID, actorID string) error {
+	var username string
+	err := s.db.QueryRowContext(ctx, `SELECT username FROM users WHERE id = ?`, userID).Scan(&username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("user not found: %w", sql.ErrNoRows)
+	}
+	if err != nil {
+		return fmt.Errorf("looking up user: %w", err)
+	}
+	res, err := s.db.ExecContext(ctx, `
+		DELETE FROM login_failures WHERE scope = 'user' AND key = ?
+	`, username)
+	if err != nil {
+		return fmt.Errorf("clearing login failures: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+
+	auditUser, actor := userID, any(nil)
+	if actorID != "" {
+		auditUser, actor = actorID, actorID
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO audit_log (id, action, token_id, token_name, user_id, detail, actor_user_id, target_user_id, created_at)
+		VALUES (?, 'login.unlock', NULL, '', ?, ?, ?, ?, ?)
+	`, uuid.New().String(), auditUser, "unlocked sign-in for "+username, actor, userID,
+		time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("writing unlock audit entry: %w", err)
+	}
+	return nil
+}
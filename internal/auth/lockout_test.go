@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fromIP returns a context whose client address is ip.
+func fromIP(ip string) context.Context {
+	return WithClientInfo(context.Background(), ClientInfo{IP: ip})
+}
+
+func TestLogin_LocksUsernameAfterThreshold(t *testing.T) {
+	t.Parallel()
+	svc, adminID := setupTwoFactorService(t)
+	var notified []Lockout
+	svc.WithLockoutPolicy(LockoutPolicy{UserThreshold: 3, BaseDuration: time.Minute, MaxDuration: time.Hour, Window: 15 * time.Minute}).
+		WithLockoutNotifier(func(l Lockout) { notified = append(notified, l) })
+
+	for i := range 3 {
+		// Each attempt from a different address: the username counter is
+		// what locks.
+		ctx := fromIP(fmt.Sprintf("192.0.2.%d", i+1))
+		if _, err := svc.Login(ctx, "admin", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalidCredentials", i+1, err)
+		}
+	}
+
+	_, err := svc.Login(fromIP("198.51.100.7"), "admin", "password123")
+	var locked *LockedError
+	if !errors.As(err, &locked) || locked.Scope != LockoutScopeUser {
+		t.Fatalf("login with the right password while locked: err = %v, want a user *LockedError", err)
+	}
+	if ra := locked.RetryAfter(); ra <= 0 || ra > time.Minute+time.Second {
+		t.Errorf("RetryAfter = %v, want about a minute", ra)
+	}
+
+	if len(notified) != 1 || notified[0].UserID != adminID || notified[0].Failures != 3 {
+		t.Errorf("notified = %+v, want one lockout of the admin after 3 failures", notified)
+	}
+	var count int
+	if err := svc.db.QueryRow(`SELECT COUNT(*) FROM audit_log WHERE action = 'login.lockout' AND target_user_id = ?`, adminID).Scan(&count); err != nil {
+		t.Fatalf("reading audit log: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("got %d lockout audit entries, want 1", count)
+	}
+
+	user, err := svc.GetUserByID(context.Background(), adminID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if user.LockedUntil == nil {
+		t.Error("LockedUntil is nil for a locked account")
+	}
+}
+
+func TestLogin_LocksAddressAcrossUsernames(t *testing.T) {
+	t.Parallel()
+	svc, _ := setupTwoFactorService(t)
+	svc.WithLockoutPolicy(LockoutPolicy{UserThreshold: 10, IPThreshold: 3, BaseDuration: time.Minute, MaxDuration: time.Hour, Window: 15 * time.Minute})
+	ctx := fromIP("203.0.113.5")
+
+	for _, name := range []string{"alice", "bob", "carol"} {
+		if _, err := svc.Login(ctx, name, "guess"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("%s: err = %v, want ErrInvalidCredentials", name, err)
+		}
+	}
+
+	var locked *LockedError
+	if _, err := svc.Login(ctx, "admin", "password123"); !errors.As(err, &locked) || locked.Scope != LockoutScopeIP {
+		t.Fatalf("err = %v, want an address *LockedError", err)
+	}
+	if _, err := svc.Login(fromIP("203.0.113.6"), "admin", "password123"); err != nil {
+		t.Errorf("login from another address: %v", err)
+	}
+
+	// An address lockout has no account behind it.
+	var count int
+	if err := svc.db.QueryRow(`SELECT COUNT(*) FROM audit_log WHERE action = 'login.lockout' AND user_id IS NULL`).Scan(&count); err != nil {
+		t.Fatalf("reading audit log: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("got %d address lockout audit entries, want 1", count)
+	}
+}
+
+func TestLogin_SuccessClearsUsernameFailures(t *testing.T) {
+	t.Parallel()
+	svc, _ := setupTwoFactorService(t)
+	svc.WithLockoutPolicy(LockoutPolicy{UserThreshold: 3, BaseDuration: time.Minute, MaxDuration: time.Hour, Window: 15 * time.Minute})
+	ctx := context.Background()
+
+	for range 2 {
+		_, _ = svc.Login(ctx, "admin", "wrong")
+	}
+	if _, err := svc.Login(ctx, "admin", "password123"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	for range 2 {
+		_, _ = svc.Login(ctx, "admin", "wrong")
+	}
+	if err := svc.CheckLogin(ctx, "admin"); err != nil {
+		t.Errorf("CheckLogin after a success reset the count: %v", err)
+	}
+}
+
+func TestRecordLoginFailure_BackoffDoublesAndWindowResets(t *testing.T) {
+	t.Parallel()
+	svc, _ := setupTwoFactorService(t)
+	svc.WithLockoutPolicy(LockoutPolicy{UserThreshold: 2, BaseDuration: time.Minute, MaxDuration: 3 * time.Minute, Window: 15 * time.Minute})
+	ctx := context.Background()
+
+	lockedFor := func() time.Duration {
+		t.Helper()
+		var until string
+		if err := svc.db.QueryRow(`SELECT locked_until FROM login_failures WHERE scope = 'user' AND key = 'ghost'`).Scan(&until); err != nil {
+			t.Fatalf("reading locked_until: %v", err)
+		}
+		parsed, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			t.Fatalf("parsing locked_until %q: %v", until, err)
+		}
+		return time.Until(parsed).Round(time.Minute)
+	}
+
+	// A username nobody holds is throttled like a real one.
+	for range 2 {
+		if err := svc.RecordLoginFailure(ctx, "ghost"); err != nil {
+			t.Fatalf("RecordLoginFailure: %v", err)
+		}
+	}
+	if got := lockedFor(); got != time.Minute {
+		t.Errorf("first lockout = %v, want 1m", got)
+	}
+	for _, want := range []time.Duration{2 * time.Minute, 3 * time.Minute, 3 * time.Minute} {
+		if err := svc.RecordLoginFailure(ctx, "ghost"); err != nil {
+			t.Fatalf("RecordLoginFailure: %v", err)
+		}
+		if got := lockedFor(); got != want {
+			t.Errorf("lockout = %v, want %v", got, want)
+		}
+	}
+
+	// Once the window has passed since the lockout ended, the count starts over.
+	old := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	if _, err := svc.db.Exec(`UPDATE login_failures SET last_failure_at = ?, locked_until = ?`, old, old); err != nil {
+		t.Fatalf("aging the counter: %v", err)
+	}
+	if err := svc.RecordLoginFailure(ctx, "ghost"); err != nil {
+		t.Fatalf("RecordLoginFailure: %v", err)
+	}
+	var failures int
+	if err := svc.db.QueryRow(`SELECT failures FROM login_failures WHERE key = 'ghost'`).Scan(&failures); err != nil {
+		t.Fatalf("reading failures: %v", err)
+	}
+	if failures != 1 {
+		t.Errorf("failures = %d after the window, want 1", failures)
+	}
+	if err := svc.CheckLogin(ctx, "ghost"); err != nil {
+		t.Errorf("CheckLogin = %v, want no lockout", err)
+	}
+}
+
+func TestUnlockUser(t *testing.T) {
+	t.Parallel()
+	svc, adminID := setupTwoFactorService(t)
+	other, err := svc.CreateLocalUser(context.Background(), "carol", "password123", "Carol", "operator", "")
+	if err != nil {
+		t.Fatalf("CreateLocalUser: %v", err)
+	}
+	svc.WithLockoutPolicy(LockoutPolicy{UserThreshold: 1, BaseDuration: time.Minute, MaxDuration: time.Hour, Window: 15 * time.Minute})
+	ctx := context.Background()
+
+	_, _ = svc.Login(ctx, "carol", "wrong")
+	if _, err := svc.Login(ctx, "carol", "password123"); err == nil {
+		t.Fatal("login succeeded while locked")
+	}
+	if err := svc.UnlockUser(ctx, other.ID, adminID); err != nil {
+		t.Fatalf("UnlockUser: %v", err)
+	}
+	if _, err := svc.Login(ctx, "carol", "password123"); err != nil {
+		t.Errorf("login after unlock: %v", err)
+	}
+
+	var actor string
+	if err := svc.db.QueryRow(`SELECT actor_user_id FROM audit_log WHERE action = 'login.unlock' AND target_user_id = ?`, other.ID).Scan(&actor); err != nil {
+		t.Fatalf("reading unlock audit entry: %v", err)
+	}
+	if actor != adminID {
+		t.Errorf("unlock actor = %q, want %q", actor, adminID)
+	}
+
+	if err := svc.UnlockUser(ctx, "missing", adminID); err == nil {
+		t.Error("UnlockUser of an unknown user succeeded")
+	}
+}
@@ -11,7 +11,7 @@ var ErrInvalidCredentials = errors.New("invalid credentials")
 
 // Authenticator handles authentication for a specific provider type.
 type Authenticator interface {
-	// Type returns the provider identifier ("local", "emby", "jellyfin", "oidc").
+	// Type returns the provider identifier ("local", "emby", "jellyfin", "oidc", "forward").
 	Type() string
 
 	// Authenticate validates credentials and returns a provider-specific identity.
@@ -39,9 +39,9 @@ type Credentials struct {
 type Identity struct {
 	ProviderID   string            // Stable user ID from the provider
 	DisplayName  string            // Human-readable name
-	ProviderType string            // "local", "emby", "jellyfin", "oidc"
+	ProviderType string            // "local", "emby", "jellyfin", "oidc", "forward"
 	IsAdmin      bool              // Whether user is admin on the provider
-	Groups       []string          // OIDC group claims
+	Groups       []string          // OIDC group claims or forward-auth groups header
 	RawToken     string            // Provider access token (for Emby/Jellyfin connection sync)
 	Extra        map[string]string // Provider-specific metadata
 }
@@ -18,7 +18,7 @@ type EmbyProvider struct {
 	serverURL     string
 	autoProvision bool
 	guardRail     string // "admin" or "any_user"
-	defaultRole   string // "operator" or "administrator"
+	defaultRole   string // "operator", "viewer" or "administrator"
 	client        *http.Client
 }
 
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ForwardAuthProvider trusts the identity an SSO reverse proxy (Authelia,
+// Authentik, oauth2-proxy, ...) has already established and passes on in a
+// request header. The header is believed only when the request arrives
+// directly from one of the trusted proxy ranges; from anywhere else it is
+// ignored, since any client can set it.
+//
+// The username in the header is the stable provider ID. Groups come from an
+// optional second header and drive provisioning and role mapping the same way
+// OIDC group claims do.
+type ForwardAuthProvider struct {
+	userHeader     string
+	groupsHeader   string
+	trustedProxies []netip.Prefix
+	adminGroups    []string // Groups that map to "administrator" role
+	userGroups     []string // Groups allowed to log in (empty = any proxied user)
+	defaultRole    string   // Fallback role when no admin group matches
+	autoProv       bool     // Whether to auto-provision unknown users
+}
+
+// ForwardAuthConfig holds the configuration for creating a forward-auth provider.
+type ForwardAuthConfig struct {
+	UserHeader     string
+	GroupsHeader   string
+	TrustedProxies []string // CIDR ranges; entries that fail to parse are skipped
+	AdminGroups    []string
+	UserGroups     []string
+	DefaultRole    string
+	AutoProvision  bool
+}
+
+// NewForwardAuthProvider creates a forward-auth authenticator. The user header
+// is required, and so is at least one trusted proxy: without one the header
+// could never be believed.
+func NewForwardAuthProvider(cfg ForwardAuthConfig) (*ForwardAuthProvider, error) {
+	if strings.TrimSpace(cfg.UserHeader) == "" {
+		return nil, fmt.Errorf("forward auth: user header is required")
+	}
+	prefixes := make([]netip.Prefix, 0, len(cfg.TrustedProxies))
+	for _, p := range cfg.TrustedProxies {
+		if prefix, err := netip.ParsePrefix(strings.TrimSpace(p)); err == nil {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) == 0 {
+		return nil, fmt.Errorf("forward auth: at least one trusted proxy range is required")
+	}
+	role := cfg.DefaultRole
+	if role == "" {
+		role = "operator"
+	}
+	return &ForwardAuthProvider{
+		userHeader:     http.CanonicalHeaderKey(strings.TrimSpace(cfg.UserHeader)),
+		groupsHeader:   http.CanonicalHeaderKey(strings.TrimSpace(cfg.GroupsHeader)),
+		trustedProxies: prefixes,
+		adminGroups:    cfg.AdminGroups,
+		userGroups:     cfg.UserGroups,
+		defaultRole:    role,
+		autoProv:       cfg.AutoProvision,
+	}, nil
+}
+
+// Type returns "forward".
+func (p *ForwardAuthProvider) Type() string { return "forward" }
+
+// Credentials reads the forward-auth headers from req. The direct peer
+// address travels with them so Authenticate can refuse headers that did not
+// come from a trusted proxy.
+func (p *ForwardAuthProvider) Credentials(req *http.Request) Credentials {
+	creds := Credentials{
+		Username: strings.TrimSpace(req.Header.Get(p.userHeader)),
+		Extra:    map[string]string{"remote_addr": req.RemoteAddr},
+	}
+	if p.groupsHeader != "" {
+		creds.Extra["groups"] = req.Header.Get(p.groupsHeader)
+	}
+	return creds
+}
+
+// HasIdentity reports whether req carries the user header from a trusted
+// proxy. The login page uses it to decide whether to sign the visitor in
+// without showing the form.
+func (p *ForwardAuthProvider) HasIdentity(req *http.Request) bool {
+	return strings.TrimSpace(req.Header.Get(p.userHeader)) != "" && p.isTrustedPeer(req.RemoteAddr)
+}
+
+// Authenticate accepts the username the proxy asserted. Credentials must come
+// from Credentials so that Extra["remote_addr"] holds the direct peer: a
+// request from outside the trusted proxy ranges is refused with
+// ErrInvalidCredentials whatever its headers say.
+func (p *ForwardAuthProvider) Authenticate(_ context.Context, creds Credentials) (*Identity, error) {
+	if !p.isTrustedPeer(creds.Extra["remote_addr"]) {
+		return nil, fmt.Errorf("forward auth: %w: request did not come from a trusted proxy", ErrInvalidCredentials)
+	}
+	if creds.Username == "" {
+		return nil, fmt.Errorf("forward auth: %w: %s header is missing", ErrInvalidCredentials, p.userHeader)
+	}
+	groups := splitGroups(creds.Extra["groups"])
+	return &Identity{
+		ProviderID:   creds.Username,
+		DisplayName:  creds.Username,
+		ProviderType: "forward",
+		IsAdmin:      matchesAnyGroup(groups, p.adminGroups),
+		Groups:       groups,
+	}, nil
+}
+
+// CanAutoProvision checks whether the identity meets the configured guard
+// rails for automatic user creation. If userGroups is empty, any user the
+// proxy authenticated is allowed; otherwise the user must be a member of at
+// least one of them.
+func (p *ForwardAuthProvider) CanAutoProvision(identity *Identity) bool {
+	if identity == nil || !p.autoProv {
+		return false
+	}
+	if len(p.userGroups) == 0 {
+		return true
+	}
+	return matchesAnyGroup(identity.Groups, p.userGroups)
+}
+
+// MapRole returns "administrator" for members of an admin group and the
+// default role otherwise.
+func (p *ForwardAuthProvider) MapRole(identity *Identity) string {
+	if identity != nil && matchesAnyGroup(identity.Groups, p.adminGroups) {
+		return "administrator"
+	}
+	return p.defaultRole
+}
+
+// isTrustedPeer reports whether remoteAddr (host:port or a bare address)
+// falls inside a trusted proxy range.
+func (p *ForwardAuthProvider) isTrustedPeer(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range p.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitGroups parses a groups header. Authelia separates groups with commas
+// and Authentik with pipes, so both are accepted.
+func splitGroups(header string) []string {
+	fields := strings.FieldsFunc(header, func(r rune) bool { return r == ',' || r == '|' })
+	groups := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if g := strings.TrimSpace(f); g != "" {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// matchesAnyGroup reports whether any of groups matches any of want,
+// case-insensitively.
+func matchesAnyGroup(groups, want []string) bool {
+	for _, w := range want {
+		for _, g := range groups {
+			if strings.EqualFold(g, w) {
+				return true
+			}
+		}
+	}
+	return false
+}
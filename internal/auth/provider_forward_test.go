@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func newTestForwardProvider(t *testing.T, cfg ForwardAuthConfig) *ForwardAuthProvider {
+	t.Helper()
+	if cfg.UserHeader == "" {
+		cfg.UserHeader = "Remote-User"
+	}
+	if cfg.TrustedProxies == nil {
+		cfg.TrustedProxies = []string{"10.0.0.0/8"}
+	}
+	p, err := NewForwardAuthProvider(cfg)
+	if err != nil {
+		t.Fatalf("NewForwardAuthProvider: %v", err)
+	}
+	return p
+}
+
+func TestNewForwardAuthProvider_RequiresHeaderAndProxy(t *testing.T) {
+	t.Parallel()
+	if _, err := NewForwardAuthProvider(ForwardAuthConfig{TrustedProxies: []string{"10.0.0.0/8"}}); err == nil {
+		t.Error("expected error without a user header")
+	}
+	if _, err := NewForwardAuthProvider(ForwardAuthConfig{UserHeader: "Remote-User"}); err == nil {
+		t.Error("expected error without trusted proxies")
+	}
+	if _, err := NewForwardAuthProvider(ForwardAuthConfig{UserHeader: "Remote-User", TrustedProxies: []string{"not-a-cidr"}}); err == nil {
+		t.Error("expected error when no trusted proxy parses")
+	}
+}
+
+func TestForwardAuthProviderType(t *testing.T) {
+	t.Parallel()
+	p := newTestForwardProvider(t, ForwardAuthConfig{})
+	if got := p.Type(); got != "forward" {
+		t.Errorf("Type() = %q, want %q", got, "forward")
+	}
+	if p.defaultRole != "operator" {
+		t.Errorf("default role = %q, want %q", p.defaultRole, "operator")
+	}
+}
+
+func TestForwardAuthAuthenticate(t *testing.T) {
+	t.Parallel()
+	p := newTestForwardProvider(t, ForwardAuthConfig{
+		GroupsHeader: "Remote-Groups",
+		AdminGroups:  []string{"Admins"},
+	})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		user       string
+		groups     string
+		wantErr    bool
+		wantAdmin  bool
+		wantGroups []string
+	}{
+		{name: "trusted proxy", remoteAddr: "10.1.2.3:5000", user: "alice", groups: "users, admins", wantAdmin: true, wantGroups: []string{"users", "admins"}},
+		{name: "pipe separated groups", remoteAddr: "10.1.2.3:5000", user: "bob", groups: "users|media", wantGroups: []string{"users", "media"}},
+		{name: "ipv4-mapped peer", remoteAddr: "[::ffff:10.1.2.3]:5000", user: "carol", wantGroups: []string{}},
+		{name: "untrusted peer", remoteAddr: "192.168.1.5:5000", user: "alice", wantErr: true},
+		{name: "missing header", remoteAddr: "10.1.2.3:5000", user: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.user != "" {
+				req.Header.Set("Remote-User", tt.user)
+			}
+			if tt.groups != "" {
+				req.Header.Set("Remote-Groups", tt.groups)
+			}
+
+			if got, want := p.HasIdentity(req), !tt.wantErr; got != want {
+				t.Errorf("HasIdentity() = %v, want %v", got, want)
+			}
+			identity, err := p.Authenticate(context.Background(), p.Credentials(req))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCredentials) {
+					t.Fatalf("Authenticate() error = %v, want ErrInvalidCredentials", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if identity.ProviderID != tt.user || identity.DisplayName != tt.user || identity.ProviderType != "forward" {
+				t.Errorf("identity = %+v, want provider id and name %q", identity, tt.user)
+			}
+			if identity.IsAdmin != tt.wantAdmin {
+				t.Errorf("IsAdmin = %v, want %v", identity.IsAdmin, tt.wantAdmin)
+			}
+			if !slices.Equal(identity.Groups, tt.wantGroups) {
+				t.Errorf("Groups = %v, want %v", identity.Groups, tt.wantGroups)
+			}
+		})
+	}
+}
+
+func TestForwardAuthMapRoleAndAutoProvision(t *testing.T) {
+	t.Parallel()
+	p := newTestForwardProvider(t, ForwardAuthConfig{
+		AdminGroups:   []string{"stillwater-admins"},
+		UserGroups:    []string{"stillwater-users", "stillwater-admins"},
+		DefaultRole:   "viewer",
+		AutoProvision: true,
+	})
+
+	admin := &Identity{Groups: []string{"Stillwater-Admins"}}
+	user := &Identity{Groups: []string{"stillwater-users"}}
+	outsider := &Identity{Groups: []string{"other"}}
+
+	if got := p.MapRole(admin); got != "administrator" {
+		t.Errorf("MapRole(admin) = %q, want administrator", got)
+	}
+	if got := p.MapRole(user); got != "viewer" {
+		t.Errorf("MapRole(user) = %q, want viewer", got)
+	}
+	if got := p.MapRole(nil); got != "viewer" {
+		t.Errorf("MapRole(nil) = %q, want viewer", got)
+	}
+	if !p.CanAutoProvision(admin) || !p.CanAutoProvision(user) {
+		t.Error("expected members of user groups to be provisionable")
+	}
+	if p.CanAutoProvision(outsider) {
+		t.Error("expected a user outside the user groups to be refused")
+	}
+	if p.CanAutoProvision(nil) {
+		t.Error("expected nil identity to be refused")
+	}
+
+	off := newTestForwardProvider(t, ForwardAuthConfig{})
+	if off.CanAutoProvision(user) {
+		t.Error("expected auto-provision to be off by default")
+	}
+}
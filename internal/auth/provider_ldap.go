@@ -0,0 +1,329 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// LDAPProvider authenticates users against an LDAP directory (OpenLDAP,
+// LLDAP, Active Directory, ...). It binds with a service account, searches
+// for the user by filter, verifies the password by binding as the user's DN,
+// and reads group membership to drive provisioning and role mapping the same
+// way OIDC group claims do.
+//
+// The value of the username attribute (uid by default) is the stable
+// provider ID, so moving an entry to another OU keeps the Stillwater account.
+type LDAPProvider struct {
+	url          string
+	startTLS     bool
+	tlsConfig    *tls.Config
+	bindDN       string
+	bindPassword string
+	baseDN       string
+	userFilter   string // Contains {username}
+	usernameAttr string
+	displayAttr  string
+	groupBaseDN  string
+	groupFilter  string   // Contains {dn} and/or {username}; empty = read memberOf
+	adminGroups  []string // Groups that map to "administrator" role
+	userGroups   []string // Groups allowed to log in (empty = any directory user)
+	defaultRole  string   // Fallback role when no admin group matches
+	autoProv     bool     // Whether to auto-provision unknown users
+	timeout      time.Duration
+}
+
+// LDAPConfig holds the configuration for creating an LDAP provider.
+type LDAPConfig struct {
+	URL                   string // ldap://host:389 or ldaps://host:636
+	StartTLS              bool   // Upgrade an ldap:// connection with StartTLS
+	InsecureSkipTLSVerify bool   // Accept any server certificate (self-signed lab setups)
+	BindDN                string // Service account; empty searches anonymously
+	BindPassword          string
+	BaseDN                string // Where users are searched
+	UserFilter            string // Default "(uid={username})"
+	UsernameAttribute     string // Default "uid"
+	DisplayNameAttribute  string // Default "displayName", falling back to cn
+	GroupBaseDN           string // Default BaseDN
+	GroupFilter           string // Optional group search, e.g. "(member={dn})"
+	AdminGroups           []string
+	UserGroups            []string
+	DefaultRole           string
+	AutoProvision         bool
+	Timeout               time.Duration // Default 10s
+}
+
+// NewLDAPProvider creates an LDAP authenticator. The URL and base DN are
+// required; the user filter must contain the {username} placeholder.
+func NewLDAPProvider(cfg LDAPConfig) (*LDAPProvider, error) {
+	u, err := url.Parse(strings.TrimSpace(cfg.URL))
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("ldap: invalid server URL %q", cfg.URL)
+	}
+	switch u.Scheme {
+	case "ldap":
+	case "ldaps":
+		if cfg.StartTLS {
+			return nil, fmt.Errorf("ldap: StartTLS cannot be combined with an ldaps:// URL")
+		}
+	default:
+		return nil, fmt.Errorf("ldap: server URL must start with ldap:// or ldaps://")
+	}
+	if strings.TrimSpace(cfg.BaseDN) == "" {
+		return nil, fmt.Errorf("ldap: base DN is required")
+	}
+	filter := orDefault(strings.TrimSpace(cfg.UserFilter), "(uid={username})")
+	if !strings.Contains(filter, "{username}") {
+		return nil, fmt.Errorf("ldap: user filter must contain {username}")
+	}
+	if _, err := ldap.CompileFilter(strings.ReplaceAll(filter, "{username}", "x")); err != nil {
+		return nil, fmt.Errorf("ldap: invalid user filter: %w", err)
+	}
+	groupFilter := strings.TrimSpace(cfg.GroupFilter)
+	if groupFilter != "" {
+		probe := strings.NewReplacer("{dn}", "x", "{username}", "x").Replace(groupFilter)
+		if _, err := ldap.CompileFilter(probe); err != nil {
+			return nil, fmt.Errorf("ldap: invalid group filter: %w", err)
+		}
+	}
+
+	p := &LDAPProvider{
+		url:      u.String(),
+		startTLS: cfg.StartTLS,
+		tlsConfig: &tls.Config{
+			ServerName:         u.Hostname(),
+			InsecureSkipVerify: cfg.InsecureSkipTLSVerify, //nolint:gosec // operator opt-in for self-signed directories
+			MinVersion:         tls.VersionTLS12,
+		},
+		bindDN:       cfg.BindDN,
+		bindPassword: cfg.BindPassword,
+		baseDN:       cfg.BaseDN,
+		userFilter:   filter,
+		usernameAttr: orDefault(cfg.UsernameAttribute, "uid"),
+		displayAttr:  orDefault(cfg.DisplayNameAttribute, "displayName"),
+		groupBaseDN:  orDefault(cfg.GroupBaseDN, cfg.BaseDN),
+		groupFilter:  groupFilter,
+		adminGroups:  cfg.AdminGroups,
+		userGroups:   cfg.UserGroups,
+		defaultRole:  orDefault(cfg.DefaultRole, "operator"),
+		autoProv:     cfg.AutoProvision,
+		timeout:      cfg.Timeout,
+	}
+	if p.timeout <= 0 {
+		p.timeout = 10 * time.Second
+	}
+	return p, nil
+}
+
+// Type returns "ldap".
+func (p *LDAPProvider) Type() string { return "ldap" }
+
+// Authenticate looks the user up with the service account, then verifies the
+// password by binding as the entry found. A missing user, an ambiguous filter
+// match and a wrong password all return ErrInvalidCredentials so the login
+// form does not reveal which usernames exist; connection and service-account
+// failures are returned as plain errors.
+func (p *LDAPProvider) Authenticate(ctx context.Context, creds Credentials) (*Identity, error) {
+	username := strings.TrimSpace(creds.Username)
+	// An empty password would be an unauthenticated bind, which most
+	// directories accept for any DN (RFC 4513 section 5.1.2).
+	if username == "" || creds.Password == "" {
+		return nil, fmt.Errorf("ldap: %w", ErrInvalidCredentials)
+	}
+
+	conn, err := p.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	if err := p.bindService(conn); err != nil {
+		return nil, err
+	}
+
+	entry, err := p.findUser(conn, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn.Bind(entry.DN, creds.Password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, fmt.Errorf("ldap: %w", ErrInvalidCredentials)
+		}
+		return nil, fmt.Errorf("ldap: binding as user: %w", err)
+	}
+
+	groups, err := p.userGroupsFor(conn, entry, username)
+	if err != nil {
+		return nil, err
+	}
+
+	id := entry.GetEqualFoldAttributeValue(p.usernameAttr)
+	if id == "" {
+		id = entry.DN
+	}
+	display := entry.GetEqualFoldAttributeValue(p.displayAttr)
+	if display == "" {
+		display = entry.GetEqualFoldAttributeValue("cn")
+	}
+	if display == "" {
+		display = id
+	}
+
+	return &Identity{
+		ProviderID:   id,
+		DisplayName:  display,
+		ProviderType: "ldap",
+		IsAdmin:      matchesAnyGroup(groups, p.adminGroups),
+		Groups:       groups,
+		Extra:        map[string]string{"dn": entry.DN},
+	}, nil
+}
+
+// CanAutoProvision checks whether the identity meets the configured guard
+// rails for automatic user creation. If userGroups is empty, any directory
+// user with a valid password is allowed; otherwise the user must be a member
+// of at least one of them.
+func (p *LDAPProvider) CanAutoProvision(identity *Identity) bool {
+	if identity == nil || !p.autoProv {
+		return false
+	}
+	if len(p.userGroups) == 0 {
+		return true
+	}
+	return matchesAnyGroup(identity.Groups, p.userGroups)
+}
+
+// MapRole returns "administrator" for members of an admin group and the
+// default role otherwise.
+func (p *LDAPProvider) MapRole(identity *Identity) string {
+	if identity != nil && matchesAnyGroup(identity.Groups, p.adminGroups) {
+		return "administrator"
+	}
+	return p.defaultRole
+}
+
+// dial opens a connection to the directory, upgrading it with StartTLS when
+// configured. The context bounds the TCP and TLS handshake; each operation
+// after that is bounded by the provider timeout.
+func (p *LDAPProvider) dial(ctx context.Context) (*ldap.Conn, error) {
+	dialer := &net.Dialer{Timeout: p.timeout}
+	if deadline, ok := ctx.Deadline(); ok {
+		dialer.Deadline = deadline
+	}
+	conn, err := ldap.DialURL(p.url, ldap.DialWithDialer(dialer), ldap.DialWithTLSConfig(p.tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("ldap: connecting to %s: %w", p.url, err)
+	}
+	conn.SetTimeout(p.timeout)
+	if p.startTLS {
+		if err := conn.StartTLS(p.tlsConfig); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("ldap: StartTLS: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// bindService binds as the service account, or does nothing when none is
+// configured and the directory allows anonymous search.
+func (p *LDAPProvider) bindService(conn *ldap.Conn) error {
+	if p.bindDN == "" {
+		return nil
+	}
+	if err := conn.Bind(p.bindDN, p.bindPassword); err != nil {
+		return fmt.Errorf("ldap: service account bind failed: %w", err)
+	}
+	return nil
+}
+
+// findUser returns the single entry matching the user filter.
+func (p *LDAPProvider) findUser(conn *ldap.Conn, username string) (*ldap.Entry, error) {
+	filter := strings.ReplaceAll(p.userFilter, "{username}", ldap.EscapeFilter(username))
+	attrs := []string{p.usernameAttr, p.displayAttr, "cn"}
+	if p.groupFilter == "" {
+		attrs = append(attrs, "memberOf")
+	}
+	res, err := conn.Search(ldap.NewSearchRequest(
+		p.baseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		2, int(p.timeout/time.Second), false, filter, attrs, nil,
+	))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return nil, fmt.Errorf("ldap: searching for user: %w", err)
+	}
+	switch {
+	case res == nil || len(res.Entries) == 0:
+		return nil, fmt.Errorf("ldap: %w: no entry matches %s", ErrInvalidCredentials, filter)
+	case len(res.Entries) > 1 || err != nil:
+		return nil, fmt.Errorf("ldap: %w: more than one entry matches %s", ErrInvalidCredentials, filter)
+	}
+	return res.Entries[0], nil
+}
+
+// userGroupsFor returns the names of the groups the user belongs to. With a
+// group filter it searches for group entries (rebinding as the service
+// account, since the user may not be allowed to read groups); otherwise it
+// takes the first RDN value of each memberOf DN, so
+// "cn=stillwater-admins,ou=groups,dc=example,dc=com" yields "stillwater-admins".
+func (p *LDAPProvider) userGroupsFor(conn *ldap.Conn, entry *ldap.Entry, username string) ([]string, error) {
+	if p.groupFilter == "" {
+		values := entry.GetEqualFoldAttributeValues("memberOf")
+		groups := make([]string, 0, len(values))
+		for _, v := range values {
+			if name := groupNameFromDN(v); name != "" {
+				groups = append(groups, name)
+			}
+		}
+		return groups, nil
+	}
+
+	if err := p.bindService(conn); err != nil {
+		return nil, err
+	}
+	filter := strings.NewReplacer(
+		"{dn}", ldap.EscapeFilter(entry.DN),
+		"{username}", ldap.EscapeFilter(username),
+	).Replace(p.groupFilter)
+	res, err := conn.Search(ldap.NewSearchRequest(
+		p.groupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		0, int(p.timeout/time.Second), false, filter, []string{"cn"}, nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("ldap: searching for groups: %w", err)
+	}
+	groups := make([]string, 0, len(res.Entries))
+	for _, e := range res.Entries {
+		name := e.GetEqualFoldAttributeValue("cn")
+		if name == "" {
+			name = groupNameFromDN(e.DN)
+		}
+		if name != "" {
+			groups = append(groups, name)
+		}
+	}
+	return groups, nil
+}
+
+// groupNameFromDN returns the value of the first RDN of dn, or "" when dn
+// does not parse.
+func groupNameFromDN(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 || len(parsed.RDNs[0].Attributes) == 0 {
+		return ""
+	}
+	return parsed.RDNs[0].Attributes[0].Value
+}
+
+// orDefault returns v, or fallback when v is blank.
+func orDefault(v, fallback string) string {
+	if strings.TrimSpace(v) == "" {
+		return fallback
+	}
+	return strings.TrimSpace(v)
+}
@@ -0,0 +1,380 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+// stubDirectoryEntry is one object in the in-process test directory.
+type stubDirectoryEntry struct {
+	dn       string
+	password string // empty means binding as this DN always fails
+	attrs    map[string][]string
+}
+
+// stubDirectory is a minimal LDAPv3 server speaking just enough of the
+// protocol (simple bind, search, unbind) for LDAPProvider. Search filters are
+// evaluated as an AND of their equality clauses, which covers the filters the
+// tests use.
+type stubDirectory struct {
+	entries []stubDirectoryEntry
+
+	mu       sync.Mutex
+	searches []string // decompiled filters, in order
+}
+
+// newStubDirectory starts the stub on a loopback port and returns its
+// ldap:// URL. The listener is closed when the test ends.
+func newStubDirectory(t *testing.T, entries ...stubDirectoryEntry) (*stubDirectory, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	d := &stubDirectory{entries: entries}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go d.serve(conn)
+		}
+	}()
+	return d, "ldap://" + ln.Addr().String()
+}
+
+func (d *stubDirectory) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	bound := ""
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		id := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			dn := op.Children[1].Value.(string)
+			password := op.Children[2].Data.String()
+			code := uint16(ldap.LDAPResultInvalidCredentials)
+			if e := d.find(dn); e != nil && e.password != "" && e.password == password {
+				code, bound = ldap.LDAPResultSuccess, dn
+			}
+			d.reply(conn, id, ldap.ApplicationBindResponse, code)
+		case ldap.ApplicationSearchRequest:
+			if bound == "" {
+				d.reply(conn, id, ldap.ApplicationSearchResultDone, ldap.LDAPResultInsufficientAccessRights)
+				continue
+			}
+			base := op.Children[0].Value.(string)
+			filter, err := ldap.DecompileFilter(op.Children[6])
+			if err != nil {
+				d.reply(conn, id, ldap.ApplicationSearchResultDone, ldap.LDAPResultProtocolError)
+				continue
+			}
+			d.mu.Lock()
+			d.searches = append(d.searches, filter)
+			d.mu.Unlock()
+			for i := range d.entries {
+				e := &d.entries[i]
+				if strings.HasSuffix(strings.ToLower(e.dn), strings.ToLower(base)) && e.matches(filter) {
+					d.sendEntry(conn, id, e)
+				}
+			}
+			d.reply(conn, id, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess)
+		case ldap.ApplicationUnbindRequest:
+			return
+		}
+	}
+}
+
+func (d *stubDirectory) find(dn string) *stubDirectoryEntry {
+	for i := range d.entries {
+		if strings.EqualFold(d.entries[i].dn, dn) {
+			return &d.entries[i]
+		}
+	}
+	return nil
+}
+
+func (d *stubDirectory) filters() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.searches)
+}
+
+var stubEqualityClause = regexp.MustCompile(`\(([^=()&|!]+)=([^()]*)\)`)
+
+// matches reports whether every equality clause in filter holds for e.
+func (e *stubDirectoryEntry) matches(filter string) bool {
+	for _, m := range stubEqualityClause.FindAllStringSubmatch(filter, -1) {
+		attr, want := m[1], m[2]
+		values := e.attrs[attr]
+		if strings.EqualFold(attr, "dn") {
+			values = []string{e.dn}
+		}
+		if !slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(ldap.EscapeFilter(v), want) }) {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *stubDirectory) reply(conn net.Conn, id int64, tag ber.Tag, code uint16) {
+	res := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Response")
+	res.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "resultCode"))
+	res.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	res.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"))
+	d.write(conn, id, res)
+}
+
+func (d *stubDirectory) sendEntry(conn net.Conn, id int64, e *stubDirectoryEntry) {
+	res := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	res.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.dn, "objectName"))
+	attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attributes")
+	for name, values := range e.attrs {
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attribute")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "type"))
+		vals := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "vals")
+		for _, v := range values {
+			vals.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, "value"))
+		}
+		attr.AppendChild(vals)
+		attrs.AppendChild(attr)
+	}
+	res.AppendChild(attrs)
+	d.write(conn, id, res)
+}
+
+func (d *stubDirectory) write(conn net.Conn, id int64, op *ber.Packet) {
+	msg := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Message")
+	msg.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "messageID"))
+	msg.AppendChild(op)
+	_, _ = conn.Write(msg.Bytes())
+}
+
+// householdDirectory mirrors a small LLDAP tree: a read-only service
+// account, two people, and two groups whose membership is exposed both as
+// memberOf on the users and as member on the groups.
+func householdDirectory(t *testing.T) (*stubDirectory, string) {
+	t.Helper()
+	const (
+		admins = "cn=stillwater-admins,ou=groups,dc=example,dc=com"
+		family = "cn=family,ou=groups,dc=example,dc=com"
+		alice  = "uid=alice,ou=people,dc=example,dc=com"
+		bob    = "uid=bob,ou=people,dc=example,dc=com"
+	)
+	return newStubDirectory(t,
+		stubDirectoryEntry{dn: "uid=svc,ou=people,dc=example,dc=com", password: "svc-secret"},
+		stubDirectoryEntry{dn: alice, password: "alice-pw", attrs: map[string][]string{
+			"uid": {"alice"}, "displayName": {"Alice Liddell"}, "cn": {"alice"}, "memberOf": {family, admins},
+		}},
+		stubDirectoryEntry{dn: bob, password: "bob-pw", attrs: map[string][]string{
+			"uid": {"bob"}, "cn": {"Bob"}, "memberOf": {family},
+		}},
+		stubDirectoryEntry{dn: admins, attrs: map[string][]string{"cn": {"stillwater-admins"}, "member": {alice}}},
+		stubDirectoryEntry{dn: family, attrs: map[string][]string{"cn": {"family"}, "member": {alice, bob}}},
+	)
+}
+
+func newTestLDAPProvider(t *testing.T, url string, cfg LDAPConfig) *LDAPProvider {
+	t.Helper()
+	cfg.URL = url
+	if cfg.BindDN == "" {
+		cfg.BindDN = "uid=svc,ou=people,dc=example,dc=com"
+		cfg.BindPassword = "svc-secret"
+	}
+	cfg.BaseDN = "dc=example,dc=com"
+	cfg.Timeout = 5 * time.Second
+	p, err := NewLDAPProvider(cfg)
+	if err != nil {
+		t.Fatalf("NewLDAPProvider: %v", err)
+	}
+	return p
+}
+
+func TestNewLDAPProvider_Validation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		cfg  LDAPConfig
+	}{
+		{name: "missing url", cfg: LDAPConfig{BaseDN: "dc=example,dc=com"}},
+		{name: "http scheme", cfg: LDAPConfig{URL: "http://dir.lan", BaseDN: "dc=example,dc=com"}},
+		{name: "missing base dn", cfg: LDAPConfig{URL: "ldap://dir.lan"}},
+		{name: "starttls over ldaps", cfg: LDAPConfig{URL: "ldaps://dir.lan", BaseDN: "dc=example,dc=com", StartTLS: true}},
+		{name: "filter without placeholder", cfg: LDAPConfig{URL: "ldap://dir.lan", BaseDN: "dc=example,dc=com", UserFilter: "(uid=alice)"}},
+		{name: "malformed filter", cfg: LDAPConfig{URL: "ldap://dir.lan", BaseDN: "dc=example,dc=com", UserFilter: "(uid={username}"}},
+		{name: "malformed group filter", cfg: LDAPConfig{URL: "ldap://dir.lan", BaseDN: "dc=example,dc=com", GroupFilter: "member={dn})"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewLDAPProvider(tt.cfg); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	p, err := NewLDAPProvider(LDAPConfig{URL: "ldaps://dir.lan:6360", BaseDN: "dc=example,dc=com"})
+	if err != nil {
+		t.Fatalf("NewLDAPProvider: %v", err)
+	}
+	if p.Type() != "ldap" || p.userFilter != "(uid={username})" || p.defaultRole != "operator" {
+		t.Errorf("defaults = type %q, filter %q, role %q", p.Type(), p.userFilter, p.defaultRole)
+	}
+}
+
+func TestLDAPAuthenticate_MemberOf(t *testing.T) {
+	t.Parallel()
+	_, url := householdDirectory(t)
+	p := newTestLDAPProvider(t, url, LDAPConfig{AdminGroups: []string{"Stillwater-Admins"}})
+
+	identity, err := p.Authenticate(context.Background(), Credentials{Username: "alice", Password: "alice-pw"})
+	if err != nil {
+		t.Fatalf("Authenticate(alice): %v", err)
+	}
+	if identity.ProviderID != "alice" || identity.DisplayName != "Alice Liddell" || identity.ProviderType != "ldap" {
+		t.Errorf("identity = %+v", identity)
+	}
+	if !identity.IsAdmin {
+		t.Error("alice should be an admin through memberOf")
+	}
+	if want := []string{"family", "stillwater-admins"}; !slices.Equal(identity.Groups, want) {
+		t.Errorf("Groups = %v, want %v", identity.Groups, want)
+	}
+
+	identity, err = p.Authenticate(context.Background(), Credentials{Username: "bob", Password: "bob-pw"})
+	if err != nil {
+		t.Fatalf("Authenticate(bob): %v", err)
+	}
+	if identity.IsAdmin || identity.DisplayName != "Bob" {
+		t.Errorf("bob identity = %+v, want non-admin with cn display name", identity)
+	}
+}
+
+func TestLDAPAuthenticate_GroupSearch(t *testing.T) {
+	t.Parallel()
+	dir, url := householdDirectory(t)
+	p := newTestLDAPProvider(t, url, LDAPConfig{
+		GroupBaseDN: "ou=groups,dc=example,dc=com",
+		GroupFilter: "(member={dn})",
+		AdminGroups: []string{"stillwater-admins"},
+	})
+
+	identity, err := p.Authenticate(context.Background(), Credentials{Username: "alice", Password: "alice-pw"})
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if !identity.IsAdmin {
+		t.Error("alice should be an admin through the group search")
+	}
+	slices.Sort(identity.Groups)
+	if want := []string{"family", "stillwater-admins"}; !slices.Equal(identity.Groups, want) {
+		t.Errorf("Groups = %v, want %v", identity.Groups, want)
+	}
+	filters := dir.filters()
+	if len(filters) != 2 || filters[1] != "(member=uid=alice,ou=people,dc=example,dc=com)" {
+		t.Errorf("searches = %q, want the user search then a member search", filters)
+	}
+}
+
+func TestLDAPAuthenticate_Rejections(t *testing.T) {
+	t.Parallel()
+	dir, url := householdDirectory(t)
+	p := newTestLDAPProvider(t, url, LDAPConfig{})
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+	}{
+		{name: "wrong password", username: "alice", password: "nope"},
+		{name: "unknown user", username: "mallory", password: "x"},
+		{name: "empty password", username: "alice", password: ""},
+		{name: "empty username", username: "", password: "x"},
+		{name: "wildcard username", username: "*", password: "alice-pw"},
+		{name: "filter injection", username: "alice)(uid=*", password: "alice-pw"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := p.Authenticate(context.Background(), Credentials{Username: tt.username, Password: tt.password})
+			if !errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("Authenticate() error = %v, want ErrInvalidCredentials", err)
+			}
+		})
+	}
+
+	for _, f := range dir.filters() {
+		if strings.Contains(f, "*") {
+			t.Errorf("search filter %q was not escaped", f)
+		}
+	}
+}
+
+func TestLDAPAuthenticate_DirectoryFailures(t *testing.T) {
+	t.Parallel()
+	_, url := householdDirectory(t)
+
+	badService := newTestLDAPProvider(t, url, LDAPConfig{BindDN: "uid=svc,ou=people,dc=example,dc=com", BindPassword: "wrong"})
+	_, err := badService.Authenticate(context.Background(), Credentials{Username: "alice", Password: "alice-pw"})
+	if err == nil || errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("service bind failure error = %v, want a non-credential error", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	closedURL := "ldap://" + ln.Addr().String()
+	_ = ln.Close()
+	unreachable := newTestLDAPProvider(t, closedURL, LDAPConfig{})
+	_, err = unreachable.Authenticate(context.Background(), Credentials{Username: "alice", Password: "alice-pw"})
+	if err == nil || errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unreachable server error = %v, want a non-credential error", err)
+	}
+}
+
+func TestLDAPMapRoleAndAutoProvision(t *testing.T) {
+	t.Parallel()
+	p := newTestLDAPProvider(t, "ldap://dir.lan", LDAPConfig{
+		AdminGroups:   []string{"stillwater-admins"},
+		UserGroups:    []string{"family", "stillwater-admins"},
+		DefaultRole:   "viewer",
+		AutoProvision: true,
+	})
+
+	admin := &Identity{Groups: []string{"Stillwater-Admins"}}
+	member := &Identity{Groups: []string{"family"}}
+	outsider := &Identity{Groups: []string{"guests"}}
+
+	if got := p.MapRole(admin); got != "administrator" {
+		t.Errorf("MapRole(admin) = %q, want administrator", got)
+	}
+	if got := p.MapRole(member); got != "viewer" {
+		t.Errorf("MapRole(member) = %q, want viewer", got)
+	}
+	if !p.CanAutoProvision(admin) || !p.CanAutoProvision(member) {
+		t.Error("expected members of user groups to be provisionable")
+	}
+	if p.CanAutoProvision(outsider) || p.CanAutoProvision(nil) {
+		t.Error("expected outsiders and nil identities to be refused")
+	}
+
+	off := newTestLDAPProvider(t, "ldap://dir.lan", LDAPConfig{})
+	if off.CanAutoProvision(member) {
+		t.Error("expected auto-provision to be off by default")
+	}
+}
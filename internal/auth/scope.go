@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Resource scopes narrow a token to one area of the API. Each is written
+// "<resource>:<action>"; the coarse ScopeRead and ScopeWrite remain valid and
+// mean "read" and "read+write" across every resource, so tokens created
+// before resource scopes existed keep exactly the access they had.
+//
+// Implications inside a resource: ":write" includes ":read", and
+// ScopeRulesRun (evaluate rules and apply their fixes) includes
+// ScopeRulesRead but not ScopeRulesWrite, so an automation token can run the
+// rules without being able to edit them.
+const (
+	ScopeArtistsRead      TokenScope = "artists:read"
+	ScopeArtistsWrite     TokenScope = "artists:write"
+	ScopeImagesRead       TokenScope = "images:read"
+	ScopeImagesWrite      TokenScope = "images:write"
+	ScopeRulesRead        TokenScope = "rules:read"
+	ScopeRulesWrite       TokenScope = "rules:write"
+	ScopeRulesRun         TokenScope = "rules:run"
+	ScopeLibrariesRead    TokenScope = "libraries:read"
+	ScopeLibrariesWrite   TokenScope = "libraries:write"
+	ScopeConnectionsRead  TokenScope = "connections:read"
+	ScopeConnectionsWrite TokenScope = "connections:write"
+	ScopeSettingsRead     TokenScope = "settings:read"
+	ScopeSettingsWrite    TokenScope = "settings:write"
+)
+
+// Resource returns the resource half of a resource scope ("artists" for
+// "artists:write"), or "" for the coarse scopes.
+func (s TokenScope) Resource() string {
+	resource, _, ok := strings.Cut(string(s), ":")
+	if !ok {
+		return ""
+	}
+	return resource
+}
+
+// Action returns the action half of a resource scope ("write" for
+// "artists:write"), or "" for the coarse scopes.
+func (s TokenScope) Action() string {
+	_, action, ok := strings.Cut(string(s), ":")
+	if !ok {
+		return ""
+	}
+	return action
+}
+
+// ParseScopes splits a stored comma-separated scope string into its scopes,
+// trimming whitespace and dropping empty segments. It does not validate; see
+// NormalizeScopes.
+func ParseScopes(scopes string) []TokenScope {
+	var out []TokenScope
+	for _, s := range strings.Split(scopes, ",") {
+		if trimmed := strings.TrimSpace(s); trimmed != "" {
+			out = append(out, TokenScope(trimmed))
+		}
+	}
+	return out
+}
+
+// NormalizeScopes validates a comma-separated scope list against ValidScopes
+// and returns it trimmed, de-duplicated and in the order given. An unknown
+// scope is an error naming it; an empty list is an error too, since a token
+// without scopes could not call anything.
+func NormalizeScopes(scopes string) (string, error) {
+	parsed := ParseScopes(scopes)
+	out := make([]string, 0, len(parsed))
+	for _, s := range parsed {
+		if !ValidScopes[s] {
+			return "", fmt.Errorf("invalid scope: %s", s)
+		}
+		if !slices.Contains(out, string(s)) {
+			out = append(out, string(s))
+		}
+	}
+	if len(out) == 0 {
+		return "", fmt.Errorf("no valid scopes provided")
+	}
+	return strings.Join(out, ","), nil
+}
+
+// ScopesAllow reports whether a token holding the comma-separated scopes may
+// perform an operation that requires the given scope. required is either a
+// resource scope, ScopeWebhook, or (for routes outside the resource map)
+// ScopeRead/ScopeWrite.
+//
+// ScopeAdmin allows everything. ScopeWebhook is only ever satisfied by
+// itself or admin: a token meant for inbound webhooks must not double as a
+// read or write token, and the reverse.
+func ScopesAllow(scopes string, required TokenScope) bool {
+	for _, held := range ParseScopes(scopes) {
+		if held == ScopeAdmin || held == required || scopeImplies(held, required) {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeImplies reports whether holding held grants required, beyond the
+// identical-scope case ScopesAllow already checks.
+func scopeImplies(held, required TokenScope) bool {
+	switch {
+	case required == ScopeWebhook:
+		return false
+	case required == ScopeRead:
+		return held == ScopeWrite
+	case required == ScopeWrite:
+		return false
+	}
+
+	// required is a resource scope from here on.
+	switch held {
+	case ScopeWrite:
+		return true
+	case ScopeRead:
+		return required.Action() == "read"
+	}
+	if held.Resource() == "" || held.Resource() != required.Resource() {
+		return false
+	}
+	switch required.Action() {
+	case "read":
+		// Every action on a resource includes reading it.
+		return true
+	case "run":
+		// Running rules is part of managing them.
+		return held.Action() == "write"
+	}
+	return false
+}
+
+// ParseLibraryIDs splits a stored comma-separated library restriction. An
+// empty result means the token is not restricted.
+func ParseLibraryIDs(ids string) []string {
+	var out []string
+	for _, id := range strings.Split(ids, ",") {
+		if trimmed := strings.TrimSpace(id); trimmed != "" && !slices.Contains(out, trimmed) {
+			out = append(out, trimmed)
+		}
+	}
+	return out
+}
+
+// TokenGrant is what a validated API token is allowed to do: the owning
+// user, the scope string as stored, and the libraries the token is
+// restricted to (empty for an unrestricted token).
+type TokenGrant struct {
+	UserID     string
+	Scopes     string
+	LibraryIDs []string
+}
+
+// Restricted reports whether the token is limited to specific libraries.
+func (g *TokenGrant) Restricted() bool {
+	return len(g.LibraryIDs) > 0
+}
+
+// AllowsLibrary reports whether the token may touch the given library. An
+// unrestricted token allows every library.
+func (g *TokenGrant) AllowsLibrary(libraryID string) bool {
+	return !g.Restricted() || slices.Contains(g.LibraryIDs, libraryID)
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestScopesAllow(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		held     string
+		required TokenScope
+		want     bool
+	}{
+		// Coarse scopes keep their pre-resource meaning.
+		{"read", ScopeArtistsRead, true},
+		{"read", ScopeConnectionsRead, true},
+		{"read", ScopeArtistsWrite, false},
+		{"read", ScopeRulesRun, false},
+		{"write", ScopeSettingsWrite, true},
+		{"write", ScopeRulesRun, true},
+		{"write", ScopeRead, true},
+		{"read", ScopeWrite, false},
+		{"admin", ScopeSettingsWrite, true},
+		{"admin", ScopeWebhook, true},
+
+		// Webhook is isolated in both directions.
+		{"webhook", ScopeArtistsRead, false},
+		{"webhook", ScopeRead, false},
+		{"read,write", ScopeWebhook, false},
+		{"webhook", ScopeWebhook, true},
+
+		// Resource scopes: write includes read, run includes read only.
+		{"artists:write", ScopeArtistsWrite, true},
+		{"artists:write", ScopeArtistsRead, true},
+		{"artists:write", ScopeImagesWrite, false},
+		{"artists:write", ScopeRead, false},
+		{"rules:run", ScopeRulesRead, true},
+		{"rules:run", ScopeRulesWrite, false},
+		{"rules:write", ScopeRulesRun, true},
+		{"connections:read", ScopeConnectionsWrite, false},
+		{" artists:read , images:write ", ScopeImagesRead, true},
+		{"", ScopeArtistsRead, false},
+	}
+	for _, tt := range tests {
+		if got := ScopesAllow(tt.held, tt.required); got != tt.want {
+			t.Errorf("ScopesAllow(%q, %q) = %v, want %v", tt.held, tt.required, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeScopes(t *testing.T) {
+	t.Parallel()
+	got, err := NormalizeScopes(" artists:write, rules:run,artists:write ,")
+	if err != nil {
+		t.Fatalf("NormalizeScopes: %v", err)
+	}
+	if got != "artists:write,rules:run" {
+		t.Errorf("NormalizeScopes = %q, want %q", got, "artists:write,rules:run")
+	}
+
+	if _, err := NormalizeScopes("artists:delete"); err == nil {
+		t.Error("expected an error for an unknown scope")
+	}
+	if _, err := NormalizeScopes(" , "); err == nil {
+		t.Error("expected an error for an empty scope list")
+	}
+}
+
+func TestTokenGrant_AllowsLibrary(t *testing.T) {
+	t.Parallel()
+	unrestricted := &TokenGrant{}
+	if unrestricted.Restricted() || !unrestricted.AllowsLibrary("lib-a") {
+		t.Error("a grant without libraries should allow every library")
+	}
+	restricted := &TokenGrant{LibraryIDs: []string{"lib-a"}}
+	if !restricted.AllowsLibrary("lib-a") {
+		t.Error("expected lib-a to be allowed")
+	}
+	if restricted.AllowsLibrary("lib-b") {
+		t.Error("expected lib-b to be refused")
+	}
+}
+
+func TestCreateAPITokenWithOptions_LibraryRestriction(t *testing.T) {
+	t.Parallel()
+	svc := createTestUser(t, "secret")
+	ctx := context.Background()
+
+	token, err := svc.Login(ctx, "admin", "secret")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	userID, err := svc.ValidateSession(ctx, token)
+	if err != nil {
+		t.Fatalf("ValidateSession: %v", err)
+	}
+
+	plaintext, id, err := svc.CreateAPITokenWithOptions(ctx, userID, "tagger", "artists:write,images:write",
+		APITokenOptions{LibraryIDs: []string{"lib-a", " lib-b", "lib-a"}})
+	if err != nil {
+		t.Fatalf("CreateAPITokenWithOptions: %v", err)
+	}
+
+	grant, err := svc.ValidateAPITokenGrant(ctx, plaintext)
+	if err != nil {
+		t.Fatalf("ValidateAPITokenGrant: %v", err)
+	}
+	if grant.UserID != userID || grant.Scopes != "artists:write,images:write" {
+		t.Errorf("grant = %+v", grant)
+	}
+	if want := []string{"lib-a", "lib-b"}; !slices.Equal(grant.LibraryIDs, want) {
+		t.Errorf("grant.LibraryIDs = %v, want %v", grant.LibraryIDs, want)
+	}
+
+	tok, err := svc.GetAPIToken(ctx, id, userID)
+	if err != nil {
+		t.Fatalf("GetAPIToken: %v", err)
+	}
+	if !slices.Equal(tok.LibraryIDs, grant.LibraryIDs) {
+		t.Errorf("GetAPIToken LibraryIDs = %v, want %v", tok.LibraryIDs, grant.LibraryIDs)
+	}
+
+	// Tokens created without options stay unrestricted.
+	plain, _, err := svc.CreateAPIToken(ctx, userID, "plain", "read")
+	if err != nil {
+		t.Fatalf("CreateAPIToken: %v", err)
+	}
+	grant, err = svc.ValidateAPITokenGrant(ctx, plain)
+	if err != nil {
+		t.Fatalf("ValidateAPITokenGrant: %v", err)
+	}
+	if grant.Restricted() {
+		t.Errorf("expected an unrestricted grant, got %v", grant.LibraryIDs)
+	}
+}
@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sydlexius/stillwater/internal/dbutil"
+)
+
+// ErrSessionNotFound is returned by RevokeSession when no live session has
+// the given ID, or the session belongs to another user.
+var ErrSessionNotFound = errors.New("session not found")
+
+// maxUserAgentLength caps the stored User-Agent header. Browsers send well
+// under this; the cap only stops a crafted header from bloating the table.
+const maxUserAgentLength = 512
+
+// lastSeenInterval is how stale last_seen_at must be before ValidateSession
+// rewrites it, so an active page does not turn every request into a write.
+const lastSeenInterval = time.Minute
+
+// ClientInfo describes the client a session is created for.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+type clientInfoKey struct{}
+
+// WithClientInfo returns a context carrying info. CreateSession records it on
+// the session row; the login rate limiter sets it because it already resolves
+// the client IP through the trusted-proxy rules.
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+// ClientInfoFromContext returns the ClientInfo stored by WithClientInfo, or
+// the zero value.
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info
+}
+
+// Session is an active login session. ID is the public handle used by the
+// API; the cookie token and its hash are never exposed.
+type Session struct {
+	ID         string `json:"id"`
+	UserID     string `json:"user_id"`
+	Username   string `json:"username,omitempty"`
+	UserAgent  string `json:"user_agent"`
+	IPAddress  string `json:"ip_address"`
+	CreatedAt  string `json:"created_at"`
+	LastSeenAt string `json:"last_seen_at"`
+	ExpiresAt  string `json:"expires_at"`
+	Current    bool   `json:"current"`
+}
+
+// ListSessions returns the unexpired sessions of userID, most recently used
+// first. The session whose cookie token is currentToken is flagged Current.
+func (s *Service) ListSessions(ctx context.Context, userID, currentToken string) ([]Session, error) {
+	return s.listSessions(ctx, "AND s.user_id = ?", []any{userID}, currentToken)
+}
+
+// ListAllSessions returns the unexpired sessions of every user, with
+// usernames, for the administrator view.
+func (s *Service) ListAllSessions(ctx context.Context, currentToken string) ([]Session, error) {
+	return s.listSessions(ctx, "", nil, currentToken)
+}
+
+func (s *Service) listSessions(ctx context.Context, where string, args []any, currentToken string) ([]Session, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	args = append([]any{now}, args...)
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.id, COALESCE(s.public_id, ''), s.user_id, COALESCE(u.username, ''),
+		       s.user_agent, s.ip_address, s.created_at,
+		       COALESCE(s.last_seen_at, s.created_at), s.expires_at
+		FROM sessions s
+		LEFT JOIN users u ON u.id = s.user_id
+		WHERE s.expires_at > ? `+where+`
+		ORDER BY COALESCE(s.last_seen_at, s.created_at) DESC
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("listing sessions: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	currentHash := ""
+	if currentToken != "" {
+		currentHash = hashToken(currentToken)
+	}
+	sessions := []Session{}
+	for rows.Next() {
+		var sess Session
+		var hash string
+		if err := rows.Scan(&hash, &sess.ID, &sess.UserID, &sess.Username,
+			&sess.UserAgent, &sess.IPAddress, &sess.CreatedAt,
+			&sess.LastSeenAt, &sess.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("scanning session: %w", err)
+		}
+		// Rows from before the metadata migration store created_at in
+		// SQLite's datetime('now') form; normalize so clients see RFC 3339.
+		sess.CreatedAt = normalizeSessionTime(sess.CreatedAt)
+		sess.LastSeenAt = normalizeSessionTime(sess.LastSeenAt)
+		sess.Current = currentHash != "" && hash == currentHash
+		sessions = append(sessions, sess)
+	}
+	return sessions, rows.Err()
+}
+
+func normalizeSessionTime(v string) string {
+	t, ok := dbutil.ParseTimeOK(v)
+	if !ok {
+		return v
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// RevokeSession deletes the session with the given public ID. A non-empty
+// ownerID restricts the delete to that user's sessions, so a user cannot end
+// someone else's session by guessing an ID; administrators pass "".
+func (s *Service) RevokeSession(ctx context.Context, id, ownerID string) error {
+	query := `DELETE FROM sessions WHERE public_id = ?`
+	args := []any{id}
+	if ownerID != "" {
+		query += ` AND user_id = ?`
+		args = append(args, ownerID)
+	}
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("revoking session: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking revoked session: %w", err)
+	}
+	if n == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeUserSessions deletes every session of userID except the one whose
+// cookie token is exceptToken (pass "" to end them all) and returns how many
+// were removed.
+func (s *Service) RevokeUserSessions(ctx context.Context, userID, exceptToken string) (int64, error) {
+	res, err := s.db.ExecContext(ctx,
+		`DELETE FROM sessions WHERE user_id = ? AND id != ?`, userID, exceptHash(exceptToken))
+	if err != nil {
+		return 0, fmt.Errorf("revoking sessions: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// exceptHash maps an optional cookie token to the sessions.id it must not
+// match. The empty string never equals a stored hash.
+func exceptHash(token string) string {
+	if token == "" {
+		return ""
+	}
+	return hashToken(token)
+}
+
+func truncateUserAgent(ua string) string {
+	if len(ua) <= maxUserAgentLength {
+		return ua
+	}
+	// Cut on a rune boundary so the stored value stays valid UTF-8.
+	cut := maxUserAgentLength
+	for cut > 0 && !utf8.RuneStart(ua[cut]) {
+		cut--
+	}
+	return ua[:cut]
+}
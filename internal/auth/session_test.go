@@ -0,0 +1,178 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loginFrom creates a session for userID as if the request came from ip
+// with the given User-Agent.
+func loginFrom(t *testing.T, svc *Service, userID, ip, ua string) string {
+	t.Helper()
+	ctx := WithClientInfo(context.Background(), ClientInfo{IP: ip, UserAgent: ua})
+	token, err := svc.CreateSession(ctx, userID)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	return token
+}
+
+func TestCreateSession_RecordsClientInfo(t *testing.T) {
+	t.Parallel()
+	svc, userID := setupTwoFactorService(t)
+	token := loginFrom(t, svc, userID, "192.0.2.10", "Mozilla/5.0 (X11; Linux x86_64) Firefox/140.0")
+
+	sessions, err := svc.ListSessions(context.Background(), userID, token)
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("got %d sessions, want 1", len(sessions))
+	}
+	got := sessions[0]
+	if got.IPAddress != "192.0.2.10" || !strings.Contains(got.UserAgent, "Firefox") {
+		t.Errorf("session = %+v, want the client IP and User-Agent", got)
+	}
+	if got.ID == "" || got.ID == token || got.ID == hashToken(token) {
+		t.Errorf("public ID %q must be set and unrelated to the cookie token", got.ID)
+	}
+	if !got.Current {
+		t.Error("the caller's own session should be flagged current")
+	}
+	for name, v := range map[string]string{"created_at": got.CreatedAt, "last_seen_at": got.LastSeenAt, "expires_at": got.ExpiresAt} {
+		if _, err := time.Parse(time.RFC3339, v); err != nil {
+			t.Errorf("%s = %q, want RFC 3339", name, v)
+		}
+	}
+}
+
+func TestCreateSession_TruncatesUserAgent(t *testing.T) {
+	t.Parallel()
+	svc, userID := setupTwoFactorService(t)
+	loginFrom(t, svc, userID, "", strings.Repeat("é", maxUserAgentLength))
+
+	sessions, err := svc.ListSessions(context.Background(), userID, "")
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	ua := sessions[0].UserAgent
+	if len(ua) > maxUserAgentLength || !strings.HasSuffix(ua, "é") {
+		t.Errorf("stored User-Agent has %d bytes and suffix %q, want <= %d and whole runes", len(ua), ua[len(ua)-2:], maxUserAgentLength)
+	}
+}
+
+func TestValidateSession_RefreshesLastSeen(t *testing.T) {
+	t.Parallel()
+	svc, userID := setupTwoFactorService(t)
+	ctx := context.Background()
+	token := loginFrom(t, svc, userID, "", "")
+
+	stale := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	if _, err := svc.db.ExecContext(ctx, `UPDATE sessions SET last_seen_at = ? WHERE id = ?`, stale, hashToken(token)); err != nil {
+		t.Fatalf("backdating last_seen_at: %v", err)
+	}
+	if _, err := svc.ValidateSession(ctx, token); err != nil {
+		t.Fatalf("ValidateSession: %v", err)
+	}
+
+	var lastSeen string
+	if err := svc.db.QueryRowContext(ctx, `SELECT last_seen_at FROM sessions WHERE id = ?`, hashToken(token)).Scan(&lastSeen); err != nil {
+		t.Fatalf("reading last_seen_at: %v", err)
+	}
+	if lastSeen == stale {
+		t.Error("last_seen_at was not refreshed")
+	}
+}
+
+func TestListSessions_ScopesAndExpiry(t *testing.T) {
+	t.Parallel()
+	svc, adminID := setupTwoFactorService(t)
+	ctx := context.Background()
+	other, err := svc.CreateLocalUser(ctx, "carol", "password123", "Carol", "operator", "")
+	if err != nil {
+		t.Fatalf("CreateLocalUser: %v", err)
+	}
+	mine := loginFrom(t, svc, adminID, "192.0.2.1", "")
+	loginFrom(t, svc, other.ID, "192.0.2.2", "")
+	expired := loginFrom(t, svc, adminID, "192.0.2.3", "")
+	if _, err := svc.db.ExecContext(ctx, `UPDATE sessions SET expires_at = ? WHERE id = ?`,
+		time.Now().UTC().Add(-time.Minute).Format(time.RFC3339), hashToken(expired)); err != nil {
+		t.Fatalf("expiring session: %v", err)
+	}
+
+	own, err := svc.ListSessions(ctx, adminID, mine)
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(own) != 1 || own[0].IPAddress != "192.0.2.1" {
+		t.Errorf("own sessions = %+v, want only the live admin session", own)
+	}
+
+	all, err := svc.ListAllSessions(ctx, mine)
+	if err != nil {
+		t.Fatalf("ListAllSessions: %v", err)
+	}
+	users := map[string]bool{}
+	for _, s := range all {
+		users[s.Username] = true
+	}
+	if len(all) != 2 || !users["admin"] || !users["carol"] {
+		t.Errorf("all sessions = %+v, want one each for admin and carol", all)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	t.Parallel()
+	svc, adminID := setupTwoFactorService(t)
+	ctx := context.Background()
+	other, err := svc.CreateLocalUser(ctx, "carol", "password123", "Carol", "operator", "")
+	if err != nil {
+		t.Fatalf("CreateLocalUser: %v", err)
+	}
+	token := loginFrom(t, svc, other.ID, "", "")
+	sessions, err := svc.ListSessions(ctx, other.ID, "")
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	id := sessions[0].ID
+
+	if err := svc.RevokeSession(ctx, id, adminID); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("revoking another user's session as its non-owner: err = %v, want ErrSessionNotFound", err)
+	}
+	if err := svc.RevokeSession(ctx, id, other.ID); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if _, err := svc.ValidateSession(ctx, token); err == nil {
+		t.Error("revoked session still validates")
+	}
+	if err := svc.RevokeSession(ctx, id, ""); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("second revoke: err = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	t.Parallel()
+	svc, userID := setupTwoFactorService(t)
+	ctx := context.Background()
+	keep := loginFrom(t, svc, userID, "", "")
+	loginFrom(t, svc, userID, "", "")
+	loginFrom(t, svc, userID, "", "")
+
+	n, err := svc.RevokeUserSessions(ctx, userID, keep)
+	if err != nil {
+		t.Fatalf("RevokeUserSessions: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("revoked %d sessions, want 2", n)
+	}
+	if _, err := svc.ValidateSession(ctx, keep); err != nil {
+		t.Errorf("excepted session no longer validates: %v", err)
+	}
+
+	if n, err = svc.RevokeUserSessions(ctx, userID, ""); err != nil || n != 1 {
+		t.Errorf("RevokeUserSessions(all) = %d, %v; want 1, nil", n, err)
+	}
+}
@@ -0,0 +1,663 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // RFC 6238 TOTP is defined over HMAC-SHA1; every authenticator app expects it.
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sydlexius/stillwater/internal/encryption"
+)
+
+// TOTP parameters. These are the defaults every authenticator app assumes, so
+// the otpauth URI states them only for completeness.
+const (
+	totpPeriod      = 30 // seconds per time step
+	totpDigits      = 6
+	totpSkew        = 1 // steps accepted either side of now, for clock drift
+	totpSecretBytes = 20
+	totpIssuer      = "Stillwater"
+
+	recoveryCodeCount = 10
+
+	loginChallengeTTL         = 5 * time.Minute
+	maxLoginChallengeAttempts = 5
+)
+
+// TwoFactorPolicySetting is the settings key holding the two-factor policy:
+// which local accounts must use two-factor authentication.
+const TwoFactorPolicySetting = "auth.two_factor.required"
+
+// Two-factor policy values. Under a policy that covers a user who has not
+// enrolled, the next password login enrolls them before it creates a session.
+const (
+	TwoFactorPolicyOff            = "off"
+	TwoFactorPolicyAdministrators = "administrators"
+	TwoFactorPolicyEveryone       = "everyone"
+)
+
+// Sentinel errors for two-factor operations.
+var (
+	// ErrInvalidSecondFactor is returned when an authentication code or
+	// recovery code is wrong, expired, or already used.
+	ErrInvalidSecondFactor = errors.New("invalid authentication code")
+
+	// ErrLoginChallengeInvalid is returned when a login challenge does not
+	// exist, has expired, or has used up its attempts. The user must sign in
+	// with their password again.
+	ErrLoginChallengeInvalid = errors.New("login challenge is invalid or has expired")
+
+	// ErrTwoFactorEnabled is returned when enrolling an account that already
+	// has two-factor authentication.
+	ErrTwoFactorEnabled = errors.New("two-factor authentication is already enabled")
+
+	// ErrTwoFactorNotEnabled is returned when an operation needs a confirmed
+	// enrollment and the account has none.
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+
+	// ErrTwoFactorRequired is returned when a user tries to turn off
+	// two-factor authentication that the policy requires for their role.
+	ErrTwoFactorRequired = errors.New("two-factor authentication is required for this account")
+
+	// ErrTwoFactorLocalOnly is returned when enrolling an account that signs
+	// in through Emby, Jellyfin or OIDC; those providers own their second
+	// factor.
+	ErrTwoFactorLocalOnly = errors.New("two-factor authentication is only available for local accounts")
+
+	// ErrNoEncryptor is returned when a TOTP secret must be stored or read but
+	// the service has no encryptor (see WithEncryptor).
+	ErrNoEncryptor = errors.New("two-factor authentication needs the encryption key")
+)
+
+// SecondFactorRequiredError is returned by Login and BeginSecondFactor when
+// the password was right but the account must pass a second step before a
+// session is created. Complete it with CompleteSecondFactor.
+type SecondFactorRequiredError struct {
+	// Challenge is the single-use login ticket to present with the code.
+	Challenge string
+	// Enrollment is set when the policy requires two-factor authentication
+	// and the user has not enrolled: they add this secret to an
+	// authenticator app and the code they enter confirms the enrollment.
+	Enrollment *TOTPEnrollment
+}
+
+func (e *SecondFactorRequiredError) Error() string {
+	return "second factor required"
+}
+
+// TOTPEnrollment is a new, unconfirmed TOTP secret to show the user once.
+type TOTPEnrollment struct {
+	// Secret is the base32 shared secret, for typing into an app by hand.
+	Secret string `json:"secret"`
+	// URI is the otpauth:// URI an app reads from a QR code.
+	URI string `json:"uri"`
+}
+
+// SecondFactorResult is the outcome of a completed second step.
+type SecondFactorResult struct {
+	// Token is the new session token.
+	Token string
+	// RecoveryCodes is set when the step confirmed an enrollment; they are
+	// shown once and never stored in plaintext.
+	RecoveryCodes []string
+}
+
+// TwoFactorStatus describes a user's two-factor state for the account page.
+type TwoFactorStatus struct {
+	Enabled bool `json:"enabled"`
+	// Required reports whether the policy requires two-factor
+	// authentication for this user, which blocks turning it off.
+	Required bool `json:"required"`
+	// RecoveryCodesRemaining counts the unused recovery codes.
+	RecoveryCodesRemaining int `json:"recovery_codes_remaining"`
+	// Available is false for federated accounts, which cannot enroll.
+	Available bool `json:"available"`
+}
+
+// WithEncryptor sets the encryptor used to store and read TOTP secrets and
+// returns the service for chaining. Without one, enrolling fails and an
+// enrolled user cannot complete a login (it never falls back to plaintext).
+func (s *Service) WithEncryptor(enc *encryption.Encryptor) *Service {
+	s.encryptor = enc
+	return s
+}
+
+// TwoFactorPolicy returns the configured two-factor policy. A missing or
+// unrecognized value reads as TwoFactorPolicyOff.
+func (s *Service) TwoFactorPolicy(ctx context.Context) (string, error) {
+	var v string
+	err := s.db.QueryRowContext(ctx, `SELECT value FROM settings WHERE key = ?`, TwoFactorPolicySetting).Scan(&v)
+	if errors.Is(err, sql.ErrNoRows) {
+		return TwoFactorPolicyOff, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading two-factor policy: %w", err)
+	}
+	switch v {
+	case TwoFactorPolicyAdministrators, TwoFactorPolicyEveryone:
+		return v, nil
+	default:
+		return TwoFactorPolicyOff, nil
+	}
+}
+
+// TwoFactorRequiredFor reports whether policy requires two-factor
+// authentication for a user with role.
+func TwoFactorRequiredFor(policy, role string) bool {
+	switch policy {
+	case TwoFactorPolicyEveryone:
+		return true
+	case TwoFactorPolicyAdministrators:
+		return role == "administrator"
+	default:
+		return false
+	}
+}
+
+// twoFactorUser is the slice of a users row the two-factor code works on.
+type twoFactorUser struct {
+	role     string
+	provider string
+	username string
+	secret   string // encrypted; empty when no secret, pending or confirmed
+	enabled  bool
+	lastStep int64
+}
+
+func (s *Service) loadTwoFactorUser(ctx context.Context, userID string) (*twoFactorUser, error) {
+	var u twoFactorUser
+	err := s.db.QueryRowContext(ctx, `
+		SELECT role, auth_provider, username, totp_secret, totp_enabled, totp_last_step
+		FROM users WHERE id = ?
+	`, userID).Scan(&u.role, &u.provider, &u.username, &u.secret, &u.enabled, &u.lastStep)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user not found: %w", sql.ErrNoRows)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading two-factor state: %w", err)
+	}
+	return &u, nil
+}
+
+// BeginSecondFactor decides whether a user who has just proved their password
+// needs a second step. It returns nil when a session may be created now, or a
+// *SecondFactorRequiredError carrying a new login challenge when the user is
+// enrolled or the policy requires them to enroll. Federated accounts always
+// get nil.
+func (s *Service) BeginSecondFactor(ctx context.Context, userID string) error {
+	u, err := s.loadTwoFactorUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if u.provider != "local" {
+		return nil
+	}
+
+	var enrollment *TOTPEnrollment
+	if !u.enabled {
+		policy, err := s.TwoFactorPolicy(ctx)
+		if err != nil {
+			return err
+		}
+		if !TwoFactorRequiredFor(policy, u.role) {
+			return nil
+		}
+		if enrollment, err = s.storePendingSecret(ctx, userID, u.username); err != nil {
+			return err
+		}
+	}
+
+	challenge, err := generateToken()
+	if err != nil {
+		return fmt.Errorf("generating login challenge: %w", err)
+	}
+	expiresAt := time.Now().UTC().Add(loginChallengeTTL).Format(time.RFC3339)
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO login_challenges (id, user_id, expires_at) VALUES (?, ?, ?)
+	`, hashToken(challenge), userID, expiresAt); err != nil {
+		return fmt.Errorf("creating login challenge: %w", err)
+	}
+	return &SecondFactorRequiredError{Challenge: challenge, Enrollment: enrollment}
+}
+
+// CompleteSecondFactor finishes a login challenge with an authentication
+// code, or with a recovery code for an enrolled user, and creates the
+// session. When the challenge was issued for a forced enrollment the code
+// confirms it and the result carries the new recovery codes.
+//
+// A wrong code returns ErrInvalidSecondFactor and leaves the challenge usable
+// until it expires or runs out of attempts, after which every call returns
+// ErrLoginChallengeInvalid. Wrong codes count towards the lockout like wrong
+// passwords do, and a locked-out user gets a *LockedError without the code
+// being checked, so fresh challenges do not buy fresh guesses. The user's
+// failure count is cleared only once the code is right.
+func (s *Service) CompleteSecondFactor(ctx context.Context, challenge, code string) (*SecondFactorResult, error) {
+	id := hashToken(challenge)
+	var userID, expiresAt string
+	var attempts int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT user_id, expires_at, attempts FROM login_challenges WHERE id = ?
+	`, id).Scan(&userID, &expiresAt, &attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrLoginChallengeInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("querying login challenge: %w", err)
+	}
+	expires, err := time.Parse(time.RFC3339, expiresAt)
+	if err != nil || time.Now().UTC().After(expires) || attempts >= maxLoginChallengeAttempts {
+		_, _ = s.db.ExecContext(ctx, `DELETE FROM login_challenges WHERE id = ?`, id)
+		return nil, ErrLoginChallengeInvalid
+	}
+	// Count the attempt before checking the code, so concurrent guesses
+	// cannot all slip in under the limit.
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE login_challenges SET attempts = attempts + 1 WHERE id = ?
+	`, id); err != nil {
+		return nil, fmt.Errorf("recording login challenge attempt: %w", err)
+	}
+
+	role, err := s.GetUserRole(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		// Deactivated between the password and the code.
+		_, _ = s.db.ExecContext(ctx, `DELETE FROM login_challenges WHERE id = ?`, id)
+		return nil, ErrLoginChallengeInvalid
+	}
+	u, err := s.loadTwoFactorUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CheckLogin(ctx, u.username); err != nil {
+		return nil, err
+	}
+
+	var result SecondFactorResult
+	if u.enabled {
+		err = s.verifySecondFactor(ctx, userID, u, code)
+	} else {
+		result.RecoveryCodes, err = s.confirmPendingSecret(ctx, userID, u, code)
+	}
+	if errors.Is(err, ErrInvalidSecondFactor) {
+		return nil, errors.Join(err, s.RecordLoginFailure(ctx, u.username))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.RecordLoginSuccess(ctx, u.username); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM login_challenges WHERE id = ?`, id); err != nil {
+		return nil, fmt.Errorf("deleting login challenge: %w", err)
+	}
+	if result.Token, err = s.CreateSession(ctx, userID); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// BeginTOTPEnrollment starts a self-service enrollment: it stores a new
+// pending secret, replacing any earlier unconfirmed one, and returns it for
+// the user to add to an authenticator app. ConfirmTOTPEnrollment enables it.
+func (s *Service) BeginTOTPEnrollment(ctx context.Context, userID string) (*TOTPEnrollment, error) {
+	u, err := s.loadTwoFactorUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.provider != "local" {
+		return nil, ErrTwoFactorLocalOnly
+	}
+	if u.enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	return s.storePendingSecret(ctx, userID, u.username)
+}
+
+// ConfirmTOTPEnrollment enables the pending secret once the user proves their
+// app produces codes for it, and returns a fresh set of recovery codes.
+func (s *Service) ConfirmTOTPEnrollment(ctx context.Context, userID, code string) ([]string, error) {
+	u, err := s.loadTwoFactorUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.enabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	return s.confirmPendingSecret(ctx, userID, u, code)
+}
+
+// DisableTOTP turns off a user's own two-factor authentication after checking
+// a current code or recovery code. It refuses when the policy requires
+// two-factor authentication for the user's role; an administrator can still
+// reset it with ResetTwoFactor.
+func (s *Service) DisableTOTP(ctx context.Context, userID, code string) error {
+	u, err := s.loadTwoFactorUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !u.enabled {
+		return ErrTwoFactorNotEnabled
+	}
+	policy, err := s.TwoFactorPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	if TwoFactorRequiredFor(policy, u.role) {
+		return ErrTwoFactorRequired
+	}
+	if err := s.verifySecondFactor(ctx, userID, u, code); err != nil {
+		return err
+	}
+	return s.ResetTwoFactor(ctx, userID)
+}
+
+// ResetTwoFactor removes a user's TOTP secret, recovery codes and pending
+// login challenges without asking for a code. It is the administrator and
+// --reset-password path for a user who lost their authenticator; under a
+// policy that covers the user, their next login enrolls them again.
+func (s *Service) ResetTwoFactor(ctx context.Context, userID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning two-factor reset: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after commit success is a no-op
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE users SET totp_secret = '', totp_enabled = 0, totp_last_step = 0 WHERE id = ?
+	`, userID); err != nil {
+		return fmt.Errorf("clearing totp secret: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("deleting recovery codes: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM login_challenges WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("deleting login challenges: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing two-factor reset: %w", err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces a user's recovery codes after checking a
+// current authentication code, and returns the new set.
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	u, err := s.loadTwoFactorUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !u.enabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err := s.verifySecondFactor(ctx, userID, u, code); err != nil {
+		return nil, err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("beginning recovery code regeneration: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after commit success is a no-op
+
+	codes, err := replaceRecoveryCodes(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("committing recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+// GetTwoFactorStatus returns a user's two-factor state.
+func (s *Service) GetTwoFactorStatus(ctx context.Context, userID string) (*TwoFactorStatus, error) {
+	u, err := s.loadTwoFactorUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := s.TwoFactorPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	st := &TwoFactorStatus{
+		Enabled:   u.enabled,
+		Available: u.provider == "local",
+		Required:  u.provider == "local" && TwoFactorRequiredFor(policy, u.role),
+	}
+	if u.enabled {
+		if err := s.db.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = ? AND used_at IS NULL
+		`, userID).Scan(&st.RecoveryCodesRemaining); err != nil {
+			return nil, fmt.Errorf("counting recovery codes: %w", err)
+		}
+	}
+	return st, nil
+}
+
+// storePendingSecret generates and stores an unconfirmed TOTP secret.
+func (s *Service) storePendingSecret(ctx context.Context, userID, username string) (*TOTPEnrollment, error) {
+	if s.encryptor == nil {
+		return nil, ErrNoEncryptor
+	}
+	raw := make([]byte, totpSecretBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("generating totp secret: %w", err)
+	}
+	secret := totpEncoding.EncodeToString(raw)
+	enc, err := s.encryptor.Encrypt(secret)
+	if err != nil {
+		return nil, fmt.Errorf("encrypting totp secret: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE users SET totp_secret = ?, totp_enabled = 0, totp_last_step = 0, updated_at = ? WHERE id = ?
+	`, enc, time.Now().UTC().Format(time.RFC3339), userID); err != nil {
+		return nil, fmt.Errorf("storing totp secret: %w", err)
+	}
+	return &TOTPEnrollment{Secret: secret, URI: totpURI(secret, username)}, nil
+}
+
+// confirmPendingSecret checks code against the pending secret and, if it
+// matches, enables it and issues recovery codes.
+func (s *Service) confirmPendingSecret(ctx context.Context, userID string, u *twoFactorUser, code string) ([]string, error) {
+	if u.secret == "" {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	secret, err := s.decryptSecret(u.secret)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := matchTOTP(secret, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidSecondFactor
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("beginning totp confirmation: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after commit success is a no-op
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE users SET totp_enabled = 1, totp_last_step = ?, updated_at = ? WHERE id = ?
+	`, step, time.Now().UTC().Format(time.RFC3339), userID); err != nil {
+		return nil, fmt.Errorf("enabling totp: %w", err)
+	}
+	codes, err := replaceRecoveryCodes(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("committing totp confirmation: %w", err)
+	}
+	return codes, nil
+}
+
+// verifySecondFactor accepts a current TOTP code or an unused recovery code
+// for an enrolled user. A TOTP code is accepted once: the step it was issued
+// for must be later than the last accepted one, so a code observed in transit
+// cannot be replayed within its window.
+func (s *Service) verifySecondFactor(ctx context.Context, userID string, u *twoFactorUser, code string) error {
+	code = strings.TrimSpace(code)
+	if isTOTPCode(code) {
+		secret, err := s.decryptSecret(u.secret)
+		if err != nil {
+			return err
+		}
+		step, ok := matchTOTP(secret, code, time.Now())
+		if !ok || step <= u.lastStep {
+			return ErrInvalidSecondFactor
+		}
+		res, err := s.db.ExecContext(ctx, `
+			UPDATE users SET totp_last_step = ? WHERE id = ? AND totp_last_step < ?
+		`, step, userID, step)
+		if err != nil {
+			return fmt.Errorf("recording totp step: %w", err)
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return ErrInvalidSecondFactor
+		}
+		return nil
+	}
+
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE user_recovery_codes SET used_at = ?
+		WHERE user_id = ? AND code_hash = ? AND used_at IS NULL
+	`, time.Now().UTC().Format(time.RFC3339), userID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return fmt.Errorf("using recovery code: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return ErrInvalidSecondFactor
+	}
+	return nil
+}
+
+func (s *Service) decryptSecret(enc string) ([]byte, error) {
+	if s.encryptor == nil {
+		return nil, ErrNoEncryptor
+	}
+	secret, err := s.encryptor.Decrypt(enc)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting totp secret: %w", err)
+	}
+	raw, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("decoding totp secret: %w", err)
+	}
+	return raw, nil
+}
+
+// replaceRecoveryCodes deletes a user's recovery codes and inserts a new set,
+// returning the plaintext codes. Only their hashes are stored.
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID string) ([]string, error) {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return nil, fmt.Errorf("deleting recovery codes: %w", err)
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	codes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, fmt.Errorf("generating recovery code: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO user_recovery_codes (id, user_id, code_hash, created_at) VALUES (?, ?, ?, ?)
+		`, uuid.New().String(), userID, hashToken(normalizeRecoveryCode(code)), now); err != nil {
+			return nil, fmt.Errorf("storing recovery code: %w", err)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// totpEncoding is the unpadded base32 alphabet authenticator apps use for
+// secrets.
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// totpURI builds the otpauth:// URI for a secret (the Key Uri Format that
+// authenticator apps read from QR codes).
+func totpURI(secret, username string) string {
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", totpIssuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(totpPeriod))
+	return "otpauth://totp/" + url.PathEscape(totpIssuer+":"+username) + "?" + q.Encode()
+}
+
+// totpCode computes the RFC 6238 code for a time step (RFC 4226 HOTP with
+// dynamic truncation).
+func totpCode(secret []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step)) //nolint:gosec // steps are positive Unix-time quotients
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	off := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, bin%1_000_000)
+}
+
+// matchTOTP reports whether code is valid for secret at now, allowing
+// totpSkew steps of clock drift, and returns the step it matched.
+func matchTOTP(secret []byte, code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if !isTOTPCode(code) {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(secret, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != totpDigits {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// generateRecoveryCode returns a random code shaped "xxxx-xxxx" (40 bits,
+// lowercase base32).
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	s := strings.ToLower(totpEncoding.EncodeToString(b))
+	return s[:4] + "-" + s[4:], nil
+}
+
+// normalizeRecoveryCode lowercases a recovery code and drops the separator
+// and any spaces, so "ABCD-EFGH", "abcd efgh" and "abcdefgh" all match.
+func normalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(code)))
+}
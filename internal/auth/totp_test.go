@@ -0,0 +1,356 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/encryption"
+)
+
+// setupTwoFactorService returns a service with an encryptor and a local
+// administrator "admin" (password "password123"), and that user's ID.
+func setupTwoFactorService(t *testing.T) (*Service, string) {
+	t.Helper()
+	svc := createTestUser(t, "password123")
+	enc, _, err := encryption.NewEncryptor("")
+	if err != nil {
+		t.Fatalf("NewEncryptor: %v", err)
+	}
+	svc.WithEncryptor(enc)
+	var id string
+	if err := svc.db.QueryRowContext(context.Background(), `SELECT id FROM users WHERE username = 'admin'`).Scan(&id); err != nil {
+		t.Fatalf("looking up admin: %v", err)
+	}
+	return svc, id
+}
+
+// codeAt returns the TOTP code for a base32 secret at now plus offset steps.
+func codeAt(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+	raw, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("decoding secret: %v", err)
+	}
+	return totpCode(raw, time.Now().Unix()/totpPeriod+offset)
+}
+
+func setTwoFactorPolicy(t *testing.T, svc *Service, policy string) {
+	t.Helper()
+	if _, err := svc.db.ExecContext(context.Background(), `
+		INSERT INTO settings (key, value, updated_at) VALUES (?, ?, datetime('now'))
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, TwoFactorPolicySetting, policy); err != nil {
+		t.Fatalf("setting policy: %v", err)
+	}
+}
+
+// enroll runs a self-service enrollment and returns the secret and recovery
+// codes. The confirming code is the current step's, so the next accepted
+// code must come from a later step.
+func enroll(t *testing.T, svc *Service, userID string) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+	e, err := svc.BeginTOTPEnrollment(ctx, userID)
+	if err != nil {
+		t.Fatalf("BeginTOTPEnrollment: %v", err)
+	}
+	codes, err := svc.ConfirmTOTPEnrollment(ctx, userID, codeAt(t, e.Secret, 0))
+	if err != nil {
+		t.Fatalf("ConfirmTOTPEnrollment: %v", err)
+	}
+	return e.Secret, codes
+}
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	t.Parallel()
+	// RFC 6238 Appendix B, SHA-1 seed, truncated to our six digits.
+	secret := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tc := range tests {
+		if got := totpCode(secret, tc.unix/totpPeriod); got != tc.want {
+			t.Errorf("totpCode(T=%d) = %s, want %s", tc.unix, got, tc.want)
+		}
+	}
+}
+
+func TestMatchTOTP_Skew(t *testing.T) {
+	t.Parallel()
+	secret := []byte("12345678901234567890")
+	now := time.Unix(1111111111, 0)
+	step := now.Unix() / totpPeriod
+	for _, offset := range []int64{-1, 0, 1} {
+		got, ok := matchTOTP(secret, totpCode(secret, step+offset), now)
+		if !ok || got != step+offset {
+			t.Errorf("offset %d: matchTOTP = (%d, %v), want (%d, true)", offset, got, ok, step+offset)
+		}
+	}
+	if _, ok := matchTOTP(secret, totpCode(secret, step+2), now); ok {
+		t.Error("a code two steps ahead was accepted")
+	}
+	if _, ok := matchTOTP(secret, "12a456", now); ok {
+		t.Error("a non-numeric code was accepted")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	t.Parallel()
+	got := totpURI("JBSWY3DPEHPK3PXP", "alice smith")
+	want := "otpauth://totp/Stillwater:alice%20smith?algorithm=SHA1&digits=6&issuer=Stillwater&period=30&secret=JBSWY3DPEHPK3PXP"
+	if got != want {
+		t.Errorf("totpURI = %s\nwant %s", got, want)
+	}
+}
+
+func TestLogin_NoSecondFactorWithoutEnrollment(t *testing.T) {
+	t.Parallel()
+	svc, _ := setupTwoFactorService(t)
+	token, err := svc.Login(context.Background(), "admin", "password123")
+	if err != nil || token == "" {
+		t.Fatalf("Login = (%q, %v), want a session", token, err)
+	}
+}
+
+func TestTOTPEnrollment_LoginNeedsCode(t *testing.T) {
+	t.Parallel()
+	svc, id := setupTwoFactorService(t)
+	ctx := context.Background()
+	e, err := svc.BeginTOTPEnrollment(ctx, id)
+	if err != nil {
+		t.Fatalf("BeginTOTPEnrollment: %v", err)
+	}
+	secret, confirming := e.Secret, codeAt(t, e.Secret, 0)
+	codes, err := svc.ConfirmTOTPEnrollment(ctx, id, confirming)
+	if err != nil {
+		t.Fatalf("ConfirmTOTPEnrollment: %v", err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("got %d recovery codes, want %d", len(codes), recoveryCodeCount)
+	}
+
+	token, err := svc.Login(ctx, "admin", "password123")
+	var sf *SecondFactorRequiredError
+	if !errors.As(err, &sf) || token != "" {
+		t.Fatalf("Login = (%q, %v), want SecondFactorRequiredError", token, err)
+	}
+	if sf.Enrollment != nil {
+		t.Error("an enrolled user was asked to enroll again")
+	}
+
+	if _, err := svc.CompleteSecondFactor(ctx, sf.Challenge, "000000"); !errors.Is(err, ErrInvalidSecondFactor) {
+		t.Fatalf("wrong code: err = %v, want ErrInvalidSecondFactor", err)
+	}
+	// The code that confirmed the enrollment has been used; replaying it fails.
+	if _, err := svc.CompleteSecondFactor(ctx, sf.Challenge, confirming); !errors.Is(err, ErrInvalidSecondFactor) {
+		t.Fatalf("replayed code: err = %v, want ErrInvalidSecondFactor", err)
+	}
+	res, err := svc.CompleteSecondFactor(ctx, sf.Challenge, codeAt(t, secret, 1))
+	if err != nil {
+		t.Fatalf("CompleteSecondFactor: %v", err)
+	}
+	if _, err := svc.ValidateSession(ctx, res.Token); err != nil {
+		t.Errorf("session from second step is not valid: %v", err)
+	}
+	if _, err := svc.CompleteSecondFactor(ctx, sf.Challenge, codeAt(t, secret, 1)); !errors.Is(err, ErrLoginChallengeInvalid) {
+		t.Errorf("reused challenge: err = %v, want ErrLoginChallengeInvalid", err)
+	}
+}
+
+func TestCompleteSecondFactor_RecoveryCodeIsSingleUse(t *testing.T) {
+	t.Parallel()
+	svc, id := setupTwoFactorService(t)
+	ctx := context.Background()
+	_, codes := enroll(t, svc, id)
+
+	login := func() string {
+		t.Helper()
+		var sf *SecondFactorRequiredError
+		if _, err := svc.Login(ctx, "admin", "password123"); !errors.As(err, &sf) {
+			t.Fatalf("Login: %v, want SecondFactorRequiredError", err)
+		}
+		return sf.Challenge
+	}
+
+	if _, err := svc.CompleteSecondFactor(ctx, login(), " "+strings.ToUpper(codes[0])+" "); err != nil {
+		t.Fatalf("recovery code: %v", err)
+	}
+	if _, err := svc.CompleteSecondFactor(ctx, login(), codes[0]); !errors.Is(err, ErrInvalidSecondFactor) {
+		t.Fatalf("reused recovery code: err = %v, want ErrInvalidSecondFactor", err)
+	}
+	st, err := svc.GetTwoFactorStatus(ctx, id)
+	if err != nil {
+		t.Fatalf("GetTwoFactorStatus: %v", err)
+	}
+	if st.RecoveryCodesRemaining != recoveryCodeCount-1 {
+		t.Errorf("RecoveryCodesRemaining = %d, want %d", st.RecoveryCodesRemaining, recoveryCodeCount-1)
+	}
+}
+
+func TestCompleteSecondFactor_AttemptLimit(t *testing.T) {
+	t.Parallel()
+	svc, id := setupTwoFactorService(t)
+	ctx := context.Background()
+	secret, _ := enroll(t, svc, id)
+
+	var sf *SecondFactorRequiredError
+	if _, err := svc.Login(ctx, "admin", "password123"); !errors.As(err, &sf) {
+		t.Fatalf("Login: %v", err)
+	}
+	for range maxLoginChallengeAttempts {
+		if _, err := svc.CompleteSecondFactor(ctx, sf.Challenge, "000000"); !errors.Is(err, ErrInvalidSecondFactor) {
+			t.Fatalf("wrong code: err = %v", err)
+		}
+	}
+	if _, err := svc.CompleteSecondFactor(ctx, sf.Challenge, codeAt(t, secret, 1)); !errors.Is(err, ErrLoginChallengeInvalid) {
+		t.Errorf("after the attempt limit: err = %v, want ErrLoginChallengeInvalid", err)
+	}
+}
+
+// TestCompleteSecondFactor_WrongCodesLockOut checks that wrong codes count
+// towards the lockout across challenges, so a known password does not buy a
+// fresh set of guesses, and that a correct password alone leaves the count.
+func TestCompleteSecondFactor_WrongCodesLockOut(t *testing.T) {
+	t.Parallel()
+	svc, id := setupTwoFactorService(t)
+	svc.WithLockoutPolicy(LockoutPolicy{UserThreshold: 3, BaseDuration: time.Minute, MaxDuration: time.Hour, Window: 15 * time.Minute})
+	ctx := context.Background()
+	secret, _ := enroll(t, svc, id)
+
+	login := func() string {
+		t.Helper()
+		var sf *SecondFactorRequiredError
+		if _, err := svc.Login(ctx, "admin", "password123"); !errors.As(err, &sf) {
+			t.Fatalf("Login: %v, want SecondFactorRequiredError", err)
+		}
+		return sf.Challenge
+	}
+
+	for _, challenge := range []string{login(), login(), login()} {
+		if _, err := svc.CompleteSecondFactor(ctx, challenge, "000000"); !errors.Is(err, ErrInvalidSecondFactor) {
+			t.Fatalf("wrong code: err = %v, want ErrInvalidSecondFactor", err)
+		}
+	}
+
+	var locked *LockedError
+	if _, err := svc.Login(ctx, "admin", "password123"); !errors.As(err, &locked) {
+		t.Fatalf("Login after wrong codes: err = %v, want LockedError", err)
+	}
+	// A challenge issued before the lockout no longer takes even the right code.
+	if err := svc.UnlockUser(ctx, id, ""); err != nil {
+		t.Fatalf("UnlockUser: %v", err)
+	}
+	challenge := login()
+	for range 3 {
+		_, _ = svc.CompleteSecondFactor(ctx, login(), "000000")
+	}
+	if _, err := svc.CompleteSecondFactor(ctx, challenge, codeAt(t, secret, 1)); !errors.As(err, &locked) {
+		t.Errorf("right code while locked: err = %v, want LockedError", err)
+	}
+}
+
+func TestLogin_PolicyForcesEnrollment(t *testing.T) {
+	t.Parallel()
+	svc, id := setupTwoFactorService(t)
+	ctx := context.Background()
+	setTwoFactorPolicy(t, svc, TwoFactorPolicyAdministrators)
+
+	var sf *SecondFactorRequiredError
+	if _, err := svc.Login(ctx, "admin", "password123"); !errors.As(err, &sf) {
+		t.Fatalf("Login: %v, want SecondFactorRequiredError", err)
+	}
+	if sf.Enrollment == nil || sf.Enrollment.Secret == "" {
+		t.Fatal("policy login did not carry an enrollment")
+	}
+	res, err := svc.CompleteSecondFactor(ctx, sf.Challenge, codeAt(t, sf.Enrollment.Secret, 0))
+	if err != nil {
+		t.Fatalf("CompleteSecondFactor: %v", err)
+	}
+	if res.Token == "" || len(res.RecoveryCodes) != recoveryCodeCount {
+		t.Errorf("result = %+v, want a session and %d recovery codes", res, recoveryCodeCount)
+	}
+	st, err := svc.GetTwoFactorStatus(ctx, id)
+	if err != nil {
+		t.Fatalf("GetTwoFactorStatus: %v", err)
+	}
+	if !st.Enabled || !st.Required {
+		t.Errorf("status = %+v, want enabled and required", st)
+	}
+	if err := svc.DisableTOTP(ctx, id, res.RecoveryCodes[0]); !errors.Is(err, ErrTwoFactorRequired) {
+		t.Errorf("DisableTOTP under policy: err = %v, want ErrTwoFactorRequired", err)
+	}
+}
+
+func TestBeginSecondFactor_FederatedUnaffected(t *testing.T) {
+	t.Parallel()
+	svc, _ := setupTwoFactorService(t)
+	ctx := context.Background()
+	setTwoFactorPolicy(t, svc, TwoFactorPolicyEveryone)
+
+	u, err := svc.CreateFederatedUser(ctx, &Identity{ProviderID: "emby-1", DisplayName: "Fed", ProviderType: "emby"}, "operator", "")
+	if err != nil {
+		t.Fatalf("CreateFederatedUser: %v", err)
+	}
+	if err := svc.BeginSecondFactor(ctx, u.ID); err != nil {
+		t.Errorf("BeginSecondFactor(federated) = %v, want nil", err)
+	}
+	if _, err := svc.BeginTOTPEnrollment(ctx, u.ID); !errors.Is(err, ErrTwoFactorLocalOnly) {
+		t.Errorf("BeginTOTPEnrollment(federated) = %v, want ErrTwoFactorLocalOnly", err)
+	}
+}
+
+func TestDisableAndResetTwoFactor(t *testing.T) {
+	t.Parallel()
+	svc, id := setupTwoFactorService(t)
+	ctx := context.Background()
+	secret, _ := enroll(t, svc, id)
+
+	if err := svc.DisableTOTP(ctx, id, "000000"); !errors.Is(err, ErrInvalidSecondFactor) {
+		t.Fatalf("DisableTOTP with a wrong code: err = %v", err)
+	}
+	if err := svc.DisableTOTP(ctx, id, codeAt(t, secret, 1)); err != nil {
+		t.Fatalf("DisableTOTP: %v", err)
+	}
+	if token, err := svc.Login(ctx, "admin", "password123"); err != nil || token == "" {
+		t.Fatalf("Login after disable = (%q, %v), want a session", token, err)
+	}
+
+	enroll(t, svc, id)
+	if err := svc.ResetTwoFactor(ctx, id); err != nil {
+		t.Fatalf("ResetTwoFactor: %v", err)
+	}
+	u, err := svc.GetUserByID(ctx, id)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if u.TwoFactorEnabled {
+		t.Error("TwoFactorEnabled still set after ResetTwoFactor")
+	}
+	var n int
+	if err := svc.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = ?`, id).Scan(&n); err != nil || n != 0 {
+		t.Errorf("recovery codes after reset = %d (err %v), want 0", n, err)
+	}
+}
+
+func TestBeginTOTPEnrollment_NeedsEncryptor(t *testing.T) {
+	t.Parallel()
+	svc := createTestUser(t, "password123")
+	var id string
+	if err := svc.db.QueryRowContext(context.Background(), `SELECT id FROM users WHERE username = 'admin'`).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.BeginTOTPEnrollment(context.Background(), id); !errors.Is(err, ErrNoEncryptor) {
+		t.Errorf("err = %v, want ErrNoEncryptor", err)
+	}
+}
@@ -21,6 +21,15 @@ var ErrLastAdmin = errors.New("cannot remove or downgrade the last administrator
 // protected from modification.
 var ErrProtectedUser = errors.New("cannot modify or deactivate the protected bootstrap administrator")
 
+// ValidRole reports whether role is one a user account can hold. An
+// "administrator" manages the server and other accounts, an "operator"
+// curates library content, and a "viewer" browses artists, reports and
+// history without changing anything (the Auth middleware refuses a viewer's
+// mutating requests).
+func ValidRole(role string) bool {
+	return role == "administrator" || role == "operator" || role == "viewer"
+}
+
 // ErrSelfDelete is returned when an administrator attempts to permanently
 // delete their own user account from the admin Users panel; that surface is
 // for cleaning up OTHER accounts. Self-account changes route through the
@@ -42,8 +51,18 @@ type User struct {
 	// session creation; nil when the user has never logged in (the inactive
 	// admin filter treats nil as "never logged in").
 	LastLogin *string `json:"last_login,omitempty"`
-	CreatedAt string  `json:"created_at"`
-	UpdatedAt string  `json:"updated_at"`
+	// LibraryIDs are the libraries an operator or viewer is granted; empty
+	// means every library. Administrators are never restricted, whatever is
+	// stored here (see SetUserLibraries).
+	LibraryIDs []string `json:"library_ids,omitempty"`
+	// TwoFactorEnabled reports a confirmed TOTP enrollment (see totp.go); a
+	// pending, unconfirmed secret does not count.
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
+	// LockedUntil is the RFC3339 time a sign-in lockout of this username
+	// ends (see LockoutPolicy); nil when it is not locked.
+	LockedUntil *string `json:"locked_until,omitempty"`
+	CreatedAt   string  `json:"created_at"`
+	UpdatedAt   string  `json:"updated_at"`
 }
 
 // GetUserByID returns a user by their ID. Returns an error wrapping
@@ -53,14 +72,18 @@ func (s *Service) GetUserByID(ctx context.Context, id string) (*User, error) {
 	var invitedBy sql.NullString
 	var providerID sql.NullString
 	var lastLogin sql.NullString
+	var libraryIDs string
+	var lockedUntil sql.NullString
 
 	err := s.db.QueryRowContext(ctx, `
 		SELECT id, username, display_name, role, auth_provider, provider_id,
-		       is_active, is_protected, invited_by, last_login, created_at, updated_at
+		       is_active, is_protected, invited_by, last_login, created_at, updated_at,
+		       library_ids, totp_enabled, `+lockedUntilColumn+`
 		FROM users WHERE id = ?
 	`, id).Scan(
 		&u.ID, &u.Username, &u.DisplayName, &u.Role, &u.AuthProvider,
 		&providerID, &u.IsActive, &u.IsProtected, &invitedBy, &lastLogin, &u.CreatedAt, &u.UpdatedAt,
+		&libraryIDs, &u.TwoFactorEnabled, &lockedUntil,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, fmt.Errorf("user not found: %w", sql.ErrNoRows)
@@ -78,6 +101,10 @@ func (s *Service) GetUserByID(ctx context.Context, id string) (*User, error) {
 	if lastLogin.Valid {
 		u.LastLogin = &lastLogin.String
 	}
+	u.LibraryIDs = ParseLibraryIDs(libraryIDs)
+	if lockedUntil.Valid {
+		u.LockedUntil = &lockedUntil.String
+	}
 
 	return &u, nil
 }
@@ -102,7 +129,8 @@ func (s *Service) GetUserRole(ctx context.Context, userID string) (string, error
 func (s *Service) ListUsers(ctx context.Context) ([]User, error) {
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT id, username, display_name, role, auth_provider, provider_id,
-		       is_active, is_protected, invited_by, last_login, created_at, updated_at
+		       is_active, is_protected, invited_by, last_login, created_at, updated_at,
+		       library_ids, totp_enabled, `+lockedUntilColumn+`
 		FROM users ORDER BY created_at ASC
 	`)
 	if err != nil {
@@ -120,7 +148,8 @@ func (s *Service) ListUsers(ctx context.Context) ([]User, error) {
 func (s *Service) ListInactiveUsers(ctx context.Context, thresholdDays int) ([]User, error) {
 	const baseSelect = `
 		SELECT id, username, display_name, role, auth_provider, provider_id,
-		       is_active, is_protected, invited_by, last_login, created_at, updated_at
+		       is_active, is_protected, invited_by, last_login, created_at, updated_at,
+		       library_ids, totp_enabled, ` + lockedUntilColumn + `
 		FROM users
 	`
 	var (
@@ -156,10 +185,13 @@ func scanUsers(rows *sql.Rows) ([]User, error) {
 		var invitedBy sql.NullString
 		var providerID sql.NullString
 		var lastLogin sql.NullString
+		var libraryIDs string
+		var lockedUntil sql.NullString
 
 		if err := rows.Scan(
 			&u.ID, &u.Username, &u.DisplayName, &u.Role, &u.AuthProvider,
 			&providerID, &u.IsActive, &u.IsProtected, &invitedBy, &lastLogin, &u.CreatedAt, &u.UpdatedAt,
+			&libraryIDs, &u.TwoFactorEnabled, &lockedUntil,
 		); err != nil {
 			return nil, fmt.Errorf("scanning user: %w", err)
 		}
@@ -173,6 +205,10 @@ func scanUsers(rows *sql.Rows) ([]User, error) {
 		if lastLogin.Valid {
 			u.LastLogin = &lastLogin.String
 		}
+		u.LibraryIDs = ParseLibraryIDs(libraryIDs)
+		if lockedUntil.Valid {
+			u.LockedUntil = &lockedUntil.String
+		}
 
 		users = append(users, u)
 	}
@@ -182,16 +218,17 @@ func scanUsers(rows *sql.Rows) ([]User, error) {
 	return users, nil
 }
 
-// UpdateUserRole changes a user's role. Valid roles are "administrator" and
-// "operator". Returns ErrProtectedUser if downgrading the protected bootstrap
-// administrator, and ErrLastAdmin if downgrading the last active administrator.
+// UpdateUserRole changes a user's role. Valid roles are "administrator",
+// "operator" and "viewer" (see ValidRole). Returns ErrProtectedUser if
+// downgrading the protected bootstrap administrator, and ErrLastAdmin if
+// downgrading the last active administrator.
 // The checks and role update run inside a BEGIN IMMEDIATE transaction
 // to prevent concurrent downgrades from racing past the safeguards.
 //
 //nolint:gocognit // Txn-bound precondition chain: role enum gate, current-role query, no-op short-circuit, protected-user guard on downgrade, last-admin guard, then a constrained UPDATE that also recognizes the SQL trigger's "cannot change role of a protected user" error so the API returns ErrProtectedUser instead of a generic error. The guards are the security invariant; splitting them into helpers would only obscure ordering.
 func (s *Service) UpdateUserRole(ctx context.Context, userID, newRole string) error {
-	if newRole != "administrator" && newRole != "operator" {
-		return fmt.Errorf("invalid role %q: must be administrator or operator", newRole)
+	if !ValidRole(newRole) {
+		return fmt.Errorf("invalid role %q: must be administrator, operator, or viewer", newRole)
 	}
 
 	return s.withImmediateTx(ctx, func(conn *sql.Conn) error {
@@ -211,7 +248,7 @@ func (s *Service) UpdateUserRole(ctx context.Context, userID, newRole string) er
 			return nil
 		}
 
-		if newRole == "operator" {
+		if newRole != "administrator" {
 			if isProtected {
 				return ErrProtectedUser
 			}
@@ -253,6 +290,50 @@ func (s *Service) UpdateUserRole(ctx context.Context, userID, newRole string) er
 	})
 }
 
+// SetUserLibraries replaces the libraries a user is granted. An empty list
+// lifts the restriction. The grant is stored whatever the user's role but is
+// only enforced for operators and viewers, so an administrator who is later
+// downgraded picks it up again (see UserLibraryIDs).
+func (s *Service) SetUserLibraries(ctx context.Context, userID string, libraryIDs []string) error {
+	ids := strings.Join(ParseLibraryIDs(strings.Join(libraryIDs, ",")), ",")
+	now := time.Now().UTC().Format(time.RFC3339)
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE users SET library_ids = ?, updated_at = ? WHERE id = ?
+	`, ids, now, userID)
+	if err != nil {
+		return fmt.Errorf("updating user libraries: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("user not found: %w", sql.ErrNoRows)
+	}
+	return nil
+}
+
+// UserLibraryIDs returns the libraries an active user is restricted to, or
+// nil when the user may touch every library: administrators, users without
+// a grant, and unknown or inactive users (whom the caller refuses anyway via
+// GetUserRole).
+func (s *Service) UserLibraryIDs(ctx context.Context, userID string) ([]string, error) {
+	var role, ids string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT role, library_ids FROM users WHERE id = ? AND is_active = 1
+	`, userID).Scan(&role, &ids)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting user libraries: %w", err)
+	}
+	if role == "administrator" {
+		return nil, nil
+	}
+	return ParseLibraryIDs(ids), nil
+}
+
 // DeactivateUser sets a user's is_active flag to 0 and deletes all their
 // sessions. Returns ErrProtectedUser if the user is the bootstrap administrator,
 // and ErrLastAdmin if deactivating the last active administrator.
@@ -431,8 +512,8 @@ func (s *Service) DeleteUser(ctx context.Context, actorID, targetID, reason stri
 // CreateLocalUser creates a new user with local password authentication.
 // The password is bcrypt-hashed via PrehashPassword before storage.
 func (s *Service) CreateLocalUser(ctx context.Context, username, password, displayName, role, invitedBy string) (*User, error) {
-	if role != "administrator" && role != "operator" {
-		return nil, fmt.Errorf("invalid role %q: must be administrator or operator", role)
+	if !ValidRole(role) {
+		return nil, fmt.Errorf("invalid role %q: must be administrator, operator, or viewer", role)
 	}
 
 	hash, err := bcrypt.GenerateFromPassword(PrehashPassword(password), bcrypt.DefaultCost)
@@ -478,8 +559,8 @@ func (s *Service) CreateFederatedUser(ctx context.Context, identity *Identity, r
 	if identity.DisplayName == "" {
 		return nil, fmt.Errorf("identity display name must not be empty")
 	}
-	if role != "administrator" && role != "operator" {
-		return nil, fmt.Errorf("invalid role %q: must be administrator or operator", role)
+	if !ValidRole(role) {
+		return nil, fmt.Errorf("invalid role %q: must be administrator, operator, or viewer", role)
 	}
 
 	id := uuid.New().String()
@@ -524,14 +605,18 @@ func (s *Service) GetUserByProvider(ctx context.Context, authProvider, providerI
 	var invitedBy sql.NullString
 	var pID sql.NullString
 	var lastLogin sql.NullString
+	var libraryIDs string
+	var lockedUntil sql.NullString
 
 	err := s.db.QueryRowContext(ctx, `
 		SELECT id, username, display_name, role, auth_provider, provider_id,
-		       is_active, is_protected, invited_by, last_login, created_at, updated_at
+		       is_active, is_protected, invited_by, last_login, created_at, updated_at,
+		       library_ids, totp_enabled, `+lockedUntilColumn+`
 		FROM users WHERE auth_provider = ? AND provider_id = ?
 	`, authProvider, providerID).Scan(
 		&u.ID, &u.Username, &u.DisplayName, &u.Role, &u.AuthProvider,
 		&pID, &u.IsActive, &u.IsProtected, &invitedBy, &lastLogin, &u.CreatedAt, &u.UpdatedAt,
+		&libraryIDs, &u.TwoFactorEnabled, &lockedUntil,
 	)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, fmt.Errorf("user not found: %w", sql.ErrNoRows)
@@ -549,6 +634,10 @@ func (s *Service) GetUserByProvider(ctx context.Context, authProvider, providerI
 	if lastLogin.Valid {
 		u.LastLogin = &lastLogin.String
 	}
+	u.LibraryIDs = ParseLibraryIDs(libraryIDs)
+	if lockedUntil.Valid {
+		u.LockedUntil = &lockedUntil.String
+	}
 
 	return &u, nil
 }
@@ -216,6 +216,73 @@ func TestUpdateUserRole_LastAdmin(t *testing.T) {
 	}
 }
 
+func TestUpdateUserRole_Viewer(t *testing.T) {
+	t.Parallel()
+	svc := setupTestService(t)
+	ctx := context.Background()
+
+	// A sole unprotected admin cannot become a viewer any more than an operator.
+	admin, err := svc.CreateLocalUser(ctx, "admin", "password", "Admin", "administrator", "")
+	if err != nil {
+		t.Fatalf("CreateLocalUser: %v", err)
+	}
+	if err := svc.UpdateUserRole(ctx, admin.ID, "viewer"); !errors.Is(err, ErrLastAdmin) {
+		t.Errorf("UpdateUserRole last admin to viewer = %v, want ErrLastAdmin", err)
+	}
+
+	op, err := svc.CreateLocalUser(ctx, "op1", "pass1", "Op One", "operator", "")
+	if err != nil {
+		t.Fatalf("CreateLocalUser: %v", err)
+	}
+	if err := svc.UpdateUserRole(ctx, op.ID, "viewer"); err != nil {
+		t.Fatalf("UpdateUserRole to viewer: %v", err)
+	}
+	if role, _ := svc.GetUserRole(ctx, op.ID); role != "viewer" {
+		t.Errorf("role = %q, want %q", role, "viewer")
+	}
+}
+
+func TestSetUserLibraries(t *testing.T) {
+	t.Parallel()
+	svc := setupTestService(t)
+	ctx := context.Background()
+
+	op, err := svc.CreateLocalUser(ctx, "op1", "pass1", "Op One", "operator", "")
+	if err != nil {
+		t.Fatalf("CreateLocalUser: %v", err)
+	}
+	if err := svc.SetUserLibraries(ctx, op.ID, []string{"lib-a", " lib-b", "lib-a"}); err != nil {
+		t.Fatalf("SetUserLibraries: %v", err)
+	}
+
+	got, err := svc.UserLibraryIDs(ctx, op.ID)
+	if err != nil {
+		t.Fatalf("UserLibraryIDs: %v", err)
+	}
+	if strings.Join(got, ",") != "lib-a,lib-b" {
+		t.Errorf("UserLibraryIDs = %v, want [lib-a lib-b]", got)
+	}
+	user, err := svc.GetUserByID(ctx, op.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if strings.Join(user.LibraryIDs, ",") != "lib-a,lib-b" {
+		t.Errorf("User.LibraryIDs = %v, want [lib-a lib-b]", user.LibraryIDs)
+	}
+
+	// An administrator's stored grant is not enforced.
+	if err := svc.UpdateUserRole(ctx, op.ID, "administrator"); err != nil {
+		t.Fatalf("UpdateUserRole: %v", err)
+	}
+	if got, _ := svc.UserLibraryIDs(ctx, op.ID); got != nil {
+		t.Errorf("administrator UserLibraryIDs = %v, want nil", got)
+	}
+
+	if err := svc.SetUserLibraries(ctx, "missing", nil); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("SetUserLibraries(missing) = %v, want sql.ErrNoRows", err)
+	}
+}
+
 func TestDeactivateUser(t *testing.T) {
 	t.Parallel()
 	svc := setupTestService(t)
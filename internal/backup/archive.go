@@ -0,0 +1,609 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	img "github.com/sydlexius/stillwater/internal/image"
+	"github.com/sydlexius/stillwater/internal/settingsio"
+	"github.com/sydlexius/stillwater/internal/version"
+)
+
+// archiveFormatVersion is the Manifest.FormatVersion written by Archive. Bump
+// it when the archive layout changes in a way older binaries cannot restore.
+const archiveFormatVersion = 1
+
+// Fixed entry names inside an archive. Artwork lives under
+// artwork/<artist id>/ and cached images under cache/<artist id>/, each with
+// the file's path relative to the artist directory (or the artist's cache
+// directory) below that.
+const (
+	manifestEntry = "manifest.json"
+	databaseEntry = "database/stillwater.db"
+	settingsEntry = "settings/settings.json"
+)
+
+// File kinds recorded in ManifestFile.Kind.
+const (
+	FileDatabase = "database"
+	FileSettings = "settings"
+	FileArtwork  = "artwork"
+	FileCache    = "cache"
+)
+
+// ErrNotArchive is returned for a backup filename that names a bare database
+// snapshot rather than a full archive.
+var ErrNotArchive = errors.New("backup is a database snapshot, not a full archive")
+
+// ErrNotInArchive is returned by RestoreArchive when the requested library or
+// artist does not appear in the archive, and by ArchiveSettings when the
+// archive was made without a settings envelope.
+var ErrNotInArchive = errors.New("not in backup archive")
+
+// ErrCorruptArchive is returned when an archive entry is missing or does not
+// match the size and checksum its manifest records.
+var ErrCorruptArchive = errors.New("backup archive is corrupt")
+
+// SettingsExporter produces the portable settings envelope carried in an
+// archive. *settingsio.Service implements it.
+type SettingsExporter interface {
+	Export(ctx context.Context, passphrase string) (*settingsio.Envelope, error)
+}
+
+// ArchiveOptions selects what a full archive carries beyond the database
+// snapshot.
+type ArchiveOptions struct {
+	// Artwork adds the images Stillwater wrote into artist directories
+	// (recognized by their provenance tag), the pre-edit originals it keeps
+	// beside them, and the image cache of artists without a directory.
+	Artwork bool `json:"artwork"`
+	// Passphrase, when set, adds the settings envelope encrypted with it.
+	// The envelope is the same file a settings export produces.
+	Passphrase string `json:"-"`
+}
+
+// Manifest is the table of contents of an archive, stored as manifest.json.
+// Every other entry is listed in Files with its size and SHA-256, so an
+// archive can be checked before anything in it is trusted.
+type Manifest struct {
+	FormatVersion int              `json:"format_version"`
+	AppVersion    string           `json:"app_version"`
+	CreatedAt     time.Time        `json:"created_at"`
+	Settings      bool             `json:"settings"`
+	Artwork       bool             `json:"artwork"`
+	Artists       []ManifestArtist `json:"artists"`
+	Files         []ManifestFile   `json:"files"`
+	// Skipped lists the ids of artists whose artwork could not be read when
+	// the archive was made, so a partial archive says so.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// ManifestArtist records an artist known when the archive was made, so a
+// restore can be scoped to one artist or one library.
+type ManifestArtist struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Path       string   `json:"path,omitempty"`
+	LibraryIDs []string `json:"library_ids,omitempty"`
+}
+
+// ManifestFile describes one archive entry.
+type ManifestFile struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	// ArtistID and Rel locate artwork and cache files: Rel is the path below
+	// the artist directory or the artist's cache directory, slash-separated.
+	ArtistID string `json:"artist_id,omitempty"`
+	Rel      string `json:"rel,omitempty"`
+}
+
+// artworkExtensions are the image formats Stillwater writes and tags with
+// provenance.
+var artworkExtensions = map[string]bool{".jpg": true, ".jpeg": true, ".png": true}
+
+// WithSettingsExporter attaches the settings exporter used for archives made
+// with a passphrase, and returns the service for chaining.
+func (s *Service) WithSettingsExporter(e SettingsExporter) *Service {
+	s.settings = e
+	return s
+}
+
+// WithImageCacheDir sets the image cache directory, where artwork for artists
+// without a directory is kept, and returns the service for chaining.
+func (s *Service) WithImageCacheDir(dir string) *Service {
+	s.imageCacheDir = dir
+	return s
+}
+
+// ScheduleArchives makes the scheduler write full archives with opts instead
+// of database snapshots. A scheduled archive has no one to ask for a
+// passphrase, so it never carries the settings envelope.
+func (s *Service) ScheduleArchives(opts ArchiveOptions) {
+	opts.Passphrase = ""
+	s.mu.Lock()
+	s.scheduled = &opts
+	s.mu.Unlock()
+}
+
+// scheduledBackup makes the backup the scheduler is configured for.
+func (s *Service) scheduledBackup(ctx context.Context) (*BackupInfo, error) {
+	s.mu.RLock()
+	opts := s.scheduled
+	s.mu.RUnlock()
+	if opts == nil {
+		return s.Backup(ctx)
+	}
+	return s.Archive(ctx, *opts)
+}
+
+// Archive writes a full backup: a zip holding a VACUUM INTO snapshot of the
+// database, the settings envelope when opts carries a passphrase, the artwork
+// when opts.Artwork is set, and a manifest with the size and SHA-256 of each.
+// It is staged owner-only and installed like a snapshot (see Backup), as
+// stillwater-YYYYMMDD-HHMMSS.zip, or .zip.enc when the service has a key.
+//
+// An artist directory that cannot be read does not fail the archive; the
+// artist is listed in Manifest.Skipped and logged.
+func (s *Service) Archive(ctx context.Context, opts ArchiveOptions) (*BackupInfo, error) {
+	if opts.Passphrase != "" && s.settings == nil {
+		return nil, errors.New("settings export is not available")
+	}
+	if err := os.MkdirAll(s.backupDir, 0o750); err != nil {
+		return nil, fmt.Errorf("creating backup directory: %w", err)
+	}
+
+	now := s.clock.Now()
+	baseFilename := fmt.Sprintf("stillwater-%s.zip", now.Format("20060102-150405"))
+
+	s.logger.Info("starting archive backup",
+		slog.String("dest", filepath.Join(s.backupDir, baseFilename)),
+		slog.Bool("artwork", opts.Artwork),
+		slog.Bool("settings", opts.Passphrase != ""))
+
+	stagingDir, err := osMkdirTemp(s.backupDir, ".archive-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating staging directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(stagingDir) }()
+
+	dbPath := filepath.Join(stagingDir, "stillwater.db")
+	if _, err := s.db.ExecContext(ctx, "VACUUM INTO ?", dbPath); err != nil {
+		return nil, fmt.Errorf("VACUUM INTO: %w", err)
+	}
+
+	stagingPath := filepath.Join(stagingDir, baseFilename)
+	f, err := os.OpenFile(stagingPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) //nolint:gosec // G304: stagingPath is inside the private staging directory created above.
+	if err != nil {
+		return nil, fmt.Errorf("creating archive: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	aw := &archiveWriter{zw: zip.NewWriter(f)}
+	m := Manifest{
+		FormatVersion: archiveFormatVersion,
+		AppVersion:    version.Version,
+		CreatedAt:     now,
+		Artwork:       opts.Artwork,
+	}
+
+	if err := aw.addFile(ManifestFile{Name: databaseEntry, Kind: FileDatabase}, dbPath, zip.Deflate); err != nil {
+		return nil, err
+	}
+
+	if opts.Passphrase != "" {
+		env, err := s.settings.Export(ctx, opts.Passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("exporting settings: %w", err)
+		}
+		data, err := json.MarshalIndent(env, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("encoding settings: %w", err)
+		}
+		if err := aw.addBytes(ManifestFile{Name: settingsEntry, Kind: FileSettings}, data); err != nil {
+			return nil, err
+		}
+		m.Settings = true
+	}
+
+	m.Artists, err = s.listArtists(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Artwork {
+		for _, a := range m.Artists {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if err := s.addArtistFiles(aw, a); err != nil {
+				if errors.Is(err, errArchiveWrite) {
+					return nil, err
+				}
+				s.logger.Warn("skipping artwork of artist in archive",
+					slog.String("artist_id", a.ID),
+					slog.String("path", a.Path),
+					slog.String("error", err.Error()))
+				m.Skipped = append(m.Skipped, a.ID)
+			}
+		}
+	}
+
+	m.Files = aw.files
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding manifest: %w", err)
+	}
+	w, err := aw.zw.CreateHeader(&zip.FileHeader{Name: manifestEntry, Method: zip.Deflate, Modified: now})
+	if err != nil {
+		return nil, fmt.Errorf("writing manifest: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("writing manifest: %w", err)
+	}
+	if err := aw.zw.Close(); err != nil {
+		return nil, fmt.Errorf("finishing archive: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return nil, fmt.Errorf("syncing archive: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("closing archive: %w", err)
+	}
+
+	return s.install(stagingPath, baseFilename, now)
+}
+
+// errArchiveWrite marks a failure writing the archive itself, as opposed to
+// reading one artist's files, so Archive can tell which one to give up on.
+var errArchiveWrite = errors.New("writing archive")
+
+// archiveWriter adds checksummed entries to a zip and records them.
+type archiveWriter struct {
+	zw    *zip.Writer
+	files []ManifestFile
+}
+
+// addFile copies the file at src into the archive as mf.Name. Errors opening
+// or reading src are returned as they are; errors writing the archive wrap
+// errArchiveWrite.
+func (aw *archiveWriter) addFile(mf ManifestFile, src string, method uint16) error {
+	f, err := os.Open(src) //nolint:gosec // G304: src comes from the database snapshot or a walk of a known artist or cache directory.
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return aw.add(mf, f, method, fi.ModTime())
+}
+
+// addBytes adds data to the archive as mf.Name.
+func (aw *archiveWriter) addBytes(mf ManifestFile, data []byte) error {
+	return aw.add(mf, bytes.NewReader(data), zip.Deflate, time.Now())
+}
+
+func (aw *archiveWriter) add(mf ManifestFile, r io.Reader, method uint16, modified time.Time) error {
+	w, err := aw.zw.CreateHeader(&zip.FileHeader{Name: mf.Name, Method: method, Modified: modified})
+	if err != nil {
+		return fmt.Errorf("%w %s: %w", errArchiveWrite, mf.Name, err)
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, h), r)
+	if err != nil {
+		return fmt.Errorf("%w %s: %w", errArchiveWrite, mf.Name, err)
+	}
+	mf.Size = n
+	mf.SHA256 = hex.EncodeToString(h.Sum(nil))
+	aw.files = append(aw.files, mf)
+	return nil
+}
+
+// listArtists returns every artist with its directory and library
+// memberships.
+func (s *Service) listArtists(ctx context.Context) ([]ManifestArtist, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT a.id, a.name, a.path,
+		       COALESCE((SELECT group_concat(library_id) FROM artist_libraries WHERE artist_id = a.id), '')
+		FROM artists a
+		ORDER BY a.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("listing artists: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck // Close error not actionable on cleanup
+
+	var artists []ManifestArtist
+	for rows.Next() {
+		var a ManifestArtist
+		var libs string
+		if err := rows.Scan(&a.ID, &a.Name, &a.Path, &libs); err != nil {
+			return nil, fmt.Errorf("scanning artist: %w", err)
+		}
+		if libs != "" {
+			a.LibraryIDs = strings.Split(libs, ",")
+			sort.Strings(a.LibraryIDs)
+		}
+		artists = append(artists, a)
+	}
+	return artists, rows.Err()
+}
+
+// addArtistFiles adds the artwork Stillwater wrote for one artist: tagged
+// images at the top of its directory, everything under the directory's
+// pre-edit backup subdirectory, and everything in its image cache directory.
+func (s *Service) addArtistFiles(aw *archiveWriter, a ManifestArtist) error {
+	if a.Path != "" {
+		rels, err := artworkFiles(a.Path)
+		if err != nil {
+			return err
+		}
+		for _, rel := range rels {
+			mf := ManifestFile{Name: path.Join("artwork", a.ID, rel), Kind: FileArtwork, ArtistID: a.ID, Rel: rel}
+			if err := aw.addFile(mf, filepath.Join(a.Path, filepath.FromSlash(rel)), zip.Store); err != nil {
+				return err
+			}
+		}
+	}
+	if s.imageCacheDir != "" {
+		dir := filepath.Join(s.imageCacheDir, a.ID)
+		rels, err := regularFiles(dir)
+		if err != nil {
+			return err
+		}
+		for _, rel := range rels {
+			mf := ManifestFile{Name: path.Join("cache", a.ID, rel), Kind: FileCache, ArtistID: a.ID, Rel: rel}
+			if err := aw.addFile(mf, filepath.Join(dir, filepath.FromSlash(rel)), zip.Store); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// artworkFiles returns the slash-separated paths, relative to dir, of the
+// images at the top of dir that carry Stillwater provenance, followed by the
+// files under its pre-edit backup subdirectory. Images from elsewhere (the
+// media server, the user's own files) are left out: they are not Stillwater's
+// to restore. A missing dir has no artwork.
+func artworkFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rels []string
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !artworkExtensions[strings.ToLower(filepath.Ext(e.Name()))] {
+			continue
+		}
+		meta, err := img.ReadProvenance(filepath.Join(dir, e.Name()))
+		if err != nil || meta == nil {
+			continue
+		}
+		rels = append(rels, e.Name())
+	}
+	originals, err := regularFiles(filepath.Join(dir, img.BackupDirName))
+	if err != nil {
+		return nil, err
+	}
+	for _, rel := range originals {
+		rels = append(rels, path.Join(img.BackupDirName, rel))
+	}
+	return rels, nil
+}
+
+// regularFiles returns the slash-separated paths, relative to dir, of every
+// regular file below dir. Symlinks are not followed. A missing dir has none.
+func regularFiles(dir string) ([]string, error) {
+	var rels []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p == dir {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rels = append(rels, filepath.ToSlash(rel))
+		return nil
+	})
+	return rels, err
+}
+
+// archiveReader is an open full archive. For an encrypted archive it reads
+// a decrypted copy in a private staging directory that Close removes.
+type archiveReader struct {
+	*zip.ReadCloser
+	stagingDir string
+}
+
+// Close closes the archive and removes any decrypted copy.
+func (a *archiveReader) Close() error {
+	err := a.ReadCloser.Close()
+	if a.stagingDir != "" {
+		_ = os.RemoveAll(a.stagingDir)
+	}
+	return err
+}
+
+// openArchive opens a full archive in the backup directory, decrypting it
+// first when it is encrypted, and reads its manifest.
+func (s *Service) openArchive(filename string) (*archiveReader, *Manifest, error) {
+	if !IsValidBackupFilename(filename) {
+		return nil, nil, fmt.Errorf("invalid backup filename")
+	}
+	if kindOf(filename) != KindArchive {
+		return nil, nil, ErrNotArchive
+	}
+	path := filepath.Join(s.backupDir, filename)
+	var stagingDir string
+	if isEncrypted(filename) {
+		if _, err := os.Stat(path); err != nil {
+			return nil, nil, fmt.Errorf("opening archive: %w", err)
+		}
+		var err error
+		stagingDir, err = osMkdirTemp(s.backupDir, ".decrypt-*")
+		if err != nil {
+			return nil, nil, fmt.Errorf("creating staging directory: %w", err)
+		}
+		path, err = s.plaintextPath(filename, stagingDir)
+		if err != nil {
+			_ = os.RemoveAll(stagingDir)
+			if errors.Is(err, ErrCorruptBackup) {
+				return nil, nil, fmt.Errorf("%w: %w", ErrCorruptArchive, err)
+			}
+			return nil, nil, err
+		}
+	}
+	zr, m, err := readArchive(path)
+	if err != nil {
+		if stagingDir != "" {
+			_ = os.RemoveAll(stagingDir)
+		}
+		return nil, nil, err
+	}
+	return &archiveReader{ReadCloser: zr, stagingDir: stagingDir}, m, nil
+}
+
+// readArchive opens the plaintext archive at path and reads its manifest.
+func readArchive(path string) (*zip.ReadCloser, *Manifest, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, fmt.Errorf("opening archive: %w", err)
+		}
+		return nil, nil, fmt.Errorf("%w: %w", ErrCorruptArchive, err)
+	}
+	f, err := zr.Open(manifestEntry)
+	if err != nil {
+		_ = zr.Close()
+		return nil, nil, fmt.Errorf("%w: no manifest", ErrCorruptArchive)
+	}
+	defer func() { _ = f.Close() }()
+	var m Manifest
+	if err := json.NewDecoder(f).Decode(&m); err != nil {
+		_ = zr.Close()
+		return nil, nil, fmt.Errorf("%w: reading manifest: %w", ErrCorruptArchive, err)
+	}
+	if m.FormatVersion > archiveFormatVersion {
+		_ = zr.Close()
+		return nil, nil, fmt.Errorf("archive format %d is newer than this version of Stillwater supports", m.FormatVersion)
+	}
+	return zr, &m, nil
+}
+
+// ReadManifest returns the manifest of a full archive.
+func (s *Service) ReadManifest(filename string) (*Manifest, error) {
+	zr, m, err := s.openArchive(filename)
+	if err != nil {
+		return nil, err
+	}
+	_ = zr.Close()
+	return m, nil
+}
+
+// VerifyArchive checks every entry of a full archive against the size and
+// checksum in its manifest. It returns an error wrapping ErrCorruptArchive
+// naming the first entry that does not match.
+func (s *Service) VerifyArchive(filename string) error {
+	zr, m, err := s.openArchive(filename)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = zr.Close() }()
+	for _, mf := range m.Files {
+		if err := extractEntry(&zr.Reader, mf, io.Discard); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ArchiveSettings returns the settings envelope carried in a full archive,
+// checked against its manifest. It returns ErrNotInArchive when the archive
+// was made without one.
+func (s *Service) ArchiveSettings(filename string) (*settingsio.Envelope, error) {
+	zr, m, err := s.openArchive(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = zr.Close() }()
+	for _, mf := range m.Files {
+		if mf.Kind != FileSettings {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := extractEntry(&zr.Reader, mf, &buf); err != nil {
+			return nil, err
+		}
+		var env settingsio.Envelope
+		if err := json.Unmarshal(buf.Bytes(), &env); err != nil {
+			return nil, fmt.Errorf("%w: reading settings: %w", ErrCorruptArchive, err)
+		}
+		return &env, nil
+	}
+	return nil, fmt.Errorf("settings: %w", ErrNotInArchive)
+}
+
+// extractEntry copies the archive entry mf describes to w, failing with
+// ErrCorruptArchive when the entry is missing or its size or SHA-256 differs
+// from the manifest.
+func extractEntry(zr *zip.Reader, mf ManifestFile, w io.Writer) error {
+	f, err := zr.Open(mf.Name)
+	if err != nil {
+		return fmt.Errorf("%w: %s is missing", ErrCorruptArchive, mf.Name)
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, h), io.LimitReader(f, mf.Size+1))
+	if err != nil {
+		if errors.Is(err, zip.ErrChecksum) || errors.Is(err, zip.ErrFormat) {
+			return fmt.Errorf("%w: %s: %w", ErrCorruptArchive, mf.Name, err)
+		}
+		return fmt.Errorf("reading %s: %w", mf.Name, err)
+	}
+	if n != mf.Size || hex.EncodeToString(h.Sum(nil)) != mf.SHA256 {
+		return fmt.Errorf("%w: %s does not match its checksum", ErrCorruptArchive, mf.Name)
+	}
+	return nil
+}
+
+// extractToFile writes the archive entry mf to the new owner-only file at
+// path, checked as extractEntry does.
+func extractToFile(zr *zip.Reader, mf ManifestFile, path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) //nolint:gosec // G304: path is inside a private staging directory.
+	if err != nil {
+		return fmt.Errorf("extracting %s: %w", mf.Name, err)
+	}
+	err = extractEntry(zr, mf, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
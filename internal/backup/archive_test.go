@@ -0,0 +1,342 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	img "github.com/sydlexius/stillwater/internal/image"
+	"github.com/sydlexius/stillwater/internal/settingsio"
+)
+
+// archiveFixture is a database with two artists, one with a directory of
+// artwork in library lib-a and one with only cached images, and a service
+// archiving it.
+type archiveFixture struct {
+	svc      *Service
+	db       *sql.DB
+	dir      string // directory of artist "a1"
+	cacheDir string
+}
+
+func newArchiveFixture(t *testing.T) *archiveFixture {
+	t.Helper()
+	db := setupTestDB(t)
+	ctx := context.Background()
+	for _, stmt := range []string{
+		`CREATE TABLE artists (id TEXT PRIMARY KEY, name TEXT NOT NULL, path TEXT NOT NULL)`,
+		`CREATE TABLE artist_libraries (artist_id TEXT NOT NULL, library_id TEXT NOT NULL, PRIMARY KEY (artist_id, library_id))`,
+		`CREATE TABLE nfo_snapshots (id TEXT PRIMARY KEY, artist_id TEXT NOT NULL, content TEXT NOT NULL, created_at TEXT NOT NULL)`,
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("creating schema: %v", err)
+		}
+	}
+
+	root := t.TempDir()
+	f := &archiveFixture{db: db, dir: filepath.Join(root, "music", "ABBA"), cacheDir: filepath.Join(root, "cache")}
+	if _, err := db.ExecContext(ctx, `INSERT INTO artists (id, name, path) VALUES ('a1', 'ABBA', ?), ('a2', 'Pathless', '')`, f.dir); err != nil {
+		t.Fatalf("inserting artists: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO artist_libraries VALUES ('a1', 'lib-a'), ('a2', 'lib-b')`); err != nil {
+		t.Fatalf("inserting memberships: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO nfo_snapshots VALUES ('s1', 'a1', '<artist/>', '2025-01-01T00:00:00Z'), ('s2', 'a2', '<artist/>', '2025-01-01T00:00:00Z')`); err != nil {
+		t.Fatalf("inserting snapshots: %v", err)
+	}
+
+	writeFile(t, filepath.Join(f.dir, "folder.png"), taggedPNG(t, true))
+	writeFile(t, filepath.Join(f.dir, "fanart.png"), taggedPNG(t, false)) // not Stillwater's
+	writeFile(t, filepath.Join(f.dir, img.BackupDirName, "thumb", "folder.png"), taggedPNG(t, false))
+	writeFile(t, filepath.Join(f.cacheDir, "a2", "folder.png"), taggedPNG(t, true))
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	f.svc = NewService(db, filepath.Join(root, "backups"), 7, logger).
+		WithClock(newTestClock()).
+		WithImageCacheDir(f.cacheDir)
+	return f
+}
+
+// taggedPNG returns a small PNG, carrying Stillwater provenance when tagged.
+func taggedPNG(t *testing.T, tagged bool) []byte {
+	t.Helper()
+	m := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	m.Set(1, 1, color.RGBA{R: 200, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, m); err != nil {
+		t.Fatalf("encoding png: %v", err)
+	}
+	if !tagged {
+		return buf.Bytes()
+	}
+	data, err := img.InjectMeta(buf.Bytes(), &img.ExifMeta{Source: "user", Fetched: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatalf("tagging png: %v", err)
+	}
+	return data
+}
+
+func writeFile(t *testing.T, p string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		t.Fatalf("creating %s: %v", filepath.Dir(p), err)
+	}
+	if err := os.WriteFile(p, data, 0o600); err != nil {
+		t.Fatalf("writing %s: %v", p, err)
+	}
+}
+
+type fakeExporter struct{}
+
+func (fakeExporter) Export(_ context.Context, passphrase string) (*settingsio.Envelope, error) {
+	return &settingsio.Envelope{Version: settingsio.CurrentEnvelopeVersion, Data: "sealed-with-" + passphrase}, nil
+}
+
+func TestArchive_ManifestAndArtworkSelection(t *testing.T) {
+	f := newArchiveFixture(t)
+	f.svc.WithSettingsExporter(fakeExporter{})
+
+	info, err := f.svc.Archive(context.Background(), ArchiveOptions{Artwork: true, Passphrase: "pw"})
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if info.Kind != KindArchive || filepath.Ext(info.Filename) != ".zip" {
+		t.Errorf("info = %+v, want a .zip archive", info)
+	}
+	st, err := os.Stat(filepath.Join(f.svc.BackupDir(), info.Filename))
+	if err != nil {
+		t.Fatalf("stat archive: %v", err)
+	}
+	if runtime.GOOS != "windows" && st.Mode().Perm() != 0o600 {
+		t.Errorf("archive mode = %o, want 0600", st.Mode().Perm())
+	}
+
+	m, err := f.svc.ReadManifest(info.Filename)
+	if err != nil {
+		t.Fatalf("ReadManifest: %v", err)
+	}
+	got := make(map[string]string)
+	for _, mf := range m.Files {
+		got[mf.Name] = mf.Kind
+	}
+	want := map[string]string{
+		databaseEntry:                            FileDatabase,
+		settingsEntry:                            FileSettings,
+		"artwork/a1/folder.png":                  FileArtwork,
+		"artwork/a1/.sw-backup/thumb/folder.png": FileArtwork,
+		"cache/a2/folder.png":                    FileCache,
+	}
+	if len(got) != len(want) {
+		t.Errorf("archived %v, want %v", got, want)
+	}
+	for name, kind := range want {
+		if got[name] != kind {
+			t.Errorf("entry %s kind = %q, want %q", name, got[name], kind)
+		}
+	}
+	if len(m.Artists) != 2 || m.Artists[0].LibraryIDs[0] != "lib-a" {
+		t.Errorf("artists = %+v, want both with their libraries", m.Artists)
+	}
+
+	if err := f.svc.VerifyArchive(info.Filename); err != nil {
+		t.Errorf("VerifyArchive: %v", err)
+	}
+	env, err := f.svc.ArchiveSettings(info.Filename)
+	if err != nil {
+		t.Fatalf("ArchiveSettings: %v", err)
+	}
+	if env.Data != "sealed-with-pw" {
+		t.Errorf("envelope data = %q, want the exporter's", env.Data)
+	}
+
+	backups, err := f.svc.ListBackups()
+	if err != nil {
+		t.Fatalf("ListBackups: %v", err)
+	}
+	if len(backups) != 1 || backups[0].Kind != KindArchive {
+		t.Errorf("ListBackups = %+v, want the one archive", backups)
+	}
+}
+
+func TestArchive_WithoutPassphraseHasNoSettings(t *testing.T) {
+	f := newArchiveFixture(t)
+	info, err := f.svc.Archive(context.Background(), ArchiveOptions{})
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if _, err := f.svc.ArchiveSettings(info.Filename); !errors.Is(err, ErrNotInArchive) {
+		t.Errorf("ArchiveSettings err = %v, want ErrNotInArchive", err)
+	}
+	m, err := f.svc.ReadManifest(info.Filename)
+	if err != nil {
+		t.Fatalf("ReadManifest: %v", err)
+	}
+	if len(m.Files) != 1 || m.Files[0].Kind != FileDatabase {
+		t.Errorf("files = %+v, want only the database", m.Files)
+	}
+}
+
+func TestRestoreArchive_Artist(t *testing.T) {
+	f := newArchiveFixture(t)
+	ctx := context.Background()
+	info, err := f.svc.Archive(ctx, ArchiveOptions{Artwork: true})
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+
+	// Lose the artwork and the snapshots, then restore the one artist.
+	original, err := os.ReadFile(filepath.Join(f.dir, "folder.png"))
+	if err != nil {
+		t.Fatalf("reading original: %v", err)
+	}
+	if err := os.RemoveAll(f.dir); err != nil {
+		t.Fatalf("removing artist dir: %v", err)
+	}
+	if _, err := f.db.ExecContext(ctx, `DELETE FROM nfo_snapshots`); err != nil {
+		t.Fatalf("deleting snapshots: %v", err)
+	}
+
+	res, err := f.svc.RestoreArchive(ctx, info.Filename, RestoreOptions{ArtistID: "a1"})
+	if err != nil {
+		t.Fatalf("RestoreArchive: %v", err)
+	}
+	if res.Artists != 1 || res.Files != 2 || res.NFOSnapshots != 1 {
+		t.Errorf("result = %+v, want 1 artist, 2 files, 1 snapshot", res)
+	}
+	restored, err := os.ReadFile(filepath.Join(f.dir, "folder.png"))
+	if err != nil || !bytes.Equal(restored, original) {
+		t.Errorf("folder.png not restored byte for byte (err %v)", err)
+	}
+	if _, err := os.Stat(filepath.Join(f.dir, img.BackupDirName, "thumb", "folder.png")); err != nil {
+		t.Errorf("pre-edit original not restored: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(f.dir, "fanart.png")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("an image without provenance was archived and restored")
+	}
+
+	// Restoring again finds everything in place.
+	res, err = f.svc.RestoreArchive(ctx, info.Filename, RestoreOptions{ArtistID: "a1"})
+	if err != nil {
+		t.Fatalf("second RestoreArchive: %v", err)
+	}
+	if res.Files != 0 || res.Unchanged != 2 || res.NFOSnapshots != 0 {
+		t.Errorf("second result = %+v, want everything unchanged", res)
+	}
+}
+
+func TestRestoreArchive_LibraryScopeAndUnknownScope(t *testing.T) {
+	f := newArchiveFixture(t)
+	ctx := context.Background()
+	info, err := f.svc.Archive(ctx, ArchiveOptions{Artwork: true})
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if err := os.RemoveAll(f.cacheDir); err != nil {
+		t.Fatalf("removing cache: %v", err)
+	}
+
+	res, err := f.svc.RestoreArchive(ctx, info.Filename, RestoreOptions{LibraryID: "lib-b"})
+	if err != nil {
+		t.Fatalf("RestoreArchive: %v", err)
+	}
+	if res.Artists != 1 || res.Files != 1 {
+		t.Errorf("result = %+v, want the pathless artist's cached image", res)
+	}
+	if _, err := os.Stat(filepath.Join(f.cacheDir, "a2", "folder.png")); err != nil {
+		t.Errorf("cached image not restored: %v", err)
+	}
+
+	if _, err := f.svc.RestoreArchive(ctx, info.Filename, RestoreOptions{LibraryID: "lib-z"}); !errors.Is(err, ErrNotInArchive) {
+		t.Errorf("unknown library: err = %v, want ErrNotInArchive", err)
+	}
+}
+
+func TestVerifyArchive_DetectsCorruption(t *testing.T) {
+	f := newArchiveFixture(t)
+	ctx := context.Background()
+	info, err := f.svc.Archive(ctx, ArchiveOptions{Artwork: true})
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	p := filepath.Join(f.svc.BackupDir(), info.Filename)
+
+	// Flip a byte inside the stored artwork entry.
+	zr, err := zip.OpenReader(p)
+	if err != nil {
+		t.Fatalf("opening archive: %v", err)
+	}
+	var offset int64 = -1
+	for _, zf := range zr.File {
+		if zf.Name == "artwork/a1/folder.png" {
+			offset, err = zf.DataOffset()
+			if err != nil {
+				t.Fatalf("DataOffset: %v", err)
+			}
+		}
+	}
+	_ = zr.Close()
+	if offset < 0 {
+		t.Fatal("artwork entry not found")
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatalf("reading archive: %v", err)
+	}
+	data[offset+10] ^= 0xff
+	if err := os.WriteFile(p, data, 0o600); err != nil {
+		t.Fatalf("writing archive: %v", err)
+	}
+
+	if err := f.svc.VerifyArchive(info.Filename); !errors.Is(err, ErrCorruptArchive) {
+		t.Errorf("VerifyArchive err = %v, want ErrCorruptArchive", err)
+	}
+	if err := os.RemoveAll(f.dir); err != nil {
+		t.Fatalf("removing artist dir: %v", err)
+	}
+	if _, err := f.svc.RestoreArchive(ctx, info.Filename, RestoreOptions{}); !errors.Is(err, ErrCorruptArchive) {
+		t.Errorf("RestoreArchive err = %v, want ErrCorruptArchive", err)
+	}
+	if _, err := os.Stat(f.dir); !errors.Is(err, os.ErrNotExist) {
+		t.Error("a corrupt archive restored files")
+	}
+}
+
+func TestArchiveReaders_RejectDatabaseSnapshot(t *testing.T) {
+	f := newArchiveFixture(t)
+	info, err := f.svc.Backup(context.Background())
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	if info.Kind != KindDatabase {
+		t.Errorf("Kind = %q, want %q", info.Kind, KindDatabase)
+	}
+	if _, err := f.svc.ReadManifest(info.Filename); !errors.Is(err, ErrNotArchive) {
+		t.Errorf("ReadManifest err = %v, want ErrNotArchive", err)
+	}
+}
+
+func TestScheduledBackup_Archives(t *testing.T) {
+	f := newArchiveFixture(t)
+	f.svc.ScheduleArchives(ArchiveOptions{Artwork: true, Passphrase: "ignored"})
+	info, err := f.svc.scheduledBackup(context.Background())
+	if err != nil {
+		t.Fatalf("scheduledBackup: %v", err)
+	}
+	if info.Kind != KindArchive {
+		t.Errorf("Kind = %q, want %q", info.Kind, KindArchive)
+	}
+	if _, err := f.svc.ArchiveSettings(info.Filename); !errors.Is(err, ErrNotInArchive) {
+		t.Errorf("scheduled archive carries settings: err = %v", err)
+	}
+}
@@ -17,10 +17,21 @@ import (
 	"github.com/sydlexius/stillwater/internal/filesystem"
 )
 
-// backupPattern matches backup filenames: stillwater-YYYYMMDD-HHMMSS.db, plus
-// an optional "-N" disambiguation suffix appended when two backups land in the
-// same wall-clock second (see linkIntoPlace).
-var backupPattern = regexp.MustCompile(`^stillwater-\d{8}-\d{6}(-\d+)?\.db$`)
+// backupPattern matches backup filenames: stillwater-YYYYMMDD-HHMMSS.db for a
+// database snapshot or .zip for a full archive (see Archive), plus an optional
+// "-N" disambiguation suffix appended when two backups land in the same
+// wall-clock second (see linkIntoPlace), and .enc when the backup is
+// encrypted (see WithKey).
+var backupPattern = regexp.MustCompile(`^stillwater-\d{8}-\d{6}(-\d+)?\.(db|zip)(\.enc)?$`)
+
+// Backup kinds reported in BackupInfo.Kind.
+const (
+	// KindDatabase is a bare VACUUM INTO snapshot of the database.
+	KindDatabase = "database"
+	// KindArchive is a full archive: the snapshot plus, optionally, the
+	// settings envelope and artwork, with a checksummed manifest.
+	KindArchive = "archive"
+)
 
 // tsLayoutLen is the length of the "20060102-150405" timestamp embedded in a
 // backup filename (8-digit date + '-' + 6-digit time = 15 chars). Anything
@@ -61,8 +72,24 @@ var syncBackupDir = filesystem.SyncDir
 // BackupInfo describes a backup file.
 type BackupInfo struct {
 	Filename  string    `json:"filename"`
+	Kind      string    `json:"kind"`
 	Size      int64     `json:"size"`
 	CreatedAt time.Time `json:"created_at"`
+	Encrypted bool      `json:"encrypted"`
+	// Status is the outcome of the last verification (see Verify), and
+	// Problem says what was wrong when it is StatusCorrupt or why the backup
+	// could not be checked.
+	Status     string     `json:"status,omitempty"`
+	Problem    string     `json:"problem,omitempty"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+}
+
+// kindOf returns the backup kind of a valid backup filename.
+func kindOf(filename string) string {
+	if strings.Contains(filename, ".zip") {
+		return KindArchive
+	}
+	return KindDatabase
 }
 
 // Clock is the time source used by Service for backup filename timestamps.
@@ -79,13 +106,25 @@ func (realClock) Now() time.Time { return time.Now().UTC() }
 
 // Service manages database backups.
 type Service struct {
-	db         *sql.DB
-	backupDir  string
-	retention  int
-	maxAgeDays int
-	clock      Clock
-	mu         sync.RWMutex
-	logger     *slog.Logger
+	db            *sql.DB
+	backupDir     string
+	retention     int
+	maxAgeDays    int
+	clock         Clock
+	mu            sync.RWMutex
+	logger        *slog.Logger
+	settings      SettingsExporter
+	imageCacheDir string
+	// scheduled, when non-nil, makes the scheduler write full archives with
+	// these options instead of database snapshots.
+	scheduled *ArchiveOptions
+	// targets receive a copy of every new backup (see Push).
+	targets    []*remoteTarget
+	notifyPush func(PushFailure)
+	// key, when non-nil, encrypts every backup written (see WithKey).
+	key *Key
+	// ledgerMu serializes updates to the checksum ledger (see ledger.go).
+	ledgerMu sync.Mutex
 }
 
 // NewService creates a backup service.
@@ -146,6 +185,26 @@ func (s *Service) Backup(ctx context.Context) (*BackupInfo, error) {
 		return nil, fmt.Errorf("restricting backup permissions: %w", err)
 	}
 
+	return s.install(stagingPath, baseFilename, now)
+}
+
+// install encrypts a finished, owner-only backup file when the service has a
+// key (see seal), moves it from its staging path into backupDir under
+// baseFilename (or a disambiguated variant), records its checksum in the
+// ledger, and describes it.
+func (s *Service) install(stagingPath, baseFilename string, now time.Time) (*BackupInfo, error) {
+	stagingPath, baseFilename, err := s.seal(stagingPath, baseFilename)
+	if err != nil {
+		return nil, err
+	}
+	// Record the checksum before the backup is in place, so the verify job
+	// never sees it without one. A backup that does not get installed leaves
+	// only a ledger entry for a file that does not exist, which is ignored.
+	sum, err := fileSHA256(stagingPath)
+	if err != nil {
+		return nil, fmt.Errorf("hashing backup: %w", err)
+	}
+
 	// Move the snapshot into backupDir without ever overwriting an existing
 	// file. On a same-second collision this returns a distinct, disambiguated
 	// filename so both snapshots survive.
@@ -181,14 +240,24 @@ func (s *Service) Backup(ctx context.Context) (*BackupInfo, error) {
 		return nil, fmt.Errorf("stat backup file: %w", err)
 	}
 
+	if err := s.recordChecksum(filename, sum, info.Size()); err != nil {
+		s.logger.Warn("recording backup checksum failed; the verify job will record it",
+			slog.String("filename", filename),
+			slog.String("error", err.Error()))
+	}
+
 	s.logger.Info("backup complete",
 		slog.String("filename", filename),
-		slog.Int64("size", info.Size()))
+		slog.Int64("size", info.Size()),
+		slog.Bool("encrypted", isEncrypted(filename)))
 
 	return &BackupInfo{
 		Filename:  filename,
+		Kind:      kindOf(filename),
 		Size:      info.Size(),
 		CreatedAt: now,
+		Encrypted: isEncrypted(filename),
+		Status:    StatusUnverified,
 	}, nil
 }
 
@@ -199,14 +268,16 @@ func (s *Service) Backup(ctx context.Context) (*BackupInfo, error) {
 // same second, unlike a stat-then-rename that both callers can pass before
 // either renames. On collision it appends an incrementing "-N" suffix
 // (stillwater-YYYYMMDD-HHMMSS-1.db, -2.db, ...) until it finds a free name, so
-// two backups in the same second both survive and neither call fails. Returns
-// the final filename actually used.
+// two backups in the same second both survive and neither call fails. The
+// suffix goes before every extension (-1.db.enc). Returns the final filename
+// actually used.
 func linkIntoPlace(stagingPath, backupDir, baseFilename string) (string, error) {
-	base := strings.TrimSuffix(baseFilename, ".db")
+	base, ext, _ := strings.Cut(baseFilename, ".")
+	ext = "." + ext
 	for i := 0; i <= maxCollisionSuffix; i++ {
 		name := baseFilename
 		if i > 0 {
-			name = fmt.Sprintf("%s-%d.db", base, i)
+			name = fmt.Sprintf("%s-%d%s", base, i, ext)
 		}
 		dest := filepath.Join(backupDir, name)
 		err := osLink(stagingPath, dest)
@@ -224,7 +295,9 @@ func linkIntoPlace(stagingPath, backupDir, baseFilename string) (string, error)
 	return "", fmt.Errorf("moving backup into place: exhausted %d collision suffixes for %q", maxCollisionSuffix, baseFilename)
 }
 
-// ListBackups returns all backup files sorted by date descending.
+// ListBackups returns all backup files sorted by date descending, each with
+// the outcome of its last verification. A backup whose size no longer
+// matches the ledger is reported corrupt without waiting for the verify job.
 func (s *Service) ListBackups() ([]BackupInfo, error) {
 	entries, err := os.ReadDir(s.backupDir)
 	if err != nil {
@@ -234,6 +307,13 @@ func (s *Service) ListBackups() ([]BackupInfo, error) {
 		return nil, fmt.Errorf("reading backup directory: %w", err)
 	}
 
+	sums, err := s.readLedger()
+	if err != nil {
+		// Listing must keep working with a damaged ledger; every backup
+		// just shows as unverified until the verify job rewrites it.
+		s.logger.Warn("reading backup checksum ledger", slog.String("error", err.Error()))
+	}
+
 	var backups []BackupInfo
 	for _, entry := range entries {
 		if entry.IsDir() || !backupPattern.MatchString(entry.Name()) {
@@ -244,24 +324,20 @@ func (s *Service) ListBackups() ([]BackupInfo, error) {
 			continue
 		}
 
-		// Parse timestamp from filename: stillwater-YYYYMMDD-HHMMSS[-N].db.
-		// The timestamp is always the first tsLayoutLen chars; a trailing
-		// "-N" collision suffix (added by linkIntoPlace on a same-second
-		// collision) is ignored so those snapshots still sort by their second.
-		name := strings.TrimSuffix(strings.TrimPrefix(entry.Name(), "stillwater-"), ".db")
-		if len(name) > tsLayoutLen {
-			name = name[:tsLayoutLen]
-		}
-		ts, err := time.Parse("20060102-150405", name)
-		if err != nil {
+		ts, ok := backupTime(entry.Name())
+		if !ok {
 			ts = info.ModTime()
 		}
 
-		backups = append(backups, BackupInfo{
+		b := BackupInfo{
 			Filename:  entry.Name(),
+			Kind:      kindOf(entry.Name()),
 			Size:      info.Size(),
 			CreatedAt: ts,
-		})
+			Encrypted: isEncrypted(entry.Name()),
+		}
+		sums.describe(&b)
+		backups = append(backups, b)
 	}
 
 	sort.Slice(backups, func(i, j int) bool {
@@ -271,13 +347,27 @@ func (s *Service) ListBackups() ([]BackupInfo, error) {
 	return backups, nil
 }
 
+// backupTime parses the timestamp out of a backup filename:
+// stillwater-YYYYMMDD-HHMMSS[-N].db (or .zip, either with .enc). The timestamp is always the
+// first tsLayoutLen chars; a trailing "-N" collision suffix (added by
+// linkIntoPlace on a same-second collision) is ignored so those snapshots
+// still sort by their second.
+func backupTime(filename string) (time.Time, bool) {
+	name := strings.TrimPrefix(filename, "stillwater-")
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	if len(name) > tsLayoutLen {
+		name = name[:tsLayoutLen]
+	}
+	ts, err := time.Parse("20060102-150405", name)
+	return ts, err == nil
+}
+
 // Delete removes a single backup file by filename.
 func (s *Service) Delete(filename string) error {
 	if !IsValidBackupFilename(filename) {
 		return fmt.Errorf("invalid backup filename")
 	}
-	path := filepath.Join(s.backupDir, filename)
-	if err := os.Remove(path); err != nil {
+	if err := s.remove(filename); err != nil {
 		return fmt.Errorf("removing backup: %w", err)
 	}
 	s.logger.Info("backup deleted", slog.String("filename", filename))
@@ -336,8 +426,7 @@ func (s *Service) Prune() error {
 	// Count-based pruning
 	if len(backups) > retention {
 		for _, b := range backups[retention:] {
-			path := filepath.Join(s.backupDir, b.Filename)
-			if err := os.Remove(path); err != nil {
+			if err := s.remove(b.Filename); err != nil {
 				s.logger.Warn("failed to remove old backup",
 					slog.String("filename", b.Filename),
 					slog.Any("error", err))
@@ -357,8 +446,7 @@ func (s *Service) Prune() error {
 		}
 		for _, b := range backups {
 			if b.CreatedAt.Before(cutoff) {
-				path := filepath.Join(s.backupDir, b.Filename)
-				if err := os.Remove(path); err != nil {
+				if err := s.remove(b.Filename); err != nil {
 					s.logger.Warn("failed to remove aged backup",
 						slog.String("filename", b.Filename),
 						slog.Any("error", err))
@@ -379,7 +467,8 @@ func (s *Service) BackupDir() string {
 	return s.backupDir
 }
 
-// StartScheduler runs backups on a fixed interval until the context is canceled.
+// StartScheduler runs backups on a fixed interval until the context is
+// canceled, copying each one to the configured targets (see Push).
 func (s *Service) StartScheduler(ctx context.Context, interval time.Duration) {
 	s.logger.Info("backup scheduler started",
 		slog.String("interval", interval.String()),
@@ -395,10 +484,14 @@ func (s *Service) StartScheduler(ctx context.Context, interval time.Duration) {
 			s.logger.Info("backup scheduler stopped")
 			return
 		case <-ticker.C:
-			if _, err := s.Backup(ctx); err != nil {
+			info, err := s.scheduledBackup(ctx)
+			if err != nil {
 				s.logger.Error("scheduled backup failed", slog.Any("error", err))
 				continue
 			}
+			// Push logs and reports each target's failure itself; a target
+			// that is down must not hold back local pruning.
+			_ = s.Push(ctx, info.Filename)
 			if err := s.Prune(); err != nil {
 				s.logger.Error("backup prune failed", slog.Any("error", err))
 			}
@@ -573,6 +573,8 @@ func TestIsValidBackupFilename(t *testing.T) {
 		want  bool
 	}{
 		{"valid", "stillwater-20260220-143022.db", true},
+		{"encrypted archive", "stillwater-20260220-143022-1.zip.enc", true},
+		{"encrypted without a kind", "stillwater-20260220-143022.enc", false},
 		{"path traversal", "../stillwater-20260220-143022.db", false},
 		{"backslash", "..\\stillwater-20260220-143022.db", false},
 		{"wrong prefix", "backup-20260220-143022.db", false},
@@ -0,0 +1,333 @@
+package backup
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sydlexius/stillwater/internal/encryption"
+	"github.com/sydlexius/stillwater/internal/settingsio"
+)
+
+// EncryptedSuffix follows the plaintext name of an encrypted backup:
+// stillwater-YYYYMMDD-HHMMSS.db.enc or .zip.enc.
+const EncryptedSuffix = ".enc"
+
+// An encrypted backup is the plaintext backup sealed with AES-256-GCM in
+// cryptChunk-sized chunks, so files of any size stream through a fixed
+// buffer. The file starts with a header:
+//
+//	magic       8 bytes  cryptMagic
+//	mode        1 byte   keyModePassphrase or keyModeFile
+//	iterations  4 bytes  PBKDF2 iterations, big endian (0 for a key file)
+//	salt       16 bytes
+//	check      16 bytes  proves the key before any chunk is opened
+//
+// Each file has its own salt and so its own key, which is what makes the
+// counter nonces safe: chunk i's nonce is i as a big-endian uint64, three
+// zero bytes, and a final-chunk flag. The header is every chunk's additional
+// data, so chunks cannot be swapped between files, reordered, dropped, or
+// cut off at the end without failing to open.
+const (
+	cryptMagic       = "SWBACK\x00\x01"
+	cryptChunk       = 64 << 10
+	cryptSaltLen     = 16
+	cryptCheckLen    = 16
+	cryptHeaderLen   = len(cryptMagic) + 1 + 4 + cryptSaltLen + cryptCheckLen
+	cryptMaxKDFIters = 10_000_000
+
+	keyModePassphrase = 1
+	keyModeFile       = 2
+)
+
+// kdfIterations is the PBKDF2 iteration count written into new encrypted
+// backups. Each file records its own count, so tests lower this without
+// affecting what they read back.
+var kdfIterations = settingsio.PBKDF2Iterations
+
+// ErrEncrypted is returned when reading an encrypted backup on a service
+// with no backup key.
+var ErrEncrypted = errors.New("backup is encrypted and no backup key is configured")
+
+// ErrWrongKey is returned when an encrypted backup was made with a different
+// passphrase or key file than the one configured.
+var ErrWrongKey = errors.New("backup was encrypted with a different key")
+
+// ErrCorruptBackup is returned when a backup fails verification: a checksum
+// that no longer matches, an encrypted chunk that does not authenticate, or
+// a database that fails its integrity check.
+var ErrCorruptBackup = errors.New("backup is corrupt")
+
+// Key encrypts and decrypts backup files: a passphrase, stretched with the
+// settings export's PBKDF2, or a 32-byte key read from a file.
+type Key struct {
+	passphrase string
+	secret     []byte
+}
+
+// PassphraseKey returns a Key for a passphrase.
+func PassphraseKey(passphrase string) *Key {
+	return &Key{passphrase: passphrase}
+}
+
+// ParseKeyFile returns a Key for the contents of a key file: a 32-byte key,
+// base64 encoded like SW_ENCRYPTION_KEY. Surrounding whitespace is ignored.
+func ParseKeyFile(data []byte) (*Key, error) {
+	secret, err := encryption.ParseKey(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, err
+	}
+	return &Key{secret: secret}, nil
+}
+
+// fileKey derives the AES key of one file from its header fields.
+func (k *Key) fileKey(mode byte, iterations uint32, salt []byte) ([]byte, error) {
+	switch mode {
+	case keyModePassphrase:
+		if k.passphrase == "" {
+			return nil, fmt.Errorf("%w: it was made with a passphrase", ErrWrongKey)
+		}
+		if iterations == 0 || iterations > cryptMaxKDFIters {
+			return nil, fmt.Errorf("%w: implausible PBKDF2 iteration count %d", ErrCorruptBackup, iterations)
+		}
+		return settingsio.DeriveKey(k.passphrase, salt, int(iterations)), nil
+	case keyModeFile:
+		if k.secret == nil {
+			return nil, fmt.Errorf("%w: it was made with a key file", ErrWrongKey)
+		}
+		return hkdf.Key(sha256.New, k.secret, salt, "stillwater backup", 32)
+	}
+	return nil, fmt.Errorf("%w: unknown key mode %d", ErrCorruptBackup, mode)
+}
+
+// keyCheck is the header value that tells a wrong key apart from a damaged
+// file.
+func keyCheck(fileKey []byte) []byte {
+	mac := hmac.New(sha256.New, fileKey)
+	mac.Write([]byte("stillwater backup key check"))
+	return mac.Sum(nil)[:cryptCheckLen]
+}
+
+func newChunkAEAD(fileKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(fileKey)
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(nonce []byte, i uint64, last bool) []byte {
+	binary.BigEndian.PutUint64(nonce, i)
+	nonce[8], nonce[9], nonce[10], nonce[11] = 0, 0, 0, 0
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// Encrypt writes src to dst as an encrypted backup.
+func (k *Key) Encrypt(dst io.Writer, src io.Reader) error {
+	header := make([]byte, 0, cryptHeaderLen)
+	header = append(header, cryptMagic...)
+	mode, iterations := byte(keyModeFile), uint32(0)
+	if k.passphrase != "" {
+		mode, iterations = keyModePassphrase, uint32(kdfIterations) //nolint:gosec // G115: a small positive constant.
+	}
+	header = append(header, mode)
+	header = binary.BigEndian.AppendUint32(header, iterations)
+	salt := make([]byte, cryptSaltLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return fmt.Errorf("generating salt: %w", err)
+	}
+	header = append(header, salt...)
+	fileKey, err := k.fileKey(mode, iterations, salt)
+	if err != nil {
+		return err
+	}
+	header = append(header, keyCheck(fileKey)...)
+	aead, err := newChunkAEAD(fileKey)
+	if err != nil {
+		return err
+	}
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	br := bufio.NewReaderSize(src, cryptChunk)
+	buf := make([]byte, cryptChunk)
+	out := make([]byte, 0, cryptChunk+aead.Overhead())
+	nonce := make([]byte, aead.NonceSize())
+	for i := uint64(0); ; i++ {
+		n, err := io.ReadFull(br, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+		last := n < cryptChunk
+		if !last {
+			if _, err := br.Peek(1); errors.Is(err, io.EOF) {
+				last = true
+			} else if err != nil {
+				return err
+			}
+		}
+		out = aead.Seal(out[:0], chunkNonce(nonce, i, last), buf[:n], header)
+		if _, err := dst.Write(out); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+// Decrypt writes the plaintext of the encrypted backup in src to dst. A key
+// that does not match returns ErrWrongKey before anything is written; a
+// damaged or truncated file returns ErrCorruptBackup, possibly after part of
+// the plaintext was written.
+func (k *Key) Decrypt(dst io.Writer, src io.Reader) error {
+	header := make([]byte, cryptHeaderLen)
+	if _, err := io.ReadFull(src, header); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("%w: header is truncated", ErrCorruptBackup)
+		}
+		return err
+	}
+	if string(header[:len(cryptMagic)]) != cryptMagic {
+		return fmt.Errorf("%w: not an encrypted backup", ErrCorruptBackup)
+	}
+	rest := header[len(cryptMagic):]
+	mode, iterations := rest[0], binary.BigEndian.Uint32(rest[1:5])
+	salt, check := rest[5:5+cryptSaltLen], rest[5+cryptSaltLen:]
+	fileKey, err := k.fileKey(mode, iterations, salt)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(keyCheck(fileKey), check) {
+		return ErrWrongKey
+	}
+	aead, err := newChunkAEAD(fileKey)
+	if err != nil {
+		return err
+	}
+
+	sealed := cryptChunk + aead.Overhead()
+	br := bufio.NewReaderSize(src, sealed)
+	buf := make([]byte, sealed)
+	out := make([]byte, 0, cryptChunk)
+	nonce := make([]byte, aead.NonceSize())
+	for i := uint64(0); ; i++ {
+		n, err := io.ReadFull(br, buf)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+		last := n < sealed
+		if !last {
+			if _, err := br.Peek(1); errors.Is(err, io.EOF) {
+				last = true
+			} else if err != nil {
+				return err
+			}
+		}
+		out, err = aead.Open(out[:0], chunkNonce(nonce, i, last), buf[:n], header)
+		if err != nil {
+			return fmt.Errorf("%w: chunk %d does not authenticate", ErrCorruptBackup, i)
+		}
+		if _, err := dst.Write(out); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+// isEncrypted reports whether a backup filename names an encrypted backup.
+func isEncrypted(filename string) bool {
+	return strings.HasSuffix(filename, EncryptedSuffix)
+}
+
+// encryptFile writes src, encrypted with k, to the new owner-only file dst.
+func encryptFile(src, dst string, k *Key) error {
+	in, err := os.Open(src) //nolint:gosec // G304: src is a staging file inside backupDir.
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) //nolint:gosec // G304: dst is a staging file inside backupDir.
+	if err != nil {
+		return err
+	}
+	err = k.Encrypt(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// seal encrypts a finished staging file when the service has a backup key,
+// returning the staging path and base filename to install in its place.
+func (s *Service) seal(stagingPath, baseFilename string) (string, string, error) {
+	if s.key == nil {
+		return stagingPath, baseFilename, nil
+	}
+	sealed := stagingPath + EncryptedSuffix
+	if err := encryptFile(stagingPath, sealed, s.key); err != nil {
+		return "", "", fmt.Errorf("encrypting backup: %w", err)
+	}
+	if err := os.Remove(stagingPath); err != nil {
+		return "", "", fmt.Errorf("removing plaintext staging file: %w", err)
+	}
+	return sealed, baseFilename + EncryptedSuffix, nil
+}
+
+// plaintextPath returns the path of a backup's plaintext: the backup itself,
+// or for an encrypted one a decrypted copy written into stagingDir, which
+// the caller owns and removes.
+func (s *Service) plaintextPath(filename, stagingDir string) (string, error) {
+	path := filepath.Join(s.backupDir, filename)
+	if !isEncrypted(filename) {
+		return path, nil
+	}
+	if s.key == nil {
+		return "", ErrEncrypted
+	}
+	in, err := os.Open(path) //nolint:gosec // G304: filename is a validated backup name inside backupDir.
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = in.Close() }()
+	plain := filepath.Join(stagingDir, strings.TrimSuffix(filename, EncryptedSuffix))
+	out, err := os.OpenFile(plain, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) //nolint:gosec // G304: plain is inside the caller's private staging directory.
+	if err != nil {
+		return "", fmt.Errorf("creating decrypted copy: %w", err)
+	}
+	err = s.key.Decrypt(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("decrypting %s: %w", filename, err)
+	}
+	return plain, nil
+}
+
+// WithKey makes the service encrypt every backup it writes with k, and
+// decrypt encrypted backups it reads. A nil key writes plaintext backups.
+func (s *Service) WithKey(k *Key) *Service {
+	s.key = k
+	return s
+}
@@ -0,0 +1,208 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Every encrypted backup records its own PBKDF2 count, so a lower one
+	// here only makes the passphrase tests fast.
+	kdfIterations = 1000
+	os.Exit(m.Run())
+}
+
+func testFileKey(t *testing.T) *Key {
+	t.Helper()
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		t.Fatal(err)
+	}
+	k, err := ParseKeyFile([]byte(base64.StdEncoding.EncodeToString(raw) + "\n"))
+	if err != nil {
+		t.Fatalf("ParseKeyFile: %v", err)
+	}
+	return k
+}
+
+func encryptBytes(t *testing.T, k *Key, plain []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := k.Encrypt(&buf, bytes.NewReader(plain)); err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestKey_RoundTrip(t *testing.T) {
+	keys := map[string]*Key{"passphrase": PassphraseKey("correct horse"), "key file": testFileKey(t)}
+	for name, k := range keys {
+		for _, size := range []int{0, 1, cryptChunk, cryptChunk + 1, 3*cryptChunk - 7} {
+			plain := make([]byte, size)
+			_, _ = rand.Read(plain)
+			sealed := encryptBytes(t, k, plain)
+			if size >= 64 && bytes.Contains(sealed, plain[:64]) {
+				t.Errorf("%s/%d: ciphertext contains the plaintext", name, size)
+			}
+			var got bytes.Buffer
+			if err := k.Decrypt(&got, bytes.NewReader(sealed)); err != nil {
+				t.Fatalf("%s/%d: Decrypt: %v", name, size, err)
+			}
+			if !bytes.Equal(got.Bytes(), plain) {
+				t.Errorf("%s/%d: round trip returned %d bytes that differ", name, size, got.Len())
+			}
+		}
+	}
+}
+
+func TestKey_DecryptRejects(t *testing.T) {
+	k := PassphraseKey("correct horse")
+	plain := bytes.Repeat([]byte("stillwater"), cryptChunk/5) // two chunks
+	sealed := encryptBytes(t, k, plain)
+	chunk := cryptChunk + 16
+
+	flipped := bytes.Clone(sealed)
+	flipped[cryptHeaderLen+100] ^= 0xff
+	for _, tc := range []struct {
+		name string
+		key  *Key
+		data []byte
+		want error
+	}{
+		{"wrong passphrase", PassphraseKey("battery staple"), sealed, ErrWrongKey},
+		{"key file for a passphrase backup", testFileKey(t), sealed, ErrWrongKey},
+		{"flipped byte", k, flipped, ErrCorruptBackup},
+		{"cut at a chunk boundary", k, sealed[:cryptHeaderLen+chunk], ErrCorruptBackup},
+		{"cut inside a chunk", k, sealed[:len(sealed)-5], ErrCorruptBackup},
+		{"header only", k, sealed[:cryptHeaderLen], ErrCorruptBackup},
+		{"not encrypted", k, plain, ErrCorruptBackup},
+	} {
+		if err := tc.key.Decrypt(io.Discard, bytes.NewReader(tc.data)); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestParseKeyFile_RejectsShortKey(t *testing.T) {
+	if _, err := ParseKeyFile([]byte(base64.StdEncoding.EncodeToString([]byte("too short")))); err == nil {
+		t.Error("ParseKeyFile accepted a 9-byte key")
+	}
+}
+
+func TestBackup_Encrypted(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dir := filepath.Join(t.TempDir(), "backups")
+	svc := NewService(setupTestDB(t), dir, 7, logger).WithClock(newTestClock()).WithKey(PassphraseKey("correct horse"))
+	ctx := context.Background()
+
+	info, err := svc.Backup(ctx)
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	if !strings.HasSuffix(info.Filename, ".db"+EncryptedSuffix) || !info.Encrypted || info.Kind != KindDatabase {
+		t.Fatalf("Backup = %+v, want an encrypted database snapshot", info)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, info.Filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("SQLite format 3")) || bytes.Contains(data, []byte("hello")) {
+		t.Error("encrypted backup holds plaintext")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".db") {
+			t.Errorf("plaintext %s left in the backup directory", e.Name())
+		}
+	}
+
+	got, err := svc.Verify(ctx, info.Filename)
+	if err != nil || got.Status != StatusOK {
+		t.Fatalf("Verify = %+v, %v; want ok", got, err)
+	}
+
+	// A same-second collision keeps every extension after the suffix.
+	name, err := linkIntoPlace(writeStaging(t, dir), dir, info.Filename)
+	if err != nil || name != strings.Replace(info.Filename, ".db", "-1.db", 1) {
+		t.Errorf("colliding name = %q, %v", name, err)
+	}
+}
+
+// writeStaging writes a throwaway staging file under dir.
+func writeStaging(t *testing.T, dir string) string {
+	t.Helper()
+	p := filepath.Join(dir, ".staging-test")
+	if err := os.WriteFile(p, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestArchive_EncryptedRoundTrip(t *testing.T) {
+	f := newArchiveFixture(t)
+	key := testFileKey(t)
+	f.svc.WithKey(key)
+	ctx := context.Background()
+
+	info, err := f.svc.Archive(ctx, ArchiveOptions{Artwork: true})
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if !strings.HasSuffix(info.Filename, ".zip"+EncryptedSuffix) || info.Kind != KindArchive {
+		t.Fatalf("Archive = %+v, want an encrypted archive", info)
+	}
+	if err := f.svc.VerifyArchive(info.Filename); err != nil {
+		t.Errorf("VerifyArchive: %v", err)
+	}
+	if err := os.RemoveAll(f.dir); err != nil {
+		t.Fatal(err)
+	}
+	res, err := f.svc.RestoreArchive(ctx, info.Filename, RestoreOptions{ArtistID: "a1"})
+	if err != nil || res.Files == 0 {
+		t.Fatalf("RestoreArchive = %+v, %v; want artwork restored", res, err)
+	}
+	if v, err := f.svc.Verify(ctx, info.Filename); err != nil || v.Status != StatusOK {
+		t.Errorf("Verify = %+v, %v; want ok", v, err)
+	}
+	assertNoStaging(t, f.svc.BackupDir())
+
+	// Without the key the archive can be listed but not opened or checked.
+	f.svc.WithKey(nil)
+	if _, err := f.svc.ReadManifest(info.Filename); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("ReadManifest without a key: err = %v, want ErrEncrypted", err)
+	}
+	v, err := f.svc.Verify(ctx, info.Filename)
+	if err != nil || v.Status != StatusUnverified || !strings.Contains(v.Problem, "no backup key") {
+		t.Errorf("Verify without a key = %+v, %v; want unverified with a reason", v, err)
+	}
+	f.svc.WithKey(PassphraseKey("not it"))
+	if _, err := f.svc.ReadManifest(info.Filename); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("ReadManifest with another key: err = %v, want ErrWrongKey", err)
+	}
+}
+
+// assertNoStaging fails if a decrypted or staging copy is left in dir.
+func assertNoStaging(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			t.Errorf("staging entry %s left behind", e.Name())
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package backup
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/sydlexius/stillwater/internal/filesystem"
+)
+
+// RestoreOptions scopes RestoreArchive. The zero value restores every artist
+// in the archive; ArtistID narrows it to one artist and LibraryID to the
+// artists of one library.
+type RestoreOptions struct {
+	LibraryID string `json:"library_id,omitempty"`
+	ArtistID  string `json:"artist_id,omitempty"`
+}
+
+// RestoreResult reports what RestoreArchive did.
+type RestoreResult struct {
+	// Artists is how many artists the scope selected.
+	Artists int `json:"artists"`
+	// Files counts artwork and cache files written.
+	Files int `json:"files"`
+	// Unchanged counts files already on disk with the archived content.
+	Unchanged int `json:"unchanged"`
+	// Skipped counts files with nowhere to go: an artist with no directory
+	// now or then, or a cached image with no image cache configured.
+	Skipped int `json:"skipped"`
+	// NFOSnapshots counts NFO snapshot rows put back. Rows that still exist
+	// and rows of artists no longer in the database are left alone.
+	NFOSnapshots int `json:"nfo_snapshots"`
+}
+
+// RestoreArchive puts the artwork and NFO snapshots in a full archive back for
+// the artists opts selects. The archive's entries for those artists are all
+// checked against the manifest before anything is written, so a corrupt
+// archive restores nothing.
+//
+// Artwork goes to the artist's directory as the database has it now, falling
+// back to the directory recorded in the archive for an artist the database
+// no longer knows. A file already holding the archived bytes is left alone.
+// NFO snapshots are copied from the archived database for artists still in
+// the database; existing snapshots are kept.
+//
+// The database snapshot itself and the settings envelope are not applied
+// here: the database cannot be replaced under a running server, and settings
+// go through the settings import (see ArchiveSettings).
+func (s *Service) RestoreArchive(ctx context.Context, filename string, opts RestoreOptions) (*RestoreResult, error) {
+	zr, m, err := s.openArchive(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = zr.Close() }()
+
+	artists := make(map[string]ManifestArtist)
+	var ids []string
+	for _, a := range m.Artists {
+		if opts.ArtistID != "" && a.ID != opts.ArtistID {
+			continue
+		}
+		if opts.LibraryID != "" && !slices.Contains(a.LibraryIDs, opts.LibraryID) {
+			continue
+		}
+		artists[a.ID] = a
+		ids = append(ids, a.ID)
+	}
+	if len(ids) == 0 && (opts.ArtistID != "" || opts.LibraryID != "") {
+		return nil, fmt.Errorf("artist or library: %w", ErrNotInArchive)
+	}
+
+	var files []ManifestFile
+	var dbFile *ManifestFile
+	for i, mf := range m.Files {
+		switch mf.Kind {
+		case FileDatabase:
+			dbFile = &m.Files[i]
+		case FileArtwork, FileCache:
+			if _, ok := artists[mf.ArtistID]; ok {
+				files = append(files, mf)
+			}
+		}
+	}
+	for _, mf := range files {
+		if err := extractEntry(&zr.Reader, mf, io.Discard); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &RestoreResult{Artists: len(ids)}
+	dirs := make(map[string]string)
+	for _, mf := range files {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		base, err := s.restoreBase(ctx, mf, artists[mf.ArtistID], dirs)
+		if err != nil {
+			return result, err
+		}
+		rel := filepath.FromSlash(mf.Rel)
+		if base == "" || !filepath.IsLocal(rel) {
+			result.Skipped++
+			continue
+		}
+		written, err := restoreFile(&zr.Reader, mf, filepath.Join(base, rel))
+		if err != nil {
+			return result, err
+		}
+		if written {
+			result.Files++
+		} else {
+			result.Unchanged++
+		}
+	}
+
+	if dbFile != nil && len(ids) > 0 {
+		n, err := s.restoreNFOSnapshots(ctx, &zr.Reader, *dbFile, ids)
+		if err != nil {
+			return result, err
+		}
+		result.NFOSnapshots = n
+	}
+
+	s.logger.Info("archive restored",
+		slog.String("filename", filename),
+		slog.String("library_id", opts.LibraryID),
+		slog.String("artist_id", opts.ArtistID),
+		slog.Int("artists", result.Artists),
+		slog.Int("files", result.Files),
+		slog.Int("unchanged", result.Unchanged),
+		slog.Int("skipped", result.Skipped),
+		slog.Int("nfo_snapshots", result.NFOSnapshots))
+	return result, nil
+}
+
+// restoreBase returns the directory an artwork or cache file is restored
+// under, or "" when there is none. dirs caches artist directory lookups.
+func (s *Service) restoreBase(ctx context.Context, mf ManifestFile, a ManifestArtist, dirs map[string]string) (string, error) {
+	if mf.Kind == FileCache {
+		if s.imageCacheDir == "" {
+			return "", nil
+		}
+		return filepath.Join(s.imageCacheDir, a.ID), nil
+	}
+	if dir, ok := dirs[a.ID]; ok {
+		return dir, nil
+	}
+	var dir string
+	err := s.db.QueryRowContext(ctx, `SELECT path FROM artists WHERE id = ?`, a.ID).Scan(&dir)
+	if errors.Is(err, sql.ErrNoRows) {
+		dir = a.Path
+	} else if err != nil {
+		return "", fmt.Errorf("looking up artist directory: %w", err)
+	}
+	if !filepath.IsAbs(dir) {
+		dir = ""
+	}
+	dirs[a.ID] = dir
+	return dir, nil
+}
+
+// restoreFile writes the archive entry mf to target unless target already
+// holds the same bytes. It reports whether it wrote.
+func restoreFile(zr *zip.Reader, mf ManifestFile, target string) (bool, error) {
+	if sum, err := fileSHA256(target); err == nil && sum == mf.SHA256 {
+		return false, nil
+	}
+	var buf bytes.Buffer
+	if err := extractEntry(zr, mf, &buf); err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o750); err != nil {
+		return false, fmt.Errorf("creating directory for %s: %w", target, err)
+	}
+	if err := filesystem.WriteFileAtomic(target, buf.Bytes(), 0o644); err != nil {
+		return false, fmt.Errorf("restoring %s: %w", target, err)
+	}
+	return true, nil
+}
+
+// fileSHA256 returns the hex SHA-256 of the file at p.
+func fileSHA256(p string) (string, error) {
+	f, err := os.Open(p) //nolint:gosec // G304: p is a restore target under a known artist or cache directory.
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// restoreNFOSnapshots copies the NFO snapshots of the given artists from the
+// archived database into the live one. The archived database is extracted to
+// an owner-only staging directory and attached to one pinned connection for
+// the copy.
+func (s *Service) restoreNFOSnapshots(ctx context.Context, zr *zip.Reader, dbFile ManifestFile, ids []string) (int, error) {
+	if err := os.MkdirAll(s.backupDir, 0o750); err != nil {
+		return 0, fmt.Errorf("creating backup directory: %w", err)
+	}
+	stagingDir, err := osMkdirTemp(s.backupDir, ".restore-*")
+	if err != nil {
+		return 0, fmt.Errorf("creating staging directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(stagingDir) }()
+
+	dbPath := filepath.Join(stagingDir, "stillwater.db")
+	if err := extractToFile(zr, dbFile, dbPath); err != nil {
+		return 0, err
+	}
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("acquiring connection: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS archived", dbPath); err != nil {
+		return 0, fmt.Errorf("attaching archived database: %w", err)
+	}
+	defer func() { _, _ = conn.ExecContext(context.WithoutCancel(ctx), "DETACH DATABASE archived") }()
+
+	var restored int64
+	for _, id := range ids {
+		res, err := conn.ExecContext(ctx, `
+			INSERT OR IGNORE INTO main.nfo_snapshots (id, artist_id, content, created_at)
+			SELECT id, artist_id, content, created_at FROM archived.nfo_snapshots
+			WHERE artist_id = ? AND EXISTS (SELECT 1 FROM main.artists WHERE id = ?)
+		`, id, id)
+		if err != nil {
+			return int(restored), fmt.Errorf("restoring NFO snapshots: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		restored += n
+	}
+	return int(restored), nil
+}
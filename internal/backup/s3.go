@@ -0,0 +1,343 @@
+package backup
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/httpsafe"
+)
+
+// s3RequestTimeout bounds one S3 request. It is generous because a single
+// PUT carries a whole backup, and a full archive with artwork can be large.
+const s3RequestTimeout = 2 * time.Hour
+
+// emptySHA256 is the hex SHA-256 of an empty body, the payload hash of every
+// request here but PUT.
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Config configures an S3Target.
+type S3Config struct {
+	// Endpoint is the service URL, for example https://s3.eu-west-1.amazonaws.com
+	// or http://minio.lan:9000.
+	Endpoint string
+	// Region is the signing region. MinIO and most S3-compatible services
+	// accept us-east-1.
+	Region string
+	Bucket string
+	// Prefix is prepended to every object key, so backups can share a bucket.
+	Prefix    string
+	AccessKey string
+	SecretKey string
+	// PathStyle addresses the bucket as endpoint/bucket rather than
+	// bucket.endpoint. Self-hosted services generally need it.
+	PathStyle bool
+}
+
+// S3Target stores backups in an S3-compatible bucket, signing requests with
+// AWS Signature Version 4. Uploads are single PUTs with an unsigned payload;
+// the push verifies them by reading them back (see Service.Push).
+type S3Target struct {
+	cfg    S3Config
+	base   *url.URL
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3Target returns a target for cfg. The endpoint comes from operator
+// configuration, so its host is exempt from the HTTP client's private-address
+// guard: a MinIO on the LAN is the common case.
+func NewS3Target(cfg S3Config) (*S3Target, error) {
+	base, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q: want http(s)://host[:port]", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, errors.New("S3 bucket is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	cfg.Prefix = strings.Trim(cfg.Prefix, "/")
+	return &S3Target{
+		cfg:    cfg,
+		base:   base,
+		client: httpsafe.SafeClientWithAllowedHosts(s3RequestTimeout, base.Hostname()),
+		now:    time.Now,
+	}, nil
+}
+
+// Name implements Target.
+func (t *S3Target) Name() string { return "s3" }
+
+// Put implements Target.
+func (t *S3Target) Put(ctx context.Context, name string, r io.Reader, size int64) error {
+	req, err := t.newRequest(ctx, http.MethodPut, t.key(name), nil, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	resp, err := t.do(req, "UNSIGNED-PAYLOAD")
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// Get implements Target.
+func (t *S3Target) Get(ctx context.Context, name string, w io.Writer) error {
+	req, err := t.newRequest(ctx, http.MethodGet, t.key(name), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := t.do(req, emptySHA256)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("reading %s: %w", name, err)
+	}
+	return nil
+}
+
+// Delete implements Target. S3 reports success for a missing key.
+func (t *S3Target) Delete(ctx context.Context, name string) error {
+	req, err := t.newRequest(ctx, http.MethodDelete, t.key(name), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := t.do(req, emptySHA256)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	return nil
+}
+
+// s3ListResult is the part of a ListObjectsV2 response List reads.
+type s3ListResult struct {
+	Contents []struct {
+		Key  string `xml:"Key"`
+		Size int64  `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List implements Target. Only objects directly under the prefix are
+// returned.
+func (t *S3Target) List(ctx context.Context) ([]RemoteObject, error) {
+	prefix := ""
+	if t.cfg.Prefix != "" {
+		prefix = t.cfg.Prefix + "/"
+	}
+	var objects []RemoteObject
+	token := ""
+	for {
+		q := url.Values{"list-type": {"2"}, "prefix": {prefix}, "delimiter": {"/"}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		req, err := t.newRequest(ctx, http.MethodGet, "", q, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := t.do(req, emptySHA256)
+		if err != nil {
+			return nil, err
+		}
+		var result s3ListResult
+		err = xml.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(&result)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decoding bucket listing: %w", err)
+		}
+		for _, c := range result.Contents {
+			objects = append(objects, RemoteObject{Name: strings.TrimPrefix(c.Key, prefix), Size: c.Size})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// key returns the object key for a backup name.
+func (t *S3Target) key(name string) string {
+	if t.cfg.Prefix == "" {
+		return name
+	}
+	return t.cfg.Prefix + "/" + name
+}
+
+// newRequest builds an unsigned request for key in the bucket ("" for the
+// bucket itself).
+func (t *S3Target) newRequest(ctx context.Context, method, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	u := *t.base
+	segments := []string{}
+	if t.cfg.PathStyle {
+		segments = append(segments, t.cfg.Bucket)
+	} else {
+		u.Host = t.cfg.Bucket + "." + u.Host
+	}
+	if key != "" {
+		segments = append(segments, strings.Split(key, "/")...)
+	}
+	u.Path = path.Join(append([]string{"/", u.Path}, segments...)...)
+	if key == "" && t.cfg.PathStyle {
+		u.Path += "/"
+	}
+	u.RawPath = ""
+	u.RawQuery = s3Query(query)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("building S3 request: %w", err)
+	}
+	// Send the path exactly as it is signed.
+	req.URL.Opaque = "//" + req.URL.Host + s3Path(u.Path)
+	return req, nil
+}
+
+// s3Error is an S3 error response body.
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// do signs and sends req, returning the response for a 2xx status and an
+// error otherwise. A missing key or bucket wraps os.ErrNotExist.
+func (t *S3Target) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	t.sign(req, payloadHash)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("S3 %s: %w", req.Method, err)
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var e s3Error
+	_ = xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&e)
+	err = fmt.Errorf("S3 %s: %s %s: %s", req.Method, resp.Status, e.Code, e.Message)
+	if resp.StatusCode == http.StatusNotFound {
+		err = fmt.Errorf("%w: %w", err, os.ErrNotExist)
+	}
+	return nil, err
+}
+
+// sign adds AWS Signature Version 4 headers to req.
+func (t *S3Target) sign(req *http.Request, payloadHash string) {
+	now := t.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		s3Path(req.URL.Path),
+		req.URL.RawQuery,
+		canonHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + t.cfg.Region + "/s3/aws4_request"
+	sum := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	key := s3SigningKey(t.cfg.SecretKey, day, t.cfg.Region, "s3")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+t.cfg.AccessKey+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// s3SigningKey derives the Signature Version 4 signing key.
+func s3SigningKey(secret, day, region, service string) []byte {
+	k := hmacSHA256([]byte("AWS4"+secret), day)
+	k = hmacSHA256(k, region)
+	k = hmacSHA256(k, service)
+	return hmacSHA256(k, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
+
+// s3Escape percent-encodes s as Signature Version 4 requires: everything but
+// the unreserved characters A-Z a-z 0-9 - . _ ~.
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// s3Path is the canonical, escaped form of a URL path.
+func s3Path(p string) string {
+	if p == "" {
+		return "/"
+	}
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		segments[i] = s3Escape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3Query is the canonical query string: keys sorted, both sides escaped.
+func s3Query(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range q[k] {
+			parts = append(parts, s3Escape(k)+"="+s3Escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,573 @@
+package backup
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SFTPConfig configures an SFTPTarget. The server's host key must be pinned,
+// by fingerprint or through a known_hosts file: a backup is the whole
+// database, and handing it to an unverified server gives it away.
+type SFTPConfig struct {
+	// Addr is host:port.
+	Addr string
+	User string
+	// Password and PrivateKey (PEM or OpenSSH format) are tried in that
+	// order; at least one is required.
+	Password   string
+	PrivateKey []byte
+	// HostKeyFingerprint is the server key's SHA256 fingerprint as
+	// ssh-keygen -l prints it ("SHA256:...").
+	HostKeyFingerprint string
+	// KnownHostsFile is an OpenSSH known_hosts file holding the server key.
+	KnownHostsFile string
+	// Dir is the remote directory backups are written to. It must exist.
+	Dir string
+}
+
+// SFTPTarget stores backups in a directory on an SFTP server. Each operation
+// opens its own SSH connection: backups are infrequent, and a connection held
+// open between them would only go stale.
+type SFTPTarget struct {
+	cfg    SFTPConfig
+	client *ssh.ClientConfig
+}
+
+// sftpDialTimeout bounds the TCP connect and SSH handshake.
+const sftpDialTimeout = 30 * time.Second
+
+// NewSFTPTarget returns a target for cfg.
+func NewSFTPTarget(cfg SFTPConfig) (*SFTPTarget, error) {
+	if _, _, err := net.SplitHostPort(cfg.Addr); err != nil {
+		return nil, fmt.Errorf("invalid SFTP address %q: %w", cfg.Addr, err)
+	}
+	if cfg.User == "" {
+		return nil, errors.New("SFTP user is required")
+	}
+	if cfg.Dir == "" {
+		return nil, errors.New("SFTP directory is required")
+	}
+	var auth []ssh.AuthMethod
+	if cfg.Password != "" {
+		auth = append(auth, ssh.Password(cfg.Password))
+	}
+	if len(cfg.PrivateKey) > 0 {
+		signer, err := ssh.ParsePrivateKey(cfg.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("parsing SFTP private key: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if len(auth) == 0 {
+		return nil, errors.New("SFTP password or private key is required")
+	}
+
+	var hostKey ssh.HostKeyCallback
+	switch {
+	case cfg.HostKeyFingerprint != "":
+		want := cfg.HostKeyFingerprint
+		hostKey = func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			if got := ssh.FingerprintSHA256(key); got != want {
+				return fmt.Errorf("SFTP host key %s does not match the configured %s", got, want)
+			}
+			return nil
+		}
+	case cfg.KnownHostsFile != "":
+		cb, err := knownhosts.New(cfg.KnownHostsFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading SFTP known_hosts: %w", err)
+		}
+		hostKey = cb
+	default:
+		return nil, errors.New("SFTP host key fingerprint or known_hosts file is required")
+	}
+
+	return &SFTPTarget{
+		cfg: cfg,
+		client: &ssh.ClientConfig{
+			User:            cfg.User,
+			Auth:            auth,
+			HostKeyCallback: hostKey,
+			Timeout:         sftpDialTimeout,
+		},
+	}, nil
+}
+
+// Name implements Target.
+func (t *SFTPTarget) Name() string { return "sftp" }
+
+// Put implements Target. The file is written under a temporary name and
+// renamed into place, so a listing never shows a half-written backup.
+func (t *SFTPTarget) Put(ctx context.Context, name string, r io.Reader, _ int64) error {
+	return t.with(ctx, func(c *sftpConn) error {
+		final := path.Join(t.cfg.Dir, name)
+		partial := final + ".part"
+		h, err := c.open(partial, sftpOpenWrite|sftpOpenCreate|sftpOpenTrunc)
+		if err != nil {
+			return err
+		}
+		buf := make([]byte, sftpChunk)
+		var off uint64
+		for {
+			n, rerr := io.ReadFull(r, buf)
+			if n > 0 {
+				if err := c.write(h, off, buf[:n]); err != nil {
+					_ = c.close(h)
+					return err
+				}
+				off += uint64(n)
+			}
+			if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+				break
+			}
+			if rerr != nil {
+				_ = c.close(h)
+				return rerr
+			}
+		}
+		if err := c.close(h); err != nil {
+			return err
+		}
+		// SFTP v3 rename refuses an existing target, which a re-pushed
+		// checksum file has.
+		if err := c.remove(final); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return c.rename(partial, final)
+	})
+}
+
+// Get implements Target.
+func (t *SFTPTarget) Get(ctx context.Context, name string, w io.Writer) error {
+	return t.with(ctx, func(c *sftpConn) error {
+		h, err := c.open(path.Join(t.cfg.Dir, name), sftpOpenRead)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = c.close(h) }()
+		var off uint64
+		for {
+			data, err := c.read(h, off, sftpChunk)
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+			off += uint64(len(data))
+		}
+	})
+}
+
+// List implements Target.
+func (t *SFTPTarget) List(ctx context.Context) ([]RemoteObject, error) {
+	var objects []RemoteObject
+	err := t.with(ctx, func(c *sftpConn) error {
+		entries, err := c.readDir(t.cfg.Dir)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if e.regular {
+				objects = append(objects, RemoteObject{Name: e.name, Size: int64(e.size)}) //nolint:gosec // G115: file sizes fit in int64.
+			}
+		}
+		return nil
+	})
+	return objects, err
+}
+
+// Delete implements Target.
+func (t *SFTPTarget) Delete(ctx context.Context, name string) error {
+	return t.with(ctx, func(c *sftpConn) error {
+		return c.remove(path.Join(t.cfg.Dir, name))
+	})
+}
+
+// with opens an SSH connection and SFTP session, runs fn, and closes both.
+// Canceling ctx closes the connection, which fails whatever fn is waiting on.
+func (t *SFTPTarget) with(ctx context.Context, fn func(*sftpConn) error) error {
+	d := net.Dialer{Timeout: sftpDialTimeout}
+	nc, err := d.DialContext(ctx, "tcp", t.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("connecting to SFTP server: %w", err)
+	}
+	stop := context.AfterFunc(ctx, func() { _ = nc.Close() })
+	defer stop()
+
+	sc, chans, reqs, err := ssh.NewClientConn(nc, t.cfg.Addr, t.client)
+	if err != nil {
+		_ = nc.Close()
+		return fmt.Errorf("SSH handshake with SFTP server: %w", err)
+	}
+	client := ssh.NewClient(sc, chans, reqs)
+	defer func() { _ = client.Close() }()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("opening SSH session: %w", err)
+	}
+	defer func() { _ = session.Close() }()
+	in, err := session.StdinPipe()
+	if err != nil {
+		return err
+	}
+	out, err := session.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		return fmt.Errorf("starting SFTP subsystem: %w", err)
+	}
+	c := &sftpConn{w: in, r: out}
+	if err := c.init(); err != nil {
+		return err
+	}
+	if err := fn(c); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+// SFTP protocol version 3 (draft-ietf-secsh-filexfer-02), the version
+// OpenSSH speaks. Only what the target needs is implemented.
+const (
+	sftpVersion = 3
+
+	sftpInit     = 1
+	sftpVersionP = 2
+	sftpOpen     = 3
+	sftpClose    = 4
+	sftpRead     = 5
+	sftpWrite    = 6
+	sftpOpenDir  = 11
+	sftpReadDir  = 12
+	sftpRemove   = 13
+	sftpRename   = 18
+	sftpStatus   = 101
+	sftpHandle   = 102
+	sftpData     = 103
+	sftpName     = 104
+
+	sftpOpenRead   = 0x01
+	sftpOpenWrite  = 0x02
+	sftpOpenCreate = 0x08
+	sftpOpenTrunc  = 0x10
+
+	sftpAttrSize        = 0x01
+	sftpAttrUIDGID      = 0x02
+	sftpAttrPermissions = 0x04
+	sftpAttrACModTime   = 0x08
+	sftpAttrExtended    = 0x80000000
+
+	sftpStatusOK         = 0
+	sftpStatusEOF        = 1
+	sftpStatusNoSuchFile = 2
+	sftpStatusPermDenied = 3
+	sftpMaxPacket        = 1 << 20
+	sftpChunk            = 32 << 10
+	sftpModeTypeMask     = 0o170000
+	sftpModeRegular      = 0o100000
+)
+
+// sftpConn is one SFTP session. Requests are sent one at a time.
+type sftpConn struct {
+	w    io.Writer
+	r    io.Reader
+	next uint32
+}
+
+// sftpStatusError is an SSH_FXP_STATUS reply other than OK.
+type sftpStatusError struct {
+	code uint32
+	msg  string
+}
+
+func (e *sftpStatusError) Error() string {
+	return "sftp: " + e.msg + " (status " + strconv.FormatUint(uint64(e.code), 10) + ")"
+}
+
+func (e *sftpStatusError) Is(target error) bool {
+	switch target {
+	case os.ErrNotExist:
+		return e.code == sftpStatusNoSuchFile
+	case os.ErrPermission:
+		return e.code == sftpStatusPermDenied
+	case io.EOF:
+		return e.code == sftpStatusEOF
+	}
+	return false
+}
+
+func (c *sftpConn) init() error {
+	if err := c.send(sftpInit, binary.BigEndian.AppendUint32(nil, sftpVersion)); err != nil {
+		return err
+	}
+	typ, _, err := c.recv()
+	if err != nil {
+		return err
+	}
+	if typ != sftpVersionP {
+		return fmt.Errorf("sftp: unexpected packet %d during init", typ)
+	}
+	return nil
+}
+
+func (c *sftpConn) send(typ byte, payload []byte) error {
+	pkt := binary.BigEndian.AppendUint32(nil, uint32(len(payload)+1)) //nolint:gosec // G115: payloads are bounded by sftpChunk plus headers.
+	pkt = append(pkt, typ)
+	pkt = append(pkt, payload...)
+	_, err := c.w.Write(pkt)
+	return err
+}
+
+func (c *sftpConn) recv() (byte, []byte, error) {
+	var hdr [4]byte
+	if _, err := io.ReadFull(c.r, hdr[:]); err != nil {
+		return 0, nil, fmt.Errorf("sftp: reading reply: %w", err)
+	}
+	n := binary.BigEndian.Uint32(hdr[:])
+	if n == 0 || n > sftpMaxPacket {
+		return 0, nil, fmt.Errorf("sftp: bad packet length %d", n)
+	}
+	pkt := make([]byte, n)
+	if _, err := io.ReadFull(c.r, pkt); err != nil {
+		return 0, nil, fmt.Errorf("sftp: reading reply: %w", err)
+	}
+	return pkt[0], pkt[1:], nil
+}
+
+// request sends a request of typ with a fresh id followed by body, and
+// returns the reply's type and payload after the id. A STATUS reply other
+// than OK is returned as an *sftpStatusError.
+func (c *sftpConn) request(typ byte, body []byte) (byte, []byte, error) {
+	c.next++
+	id := c.next
+	if err := c.send(typ, append(binary.BigEndian.AppendUint32(nil, id), body...)); err != nil {
+		return 0, nil, err
+	}
+	rtyp, payload, err := c.recv()
+	if err != nil {
+		return 0, nil, err
+	}
+	b := sftpBuf(payload)
+	if got, ok := b.uint32(); !ok || got != id {
+		return 0, nil, fmt.Errorf("sftp: reply to request %d carries id %d", id, got)
+	}
+	if rtyp == sftpStatus {
+		code, _ := b.uint32()
+		msg, _ := b.string()
+		if code == sftpStatusOK {
+			return rtyp, nil, nil
+		}
+		return 0, nil, &sftpStatusError{code: code, msg: msg}
+	}
+	return rtyp, b, nil
+}
+
+func (c *sftpConn) handleRequest(typ byte, body []byte) (string, error) {
+	rtyp, payload, err := c.request(typ, body)
+	if err != nil {
+		return "", err
+	}
+	b := sftpBuf(payload)
+	h, ok := b.string()
+	if rtyp != sftpHandle || !ok {
+		return "", fmt.Errorf("sftp: expected a handle, got packet %d", rtyp)
+	}
+	return h, nil
+}
+
+func (c *sftpConn) statusRequest(typ byte, body []byte) error {
+	rtyp, _, err := c.request(typ, body)
+	if err != nil {
+		return err
+	}
+	if rtyp != sftpStatus {
+		return fmt.Errorf("sftp: expected a status, got packet %d", rtyp)
+	}
+	return nil
+}
+
+func (c *sftpConn) open(p string, flags uint32) (string, error) {
+	body := appendSFTPString(nil, p)
+	body = binary.BigEndian.AppendUint32(body, flags)
+	body = binary.BigEndian.AppendUint32(body, sftpAttrPermissions)
+	body = binary.BigEndian.AppendUint32(body, 0o600)
+	return c.handleRequest(sftpOpen, body)
+}
+
+func (c *sftpConn) close(h string) error {
+	return c.statusRequest(sftpClose, appendSFTPString(nil, h))
+}
+
+func (c *sftpConn) write(h string, off uint64, data []byte) error {
+	body := appendSFTPString(nil, h)
+	body = binary.BigEndian.AppendUint64(body, off)
+	body = appendSFTPString(body, string(data))
+	return c.statusRequest(sftpWrite, body)
+}
+
+// read returns up to n bytes at off, or an error matching io.EOF past the end.
+func (c *sftpConn) read(h string, off uint64, n uint32) ([]byte, error) {
+	body := appendSFTPString(nil, h)
+	body = binary.BigEndian.AppendUint64(body, off)
+	body = binary.BigEndian.AppendUint32(body, n)
+	rtyp, payload, err := c.request(sftpRead, body)
+	if err != nil {
+		return nil, err
+	}
+	b := sftpBuf(payload)
+	data, ok := b.string()
+	if rtyp != sftpData || !ok {
+		return nil, fmt.Errorf("sftp: expected data, got packet %d", rtyp)
+	}
+	return []byte(data), nil
+}
+
+func (c *sftpConn) remove(p string) error {
+	return c.statusRequest(sftpRemove, appendSFTPString(nil, p))
+}
+
+func (c *sftpConn) rename(from, to string) error {
+	return c.statusRequest(sftpRename, appendSFTPString(appendSFTPString(nil, from), to))
+}
+
+// sftpEntry is one directory entry.
+type sftpEntry struct {
+	name    string
+	size    uint64
+	regular bool
+}
+
+func (c *sftpConn) readDir(dir string) ([]sftpEntry, error) {
+	h, err := c.handleRequest(sftpOpenDir, appendSFTPString(nil, dir))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = c.close(h) }()
+	var entries []sftpEntry
+	for {
+		rtyp, payload, err := c.request(sftpReadDir, appendSFTPString(nil, h))
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if rtyp != sftpName {
+			return nil, fmt.Errorf("sftp: expected names, got packet %d", rtyp)
+		}
+		b := sftpBuf(payload)
+		count, _ := b.uint32()
+		for range count {
+			name, ok1 := b.string()
+			_, ok2 := b.string() // longname
+			size, mode, ok3 := b.attrs()
+			if !ok1 || !ok2 || !ok3 {
+				return nil, errors.New("sftp: malformed directory listing")
+			}
+			if name == "." || name == ".." || strings.Contains(name, "/") {
+				continue
+			}
+			entries = append(entries, sftpEntry{name: name, size: size, regular: mode == 0 || mode&sftpModeTypeMask == sftpModeRegular})
+		}
+	}
+}
+
+func appendSFTPString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s))) //nolint:gosec // G115: strings are bounded by sftpChunk or path lengths.
+	return append(b, s...)
+}
+
+// sftpBuf decodes SFTP wire values, consuming them from the front.
+type sftpBuf []byte
+
+func (b *sftpBuf) uint32() (uint32, bool) {
+	if len(*b) < 4 {
+		return 0, false
+	}
+	v := binary.BigEndian.Uint32(*b)
+	*b = (*b)[4:]
+	return v, true
+}
+
+func (b *sftpBuf) uint64() (uint64, bool) {
+	if len(*b) < 8 {
+		return 0, false
+	}
+	v := binary.BigEndian.Uint64(*b)
+	*b = (*b)[8:]
+	return v, true
+}
+
+func (b *sftpBuf) string() (string, bool) {
+	n, ok := b.uint32()
+	if !ok || uint32(len(*b)) < n { //nolint:gosec // G115: packet lengths are capped at sftpMaxPacket.
+		return "", false
+	}
+	s := string((*b)[:n])
+	*b = (*b)[n:]
+	return s, true
+}
+
+// attrs decodes an ATTRS block and returns its size and permissions (zero
+// when absent).
+func (b *sftpBuf) attrs() (size uint64, mode uint32, ok bool) {
+	flags, ok := b.uint32()
+	if !ok {
+		return 0, 0, false
+	}
+	if flags&sftpAttrSize != 0 {
+		if size, ok = b.uint64(); !ok {
+			return 0, 0, false
+		}
+	}
+	if flags&sftpAttrUIDGID != 0 {
+		if _, ok = b.uint64(); !ok {
+			return 0, 0, false
+		}
+	}
+	if flags&sftpAttrPermissions != 0 {
+		if mode, ok = b.uint32(); !ok {
+			return 0, 0, false
+		}
+	}
+	if flags&sftpAttrACModTime != 0 {
+		if _, ok = b.uint64(); !ok {
+			return 0, 0, false
+		}
+	}
+	if flags&sftpAttrExtended != 0 {
+		n, ok := b.uint32()
+		if !ok {
+			return 0, 0, false
+		}
+		for range n {
+			_, ok1 := b.string()
+			_, ok2 := b.string()
+			if !ok1 || !ok2 {
+				return 0, 0, false
+			}
+		}
+	}
+	return size, mode, true
+}
@@ -0,0 +1,338 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// startSFTPServer runs an SSH server on loopback whose "sftp" subsystem serves
+// the local filesystem, enough of it for SFTPTarget. It returns the address
+// and the host key fingerprint.
+func startSFTPServer(t *testing.T) (addr, fingerprint string) {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if c.User() == "backup" && string(pass) == "hunter2" {
+				return nil, nil
+			}
+			return nil, errors.New("denied")
+		},
+	}
+	cfg.AddHostKey(signer)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSSH(nc, cfg)
+		}
+	}()
+	return ln.Addr().String(), ssh.FingerprintSHA256(signer.PublicKey())
+}
+
+func serveSSH(nc net.Conn, cfg *ssh.ServerConfig) {
+	defer func() { _ = nc.Close() }()
+	_, chans, reqs, err := ssh.NewServerConn(nc, cfg)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for nch := range chans {
+		if nch.ChannelType() != "session" {
+			_ = nch.Reject(ssh.UnknownChannelType, "session only")
+			continue
+		}
+		ch, chReqs, err := nch.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range chReqs {
+				ok := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+				_ = req.Reply(ok, nil)
+				if ok {
+					go func() {
+						defer func() { _ = ch.Close() }()
+						(&testSFTPServer{rw: ch, handles: make(map[string]any)}).serve()
+					}()
+				}
+			}
+		}()
+	}
+}
+
+// testSFTPServer is the server half of the SFTP subset SFTPTarget speaks.
+// Like OpenSSH with protocol version 3, rename refuses an existing target.
+type testSFTPServer struct {
+	rw      io.ReadWriter
+	handles map[string]any // *os.File, or []fs.DirEntry for a directory
+	next    int
+}
+
+func (s *testSFTPServer) serve() {
+	c := &sftpConn{w: s.rw, r: s.rw}
+	for {
+		typ, payload, err := c.recv()
+		if err != nil {
+			return
+		}
+		b := sftpBuf(payload)
+		if typ == sftpInit {
+			_ = c.send(sftpVersionP, binary.BigEndian.AppendUint32(nil, sftpVersion))
+			continue
+		}
+		id, _ := b.uint32()
+		rtyp, reply := s.handle(typ, &b)
+		_ = c.send(rtyp, append(binary.BigEndian.AppendUint32(nil, id), reply...))
+	}
+}
+
+func (s *testSFTPServer) handle(typ byte, b *sftpBuf) (byte, []byte) {
+	switch typ {
+	case sftpOpen:
+		p, _ := b.string()
+		pflags, _ := b.uint32()
+		flags := os.O_RDONLY
+		if pflags&sftpOpenWrite != 0 {
+			flags = os.O_WRONLY
+		}
+		if pflags&sftpOpenCreate != 0 {
+			flags |= os.O_CREATE
+		}
+		if pflags&sftpOpenTrunc != 0 {
+			flags |= os.O_TRUNC
+		}
+		f, err := os.OpenFile(p, flags, 0o600) //nolint:gosec // G304: test server over a temp dir.
+		if err != nil {
+			return statusReply(err)
+		}
+		return s.newHandle(f)
+	case sftpOpenDir:
+		p, _ := b.string()
+		entries, err := os.ReadDir(p)
+		if err != nil {
+			return statusReply(err)
+		}
+		return s.newHandle(entries)
+	case sftpReadDir:
+		h, _ := b.string()
+		entries, ok := s.handles[h].([]fs.DirEntry)
+		if !ok || len(entries) == 0 {
+			return statusReply(io.EOF)
+		}
+		s.handles[h] = []fs.DirEntry{}
+		reply := binary.BigEndian.AppendUint32(nil, uint32(len(entries))) //nolint:gosec // G115: test directories are tiny.
+		for _, e := range entries {
+			info, err := e.Info()
+			if err != nil {
+				return statusReply(err)
+			}
+			mode := uint32(0o100600)
+			if e.IsDir() {
+				mode = 0o040700
+			}
+			reply = appendSFTPString(reply, e.Name())
+			reply = appendSFTPString(reply, "-rw------- 1 backup backup "+e.Name())
+			reply = binary.BigEndian.AppendUint32(reply, sftpAttrSize|sftpAttrPermissions)
+			reply = binary.BigEndian.AppendUint64(reply, uint64(info.Size())) //nolint:gosec // G115: sizes are non-negative.
+			reply = binary.BigEndian.AppendUint32(reply, mode)
+		}
+		return sftpName, reply
+	case sftpClose:
+		h, _ := b.string()
+		if f, ok := s.handles[h].(*os.File); ok {
+			_ = f.Close()
+		}
+		delete(s.handles, h)
+		return statusReply(nil)
+	case sftpRead:
+		h, _ := b.string()
+		off, _ := b.uint64()
+		n, _ := b.uint32()
+		f, _ := s.handles[h].(*os.File)
+		buf := make([]byte, n)
+		got, err := f.ReadAt(buf, int64(off)) //nolint:gosec // G115: test offsets are small.
+		if got == 0 {
+			return statusReply(err)
+		}
+		return sftpData, appendSFTPString(nil, string(buf[:got]))
+	case sftpWrite:
+		h, _ := b.string()
+		off, _ := b.uint64()
+		data, _ := b.string()
+		f, _ := s.handles[h].(*os.File)
+		_, err := f.WriteAt([]byte(data), int64(off)) //nolint:gosec // G115: test offsets are small.
+		return statusReply(err)
+	case sftpRemove:
+		p, _ := b.string()
+		return statusReply(os.Remove(p))
+	case sftpRename:
+		from, _ := b.string()
+		to, _ := b.string()
+		if _, err := os.Stat(to); err == nil {
+			return statusReply(errors.New("target exists"))
+		}
+		return statusReply(os.Rename(from, to))
+	}
+	return statusReply(errors.New("unsupported"))
+}
+
+func (s *testSFTPServer) newHandle(v any) (byte, []byte) {
+	s.next++
+	h := strconv.Itoa(s.next)
+	s.handles[h] = v
+	return sftpHandle, appendSFTPString(nil, h)
+}
+
+func statusReply(err error) (byte, []byte) {
+	code := uint32(sftpStatusOK)
+	msg := "ok"
+	switch {
+	case err == nil:
+	case errors.Is(err, io.EOF):
+		code, msg = sftpStatusEOF, "eof"
+	case errors.Is(err, os.ErrNotExist):
+		code, msg = sftpStatusNoSuchFile, "no such file"
+	default:
+		code, msg = 4, err.Error() // SSH_FX_FAILURE
+	}
+	return sftpStatus, appendSFTPString(appendSFTPString(binary.BigEndian.AppendUint32(nil, code), msg), "")
+}
+
+func newTestSFTPTarget(t *testing.T) (*SFTPTarget, string) {
+	t.Helper()
+	addr, fingerprint := startSFTPServer(t)
+	dir := t.TempDir()
+	target, err := NewSFTPTarget(SFTPConfig{
+		Addr:               addr,
+		User:               "backup",
+		Password:           "hunter2",
+		HostKeyFingerprint: fingerprint,
+		Dir:                dir,
+	})
+	if err != nil {
+		t.Fatalf("NewSFTPTarget: %v", err)
+	}
+	return target, dir
+}
+
+func TestSFTPTarget_RoundTrip(t *testing.T) {
+	target, dir := newTestSFTPTarget(t)
+	ctx := context.Background()
+	// Larger than one chunk, so reads and writes take several requests.
+	body := bytes.Repeat([]byte("stillwater"), sftpChunk/5)
+
+	if err := target.Put(ctx, "a.db", bytes.NewReader(body), int64(len(body))); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	// A second Put replaces the file, as a re-pushed checksum file needs.
+	if err := target.Put(ctx, "a.db", bytes.NewReader(body), int64(len(body))); err != nil {
+		t.Fatalf("second Put: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "subdir"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	var got bytes.Buffer
+	if err := target.Get(ctx, "a.db", &got); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !bytes.Equal(got.Bytes(), body) {
+		t.Errorf("Get returned %d bytes, want %d", got.Len(), len(body))
+	}
+	if err := target.Get(ctx, "missing.db", io.Discard); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Get missing: err = %v, want os.ErrNotExist", err)
+	}
+
+	objects, err := target.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(objects) != 1 || objects[0].Name != "a.db" || objects[0].Size != int64(len(body)) {
+		t.Errorf("List = %+v, want only a.db", objects)
+	}
+
+	if err := target.Delete(ctx, "a.db"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := target.Delete(ctx, "a.db"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("second Delete: err = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestSFTPTarget_PushAndFetch(t *testing.T) {
+	target, dir := newTestSFTPTarget(t)
+	ctx := context.Background()
+	src := newTargetTestService(t)
+	src.AddTarget(target, 7)
+	info, err := src.Backup(ctx)
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	if err := src.Push(ctx, info.Filename); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, info.Filename+checksumSuffix)); err != nil {
+		t.Errorf("checksum file not pushed: %v", err)
+	}
+
+	dst := newTargetTestService(t)
+	dst.AddTarget(target, 7)
+	if _, err := dst.FetchRemote(ctx, "sftp", info.Filename); err != nil {
+		t.Fatalf("FetchRemote: %v", err)
+	}
+}
+
+func TestSFTPTarget_RejectsUnpinnedHostKey(t *testing.T) {
+	addr, _ := startSFTPServer(t)
+	target, err := NewSFTPTarget(SFTPConfig{
+		Addr:               addr,
+		User:               "backup",
+		Password:           "hunter2",
+		HostKeyFingerprint: "SHA256:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA",
+		Dir:                t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("NewSFTPTarget: %v", err)
+	}
+	err = target.Put(context.Background(), "a.db", strings.NewReader("x"), 1)
+	if err == nil || !strings.Contains(err.Error(), "host key") {
+		t.Errorf("Put to an unpinned host: err = %v, want a host key error", err)
+	}
+}
+
+func TestNewSFTPTarget_RequiresHostKey(t *testing.T) {
+	_, err := NewSFTPTarget(SFTPConfig{Addr: "nas.lan:22", User: "backup", Password: "x", Dir: "/backups"})
+	if err == nil {
+		t.Error("NewSFTPTarget without a pinned host key succeeded")
+	}
+}
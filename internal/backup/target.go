@@ -0,0 +1,451 @@
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// checksumSuffix is appended to a backup's name for the checksum file pushed
+// beside it. The file holds one sha256sum(1)-style line, "<hex>  <name>", so
+// a copy can be checked with standard tools as well as by FetchRemote.
+const checksumSuffix = ".sha256"
+
+// Push stages reported in PushFailure.Stage.
+const (
+	// StageUpload is a failure to write the backup or its checksum file.
+	StageUpload = "upload"
+	// StageVerify is a failure to read the upload back, or a read-back whose
+	// checksum does not match the local file. The remote copy is removed.
+	StageVerify = "verify"
+	// StagePrune is a failure to apply the target's retention. The backup
+	// itself was pushed and verified.
+	StagePrune = "prune"
+)
+
+// ErrUnknownTarget is returned for a target name that is not configured.
+var ErrUnknownTarget = errors.New("unknown backup target")
+
+// ErrChecksumMismatch is returned when a pushed or fetched backup does not
+// match the checksum of the original.
+var ErrChecksumMismatch = errors.New("backup checksum mismatch")
+
+// ErrNoChecksum is returned by FetchRemote for a remote backup that has no
+// checksum file to verify the download against.
+var ErrNoChecksum = errors.New("remote backup has no checksum")
+
+// Target is an off-box destination backups are copied to. Names are bare
+// backup filenames; where they live on the remote (a bucket prefix, a
+// directory) is the target's business. Get and Delete of a missing name
+// return an error wrapping os.ErrNotExist.
+type Target interface {
+	// Name identifies the target in logs, notifications and the API.
+	Name() string
+	Put(ctx context.Context, name string, r io.Reader, size int64) error
+	Get(ctx context.Context, name string, w io.Writer) error
+	List(ctx context.Context) ([]RemoteObject, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// RemoteObject is one file as a Target lists it.
+type RemoteObject struct {
+	Name string
+	Size int64
+}
+
+// RemoteBackup describes a backup held by a target.
+type RemoteBackup struct {
+	BackupInfo
+	Target string `json:"target"`
+	// Verifiable reports whether the checksum file is present, without
+	// which FetchRemote refuses the backup.
+	Verifiable bool `json:"verifiable"`
+}
+
+// PushFailure describes a backup that could not be copied to a target, for
+// the notifier set with WithPushFailureNotifier.
+type PushFailure struct {
+	Target   string
+	Filename string
+	Stage    string
+	Err      error
+}
+
+// Message is a one-line description of the failure for operators. It leaves
+// out the underlying error, which may carry remote paths or server replies.
+func (f PushFailure) Message() string {
+	switch f.Stage {
+	case StageVerify:
+		return fmt.Sprintf("Backup %s failed verification on %s and was removed there", f.Filename, f.Target)
+	case StagePrune:
+		return fmt.Sprintf("Backup %s was copied to %s, but older backups there could not be pruned", f.Filename, f.Target)
+	default:
+		return fmt.Sprintf("Backup %s could not be copied to %s", f.Filename, f.Target)
+	}
+}
+
+// remoteTarget is a configured Target with its own retention count.
+type remoteTarget struct {
+	Target
+	retention int
+}
+
+// AddTarget adds an off-box target that the scheduler pushes every new backup
+// to, keeping the newest retention backups there. A non-positive retention
+// keeps everything. It returns the service for chaining.
+func (s *Service) AddTarget(t Target, retention int) *Service {
+	s.mu.Lock()
+	s.targets = append(s.targets, &remoteTarget{Target: t, retention: retention})
+	s.mu.Unlock()
+	s.logger.Info("backup target added", slog.String("target", t.Name()), slog.Int("retention", retention))
+	return s
+}
+
+// WithPushFailureNotifier sets the function told about each failed push, and
+// returns the service for chaining.
+func (s *Service) WithPushFailureNotifier(fn func(PushFailure)) *Service {
+	s.mu.Lock()
+	s.notifyPush = fn
+	s.mu.Unlock()
+	return s
+}
+
+// TargetNames returns the names of the configured targets, in the order they
+// were added.
+func (s *Service) TargetNames() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.targets))
+	for _, t := range s.targets {
+		names = append(names, t.Name())
+	}
+	return names
+}
+
+func (s *Service) targetList() []*remoteTarget {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]*remoteTarget(nil), s.targets...)
+}
+
+func (s *Service) target(name string) (*remoteTarget, error) {
+	for _, t := range s.targetList() {
+		if t.Name() == name {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("%q: %w", name, ErrUnknownTarget)
+}
+
+// Push copies a local backup to every target, verifies each copy by reading
+// it back against the local checksum, and then applies the target's
+// retention. Targets are independent: a failure on one is logged, reported
+// to the push-failure notifier, and does not stop the others. The returned
+// error joins every failure.
+func (s *Service) Push(ctx context.Context, filename string) error {
+	if !IsValidBackupFilename(filename) {
+		return fmt.Errorf("invalid backup filename")
+	}
+	targets := s.targetList()
+	if len(targets) == 0 {
+		return nil
+	}
+	local := filepath.Join(s.backupDir, filename)
+	sum, err := fileSHA256(local)
+	if err != nil {
+		return fmt.Errorf("hashing backup: %w", err)
+	}
+
+	var errs []error
+	for _, t := range targets {
+		stage, err := s.pushTo(ctx, t, local, filename, sum)
+		if err == nil {
+			continue
+		}
+		err = fmt.Errorf("%s: %s: %w", t.Name(), stage, err)
+		errs = append(errs, err)
+		s.logger.Error("backup push failed",
+			slog.String("target", t.Name()),
+			slog.String("filename", filename),
+			slog.String("stage", stage),
+			slog.Any("error", err))
+		s.mu.RLock()
+		notify := s.notifyPush
+		s.mu.RUnlock()
+		if notify != nil {
+			notify(PushFailure{Target: t.Name(), Filename: filename, Stage: stage, Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// pushTo uploads one backup and its checksum file to t, reads the upload back
+// to check it, and prunes t. It returns the stage that failed.
+func (s *Service) pushTo(ctx context.Context, t *remoteTarget, local, filename, sum string) (string, error) {
+	f, err := os.Open(local) //nolint:gosec // G304: local is a validated backup filename inside backupDir.
+	if err != nil {
+		return StageUpload, err
+	}
+	defer func() { _ = f.Close() }()
+	st, err := f.Stat()
+	if err != nil {
+		return StageUpload, err
+	}
+	if err := t.Put(ctx, filename, f, st.Size()); err != nil {
+		return StageUpload, err
+	}
+	line := sum + "  " + filename + "\n"
+	if err := t.Put(ctx, filename+checksumSuffix, strings.NewReader(line), int64(len(line))); err != nil {
+		return StageUpload, err
+	}
+
+	h := sha256.New()
+	if err := t.Get(ctx, filename, h); err != nil {
+		return StageVerify, err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != sum {
+		// A copy that does not match is worse than none: it would pass for a
+		// good backup until the day it is needed.
+		_ = t.Delete(ctx, filename)
+		_ = t.Delete(ctx, filename+checksumSuffix)
+		return StageVerify, fmt.Errorf("read back %s, want %s: %w", got, sum, ErrChecksumMismatch)
+	}
+	s.logger.Info("backup pushed",
+		slog.String("target", t.Name()),
+		slog.String("filename", filename),
+		slog.Int64("size", st.Size()))
+
+	if err := s.pruneTarget(ctx, t); err != nil {
+		return StagePrune, err
+	}
+	return "", nil
+}
+
+// pruneTarget deletes the backups on t beyond its retention count, newest
+// kept, together with their checksum files. Files that are not backups are
+// left alone.
+func (s *Service) pruneTarget(ctx context.Context, t *remoteTarget) error {
+	if t.retention <= 0 {
+		return nil
+	}
+	backups, err := s.listTarget(ctx, t)
+	if err != nil {
+		return err
+	}
+	if len(backups) <= t.retention {
+		return nil
+	}
+	var errs []error
+	for _, b := range backups[t.retention:] {
+		if err := t.Delete(ctx, b.Filename); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("deleting %s: %w", b.Filename, err))
+			continue
+		}
+		if b.Verifiable {
+			if err := t.Delete(ctx, b.Filename+checksumSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, fmt.Errorf("deleting %s: %w", b.Filename+checksumSuffix, err))
+			}
+		}
+		s.logger.Info("pruned remote backup", slog.String("target", t.Name()), slog.String("filename", b.Filename))
+	}
+	return errors.Join(errs...)
+}
+
+// listTarget returns the backups on t, newest first.
+func (s *Service) listTarget(ctx context.Context, t *remoteTarget) ([]RemoteBackup, error) {
+	objects, err := t.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing %s: %w", t.Name(), err)
+	}
+	sums := make(map[string]bool)
+	for _, o := range objects {
+		if name, ok := strings.CutSuffix(o.Name, checksumSuffix); ok {
+			sums[name] = true
+		}
+	}
+	var backups []RemoteBackup
+	for _, o := range objects {
+		if !IsValidBackupFilename(o.Name) {
+			continue
+		}
+		ts, _ := backupTime(o.Name)
+		backups = append(backups, RemoteBackup{
+			BackupInfo: BackupInfo{Filename: o.Name, Kind: kindOf(o.Name), Size: o.Size, CreatedAt: ts, Encrypted: isEncrypted(o.Name)},
+			Target:     t.Name(),
+			Verifiable: sums[o.Name],
+		})
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		if !backups[i].CreatedAt.Equal(backups[j].CreatedAt) {
+			return backups[i].CreatedAt.After(backups[j].CreatedAt)
+		}
+		return backups[i].Filename > backups[j].Filename
+	})
+	return backups, nil
+}
+
+// ListRemote returns the backups on every target, each target's newest
+// first. A target that cannot be listed fails the call.
+func (s *Service) ListRemote(ctx context.Context) ([]RemoteBackup, error) {
+	var all []RemoteBackup
+	for _, t := range s.targetList() {
+		backups, err := s.listTarget(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, backups...)
+	}
+	return all, nil
+}
+
+// FetchRemote downloads a backup from the named target into the local backup
+// directory, checking it against the checksum file pushed beside it, and
+// describes the local copy. A local backup of the same name is reused when
+// its content matches; one that differs is an error wrapping os.ErrExist. A
+// download that fails its checksum returns ErrChecksumMismatch, and a backup
+// with no checksum file ErrNoChecksum. Nothing is left behind on failure.
+func (s *Service) FetchRemote(ctx context.Context, targetName, filename string) (*BackupInfo, error) {
+	if !IsValidBackupFilename(filename) {
+		return nil, fmt.Errorf("invalid backup filename")
+	}
+	t, err := s.target(targetName)
+	if err != nil {
+		return nil, err
+	}
+	want, err := remoteChecksum(ctx, t, filename)
+	if err != nil {
+		return nil, err
+	}
+	ts, _ := backupTime(filename)
+
+	dest := filepath.Join(s.backupDir, filename)
+	if sum, err := fileSHA256(dest); err == nil {
+		if sum != want {
+			return nil, fmt.Errorf("a different local backup is named %s: %w", filename, os.ErrExist)
+		}
+		return s.describe(filename, ts)
+	}
+
+	if err := os.MkdirAll(s.backupDir, 0o750); err != nil {
+		return nil, fmt.Errorf("creating backup directory: %w", err)
+	}
+	stagingDir, err := osMkdirTemp(s.backupDir, ".fetch-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating staging directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(stagingDir) }()
+
+	stagingPath := filepath.Join(stagingDir, filename)
+	f, err := os.OpenFile(stagingPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) //nolint:gosec // G304: stagingPath is inside the private staging directory created above.
+	if err != nil {
+		return nil, fmt.Errorf("creating staging file: %w", err)
+	}
+	h := sha256.New()
+	err = t.Get(ctx, filename, io.MultiWriter(f, h))
+	if syncErr := f.Sync(); err == nil {
+		err = syncErr
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s from %s: %w", filename, t.Name(), err)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return nil, fmt.Errorf("downloaded %s from %s: got %s, want %s: %w", filename, t.Name(), got, want, ErrChecksumMismatch)
+	}
+
+	// A fetched backup keeps the name it has on the target, so unlike
+	// linkIntoPlace a name taken since the check above is an error.
+	st, err := os.Stat(stagingPath)
+	if err != nil {
+		return nil, fmt.Errorf("stat staging file: %w", err)
+	}
+	if err := osLink(stagingPath, dest); err != nil {
+		return nil, fmt.Errorf("moving backup into place: %w", err)
+	}
+	if err := syncBackupDir(s.backupDir); err != nil {
+		s.logger.Warn("backup directory sync failed; backup is in place but its directory entry is not yet durable",
+			slog.String("filename", filename),
+			slog.String("error", err.Error()))
+	}
+	if err := s.recordChecksum(filename, want, st.Size()); err != nil {
+		s.logger.Warn("recording backup checksum failed; the verify job will record it",
+			slog.String("filename", filename),
+			slog.String("error", err.Error()))
+	}
+	info, err := s.describe(filename, ts)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("backup fetched", slog.String("target", t.Name()), slog.String("filename", filename), slog.Int64("size", info.Size))
+	return info, nil
+}
+
+// remoteChecksum reads the hex SHA-256 recorded in the checksum file for
+// filename on t.
+func remoteChecksum(ctx context.Context, t Target, filename string) (string, error) {
+	var buf strings.Builder
+	err := t.Get(ctx, filename+checksumSuffix, &limitedWriter{w: &buf, n: 4096})
+	if errors.Is(err, os.ErrNotExist) {
+		// Tell a missing backup apart from a missing checksum file.
+		objects, listErr := t.List(ctx)
+		if listErr != nil {
+			return "", fmt.Errorf("listing %s: %w", t.Name(), listErr)
+		}
+		for _, o := range objects {
+			if o.Name == filename {
+				return "", fmt.Errorf("%s on %s: %w", filename, t.Name(), ErrNoChecksum)
+			}
+		}
+		return "", fmt.Errorf("%s on %s: %w", filename, t.Name(), os.ErrNotExist)
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading checksum of %s from %s: %w", filename, t.Name(), err)
+	}
+	fields := strings.Fields(buf.String())
+	if len(fields) == 0 {
+		return "", fmt.Errorf("checksum file of %s on %s is empty: %w", filename, t.Name(), ErrNoChecksum)
+	}
+	sum := fields[0]
+	if _, err := hex.DecodeString(sum); err != nil || len(sum) != sha256.Size*2 {
+		return "", fmt.Errorf("checksum file of %s on %s is malformed: %w", filename, t.Name(), ErrNoChecksum)
+	}
+	return strings.ToLower(sum), nil
+}
+
+// limitedWriter accepts at most n bytes and fails past that, so a runaway
+// remote file cannot fill memory.
+type limitedWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > l.n {
+		return 0, errors.New("remote file is larger than expected")
+	}
+	l.n -= int64(len(p))
+	return l.w.Write(p)
+}
+
+// describe stats a backup in backupDir and looks up its verification.
+func (s *Service) describe(filename string, createdAt time.Time) (*BackupInfo, error) {
+	st, err := os.Stat(filepath.Join(s.backupDir, filename))
+	if err != nil {
+		return nil, fmt.Errorf("stat backup file: %w", err)
+	}
+	info := &BackupInfo{Filename: filename, Kind: kindOf(filename), Size: st.Size(), CreatedAt: createdAt, Encrypted: isEncrypted(filename)}
+	sums, _ := s.readLedger()
+	sums.describe(info)
+	return info, nil
+}
@@ -0,0 +1,274 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// memTarget is an in-memory Target. corrupt, when set, flips a byte of every
+// backup Get returns, as a target that stores bytes wrongly would.
+type memTarget struct {
+	mu      sync.Mutex
+	name    string
+	files   map[string][]byte
+	corrupt bool
+	putErr  error
+}
+
+func newMemTarget(name string) *memTarget {
+	return &memTarget{name: name, files: make(map[string][]byte)}
+}
+
+func (m *memTarget) Name() string { return m.name }
+
+func (m *memTarget) Put(_ context.Context, name string, r io.Reader, _ int64) error {
+	if m.putErr != nil {
+		return m.putErr
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[name] = data
+	return nil
+}
+
+func (m *memTarget) Get(_ context.Context, name string, w io.Writer) error {
+	m.mu.Lock()
+	data, ok := m.files[name]
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%s: %w", name, os.ErrNotExist)
+	}
+	if m.corrupt && IsValidBackupFilename(name) && len(data) > 0 {
+		data = bytes.Clone(data)
+		data[len(data)/2] ^= 0xff
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+func (m *memTarget) List(context.Context) ([]RemoteObject, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var objects []RemoteObject
+	for name, data := range m.files {
+		objects = append(objects, RemoteObject{Name: name, Size: int64(len(data))})
+	}
+	return objects, nil
+}
+
+func (m *memTarget) Delete(_ context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[name]; !ok {
+		return fmt.Errorf("%s: %w", name, os.ErrNotExist)
+	}
+	delete(m.files, name)
+	return nil
+}
+
+func (m *memTarget) names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var names []string
+	for name := range m.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newTargetTestService(t *testing.T) *Service {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return NewService(setupTestDB(t), filepath.Join(t.TempDir(), "backups"), 0, logger).WithClock(newTestClock())
+}
+
+func TestPush_VerifiesAndAppliesRemoteRetention(t *testing.T) {
+	svc := newTargetTestService(t)
+	target := newMemTarget("mem")
+	svc.AddTarget(target, 2)
+	ctx := context.Background()
+
+	var filenames []string
+	for range 3 {
+		info, err := svc.Backup(ctx)
+		if err != nil {
+			t.Fatalf("Backup: %v", err)
+		}
+		if err := svc.Push(ctx, info.Filename); err != nil {
+			t.Fatalf("Push: %v", err)
+		}
+		filenames = append(filenames, info.Filename)
+	}
+
+	want := []string{
+		filenames[1], filenames[1] + checksumSuffix,
+		filenames[2], filenames[2] + checksumSuffix,
+	}
+	sort.Strings(want)
+	if got := target.names(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("remote files = %v, want %v", got, want)
+	}
+
+	remote, err := svc.ListRemote(ctx)
+	if err != nil {
+		t.Fatalf("ListRemote: %v", err)
+	}
+	if len(remote) != 2 || remote[0].Filename != filenames[2] || !remote[0].Verifiable || remote[0].Target != "mem" {
+		t.Errorf("ListRemote = %+v, want the two newest, newest first, verifiable", remote)
+	}
+}
+
+func TestPush_CorruptReadBackIsRemovedAndReported(t *testing.T) {
+	svc := newTargetTestService(t)
+	bad := newMemTarget("bad")
+	bad.corrupt = true
+	good := newMemTarget("good")
+	var failures []PushFailure
+	svc.AddTarget(bad, 7).AddTarget(good, 7).WithPushFailureNotifier(func(f PushFailure) {
+		failures = append(failures, f)
+	})
+	ctx := context.Background()
+
+	info, err := svc.Backup(ctx)
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	err = svc.Push(ctx, info.Filename)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Push error = %v, want ErrChecksumMismatch", err)
+	}
+	if names := bad.names(); len(names) != 0 {
+		t.Errorf("corrupt target still holds %v", names)
+	}
+	if names := good.names(); len(names) != 2 {
+		t.Errorf("good target holds %v, want the backup and its checksum", names)
+	}
+	if len(failures) != 1 || failures[0].Target != "bad" || failures[0].Stage != StageVerify || failures[0].Filename != info.Filename {
+		t.Errorf("failures = %+v, want one verify failure on bad", failures)
+	}
+}
+
+func TestPush_UploadFailureIsReported(t *testing.T) {
+	svc := newTargetTestService(t)
+	target := newMemTarget("down")
+	target.putErr = errors.New("connection refused")
+	var failures []PushFailure
+	svc.AddTarget(target, 7).WithPushFailureNotifier(func(f PushFailure) { failures = append(failures, f) })
+
+	info, err := svc.Backup(context.Background())
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	if err := svc.Push(context.Background(), info.Filename); err == nil {
+		t.Fatal("Push succeeded against a failing target")
+	}
+	if len(failures) != 1 || failures[0].Stage != StageUpload {
+		t.Errorf("failures = %+v, want one upload failure", failures)
+	}
+}
+
+func TestFetchRemote(t *testing.T) {
+	src := newTargetTestService(t)
+	target := newMemTarget("mem")
+	src.AddTarget(target, 7)
+	ctx := context.Background()
+	info, err := src.Backup(ctx)
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	if err := src.Push(ctx, info.Filename); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	original, err := os.ReadFile(filepath.Join(src.backupDir, info.Filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh instance, as on a rebuilt host, pulls the backup down.
+	dst := newTargetTestService(t)
+	dst.AddTarget(target, 7)
+	got, err := dst.FetchRemote(ctx, "mem", info.Filename)
+	if err != nil {
+		t.Fatalf("FetchRemote: %v", err)
+	}
+	if got.Filename != info.Filename || got.Size != int64(len(original)) {
+		t.Errorf("FetchRemote = %+v, want %s of %d bytes", got, info.Filename, len(original))
+	}
+	fetched, err := os.ReadFile(filepath.Join(dst.backupDir, info.Filename))
+	if err != nil || !bytes.Equal(fetched, original) {
+		t.Fatalf("fetched copy differs from the original (err %v)", err)
+	}
+
+	// Fetching again reuses the identical local copy.
+	if _, err := dst.FetchRemote(ctx, "mem", info.Filename); err != nil {
+		t.Errorf("second FetchRemote: %v", err)
+	}
+
+	entries, err := os.ReadDir(dst.backupDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if fmt.Sprint(names) != fmt.Sprint([]string{ledgerFile, info.Filename}) {
+		t.Errorf("backup directory holds %v, want only the checksum ledger and the fetched backup", names)
+	}
+}
+
+func TestFetchRemote_Errors(t *testing.T) {
+	ctx := context.Background()
+	src := newTargetTestService(t)
+	info, err := src.Backup(ctx)
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(src.backupDir, info.Filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := newMemTarget("mem")
+	svc := newTargetTestService(t)
+	svc.AddTarget(target, 7)
+
+	if _, err := svc.FetchRemote(ctx, "other", info.Filename); !errors.Is(err, ErrUnknownTarget) {
+		t.Errorf("unknown target: err = %v, want ErrUnknownTarget", err)
+	}
+	if _, err := svc.FetchRemote(ctx, "mem", info.Filename); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing backup: err = %v, want os.ErrNotExist", err)
+	}
+
+	target.files[info.Filename] = data
+	if _, err := svc.FetchRemote(ctx, "mem", info.Filename); !errors.Is(err, ErrNoChecksum) {
+		t.Errorf("no checksum file: err = %v, want ErrNoChecksum", err)
+	}
+
+	sum, err := fileSHA256(filepath.Join(src.backupDir, info.Filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target.files[info.Filename+checksumSuffix] = []byte(sum + "  " + info.Filename + "\n")
+	target.corrupt = true
+	if _, err := svc.FetchRemote(ctx, "mem", info.Filename); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("corrupt download: err = %v, want ErrChecksumMismatch", err)
+	}
+	if _, err := os.Stat(filepath.Join(svc.backupDir, info.Filename)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("corrupt download left a local file behind (stat err %v)", err)
+	}
+}
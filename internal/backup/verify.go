@@ -0,0 +1,352 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/filesystem"
+)
+
+// Verification outcomes reported in BackupInfo.Status.
+const (
+	// StatusUnverified is a backup the verify job has not checked yet, or
+	// could not check (an encrypted backup without its key).
+	StatusUnverified = "unverified"
+	// StatusOK is a backup that passed its last verification.
+	StatusOK = "ok"
+	// StatusCorrupt is a backup that failed verification; BackupInfo.Problem
+	// says how.
+	StatusCorrupt = "corrupt"
+)
+
+// ledgerFile is the checksum ledger in backupDir: the SHA-256 and size of
+// each backup, recorded when it was made or fetched, and the outcome of its
+// last verification. Its name is not a backup name, so listing, pruning and
+// the remote targets pass it by.
+const ledgerFile = "checksums.json"
+
+// maxIntegrityProblems bounds the messages PRAGMA integrity_check returns
+// for one backup. The first few say enough; a badly damaged file has
+// thousands.
+const maxIntegrityProblems = 10
+
+// ledgerEntry is what the ledger holds for one backup.
+type ledgerEntry struct {
+	SHA256     string     `json:"sha256"`
+	Size       int64      `json:"size"`
+	Status     string     `json:"status,omitempty"`
+	Problem    string     `json:"problem,omitempty"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+}
+
+// ledger maps backup filenames to their entries.
+type ledger map[string]ledgerEntry
+
+// readLedger reads the checksum ledger. A missing ledger is empty; on error
+// the returned ledger is empty too, so callers that can do without it may
+// ignore the error.
+func (s *Service) readLedger() (ledger, error) {
+	data, err := os.ReadFile(filepath.Join(s.backupDir, ledgerFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return ledger{}, nil
+	}
+	if err != nil {
+		return ledger{}, fmt.Errorf("reading %s: %w", ledgerFile, err)
+	}
+	var doc struct {
+		Backups ledger `json:"backups"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return ledger{}, fmt.Errorf("parsing %s: %w", ledgerFile, err)
+	}
+	if doc.Backups == nil {
+		doc.Backups = ledger{}
+	}
+	return doc.Backups, nil
+}
+
+// updateLedger applies fn to the ledger and writes it back atomically. A
+// ledger that cannot be parsed is started afresh rather than blocking every
+// backup; the verify job re-records what it finds.
+func (s *Service) updateLedger(fn func(ledger)) error {
+	s.ledgerMu.Lock()
+	defer s.ledgerMu.Unlock()
+	l, err := s.readLedger()
+	if err != nil {
+		s.logger.Warn("replacing unreadable backup checksum ledger", slog.String("error", err.Error()))
+	}
+	fn(l)
+	data, err := json.MarshalIndent(struct {
+		Backups ledger `json:"backups"`
+	}{l}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", ledgerFile, err)
+	}
+	return filesystem.WriteFileAtomic(filepath.Join(s.backupDir, ledgerFile), data, 0o600)
+}
+
+// recordChecksum records a new backup's SHA-256 and size in the ledger.
+func (s *Service) recordChecksum(filename, sum string, size int64) error {
+	return s.updateLedger(func(l ledger) {
+		l[filename] = ledgerEntry{SHA256: sum, Size: size}
+	})
+}
+
+// remove deletes a backup and its ledger entry. Only a failure to delete the
+// file is returned; a stale ledger entry is harmless and dropped by the next
+// verify run.
+func (s *Service) remove(filename string) error {
+	if err := os.Remove(filepath.Join(s.backupDir, filename)); err != nil {
+		return err
+	}
+	if err := s.updateLedger(func(l ledger) { delete(l, filename) }); err != nil {
+		s.logger.Warn("removing backup from checksum ledger",
+			slog.String("filename", filename),
+			slog.String("error", err.Error()))
+	}
+	return nil
+}
+
+// describe fills in b's verification fields from the ledger. A backup whose
+// size differs from the recorded one is corrupt whatever the last
+// verification found: it was truncated or overwritten since.
+func (l ledger) describe(b *BackupInfo) {
+	b.Status = StatusUnverified
+	e, ok := l[b.Filename]
+	if !ok {
+		return
+	}
+	if e.Status != "" {
+		b.Status = e.Status
+	}
+	b.Problem = e.Problem
+	b.VerifiedAt = e.VerifiedAt
+	if e.Size != b.Size && b.Status != StatusCorrupt {
+		b.Status = StatusCorrupt
+		b.Problem = fmt.Sprintf("%s: it is %d bytes but was %d when it was made", ErrCorruptBackup, b.Size, e.Size)
+	}
+}
+
+// Verify checks one backup and records the outcome in the ledger. It
+// compares the backup's SHA-256 with the one recorded when it was made,
+// decrypts an encrypted backup (which authenticates every chunk), checks a
+// full archive's entries against its manifest, and opens the database
+// read-only to run PRAGMA integrity_check. A backup made before the ledger
+// existed has its checksum recorded now.
+//
+// A corrupt backup is not an error: the returned BackupInfo has
+// StatusCorrupt and the reason in Problem. An encrypted backup this service
+// has no key for stays StatusUnverified, with the reason in Problem. The
+// error is for a backup that could not be read at all.
+func (s *Service) Verify(ctx context.Context, filename string) (*BackupInfo, error) {
+	if !IsValidBackupFilename(filename) {
+		return nil, fmt.Errorf("invalid backup filename")
+	}
+	path := filepath.Join(s.backupDir, filename)
+	st, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("stat backup file: %w", err)
+	}
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return nil, fmt.Errorf("hashing backup: %w", err)
+	}
+	sums, _ := s.readLedger()
+	entry, known := sums[filename]
+	if !known {
+		entry = ledgerEntry{SHA256: sum, Size: st.Size()}
+	}
+
+	checkErr := s.checkBackup(ctx, filename, sum, entry.SHA256)
+	now := s.clock.Now()
+	entry.Status, entry.Problem, entry.VerifiedAt = StatusOK, "", &now
+	switch {
+	case checkErr == nil:
+	case errors.Is(checkErr, ErrCorruptBackup), errors.Is(checkErr, ErrCorruptArchive):
+		entry.Status, entry.Problem = StatusCorrupt, checkErr.Error()
+		s.logger.Error("backup failed verification",
+			slog.String("filename", filename),
+			slog.String("error", checkErr.Error()))
+	case errors.Is(checkErr, ErrEncrypted), errors.Is(checkErr, ErrWrongKey):
+		entry.Status, entry.Problem, entry.VerifiedAt = StatusUnverified, checkErr.Error(), nil
+		s.logger.Warn("backup could not be verified",
+			slog.String("filename", filename),
+			slog.String("error", checkErr.Error()))
+	default:
+		return nil, fmt.Errorf("verifying %s: %w", filename, checkErr)
+	}
+	if err := s.updateLedger(func(l ledger) { l[filename] = entry }); err != nil {
+		return nil, fmt.Errorf("recording verification: %w", err)
+	}
+
+	ts, ok := backupTime(filename)
+	if !ok {
+		ts = st.ModTime()
+	}
+	info := &BackupInfo{
+		Filename:  filename,
+		Kind:      kindOf(filename),
+		Size:      st.Size(),
+		CreatedAt: ts,
+		Encrypted: isEncrypted(filename),
+	}
+	ledger{filename: entry}.describe(info)
+	return info, nil
+}
+
+// checkBackup runs Verify's checks on a backup whose current SHA-256 is sum
+// and whose recorded one is want.
+func (s *Service) checkBackup(ctx context.Context, filename, sum, want string) error {
+	if sum != want {
+		return fmt.Errorf("%w: its SHA-256 no longer matches the one recorded when it was made", ErrCorruptBackup)
+	}
+	stagingDir, err := osMkdirTemp(s.backupDir, ".verify-*")
+	if err != nil {
+		return fmt.Errorf("creating staging directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(stagingDir) }()
+
+	plain, err := s.plaintextPath(filename, stagingDir)
+	if err != nil {
+		return err
+	}
+	if kindOf(filename) == KindDatabase {
+		return integrityCheck(ctx, plain)
+	}
+
+	zr, m, err := readArchive(plain)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = zr.Close() }()
+	dbPath := ""
+	for _, mf := range m.Files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if mf.Kind == FileDatabase {
+			dbPath = filepath.Join(stagingDir, "stillwater.db")
+			err = extractToFile(&zr.Reader, mf, dbPath)
+		} else {
+			err = extractEntry(&zr.Reader, mf, io.Discard)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if dbPath == "" {
+		return fmt.Errorf("%w: it holds no database", ErrCorruptArchive)
+	}
+	return integrityCheck(ctx, dbPath)
+}
+
+// integrityCheck opens the SQLite database at path read-only and runs PRAGMA
+// integrity_check, failing with ErrCorruptBackup on anything but "ok".
+func integrityCheck(ctx context.Context, path string) error {
+	// The file: prefix is what makes the driver honor mode=ro. immutable=1
+	// tells SQLite the file cannot change under it, so it takes no locks and
+	// never looks for a WAL beside it.
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro&immutable=1")
+	if err != nil {
+		return fmt.Errorf("opening backup database: %w", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("PRAGMA integrity_check(%d)", maxIntegrityProblems))
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		// A file SQLite cannot read as a database at all (truncated inside
+		// the header, or not a database) fails here rather than in a row.
+		return fmt.Errorf("%w: %w", ErrCorruptBackup, err)
+	}
+	defer func() { _ = rows.Close() }()
+	var problems []string
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return fmt.Errorf("reading integrity check: %w", err)
+		}
+		if msg != "ok" {
+			problems = append(problems, msg)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("%w: %w", ErrCorruptBackup, err)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: integrity check: %s", ErrCorruptBackup, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// VerifyAll verifies every backup in the backup directory and drops ledger
+// entries for backups that are gone. A backup that cannot be read is logged
+// and skipped. It returns the number of corrupt backups found.
+func (s *Service) VerifyAll(ctx context.Context) (int, error) {
+	backups, err := s.ListBackups()
+	if err != nil {
+		return 0, err
+	}
+	corrupt := 0
+	for _, b := range backups {
+		if err := ctx.Err(); err != nil {
+			return corrupt, err
+		}
+		info, err := s.Verify(ctx, b.Filename)
+		if err != nil {
+			s.logger.Error("verifying backup", slog.String("filename", b.Filename), slog.Any("error", err))
+			continue
+		}
+		if info.Status == StatusCorrupt {
+			corrupt++
+		}
+	}
+	if err := s.updateLedger(func(l ledger) {
+		for name := range l {
+			if _, err := os.Stat(filepath.Join(s.backupDir, name)); errors.Is(err, os.ErrNotExist) {
+				delete(l, name)
+			}
+		}
+	}); err != nil {
+		s.logger.Warn("pruning backup checksum ledger", slog.String("error", err.Error()))
+	}
+	s.logger.Info("backup verification complete",
+		slog.Int("backups", len(backups)),
+		slog.Int("corrupt", corrupt))
+	return corrupt, nil
+}
+
+// StartVerifier verifies every backup on a fixed interval until the context
+// is canceled (see VerifyAll).
+func (s *Service) StartVerifier(ctx context.Context, interval time.Duration) {
+	s.logger.Info("backup verifier started", slog.String("interval", interval.String()))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Info("backup verifier stopped")
+			return
+		case <-ticker.C:
+			if _, err := s.VerifyAll(ctx); err != nil && ctx.Err() == nil {
+				s.logger.Error("backup verification failed", slog.Any("error", err))
+			}
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerify_DetectsTruncation(t *testing.T) {
+	svc := newTargetTestService(t)
+	ctx := context.Background()
+	info, err := svc.Backup(ctx)
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	if info.Status != StatusUnverified {
+		t.Errorf("new backup status = %q, want unverified", info.Status)
+	}
+
+	got, err := svc.Verify(ctx, info.Filename)
+	if err != nil || got.Status != StatusOK || got.VerifiedAt == nil {
+		t.Fatalf("Verify = %+v, %v; want ok", got, err)
+	}
+	backups, err := svc.ListBackups()
+	if err != nil || len(backups) != 1 || backups[0].Status != StatusOK {
+		t.Fatalf("ListBackups = %+v, %v; want one ok backup", backups, err)
+	}
+
+	p := filepath.Join(svc.backupDir, info.Filename)
+	if err := os.Truncate(p, info.Size/2); err != nil {
+		t.Fatal(err)
+	}
+	// The size check catches it on listing, before the verify job runs.
+	backups, err = svc.ListBackups()
+	if err != nil || backups[0].Status != StatusCorrupt || !strings.Contains(backups[0].Problem, "bytes") {
+		t.Fatalf("ListBackups after truncation = %+v, %v; want corrupt", backups, err)
+	}
+	got, err = svc.Verify(ctx, info.Filename)
+	if err != nil || got.Status != StatusCorrupt || !strings.Contains(got.Problem, "SHA-256") {
+		t.Errorf("Verify after truncation = %+v, %v; want corrupt on its checksum", got, err)
+	}
+}
+
+// damagedSnapshot writes a database with enough rows to span many pages into
+// the backup directory, without a ledger entry, and overwrites one of its
+// pages in the middle, past the header.
+func damagedSnapshot(t *testing.T, dir, name string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(dir, name)
+	db, err := sql.Open("sqlite", p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, stmt := range []string{
+		`CREATE TABLE t (id INTEGER PRIMARY KEY, v TEXT)`,
+		`CREATE INDEX t_v ON t (v)`,
+		`WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 2000)
+		 INSERT INTO t (v) SELECT printf('value-%08d', i) FROM n`,
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := 4096
+	for i := 5 * page; i < 6*page; i++ {
+		data[i] = 0xA5
+	}
+	if err := os.WriteFile(p, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerify_IntegrityCheckFindsDamage(t *testing.T) {
+	svc := newTargetTestService(t)
+	ctx := context.Background()
+	const name = "stillwater-20240101-000000.db"
+	damagedSnapshot(t, svc.backupDir, name)
+
+	got, err := svc.Verify(ctx, name)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if got.Status != StatusCorrupt || !strings.Contains(got.Problem, ErrCorruptBackup.Error()) {
+		t.Errorf("Verify = %+v, want corrupt from the integrity check", got)
+	}
+	sums, err := svc.readLedger()
+	if err != nil || sums[name].SHA256 == "" {
+		t.Errorf("ledger = %+v, %v; want the pre-ledger backup's checksum recorded", sums, err)
+	}
+}
+
+func TestVerify_Archive(t *testing.T) {
+	f := newArchiveFixture(t)
+	ctx := context.Background()
+	info, err := f.svc.Archive(ctx, ArchiveOptions{})
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	got, err := f.svc.Verify(ctx, info.Filename)
+	if err != nil || got.Status != StatusOK {
+		t.Fatalf("Verify = %+v, %v; want ok", got, err)
+	}
+	assertNoStaging(t, f.svc.BackupDir())
+}
+
+func TestVerifyAll(t *testing.T) {
+	svc := newTargetTestService(t)
+	ctx := context.Background()
+	var names []string
+	for range 3 {
+		info, err := svc.Backup(ctx)
+		if err != nil {
+			t.Fatalf("Backup: %v", err)
+		}
+		names = append(names, info.Filename)
+	}
+	// One is damaged in place, keeping its size; one is removed behind the
+	// service's back.
+	p := filepath.Join(svc.backupDir, names[0])
+	data, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-1] ^= 0xff
+	if err := os.WriteFile(p, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(svc.backupDir, names[1])); err != nil {
+		t.Fatal(err)
+	}
+
+	corrupt, err := svc.VerifyAll(ctx)
+	if err != nil || corrupt != 1 {
+		t.Fatalf("VerifyAll = %d, %v; want 1 corrupt", corrupt, err)
+	}
+	sums, err := svc.readLedger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sums[names[1]]; ok || len(sums) != 2 {
+		t.Errorf("ledger = %v, want the removed backup dropped", sums)
+	}
+	backups, err := svc.ListBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := make(map[string]string)
+	for _, b := range backups {
+		status[b.Filename] = b.Status
+	}
+	want := map[string]string{names[0]: StatusCorrupt, names[2]: StatusOK}
+	if fmt.Sprint(status) != fmt.Sprint(want) {
+		t.Errorf("statuses = %v, want %v", status, want)
+	}
+}
+
+func TestDeleteAndPrune_DropLedgerEntries(t *testing.T) {
+	svc := newTargetTestService(t)
+	svc.SetRetention(1)
+	ctx := context.Background()
+	var names []string
+	for range 3 {
+		info, err := svc.Backup(ctx)
+		if err != nil {
+			t.Fatalf("Backup: %v", err)
+		}
+		names = append(names, info.Filename)
+	}
+	if err := svc.Delete(names[2]); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := svc.Prune(); err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	sums, err := svc.readLedger()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sums[names[1]]; !ok || len(sums) != 1 {
+		t.Errorf("ledger = %v, want only %s", sums, names[1])
+	}
+	if err := svc.Delete(names[0]); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Delete of a pruned backup: err = %v, want os.ErrNotExist", err)
+	}
+}
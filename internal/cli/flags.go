@@ -36,7 +36,7 @@ type Flags struct {
 	// ResetPassword, when true, resets the admin user password and exits.
 	// The --username and --new-password flags control whose password is changed
 	// and how the new value is supplied.
-	ResetPassword bool `flag:"reset-password" default:"false" desc:"Reset the admin user password and exit. Prompts interactively unless --new-password is also set."`
+	ResetPassword bool `flag:"reset-password" default:"false" desc:"Reset the admin user password, sign out its existing sessions, lift a sign-in lockout, and exit. Prompts interactively unless --new-password is also set."`
 
 	// Username specifies which user account to target for --reset-password.
 	// When empty, Stillwater picks the only admin user in the database, or
@@ -49,6 +49,11 @@ type Flags struct {
 	// /proc); prefer the interactive prompt whenever possible.
 	NewPassword string `flag:"new-password" default:"" desc:"New password for --reset-password (INSECURE: visible in process listings; prefer the interactive prompt instead)."`
 
+	// ClearTwoFactor, when true alongside --reset-password, also removes the
+	// user's two-factor enrollment and recovery codes, for a user locked out
+	// by a lost authenticator.
+	ClearTwoFactor bool `flag:"clear-2fa" default:"false" desc:"With --reset-password, also remove the user's two-factor authentication enrollment and recovery codes."`
+
 	// LockDamageDryRun, when true, runs the locked-field damage repair in
 	// report-only mode and exits: it selects and prints the candidate report,
 	// writes nothing, does not record completion, and never starts a listener.
@@ -58,8 +63,9 @@ type Flags struct {
 }
 
 // SubcommandInfo describes a CLI subcommand (os.Args[1] dispatch) that
-// Stillwater recognizes. Subcommands are handled before flag parsing and
-// accept no additional flags themselves.
+// Stillwater recognizes. Subcommands are handled before the global flags are
+// parsed; the headless job commands (internal/headless) parse their own flags,
+// which Details documents.
 type SubcommandInfo struct {
 	// Name is the exact string to pass as the first argument (e.g.
 	// "reset-credentials").
@@ -86,6 +92,70 @@ var Subcommands = []SubcommandInfo{
 			"for initial setup on the next start. Requires database access (SW_DB_PATH or " +
 			"SW_CONFIG_PATH must resolve to the live database).",
 	},
+	{
+		Name:    "scan",
+		Summary: "Scan every library once in the foreground, print the result as JSON, and exit.",
+		Details: "Runs the same filesystem scan as the Scan button without starting the web " +
+			"server, prints the final scan result (artists found, new, removed, status) to " +
+			"stdout as JSON, and exits. Logs go to stderr. Exits 0 when the scan completes " +
+			"and 2 when it fails. A server process scanning the same database at the same " +
+			"time does not see this scan, so schedule it when the server's own scan is idle.",
+	},
+	{
+		Name:    "rules run",
+		Summary: "Run the rule pipeline headlessly and exit non-zero while violations remain.",
+		Details: "Usage: `stillwater rules run [--rule ID] [--library ID] [--dry-run] [--incremental]`. " +
+			"Without --dry-run it evaluates and auto-fixes exactly like the Run Rules button and " +
+			"prints the run result as JSON, including violations_remaining. With --dry-run it only " +
+			"evaluates: nothing is fixed, recorded, or stamped, and each violation is listed. " +
+			"--rule limits the run to one rule, --library to one library's artists, and " +
+			"--incremental processes only artists changed since their last evaluation. " +
+			"Exits 0 when no violations remain, 1 when some do, and 2 on errors.",
+	},
+	{
+		Name:    "fetch",
+		Summary: "Refresh one artist's metadata from the providers and print what changed as JSON.",
+		Details: "Usage: `stillwater fetch --artist ID|MBID|NAME`. The artist is matched by " +
+			"Stillwater ID, then by MusicBrainz ID or exact name. Runs the same refresh as the " +
+			"bulk Refresh action, including the post-refresh rules, and prints the fields each " +
+			"provider supplied. Exits 0 on success, 1 when a provider reported an error, and 2 " +
+			"when the artist is missing, locked, has no MusicBrainz ID, or the refresh failed.",
+	},
+	{
+		Name:    "decrypt-backup",
+		Summary: "Write the plaintext of an encrypted backup to stdout or a file.",
+		Details: "Usage: `stillwater decrypt-backup --input FILE [--output FILE]`. Decrypts a " +
+			"backup ending in .enc with the key configured by SW_BACKUP_PASSPHRASE or " +
+			"SW_BACKUP_KEY_FILE, for restoring a database snapshot by hand. --output files are " +
+			"created with mode 0600 and never overwrite an existing file. A wrong key or a " +
+			"damaged backup is an error, and a partly written --output file is removed.",
+	},
+	{
+		Name:    "export-settings",
+		Summary: "Write the encrypted settings export to stdout or a file.",
+		Details: "Usage: `stillwater export-settings [--passphrase-file FILE] [--output FILE]`. " +
+			"Produces the same encrypted envelope as Settings > Backup > Export. The passphrase " +
+			"is read from --passphrase-file (trailing newline trimmed) or the " +
+			"SW_SETTINGS_PASSPHRASE environment variable. --output files are created with mode 0600.",
+	},
+	{
+		Name:    "import-settings",
+		Summary: "Apply a settings export from stdin or a file and print the import summary.",
+		Details: "Usage: `stillwater import-settings [--passphrase-file FILE] [--input FILE]`. " +
+			"Decrypts and applies an export produced by export-settings or the UI, then prints " +
+			"the counts of imported items as JSON. API tokens whose owner does not exist on this " +
+			"install are skipped. The passphrase is supplied as for export-settings.",
+	},
+	{
+		Name:    "report compliance",
+		Summary: "Print the compliance report as JSON or CSV and exit non-zero when violations are open.",
+		Details: "Usage: `stillwater report compliance [--format csv|json] [--library ID]`. " +
+			"Lists every artist with its health score, file and MBID presence, and open rule " +
+			"violations, from the stored evaluation results (run `rules run` first for fresh " +
+			"data). CSV columns use fixed snake_case names so scripts keep working when the " +
+			"platform profile changes. Exits 0 when no artist has an open violation, 1 when " +
+			"some do, and 2 on errors.",
+	},
 }
 
 // RegisterFlags binds the fields of f to the given flag set using the flag:
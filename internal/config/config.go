@@ -55,13 +55,14 @@ type ServerConfig struct {
 	BasePathFromEnv bool   `yaml:"-" toml:"-"`
 	UX              string `yaml:"ux" toml:"ux" env:"SW_UX" default:"stable" desc:"Web UI channel: stable (the current UI), next (the in-development preview UI), or dual (both served; defaults to stable, users opt into the preview via the sw_ux cookie or /next/ paths). Default stable means no behavior change."`
 	// TrustedProxies is the set of CIDR ranges whose direct connections are
-	// trusted to set X-Forwarded-For / X-Real-Ip for login rate limiting. Only
+	// trusted to set X-Forwarded-For / X-Real-Ip for login rate limiting (and
+	// the forward-auth headers, see ForwardAuthConfig). Only
 	// when the direct peer falls inside one of these prefixes is the forwarded
 	// client IP honored; otherwise the direct peer address is used. Empty means
 	// no proxy is trusted (forwarded headers are ignored). Stored as raw strings
 	// (parsed into netip.Prefix by the rate limiter) so config loading stays a
 	// pure string overlay; entries are validated as CIDRs at startup.
-	TrustedProxies []string           `yaml:"trusted_proxies" toml:"trusted_proxies" env:"SW_TRUSTED_PROXIES" default:"" desc:"Comma-separated CIDR ranges (for example 10.0.0.0/8,192.168.0.0/16) whose direct connections are trusted reverse proxies. Only requests arriving directly from one of these ranges have their X-Forwarded-For / X-Real-Ip header honored for login rate limiting; all other clients are rate-limited by their direct connection IP. Empty (the default) trusts no proxy and ignores forwarded headers. Whitespace around each entry is trimmed."`
+	TrustedProxies []string           `yaml:"trusted_proxies" toml:"trusted_proxies" env:"SW_TRUSTED_PROXIES" default:"" desc:"Comma-separated CIDR ranges (for example 10.0.0.0/8,192.168.0.0/16) whose direct connections are trusted reverse proxies. Only requests arriving directly from one of these ranges have their X-Forwarded-For / X-Real-Ip header honored for login rate limiting, per-address sign-in lockout and the address recorded on new sessions, and their SW_AUTH_FORWARD_USER_HEADER honored for forward authentication; all other clients are rate-limited by their direct connection IP. Empty (the default) trusts no proxy and ignores forwarded headers. Whitespace around each entry is trimmed."`
 	TLS            TLSConfig          `yaml:"tls" toml:"tls"`
 	HTTPRedirect   HTTPRedirectConfig `yaml:"http_redirect" toml:"http_redirect"`
 	HTTP3          HTTP3Config        `yaml:"http3" toml:"http3"`
@@ -139,7 +140,70 @@ type DatabaseConfig struct {
 
 // AuthConfig holds authentication settings.
 type AuthConfig struct {
-	SessionSecret string `yaml:"session_secret" toml:"session_secret" env:"SW_SESSION_SECRET" default:"" desc:"Secret used to sign CSRF tokens (minimum 32 bytes). When unset Stillwater generates 32 random bytes on first run and persists them alongside the database file as session.secret. Must be kept stable across restarts; rotating it invalidates all in-flight CSRF cookies."`
+	SessionSecret string            `yaml:"session_secret" toml:"session_secret" env:"SW_SESSION_SECRET" default:"" desc:"Secret used to sign CSRF tokens (minimum 32 bytes). When unset Stillwater generates 32 random bytes on first run and persists them alongside the database file as session.secret. Must be kept stable across restarts; rotating it invalidates all in-flight CSRF cookies."`
+	Forward       ForwardAuthConfig `yaml:"forward" toml:"forward"`
+	LDAP          LDAPAuthConfig    `yaml:"ldap" toml:"ldap"`
+	Lockout       LockoutConfig     `yaml:"lockout" toml:"lockout"`
+}
+
+// LockoutConfig configures brute-force protection for password sign-in (the
+// login form, for local, LDAP, Emby and Jellyfin accounts). Failed passwords
+// are counted per username and per client address; the address is the one
+// resolved through Server.TrustedProxies. A zero threshold turns that counter
+// off.
+type LockoutConfig struct {
+	UserThreshold  int `yaml:"user_threshold" toml:"user_threshold" env:"SW_AUTH_LOCKOUT_USER_THRESHOLD" default:"5" desc:"Failed sign-ins for one username, within SW_AUTH_LOCKOUT_WINDOW, before that username is locked out. Each further failure doubles the lockout. Counts usernames nobody holds too. 0 turns the per-username lockout off."`
+	IPThreshold    int `yaml:"ip_threshold" toml:"ip_threshold" env:"SW_AUTH_LOCKOUT_IP_THRESHOLD" default:"20" desc:"Failed sign-ins from one client address, across all usernames, before that address is locked out. Behind a reverse proxy the address is only right when the proxy is listed in SW_TRUSTED_PROXIES. 0 turns the per-address lockout off."`
+	LockMinutes    int `yaml:"lock_minutes" toml:"lock_minutes" env:"SW_AUTH_LOCKOUT_DURATION" default:"1" desc:"Minutes of the first lockout. Must be a positive integer; non-positive or non-numeric values are silently ignored."`
+	MaxLockMinutes int `yaml:"max_lock_minutes" toml:"max_lock_minutes" env:"SW_AUTH_LOCKOUT_MAX_DURATION" default:"60" desc:"Longest lockout in minutes, however many failures follow. Must be a positive integer; non-positive or non-numeric values are silently ignored."`
+	WindowMinutes  int `yaml:"window_minutes" toml:"window_minutes" env:"SW_AUTH_LOCKOUT_WINDOW" default:"15" desc:"Minutes after the last failure, or the end of the last lockout, before the failure count starts over. Must be a positive integer; non-positive or non-numeric values are silently ignored."`
+}
+
+// ForwardAuthConfig configures trusted-header (forward auth) sign-in behind an
+// SSO reverse proxy such as Authelia or Authentik. It is off while UserHeader
+// is empty. The header is only believed from a direct peer inside
+// Server.TrustedProxies; validate() refuses a user header with no trusted
+// proxy, since any client could otherwise name itself.
+type ForwardAuthConfig struct {
+	UserHeader    string   `yaml:"user_header" toml:"user_header" env:"SW_AUTH_FORWARD_USER_HEADER" default:"unset" desc:"Request header carrying the signed-in username from an SSO reverse proxy (for example Remote-User or X-Forwarded-User). Setting it turns on forward authentication. The header is only trusted on requests arriving directly from SW_TRUSTED_PROXIES, which must also be set."`
+	GroupsHeader  string   `yaml:"groups_header" toml:"groups_header" env:"SW_AUTH_FORWARD_GROUPS_HEADER" default:"unset" desc:"Request header carrying the user's groups, separated by commas or pipes (for example Remote-Groups). Used for SW_AUTH_FORWARD_ADMIN_GROUPS and SW_AUTH_FORWARD_USER_GROUPS."`
+	AdminGroups   []string `yaml:"admin_groups" toml:"admin_groups" env:"SW_AUTH_FORWARD_ADMIN_GROUPS" default:"" desc:"Comma-separated groups whose members are provisioned as administrators. Matching is case-insensitive."`
+	UserGroups    []string `yaml:"user_groups" toml:"user_groups" env:"SW_AUTH_FORWARD_USER_GROUPS" default:"" desc:"Comma-separated groups allowed to be provisioned automatically. Empty allows any user the proxy authenticated."`
+	DefaultRole   string   `yaml:"default_role" toml:"default_role" env:"SW_AUTH_FORWARD_DEFAULT_ROLE" default:"operator" desc:"Role given to an automatically provisioned user outside the admin groups: operator or viewer."`
+	AutoProvision bool     `yaml:"auto_provision" toml:"auto_provision" env:"SW_AUTH_FORWARD_AUTO_PROVISION" default:"false" desc:"Set to true or 1 to create an account on first sign-in for a proxy user Stillwater does not know yet. When false, only proxy users who already have a Stillwater account can sign in."`
+}
+
+// Enabled reports whether forward authentication is configured.
+func (c ForwardAuthConfig) Enabled() bool {
+	return c.UserHeader != ""
+}
+
+// LDAPAuthConfig configures sign-in against an LDAP directory such as LLDAP,
+// OpenLDAP or Active Directory. It is off while URL is empty. Like forward
+// auth it lives in the environment rather than the settings table: the bind
+// password is a directory credential, and turning the provider on decides who
+// can become an administrator.
+type LDAPAuthConfig struct {
+	URL                  string   `yaml:"url" toml:"url" env:"SW_AUTH_LDAP_URL" default:"unset" desc:"Directory server URL, ldap://host:389 or ldaps://host:636 (LLDAP listens on 3890 and 6360). Setting it turns on LDAP sign-in."`
+	StartTLS             bool     `yaml:"start_tls" toml:"start_tls" env:"SW_AUTH_LDAP_START_TLS" default:"false" desc:"Set to true or 1 to upgrade an ldap:// connection with StartTLS before binding. Not used with ldaps://."`
+	SkipTLSVerify        bool     `yaml:"skip_tls_verify" toml:"skip_tls_verify" env:"SW_AUTH_LDAP_SKIP_TLS_VERIFY" default:"false" desc:"Set to true or 1 to accept any certificate from the directory server, for example a self-signed one. Passwords are then exposed to anyone who can intercept the connection."`
+	BindDN               string   `yaml:"bind_dn" toml:"bind_dn" env:"SW_AUTH_LDAP_BIND_DN" default:"unset" desc:"DN of the service account used to search for users, for example uid=stillwater,ou=people,dc=example,dc=com. Empty searches anonymously."`
+	BindPassword         string   `yaml:"bind_password" toml:"bind_password" env:"SW_AUTH_LDAP_BIND_PASSWORD" default:"unset" desc:"Password of SW_AUTH_LDAP_BIND_DN. Treat as a secret; it is never written to the database."`
+	BaseDN               string   `yaml:"base_dn" toml:"base_dn" env:"SW_AUTH_LDAP_BASE_DN" default:"unset" desc:"DN under which users are searched, for example ou=people,dc=example,dc=com. Required when SW_AUTH_LDAP_URL is set."`
+	UserFilter           string   `yaml:"user_filter" toml:"user_filter" env:"SW_AUTH_LDAP_USER_FILTER" default:"(uid={username})" desc:"Search filter that finds the signing-in user. {username} is replaced with the escaped login name. Use (sAMAccountName={username}) for Active Directory."`
+	UsernameAttribute    string   `yaml:"username_attribute" toml:"username_attribute" env:"SW_AUTH_LDAP_USERNAME_ATTRIBUTE" default:"uid" desc:"Attribute whose value identifies the Stillwater account. Keep it stable: changing it later creates new accounts."`
+	DisplayNameAttribute string   `yaml:"display_name_attribute" toml:"display_name_attribute" env:"SW_AUTH_LDAP_DISPLAY_NAME_ATTRIBUTE" default:"displayName" desc:"Attribute used as the display name of a provisioned account. Falls back to cn."`
+	GroupBaseDN          string   `yaml:"group_base_dn" toml:"group_base_dn" env:"SW_AUTH_LDAP_GROUP_BASE_DN" default:"unset" desc:"DN under which groups are searched when SW_AUTH_LDAP_GROUP_FILTER is set. Defaults to SW_AUTH_LDAP_BASE_DN."`
+	GroupFilter          string   `yaml:"group_filter" toml:"group_filter" env:"SW_AUTH_LDAP_GROUP_FILTER" default:"unset" desc:"Search filter for the user's groups, for example (member={dn}); {dn} and {username} are replaced. When unset, groups are read from the user's memberOf attribute."`
+	AdminGroups          []string `yaml:"admin_groups" toml:"admin_groups" env:"SW_AUTH_LDAP_ADMIN_GROUPS" default:"" desc:"Comma-separated group names (the cn) whose members are provisioned as administrators. Matching is case-insensitive."`
+	UserGroups           []string `yaml:"user_groups" toml:"user_groups" env:"SW_AUTH_LDAP_USER_GROUPS" default:"" desc:"Comma-separated group names allowed to be provisioned automatically. Empty allows any directory user with a valid password."`
+	DefaultRole          string   `yaml:"default_role" toml:"default_role" env:"SW_AUTH_LDAP_DEFAULT_ROLE" default:"operator" desc:"Role given to an automatically provisioned user outside the admin groups: operator or viewer."`
+	AutoProvision        bool     `yaml:"auto_provision" toml:"auto_provision" env:"SW_AUTH_LDAP_AUTO_PROVISION" default:"false" desc:"Set to true or 1 to create an account on first sign-in for a directory user Stillwater does not know yet. When false, only directory users who already have a Stillwater account can sign in."`
+}
+
+// Enabled reports whether LDAP authentication is configured.
+func (c LDAPAuthConfig) Enabled() bool {
+	return c.URL != ""
 }
 
 // EncryptionConfig holds encryption key settings.
@@ -165,6 +229,16 @@ type ScannerConfig struct {
 	// mounts, and any backup-restored tree where mtimes were not
 	// preserved fall into this category.
 	MtimeFastPath bool `yaml:"mtime_fast_path" toml:"mtime_fast_path" env:"SW_SCANNER_MTIME_FAST_PATH" default:"true" desc:"When true the scanner reuses cached image flags for artist directories whose mtime has not advanced since the previous scan, eliminating the per-file stat + dimension probe loop. Set to false on filesystems with unreliable mtimes (some network shares, FUSE mounts, backup-restored trees) so every scan re-probes. When set from the environment, this value takes precedence over the saved setting, so the Settings control is shown read-only."`
+	// TagIdentity, when true, has the scanner read a few tracks' embedded
+	// tags for each newly discovered artist directory and adopt a
+	// MusicBrainz artist ID the tracks agree on. Defaults to true; disable
+	// it (SW_SCANNER_TAG_IDENTITY=false) when opening audio files during a
+	// scan is expensive, e.g. on cold-storage or high-latency mounts.
+	TagIdentity bool `yaml:"tag_identity" toml:"tag_identity" env:"SW_SCANNER_TAG_IDENTITY" default:"true" desc:"When true the scanner reads the embedded tags of a few tracks per new artist directory and adopts the MusicBrainz artist ID they agree on, before any provider is asked. Set to false when opening audio files during a scan is expensive."`
+	// ReconcileHours is how often the filesystem watcher runs a full scan.
+	// Watched and polled libraries are otherwise only rescanned one artist
+	// directory at a time, for the directories the watcher saw change.
+	ReconcileHours int `yaml:"reconcile_hours" toml:"reconcile_hours" env:"SW_SCANNER_RECONCILE_INTERVAL" default:"24" desc:"Hours between full scans while any library is watched or polled. Between them the watcher rescans only the artist directories it saw change, so this catches what it cannot see, such as edits deep inside album folders. 0 turns it off."`
 }
 
 // BackupConfig holds database backup settings.
@@ -173,6 +247,52 @@ type BackupConfig struct {
 	RetentionCount int    `yaml:"retention_count" toml:"retention_count" env:"SW_BACKUP_RETENTION" default:"7" desc:"Number of recent backups to keep. Must be a positive integer; non-positive or non-numeric values are silently ignored."`
 	IntervalHours  int    `yaml:"interval_hours" toml:"interval_hours" env:"SW_BACKUP_INTERVAL" default:"24" desc:"Hours between automated backups. Must be a positive integer; non-positive or non-numeric values are silently ignored. When set from the environment, this value takes precedence over the saved setting, so the Settings control is shown read-only."`
 	Enabled        bool   `yaml:"enabled" toml:"enabled" env:"SW_BACKUP_ENABLED" default:"true" desc:"Set to true or 1 to enable automated backups. Any other value disables them."`
+	Archive        bool   `yaml:"archive" toml:"archive" env:"SW_BACKUP_ARCHIVE" default:"false" desc:"When true, automated backups are full archives (.zip) carrying the database, artist manifest and, with SW_BACKUP_ARCHIVE_ARTWORK, the artwork. When false they are database snapshots (.db)."`
+	ArchiveArtwork bool   `yaml:"archive_artwork" toml:"archive_artwork" env:"SW_BACKUP_ARCHIVE_ARTWORK" default:"true" desc:"Include Stillwater-managed artwork, kept originals and the image cache in automated archives. Only applies when SW_BACKUP_ARCHIVE is true."`
+	Passphrase     string `yaml:"passphrase" toml:"passphrase" env:"SW_BACKUP_PASSPHRASE" default:"unset" desc:"Encrypt every backup with this passphrase. Encrypted backups end in .enc and can only be read, verified or restored with the same passphrase. Cannot be combined with SW_BACKUP_KEY_FILE."`
+	KeyFile        string `yaml:"key_file" toml:"key_file" env:"SW_BACKUP_KEY_FILE" default:"unset" desc:"Path to a file holding a base64-encoded 32-byte key (openssl rand -base64 32) to encrypt every backup with, instead of a passphrase. The file must be readable at startup or Stillwater does not start."`
+	VerifyHours    int    `yaml:"verify_hours" toml:"verify_hours" env:"SW_BACKUP_VERIFY_INTERVAL" default:"24" desc:"Hours between checks of every backup: its checksum, its decryption when encrypted, and an integrity check of its database. Corrupt backups are flagged in the backup list. 0 turns the checks off."`
+
+	S3   BackupS3Config   `yaml:"s3" toml:"s3"`
+	SFTP BackupSFTPConfig `yaml:"sftp" toml:"sftp"`
+}
+
+// BackupS3Config configures pushing automated backups to an S3-compatible
+// bucket (AWS S3, MinIO, Backblaze B2, Wasabi). It is off while Bucket is
+// empty.
+type BackupS3Config struct {
+	Endpoint  string `yaml:"endpoint" toml:"endpoint" env:"SW_BACKUP_S3_ENDPOINT" default:"unset" desc:"Service URL, for example https://s3.eu-west-1.amazonaws.com or http://minio:9000. Required when SW_BACKUP_S3_BUCKET is set."`
+	Region    string `yaml:"region" toml:"region" env:"SW_BACKUP_S3_REGION" default:"us-east-1" desc:"Signing region. MinIO and most S3-compatible services accept us-east-1."`
+	Bucket    string `yaml:"bucket" toml:"bucket" env:"SW_BACKUP_S3_BUCKET" default:"unset" desc:"Bucket backups are copied to. Setting it turns on the S3 backup target. The bucket must already exist."`
+	Prefix    string `yaml:"prefix" toml:"prefix" env:"SW_BACKUP_S3_PREFIX" default:"" desc:"Key prefix backups are written under, for example stillwater, so one bucket can hold several instances."`
+	AccessKey string `yaml:"access_key" toml:"access_key" env:"SW_BACKUP_S3_ACCESS_KEY" default:"unset" desc:"Access key ID. Required when SW_BACKUP_S3_BUCKET is set."`
+	SecretKey string `yaml:"secret_key" toml:"secret_key" env:"SW_BACKUP_S3_SECRET_KEY" default:"unset" desc:"Secret access key. Treat as a secret; it is never written to the database."`
+	PathStyle bool   `yaml:"path_style" toml:"path_style" env:"SW_BACKUP_S3_PATH_STYLE" default:"true" desc:"Set to true or 1 to address the bucket as endpoint/bucket, which MinIO and most self-hosted services need. Set to false for virtual-hosted addressing (bucket.endpoint)."`
+	Retention int    `yaml:"retention" toml:"retention" env:"SW_BACKUP_S3_RETENTION" default:"7" desc:"Number of recent backups to keep in the bucket. Older ones are deleted after each push. Must be a positive integer; non-positive or non-numeric values are silently ignored."`
+}
+
+// Enabled reports whether the S3 backup target is configured.
+func (c BackupS3Config) Enabled() bool {
+	return c.Bucket != ""
+}
+
+// BackupSFTPConfig configures pushing automated backups to a directory on an
+// SFTP server. It is off while Host is empty.
+type BackupSFTPConfig struct {
+	Host           string `yaml:"host" toml:"host" env:"SW_BACKUP_SFTP_HOST" default:"unset" desc:"SFTP server host name or address. Setting it turns on the SFTP backup target."`
+	Port           int    `yaml:"port" toml:"port" env:"SW_BACKUP_SFTP_PORT" default:"22" desc:"SFTP server port. Must be a positive integer; non-positive or non-numeric values are silently ignored."`
+	User           string `yaml:"user" toml:"user" env:"SW_BACKUP_SFTP_USER" default:"unset" desc:"User to sign in as. Required when SW_BACKUP_SFTP_HOST is set."`
+	Password       string `yaml:"password" toml:"password" env:"SW_BACKUP_SFTP_PASSWORD" default:"unset" desc:"Password of SW_BACKUP_SFTP_USER. Treat as a secret. Either this or SW_BACKUP_SFTP_KEY_FILE is required."`
+	KeyFile        string `yaml:"key_file" toml:"key_file" env:"SW_BACKUP_SFTP_KEY_FILE" default:"unset" desc:"Path to an unencrypted private key (OpenSSH or PEM format) for SW_BACKUP_SFTP_USER. Either this or SW_BACKUP_SFTP_PASSWORD is required."`
+	HostKey        string `yaml:"host_key" toml:"host_key" env:"SW_BACKUP_SFTP_HOST_KEY" default:"unset" desc:"SHA256 fingerprint of the server's host key as ssh-keygen -l prints it (SHA256:...). This or SW_BACKUP_SFTP_KNOWN_HOSTS_FILE is required: backups are never sent to an unverified server."`
+	KnownHostsFile string `yaml:"known_hosts_file" toml:"known_hosts_file" env:"SW_BACKUP_SFTP_KNOWN_HOSTS_FILE" default:"unset" desc:"Path to an OpenSSH known_hosts file holding the server's host key. Used when SW_BACKUP_SFTP_HOST_KEY is unset."`
+	Path           string `yaml:"path" toml:"path" env:"SW_BACKUP_SFTP_PATH" default:"unset" desc:"Remote directory backups are written to. It must already exist. Required when SW_BACKUP_SFTP_HOST is set."`
+	Retention      int    `yaml:"retention" toml:"retention" env:"SW_BACKUP_SFTP_RETENTION" default:"7" desc:"Number of recent backups to keep on the server. Older ones are deleted after each push. Must be a positive integer; non-positive or non-numeric values are silently ignored."`
+}
+
+// Enabled reports whether the SFTP backup target is configured.
+func (c BackupSFTPConfig) Enabled() bool {
+	return c.Host != ""
 }
 
 // LoggingConfig holds logging settings.
@@ -238,7 +358,24 @@ func Default() *Config {
 		Database: DatabaseConfig{
 			Path: "/config/stillwater.db",
 		},
-		Auth:       AuthConfig{},
+		Auth: AuthConfig{
+			Forward: ForwardAuthConfig{
+				DefaultRole: "operator",
+			},
+			LDAP: LDAPAuthConfig{
+				UserFilter:           "(uid={username})",
+				UsernameAttribute:    "uid",
+				DisplayNameAttribute: "displayName",
+				DefaultRole:          "operator",
+			},
+			Lockout: LockoutConfig{
+				UserThreshold:  5,
+				IPThreshold:    20,
+				LockMinutes:    1,
+				MaxLockMinutes: 60,
+				WindowMinutes:  15,
+			},
+		},
 		Encryption: EncryptionConfig{},
 		Music: MusicConfig{
 			LibraryPath: "/music",
@@ -254,12 +391,25 @@ func Default() *Config {
 			// been touched since the last scan, so the only behavioral
 			// difference is "second scan of an unchanged directory
 			// skips its inner ReadDir + image probe loop".
-			MtimeFastPath: true,
+			MtimeFastPath:  true,
+			TagIdentity:    true,
+			ReconcileHours: 24,
 		},
 		Backup: BackupConfig{
 			RetentionCount: 7,
 			IntervalHours:  24,
 			Enabled:        true,
+			ArchiveArtwork: true,
+			VerifyHours:    24,
+			S3: BackupS3Config{
+				Region:    "us-east-1",
+				PathStyle: true,
+				Retention: 7,
+			},
+			SFTP: BackupSFTPConfig{
+				Port:      22,
+				Retention: 7,
+			},
 		},
 		Logging: LoggingConfig{
 			Level:  "info",
@@ -338,6 +488,32 @@ const scaffoldTOML = `# Stillwater configuration
 # session_secret is generated automatically on first run when unset.
 # session_secret = ""
 
+# Forward authentication: trust the username an SSO reverse proxy (Authelia,
+# Authentik, ...) puts in a request header. Only honored on requests from
+# SW_TRUSTED_PROXIES, which must be set.
+# See: https://sydlexius.github.io/stillwater/how-to/forward-auth/
+# [auth.forward]
+# user_header = "Remote-User"
+# groups_header = "Remote-Groups"
+# admin_groups = ["stillwater-admins"]
+# user_groups = []
+# default_role = "operator"
+# auto_provision = false
+
+# LDAP: sign in with directory accounts (LLDAP, OpenLDAP, Active Directory).
+# See: https://sydlexius.github.io/stillwater/how-to/ldap-authentication/
+# [auth.ldap]
+# url = "ldap://lldap:3890"
+# start_tls = false
+# bind_dn = "uid=stillwater,ou=people,dc=example,dc=com"
+# bind_password = ""  # Secret; prefer SW_AUTH_LDAP_BIND_PASSWORD.
+# base_dn = "ou=people,dc=example,dc=com"
+# user_filter = "(uid={username})"
+# admin_groups = ["stillwater-admins"]
+# user_groups = []
+# default_role = "operator"
+# auto_provision = false
+
 [encryption]
 # key is generated automatically on first run when unset.
 # key = ""
@@ -349,12 +525,38 @@ const scaffoldTOML = `# Stillwater configuration
 # depth = 1
 # exclusions = ["Various Artists", "Various", "VA", "Soundtrack", "OST"]
 # mtime_fast_path = true  # set to false on filesystems with unreliable mtimes
+# reconcile_hours = 24  # full scan of watched and polled libraries
 
 [backup]
 # path = ""
 # retention_count = 7
 # interval_hours = 24
 # enabled = true
+# verify_hours = 24
+# Encrypt backups with a passphrase or a key file (not both).
+# See: https://sydlexius.github.io/stillwater/how-to/backup-encryption/
+# passphrase = ""  # Secret; prefer SW_BACKUP_PASSPHRASE.
+# key_file = "/config/backup.key"
+
+# Off-box copies: every automated backup is also pushed to these targets.
+# See: https://sydlexius.github.io/stillwater/how-to/backup-targets/
+# [backup.s3]
+# endpoint = "http://minio:9000"
+# bucket = "stillwater-backups"
+# prefix = ""
+# access_key = ""
+# secret_key = ""  # Secret; prefer SW_BACKUP_S3_SECRET_KEY.
+# path_style = true
+# retention = 7
+#
+# [backup.sftp]
+# host = "nas.lan"
+# port = 22
+# user = "backup"
+# key_file = "/config/backup_ed25519"
+# host_key = "SHA256:..."
+# path = "/volume1/backups/stillwater"
+# retention = 7
 
 [logging]
 # level = "info"
@@ -598,6 +800,34 @@ func (c *Config) loadFromEnv() error {
 		{Key: "SW_DB_PATH", Apply: setString(&c.Database.Path)},
 		// Auth / encryption
 		{Key: "SW_SESSION_SECRET", Apply: setString(&c.Auth.SessionSecret)},
+		{Key: "SW_AUTH_FORWARD_USER_HEADER", Apply: setString(&c.Auth.Forward.UserHeader)},
+		{Key: "SW_AUTH_FORWARD_GROUPS_HEADER", Apply: setString(&c.Auth.Forward.GroupsHeader)},
+		{Key: "SW_AUTH_FORWARD_ADMIN_GROUPS", Apply: setCSV(&c.Auth.Forward.AdminGroups)},
+		{Key: "SW_AUTH_FORWARD_USER_GROUPS", Apply: setCSV(&c.Auth.Forward.UserGroups)},
+		{Key: "SW_AUTH_FORWARD_DEFAULT_ROLE", Apply: setString(&c.Auth.Forward.DefaultRole)},
+		{Key: "SW_AUTH_FORWARD_AUTO_PROVISION", Apply: setBool(&c.Auth.Forward.AutoProvision)},
+		{Key: "SW_AUTH_LDAP_URL", Apply: setString(&c.Auth.LDAP.URL)},
+		{Key: "SW_AUTH_LDAP_START_TLS", Apply: setBool(&c.Auth.LDAP.StartTLS)},
+		{Key: "SW_AUTH_LDAP_SKIP_TLS_VERIFY", Apply: setBool(&c.Auth.LDAP.SkipTLSVerify)},
+		{Key: "SW_AUTH_LDAP_BIND_DN", Apply: setString(&c.Auth.LDAP.BindDN)},
+		{Key: "SW_AUTH_LDAP_BIND_PASSWORD", Apply: setString(&c.Auth.LDAP.BindPassword)},
+		{Key: "SW_AUTH_LDAP_BASE_DN", Apply: setString(&c.Auth.LDAP.BaseDN)},
+		{Key: "SW_AUTH_LDAP_USER_FILTER", Apply: setString(&c.Auth.LDAP.UserFilter)},
+		{Key: "SW_AUTH_LDAP_USERNAME_ATTRIBUTE", Apply: setString(&c.Auth.LDAP.UsernameAttribute)},
+		{Key: "SW_AUTH_LDAP_DISPLAY_NAME_ATTRIBUTE", Apply: setString(&c.Auth.LDAP.DisplayNameAttribute)},
+		{Key: "SW_AUTH_LDAP_GROUP_BASE_DN", Apply: setString(&c.Auth.LDAP.GroupBaseDN)},
+		{Key: "SW_AUTH_LDAP_GROUP_FILTER", Apply: setString(&c.Auth.LDAP.GroupFilter)},
+		{Key: "SW_AUTH_LDAP_ADMIN_GROUPS", Apply: setCSV(&c.Auth.LDAP.AdminGroups)},
+		{Key: "SW_AUTH_LDAP_USER_GROUPS", Apply: setCSV(&c.Auth.LDAP.UserGroups)},
+		{Key: "SW_AUTH_LDAP_DEFAULT_ROLE", Apply: setString(&c.Auth.LDAP.DefaultRole)},
+		{Key: "SW_AUTH_LDAP_AUTO_PROVISION", Apply: setBool(&c.Auth.LDAP.AutoProvision)},
+		// Lockout thresholds accept 0 (off); the durations use the lenient
+		// positive-int convention of the backup and rule-engine knobs.
+		{Key: "SW_AUTH_LOCKOUT_USER_THRESHOLD", Apply: setInt("SW_AUTH_LOCKOUT_USER_THRESHOLD", &c.Auth.Lockout.UserThreshold)},
+		{Key: "SW_AUTH_LOCKOUT_IP_THRESHOLD", Apply: setInt("SW_AUTH_LOCKOUT_IP_THRESHOLD", &c.Auth.Lockout.IPThreshold)},
+		{Key: "SW_AUTH_LOCKOUT_DURATION", Apply: setIntPositive(&c.Auth.Lockout.LockMinutes)},
+		{Key: "SW_AUTH_LOCKOUT_MAX_DURATION", Apply: setIntPositive(&c.Auth.Lockout.MaxLockMinutes)},
+		{Key: "SW_AUTH_LOCKOUT_WINDOW", Apply: setIntPositive(&c.Auth.Lockout.WindowMinutes)},
 		{Key: "SW_ENCRYPTION_KEY", Apply: setString(&c.Encryption.Key)},
 		{Key: "SW_ENCRYPTION_KEY_FILE", Apply: setString(&c.Encryption.KeyFile)},
 		// Music
@@ -607,10 +837,37 @@ func (c *Config) loadFromEnv() error {
 		// an unset variable leaves the default-on behavior intact; only
 		// an explicit "false" / "0" disables the fast path.
 		{Key: "SW_SCANNER_MTIME_FAST_PATH", Apply: setBool(&c.Scanner.MtimeFastPath)},
+		{Key: "SW_SCANNER_TAG_IDENTITY", Apply: setBool(&c.Scanner.TagIdentity)},
+		// Strict for the same reason as SW_BACKUP_VERIFY_INTERVAL: 0 means off.
+		{Key: "SW_SCANNER_RECONCILE_INTERVAL", Apply: setInt("SW_SCANNER_RECONCILE_INTERVAL", &c.Scanner.ReconcileHours)},
 		// Backup (lenient int; non-positive values are silently ignored)
 		{Key: "SW_BACKUP_PATH", Apply: setString(&c.Backup.Path)},
 		{Key: "SW_BACKUP_RETENTION", Apply: setIntPositive(&c.Backup.RetentionCount)},
 		{Key: "SW_BACKUP_INTERVAL", Apply: setIntPositive(&c.Backup.IntervalHours)},
+		{Key: "SW_BACKUP_ARCHIVE", Apply: setBool(&c.Backup.Archive)},
+		{Key: "SW_BACKUP_ARCHIVE_ARTWORK", Apply: setBool(&c.Backup.ArchiveArtwork)},
+		{Key: "SW_BACKUP_PASSPHRASE", Apply: setString(&c.Backup.Passphrase)},
+		{Key: "SW_BACKUP_KEY_FILE", Apply: setString(&c.Backup.KeyFile)},
+		// Strict: 0 is meaningful here (checks off), so it cannot share
+		// the lenient parse that drops non-positive values.
+		{Key: "SW_BACKUP_VERIFY_INTERVAL", Apply: setInt("SW_BACKUP_VERIFY_INTERVAL", &c.Backup.VerifyHours)},
+		{Key: "SW_BACKUP_S3_ENDPOINT", Apply: setString(&c.Backup.S3.Endpoint)},
+		{Key: "SW_BACKUP_S3_REGION", Apply: setString(&c.Backup.S3.Region)},
+		{Key: "SW_BACKUP_S3_BUCKET", Apply: setString(&c.Backup.S3.Bucket)},
+		{Key: "SW_BACKUP_S3_PREFIX", Apply: setString(&c.Backup.S3.Prefix)},
+		{Key: "SW_BACKUP_S3_ACCESS_KEY", Apply: setString(&c.Backup.S3.AccessKey)},
+		{Key: "SW_BACKUP_S3_SECRET_KEY", Apply: setString(&c.Backup.S3.SecretKey)},
+		{Key: "SW_BACKUP_S3_PATH_STYLE", Apply: setBool(&c.Backup.S3.PathStyle)},
+		{Key: "SW_BACKUP_S3_RETENTION", Apply: setIntPositive(&c.Backup.S3.Retention)},
+		{Key: "SW_BACKUP_SFTP_HOST", Apply: setString(&c.Backup.SFTP.Host)},
+		{Key: "SW_BACKUP_SFTP_PORT", Apply: setIntPositive(&c.Backup.SFTP.Port)},
+		{Key: "SW_BACKUP_SFTP_USER", Apply: setString(&c.Backup.SFTP.User)},
+		{Key: "SW_BACKUP_SFTP_PASSWORD", Apply: setString(&c.Backup.SFTP.Password)},
+		{Key: "SW_BACKUP_SFTP_KEY_FILE", Apply: setString(&c.Backup.SFTP.KeyFile)},
+		{Key: "SW_BACKUP_SFTP_HOST_KEY", Apply: setString(&c.Backup.SFTP.HostKey)},
+		{Key: "SW_BACKUP_SFTP_KNOWN_HOSTS_FILE", Apply: setString(&c.Backup.SFTP.KnownHostsFile)},
+		{Key: "SW_BACKUP_SFTP_PATH", Apply: setString(&c.Backup.SFTP.Path)},
+		{Key: "SW_BACKUP_SFTP_RETENTION", Apply: setIntPositive(&c.Backup.SFTP.Retention)},
 		// Logging
 		{Key: "SW_LOG_LEVEL", Apply: setString(&c.Logging.Level)},
 		{Key: "SW_LOG_FORMAT", Apply: setString(&c.Logging.Format)},
@@ -832,6 +1089,50 @@ var crossFieldRules = []func(*Config) error{
 		return nil
 	},
 
+	// Forward auth believes a request header, so it needs to know which
+	// peers are the proxy. Without SW_TRUSTED_PROXIES every client could
+	// send the header and sign in as anyone.
+	func(c *Config) error {
+		if c.Auth.Forward.Enabled() && len(c.Server.TrustedProxies) == 0 {
+			return fmt.Errorf("SW_AUTH_FORWARD_USER_HEADER requires SW_TRUSTED_PROXIES to be set")
+		}
+		return nil
+	},
+
+	// LDAP needs to know where to search. Without a base DN every sign-in
+	// would fail at the directory, which is better caught at startup.
+	func(c *Config) error {
+		if c.Auth.LDAP.Enabled() && c.Auth.LDAP.BaseDN == "" {
+			return fmt.Errorf("SW_AUTH_LDAP_URL requires SW_AUTH_LDAP_BASE_DN to be set")
+		}
+		return nil
+	},
+
+	// An off-box backup target that is half configured would fail on the
+	// first push, hours after startup, so its gaps are caught here.
+	func(c *Config) error {
+		if c.Backup.Passphrase != "" && c.Backup.KeyFile != "" {
+			return fmt.Errorf("SW_BACKUP_PASSPHRASE and SW_BACKUP_KEY_FILE cannot both be set")
+		}
+		if s3 := c.Backup.S3; s3.Enabled() && (s3.Endpoint == "" || s3.AccessKey == "" || s3.SecretKey == "") {
+			return fmt.Errorf("SW_BACKUP_S3_BUCKET requires SW_BACKUP_S3_ENDPOINT, SW_BACKUP_S3_ACCESS_KEY and SW_BACKUP_S3_SECRET_KEY to be set")
+		}
+		sftp := c.Backup.SFTP
+		if !sftp.Enabled() {
+			return nil
+		}
+		if sftp.User == "" || sftp.Path == "" {
+			return fmt.Errorf("SW_BACKUP_SFTP_HOST requires SW_BACKUP_SFTP_USER and SW_BACKUP_SFTP_PATH to be set")
+		}
+		if sftp.Password == "" && sftp.KeyFile == "" {
+			return fmt.Errorf("SW_BACKUP_SFTP_HOST requires SW_BACKUP_SFTP_PASSWORD or SW_BACKUP_SFTP_KEY_FILE to be set")
+		}
+		if sftp.HostKey == "" && sftp.KnownHostsFile == "" {
+			return fmt.Errorf("SW_BACKUP_SFTP_HOST requires SW_BACKUP_SFTP_HOST_KEY or SW_BACKUP_SFTP_KNOWN_HOSTS_FILE to pin the server's host key")
+		}
+		return nil
+	},
+
 	// HTTP/3 requires TLS (HTTP/3 mandates TLS 1.3). BYO cert must be
 	// configured; ACME is not yet wired to the HTTP/3 listener.
 	func(c *Config) error {
@@ -905,6 +1206,53 @@ func (c *Config) validate() error {
 		c.Image.DecodeConcurrency = maxDecodeConcurrency
 	}
 
+	// Same default-or-refuse shape for the forward-auth role. Administrator
+	// is not a default: admins come from SW_AUTH_FORWARD_ADMIN_GROUPS.
+	if c.Auth.Forward.DefaultRole == "" {
+		c.Auth.Forward.DefaultRole = Default().Auth.Forward.DefaultRole
+	}
+	switch c.Auth.Forward.DefaultRole {
+	case "operator", "viewer":
+	default:
+		return fmt.Errorf("invalid SW_AUTH_FORWARD_DEFAULT_ROLE %q: must be operator or viewer", c.Auth.Forward.DefaultRole)
+	}
+	if c.Auth.LDAP.DefaultRole == "" {
+		c.Auth.LDAP.DefaultRole = Default().Auth.LDAP.DefaultRole
+	}
+	switch c.Auth.LDAP.DefaultRole {
+	case "operator", "viewer":
+	default:
+		return fmt.Errorf("invalid SW_AUTH_LDAP_DEFAULT_ROLE %q: must be operator or viewer", c.Auth.LDAP.DefaultRole)
+	}
+
+	// Lockout: a negative threshold is a typo, not "off". File-backed
+	// durations get the same non-positive-is-default treatment as the env
+	// path, and the cap is never shorter than the first lockout.
+	if c.Auth.Lockout.UserThreshold < 0 {
+		return fmt.Errorf("invalid SW_AUTH_LOCKOUT_USER_THRESHOLD %d: must be 0 or more", c.Auth.Lockout.UserThreshold)
+	}
+	if c.Auth.Lockout.IPThreshold < 0 {
+		return fmt.Errorf("invalid SW_AUTH_LOCKOUT_IP_THRESHOLD %d: must be 0 or more", c.Auth.Lockout.IPThreshold)
+	}
+	if c.Auth.Lockout.LockMinutes <= 0 {
+		c.Auth.Lockout.LockMinutes = Default().Auth.Lockout.LockMinutes
+	}
+	if c.Auth.Lockout.MaxLockMinutes <= 0 {
+		c.Auth.Lockout.MaxLockMinutes = Default().Auth.Lockout.MaxLockMinutes
+	}
+	if c.Auth.Lockout.WindowMinutes <= 0 {
+		c.Auth.Lockout.WindowMinutes = Default().Auth.Lockout.WindowMinutes
+	}
+	c.Auth.Lockout.MaxLockMinutes = max(c.Auth.Lockout.MaxLockMinutes, c.Auth.Lockout.LockMinutes)
+
+	// Periodic jobs where 0 means off, so a negative interval is a typo.
+	if c.Backup.VerifyHours < 0 {
+		return fmt.Errorf("invalid SW_BACKUP_VERIFY_INTERVAL %d: must be 0 or more", c.Backup.VerifyHours)
+	}
+	if c.Scanner.ReconcileHours < 0 {
+		return fmt.Errorf("invalid SW_SCANNER_RECONCILE_INTERVAL %d: must be 0 or more", c.Scanner.ReconcileHours)
+	}
+
 	// Normalize and validate the UI channel flag. An empty value (file-backed
 	// config that omits the key) falls back to the documented default; any other
 	// non-legal value is a hard error so a typo never silently serves the wrong UI.
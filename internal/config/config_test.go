@@ -138,15 +138,294 @@ func TestTrustedProxies_EnvAndValidation(t *testing.T) {
 	})
 }
 
+func TestForwardAuth_EnvAndValidation(t *testing.T) {
+	t.Run("off by default", func(t *testing.T) {
+		clearSWEnv(t)
+		cfg, err := Load("")
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.Auth.Forward.Enabled() {
+			t.Error("Auth.Forward.Enabled() = true, want false")
+		}
+		if cfg.Auth.Forward.DefaultRole != "operator" {
+			t.Errorf("Auth.Forward.DefaultRole = %q, want operator", cfg.Auth.Forward.DefaultRole)
+		}
+	})
+
+	t.Run("env populates every field", func(t *testing.T) {
+		clearSWEnv(t)
+		t.Setenv("SW_TRUSTED_PROXIES", "172.16.0.0/12")
+		t.Setenv("SW_AUTH_FORWARD_USER_HEADER", "Remote-User")
+		t.Setenv("SW_AUTH_FORWARD_GROUPS_HEADER", "Remote-Groups")
+		t.Setenv("SW_AUTH_FORWARD_ADMIN_GROUPS", "admins, media-admins")
+		t.Setenv("SW_AUTH_FORWARD_USER_GROUPS", "family")
+		t.Setenv("SW_AUTH_FORWARD_DEFAULT_ROLE", "viewer")
+		t.Setenv("SW_AUTH_FORWARD_AUTO_PROVISION", "true")
+		cfg, err := Load("")
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		fwd := cfg.Auth.Forward
+		if !fwd.Enabled() || fwd.UserHeader != "Remote-User" || fwd.GroupsHeader != "Remote-Groups" {
+			t.Errorf("headers = %q / %q, want Remote-User / Remote-Groups", fwd.UserHeader, fwd.GroupsHeader)
+		}
+		if len(fwd.AdminGroups) != 2 || fwd.AdminGroups[1] != "media-admins" {
+			t.Errorf("AdminGroups = %v, want [admins media-admins]", fwd.AdminGroups)
+		}
+		if len(fwd.UserGroups) != 1 || fwd.UserGroups[0] != "family" {
+			t.Errorf("UserGroups = %v, want [family]", fwd.UserGroups)
+		}
+		if fwd.DefaultRole != "viewer" || !fwd.AutoProvision {
+			t.Errorf("DefaultRole = %q, AutoProvision = %v; want viewer, true", fwd.DefaultRole, fwd.AutoProvision)
+		}
+	})
+
+	t.Run("user header requires trusted proxies", func(t *testing.T) {
+		clearSWEnv(t)
+		t.Setenv("SW_AUTH_FORWARD_USER_HEADER", "Remote-User")
+		_, err := Load("")
+		if err == nil {
+			t.Fatal("Load() without SW_TRUSTED_PROXIES returned nil error, want validation failure")
+		}
+		if !strings.Contains(err.Error(), "SW_TRUSTED_PROXIES") {
+			t.Errorf("error = %q, want it to mention SW_TRUSTED_PROXIES", err.Error())
+		}
+	})
+
+	t.Run("administrator is not a default role", func(t *testing.T) {
+		clearSWEnv(t)
+		t.Setenv("SW_TRUSTED_PROXIES", "172.16.0.0/12")
+		t.Setenv("SW_AUTH_FORWARD_USER_HEADER", "Remote-User")
+		t.Setenv("SW_AUTH_FORWARD_DEFAULT_ROLE", "administrator")
+		if _, err := Load(""); err == nil {
+			t.Error("Load() with SW_AUTH_FORWARD_DEFAULT_ROLE=administrator returned nil error, want validation failure")
+		}
+	})
+}
+
+func TestLDAPAuth_EnvAndValidation(t *testing.T) {
+	t.Run("off by default", func(t *testing.T) {
+		clearSWEnv(t)
+		cfg, err := Load("")
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		ldap := cfg.Auth.LDAP
+		if ldap.Enabled() {
+			t.Error("Auth.LDAP.Enabled() = true, want false")
+		}
+		if ldap.UserFilter != "(uid={username})" || ldap.UsernameAttribute != "uid" || ldap.DefaultRole != "operator" {
+			t.Errorf("defaults = %q / %q / %q", ldap.UserFilter, ldap.UsernameAttribute, ldap.DefaultRole)
+		}
+	})
+
+	t.Run("env populates the directory settings", func(t *testing.T) {
+		clearSWEnv(t)
+		t.Setenv("SW_AUTH_LDAP_URL", "ldaps://lldap:6360")
+		t.Setenv("SW_AUTH_LDAP_BIND_DN", "uid=stillwater,ou=people,dc=example,dc=com")
+		t.Setenv("SW_AUTH_LDAP_BIND_PASSWORD", "s3cret")
+		t.Setenv("SW_AUTH_LDAP_BASE_DN", "ou=people,dc=example,dc=com")
+		t.Setenv("SW_AUTH_LDAP_GROUP_FILTER", "(member={dn})")
+		t.Setenv("SW_AUTH_LDAP_ADMIN_GROUPS", "stillwater-admins, lldap_admin")
+		t.Setenv("SW_AUTH_LDAP_DEFAULT_ROLE", "viewer")
+		t.Setenv("SW_AUTH_LDAP_AUTO_PROVISION", "1")
+		cfg, err := Load("")
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		ldap := cfg.Auth.LDAP
+		if !ldap.Enabled() || ldap.BindPassword != "s3cret" || ldap.BaseDN != "ou=people,dc=example,dc=com" {
+			t.Errorf("LDAP = %+v", ldap)
+		}
+		if ldap.GroupFilter != "(member={dn})" || len(ldap.AdminGroups) != 2 || ldap.AdminGroups[1] != "lldap_admin" {
+			t.Errorf("groups = %q / %v", ldap.GroupFilter, ldap.AdminGroups)
+		}
+		if ldap.DefaultRole != "viewer" || !ldap.AutoProvision {
+			t.Errorf("DefaultRole = %q, AutoProvision = %v; want viewer, true", ldap.DefaultRole, ldap.AutoProvision)
+		}
+	})
+
+	t.Run("url requires base dn", func(t *testing.T) {
+		clearSWEnv(t)
+		t.Setenv("SW_AUTH_LDAP_URL", "ldap://lldap:3890")
+		_, err := Load("")
+		if err == nil || !strings.Contains(err.Error(), "SW_AUTH_LDAP_BASE_DN") {
+			t.Fatalf("Load() error = %v, want it to mention SW_AUTH_LDAP_BASE_DN", err)
+		}
+	})
+
+	t.Run("administrator is not a default role", func(t *testing.T) {
+		clearSWEnv(t)
+		t.Setenv("SW_AUTH_LDAP_DEFAULT_ROLE", "administrator")
+		if _, err := Load(""); err == nil {
+			t.Error("Load() with SW_AUTH_LDAP_DEFAULT_ROLE=administrator returned nil error, want validation failure")
+		}
+	})
+}
+
+func TestBackupTargets_EnvAndValidation(t *testing.T) {
+	t.Run("off by default", func(t *testing.T) {
+		clearSWEnv(t)
+		cfg, err := Load("")
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		b := cfg.Backup
+		if b.S3.Enabled() || b.SFTP.Enabled() {
+			t.Errorf("targets enabled by default: S3 %v, SFTP %v", b.S3.Enabled(), b.SFTP.Enabled())
+		}
+		if b.S3.Region != "us-east-1" || !b.S3.PathStyle || b.S3.Retention != 7 || b.SFTP.Port != 22 || b.SFTP.Retention != 7 {
+			t.Errorf("defaults = %+v / %+v", b.S3, b.SFTP)
+		}
+		if b.Passphrase != "" || b.KeyFile != "" || b.VerifyHours != 24 {
+			t.Errorf("encryption and verification defaults = %q %q %d", b.Passphrase, b.KeyFile, b.VerifyHours)
+		}
+	})
+
+	t.Run("env populates both targets", func(t *testing.T) {
+		clearSWEnv(t)
+		t.Setenv("SW_BACKUP_S3_ENDPOINT", "http://minio:9000")
+		t.Setenv("SW_BACKUP_S3_BUCKET", "stillwater")
+		t.Setenv("SW_BACKUP_S3_ACCESS_KEY", "AKID")
+		t.Setenv("SW_BACKUP_S3_SECRET_KEY", "s3cret")
+		t.Setenv("SW_BACKUP_S3_PATH_STYLE", "false")
+		t.Setenv("SW_BACKUP_S3_RETENTION", "30")
+		t.Setenv("SW_BACKUP_SFTP_HOST", "nas.lan")
+		t.Setenv("SW_BACKUP_SFTP_PORT", "2222")
+		t.Setenv("SW_BACKUP_SFTP_USER", "backup")
+		t.Setenv("SW_BACKUP_SFTP_KEY_FILE", "/config/id_ed25519")
+		t.Setenv("SW_BACKUP_SFTP_HOST_KEY", "SHA256:abc")
+		t.Setenv("SW_BACKUP_SFTP_PATH", "/backups")
+		t.Setenv("SW_BACKUP_KEY_FILE", "/config/backup.key")
+		t.Setenv("SW_BACKUP_VERIFY_INTERVAL", "6")
+		cfg, err := Load("")
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		s3, sftp := cfg.Backup.S3, cfg.Backup.SFTP
+		if !s3.Enabled() || s3.SecretKey != "s3cret" || s3.PathStyle || s3.Retention != 30 {
+			t.Errorf("S3 = %+v", s3)
+		}
+		if !sftp.Enabled() || sftp.Port != 2222 || sftp.KeyFile != "/config/id_ed25519" || sftp.Path != "/backups" {
+			t.Errorf("SFTP = %+v", sftp)
+		}
+		if cfg.Backup.KeyFile != "/config/backup.key" || cfg.Backup.VerifyHours != 6 {
+			t.Errorf("backup key file %q, verify hours %d", cfg.Backup.KeyFile, cfg.Backup.VerifyHours)
+		}
+	})
+
+	t.Run("zero verify interval turns the checks off", func(t *testing.T) {
+		clearSWEnv(t)
+		t.Setenv("SW_BACKUP_VERIFY_INTERVAL", "0")
+		cfg, err := Load("")
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if cfg.Backup.VerifyHours != 0 {
+			t.Errorf("VerifyHours = %d, want 0", cfg.Backup.VerifyHours)
+		}
+	})
+
+	for _, tc := range []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"s3 bucket without credentials", map[string]string{
+			"SW_BACKUP_S3_BUCKET": "b", "SW_BACKUP_S3_ENDPOINT": "http://minio:9000",
+		}, "SW_BACKUP_S3_ACCESS_KEY"},
+		{"sftp host without auth", map[string]string{
+			"SW_BACKUP_SFTP_HOST": "nas", "SW_BACKUP_SFTP_USER": "u", "SW_BACKUP_SFTP_PATH": "/b", "SW_BACKUP_SFTP_HOST_KEY": "SHA256:x",
+		}, "SW_BACKUP_SFTP_PASSWORD"},
+		{"sftp host without a pinned key", map[string]string{
+			"SW_BACKUP_SFTP_HOST": "nas", "SW_BACKUP_SFTP_USER": "u", "SW_BACKUP_SFTP_PATH": "/b", "SW_BACKUP_SFTP_PASSWORD": "p",
+		}, "SW_BACKUP_SFTP_HOST_KEY"},
+		{"passphrase and key file", map[string]string{
+			"SW_BACKUP_PASSPHRASE": "p", "SW_BACKUP_KEY_FILE": "/config/backup.key",
+		}, "SW_BACKUP_KEY_FILE"},
+		{"negative verify interval", map[string]string{
+			"SW_BACKUP_VERIFY_INTERVAL": "-1",
+		}, "SW_BACKUP_VERIFY_INTERVAL"},
+		{"non-numeric verify interval", map[string]string{
+			"SW_BACKUP_VERIFY_INTERVAL": "daily",
+		}, "SW_BACKUP_VERIFY_INTERVAL"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			clearSWEnv(t)
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			_, err := Load("")
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("Load() error = %v, want it to mention %s", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestLockout_EnvAndValidation(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		clearSWEnv(t)
+		cfg, err := Load("")
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		want := LockoutConfig{UserThreshold: 5, IPThreshold: 20, LockMinutes: 1, MaxLockMinutes: 60, WindowMinutes: 15}
+		if cfg.Auth.Lockout != want {
+			t.Errorf("Lockout = %+v, want %+v", cfg.Auth.Lockout, want)
+		}
+	})
+
+	t.Run("env overrides and zero turns a counter off", func(t *testing.T) {
+		clearSWEnv(t)
+		t.Setenv("SW_AUTH_LOCKOUT_USER_THRESHOLD", "3")
+		t.Setenv("SW_AUTH_LOCKOUT_IP_THRESHOLD", "0")
+		t.Setenv("SW_AUTH_LOCKOUT_DURATION", "10")
+		t.Setenv("SW_AUTH_LOCKOUT_MAX_DURATION", "5")
+		t.Setenv("SW_AUTH_LOCKOUT_WINDOW", "-1")
+		cfg, err := Load("")
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		got := cfg.Auth.Lockout
+		if got.UserThreshold != 3 || got.IPThreshold != 0 || got.LockMinutes != 10 {
+			t.Errorf("Lockout = %+v, want thresholds 3 / 0 and a 10 minute lockout", got)
+		}
+		if got.MaxLockMinutes != 10 {
+			t.Errorf("MaxLockMinutes = %d, want it raised to the first lockout (10)", got.MaxLockMinutes)
+		}
+		if got.WindowMinutes != 15 {
+			t.Errorf("WindowMinutes = %d, want the default for a non-positive value", got.WindowMinutes)
+		}
+	})
+
+	t.Run("negative threshold is refused", func(t *testing.T) {
+		clearSWEnv(t)
+		t.Setenv("SW_AUTH_LOCKOUT_USER_THRESHOLD", "-1")
+		if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "SW_AUTH_LOCKOUT_USER_THRESHOLD") {
+			t.Errorf("Load() error = %v, want it to mention SW_AUTH_LOCKOUT_USER_THRESHOLD", err)
+		}
+	})
+}
+
 // clearSWEnv unsets all SW_* environment variables to prevent env overrides
 // from interfering with tests that assert YAML/default behavior.
 func clearSWEnv(t *testing.T) {
 	t.Helper()
 	for _, key := range []string{
 		"SW_PORT", "SW_BASE_PATH", "SW_DB_PATH", "SW_SESSION_SECRET",
-		"SW_ENCRYPTION_KEY", "SW_MUSIC_PATH", "SW_SCANNER_EXCLUSIONS",
+		"SW_ENCRYPTION_KEY", "SW_MUSIC_PATH", "SW_SCANNER_EXCLUSIONS", "SW_SCANNER_RECONCILE_INTERVAL",
 		"SW_BACKUP_PATH", "SW_BACKUP_RETENTION", "SW_BACKUP_INTERVAL",
-		"SW_BACKUP_ENABLED", "SW_LOG_LEVEL", "SW_LOG_FORMAT",
+		"SW_BACKUP_ENABLED", "SW_BACKUP_ARCHIVE", "SW_BACKUP_ARCHIVE_ARTWORK",
+		"SW_BACKUP_PASSPHRASE", "SW_BACKUP_KEY_FILE", "SW_BACKUP_VERIFY_INTERVAL",
+		"SW_BACKUP_S3_ENDPOINT", "SW_BACKUP_S3_REGION", "SW_BACKUP_S3_BUCKET",
+		"SW_BACKUP_S3_PREFIX", "SW_BACKUP_S3_ACCESS_KEY", "SW_BACKUP_S3_SECRET_KEY",
+		"SW_BACKUP_S3_PATH_STYLE", "SW_BACKUP_S3_RETENTION",
+		"SW_BACKUP_SFTP_HOST", "SW_BACKUP_SFTP_PORT", "SW_BACKUP_SFTP_USER",
+		"SW_BACKUP_SFTP_PASSWORD", "SW_BACKUP_SFTP_KEY_FILE", "SW_BACKUP_SFTP_HOST_KEY",
+		"SW_BACKUP_SFTP_KNOWN_HOSTS_FILE", "SW_BACKUP_SFTP_PATH", "SW_BACKUP_SFTP_RETENTION",
+		"SW_LOG_LEVEL", "SW_LOG_FORMAT",
 		"SW_RULE_ENGINE_ARTIST_WORKERS", "SW_IMAGE_DECODE_CONCURRENCY",
 		"SW_TLS_CERT_FILE", "SW_TLS_KEY_FILE", "SW_TLS_PORT",
 		"SW_HTTP_REDIRECT_PORT", "SW_HTTP3_ENABLED", "SW_HTTP3_PORT",
@@ -154,6 +433,19 @@ func clearSWEnv(t *testing.T) {
 		"SW_ACME_EAB_KEY_ID", "SW_ACME_EAB_MAC_KEY",
 		"SW_ACME_IP", "SW_ACME_CACHE_DIR", "SW_UX",
 		"SW_TRUSTED_PROXIES",
+		"SW_AUTH_FORWARD_USER_HEADER", "SW_AUTH_FORWARD_GROUPS_HEADER",
+		"SW_AUTH_FORWARD_ADMIN_GROUPS", "SW_AUTH_FORWARD_USER_GROUPS",
+		"SW_AUTH_FORWARD_DEFAULT_ROLE", "SW_AUTH_FORWARD_AUTO_PROVISION",
+		"SW_AUTH_LDAP_URL", "SW_AUTH_LDAP_START_TLS", "SW_AUTH_LDAP_SKIP_TLS_VERIFY",
+		"SW_AUTH_LDAP_BIND_DN", "SW_AUTH_LDAP_BIND_PASSWORD", "SW_AUTH_LDAP_BASE_DN",
+		"SW_AUTH_LDAP_USER_FILTER", "SW_AUTH_LDAP_USERNAME_ATTRIBUTE",
+		"SW_AUTH_LDAP_DISPLAY_NAME_ATTRIBUTE", "SW_AUTH_LDAP_GROUP_BASE_DN",
+		"SW_AUTH_LDAP_GROUP_FILTER", "SW_AUTH_LDAP_ADMIN_GROUPS",
+		"SW_AUTH_LDAP_USER_GROUPS", "SW_AUTH_LDAP_DEFAULT_ROLE",
+		"SW_AUTH_LDAP_AUTO_PROVISION",
+		"SW_AUTH_LOCKOUT_USER_THRESHOLD", "SW_AUTH_LOCKOUT_IP_THRESHOLD",
+		"SW_AUTH_LOCKOUT_DURATION", "SW_AUTH_LOCKOUT_MAX_DURATION",
+		"SW_AUTH_LOCKOUT_WINDOW",
 	} {
 		t.Setenv(key, "")
 	}
@@ -819,6 +1111,34 @@ func TestLoadFromEnv_ScannerExclusions(t *testing.T) {
 	}
 }
 
+func TestLoadFromEnv_ScannerReconcileInterval(t *testing.T) {
+	clearSWEnv(t)
+	if cfg, err := Load(""); err != nil || cfg.Scanner.ReconcileHours != 24 {
+		t.Fatalf("default ReconcileHours = %v, %v; want 24", cfg, err)
+	}
+
+	t.Setenv("SW_SCANNER_RECONCILE_INTERVAL", "6")
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Scanner.ReconcileHours != 6 {
+		t.Errorf("ReconcileHours = %d, want 6", cfg.Scanner.ReconcileHours)
+	}
+
+	t.Setenv("SW_SCANNER_RECONCILE_INTERVAL", "0")
+	if cfg, err = Load(""); err != nil || cfg.Scanner.ReconcileHours != 0 {
+		t.Errorf("ReconcileHours = %v, %v; want 0 to turn reconciliation off", cfg, err)
+	}
+
+	for _, v := range []string{"-1", "daily"} {
+		t.Setenv("SW_SCANNER_RECONCILE_INTERVAL", v)
+		if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "SW_SCANNER_RECONCILE_INTERVAL") {
+			t.Errorf("Load() with %q: error = %v, want it to mention SW_SCANNER_RECONCILE_INTERVAL", v, err)
+		}
+	}
+}
+
 func TestEnsureScaffold_CreatesMissingFile(t *testing.T) {
 	clearSWEnv(t)
 	dir := t.TempDir()
@@ -74,6 +74,94 @@ func TestSetters_AllocateMatchingConfig(t *testing.T) {
 	}
 }
 
+// TestPlexConfig checks the Plex arm of the sub-config contract: it carries a
+// server identity and the feature toggles, but no platform user.
+func TestPlexConfig(t *testing.T) {
+	plex := &Connection{Name: "plex", Type: TypePlex, URL: "http://plex:32400", APIKey: "token"}
+	if err := plex.Validate(); err != nil {
+		t.Fatalf("Validate(): %v", err)
+	}
+	if plex.Plex == nil {
+		t.Fatal("Validate() did not allocate the PlexConfig")
+	}
+	plex.SetPlatformUserID("ignored")
+	plex.SetPlatformServerID("machine-1")
+	plex.SetFeatures(true, true, false)
+	if plex.GetPlatformUserID() != "" {
+		t.Errorf("GetPlatformUserID() = %q, want empty (Plex has no platform user)", plex.GetPlatformUserID())
+	}
+	if plex.GetPlatformServerID() != "machine-1" {
+		t.Errorf("GetPlatformServerID() = %q, want machine-1", plex.GetPlatformServerID())
+	}
+	if !plex.GetFeatureImageWrite() || !plex.GetFeatureMetadataPush() || plex.GetFeatureTriggerRefresh() {
+		t.Errorf("feature getters = %+v, want image+metadata on, refresh off", plex.Plex)
+	}
+
+	mixed := &Connection{Name: "bad", Type: TypePlex, URL: "http://plex:32400", APIKey: "k", Emby: &EmbyConfig{}}
+	if err := mixed.Validate(); err == nil {
+		t.Error("Validate() must reject a Plex connection carrying an EmbyConfig")
+	}
+}
+
+// TestSubsonicConfig checks the Subsonic arm: the trigger-refresh toggle is
+// the only one it keeps, and it has neither a platform user nor a server ID.
+func TestSubsonicConfig(t *testing.T) {
+	sub := &Connection{Name: "navidrome", Type: TypeSubsonic, URL: "http://navidrome:4533", APIKey: "alice:secret"}
+	if err := sub.Validate(); err != nil {
+		t.Fatalf("Validate(): %v", err)
+	}
+	if sub.Subsonic == nil {
+		t.Fatal("Validate() did not allocate the SubsonicConfig")
+	}
+	sub.SetPlatformServerID("ignored")
+	sub.SetFeatures(true, true, true)
+	if sub.GetPlatformServerID() != "" {
+		t.Errorf("GetPlatformServerID() = %q, want empty", sub.GetPlatformServerID())
+	}
+	if sub.GetFeatureImageWrite() || sub.GetFeatureMetadataPush() {
+		t.Error("image write and metadata push must stay off: Subsonic has no write API for them")
+	}
+	if !sub.GetFeatureTriggerRefresh() {
+		t.Error("GetFeatureTriggerRefresh() = false after SetFeatures(_, _, true)")
+	}
+
+	mixed := &Connection{Name: "bad", Type: TypeSubsonic, URL: "http://navidrome:4533", APIKey: "a:b", Plex: &PlexConfig{}}
+	if err := mixed.Validate(); err == nil {
+		t.Error("Validate() must reject a Subsonic connection carrying a PlexConfig")
+	}
+}
+
+// TestKodiConfig checks the Kodi arm: all three toggles round-trip, and there
+// is no platform user or server ID to store.
+func TestKodiConfig(t *testing.T) {
+	k := &Connection{Name: "kodi", Type: TypeKodi, URL: "http://kodi:8080", APIKey: "kodi:secret"}
+	if err := k.Validate(); err != nil {
+		t.Fatalf("Validate(): %v", err)
+	}
+	if k.Kodi == nil {
+		t.Fatal("Validate() did not allocate the KodiConfig")
+	}
+	k.SetPlatformUserID("ignored")
+	k.SetPlatformServerID("ignored")
+	k.SetFeatures(true, false, true)
+	if k.GetPlatformUserID() != "" || k.GetPlatformServerID() != "" {
+		t.Errorf("platform ids = %q/%q, want both empty", k.GetPlatformUserID(), k.GetPlatformServerID())
+	}
+	if !k.GetFeatureImageWrite() || k.GetFeatureMetadataPush() || !k.GetFeatureTriggerRefresh() {
+		t.Errorf("features = %v/%v/%v, want true/false/true",
+			k.GetFeatureImageWrite(), k.GetFeatureMetadataPush(), k.GetFeatureTriggerRefresh())
+	}
+
+	mixed := &Connection{Name: "bad", Type: TypeKodi, URL: "http://kodi:8080", APIKey: "a:b", Subsonic: &SubsonicConfig{}}
+	if err := mixed.Validate(); err == nil {
+		t.Error("Validate() must reject a Kodi connection carrying a SubsonicConfig")
+	}
+	plex := &Connection{Name: "bad", Type: TypePlex, URL: "http://plex:32400", APIKey: "t", Kodi: &KodiConfig{}}
+	if err := plex.Validate(); err == nil {
+		t.Error("Validate() must reject a Plex connection carrying a KodiConfig")
+	}
+}
+
 func TestValidate_RejectsMismatchedConfig(t *testing.T) {
 	c := &Connection{
 		Name:   "bad",
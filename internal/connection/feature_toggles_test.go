@@ -23,12 +23,15 @@ func TestSupportsFeatureToggles(t *testing.T) {
 	}{
 		{TypeEmby, true},
 		{TypeJellyfin, true},
+		{TypePlex, true},
 		{TypeLidarr, false},
+		{TypeSubsonic, false}, // trigger refresh only; see TestSupportsTriggerRefresh
+		{TypeKodi, true},
 		// Unrecognized input must default to unsupported -- the safe
 		// direction. "" covers the zero value a partially-built Connection
 		// would carry.
 		{"", false},
-		{"plex", false},
+		{"sonarr", false},
 		{"EMBY", false}, // the switch is case-sensitive; callers pass the stored lowercase type
 	}
 	for _, tc := range cases {
@@ -40,3 +43,22 @@ func TestSupportsFeatureToggles(t *testing.T) {
 		})
 	}
 }
+
+// TestSupportsTriggerRefresh pins the narrower allow-list for the one toggle
+// Subsonic has without the other two.
+func TestSupportsTriggerRefresh(t *testing.T) {
+	t.Parallel()
+	for connType, want := range map[string]bool{
+		TypeEmby:     true,
+		TypeJellyfin: true,
+		TypePlex:     true,
+		TypeSubsonic: true,
+		TypeKodi:     true,
+		TypeLidarr:   false,
+		"":           false,
+	} {
+		if got := SupportsTriggerRefresh(connType); got != want {
+			t.Errorf("SupportsTriggerRefresh(%q) = %v, want %v", connType, got, want)
+		}
+	}
+}
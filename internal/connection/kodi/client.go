@@ -0,0 +1,303 @@
+package kodi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/connection"
+	"github.com/sydlexius/stillwater/internal/connection/httpclient"
+)
+
+// ErrAuthRequired is the sentinel wrapped by every call the web server
+// refuses with a 401 or 403.
+var ErrAuthRequired = errors.New("kodi: authentication required")
+
+// artistPageSize bounds each AudioLibrary.GetArtists page. Kodi builds the
+// whole reply in memory on the box, which is often a low-powered player, so
+// pages stay small.
+const artistPageSize = 500
+
+// artistPageCap bounds the number of pages GetArtists fetches. 200 pages x 500
+// is far past any real music library, so the cap only stops a server whose
+// reported total never converges.
+const artistPageCap = 200
+
+// listProperties are the artist properties requested on list calls: enough
+// to map an artist (MBID, name) and to filter by source.
+var listProperties = []string{"sortname", "musicbrainzartistid", "sourceid"}
+
+// detailProperties are the artist properties requested for the platform
+// state card and pull.
+var detailProperties = []string{
+	"sortname", "description", "genre", "style", "mood",
+	"born", "formed", "died", "disbanded", "musicbrainzartistid", "art",
+}
+
+// Client communicates with Kodi's JSON-RPC API.
+//
+// Kodi's web server authenticates with HTTP basic auth. The connection stores
+// the pair in its single encrypted API-key field as "username:password" (see
+// SplitCredentials), the same shape the Subsonic connection uses.
+type Client struct {
+	httpclient.BaseClient
+	username string
+	password string
+}
+
+// New creates a Kodi client with default HTTP settings. credentials is the
+// connection's "username:password" API-key value.
+//
+// Uses a raw http.Client (not httpsafe.SafeClient) because Kodi is a
+// user-configured player that runs on a LAN address (192.168.1.50:8080). The
+// httpsafe.SafeTransport SSRF guard would reject those destinations. The
+// destination URL is operator-supplied via Settings, not user-controlled
+// input.
+func New(baseURL, credentials string, logger *slog.Logger) *Client {
+	return NewWithHTTPClient(baseURL, credentials, &http.Client{Timeout: 10 * time.Second}, logger)
+}
+
+// NewWithHTTPClient creates a Kodi client with a custom HTTP client (for testing).
+func NewWithHTTPClient(baseURL, credentials string, httpClient *http.Client, logger *slog.Logger) *Client {
+	user, pass := SplitCredentials(credentials)
+	c := &Client{
+		BaseClient: httpclient.NewBase(baseURL, credentials, httpClient, logger, "kodi"),
+		username:   user,
+		password:   pass,
+	}
+	c.AuthFunc = c.setAuth
+	return c
+}
+
+// SplitCredentials splits a "username:password" API-key value at its first
+// colon; everything after it is the password. Kodi's web server allows an
+// empty password, so a value without a colon is taken as a username alone.
+func SplitCredentials(credentials string) (username, password string) {
+	user, pass, _ := strings.Cut(credentials, ":")
+	return user, pass
+}
+
+func (c *Client) setAuth(req *http.Request) {
+	req.SetBasicAuth(c.username, c.password)
+}
+
+// call invokes method with params and decodes its result into result (which
+// may be nil). Kodi answers a failed call with HTTP 200 and a JSON-RPC error
+// object, so both layers are checked; a 401/403 wraps ErrAuthRequired.
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	body, err := json.Marshal(rpcRequest{JSONRPC: "2.0", Method: method, Params: params, ID: 1})
+	if err != nil {
+		return fmt.Errorf("encoding %s: %w", method, err)
+	}
+	var resp rpcResponse
+	if err := c.PostJSON(ctx, "/jsonrpc", bytes.NewReader(body), &resp); err != nil {
+		var se *httpclient.StatusError
+		if errors.As(err, &se) && se.IsAuth() {
+			return fmt.Errorf("%s: %w: %w", method, ErrAuthRequired, err)
+		}
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%s: error %d: %s", method, resp.Error.Code, resp.Error.Message)
+	}
+	if result != nil {
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("decoding %s result: %w", method, err)
+		}
+	}
+	return nil
+}
+
+// TestConnection verifies connectivity and the credentials with
+// JSONRPC.Version. The web server authenticates every /jsonrpc request, so a
+// success proves both.
+func (c *Client) TestConnection(ctx context.Context) error {
+	var res versionResult
+	if err := c.call(ctx, "JSONRPC.Version", nil, &res); err != nil {
+		return fmt.Errorf("testing connection: %w", err)
+	}
+	c.Logger.Debug("kodi connection ok",
+		"api_version", fmt.Sprintf("%d.%d.%d", res.Version.Major, res.Version.Minor, res.Version.Patch))
+	return nil
+}
+
+// GetSources returns the music sources configured in Kodi's music library.
+func (c *Client) GetSources(ctx context.Context) ([]Source, error) {
+	var res sourcesResult
+	params := map[string]any{"properties": []string{"file"}}
+	if err := c.call(ctx, "AudioLibrary.GetSources", params, &res); err != nil {
+		return nil, fmt.Errorf("getting sources: %w", err)
+	}
+	return res.Sources, nil
+}
+
+// Paths returns the folders a source covers, as Kodi addresses them. A
+// multi-folder source reports its folders as one multipath:// URL whose
+// segments are each URL-encoded; those are decoded and returned separately.
+func (s *Source) Paths() []string {
+	rest, ok := strings.CutPrefix(s.File, "multipath://")
+	if !ok {
+		if s.File == "" {
+			return nil
+		}
+		return []string{s.File}
+	}
+	var out []string
+	for _, seg := range strings.Split(rest, "/") {
+		if seg == "" {
+			continue
+		}
+		p, err := url.PathUnescape(seg)
+		if err != nil {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// GetArtists returns the album artists in a source, or in the whole library
+// when sourceID is empty. Kodi has no server-side source filter for artists,
+// so every page is fetched with each artist's source IDs and filtered here.
+//
+// Only album artists are listed: they are the artists with folders of their
+// own, which is what a Stillwater artist is. Featured and track artists stay
+// out, as they do in Kodi's own default artist view.
+func (c *Client) GetArtists(ctx context.Context, sourceID string) ([]Artist, error) {
+	want := 0
+	if sourceID != "" {
+		n, err := strconv.Atoi(sourceID)
+		if err != nil {
+			return nil, fmt.Errorf("source id %q is not numeric", sourceID)
+		}
+		want = n
+	}
+
+	var out []Artist
+	for page := 0; page < artistPageCap; page++ {
+		start := page * artistPageSize
+		params := map[string]any{
+			"albumartistsonly": true,
+			"properties":       listProperties,
+			"limits":           listLimits{Start: start, End: start + artistPageSize},
+		}
+		var res artistsResult
+		if err := c.call(ctx, "AudioLibrary.GetArtists", params, &res); err != nil {
+			return nil, fmt.Errorf("getting artists: %w", err)
+		}
+		for i := range res.Artists {
+			if want == 0 || res.Artists[i].InSource(want) {
+				out = append(out, res.Artists[i])
+			}
+		}
+		if len(res.Artists) < artistPageSize || start+len(res.Artists) >= res.Limits.Total {
+			return out, nil
+		}
+	}
+	return nil, fmt.Errorf("getting artists: more than %d pages", artistPageCap)
+}
+
+// GetArtist returns one artist by ID with the detail properties.
+func (c *Client) GetArtist(ctx context.Context, platformArtistID string) (*Artist, error) {
+	id, err := parseArtistID(platformArtistID)
+	if err != nil {
+		return nil, err
+	}
+	var res artistDetailsResult
+	params := map[string]any{"artistid": id, "properties": detailProperties}
+	if err := c.call(ctx, "AudioLibrary.GetArtistDetails", params, &res); err != nil {
+		return nil, fmt.Errorf("getting artist %s: %w", platformArtistID, err)
+	}
+	return &res.ArtistDetails, nil
+}
+
+// GetArtistDetail implements connection.ArtistStateGetter.
+//
+// Kodi keeps one image per art type, so BackdropCount is 1 when the artist
+// has fanart. Kodi has no field locks; IsLocked and LockedFields stay empty.
+func (c *Client) GetArtistDetail(ctx context.Context, platformArtistID string) (*connection.ArtistPlatformState, error) {
+	a, err := c.GetArtist(ctx, platformArtistID)
+	if err != nil {
+		return nil, err
+	}
+	premiere := a.Formed
+	if premiere == "" {
+		premiere = a.Born
+	}
+	end := a.Disbanded
+	if end == "" {
+		end = a.Died
+	}
+	state := &connection.ArtistPlatformState{
+		Name:          a.Name,
+		SortName:      a.SortName,
+		Biography:     a.Description,
+		Genres:        a.Genres,
+		PremiereDate:  premiere,
+		EndDate:       end,
+		MusicBrainzID: a.MusicBrainzID(),
+		HasThumb:      a.Art["thumb"] != "",
+		HasFanart:     a.Art["fanart"] != "",
+		HasLogo:       a.Art["clearlogo"] != "",
+		HasBanner:     a.Art["banner"] != "",
+	}
+	if state.HasFanart {
+		state.BackdropCount = 1
+	}
+	return state, nil
+}
+
+// ScanDirectory asks Kodi to scan one folder into the music library, which is
+// how it re-reads an artist folder after Stillwater rewrote the files in it.
+// Kodi requires a trailing separator on a directory path. The call returns as
+// soon as the scan is queued. An empty directory is refused rather than
+// passed through, because Kodi treats a missing directory as "scan
+// everything".
+func (c *Client) ScanDirectory(ctx context.Context, directory string) error {
+	if strings.TrimSpace(directory) == "" {
+		return fmt.Errorf("scan directory is required")
+	}
+	if !strings.HasSuffix(directory, "/") {
+		directory += "/"
+	}
+	params := map[string]any{"directory": directory, "showdialogs": false}
+	if err := c.call(ctx, "AudioLibrary.Scan", params, nil); err != nil {
+		return fmt.Errorf("scanning %s: %w", directory, err)
+	}
+	return nil
+}
+
+// ScanLibrary asks Kodi to scan every music source. The scan is incremental:
+// Kodi skips folders whose contents have not changed since the last one.
+func (c *Client) ScanLibrary(ctx context.Context) error {
+	if err := c.call(ctx, "AudioLibrary.Scan", map[string]any{"showdialogs": false}, nil); err != nil {
+		return fmt.Errorf("scanning library: %w", err)
+	}
+	return nil
+}
+
+// Clean asks Kodi to drop library entries whose files are gone.
+func (c *Client) Clean(ctx context.Context) error {
+	if err := c.call(ctx, "AudioLibrary.Clean", map[string]any{"showdialogs": false}, nil); err != nil {
+		return fmt.Errorf("cleaning library: %w", err)
+	}
+	return nil
+}
+
+// parseArtistID converts a stored platform artist ID back to Kodi's integer
+// artistid.
+func parseArtistID(platformArtistID string) (int, error) {
+	id, err := strconv.Atoi(strings.TrimSpace(platformArtistID))
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("kodi artist id %q is not a positive integer", platformArtistID)
+	}
+	return id, nil
+}
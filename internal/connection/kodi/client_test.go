@@ -0,0 +1,254 @@
+package kodi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+}
+
+// rpcCall is one JSON-RPC request the fake server received.
+type rpcCall struct {
+	Method string         `json:"method"`
+	Params map[string]any `json:"params"`
+}
+
+// rpcServer is a fake Kodi web server. handle returns the result (encoded as
+// the "result" member) for each call, or an *APIError to answer with an error
+// object. Every call is recorded.
+type rpcServer struct {
+	*httptest.Server
+	mu    sync.Mutex
+	calls []rpcCall
+}
+
+func newRPCServer(t *testing.T, handle func(call rpcCall) any) *rpcServer {
+	t.Helper()
+	s := &rpcServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jsonrpc" || r.Method != http.MethodPost {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "kodi" || pass != "se:cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var call rpcCall
+		if err := json.NewDecoder(r.Body).Decode(&call); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		s.mu.Lock()
+		s.calls = append(s.calls, call)
+		s.mu.Unlock()
+
+		out := map[string]any{"jsonrpc": "2.0", "id": 1}
+		switch res := handle(call).(type) {
+		case *APIError:
+			out["error"] = res
+		case nil:
+			out["result"] = "OK"
+		default:
+			out["result"] = res
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(out)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *rpcServer) client(credentials string) *Client {
+	return NewWithHTTPClient(s.URL, credentials, s.Client(), testLogger())
+}
+
+func (s *rpcServer) recorded() []rpcCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]rpcCall(nil), s.calls...)
+}
+
+func TestSplitCredentials(t *testing.T) {
+	tests := []struct {
+		in, user, pass string
+	}{
+		{"kodi:secret", "kodi", "secret"},
+		{"kodi:se:cret", "kodi", "se:cret"},
+		{"kodi", "kodi", ""},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		user, pass := SplitCredentials(tt.in)
+		if user != tt.user || pass != tt.pass {
+			t.Errorf("SplitCredentials(%q) = %q, %q; want %q, %q", tt.in, user, pass, tt.user, tt.pass)
+		}
+	}
+}
+
+func TestTestConnection(t *testing.T) {
+	srv := newRPCServer(t, func(call rpcCall) any {
+		if call.Method != "JSONRPC.Version" {
+			t.Errorf("method = %q", call.Method)
+		}
+		return map[string]any{"version": map[string]int{"major": 13, "minor": 5, "patch": 0}}
+	})
+	if err := srv.client("kodi:se:cret").TestConnection(context.Background()); err != nil {
+		t.Fatalf("TestConnection: %v", err)
+	}
+}
+
+func TestTestConnection_BadCredentials(t *testing.T) {
+	srv := newRPCServer(t, func(rpcCall) any { return nil })
+	err := srv.client("kodi:wrong").TestConnection(context.Background())
+	if !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("err = %v, want ErrAuthRequired", err)
+	}
+}
+
+func TestCall_RPCErrorSurfaces(t *testing.T) {
+	srv := newRPCServer(t, func(rpcCall) any { return &APIError{Code: -32602, Message: "Invalid params."} })
+	err := srv.client("kodi:se:cret").ScanLibrary(context.Background())
+	if err == nil || !containsAll(err.Error(), "AudioLibrary.Scan", "-32602", "Invalid params.") {
+		t.Fatalf("err = %v, want the method, code and message", err)
+	}
+}
+
+func TestGetSources_Paths(t *testing.T) {
+	srv := newRPCServer(t, func(rpcCall) any {
+		return map[string]any{"sources": []map[string]any{
+			{"sourceid": 1, "label": "Music", "file": "smb://nas/music/"},
+			{"sourceid": 2, "label": "Both", "file": "multipath://smb%3a%2f%2fnas%2fa%2f/nfs%3a%2f%2fnas%2fb%2f/"},
+		}}
+	})
+	sources, err := srv.client("kodi:se:cret").GetSources(context.Background())
+	if err != nil {
+		t.Fatalf("GetSources: %v", err)
+	}
+	if len(sources) != 2 || sources[0].Label != "Music" {
+		t.Fatalf("sources = %+v", sources)
+	}
+	if got := sources[0].Paths(); !reflect.DeepEqual(got, []string{"smb://nas/music/"}) {
+		t.Errorf("single paths = %v", got)
+	}
+	if got := sources[1].Paths(); !reflect.DeepEqual(got, []string{"smb://nas/a/", "nfs://nas/b/"}) {
+		t.Errorf("multipath paths = %v", got)
+	}
+}
+
+// TestGetArtists_FiltersBySource covers the client-side source filter, the
+// album-artist restriction and both MBID encodings Kodi uses.
+func TestGetArtists_FiltersBySource(t *testing.T) {
+	srv := newRPCServer(t, func(call rpcCall) any {
+		if call.Params["albumartistsonly"] != true {
+			t.Errorf("albumartistsonly = %v, want true", call.Params["albumartistsonly"])
+		}
+		return map[string]any{
+			"artists": []map[string]any{
+				{"artistid": 1, "artist": "A", "musicbrainzartistid": []string{"mbid-a"}, "sourceid": []int{1}},
+				{"artistid": 2, "artist": "B", "musicbrainzartistid": "mbid-b", "sourceid": []int{2}},
+				{"artistid": 3, "artist": "C", "sourceid": []int{1, 2}},
+			},
+			"limits": map[string]int{"start": 0, "end": 3, "total": 3},
+		}
+	})
+	c := srv.client("kodi:se:cret")
+
+	got, err := c.GetArtists(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("GetArtists: %v", err)
+	}
+	if len(got) != 2 || got[0].ArtistID != 1 || got[1].ArtistID != 3 {
+		t.Fatalf("source 1 artists = %+v, want ids 1 and 3", got)
+	}
+	if got[0].MusicBrainzID() != "mbid-a" || got[1].MusicBrainzID() != "" {
+		t.Errorf("mbids = %q, %q", got[0].MusicBrainzID(), got[1].MusicBrainzID())
+	}
+
+	all, err := c.GetArtists(context.Background(), "")
+	if err != nil {
+		t.Fatalf("GetArtists(all): %v", err)
+	}
+	if len(all) != 3 || all[1].MusicBrainzID() != "mbid-b" {
+		t.Errorf("all artists = %+v", all)
+	}
+
+	if _, err := c.GetArtists(context.Background(), "music"); err == nil {
+		t.Error("expected an error for a non-numeric source id")
+	}
+}
+
+func TestGetArtistDetail(t *testing.T) {
+	srv := newRPCServer(t, func(call rpcCall) any {
+		if call.Params["artistid"] != float64(7) {
+			t.Errorf("artistid = %v", call.Params["artistid"])
+		}
+		return map[string]any{"artistdetails": map[string]any{
+			"artistid": 7, "artist": "Portishead", "sortname": "Portishead",
+			"description": "Bristol trio.", "genre": []string{"Trip Hop"},
+			"born": "", "formed": "1991", "died": "", "disbanded": "",
+			"musicbrainzartistid": []string{"8f6bd1e4-fbe1-4f50-aa9b-94c450ec0f11"},
+			"art":                 map[string]string{"thumb": "image://x/", "fanart": "image://y/"},
+		}}
+	})
+	state, err := srv.client("kodi:se:cret").GetArtistDetail(context.Background(), "7")
+	if err != nil {
+		t.Fatalf("GetArtistDetail: %v", err)
+	}
+	if state.Name != "Portishead" || state.Biography != "Bristol trio." || state.PremiereDate != "1991" {
+		t.Errorf("state = %+v", state)
+	}
+	if !state.HasThumb || !state.HasFanart || state.HasLogo || state.HasBanner || state.BackdropCount != 1 {
+		t.Errorf("image flags = %+v", state)
+	}
+	if state.MusicBrainzID != "8f6bd1e4-fbe1-4f50-aa9b-94c450ec0f11" {
+		t.Errorf("mbid = %q", state.MusicBrainzID)
+	}
+}
+
+func TestScanDirectory(t *testing.T) {
+	srv := newRPCServer(t, func(rpcCall) any { return nil })
+	c := srv.client("kodi:se:cret")
+	if err := c.ScanDirectory(context.Background(), "smb://nas/music/Portishead"); err != nil {
+		t.Fatalf("ScanDirectory: %v", err)
+	}
+	if err := c.ScanDirectory(context.Background(), " "); err == nil {
+		t.Error("expected an empty directory to be refused")
+	}
+	calls := srv.recorded()
+	if len(calls) != 1 {
+		t.Fatalf("calls = %d, want 1 (the empty directory must not reach Kodi)", len(calls))
+	}
+	if calls[0].Method != "AudioLibrary.Scan" || calls[0].Params["directory"] != "smb://nas/music/Portishead/" {
+		t.Errorf("call = %+v, want a scan of the folder with a trailing slash", calls[0])
+	}
+}
+
+func TestParseArtistID(t *testing.T) {
+	for _, in := range []string{"", "abc", "0", "-3"} {
+		if _, err := parseArtistID(in); err == nil {
+			t.Errorf("parseArtistID(%q): expected error", in)
+		}
+	}
+	if id, err := parseArtistID(" 12 "); err != nil || id != 12 {
+		t.Errorf("parseArtistID(12) = %d, %v", id, err)
+	}
+}
+
+func containsAll(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if !strings.Contains(s, sub) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,161 @@
+package kodi
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sydlexius/stillwater/internal/connection"
+)
+
+// PushMetadata writes the artist's fields to Kodi with
+// AudioLibrary.SetArtistDetails.
+//
+// Kodi stores every field Stillwater curates, dates and disambiguation
+// included, so unlike the Emby and Jellyfin pushes nothing is left for an NFO
+// re-import to carry. Text, date and tag fields are written through even when
+// empty so a clear in Stillwater propagates; the name and sort name are not,
+// because Kodi keys its artist view on them, and the MusicBrainz ID is not,
+// because an ID Kodi read from the file tags is worth keeping when Stillwater
+// has none.
+//
+// Kodi has no field locks. A later scan that re-reads artist.nfo (or an
+// online scraper, if the operator enabled one) can overwrite what this wrote;
+// Stillwater writes the same values into artist.nfo, so a re-read restores
+// them rather than losing them.
+func (c *Client) PushMetadata(ctx context.Context, platformArtistID string, data connection.ArtistPushData) error {
+	id, err := parseArtistID(platformArtistID)
+	if err != nil {
+		return err
+	}
+	params := map[string]any{
+		"artistid":       id,
+		"description":    data.Biography,
+		"genre":          nonNil(data.Genres),
+		"style":          nonNil(data.Styles),
+		"mood":           nonNil(data.Moods),
+		"born":           data.Born,
+		"formed":         data.Formed,
+		"died":           data.Died,
+		"disbanded":      data.Disbanded,
+		"disambiguation": data.Disambiguation,
+		"yearsactive":    splitYearsActive(data.YearsActive),
+	}
+	if data.Name != "" {
+		params["artist"] = data.Name
+	}
+	if data.SortName != "" {
+		params["sortname"] = data.SortName
+	}
+	if data.MusicBrainzID != "" {
+		params["musicbrainzartistid"] = []string{data.MusicBrainzID}
+	}
+	if err := c.call(ctx, "AudioLibrary.SetArtistDetails", params, nil); err != nil {
+		return fmt.Errorf("pushing metadata: %w", err)
+	}
+	return nil
+}
+
+// LinkImage implements connection.ImageLinker: it points the artist's art
+// slot at an image file in the artist folder. Kodi loads artwork from a path
+// or URL it can read itself and takes no uploaded bytes, so this is the Kodi
+// form of an image write. platformPath must be the file as Kodi addresses it
+// (the publisher maps the host path through the connection's path mappings).
+//
+// Backdrops map to Kodi's extra fanart slots: index 0 is "fanart", index n is
+// "fanart<n>". Image types Kodi has no artist slot for are skipped without
+// error.
+func (c *Client) LinkImage(ctx context.Context, platformArtistID, imageType string, index int, platformPath string) error {
+	slot := artSlot(imageType, index)
+	if slot == "" {
+		c.Logger.Debug("kodi: no artist art slot for image type, skipping",
+			"artist_id", platformArtistID, "type", imageType, "index", index)
+		return nil
+	}
+	id, err := parseArtistID(platformArtistID)
+	if err != nil {
+		return err
+	}
+	params := map[string]any{
+		"artistid": id,
+		"art":      map[string]string{slot: platformPath},
+	}
+	if err := c.call(ctx, "AudioLibrary.SetArtistDetails", params, nil); err != nil {
+		return fmt.Errorf("linking %s image: %w", slot, err)
+	}
+	return nil
+}
+
+// DeleteImage implements connection.ImageDeleter: it clears the artist's art
+// slot for imageType. A null value is how SetArtistDetails removes an art
+// entry; an empty string would leave a blank one behind. Types Kodi has no
+// slot for are skipped without error.
+func (c *Client) DeleteImage(ctx context.Context, platformArtistID, imageType string) error {
+	slot := artSlot(imageType, 0)
+	if slot == "" {
+		return nil
+	}
+	id, err := parseArtistID(platformArtistID)
+	if err != nil {
+		return err
+	}
+	params := map[string]any{
+		"artistid": id,
+		"art":      map[string]any{slot: nil},
+	}
+	if err := c.call(ctx, "AudioLibrary.SetArtistDetails", params, nil); err != nil {
+		return fmt.Errorf("clearing %s image: %w", slot, err)
+	}
+	return nil
+}
+
+// artSlot maps a Stillwater image type and index to Kodi's artist art type,
+// or "" when Kodi has no slot for it.
+func artSlot(imageType string, index int) string {
+	switch imageType {
+	case "thumb":
+		if index == 0 {
+			return "thumb"
+		}
+	case "fanart":
+		if index == 0 {
+			return "fanart"
+		}
+		if index > 0 {
+			return "fanart" + strconv.Itoa(index)
+		}
+	case "logo":
+		if index == 0 {
+			return "clearlogo"
+		}
+	case "banner":
+		if index == 0 {
+			return "banner"
+		}
+	}
+	return ""
+}
+
+// splitYearsActive turns Stillwater's single years-active string into Kodi's
+// list, splitting on the commas a multi-period value carries
+// ("1967-1973, 1977-1987"). Empty yields an empty list, which clears the
+// field.
+func splitYearsActive(s string) []string {
+	out := []string{}
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// nonNil returns in, or an empty list when in is nil, so the field encodes as
+// [] (clear) rather than null (which Kodi reads as "leave unchanged").
+func nonNil(in []string) []string {
+	if in == nil {
+		return []string{}
+	}
+	return in
+}
@@ -0,0 +1,102 @@
+package kodi
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/connection"
+)
+
+func TestPushMetadata_WritesFieldsAndClears(t *testing.T) {
+	srv := newRPCServer(t, func(rpcCall) any { return nil })
+	err := srv.client("kodi:se:cret").PushMetadata(context.Background(), "7", connection.ArtistPushData{
+		Name:          "Portishead",
+		Biography:     "Bristol trio.",
+		Genres:        []string{"Trip Hop"},
+		YearsActive:   "1991-1999, 2005-present",
+		MusicBrainzID: "8f6bd1e4-fbe1-4f50-aa9b-94c450ec0f11",
+	})
+	if err != nil {
+		t.Fatalf("PushMetadata: %v", err)
+	}
+	calls := srv.recorded()
+	if len(calls) != 1 || calls[0].Method != "AudioLibrary.SetArtistDetails" {
+		t.Fatalf("calls = %+v", calls)
+	}
+	p := calls[0].Params
+	if p["artistid"] != float64(7) || p["artist"] != "Portishead" || p["description"] != "Bristol trio." {
+		t.Errorf("params = %v", p)
+	}
+	if got := p["yearsactive"]; !reflect.DeepEqual(got, []any{"1991-1999", "2005-present"}) {
+		t.Errorf("yearsactive = %v", got)
+	}
+	if got := p["style"]; !reflect.DeepEqual(got, []any{}) {
+		t.Errorf("style = %#v, want an empty list so the field is cleared", got)
+	}
+	if got := p["musicbrainzartistid"]; !reflect.DeepEqual(got, []any{"8f6bd1e4-fbe1-4f50-aa9b-94c450ec0f11"}) {
+		t.Errorf("musicbrainzartistid = %v", got)
+	}
+	if _, ok := p["sortname"]; ok {
+		t.Error("an empty sort name must not be sent")
+	}
+}
+
+func TestArtSlot(t *testing.T) {
+	tests := []struct {
+		imageType string
+		index     int
+		want      string
+	}{
+		{"thumb", 0, "thumb"},
+		{"fanart", 0, "fanart"},
+		{"fanart", 2, "fanart2"},
+		{"logo", 0, "clearlogo"},
+		{"banner", 0, "banner"},
+		{"thumb", 1, ""},
+		{"fanart", -1, ""},
+		{"clearart", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := artSlot(tt.imageType, tt.index); got != tt.want {
+			t.Errorf("artSlot(%q, %d) = %q, want %q", tt.imageType, tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestLinkImage_SetsArtSlot(t *testing.T) {
+	srv := newRPCServer(t, func(rpcCall) any { return nil })
+	c := srv.client("kodi:se:cret")
+	if err := c.LinkImage(context.Background(), "7", "fanart", 1, "smb://nas/music/P/backdrop2.jpg"); err != nil {
+		t.Fatalf("LinkImage: %v", err)
+	}
+	if err := c.LinkImage(context.Background(), "7", "clearart", 0, "smb://nas/music/P/clearart.png"); err != nil {
+		t.Fatalf("LinkImage(no slot): %v", err)
+	}
+	calls := srv.recorded()
+	if len(calls) != 1 {
+		t.Fatalf("calls = %d, want 1 (a type with no slot is skipped)", len(calls))
+	}
+	want := map[string]any{"fanart1": "smb://nas/music/P/backdrop2.jpg"}
+	if got := calls[0].Params["art"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("art = %v, want %v", got, want)
+	}
+}
+
+func TestDeleteImage_ClearsWithNull(t *testing.T) {
+	srv := newRPCServer(t, func(rpcCall) any { return nil })
+	if err := srv.client("kodi:se:cret").DeleteImage(context.Background(), "7", "logo"); err != nil {
+		t.Fatalf("DeleteImage: %v", err)
+	}
+	calls := srv.recorded()
+	if len(calls) != 1 {
+		t.Fatalf("calls = %d, want 1", len(calls))
+	}
+	art, ok := calls[0].Params["art"].(map[string]any)
+	if !ok {
+		t.Fatalf("art = %#v", calls[0].Params["art"])
+	}
+	if v, present := art["clearlogo"]; !present || v != nil {
+		t.Errorf("clearlogo = %#v (present %v), want an explicit null", v, present)
+	}
+}
@@ -0,0 +1,150 @@
+package kodi
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Kodi speaks JSON-RPC 2.0 over HTTP POST /jsonrpc. The types below model only
+// the members Stillwater sends and reads.
+
+// rpcRequest is one JSON-RPC call. Params is omitted for methods without any.
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+	ID      int    `json:"id"`
+}
+
+// rpcResponse is the reply to one call: exactly one of Result and Error is
+// set. Result stays raw so each method decodes its own shape.
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *APIError       `json:"error"`
+}
+
+// APIError is a JSON-RPC error object. Kodi answers HTTP 200 for a failed
+// call, so the code here is the only signal: -32602 for invalid parameters
+// (an unknown artist ID lands here), -32100 when the method failed to run.
+type APIError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Version is the JSON-RPC API version Kodi reports. Major 12 is Kodi 19
+// (Matrix), the first release whose artist records carry sourceid.
+type Version struct {
+	Major int `json:"major"`
+	Minor int `json:"minor"`
+	Patch int `json:"patch"`
+}
+
+// Source is a music source from AudioLibrary.GetSources: a named root the
+// operator added in Kodi's music library. File is the source path as Kodi
+// addresses it; a source with several folders reports them as one
+// multipath:// URL (see Source.Paths).
+type Source struct {
+	SourceID int    `json:"sourceid"`
+	Label    string `json:"label"`
+	File     string `json:"file"`
+}
+
+// StringList is a Kodi field that older API versions return as a string and
+// newer ones as an array (musicbrainzartistid became an array in Kodi 18).
+// It accepts either form; an empty string decodes to an empty list.
+type StringList []string
+
+// UnmarshalJSON accepts a JSON string or an array of strings.
+func (l *StringList) UnmarshalJSON(b []byte) error {
+	if bytes.HasPrefix(b, []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		if s == "" {
+			*l = nil
+		} else {
+			*l = StringList{s}
+		}
+		return nil
+	}
+	var arr []string
+	if err := json.Unmarshal(b, &arr); err != nil {
+		return err
+	}
+	*l = arr
+	return nil
+}
+
+// First returns the first non-empty entry, or "".
+func (l StringList) First() string {
+	for _, s := range l {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// Artist is an artist record from AudioLibrary.GetArtists or
+// GetArtistDetails. Only the properties the call asked for are populated.
+type Artist struct {
+	ArtistID    int               `json:"artistid"`
+	Name        string            `json:"artist"`
+	SortName    string            `json:"sortname"`
+	Description string            `json:"description"`
+	Genres      []string          `json:"genre"`
+	Styles      []string          `json:"style"`
+	Moods       []string          `json:"mood"`
+	Born        string            `json:"born"`
+	Formed      string            `json:"formed"`
+	Died        string            `json:"died"`
+	Disbanded   string            `json:"disbanded"`
+	MBIDs       StringList        `json:"musicbrainzartistid"`
+	Art         map[string]string `json:"art"`
+	SourceIDs   []int             `json:"sourceid"`
+}
+
+// MusicBrainzID returns the artist's MusicBrainz ID, or "" when Kodi has none
+// (the files carry no MusicBrainz tags).
+func (a *Artist) MusicBrainzID() string {
+	return a.MBIDs.First()
+}
+
+// InSource reports whether any of the artist's songs live under sourceID.
+func (a *Artist) InSource(sourceID int) bool {
+	for _, id := range a.SourceIDs {
+		if id == sourceID {
+			return true
+		}
+	}
+	return false
+}
+
+// listLimits is the paging window of a list call; End is exclusive.
+type listLimits struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+	Total int `json:"total,omitempty"`
+}
+
+// artistsResult is the result of AudioLibrary.GetArtists.
+type artistsResult struct {
+	Artists []Artist   `json:"artists"`
+	Limits  listLimits `json:"limits"`
+}
+
+// artistDetailsResult is the result of AudioLibrary.GetArtistDetails.
+type artistDetailsResult struct {
+	ArtistDetails Artist `json:"artistdetails"`
+}
+
+// sourcesResult is the result of AudioLibrary.GetSources.
+type sourcesResult struct {
+	Sources []Source `json:"sources"`
+}
+
+// versionResult is the result of JSONRPC.Version.
+type versionResult struct {
+	Version Version `json:"version"`
+}
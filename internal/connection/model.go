@@ -13,6 +13,9 @@ const (
 	TypeEmby     = "emby"
 	TypeJellyfin = "jellyfin"
 	TypeLidarr   = "lidarr"
+	TypePlex     = "plex"
+	TypeSubsonic = "subsonic"
+	TypeKodi     = "kodi"
 )
 
 // LidarrConfig holds the fields that are only meaningful for a Lidarr
@@ -77,9 +80,46 @@ type JellyfinConfig struct {
 	FeatureTriggerRefresh bool   `json:"feature_trigger_refresh,omitempty"`
 }
 
+// PlexConfig holds the Plex-only fields. Plex has no per-user item view the
+// way Emby and Jellyfin do (the X-Plex-Token already scopes every request to
+// its account), so there is no PlatformUserID; PlatformServerID carries the
+// server's machineIdentifier from /identity, which Plex Web deep links need in
+// their #!/server/<id>/details URL. FeatureTriggerRefresh is carried so the
+// three toggles round-trip like the other media servers, but nothing acts on
+// it: the refresh it gates is an NFO re-import, and Plex reads no NFO.
+type PlexConfig struct {
+	PlatformServerID      string `json:"platform_server_id,omitempty"`
+	FeatureImageWrite     bool   `json:"feature_image_write,omitempty"`
+	FeatureMetadataPush   bool   `json:"feature_metadata_push,omitempty"`
+	FeatureTriggerRefresh bool   `json:"feature_trigger_refresh,omitempty"`
+}
+
+// SubsonicConfig holds the Subsonic-only fields. A Subsonic server (Navidrome,
+// gonic, Airsonic) exposes no metadata or image write API: it reads artwork
+// from the artist folder on its next scan. The one write Stillwater can make is
+// to start that scan, so FeatureTriggerRefresh is the only toggle, and it gates
+// the startScan Stillwater issues after writing artwork. The credentials
+// ("username:password") live in Connection.APIKey like every other peer's
+// secret.
+type SubsonicConfig struct {
+	FeatureTriggerRefresh bool `json:"feature_trigger_refresh,omitempty"`
+}
+
+// KodiConfig holds the Kodi-only fields. Kodi has no server identity or user
+// to resolve (the web server's basic-auth pair, stored as "username:password"
+// in Connection.APIKey, is the whole identity), so only the three write
+// toggles live here. Image write points Kodi's art slots at the files
+// Stillwater saved rather than uploading bytes, and trigger refresh scans the
+// artist's folder after Stillwater rewrote its NFO.
+type KodiConfig struct {
+	FeatureImageWrite     bool `json:"feature_image_write,omitempty"`
+	FeatureMetadataPush   bool `json:"feature_metadata_push,omitempty"`
+	FeatureTriggerRefresh bool `json:"feature_trigger_refresh,omitempty"`
+}
+
 // Connection represents an external service connection. Platform-specific
-// state lives on exactly one of the Lidarr/Emby/Jellyfin sub-configs (the one
-// matching Type); Validate enforces that invariant and lazily allocates the
+// state lives on exactly one of the Lidarr/Emby/Jellyfin/Plex/Subsonic/Kodi
+// sub-configs (the one matching Type); Validate enforces that invariant and lazily allocates the
 // matching empty config when a caller leaves it nil. Persistence still uses
 // the original flat columns (see service.go scanConnection / Create / Update):
 // the sub-structs are purely the in-memory representation, so no schema
@@ -123,11 +163,14 @@ type Connection struct {
 	// thing. Persisted in the existing connections.path_mappings column for
 	// every type, so promoting it needs no schema migration.
 	PathMappings []PathMapping `json:"path_mappings,omitempty"`
-	// Lidarr/Emby/Jellyfin hold the platform-specific config. Exactly one is
-	// non-nil after Validate, corresponding to Type.
+	// Lidarr/Emby/Jellyfin/Plex/Subsonic/Kodi hold the platform-specific
+	// config. Exactly one is non-nil after Validate, corresponding to Type.
 	Lidarr   *LidarrConfig   `json:"lidarr,omitempty"`
 	Emby     *EmbyConfig     `json:"emby,omitempty"`
 	Jellyfin *JellyfinConfig `json:"jellyfin,omitempty"`
+	Plex     *PlexConfig     `json:"plex,omitempty"`
+	Subsonic *SubsonicConfig `json:"subsonic,omitempty"`
+	Kodi     *KodiConfig     `json:"kodi,omitempty"`
 }
 
 // GetPlatformUserID returns the resolved platform user ID for an Emby or
@@ -144,14 +187,16 @@ func (c *Connection) GetPlatformUserID() string {
 	}
 }
 
-// GetPlatformServerID returns the resolved platform server ID for an Emby or
-// Jellyfin connection, or "" otherwise. Nil-safe.
+// GetPlatformServerID returns the resolved platform server ID for an Emby,
+// Jellyfin or Plex connection, or "" otherwise. Nil-safe.
 func (c *Connection) GetPlatformServerID() string {
 	switch {
 	case c.Emby != nil:
 		return c.Emby.PlatformServerID
 	case c.Jellyfin != nil:
 		return c.Jellyfin.PlatformServerID
+	case c.Plex != nil:
+		return c.Plex.PlatformServerID
 	default:
 		return ""
 	}
@@ -231,6 +276,38 @@ func (c *Connection) MapArtistPath(hostPath string) string {
 	return mapped
 }
 
+// UnmapArtistPath is the inverse of MapArtistPath: it translates a path the
+// platform reports (an artist folder as the peer's container sees it) back
+// into Stillwater's host namespace, using the mapping whose PlatformPrefix is
+// the longest separator-bounded prefix of platformPath. When no mapping
+// matches, the path is returned POSIX-folded but otherwise unchanged, which is
+// the right answer for a shared-mount deployment. Used by the peers that
+// identify artists by folder (Plex reports a Location per artist) so the
+// reported path can be matched against artists.path. Nil-safe.
+func (c *Connection) UnmapArtistPath(platformPath string) string {
+	if c == nil || platformPath == "" {
+		return platformPath
+	}
+	posixPlatform := toPosixPath(platformPath)
+	bestLen := -1
+	unmapped := posixPlatform
+	for _, m := range c.PathMappings {
+		plat := strings.TrimRight(toPosixPath(m.PlatformPrefix), "/")
+		if plat == "" {
+			continue
+		}
+		remainder, ok := pathRemainder(posixPlatform, plat)
+		if !ok {
+			continue
+		}
+		if len(plat) > bestLen {
+			bestLen = len(plat)
+			unmapped = strings.TrimRight(toPosixPath(m.HostPrefix), "/") + remainder
+		}
+	}
+	return unmapped
+}
+
 // toPosixPath folds Windows backslash separators to forward slashes. It is the
 // single normalization both halves of the push path-check share: MapArtistPath
 // (which produces the path) and connection.normalizeRootPath (which the root
@@ -293,19 +370,27 @@ func DecodePathMappings(s string) ([]PathMapping, error) {
 // discard behavior #2579 fixed.
 //
 // The toggles are stored in three shared connections columns but only mapped
-// onto the Emby/Jellyfin sub-configs on read (see Service.scanConnection), so a
+// onto the Emby/Jellyfin/Plex/Kodi sub-configs on read (see Service.scanConnection), so a
 // value written for any other type persists in the column and is invisible to
 // every Get* accessor below. Callers that accept a toggle from an operator MUST
 // consult this before writing, or they store state nothing can read back.
 func SupportsFeatureToggles(connType string) bool {
 	switch connType {
-	case TypeEmby, TypeJellyfin:
+	case TypeEmby, TypeJellyfin, TypePlex, TypeKodi:
 		return true
 	default:
 		return false
 	}
 }
 
+// SupportsTriggerRefresh reports whether connType has the trigger-refresh
+// toggle. Every type with the full set of three has it; Subsonic has it
+// alone, because starting a scan is the only write a Subsonic server accepts.
+// Same positive allow-list rule as SupportsFeatureToggles.
+func SupportsTriggerRefresh(connType string) bool {
+	return SupportsFeatureToggles(connType) || connType == TypeSubsonic
+}
+
 // GetFeatureImageWrite reports the image-write toggle. Nil-safe.
 func (c *Connection) GetFeatureImageWrite() bool {
 	switch {
@@ -313,6 +398,10 @@ func (c *Connection) GetFeatureImageWrite() bool {
 		return c.Emby.FeatureImageWrite
 	case c.Jellyfin != nil:
 		return c.Jellyfin.FeatureImageWrite
+	case c.Plex != nil:
+		return c.Plex.FeatureImageWrite
+	case c.Kodi != nil:
+		return c.Kodi.FeatureImageWrite
 	default:
 		return false
 	}
@@ -325,6 +414,10 @@ func (c *Connection) GetFeatureMetadataPush() bool {
 		return c.Emby.FeatureMetadataPush
 	case c.Jellyfin != nil:
 		return c.Jellyfin.FeatureMetadataPush
+	case c.Plex != nil:
+		return c.Plex.FeatureMetadataPush
+	case c.Kodi != nil:
+		return c.Kodi.FeatureMetadataPush
 	default:
 		return false
 	}
@@ -337,6 +430,12 @@ func (c *Connection) GetFeatureTriggerRefresh() bool {
 		return c.Emby.FeatureTriggerRefresh
 	case c.Jellyfin != nil:
 		return c.Jellyfin.FeatureTriggerRefresh
+	case c.Plex != nil:
+		return c.Plex.FeatureTriggerRefresh
+	case c.Subsonic != nil:
+		return c.Subsonic.FeatureTriggerRefresh
+	case c.Kodi != nil:
+		return c.Kodi.FeatureTriggerRefresh
 	default:
 		return false
 	}
@@ -374,14 +473,20 @@ func (c *Connection) SetPlatformServerID(id string) {
 			c.Jellyfin = &JellyfinConfig{}
 		}
 		c.Jellyfin.PlatformServerID = id
+	case TypePlex:
+		if c.Plex == nil {
+			c.Plex = &PlexConfig{}
+		}
+		c.Plex.PlatformServerID = id
 	}
 }
 
-// SetFeatures writes the Emby/Jellyfin write-feature toggles onto the matching
-// media sub-config, allocating it if nil. No-op for Lidarr (which has no such
-// features). Mirrors Service.UpdateFeatures' parameter order so callers holding
-// an in-memory Connection (e.g. the update handler) set features the same way
-// the targeted DB updater does.
+// SetFeatures writes the Emby/Jellyfin/Plex/Kodi write-feature toggles onto
+// the matching media sub-config, allocating it if nil. No-op for Lidarr (which
+// has no such features); Subsonic takes triggerRefresh only. Mirrors
+// Service.UpdateFeatures' parameter order so callers holding an in-memory
+// Connection (e.g. the update handler) set features the same way the targeted
+// DB updater does.
 func (c *Connection) SetFeatures(imageWrite, metadataPush, triggerRefresh bool) {
 	switch c.Type {
 	case TypeEmby:
@@ -398,6 +503,25 @@ func (c *Connection) SetFeatures(imageWrite, metadataPush, triggerRefresh bool)
 		c.Jellyfin.FeatureImageWrite = imageWrite
 		c.Jellyfin.FeatureMetadataPush = metadataPush
 		c.Jellyfin.FeatureTriggerRefresh = triggerRefresh
+	case TypePlex:
+		if c.Plex == nil {
+			c.Plex = &PlexConfig{}
+		}
+		c.Plex.FeatureImageWrite = imageWrite
+		c.Plex.FeatureMetadataPush = metadataPush
+		c.Plex.FeatureTriggerRefresh = triggerRefresh
+	case TypeSubsonic:
+		if c.Subsonic == nil {
+			c.Subsonic = &SubsonicConfig{}
+		}
+		c.Subsonic.FeatureTriggerRefresh = triggerRefresh
+	case TypeKodi:
+		if c.Kodi == nil {
+			c.Kodi = &KodiConfig{}
+		}
+		c.Kodi.FeatureImageWrite = imageWrite
+		c.Kodi.FeatureMetadataPush = metadataPush
+		c.Kodi.FeatureTriggerRefresh = triggerRefresh
 	}
 }
 
@@ -519,7 +643,7 @@ func (c *Connection) Validate() error {
 		return fmt.Errorf("name is required")
 	}
 	if !isValidType(c.Type) {
-		return fmt.Errorf("type must be one of: emby, jellyfin, lidarr")
+		return fmt.Errorf("type must be one of: emby, jellyfin, lidarr, plex, subsonic, kodi")
 	}
 	cleaned, err := ValidateBaseURL(c.URL)
 	if err != nil {
@@ -536,7 +660,7 @@ func (c *Connection) Validate() error {
 }
 
 // normalizeConfig enforces the type-discriminated config invariant: exactly
-// one of Lidarr/Emby/Jellyfin is non-nil and corresponds to Type. A sub-config
+// one of Lidarr/Emby/Jellyfin/Plex/Subsonic/Kodi is non-nil and corresponds to Type. A sub-config
 // belonging to a different platform is rejected (that is the invalid state the
 // type system now makes loud); the matching config is lazily allocated when
 // the caller left it nil so construction sites that set no platform-specific
@@ -545,26 +669,47 @@ func (c *Connection) Validate() error {
 func (c *Connection) normalizeConfig() error {
 	switch c.Type {
 	case TypeLidarr:
-		if c.Emby != nil || c.Jellyfin != nil {
-			return fmt.Errorf("lidarr connection must not carry emby or jellyfin config")
+		if c.Emby != nil || c.Jellyfin != nil || c.Plex != nil || c.Subsonic != nil || c.Kodi != nil {
+			return fmt.Errorf("lidarr connection must not carry emby, jellyfin, plex, subsonic or kodi config")
 		}
 		if c.Lidarr == nil {
 			c.Lidarr = &LidarrConfig{}
 		}
 	case TypeEmby:
-		if c.Lidarr != nil || c.Jellyfin != nil {
-			return fmt.Errorf("emby connection must not carry lidarr or jellyfin config")
+		if c.Lidarr != nil || c.Jellyfin != nil || c.Plex != nil || c.Subsonic != nil || c.Kodi != nil {
+			return fmt.Errorf("emby connection must not carry lidarr, jellyfin, plex, subsonic or kodi config")
 		}
 		if c.Emby == nil {
 			c.Emby = &EmbyConfig{}
 		}
 	case TypeJellyfin:
-		if c.Lidarr != nil || c.Emby != nil {
-			return fmt.Errorf("jellyfin connection must not carry lidarr or emby config")
+		if c.Lidarr != nil || c.Emby != nil || c.Plex != nil || c.Subsonic != nil || c.Kodi != nil {
+			return fmt.Errorf("jellyfin connection must not carry lidarr, emby, plex, subsonic or kodi config")
 		}
 		if c.Jellyfin == nil {
 			c.Jellyfin = &JellyfinConfig{}
 		}
+	case TypePlex:
+		if c.Lidarr != nil || c.Emby != nil || c.Jellyfin != nil || c.Subsonic != nil || c.Kodi != nil {
+			return fmt.Errorf("plex connection must not carry lidarr, emby, jellyfin, subsonic or kodi config")
+		}
+		if c.Plex == nil {
+			c.Plex = &PlexConfig{}
+		}
+	case TypeSubsonic:
+		if c.Lidarr != nil || c.Emby != nil || c.Jellyfin != nil || c.Plex != nil || c.Kodi != nil {
+			return fmt.Errorf("subsonic connection must not carry lidarr, emby, jellyfin, plex or kodi config")
+		}
+		if c.Subsonic == nil {
+			c.Subsonic = &SubsonicConfig{}
+		}
+	case TypeKodi:
+		if c.Lidarr != nil || c.Emby != nil || c.Jellyfin != nil || c.Plex != nil || c.Subsonic != nil {
+			return fmt.Errorf("kodi connection must not carry lidarr, emby, jellyfin, plex or subsonic config")
+		}
+		if c.Kodi == nil {
+			c.Kodi = &KodiConfig{}
+		}
 	}
 	return nil
 }
@@ -573,10 +718,10 @@ func isValidType(t string) bool {
 	return IsValidType(t)
 }
 
-// IsValidType reports whether t is one of the three supported connection
-// types. Exported so an API handler can reject an unknown type at the request
+// IsValidType reports whether t is one of the supported connection types.
+// Exported so an API handler can reject an unknown type at the request
 // boundary, where it can still answer 400 with an accurate message, rather
 // than letting it reach Validate() deeper in the write path (#2975 review).
 func IsValidType(t string) bool {
-	return t == TypeEmby || t == TypeJellyfin || t == TypeLidarr
+	return t == TypeEmby || t == TypeJellyfin || t == TypeLidarr || t == TypePlex || t == TypeSubsonic || t == TypeKodi
 }
@@ -104,6 +104,36 @@ func TestMapArtistPath_NilSafe(t *testing.T) {
 	}
 }
 
+// TestUnmapArtistPath checks the platform-to-host direction: longest platform
+// prefix wins, the match is separator-bounded, and an unmapped path comes back
+// verbatim, so mapping a path and unmapping the result is the identity.
+func TestUnmapArtistPath(t *testing.T) {
+	c := &Connection{Type: TypePlex, PathMappings: []PathMapping{
+		{HostPrefix: "/music", PlatformPrefix: "/data"},
+		{HostPrefix: "/archive/jazz", PlatformPrefix: "/data/jazz"},
+	}}
+	cases := map[string]string{
+		"/data/Artist":      "/music/Artist",
+		"/data/jazz/Miles":  "/archive/jazz/Miles",
+		"/datastore/Artist": "/datastore/Artist",
+		"/elsewhere/Artist": "/elsewhere/Artist",
+		`\data\Artist`:      "/music/Artist",
+		"":                  "",
+	}
+	for in, want := range cases {
+		if got := c.UnmapArtistPath(in); got != want {
+			t.Errorf("UnmapArtistPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := c.UnmapArtistPath(c.MapArtistPath("/music/Artist")); got != "/music/Artist" {
+		t.Errorf("round trip = %q, want /music/Artist", got)
+	}
+	var nilConn *Connection
+	if got := nilConn.UnmapArtistPath("/data/Artist"); got != "/data/Artist" {
+		t.Errorf("nil connection: got %q, want verbatim", got)
+	}
+}
+
 // TestEncodeDecodePathMappings round-trips the JSON column encoding and pins the
 // empty-list <-> "" equivalence that keeps a verbatim connection's column at its
 // default.
@@ -0,0 +1,208 @@
+package plex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/connection/httpclient"
+)
+
+// ErrAuthRequired is the sentinel wrapped by write-method failures when the
+// peer returns a 401 or 403.
+var ErrAuthRequired = errors.New("plex: authentication required")
+
+// wrapAuthIfStatusAuth wraps 401/403 StatusError with ErrAuthRequired; see
+// emby.wrapAuthIfStatusAuth for rationale.
+func wrapAuthIfStatusAuth(err error) error {
+	if err == nil {
+		return nil
+	}
+	var se *httpclient.StatusError
+	if errors.As(err, &se) && se.IsAuth() {
+		return fmt.Errorf("%w: %w", ErrAuthRequired, err)
+	}
+	return err
+}
+
+// clientIdentifier is sent as X-Plex-Client-Identifier. Plex lists every
+// client that talks to the server under Settings > Devices keyed by this
+// value, so a fixed identifier keeps Stillwater to one entry there instead of
+// one per request.
+const clientIdentifier = "stillwater"
+
+// artistMetadataType is Plex's metadata type number for an artist. Section
+// listings and section-scoped edits take it as ?type=.
+const artistMetadataType = 8
+
+// mbidGUIDPrefix prefixes the MusicBrainz entry of an artist's Guid list.
+const mbidGUIDPrefix = "mbid://"
+
+// Client communicates with a Plex Media Server.
+type Client struct {
+	httpclient.BaseClient
+}
+
+// New creates a Plex client with default HTTP settings. apiKey is the
+// server's X-Plex-Token.
+//
+// Uses a raw http.Client (not httpsafe.SafeClient) because Plex Media Server
+// is a user-configured self-hosted service that typically runs on loopback
+// or an RFC 1918 LAN address (192.168.1.10:32400). The httpsafe.SafeTransport
+// SSRF guard would reject those destinations. The destination URL is
+// operator-supplied via Settings, not user-controlled input.
+func New(baseURL, apiKey string, logger *slog.Logger) *Client {
+	return NewWithHTTPClient(baseURL, apiKey, &http.Client{Timeout: 10 * time.Second}, logger)
+}
+
+// NewWithHTTPClient creates a Plex client with a custom HTTP client (for testing).
+func NewWithHTTPClient(baseURL, apiKey string, httpClient *http.Client, logger *slog.Logger) *Client {
+	c := &Client{
+		BaseClient: httpclient.NewBase(baseURL, apiKey, httpClient, logger, "plex"),
+	}
+	c.AuthFunc = c.setAuth
+	return c
+}
+
+// TestConnection verifies connectivity and the token by listing the library
+// sections. GET /identity would be cheaper but answers without a token, so it
+// cannot tell a wrong token from a right one.
+func (c *Client) TestConnection(ctx context.Context) error {
+	var resp sectionsResponse
+	if err := c.Get(ctx, "/library/sections", &resp); err != nil {
+		return fmt.Errorf("testing connection: %w", err)
+	}
+	c.Logger.Debug("plex connection ok", "sections", len(resp.MediaContainer.Directory))
+	return nil
+}
+
+// GetServerID returns the server's machineIdentifier from GET /identity. Plex
+// web deep links address an item as /web/index.html#!/server/<id>/details.
+// Used at connection-test time to resolve and persist the server ID in the
+// connections table.
+func (c *Client) GetServerID(ctx context.Context) (string, error) {
+	var id Identity
+	if err := c.Get(ctx, "/identity", &id); err != nil {
+		return "", fmt.Errorf("getting server identity: %w", err)
+	}
+	if id.MediaContainer.MachineIdentifier == "" {
+		return "", fmt.Errorf("identity did not return a machine identifier")
+	}
+	return id.MediaContainer.MachineIdentifier, nil
+}
+
+// GetMusicLibraries returns the server's music sections (type "artist").
+func (c *Client) GetMusicLibraries(ctx context.Context) ([]Section, error) {
+	var resp sectionsResponse
+	if err := c.Get(ctx, "/library/sections", &resp); err != nil {
+		return nil, fmt.Errorf("getting library sections: %w", err)
+	}
+	var music []Section
+	for _, s := range resp.MediaContainer.Directory {
+		if s.Type == "artist" {
+			music = append(music, s)
+		}
+	}
+	return music, nil
+}
+
+// GetArtists returns one page of a music section's artists, with their
+// external GUIDs, starting at start. The response's TotalSize is the
+// section's full artist count.
+func (c *Client) GetArtists(ctx context.Context, sectionKey string, start, size int) (*ArtistsResponse, error) {
+	q := url.Values{}
+	q.Set("type", strconv.Itoa(artistMetadataType))
+	q.Set("includeGuids", "1")
+	q.Set("X-Plex-Container-Start", strconv.Itoa(start))
+	q.Set("X-Plex-Container-Size", strconv.Itoa(size))
+	path := "/library/sections/" + url.PathEscape(sectionKey) + "/all?" + q.Encode()
+
+	var resp ArtistsResponse
+	if err := c.Get(ctx, path, &resp); err != nil {
+		return nil, fmt.Errorf("getting artists: %w", err)
+	}
+	return &resp, nil
+}
+
+// GetArtist returns one artist by rating key, including its section ID,
+// current tags and filesystem locations.
+func (c *Client) GetArtist(ctx context.Context, ratingKey string) (*Artist, error) {
+	if strings.TrimSpace(ratingKey) == "" {
+		return nil, fmt.Errorf("rating key is required")
+	}
+	var resp ArtistsResponse
+	path := "/library/metadata/" + url.PathEscape(ratingKey) + "?includeGuids=1"
+	if err := c.Get(ctx, path, &resp); err != nil {
+		return nil, fmt.Errorf("getting artist %s: %w", ratingKey, err)
+	}
+	if len(resp.MediaContainer.Metadata) == 0 {
+		return nil, fmt.Errorf("artist %s: empty metadata response", ratingKey)
+	}
+	return &resp.MediaContainer.Metadata[0], nil
+}
+
+// GetArtistImage downloads an artist's current poster ("thumb") or background
+// ("fanart"). Plex artists have no logo or banner.
+func (c *Client) GetArtistImage(ctx context.Context, artistID, imageType string) ([]byte, string, error) {
+	a, err := c.GetArtist(ctx, artistID)
+	if err != nil {
+		return nil, "", err
+	}
+	var path string
+	switch imageType {
+	case "thumb":
+		path = a.Thumb
+	case "fanart":
+		path = a.Art
+	default:
+		return nil, "", fmt.Errorf("unsupported image type: %s", imageType)
+	}
+	if path == "" {
+		return nil, "", fmt.Errorf("artist %s has no %s image", artistID, imageType)
+	}
+	return c.GetRaw(ctx, path)
+}
+
+// GetArtistBackdrop downloads an artist's background. Plex keeps a single
+// selected background per artist, so only index 0 exists.
+func (c *Client) GetArtistBackdrop(ctx context.Context, artistID string, index int) ([]byte, string, error) {
+	if index != 0 {
+		return nil, "", fmt.Errorf("plex artists have a single background; index %d does not exist", index)
+	}
+	return c.GetArtistImage(ctx, artistID, "fanart")
+}
+
+// MusicBrainzID returns the artist's MusicBrainz ID from its Guid list, or ""
+// when the agent did not match one.
+func (a *Artist) MusicBrainzID() string {
+	for _, g := range a.GUIDs {
+		if id, ok := strings.CutPrefix(g.ID, mbidGUIDPrefix); ok {
+			return id
+		}
+	}
+	return ""
+}
+
+// Path returns the artist's first filesystem location as the Plex server sees
+// it, or "" when Plex reported none. An artist whose albums span several
+// folders has one location per folder; the first is its primary directory.
+func (a *Artist) Path() string {
+	if len(a.Locations) == 0 {
+		return ""
+	}
+	return a.Locations[0].Path
+}
+
+func (c *Client) setAuth(req *http.Request) {
+	req.Header.Set("X-Plex-Token", c.APIKey)
+	req.Header.Set("X-Plex-Client-Identifier", clientIdentifier)
+	req.Header.Set("X-Plex-Product", "Stillwater")
+	// Plex answers in XML unless JSON is asked for.
+	req.Header.Set("Accept", "application/json")
+}
@@ -0,0 +1,182 @@
+package plex
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+}
+
+const sectionsJSON = `{"MediaContainer":{"Directory":[
+	{"key":"1","type":"movie","title":"Movies","Location":[{"path":"/data/movies"}]},
+	{"key":"3","type":"artist","title":"Music","agent":"tv.plex.agents.music","Location":[{"path":"/data/music"}]}
+]}}`
+
+func TestTestConnection_Success(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/library/sections" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("X-Plex-Token") != "test-token" {
+			t.Errorf("missing or wrong token header: %q", r.Header.Get("X-Plex-Token"))
+		}
+		if r.Header.Get("Accept") != "application/json" {
+			t.Errorf("Accept = %q, want application/json", r.Header.Get("Accept"))
+		}
+		if r.Header.Get("X-Plex-Client-Identifier") == "" {
+			t.Error("missing X-Plex-Client-Identifier")
+		}
+		_, _ = w.Write([]byte(sectionsJSON))
+	}))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "test-token", srv.Client(), testLogger())
+	if err := c.TestConnection(context.Background()); err != nil {
+		t.Fatalf("TestConnection failed: %v", err)
+	}
+}
+
+func TestTestConnection_Unauthorized(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "bad-token", srv.Client(), testLogger())
+	if err := c.TestConnection(context.Background()); err == nil {
+		t.Fatal("expected error for unauthorized")
+	}
+}
+
+func TestGetServerID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/identity" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"MediaContainer":{"machineIdentifier":"abc123","version":"1.40.0"}}`))
+	}))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "t", srv.Client(), testLogger())
+	id, err := c.GetServerID(context.Background())
+	if err != nil {
+		t.Fatalf("GetServerID: %v", err)
+	}
+	if id != "abc123" {
+		t.Errorf("server id = %q, want abc123", id)
+	}
+}
+
+func TestGetMusicLibraries_FiltersArtistSections(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(sectionsJSON))
+	}))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "t", srv.Client(), testLogger())
+	libs, err := c.GetMusicLibraries(context.Background())
+	if err != nil {
+		t.Fatalf("GetMusicLibraries: %v", err)
+	}
+	if len(libs) != 1 || libs[0].Key != "3" || libs[0].Title != "Music" {
+		t.Fatalf("libraries = %+v, want only the Music section", libs)
+	}
+	if len(libs[0].Locations) != 1 || libs[0].Locations[0].Path != "/data/music" {
+		t.Errorf("locations = %+v, want /data/music", libs[0].Locations)
+	}
+}
+
+func TestGetArtists_Pagination(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/library/sections/3/all" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("type") != "8" || q.Get("includeGuids") != "1" {
+			t.Errorf("query = %v, want type=8 and includeGuids=1", q)
+		}
+		if q.Get("X-Plex-Container-Start") != "100" || q.Get("X-Plex-Container-Size") != "50" {
+			t.Errorf("paging = %v, want start 100 size 50", q)
+		}
+		_, _ = w.Write([]byte(`{"MediaContainer":{"totalSize":151,"Metadata":[
+			{"ratingKey":"42","title":"Radiohead","titleSort":"Radiohead","summary":"Bio",
+			 "Genre":[{"tag":"Rock"}],"Guid":[{"id":"mbid://a74b1b7f-71a5-4011-9441-d0b5e4122711"}],
+			 "Location":[{"path":"/data/music/Radiohead"}]}
+		]}}`))
+	}))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "t", srv.Client(), testLogger())
+	resp, err := c.GetArtists(context.Background(), "3", 100, 50)
+	if err != nil {
+		t.Fatalf("GetArtists: %v", err)
+	}
+	if resp.MediaContainer.TotalSize != 151 || len(resp.MediaContainer.Metadata) != 1 {
+		t.Fatalf("response = %+v", resp.MediaContainer)
+	}
+	a := resp.MediaContainer.Metadata[0]
+	if a.MusicBrainzID() != "a74b1b7f-71a5-4011-9441-d0b5e4122711" {
+		t.Errorf("MusicBrainzID = %q", a.MusicBrainzID())
+	}
+	if a.Path() != "/data/music/Radiohead" {
+		t.Errorf("Path = %q", a.Path())
+	}
+	if len(a.Genres) != 1 || a.Genres[0].Tag != "Rock" {
+		t.Errorf("genres = %+v", a.Genres)
+	}
+}
+
+func TestArtistHelpers_Empty(t *testing.T) {
+	a := Artist{GUIDs: []GUID{{ID: "discogs://123"}}}
+	if got := a.MusicBrainzID(); got != "" {
+		t.Errorf("MusicBrainzID = %q, want empty without an mbid guid", got)
+	}
+	if got := a.Path(); got != "" {
+		t.Errorf("Path = %q, want empty without a location", got)
+	}
+}
+
+func TestGetArtistImage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/library/metadata/42":
+			_, _ = w.Write([]byte(`{"MediaContainer":{"Metadata":[
+				{"ratingKey":"42","title":"Radiohead","thumb":"/library/metadata/42/thumb/1700000000","art":""}
+			]}}`))
+		case "/library/metadata/42/thumb/1700000000":
+			if r.Header.Get("X-Plex-Token") != "t" {
+				t.Error("image request missing token")
+			}
+			w.Header().Set("Content-Type", "image/jpeg")
+			_, _ = w.Write([]byte("jpeg-bytes"))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "t", srv.Client(), testLogger())
+	data, ct, err := c.GetArtistImage(context.Background(), "42", "thumb")
+	if err != nil {
+		t.Fatalf("GetArtistImage: %v", err)
+	}
+	if string(data) != "jpeg-bytes" || ct != "image/jpeg" {
+		t.Errorf("got %q (%s)", data, ct)
+	}
+	if _, _, err := c.GetArtistImage(context.Background(), "42", "fanart"); err == nil {
+		t.Error("expected an error for an artist without a background")
+	}
+	if _, _, err := c.GetArtistImage(context.Background(), "42", "logo"); err == nil {
+		t.Error("expected an error for an image type Plex does not have")
+	}
+	if _, _, err := c.GetArtistBackdrop(context.Background(), "42", 1); err == nil {
+		t.Error("expected an error for a backdrop index past the single background")
+	}
+}
@@ -0,0 +1,213 @@
+package plex
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/sydlexius/stillwater/internal/connection"
+	"github.com/sydlexius/stillwater/internal/connection/httpclient"
+)
+
+// Plex reads no NFO and no sidecar artwork from an artist folder: its agent
+// fills an artist from online sources and overwrites any unlocked field on the
+// next metadata refresh. Every write below therefore also locks the field it
+// writes (<field>.locked=1), which is the only thing that keeps a pushed value
+// in place on Plex.
+
+// lockedFieldCanonical maps Stillwater's lockable field keys to Plex's edit
+// field names. Plex edits fewer fields than Stillwater curates (no formed,
+// born, members...); keys without an entry have no Plex counterpart and are
+// dropped.
+var lockedFieldCanonical = map[string]string{
+	"name":      "title",
+	"sort_name": "titleSort",
+	"sortname":  "titleSort",
+	"biography": "summary",
+	"genres":    "genre",
+	"styles":    "style",
+	"moods":     "mood",
+}
+
+// imageFields are the artwork fields a whole-item lock also covers.
+var imageFields = []string{"thumb", "art"}
+
+// PushMetadata writes the artist's name, sort name, biography, genres, styles
+// and moods to Plex and locks each of them so the Plex agent keeps them.
+//
+// Plex edits an item through its section (PUT /library/sections/{id}/all with
+// the item's rating key), so the current item is fetched first for its section
+// ID. The same fetch supplies the current tags: Plex appends tags rather than
+// replacing the list, so tags Stillwater no longer has are removed explicitly.
+// An empty biography is written through so a clear in Stillwater propagates;
+// an empty name is not, because Plex rejects an untitled item.
+func (c *Client) PushMetadata(ctx context.Context, platformArtistID string, data connection.ArtistPushData) error {
+	current, err := c.GetArtist(ctx, platformArtistID)
+	if err != nil {
+		return fmt.Errorf("fetching current artist for push: %w", err)
+	}
+
+	v := url.Values{}
+	if data.Name != "" {
+		setField(v, "title", data.Name)
+	}
+	if data.SortName != "" {
+		setField(v, "titleSort", data.SortName)
+	}
+	setField(v, "summary", data.Biography)
+	setTags(v, "genre", data.Genres, current.Genres)
+	setTags(v, "style", data.Styles, current.Styles)
+	setTags(v, "mood", data.Moods, current.Moods)
+
+	return c.editArtist(ctx, current, v, "push")
+}
+
+// UpdateArtistLocks locks on Plex the fields Stillwater has locked, or every
+// editable field plus the poster and background when lockData is set. Fields
+// with no Plex counterpart are logged and dropped.
+//
+// Locks are only ever added here, never removed. PushMetadata locks what it
+// writes, so unlocking a field on the Plex side because it is unlocked in
+// Stillwater would hand the pushed value back to the Plex agent, which is the
+// opposite of what an operator unlocking a field in Stillwater asks for.
+func (c *Client) UpdateArtistLocks(ctx context.Context, platformArtistID string, lockData bool, lockedFields []string) error {
+	fields, dropped := canonicalizeLockedFieldsDrops(lockedFields)
+	for _, d := range dropped {
+		c.Logger.Debug("plex: dropping lock field with no plex counterpart",
+			"artist_id", platformArtistID, "field", d)
+	}
+	if lockData {
+		fields = append([]string{"title", "titleSort", "summary", "genre", "style", "mood"}, imageFields...)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	current, err := c.GetArtist(ctx, platformArtistID)
+	if err != nil {
+		return fmt.Errorf("fetching artist for lock update: %w", err)
+	}
+	v := url.Values{}
+	for _, f := range fields {
+		v.Set(f+".locked", "1")
+	}
+	return c.editArtist(ctx, current, v, "lock update")
+}
+
+// UploadImage uploads an artist poster ("thumb") or background ("fanart"),
+// which Plex selects on upload, and locks the field so the agent does not
+// swap it back. Plex artists have no logo or banner; those types are skipped
+// without error because there is nothing on Plex for them to update.
+func (c *Client) UploadImage(ctx context.Context, platformArtistID string, imageType string, data []byte, contentType string) error {
+	var endpoint, field string
+	switch imageType {
+	case "thumb":
+		endpoint, field = "posters", "thumb"
+	case "fanart":
+		endpoint, field = "arts", "art"
+	default:
+		c.Logger.Debug("plex: no artist slot for image type, skipping upload",
+			"artist_id", platformArtistID, "type", imageType)
+		return nil
+	}
+
+	path := "/library/metadata/" + url.PathEscape(platformArtistID) + "/" + endpoint
+	resp, err := c.Do(ctx, http.MethodPost, path, bytes.NewReader(data), contentType)
+	if err != nil {
+		return fmt.Errorf("executing image upload: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // Close error not actionable on HTTP response cleanup
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("image upload: %w", wrapAuthIfStatusAuth(httpclient.ReadBoundedStatusError(resp)))
+	}
+
+	current, err := c.GetArtist(ctx, platformArtistID)
+	if err != nil {
+		return fmt.Errorf("fetching artist to lock uploaded image: %w", err)
+	}
+	v := url.Values{}
+	v.Set(field+".locked", "1")
+	return c.editArtist(ctx, current, v, "image lock")
+}
+
+// editArtist applies v to artist a through its section's edit endpoint. op
+// names the operation in error messages.
+func (c *Client) editArtist(ctx context.Context, a *Artist, v url.Values, op string) error {
+	if a.LibrarySectionID == 0 {
+		return fmt.Errorf("%s: artist %s reported no library section", op, a.RatingKey)
+	}
+	v.Set("type", strconv.Itoa(artistMetadataType))
+	v.Set("id", a.RatingKey)
+	path := "/library/sections/" + strconv.Itoa(a.LibrarySectionID) + "/all?" + v.Encode()
+
+	resp, err := c.Do(ctx, http.MethodPut, path, nil, "")
+	if err != nil {
+		return fmt.Errorf("executing %s: %w", op, err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // Close error not actionable on HTTP response cleanup
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: %w", op, wrapAuthIfStatusAuth(httpclient.ReadBoundedStatusError(resp)))
+	}
+	return nil
+}
+
+// setField writes a scalar field and locks it.
+func setField(v url.Values, field, value string) {
+	v.Set(field+".value", value)
+	v.Set(field+".locked", "1")
+}
+
+// setTags replaces a tag field's list with want and locks it. Plex merges
+// written tags into the existing list, so every current tag not in want is
+// removed with the field[].tag.tag- form.
+func setTags(v url.Values, field string, want []string, current []Tag) {
+	keep := make(map[string]bool, len(want))
+	i := 0
+	for _, t := range want {
+		t = strings.TrimSpace(t)
+		if t == "" || keep[strings.ToLower(t)] {
+			continue
+		}
+		keep[strings.ToLower(t)] = true
+		v.Set(fmt.Sprintf("%s[%d].tag.tag", field, i), t)
+		i++
+	}
+	var stale []string
+	for _, t := range current {
+		if !keep[strings.ToLower(t.Tag)] {
+			stale = append(stale, t.Tag)
+		}
+	}
+	if len(stale) > 0 {
+		v.Set(field+"[].tag.tag-", strings.Join(stale, ","))
+	}
+	v.Set(field+".locked", "1")
+}
+
+// canonicalizeLockedFieldsDrops converts Stillwater lock keys to Plex field
+// names, deduplicated, and returns the keys that have no Plex counterpart.
+func canonicalizeLockedFieldsDrops(in []string) (canon []string, dropped []string) {
+	canon = make([]string, 0, len(in))
+	seen := make(map[string]struct{}, len(in))
+	for _, f := range in {
+		key := strings.ToLower(strings.TrimSpace(f))
+		if key == "" {
+			continue
+		}
+		c, ok := lockedFieldCanonical[key]
+		if !ok {
+			dropped = append(dropped, key)
+			continue
+		}
+		if _, dup := seen[c]; dup {
+			continue
+		}
+		seen[c] = struct{}{}
+		canon = append(canon, c)
+	}
+	return canon, dropped
+}
@@ -0,0 +1,201 @@
+package plex
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/connection"
+)
+
+// plexArtistJSON is GET /library/metadata/42 for an artist in section 3 that
+// already carries two genres.
+const plexArtistJSON = `{"MediaContainer":{"Metadata":[
+	{"ratingKey":"42","title":"Radiohead","librarySectionID":3,
+	 "Genre":[{"tag":"Rock"},{"tag":"Britpop"}]}
+]}}`
+
+// editRecorder is a fake Plex server that serves plexArtistJSON and records
+// the query of every section edit.
+type editRecorder struct {
+	mu      sync.Mutex
+	edits   []url.Values
+	uploads []string
+	upload  []byte
+}
+
+func (e *editRecorder) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/library/metadata/42":
+			_, _ = w.Write([]byte(plexArtistJSON))
+		case r.Method == http.MethodPut && r.URL.Path == "/library/sections/3/all":
+			e.edits = append(e.edits, r.URL.Query())
+		case r.Method == http.MethodPost && (r.URL.Path == "/library/metadata/42/posters" || r.URL.Path == "/library/metadata/42/arts"):
+			e.uploads = append(e.uploads, r.URL.Path)
+			e.upload, _ = io.ReadAll(r.Body)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+func TestPushMetadata(t *testing.T) {
+	rec := &editRecorder{}
+	srv := httptest.NewServer(rec.handler(t))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "t", srv.Client(), testLogger())
+	err := c.PushMetadata(context.Background(), "42", connection.ArtistPushData{
+		Name:      "Radiohead",
+		SortName:  "Radiohead",
+		Biography: "English rock band.",
+		Genres:    []string{"Rock", "Alternative", "rock"},
+		Styles:    []string{"Art Rock"},
+	})
+	if err != nil {
+		t.Fatalf("PushMetadata: %v", err)
+	}
+	if len(rec.edits) != 1 {
+		t.Fatalf("edits = %d, want 1", len(rec.edits))
+	}
+	q := rec.edits[0]
+	want := map[string]string{
+		"type":             "8",
+		"id":               "42",
+		"title.value":      "Radiohead",
+		"title.locked":     "1",
+		"titleSort.value":  "Radiohead",
+		"titleSort.locked": "1",
+		"summary.value":    "English rock band.",
+		"summary.locked":   "1",
+		"genre[0].tag.tag": "Rock",
+		"genre[1].tag.tag": "Alternative",
+		"genre[2].tag.tag": "", // "rock" is a case-folded duplicate
+		"genre[].tag.tag-": "Britpop",
+		"genre.locked":     "1",
+		"style[0].tag.tag": "Art Rock",
+		"style[].tag.tag-": "",
+		"style.locked":     "1",
+		"mood[0].tag.tag":  "",
+		"mood.locked":      "1",
+	}
+	for k, v := range want {
+		if got := q.Get(k); got != v {
+			t.Errorf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
+
+func TestPushMetadata_EmptyNameNotWritten(t *testing.T) {
+	rec := &editRecorder{}
+	srv := httptest.NewServer(rec.handler(t))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "t", srv.Client(), testLogger())
+	if err := c.PushMetadata(context.Background(), "42", connection.ArtistPushData{}); err != nil {
+		t.Fatalf("PushMetadata: %v", err)
+	}
+	q := rec.edits[0]
+	if q.Has("title.value") {
+		t.Error("an empty name must not be written: Plex rejects an untitled item")
+	}
+	if !q.Has("summary.value") {
+		t.Error("an empty biography should be written through to clear it")
+	}
+	if q.Get("genre[].tag.tag-") != "Rock,Britpop" {
+		t.Errorf("genre removal = %q, want both current genres", q.Get("genre[].tag.tag-"))
+	}
+}
+
+func TestUpdateArtistLocks(t *testing.T) {
+	rec := &editRecorder{}
+	srv := httptest.NewServer(rec.handler(t))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "t", srv.Client(), testLogger())
+	if err := c.UpdateArtistLocks(context.Background(), "42", false, []string{"biography", "genres", "formed"}); err != nil {
+		t.Fatalf("UpdateArtistLocks: %v", err)
+	}
+	q := rec.edits[0]
+	if q.Get("summary.locked") != "1" || q.Get("genre.locked") != "1" {
+		t.Errorf("locks = %v, want summary and genre locked", q)
+	}
+	if q.Has("title.locked") || q.Has("thumb.locked") {
+		t.Errorf("locks = %v, want only the listed fields", q)
+	}
+
+	if err := c.UpdateArtistLocks(context.Background(), "42", true, nil); err != nil {
+		t.Fatalf("UpdateArtistLocks(lockData): %v", err)
+	}
+	q = rec.edits[1]
+	for _, f := range []string{"title", "titleSort", "summary", "genre", "style", "mood", "thumb", "art"} {
+		if q.Get(f+".locked") != "1" {
+			t.Errorf("whole-item lock: %s not locked", f)
+		}
+	}
+}
+
+func TestUpdateArtistLocks_NothingMappableSendsNothing(t *testing.T) {
+	rec := &editRecorder{}
+	srv := httptest.NewServer(rec.handler(t))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "t", srv.Client(), testLogger())
+	if err := c.UpdateArtistLocks(context.Background(), "42", false, []string{"formed", "members"}); err != nil {
+		t.Fatalf("UpdateArtistLocks: %v", err)
+	}
+	if len(rec.edits) != 0 {
+		t.Errorf("edits = %v, want none", rec.edits)
+	}
+}
+
+func TestUploadImage(t *testing.T) {
+	rec := &editRecorder{}
+	srv := httptest.NewServer(rec.handler(t))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "t", srv.Client(), testLogger())
+	if err := c.UploadImage(context.Background(), "42", "fanart", []byte("jpeg"), "image/jpeg"); err != nil {
+		t.Fatalf("UploadImage: %v", err)
+	}
+	if len(rec.uploads) != 1 || rec.uploads[0] != "/library/metadata/42/arts" || string(rec.upload) != "jpeg" {
+		t.Fatalf("uploads = %v (%q), want the bytes posted to /arts", rec.uploads, rec.upload)
+	}
+	if len(rec.edits) != 1 || rec.edits[0].Get("art.locked") != "1" {
+		t.Fatalf("edits = %v, want art locked after upload", rec.edits)
+	}
+
+	// Plex artists have no logo; the upload is a no-op, not an error.
+	if err := c.UploadImage(context.Background(), "42", "logo", []byte("png"), "image/png"); err != nil {
+		t.Fatalf("UploadImage(logo): %v", err)
+	}
+	if len(rec.uploads) != 1 {
+		t.Errorf("logo upload reached Plex: %v", rec.uploads)
+	}
+}
+
+func TestPushMetadata_AuthFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(plexArtistJSON))
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "t", srv.Client(), testLogger())
+	err := c.PushMetadata(context.Background(), "42", connection.ArtistPushData{Name: "Radiohead"})
+	if !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("err = %v, want ErrAuthRequired", err)
+	}
+}
@@ -0,0 +1,74 @@
+package plex
+
+// Plex wraps every JSON response in a MediaContainer object; the types below
+// model only the members Stillwater reads.
+
+// Identity represents the response from GET /identity.
+type Identity struct {
+	MediaContainer struct {
+		MachineIdentifier string `json:"machineIdentifier"`
+		Version           string `json:"version"`
+	} `json:"MediaContainer"`
+}
+
+// Location is one filesystem path of a library section or artist, as the Plex
+// server sees it.
+type Location struct {
+	Path string `json:"path"`
+}
+
+// Section represents a library section from GET /library/sections. Music
+// sections have Type "artist".
+type Section struct {
+	Key       string     `json:"key"`
+	Type      string     `json:"type"`
+	Title     string     `json:"title"`
+	Agent     string     `json:"agent"`
+	Locations []Location `json:"Location"`
+}
+
+// sectionsResponse represents the response from GET /library/sections.
+type sectionsResponse struct {
+	MediaContainer struct {
+		Directory []Section `json:"Directory"`
+	} `json:"MediaContainer"`
+}
+
+// Tag is one entry of a Plex tag list (Genre, Style, Mood).
+type Tag struct {
+	Tag string `json:"tag"`
+}
+
+// GUID is one external identifier of a Plex item, e.g. "mbid://<uuid>".
+// Listing endpoints return these only when called with includeGuids=1.
+type GUID struct {
+	ID string `json:"id"`
+}
+
+// Artist represents an artist (metadata type 8) from a section listing or
+// GET /library/metadata/{ratingKey}.
+type Artist struct {
+	RatingKey        string     `json:"ratingKey"`
+	GUID             string     `json:"guid"`
+	Title            string     `json:"title"`
+	TitleSort        string     `json:"titleSort"`
+	Summary          string     `json:"summary"`
+	Thumb            string     `json:"thumb"`
+	Art              string     `json:"art"`
+	LibrarySectionID int        `json:"librarySectionID"`
+	Genres           []Tag      `json:"Genre"`
+	Styles           []Tag      `json:"Style"`
+	Moods            []Tag      `json:"Mood"`
+	GUIDs            []GUID     `json:"Guid"`
+	Locations        []Location `json:"Location"`
+}
+
+// ArtistsResponse represents one page of a section's artist listing.
+// TotalSize is the section's full artist count, reported because the request
+// carries X-Plex-Container-Start.
+type ArtistsResponse struct {
+	MediaContainer struct {
+		TotalSize int      `json:"totalSize"`
+		Metadata  []Artist `json:"Metadata"`
+	} `json:"MediaContainer"`
+}
@@ -92,6 +92,15 @@ type IndexedImageUploader interface {
 	UploadImageAtIndex(ctx context.Context, platformArtistID string, imageType string, index int, data []byte, contentType string) error
 }
 
+// ImageLinker points a platform's artist artwork at an image file by path
+// instead of uploading its bytes, for platforms that load artwork from a path
+// they can read themselves (Kodi). index is the slot for multi-image types
+// (backdrops) and 0 otherwise; platformPath is the file as the platform
+// addresses it, already translated through the connection's path mappings.
+type ImageLinker interface {
+	LinkImage(ctx context.Context, platformArtistID, imageType string, index int, platformPath string) error
+}
+
 // ImageDeleter deletes images from an external platform.
 type ImageDeleter interface {
 	DeleteImage(ctx context.Context, platformArtistID string, imageType string) error
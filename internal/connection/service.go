@@ -288,10 +288,11 @@ func (s *Service) UpdateFeatures(ctx context.Context, id string, imageWrite, met
 }
 
 // UpdatePlatformServerID stores the resolved platform server ID (from the
-// Emby/Jellyfin /System/Info "Id" field) for a connection. This is the
-// identifier the platform's web client expects in ?serverId=<id> when deep
-// linking into an item; without it the View on Platform link lands on a
-// generic page or the wrong server in multi-server setups.
+// Emby/Jellyfin /System/Info "Id" field, or the Plex /identity
+// machineIdentifier) for a connection. This is the identifier the platform's
+// web client expects when deep linking into an item; without it the View on
+// Platform link lands on a generic page or the wrong server in multi-server
+// setups.
 func (s *Service) UpdatePlatformServerID(ctx context.Context, id, platformServerID string) error {
 	now := time.Now().UTC()
 	result, err := s.db.ExecContext(ctx, `
@@ -451,6 +452,23 @@ func (s *Service) scanConnection(row interface{ Scan(...any) error }) (*Connecti
 			FeatureMetadataPush:   featMetadataPush == 1,
 			FeatureTriggerRefresh: featTriggerRefresh == 1,
 		}
+	case TypePlex:
+		c.Plex = &PlexConfig{
+			PlatformServerID:      platformServerID.String,
+			FeatureImageWrite:     featImageWrite == 1,
+			FeatureMetadataPush:   featMetadataPush == 1,
+			FeatureTriggerRefresh: featTriggerRefresh == 1,
+		}
+	case TypeSubsonic:
+		// Only the trigger-refresh column means anything for Subsonic; the
+		// image-write and metadata-push columns stay 0 (see SubsonicConfig).
+		c.Subsonic = &SubsonicConfig{FeatureTriggerRefresh: featTriggerRefresh == 1}
+	case TypeKodi:
+		c.Kodi = &KodiConfig{
+			FeatureImageWrite:     featImageWrite == 1,
+			FeatureMetadataPush:   featMetadataPush == 1,
+			FeatureTriggerRefresh: featTriggerRefresh == 1,
+		}
 	}
 
 	if lastCheckedAt.Valid {
@@ -0,0 +1,282 @@
+package subsonic
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec // G501: the Subsonic token scheme is defined as md5(password+salt); see setAuth
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/connection"
+	"github.com/sydlexius/stillwater/internal/connection/httpclient"
+)
+
+// ErrAuthRequired is the sentinel wrapped by every call that fails because
+// the server rejected the credentials: an HTTP 401/403, or a Subsonic error
+// code in the authentication range (see isAuthCode).
+var ErrAuthRequired = errors.New("subsonic: authentication required")
+
+// apiVersion is the Subsonic REST protocol version Stillwater speaks. 1.16.1
+// is the last version of the original specification and the baseline the
+// OpenSubsonic extensions build on; Navidrome, gonic and Airsonic all accept
+// it. getArtistInfo2 and token authentication both predate it.
+const apiVersion = "1.16.1"
+
+// clientName is sent as the c= parameter. Navidrome lists it under each
+// user's Players, so a fixed name keeps Stillwater to one entry there.
+const clientName = "stillwater"
+
+// Client communicates with a Subsonic-compatible server (Navidrome, gonic,
+// Airsonic) over the Subsonic REST API.
+//
+// Subsonic has no API keys: every request carries a username plus a salted
+// MD5 token derived from the password. The connection stores both in its
+// single encrypted API-key field as "username:password" (see
+// SplitCredentials), so the pair never appears in the URL the operator
+// configures.
+type Client struct {
+	httpclient.BaseClient
+	username string
+	password string
+}
+
+// New creates a Subsonic client with default HTTP settings. credentials is
+// the connection's "username:password" API-key value.
+//
+// Uses a raw http.Client (not httpsafe.SafeClient) because a Subsonic server
+// is a user-configured self-hosted service that typically runs on loopback
+// or an RFC 1918 LAN address (192.168.1.10:4533). The httpsafe.SafeTransport
+// SSRF guard would reject those destinations. The destination URL is
+// operator-supplied via Settings, not user-controlled input.
+func New(baseURL, credentials string, logger *slog.Logger) *Client {
+	return NewWithHTTPClient(baseURL, credentials, &http.Client{Timeout: 10 * time.Second}, logger)
+}
+
+// NewWithHTTPClient creates a Subsonic client with a custom HTTP client (for testing).
+func NewWithHTTPClient(baseURL, credentials string, httpClient *http.Client, logger *slog.Logger) *Client {
+	user, pass := SplitCredentials(credentials)
+	c := &Client{
+		BaseClient: httpclient.NewBase(baseURL, credentials, httpClient, logger, "subsonic"),
+		username:   user,
+		password:   pass,
+	}
+	c.AuthFunc = c.setAuth
+	return c
+}
+
+// SplitCredentials splits a "username:password" API-key value at its first
+// colon. Subsonic usernames cannot contain a colon but passwords can, so
+// everything after the first one is the password. A value without a colon
+// yields an empty username, which every call then rejects before sending.
+func SplitCredentials(credentials string) (username, password string) {
+	user, pass, ok := strings.Cut(credentials, ":")
+	if !ok {
+		return "", credentials
+	}
+	return user, pass
+}
+
+// setAuth adds the Subsonic authentication and protocol parameters to req.
+// The token is md5(password + salt) with a fresh random salt per request,
+// which is the scheme the specification defines; the password itself is
+// never sent. The salt is random rather than fixed so a captured URL cannot
+// be replayed as a standing credential.
+func (c *Client) setAuth(req *http.Request) {
+	salt := newSalt()
+	sum := md5.Sum([]byte(c.password + salt)) //nolint:gosec // G401: mandated by the Subsonic protocol, not used as a password hash at rest
+	q := req.URL.Query()
+	q.Set("u", c.username)
+	q.Set("t", hex.EncodeToString(sum[:]))
+	q.Set("s", salt)
+	q.Set("v", apiVersion)
+	q.Set("c", clientName)
+	q.Set("f", "json")
+	req.URL.RawQuery = q.Encode()
+}
+
+// newSalt returns a random 12-character hex salt. The specification asks for
+// at least six characters.
+func newSalt() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b) // crypto/rand.Read never returns an error on supported platforms
+	return hex.EncodeToString(b)
+}
+
+// isAuthCode reports whether a Subsonic error code means the credentials were
+// refused: 40 wrong username or password, 41 token authentication not
+// supported for this user (LDAP-backed accounts), 44 invalid API key
+// (OpenSubsonic), and 50 user not authorized for the operation (startScan
+// needs an administrator).
+func isAuthCode(code int) bool {
+	switch code {
+	case 40, 41, 44, 50:
+		return true
+	default:
+		return false
+	}
+}
+
+// call issues GET /rest/<endpoint>.view with q and returns the decoded
+// response body. A transport error, a non-200 status and a "failed" Subsonic
+// status are all returned as errors; authentication failures of either kind
+// wrap ErrAuthRequired.
+func (c *Client) call(ctx context.Context, endpoint string, q url.Values) (*Response, error) {
+	if c.username == "" {
+		return nil, fmt.Errorf("%w: the API key must be username:password", ErrAuthRequired)
+	}
+	path := "/rest/" + endpoint + ".view"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var env envelope
+	if err := c.Get(ctx, path, &env); err != nil {
+		var se *httpclient.StatusError
+		if errors.As(err, &se) && se.IsAuth() {
+			return nil, fmt.Errorf("%w: %w", ErrAuthRequired, err)
+		}
+		return nil, err
+	}
+	resp := &env.Response
+	if resp.Status != "ok" {
+		if resp.Error == nil {
+			return nil, fmt.Errorf("%s: status %q", endpoint, resp.Status)
+		}
+		err := fmt.Errorf("%s: error %d: %s", endpoint, resp.Error.Code, resp.Error.Message)
+		if isAuthCode(resp.Error.Code) {
+			return nil, fmt.Errorf("%w: %w", ErrAuthRequired, err)
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// TestConnection verifies connectivity and the credentials with ping.
+// Unlike most peers' unauthenticated health endpoints, ping checks the
+// username and token, so a success proves both.
+func (c *Client) TestConnection(ctx context.Context) error {
+	resp, err := c.call(ctx, "ping", nil)
+	if err != nil {
+		return fmt.Errorf("testing connection: %w", err)
+	}
+	c.Logger.Debug("subsonic connection ok",
+		"server", resp.Type, "server_version", resp.ServerVersion, "api_version", resp.Version)
+	return nil
+}
+
+// GetMusicFolders returns the server's top-level music folders.
+func (c *Client) GetMusicFolders(ctx context.Context) ([]MusicFolder, error) {
+	resp, err := c.call(ctx, "getMusicFolders", nil)
+	if err != nil {
+		return nil, fmt.Errorf("getting music folders: %w", err)
+	}
+	if resp.MusicFolders == nil {
+		return nil, nil
+	}
+	return resp.MusicFolders.MusicFolder, nil
+}
+
+// GetArtists returns every artist in a music folder, or in all folders when
+// musicFolderID is empty. getArtists is not paginated: the server returns
+// the whole index in one response, grouped by letter, which this flattens.
+func (c *Client) GetArtists(ctx context.Context, musicFolderID string) ([]Artist, error) {
+	q := url.Values{}
+	if musicFolderID != "" {
+		q.Set("musicFolderId", musicFolderID)
+	}
+	resp, err := c.call(ctx, "getArtists", q)
+	if err != nil {
+		return nil, fmt.Errorf("getting artists: %w", err)
+	}
+	if resp.Artists == nil {
+		return nil, nil
+	}
+	var out []Artist
+	for _, idx := range resp.Artists.Index {
+		out = append(out, idx.Artist...)
+	}
+	return out, nil
+}
+
+// GetArtist returns one artist by ID.
+func (c *Client) GetArtist(ctx context.Context, id string) (*Artist, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, fmt.Errorf("artist id is required")
+	}
+	resp, err := c.call(ctx, "getArtist", url.Values{"id": {id}})
+	if err != nil {
+		return nil, fmt.Errorf("getting artist %s: %w", id, err)
+	}
+	if resp.Artist == nil {
+		return nil, fmt.Errorf("artist %s: empty response", id)
+	}
+	return resp.Artist, nil
+}
+
+// GetArtistInfo returns the biography and image URLs the server holds for an
+// artist (getArtistInfo2, the ID3 variant).
+func (c *Client) GetArtistInfo(ctx context.Context, id string) (*ArtistInfo, error) {
+	resp, err := c.call(ctx, "getArtistInfo2", url.Values{"id": {id}})
+	if err != nil {
+		return nil, fmt.Errorf("getting artist info %s: %w", id, err)
+	}
+	if resp.ArtistInfo2 == nil {
+		return &ArtistInfo{}, nil
+	}
+	return resp.ArtistInfo2, nil
+}
+
+// GetArtistDetail implements connection.ArtistStateGetter from getArtist and
+// getArtistInfo2.
+//
+// Subsonic models one artist image and no backdrops, logos or banners, so
+// only HasThumb can be true. It is set when the server reports any artist
+// image URL. Navidrome answers with an image URL for every artist once it has
+// an image from the artist folder or an external agent, which is what the
+// dashboard needs to show: whether the server picked up the curated artwork
+// after the scan Stillwater triggered. Genres, dates and locks do not exist
+// on a Subsonic artist and stay empty.
+func (c *Client) GetArtistDetail(ctx context.Context, platformArtistID string) (*connection.ArtistPlatformState, error) {
+	a, err := c.GetArtist(ctx, platformArtistID)
+	if err != nil {
+		return nil, err
+	}
+	info, err := c.GetArtistInfo(ctx, platformArtistID)
+	if err != nil {
+		return nil, err
+	}
+	mbid := a.MusicBrainzID
+	if mbid == "" {
+		mbid = info.MusicBrainzID
+	}
+	return &connection.ArtistPlatformState{
+		Name:          a.Name,
+		SortName:      a.SortName,
+		Biography:     info.Biography,
+		MusicBrainzID: mbid,
+		HasThumb: a.ArtistImageURL != "" || info.LargeImageURL != "" ||
+			info.MediumImageURL != "" || info.SmallImageURL != "",
+	}, nil
+}
+
+// StartScan asks the server to start a library scan. The call returns as
+// soon as the scan is queued; a server already scanning answers with its
+// current status rather than an error, so a second request while one is in
+// flight is harmless. The scan is incremental: the server re-reads only
+// folders whose contents changed, which is how it notices artwork Stillwater
+// wrote into an artist folder.
+func (c *Client) StartScan(ctx context.Context) error {
+	resp, err := c.call(ctx, "startScan", nil)
+	if err != nil {
+		return fmt.Errorf("starting scan: %w", err)
+	}
+	if resp.ScanStatus != nil {
+		c.Logger.Debug("subsonic scan requested", "scanning", resp.ScanStatus.Scanning, "count", resp.ScanStatus.Count)
+	}
+	return nil
+}
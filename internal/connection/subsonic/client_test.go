@@ -0,0 +1,238 @@
+package subsonic
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec // G501: verifying the protocol's own token scheme
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+}
+
+// okEnvelope wraps payload (the members after "status") in a successful
+// subsonic-response.
+func okEnvelope(payload string) string {
+	if payload != "" {
+		payload = "," + payload
+	}
+	return `{"subsonic-response":{"status":"ok","version":"1.16.1","type":"navidrome","serverVersion":"0.53.3","openSubsonic":true` + payload + `}}`
+}
+
+func TestSplitCredentials(t *testing.T) {
+	tests := []struct {
+		in, user, pass string
+	}{
+		{"alice:secret", "alice", "secret"},
+		{"alice:pa:ss", "alice", "pa:ss"},
+		{"secret", "", "secret"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		user, pass := SplitCredentials(tt.in)
+		if user != tt.user || pass != tt.pass {
+			t.Errorf("SplitCredentials(%q) = %q, %q; want %q, %q", tt.in, user, pass, tt.user, tt.pass)
+		}
+	}
+}
+
+func TestTestConnection_SendsTokenAuth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/ping.view" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		q := r.URL.Query()
+		if q.Get("u") != "alice" || q.Get("v") != apiVersion || q.Get("c") != clientName || q.Get("f") != "json" {
+			t.Errorf("protocol params = %v", q)
+		}
+		if q.Has("p") {
+			t.Error("the password must never be sent")
+		}
+		salt := q.Get("s")
+		if len(salt) < 6 {
+			t.Errorf("salt %q is shorter than the six characters the spec requires", salt)
+		}
+		sum := md5.Sum([]byte("secret" + salt)) //nolint:gosec // G401: protocol token
+		if q.Get("t") != hex.EncodeToString(sum[:]) {
+			t.Errorf("token = %q, want md5(password+salt)", q.Get("t"))
+		}
+		_, _ = w.Write([]byte(okEnvelope("")))
+	}))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "alice:secret", srv.Client(), testLogger())
+	if err := c.TestConnection(context.Background()); err != nil {
+		t.Fatalf("TestConnection: %v", err)
+	}
+}
+
+func TestTestConnection_WrongPassword(t *testing.T) {
+	// Subsonic reports a credential failure as HTTP 200 with error code 40.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"subsonic-response":{"status":"failed","version":"1.16.1","error":{"code":40,"message":"Wrong username or password"}}}`))
+	}))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "alice:wrong", srv.Client(), testLogger())
+	err := c.TestConnection(context.Background())
+	if !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("err = %v, want ErrAuthRequired", err)
+	}
+}
+
+func TestTestConnection_MissingUsernameNotSent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("request sent without a username: %s", r.URL)
+	}))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "just-a-password", srv.Client(), testLogger())
+	if err := c.TestConnection(context.Background()); !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("err = %v, want ErrAuthRequired", err)
+	}
+}
+
+func TestGetMusicFolders_NumericIDs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(okEnvelope(`"musicFolders":{"musicFolder":[{"id":1,"name":"Music"},{"id":"2","name":"Audiobooks"}]}`)))
+	}))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "alice:secret", srv.Client(), testLogger())
+	folders, err := c.GetMusicFolders(context.Background())
+	if err != nil {
+		t.Fatalf("GetMusicFolders: %v", err)
+	}
+	if len(folders) != 2 || folders[0].ID != "1" || folders[1].ID != "2" || folders[0].Name != "Music" {
+		t.Fatalf("folders = %+v", folders)
+	}
+}
+
+func TestGetArtists_FlattensIndex(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/getArtists.view" || r.URL.Query().Get("musicFolderId") != "1" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		_, _ = w.Write([]byte(okEnvelope(`"artists":{"index":[
+			{"name":"B","artist":[{"id":"ar-1","name":"Björk","musicBrainzId":"87c5dedd-371d-4571-9e1c-45f6e0ed3fce"}]},
+			{"name":"R","artist":[{"id":"ar-2","name":"Radiohead"},{"id":"ar-3","name":"Röyksopp"}]}
+		]}`)))
+	}))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "alice:secret", srv.Client(), testLogger())
+	artists, err := c.GetArtists(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("GetArtists: %v", err)
+	}
+	if len(artists) != 3 {
+		t.Fatalf("artists = %+v, want three across both index letters", artists)
+	}
+	if artists[0].MusicBrainzID != "87c5dedd-371d-4571-9e1c-45f6e0ed3fce" || artists[2].ID != "ar-3" {
+		t.Errorf("artists = %+v", artists)
+	}
+}
+
+func TestGetArtistDetail(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") != "ar-2" {
+			t.Errorf("id = %q", r.URL.Query().Get("id"))
+		}
+		switch r.URL.Path {
+		case "/rest/getArtist.view":
+			_, _ = w.Write([]byte(okEnvelope(`"artist":{"id":"ar-2","name":"Radiohead","sortName":"radiohead","albumCount":9}`)))
+		case "/rest/getArtistInfo2.view":
+			_, _ = w.Write([]byte(okEnvelope(`"artistInfo2":{"biography":"English rock band.",
+				"musicBrainzId":"a74b1b7f-71a5-4011-9441-d0b5e4122711",
+				"largeImageUrl":"http://navidrome/share/img/abc?size=600"}`)))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "alice:secret", srv.Client(), testLogger())
+	state, err := c.GetArtistDetail(context.Background(), "ar-2")
+	if err != nil {
+		t.Fatalf("GetArtistDetail: %v", err)
+	}
+	if state.Name != "Radiohead" || state.SortName != "radiohead" || state.Biography != "English rock band." {
+		t.Errorf("state = %+v", state)
+	}
+	if state.MusicBrainzID != "a74b1b7f-71a5-4011-9441-d0b5e4122711" {
+		t.Errorf("MusicBrainzID = %q, want the artist-info MBID as a fallback", state.MusicBrainzID)
+	}
+	if !state.HasThumb || state.HasFanart {
+		t.Errorf("images = thumb %v fanart %v, want only a thumb", state.HasThumb, state.HasFanart)
+	}
+}
+
+func TestGetArtistDetail_NoImage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/rest/getArtist.view" {
+			_, _ = w.Write([]byte(okEnvelope(`"artist":{"id":"ar-9","name":"Unknown"}`)))
+			return
+		}
+		_, _ = w.Write([]byte(okEnvelope(`"artistInfo2":{}`)))
+	}))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "alice:secret", srv.Client(), testLogger())
+	state, err := c.GetArtistDetail(context.Background(), "ar-9")
+	if err != nil {
+		t.Fatalf("GetArtistDetail: %v", err)
+	}
+	if state.HasThumb {
+		t.Error("HasThumb = true for an artist without any image URL")
+	}
+}
+
+func TestStartScan(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/rest/startScan.view" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(okEnvelope(`"scanStatus":{"scanning":true,"count":0}`)))
+	}))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "alice:secret", srv.Client(), testLogger())
+	if err := c.StartScan(context.Background()); err != nil {
+		t.Fatalf("StartScan: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestStartScan_NotAdmin(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"subsonic-response":{"status":"failed","version":"1.16.1","error":{"code":50,"message":"user not authorized"}}}`))
+	}))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "alice:secret", srv.Client(), testLogger())
+	if err := c.StartScan(context.Background()); !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("err = %v, want ErrAuthRequired", err)
+	}
+}
+
+func TestCall_HTTPUnauthorized(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	c := NewWithHTTPClient(srv.URL, "alice:secret", srv.Client(), testLogger())
+	if _, err := c.GetMusicFolders(context.Background()); !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("err = %v, want ErrAuthRequired", err)
+	}
+}
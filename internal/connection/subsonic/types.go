@@ -0,0 +1,115 @@
+package subsonic
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Every Subsonic endpoint wraps its payload in a "subsonic-response" object
+// that also carries the call's status. The types below model only the members
+// Stillwater reads.
+
+// envelope is the outer JSON object of every f=json response.
+type envelope struct {
+	Response Response `json:"subsonic-response"`
+}
+
+// Response is the body of a subsonic-response. Status is "ok" or "failed";
+// on failure Error says why. Exactly one payload member is set on success,
+// matching the endpoint that was called.
+type Response struct {
+	Status  string    `json:"status"`
+	Version string    `json:"version"`
+	Error   *APIError `json:"error,omitempty"`
+
+	// Type, ServerVersion and OpenSubsonic are OpenSubsonic additions: the
+	// server's product name ("navidrome"), its own version, and whether it
+	// implements the OpenSubsonic extensions (musicBrainzId, sortName).
+	Type          string `json:"type,omitempty"`
+	ServerVersion string `json:"serverVersion,omitempty"`
+	OpenSubsonic  bool   `json:"openSubsonic,omitempty"`
+
+	MusicFolders *musicFolders `json:"musicFolders,omitempty"`
+	Artists      *artistsID3   `json:"artists,omitempty"`
+	Artist       *Artist       `json:"artist,omitempty"`
+	ArtistInfo2  *ArtistInfo   `json:"artistInfo2,omitempty"`
+	ScanStatus   *ScanStatus   `json:"scanStatus,omitempty"`
+}
+
+// APIError is the error member of a failed response. Subsonic answers HTTP
+// 200 for most failures, so the code here is the only reliable signal.
+type APIError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// ID is a Subsonic identifier. The specification types music folder IDs as
+// integers and everything else as strings, and servers disagree on both;
+// ID accepts either JSON form and holds the value as a string.
+type ID string
+
+// UnmarshalJSON accepts a JSON string or number.
+func (id *ID) UnmarshalJSON(b []byte) error {
+	if bytes.HasPrefix(b, []byte(`"`)) {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*id = ID(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return err
+	}
+	*id = ID(n.String())
+	return nil
+}
+
+// MusicFolder is one top-level music folder from getMusicFolders. Navidrome
+// calls these libraries.
+type MusicFolder struct {
+	ID   ID     `json:"id"`
+	Name string `json:"name"`
+}
+
+type musicFolders struct {
+	MusicFolder []MusicFolder `json:"musicFolder"`
+}
+
+// artistsID3 is the getArtists payload: artists grouped by index letter.
+type artistsID3 struct {
+	Index []struct {
+		Name   string   `json:"name"`
+		Artist []Artist `json:"artist"`
+	} `json:"index"`
+}
+
+// Artist is an ID3 artist from getArtists or getArtist. MusicBrainzID and
+// SortName are OpenSubsonic additions and are empty on servers without them.
+type Artist struct {
+	ID             ID     `json:"id"`
+	Name           string `json:"name"`
+	SortName       string `json:"sortName,omitempty"`
+	MusicBrainzID  string `json:"musicBrainzId,omitempty"`
+	CoverArt       string `json:"coverArt,omitempty"`
+	ArtistImageURL string `json:"artistImageUrl,omitempty"`
+	AlbumCount     int    `json:"albumCount"`
+}
+
+// ArtistInfo is the getArtistInfo2 payload: the biography and image URLs the
+// server holds for an artist, from its own agents or the artist folder.
+type ArtistInfo struct {
+	Biography      string `json:"biography,omitempty"`
+	MusicBrainzID  string `json:"musicBrainzId,omitempty"`
+	LastFmURL      string `json:"lastFmUrl,omitempty"`
+	SmallImageURL  string `json:"smallImageUrl,omitempty"`
+	MediumImageURL string `json:"mediumImageUrl,omitempty"`
+	LargeImageURL  string `json:"largeImageUrl,omitempty"`
+}
+
+// ScanStatus is the startScan and getScanStatus payload.
+type ScanStatus struct {
+	Scanning bool `json:"scanning"`
+	Count    int  `json:"count"`
+}
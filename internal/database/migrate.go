@@ -461,13 +461,14 @@ func ensureArtistLibrariesMembership(db *sql.DB) error {
 
 	// Step 1: idempotent table + index. 001 has these for fresh installs;
 	// pre-1004 DBs need them at startup. The CHECK constraint includes
-	// 'lidarr' (later addition); rebuildArtistLibrariesIfStaleCheck
-	// detects an old shape (no lidarr) and rewrites the table in place.
+	// 'lidarr', 'plex', 'subsonic' and 'kodi' (later additions);
+	// rebuildArtistLibrariesIfStaleCheck detects an old shape (no kodi) and
+	// rewrites the table in place.
 	if _, err := db.ExecContext(ctx, `
 		CREATE TABLE IF NOT EXISTS artist_libraries (
 			artist_id TEXT NOT NULL REFERENCES artists(id) ON DELETE CASCADE,
 			library_id TEXT NOT NULL REFERENCES libraries(id) ON DELETE CASCADE,
-			source TEXT NOT NULL CHECK (source IN ('filesystem','emby','jellyfin','lidarr','manual')),
+			source TEXT NOT NULL CHECK (source IN ('filesystem','emby','jellyfin','lidarr','plex','subsonic','kodi','manual')),
 			added_at TEXT NOT NULL DEFAULT (datetime('now')),
 			PRIMARY KEY (artist_id, library_id)
 		)
@@ -503,6 +504,9 @@ func ensureArtistLibrariesMembership(db *sql.DB) error {
 				WHEN c.type = 'emby' THEN 'emby'
 				WHEN c.type = 'jellyfin' THEN 'jellyfin'
 				WHEN c.type = 'lidarr' THEN 'lidarr'
+				WHEN c.type = 'plex' THEN 'plex'
+				WHEN c.type = 'subsonic' THEN 'subsonic'
+				WHEN c.type = 'kodi' THEN 'kodi'
 				ELSE 'filesystem'
 			END,
 			a.created_at
@@ -608,6 +612,9 @@ func collapseDuplicateArtists(ctx context.Context, db *sql.DB, logger *slog.Logg
 					WHEN c.type = 'emby' THEN 'emby'
 					WHEN c.type = 'jellyfin' THEN 'jellyfin'
 					WHEN c.type = 'lidarr' THEN 'lidarr'
+					WHEN c.type = 'plex' THEN 'plex'
+					WHEN c.type = 'subsonic' THEN 'subsonic'
+					WHEN c.type = 'kodi' THEN 'kodi'
 					ELSE 'filesystem'
 				END,
 				a.created_at
@@ -938,8 +945,8 @@ func repointArtistFKs(ctx context.Context, tx *sql.Tx, canonical string, losers
 }
 
 // rebuildArtistLibrariesIfStaleCheck detects pre-existing artist_libraries
-// tables whose CHECK constraint predates the addition of 'lidarr' as a
-// permitted source value and rewrites them in place.
+// tables whose CHECK constraint predates the addition of 'lidarr', 'plex',
+// 'subsonic' or 'kodi' as a permitted source value and rewrites them in place.
 // SQLite does not support ALTER ... DROP CHECK, so we do the standard
 // rebuild dance: create a temp table with the current shape, copy data
 // across, drop the old, rename. Idempotent: when the existing CHECK
@@ -953,7 +960,7 @@ func rebuildArtistLibrariesIfStaleCheck(ctx context.Context, db *sql.DB) error {
 		}
 		return fmt.Errorf("reading artist_libraries CREATE: %w", err)
 	}
-	if !sqlText.Valid || strings.Contains(sqlText.String, "'lidarr'") {
+	if !sqlText.Valid || strings.Contains(sqlText.String, "'kodi'") {
 		return nil
 	}
 
@@ -967,7 +974,7 @@ func rebuildArtistLibrariesIfStaleCheck(ctx context.Context, db *sql.DB) error {
 		CREATE TABLE artist_libraries_new (
 			artist_id TEXT NOT NULL REFERENCES artists(id) ON DELETE CASCADE,
 			library_id TEXT NOT NULL REFERENCES libraries(id) ON DELETE CASCADE,
-			source TEXT NOT NULL CHECK (source IN ('filesystem','emby','jellyfin','lidarr','manual')),
+			source TEXT NOT NULL CHECK (source IN ('filesystem','emby','jellyfin','lidarr','plex','subsonic','kodi','manual')),
 			added_at TEXT NOT NULL DEFAULT (datetime('now')),
 			PRIMARY KEY (artist_id, library_id)
 		)
@@ -0,0 +1,70 @@
+-- +goose Up
+-- Provider response cache. Orchestrator.FetchMetadata only ever kept a per-call
+-- map of provider results, so every refresh, bulk fetch and rule fixer went
+-- back to MusicBrainz, Discogs and the rest for data fetched minutes earlier.
+-- At MusicBrainz's 1 req/s a full-library refresh took hours. This table lets
+-- provider.ResponseCache answer repeat lookups locally, and answer ALL lookups
+-- locally when the operator switches the cache to offline mode during a
+-- provider outage.
+--
+-- One row per (provider, endpoint, lookup key):
+--
+--   endpoint     'artist' (GetArtist), 'images' (GetImages) or 'search'
+--                (SearchArtist). TTLs are configured per provider and per
+--                endpoint, so the endpoint is part of the key.
+--   lookup_key   the exact ID or name the adapter was called with. Search keys
+--                are lowercased so "Radiohead" and "radiohead" share a row;
+--                IDs are kept verbatim because some providers' IDs are
+--                case-sensitive.
+--   not_found    1 when the provider definitively answered "no such artist".
+--                Negative answers are cached too (with a shorter TTL) because
+--                re-asking every refresh is exactly the cost this removes.
+--                Transient failures are NEVER stored: a cached error would
+--                outlive the outage it describes.
+--   payload      the JSON-encoded response; empty for a not_found row.
+--   expires_at   computed at write time from the TTL in force then. Changing a
+--                TTL affects new rows only; the old ones age out on their own.
+--
+-- No foreign key to artists: the cache is keyed by what the provider was asked,
+-- not by our artist row, and the same MBID can be asked on behalf of an artist
+-- that does not exist yet (the add-artist search flow). Per-artist invalidation
+-- deletes by the artist's MBID, name and provider IDs instead.
+--
+-- Pure cache: dropping the table loses nothing but warm entries.
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS provider_response_cache (
+    provider    TEXT    NOT NULL,
+    endpoint    TEXT    NOT NULL,
+    lookup_key  TEXT    NOT NULL,
+    not_found   INTEGER NOT NULL DEFAULT 0,
+    payload     BLOB    NOT NULL DEFAULT '',
+    fetched_at  TEXT    NOT NULL,
+    expires_at  TEXT    NOT NULL,
+    PRIMARY KEY (provider, endpoint, lookup_key)
+);
+-- +goose StatementEnd
+
+-- Per-artist invalidation deletes by lookup_key across every provider and
+-- endpoint; without this index that is a full scan.
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_provider_response_cache_lookup_key
+    ON provider_response_cache(lookup_key);
+-- +goose StatementEnd
+
+-- Expired-row pruning.
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_provider_response_cache_expires_at
+    ON provider_response_cache(expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_provider_response_cache_expires_at;
+-- +goose StatementEnd
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_provider_response_cache_lookup_key;
+-- +goose StatementEnd
+-- +goose StatementBegin
+DROP TABLE IF EXISTS provider_response_cache;
+-- +goose StatementEnd
@@ -0,0 +1,71 @@
+-- +goose Up
+-- First-class albums. Until now the only album data Stillwater held was the
+-- <album> title/year/release-group triple embedded in artist.nfo, plus the
+-- directory names ListLocalAlbums reads on demand. Neither gives an album an
+-- identity of its own, so album.nfo drift on Emby/Jellyfin had nothing to be
+-- compared against and nothing to be locked.
+--
+-- One row per album DIRECTORY under an artist directory. The directory is the
+-- identity (path is UNIQUE): an album rename on disk is a delete plus an insert,
+-- the same rule artists follow.
+--
+--   musicbrainz_release_group_id  the release group the directory was matched
+--                                 to (CompareAlbums against the artist's MB
+--                                 release groups) or read from album.nfo.
+--   musicbrainz_album_id          the specific release MBID, only ever read
+--                                 from album.nfo; Stillwater does not choose a
+--                                 release within a group.
+--   locked / locked_fields        same semantics as the artists columns: the
+--                                 whole-album lock blocks every automated
+--                                 write; locked_fields pins individual fields.
+--   thumb_exists / discart_exists per-album artwork (cover and disc art) found
+--                                 in the album directory at the last scan.
+--
+-- Genres, styles and moods are JSON arrays, as on artists.
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS albums (
+    id TEXT PRIMARY KEY,
+    artist_id TEXT NOT NULL REFERENCES artists(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    path TEXT NOT NULL UNIQUE,
+    year TEXT NOT NULL DEFAULT '',
+    release_date TEXT NOT NULL DEFAULT '',
+    original_release_date TEXT NOT NULL DEFAULT '',
+    release_type TEXT NOT NULL DEFAULT '',
+    label TEXT NOT NULL DEFAULT '',
+    genres TEXT NOT NULL DEFAULT '[]',
+    styles TEXT NOT NULL DEFAULT '[]',
+    moods TEXT NOT NULL DEFAULT '[]',
+    review TEXT NOT NULL DEFAULT '',
+    musicbrainz_release_group_id TEXT NOT NULL DEFAULT '',
+    musicbrainz_album_id TEXT NOT NULL DEFAULT '',
+    nfo_exists INTEGER NOT NULL DEFAULT 0,
+    thumb_exists INTEGER NOT NULL DEFAULT 0,
+    discart_exists INTEGER NOT NULL DEFAULT 0,
+    locked INTEGER NOT NULL DEFAULT 0,
+    locked_fields TEXT NOT NULL DEFAULT '[]',
+    last_scanned_at TEXT,
+    created_at TEXT NOT NULL DEFAULT (datetime('now')),
+    updated_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_albums_artist_id ON albums(artist_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_albums_release_group ON albums(musicbrainz_release_group_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_albums_release_group;
+-- +goose StatementEnd
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_albums_artist_id;
+-- +goose StatementEnd
+-- +goose StatementBegin
+DROP TABLE IF EXISTS albums;
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- +goose Up
+-- Operator-defined rules. Every rule used to be compiled in: the rules table
+-- held only the operator's knobs (enabled, automation_mode, config) for a
+-- fixed catalogue, and any house-style check beyond those knobs lived in
+-- ad-hoc SQL outside Stillwater. A custom rule is an ordinary rules row with a
+-- non-empty expression (see internal/ruleexpr) that rule.Engine compiles and
+-- evaluates alongside the built-in checkers, so its violations flow through
+-- the same rule_violations / rule_results tables and count toward the same
+-- health score.
+--
+--   expression  the ruleexpr predicate; '' for every built-in rule, and that
+--               emptiness is what distinguishes the two kinds. A second
+--               "is_custom" flag would be a fact that could disagree with it.
+--   message     the violation message template for a custom rule ('' for
+--               built-ins, whose checkers compose their own messages).
+--
+-- Severity is not a new column: RuleConfig.Severity already carries it for
+-- every rule, and a custom rule uses the same field.
+-- +goose StatementBegin
+ALTER TABLE rules ADD COLUMN expression TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+-- +goose StatementBegin
+ALTER TABLE rules ADD COLUMN message TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- Custom rules cannot exist without their expression, so they are deleted
+-- before the column goes; ON DELETE CASCADE takes their violations and
+-- rule_results rows with them. Built-in rules are untouched.
+-- +goose StatementBegin
+DELETE FROM rules WHERE expression != '';
+-- +goose StatementEnd
+-- +goose StatementBegin
+ALTER TABLE rules DROP COLUMN message;
+-- +goose StatementEnd
+-- +goose StatementBegin
+ALTER TABLE rules DROP COLUMN expression;
+-- +goose StatementEnd
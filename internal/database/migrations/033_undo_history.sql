@@ -0,0 +1,88 @@
+-- +goose Up
+-- Durable undo. rule.UndoStore used to be an in-memory map of revert closures
+-- with a 30-second expiry: every undo token died with the process, and a token
+-- covered only the single fix just applied. A bulk fetch-metadata run across
+-- hundreds of artists could only be unwound one field at a time through
+-- /history/{id}/revert.
+--
+-- An undo record is now data rather than a closure. It holds everything needed
+-- to put one artist back the way it was before one change:
+--
+--   undo_records  one row per applied change (a single fix, or one artist's
+--                 share of a bulk job). violation_id / rule_id / bulk_job_id
+--                 say what produced it ('' when not applicable); old_path /
+--                 new_path record a directory rename.
+--   undo_files    the pre-change content of each file the change touched
+--                 (existed=0 means the change created it), plus post_hash,
+--                 the SHA-256 of the file as the change left it ('' when the
+--                 change left no file). Revert compares the file on disk with
+--                 post_hash to detect that something LATER rewrote it.
+--   undo_fields   the artist field values before and after the change. The
+--                 same later-change check applies: a field is only restored
+--                 while it still holds new_value.
+--
+-- status moves applied -> reverting -> reverted. 'reverting' is a claim: the
+-- UPDATE ... WHERE status = 'applied' that sets it is what stops two
+-- concurrent reverts of the same record from both running. bulk_job_id has no
+-- foreign key: a job's records stay revertable after the job row is pruned.
+-- Records are pruned by age (rule.UndoRetention), not by count.
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS undo_records (
+    id TEXT PRIMARY KEY,
+    artist_id TEXT NOT NULL REFERENCES artists(id) ON DELETE CASCADE,
+    violation_id TEXT NOT NULL DEFAULT '',
+    rule_id TEXT NOT NULL DEFAULT '',
+    bulk_job_id TEXT NOT NULL DEFAULT '',
+    summary TEXT NOT NULL DEFAULT '',
+    old_path TEXT NOT NULL DEFAULT '',
+    new_path TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'applied',
+    created_at TEXT NOT NULL,
+    reverted_at TEXT
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_undo_records_artist ON undo_records(artist_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_undo_records_bulk_job ON undo_records(bulk_job_id, created_at DESC) WHERE bulk_job_id != '';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_undo_records_created ON undo_records(created_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS undo_files (
+    undo_id TEXT NOT NULL REFERENCES undo_records(id) ON DELETE CASCADE,
+    path TEXT NOT NULL,
+    existed INTEGER NOT NULL DEFAULT 0,
+    content BLOB,
+    post_hash TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (undo_id, path)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS undo_fields (
+    undo_id TEXT NOT NULL REFERENCES undo_records(id) ON DELETE CASCADE,
+    field TEXT NOT NULL,
+    old_value TEXT NOT NULL DEFAULT '',
+    new_value TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (undo_id, field)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS undo_fields;
+-- +goose StatementEnd
+-- +goose StatementBegin
+DROP TABLE IF EXISTS undo_files;
+-- +goose StatementEnd
+-- +goose StatementBegin
+DROP TABLE IF EXISTS undo_records;
+-- +goose StatementEnd
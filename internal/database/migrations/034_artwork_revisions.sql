@@ -0,0 +1,59 @@
+-- +goose Up
+-- Artwork revision history. image.BackupSingleSlot / BackupSlot keep exactly
+-- one pre-edit original per slot under .sw-backup/, so a second crop, trim or
+-- replace destroys the image before it -- and an operator who notices weeks
+-- later that a fixer swapped a good logo for a worse one has nothing to go back
+-- to.
+--
+-- artwork_revisions is the index of every version internal/artwork.Store has
+-- seen of each artist image slot. The bytes themselves are NOT stored here:
+-- they live content-addressed on disk (<data dir>/artwork-revisions/objects/
+-- <sha256[:2]>/<sha256>), so a revision row is cheap and identical images
+-- shared by many revisions or artists are stored once. content_hash is that
+-- SHA-256.
+--
+-- slot is the image type for the single-slot kinds (thumb/logo/banner) and the
+-- extension-less file name for fanart (fanart, fanart1, backdrop2...).
+-- source..mode are the Stillwater provenance embedded in the image (see
+-- image.ExifMeta), '' when it carried none; action says what produced the
+-- revision ("observed", "upload", "crop", "rule:logo_padding", "restore"...).
+--
+-- Retention is per (artist_id, image_type, slot) and enforced by the store, not
+-- the schema. Blobs no row references any more are removed by its cleanup pass.
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS artwork_revisions (
+    id TEXT PRIMARY KEY,
+    artist_id TEXT NOT NULL REFERENCES artists(id) ON DELETE CASCADE,
+    image_type TEXT NOT NULL,
+    slot TEXT NOT NULL,
+    content_hash TEXT NOT NULL,
+    size_bytes INTEGER NOT NULL DEFAULT 0,
+    format TEXT NOT NULL DEFAULT '',
+    source TEXT NOT NULL DEFAULT '',
+    url TEXT NOT NULL DEFAULT '',
+    dhash TEXT NOT NULL DEFAULT '',
+    rule TEXT NOT NULL DEFAULT '',
+    mode TEXT NOT NULL DEFAULT '',
+    fetched_at TEXT,
+    action TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_artwork_revisions_slot ON artwork_revisions(artist_id, image_type, slot, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_artwork_revisions_hash ON artwork_revisions(content_hash);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_artwork_revisions_created ON artwork_revisions(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS artwork_revisions;
+-- +goose StatementEnd
@@ -0,0 +1,62 @@
+-- +goose Up
+-- Outbound webhook signing and delivery log.
+--
+-- webhooks.secret is the per-webhook HMAC-SHA256 signing secret, encrypted at
+-- rest with the same encryption.Encryptor as connection API keys and the
+-- inbound webhook secrets. '' means the webhook is unsigned (the behavior of
+-- every webhook created before this migration).
+--
+-- webhook_deliveries records every outbound delivery: the exact body sent, the
+-- final response status and latency, and how many attempts it took. Before
+-- this table the dispatcher logged "exhausted retries" and the event was gone;
+-- now a receiver that was down can have its failed deliveries replayed.
+--
+-- status is 'pending' while attempts are in flight, then 'succeeded' or
+-- 'failed'. response_status is the last HTTP status received (0 when no
+-- response arrived at all) and latency_ms the duration of the last attempt.
+-- replay_of is the ID of the original delivery a replay re-sends ('' for an
+-- original), so a chain of replays always points at the delivery the receiver
+-- first missed. Rows go with their webhook.
+
+-- +goose StatementBegin
+ALTER TABLE webhooks ADD COLUMN secret TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id TEXT PRIMARY KEY,
+    webhook_id TEXT NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_type TEXT NOT NULL,
+    request_body TEXT NOT NULL,
+    content_type TEXT NOT NULL DEFAULT 'application/json',
+    status TEXT NOT NULL DEFAULT 'pending',
+    response_status INTEGER NOT NULL DEFAULT 0,
+    latency_ms INTEGER NOT NULL DEFAULT 0,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    replay_of TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL,
+    completed_at TEXT
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at DESC);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_replay_of ON webhook_deliveries(replay_of);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created ON webhook_deliveries(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS webhook_deliveries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE webhooks DROP COLUMN secret;
+-- +goose StatementEnd
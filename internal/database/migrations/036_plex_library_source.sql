@@ -0,0 +1,55 @@
+-- +goose Up
+-- Plex connections: allow 'plex' as an artist_libraries.source value.
+--
+-- A Plex connection library records its memberships with source 'plex', the
+-- same way Emby, Jellyfin and Lidarr libraries record theirs. SQLite cannot
+-- ALTER a CHECK constraint in place, so the table is rebuilt: create-new +
+-- INSERT SELECT + drop-old + rename, then re-create idx_artist_libraries_library
+-- from 001. No other table references artist_libraries.
+
+-- +goose StatementBegin
+PRAGMA foreign_keys = OFF;
+
+CREATE TABLE artist_libraries_new (
+    artist_id  TEXT NOT NULL REFERENCES artists(id)   ON DELETE CASCADE,
+    library_id TEXT NOT NULL REFERENCES libraries(id) ON DELETE CASCADE,
+    source     TEXT NOT NULL CHECK (source IN ('filesystem','emby','jellyfin','lidarr','plex','manual')),
+    added_at   TEXT NOT NULL DEFAULT (datetime('now')),
+    PRIMARY KEY (artist_id, library_id)
+);
+
+INSERT INTO artist_libraries_new (artist_id, library_id, source, added_at)
+SELECT artist_id, library_id, source, added_at FROM artist_libraries;
+
+DROP TABLE artist_libraries;
+ALTER TABLE artist_libraries_new RENAME TO artist_libraries;
+
+CREATE INDEX idx_artist_libraries_library ON artist_libraries(library_id);
+
+PRAGMA foreign_keys = ON;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+PRAGMA foreign_keys = OFF;
+
+-- Plex memberships have no legal value under the old CHECK; drop them.
+CREATE TABLE artist_libraries_new (
+    artist_id  TEXT NOT NULL REFERENCES artists(id)   ON DELETE CASCADE,
+    library_id TEXT NOT NULL REFERENCES libraries(id) ON DELETE CASCADE,
+    source     TEXT NOT NULL CHECK (source IN ('filesystem','emby','jellyfin','lidarr','manual')),
+    added_at   TEXT NOT NULL DEFAULT (datetime('now')),
+    PRIMARY KEY (artist_id, library_id)
+);
+
+INSERT INTO artist_libraries_new (artist_id, library_id, source, added_at)
+SELECT artist_id, library_id, source, added_at FROM artist_libraries
+WHERE source != 'plex';
+
+DROP TABLE artist_libraries;
+ALTER TABLE artist_libraries_new RENAME TO artist_libraries;
+
+CREATE INDEX idx_artist_libraries_library ON artist_libraries(library_id);
+
+PRAGMA foreign_keys = ON;
+-- +goose StatementEnd
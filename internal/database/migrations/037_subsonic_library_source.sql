@@ -0,0 +1,53 @@
+-- +goose Up
+-- Subsonic connections: allow 'subsonic' as an artist_libraries.source value.
+--
+-- Same rebuild as 036 (which added 'plex'): SQLite cannot ALTER a CHECK
+-- constraint in place, so the table is re-created with the wider CHECK, the
+-- rows copied across, and idx_artist_libraries_library re-created.
+
+-- +goose StatementBegin
+PRAGMA foreign_keys = OFF;
+
+CREATE TABLE artist_libraries_new (
+    artist_id  TEXT NOT NULL REFERENCES artists(id)   ON DELETE CASCADE,
+    library_id TEXT NOT NULL REFERENCES libraries(id) ON DELETE CASCADE,
+    source     TEXT NOT NULL CHECK (source IN ('filesystem','emby','jellyfin','lidarr','plex','subsonic','manual')),
+    added_at   TEXT NOT NULL DEFAULT (datetime('now')),
+    PRIMARY KEY (artist_id, library_id)
+);
+
+INSERT INTO artist_libraries_new (artist_id, library_id, source, added_at)
+SELECT artist_id, library_id, source, added_at FROM artist_libraries;
+
+DROP TABLE artist_libraries;
+ALTER TABLE artist_libraries_new RENAME TO artist_libraries;
+
+CREATE INDEX idx_artist_libraries_library ON artist_libraries(library_id);
+
+PRAGMA foreign_keys = ON;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+PRAGMA foreign_keys = OFF;
+
+-- Subsonic memberships have no legal value under the old CHECK; drop them.
+CREATE TABLE artist_libraries_new (
+    artist_id  TEXT NOT NULL REFERENCES artists(id)   ON DELETE CASCADE,
+    library_id TEXT NOT NULL REFERENCES libraries(id) ON DELETE CASCADE,
+    source     TEXT NOT NULL CHECK (source IN ('filesystem','emby','jellyfin','lidarr','plex','manual')),
+    added_at   TEXT NOT NULL DEFAULT (datetime('now')),
+    PRIMARY KEY (artist_id, library_id)
+);
+
+INSERT INTO artist_libraries_new (artist_id, library_id, source, added_at)
+SELECT artist_id, library_id, source, added_at FROM artist_libraries
+WHERE source != 'subsonic';
+
+DROP TABLE artist_libraries;
+ALTER TABLE artist_libraries_new RENAME TO artist_libraries;
+
+CREATE INDEX idx_artist_libraries_library ON artist_libraries(library_id);
+
+PRAGMA foreign_keys = ON;
+-- +goose StatementEnd
@@ -0,0 +1,53 @@
+-- +goose Up
+-- Durable event outbox.
+--
+-- The in-process event bus used to hand every event to a 256-slot channel and
+-- drop it with a warning when the channel was full, which is exactly what
+-- happens during a bulk fix: rule.violation, metadata.fixed and every other
+-- webhook-bound event of a large run could vanish. Event types that webhooks,
+-- notifications and the SSE banner depend on (event.IsDurable) are now
+-- appended to event_outbox instead and read back by the subscribers, so a
+-- burst only makes them late, never lost. Ephemeral types such as
+-- operation.progress still go through the channel.
+--
+-- id is AUTOINCREMENT so it is never reused after old rows are pruned: the
+-- cursors below are "last id handled", and a reused id would make a
+-- subscriber skip the event that took it. payload is the event's Data as
+-- JSON; occurred_at is the event's own timestamp and created_at when it was
+-- stored (the retention window is measured on the latter).
+--
+-- event_outbox_cursors holds one row per named durable subscriber (the
+-- webhook dispatcher is "webhooks"). A subscriber resumes after its cursor on
+-- restart, so events published while it was stopped or behind are delivered
+-- at least once.
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL DEFAULT '{}',
+    occurred_at TEXT NOT NULL,
+    created_at TEXT NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_event_outbox_created ON event_outbox(created_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS event_outbox_cursors (
+    subscriber TEXT PRIMARY KEY,
+    last_id INTEGER NOT NULL DEFAULT 0,
+    updated_at TEXT NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS event_outbox_cursors;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS event_outbox;
+-- +goose StatementEnd
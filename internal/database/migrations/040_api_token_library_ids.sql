@@ -0,0 +1,17 @@
+-- +goose Up
+-- Per-library API token restriction.
+--
+-- library_ids is a comma-separated list of library IDs the token may touch;
+-- the empty string (every existing token) means "no restriction". It is not a
+-- foreign key: a token restricted to a library that is later deleted keeps
+-- the dead ID and simply matches nothing, rather than silently widening to
+-- every library the way an ON DELETE SET DEFAULT would.
+
+-- +goose StatementBegin
+ALTER TABLE api_tokens ADD COLUMN library_ids TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE api_tokens DROP COLUMN library_ids;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- Per-library user grants, and the grant an invite hands to the account it
+-- creates.
+--
+-- library_ids is a comma-separated list of library IDs, with the same shape
+-- and the same "empty means every library" meaning as api_tokens.library_ids
+-- (040). Administrators are never restricted; the column is only consulted
+-- for operators and viewers. It is not a foreign key, for the reason given in
+-- 040: a deleted library must leave a dead ID that matches nothing rather
+-- than widen the grant to every library.
+
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN library_ids TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE invites ADD COLUMN library_ids TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE invites DROP COLUMN library_ids;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN library_ids;
+-- +goose StatementEnd
@@ -0,0 +1,70 @@
+-- +goose Up
+-- TOTP two-factor authentication for local accounts.
+--
+-- totp_secret holds the base32 shared secret encrypted with the instance key
+-- (never plaintext). A secret with totp_enabled = 0 is a pending enrollment
+-- that has not been confirmed with a code yet; it does not gate login.
+-- totp_last_step is the RFC 6238 time step of the last accepted code, so a
+-- code cannot be replayed within its validity window.
+--
+-- user_recovery_codes holds the single-use recovery codes handed out at
+-- enrollment, as SHA-256 hashes. login_challenges holds the short-lived
+-- second-step tickets issued after a correct password; id is the hash of the
+-- ticket, the same at-rest treatment as sessions.
+
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN totp_secret TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN totp_enabled INTEGER NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN totp_last_step INTEGER NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash  TEXT NOT NULL,
+    used_at    TEXT,
+    created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON user_recovery_codes(user_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS login_challenges (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TEXT NOT NULL,
+    attempts   INTEGER NOT NULL DEFAULT 0,
+    created_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS login_challenges;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS user_recovery_codes;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN totp_last_step;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN totp_enabled;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN totp_secret;
+-- +goose StatementEnd
@@ -0,0 +1,54 @@
+-- +goose Up
+-- Session metadata for the session management endpoints.
+--
+-- sessions.id is the SHA-256 of the cookie token and never leaves the
+-- database, so public_id is the handle the API lists and revokes sessions by.
+-- user_agent and ip_address are captured when the session is created;
+-- last_seen_at is refreshed (at most once a minute) as the session is used.
+-- Sessions that exist before this migration get a fresh public_id and start
+-- with last_seen_at = created_at.
+
+-- +goose StatementBegin
+ALTER TABLE sessions ADD COLUMN public_id TEXT;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE sessions ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE sessions ADD COLUMN ip_address TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE sessions ADD COLUMN last_seen_at TEXT;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE sessions SET public_id = lower(hex(randomblob(16))), last_seen_at = created_at;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_public_id ON sessions(public_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_sessions_public_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE sessions DROP COLUMN last_seen_at;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE sessions DROP COLUMN ip_address;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE sessions DROP COLUMN user_agent;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE sessions DROP COLUMN public_id;
+-- +goose StatementEnd
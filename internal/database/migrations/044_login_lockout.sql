@@ -0,0 +1,87 @@
+-- +goose Up
+-- Login brute-force protection.
+--
+-- login_failures counts failed password sign-ins per username (scope 'user')
+-- and per client address (scope 'ip'). failures restarts from zero once
+-- last_failure_at is older than the configured window; locked_until is set
+-- once failures reaches the threshold and grows with each further failure.
+-- The key for scope 'user' is the username exactly as typed, whether or
+-- not an account by that name exists, so probing unknown names is throttled
+-- the same way.
+--
+-- audit_log.user_id becomes nullable so a lockout that has no account behind
+-- it (an address, or a username nobody holds) can still be recorded. SQLite
+-- cannot drop NOT NULL in place, so the table is rebuilt with the 001 and 012
+-- columns, foreign keys and indexes.
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS login_failures (
+    scope           TEXT NOT NULL CHECK (scope IN ('user','ip')),
+    key             TEXT NOT NULL,
+    failures        INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TEXT NOT NULL,
+    locked_until    TEXT,
+    PRIMARY KEY (scope, key)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+PRAGMA foreign_keys = OFF;
+
+CREATE TABLE audit_log_new (
+    id             TEXT PRIMARY KEY,
+    action         TEXT NOT NULL,
+    token_id       TEXT REFERENCES api_tokens(id) ON DELETE SET NULL,
+    token_name     TEXT NOT NULL,
+    user_id        TEXT REFERENCES users(id) ON DELETE CASCADE,
+    detail         TEXT NOT NULL DEFAULT '',
+    created_at     TEXT NOT NULL DEFAULT (datetime('now')),
+    actor_user_id  TEXT REFERENCES users(id) ON DELETE SET NULL,
+    target_user_id TEXT REFERENCES users(id) ON DELETE SET NULL
+);
+
+INSERT INTO audit_log_new (id, action, token_id, token_name, user_id, detail, created_at, actor_user_id, target_user_id)
+SELECT id, action, token_id, token_name, user_id, detail, created_at, actor_user_id, target_user_id FROM audit_log;
+
+DROP TABLE audit_log;
+ALTER TABLE audit_log_new RENAME TO audit_log;
+
+CREATE INDEX idx_audit_log_token_id ON audit_log(token_id);
+CREATE INDEX idx_audit_log_user_id ON audit_log(user_id);
+
+PRAGMA foreign_keys = ON;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+PRAGMA foreign_keys = OFF;
+
+-- Entries without an account have no legal value under NOT NULL; drop them.
+CREATE TABLE audit_log_new (
+    id             TEXT PRIMARY KEY,
+    action         TEXT NOT NULL,
+    token_id       TEXT REFERENCES api_tokens(id) ON DELETE SET NULL,
+    token_name     TEXT NOT NULL,
+    user_id        TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    detail         TEXT NOT NULL DEFAULT '',
+    created_at     TEXT NOT NULL DEFAULT (datetime('now')),
+    actor_user_id  TEXT REFERENCES users(id) ON DELETE SET NULL,
+    target_user_id TEXT REFERENCES users(id) ON DELETE SET NULL
+);
+
+INSERT INTO audit_log_new (id, action, token_id, token_name, user_id, detail, created_at, actor_user_id, target_user_id)
+SELECT id, action, token_id, token_name, user_id, detail, created_at, actor_user_id, target_user_id FROM audit_log
+WHERE user_id IS NOT NULL;
+
+DROP TABLE audit_log;
+ALTER TABLE audit_log_new RENAME TO audit_log;
+
+CREATE INDEX idx_audit_log_token_id ON audit_log(token_id);
+CREATE INDEX idx_audit_log_user_id ON audit_log(user_id);
+
+PRAGMA foreign_keys = ON;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS login_failures;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- Per-library directory layout. layout is a path template, relative to the
+-- library path, naming what each directory level holds, such as
+-- '{letter}/{artist}' for letter buckets or '{group}/{artist}' for genre or
+-- label folders. The empty string is the flat layout every library had
+-- before: artist directories directly in the library path.
+ALTER TABLE libraries ADD COLUMN layout TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE libraries DROP COLUMN layout;
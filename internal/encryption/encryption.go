@@ -27,23 +27,12 @@ func NewEncryptor(key string) (*Encryptor, string, error) {
 		}
 		key = base64.StdEncoding.EncodeToString(keyBytes)
 	} else {
-		decoded, err := base64.StdEncoding.DecodeString(key)
-		if err != nil {
-			// Try using the key as raw bytes (for testing)
-			if len(key) == 32 {
-				keyBytes = []byte(key)
-			} else {
-				return nil, "", fmt.Errorf("decoding encryption key: %w", err)
-			}
-		} else {
-			keyBytes = decoded
+		var err error
+		if keyBytes, err = ParseKey(key); err != nil {
+			return nil, "", err
 		}
 	}
 
-	if len(keyBytes) != 32 {
-		return nil, "", fmt.Errorf("encryption key must be 32 bytes, got %d", len(keyBytes))
-	}
-
 	block, err := aes.NewCipher(keyBytes)
 	if err != nil {
 		return nil, "", fmt.Errorf("creating AES cipher: %w", err)
@@ -57,6 +46,22 @@ func NewEncryptor(key string) (*Encryptor, string, error) {
 	return &Encryptor{gcm: gcm}, key, nil
 }
 
+// ParseKey decodes a 32-byte key given as base64, or as 32 raw bytes.
+func ParseKey(key string) ([]byte, error) {
+	keyBytes, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		// Try using the key as raw bytes (for testing)
+		if len(key) != 32 {
+			return nil, fmt.Errorf("decoding encryption key: %w", err)
+		}
+		keyBytes = []byte(key)
+	}
+	if len(keyBytes) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(keyBytes))
+	}
+	return keyBytes, nil
+}
+
 // Encrypt encrypts plaintext and returns a base64-encoded ciphertext.
 func (e *Encryptor) Encrypt(plaintext string) (string, error) {
 	nonce := make([]byte, e.gcm.NonceSize())
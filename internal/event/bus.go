@@ -1,6 +1,7 @@
 package event
 
 import (
+	"context"
 	"log/slog"
 	"sync"
 	"time"
@@ -79,6 +80,24 @@ const (
 	// hunting for the one case this rule does not raise.
 	MBIDRevalidationSummary Type = "mbid.revalidation.summary"
 
+	// SecurityLockout fires when repeated failed passwords lock a username or
+	// a client address out of sign-in (see auth.LockoutPolicy). It is
+	// webhook-subscribable so an operator can be alerted to a guessing
+	// attack. Data carries scope ("user" or "ip"), key (the username or
+	// address), user_id when the username belongs to an account, failures,
+	// locked_until (RFC 3339), ip_address (the last failing client) and a
+	// one-line message.
+	SecurityLockout Type = "security.lockout"
+
+	// BackupPushFailed fires when a backup could not be copied to an
+	// off-box target (S3, SFTP), or the copy failed its read-back check, or
+	// the target's retention could not be applied (see backup.Service.Push).
+	// The push runs from the scheduler with no caller to report to, so the
+	// SSE hub broadcasts it as an error toast and it is webhook-subscribable
+	// for alerting. Data carries target, filename, stage (upload, verify or
+	// prune) and a one-line message; the raw error stays in the log.
+	BackupPushFailed Type = "backup.push_failed"
+
 	// --- M55 next-channel events (catalog defined by #1341) ---
 
 	// ActivityRecent carries a single recent-activity item for the next
@@ -130,7 +149,32 @@ func NewActivityRecent(kind, text, artistID string) Event {
 // Handler is a function that processes an event.
 type Handler func(Event)
 
+// Outbox tuning. Package variables so tests can shorten the intervals.
+var (
+	// outboxBatchSize is how many outbox rows a reader loads per query.
+	outboxBatchSize = 100
+	// outboxPollInterval is how often readers look for rows they were not
+	// woken for: rows appended by a Publish whose wake signal coalesced with
+	// an earlier one, and rows a durable subscriber left behind after a
+	// handler or cursor-save failure.
+	outboxPollInterval = 5 * time.Second
+	// outboxPruneInterval is how often the bus deletes rows older than the
+	// outbox's retention window.
+	outboxPruneInterval = time.Hour
+	// outboxAppendTimeout bounds the outbox write inside Publish. The
+	// database is single-connection, so a Publish issued while the caller
+	// still holds a transaction would otherwise wait forever; after the
+	// timeout the event takes the in-memory path instead.
+	outboxAppendTimeout = 5 * time.Second
+)
+
 // Bus is an in-process event bus backed by a buffered channel.
+//
+// With an outbox installed (SetOutbox), durable event types (IsDurable) are
+// written to SQLite instead of the channel, so a full buffer can no longer
+// drop them. Plain subscribers still receive them on the dispatch goroutine;
+// SubscribeDurable subscribers read them with a persisted cursor and resume
+// where they left off after a restart.
 type Bus struct {
 	ch      chan Event
 	mu      sync.RWMutex
@@ -138,6 +182,18 @@ type Bus struct {
 	logger  *slog.Logger
 	done    chan struct{}
 	stopped bool
+
+	outbox *Outbox
+	// wake nudges the dispatch goroutine after a durable Publish. Capacity
+	// 1: a pending signal already covers every row appended before the
+	// reader runs.
+	wake chan struct{}
+	// liveCursor is the last outbox ID dispatched to plain subscribers.
+	// Only the Start goroutine reads or writes it after SetOutbox.
+	liveCursor int64
+	// durableWakes holds one wake channel per SubscribeDurable reader.
+	durableWakes []chan struct{}
+	durableWG    sync.WaitGroup
 }
 
 // NewBus creates a new event bus with the given buffer size.
@@ -150,9 +206,28 @@ func NewBus(logger *slog.Logger, bufSize int) *Bus {
 		subs:   make(map[Type][]Handler),
 		logger: logger,
 		done:   make(chan struct{}),
+		wake:   make(chan struct{}, 1),
 	}
 }
 
+// SetOutbox routes durable event types through o from now on. Call it once,
+// before Start and before any SubscribeDurable. Plain subscribers only see
+// durable events published after this call: rows already in the outbox
+// belong to the durable subscribers' cursors, not to the in-process
+// listeners (the SSE hub has its own replay buffer for reconnecting
+// browsers).
+func (b *Bus) SetOutbox(o *Outbox) error {
+	head, err := o.Head(context.Background())
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.outbox = o
+	b.liveCursor = head
+	return nil
+}
+
 // Subscribe registers a handler for the given event type.
 func (b *Bus) Subscribe(t Type, h Handler) {
 	b.mu.Lock()
@@ -160,8 +235,67 @@ func (b *Bus) Subscribe(t Type, h Handler) {
 	b.subs[t] = append(b.subs[t], h)
 }
 
-// Publish sends an event to the bus. Non-blocking; drops with a warning
-// if the buffer is full.
+// SubscribeDurable registers h for types under the persistent cursor name.
+// Durable types among them are read from the outbox on a goroutine of their
+// own, in outbox order, with the cursor saved after each batch: delivery is
+// at-least-once, so a crash between a handler call and the cursor save
+// repeats that batch on the next start, and h must tolerate a repeat. A name
+// seen for the first time starts at the current head rather than replaying
+// the whole retention window. Non-durable types in the list, and every type
+// when no outbox is installed, fall back to Subscribe.
+//
+// Events read from the outbox have been through JSON: see Outbox.
+func (b *Bus) SubscribeDurable(name string, types []Type, h Handler) {
+	b.mu.Lock()
+	o := b.outbox
+	b.mu.Unlock()
+
+	want := make(map[Type]bool, len(types))
+	for _, t := range types {
+		if o == nil || !IsDurable(t) {
+			b.Subscribe(t, h)
+			continue
+		}
+		want[t] = true
+	}
+	if len(want) == 0 {
+		return
+	}
+
+	ctx := context.Background()
+	cursor, ok, err := o.Cursor(ctx, name)
+	if err == nil && !ok {
+		cursor, err = o.Head(ctx)
+		if err == nil {
+			err = o.SaveCursor(ctx, name, cursor)
+		}
+	}
+	if err != nil {
+		// Without a cursor the reader cannot know where it stands. Starting
+		// from head loses nothing published from here on, which is the best
+		// the bus can offer until the database recovers.
+		b.logger.Error("reading durable subscriber cursor; starting from head",
+			"subscriber", name, "error", err)
+		cursor, _ = o.Head(ctx)
+	}
+
+	wake := make(chan struct{}, 1)
+	wake <- struct{}{} // catch up on anything behind the saved cursor
+	b.mu.Lock()
+	b.durableWakes = append(b.durableWakes, wake)
+	b.mu.Unlock()
+
+	b.durableWG.Add(1)
+	go b.runDurable(name, want, h, cursor, wake)
+}
+
+// Publish sends an event to the bus. Non-blocking for in-memory events,
+// which are dropped with a warning if the buffer is full.
+//
+// Durable events (IsDurable) are appended to the outbox instead when one is
+// installed, so backpressure delays them rather than dropping them. A
+// failed append (database locked past outboxAppendTimeout, disk full) is
+// logged and the event falls back to the channel.
 //
 // ConnectionPushFailed is escalated to slog.Error with the full Data
 // payload because it is low-volume + high-importance: a silent drop under
@@ -173,6 +307,9 @@ func (b *Bus) Publish(e Event) {
 	if e.Timestamp.IsZero() {
 		e.Timestamp = time.Now().UTC()
 	}
+	if IsDurable(e.Type) && b.appendDurable(e) {
+		return
+	}
 	select {
 	case b.ch <- e:
 	default:
@@ -187,13 +324,64 @@ func (b *Bus) Publish(e Event) {
 	}
 }
 
+// appendDurable writes e to the outbox and wakes its readers. It reports
+// false when there is no outbox or the write failed, leaving e to the
+// in-memory path.
+func (b *Bus) appendDurable(e Event) bool {
+	b.mu.RLock()
+	o := b.outbox
+	wakes := b.durableWakes
+	b.mu.RUnlock()
+	if o == nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), outboxAppendTimeout)
+	defer cancel()
+	if _, err := o.Append(ctx, e); err != nil {
+		b.logger.Error("writing event to outbox; falling back to in-memory delivery",
+			"type", string(e.Type), "error", err)
+		return false
+	}
+	signal(b.wake)
+	for _, w := range wakes {
+		signal(w)
+	}
+	return true
+}
+
+// signal performs a non-blocking send on a capacity-1 wake channel.
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
 // Start begins draining the channel and dispatching events to subscribers.
 // Call this in a goroutine. It blocks until Stop is called.
+//
+// With an outbox installed it also dispatches newly appended durable events
+// to plain subscribers and prunes the outbox to its retention window.
+// Durable and in-memory events travel separate paths, so their relative
+// order is not preserved; ordering within each path is.
 func (b *Bus) Start() {
+	poll := time.NewTicker(outboxPollInterval)
+	defer poll.Stop()
+	prune := time.NewTicker(outboxPruneInterval)
+	defer prune.Stop()
+	b.pruneOutbox()
+
 	for {
 		select {
 		case e := <-b.ch:
 			b.dispatch(e)
+		case <-b.wake:
+			b.dispatchLive()
+		case <-poll.C:
+			b.dispatchLive()
+		case <-prune.C:
+			b.pruneOutbox()
 		case <-b.done:
 			// Drain remaining events
 			for {
@@ -201,6 +389,7 @@ func (b *Bus) Start() {
 				case e := <-b.ch:
 					b.dispatch(e)
 				default:
+					b.dispatchLive()
 					return
 				}
 			}
@@ -208,14 +397,144 @@ func (b *Bus) Start() {
 	}
 }
 
-// Stop signals the bus to stop processing events after draining the buffer.
+// Stop signals the bus to stop processing events after draining the buffer,
+// and waits for the durable subscribers to finish the batch in hand. Rows
+// they have not reached stay in the outbox for the next start.
 func (b *Bus) Stop() {
 	b.mu.Lock()
-	defer b.mu.Unlock()
 	if !b.stopped {
 		b.stopped = true
 		close(b.done)
 	}
+	b.mu.Unlock()
+	b.durableWG.Wait()
+}
+
+// dispatchLive hands outbox rows after liveCursor to plain subscribers.
+// Delivery here is in-process only: the cursor is not persisted, and a row
+// that fails to load is retried on the next wake or poll.
+func (b *Bus) dispatchLive() {
+	b.mu.RLock()
+	o := b.outbox
+	b.mu.RUnlock()
+	if o == nil {
+		return
+	}
+	for {
+		batch, err := o.ReadAfter(context.Background(), b.liveCursor, outboxBatchSize)
+		if err != nil {
+			b.logger.Error("reading event outbox", "error", err)
+			return
+		}
+		for _, se := range batch {
+			b.dispatch(se.Event)
+			b.liveCursor = se.ID
+		}
+		if len(batch) < outboxBatchSize {
+			return
+		}
+	}
+}
+
+// runDurable is the reader loop behind SubscribeDurable.
+func (b *Bus) runDurable(name string, want map[Type]bool, h Handler, cursor int64, wake chan struct{}) {
+	defer b.durableWG.Done()
+	poll := time.NewTicker(outboxPollInterval)
+	defer poll.Stop()
+	warnedGap := false
+
+	for {
+		select {
+		case <-b.done:
+			return
+		default:
+		}
+		select {
+		case <-b.done:
+			return
+		case <-wake:
+		case <-poll.C:
+		}
+		for {
+			batch, err := b.outbox.ReadAfter(context.Background(), cursor, outboxBatchSize)
+			if err != nil {
+				b.logger.Error("reading event outbox", "subscriber", name, "error", err)
+				break
+			}
+			if len(batch) == 0 {
+				break
+			}
+			if !warnedGap && batch[0].ID > cursor+1 {
+				warnedGap = b.warnPrunedGap(name, cursor)
+			}
+			for _, se := range batch {
+				if want[se.Event.Type] {
+					b.callDurable(name, h, se.Event)
+				}
+			}
+			next := batch[len(batch)-1].ID
+			if err := b.outbox.SaveCursor(context.Background(), name, next); err != nil {
+				// The batch was delivered; it will be delivered again after a
+				// restart. Keep the in-memory position so this run does not
+				// loop on the same rows.
+				b.logger.Error("saving durable subscriber cursor", "subscriber", name, "error", err)
+			}
+			cursor = next
+			if len(batch) < outboxBatchSize {
+				break
+			}
+			select {
+			case <-b.done:
+				return
+			default:
+			}
+		}
+	}
+}
+
+// warnPrunedGap logs when a durable subscriber resumes behind rows the
+// retention window already removed. IDs are also skipped by appends that
+// rolled back, so the gap only counts as a loss when the oldest surviving row
+// is past the cursor. Reports whether it warned.
+func (b *Bus) warnPrunedGap(name string, cursor int64) bool {
+	lowest, _, err := b.outbox.Bounds(context.Background())
+	if err != nil || lowest <= cursor+1 {
+		return false
+	}
+	b.logger.Warn("durable subscriber fell behind the outbox retention window; older events were pruned",
+		"subscriber", name, "cursor", cursor, "oldest_available", lowest,
+		"retention", b.outbox.Retention().String())
+	return true
+}
+
+// callDurable invokes h for one outbox event, recovering a panic so one bad
+// event cannot wedge the reader behind it.
+func (b *Bus) callDurable(name string, h Handler, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Error("durable event handler panicked",
+				"subscriber", name, "type", string(e.Type), "panic", r)
+		}
+	}()
+	h(e)
+}
+
+// pruneOutbox deletes outbox rows older than the retention window.
+func (b *Bus) pruneOutbox() {
+	b.mu.RLock()
+	o := b.outbox
+	b.mu.RUnlock()
+	if o == nil {
+		return
+	}
+	n, err := o.Prune(context.Background(), time.Now().Add(-o.Retention()))
+	if err != nil {
+		b.logger.Error("pruning event outbox", "error", err)
+		return
+	}
+	if n > 0 {
+		b.logger.Info("pruned event outbox", "removed", n)
+	}
 }
 
 func (b *Bus) dispatch(e Event) {
@@ -0,0 +1,44 @@
+package event
+
+import "slices"
+
+// durableEventTypes is the set of event types the bus writes to the outbox
+// (see Outbox) instead of its in-memory channel, once an outbox is installed.
+// These are the events something outside the process, or the operator, is
+// waiting for: every webhook-subscribable type, plus the notification events
+// the SSE hub turns into toasts and the banner refetch signal. Losing one
+// under load means a missed webhook or a failure the operator never hears
+// about.
+//
+// Everything else stays in memory. Those types are either high-volume and
+// superseded by the next event of the same kind (operation.progress,
+// artist.updated, logs.line) or purely cosmetic cross-tab signals, and
+// writing them to SQLite would cost a row per tick of a progress bar.
+//
+// Every webhook type must be durable; TestDurableCoversWebhookEvents holds the
+// two lists together.
+var durableEventTypes = []Type{
+	ArtistNew, MetadataFixed, ReviewNeeded,
+	RuleViolation, BulkCompleted, ScanCompleted,
+	LidarrArtistAdd, LidarrDownload,
+	EmbyArtistUpdate, EmbyLibraryScan,
+	JellyfinArtistUpdate, JellyfinLibraryScan,
+	FSDirCreated, FSDirRemoved, FSUnexpectedWrite,
+	SecurityLockout,
+	BackupPushFailed,
+	ConflictChanged,
+	ConnectionPushFailed,
+	BackdropCollision,
+	MBIDRevalidationSummary,
+}
+
+// DurableEventTypes returns the event types routed through the outbox. It
+// returns a copy, so callers cannot mutate the registry.
+func DurableEventTypes() []Type {
+	return slices.Clone(durableEventTypes)
+}
+
+// IsDurable reports whether t is routed through the outbox.
+func IsDurable(t Type) bool {
+	return slices.Contains(durableEventTypes, t)
+}
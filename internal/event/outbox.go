@@ -0,0 +1,185 @@
+package event
+
+// outbox.go persists durable events (event_outbox) and the named subscribers'
+// read positions (event_outbox_cursors). The bus appends to it from Publish
+// and reads it back from its dispatch goroutine and from one goroutine per
+// durable subscriber; see Bus.SetOutbox.
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultOutboxRetention is how long outbox rows are kept. Long enough for a
+// subscriber that was stopped over a weekend to catch up, short enough that a
+// fix storm's rows do not accumulate indefinitely. A subscriber further behind
+// than this loses the pruned events (logged when it resumes).
+const DefaultOutboxRetention = 7 * 24 * time.Hour
+
+// outboxTimeLayout is a fixed-width RFC 3339 layout, so created_at orders
+// correctly as text within the same second.
+const outboxTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// StoredEvent is an event read back from the outbox with its outbox ID.
+type StoredEvent struct {
+	ID    int64
+	Event Event
+}
+
+// Outbox is the SQLite-backed log durable events are written to.
+//
+// Data round-trips through JSON, so a subscriber fed from the outbox sees
+// numbers as float64 and nested values as map[string]any / []any, exactly as
+// a webhook receiver or browser does.
+type Outbox struct {
+	db        *sql.DB
+	retention time.Duration
+}
+
+// NewOutbox creates an outbox over db with DefaultOutboxRetention.
+func NewOutbox(db *sql.DB) *Outbox {
+	return &Outbox{db: db, retention: DefaultOutboxRetention}
+}
+
+// SetRetention overrides the retention window. Non-positive values are
+// ignored.
+func (o *Outbox) SetRetention(d time.Duration) {
+	if d > 0 {
+		o.retention = d
+	}
+}
+
+// Retention returns the retention window.
+func (o *Outbox) Retention() time.Duration {
+	return o.retention
+}
+
+// Append stores e and returns its outbox ID.
+func (o *Outbox) Append(ctx context.Context, e Event) (int64, error) {
+	payload := []byte("{}")
+	if len(e.Data) > 0 {
+		b, err := json.Marshal(e.Data)
+		if err != nil {
+			return 0, fmt.Errorf("encoding %s event data: %w", e.Type, err)
+		}
+		payload = b
+	}
+	res, err := o.db.ExecContext(ctx, `
+		INSERT INTO event_outbox (event_type, payload, occurred_at, created_at)
+		VALUES (?, ?, ?, ?)
+	`, string(e.Type), string(payload), e.Timestamp.UTC().Format(outboxTimeLayout),
+		time.Now().UTC().Format(outboxTimeLayout))
+	if err != nil {
+		return 0, fmt.Errorf("appending %s event to outbox: %w", e.Type, err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("reading outbox id: %w", err)
+	}
+	return id, nil
+}
+
+// ReadAfter returns up to limit events with an ID greater than afterID, in ID
+// order. A row whose payload no longer decodes is returned with nil Data
+// rather than stalling every reader behind it.
+func (o *Outbox) ReadAfter(ctx context.Context, afterID int64, limit int) ([]StoredEvent, error) {
+	rows, err := o.db.QueryContext(ctx, `
+		SELECT id, event_type, payload, occurred_at FROM event_outbox
+		WHERE id > ? ORDER BY id LIMIT ?
+	`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("reading event outbox: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck // Close error not actionable on cleanup
+
+	var out []StoredEvent
+	for rows.Next() {
+		var (
+			se                    StoredEvent
+			typ, payload, occured string
+		)
+		if err := rows.Scan(&se.ID, &typ, &payload, &occured); err != nil {
+			return nil, fmt.Errorf("scanning event outbox row: %w", err)
+		}
+		se.Event.Type = Type(typ)
+		se.Event.Timestamp, _ = time.Parse(outboxTimeLayout, occured)
+		if payload != "" && payload != "{}" {
+			_ = json.Unmarshal([]byte(payload), &se.Event.Data)
+		}
+		out = append(out, se)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating event outbox: %w", err)
+	}
+	return out, nil
+}
+
+// Bounds returns the lowest and highest IDs currently stored, or 0, 0 when
+// the outbox is empty.
+func (o *Outbox) Bounds(ctx context.Context) (lowest, highest int64, err error) {
+	var lo, hi sql.NullInt64
+	if err := o.db.QueryRowContext(ctx, `SELECT MIN(id), MAX(id) FROM event_outbox`).Scan(&lo, &hi); err != nil {
+		return 0, 0, fmt.Errorf("reading event outbox bounds: %w", err)
+	}
+	return lo.Int64, hi.Int64, nil
+}
+
+// Head returns the ID the next reader starting "from now" should start after:
+// the highest ID ever assigned, which survives pruning an outbox empty.
+func (o *Outbox) Head(ctx context.Context) (int64, error) {
+	var seq sql.NullInt64
+	err := o.db.QueryRowContext(ctx, `SELECT seq FROM sqlite_sequence WHERE name = 'event_outbox'`).Scan(&seq)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading event outbox head: %w", err)
+	}
+	return seq.Int64, nil
+}
+
+// Cursor returns subscriber's last handled ID, and false when the subscriber
+// has never saved one.
+func (o *Outbox) Cursor(ctx context.Context, subscriber string) (int64, bool, error) {
+	var id int64
+	err := o.db.QueryRowContext(ctx, `SELECT last_id FROM event_outbox_cursors WHERE subscriber = ?`, subscriber).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("reading outbox cursor %s: %w", subscriber, err)
+	}
+	return id, true, nil
+}
+
+// SaveCursor records id as subscriber's last handled ID.
+func (o *Outbox) SaveCursor(ctx context.Context, subscriber string, id int64) error {
+	_, err := o.db.ExecContext(ctx, `
+		INSERT INTO event_outbox_cursors (subscriber, last_id, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(subscriber) DO UPDATE SET last_id = excluded.last_id, updated_at = excluded.updated_at
+	`, subscriber, id, time.Now().UTC().Format(outboxTimeLayout))
+	if err != nil {
+		return fmt.Errorf("saving outbox cursor %s: %w", subscriber, err)
+	}
+	return nil
+}
+
+// Prune deletes events stored before cutoff and returns how many were
+// removed. It does not consult the cursors: the retention window bounds the
+// table even when a subscriber never comes back.
+func (o *Outbox) Prune(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := o.db.ExecContext(ctx, `DELETE FROM event_outbox WHERE created_at < ?`,
+		cutoff.UTC().Format(outboxTimeLayout))
+	if err != nil {
+		return 0, fmt.Errorf("pruning event outbox: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("counting pruned outbox rows: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,228 @@
+package event
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/database"
+)
+
+func setupOutboxDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := database.Open(":memory:")
+	if err != nil {
+		t.Fatalf("opening test db: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("running migrations: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestOutbox_AppendReadAfter(t *testing.T) {
+	ctx := context.Background()
+	o := NewOutbox(setupOutboxDB(t))
+
+	ts := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	first, err := o.Append(ctx, Event{Type: RuleViolation, Timestamp: ts, Data: map[string]any{"count": 3, "rule": "nfo_exists"}})
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if _, err := o.Append(ctx, Event{Type: ScanCompleted, Timestamp: ts}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	got, err := o.ReadAfter(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("ReadAfter: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("ReadAfter returned %d events, want 2", len(got))
+	}
+	if got[0].ID != first || got[0].Event.Type != RuleViolation || !got[0].Event.Timestamp.Equal(ts) {
+		t.Errorf("first event = %+v", got[0])
+	}
+	// Data is JSON on disk: numbers come back as float64.
+	if got[0].Event.Data["count"] != float64(3) || got[0].Event.Data["rule"] != "nfo_exists" {
+		t.Errorf("first event data = %v", got[0].Event.Data)
+	}
+	if got[1].Event.Data != nil {
+		t.Errorf("second event data = %v, want nil", got[1].Event.Data)
+	}
+
+	rest, err := o.ReadAfter(ctx, first, 10)
+	if err != nil {
+		t.Fatalf("ReadAfter: %v", err)
+	}
+	if len(rest) != 1 || rest[0].Event.Type != ScanCompleted {
+		t.Errorf("ReadAfter(first) = %+v, want the scan event only", rest)
+	}
+}
+
+func TestOutbox_Cursor(t *testing.T) {
+	ctx := context.Background()
+	o := NewOutbox(setupOutboxDB(t))
+
+	if _, ok, err := o.Cursor(ctx, "webhooks"); err != nil || ok {
+		t.Fatalf("Cursor before save = ok %v, err %v; want not found", ok, err)
+	}
+	for _, id := range []int64{7, 12} {
+		if err := o.SaveCursor(ctx, "webhooks", id); err != nil {
+			t.Fatalf("SaveCursor(%d): %v", id, err)
+		}
+	}
+	id, ok, err := o.Cursor(ctx, "webhooks")
+	if err != nil || !ok || id != 12 {
+		t.Errorf("Cursor = %d, %v, %v; want 12, true, nil", id, ok, err)
+	}
+}
+
+func TestOutbox_PruneKeepsHead(t *testing.T) {
+	ctx := context.Background()
+	db := setupOutboxDB(t)
+	o := NewOutbox(db)
+
+	for range 3 {
+		if _, err := o.Append(ctx, Event{Type: ArtistNew, Timestamp: time.Now()}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	old := time.Now().Add(-30 * 24 * time.Hour).UTC().Format(outboxTimeLayout)
+	if _, err := db.ExecContext(ctx, `UPDATE event_outbox SET created_at = ? WHERE id < 3`, old); err != nil {
+		t.Fatalf("aging rows: %v", err)
+	}
+
+	n, err := o.Prune(ctx, time.Now().Add(-o.Retention()))
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("Prune removed %d rows, want 2", n)
+	}
+	lowest, highest, err := o.Bounds(ctx)
+	if err != nil || lowest != 3 || highest != 3 {
+		t.Errorf("Bounds = %d, %d, %v; want 3, 3, nil", lowest, highest, err)
+	}
+
+	if _, err := o.Prune(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Prune all: %v", err)
+	}
+	// AUTOINCREMENT keeps the head past the pruned rows, so a new reader
+	// never re-reads an ID a cursor already passed.
+	head, err := o.Head(ctx)
+	if err != nil || head != 3 {
+		t.Errorf("Head after pruning everything = %d, %v; want 3, nil", head, err)
+	}
+}
+
+// TestBus_DurableEventsSurviveFullBuffer is the regression the outbox exists
+// for: with a one-slot channel, a burst of durable events used to be dropped.
+func TestBus_DurableEventsSurviveFullBuffer(t *testing.T) {
+	bus := NewBus(testLogger(), 1)
+	if err := bus.SetOutbox(NewOutbox(setupOutboxDB(t))); err != nil {
+		t.Fatalf("SetOutbox: %v", err)
+	}
+
+	const total = 250
+	var wg sync.WaitGroup
+	wg.Add(total)
+	var mu sync.Mutex
+	seen := make(map[float64]bool)
+	bus.Subscribe(RuleViolation, func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		n := e.Data["n"].(float64)
+		if !seen[n] {
+			seen[n] = true
+			wg.Done()
+		}
+	})
+
+	// Publish the whole burst before the dispatcher starts, so the channel
+	// path could not have kept up.
+	for i := range total {
+		bus.Publish(Event{Type: RuleViolation, Data: map[string]any{"n": i}})
+	}
+	go bus.Start()
+	defer bus.Stop()
+
+	waitOrFail(t, &wg, fmt.Sprintf("not all %d durable events were delivered", total))
+}
+
+func TestBus_SubscribeDurableResumesFromCursor(t *testing.T) {
+	db := setupOutboxDB(t)
+	ctx := context.Background()
+
+	// First run: the subscriber registers (cursor at head) and sees one
+	// event; the next is published while it is stopped.
+	bus := NewBus(testLogger(), 16)
+	if err := bus.SetOutbox(NewOutbox(db)); err != nil {
+		t.Fatalf("SetOutbox: %v", err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	bus.SubscribeDurable("webhooks", []Type{ArtistNew}, func(Event) { wg.Done() })
+	go bus.Start()
+	bus.Publish(Event{Type: ArtistNew, Data: map[string]any{"name": "first"}})
+	waitOrFail(t, &wg, "first run did not deliver")
+	bus.Stop()
+
+	if _, err := NewOutbox(db).Append(ctx, Event{Type: ArtistNew, Timestamp: time.Now(), Data: map[string]any{"name": "missed"}}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	// Second run: the same name picks up the event it missed, and only that.
+	bus2 := NewBus(testLogger(), 16)
+	if err := bus2.SetOutbox(NewOutbox(db)); err != nil {
+		t.Fatalf("SetOutbox: %v", err)
+	}
+	got := make(chan string, 4)
+	bus2.SubscribeDurable("webhooks", []Type{ArtistNew}, func(e Event) {
+		got <- e.Data["name"].(string)
+	})
+	go bus2.Start()
+	defer bus2.Stop()
+
+	select {
+	case name := <-got:
+		if name != "missed" {
+			t.Errorf("resumed subscriber got %q, want %q", name, "missed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("resumed subscriber did not receive the missed event")
+	}
+	select {
+	case name := <-got:
+		t.Errorf("resumed subscriber got an extra event %q", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestBus_EphemeralEventsBypassOutbox(t *testing.T) {
+	db := setupOutboxDB(t)
+	bus := NewBus(testLogger(), 16)
+	if err := bus.SetOutbox(NewOutbox(db)); err != nil {
+		t.Fatalf("SetOutbox: %v", err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	bus.Subscribe(OperationProgress, func(Event) { wg.Done() })
+	go bus.Start()
+	defer bus.Stop()
+
+	bus.Publish(Event{Type: OperationProgress, Data: map[string]any{"op_id": "x"}})
+	waitOrFail(t, &wg, "progress event not delivered")
+
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM event_outbox`).Scan(&n); err != nil {
+		t.Fatalf("counting outbox rows: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("outbox has %d rows after an ephemeral publish, want 0", n)
+	}
+}
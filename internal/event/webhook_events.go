@@ -26,6 +26,8 @@ var webhookEventTypes = []Type{
 	EmbyArtistUpdate, EmbyLibraryScan,
 	JellyfinArtistUpdate, JellyfinLibraryScan,
 	FSDirCreated, FSDirRemoved, FSUnexpectedWrite,
+	SecurityLockout,
+	BackupPushFailed,
 }
 
 // WebhookEventTypes returns the canonical, ordered set of subscribable webhook
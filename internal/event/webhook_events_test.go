@@ -34,3 +34,17 @@ func TestWebhookEventStrings(t *testing.T) {
 		}
 	}
 }
+
+// TestDurableCoversWebhookEvents keeps every webhook-subscribable type on the
+// outbox path: a webhook type left in memory could be dropped under load,
+// which is the failure the outbox exists to prevent.
+func TestDurableCoversWebhookEvents(t *testing.T) {
+	for _, ty := range WebhookEventTypes() {
+		if !IsDurable(ty) {
+			t.Errorf("IsDurable(%q) = false; every webhook event type must be durable", ty)
+		}
+	}
+	if IsDurable(OperationProgress) {
+		t.Errorf("IsDurable(%q) = true; progress ticks must stay in memory", OperationProgress)
+	}
+}
@@ -0,0 +1,94 @@
+package headless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/provider"
+)
+
+// parseFetch parses `stillwater fetch --artist REF`.
+func parseFetch(args []string, stderr io.Writer) (*Command, error) {
+	const usage = "fetch --artist ID|MBID|NAME"
+	var ref string
+	fs := newFlagSet("fetch", usage, stderr)
+	fs.StringVar(&ref, "artist", "", "Artist to refresh: a Stillwater artist ID, a MusicBrainz ID, or the exact artist name.")
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+	if ref == "" {
+		fs.Usage()
+		return nil, errors.New("fetch: --artist is required")
+	}
+	return &Command{
+		name:   "fetch",
+		stderr: stderr,
+		run: func(ctx context.Context, d Deps, stdout io.Writer) (int, error) {
+			return runFetch(ctx, d, stdout, ref)
+		},
+	}, nil
+}
+
+// fetchReport is the document fetch prints.
+type fetchReport struct {
+	ArtistID           string                  `json:"artist_id"`
+	ArtistName         string                  `json:"artist_name"`
+	Sources            []provider.FieldSource  `json:"sources"`
+	AttemptedProviders []provider.ProviderName `json:"attempted_providers,omitempty"`
+	Errors             []string                `json:"errors,omitempty"`
+}
+
+// runFetch refreshes one artist's metadata from the providers, exactly as
+// the bulk Refresh action does, and exits with ExitFindings when a provider
+// reported an error (the refresh still saved what the others returned).
+func runFetch(ctx context.Context, d Deps, stdout io.Writer, ref string) (int, error) {
+	a, err := resolveArtist(ctx, d.Artists, ref)
+	if err != nil {
+		return ExitError, err
+	}
+	res, err := d.Refresher.RefreshArtist(ctx, a)
+	if err != nil {
+		return ExitError, fmt.Errorf("refreshing %s: %w", a.Name, err)
+	}
+
+	report := fetchReport{
+		ArtistID:           a.ID,
+		ArtistName:         a.Name,
+		Sources:            res.Sources,
+		AttemptedProviders: res.AttemptedProviders,
+		Errors:             res.Errors,
+	}
+	if report.Sources == nil {
+		report.Sources = []provider.FieldSource{}
+	}
+	if err := writeJSON(stdout, report); err != nil {
+		return ExitError, err
+	}
+	if len(res.Errors) > 0 {
+		return ExitFindings, nil
+	}
+	return ExitOK, nil
+}
+
+// resolveArtist finds the artist ref names: a Stillwater ID first, then a
+// MusicBrainz ID or a case-insensitive exact name across all libraries.
+func resolveArtist(ctx context.Context, artists ArtistStore, ref string) (*artist.Artist, error) {
+	a, err := artists.GetByID(ctx, ref)
+	if err == nil {
+		return a, nil
+	}
+	if !errors.Is(err, artist.ErrNotFound) {
+		return nil, err
+	}
+	a, err = artists.FindByMBIDOrNameUnscoped(ctx, ref, ref)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, fmt.Errorf("no artist matches %q", ref)
+	}
+	return a, nil
+}
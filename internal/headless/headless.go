@@ -0,0 +1,256 @@
+// Package headless implements the stillwater subcommands that run a single
+// job against the database and exit, without starting any listener: scan,
+// rules run, fetch, export-settings, import-settings and report compliance.
+// They exist for cron jobs and CI-style checks, so every command writes one
+// machine-readable document to stdout (JSON, or CSV where asked for), sends
+// logs to stderr, and reports through its exit code:
+//
+//	0  the command ran and found nothing to report
+//	1  the command ran and has findings (violations, provider errors)
+//	2  the command failed, or was invoked incorrectly
+//
+// The package owns parsing and running; cmd/stillwater builds the services
+// the same way the server does and hands them over in Deps, so a headless
+// run and a server run share one wiring.
+package headless
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/library"
+	"github.com/sydlexius/stillwater/internal/provider"
+	"github.com/sydlexius/stillwater/internal/rule"
+	"github.com/sydlexius/stillwater/internal/scanner"
+	"github.com/sydlexius/stillwater/internal/settingsio"
+)
+
+// Exit codes. See the package documentation.
+const (
+	ExitOK       = 0
+	ExitFindings = 1
+	ExitError    = 2
+)
+
+// Scanner is the subset of *scanner.Service the scan command uses.
+type Scanner interface {
+	Run(ctx context.Context) (*scanner.ScanResult, error)
+	Wait()
+	Status() *scanner.ScanResult
+}
+
+// ArtistStore is the subset of *artist.Service the commands use.
+type ArtistStore interface {
+	List(ctx context.Context, params artist.ListParams) ([]artist.Artist, int, error)
+	GetByID(ctx context.Context, id string, opts ...artist.HydrateOpts) (*artist.Artist, error)
+	FindByMBIDOrNameUnscoped(ctx context.Context, mbid, name string, opts ...artist.HydrateOpts) (*artist.Artist, error)
+}
+
+// LibraryStore is the subset of *library.Service the commands use.
+type LibraryStore interface {
+	GetByID(ctx context.Context, id string) (*library.Library, error)
+	List(ctx context.Context) ([]library.Library, error)
+}
+
+// RuleStore is the subset of *rule.Service the commands use.
+type RuleStore interface {
+	GetByID(ctx context.Context, id string) (*rule.Rule, error)
+	GetViolationsForArtists(ctx context.Context, artistIDs []string) (map[string][]rule.Violation, error)
+}
+
+// Evaluator is the subset of *rule.Engine the rules dry run uses.
+type Evaluator interface {
+	EvaluateScoped(ctx context.Context, a *artist.Artist, only map[string]bool) (*rule.EvaluationResult, error)
+}
+
+// RuleRunner is the subset of *rule.Pipeline the rules command uses.
+type RuleRunner interface {
+	RunAllScoped(ctx context.Context, scope rule.RunScope) (*rule.RunResult, error)
+	RunRuleScoped(ctx context.Context, ruleID string, scope rule.RunScope) (*rule.RunResult, error)
+	RunForArtist(ctx context.Context, a *artist.Artist) (*rule.RunResult, error)
+	RunRuleForArtist(ctx context.Context, a *artist.Artist, ruleID string) (*rule.RunResult, error)
+}
+
+// Refresher refreshes one artist from the providers. The API router
+// implements it (api.Router.RefreshArtist), so fetch and the bulk refresh
+// action share one code path.
+type Refresher interface {
+	RefreshArtist(ctx context.Context, a *artist.Artist) (*provider.FetchResult, error)
+}
+
+// SettingsTransfer is the subset of *settingsio.Service the settings commands
+// use.
+type SettingsTransfer interface {
+	Export(ctx context.Context, passphrase string) (*settingsio.Envelope, error)
+	ImportWithOptions(ctx context.Context, env *settingsio.Envelope, passphrase string, opts settingsio.ImportOptions) (*settingsio.ImportResult, error)
+}
+
+// Deps carries the services the commands run against. A command only touches
+// the fields it needs; tests fill in just those.
+type Deps struct {
+	Scanner   Scanner
+	Artists   ArtistStore
+	Libraries LibraryStore
+	Rules     RuleStore
+	Engine    Evaluator
+	Pipeline  RuleRunner
+	Refresher Refresher
+	Settings  SettingsTransfer
+	// Getenv reads environment variables (the settings passphrase).
+	// Defaults to returning "" when nil, so tests never see the real
+	// environment.
+	Getenv func(string) string
+	// Stdin is read by import-settings when no --input file is given.
+	Stdin io.Reader
+}
+
+func (d Deps) getenv(key string) string {
+	if d.Getenv == nil {
+		return ""
+	}
+	return d.Getenv(key)
+}
+
+// Command is a parsed headless subcommand, ready to run.
+type Command struct {
+	name   string
+	stderr io.Writer
+	run    func(ctx context.Context, d Deps, stdout io.Writer) (int, error)
+}
+
+// Name returns the command's name as typed ("rules run", "report compliance").
+func (c *Command) Name() string { return c.name }
+
+// Run executes the command and returns its exit code. Errors are written to
+// stderr as a single "error: ..." line; stdout only ever carries the
+// command's document.
+func (c *Command) Run(ctx context.Context, d Deps, stdout io.Writer) int {
+	code, err := c.run(ctx, d, stdout)
+	if err != nil {
+		_, _ = fmt.Fprintf(c.stderr, "error: %v\n", err)
+		return ExitError
+	}
+	return code
+}
+
+// parser builds a Command from the arguments after the subcommand name.
+type parser func(args []string, stderr io.Writer) (*Command, error)
+
+// commands maps each top-level subcommand to its parser.
+var commands = map[string]parser{
+	"scan":            parseScan,
+	"rules":           parseRules,
+	"fetch":           parseFetch,
+	"export-settings": parseExportSettings,
+	"import-settings": parseImportSettings,
+	"report":          parseReport,
+}
+
+// IsCommand reports whether name is a headless subcommand.
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// Names returns the headless subcommand names, sorted.
+func Names() []string {
+	names := make([]string, 0, len(commands))
+	for n := range commands {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse parses the arguments of subcommand name (args excludes the name). It
+// is separate from Run so a usage error, or -h, is answered before the
+// caller opens the database. For -h it prints usage and returns
+// flag.ErrHelp, which the caller should treat as success.
+func Parse(name string, args []string, stderr io.Writer) (*Command, error) {
+	p, ok := commands[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown command %q (want one of: %s)", name, strings.Join(Names(), ", "))
+	}
+	return p(args, stderr)
+}
+
+// newFlagSet returns a flag set for a subcommand that reports errors instead
+// of exiting, and prints usage to stderr.
+func newFlagSet(name, usage string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "usage: stillwater %s\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses args into fs and rejects positional leftovers, which are
+// always a typo for these commands.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	return nil
+}
+
+// writeJSON writes v to w as indented JSON followed by a newline.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	return nil
+}
+
+// requireLibrary checks that id names a library, so a typo fails loudly
+// instead of reporting an empty, clean library. An empty id is accepted.
+func requireLibrary(ctx context.Context, libs LibraryStore, id string) error {
+	if id == "" {
+		return nil
+	}
+	if _, err := libs.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return nil
+}
+
+// eachArtist calls fn for every artist matching params, a page at a time.
+func eachArtist(ctx context.Context, artists ArtistStore, params artist.ListParams, fn func(a *artist.Artist) error) error {
+	const pageSize = 200
+	params.Page = 1
+	params.PageSize = pageSize
+	if params.Sort == "" {
+		params.Sort = "name"
+	}
+	for {
+		page, _, err := artists.List(ctx, params)
+		if err != nil {
+			return fmt.Errorf("listing artists: %w", err)
+		}
+		for i := range page {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(&page[i]); err != nil {
+				return err
+			}
+		}
+		if len(page) < pageSize {
+			return nil
+		}
+		params.Page++
+	}
+}
@@ -0,0 +1,515 @@
+package headless
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/library"
+	"github.com/sydlexius/stillwater/internal/provider"
+	"github.com/sydlexius/stillwater/internal/rule"
+	"github.com/sydlexius/stillwater/internal/scanner"
+	"github.com/sydlexius/stillwater/internal/settingsio"
+)
+
+// The real services must keep satisfying the interfaces cmd/stillwater hands
+// them in as; cmd/stillwater itself has no test that would notice.
+var (
+	_ Scanner          = (*scanner.Service)(nil)
+	_ ArtistStore      = (*artist.Service)(nil)
+	_ LibraryStore     = (*library.Service)(nil)
+	_ RuleStore        = (*rule.Service)(nil)
+	_ Evaluator        = (*rule.Engine)(nil)
+	_ RuleRunner       = (*rule.Pipeline)(nil)
+	_ SettingsTransfer = (*settingsio.Service)(nil)
+)
+
+type stubArtists struct {
+	artists []artist.Artist
+}
+
+func (s *stubArtists) List(_ context.Context, p artist.ListParams) ([]artist.Artist, int, error) {
+	var matched []artist.Artist
+	for _, a := range s.artists {
+		if p.LibraryID == "" || a.LibraryID == p.LibraryID {
+			matched = append(matched, a)
+		}
+	}
+	start := (p.Page - 1) * p.PageSize
+	if start >= len(matched) {
+		return nil, len(matched), nil
+	}
+	end := min(start+p.PageSize, len(matched))
+	return matched[start:end], len(matched), nil
+}
+
+func (s *stubArtists) GetByID(_ context.Context, id string, _ ...artist.HydrateOpts) (*artist.Artist, error) {
+	for i := range s.artists {
+		if s.artists[i].ID == id {
+			return &s.artists[i], nil
+		}
+	}
+	return nil, artist.ErrNotFound
+}
+
+func (s *stubArtists) FindByMBIDOrNameUnscoped(_ context.Context, mbid, name string, _ ...artist.HydrateOpts) (*artist.Artist, error) {
+	for i := range s.artists {
+		a := &s.artists[i]
+		if (mbid != "" && a.MusicBrainzID == mbid) || strings.EqualFold(a.Name, name) {
+			return a, nil
+		}
+	}
+	return nil, nil
+}
+
+type stubLibraries struct {
+	libs []library.Library
+}
+
+func (s *stubLibraries) GetByID(_ context.Context, id string) (*library.Library, error) {
+	for i := range s.libs {
+		if s.libs[i].ID == id {
+			return &s.libs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("library not found: %s", id)
+}
+
+func (s *stubLibraries) List(context.Context) ([]library.Library, error) {
+	return s.libs, nil
+}
+
+type stubRules struct {
+	violations map[string][]rule.Violation
+}
+
+func (s *stubRules) GetByID(_ context.Context, id string) (*rule.Rule, error) {
+	if id == "missing" {
+		return nil, errors.New("rule not found")
+	}
+	return &rule.Rule{ID: id}, nil
+}
+
+func (s *stubRules) GetViolationsForArtists(_ context.Context, ids []string) (map[string][]rule.Violation, error) {
+	out := make(map[string][]rule.Violation)
+	for _, id := range ids {
+		if v, ok := s.violations[id]; ok {
+			out[id] = v
+		}
+	}
+	return out, nil
+}
+
+type stubEngine struct {
+	violations map[string][]rule.Violation
+	evaluated  []string
+}
+
+func (s *stubEngine) EvaluateScoped(_ context.Context, a *artist.Artist, only map[string]bool) (*rule.EvaluationResult, error) {
+	s.evaluated = append(s.evaluated, a.ID)
+	var vs []rule.Violation
+	for _, v := range s.violations[a.ID] {
+		if only == nil || only[v.RuleID] {
+			vs = append(vs, v)
+		}
+	}
+	return &rule.EvaluationResult{Violations: vs}, nil
+}
+
+type stubPipeline struct {
+	result    *rule.RunResult
+	perArtist map[string]*rule.RunResult
+	calls     []string
+}
+
+func (s *stubPipeline) RunAllScoped(_ context.Context, scope rule.RunScope) (*rule.RunResult, error) {
+	s.calls = append(s.calls, "all:"+scope.String())
+	return s.result, nil
+}
+
+func (s *stubPipeline) RunRuleScoped(_ context.Context, ruleID string, scope rule.RunScope) (*rule.RunResult, error) {
+	s.calls = append(s.calls, "rule:"+ruleID+":"+scope.String())
+	return s.result, nil
+}
+
+func (s *stubPipeline) RunForArtist(_ context.Context, a *artist.Artist) (*rule.RunResult, error) {
+	s.calls = append(s.calls, "artist:"+a.ID)
+	return s.perArtist[a.ID], nil
+}
+
+func (s *stubPipeline) RunRuleForArtist(_ context.Context, a *artist.Artist, ruleID string) (*rule.RunResult, error) {
+	s.calls = append(s.calls, "artist-rule:"+a.ID+":"+ruleID)
+	return s.perArtist[a.ID], nil
+}
+
+type stubRefresher struct {
+	result *provider.FetchResult
+	err    error
+}
+
+func (s *stubRefresher) RefreshArtist(context.Context, *artist.Artist) (*provider.FetchResult, error) {
+	return s.result, s.err
+}
+
+type stubScanner struct {
+	result *scanner.ScanResult
+}
+
+func (s *stubScanner) Run(context.Context) (*scanner.ScanResult, error) {
+	return &scanner.ScanResult{Status: "running"}, nil
+}
+
+func (s *stubScanner) Wait() {}
+
+func (s *stubScanner) Status() *scanner.ScanResult { return s.result }
+
+type stubSettings struct {
+	passphrase string
+	imported   *settingsio.Envelope
+}
+
+func (s *stubSettings) Export(_ context.Context, passphrase string) (*settingsio.Envelope, error) {
+	s.passphrase = passphrase
+	return &settingsio.Envelope{Version: "1.0", Data: "sealed"}, nil
+}
+
+func (s *stubSettings) ImportWithOptions(_ context.Context, env *settingsio.Envelope, passphrase string, _ settingsio.ImportOptions) (*settingsio.ImportResult, error) {
+	s.passphrase = passphrase
+	s.imported = env
+	return &settingsio.ImportResult{Settings: 3}, nil
+}
+
+// run parses and runs a command line and returns its exit code, stdout and
+// stderr.
+func run(t *testing.T, d Deps, argv ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	cmd, err := Parse(argv[0], argv[1:], &stderr)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", argv, err)
+	}
+	code := cmd.Run(context.Background(), d, &stdout)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		argv []string
+	}{
+		{"unknown command", []string{"serve"}},
+		{"rules without verb", []string{"rules"}},
+		{"rules unknown verb", []string{"rules", "list"}},
+		{"unknown flag", []string{"rules", "run", "--bogus"}},
+		{"positional leftover", []string{"scan", "now"}},
+		{"fetch without artist", []string{"fetch"}},
+		{"report without kind", []string{"report"}},
+		{"report bad format", []string{"report", "compliance", "--format", "xml"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.argv[0], tt.argv[1:], io.Discard)
+			if err == nil {
+				t.Fatalf("Parse(%q) = nil error, want error", tt.argv)
+			}
+		})
+	}
+}
+
+func TestParse_Help(t *testing.T) {
+	var stderr bytes.Buffer
+	_, err := Parse("rules", []string{"run", "-h"}, &stderr)
+	if !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("err = %v, want flag.ErrHelp", err)
+	}
+	if !strings.Contains(stderr.String(), "--dry-run") && !strings.Contains(stderr.String(), "-dry-run") {
+		t.Errorf("usage does not list --dry-run:\n%s", stderr.String())
+	}
+}
+
+func TestIsCommand(t *testing.T) {
+	for _, name := range []string{"scan", "rules", "fetch", "export-settings", "import-settings", "report"} {
+		if !IsCommand(name) {
+			t.Errorf("IsCommand(%q) = false", name)
+		}
+	}
+	for _, name := range []string{"version", "reset-credentials", ""} {
+		if IsCommand(name) {
+			t.Errorf("IsCommand(%q) = true", name)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	d := Deps{Scanner: &stubScanner{result: &scanner.ScanResult{ID: "s1", Status: "completed", NewArtists: 2}}}
+	code, out, _ := run(t, d, "scan")
+	if code != ExitOK {
+		t.Fatalf("exit = %d, want %d", code, ExitOK)
+	}
+	var got scanner.ScanResult
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("stdout is not JSON: %v\n%s", err, out)
+	}
+	if got.NewArtists != 2 {
+		t.Errorf("new_artists = %d, want 2", got.NewArtists)
+	}
+
+	d.Scanner = &stubScanner{result: &scanner.ScanResult{Status: "failed", Error: "boom"}}
+	code, _, stderr := run(t, d, "scan")
+	if code != ExitError {
+		t.Errorf("failed scan exit = %d, want %d", code, ExitError)
+	}
+	if !strings.Contains(stderr, "boom") {
+		t.Errorf("stderr = %q, want the scan error", stderr)
+	}
+}
+
+func rulesDeps() (Deps, *stubEngine, *stubPipeline) {
+	engine := &stubEngine{violations: map[string][]rule.Violation{
+		"a1": {{RuleID: "nfo_exists", RuleName: "NFO exists", Severity: "error", Message: "missing"}},
+		"a2": {{RuleID: "logo_exists", Severity: "warning", Message: "no logo"}},
+	}}
+	pipeline := &stubPipeline{
+		result: &rule.RunResult{ViolationsFound: 3, FixesSucceeded: 3},
+		perArtist: map[string]*rule.RunResult{
+			"a1": {ArtistsProcessed: 1, ViolationsFound: 2, FixesSucceeded: 1},
+		},
+	}
+	return Deps{
+		Artists: &stubArtists{artists: []artist.Artist{
+			{ID: "a1", Name: "Alpha", LibraryID: "lib1"},
+			{ID: "a2", Name: "Beta", LibraryID: "lib2"},
+			{ID: "a3", Name: "Gamma", LibraryID: "lib1", IsExcluded: true},
+		}},
+		Libraries: &stubLibraries{libs: []library.Library{{ID: "lib1", Name: "Main"}, {ID: "lib2", Name: "Other"}}},
+		Rules:     &stubRules{},
+		Engine:    engine,
+		Pipeline:  pipeline,
+	}, engine, pipeline
+}
+
+func TestRulesRun_DryRun(t *testing.T) {
+	d, engine, pipeline := rulesDeps()
+	code, out, _ := run(t, d, "rules", "run", "--dry-run", "--library", "lib1")
+	if code != ExitFindings {
+		t.Fatalf("exit = %d, want %d", code, ExitFindings)
+	}
+	if len(pipeline.calls) != 0 {
+		t.Errorf("dry run called the pipeline: %v", pipeline.calls)
+	}
+	// a3 is excluded and a2 is in another library.
+	if len(engine.evaluated) != 1 || engine.evaluated[0] != "a1" {
+		t.Errorf("evaluated = %v, want [a1]", engine.evaluated)
+	}
+	var got dryRunReport
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("stdout is not JSON: %v\n%s", err, out)
+	}
+	if len(got.Violations) != 1 || got.Violations[0].ArtistName != "Alpha" {
+		t.Errorf("violations = %+v", got.Violations)
+	}
+
+	// Scoping to a rule nobody violates is a clean run.
+	d, _, _ = rulesDeps()
+	code, _, _ = run(t, d, "rules", "run", "--dry-run", "--rule", "mbid_exists")
+	if code != ExitOK {
+		t.Errorf("clean dry run exit = %d, want %d", code, ExitOK)
+	}
+}
+
+func TestRulesRun_Fix(t *testing.T) {
+	d, _, pipeline := rulesDeps()
+	code, out, _ := run(t, d, "rules", "run")
+	if code != ExitOK {
+		t.Fatalf("exit = %d, want %d (all violations fixed)", code, ExitOK)
+	}
+	if len(pipeline.calls) != 1 || pipeline.calls[0] != "all:"+rule.RunScopeAll.String() {
+		t.Errorf("pipeline calls = %v", pipeline.calls)
+	}
+	if !strings.Contains(out, `"violations_remaining": 0`) {
+		t.Errorf("stdout lacks violations_remaining:\n%s", out)
+	}
+
+	d, _, pipeline = rulesDeps()
+	code, out, _ = run(t, d, "rules", "run", "--library", "lib1", "--rule", "nfo_exists")
+	if code != ExitFindings {
+		t.Fatalf("library run exit = %d, want %d", code, ExitFindings)
+	}
+	if len(pipeline.calls) != 1 || pipeline.calls[0] != "artist-rule:a1:nfo_exists" {
+		t.Errorf("pipeline calls = %v", pipeline.calls)
+	}
+	if !strings.Contains(out, `"violations_remaining": 1`) {
+		t.Errorf("stdout lacks violations_remaining 1:\n%s", out)
+	}
+}
+
+func TestRulesRun_PersistFailureIsAnError(t *testing.T) {
+	d, _, pipeline := rulesDeps()
+	pipeline.result = &rule.RunResult{PersistFailures: 1}
+	code, _, _ := run(t, d, "rules", "run")
+	if code != ExitError {
+		t.Errorf("exit = %d, want %d", code, ExitError)
+	}
+}
+
+func TestRulesRun_UnknownRuleOrLibrary(t *testing.T) {
+	d, _, _ := rulesDeps()
+	if code, _, _ := run(t, d, "rules", "run", "--rule", "missing"); code != ExitError {
+		t.Errorf("unknown rule exit = %d, want %d", code, ExitError)
+	}
+	if code, _, _ := run(t, d, "rules", "run", "--library", "nope"); code != ExitError {
+		t.Errorf("unknown library exit = %d, want %d", code, ExitError)
+	}
+}
+
+func TestFetch(t *testing.T) {
+	d, _, _ := rulesDeps()
+	d.Artists.(*stubArtists).artists[1].MusicBrainzID = "mbid-beta"
+	d.Refresher = &stubRefresher{result: &provider.FetchResult{
+		Sources: []provider.FieldSource{{Field: "biography", Provider: "musicbrainz"}},
+	}}
+
+	for _, ref := range []string{"a2", "mbid-beta", "beta"} {
+		code, out, stderr := run(t, d, "fetch", "--artist", ref)
+		if code != ExitOK {
+			t.Fatalf("fetch %q exit = %d, stderr %q", ref, code, stderr)
+		}
+		if !strings.Contains(out, `"artist_id": "a2"`) {
+			t.Errorf("fetch %q resolved the wrong artist:\n%s", ref, out)
+		}
+	}
+
+	if code, _, _ := run(t, d, "fetch", "--artist", "nobody"); code != ExitError {
+		t.Errorf("unknown artist exit = %d, want %d", code, ExitError)
+	}
+
+	d.Refresher = &stubRefresher{result: &provider.FetchResult{Errors: []string{"lastfm: timeout"}}}
+	if code, _, _ := run(t, d, "fetch", "--artist", "a1"); code != ExitFindings {
+		t.Errorf("provider error exit = %d, want %d", code, ExitFindings)
+	}
+
+	d.Refresher = &stubRefresher{err: errors.New("artist is locked")}
+	if code, _, _ := run(t, d, "fetch", "--artist", "a1"); code != ExitError {
+		t.Errorf("refused refresh exit = %d, want %d", code, ExitError)
+	}
+}
+
+func TestReportCompliance_CSV(t *testing.T) {
+	d, _, _ := rulesDeps()
+	arts := d.Artists.(*stubArtists)
+	arts.artists[0].Name = "=HYPERLINK(\"x\")"
+	arts.artists[0].NFOExists = true
+	arts.artists[0].HealthScore = 80
+	d.Rules = &stubRules{violations: map[string][]rule.Violation{
+		"a1": {{RuleID: "logo_exists"}, {RuleID: "fanart_exists"}},
+	}}
+
+	code, out, _ := run(t, d, "report", "compliance", "--format", "csv")
+	if code != ExitFindings {
+		t.Fatalf("exit = %d, want %d", code, ExitFindings)
+	}
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("stdout is not CSV: %v\n%s", err, out)
+	}
+	if strings.Join(records[0], ",") != strings.Join(complianceHeader, ",") {
+		t.Errorf("header = %v", records[0])
+	}
+	if len(records) != 4 {
+		t.Fatalf("got %d records, want header + 3 artists", len(records))
+	}
+	row := records[1]
+	if row[1] != "'=HYPERLINK(\"x\")" {
+		t.Errorf("artist_name = %q, want the formula neutralized", row[1])
+	}
+	if row[2] != "Main" || row[3] != "80" || row[4] != "true" {
+		t.Errorf("row = %v", row)
+	}
+	if row[9] != "logo_exists; fanart_exists" {
+		t.Errorf("violations = %q", row[9])
+	}
+}
+
+func TestReportCompliance_JSONClean(t *testing.T) {
+	d, _, _ := rulesDeps()
+	code, out, _ := run(t, d, "report", "compliance", "--library", "lib2")
+	if code != ExitOK {
+		t.Fatalf("exit = %d, want %d", code, ExitOK)
+	}
+	var rows []complianceRow
+	if err := json.Unmarshal([]byte(out), &rows); err != nil {
+		t.Fatalf("stdout is not JSON: %v\n%s", err, out)
+	}
+	if len(rows) != 1 || rows[0].ArtistID != "a2" || rows[0].Library != "Other" {
+		t.Errorf("rows = %+v", rows)
+	}
+}
+
+func TestSettings_ExportImport(t *testing.T) {
+	dir := t.TempDir()
+	passFile := filepath.Join(dir, "pass")
+	if err := os.WriteFile(passFile, []byte("hunter2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "export.json")
+
+	settings := &stubSettings{}
+	d := Deps{Settings: settings}
+	if code, _, stderr := run(t, d, "export-settings", "--passphrase-file", passFile, "--output", out); code != ExitOK {
+		t.Fatalf("export exit = %d, stderr %q", code, stderr)
+	}
+	if settings.passphrase != "hunter2" {
+		t.Errorf("passphrase = %q, want the trailing newline trimmed", settings.passphrase)
+	}
+	info, err := os.Stat(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("export mode = %o, want 600", perm)
+	}
+
+	// Import from stdin with the passphrase from the environment.
+	raw, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Stdin = bytes.NewReader(raw)
+	d.Getenv = func(k string) string {
+		if k == PassphraseEnv {
+			return "from-env"
+		}
+		return ""
+	}
+	code, stdout, stderr := run(t, d, "import-settings")
+	if code != ExitOK {
+		t.Fatalf("import exit = %d, stderr %q", code, stderr)
+	}
+	if settings.passphrase != "from-env" || settings.imported == nil || settings.imported.Data != "sealed" {
+		t.Errorf("import got passphrase %q, envelope %+v", settings.passphrase, settings.imported)
+	}
+	if !strings.Contains(stdout, `"settings": 3`) {
+		t.Errorf("stdout lacks the import result:\n%s", stdout)
+	}
+}
+
+func TestSettings_PassphraseRequired(t *testing.T) {
+	d := Deps{Settings: &stubSettings{}}
+	code, _, stderr := run(t, d, "export-settings")
+	if code != ExitError {
+		t.Fatalf("exit = %d, want %d", code, ExitError)
+	}
+	if !strings.Contains(stderr, PassphraseEnv) {
+		t.Errorf("stderr = %q, want it to name %s", stderr, PassphraseEnv)
+	}
+}
@@ -0,0 +1,207 @@
+package headless
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/rule"
+)
+
+// reportOptions are the flags of `stillwater report compliance`.
+type reportOptions struct {
+	format    string
+	libraryID string
+}
+
+// parseReport parses `stillwater report compliance`. "compliance" is the only
+// report today; requiring it keeps room for others.
+func parseReport(args []string, stderr io.Writer) (*Command, error) {
+	const usage = "report compliance [--format csv|json] [--library ID]"
+	if len(args) == 0 || args[0] != "compliance" {
+		_, _ = fmt.Fprintf(stderr, "usage: stillwater %s\n", usage)
+		return nil, errors.New(`report: expected "compliance"`)
+	}
+	o := reportOptions{format: "json"}
+	fs := newFlagSet("report compliance", usage, stderr)
+	fs.StringVar(&o.format, "format", "json", "Output format: csv or json.")
+	fs.StringVar(&o.libraryID, "library", "", "Report only artists in this library ID.")
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return nil, err
+	}
+	if o.format != "csv" && o.format != "json" {
+		fs.Usage()
+		return nil, fmt.Errorf("report: unknown format %q (want csv or json)", o.format)
+	}
+	return &Command{
+		name:   "report compliance",
+		stderr: stderr,
+		run: func(ctx context.Context, d Deps, stdout io.Writer) (int, error) {
+			return runReportCompliance(ctx, d, stdout, o)
+		},
+	}, nil
+}
+
+// complianceViolation is one stored violation in the JSON report.
+type complianceViolation struct {
+	RuleID   string `json:"rule_id"`
+	RuleName string `json:"rule_name"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// complianceRow is one artist in the report.
+type complianceRow struct {
+	ArtistID    string                `json:"artist_id"`
+	ArtistName  string                `json:"artist_name"`
+	Library     string                `json:"library"`
+	HealthScore float64               `json:"health_score"`
+	NFO         bool                  `json:"nfo"`
+	Thumb       bool                  `json:"thumb"`
+	Fanart      bool                  `json:"fanart"`
+	Logo        bool                  `json:"logo"`
+	MBID        bool                  `json:"mbid"`
+	Violations  []complianceViolation `json:"violations"`
+}
+
+// complianceHeader is the CSV header. Unlike the UI export it uses stable
+// snake_case names rather than the active profile's image terms, so a script
+// reading the columns does not break when the profile changes.
+var complianceHeader = []string{"artist_id", "artist_name", "library", "health_score", "nfo", "thumb", "fanart", "logo", "mbid", "violations"}
+
+// runReportCompliance prints the compliance report from the stored
+// violations (it evaluates nothing; run `rules run` first for fresh data)
+// and exits with ExitFindings when any artist has an open violation.
+func runReportCompliance(ctx context.Context, d Deps, stdout io.Writer, o reportOptions) (int, error) {
+	if err := requireLibrary(ctx, d.Libraries, o.libraryID); err != nil {
+		return ExitError, err
+	}
+	libs, err := d.Libraries.List(ctx)
+	if err != nil {
+		return ExitError, fmt.Errorf("listing libraries: %w", err)
+	}
+	libNames := make(map[string]string, len(libs))
+	for i := range libs {
+		libNames[libs[i].ID] = libs[i].Name
+	}
+
+	var all []artist.Artist
+	err = eachArtist(ctx, d.Artists, artist.ListParams{LibraryID: o.libraryID}, func(a *artist.Artist) error {
+		all = append(all, *a)
+		return nil
+	})
+	if err != nil {
+		return ExitError, err
+	}
+	ids := make([]string, len(all))
+	for i := range all {
+		ids[i] = all[i].ID
+	}
+	violations, err := d.Rules.GetViolationsForArtists(ctx, ids)
+	if err != nil {
+		return ExitError, fmt.Errorf("loading violations: %w", err)
+	}
+
+	rows := make([]complianceRow, 0, len(all))
+	findings := false
+	for i := range all {
+		a := &all[i]
+		row := complianceRow{
+			ArtistID:    a.ID,
+			ArtistName:  a.Name,
+			Library:     libNames[a.LibraryID],
+			HealthScore: a.HealthScore,
+			NFO:         a.NFOExists,
+			Thumb:       a.ThumbExists,
+			Fanart:      a.FanartExists,
+			Logo:        a.LogoExists,
+			MBID:        a.MusicBrainzID != "",
+			Violations:  toComplianceViolations(violations[a.ID]),
+		}
+		if len(row.Violations) > 0 {
+			findings = true
+		}
+		rows = append(rows, row)
+	}
+
+	if o.format == "csv" {
+		err = writeComplianceCSV(stdout, rows)
+	} else {
+		err = writeJSON(stdout, rows)
+	}
+	if err != nil {
+		return ExitError, err
+	}
+	if findings {
+		return ExitFindings, nil
+	}
+	return ExitOK, nil
+}
+
+func toComplianceViolations(vs []rule.Violation) []complianceViolation {
+	out := make([]complianceViolation, 0, len(vs))
+	for _, v := range vs {
+		out = append(out, complianceViolation{
+			RuleID:   v.RuleID,
+			RuleName: v.RuleName,
+			Severity: v.Severity,
+			Message:  v.Message,
+		})
+	}
+	return out
+}
+
+// writeComplianceCSV writes rows as CSV. Violations are the rule IDs joined
+// with "; ", which stays parseable where rule names may not.
+func writeComplianceCSV(w io.Writer, rows []complianceRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(complianceHeader); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	for i := range rows {
+		r := &rows[i]
+		ruleIDs := make([]string, len(r.Violations))
+		for j, v := range r.Violations {
+			ruleIDs[j] = v.RuleID
+		}
+		if err := cw.Write([]string{
+			r.ArtistID,
+			csvText(r.ArtistName),
+			csvText(r.Library),
+			strconv.FormatFloat(r.HealthScore, 'f', 0, 64),
+			strconv.FormatBool(r.NFO),
+			strconv.FormatBool(r.Thumb),
+			strconv.FormatBool(r.Fanart),
+			strconv.FormatBool(r.Logo),
+			strconv.FormatBool(r.MBID),
+			strings.Join(ruleIDs, "; "),
+		}); err != nil {
+			return fmt.Errorf("writing output: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	return nil
+}
+
+// csvText guards free text against CSV formula injection the way the UI
+// export does: a value a spreadsheet would read as a formula (=, +, -, @) is
+// prefixed with a single quote.
+func csvText(s string) string {
+	trimmed := strings.TrimLeft(s, " \t")
+	if trimmed == "" {
+		return s
+	}
+	switch trimmed[0] {
+	case '=', '+', '-', '@':
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,217 @@
+package headless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/rule"
+)
+
+// rulesOptions are the flags of `stillwater rules run`.
+type rulesOptions struct {
+	ruleID      string
+	libraryID   string
+	dryRun      bool
+	incremental bool
+}
+
+// parseRules parses `stillwater rules run`. "run" is the only rules verb
+// today; requiring it keeps room for others without a breaking change.
+func parseRules(args []string, stderr io.Writer) (*Command, error) {
+	const usage = "rules run [--rule ID] [--library ID] [--dry-run] [--incremental]"
+	if len(args) == 0 || args[0] != "run" {
+		_, _ = fmt.Fprintf(stderr, "usage: stillwater %s\n", usage)
+		return nil, errors.New(`rules: expected "run"`)
+	}
+	var o rulesOptions
+	fs := newFlagSet("rules run", usage, stderr)
+	fs.StringVar(&o.ruleID, "rule", "", "Run only this rule ID.")
+	fs.StringVar(&o.libraryID, "library", "", "Run only against artists in this library ID.")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "Evaluate and report violations without fixing or recording anything.")
+	fs.BoolVar(&o.incremental, "incremental", false, "Process only artists changed since their last evaluation (ignored with --library and --dry-run).")
+	if err := parseFlags(fs, args[1:]); err != nil {
+		return nil, err
+	}
+	return &Command{
+		name:   "rules run",
+		stderr: stderr,
+		run: func(ctx context.Context, d Deps, stdout io.Writer) (int, error) {
+			return runRules(ctx, d, stdout, o)
+		},
+	}, nil
+}
+
+// dryRunViolation is one violation in the dry-run report.
+type dryRunViolation struct {
+	ArtistID   string `json:"artist_id"`
+	ArtistName string `json:"artist_name"`
+	RuleID     string `json:"rule_id"`
+	RuleName   string `json:"rule_name"`
+	Severity   string `json:"severity"`
+	Message    string `json:"message"`
+	Fixable    bool   `json:"fixable"`
+}
+
+// dryRunReport is the document a dry run prints.
+type dryRunReport struct {
+	DryRun           bool              `json:"dry_run"`
+	RuleID           string            `json:"rule_id,omitempty"`
+	LibraryID        string            `json:"library_id,omitempty"`
+	ArtistsEvaluated int               `json:"artists_evaluated"`
+	ArtistsFailed    int               `json:"artists_failed,omitempty"`
+	Violations       []dryRunViolation `json:"violations"`
+}
+
+// runReport is the document a fixing run prints: the pipeline's RunResult
+// plus what is left after the fixes, which decides the exit code.
+type runReport struct {
+	*rule.RunResult
+	RuleID              string `json:"rule_id,omitempty"`
+	LibraryID           string `json:"library_id,omitempty"`
+	ViolationsRemaining int    `json:"violations_remaining"`
+}
+
+// runRules runs the rule pipeline, or with --dry-run only the engine, and
+// exits with ExitFindings while violations remain.
+func runRules(ctx context.Context, d Deps, stdout io.Writer, o rulesOptions) (int, error) {
+	if o.ruleID != "" {
+		if _, err := d.Rules.GetByID(ctx, o.ruleID); err != nil {
+			return ExitError, fmt.Errorf("rule %q: %w", o.ruleID, err)
+		}
+	}
+	if err := requireLibrary(ctx, d.Libraries, o.libraryID); err != nil {
+		return ExitError, err
+	}
+	if o.dryRun {
+		return runRulesDry(ctx, d, stdout, o)
+	}
+
+	res, err := runRulesFix(ctx, d, o)
+	if err != nil {
+		return ExitError, err
+	}
+	report := runReport{
+		RunResult:           res,
+		RuleID:              o.ruleID,
+		LibraryID:           o.libraryID,
+		ViolationsRemaining: max(res.ViolationsFound-res.FixesSucceeded, 0),
+	}
+	if err := writeJSON(stdout, report); err != nil {
+		return ExitError, err
+	}
+	if res.PersistFailures > 0 {
+		// The database does not reflect what the run reported (see
+		// RunResult.PersistFailures); that is a failure, not a finding.
+		return ExitError, fmt.Errorf("%d artist(s) could not be fully saved; see the log", res.PersistFailures)
+	}
+	if report.ViolationsRemaining > 0 {
+		return ExitFindings, nil
+	}
+	return ExitOK, nil
+}
+
+// runRulesFix runs the fixing pipeline. Without --library it is the same
+// library-wide sweep as the Run Rules button, over every artist unless
+// --incremental; with --library it walks that library's artists one by one,
+// since the pipeline's sweeps have no library scope.
+func runRulesFix(ctx context.Context, d Deps, o rulesOptions) (*rule.RunResult, error) {
+	if o.libraryID == "" {
+		scope := rule.RunScopeAll
+		if o.incremental {
+			scope = rule.RunScopeIncremental
+		}
+		if o.ruleID != "" {
+			return d.Pipeline.RunRuleScoped(ctx, o.ruleID, scope)
+		}
+		return d.Pipeline.RunAllScoped(ctx, scope)
+	}
+
+	total := &rule.RunResult{Scope: rule.RunScopeAll.String()}
+	err := eachArtist(ctx, d.Artists, artist.ListParams{LibraryID: o.libraryID}, func(a *artist.Artist) error {
+		if a.IsExcluded || a.Locked {
+			return nil
+		}
+		total.ArtistsTotal++
+		var (
+			res *rule.RunResult
+			err error
+		)
+		if o.ruleID != "" {
+			res, err = d.Pipeline.RunRuleForArtist(ctx, a, o.ruleID)
+		} else {
+			res, err = d.Pipeline.RunForArtist(ctx, a)
+		}
+		if err != nil {
+			// One artist failing to evaluate should not hide the rest of
+			// the library; the pipeline logged the cause.
+			return nil
+		}
+		total.ArtistsProcessed += res.ArtistsProcessed
+		total.ViolationsFound += res.ViolationsFound
+		total.FixesAttempted += res.FixesAttempted
+		total.FixesSucceeded += res.FixesSucceeded
+		total.PersistFailures += res.PersistFailures
+		total.Results = append(total.Results, res.Results...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return total, nil
+}
+
+// runRulesDry evaluates the rules against every artist in scope and reports
+// the violations without fixing, persisting or stamping anything: the engine
+// alone, with no pipeline. Excluded and locked artists are skipped, as the
+// fixing run skips them.
+func runRulesDry(ctx context.Context, d Deps, stdout io.Writer, o rulesOptions) (int, error) {
+	var only map[string]bool
+	if o.ruleID != "" {
+		only = map[string]bool{o.ruleID: true}
+	}
+	report := dryRunReport{
+		DryRun:     true,
+		RuleID:     o.ruleID,
+		LibraryID:  o.libraryID,
+		Violations: []dryRunViolation{},
+	}
+	err := eachArtist(ctx, d.Artists, artist.ListParams{LibraryID: o.libraryID}, func(a *artist.Artist) error {
+		if a.IsExcluded || a.Locked {
+			return nil
+		}
+		eval, err := d.Engine.EvaluateScoped(ctx, a, only)
+		if err != nil {
+			report.ArtistsFailed++
+			return nil
+		}
+		report.ArtistsEvaluated++
+		for _, v := range eval.Violations {
+			report.Violations = append(report.Violations, dryRunViolation{
+				ArtistID:   a.ID,
+				ArtistName: a.Name,
+				RuleID:     v.RuleID,
+				RuleName:   v.RuleName,
+				Severity:   v.Severity,
+				Message:    v.Message,
+				Fixable:    v.Fixable,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return ExitError, err
+	}
+	if err := writeJSON(stdout, report); err != nil {
+		return ExitError, err
+	}
+	if report.ArtistsFailed > 0 {
+		return ExitError, fmt.Errorf("%d artist(s) could not be evaluated; see the log", report.ArtistsFailed)
+	}
+	if len(report.Violations) > 0 {
+		return ExitFindings, nil
+	}
+	return ExitOK, nil
+}
@@ -0,0 +1,54 @@
+package headless
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// parseScan parses `stillwater scan`.
+func parseScan(args []string, stderr io.Writer) (*Command, error) {
+	fs := newFlagSet("scan", "scan", stderr)
+	if err := parseFlags(fs, args); err != nil {
+		return nil, err
+	}
+	return &Command{name: "scan", stderr: stderr, run: runScan}, nil
+}
+
+// runScan scans every library in the foreground and prints the final
+// ScanResult. The scanner runs scans on a background goroutine for the
+// server's sake; Wait turns that into a blocking call here. When ctx is
+// canceled (Ctrl-C) the command returns at once and leaves the caller's
+// scanner Shutdown to stop the walk.
+//
+// A scan running in a server process against the same database is not
+// visible to this process, so the two would both walk the library. Schedule
+// the cron job for a time the server's own scan schedule is idle.
+func runScan(ctx context.Context, d Deps, stdout io.Writer) (int, error) {
+	if _, err := d.Scanner.Run(ctx); err != nil {
+		return ExitError, err
+	}
+	done := make(chan struct{})
+	go func() {
+		d.Scanner.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ExitError, ctx.Err()
+	}
+
+	res := d.Scanner.Status()
+	if res == nil {
+		return ExitError, errors.New("scan finished without a result")
+	}
+	if err := writeJSON(stdout, res); err != nil {
+		return ExitError, err
+	}
+	if res.Status != "completed" {
+		return ExitError, fmt.Errorf("scan %s: %s", res.Status, res.Error)
+	}
+	return ExitOK, nil
+}
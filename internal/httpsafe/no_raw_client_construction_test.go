@@ -73,6 +73,15 @@ var allowedRawClientSites = map[string]bool{
 	"internal/connection/jellyfin/client.go:303": true,
 	// Lidarr connection client: operator-supplied *arr-stack URL.
 	"internal/connection/lidarr/client.go:32": true,
+	// Plex connection client: operator-supplied Plex Media Server URL,
+	// validated via connection.ValidateBaseURL.
+	"internal/connection/plex/client.go:61": true,
+	// Subsonic connection client: operator-supplied Navidrome/Subsonic URL,
+	// validated via connection.ValidateBaseURL.
+	"internal/connection/subsonic/client.go:58": true,
+	// Kodi connection client: operator-supplied Kodi web server URL,
+	// validated via connection.ValidateBaseURL.
+	"internal/connection/kodi/client.go:65": true,
 	// Auth providers (login backends): operator-supplied media-server
 	// URLs, validated via connection.ValidateBaseURL.
 	"internal/auth/provider_emby.go:43":     true,
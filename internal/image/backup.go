@@ -177,6 +177,13 @@ func BackupSingleSlot(ctx context.Context, dir, imageType string, naming []strin
 		return fmt.Errorf("reading original for backup (%s, max %d bytes): %w", existing, MaxDecodeBytes, err)
 	}
 
+	// The one-deep backup below is overwritten by the next edit; the revision
+	// history is not. Recording here, with the bytes already in hand, is what
+	// puts the image this edit replaces into the history even when Stillwater
+	// never wrote it. The recorder skips a version identical to the slot's
+	// newest revision, so an image Stillwater wrote itself is not stored twice.
+	recordRevision(ctx, imageType, existing, data, RevisionActionObserved)
+
 	typeDir, err := backupTypeDir(dir, imageType)
 	if err != nil {
 		return err
@@ -341,6 +348,10 @@ func BackupSlot(ctx context.Context, dir, imageType, fileName string) error {
 		return fmt.Errorf("reading slot original for backup (%s, max %d bytes): %w", existing, MaxDecodeBytes, err)
 	}
 
+	// Same as BackupSingleSlot: the slot's pre-edit image enters the revision
+	// history before it is overwritten.
+	recordRevision(ctx, imageType, existing, data, RevisionActionObserved)
+
 	typeDir, err := backupTypeDir(dir, imageType)
 	if err != nil {
 		return err
@@ -654,6 +665,9 @@ func SaveSlotProtected(ctx context.Context, dir, imageType string, naming []stri
 
 	saved, saveErr := Save(dir, imageType, data, naming, useSymlinks, meta, logger)
 	if saveErr == nil {
+		if len(saved) > 0 {
+			RecordSavedRevision(ctx, dir, imageType, saved[0])
+		}
 		return saved, nil
 	}
 	// The save failed after successful backups. Put the originals back rather than
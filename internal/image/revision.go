@@ -0,0 +1,175 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// Revision actions recorded by this package itself. Callers name their own
+// actions ("upload", "crop", "rule:logo_min_res", ...) through
+// WithRevisionContext; these two cover the versions this package sees without
+// a caller-supplied action.
+const (
+	// RevisionActionObserved marks the image found on disk immediately before
+	// a destructive write: the artwork the write is about to replace. It is
+	// how a version Stillwater never wrote (the scanner found it, a user
+	// copied it in) still enters the history before its first edit.
+	RevisionActionObserved = "observed"
+	// RevisionActionSave is the fallback action of a write whose caller did
+	// not name one.
+	RevisionActionSave = "save"
+)
+
+// RevisionInput is one image version handed to the RevisionRecorder: the
+// bytes exactly as they are on disk, embedded provenance included, plus where
+// they came from.
+type RevisionInput struct {
+	ArtistID  string
+	ImageType string
+	// Slot names the image within its type: the type itself for the
+	// single-slot kinds (thumb/logo/banner), and the extension-less file name
+	// for fanart (fanart, fanart1, backdrop2...), whose slots are distinct
+	// images.
+	Slot   string
+	Action string
+	Data   []byte
+	Format string
+	// Meta is the Stillwater provenance embedded in Data, nil when the file
+	// carries none.
+	Meta *ExifMeta
+}
+
+// RevisionRecorder keeps artwork revision history. internal/artwork's Store
+// implements it; this package only notifies it, so a recorder that is not
+// installed (tests, tools) costs nothing and a recorder that fails never fails
+// the write that notified it -- implementations log and move on.
+type RevisionRecorder interface {
+	RecordRevision(ctx context.Context, rev RevisionInput)
+}
+
+type revisionRecorderBox struct{ r RevisionRecorder }
+
+var revisionRecorder atomic.Pointer[revisionRecorderBox]
+
+// SetRevisionRecorder installs the process-wide revision recorder. Passing nil
+// uninstalls it. It is process-wide for the same reason SetMaxConcurrentDecodes
+// is: the write primitives that feed it (BackupSingleSlot, BackupSlot,
+// SaveSlotProtected) are package-level functions called from internal/api and
+// internal/rule alike, and threading a recorder through every one of their
+// callers would touch every image write path for an optional side effect.
+func SetRevisionRecorder(r RevisionRecorder) {
+	if r == nil {
+		revisionRecorder.Store(nil)
+		return
+	}
+	revisionRecorder.Store(&revisionRecorderBox{r: r})
+}
+
+type revisionCtxKey struct{}
+
+type revisionCtx struct {
+	artistID string
+	action   string
+}
+
+// WithRevisionContext tags ctx with the artist an image write belongs to and
+// the action performing it. Every revision the write produces is attributed
+// to both. A write whose context carries no artist is not recorded: the
+// image layer only knows directories, and a revision that cannot be listed
+// under an artist is one nobody can find or restore.
+func WithRevisionContext(ctx context.Context, artistID, action string) context.Context {
+	return context.WithValue(ctx, revisionCtxKey{}, revisionCtx{artistID: artistID, action: action})
+}
+
+// RevisionContextFrom returns the artist and action set by WithRevisionContext.
+func RevisionContextFrom(ctx context.Context) (artistID, action string) {
+	rc, _ := ctx.Value(revisionCtxKey{}).(revisionCtx)
+	return rc.artistID, rc.action
+}
+
+// RevisionSlot returns the slot name a file of imageType is recorded under.
+// See RevisionInput.Slot.
+func RevisionSlot(imageType, fileName string) string {
+	if imageType != "fanart" {
+		return imageType
+	}
+	return slotBase(filepath.Base(fileName))
+}
+
+// recordRevision hands data to the installed recorder as a version of the
+// slot that fileName belongs to. action overrides the context's action when
+// non-empty. It is a no-op without a recorder or without an artist in ctx.
+func recordRevision(ctx context.Context, imageType, fileName string, data []byte, action string) {
+	box := revisionRecorder.Load()
+	if box == nil || len(data) == 0 {
+		return
+	}
+	artistID, ctxAction := RevisionContextFrom(ctx)
+	if artistID == "" {
+		return
+	}
+	if action == "" {
+		action = ctxAction
+	}
+	if action == "" {
+		action = RevisionActionSave
+	}
+	format, _, _ := DetectFormat(bytes.NewReader(data))
+	// Unparseable provenance is recorded as none; the bytes are still the
+	// version and still restorable.
+	meta, _ := parseProvenanceFromBytes(data)
+	box.r.RecordRevision(ctx, RevisionInput{
+		ArtistID:  artistID,
+		ImageType: imageType,
+		Slot:      RevisionSlot(imageType, fileName),
+		Action:    action,
+		Data:      data,
+		Format:    format,
+		Meta:      meta,
+	})
+}
+
+// RecordSavedRevision records the file a write just produced, fileName in dir,
+// as the newest version of its slot, attributed to the action in ctx. Call it
+// after a successful save with the first name the save returned (the real
+// file; later names may be symlinks to it). Failures to read the file are
+// ignored: the write itself succeeded, and the next write's observed revision
+// picks the version up.
+func RecordSavedRevision(ctx context.Context, dir, imageType, fileName string) {
+	if revisionRecorder.Load() == nil {
+		return
+	}
+	if artistID, _ := RevisionContextFrom(ctx); artistID == "" {
+		return
+	}
+	path := filepath.Join(dir, filepath.Base(fileName))
+	data, err := readFileBounded(ctx, path)
+	if err != nil {
+		return
+	}
+	recordRevision(ctx, imageType, path, data, "")
+}
+
+// RecordCurrentRevision records the image currently on disk for imageType (the
+// first of naming that exists) as an observed revision. It is for destructive
+// writers that do not go through BackupSingleSlot or BackupSlot, which record
+// the pre-edit image themselves.
+func RecordCurrentRevision(ctx context.Context, dir, imageType string, naming []string) {
+	if revisionRecorder.Load() == nil {
+		return
+	}
+	if artistID, _ := RevisionContextFrom(ctx); artistID == "" {
+		return
+	}
+	existing, found := FindExistingImage(ctx, dir, naming)
+	if !found {
+		return
+	}
+	data, err := readFileBounded(ctx, existing)
+	if err != nil {
+		return
+	}
+	recordRevision(ctx, imageType, existing, data, RevisionActionObserved)
+}
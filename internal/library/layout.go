@@ -0,0 +1,135 @@
+package library
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Layout placeholders. A library layout is a path template, relative to the
+// library path, naming what each directory level holds. The last level is
+// always {artist}; the levels above it are bucket directories that group
+// artists and are never artists themselves.
+const (
+	LayoutArtist = "{artist}"
+	LayoutLetter = "{letter}" // the first letter of the artist directory name: A/ABBA
+	LayoutGroup  = "{group}"  // any name, such as a genre or a label: Jazz/Miles Davis
+)
+
+// Layouts offered in the UI. LayoutFlat, the default, keeps artist
+// directories directly in the library path.
+const (
+	LayoutFlat        = ""
+	LayoutLetterFirst = "{letter}/{artist}"
+	LayoutGroupFirst  = "{group}/{artist}"
+)
+
+// maxLayoutDepth bounds how many directory levels a layout may have.
+const maxLayoutDepth = 4
+
+// ValidateLayout checks a layout template and returns it in canonical form:
+// levels separated by "/", and LayoutFlat however the flat layout was
+// spelled ("", "{artist}").
+func ValidateLayout(raw string) (string, error) {
+	trimmed := strings.Trim(strings.TrimSpace(strings.ReplaceAll(raw, `\`, "/")), "/")
+	if trimmed == "" || trimmed == LayoutArtist {
+		return LayoutFlat, nil
+	}
+	levels := strings.Split(trimmed, "/")
+	if len(levels) > maxLayoutDepth {
+		return "", fmt.Errorf("library layout may have at most %d levels: %q", maxLayoutDepth, raw)
+	}
+	for i, level := range levels {
+		level = strings.TrimSpace(level)
+		levels[i] = level
+		if i == len(levels)-1 {
+			if level != LayoutArtist {
+				return "", fmt.Errorf("library layout must end in %s: %q", LayoutArtist, raw)
+			}
+			continue
+		}
+		if level != LayoutLetter && level != LayoutGroup {
+			return "", fmt.Errorf("library layout level %q must be %s or %s", level, LayoutLetter, LayoutGroup)
+		}
+	}
+	return strings.Join(levels, "/"), nil
+}
+
+// bucketLevels returns the levels of a validated layout above {artist},
+// outermost first.
+func bucketLevels(layout string) []string {
+	if layout == LayoutFlat {
+		return nil
+	}
+	levels := strings.Split(layout, "/")
+	return levels[:len(levels)-1]
+}
+
+// ArtistDepth returns how many directory levels below the library path its
+// artist directories are: 1 for the flat layout, 2 for {letter}/{artist}.
+func (lib Library) ArtistDepth() int {
+	return len(bucketLevels(lib.Layout)) + 1
+}
+
+// LetterBucket returns the {letter} bucket an artist directory name sorts
+// into: its first letter, upper-cased and without accents, so "Édith Piaf"
+// is under E. It returns "" when the name does not start with a letter.
+func LetterBucket(dirName string) string {
+	for _, r := range norm.NFD.String(strings.TrimSpace(dirName)) {
+		if !unicode.IsLetter(r) {
+			return ""
+		}
+		return string(unicode.ToUpper(r))
+	}
+	return ""
+}
+
+// ArtistDirParent returns the directory an artist directory of lib, now at
+// artistPath, belongs in once it is named dirName. A {letter} level moves to
+// the bucket of dirName's first letter, reusing an existing bucket that
+// differs only in case; a name that does not start with a letter stays in
+// its bucket, as does every {group} level. ok is false when artistPath is not
+// at lib's artist depth below its path.
+func (lib Library) ArtistDirParent(artistPath, dirName string) (string, bool) {
+	if lib.Path == "" {
+		return "", false
+	}
+	rel, err := filepath.Rel(lib.Path, filepath.Clean(artistPath))
+	if err != nil || rel == "." || !filepath.IsLocal(rel) {
+		return "", false
+	}
+	segs := strings.Split(rel, string(filepath.Separator))
+	if len(segs) != lib.ArtistDepth() {
+		return "", false
+	}
+	parent := lib.Path
+	for i, level := range bucketLevels(lib.Layout) {
+		seg := segs[i]
+		if level == LayoutLetter {
+			if letter := LetterBucket(dirName); letter != "" && !strings.EqualFold(seg, letter) {
+				seg = existingBucket(parent, letter)
+			}
+		}
+		parent = filepath.Join(parent, seg)
+	}
+	return parent, true
+}
+
+// existingBucket returns the name of the subdirectory of parent that matches
+// name case-insensitively, or name when there is none.
+func existingBucket(parent, name string) string {
+	entries, err := os.ReadDir(parent)
+	if err != nil {
+		return name
+	}
+	for _, e := range entries {
+		if e.IsDir() && strings.EqualFold(e.Name(), name) {
+			return e.Name()
+		}
+	}
+	return name
+}
@@ -0,0 +1,115 @@
+package library
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateLayout(t *testing.T) {
+	cases := []struct {
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{"", LayoutFlat, false},
+		{"{artist}", LayoutFlat, false},
+		{"/{letter}/{artist}/", LayoutLetterFirst, false},
+		{` {group} \ {artist} `, LayoutGroupFirst, false},
+		{"{group}/{letter}/{artist}", "{group}/{letter}/{artist}", false},
+		{"{letter}", "", true},
+		{"{artist}/{letter}", "", true},
+		{"Genre/{artist}", "", true},
+		{"{group}/{group}/{group}/{group}/{artist}", "", true},
+	}
+	for _, tc := range cases {
+		got, err := ValidateLayout(tc.raw)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("ValidateLayout(%q) = %q, %v; want %q, error %v", tc.raw, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+func TestLetterBucket(t *testing.T) {
+	for name, want := range map[string]string{
+		"ABBA":        "A",
+		"beatles":     "B",
+		"Édith Piaf":  "E",
+		"2Pac":        "",
+		"...And You":  "",
+		"":            "",
+		"Øystein Sev": "Ø",
+	} {
+		if got := LetterBucket(name); got != want {
+			t.Errorf("LetterBucket(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestLibrary_ArtistDirParent(t *testing.T) {
+	root := t.TempDir()
+	for _, d := range []string{"b", "0-9", "Jazz"} {
+		if err := os.Mkdir(filepath.Join(root, d), 0o750); err != nil {
+			t.Fatal(err)
+		}
+	}
+	letters := Library{Path: root, Layout: LayoutLetterFirst}
+	groups := Library{Path: root, Layout: LayoutGroupFirst}
+
+	cases := []struct {
+		name    string
+		lib     Library
+		path    string
+		dirName string
+		want    string
+		wantOK  bool
+	}{
+		{"same letter stays", letters, filepath.Join(root, "B", "Beatles"), "Beatles, The", filepath.Join(root, "B"), true},
+		{"new letter, existing bucket in another case", letters, filepath.Join(root, "T", "The Beatles"), "Beatles, The", filepath.Join(root, "b"), true},
+		{"new letter, new bucket", letters, filepath.Join(root, "B", "Beatles"), "The Beatles", filepath.Join(root, "T"), true},
+		{"leading digit stays", letters, filepath.Join(root, "0-9", "2 Pac"), "2Pac", filepath.Join(root, "0-9"), true},
+		{"letter leaves the digit bucket", letters, filepath.Join(root, "0-9", "2Pac"), "Tupac", filepath.Join(root, "T"), true},
+		{"group stays", groups, filepath.Join(root, "Jazz", "Miles"), "Miles Davis", filepath.Join(root, "Jazz"), true},
+		{"bucket is not an artist", letters, filepath.Join(root, "B"), "C", "", false},
+		{"outside the library", letters, filepath.Join(filepath.Dir(root), "X", "Y"), "Z", "", false},
+		{"flat", Library{Path: root}, filepath.Join(root, "Beatles"), "The Beatles", root, true},
+	}
+	for _, tc := range cases {
+		got, ok := tc.lib.ArtistDirParent(tc.path, tc.dirName)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("%s: ArtistDirParent = %q, %v; want %q, %v", tc.name, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
+
+func TestCreate_Layout(t *testing.T) {
+	t.Parallel()
+	svc := NewService(setupTestDB(t))
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	if err := svc.Create(ctx, &Library{Name: "Bad", Path: dir, Type: TypeRegular, Layout: "{artist}/{letter}"}); err == nil {
+		t.Fatal("Create accepted a layout that does not end in {artist}")
+	}
+	lib := &Library{Name: "Letters", Path: dir, Type: TypeRegular, Layout: "{letter}/{artist}/"}
+	if err := svc.Create(ctx, lib); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	got, err := svc.GetByID(ctx, lib.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Layout != LayoutLetterFirst || got.ArtistDepth() != 2 {
+		t.Errorf("Layout = %q, depth %d; want %q, 2", got.Layout, got.ArtistDepth(), LayoutLetterFirst)
+	}
+
+	parent, err := svc.ArtistDirParent(ctx, filepath.Join(dir, "B", "Beatles"), "The Beatles")
+	if err != nil || parent != filepath.Join(dir, "T") {
+		t.Errorf("ArtistDirParent = %q, %v; want %q", parent, err, filepath.Join(dir, "T"))
+	}
+	parent, err = svc.ArtistDirParent(ctx, "/elsewhere/Beatles", "The Beatles")
+	if err != nil || parent != "/elsewhere" {
+		t.Errorf("ArtistDirParent outside every library = %q, %v; want /elsewhere", parent, err)
+	}
+}
@@ -13,6 +13,9 @@ const (
 	SourceEmby     = "emby"
 	SourceJellyfin = "jellyfin"
 	SourceLidarr   = "lidarr"
+	SourcePlex     = "plex"
+	SourceSubsonic = "subsonic"
+	SourceKodi     = "kodi"
 )
 
 // FSWatch mode constants (bitfield).
@@ -39,7 +42,7 @@ type Library struct {
 	Name                   string    `json:"name"`
 	Path                   string    `json:"path"`
 	Type                   string    `json:"type"`                          // always "regular" as of v1.3.0
-	Source                 string    `json:"source"`                        // "manual", "emby", "jellyfin", "lidarr"
+	Source                 string    `json:"source"`                        // "manual", "emby", "jellyfin", "lidarr", "plex", "subsonic", "kodi"
 	ConnectionID           string    `json:"connection_id"`                 // FK to connections.id (empty for manual)
 	ExternalID             string    `json:"external_id"`                   // Platform-specific library ID
 	FSWatch                int       `json:"fs_watch"`                      // Bitfield: 0=off, 1=watch, 2=poll, 3=both
@@ -48,6 +51,7 @@ type Library struct {
 	SharedFSEvidence       string    `json:"shared_fs_evidence"`            // JSON array of evidence strings
 	SharedFSPeerLibraryIDs string    `json:"shared_fs_peer_library_ids"`    // Comma-separated library IDs
 	NFOLockData            bool      `json:"nfo_lock_data"`                 // When true, NFOs written for artists in this library carry <lockdata>true</lockdata>; opt-in, default false (issue #1264)
+	Layout                 string    `json:"layout"`                        // Directory layout template, e.g. "{letter}/{artist}"; "" = artist directories directly in Path
 	FSNotifySupported      bool      `json:"fs_notify_supported,omitempty"` // Runtime-only, not stored in DB
 	CreatedAt              time.Time `json:"created_at"`
 	UpdatedAt              time.Time `json:"updated_at"`
@@ -81,6 +85,12 @@ func (lib Library) SourceDisplayName() string {
 		return "Jellyfin"
 	case SourceLidarr:
 		return "Lidarr"
+	case SourcePlex:
+		return "Plex"
+	case SourceSubsonic:
+		return "Navidrome"
+	case SourceKodi:
+		return "Kodi"
 	default:
 		return ""
 	}
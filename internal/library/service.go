@@ -13,7 +13,7 @@ import (
 	"github.com/sydlexius/stillwater/internal/dbutil"
 )
 
-const libraryColumns = `id, name, path, type, source, connection_id, external_id, fs_watch, fs_poll_interval, shared_fs_status, shared_fs_evidence, shared_fs_peer_library_ids, nfo_lock_data, created_at, updated_at`
+const libraryColumns = `id, name, path, type, source, connection_id, external_id, fs_watch, fs_poll_interval, shared_fs_status, shared_fs_evidence, shared_fs_peer_library_ids, nfo_lock_data, layout, created_at, updated_at`
 
 // Service provides library data operations.
 type Service struct {
@@ -37,7 +37,7 @@ func (s *Service) Create(ctx context.Context, lib *Library) error {
 		lib.Source = SourceManual
 	}
 	if !isValidSource(lib.Source) {
-		return fmt.Errorf("library source must be one of %q, %q, %q, %q", SourceManual, SourceEmby, SourceJellyfin, SourceLidarr)
+		return fmt.Errorf("library source must be one of %q, %q, %q, %q, %q, %q, %q", SourceManual, SourceEmby, SourceJellyfin, SourceLidarr, SourcePlex, SourceSubsonic, SourceKodi)
 	}
 	if lib.Path != "" {
 		cleaned, err := ValidatePath(lib.Path)
@@ -49,6 +49,11 @@ func (s *Service) Create(ctx context.Context, lib *Library) error {
 		}
 		lib.Path = cleaned
 	}
+	layout, err := ValidateLayout(lib.Layout)
+	if err != nil {
+		return err
+	}
+	lib.Layout = layout
 
 	if lib.ID == "" {
 		lib.ID = uuid.New().String()
@@ -60,15 +65,15 @@ func (s *Service) Create(ctx context.Context, lib *Library) error {
 	lib.CreatedAt = now
 	lib.UpdatedAt = now
 
-	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO libraries (id, name, path, type, source, connection_id, external_id, fs_watch, fs_poll_interval, shared_fs_status, shared_fs_evidence, shared_fs_peer_library_ids, nfo_lock_data, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO libraries (id, name, path, type, source, connection_id, external_id, fs_watch, fs_poll_interval, shared_fs_status, shared_fs_evidence, shared_fs_peer_library_ids, nfo_lock_data, layout, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		lib.ID, lib.Name, lib.Path, lib.Type,
 		lib.Source, dbutil.NullableString(lib.ConnectionID), lib.ExternalID,
 		lib.FSWatch, lib.FSPollInterval,
 		lib.SharedFSStatus, lib.SharedFSEvidence, lib.SharedFSPeerLibraryIDs,
-		boolToInt(lib.NFOLockData),
+		boolToInt(lib.NFOLockData), lib.Layout,
 		now.Format(time.RFC3339), now.Format(time.RFC3339),
 	)
 	if err != nil {
@@ -154,7 +159,7 @@ func (s *Service) Update(ctx context.Context, lib *Library) error {
 		lib.Source = SourceManual
 	}
 	if !isValidSource(lib.Source) {
-		return fmt.Errorf("library source must be one of %q, %q, %q, %q", SourceManual, SourceEmby, SourceJellyfin, SourceLidarr)
+		return fmt.Errorf("library source must be one of %q, %q, %q, %q, %q, %q, %q", SourceManual, SourceEmby, SourceJellyfin, SourceLidarr, SourcePlex, SourceSubsonic, SourceKodi)
 	}
 	if lib.Path != "" {
 		cleaned, err := ValidatePath(lib.Path)
@@ -166,6 +171,11 @@ func (s *Service) Update(ctx context.Context, lib *Library) error {
 		}
 		lib.Path = cleaned
 	}
+	layout, err := ValidateLayout(lib.Layout)
+	if err != nil {
+		return err
+	}
+	lib.Layout = layout
 
 	if lib.FSPollInterval <= 0 || !IsValidPollInterval(lib.FSPollInterval) {
 		lib.FSPollInterval = 60
@@ -173,14 +183,14 @@ func (s *Service) Update(ctx context.Context, lib *Library) error {
 	lib.UpdatedAt = time.Now().UTC()
 
 	result, err := s.db.ExecContext(ctx, `
-		UPDATE libraries SET name = ?, path = ?, type = ?, source = ?, connection_id = ?, external_id = ?, fs_watch = ?, fs_poll_interval = ?, shared_fs_status = ?, shared_fs_evidence = ?, shared_fs_peer_library_ids = ?, nfo_lock_data = ?, updated_at = ?
+		UPDATE libraries SET name = ?, path = ?, type = ?, source = ?, connection_id = ?, external_id = ?, fs_watch = ?, fs_poll_interval = ?, shared_fs_status = ?, shared_fs_evidence = ?, shared_fs_peer_library_ids = ?, nfo_lock_data = ?, layout = ?, updated_at = ?
 		WHERE id = ?
 	`,
 		lib.Name, lib.Path, lib.Type,
 		lib.Source, dbutil.NullableString(lib.ConnectionID), lib.ExternalID,
 		lib.FSWatch, lib.FSPollInterval,
 		lib.SharedFSStatus, lib.SharedFSEvidence, lib.SharedFSPeerLibraryIDs,
-		boolToInt(lib.NFOLockData),
+		boolToInt(lib.NFOLockData), lib.Layout,
 		lib.UpdatedAt.Format(time.RFC3339),
 		lib.ID,
 	)
@@ -558,7 +568,7 @@ func scanLibrary(row interface{ Scan(...any) error }) (*Library, error) {
 		&lib.Source, &connectionID, &lib.ExternalID,
 		&lib.FSWatch, &lib.FSPollInterval,
 		&lib.SharedFSStatus, &lib.SharedFSEvidence, &lib.SharedFSPeerLibraryIDs,
-		&nfoLockData,
+		&nfoLockData, &lib.Layout,
 		&createdAt, &updatedAt,
 	)
 	if err != nil {
@@ -696,6 +706,24 @@ func (s *Service) FindForArtistPath(ctx context.Context, artistPath string) (*Li
 	return best, nil
 }
 
+// ArtistDirParent returns the directory the artist directory at artistPath
+// belongs in once it is renamed to dirName, following the layout of the
+// library that owns it (see Library.ArtistDirParent). A path outside every
+// library, or not at its library's artist depth, keeps its parent.
+// Implements artist.DirectoryPlacer.
+func (s *Service) ArtistDirParent(ctx context.Context, artistPath, dirName string) (string, error) {
+	lib, err := s.FindForArtistPath(ctx, artistPath)
+	if err != nil {
+		return "", err
+	}
+	if lib != nil {
+		if parent, ok := lib.ArtistDirParent(artistPath, dirName); ok {
+			return parent, nil
+		}
+	}
+	return filepath.Dir(filepath.Clean(artistPath)), nil
+}
+
 // pathContains reports whether parent is an ancestor of, or equal to, child
 // using filesystem path semantics. Both inputs are expected to be cleaned.
 // The check is a prefix match guarded by a separator boundary so siblings
@@ -728,7 +756,7 @@ func (s *Service) HasLocalLibrary(ctx context.Context) (bool, error) {
 // isValidSource reports whether s is one of the allowed library source values.
 func isValidSource(s string) bool {
 	switch s {
-	case SourceManual, SourceEmby, SourceJellyfin, SourceLidarr:
+	case SourceManual, SourceEmby, SourceJellyfin, SourceLidarr, SourcePlex, SourceSubsonic, SourceKodi:
 		return true
 	default:
 		return false
@@ -22,6 +22,12 @@ type Config struct {
 	FileMaxSizeMB  int    `json:"file_max_size_mb,omitempty"`
 	FileMaxFiles   int    `json:"file_max_files,omitempty"`
 	FileMaxAgeDays int    `json:"file_max_age_days,omitempty"`
+
+	// Console replaces stdout as the console destination when non-nil. The
+	// headless CLI commands set it to stderr so their machine-readable
+	// output owns stdout. Not persisted; Reconfigure keeps the current
+	// value when the new Config leaves it nil.
+	Console io.Writer `json:"-"`
 }
 
 // SwappableHandler is a thread-safe slog.Handler that delegates to an inner
@@ -173,6 +179,10 @@ func (m *Manager) Reconfigure(cfg Config) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	if cfg.Console == nil {
+		cfg.Console = m.config.Console
+	}
+
 	newLevel := parseLevel(cfg.Level)
 	m.levelVar.Set(newLevel)
 
@@ -308,11 +318,16 @@ func FormatLevel(l slog.Level) string {
 }
 
 // buildWriter creates the io.Writer for log output. If a file path is
-// configured, it returns a MultiWriter (stdout + lumberjack) and the
-// lumberjack logger as the closer.
+// configured, it returns a MultiWriter (console + lumberjack) and the
+// lumberjack logger as the closer. The console is stdout unless cfg.Console
+// overrides it.
 func buildWriter(cfg Config) (io.Writer, io.Closer) {
+	var console io.Writer = os.Stdout
+	if cfg.Console != nil {
+		console = cfg.Console
+	}
 	if cfg.FilePath == "" {
-		return os.Stdout, nil
+		return console, nil
 	}
 
 	maxSize := cfg.FileMaxSizeMB
@@ -336,7 +351,7 @@ func buildWriter(cfg Config) (io.Writer, io.Closer) {
 		Compress:   false,
 	}
 
-	return io.MultiWriter(os.Stdout, lj), lj
+	return io.MultiWriter(console, lj), lj
 }
 
 // buildHandler creates a slog.Handler with the given writer, leveler, and format.
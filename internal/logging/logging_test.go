@@ -1,10 +1,12 @@
 package logging
 
 import (
+	"bytes"
 	"context"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -108,6 +110,26 @@ func TestManager_FileOutput(t *testing.T) {
 	}
 }
 
+// TestManager_ConsoleOverride pins the headless CLI's contract: with Console
+// set, log lines go there rather than stdout, and a Reconfigure that does not
+// mention Console (the DB logging overrides) keeps them there.
+func TestManager_ConsoleOverride(t *testing.T) {
+	var buf bytes.Buffer
+	mgr, logger := NewManager(Config{Level: "info", Format: "json", Console: &buf})
+	defer mgr.Close()
+
+	logger.Info("before reconfigure")
+	mgr.Reconfigure(Config{Level: "info", Format: "text"})
+	logger.Info("after reconfigure")
+
+	out := buf.String()
+	for _, want := range []string{"before reconfigure", "after reconfigure"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("console output missing %q:\n%s", want, out)
+		}
+	}
+}
+
 func TestManager_CloseIdempotent(t *testing.T) {
 	mgr, _ := NewManager(DefaultConfig())
 	if err := mgr.Close(); err != nil {
@@ -0,0 +1,241 @@
+package nfo
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// AlbumNFO represents the XML structure of a Kodi/Emby/Jellyfin album.nfo file,
+// the per-album counterpart of ArtistNFO. It lives next to the album's audio
+// files, one directory below artist.nfo.
+//
+// Only the fields Stillwater manages are modeled. Everything else -- track
+// listings, <albumArtistCredits>, <path>, platform-specific extras -- is kept
+// in ExtraElements and written back verbatim, so rewriting an album.nfo never
+// drops data another tool put there.
+type AlbumNFO struct {
+	XMLName                   xml.Name        `xml:"album"`
+	Title                     string          `xml:"title,omitempty"`
+	MusicBrainzAlbumID        string          `xml:"musicbrainzalbumid,omitempty"`
+	MusicBrainzReleaseGroupID string          `xml:"musicbrainzreleasegroupid,omitempty"`
+	ArtistDesc                string          `xml:"artistdesc,omitempty"`
+	Genres                    []string        `xml:"genre,omitempty"`
+	Styles                    []string        `xml:"style,omitempty"`
+	Moods                     []string        `xml:"mood,omitempty"`
+	Review                    string          `xml:"review,omitempty"`
+	ReleaseType               string          `xml:"releasetype,omitempty"`
+	ReleaseDate               string          `xml:"releasedate,omitempty"`
+	OriginalReleaseDate       string          `xml:"originalreleasedate,omitempty"`
+	Label                     string          `xml:"label,omitempty"`
+	Year                      string          `xml:"year,omitempty"`
+	Thumbs                    []Thumb         `xml:"thumb,omitempty"`
+	LockData                  bool            `xml:"lockdata,omitempty"`
+	Stillwater                *StillwaterMeta `xml:"stillwater,omitempty"`
+	ExtraElements             []RawElement    `xml:"-"`
+}
+
+// albumStringFieldTarget is stringFieldTarget for album.nfo.
+//
+// Kodi writes the release type as <releasetype>; older Emby exports use <type>
+// for the same value. Both land in ReleaseType, and Write emits <releasetype>.
+var albumStringFieldTarget = map[string]func(*AlbumNFO) *string{
+	"title":                     func(n *AlbumNFO) *string { return &n.Title },
+	"musicbrainzalbumid":        func(n *AlbumNFO) *string { return &n.MusicBrainzAlbumID },
+	"musicbrainzreleasegroupid": func(n *AlbumNFO) *string { return &n.MusicBrainzReleaseGroupID },
+	"artistdesc":                func(n *AlbumNFO) *string { return &n.ArtistDesc },
+	"review":                    func(n *AlbumNFO) *string { return &n.Review },
+	"releasetype":               func(n *AlbumNFO) *string { return &n.ReleaseType },
+	"type":                      func(n *AlbumNFO) *string { return &n.ReleaseType },
+	"releasedate":               func(n *AlbumNFO) *string { return &n.ReleaseDate },
+	"originalreleasedate":       func(n *AlbumNFO) *string { return &n.OriginalReleaseDate },
+	"label":                     func(n *AlbumNFO) *string { return &n.Label },
+	"year":                      func(n *AlbumNFO) *string { return &n.Year },
+}
+
+// ParseAlbum reads an album.nfo from the reader. It applies the same input
+// hardening as Parse (size cap, BOM strip, HTML entity rewrite, lenient
+// decoder) and preserves unknown elements for round-trip fidelity.
+func ParseAlbum(r io.Reader) (*AlbumNFO, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxNFOBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading album nfo data: %w", err)
+	}
+	if int64(len(data)) > maxNFOBytes {
+		return nil, fmt.Errorf("album nfo file too large (max %d bytes)", maxNFOBytes)
+	}
+
+	data = stripBOM(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty album nfo file")
+	}
+
+	content := htmlEntityReplacer.Replace(string(data))
+
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+
+	nfo := &AlbumNFO{}
+	if err := parseAlbumTokens(decoder, nfo); err != nil {
+		return nil, fmt.Errorf("parsing album nfo xml: %w", err)
+	}
+	return nfo, nil
+}
+
+// parseAlbumTokens walks the token stream of an album.nfo. It mirrors
+// parseTokens: known children of the root <album> populate fields, unknown
+// children are captured raw, and elements with names a strict re-serializer
+// would reject are skipped.
+//
+// Only a document whose ROOT is <album> is read. artist.nfo also contains
+// <album> elements (the discography entries), and a misnamed artist.nfo handed
+// to this parser must come back empty rather than as an album titled after the
+// artist's first release.
+func parseAlbumTokens(decoder *xml.Decoder, nfo *AlbumNFO) error {
+	var seenRoot bool
+
+	for {
+		tok, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if !seenRoot {
+				seenRoot = true
+				if t.Name.Local != "album" {
+					return decoder.Skip()
+				}
+				continue
+			}
+			if err := parseAlbumChild(decoder, nfo, t); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			if t.Name.Local == "album" {
+				return nil
+			}
+		}
+	}
+}
+
+// parseAlbumChild handles one direct child of the root <album> element.
+func parseAlbumChild(decoder *xml.Decoder, nfo *AlbumNFO, start xml.StartElement) error {
+	name := start.Name.Local
+	if target := albumStringFieldTarget[name]; target != nil {
+		return decodeCharData(decoder, target(nfo))
+	}
+
+	switch name {
+	case "genre":
+		return parseSliceField(decoder, &nfo.Genres)
+	case "style":
+		return parseSliceField(decoder, &nfo.Styles)
+	case "mood":
+		return parseSliceField(decoder, &nfo.Moods)
+	case "thumb":
+		thumb := Thumb{}
+		for _, attr := range start.Attr {
+			switch attr.Name.Local {
+			case "aspect":
+				thumb.Aspect = attr.Value
+			case "preview":
+				thumb.Preview = attr.Value
+			}
+		}
+		if err := decodeCharData(decoder, &thumb.Value); err != nil {
+			return err
+		}
+		nfo.Thumbs = append(nfo.Thumbs, thumb)
+		return nil
+	case "lockdata":
+		var s string
+		if err := decodeCharData(decoder, &s); err != nil {
+			return err
+		}
+		nfo.LockData = parseBoolString(s)
+		return nil
+	case "stillwater":
+		nfo.Stillwater = &StillwaterMeta{}
+		for _, attr := range start.Attr {
+			switch attr.Name.Local {
+			case "version":
+				nfo.Stillwater.Version = attr.Value
+			case "written":
+				nfo.Stillwater.Written = attr.Value
+			}
+		}
+		return decoder.Skip()
+	}
+
+	if !isValidXMLName(start.Name) {
+		return decoder.Skip()
+	}
+	raw, err := captureRawElement(decoder, start)
+	if err != nil {
+		return err
+	}
+	nfo.ExtraElements = append(nfo.ExtraElements, RawElement{Name: name, Raw: raw})
+	return nil
+}
+
+// WriteAlbum writes an AlbumNFO as XML to the writer, known fields first in
+// Kodi order, then thumbs, then preserved unknown elements.
+func WriteAlbum(w io.Writer, nfo *AlbumNFO) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "<album>\n"); err != nil {
+		return err
+	}
+
+	writeElement(w, "title", nfo.Title)
+	writeElement(w, "musicbrainzalbumid", nfo.MusicBrainzAlbumID)
+	writeElement(w, "musicbrainzreleasegroupid", nfo.MusicBrainzReleaseGroupID)
+	writeElement(w, "artistdesc", nfo.ArtistDesc)
+	for _, g := range nfo.Genres {
+		writeElement(w, "genre", g)
+	}
+	for _, s := range nfo.Styles {
+		writeElement(w, "style", s)
+	}
+	for _, m := range nfo.Moods {
+		writeElement(w, "mood", m)
+	}
+	writeElement(w, "review", nfo.Review)
+	writeElement(w, "releasetype", nfo.ReleaseType)
+	writeElement(w, "releasedate", nfo.ReleaseDate)
+	writeElement(w, "originalreleasedate", nfo.OriginalReleaseDate)
+	writeElement(w, "label", nfo.Label)
+	writeElement(w, "year", nfo.Year)
+
+	if nfo.LockData {
+		fmt.Fprintf(w, "  <lockdata>true</lockdata>\n") //nolint:errcheck // best-effort write to io.Writer; intermediate serializer fragment errors are intentionally ignored
+	}
+	if nfo.Stillwater != nil {
+		fmt.Fprintf(w, "  <stillwater version=%q written=%q />\n", //nolint:errcheck // best-effort write to io.Writer; intermediate serializer fragment errors are intentionally ignored
+			nfo.Stillwater.Version, nfo.Stillwater.Written)
+	}
+
+	for _, thumb := range nfo.Thumbs {
+		writeThumb(w, thumb)
+	}
+
+	for _, extra := range nfo.ExtraElements {
+		fmt.Fprintf(w, "  ") //nolint:errcheck // best-effort write to io.Writer; intermediate serializer fragment errors are intentionally ignored
+		w.Write(extra.Raw)   //nolint:errcheck // best-effort write to io.Writer; intermediate serializer fragment errors are intentionally ignored
+		fmt.Fprintf(w, "\n") //nolint:errcheck // best-effort write to io.Writer; intermediate serializer fragment errors are intentionally ignored
+	}
+
+	if _, err := io.WriteString(w, "</album>\n"); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package nfo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const albumNFOFixture = `<?xml version="1.0" encoding="UTF-8" standalone="yes" ?>
+<album>
+  <title>Nevermind</title>
+  <musicbrainzalbumid>b52a8f31-b5ab-34e9-92f4-f5b7110220f0</musicbrainzalbumid>
+  <musicbrainzreleasegroupid>1b022e01-4da6-387b-8658-8678046e4cef</musicbrainzreleasegroupid>
+  <artistdesc>Nirvana</artistdesc>
+  <genre>Grunge</genre>
+  <genre>Alternative Rock</genre>
+  <style>Grunge</style>
+  <mood>Angry</mood>
+  <review>Second studio album &amp; breakthrough.&nbsp;</review>
+  <type>album</type>
+  <releasedate>1991-09-24</releasedate>
+  <label>DGC</label>
+  <year>1991</year>
+  <lockdata>true</lockdata>
+  <thumb aspect="thumb">https://example.com/cover.jpg</thumb>
+  <albumArtistCredits>
+    <artist>Nirvana</artist>
+    <musicBrainzArtistID>5b11f4ce-a62d-471e-81fc-a69a8278c7da</musicBrainzArtistID>
+  </albumArtistCredits>
+  <track>
+    <position>1</position>
+    <title>Smells Like Teen Spirit</title>
+  </track>
+</album>
+`
+
+func TestParseAlbum(t *testing.T) {
+	n, err := ParseAlbum(strings.NewReader(albumNFOFixture))
+	if err != nil {
+		t.Fatalf("ParseAlbum: %v", err)
+	}
+
+	checks := []struct{ field, got, want string }{
+		{"Title", n.Title, "Nevermind"},
+		{"MusicBrainzAlbumID", n.MusicBrainzAlbumID, "b52a8f31-b5ab-34e9-92f4-f5b7110220f0"},
+		{"MusicBrainzReleaseGroupID", n.MusicBrainzReleaseGroupID, "1b022e01-4da6-387b-8658-8678046e4cef"},
+		{"ArtistDesc", n.ArtistDesc, "Nirvana"},
+		{"ReleaseType", n.ReleaseType, "album"},
+		{"ReleaseDate", n.ReleaseDate, "1991-09-24"},
+		{"Label", n.Label, "DGC"},
+		{"Year", n.Year, "1991"},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %q, want %q", c.field, c.got, c.want)
+		}
+	}
+	if !strings.HasPrefix(n.Review, "Second studio album & breakthrough.") {
+		t.Errorf("Review = %q", n.Review)
+	}
+	if len(n.Genres) != 2 || n.Genres[1] != "Alternative Rock" {
+		t.Errorf("Genres = %v", n.Genres)
+	}
+	if len(n.Styles) != 1 || len(n.Moods) != 1 {
+		t.Errorf("Styles = %v, Moods = %v", n.Styles, n.Moods)
+	}
+	if !n.LockData {
+		t.Error("LockData = false, want true")
+	}
+	if len(n.Thumbs) != 1 || n.Thumbs[0].Aspect != "thumb" {
+		t.Errorf("Thumbs = %+v", n.Thumbs)
+	}
+	if len(n.ExtraElements) != 2 {
+		t.Fatalf("ExtraElements = %d, want 2 (albumArtistCredits, track)", len(n.ExtraElements))
+	}
+	if n.ExtraElements[0].Name != "albumArtistCredits" || n.ExtraElements[1].Name != "track" {
+		t.Errorf("ExtraElements names = %q, %q", n.ExtraElements[0].Name, n.ExtraElements[1].Name)
+	}
+}
+
+func TestWriteAlbum_RoundTrip(t *testing.T) {
+	orig, err := ParseAlbum(strings.NewReader(albumNFOFixture))
+	if err != nil {
+		t.Fatalf("ParseAlbum: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAlbum(&buf, orig); err != nil {
+		t.Fatalf("WriteAlbum: %v", err)
+	}
+	out := buf.String()
+
+	// The legacy <type> spelling is normalized to Kodi's <releasetype>.
+	if !strings.Contains(out, "<releasetype>album</releasetype>") || strings.Contains(out, "<type>") {
+		t.Errorf("release type not normalized:\n%s", out)
+	}
+	// Unknown elements survive the rewrite.
+	if !strings.Contains(out, "<title>Smells Like Teen Spirit</title>") {
+		t.Errorf("track listing dropped:\n%s", out)
+	}
+
+	again, err := ParseAlbum(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("re-parsing written nfo: %v", err)
+	}
+	if again.Title != orig.Title || again.MusicBrainzReleaseGroupID != orig.MusicBrainzReleaseGroupID ||
+		again.Review != orig.Review || again.LockData != orig.LockData ||
+		len(again.Genres) != len(orig.Genres) || len(again.ExtraElements) != len(orig.ExtraElements) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", again, orig)
+	}
+}
+
+func TestParseAlbum_Empty(t *testing.T) {
+	if _, err := ParseAlbum(strings.NewReader("")); err == nil {
+		t.Error("expected error for empty album nfo")
+	}
+}
+
+func TestParseAlbum_IgnoresArtistRoot(t *testing.T) {
+	// An artist.nfo handed to the album parser yields no album fields rather
+	// than mis-reading the artist's <album> discography entries.
+	n, err := ParseAlbum(strings.NewReader(`<artist><name>X</name><album><title>Bleach</title></album></artist>`))
+	if err != nil {
+		t.Fatalf("ParseAlbum: %v", err)
+	}
+	if n.Title != "" || len(n.ExtraElements) != 0 {
+		t.Errorf("got %+v, want empty", n)
+	}
+}
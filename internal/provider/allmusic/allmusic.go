@@ -0,0 +1,330 @@
+package allmusic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/httpsafe"
+	"github.com/sydlexius/stillwater/internal/provider"
+)
+
+const (
+	defaultBaseURL = "https://www.allmusic.com"
+	// userAgent is a browser UA string. AllMusic has no public API and serves
+	// a bot-challenge page to unrecognized clients, so the adapter identifies
+	// the same way the DuckDuckGo adapter does for its HTML endpoints.
+	userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36"
+	// maxPageBytes bounds a single page read. AllMusic artist pages are large
+	// (inline scripts, discography tables) but stay well under this.
+	maxPageBytes = 2 * 1024 * 1024
+	// maxSearchResults caps how many search hits are returned to callers.
+	maxSearchResults = 10
+)
+
+// Adapter implements provider.Provider for AllMusic.
+//
+// AllMusic publishes no API, so the adapter reads the public artist pages:
+// the overview page carries genres, styles, moods, active decades and the
+// artist photo, and the biography tab carries the long-form bio. No API key
+// is needed. AllMusic's curated style and mood taxonomy is the reason this
+// provider exists -- it is the seed for tagdict's canonical map and is
+// consistently richer than the tag clouds other providers return.
+type Adapter struct {
+	client   *http.Client
+	limiter  *provider.RateLimiterMap
+	settings *provider.SettingsService
+	logger   *slog.Logger
+	baseURL  string
+}
+
+// New creates an AllMusic adapter with the default base URL.
+func New(limiter *provider.RateLimiterMap, settings *provider.SettingsService, logger *slog.Logger) *Adapter {
+	return NewWithBaseURL(limiter, settings, logger, defaultBaseURL)
+}
+
+// NewWithBaseURL creates an AllMusic adapter with a custom base URL (for testing).
+func NewWithBaseURL(limiter *provider.RateLimiterMap, settings *provider.SettingsService, logger *slog.Logger, baseURL string) *Adapter {
+	return &Adapter{
+		client:   httpsafe.SafeClient(15 * time.Second),
+		limiter:  limiter,
+		settings: settings,
+		logger:   logger.With(slog.String("provider", "allmusic")),
+		baseURL:  strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// Name returns the provider identifier.
+func (a *Adapter) Name() provider.ProviderName { return provider.NameAllMusic }
+
+// RequiresAuth returns false since AllMusic's public pages need no API key.
+func (a *Adapter) RequiresAuth() bool { return false }
+
+// SupportsNameLookup returns true because GetArtist accepts an artist name
+// (anything that is not an AllMusic ID or a UUID) and resolves it through
+// SearchArtist. AllMusic has no MusicBrainz lookup, so without this the
+// provider would be unreachable for artists whose MusicBrainz entry carries
+// no allmusic URL relation.
+func (a *Adapter) SupportsNameLookup() bool { return true }
+
+// SearchArtist searches AllMusic for artists matching the given name.
+func (a *Adapter) SearchArtist(ctx context.Context, name string) ([]provider.ArtistSearchResult, error) {
+	if provider.ShouldInjectFailure(a.Name()) {
+		return nil, provider.ErrInjectedFailure
+	}
+	if strings.TrimSpace(name) == "" {
+		return nil, nil
+	}
+
+	reqURL := a.baseURL + "/search/artists/" + url.PathEscape(name)
+	body, err := a.doRequest(ctx, reqURL)
+	if err != nil {
+		var notFound *provider.ErrNotFound
+		if errors.As(err, &notFound) {
+			// AllMusic answers a query with no hits with a 404 page rather
+			// than an empty result list.
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	hits := parseSearchResults(body)
+	results := make([]provider.ArtistSearchResult, 0, len(hits))
+	for _, h := range hits {
+		results = append(results, provider.ArtistSearchResult{
+			ProviderID: h.ID,
+			Name:       h.Name,
+			Score:      provider.NameSimilarity(name, h.Name),
+			Source:     string(provider.NameAllMusic),
+		})
+	}
+
+	// Sort by score descending so the best match appears first. Stable so
+	// equal scores keep AllMusic's own relevance order.
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > maxSearchResults {
+		results = results[:maxSearchResults]
+	}
+
+	a.logger.Debug("artist search completed",
+		slog.String("query", name),
+		slog.Int("results", len(results)))
+
+	return results, nil
+}
+
+// GetArtist fetches metadata for an artist. An AllMusic ID ("mn" + 10 digits)
+// is fetched directly; any other non-UUID value is treated as a name and
+// resolved through SearchArtist. UUIDs (MusicBrainz IDs) are rejected with
+// ErrNotFound since AllMusic cannot look them up, which lets the orchestrator
+// fall back to a name lookup via SupportsNameLookup.
+func (a *Adapter) GetArtist(ctx context.Context, id string) (*provider.ArtistMetadata, error) {
+	if provider.ShouldInjectFailure(a.Name()) {
+		return nil, provider.ErrInjectedFailure
+	}
+	id = strings.TrimSpace(id)
+	if id == "" || provider.IsUUID(id) {
+		return nil, &provider.ErrNotFound{Provider: provider.NameAllMusic, ID: id}
+	}
+	if !isAllMusicID(id) {
+		return a.getArtistByName(ctx, id)
+	}
+	return a.getArtistByID(ctx, id)
+}
+
+// GetImages returns the artist photo from the AllMusic overview page. Only
+// AllMusic IDs are accepted; anything else returns ErrNotFound because a
+// name-resolved image is too likely to belong to a namesake.
+func (a *Adapter) GetImages(ctx context.Context, id string) ([]provider.ImageResult, error) {
+	if provider.ShouldInjectFailure(a.Name()) {
+		return nil, provider.ErrInjectedFailure
+	}
+	if !isAllMusicID(id) {
+		return nil, &provider.ErrNotFound{Provider: provider.NameAllMusic, ID: id}
+	}
+
+	body, err := a.doRequest(ctx, a.artistURL(id, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	page := parseArtistPage(body)
+	if page.ImageURL == "" {
+		return nil, nil
+	}
+	return []provider.ImageResult{{
+		URL:    page.ImageURL,
+		Type:   provider.ImageThumb,
+		Source: string(provider.NameAllMusic),
+	}}, nil
+}
+
+// TestConnection verifies AllMusic is reachable and still serves artist
+// search pages the adapter can parse.
+func (a *Adapter) TestConnection(ctx context.Context) error {
+	_, err := a.doRequest(ctx, a.baseURL+"/search/artists/"+url.PathEscape("radiohead"))
+	return err
+}
+
+// getArtistByID fetches the overview page and the biography tab and merges
+// them. A failed biography fetch is logged and tolerated: the overview page
+// alone carries the styles and moods this provider is primarily used for.
+func (a *Adapter) getArtistByID(ctx context.Context, id string) (*provider.ArtistMetadata, error) {
+	body, err := a.doRequest(ctx, a.artistURL(id, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	page := parseArtistPage(body)
+	if page.Name == "" {
+		// A 200 with no artist heading is AllMusic's soft-404 page.
+		return nil, &provider.ErrNotFound{Provider: provider.NameAllMusic, ID: id}
+	}
+
+	meta := &provider.ArtistMetadata{
+		ProviderID:  id,
+		AllMusicID:  id,
+		Name:        page.Name,
+		Genres:      page.Genres,
+		Styles:      page.Styles,
+		Moods:       page.Moods,
+		YearsActive: page.ActiveYears,
+		URLs:        map[string]string{"allmusic": a.artistURL(id, "")},
+	}
+
+	bioBody, err := a.doRequest(ctx, a.artistURL(id, "biography"))
+	if err != nil {
+		var notFound *provider.ErrNotFound
+		if !errors.As(err, &notFound) {
+			a.logger.Warn("fetching biography failed; returning overview data only",
+				slog.String("id", id),
+				slog.String("error", provider.ScrubError(err)))
+		}
+		return meta, nil
+	}
+	meta.Biography = parseBiography(bioBody)
+
+	return meta, nil
+}
+
+// getArtistByName resolves name through SearchArtist and fetches the best hit,
+// subject to the configured name-similarity threshold.
+func (a *Adapter) getArtistByName(ctx context.Context, name string) (*provider.ArtistMetadata, error) {
+	results, err := a.SearchArtist(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, &provider.ErrNotFound{Provider: provider.NameAllMusic, ID: name}
+	}
+	// SearchArtist returns results sorted descending by score,
+	// so the first entry is the best match.
+	best := results[0]
+	threshold, err := a.getNameSimilarityThreshold(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if threshold > 0 && best.Score < threshold {
+		a.logger.Warn("rejecting search result: name similarity too low",
+			slog.String("search_term", name),
+			slog.String("result_name", best.Name),
+			slog.Int("similarity", best.Score),
+			slog.Int("threshold", threshold),
+		)
+		return nil, &provider.ErrNotFound{Provider: provider.NameAllMusic, ID: name}
+	}
+	return a.getArtistByID(ctx, best.ProviderID)
+}
+
+// artistURL builds the URL of an artist page, optionally a sub-tab such as
+// "biography".
+func (a *Adapter) artistURL(id, tab string) string {
+	u := a.baseURL + "/artist/" + url.PathEscape(id)
+	if tab != "" {
+		u += "/" + tab
+	}
+	return u
+}
+
+// doRequest executes a GET request and returns the response body, backing off
+// and retrying on a rate-limited (429) or unavailable (503) response via
+// provider.DoWithRetry.
+func (a *Adapter) doRequest(ctx context.Context, reqURL string) ([]byte, error) {
+	// do performs one HTTP attempt. The limiter wait lives inside it so each
+	// retry triggered by DoWithRetry still respects the per-provider budget.
+	do := func(ctx context.Context) (*http.Response, error) {
+		if err := a.limiter.Wait(ctx, provider.NameAllMusic); err != nil {
+			return nil, &provider.ErrProviderUnavailable{
+				Provider: provider.NameAllMusic,
+				Cause:    fmt.Errorf("rate limiter: %w", err),
+			}
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, http.NoBody)
+		if err != nil {
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		req.Header.Set("User-Agent", userAgent)
+		req.Header.Set("Accept", "text/html")
+		return a.client.Do(req)
+	}
+
+	// DoWithRetry consumes 429/503, so the switch below only sees 200/404/other.
+	resp, err := provider.DoWithRetry(ctx, provider.SystemClock(), provider.NameAllMusic, provider.DefaultRetryPolicy(), do)
+	if err != nil {
+		var unavailable *provider.ErrProviderUnavailable
+		if errors.As(err, &unavailable) {
+			return nil, err
+		}
+		return nil, &provider.ErrProviderUnavailable{
+			Provider: provider.NameAllMusic,
+			Cause:    err,
+		}
+	}
+	defer resp.Body.Close() //nolint:errcheck // Close error not actionable on HTTP response cleanup
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// continue
+	case http.StatusNotFound:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, &provider.ErrNotFound{Provider: provider.NameAllMusic, ID: reqURL}
+	default:
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, &provider.ErrProviderUnavailable{
+			Provider: provider.NameAllMusic,
+			Cause:    fmt.Errorf("unexpected status %d", resp.StatusCode),
+		}
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxPageBytes))
+}
+
+// getNameSimilarityThreshold reads the configurable threshold from settings.
+// Returns an error if the context is canceled. Falls back to the default when
+// no settings service is wired or the setting is unreadable.
+func (a *Adapter) getNameSimilarityThreshold(ctx context.Context) (int, error) {
+	if a.settings == nil {
+		return provider.DefaultNameSimilarityThreshold, nil
+	}
+	threshold, err := a.settings.GetNameSimilarityThreshold(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		a.logger.Warn("reading name similarity threshold, using default",
+			slog.Int("default", provider.DefaultNameSimilarityThreshold),
+			slog.String("error", err.Error()),
+		)
+		return provider.DefaultNameSimilarityThreshold, nil
+	}
+	return threshold, nil
+}
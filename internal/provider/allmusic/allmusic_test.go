@@ -0,0 +1,345 @@
+package allmusic
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/provider"
+)
+
+func loadFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("loading fixture %s: %v", name, err)
+	}
+	return data
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		path := r.URL.Path
+
+		switch {
+		case strings.HasPrefix(path, "/search/artists/"):
+			q := strings.TrimPrefix(path, "/search/artists/")
+			if q == "no-results-query" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write(loadFixture(t, "search_radiohead.html"))
+
+		case strings.HasPrefix(path, "/artist/") && strings.HasSuffix(path, "/biography"):
+			id := strings.TrimSuffix(strings.TrimPrefix(path, "/artist/"), "/biography")
+			switch id {
+			case "mn0000326249":
+				_, _ = w.Write(loadFixture(t, "biography_radiohead.html"))
+			case "mn0000000500":
+				w.WriteHeader(http.StatusInternalServerError)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+
+		case strings.HasPrefix(path, "/artist/"):
+			id := strings.TrimPrefix(path, "/artist/")
+			switch id {
+			case "mn0000326249", "mn0000000500":
+				_, _ = w.Write(loadFixture(t, "artist_radiohead.html"))
+			case "mn0000000001":
+				_, _ = w.Write(loadFixture(t, "artist_no_photo.html"))
+			case "mn0000000404":
+				// Soft 404: AllMusic answers 200 with a generic page.
+				_, _ = w.Write(loadFixture(t, "not_an_artist.html"))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newTestAdapter(t *testing.T, baseURL string) *Adapter {
+	t.Helper()
+	limiter := provider.NewRateLimiterMap()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	a := NewWithBaseURL(limiter, nil, logger, baseURL)
+	// Override the SafeClient-backed default (which rejects httptest's loopback) with a plain client.
+	a.client = &http.Client{Timeout: 10 * time.Second}
+	return a
+}
+
+func TestName(t *testing.T) {
+	a := newTestAdapter(t, "http://localhost")
+	if a.Name() != provider.NameAllMusic {
+		t.Errorf("expected %q, got %q", provider.NameAllMusic, a.Name())
+	}
+	if a.RequiresAuth() {
+		t.Error("expected RequiresAuth to return false")
+	}
+	if !a.SupportsNameLookup() {
+		t.Error("expected SupportsNameLookup to return true")
+	}
+}
+
+func TestSearchArtist(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+	a := newTestAdapter(t, srv.URL)
+
+	results, err := a.SearchArtist(context.Background(), "radiohead")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The duplicate Radiohead entry and the link without an ID are dropped.
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d: %+v", len(results), results)
+	}
+	if results[0].ProviderID != "mn0000326249" || results[0].Name != "Radiohead" {
+		t.Errorf("best hit = %+v, want Radiohead mn0000326249", results[0])
+	}
+	if results[0].Score < results[1].Score {
+		t.Errorf("results not sorted by score: %d < %d", results[0].Score, results[1].Score)
+	}
+	if results[0].Source != "allmusic" {
+		t.Errorf("Source = %q, want allmusic", results[0].Source)
+	}
+}
+
+func TestSearchArtist_NoResults(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+	a := newTestAdapter(t, srv.URL)
+
+	results, err := a.SearchArtist(context.Background(), "no-results-query")
+	if err != nil {
+		t.Fatalf("a 404 search page must read as zero results, got error: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("expected no results, got %d", len(results))
+	}
+}
+
+func TestGetArtist_ByID(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+	a := newTestAdapter(t, srv.URL)
+
+	meta, err := a.GetArtist(context.Background(), "mn0000326249")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if meta.Name != "Radiohead" {
+		t.Errorf("Name = %q, want Radiohead", meta.Name)
+	}
+	if meta.AllMusicID != "mn0000326249" || meta.ProviderID != "mn0000326249" {
+		t.Errorf("IDs = %q/%q, want mn0000326249", meta.ProviderID, meta.AllMusicID)
+	}
+	if meta.YearsActive != "1980s-2020s" {
+		t.Errorf("YearsActive = %q, want 1980s-2020s", meta.YearsActive)
+	}
+	assertStrings(t, "Genres", meta.Genres, []string{"Pop/Rock", "Electronic"})
+	assertStrings(t, "Styles", meta.Styles, []string{"Alternative/Indie Rock", "Art Rock", "Britpop"})
+	assertStrings(t, "Moods", meta.Moods, []string{"Brooding", "Atmospheric", "Cerebral"})
+
+	wantBio := "Radiohead became one of the most acclaimed bands of their era by pushing guitar rock into electronic territory.\n\n" +
+		"Formed in Abingdon by Thom Yorke, the group released Pablo Honey in 1993 & followed it with The Bends."
+	if meta.Biography != wantBio {
+		t.Errorf("Biography =\n%q\nwant\n%q", meta.Biography, wantBio)
+	}
+	if !strings.HasSuffix(meta.URLs["allmusic"], "/artist/mn0000326249") {
+		t.Errorf("URLs[allmusic] = %q", meta.URLs["allmusic"])
+	}
+}
+
+func TestGetArtist_ByName(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+	a := newTestAdapter(t, srv.URL)
+
+	meta, err := a.GetArtist(context.Background(), "Radiohead")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if meta.AllMusicID != "mn0000326249" {
+		t.Errorf("AllMusicID = %q, want the best search hit", meta.AllMusicID)
+	}
+}
+
+func TestGetArtist_ByName_BelowThreshold(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+	a := newTestAdapter(t, srv.URL)
+
+	// The fixture always returns Radiohead hits; an unrelated query must be
+	// rejected by the similarity gate rather than adopting a namesake.
+	_, err := a.GetArtist(context.Background(), "Completely Different Artist")
+	var notFound *provider.ErrNotFound
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestGetArtist_UUIDRejected(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	a := newTestAdapter(t, srv.URL)
+
+	_, err := a.GetArtist(context.Background(), "a74b1b7f-71a5-4011-9441-d0b5e4122711")
+	var notFound *provider.ErrNotFound
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected ErrNotFound for an MBID, got %v", err)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("expected no HTTP requests for an MBID, got %d", n)
+	}
+}
+
+func TestGetArtist_NotFound(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+	a := newTestAdapter(t, srv.URL)
+
+	for _, id := range []string{"mn0000009999", "mn0000000404"} {
+		_, err := a.GetArtist(context.Background(), id)
+		var notFound *provider.ErrNotFound
+		if !errors.As(err, &notFound) {
+			t.Errorf("GetArtist(%s): expected ErrNotFound, got %v", id, err)
+		}
+	}
+}
+
+func TestGetArtist_BiographyFailureTolerated(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+	a := newTestAdapter(t, srv.URL)
+
+	meta, err := a.GetArtist(context.Background(), "mn0000000500")
+	if err != nil {
+		t.Fatalf("a failed biography fetch must not fail GetArtist: %v", err)
+	}
+	if meta.Biography != "" {
+		t.Errorf("Biography = %q, want empty", meta.Biography)
+	}
+	if len(meta.Styles) == 0 {
+		t.Error("expected overview styles to survive the biography failure")
+	}
+}
+
+func TestGetImages(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+	a := newTestAdapter(t, srv.URL)
+
+	images, err := a.GetImages(context.Background(), "mn0000326249")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(images) != 1 {
+		t.Fatalf("expected 1 image, got %d", len(images))
+	}
+	img := images[0]
+	if img.Type != provider.ImageThumb {
+		t.Errorf("Type = %q, want thumb", img.Type)
+	}
+	if img.URL != "https://rovimusic.rovicorp.com/image.jpg?c=radiohead-photo&f=4" {
+		t.Errorf("URL = %q (entities must be decoded)", img.URL)
+	}
+}
+
+func TestGetImages_Placeholder(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+	a := newTestAdapter(t, srv.URL)
+
+	images, err := a.GetImages(context.Background(), "mn0000000001")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(images) != 0 {
+		t.Errorf("expected the placeholder silhouette to be skipped, got %+v", images)
+	}
+}
+
+func TestGetImages_NonAllMusicID(t *testing.T) {
+	a := newTestAdapter(t, "http://localhost")
+	_, err := a.GetImages(context.Background(), "a74b1b7f-71a5-4011-9441-d0b5e4122711")
+	var notFound *provider.ErrNotFound
+	if !errors.As(err, &notFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	a := newTestAdapter(t, srv.URL)
+
+	_, err := a.GetArtist(context.Background(), "mn0000326249")
+	var unavailable *provider.ErrProviderUnavailable
+	if !errors.As(err, &unavailable) {
+		t.Fatalf("expected ErrProviderUnavailable, got %v", err)
+	}
+}
+
+func TestIsAllMusicID(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"mn0000326249", true},
+		{"mn000032624", false},
+		{"mn00003262499", false},
+		{"radiohead-mn0000326249", false},
+		{"MN0000326249", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isAllMusicID(tt.in); got != tt.want {
+			t.Errorf("isAllMusicID(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeActiveYears(t *testing.T) {
+	tests := map[string]string{
+		"1980s - 2020s": "1980s-2020s",
+		"1960s – 1970s": "1960s-1970s",
+		"2010s":         "2010s",
+		"":              "",
+	}
+	for in, want := range tests {
+		if got := normalizeActiveYears(in); got != want {
+			t.Errorf("normalizeActiveYears(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func assertStrings(t *testing.T, field string, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s = %v, want %v", field, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%s[%d] = %q, want %q", field, i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package allmusic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/provider"
+)
+
+// TestInjection_AllMusic verifies that all outbound methods respect the
+// fault-injection hook when SW_FORCE_PROVIDER_ERROR includes "allmusic".
+func TestInjection_AllMusic(t *testing.T) {
+	provider.SetInjectedProviders([]string{"allmusic"})
+	t.Cleanup(func() { provider.SetInjectedProviders(nil) })
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	a := New(provider.NewRateLimiterMap(), nil, logger)
+
+	ctx := context.Background()
+
+	if _, err := a.SearchArtist(ctx, "test"); !errors.Is(err, provider.ErrInjectedFailure) {
+		t.Errorf("SearchArtist: want ErrInjectedFailure, got %v", err)
+	}
+	// GetArtist and GetImages with a well-formed AllMusic ID so the ID guard
+	// does not trigger before the injection hook.
+	if _, err := a.GetArtist(ctx, "mn0000326249"); !errors.Is(err, provider.ErrInjectedFailure) {
+		t.Errorf("GetArtist: want ErrInjectedFailure, got %v", err)
+	}
+	if _, err := a.GetImages(ctx, "mn0000326249"); !errors.Is(err, provider.ErrInjectedFailure) {
+		t.Errorf("GetImages: want ErrInjectedFailure, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package allmusic
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// The parsers below read AllMusic's server-rendered HTML with anchored regular
+// expressions rather than a DOM parser. The page sections the adapter needs
+// are each wrapped in a stable, class-named container, so a targeted match is
+// both simpler and more tolerant of unrelated markup churn than walking a tree.
+// Every parser degrades to a zero value when its container is absent: a
+// missing section means "AllMusic has no data for this", never an error.
+
+var (
+	// allMusicIDRe matches an AllMusic artist ID: "mn" followed by 10 digits.
+	allMusicIDRe = regexp.MustCompile(`mn\d{10}`)
+
+	// searchHitRe matches one artist entry on the search results page. The
+	// name container wraps a link to the artist page whose href carries the
+	// ID, either bare or as a slug suffix.
+	searchHitRe = regexp.MustCompile(`(?s)<div class="name">\s*<a[^>]+href="([^"]+)"[^>]*>(.*?)</a>`)
+
+	artistNameRe  = regexp.MustCompile(`(?s)<h1[^>]*id="artistName"[^>]*>(.*?)</h1>`)
+	activeDatesRe = regexp.MustCompile(`(?s)<div class="activeDates">.*?<div>(.*?)</div>`)
+	genreBlockRe  = regexp.MustCompile(`(?s)<div class="genre">(.*?)</div>`)
+	stylesBlockRe = regexp.MustCompile(`(?s)<div class="styles">(.*?)</div>`)
+	moodsBlockRe  = regexp.MustCompile(`(?s)<div class="moods">(.*?)</div>`)
+	anchorTextRe  = regexp.MustCompile(`(?s)<a[^>]*>(.*?)</a>`)
+	artistImageRe = regexp.MustCompile(`(?s)<div class="artistImage">.*?<img[^>]+src="([^"]+)"`)
+
+	biographyRe = regexp.MustCompile(`(?s)<section class="biography">(.*?)</section>`)
+	paragraphRe = regexp.MustCompile(`(?s)<p[^>]*>(.*?)</p>`)
+	lineBreakRe = regexp.MustCompile(`(?i)<br\s*/?>`)
+	tagRe       = regexp.MustCompile(`<[^>]+>`)
+	spaceRe     = regexp.MustCompile(`\s+`)
+	dashRe      = regexp.MustCompile(`\s*[-\x{2013}\x{2014}]\s*`)
+)
+
+// searchHit is one artist parsed from the search results page.
+type searchHit struct {
+	ID   string
+	Name string
+}
+
+// artistPage holds the fields parsed from an artist overview page.
+type artistPage struct {
+	Name        string
+	ActiveYears string
+	Genres      []string
+	Styles      []string
+	Moods       []string
+	ImageURL    string
+}
+
+// isAllMusicID reports whether s is exactly an AllMusic artist ID.
+func isAllMusicID(s string) bool {
+	return len(s) == 12 && allMusicIDRe.FindString(s) == s
+}
+
+// parseSearchResults extracts artist hits from a search results page,
+// deduplicated by ID in page order.
+func parseSearchResults(body []byte) []searchHit {
+	var hits []searchHit
+	seen := make(map[string]bool)
+	for _, m := range searchHitRe.FindAllSubmatch(body, -1) {
+		id := lastAllMusicID(string(m[1]))
+		name := cleanText(string(m[2]))
+		if id == "" || name == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		hits = append(hits, searchHit{ID: id, Name: name})
+	}
+	return hits
+}
+
+// parseArtistPage extracts the overview fields from an artist page.
+func parseArtistPage(body []byte) artistPage {
+	page := artistPage{
+		Name:   cleanText(firstSubmatch(artistNameRe, body)),
+		Genres: anchorTexts(firstSubmatch(genreBlockRe, body)),
+		Styles: anchorTexts(firstSubmatch(stylesBlockRe, body)),
+		Moods:  anchorTexts(firstSubmatch(moodsBlockRe, body)),
+	}
+	page.ActiveYears = normalizeActiveYears(cleanText(firstSubmatch(activeDatesRe, body)))
+	if src := html.UnescapeString(firstSubmatch(artistImageRe, body)); isUsableImageURL(src) {
+		page.ImageURL = src
+	}
+	return page
+}
+
+// parseBiography extracts the biography text from the biography tab,
+// joining paragraphs with a blank line the way other providers' biographies
+// are stored.
+func parseBiography(body []byte) string {
+	section := firstSubmatch(biographyRe, body)
+	if section == "" {
+		return ""
+	}
+	var paras []string
+	for _, m := range paragraphRe.FindAllStringSubmatch(section, -1) {
+		if p := cleanText(m[1]); p != "" {
+			paras = append(paras, p)
+		}
+	}
+	return strings.Join(paras, "\n\n")
+}
+
+// normalizeActiveYears collapses AllMusic's "1980s - 2020s" decade range into
+// the compact "1980s-2020s" form, leaving single decades untouched.
+func normalizeActiveYears(s string) string {
+	if s == "" {
+		return ""
+	}
+	return dashRe.ReplaceAllString(s, "-")
+}
+
+// isUsableImageURL rejects empty, relative and placeholder image sources.
+// AllMusic renders a generic silhouette for artists without a photo.
+func isUsableImageURL(src string) bool {
+	if !strings.HasPrefix(src, "https://") && !strings.HasPrefix(src, "http://") {
+		return false
+	}
+	return !strings.Contains(src, "/images/no_image/")
+}
+
+// lastAllMusicID returns the last AllMusic ID embedded in s (an href may carry
+// a slug before the ID), or "" when none is present.
+func lastAllMusicID(s string) string {
+	all := allMusicIDRe.FindAllString(s, -1)
+	if len(all) == 0 {
+		return ""
+	}
+	return all[len(all)-1]
+}
+
+// anchorTexts returns the cleaned, deduplicated link texts inside block.
+func anchorTexts(block string) []string {
+	if block == "" {
+		return nil
+	}
+	var out []string
+	seen := make(map[string]bool)
+	for _, m := range anchorTextRe.FindAllStringSubmatch(block, -1) {
+		t := cleanText(m[1])
+		key := strings.ToLower(t)
+		if t == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// firstSubmatch returns the first capture group of re in body, or "".
+func firstSubmatch(re *regexp.Regexp, body []byte) string {
+	m := re.FindSubmatch(body)
+	if len(m) < 2 {
+		return ""
+	}
+	return string(m[1])
+}
+
+// cleanText strips tags, decodes entities and collapses whitespace. Line
+// breaks become spaces; every other tag is removed outright so inline links
+// ("<a>Thom Yorke</a>,") do not leave a stray space before punctuation.
+func cleanText(s string) string {
+	s = lineBreakRe.ReplaceAllString(s, " ")
+	s = tagRe.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	return strings.TrimSpace(spaceRe.ReplaceAllString(s, " "))
+}
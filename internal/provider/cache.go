@@ -0,0 +1,525 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheEndpoint names one cacheable provider call. TTLs are configured per
+// provider and per endpoint because the three calls age very differently: an
+// artist's biography changes over months, an image list over days, and a name
+// search is the first thing to go stale when a new artist is added upstream.
+type CacheEndpoint string
+
+// Cacheable provider calls.
+const (
+	CacheEndpointArtist CacheEndpoint = "artist" // Provider.GetArtist
+	CacheEndpointImages CacheEndpoint = "images" // Provider.GetImages
+	CacheEndpointSearch CacheEndpoint = "search" // Provider.SearchArtist
+)
+
+// CacheEndpoints returns every cacheable endpoint in display order.
+func CacheEndpoints() []CacheEndpoint {
+	return []CacheEndpoint{CacheEndpointArtist, CacheEndpointImages, CacheEndpointSearch}
+}
+
+// IsValidCacheEndpoint reports whether e is one of CacheEndpoints.
+func IsValidCacheEndpoint(e CacheEndpoint) bool {
+	switch e {
+	case CacheEndpointArtist, CacheEndpointImages, CacheEndpointSearch:
+		return true
+	default:
+		return false
+	}
+}
+
+// defaultCacheTTLs is the TTL for each endpoint when neither a provider
+// override nor an operator setting applies.
+var defaultCacheTTLs = map[CacheEndpoint]time.Duration{
+	CacheEndpointArtist: 7 * 24 * time.Hour,
+	CacheEndpointImages: 3 * 24 * time.Hour,
+	CacheEndpointSearch: 24 * time.Hour,
+}
+
+// providerCacheTTLOverrides raises or lowers the endpoint default for a single
+// provider. MusicBrainz artist records are edited rarely and MusicBrainz is the
+// slowest provider to re-ask (1 req/s), so its artist lookups are kept twice as
+// long; that single entry is most of the full-library refresh saving.
+var providerCacheTTLOverrides = map[ProviderName]map[CacheEndpoint]time.Duration{
+	NameMusicBrainz: {CacheEndpointArtist: 14 * 24 * time.Hour},
+}
+
+// negativeCacheTTL caps how long a definitive "not found" is remembered. A miss
+// is cached at all because re-asking it every refresh is exactly the cost the
+// cache removes; it is capped because a miss is also what flips first when the
+// artist is added upstream.
+const negativeCacheTTL = 24 * time.Hour
+
+// cacheTimeLayout is fixed-width UTC so expires_at compares correctly as text
+// in SQL (the prune query relies on that).
+const cacheTimeLayout = "2006-01-02T15:04:05Z"
+
+// ErrCacheOffline is returned for a cache miss while the cache is in offline
+// mode: the provider was deliberately not contacted. Callers treat it like any
+// other transient failure -- existing data is preserved, nothing is cleared --
+// and it is not a rate-limit signal, so AIMD ignores it.
+var ErrCacheOffline = errors.New("provider cache is offline and holds no entry for this lookup")
+
+// DefaultCacheTTL returns the built-in TTL for a provider endpoint, before any
+// operator setting is applied.
+func DefaultCacheTTL(name ProviderName, endpoint CacheEndpoint) time.Duration {
+	if byEndpoint, ok := providerCacheTTLOverrides[name]; ok {
+		if ttl, ok := byEndpoint[endpoint]; ok {
+			return ttl
+		}
+	}
+	return defaultCacheTTLs[endpoint]
+}
+
+// CacheCounters is a snapshot of hit/miss counts for one provider endpoint.
+type CacheCounters struct {
+	Hits          int64 `json:"hits"`
+	Misses        int64 `json:"misses"`
+	OfflineMisses int64 `json:"offline_misses"`
+	Stores        int64 `json:"stores"`
+}
+
+// HitRatio returns Hits / (Hits + Misses + OfflineMisses), or 0 before the
+// first lookup.
+func (c CacheCounters) HitRatio() float64 {
+	total := c.Hits + c.Misses + c.OfflineMisses
+	if total == 0 {
+		return 0
+	}
+	return float64(c.Hits) / float64(total)
+}
+
+// ProviderCacheStats is the per-provider, per-endpoint view returned by Stats.
+type ProviderCacheStats struct {
+	Provider   ProviderName  `json:"provider"`
+	Endpoint   CacheEndpoint `json:"endpoint"`
+	TTLSeconds int64         `json:"ttl_seconds"`
+	CacheCounters
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+// CacheStats is the response cache's metrics snapshot. Counters are in-memory
+// and reset on restart; Entries is read from the table.
+type CacheStats struct {
+	Offline   bool                 `json:"offline"`
+	Entries   int                  `json:"entries"`
+	Endpoints []ProviderCacheStats `json:"endpoints"`
+}
+
+type cacheStatKey struct {
+	provider ProviderName
+	endpoint CacheEndpoint
+}
+
+type cacheCounters struct {
+	hits, misses, offlineMisses, stores atomic.Int64
+}
+
+// ResponseCache is a SQLite-backed cache of provider responses, shared by the
+// orchestrator and the scraper executor so that a refresh, a bulk fetch and a
+// rule fixer asking for the same artist within the TTL cost one provider call
+// between them rather than one each.
+//
+// Only definitive answers are cached: a successful response, or ErrNotFound
+// (remembered for at most negativeCacheTTL). Transient failures are never
+// stored. In offline mode the cache answers from whatever it holds -- expired
+// entries included, since stale data beats none during an outage -- and a miss
+// returns ErrCacheOffline without contacting the provider.
+//
+// A nil *ResponseCache is valid and disables caching; every method is nil-safe.
+// Cache storage errors are logged and treated as a miss: the cache must never
+// be the reason a fetch fails.
+type ResponseCache struct {
+	db     *sql.DB
+	logger *slog.Logger
+	clock  Clock
+
+	offline atomic.Bool
+
+	ttlMu sync.RWMutex
+	ttls  map[cacheStatKey]time.Duration // operator overrides only
+
+	statsMu sync.Mutex
+	stats   map[cacheStatKey]*cacheCounters
+}
+
+// NewResponseCache creates a ResponseCache backed by the
+// provider_response_cache table.
+func NewResponseCache(db *sql.DB, logger *slog.Logger) *ResponseCache {
+	return &ResponseCache{
+		db:     db,
+		logger: logger.With(slog.String("component", "provider-cache")),
+		clock:  SystemClock(),
+		ttls:   make(map[cacheStatKey]time.Duration),
+		stats:  make(map[cacheStatKey]*cacheCounters),
+	}
+}
+
+// LoadSettings applies the persisted offline flag and TTL overrides from the
+// settings table. Called once at startup; the settings handlers keep the live
+// cache in step afterwards.
+func (c *ResponseCache) LoadSettings(ctx context.Context, s *SettingsService) error {
+	if c == nil || s == nil {
+		return nil
+	}
+	offline, err := s.GetCacheOffline(ctx)
+	if err != nil {
+		return err
+	}
+	c.SetOffline(offline)
+	for _, name := range AllProviderNames() {
+		for _, endpoint := range CacheEndpoints() {
+			ttl, err := s.GetCacheTTL(ctx, name, endpoint)
+			if err != nil {
+				return err
+			}
+			if ttl > 0 {
+				c.SetTTL(name, endpoint, ttl)
+			}
+		}
+	}
+	return nil
+}
+
+// SetOffline switches offline mode on or off.
+func (c *ResponseCache) SetOffline(offline bool) {
+	if c == nil {
+		return
+	}
+	c.offline.Store(offline)
+}
+
+// Offline reports whether the cache is answering from cache only.
+func (c *ResponseCache) Offline() bool {
+	return c != nil && c.offline.Load()
+}
+
+// SetTTL overrides the TTL for one provider endpoint. A ttl <= 0 removes the
+// override and restores DefaultCacheTTL.
+func (c *ResponseCache) SetTTL(name ProviderName, endpoint CacheEndpoint, ttl time.Duration) {
+	if c == nil {
+		return
+	}
+	c.ttlMu.Lock()
+	defer c.ttlMu.Unlock()
+	key := cacheStatKey{provider: name, endpoint: endpoint}
+	if ttl <= 0 {
+		delete(c.ttls, key)
+		return
+	}
+	c.ttls[key] = ttl
+}
+
+// TTL returns the TTL in force for one provider endpoint.
+func (c *ResponseCache) TTL(name ProviderName, endpoint CacheEndpoint) time.Duration {
+	if c != nil {
+		c.ttlMu.RLock()
+		ttl, ok := c.ttls[cacheStatKey{provider: name, endpoint: endpoint}]
+		c.ttlMu.RUnlock()
+		if ok {
+			return ttl
+		}
+	}
+	return DefaultCacheTTL(name, endpoint)
+}
+
+// Stats returns the hit/miss counters for every provider endpoint that has
+// been looked up since startup, sorted by provider then endpoint.
+func (c *ResponseCache) Stats(ctx context.Context) (CacheStats, error) {
+	if c == nil {
+		return CacheStats{}, nil
+	}
+	out := CacheStats{Offline: c.Offline()}
+	if err := c.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM provider_response_cache`).Scan(&out.Entries); err != nil {
+		return CacheStats{}, fmt.Errorf("counting provider cache entries: %w", err)
+	}
+
+	c.statsMu.Lock()
+	for key, counters := range c.stats {
+		snap := CacheCounters{
+			Hits:          counters.hits.Load(),
+			Misses:        counters.misses.Load(),
+			OfflineMisses: counters.offlineMisses.Load(),
+			Stores:        counters.stores.Load(),
+		}
+		out.Endpoints = append(out.Endpoints, ProviderCacheStats{
+			Provider:      key.provider,
+			Endpoint:      key.endpoint,
+			TTLSeconds:    int64(c.TTL(key.provider, key.endpoint) / time.Second),
+			CacheCounters: snap,
+			HitRatio:      snap.HitRatio(),
+		})
+	}
+	c.statsMu.Unlock()
+
+	sort.Slice(out.Endpoints, func(i, j int) bool {
+		if out.Endpoints[i].Provider != out.Endpoints[j].Provider {
+			return out.Endpoints[i].Provider < out.Endpoints[j].Provider
+		}
+		return out.Endpoints[i].Endpoint < out.Endpoints[j].Endpoint
+	})
+	return out, nil
+}
+
+// InvalidateArtist deletes every cached response that was looked up by one of
+// the artist's identifiers -- its MBID, its name (search rows and name-based
+// GetArtist rows) and each provider-specific ID -- across all providers and
+// endpoints. It returns the number of rows removed.
+//
+// Matching is by lookup key, not by provider: an MBID row cached for AudioDB
+// and the same MBID cached for MusicBrainz both belong to this artist.
+func (c *ResponseCache) InvalidateArtist(ctx context.Context, mbid, name string, providerIDs map[ProviderName]string) (int64, error) {
+	if c == nil {
+		return 0, nil
+	}
+	keys := make(map[string]struct{})
+	add := func(k string) {
+		if k = strings.TrimSpace(k); k != "" {
+			keys[k] = struct{}{}
+		}
+	}
+	add(mbid)
+	add(name)
+	add(searchCacheKey(name))
+	for _, id := range providerIDs {
+		add(id)
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	args := make([]any, 0, len(keys))
+	placeholders := make([]string, 0, len(keys))
+	for k := range keys {
+		args = append(args, k)
+		placeholders = append(placeholders, "?")
+	}
+	res, err := c.db.ExecContext(ctx,
+		`DELETE FROM provider_response_cache WHERE lookup_key IN (`+strings.Join(placeholders, ",")+`)`, //nolint:gosec // G202: placeholders only, values are bound
+		args...)
+	if err != nil {
+		return 0, fmt.Errorf("invalidating provider cache: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// Clear deletes every cached response.
+func (c *ResponseCache) Clear(ctx context.Context) (int64, error) {
+	if c == nil {
+		return 0, nil
+	}
+	res, err := c.db.ExecContext(ctx, `DELETE FROM provider_response_cache`)
+	if err != nil {
+		return 0, fmt.Errorf("clearing provider cache: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// Prune deletes expired entries. Offline mode still reads expired rows, so
+// Prune is skipped while offline: pruning during an outage would throw away
+// the only copy of the data the operator switched offline to keep using.
+func (c *ResponseCache) Prune(ctx context.Context) (int64, error) {
+	if c == nil || c.Offline() {
+		return 0, nil
+	}
+	res, err := c.db.ExecContext(ctx,
+		`DELETE FROM provider_response_cache WHERE expires_at < ?`,
+		c.clock.Now().UTC().Format(cacheTimeLayout))
+	if err != nil {
+		return 0, fmt.Errorf("pruning provider cache: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// StartPruner prunes expired entries straight away and then on a fixed
+// interval until the context is canceled. Entries are otherwise only
+// replaced when the same lookup is made again, so without it the table
+// keeps every artist, image list and search ever cached.
+func (c *ResponseCache) StartPruner(ctx context.Context, interval time.Duration) {
+	if c == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := c.Prune(ctx); err != nil && ctx.Err() == nil {
+			c.logger.Warn("failed to prune provider cache", "error", err)
+		} else if n > 0 {
+			c.logger.Info("pruned expired provider cache entries", slog.Int64("removed", n))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// counters returns the counter set for one provider endpoint, creating it on
+// first use.
+func (c *ResponseCache) counters(name ProviderName, endpoint CacheEndpoint) *cacheCounters {
+	key := cacheStatKey{provider: name, endpoint: endpoint}
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+	cc, ok := c.stats[key]
+	if !ok {
+		cc = &cacheCounters{}
+		c.stats[key] = cc
+	}
+	return cc
+}
+
+// lookup returns the cached payload for a key. ok is false on a miss, on an
+// expired entry (unless offline), and on any storage error.
+func (c *ResponseCache) lookup(ctx context.Context, name ProviderName, endpoint CacheEndpoint, key string) (payload []byte, notFound bool, ok bool) {
+	var (
+		nf        int
+		expiresAt string
+	)
+	err := c.db.QueryRowContext(ctx,
+		`SELECT not_found, payload, expires_at FROM provider_response_cache
+		 WHERE provider = ? AND endpoint = ? AND lookup_key = ?`,
+		string(name), string(endpoint), key).Scan(&nf, &payload, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, false
+	}
+	if err != nil {
+		c.logger.Warn("provider cache lookup failed",
+			slog.String("provider", string(name)),
+			slog.String("endpoint", string(endpoint)),
+			slog.String("error", err.Error()))
+		return nil, false, false
+	}
+	if !c.Offline() {
+		exp, err := time.Parse(cacheTimeLayout, expiresAt)
+		if err != nil || !c.clock.Now().Before(exp) {
+			return nil, false, false
+		}
+	}
+	return payload, nf != 0, true
+}
+
+// store writes one definitive response. payload is ignored when notFound.
+func (c *ResponseCache) store(ctx context.Context, name ProviderName, endpoint CacheEndpoint, key string, payload []byte, notFound bool) {
+	ttl := c.TTL(name, endpoint)
+	nf := 0
+	if notFound {
+		nf = 1
+		payload = []byte{}
+		ttl = min(ttl, negativeCacheTTL)
+	}
+	now := c.clock.Now().UTC()
+	_, err := c.db.ExecContext(ctx,
+		`INSERT INTO provider_response_cache (provider, endpoint, lookup_key, not_found, payload, fetched_at, expires_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(provider, endpoint, lookup_key) DO UPDATE SET
+		   not_found = excluded.not_found,
+		   payload = excluded.payload,
+		   fetched_at = excluded.fetched_at,
+		   expires_at = excluded.expires_at`,
+		string(name), string(endpoint), key, nf, payload,
+		now.Format(cacheTimeLayout), now.Add(ttl).Format(cacheTimeLayout))
+	if err != nil {
+		c.logger.Warn("provider cache store failed",
+			slog.String("provider", string(name)),
+			slog.String("endpoint", string(endpoint)),
+			slog.String("error", err.Error()))
+		return
+	}
+	c.counters(name, endpoint).stores.Add(1)
+}
+
+// searchCacheKey normalizes a search query so case variants share a row.
+func searchCacheKey(q string) string {
+	return strings.ToLower(strings.TrimSpace(q))
+}
+
+// cachedCall runs fetch through the cache. hit reports whether the answer came
+// from the cache (callers use it to keep cache hits out of AIMD accounting). A
+// cached not-found is returned as *ErrNotFound so callers cannot tell it from
+// a live one, which is the point.
+func cachedCall[T any](ctx context.Context, c *ResponseCache, name ProviderName, endpoint CacheEndpoint, key string, fetch func() (T, error)) (result T, hit bool, err error) {
+	if c == nil || key == "" {
+		result, err = fetch()
+		return result, false, err
+	}
+
+	counters := c.counters(name, endpoint)
+	if payload, notFound, ok := c.lookup(ctx, name, endpoint, key); ok {
+		if notFound {
+			counters.hits.Add(1)
+			return result, true, &ErrNotFound{Provider: name, ID: key}
+		}
+		if jerr := json.Unmarshal(payload, &result); jerr == nil {
+			counters.hits.Add(1)
+			return result, true, nil
+		}
+		// An undecodable row (written by an older struct shape) is a miss; the
+		// fresh answer below overwrites it.
+		var zero T
+		result = zero
+	}
+
+	if c.Offline() {
+		counters.offlineMisses.Add(1)
+		return result, false, fmt.Errorf("%s %s %q: %w", name, endpoint, key, ErrCacheOffline)
+	}
+
+	counters.misses.Add(1)
+	result, err = fetch()
+	if err != nil {
+		var nf *ErrNotFound
+		if errors.As(err, &nf) {
+			c.store(ctx, name, endpoint, key, nil, true)
+		}
+		return result, false, err
+	}
+	payload, jerr := json.Marshal(result)
+	if jerr != nil {
+		c.logger.Warn("provider cache encode failed",
+			slog.String("provider", string(name)),
+			slog.String("endpoint", string(endpoint)),
+			slog.String("error", jerr.Error()))
+		return result, false, nil
+	}
+	c.store(ctx, name, endpoint, key, payload, false)
+	return result, false, nil
+}
+
+// cachedGetArtist is p.GetArtist through the cache.
+func cachedGetArtist(ctx context.Context, c *ResponseCache, p Provider, id string) (*ArtistMetadata, bool, error) {
+	return cachedCall(ctx, c, p.Name(), CacheEndpointArtist, id, func() (*ArtistMetadata, error) {
+		return p.GetArtist(ctx, id)
+	})
+}
+
+// cachedGetImages is p.GetImages through the cache.
+func cachedGetImages(ctx context.Context, c *ResponseCache, p Provider, id string) ([]ImageResult, bool, error) {
+	return cachedCall(ctx, c, p.Name(), CacheEndpointImages, id, func() ([]ImageResult, error) {
+		return p.GetImages(ctx, id)
+	})
+}
+
+// cachedSearchArtist is p.SearchArtist through the cache.
+func cachedSearchArtist(ctx context.Context, c *ResponseCache, p Provider, name string) ([]ArtistSearchResult, bool, error) {
+	return cachedCall(ctx, c, p.Name(), CacheEndpointSearch, searchCacheKey(name), func() ([]ArtistSearchResult, error) {
+		return p.SearchArtist(ctx, name)
+	})
+}
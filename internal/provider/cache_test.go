@@ -0,0 +1,333 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// newTestResponseCache returns a ResponseCache over a fresh provider_response_cache
+// table, driven by a fake clock so expiry can be stepped deterministically.
+func newTestResponseCache(t *testing.T) (*ResponseCache, *fakeClock) {
+	t.Helper()
+	db := setupTestDB(t)
+	if _, err := db.ExecContext(context.Background(), `
+		CREATE TABLE provider_response_cache (
+			provider    TEXT    NOT NULL,
+			endpoint    TEXT    NOT NULL,
+			lookup_key  TEXT    NOT NULL,
+			not_found   INTEGER NOT NULL DEFAULT 0,
+			payload     BLOB    NOT NULL DEFAULT '',
+			fetched_at  TEXT    NOT NULL,
+			expires_at  TEXT    NOT NULL,
+			PRIMARY KEY (provider, endpoint, lookup_key)
+		)
+	`); err != nil {
+		t.Fatalf("creating provider_response_cache table: %v", err)
+	}
+	clk := newFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := NewResponseCache(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	c.clock = clk
+	return c, clk
+}
+
+// countingProvider is a mockProvider whose GetArtist returns a fixed result and
+// counts how often it was actually called.
+func countingProvider(calls *int, meta *ArtistMetadata, err error) *mockProvider {
+	return &mockProvider{
+		name: NameMusicBrainz,
+		getArtFn: func(_ context.Context, _ string) (*ArtistMetadata, error) {
+			*calls++
+			return meta, err
+		},
+	}
+}
+
+func TestResponseCache_HitAfterMiss(t *testing.T) {
+	c, _ := newTestResponseCache(t)
+	ctx := context.Background()
+	calls := 0
+	p := countingProvider(&calls, &ArtistMetadata{Name: "Radiohead", Biography: "bio"}, nil)
+
+	meta, hit, err := cachedGetArtist(ctx, c, p, "mbid-1")
+	if err != nil || hit {
+		t.Fatalf("first call: hit=%v err=%v, want miss with no error", hit, err)
+	}
+	if meta.Name != "Radiohead" {
+		t.Fatalf("first call name = %q", meta.Name)
+	}
+
+	meta, hit, err = cachedGetArtist(ctx, c, p, "mbid-1")
+	if err != nil || !hit {
+		t.Fatalf("second call: hit=%v err=%v, want hit", hit, err)
+	}
+	if meta.Biography != "bio" {
+		t.Errorf("cached biography = %q, want %q", meta.Biography, "bio")
+	}
+	if calls != 1 {
+		t.Errorf("provider called %d times, want 1", calls)
+	}
+
+	stats, err := c.Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.Entries != 1 || len(stats.Endpoints) != 1 {
+		t.Fatalf("stats = %+v, want 1 entry on 1 endpoint", stats)
+	}
+	got := stats.Endpoints[0]
+	if got.Hits != 1 || got.Misses != 1 || got.Stores != 1 || got.HitRatio != 0.5 {
+		t.Errorf("counters = %+v, want 1 hit, 1 miss, 1 store, ratio 0.5", got)
+	}
+}
+
+func TestResponseCache_NotFoundIsCached(t *testing.T) {
+	c, clk := newTestResponseCache(t)
+	ctx := context.Background()
+	calls := 0
+	p := countingProvider(&calls, nil, &ErrNotFound{Provider: NameMusicBrainz, ID: "missing"})
+
+	for i := 0; i < 2; i++ {
+		_, _, err := cachedGetArtist(ctx, c, p, "missing")
+		var nf *ErrNotFound
+		if !errors.As(err, &nf) {
+			t.Fatalf("call %d: err = %v, want *ErrNotFound", i, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("provider called %d times, want 1 (negative answer cached)", calls)
+	}
+
+	// Negative entries are capped at negativeCacheTTL even though the
+	// MusicBrainz artist TTL is two weeks.
+	clk.advance(negativeCacheTTL + time.Minute)
+	if _, hit, _ := cachedGetArtist(ctx, c, p, "missing"); hit {
+		t.Error("not-found entry still served after negativeCacheTTL")
+	}
+	if calls != 2 {
+		t.Errorf("provider called %d times after expiry, want 2", calls)
+	}
+}
+
+func TestResponseCache_TransientErrorNotCached(t *testing.T) {
+	c, _ := newTestResponseCache(t)
+	ctx := context.Background()
+	calls := 0
+	p := countingProvider(&calls, nil, &ErrProviderUnavailable{Provider: NameMusicBrainz, Cause: errors.New("timeout")})
+
+	for i := 0; i < 2; i++ {
+		if _, hit, err := cachedGetArtist(ctx, c, p, "mbid-1"); err == nil || hit {
+			t.Fatalf("call %d: hit=%v err=%v, want uncached error", i, hit, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("provider called %d times, want 2 (errors must not be cached)", calls)
+	}
+	stats, _ := c.Stats(ctx)
+	if stats.Entries != 0 {
+		t.Errorf("entries = %d, want 0", stats.Entries)
+	}
+}
+
+func TestResponseCache_Expiry(t *testing.T) {
+	c, clk := newTestResponseCache(t)
+	ctx := context.Background()
+	calls := 0
+	p := countingProvider(&calls, &ArtistMetadata{Name: "A"}, nil)
+
+	c.SetTTL(NameMusicBrainz, CacheEndpointArtist, time.Hour)
+	_, _, _ = cachedGetArtist(ctx, c, p, "mbid-1")
+	clk.advance(59 * time.Minute)
+	if _, hit, _ := cachedGetArtist(ctx, c, p, "mbid-1"); !hit {
+		t.Error("entry not served within TTL")
+	}
+	clk.advance(2 * time.Minute)
+	if _, hit, _ := cachedGetArtist(ctx, c, p, "mbid-1"); hit {
+		t.Error("entry served after TTL")
+	}
+	if calls != 2 {
+		t.Errorf("provider called %d times, want 2", calls)
+	}
+}
+
+func TestResponseCache_Offline(t *testing.T) {
+	c, clk := newTestResponseCache(t)
+	ctx := context.Background()
+	calls := 0
+	p := countingProvider(&calls, &ArtistMetadata{Name: "A"}, nil)
+
+	_, _, _ = cachedGetArtist(ctx, c, p, "mbid-1")
+	clk.advance(30 * 24 * time.Hour) // well past every TTL
+	c.SetOffline(true)
+
+	if _, hit, err := cachedGetArtist(ctx, c, p, "mbid-1"); err != nil || !hit {
+		t.Errorf("offline expired entry: hit=%v err=%v, want stale hit", hit, err)
+	}
+	if _, _, err := cachedGetArtist(ctx, c, p, "mbid-2"); !errors.Is(err, ErrCacheOffline) {
+		t.Errorf("offline miss err = %v, want ErrCacheOffline", err)
+	}
+	if calls != 1 {
+		t.Errorf("provider called %d times, want 1 (offline never fetches)", calls)
+	}
+
+	// Prune must not discard the stale rows offline mode is living on.
+	if n, err := c.Prune(ctx); err != nil || n != 0 {
+		t.Errorf("Prune while offline = %d, %v; want 0, nil", n, err)
+	}
+	c.SetOffline(false)
+	if n, err := c.Prune(ctx); err != nil || n != 1 {
+		t.Errorf("Prune = %d, %v; want 1, nil", n, err)
+	}
+}
+
+func TestResponseCache_SearchKeyIsCaseInsensitive(t *testing.T) {
+	c, _ := newTestResponseCache(t)
+	ctx := context.Background()
+	calls := 0
+	p := &mockProvider{
+		name: NameDiscogs,
+		searchFn: func(_ context.Context, _ string) ([]ArtistSearchResult, error) {
+			calls++
+			return []ArtistSearchResult{{Name: "Radiohead", ProviderID: "3840"}}, nil
+		},
+	}
+
+	_, _, _ = cachedSearchArtist(ctx, c, p, "Radiohead")
+	res, hit, err := cachedSearchArtist(ctx, c, p, "  radiohead ")
+	if err != nil || !hit || len(res) != 1 {
+		t.Fatalf("second search: hit=%v err=%v len=%d", hit, err, len(res))
+	}
+	if calls != 1 {
+		t.Errorf("provider called %d times, want 1", calls)
+	}
+}
+
+func TestResponseCache_InvalidateArtist(t *testing.T) {
+	c, _ := newTestResponseCache(t)
+	ctx := context.Background()
+	meta := &ArtistMetadata{Name: "A"}
+	calls := 0
+	mb := countingProvider(&calls, meta, nil)
+	discogs := &mockProvider{
+		name:     NameDiscogs,
+		getArtFn: func(_ context.Context, _ string) (*ArtistMetadata, error) { return meta, nil },
+		searchFn: func(_ context.Context, _ string) ([]ArtistSearchResult, error) { return nil, nil },
+	}
+
+	_, _, _ = cachedGetArtist(ctx, c, mb, "mbid-1")
+	_, _, _ = cachedGetArtist(ctx, c, discogs, "3840")
+	_, _, _ = cachedSearchArtist(ctx, c, discogs, "Radiohead")
+	_, _, _ = cachedGetArtist(ctx, c, mb, "mbid-other")
+
+	n, err := c.InvalidateArtist(ctx, "mbid-1", "Radiohead", map[ProviderName]string{NameDiscogs: "3840"})
+	if err != nil {
+		t.Fatalf("InvalidateArtist: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("removed %d rows, want 3", n)
+	}
+	stats, _ := c.Stats(ctx)
+	if stats.Entries != 1 {
+		t.Errorf("entries left = %d, want 1 (unrelated artist untouched)", stats.Entries)
+	}
+}
+
+func TestResponseCache_TTLOverride(t *testing.T) {
+	c, _ := newTestResponseCache(t)
+	if got := c.TTL(NameMusicBrainz, CacheEndpointArtist); got != 14*24*time.Hour {
+		t.Errorf("MusicBrainz artist default TTL = %v, want 14d", got)
+	}
+	c.SetTTL(NameMusicBrainz, CacheEndpointArtist, time.Hour)
+	if got := c.TTL(NameMusicBrainz, CacheEndpointArtist); got != time.Hour {
+		t.Errorf("TTL after override = %v, want 1h", got)
+	}
+	c.SetTTL(NameMusicBrainz, CacheEndpointArtist, 0)
+	if got := c.TTL(NameMusicBrainz, CacheEndpointArtist); got != DefaultCacheTTL(NameMusicBrainz, CacheEndpointArtist) {
+		t.Errorf("TTL after reset = %v, want default", got)
+	}
+}
+
+func TestResponseCache_LoadSettings(t *testing.T) {
+	c, _ := newTestResponseCache(t)
+	ctx := context.Background()
+	s := NewSettingsService(c.db, setupTestEncryptor(t))
+
+	if err := s.SetCacheOffline(ctx, true); err != nil {
+		t.Fatalf("SetCacheOffline: %v", err)
+	}
+	if err := s.SetCacheTTL(ctx, NameLastFM, CacheEndpointSearch, 2*time.Hour); err != nil {
+		t.Fatalf("SetCacheTTL: %v", err)
+	}
+	if err := c.LoadSettings(ctx, s); err != nil {
+		t.Fatalf("LoadSettings: %v", err)
+	}
+	if !c.Offline() {
+		t.Error("offline flag not loaded")
+	}
+	if got := c.TTL(NameLastFM, CacheEndpointSearch); got != 2*time.Hour {
+		t.Errorf("TTL = %v, want 2h", got)
+	}
+}
+
+func TestResponseCache_NilPassesThrough(t *testing.T) {
+	var c *ResponseCache
+	calls := 0
+	p := countingProvider(&calls, &ArtistMetadata{Name: "A"}, nil)
+	for i := 0; i < 2; i++ {
+		if _, hit, err := cachedGetArtist(context.Background(), c, p, "mbid-1"); err != nil || hit {
+			t.Fatalf("nil cache: hit=%v err=%v", hit, err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("provider called %d times, want 2", calls)
+	}
+	if c.Offline() {
+		t.Error("nil cache reports offline")
+	}
+}
+
+// TestResponseCache_StartPrunerRemovesExpired verifies that the pruner drops
+// expired entries on its ticks, not only when it starts.
+func TestResponseCache_StartPrunerRemovesExpired(t *testing.T) {
+	c, clk := newTestResponseCache(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	p := countingProvider(&calls, &ArtistMetadata{Name: "A"}, nil)
+	c.SetTTL(NameMusicBrainz, CacheEndpointArtist, time.Hour)
+
+	done := make(chan struct{})
+	go func() {
+		c.StartPruner(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	// Cached after the pruner started, so only a tick can remove it.
+	_, _, _ = cachedGetArtist(ctx, c, p, "mbid-1")
+	clk.advance(2 * time.Hour)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var n int
+		if err := c.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM provider_response_cache`).Scan(&n); err != nil {
+			t.Fatalf("counting entries: %v", err)
+		}
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expired entry still cached: %d rows", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("StartPruner did not return after cancel")
+	}
+}
@@ -143,6 +143,7 @@ type Orchestrator struct {
 	settings *SettingsService
 	executor ScraperExecutor
 	aimd     *AIMDController
+	cache    *ResponseCache
 	logger   *slog.Logger
 
 	// searchTimeout overrides perProviderSearchTimeout when non-zero. It exists
@@ -168,6 +169,17 @@ func (o *Orchestrator) SetExecutor(e ScraperExecutor) {
 	o.executor = e
 }
 
+// SetResponseCache routes provider calls made by the orchestrator through the
+// persistent response cache. A nil cache (the default) disables caching.
+func (o *Orchestrator) SetResponseCache(c *ResponseCache) {
+	o.cache = c
+}
+
+// ResponseCache returns the configured response cache, or nil.
+func (o *Orchestrator) ResponseCache() *ResponseCache {
+	return o.cache
+}
+
 // FetchMetadata queries all providers in priority order and merges the results.
 // It uses the artist's MBID when available, falling back to name-based search.
 // providerIDs supplies provider-specific IDs (AudioDB numeric ID, Discogs ID, etc.)
@@ -439,7 +451,7 @@ func (o *Orchestrator) FetchImages(ctx context.Context, mbid string, providerIDs
 			})
 			continue
 		}
-		images, err := p.GetImages(ctx, id)
+		images, hit, err := cachedGetImages(ctx, o.cache, p, id)
 		if err != nil {
 			var notFound *ErrNotFound
 			if errors.As(err, &notFound) {
@@ -476,7 +488,7 @@ func (o *Orchestrator) FetchImages(ctx context.Context, mbid string, providerIDs
 			Provider: name,
 			Outcome:  ImageOutcomeQueried,
 		})
-		if o.aimd != nil {
+		if o.aimd != nil && !hit {
 			o.aimd.RecordSuccess(name)
 		}
 	}
@@ -494,7 +506,7 @@ func (o *Orchestrator) Search(ctx context.Context, name string) ([]ArtistSearchR
 	}
 
 	for _, p := range providers {
-		results, err := p.SearchArtist(ctx, name)
+		results, hit, err := cachedSearchArtist(ctx, o.cache, p, name)
 		if err != nil {
 			o.logger.Warn("provider search failed",
 				slog.String("provider", string(p.Name())),
@@ -508,7 +520,7 @@ func (o *Orchestrator) Search(ctx context.Context, name string) ([]ArtistSearchR
 			continue
 		}
 		allResults = append(allResults, results...)
-		if o.aimd != nil {
+		if o.aimd != nil && !hit {
 			o.aimd.RecordSuccess(p.Name())
 		}
 	}
@@ -659,7 +671,7 @@ func (o *Orchestrator) getProviderResult(ctx context.Context, name ProviderName,
 		return pr
 	}
 
-	pr := FetchProviderResult(ctx, p, name, mbid, artistName, providerIDs, o.logger, o.aimd)
+	pr := FetchProviderResult(ctx, p, name, mbid, artistName, providerIDs, o.logger, o.aimd, o.cache)
 	mu.Lock()
 	cache[name] = pr
 	mu.Unlock()
@@ -1364,7 +1376,7 @@ func (o *Orchestrator) SearchForLinking(ctx context.Context, name string, provid
 			provCtx, cancel := context.WithTimeout(ctx, o.perProviderTimeout())
 			defer cancel()
 
-			results, err := queried[i].SearchArtist(provCtx, name)
+			results, hit, err := cachedSearchArtist(provCtx, o.cache, queried[i], name)
 			if err != nil {
 				scrubbed := ScrubError(err)
 				o.logger.Warn("provider search failed",
@@ -1401,7 +1413,7 @@ func (o *Orchestrator) SearchForLinking(ctx context.Context, name string, provid
 			}
 			perStatus[i] = ProviderSearchStatus{Provider: names[i]}
 			perResults[i] = results
-			if o.aimd != nil {
+			if o.aimd != nil && !hit {
 				o.aimd.RecordSuccess(names[i])
 			}
 		}(i)
@@ -581,12 +581,12 @@ func TestFetchFieldFromProviders_ErrNotFoundSuppressed(t *testing.T) {
 	})
 
 	// Set priorities to only AudioDB and LastFM. Also disable any providers
-	// that may have been appended from defaults (e.g. MusicBrainz, Discogs)
+	// that may have been appended from defaults (e.g. MusicBrainz, Discogs, AllMusic)
 	// to keep this test focused on ErrNotFound suppression.
 	if err := settings.SetPriority(context.Background(), "styles", []ProviderName{NameAudioDB, NameLastFM}); err != nil {
 		t.Fatalf("SetPriority: %v", err)
 	}
-	if err := settings.SetDisabledProviders(context.Background(), "styles", []ProviderName{NameDiscogs, NameMusicBrainz}); err != nil {
+	if err := settings.SetDisabledProviders(context.Background(), "styles", []ProviderName{NameDiscogs, NameMusicBrainz, NameAllMusic}); err != nil {
 		t.Fatalf("SetDisabledProviders: %v", err)
 	}
 
@@ -123,6 +123,14 @@ func ProviderCapabilities() map[ProviderName]ProviderCapability {
 			RateLimit:       &RateLimitInfo{RequestsPerSecond: 5},
 			SupportedFields: []string{"name", "type", "biography", "born", "died", "years_active", "origin", "genres", "members"},
 		},
+		NameAllMusic: {
+			Tier:      TierFree,
+			RateLimit: &RateLimitInfo{RequestsPerSecond: 1},
+			SupportedFields: []string{
+				"name", "biography", "genres", "styles", "moods", "years_active",
+			},
+			SupportedImages: []ImageType{ImageThumb},
+		},
 		NameSpotify: {
 			Tier:            TierPaid,
 			HelpURL:         "https://developer.spotify.com/dashboard",
@@ -164,6 +172,7 @@ func AllProviderNames() []ProviderName {
 		NameWikidata,
 		NameDeezer,
 		NameGenius,
+		NameAllMusic,
 		NameSpotify,
 	}
 }
@@ -42,7 +42,10 @@ func (pr *ProviderResult) ImagesAttempted() bool { return pr.imagesAttempted }
 // definitive miss while preserving it on a transient failure.
 //
 // p must be non-nil. aimd may be nil; when nil, AIMD signals are skipped.
-// Cache management and registry lookup are the caller's responsibility.
+// cache may be nil; when non-nil, GetArtist and GetImages go through the
+// persistent response cache, and an answer served from it sends no AIMD signal
+// (the provider was not contacted, so it says nothing about its rate limit).
+// The per-call result map and registry lookup are the caller's responsibility.
 func FetchProviderResult(
 	ctx context.Context,
 	p Provider,
@@ -51,6 +54,7 @@ func FetchProviderResult(
 	providerIDs map[ProviderName]string,
 	logger *slog.Logger,
 	aimd *AIMDController,
+	cache *ResponseCache,
 ) *ProviderResult {
 	pr := &ProviderResult{}
 
@@ -67,7 +71,7 @@ func FetchProviderResult(
 	}
 
 	if id != "" {
-		meta, queryID, err := fetchArtist(ctx, p, name, id, mbid, artistName, usedProviderID, logger)
+		meta, queryID, hit, err := fetchArtist(ctx, p, name, id, mbid, artistName, usedProviderID, logger, cache)
 		if err != nil {
 			var notFound *ErrNotFound
 			if errors.As(err, &notFound) {
@@ -86,7 +90,7 @@ func FetchProviderResult(
 			}
 		} else {
 			pr.meta = meta
-			aimdGotResult = true
+			aimdGotResult = aimdGotResult || !hit
 		}
 	}
 
@@ -95,7 +99,7 @@ func FetchProviderResult(
 		imgID = pid
 	}
 	if imgID != "" {
-		images, err := p.GetImages(ctx, imgID)
+		images, hit, err := cachedGetImages(ctx, cache, p, imgID)
 		pr.imagesAttempted = true
 		if err != nil {
 			var notFound *ErrNotFound
@@ -115,7 +119,7 @@ func FetchProviderResult(
 			}
 		} else {
 			pr.images = images
-			aimdGotResult = true
+			aimdGotResult = aimdGotResult || !hit
 		}
 	}
 
@@ -126,7 +130,8 @@ func FetchProviderResult(
 // fetchArtist calls p.GetArtist(id) and, when the provider returns ErrNotFound
 // for an MBID-based lookup and supports name lookups, retries with artistName.
 // It returns the metadata, the query ID that produced the final result (used
-// only for logging), and the final error.
+// only for logging), whether the final answer came from the response cache, and
+// the final error.
 func fetchArtist(
 	ctx context.Context,
 	p Provider,
@@ -134,9 +139,10 @@ func fetchArtist(
 	id, mbid, artistName string,
 	usedProviderID bool,
 	logger *slog.Logger,
-) (meta *ArtistMetadata, queryID string, err error) {
+	cache *ResponseCache,
+) (meta *ArtistMetadata, queryID string, hit bool, err error) {
 	queryID = id
-	meta, err = p.GetArtist(ctx, id)
+	meta, hit, err = cachedGetArtist(ctx, cache, p, id)
 	if err != nil && !usedProviderID && mbid != "" && artistName != "" {
 		var notFound *ErrNotFound
 		if errors.As(err, &notFound) {
@@ -145,11 +151,11 @@ func fetchArtist(
 					slog.String("provider", string(name)),
 					slog.String("name", artistName))
 				queryID = artistName
-				meta, err = p.GetArtist(ctx, artistName)
+				meta, hit, err = cachedGetArtist(ctx, cache, p, artistName)
 			}
 		}
 	}
-	return meta, queryID, err
+	return meta, queryID, hit, err
 }
 
 // emitAIMDSignal fires exactly one AIMD signal per provider call:
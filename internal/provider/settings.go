@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/sydlexius/stillwater/internal/encryption"
 )
@@ -312,7 +313,7 @@ func (s *SettingsService) getVerbosityForStatus(ctx context.Context, name Provid
 // providerRequiresKey returns whether a provider needs an API key.
 func providerRequiresKey(name ProviderName) bool {
 	switch name {
-	case NameMusicBrainz, NameWikidata, NameWikipedia, NameDeezer, NameAudioDB:
+	case NameMusicBrainz, NameWikidata, NameWikipedia, NameDeezer, NameAudioDB, NameAllMusic:
 		return false
 	default:
 		return true
@@ -392,10 +393,10 @@ func (fp *FieldPriority) RemoveProvider(name ProviderName) bool {
 // DefaultPriorities returns the default provider priority order per field.
 func DefaultPriorities() []FieldPriority {
 	return []FieldPriority{
-		{Field: "biography", Providers: []ProviderName{NameWikipedia, NameLastFM, NameAudioDB, NameDiscogs, NameGenius}},
+		{Field: "biography", Providers: []ProviderName{NameWikipedia, NameLastFM, NameAudioDB, NameDiscogs, NameGenius, NameAllMusic}},
 		{Field: "genres", Providers: []ProviderName{NameMusicBrainz, NameLastFM, NameAudioDB, NameDiscogs, NameWikipedia}},
-		{Field: "styles", Providers: []ProviderName{NameDiscogs, NameAudioDB, NameLastFM, NameMusicBrainz}},
-		{Field: "moods", Providers: []ProviderName{NameAudioDB, NameLastFM}},
+		{Field: "styles", Providers: []ProviderName{NameDiscogs, NameAllMusic, NameAudioDB, NameLastFM, NameMusicBrainz}},
+		{Field: "moods", Providers: []ProviderName{NameAllMusic, NameAudioDB, NameLastFM}},
 		{Field: "members", Providers: []ProviderName{NameMusicBrainz, NameWikidata, NameWikipedia}},
 		{Field: "formed", Providers: []ProviderName{NameMusicBrainz, NameWikidata, NameAudioDB}},
 		{Field: "born", Providers: []ProviderName{NameMusicBrainz, NameWikidata, NameWikipedia}},
@@ -1028,3 +1029,77 @@ func (s *SettingsService) SetNameSimilarityThreshold(ctx context.Context, thresh
 	}
 	return nil
 }
+
+// cacheTTLSettingKey returns the settings table key for a provider endpoint's
+// response-cache TTL override.
+func cacheTTLSettingKey(name ProviderName, endpoint CacheEndpoint) string {
+	return fmt.Sprintf("provider.%s.cache_ttl.%s", name, endpoint)
+}
+
+const cacheOfflineKey = "provider.cache.offline"
+
+// GetCacheTTL returns the operator's response-cache TTL override for a provider
+// endpoint. Returns 0 if no override is stored (DefaultCacheTTL applies).
+func (s *SettingsService) GetCacheTTL(ctx context.Context, name ProviderName, endpoint CacheEndpoint) (time.Duration, error) {
+	var value string
+	err := s.db.QueryRowContext(ctx, "SELECT value FROM settings WHERE key = ?", cacheTTLSettingKey(name, endpoint)).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading cache TTL for %s %s: %w", name, endpoint, err)
+	}
+	secs, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing cache TTL for %s %s: %w", name, endpoint, err)
+	}
+	return time.Duration(secs) * time.Second, nil
+}
+
+// SetCacheTTL stores a response-cache TTL override for a provider endpoint. A
+// ttl <= 0 deletes the override so the built-in default applies again.
+func (s *SettingsService) SetCacheTTL(ctx context.Context, name ProviderName, endpoint CacheEndpoint, ttl time.Duration) error {
+	key := cacheTTLSettingKey(name, endpoint)
+	if ttl <= 0 {
+		if _, err := s.db.ExecContext(ctx, "DELETE FROM settings WHERE key = ?", key); err != nil {
+			return fmt.Errorf("deleting cache TTL for %s %s: %w", name, endpoint, err)
+		}
+		return nil
+	}
+	value := strconv.FormatInt(int64(ttl/time.Second), 10)
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO settings (key, value) VALUES (?, ?) ON CONFLICT(key) DO UPDATE SET value = ?, updated_at = datetime('now')",
+		key, value, value,
+	)
+	if err != nil {
+		return fmt.Errorf("storing cache TTL for %s %s: %w", name, endpoint, err)
+	}
+	return nil
+}
+
+// GetCacheOffline reports whether the provider response cache is in offline
+// mode (answer from cache only, never contact providers).
+func (s *SettingsService) GetCacheOffline(ctx context.Context) (bool, error) {
+	var value string
+	err := s.db.QueryRowContext(ctx, "SELECT value FROM settings WHERE key = ?", cacheOfflineKey).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("reading cache offline flag: %w", err)
+	}
+	return value == "true", nil
+}
+
+// SetCacheOffline stores the provider response cache offline flag.
+func (s *SettingsService) SetCacheOffline(ctx context.Context, offline bool) error {
+	value := strconv.FormatBool(offline)
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO settings (key, value) VALUES (?, ?) ON CONFLICT(key) DO UPDATE SET value = ?, updated_at = datetime('now')",
+		cacheOfflineKey, value, value,
+	)
+	if err != nil {
+		return fmt.Errorf("storing cache offline flag: %w", err)
+	}
+	return nil
+}
@@ -1423,7 +1423,7 @@ func TestDefaultPriorities_BiographyExcludesWikidata(t *testing.T) {
 	// Pin the exact contents so a future refactor that renames or drops one
 	// of the remaining biography providers fails loudly instead of silently
 	// reordering the default chain.
-	wantBio := []ProviderName{NameWikipedia, NameLastFM, NameAudioDB, NameDiscogs, NameGenius}
+	wantBio := []ProviderName{NameWikipedia, NameLastFM, NameAudioDB, NameDiscogs, NameGenius, NameAllMusic}
 	if !reflect.DeepEqual(bio.Providers, wantBio) {
 		t.Errorf("biography default = %v, want %v", bio.Providers, wantBio)
 	}
@@ -211,6 +211,7 @@ func TestUploaderConstructors(t *testing.T) {
 		{"emby", &connection.Connection{Type: connection.TypeEmby}, true, true},
 		{"jellyfin", &connection.Connection{Type: connection.TypeJellyfin}, true, true},
 		{"lidarr", &connection.Connection{Type: connection.TypeLidarr}, false, false},
+		{"kodi", &connection.Connection{Type: connection.TypeKodi}, false, true},
 		{"unknown", &connection.Connection{Type: "other"}, false, false},
 	}
 	for _, c := range cases {
@@ -0,0 +1,48 @@
+package publish
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/connection"
+	"github.com/sydlexius/stillwater/internal/connection/kodi"
+)
+
+// newImageLinker constructs an ImageLinker for connection types that load
+// artwork by path instead of taking an upload. Returns nil for every other
+// type, which then goes through newImageUploader as before. Injectable for
+// the same reason as newImageUploader.
+var newImageLinker = func(conn *connection.Connection, logger *slog.Logger) connection.ImageLinker {
+	switch conn.Type {
+	case connection.TypeKodi:
+		return kodi.New(conn.URL, conn.APIKey, logger)
+	default:
+		return nil
+	}
+}
+
+// linkImage points one of conn's artwork slots at the local file hostPath,
+// translated into the platform's namespace through the connection's path
+// mappings. Returns the operator-facing warnings for a failure, and raises the
+// same push-failure notification an upload failure does.
+//
+// A linked peer is never handed the image bytes and has nothing to delete or
+// overwrite, so the callers do not add it to the peers the post-push repair
+// names.
+func (p *Publisher) linkImage(ctx context.Context, linker connection.ImageLinker, conn *connection.Connection, pid artist.PlatformID, a *artist.Artist, imageType string, index int, hostPath string) []string {
+	platformPath := conn.MapArtistPath(hostPath)
+	if err := linker.LinkImage(ctx, pid.PlatformArtistID, imageType, index, platformPath); err != nil {
+		p.logger.Error("linking image on platform",
+			slog.String("artist", a.Name),
+			slog.String("connection", conn.Name),
+			slog.String("type", imageType),
+			slog.Int("index", index),
+			slog.String("platform_path", platformPath),
+			slog.String("error", err.Error()))
+		p.notifyPushFailure(pid.ConnectionID, conn.Name, classifyPushErr(err), a.ID, artistDisplayName(a), pushOpImageUpload, err)
+		return []string{truncateWarning(fmt.Sprintf("%s (%s): image link failed", conn.Name, conn.Type))}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package publish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/connection"
+)
+
+// recordingLinker captures LinkImage calls and returns err for each of them.
+type recordingLinker struct {
+	mu    sync.Mutex
+	err   error
+	calls []string // "<id> <type> <index> <path>"
+}
+
+func (l *recordingLinker) LinkImage(_ context.Context, platformArtistID, imageType string, index int, platformPath string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, fmt.Sprintf("%s %s %d %s", platformArtistID, imageType, index, platformPath))
+	return l.err
+}
+
+// swapImageLinker routes every connection to linker and fails the test if an
+// uploader is constructed, restoring both factories on cleanup. Not
+// parallel-safe (the vars are global).
+func swapImageLinker(t *testing.T, linker connection.ImageLinker) {
+	t.Helper()
+	origLinker, origUploader := newImageLinker, newImageUploader
+	t.Cleanup(func() { newImageLinker, newImageUploader = origLinker, origUploader })
+	newImageLinker = func(*connection.Connection, *slog.Logger) connection.ImageLinker { return linker }
+	newImageUploader = func(*connection.Connection, *slog.Logger) connection.ImageUploader {
+		t.Error("uploader constructed for a linking connection")
+		return nil
+	}
+}
+
+func kodiConn(dir string) *connection.Connection {
+	return &connection.Connection{
+		ID: "c-kodi", Name: "Kodi", Type: connection.TypeKodi,
+		URL: "http://example.invalid", Enabled: true, Status: "ok",
+		Kodi:         &connection.KodiConfig{FeatureImageWrite: true},
+		PathMappings: []connection.PathMapping{{HostPrefix: dir, PlatformPrefix: "nfs://nas/music"}},
+	}
+}
+
+// TestSyncImageToPlatforms_KodiLinksMappedPath covers the link branch: a saved
+// thumb is linked by its platform-side path, not uploaded, with no warning.
+func TestSyncImageToPlatforms_KodiLinksMappedPath(t *testing.T) {
+	linker := &recordingLinker{}
+	swapImageLinker(t, linker)
+
+	dir := t.TempDir()
+	seedJPG(t, dir, "folder.jpg")
+	conn := kodiConn(dir)
+	p := New(Deps{
+		Logger: silentLogger(),
+		ArtistService: &fakePlatformLister{ids: []artist.PlatformID{
+			{ArtistID: "a1", ConnectionID: conn.ID, PlatformArtistID: "7"},
+		}},
+		ConnectionService: &fakeConnectionGetter{conns: map[string]*connection.Connection{conn.ID: conn}},
+	})
+	warnings := p.SyncImageToPlatforms(context.Background(), &artist.Artist{ID: "a1", Name: "X", Path: dir}, "thumb")
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings; got %v", warnings)
+	}
+	want := "7 thumb 0 nfs://nas/music/folder.jpg"
+	if len(linker.calls) != 1 || linker.calls[0] != want {
+		t.Errorf("link calls = %v, want [%s]", linker.calls, want)
+	}
+}
+
+// TestSyncImageToPlatforms_KodiLinkFailureWarns covers the failure branch: a
+// rejected link surfaces the operator-facing warning.
+func TestSyncImageToPlatforms_KodiLinkFailureWarns(t *testing.T) {
+	swapImageLinker(t, &recordingLinker{err: errors.New("invalid params")})
+
+	dir := t.TempDir()
+	seedJPG(t, dir, "folder.jpg")
+	conn := kodiConn(filepath.Dir(dir))
+	p := New(Deps{
+		Logger: silentLogger(),
+		ArtistService: &fakePlatformLister{ids: []artist.PlatformID{
+			{ArtistID: "a1", ConnectionID: conn.ID, PlatformArtistID: "7"},
+		}},
+		ConnectionService: &fakeConnectionGetter{conns: map[string]*connection.Connection{conn.ID: conn}},
+	})
+	warnings := p.SyncImageToPlatforms(context.Background(), &artist.Artist{ID: "a1", Name: "X", Path: dir}, "thumb")
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "image link failed") {
+		t.Errorf("warnings = %v, want one image link failure", warnings)
+	}
+}
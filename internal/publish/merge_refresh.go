@@ -12,7 +12,9 @@ import (
 	"github.com/sydlexius/stillwater/internal/connection"
 	"github.com/sydlexius/stillwater/internal/connection/emby"
 	"github.com/sydlexius/stillwater/internal/connection/jellyfin"
+	"github.com/sydlexius/stillwater/internal/connection/kodi"
 	"github.com/sydlexius/stillwater/internal/connection/lidarr"
+	"github.com/sydlexius/stillwater/internal/connection/subsonic"
 )
 
 // mergeRefreshTimeout caps each per-connection refresh call. A library scan
@@ -98,6 +100,26 @@ func (r lidarrRefresher) RefreshAfterMerge(ctx context.Context, survivorPlatform
 	return err
 }
 
+type subsonicRefresher struct{ c *subsonic.Client }
+
+func (r subsonicRefresher) RefreshAfterMerge(ctx context.Context, _ string, _ []string) error {
+	// Subsonic has no per-item refresh; a scan re-reads the survivor's folder
+	// and drops the artists whose folders the merge removed.
+	return r.c.StartScan(ctx)
+}
+
+type kodiRefresher struct{ c *kodi.Client }
+
+func (r kodiRefresher) RefreshAfterMerge(ctx context.Context, _ string, _ []string) error {
+	// Kodi addresses neither refresh by artist ID. A library scan picks up the
+	// albums now in the survivor's folder (it is incremental, so only changed
+	// folders are read) and a clean drops the entries whose files moved away.
+	if err := r.c.ScanLibrary(ctx); err != nil {
+		return err
+	}
+	return r.c.Clean(ctx)
+}
+
 // mergeRefresherFactory builds a mergeRefresher for a connection. Overridable
 // by tests. Returns (nil, false) for types without a refresh primitive.
 var mergeRefresherFactory = func(conn *connection.Connection, logger *slog.Logger) (mergeRefresher, bool) {
@@ -108,6 +130,10 @@ var mergeRefresherFactory = func(conn *connection.Connection, logger *slog.Logge
 		return jellyfinRefresher{jellyfin.New(conn.URL, conn.APIKey, conn.GetPlatformUserID(), logger)}, true
 	case connection.TypeLidarr:
 		return lidarrRefresher{lidarr.New(conn.URL, conn.APIKey, logger)}, true
+	case connection.TypeSubsonic:
+		return subsonicRefresher{subsonic.New(conn.URL, conn.APIKey, logger)}, true
+	case connection.TypeKodi:
+		return kodiRefresher{kodi.New(conn.URL, conn.APIKey, logger)}, true
 	default:
 		return nil, false
 	}
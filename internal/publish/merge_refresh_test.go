@@ -220,12 +220,12 @@ func TestSyncMergeRefresh_UnsupportedConnectionType(t *testing.T) {
 	p := New(Deps{
 		ArtistService: &fakePlatformLister{ids: nil},
 		ConnectionService: &fakeConnectionGetter{conns: map[string]*connection.Connection{
-			"conn-kodi": {ID: "conn-kodi", Type: "kodi", Enabled: true, Name: "Kodi"},
+			"conn-sonarr": {ID: "conn-sonarr", Type: "sonarr", Enabled: true, Name: "Sonarr"},
 		}},
 		Logger: silentLogger(),
 	})
 
-	got, err := p.SyncMergeRefresh(context.Background(), "survivor-1", []string{"conn-kodi"}, nil)
+	got, err := p.SyncMergeRefresh(context.Background(), "survivor-1", []string{"conn-sonarr"}, nil)
 	if err != nil {
 		t.Fatalf("SyncMergeRefresh: %v", err)
 	}
@@ -357,12 +357,14 @@ func TestSyncMergeRefresh_FactoryProductionDispatch(t *testing.T) {
 			"conn-emby":   {ID: "conn-emby", Type: connection.TypeEmby, URL: srv.URL, Enabled: true, Name: "Emby", Emby: &connection.EmbyConfig{PlatformUserID: "u1"}},
 			"conn-jf":     {ID: "conn-jf", Type: connection.TypeJellyfin, URL: srv.URL, Enabled: true, Name: "JF", Jellyfin: &connection.JellyfinConfig{PlatformUserID: "u1"}},
 			"conn-lidarr": {ID: "conn-lidarr", Type: connection.TypeLidarr, URL: srv.URL, Enabled: true, Name: "Lidarr"},
+			"conn-sub":    {ID: "conn-sub", Type: connection.TypeSubsonic, URL: srv.URL, APIKey: "u:p", Enabled: true, Name: "Navidrome"},
+			"conn-kodi":   {ID: "conn-kodi", Type: connection.TypeKodi, URL: srv.URL, APIKey: "u:p", Enabled: true, Name: "Kodi"},
 		}},
 		Logger: silentLogger(),
 	})
 
 	got, err := p.SyncMergeRefresh(context.Background(), "survivor-1",
-		[]string{"conn-emby", "conn-jf", "conn-lidarr"}, nil)
+		[]string{"conn-emby", "conn-jf", "conn-lidarr", "conn-sub", "conn-kodi"}, nil)
 	if err != nil {
 		t.Fatalf("SyncMergeRefresh: %v", err)
 	}
@@ -22,6 +22,7 @@ import (
 	"github.com/sydlexius/stillwater/internal/connection"
 	"github.com/sydlexius/stillwater/internal/connection/emby"
 	"github.com/sydlexius/stillwater/internal/connection/jellyfin"
+	"github.com/sydlexius/stillwater/internal/connection/plex"
 	"github.com/sydlexius/stillwater/internal/filesystem"
 	img "github.com/sydlexius/stillwater/internal/image"
 	"github.com/sydlexius/stillwater/internal/library"
@@ -254,6 +255,10 @@ type Publisher struct {
 	// double delete or a duplicate upload. See lockPhashTarget in
 	// phash_platform.go. Keyed lazily; entries are cheap and never removed.
 	phashTargetLocks sync.Map
+
+	// libraryScans coalesces the library scans requested after artwork saves
+	// for connections that read artwork from disk (see scan_trigger.go).
+	libraryScans libraryScanQueue
 }
 
 // Narrow interfaces keep the publish package decoupled from concrete types.
@@ -854,6 +859,8 @@ func newLockSyncer(conn *connection.Connection, logger *slog.Logger) connection.
 		return emby.New(conn.URL, conn.APIKey, conn.GetPlatformUserID(), logger)
 	case connection.TypeJellyfin:
 		return jellyfin.New(conn.URL, conn.APIKey, conn.GetPlatformUserID(), logger)
+	case connection.TypePlex:
+		return plex.New(conn.URL, conn.APIKey, logger)
 	default:
 		return nil
 	}
@@ -1013,11 +1020,28 @@ func (p *Publisher) syncImageToPlatforms(ctx context.Context, a *artist.Artist,
 			p.notifyPushFailure(pid.ConnectionID, shortConnLabel(pid.ConnectionID), classifyPushErr(connErr), a.ID, artistDisplayName(a), pushOpImageUpload, connErr)
 			continue
 		}
+		// A Subsonic server takes no upload: it reads the image Stillwater just
+		// saved from the artist folder once a scan re-reads it. It never reaches
+		// the uploader, so it neither counts as a peer handed the image nor
+		// warns about an unsupported type.
+		if conn.Type == connection.TypeSubsonic {
+			if conn.Enabled && conn.Status == "ok" {
+				p.requestLibraryScan(conn)
+			}
+			continue
+		}
 		if !conn.Enabled || conn.Status != "ok" || (respectWriteGate && !conn.GetFeatureImageWrite()) {
 			p.logger.Debug("skipping connection for image sync", "connection", conn.Name, "type", imageType, "status", conn.Status)
 			continue
 		}
 
+		// Kodi takes no upload: its art slot is pointed at the file just saved,
+		// which Kodi reads itself. See linkImage.
+		if linker := newImageLinker(conn, p.logger); linker != nil {
+			warnings = append(warnings, p.linkImage(ctx, linker, conn, pid, a, imageType, 0, filePath)...)
+			continue
+		}
+
 		// #2698: the #2533 pre-push write-back disable used to live here and is
 		// removed. Measured on Emby 4.10, the peer deletes the operator's library
 		// file with its image saver either ON or OFF, so the pre-disable prevented
@@ -2075,6 +2099,13 @@ func (p *Publisher) syncAllFanartToPlatforms(ctx context.Context, a *artist.Arti
 			p.notifyPushFailure(pid.ConnectionID, shortConnLabel(pid.ConnectionID), classifyPushErr(connErr), a.ID, artistDisplayName(a), pushOpImageUpload, connErr)
 			continue
 		}
+		// Subsonic reads backdrops from disk too; see syncImageToPlatforms.
+		if conn.Type == connection.TypeSubsonic {
+			if conn.Enabled && conn.Status == "ok" {
+				p.requestLibraryScan(conn)
+			}
+			continue
+		}
 		if !conn.Enabled || conn.Status != "ok" || (respectWriteGate && !conn.GetFeatureImageWrite()) {
 			p.logger.Debug("skipping connection for fanart sync",
 				slog.String("connection", conn.Name),
@@ -2082,6 +2113,17 @@ func (p *Publisher) syncAllFanartToPlatforms(ctx context.Context, a *artist.Arti
 			continue
 		}
 
+		// Kodi links each backdrop by path into its fanart slots; see linkImage.
+		if linker := newImageLinker(conn, p.logger); linker != nil {
+			for _, sf := range snapshot {
+				if sf.data == nil {
+					continue
+				}
+				warnings = append(warnings, p.linkImage(ctx, linker, conn, pid, a, "fanart", sf.index, sf.path)...)
+			}
+			continue
+		}
+
 		// #2698: the #2533 pre-disable was here too and is removed for the same
 		// reason as on the primary path -- it is what turns the peer's overwrite
 		// into a DELETE. Measured on Emby 4.10: a fanart reorder pushes both
@@ -2285,6 +2327,8 @@ var newImageUploader = func(conn *connection.Connection, logger *slog.Logger) co
 		return emby.New(conn.URL, conn.APIKey, conn.GetPlatformUserID(), logger)
 	case connection.TypeJellyfin:
 		return jellyfin.New(conn.URL, conn.APIKey, conn.GetPlatformUserID(), logger)
+	case connection.TypePlex:
+		return plex.New(conn.URL, conn.APIKey, logger)
 	default:
 		return nil
 	}
@@ -8,6 +8,8 @@ import (
 	"github.com/sydlexius/stillwater/internal/connection"
 	"github.com/sydlexius/stillwater/internal/connection/emby"
 	"github.com/sydlexius/stillwater/internal/connection/jellyfin"
+	"github.com/sydlexius/stillwater/internal/connection/kodi"
+	"github.com/sydlexius/stillwater/internal/connection/plex"
 )
 
 // BuildArtistPushData maps an Artist into the platform-agnostic push payload.
@@ -194,6 +196,10 @@ func NewMetadataPusher(conn *connection.Connection, logger *slog.Logger) (connec
 		return emby.New(conn.URL, conn.APIKey, conn.GetPlatformUserID(), logger), true
 	case connection.TypeJellyfin:
 		return jellyfin.New(conn.URL, conn.APIKey, conn.GetPlatformUserID(), logger), true
+	case connection.TypePlex:
+		return plex.New(conn.URL, conn.APIKey, logger), true
+	case connection.TypeKodi:
+		return kodi.New(conn.URL, conn.APIKey, logger), true
 	default:
 		return nil, false
 	}
@@ -10,6 +10,7 @@ import (
 	"github.com/sydlexius/stillwater/internal/connection"
 	"github.com/sydlexius/stillwater/internal/connection/emby"
 	"github.com/sydlexius/stillwater/internal/connection/jellyfin"
+	"github.com/sydlexius/stillwater/internal/connection/kodi"
 )
 
 // artistRefreshTimeout caps each per-connection full-reimport refresh call. The
@@ -37,6 +38,29 @@ func (r jellyfinArtistRefresher) RefreshArtist(ctx context.Context, platformArti
 	return r.c.TriggerArtistRefresh(ctx, platformArtistID)
 }
 
+// artistFolderRefresher is implemented by refreshers whose platform re-reads
+// an artist by folder rather than by ID. RefreshArtistOnPlatforms prefers it
+// when the refresher has it, passing the artist's directory translated
+// through the connection's path mappings.
+type artistFolderRefresher interface {
+	RefreshArtistFolder(ctx context.Context, platformArtistID, platformPath string) error
+}
+
+// kodiArtistRefresher scans the artist's folder: Kodi's JSON-RPC API has no
+// per-artist refresh, but AudioLibrary.Scan takes a directory, which re-reads
+// just that folder (artist.nfo and artwork included).
+type kodiArtistRefresher struct{ c *kodi.Client }
+
+func (r kodiArtistRefresher) RefreshArtistFolder(ctx context.Context, _, platformPath string) error {
+	return r.c.ScanDirectory(ctx, platformPath)
+}
+
+// RefreshArtist is only reached without a folder to scan; ScanDirectory
+// refuses the empty path rather than scanning the whole library.
+func (r kodiArtistRefresher) RefreshArtist(ctx context.Context, platformArtistID string) error {
+	return r.RefreshArtistFolder(ctx, platformArtistID, "")
+}
+
 // artistRefresherFactory builds an artistRefresher for a connection. Overridable
 // by tests (mirrors mergeRefresherFactory). Returns (nil, false) for types
 // without a per-artist NFO re-import primitive: Lidarr does not consume NFO the
@@ -47,13 +71,16 @@ var artistRefresherFactory = func(conn *connection.Connection, logger *slog.Logg
 		return embyArtistRefresher{emby.New(conn.URL, conn.APIKey, conn.GetPlatformUserID(), logger)}, true
 	case connection.TypeJellyfin:
 		return jellyfinArtistRefresher{jellyfin.New(conn.URL, conn.APIKey, conn.GetPlatformUserID(), logger)}, true
+	case connection.TypeKodi:
+		return kodiArtistRefresher{kodi.New(conn.URL, conn.APIKey, logger)}, true
 	default:
 		return nil, false
 	}
 }
 
 // RefreshArtistOnPlatforms tells opted-in Emby/Jellyfin connections to re-import
-// the artist's on-disk NFO after Stillwater has rewritten it (#2336). This is the
+// the artist's on-disk NFO after Stillwater has rewritten it (#2336), and
+// opted-in Kodi connections to rescan the artist's folder. This is the
 // channel through which NFO-only fields -- Disambiguation and YearsActive, which
 // have no Emby/Jellyfin BaseItemDto field and are therefore dropped by the
 // metadata API push -- actually reach the platform: the server re-reads the NFO,
@@ -129,7 +156,13 @@ func (p *Publisher) RefreshArtistOnPlatforms(ctx context.Context, a *artist.Arti
 				// Unsupported type (e.g. Lidarr): nothing to re-import.
 				return
 			}
-			if refreshErr := refresher.RefreshArtist(gCtx, pid.PlatformArtistID); refreshErr != nil {
+			var refreshErr error
+			if fr, isFolder := refresher.(artistFolderRefresher); isFolder && a.Path != "" {
+				refreshErr = fr.RefreshArtistFolder(gCtx, pid.PlatformArtistID, conn.MapArtistPath(a.Path))
+			} else {
+				refreshErr = refresher.RefreshArtist(gCtx, pid.PlatformArtistID)
+			}
+			if refreshErr != nil {
 				p.logger.Error("refresh-trigger: NFO re-import failed",
 					slog.String("artist_id", a.ID),
 					slog.String("artist_name", a.Name),
@@ -97,9 +97,9 @@ func waitForRefreshCount(t *testing.T, rec *artistRefreshRecorder, want int) {
 	t.Fatalf("expected %d refresh calls, got %d", want, rec.count())
 }
 
-// TestArtistRefresherFactory locks in the production factory type-switch: Emby
-// and Jellyfin get a non-nil per-artist refresher; Lidarr and unknown types do
-// not (out of scope for #2336, no NFO re-import primitive).
+// TestArtistRefresherFactory locks in the production factory type-switch: Emby,
+// Jellyfin and Kodi get a non-nil per-artist refresher; Lidarr and unknown
+// types do not (out of scope for #2336, no NFO re-import primitive).
 func TestArtistRefresherFactory(t *testing.T) {
 	logger := silentLogger()
 	cases := []struct {
@@ -110,7 +110,8 @@ func TestArtistRefresherFactory(t *testing.T) {
 		{"emby", &connection.Connection{Type: connection.TypeEmby}, true},
 		{"jellyfin", &connection.Connection{Type: connection.TypeJellyfin}, true},
 		{"lidarr", &connection.Connection{Type: connection.TypeLidarr}, false},
-		{"unknown", &connection.Connection{Type: "kodi"}, false},
+		{"kodi", &connection.Connection{Type: connection.TypeKodi}, true},
+		{"unknown", &connection.Connection{Type: "sonarr"}, false},
 	}
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
@@ -414,3 +415,71 @@ func TestRefreshArtistOnPlatforms_ListerErrorReturns(t *testing.T) {
 		t.Errorf("refresh calls = %d, want 0 when GetPlatformIDs errors", got)
 	}
 }
+
+// folderRefreshRecorder records the folder a Kodi-style refresher was asked to
+// rescan, proving RefreshArtistOnPlatforms prefers artistFolderRefresher and
+// hands it the artist path translated through the connection's mappings.
+type folderRefreshRecorder struct {
+	mu     sync.Mutex
+	byID   []string
+	folder []string
+}
+
+func (r *folderRefreshRecorder) RefreshArtist(_ context.Context, platformArtistID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byID = append(r.byID, platformArtistID)
+	return nil
+}
+
+func (r *folderRefreshRecorder) RefreshArtistFolder(_ context.Context, _, platformPath string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.folder = append(r.folder, platformPath)
+	return nil
+}
+
+func (r *folderRefreshRecorder) folders() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.folder...)
+}
+
+// TestRefreshArtistOnPlatforms_KodiScansMappedFolder covers the folder path: a
+// Kodi connection rescans the artist's directory as Kodi addresses it, and
+// never falls back to the ID-only refresh when the artist has a path.
+func TestRefreshArtistOnPlatforms_KodiScansMappedFolder(t *testing.T) {
+	rec := &folderRefreshRecorder{}
+	swapArtistRefresherFactory(t, func(*connection.Connection, *slog.Logger) (artistRefresher, bool) {
+		return rec, true
+	})
+
+	conn := &connection.Connection{
+		ID: "c-kodi", Type: connection.TypeKodi, Enabled: true, Status: "ok", Name: "Kodi",
+		Kodi:         &connection.KodiConfig{FeatureTriggerRefresh: true},
+		PathMappings: []connection.PathMapping{{HostPrefix: "/host/music", PlatformPrefix: "smb://nas/music"}},
+	}
+	p := New(Deps{
+		ArtistService: &fakePlatformLister{ids: []artist.PlatformID{
+			{ArtistID: "a1", ConnectionID: conn.ID, PlatformArtistID: "7"},
+		}},
+		ConnectionService: &fakeConnectionGetter{conns: map[string]*connection.Connection{conn.ID: conn}},
+		Logger:            silentLogger(),
+	})
+
+	p.RefreshArtistOnPlatforms(context.Background(), &artist.Artist{ID: "a1", Name: "X", Path: "/host/music/X"})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(rec.folders()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	got := rec.folders()
+	if len(got) != 1 || got[0] != "smb://nas/music/X" {
+		t.Fatalf("folder refreshes = %v, want [smb://nas/music/X]", got)
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.byID) != 0 {
+		t.Errorf("ID refreshes = %v, want none when a folder is known", rec.byID)
+	}
+}
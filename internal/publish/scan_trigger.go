@@ -0,0 +1,125 @@
+package publish
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/connection"
+	"github.com/sydlexius/stillwater/internal/connection/subsonic"
+)
+
+// libraryScanDelay is how long a requested library scan waits before it is
+// sent. Artwork is usually saved in bursts (a thumb and several backdrops for
+// one artist, or a bulk fetch across many), and every save in the window
+// folds into the one pending scan instead of starting its own. A var so tests
+// can shorten it.
+var libraryScanDelay = 30 * time.Second
+
+// libraryScanTimeout caps the startScan call itself. The server returns as
+// soon as it queues the scan, so this only guards an unresponsive peer.
+// Matches artistRefreshTimeout.
+var libraryScanTimeout = 30 * time.Second
+
+// libraryScanner is the per-connection capability the scan trigger needs:
+// ask the server to re-read its library. Subsonic has no per-artist refresh,
+// so the whole library is the smallest unit; the server's scan is incremental
+// and only re-reads folders whose contents changed.
+type libraryScanner interface {
+	StartScan(ctx context.Context) error
+}
+
+// libraryScannerFactory builds a libraryScanner for a connection. Overridable
+// by tests (mirrors artistRefresherFactory). Returns (nil, false) for types
+// that pick up artwork through an upload or a per-artist refresh instead.
+var libraryScannerFactory = func(conn *connection.Connection, logger *slog.Logger) (libraryScanner, bool) {
+	switch conn.Type {
+	case connection.TypeSubsonic:
+		return subsonic.New(conn.URL, conn.APIKey, logger), true
+	default:
+		return nil, false
+	}
+}
+
+// libraryScanQueue coalesces scan requests per connection. The zero value is
+// ready to use.
+type libraryScanQueue struct {
+	mu      sync.Mutex
+	pending map[string]bool // connection ID -> a scan is scheduled
+}
+
+// claim marks a scan pending for connID and reports whether the caller should
+// schedule it: false means one is already pending and will cover this write.
+func (q *libraryScanQueue) claim(connID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pending[connID] {
+		return false
+	}
+	if q.pending == nil {
+		q.pending = make(map[string]bool)
+	}
+	q.pending[connID] = true
+	return true
+}
+
+// release clears connID's pending mark. Called as the scan is sent, not after
+// it returns, so a save that lands while the request is in flight schedules a
+// follow-up scan rather than being folded into one that may already have
+// passed its folder.
+func (q *libraryScanQueue) release(connID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.pending, connID)
+}
+
+// requestLibraryScan schedules a library scan on conn after Stillwater saved
+// artwork the server reads from the artist folder. It is the Subsonic
+// counterpart of RefreshArtistOnPlatforms: Navidrome and the other Subsonic
+// servers accept no image upload, and notice a new artist image only when a
+// scan re-reads the folder.
+//
+// Gated on conn.GetFeatureTriggerRefresh(), like the Emby/Jellyfin refresh:
+// a scan costs the server real work, so it only runs when the operator opted
+// in. Callers have already checked the connection is enabled and healthy.
+//
+// Debounced per connection by libraryScanDelay and fire-and-forget: the scan
+// runs on a timer detached from any request, and failures are logged only,
+// matching the package contract that the local write already succeeded.
+func (p *Publisher) requestLibraryScan(conn *connection.Connection) {
+	if !conn.GetFeatureTriggerRefresh() {
+		p.logger.Debug("scan-trigger: skipping connection without trigger-refresh opt-in",
+			slog.String("connection", conn.Name))
+		return
+	}
+	scanner, ok := libraryScannerFactory(conn, p.logger)
+	if !ok {
+		return
+	}
+	if !p.libraryScans.claim(conn.ID) {
+		return
+	}
+	name := conn.Name
+	time.AfterFunc(libraryScanDelay, func() {
+		p.libraryScans.release(conn.ID)
+		defer func() {
+			if v := recover(); v != nil {
+				p.logger.Error("scan-trigger: panic in timer",
+					slog.String("connection", name),
+					slog.Any("panic", v),
+					slog.String("stack", string(debug.Stack())))
+			}
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), libraryScanTimeout)
+		defer cancel()
+		if err := scanner.StartScan(ctx); err != nil {
+			p.logger.Error("scan-trigger: library scan failed",
+				slog.String("connection", name),
+				slog.String("error", err.Error()))
+			return
+		}
+		p.logger.Info("scan-trigger: library scan started", slog.String("connection", name))
+	})
+}
@@ -0,0 +1,138 @@
+package publish
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/connection"
+)
+
+// countingScanner records StartScan calls a swapped libraryScannerFactory
+// routes to it.
+type countingScanner struct{ calls atomic.Int32 }
+
+func (s *countingScanner) StartScan(context.Context) error {
+	s.calls.Add(1)
+	return nil
+}
+
+// swapLibraryScanner points libraryScannerFactory at scanner for Subsonic
+// connections and shortens libraryScanDelay, restoring both on cleanup. Not
+// parallel-safe: the tests that call it must not run t.Parallel.
+func swapLibraryScanner(t *testing.T, scanner *countingScanner, delay time.Duration) {
+	t.Helper()
+	origFactory, origDelay := libraryScannerFactory, libraryScanDelay
+	t.Cleanup(func() { libraryScannerFactory, libraryScanDelay = origFactory, origDelay })
+	libraryScanDelay = delay
+	libraryScannerFactory = func(conn *connection.Connection, _ *slog.Logger) (libraryScanner, bool) {
+		if conn.Type != connection.TypeSubsonic {
+			return nil, false
+		}
+		return scanner, true
+	}
+}
+
+func waitForScans(t *testing.T, s *countingScanner, want int32) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if s.calls.Load() >= want {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("expected %d scans, got %d", want, s.calls.Load())
+}
+
+func subsonicConn(triggerRefresh bool) *connection.Connection {
+	return &connection.Connection{
+		ID: "c-sub", Name: "Navidrome", Type: connection.TypeSubsonic,
+		URL: "http://example.invalid", Enabled: true, Status: "ok",
+		Subsonic: &connection.SubsonicConfig{FeatureTriggerRefresh: triggerRefresh},
+	}
+}
+
+// TestLibraryScannerFactory locks in the production type-switch: only
+// Subsonic picks up artwork through a library scan.
+func TestLibraryScannerFactory(t *testing.T) {
+	cases := []struct {
+		connType string
+		wantOK   bool
+	}{
+		{connection.TypeSubsonic, true},
+		{connection.TypeEmby, false},
+		{connection.TypeJellyfin, false},
+		{connection.TypePlex, false},
+		{connection.TypeLidarr, false},
+	}
+	for _, c := range cases {
+		s, ok := libraryScannerFactory(&connection.Connection{Type: c.connType}, silentLogger())
+		if ok != c.wantOK || (ok && s == nil) {
+			t.Errorf("%s: ok = %v (scanner %v), want %v", c.connType, ok, s, c.wantOK)
+		}
+	}
+}
+
+// TestRequestLibraryScan_CoalescesBurst saves several images inside one delay
+// window and expects a single scan, then a fresh request after it fired to
+// start a second.
+func TestRequestLibraryScan_CoalescesBurst(t *testing.T) {
+	scanner := &countingScanner{}
+	swapLibraryScanner(t, scanner, 20*time.Millisecond)
+	p := New(Deps{Logger: silentLogger()})
+	conn := subsonicConn(true)
+
+	for range 5 {
+		p.requestLibraryScan(conn)
+	}
+	waitForScans(t, scanner, 1)
+	time.Sleep(50 * time.Millisecond)
+	if got := scanner.calls.Load(); got != 1 {
+		t.Fatalf("scans after a burst = %d, want 1", got)
+	}
+
+	p.requestLibraryScan(conn)
+	waitForScans(t, scanner, 2)
+}
+
+// TestRequestLibraryScan_RequiresOptIn: without the trigger-refresh toggle no
+// scan is scheduled.
+func TestRequestLibraryScan_RequiresOptIn(t *testing.T) {
+	scanner := &countingScanner{}
+	swapLibraryScanner(t, scanner, time.Millisecond)
+	p := New(Deps{Logger: silentLogger()})
+
+	p.requestLibraryScan(subsonicConn(false))
+	time.Sleep(30 * time.Millisecond)
+	if got := scanner.calls.Load(); got != 0 {
+		t.Errorf("scans = %d, want 0 without the opt-in", got)
+	}
+}
+
+// TestSyncImageToPlatforms_SubsonicRequestsScan covers the publish hook: a
+// saved thumb schedules a scan on the Subsonic connection instead of an
+// upload, with no unsupported-type warning.
+func TestSyncImageToPlatforms_SubsonicRequestsScan(t *testing.T) {
+	scanner := &countingScanner{}
+	swapLibraryScanner(t, scanner, time.Millisecond)
+
+	dir := t.TempDir()
+	seedJPG(t, dir, "folder.jpg")
+	conn := subsonicConn(true)
+	p := New(Deps{
+		Logger: silentLogger(),
+		ArtistService: &fakePlatformLister{ids: []artist.PlatformID{
+			{ArtistID: "a1", ConnectionID: conn.ID, PlatformArtistID: "ar-1"},
+		}},
+		ConnectionService: &fakeConnectionGetter{conns: map[string]*connection.Connection{conn.ID: conn}},
+	})
+	warnings := p.SyncImageToPlatforms(context.Background(), &artist.Artist{ID: "a1", Name: "X", Path: dir}, "thumb")
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings; got %v", warnings)
+	}
+	waitForScans(t, scanner, 1)
+}
@@ -73,6 +73,12 @@ type BulkExecutor struct {
 	historyServiceMu sync.RWMutex
 	historyService   *artist.HistoryService
 
+	// undoStoreMu guards undoStore, late-wired via SetUndoStore under the same
+	// one-lock-per-late-wired-field shape as historyServiceMu. Nil disables
+	// undo records for bulk jobs.
+	undoStoreMu sync.RWMutex
+	undoStore   *UndoStore
+
 	mu        sync.Mutex
 	cancelFn  context.CancelFunc
 	currentID string
@@ -226,6 +232,22 @@ func (e *BulkExecutor) recordBulkMBIDHistory(ctx context.Context, artistID, newV
 	}
 }
 
+// SetUndoStore installs (or replaces) the store the bulk fetch-metadata job
+// saves one undo record per changed artist into, tagged with the job ID, so a
+// bad run can be unwound as a whole (UndoStore.RevertBulkJob). Passing nil
+// disables the records.
+func (e *BulkExecutor) SetUndoStore(s *UndoStore) {
+	e.undoStoreMu.Lock()
+	e.undoStore = s
+	e.undoStoreMu.Unlock()
+}
+
+func (e *BulkExecutor) getUndoStore() *UndoStore {
+	e.undoStoreMu.RLock()
+	defer e.undoStoreMu.RUnlock()
+	return e.undoStore
+}
+
 // NewBulkExecutor creates a BulkExecutor.
 func NewBulkExecutor(bulkService *BulkService, artistService *artist.Service, orchestrator *provider.Orchestrator, pipeline PipelineRunner, snapshotService *nfo.SnapshotService, platformService *platform.Service, expectedWrites *watcher.ExpectedWrites, publisher *publish.Publisher, logger *slog.Logger) *BulkExecutor {
 	return &BulkExecutor{
@@ -423,7 +445,7 @@ func (e *BulkExecutor) run(ctx context.Context, job *BulkJob) {
 func (e *BulkExecutor) processArtist(ctx context.Context, a *artist.Artist, job *BulkJob, identityIdx *fanartIndex) (string, string) {
 	switch job.Type {
 	case BulkTypeFetchMetadata:
-		return e.fetchMetadata(ctx, a, job.Mode)
+		return e.fetchMetadata(ctx, a, job.Mode, job.ID)
 	case BulkTypeFetchImages:
 		return e.fetchImages(ctx, a, job.Mode, identityIdx)
 	default:
@@ -431,7 +453,10 @@ func (e *BulkExecutor) processArtist(ctx context.Context, a *artist.Artist, job
 	}
 }
 
-func (e *BulkExecutor) fetchMetadata(ctx context.Context, a *artist.Artist, mode string) (string, string) {
+// fetchMetadata fills an artist's empty metadata from the providers. jobID
+// tags the artist's undo record; "" (tests) still records one when undo is
+// wired.
+func (e *BulkExecutor) fetchMetadata(ctx context.Context, a *artist.Artist, mode, jobID string) (string, string) {
 	if a.MusicBrainzID != "" && a.Biography != "" {
 		return BulkItemSkipped, "already has MBID and biography"
 	}
@@ -451,6 +476,10 @@ func (e *BulkExecutor) fetchMetadata(ctx context.Context, a *artist.Artist, mode
 		return BulkItemSkipped, "manual mode: skipped MBID assignment"
 	}
 
+	// Capture before ApplyMetadata mutates a: the undo record's "before" is
+	// the artist as loaded, plus the NFO that PublishMetadata will rewrite.
+	undo := e.captureBulkUndo(a)
+
 	// The operator's per-field locks are enforced here: ApplyMetadata reads
 	// a.LockedFields off the artist itself, so a pinned field is not filled by
 	// this bulk fetch even when it is currently empty (issue #2749).
@@ -473,9 +502,58 @@ func (e *BulkExecutor) fetchMetadata(ctx context.Context, a *artist.Artist, mode
 
 	e.publisher.PublishMetadata(ctx, a)
 
+	e.saveBulkUndo(ctx, undo, a.ID, jobID, "bulk fetch metadata")
+
 	return BulkItemFixed, "metadata updated"
 }
 
+// captureBulkUndo takes the pre-change capture for one artist of a bulk job,
+// or returns nil when undo is not wired or the NFO cannot be read. The
+// capture is best-effort, like the single-fix path in the API: a missing
+// undo record never fails the job item.
+func (e *BulkExecutor) captureBulkUndo(a *artist.Artist) *UndoCapture {
+	if e.getUndoStore() == nil {
+		return nil
+	}
+	var snaps []FileSnapshot
+	if a.Path != "" {
+		snap, err := CaptureFile(filepath.Join(a.Path, "artist.nfo"))
+		if err != nil {
+			e.logger.Warn("undo: could not snapshot artist.nfo for bulk job",
+				"artist", a.Name, "error", err)
+			return nil
+		}
+		snaps = append(snaps, snap)
+	}
+	return CaptureUndo(a, snaps)
+}
+
+// saveBulkUndo finishes capture against the artist as stored now and saves
+// the record tagged with jobID. Best-effort; failures are logged.
+func (e *BulkExecutor) saveBulkUndo(ctx context.Context, capture *UndoCapture, artistID, jobID, summary string) {
+	store := e.getUndoStore()
+	if capture == nil || store == nil {
+		return
+	}
+	after, err := e.artistService.GetByID(ctx, artistID)
+	if err != nil {
+		e.logger.Warn("undo: could not load artist after bulk write", "artist_id", artistID, "error", err)
+		return
+	}
+	rec, err := capture.Finish(after)
+	if err != nil || rec == nil {
+		if err != nil {
+			e.logger.Warn("undo: could not capture post-write state", "artist_id", artistID, "error", err)
+		}
+		return
+	}
+	rec.BulkJobID = jobID
+	rec.Summary = summary
+	if err := store.Save(ctx, rec); err != nil {
+		e.logger.Warn("undo: could not save bulk undo record", "artist_id", artistID, "error", err)
+	}
+}
+
 func (e *BulkExecutor) fetchImages(ctx context.Context, a *artist.Artist, mode string, identityIdx *fanartIndex) (string, string) {
 	if a.MusicBrainzID == "" {
 		if status, message := e.selfHealMBID(ctx, a, mode); status != "" {
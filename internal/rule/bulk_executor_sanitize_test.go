@@ -142,7 +142,7 @@ func TestFetchMetadata_UpdateFailure_SanitizesOperatorMessage(t *testing.T) {
 
 	e, buf := sanitizeCapturingExecutor(t, artistSvc, orch)
 
-	status, message := e.fetchMetadata(context.Background(), a, BulkModeYOLO)
+	status, message := e.fetchMetadata(context.Background(), a, BulkModeYOLO, "")
 
 	assertSanitized(t, status, message, buf, "saving fetched metadata failed", a, BulkTypeFetchMetadata)
 }
@@ -367,7 +367,7 @@ func TestFetchMetadata_FetchFailure_SanitizesOperatorMessage(t *testing.T) {
 
 	e, buf := sanitizeCapturingExecutor(t, artistSvc, orch)
 
-	status, message := e.fetchMetadata(context.Background(), a, BulkModeYOLO)
+	status, message := e.fetchMetadata(context.Background(), a, BulkModeYOLO, "")
 
 	assertSanitized(t, status, message, buf, "metadata fetch from providers failed", a, BulkTypeFetchMetadata)
 }
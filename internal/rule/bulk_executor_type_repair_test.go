@@ -113,7 +113,7 @@ func TestBulkFetchMetadata_TypeRepairAloneReportsSkipped(t *testing.T) {
 
 	e, stub := newTypeRepairExecutor(t, artistSvc, result)
 
-	status, reason := e.fetchMetadata(ctx, a, BulkModeYOLO)
+	status, reason := e.fetchMetadata(ctx, a, BulkModeYOLO, "")
 
 	if stub.calls != 1 {
 		t.Fatalf("provider stub called %d times, want 1; fetchMetadata bailed out before "+
@@ -184,7 +184,7 @@ func TestBulkFetchMetadata_RealChangeStillPersists(t *testing.T) {
 
 	e, _ := newTypeRepairExecutor(t, artistSvc, result)
 
-	status, reason := e.fetchMetadata(ctx, a, BulkModeYOLO)
+	status, reason := e.fetchMetadata(ctx, a, BulkModeYOLO, "")
 
 	if status != BulkItemFixed {
 		t.Fatalf("status = %q (%s), want %q; a genuine metadata arrival must still persist",
@@ -12,9 +12,10 @@ import (
 )
 
 // inScopeProviderIDs is the fixed set of non-MusicBrainz provider IDs the
-// provider_id_missing rule can require. It is deliberately limited to the three
-// providers whose IDs both (a) matter for artwork search and (b) are derivable
-// from a MusicBrainz URL relation, so the fix has a source (issue #2457):
+// provider_id_missing rule can require. It is deliberately limited to the
+// providers whose IDs both (a) matter for artwork or metadata lookup and (b) are
+// derivable from a MusicBrainz URL relation, so the fix has a source (issue
+// #2457):
 //
 //   - AudioDB is excluded: its adapter accepts a bare MBID
 //     (provider.ProviderAcceptsMBID), so a missing AudioDB ID never silently
@@ -25,11 +26,22 @@ var inScopeProviderIDs = []provider.ProviderName{
 	provider.NameDiscogs,
 	provider.NameDeezer,
 	provider.NameSpotify,
+	provider.NameAllMusic,
+}
+
+// optInProviderIDs are in-scope providers the dynamic default never requires;
+// they are checked only when the operator names them in RequiredProviderIDs.
+// AllMusic is keyless, so it is "available" on every install, and requiring it
+// by default would flag most of a library the day the adapter shipped even
+// though its absence never skips an image search the way a missing Discogs or
+// Deezer ID does. Operators who rely on it for styles and moods opt in.
+var optInProviderIDs = map[provider.ProviderName]bool{
+	provider.NameAllMusic: true,
 }
 
 // InScopeProviderIDs returns a copy of the fixed set of non-MusicBrainz
 // provider IDs the provider_id_missing rule can require (Discogs/Deezer/
-// Spotify). It exists so the settings UI can render one config toggle per
+// Spotify/AllMusic). It exists so the settings UI can render one config toggle per
 // in-scope provider without duplicating the authoritative set; the copy keeps
 // callers from mutating the package-level source of truth.
 func InScopeProviderIDs() []provider.ProviderName {
@@ -49,6 +61,8 @@ func providerIDForName(a *artist.Artist, name provider.ProviderName) string {
 		return a.DeezerID
 	case provider.NameSpotify:
 		return a.SpotifyID
+	case provider.NameAllMusic:
+		return a.AllMusicID
 	default:
 		return ""
 	}
@@ -82,7 +96,8 @@ func parseProviderIDSet(csv string) map[provider.ProviderName]bool {
 //	expected = (configured providers) ∩ {Discogs, Deezer, Spotify}
 //
 // narrowed further to the operator's RequiredProviderIDs override when one is
-// set. The result is sorted so the resulting violation message is deterministic.
+// set. Opt-in providers (optInProviderIDs, i.e. AllMusic) join the set only
+// when the override names them. The result is sorted so the resulting violation message is deterministic.
 //
 // The provider-availability dependency is threaded a context and its error is
 // handled by the caller: an unwired dependency yields an empty set (the rule
@@ -107,6 +122,9 @@ func (e *Engine) expectedProviderIDs(ctx context.Context, cfg RuleConfig) ([]pro
 		if len(override) > 0 && !override[name] {
 			continue
 		}
+		if len(override) == 0 && optInProviderIDs[name] {
+			continue
+		}
 		expected = append(expected, name)
 	}
 	sort.Slice(expected, func(i, j int) bool { return expected[i] < expected[j] })
@@ -116,7 +134,8 @@ func (e *Engine) expectedProviderIDs(ctx context.Context, cfg RuleConfig) ([]pro
 // makeProviderIDMissingChecker returns a Checker that flags an artist missing
 // one or more of the required non-MusicBrainz provider IDs. The required set is
 // the configured providers among Discogs/Deezer/Spotify, optionally narrowed by
-// the rule's RequiredProviderIDs override.
+// the rule's RequiredProviderIDs override (which is also the only way to
+// require an AllMusic ID).
 //
 // The rule is detection-only here; ProviderIDBackfillFixer performs the
 // MusicBrainz-derived backfill. It is not filesystem-dependent: provider IDs
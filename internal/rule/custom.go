@@ -0,0 +1,374 @@
+package rule
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/dbutil"
+	"github.com/sydlexius/stillwater/internal/ruleexpr"
+)
+
+// Custom rules are operator-defined detection rules: a ruleexpr predicate over
+// the artist record, a severity and a message, stored as an ordinary rules row
+// whose expression column is non-empty. The engine evaluates them alongside
+// the compiled-in checkers (see Engine.checkerFor), so their violations use
+// the same tables, the same Run Rules flow and the same health score.
+//
+// They are detection-only. An expression says what is wrong, not how to
+// change it, so a custom rule is never fixable and never registered with the
+// fixer pipeline.
+
+// CustomRuleIDPrefix prefixes every custom rule's ID. The built-in IDs are
+// bare snake_case names; the prefix keeps a custom rule from ever colliding
+// with a built-in added in a later release.
+const CustomRuleIDPrefix = "custom_"
+
+// ErrInvalidCustomRule wraps every validation failure from CreateCustom and
+// UpdateCustom, so the API can map it to 400 without string matching.
+var ErrInvalidCustomRule = errors.New("invalid custom rule")
+
+// ErrNotCustom is returned when a custom-rule operation targets a built-in
+// rule. Built-ins cannot be edited beyond their knobs or deleted; their rows
+// are re-seeded on every start.
+var ErrNotCustom = errors.New("rule is built in")
+
+// CustomRuleSchema is the field set a custom rule expression may reference.
+// Field names are the artist's JSON names, which are what operators see in the
+// API and in NFO-adjacent tooling. Integer fields are numbers; Genres, Styles
+// and Moods are lists; the rest are strings or bools.
+//
+// Timestamps, lock provenance and the health score itself are left out on
+// purpose: a rule that reads health_score would feed its own output back into
+// its input.
+var CustomRuleSchema = ruleexpr.Schema{
+	"name":           ruleexpr.TypeString,
+	"sort_name":      ruleexpr.TypeString,
+	"type":           ruleexpr.TypeString,
+	"gender":         ruleexpr.TypeString,
+	"origin":         ruleexpr.TypeString,
+	"disambiguation": ruleexpr.TypeString,
+	"years_active":   ruleexpr.TypeString,
+	"born":           ruleexpr.TypeString,
+	"formed":         ruleexpr.TypeString,
+	"died":           ruleexpr.TypeString,
+	"disbanded":      ruleexpr.TypeString,
+	"biography":      ruleexpr.TypeString,
+	"path":           ruleexpr.TypeString,
+	"genres":         ruleexpr.TypeList,
+	"styles":         ruleexpr.TypeList,
+	"moods":          ruleexpr.TypeList,
+
+	"musicbrainz_id": ruleexpr.TypeString,
+	"audiodb_id":     ruleexpr.TypeString,
+	"discogs_id":     ruleexpr.TypeString,
+	"wikidata_id":    ruleexpr.TypeString,
+	"deezer_id":      ruleexpr.TypeString,
+	"spotify_id":     ruleexpr.TypeString,
+	"allmusic_id":    ruleexpr.TypeString,
+
+	"nfo_exists":     ruleexpr.TypeBool,
+	"thumb_exists":   ruleexpr.TypeBool,
+	"fanart_exists":  ruleexpr.TypeBool,
+	"logo_exists":    ruleexpr.TypeBool,
+	"banner_exists":  ruleexpr.TypeBool,
+	"thumb_low_res":  ruleexpr.TypeBool,
+	"fanart_low_res": ruleexpr.TypeBool,
+	"logo_low_res":   ruleexpr.TypeBool,
+	"banner_low_res": ruleexpr.TypeBool,
+	"fanart_count":   ruleexpr.TypeNumber,
+	"thumb_width":    ruleexpr.TypeNumber,
+	"thumb_height":   ruleexpr.TypeNumber,
+	"fanart_width":   ruleexpr.TypeNumber,
+	"fanart_height":  ruleexpr.TypeNumber,
+	"logo_width":     ruleexpr.TypeNumber,
+	"logo_height":    ruleexpr.TypeNumber,
+	"banner_width":   ruleexpr.TypeNumber,
+	"banner_height":  ruleexpr.TypeNumber,
+
+	"is_classical": ruleexpr.TypeBool,
+	"is_excluded":  ruleexpr.TypeBool,
+	"locked":       ruleexpr.TypeBool,
+}
+
+// customRuleEnv maps an artist onto CustomRuleSchema. Keep the two in step: a
+// schema field missing here silently reads as its zero value.
+func customRuleEnv(a *artist.Artist) ruleexpr.Env {
+	return ruleexpr.Env{
+		"name":           a.Name,
+		"sort_name":      a.SortName,
+		"type":           a.Type,
+		"gender":         a.Gender,
+		"origin":         a.Origin,
+		"disambiguation": a.Disambiguation,
+		"years_active":   a.YearsActive,
+		"born":           a.Born,
+		"formed":         a.Formed,
+		"died":           a.Died,
+		"disbanded":      a.Disbanded,
+		"biography":      a.Biography,
+		"path":           a.Path,
+		"genres":         a.Genres,
+		"styles":         a.Styles,
+		"moods":          a.Moods,
+
+		"musicbrainz_id": a.MusicBrainzID,
+		"audiodb_id":     a.AudioDBID,
+		"discogs_id":     a.DiscogsID,
+		"wikidata_id":    a.WikidataID,
+		"deezer_id":      a.DeezerID,
+		"spotify_id":     a.SpotifyID,
+		"allmusic_id":    a.AllMusicID,
+
+		"nfo_exists":     a.NFOExists,
+		"thumb_exists":   a.ThumbExists,
+		"fanart_exists":  a.FanartExists,
+		"logo_exists":    a.LogoExists,
+		"banner_exists":  a.BannerExists,
+		"thumb_low_res":  a.ThumbLowRes,
+		"fanart_low_res": a.FanartLowRes,
+		"logo_low_res":   a.LogoLowRes,
+		"banner_low_res": a.BannerLowRes,
+		"fanart_count":   float64(a.FanartCount),
+		"thumb_width":    float64(a.ThumbWidth),
+		"thumb_height":   float64(a.ThumbHeight),
+		"fanart_width":   float64(a.FanartWidth),
+		"fanart_height":  float64(a.FanartHeight),
+		"logo_width":     float64(a.LogoWidth),
+		"logo_height":    float64(a.LogoHeight),
+		"banner_width":   float64(a.BannerWidth),
+		"banner_height":  float64(a.BannerHeight),
+
+		"is_classical": a.IsClassical,
+		"is_excluded":  a.IsExcluded,
+		"locked":       a.Locked,
+	}
+}
+
+// CompileExpression compiles a custom rule expression against
+// CustomRuleSchema. The API calls it to validate an expression before saving;
+// the engine calls it (through its program cache) to evaluate one.
+func CompileExpression(src string) (*ruleexpr.Program, error) {
+	return ruleexpr.Compile(src, CustomRuleSchema)
+}
+
+// messagePlaceholder matches a {field} reference in a custom rule message.
+var messagePlaceholder = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// renderCustomMessage expands {field} references in a custom rule's message
+// with the artist's values, so "{name} has {fanart_count} backdrops" reads
+// naturally in the notifications list. Lists are joined with ", "; an unknown
+// placeholder is left as written (validateCustomRule rejects those at save
+// time, so this only matters for a field removed by a later release).
+func renderCustomMessage(msg string, env ruleexpr.Env) string {
+	return messagePlaceholder.ReplaceAllStringFunc(msg, func(m string) string {
+		field := m[1 : len(m)-1]
+		if _, ok := CustomRuleSchema[field]; !ok {
+			return m
+		}
+		switch v := env[field].(type) {
+		case string:
+			return v
+		case []string:
+			return strings.Join(v, ", ")
+		case float64:
+			return fmt.Sprintf("%g", v)
+		case bool:
+			return fmt.Sprintf("%t", v)
+		default:
+			return ""
+		}
+	})
+}
+
+// validateCustomRule normalizes and checks the operator-supplied fields of a
+// custom rule. It fills the defaults (metadata category, warning severity,
+// auto automation mode) so a minimal create request of name + expression +
+// message is enough.
+func validateCustomRule(r *Rule) error {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Message = strings.TrimSpace(r.Message)
+	if r.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCustomRule)
+	}
+	if r.Message == "" {
+		return fmt.Errorf("%w: message is required", ErrInvalidCustomRule)
+	}
+	if _, err := CompileExpression(r.Expression); err != nil {
+		return fmt.Errorf("%w: expression: %w", ErrInvalidCustomRule, err)
+	}
+	for _, m := range messagePlaceholder.FindAllStringSubmatch(r.Message, -1) {
+		if _, ok := CustomRuleSchema[m[1]]; !ok {
+			return fmt.Errorf("%w: message references unknown field {%s}", ErrInvalidCustomRule, m[1])
+		}
+	}
+
+	switch r.Category {
+	case "":
+		r.Category = RuleCategoryMetadata
+	case RuleCategoryNFO, RuleCategoryImage, RuleCategoryMetadata:
+	default:
+		return fmt.Errorf("%w: unknown category %q", ErrInvalidCustomRule, r.Category)
+	}
+	switch r.Config.Severity {
+	case "":
+		r.Config.Severity = "warning"
+	case "error", "warning", "info":
+	default:
+		return fmt.Errorf("%w: unknown severity %q", ErrInvalidCustomRule, r.Config.Severity)
+	}
+	switch r.AutomationMode {
+	case "":
+		r.AutomationMode = AutomationModeAuto
+	case AutomationModeAuto, AutomationModeManual:
+	default:
+		return fmt.Errorf("%w: unknown automation_mode %q", ErrInvalidCustomRule, r.AutomationMode)
+	}
+	return nil
+}
+
+// CreateCustom validates and inserts an operator-defined rule, assigning its
+// ID. A new rule's updated_at is now, so artist.ListDirtyIDs schedules every
+// artist for it on the next incremental Run Rules pass -- the same path a
+// newly seeded built-in takes.
+func (s *Service) CreateCustom(ctx context.Context, r *Rule) error {
+	if err := validateCustomRule(r); err != nil {
+		return err
+	}
+	now := s.clock.Now()
+	r.ID = CustomRuleIDPrefix + uuid.New().String()
+	r.Custom = true
+	r.FilesystemDependent = false
+	r.CreatedAt = now
+	r.UpdatedAt = now
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO rules (id, name, description, category, enabled, automation_mode, config, expression, message, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, r.ID, r.Name, r.Description, r.Category, dbutil.BoolToInt(r.Enabled),
+		r.AutomationMode, MarshalConfig(r.Config), r.Expression, r.Message,
+		now.Format(time.RFC3339), now.Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("creating custom rule: %w", err)
+	}
+	return nil
+}
+
+// UpdateCustom rewrites every operator-editable field of a custom rule. It
+// refuses built-in rules (ErrNotCustom); those go through Update, which
+// touches only the knobs. Disabling follows Update's cleanup path so the two
+// kinds converge on the same end state.
+func (s *Service) UpdateCustom(ctx context.Context, r *Rule) error {
+	if !strings.HasPrefix(r.ID, CustomRuleIDPrefix) {
+		return fmt.Errorf("%w: %s", ErrNotCustom, r.ID)
+	}
+	if err := validateCustomRule(r); err != nil {
+		return err
+	}
+	r.UpdatedAt = s.clock.Now()
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE rules
+		SET name = ?, description = ?, category = ?, enabled = ?, automation_mode = ?,
+		    config = ?, expression = ?, message = ?, updated_at = ?
+		WHERE id = ? AND expression != ''
+	`, r.Name, r.Description, r.Category, dbutil.BoolToInt(r.Enabled), r.AutomationMode,
+		MarshalConfig(r.Config), r.Expression, r.Message, r.UpdatedAt.Format(time.RFC3339), r.ID)
+	if err != nil {
+		return fmt.Errorf("updating custom rule: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("checking updated custom rule rows: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, r.ID)
+	}
+	r.Custom = true
+	if !r.Enabled {
+		return s.cleanupDisabledRuleState(ctx, r.ID)
+	}
+	return nil
+}
+
+// DeleteCustom removes a custom rule. Its violations and rule_results rows go
+// with it (ON DELETE CASCADE): unlike disabling, deleting says the check no
+// longer exists, so there is nothing left for the history to be history of.
+func (s *Service) DeleteCustom(ctx context.Context, id string) error {
+	var expr string
+	err := s.db.QueryRowContext(ctx, `SELECT expression FROM rules WHERE id = ?`, id).Scan(&expr)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return fmt.Errorf("looking up custom rule: %w", err)
+	}
+	if expr == "" {
+		return fmt.Errorf("%w: %s", ErrNotCustom, id)
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM rules WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("deleting custom rule: %w", err)
+	}
+	return nil
+}
+
+// customChecker returns the Checker for a custom rule, or false when its
+// stored expression no longer compiles (a field renamed by an upgrade, say).
+// Programs are cached by source text, so the compile runs once per distinct
+// expression rather than once per artist; the cache is keyed on the text
+// rather than the rule ID so an edited rule can never run its old program.
+func (e *Engine) customChecker(r *Rule) (Checker, bool) {
+	e.customMu.Lock()
+	entry, ok := e.customPrograms[r.Expression]
+	if !ok {
+		prog, err := CompileExpression(r.Expression)
+		entry = customProgram{prog: prog, err: err}
+		if e.customPrograms == nil {
+			e.customPrograms = make(map[string]customProgram)
+		}
+		e.customPrograms[r.Expression] = entry
+		if err != nil {
+			e.logger.Warn("custom rule expression does not compile; rule skipped",
+				"rule_id", r.ID, "rule", r.Name, "error", err)
+		}
+	}
+	e.customMu.Unlock()
+	if entry.err != nil {
+		return nil, false
+	}
+
+	ruleID, name, category, message := r.ID, r.Name, string(r.Category), r.Message
+	return func(_ context.Context, a *artist.Artist, cfg RuleConfig) *Violation {
+		env := customRuleEnv(a)
+		matched, err := entry.prog.Eval(env)
+		if err != nil {
+			// Division by zero is the only evaluation error. The rule is
+			// saying nothing about this artist, which is a pass rather
+			// than a finding nobody can act on.
+			e.logger.Debug("custom rule evaluation failed",
+				"rule_id", ruleID, "artist", a.Name, "error", err)
+			return nil
+		}
+		if !matched {
+			return nil
+		}
+		return &Violation{
+			RuleID:   ruleID,
+			RuleName: name,
+			Category: category,
+			Severity: cfg.Severity,
+			Message:  renderCustomMessage(message, env),
+		}
+	}, true
+}
+
+// customProgram is one entry in the engine's compiled-expression cache. A
+// compile failure is cached too, so a broken rule logs once per distinct
+// expression rather than once per artist.
+type customProgram struct {
+	prog *ruleexpr.Program
+	err  error
+}
@@ -0,0 +1,162 @@
+package rule
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+)
+
+func TestCreateCustom_DefaultsAndRoundTrip(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db)
+	ctx := context.Background()
+
+	r := &Rule{
+		Name:       "Person without birth date",
+		Expression: `type == "Person" && born == ""`,
+		Message:    "{name} has no birth date",
+		Enabled:    true,
+	}
+	if err := svc.CreateCustom(ctx, r); err != nil {
+		t.Fatalf("CreateCustom: %v", err)
+	}
+	if !strings.HasPrefix(r.ID, CustomRuleIDPrefix) {
+		t.Errorf("ID = %q, want %q prefix", r.ID, CustomRuleIDPrefix)
+	}
+
+	got, err := svc.GetByID(ctx, r.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if !got.Custom || got.Expression != r.Expression || got.Message != r.Message {
+		t.Errorf("stored rule = %+v, want custom with expression and message", got)
+	}
+	if got.Category != RuleCategoryMetadata || got.Config.Severity != "warning" || got.AutomationMode != AutomationModeAuto {
+		t.Errorf("defaults = %s/%s/%s, want metadata/warning/auto", got.Category, got.Config.Severity, got.AutomationMode)
+	}
+}
+
+func TestCreateCustom_Validation(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		r    Rule
+	}{
+		{"missing name", Rule{Expression: `born == ""`, Message: "m"}},
+		{"missing message", Rule{Name: "n", Expression: `born == ""`}},
+		{"bad expression", Rule{Name: "n", Expression: `brn == ""`, Message: "m"}},
+		{"non-bool expression", Rule{Name: "n", Expression: `name`, Message: "m"}},
+		{"unknown placeholder", Rule{Name: "n", Expression: `born == ""`, Message: "{nmae}"}},
+		{"bad category", Rule{Name: "n", Expression: `born == ""`, Message: "m", Category: "audio"}},
+		{"bad severity", Rule{Name: "n", Expression: `born == ""`, Message: "m", Config: RuleConfig{Severity: "fatal"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.r
+			if err := svc.CreateCustom(ctx, &r); !errors.Is(err, ErrInvalidCustomRule) {
+				t.Errorf("CreateCustom err = %v, want ErrInvalidCustomRule", err)
+			}
+		})
+	}
+}
+
+func TestUpdateAndDeleteCustom_RefuseBuiltins(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db)
+	ctx := context.Background()
+	if err := svc.SeedDefaults(ctx); err != nil {
+		t.Fatalf("SeedDefaults: %v", err)
+	}
+
+	builtin := &Rule{ID: RuleBioExists, Name: "n", Expression: `born == ""`, Message: "m"}
+	if err := svc.UpdateCustom(ctx, builtin); !errors.Is(err, ErrNotCustom) {
+		t.Errorf("UpdateCustom(builtin) err = %v, want ErrNotCustom", err)
+	}
+	if err := svc.DeleteCustom(ctx, RuleBioExists); !errors.Is(err, ErrNotCustom) {
+		t.Errorf("DeleteCustom(builtin) err = %v, want ErrNotCustom", err)
+	}
+	if err := svc.DeleteCustom(ctx, CustomRuleIDPrefix+"missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeleteCustom(missing) err = %v, want ErrNotFound", err)
+	}
+
+	r := &Rule{Name: "n", Expression: `born == ""`, Message: "m", Enabled: true}
+	if err := svc.CreateCustom(ctx, r); err != nil {
+		t.Fatalf("CreateCustom: %v", err)
+	}
+	r.Expression = `len(genres) > 8`
+	if err := svc.UpdateCustom(ctx, r); err != nil {
+		t.Fatalf("UpdateCustom: %v", err)
+	}
+	if got, _ := svc.GetByID(ctx, r.ID); got == nil || got.Expression != `len(genres) > 8` {
+		t.Errorf("expression after update = %+v", got)
+	}
+	if err := svc.DeleteCustom(ctx, r.ID); err != nil {
+		t.Fatalf("DeleteCustom: %v", err)
+	}
+	if _, err := svc.GetByID(ctx, r.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetByID after delete err = %v, want ErrNotFound", err)
+	}
+}
+
+// TestEvaluate_CustomRule checks that a custom rule is counted in the health
+// denominator like any built-in, and that its violation carries the rendered
+// message and the configured severity.
+func TestEvaluate_CustomRule(t *testing.T) {
+	db := setupTestDB(t)
+	svc := NewService(db)
+	ctx := context.Background()
+	if err := svc.SeedDefaults(ctx); err != nil {
+		t.Fatalf("SeedDefaults: %v", err)
+	}
+	rules, _ := svc.List(ctx)
+	for i := range rules {
+		rules[i].Enabled = false
+		if err := svc.Update(ctx, &rules[i]); err != nil {
+			t.Fatalf("disabling rule %s: %v", rules[i].ID, err)
+		}
+	}
+
+	r := &Rule{
+		Name:       "Too many genres",
+		Expression: `len(genres) > 2`,
+		Message:    "{name} has {genres}",
+		Enabled:    true,
+		Config:     RuleConfig{Severity: "info"},
+	}
+	if err := svc.CreateCustom(ctx, r); err != nil {
+		t.Fatalf("CreateCustom: %v", err)
+	}
+
+	engine := NewEngine(svc, db, nil, nil, testLogger())
+
+	a := &artist.Artist{ID: "custom-1", Name: "Björk", Genres: []string{"Electronic", "Art Pop", "Trip Hop"}, Path: t.TempDir()}
+	result, err := engine.Evaluate(ctx, a)
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if result.RulesTotal != 1 {
+		t.Fatalf("RulesTotal = %d, want 1", result.RulesTotal)
+	}
+	if len(result.Violations) != 1 {
+		t.Fatalf("violations = %v, want exactly one", result.Violations)
+	}
+	v := result.Violations[0]
+	if v.RuleID != r.ID || v.Severity != "info" || v.Message != "Björk has Electronic, Art Pop, Trip Hop" {
+		t.Errorf("violation = %+v", v)
+	}
+
+	a.Genres = a.Genres[:1]
+	result, err = engine.Evaluate(ctx, a)
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if len(result.Violations) != 0 || result.RulesPassed != 1 {
+		t.Errorf("passing artist: violations = %v, passed = %d", result.Violations, result.RulesPassed)
+	}
+}
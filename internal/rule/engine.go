@@ -203,6 +203,13 @@ type Engine struct {
 	// because nothing writes the result back; wiring it is what turns hashing
 	// into a once-per-file cost. Set via SetImageHashRecorder.
 	imageHashRecorder imageHashRecorder
+
+	// customPrograms caches compiled custom rule expressions by source text
+	// (see customChecker). Cleared with the rule list by InvalidateRuleCache
+	// so edited-away expressions do not accumulate. Guarded by customMu
+	// because evaluation runs from concurrent HTTP handlers.
+	customMu       sync.Mutex
+	customPrograms map[string]customProgram
 }
 
 // NewEngine creates a rule evaluation engine with all built-in checkers registered.
@@ -420,6 +427,10 @@ func (e *Engine) InvalidateRuleCache() {
 	e.ruleList = nil
 	e.ruleFetchedAt = time.Time{}
 	e.ruleCacheMu.Unlock()
+
+	e.customMu.Lock()
+	e.customPrograms = nil
+	e.customMu.Unlock()
 }
 
 // Evaluate runs all enabled rules against an artist and returns the results.
@@ -520,8 +531,8 @@ func (e *Engine) EvaluateScoped(ctx context.Context, a *artist.Artist, only map[
 			continue
 		}
 
-		// eligibleRules already guaranteed a checker is registered.
-		checker := e.checkers[r.ID]
+		// eligibleRules already guaranteed a checker exists.
+		checker, _ := e.checkerFor(r)
 
 		result.RulesTotal++
 		result.RulesConsidered = append(result.RulesConsidered, r.ID)
@@ -546,6 +557,18 @@ func (e *Engine) EvaluateScoped(ctx context.Context, a *artist.Artist, only map[
 	return result, nil
 }
 
+// checkerFor returns the checker that evaluates r: the compiled-in checker
+// registered under its ID for a built-in rule, or the compiled expression for
+// a custom one. A custom rule whose expression no longer compiles has no
+// checker and is treated exactly like a built-in without one.
+func (e *Engine) checkerFor(r *Rule) (Checker, bool) {
+	if r.Expression != "" {
+		return e.customChecker(r)
+	}
+	c, ok := e.checkers[r.ID]
+	return c, ok
+}
+
 // eligibleRules returns the rules a full evaluation would consider for this
 // artist, in evaluation order: enabled, not skipped for want of a local path,
 // and backed by a registered checker.
@@ -589,7 +612,7 @@ func (e *Engine) eligibleRules(ctx context.Context, a *artist.Artist) ([]Rule, [
 			continue
 		}
 
-		if _, ok := e.checkers[r.ID]; !ok {
+		if _, ok := e.checkerFor(r); !ok {
 			e.logger.Debug("no checker registered for rule", slog.String("rule_id", r.ID))
 			continue
 		}
@@ -664,6 +664,35 @@ func (p *Pipeline) RunImageRulesForArtist(ctx context.Context, a *artist.Artist)
 	return p.runForArtistFiltered(ctx, a, "image")
 }
 
+// RunRuleForArtist is the single-artist counterpart to RunRuleScoped: it
+// evaluates ruleID alone against a and attempts its fix, with the same
+// per-artist work unit (processArtistForRunRule), so the automation mode,
+// pass-row and resolved-row bookkeeping cannot drift from the library-wide
+// sweep. Like RunRuleScoped it leaves rules_evaluated_at untouched. Excluded
+// and locked artists are skipped, matching RunForArtist.
+//
+// The headless `rules run --rule --library` command uses it to walk one
+// library, which the scope-based walkers cannot express.
+func (p *Pipeline) RunRuleForArtist(ctx context.Context, a *artist.Artist, ruleID string) (*RunResult, error) {
+	result := &RunResult{Scope: RunScopeAll.String()}
+	if a.IsExcluded || a.Locked {
+		return result, nil
+	}
+	targetRule, err := p.ruleService.GetByID(ctx, ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("getting rule %s: %w", ruleID, err)
+	}
+	result.ArtistsTotal = 1
+
+	var mu sync.Mutex
+	contrib, ok := p.processArtistForRunRule(ctx, a, ruleID, targetRule)
+	mergeContribution(&mu, &result.ArtistsProcessed, result, contrib, ok)
+	if !ok && !contrib.persistFailed {
+		return nil, fmt.Errorf("evaluating rule %s for artist %s", ruleID, a.Name)
+	}
+	return result, nil
+}
+
 // violationOutcome is the per-violation delta produced by a strategy
 // (processManualViolation / processAutoFixViolation). The orchestrator
 // merges these into the accumulating per-artist state and the RunResult.
@@ -214,3 +214,89 @@ func TestProcessArtistForRunRule_PersistPassResultsError(t *testing.T) {
 		t.Error("persistOK = true; want false when persistPassResults fails on the closed rule DB")
 	}
 }
+
+// TestRunRuleForArtist_EvaluatesOnlyTheTargetRule pins the single-artist,
+// single-rule unit the headless `rules run --rule --library` walk is built
+// on: it evaluates the artist, counts it processed, and reports only the
+// target rule's violation even though the artist breaks others too.
+func TestRunRuleForArtist_EvaluatesOnlyTheTargetRule(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	ruleSvc := NewService(db)
+	artistSvc := artist.NewService(db)
+	if err := ruleSvc.SeedDefaults(ctx); err != nil {
+		t.Fatalf("seeding rules: %v", err)
+	}
+	// An empty directory: no NFO, no images, no MBID.
+	a := &artist.Artist{Name: "Single Rule", SortName: "Single Rule", Path: t.TempDir()}
+	if err := artistSvc.Create(ctx, a); err != nil {
+		t.Fatalf("creating artist: %v", err)
+	}
+	engine := NewEngine(ruleSvc, db, nil, nil, testLogger())
+	p := NewPipeline(engine, artistSvc, ruleSvc, nil, nil, testLogger())
+
+	result, err := p.RunRuleForArtist(ctx, a, RuleNFOExists)
+	if err != nil {
+		t.Fatalf("RunRuleForArtist: %v", err)
+	}
+	if result.ArtistsTotal != 1 || result.ArtistsProcessed != 1 {
+		t.Errorf("ArtistsTotal/Processed = %d/%d; want 1/1", result.ArtistsTotal, result.ArtistsProcessed)
+	}
+	if result.ViolationsFound != 1 {
+		t.Errorf("ViolationsFound = %d; want 1 (only %s, not the artist's other violations)", result.ViolationsFound, RuleNFOExists)
+	}
+	for _, fr := range result.Results {
+		if fr.RuleID != RuleNFOExists {
+			t.Errorf("result for rule %s; want only %s", fr.RuleID, RuleNFOExists)
+		}
+	}
+}
+
+// TestRunRuleForArtist_SkipsExcludedAndLocked matches RunForArtist: an
+// excluded or locked artist is not evaluated and the empty result is not an
+// error, so a library walk moves on to the next artist.
+func TestRunRuleForArtist_SkipsExcludedAndLocked(t *testing.T) {
+	db := setupTestDB(t)
+	ruleSvc := NewService(db)
+	engine := NewEngine(ruleSvc, db, nil, nil, testLogger())
+	p := NewPipeline(engine, artist.NewService(db), ruleSvc, nil, nil, testLogger())
+
+	for _, a := range []*artist.Artist{
+		{Name: "Excluded", IsExcluded: true},
+		{Name: "Locked", Locked: true},
+	} {
+		// The rule ID is deliberately unknown: the skip must come before the
+		// rule lookup, or a skipped artist would surface as an error.
+		result, err := p.RunRuleForArtist(context.Background(), a, "no-such-rule")
+		if err != nil {
+			t.Fatalf("%s: RunRuleForArtist: %v", a.Name, err)
+		}
+		if result.ArtistsTotal != 0 || result.ArtistsProcessed != 0 {
+			t.Errorf("%s: ArtistsTotal/Processed = %d/%d; want 0/0", a.Name, result.ArtistsTotal, result.ArtistsProcessed)
+		}
+	}
+}
+
+// TestRunRuleForArtist_EvaluateErrorIsReturned: unlike the library-wide
+// sweeps, which warn-log a failed artist and carry on, the single-artist
+// entry point has no one else to report to, so a failed evaluation is its
+// error. Same split-DB injection as TestRunRuleScoped_EvaluateError_Accounting.
+func TestRunRuleForArtist_EvaluateErrorIsReturned(t *testing.T) {
+	ctx := context.Background()
+	db := setupTestDB(t)
+	ruleSvc := NewService(db)
+	if err := ruleSvc.SeedDefaults(ctx); err != nil {
+		t.Fatalf("seeding rules: %v", err)
+	}
+	engineDB := setupTestDB(t)
+	if err := engineDB.Close(); err != nil {
+		t.Fatalf("closing engine db: %v", err)
+	}
+	engine := NewEngine(NewService(engineDB), engineDB, nil, nil, testLogger())
+	p := NewPipeline(engine, artist.NewService(db), ruleSvc, nil, nil, testLogger())
+
+	a := &artist.Artist{ID: "eval-err", Name: "Eval Err", Path: t.TempDir()}
+	if _, err := p.RunRuleForArtist(ctx, a, RuleNFOExists); err == nil {
+		t.Fatal("RunRuleForArtist returned nil error for a failed evaluation")
+	}
+}
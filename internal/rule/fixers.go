@@ -256,6 +256,11 @@ type MetadataFixer struct {
 	// way rather than one relying on a happens-before nobody wrote down.
 	albumGateMu sync.RWMutex
 	albumGate   ruleAlbumGate
+
+	// readTags samples the artist directory's embedded audio tags. nil means
+	// artist.ReadTagEvidence; tests substitute a stub so they do not need
+	// real audio files on disk.
+	readTags func(ctx context.Context, artistName, dir string) (artist.TagEvidence, error)
 }
 
 // SetAlbumGate late-wires the #2858 album-evidence gate for the nfo_has_mbid
@@ -322,12 +327,33 @@ func (f *MetadataFixer) Fix(ctx context.Context, a *artist.Artist, v *Violation)
 	}
 }
 
-// fixMBID adopts a MusicBrainz ID for the artist from a provider name search,
-// but only when the top hit clears every gate in artist/mbidcandidate.go. On
+// fixMBID adopts a MusicBrainz ID for the artist from a consensus of its
+// embedded audio tags or, failing that, from a provider name search -- but only
+// when the top hit clears every gate in artist/mbidcandidate.go and the tags do
+// not name someone else. On
 // failure it leaves the artist untouched and returns Fixed=false, so the
 // violation stays open for the operator rather than being silently closed over
 // a guess. See artist.EvaluateMBIDCandidate for the gate logic.
 func (f *MetadataFixer) fixMBID(ctx context.Context, a *artist.Artist) (*FixResult, error) {
+	// Embedded tags come first: a consensus of the MusicBrainz artist IDs a
+	// tagger wrote into the artist's own files is stronger evidence than any
+	// name search, and adopting it costs no provider call. The consensus
+	// gates live in artist/mbidtags.go. The album gate below is not applied
+	// to a tag-derived ID: the tags were written against those very albums.
+	tags := f.tagEvidence(ctx, a)
+	if mbid, rej := tags.Consensus(); rej == nil {
+		a.MusicBrainzID = mbid
+		if a.MetadataSources == nil {
+			a.MetadataSources = make(map[string]string)
+		}
+		a.MetadataSources[artist.SourceKeyMusicBrainzID] = artist.SourceEmbeddedTags
+		return &FixResult{
+			RuleID:  RuleNFOHasMBID,
+			Fixed:   true,
+			Message: fmt.Sprintf("set MusicBrainz ID %s for %s from embedded audio tags (%s)", mbid, a.Name, tags.Describe()),
+		}, nil
+	}
+
 	results, err := coalescedSearch(ctx, f.orchestrator, a.Name)
 	if err != nil {
 		return nil, fmt.Errorf("searching providers: %w", err)
@@ -370,6 +396,22 @@ func (f *MetadataFixer) fixMBID(ctx context.Context, a *artist.Artist) (*FixResu
 		}, nil
 	}
 
+	// Tags that carry an ID for this artist but fell short of a consensus
+	// (one track, or disagreeing tracks) cannot adopt an ID on their own, but
+	// they can still veto a search hit none of them names.
+	if rej := tags.CheckCandidate(best); rej != nil {
+		f.log().Info("declined to adopt MusicBrainz ID from search",
+			slog.String("rule_id", RuleNFOHasMBID),
+			slog.String("artist", a.Name),
+			slog.String("candidate_mbid", best.MusicBrainzID),
+			slog.String("reason", rej.Reason))
+		return &FixResult{
+			RuleID:  RuleNFOHasMBID,
+			Fixed:   false,
+			Message: fmt.Sprintf("declined to set MusicBrainz ID for %s: %s", a.Name, rej.Reason),
+		}, nil
+	}
+
 	// #2858: the name gates above read only the SEARCH RESULT. Ask the catalogue
 	// question too -- do the candidate's release groups look like this artist's
 	// albums? -- before an unattended write. The gate FAILS OPEN: it permits
@@ -422,6 +464,24 @@ func (f *MetadataFixer) fixMBID(ctx context.Context, a *artist.Artist) (*FixResu
 	}, nil
 }
 
+// tagEvidence reads the artist's embedded-tag evidence. A directory that cannot
+// be sampled yields empty evidence, which adopts nothing and vetoes nothing, so
+// the fixer behaves exactly as it did before tags were consulted.
+func (f *MetadataFixer) tagEvidence(ctx context.Context, a *artist.Artist) artist.TagEvidence {
+	read := f.readTags
+	if read == nil {
+		read = artist.ReadTagEvidence
+	}
+	ev, err := read(ctx, a.Name, a.Path)
+	if err != nil {
+		f.log().Debug("embedded tags unavailable for MBID fix",
+			slog.String("artist", a.Name),
+			slog.String("error", err.Error()))
+		return artist.TagEvidence{}
+	}
+	return ev
+}
+
 // log returns the fixer's logger, falling back to the default when the fixer
 // was constructed without one (several tests build a bare MetadataFixer).
 func (f *MetadataFixer) log() *slog.Logger {
@@ -1125,7 +1185,7 @@ func SaveImageFromData(ctx context.Context, a *artist.Artist, imageType string,
 		naming = existingImageFileNames(ctx, a.Path, imageType, platformService)
 	}
 
-	saved, err := saveImageToDisk(ctx, a.Path, imageType, converted, naming, useSymlinks, meta, logger)
+	saved, err := saveImageToDisk(withImageRevisionContext(ctx, a, meta), a.Path, imageType, converted, naming, useSymlinks, meta, logger)
 	if err != nil {
 		return nil, fmt.Errorf("saving image: %w", err)
 	}
@@ -1155,11 +1215,35 @@ func SaveImageFromData(ctx context.Context, a *artist.Artist, imageType string,
 // directory would corrupt the Router's revert feature. Closing it means lifting the
 // Router's single-slot backup+rollback policy into internal/image as a second shared
 // chokepoint, which is its own change.
+//
+// Both paths feed the artwork revision history. SaveSlotProtected records the replaced
+// and the written image itself; the single-slot path records them here, around the
+// bare Save, so a thumb a fixer swaps out is still restorable even without a backup.
 func saveImageToDisk(ctx context.Context, dir, imageType string, data []byte, naming []string, useSymlinks bool, meta *img.ExifMeta, logger *slog.Logger) ([]string, error) {
 	if imageType == "fanart" {
 		return img.SaveSlotProtected(ctx, dir, imageType, naming, data, useSymlinks, meta, logger)
 	}
-	return img.Save(dir, imageType, data, naming, useSymlinks, meta, logger)
+	img.RecordCurrentRevision(ctx, dir, imageType, naming)
+	saved, err := img.Save(dir, imageType, data, naming, useSymlinks, meta, logger)
+	if err == nil && len(saved) > 0 {
+		img.RecordSavedRevision(ctx, dir, imageType, saved[0])
+	}
+	return saved, err
+}
+
+// withImageRevisionContext attributes the image writes made under ctx to a in
+// the artwork revision history, unless a caller further up (an API handler
+// applying a candidate) already did. The action is "rule:<id>" when meta names
+// the rule driving the write, and plain "rule" otherwise.
+func withImageRevisionContext(ctx context.Context, a *artist.Artist, meta *img.ExifMeta) context.Context {
+	if artistID, _ := img.RevisionContextFrom(ctx); artistID != "" {
+		return ctx
+	}
+	action := "rule"
+	if meta != nil && meta.Rule != "" {
+		action = "rule:" + meta.Rule
+	}
+	return img.WithRevisionContext(ctx, a.ID, action)
 }
 
 // existingImageFileNames returns the subset of canonical filenames for imageType
@@ -1530,7 +1614,7 @@ func (f *LogoPaddingFixer) fixViaDisk(ctx context.Context, a *artist.Artist, v *
 	// place in this package that reaches the image-write primitives. A logo is
 	// single-slot, so this still lands on a bare Save today (see saveImageToDisk); the
 	// point is that a fanart write can never be added here without the guard seeing it.
-	savedNames, err := saveImageToDisk(ctx, a.Path, "logo", trimmed, naming, useSymlinks, padMeta, f.logger)
+	savedNames, err := saveImageToDisk(withImageRevisionContext(ctx, a, padMeta), a.Path, "logo", trimmed, naming, useSymlinks, padMeta, f.logger)
 	if err != nil {
 		return nil, fmt.Errorf("saving trimmed logo: %w", err)
 	}
@@ -1701,8 +1785,13 @@ func fetchImageURL(ctx context.Context, client *http.Client, rawURL string) ([]b
 // symptom - peers kept the old path, a peer's NFO saver re-created the directory
 // that was just renamed away, and the next scan re-imported it as a duplicate.
 // Do not reintroduce a direct filesystem rename here.
+//
+// RenamedDirPath is where RenameDirectory would put the directory, which in a
+// library sorted into letter buckets is not always beside it; the fixer probes
+// that path for collisions.
 type DirectoryRenamer interface {
 	RenameDirectory(ctx context.Context, artistID, newDirName string) (newPath string, platforms []artist.PlatformRemapResult, err error)
+	RenamedDirPath(ctx context.Context, artistPath, newDirName string) (string, error)
 }
 
 // DirectoryRenameFixer renames an artist's directory to match the canonical name.
@@ -1769,8 +1858,10 @@ func (f *DirectoryRenameFixer) Fix(ctx context.Context, a *artist.Artist, v *Vio
 		}, nil
 	}
 
-	parentDir := filepath.Dir(a.Path)
-	newPath := filepath.Join(parentDir, canonical)
+	newPath, err := f.targetPath(ctx, a, canonical)
+	if err != nil {
+		return nil, err
+	}
 
 	if a.Path == newPath {
 		return &FixResult{RuleID: v.RuleID, Fixed: false, Message: "paths already match"}, nil
@@ -1800,7 +1891,10 @@ func (f *DirectoryRenameFixer) Fix(ctx context.Context, a *artist.Artist, v *Vio
 				Message: fmt.Sprintf("destination '%s' already exists", canonical),
 			}, nil
 		}
-		fallbackPath := filepath.Join(parentDir, fallback)
+		fallbackPath, err := f.targetPath(ctx, a, fallback)
+		if err != nil {
+			return nil, err
+		}
 		// Idempotency: if a prior run already renamed a.Path to fallbackPath,
 		// pathIsFree would return false (the current directory occupies the
 		// target) and bounce the artist back into a "destination collides"
@@ -1885,6 +1979,16 @@ func (f *DirectoryRenameFixer) Fix(ctx context.Context, a *artist.Artist, v *Vio
 	}, nil
 }
 
+// targetPath returns where the renamer would move the artist's directory for
+// newDirName: beside it, or in another letter bucket of a nested library.
+// Without a renamer the fix is refused later, so the sibling path stands in.
+func (f *DirectoryRenameFixer) targetPath(ctx context.Context, a *artist.Artist, newDirName string) (string, error) {
+	if f.renamer == nil {
+		return filepath.Join(filepath.Dir(a.Path), newDirName), nil
+	}
+	return f.renamer.RenamedDirPath(ctx, a.Path, newDirName)
+}
+
 // renameGuarded performs the rename through the injected DirectoryRenamer, the
 // SAME chokepoint the user-driven rename and the merge flow use (and therefore
 // the same one publish.guardPlatformPath sits on).
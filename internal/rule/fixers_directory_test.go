@@ -712,3 +712,51 @@ func TestDirectoryRenameFixer_GuardedRefusalsAreUnfixedNotErrors(t *testing.T) {
 		}
 	})
 }
+
+// TestDirectoryRenameFixer_LetterBuckets checks that in a library sorted into
+// letter buckets, a canonical name with another first letter moves the
+// directory to that letter's bucket instead of leaving it under the old one.
+func TestDirectoryRenameFixer_LetterBuckets(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	db := setupTestDB(t)
+	svc := artist.NewService(db)
+	svc.SetPlatformRenameSyncer(&spyRenameSyncer{})
+	libs := library.NewService(db)
+	svc.SetDirectoryPlacer(libs)
+	fixer := NewDirectoryRenameFixer(nonSharedFSCheck(), svc, logger)
+	ctx := context.Background()
+
+	root := t.TempDir()
+	lib := &library.Library{Name: "Letters", Path: root, Type: library.TypeRegular, Layout: library.LayoutLetterFirst}
+	if err := libs.Create(ctx, lib); err != nil {
+		t.Fatalf("creating library: %v", err)
+	}
+	oldPath := filepath.Join(root, "B", "Beatles")
+	if err := os.MkdirAll(oldPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	a := &artist.Artist{Name: "The Beatles", Path: oldPath, LibraryID: lib.ID}
+	persistArtist(t, svc, a)
+
+	v := &Violation{RuleID: RuleDirectoryNameMismatch, Config: RuleConfig{ArticleMode: "prefix"}}
+	result, err := fixer.Fix(ctx, a, v)
+	if err != nil {
+		t.Fatalf("Fix: %v", err)
+	}
+	want := filepath.Join(root, "T", "The Beatles")
+	if !result.Fixed || a.Path != want {
+		t.Fatalf("Fix = %+v, path %q; want fixed at %q", result, a.Path, want)
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Errorf("expected the directory in the T bucket: %v", err)
+	}
+
+	// With the article suffixed the name sorts under B again.
+	v.Config.ArticleMode = "suffix"
+	if _, err := fixer.Fix(ctx, a, v); err != nil {
+		t.Fatalf("Fix (suffix): %v", err)
+	}
+	if want := filepath.Join(root, "B", "Beatles, The"); a.Path != want {
+		t.Errorf("path after suffix fix = %q, want %q", a.Path, want)
+	}
+}
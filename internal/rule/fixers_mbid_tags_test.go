@@ -0,0 +1,85 @@
+package rule
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/provider"
+)
+
+// tagEvidenceStub returns a readTags func that reports fixed evidence.
+func tagEvidenceStub(ev artist.TagEvidence) func(context.Context, string, string) (artist.TagEvidence, error) {
+	return func(context.Context, string, string) (artist.TagEvidence, error) { return ev, nil }
+}
+
+// TestFixMBID_TagConsensusSkipsSearch pins the point of reading tags: an
+// agreeing sample is adopted without a provider call, and its provenance says
+// where it came from.
+func TestFixMBID_TagConsensusSkipsSearch(t *testing.T) {
+	f := &MetadataFixer{
+		orchestrator: &stubMBIDSearchOrchestrator{err: errors.New("search must not be called")},
+		logger:       testLogger(),
+		readTags:     tagEvidenceStub(artist.TagEvidence{Sampled: 3, Votes: map[string]int{mbidRadiohead: 3}}),
+	}
+	a := &artist.Artist{Name: "Radiohead", Path: "/music/Radiohead"}
+
+	fr := fixMBIDFor(t, f, a)
+
+	if !fr.Fixed || a.MusicBrainzID != mbidRadiohead {
+		t.Fatalf("Fixed=%v MusicBrainzID=%q, want the tag consensus adopted (message: %q)", fr.Fixed, a.MusicBrainzID, fr.Message)
+	}
+	if got := a.MetadataSources[artist.SourceKeyMusicBrainzID]; got != artist.SourceEmbeddedTags {
+		t.Errorf("provenance = %q, want %q", got, artist.SourceEmbeddedTags)
+	}
+}
+
+// TestFixMBID_TagsVetoContradictedSearchHit covers the other direction: tags
+// too thin to adopt on their own still decline a search hit they contradict.
+func TestFixMBID_TagsVetoContradictedSearchHit(t *testing.T) {
+	results := []provider.ArtistSearchResult{
+		{Name: "Radiohead", MusicBrainzID: mbidRadiohead, Score: 100, Source: "musicbrainz"},
+	}
+	f := &MetadataFixer{
+		orchestrator: &stubMBIDSearchOrchestrator{results: results},
+		logger:       testLogger(),
+		readTags:     tagEvidenceStub(artist.TagEvidence{Sampled: 4, Votes: map[string]int{mbidRival: 1}}),
+	}
+	a := &artist.Artist{Name: "Radiohead", Path: "/music/Radiohead"}
+
+	fr := fixMBIDFor(t, f, a)
+
+	if fr.Fixed || a.MusicBrainzID != "" {
+		t.Fatalf("contradicted search hit adopted: MusicBrainzID=%q", a.MusicBrainzID)
+	}
+	if !strings.Contains(fr.Message, "embedded tags") {
+		t.Errorf("message %q does not name the tag evidence", fr.Message)
+	}
+}
+
+// TestFixMBID_TagReadErrorFallsBackToSearch pins fail-open: unreadable tags
+// leave the search path exactly as it was.
+func TestFixMBID_TagReadErrorFallsBackToSearch(t *testing.T) {
+	results := []provider.ArtistSearchResult{
+		{Name: "Radiohead", MusicBrainzID: mbidRadiohead, Score: 100, Source: "musicbrainz"},
+	}
+	f := &MetadataFixer{
+		orchestrator: &stubMBIDSearchOrchestrator{results: results},
+		logger:       testLogger(),
+		readTags: func(context.Context, string, string) (artist.TagEvidence, error) {
+			return artist.TagEvidence{}, errors.New("permission denied")
+		},
+	}
+	a := &artist.Artist{Name: "Radiohead"}
+
+	fr := fixMBIDFor(t, f, a)
+
+	if !fr.Fixed || a.MusicBrainzID != mbidRadiohead {
+		t.Fatalf("Fixed=%v MusicBrainzID=%q, want the search hit adopted", fr.Fixed, a.MusicBrainzID)
+	}
+	if got := a.MetadataSources[artist.SourceKeyMusicBrainzID]; got != artist.SourceMachinePicked {
+		t.Errorf("provenance = %q, want %q", got, artist.SourceMachinePicked)
+	}
+}
@@ -22,9 +22,10 @@ type ProviderFieldUpdater interface {
 // providerIDBackfillField maps an in-scope provider name to the artist
 // field-update key UpdateProviderField expects.
 var providerIDBackfillField = map[provider.ProviderName]string{
-	provider.NameDiscogs: "discogs_id",
-	provider.NameDeezer:  "deezer_id",
-	provider.NameSpotify: "spotify_id",
+	provider.NameDiscogs:  "discogs_id",
+	provider.NameDeezer:   "deezer_id",
+	provider.NameSpotify:  "spotify_id",
+	provider.NameAllMusic: "allmusic_id",
 }
 
 // setProviderIDForName writes value onto the in-scope flat field of a that
@@ -40,6 +41,8 @@ func setProviderIDForName(a *artist.Artist, name provider.ProviderName, value st
 		a.DeezerID = value
 	case provider.NameSpotify:
 		a.SpotifyID = value
+	case provider.NameAllMusic:
+		a.AllMusicID = value
 	default:
 		// Out-of-scope provider: nothing to write. Mirrors providerIDForName's
 		// default branch above.
@@ -47,8 +50,8 @@ func setProviderIDForName(a *artist.Artist, name provider.ProviderName, value st
 }
 
 // ProviderIDBackfillFixer resolves provider_id_missing violations by deriving
-// the missing Discogs/Deezer/Spotify IDs from an artist's MusicBrainz URL
-// relations and filling only the empty ones (issue #2457).
+// the missing Discogs/Deezer/Spotify/AllMusic IDs from an artist's MusicBrainz
+// URL relations and filling only the empty ones (issue #2457).
 //
 // It reuses the shipped provider.ExtractProviderIDsFromURLs helper (and its
 // providerURLParsers table) so the parsing stays identical to the orchestrator
@@ -182,7 +185,7 @@ func (f *ProviderIDBackfillFixer) Fix(ctx context.Context, a *artist.Artist, _ *
 	scratch := &provider.ArtistMetadata{URLs: res.Metadata.URLs}
 	provider.ExtractProviderIDsFromURLs(scratch)
 
-	// Fill-empty only, scoped to the three derivable providers. Iterate the
+	// Fill-empty only, scoped to the derivable in-scope providers. Iterate the
 	// in-scope order for a deterministic message.
 	derivedFor := func(name provider.ProviderName) string {
 		switch name {
@@ -192,6 +195,8 @@ func (f *ProviderIDBackfillFixer) Fix(ctx context.Context, a *artist.Artist, _ *
 			return scratch.DeezerID
 		case provider.NameSpotify:
 			return scratch.SpotifyID
+		case provider.NameAllMusic:
+			return scratch.AllMusicID
 		default:
 			return ""
 		}
@@ -298,7 +303,7 @@ func (f *ProviderIDBackfillFixer) Fix(ctx context.Context, a *artist.Artist, _ *
 	//
 	// It errs one way on purpose. The checker can narrow the required set
 	// (provider availability, RequiredProviderIDs) while this fixer iterates all
-	// three in-scope providers, so an unconfigured provider with no relation also
+	// in-scope providers, so an unconfigured provider with no relation also
 	// blocks the dismiss. That leaves the row open when it could have closed,
 	// costing a Fix click; the inverse error costs the operator the finding.
 	if len(filled) == 0 && len(refused) > 0 && skippedNoRelation == 0 {
@@ -25,14 +25,15 @@ func (u *recordingUpdater) UpdateProviderField(_ context.Context, _, field, valu
 }
 
 // mbURLMetadata builds ArtistMetadata carrying MusicBrainz URL relations for
-// Discogs, Deezer, and Spotify. The shared stubMetadataProvider wraps it in a
+// Discogs, Deezer, Spotify, and AllMusic. The shared stubMetadataProvider wraps it in a
 // FetchResult.
 func mbURLMetadata() *provider.ArtistMetadata {
 	return &provider.ArtistMetadata{
 		URLs: map[string]string{
-			"discogs": "https://www.discogs.com/artist/24941",
-			"deezer":  "https://www.deezer.com/artist/3106",
-			"spotify": "https://open.spotify.com/artist/7dGJo4pcD2V6oG8kP0tJRR",
+			"discogs":  "https://www.discogs.com/artist/24941",
+			"deezer":   "https://www.deezer.com/artist/3106",
+			"spotify":  "https://open.spotify.com/artist/7dGJo4pcD2V6oG8kP0tJRR",
+			"allmusic": "https://www.allmusic.com/artist/mn0000505828",
 		},
 	}
 }
@@ -53,9 +54,10 @@ func TestProviderIDBackfill_FillsEmptyFromRelations(t *testing.T) {
 		t.Fatalf("expected Fixed=true, got %+v", res)
 	}
 	want := map[string]string{
-		"discogs_id": "24941",
-		"deezer_id":  "3106",
-		"spotify_id": "7dGJo4pcD2V6oG8kP0tJRR",
+		"discogs_id":  "24941",
+		"deezer_id":   "3106",
+		"spotify_id":  "7dGJo4pcD2V6oG8kP0tJRR",
+		"allmusic_id": "mn0000505828",
 	}
 	if len(updater.updates) != len(want) {
 		t.Fatalf("wrote %d fields, want %d: %+v", len(updater.updates), len(want), updater.updates)
@@ -374,7 +376,7 @@ func TestProviderIDBackfill_LockRefusalIsATerminalSkip(t *testing.T) {
 func TestProviderIDBackfill_AllRefusedDismisses(t *testing.T) {
 	fetcher := &stubMetadataProvider{metadata: mbURLMetadata()}
 	updater := &lockingUpdater{locked: map[string]bool{
-		"discogs_id": true, "deezer_id": true, "spotify_id": true,
+		"discogs_id": true, "deezer_id": true, "spotify_id": true, "allmusic_id": true,
 	}}
 	f := NewProviderIDBackfillFixer(fetcher, updater, testLogger())
 
@@ -291,7 +291,7 @@ func TestBulkFetchMetadata_StampsBulkSource(t *testing.T) {
 	orch.SetExecutor(bioReturningScrapeAll{bio: "a bulk job wrote this"})
 	e := &BulkExecutor{artistService: artistSvc, orchestrator: orch, logger: testLogger()}
 
-	status, msg := e.fetchMetadata(ctx, a, BulkModeYOLO)
+	status, msg := e.fetchMetadata(ctx, a, BulkModeYOLO, "")
 	if status != BulkItemFixed {
 		t.Fatalf("status = %q (%s); the attributed write never happened", status, msg)
 	}
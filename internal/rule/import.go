@@ -27,7 +27,7 @@ type DBExecutor interface {
 // ImportGetByIDTx is the tx-aware equivalent of GetByID.
 func (s *Service) ImportGetByIDTx(ctx context.Context, db DBExecutor, id string) (*Rule, error) {
 	row := db.QueryRowContext(ctx, `
-		SELECT id, name, description, category, enabled, automation_mode, config, created_at, updated_at
+		SELECT id, name, description, category, enabled, automation_mode, config, expression, message, created_at, updated_at
 		FROM rules WHERE id = ?
 	`, id)
 	r, err := scanRule(row)
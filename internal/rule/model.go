@@ -46,8 +46,16 @@ type Rule struct {
 	AutomationMode      string       `json:"automation_mode"` // "auto", "manual"
 	Config              RuleConfig   `json:"config"`
 	FilesystemDependent bool         `json:"filesystem_dependent"` // true if rule requires a local library with filesystem path
-	CreatedAt           time.Time    `json:"created_at"`
-	UpdatedAt           time.Time    `json:"updated_at"`
+	// Expression and Message are set only on an operator-defined rule (see
+	// custom.go): the ruleexpr predicate the engine evaluates in place of a
+	// compiled-in checker, and the violation message it reports. Both are
+	// empty for every built-in rule, which is how the two kinds are told
+	// apart; Custom mirrors that for API clients.
+	Expression string    `json:"expression,omitempty"`
+	Message    string    `json:"message,omitempty"`
+	Custom     bool      `json:"custom"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 // RuleConfig holds configurable acceptance criteria for a rule.
@@ -66,7 +74,7 @@ type RuleConfig struct {
 	ArticleMode         string  `json:"article_mode,omitempty"`          // "prefix" (default), "suffix", "strip"
 	CoverageThreshold   float64 `json:"coverage_threshold,omitempty"`    // discography_populated: min % of MB release groups the NFO must cover (0-100)
 	ReleaseTypes        string  `json:"release_types,omitempty"`         // discography_populated: comma-separated MB primary types to include (e.g. "Album,EP")
-	RequiredProviderIDs string  `json:"required_provider_ids,omitempty"` // provider_id_missing: comma-separated provider names to require (subset of discogs,deezer,spotify); empty = dynamic default (all available)
+	RequiredProviderIDs string  `json:"required_provider_ids,omitempty"` // provider_id_missing: comma-separated provider names to require (subset of discogs,deezer,spotify,allmusic); empty = dynamic default (all available except opt-in allmusic)
 	DiscoveryOnly       bool    `json:"-"`                               // transient: set by pipeline in manual mode, never persisted
 }
 
@@ -625,7 +625,7 @@ func (s *Service) migrateDeprecatedRule(ctx context.Context, ruleID string) erro
 func (s *Service) List(ctx context.Context) ([]Rule, error) {
 	atomic.AddInt64(&s.listCallCount, 1)
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, name, description, category, enabled, automation_mode, config, created_at, updated_at
+		SELECT id, name, description, category, enabled, automation_mode, config, expression, message, created_at, updated_at
 		FROM rules ORDER BY category, name
 	`)
 	if err != nil {
@@ -651,7 +651,7 @@ func (s *Service) List(ctx context.Context) ([]Rule, error) {
 // GetByID retrieves a rule by primary key.
 func (s *Service) GetByID(ctx context.Context, id string) (*Rule, error) {
 	row := s.db.QueryRowContext(ctx, `
-		SELECT id, name, description, category, enabled, automation_mode, config, created_at, updated_at
+		SELECT id, name, description, category, enabled, automation_mode, config, expression, message, created_at, updated_at
 		FROM rules WHERE id = ?
 	`, id)
 	r, err := scanRule(row)
@@ -2257,6 +2257,11 @@ func (s *Service) ReopenViolation(ctx context.Context, id string) error {
 // Restricting the DELETE to clearableRuleIDs keeps them out of reach while
 // ordinary rules -- whose findings the next evaluation pass simply rebuilds --
 // are still cleared as before.
+//
+// Custom rules (custom.go) are cleared too. They are not in the built-in
+// registry, but they are evaluated like any ordinary rule, so their findings
+// are rebuilt by the next pass; they are matched by the non-empty expression
+// column, which no event-driven rule can ever carry.
 func (s *Service) ClearResolvedViolations(ctx context.Context, daysOld int) error {
 	ids := clearableRuleIDs()
 	if len(ids) == 0 {
@@ -2277,7 +2282,8 @@ func (s *Service) ClearResolvedViolations(ctx context.Context, daysOld int) erro
 
 	//nolint:gosec // G201: only "?" placeholders are interpolated; every value is parameterized
 	query := fmt.Sprintf(
-		`DELETE FROM rule_violations WHERE status = ? AND resolved_at < ? AND rule_id IN (%s)`,
+		`DELETE FROM rule_violations WHERE status = ? AND resolved_at < ?
+		 AND (rule_id IN (%s) OR rule_id IN (SELECT id FROM rules WHERE expression != ''))`,
 		strings.Join(placeholders, ", "),
 	)
 	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
@@ -2477,12 +2483,13 @@ func scanRule(row interface{ Scan(...any) error }) (*Rule, error) {
 	var createdAt, updatedAt string
 
 	err := row.Scan(&r.ID, &r.Name, &r.Description, &r.Category,
-		&enabled, &r.AutomationMode, &config, &createdAt, &updatedAt)
+		&enabled, &r.AutomationMode, &config, &r.Expression, &r.Message, &createdAt, &updatedAt)
 	if err != nil {
 		return nil, err
 	}
 
 	r.Enabled = enabled == 1
+	r.Custom = r.Expression != ""
 	r.Config = UnmarshalConfig(config)
 	r.CreatedAt = dbutil.ParseTime(createdAt)
 	r.UpdatedAt = dbutil.ParseTime(updatedAt)
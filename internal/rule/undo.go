@@ -1,122 +1,641 @@
 package rule
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
-	"sync"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/sydlexius/stillwater/internal/artist"
+	"github.com/sydlexius/stillwater/internal/dbutil"
 	"github.com/sydlexius/stillwater/internal/filesystem"
 )
 
-// UndoWindowDuration is the time window within which a fix can be reverted.
+// UndoWindowDuration is how long the UI offers the Undo toast after a fix.
+// The record behind the toast outlives it: it stays revertable from the
+// artist's undo history until UndoRetention prunes it.
 const UndoWindowDuration = 30 * time.Second
 
+// UndoRetention is how long an undo record is kept. Records carry file
+// content (an NFO, or a replaced image), so they are pruned by age rather than
+// kept forever; a month covers noticing a bad bulk run well after the fact.
+const UndoRetention = 30 * 24 * time.Hour
+
+// MaxUndoFileBytes caps the size of a single file an undo record will hold.
+// A change touching a larger file gets no undo record at all: a record that
+// silently left one file out would "revert" to a state that never existed.
+const MaxUndoFileBytes = 32 << 20
+
+// Undo record statuses. See migration 033 for the applied -> reverting ->
+// reverted lifecycle and why "reverting" exists.
+const (
+	UndoStatusApplied   = "applied"
+	UndoStatusReverting = "reverting"
+	UndoStatusReverted  = "reverted"
+)
+
+var (
+	// ErrUndoNotFound is returned for an undo ID that was never recorded or
+	// has been pruned.
+	ErrUndoNotFound = errors.New("undo record not found")
+
+	// ErrUndoReverted is returned when the record was already reverted, or a
+	// concurrent revert of it is in progress.
+	ErrUndoReverted = errors.New("undo record already reverted")
+)
+
+// UndoConflictError is returned when something changed the artist AFTER the
+// change being undone, so restoring the captured state would silently discard
+// that later change. Each conflict names the field, file or path concerned.
+// Nothing is modified when this is returned.
+type UndoConflictError struct {
+	Conflicts []string
+}
+
+func (e *UndoConflictError) Error() string {
+	return "later changes conflict with this undo: " + strings.Join(e.Conflicts, "; ")
+}
+
 // RevertFunc is a function that reverses a previously applied fix.
 // It accepts a context and returns an error if the revert fails.
 type RevertFunc func(ctx context.Context) error
 
-// UndoEntry holds the data needed to undo a recently applied fix.
-type UndoEntry struct {
-	ID          string     // unique undo token (UUID)
-	ViolationID string     // the violation that was fixed
-	ExpiresAt   time.Time  // when the undo window closes
-	Revert      RevertFunc // how to reverse the fix
+// FieldChange is one artist field an undo record restores.
+type FieldChange struct {
+	Field    string `json:"field"`
+	OldValue string `json:"old_value"`
+	NewValue string `json:"new_value"`
+}
+
+// UndoRecord is the durable record of one applied change to one artist: a
+// single fix, or one artist's share of a bulk job. It holds the state before
+// the change (to restore) and after it (to detect a later change that a revert
+// would clobber).
+type UndoRecord struct {
+	ID          string        `json:"id"`
+	ArtistID    string        `json:"artist_id"`
+	ArtistName  string        `json:"artist_name,omitempty"`
+	ViolationID string        `json:"violation_id,omitempty"`
+	RuleID      string        `json:"rule_id,omitempty"`
+	BulkJobID   string        `json:"bulk_job_id,omitempty"`
+	Summary     string        `json:"summary"`
+	Fields      []FieldChange `json:"fields"`
+	// Files is never serialized: it carries whole file contents. FilePaths
+	// is the listing view of the same set.
+	Files      []FileSnapshot `json:"-"`
+	FilePaths  []string       `json:"files"`
+	OldPath    string         `json:"old_path,omitempty"`
+	NewPath    string         `json:"new_path,omitempty"`
+	Status     string         `json:"status"`
+	CreatedAt  time.Time      `json:"created_at"`
+	RevertedAt *time.Time     `json:"reverted_at,omitempty"`
+}
+
+// UndoCapture is an artist's state captured immediately before a change.
+// Finish turns it into an UndoRecord once the change has been applied, by
+// diffing against the state the change left behind.
+type UndoCapture struct {
+	artistID string
+	path     string
+	fields   map[string]string
+	files    []FileSnapshot
+}
+
+// CaptureUndo records a's editable field values and the given pre-change file
+// snapshots (see CaptureFile). It does no I/O of its own, so a caller can take
+// the capture from an artist it already loaded.
+func CaptureUndo(a *artist.Artist, files []FileSnapshot) *UndoCapture {
+	fields := make(map[string]string)
+	for _, f := range artist.EditableFieldsList() {
+		fields[f] = artist.FieldValueFromArtist(a, f)
+	}
+	return &UndoCapture{artistID: a.ID, path: a.Path, fields: fields, files: files}
 }
 
-// Expired reports whether the undo window has passed.
-func (e *UndoEntry) Expired() bool {
-	return time.Now().After(e.ExpiresAt)
+// Finish builds the UndoRecord for the change applied since the capture,
+// given the artist as it is now. Only what actually changed is kept: fields
+// whose value moved, files whose content moved, and the directory if it was
+// renamed. It returns (nil, nil) when the change touched nothing the capture
+// covered, since there is nothing to undo.
+func (c *UndoCapture) Finish(after *artist.Artist) (*UndoRecord, error) {
+	rec := &UndoRecord{ArtistID: c.artistID, ArtistName: after.Name}
+	for _, f := range artist.EditableFieldsList() {
+		if now := artist.FieldValueFromArtist(after, f); now != c.fields[f] {
+			rec.Fields = append(rec.Fields, FieldChange{Field: f, OldValue: c.fields[f], NewValue: now})
+		}
+	}
+	for _, snap := range c.files {
+		post, exists, err := readForUndo(snap.Path)
+		if err != nil {
+			return nil, err
+		}
+		if exists == snap.Exists && bytes.Equal(post, snap.Content) {
+			continue
+		}
+		if len(snap.Content) > MaxUndoFileBytes {
+			return nil, fmt.Errorf("capturing file for undo: %q is larger than %d bytes", snap.Path, MaxUndoFileBytes)
+		}
+		if exists {
+			snap.PostHash = hashContent(post)
+		}
+		rec.Files = append(rec.Files, snap)
+		rec.FilePaths = append(rec.FilePaths, snap.Path)
+	}
+	if after.Path != c.path && c.path != "" && after.Path != "" {
+		rec.OldPath, rec.NewPath = c.path, after.Path
+	}
+	if len(rec.Fields) == 0 && len(rec.Files) == 0 && rec.OldPath == "" {
+		return nil, nil
+	}
+	return rec, nil
 }
 
-// UndoStore holds short-lived undo entries for recently applied fixes.
-// It is safe for concurrent use.
+// UndoStore persists undo records and reverts them. Records survive restarts
+// and can be reverted in any order; a revert is refused (UndoConflictError)
+// only when a later change touched the same field, file or directory.
+//
+// It is safe for concurrent use, across instances too: the claim that stops
+// two reverts of one record is a conditional UPDATE, not a mutex.
 type UndoStore struct {
-	mu      sync.Mutex
-	entries map[string]*UndoEntry // keyed by undo ID
+	db      *sql.DB
+	artists *artist.Service
 }
 
-// NewUndoStore creates an empty UndoStore.
-func NewUndoStore() *UndoStore {
-	return &UndoStore{
-		entries: make(map[string]*UndoEntry),
+// NewUndoStore creates an UndoStore. artists applies field and directory
+// reverts; it is the same service every other artist write goes through, so
+// a revert produces ordinary "revert" history rows.
+func NewUndoStore(db *sql.DB, artists *artist.Service) *UndoStore {
+	return &UndoStore{db: db, artists: artists}
+}
+
+// Save stores rec, assigning its ID, status and creation time. The record and
+// its fields and files are written in one transaction.
+func (s *UndoStore) Save(ctx context.Context, rec *UndoRecord) error {
+	rec.ID = uuid.New().String()
+	rec.Status = UndoStatusApplied
+	rec.CreatedAt = time.Now().UTC()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning undo record transaction: %w", err)
 	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO undo_records (id, artist_id, violation_id, rule_id, bulk_job_id, summary, old_path, new_path, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, rec.ID, rec.ArtistID, rec.ViolationID, rec.RuleID, rec.BulkJobID, rec.Summary,
+		rec.OldPath, rec.NewPath, rec.Status, rec.CreatedAt.Format(undoTimeLayout)); err != nil {
+		return fmt.Errorf("inserting undo record: %w", err)
+	}
+	for _, f := range rec.Fields {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO undo_fields (undo_id, field, old_value, new_value) VALUES (?, ?, ?, ?)
+		`, rec.ID, f.Field, f.OldValue, f.NewValue); err != nil {
+			return fmt.Errorf("inserting undo field %s: %w", f.Field, err)
+		}
+	}
+	for _, f := range rec.Files {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO undo_files (undo_id, path, existed, content, post_hash) VALUES (?, ?, ?, ?, ?)
+		`, rec.ID, f.Path, dbutil.BoolToInt(f.Exists), f.Content, f.PostHash); err != nil {
+			return fmt.Errorf("inserting undo file %s: %w", f.Path, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing undo record: %w", err)
+	}
+	return nil
 }
 
-// Register adds an undo entry and returns its ID.
-// The entry expires after UndoWindowDuration.
-func (s *UndoStore) Register(violationID string, revert RevertFunc) string {
-	id := uuid.New().String()
-	entry := &UndoEntry{
-		ID:          id,
-		ViolationID: violationID,
-		ExpiresAt:   time.Now().Add(UndoWindowDuration),
-		Revert:      revert,
+// UndoFilter selects undo records for List. ArtistID and BulkJobID are
+// optional and combine with AND.
+type UndoFilter struct {
+	ArtistID  string
+	BulkJobID string
+	Limit     int
+	Offset    int
+}
+
+// List returns matching records newest first, with their fields and file
+// paths (not file contents), and the total match count.
+func (s *UndoStore) List(ctx context.Context, f UndoFilter) ([]UndoRecord, int, error) {
+	where := []string{"1=1"}
+	var args []any
+	if f.ArtistID != "" {
+		where = append(where, "u.artist_id = ?")
+		args = append(args, f.ArtistID)
+	}
+	if f.BulkJobID != "" {
+		where = append(where, "u.bulk_job_id = ?")
+		args = append(args, f.BulkJobID)
+	}
+	cond := strings.Join(where, " AND ")
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM undo_records u WHERE `+cond, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("counting undo records: %w", err)
+	}
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 50
 	}
-	s.mu.Lock()
-	s.entries[id] = entry
-	s.mu.Unlock()
-	return id
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+undoRecordColumns+`
+		FROM undo_records u LEFT JOIN artists a ON a.id = u.artist_id
+		WHERE `+cond+`
+		ORDER BY u.created_at DESC, u.id
+		LIMIT ? OFFSET ?
+	`, append(args, limit, f.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("listing undo records: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+
+	var recs []UndoRecord
+	for rows.Next() {
+		rec, err := scanUndoRecord(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		recs = append(recs, *rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterating undo records: %w", err)
+	}
+	for i := range recs {
+		if err := s.loadDetail(ctx, &recs[i], false); err != nil {
+			return nil, 0, err
+		}
+	}
+	return recs, total, nil
 }
 
-// Pop retrieves and removes an undo entry by ID.
-// Returns (entry, true) if found and not expired, (nil, false) otherwise.
-func (s *UndoStore) Pop(id string) (*UndoEntry, bool) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// Get returns one record with its fields and file snapshots, contents
+// included.
+func (s *UndoStore) Get(ctx context.Context, id string) (*UndoRecord, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+undoRecordColumns+`
+		FROM undo_records u LEFT JOIN artists a ON a.id = u.artist_id
+		WHERE u.id = ?
+	`, id)
+	rec, err := scanUndoRecord(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrUndoNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadDetail(ctx, rec, true); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
 
-	entry, ok := s.entries[id]
-	if !ok {
-		return nil, false
+// Revert restores the state captured in record id and marks it reverted. It
+// returns the record so the caller can follow up (reopen its violation,
+// refresh caches).
+//
+// Nothing is written unless every part of the record can be restored: if any
+// field, file or directory was changed again after this record's change, the
+// revert returns an *UndoConflictError and leaves everything as it is. A part
+// that already holds its pre-change state is skipped rather than treated as a
+// conflict, so a revert that failed halfway can simply be retried.
+func (s *UndoStore) Revert(ctx context.Context, id string) (*UndoRecord, error) {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE undo_records SET status = ? WHERE id = ? AND status = ?`,
+		UndoStatusReverting, id, UndoStatusApplied)
+	if err != nil {
+		return nil, fmt.Errorf("claiming undo record: %w", err)
 	}
-	delete(s.entries, id)
-	if entry.Expired() {
-		return nil, false
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := s.Get(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %s", ErrUndoReverted, id)
 	}
-	return entry, true
+
+	rec, err := s.revertClaimed(ctx, id)
+	if err != nil {
+		// Release the claim so the record can be retried once the conflict
+		// or failure is dealt with.
+		if _, rerr := s.db.ExecContext(context.WithoutCancel(ctx),
+			`UPDATE undo_records SET status = ? WHERE id = ?`, UndoStatusApplied, id); rerr != nil {
+			return nil, errors.Join(err, fmt.Errorf("releasing undo claim: %w", rerr))
+		}
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	if _, err := s.db.ExecContext(ctx,
+		`UPDATE undo_records SET status = ?, reverted_at = ? WHERE id = ?`,
+		UndoStatusReverted, now.Format(undoTimeLayout), id); err != nil {
+		return nil, fmt.Errorf("marking undo record reverted: %w", err)
+	}
+	rec.Status = UndoStatusReverted
+	rec.RevertedAt = &now
+	return rec, nil
 }
 
-// Expire removes all entries whose undo window has passed.
-// Call periodically to prevent unbounded memory growth.
-func (s *UndoStore) Expire() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	for id, entry := range s.entries {
-		if entry.Expired() {
-			delete(s.entries, id)
+// revertClaimed does the work of Revert for a record this caller has claimed.
+func (s *UndoStore) revertClaimed(ctx context.Context, id string) (*UndoRecord, error) {
+	rec, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.artists.GetByID(ctx, rec.ArtistID)
+	if err != nil {
+		return nil, fmt.Errorf("loading artist for undo: %w", err)
+	}
+
+	// Check every part before touching any of them.
+	var conflicts []string
+	var fields []FieldChange
+	for _, f := range rec.Fields {
+		switch artist.FieldValueFromArtist(current, f.Field) {
+		case f.OldValue:
+			// Already restored.
+		case f.NewValue:
+			fields = append(fields, f)
+		default:
+			conflicts = append(conflicts, "field "+f.Field+" was changed again")
 		}
 	}
+	var files []FileSnapshot
+	for _, snap := range rec.Files {
+		content, exists, err := readForUndo(snap.Path)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case exists == snap.Exists && bytes.Equal(content, snap.Content):
+			// Already restored.
+		case (exists && hashContent(content) == snap.PostHash) || (!exists && snap.PostHash == ""):
+			files = append(files, snap)
+		default:
+			conflicts = append(conflicts, "file "+snap.Path+" was changed again")
+		}
+	}
+	renameBack := false
+	if rec.OldPath != "" {
+		switch current.Path {
+		case rec.OldPath:
+			// Already restored.
+		case rec.NewPath:
+			if filepath.Dir(rec.OldPath) != filepath.Dir(rec.NewPath) {
+				conflicts = append(conflicts, "directory was moved to a different parent")
+			} else {
+				renameBack = true
+			}
+		default:
+			conflicts = append(conflicts, "directory was renamed again")
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, &UndoConflictError{Conflicts: conflicts}
+	}
+
+	// Directory first: the file snapshots of a record that also renamed the
+	// directory were taken under the old path, which is where they belong.
+	if renameBack {
+		if _, _, err := s.artists.RenameDirectory(ctx, rec.ArtistID, filepath.Base(rec.OldPath)); err != nil {
+			return nil, fmt.Errorf("reverting directory rename: %w", err)
+		}
+	}
+	if len(files) > 0 {
+		if err := MultiFileRevert(files)(ctx); err != nil {
+			return nil, err
+		}
+	}
+	// Field reverts are an operator act, like POST /history/{id}/revert: they
+	// are recorded as "revert" history and are not refused by field locks.
+	fctx := artist.ContextWithSource(ctx, "revert")
+	for _, f := range fields {
+		if err := s.revertField(fctx, rec.ArtistID, f); err != nil {
+			return nil, err
+		}
+	}
+	return rec, nil
+}
+
+func (s *UndoStore) revertField(ctx context.Context, artistID string, f FieldChange) error {
+	var err error
+	switch {
+	case artist.IsProviderIDField(f.Field) && f.OldValue == "":
+		err = s.artists.ClearProviderField(artist.ContextWithLockOverride(ctx, f.Field), artistID, f.Field)
+	case artist.IsProviderIDField(f.Field):
+		err = s.artists.UpdateProviderField(artist.ContextWithLockOverride(ctx, f.Field), artistID, f.Field, f.OldValue)
+	case f.OldValue == "":
+		_, err = s.artists.ClearField(ctx, artistID, f.Field)
+	default:
+		_, err = s.artists.UpdateField(ctx, artistID, f.Field, f.OldValue)
+	}
+	if err != nil {
+		return fmt.Errorf("reverting field %s: %w", f.Field, err)
+	}
+	return nil
+}
+
+// UndoBatchResult summarizes RevertBulkJob.
+type UndoBatchResult struct {
+	Reverted  int      `json:"reverted"`
+	Conflicts int      `json:"conflicts"`
+	Failed    int      `json:"failed"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// maxBatchErrors bounds UndoBatchResult.Errors so an 800-artist job with a
+// systemic failure does not produce an 800-line response.
+const maxBatchErrors = 20
+
+// RevertBulkJob reverts every still-applied record of a bulk job, newest
+// first. A record that conflicts or fails is counted and skipped; the rest
+// are still reverted, so one artist edited since the job does not block
+// unwinding the other several hundred.
+func (s *UndoStore) RevertBulkJob(ctx context.Context, jobID string) (UndoBatchResult, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id FROM undo_records WHERE bulk_job_id = ? AND status = ? ORDER BY created_at DESC, id
+	`, jobID, UndoStatusApplied)
+	if err != nil {
+		return UndoBatchResult{}, fmt.Errorf("listing bulk job undo records: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return UndoBatchResult{}, fmt.Errorf("scanning undo record id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return UndoBatchResult{}, fmt.Errorf("iterating bulk job undo records: %w", err)
+	}
+
+	var result UndoBatchResult
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		_, err := s.Revert(ctx, id)
+		var conflict *UndoConflictError
+		switch {
+		case err == nil:
+			result.Reverted++
+			continue
+		case errors.Is(err, ErrUndoReverted):
+			// Reverted individually in the meantime.
+			continue
+		case errors.As(err, &conflict):
+			result.Conflicts++
+		default:
+			result.Failed++
+		}
+		if len(result.Errors) < maxBatchErrors {
+			result.Errors = append(result.Errors, id+": "+err.Error())
+		}
+	}
+	return result, nil
+}
+
+// Prune deletes records created before cutoff, reverted or not, and returns
+// how many were removed.
+func (s *UndoStore) Prune(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM undo_records WHERE created_at < ?`,
+		cutoff.UTC().Format(undoTimeLayout))
+	if err != nil {
+		return 0, fmt.Errorf("pruning undo records: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
 }
 
-// StartCleanup launches a background goroutine that calls Expire every 60 seconds
-// to prevent unbounded memory growth. It returns immediately; the goroutine stops
-// when ctx is canceled.
+// StartCleanup releases revert claims left behind by a previous process (a
+// crash mid-revert would otherwise leave a record "reverting" forever) and
+// launches a background goroutine that prunes records older than
+// UndoRetention once an hour. Call it once at startup, before any revert can
+// run. It returns immediately; the goroutine stops when ctx is canceled.
 func (s *UndoStore) StartCleanup(ctx context.Context) {
+	_, _ = s.db.ExecContext(ctx, `UPDATE undo_records SET status = ? WHERE status = ?`,
+		UndoStatusApplied, UndoStatusReverting)
 	go func() {
-		ticker := time.NewTicker(60 * time.Second)
+		ticker := time.NewTicker(time.Hour)
 		defer ticker.Stop()
 		for {
 			select {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				s.Expire()
+				_, _ = s.Prune(ctx, time.Now().Add(-UndoRetention))
 			}
 		}
 	}()
 }
 
-// ForceExpire immediately expires the entry with the given ID.
-// This is intended for use in tests only.
-func (s *UndoStore) ForceExpire(id string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	if entry, ok := s.entries[id]; ok {
-		entry.ExpiresAt = time.Now().Add(-time.Second)
+// undoTimeLayout is RFC 3339 with fixed-width nanoseconds. RFC3339Nano trims
+// trailing zeros, which breaks the lexical ordering ORDER BY created_at and
+// Prune's comparison rely on; a bulk job writes many records per second.
+const undoTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+const undoRecordColumns = `u.id, u.artist_id, COALESCE(a.name, ''), u.violation_id, u.rule_id, u.bulk_job_id,
+		u.summary, u.old_path, u.new_path, u.status, u.created_at, u.reverted_at`
+
+func scanUndoRecord(row interface{ Scan(...any) error }) (*UndoRecord, error) {
+	var rec UndoRecord
+	var createdAt string
+	var revertedAt sql.NullString
+	if err := row.Scan(&rec.ID, &rec.ArtistID, &rec.ArtistName, &rec.ViolationID, &rec.RuleID,
+		&rec.BulkJobID, &rec.Summary, &rec.OldPath, &rec.NewPath, &rec.Status,
+		&createdAt, &revertedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("scanning undo record: %w", err)
+	}
+	rec.CreatedAt = dbutil.ParseTime(createdAt)
+	if revertedAt.Valid {
+		t := dbutil.ParseTime(revertedAt.String)
+		rec.RevertedAt = &t
 	}
+	return &rec, nil
+}
+
+// loadDetail fills rec's fields and files. withContent controls whether file
+// contents are read; listings only need the paths.
+func (s *UndoStore) loadDetail(ctx context.Context, rec *UndoRecord, withContent bool) error {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT field, old_value, new_value FROM undo_fields WHERE undo_id = ? ORDER BY field`, rec.ID)
+	if err != nil {
+		return fmt.Errorf("loading undo fields: %w", err)
+	}
+	rec.Fields = []FieldChange{}
+	for rows.Next() {
+		var f FieldChange
+		if err := rows.Scan(&f.Field, &f.OldValue, &f.NewValue); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("scanning undo field: %w", err)
+		}
+		rec.Fields = append(rec.Fields, f)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating undo fields: %w", err)
+	}
+
+	content := "NULL"
+	if withContent {
+		content = "content"
+	}
+	rows, err = s.db.QueryContext(ctx,
+		`SELECT path, existed, `+content+`, post_hash FROM undo_files WHERE undo_id = ? ORDER BY path`, rec.ID)
+	if err != nil {
+		return fmt.Errorf("loading undo files: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck
+	rec.FilePaths = []string{}
+	for rows.Next() {
+		var f FileSnapshot
+		var existed int
+		if err := rows.Scan(&f.Path, &existed, &f.Content, &f.PostHash); err != nil {
+			return fmt.Errorf("scanning undo file: %w", err)
+		}
+		f.Exists = existed != 0
+		if withContent {
+			rec.Files = append(rec.Files, f)
+		}
+		rec.FilePaths = append(rec.FilePaths, f.Path)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating undo files: %w", err)
+	}
+	return nil
+}
+
+// readForUndo reads path, reporting a missing file as (nil, false, nil).
+func readForUndo(path string) ([]byte, bool, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is from trusted artist directory
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("reading %q for undo: %w", path, err)
+	}
+	return data, true, nil
+}
+
+func hashContent(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
 }
 
 // FileSnapshot holds the pre-fix state of a single file on disk.
@@ -125,20 +644,22 @@ type FileSnapshot struct {
 	Path    string // absolute path to the file
 	Exists  bool   // whether the file existed before the fix
 	Content []byte // file content captured before the fix (nil if Exists is false)
+
+	// PostHash is the SHA-256 of the file as the fix left it, or "" when the
+	// fix left no file. Set by UndoCapture.Finish; a revert only overwrites a
+	// file that still matches it.
+	PostHash string
 }
 
 // CaptureFile reads the current content of a file for undo purposes.
 // If the file does not exist, returns a snapshot with Exists=false.
 // Read errors (permissions, I/O) are returned as errors.
 func CaptureFile(path string) (FileSnapshot, error) {
-	data, err := os.ReadFile(path) //nolint:gosec // G304: path is from trusted artist directory
-	if os.IsNotExist(err) {
-		return FileSnapshot{Path: path, Exists: false}, nil
-	}
+	data, exists, err := readForUndo(path)
 	if err != nil {
 		return FileSnapshot{}, fmt.Errorf("capturing file for undo: %w", err)
 	}
-	return FileSnapshot{Path: path, Exists: true, Content: data}, nil
+	return FileSnapshot{Path: path, Exists: exists, Content: data}, nil
 }
 
 // FileRevert returns a RevertFunc that restores a file to its pre-fix state.
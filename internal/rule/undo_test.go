@@ -2,134 +2,244 @@ package rule
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
 	"testing"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/artist"
 )
 
-func TestUndoStore_RegisterAndPop(t *testing.T) {
-	store := NewUndoStore()
-	called := false
-	revert := func(_ context.Context) error {
-		called = true
-		return nil
-	}
+// newUndoTestStore returns an UndoStore over a fresh database and an artist
+// whose directory exists, so records can cover both fields and files.
+func newUndoTestStore(t *testing.T) (*UndoStore, *artist.Service, *artist.Artist) {
+	t.Helper()
+	db := setupTestDB(t)
+	artistSvc := artist.NewService(db)
+	a := &artist.Artist{Name: "Undo Artist", SortName: "Undo Artist", Type: "group", Path: t.TempDir()}
+	if err := artistSvc.Create(context.Background(), a); err != nil {
+		t.Fatalf("creating artist: %v", err)
+	}
+	return NewUndoStore(db, artistSvc), artistSvc, a
+}
 
-	id := store.Register("v-1", revert)
-	if id == "" {
-		t.Fatal("expected non-empty undo ID")
+// applyTestChange mimics a fix: it captures a, sets its biography and
+// rewrites its artist.nfo, then saves the resulting undo record.
+func applyTestChange(t *testing.T, store *UndoStore, svc *artist.Service, a *artist.Artist, bio, nfo string) *UndoRecord {
+	t.Helper()
+	ctx := context.Background()
+	before, err := svc.GetByID(ctx, a.ID)
+	if err != nil {
+		t.Fatalf("loading artist: %v", err)
 	}
+	nfoPath := filepath.Join(a.Path, "artist.nfo")
+	snap, err := CaptureFile(nfoPath)
+	if err != nil {
+		t.Fatalf("CaptureFile: %v", err)
+	}
+	capture := CaptureUndo(before, []FileSnapshot{snap})
 
-	entry, ok := store.Pop(id)
-	if !ok {
-		t.Fatal("expected Pop to return entry")
+	if _, err := svc.UpdateField(ctx, a.ID, "biography", bio); err != nil {
+		t.Fatalf("UpdateField: %v", err)
 	}
-	if entry.ViolationID != "v-1" {
-		t.Errorf("ViolationID = %q, want %q", entry.ViolationID, "v-1")
+	if err := os.WriteFile(nfoPath, []byte(nfo), 0o644); err != nil {
+		t.Fatalf("writing nfo: %v", err)
 	}
 
-	// Execute the revert function to verify it works.
-	if err := entry.Revert(context.Background()); err != nil {
-		t.Fatalf("revert error: %v", err)
+	after, err := svc.GetByID(ctx, a.ID)
+	if err != nil {
+		t.Fatalf("loading artist: %v", err)
 	}
-	if !called {
-		t.Error("expected revert function to have been called")
+	rec, err := capture.Finish(after)
+	if err != nil {
+		t.Fatalf("Finish: %v", err)
 	}
-
-	// Popping the same ID again should return nothing (already consumed).
-	_, ok2 := store.Pop(id)
-	if ok2 {
-		t.Error("expected second Pop to return false (already consumed)")
+	if rec == nil {
+		t.Fatal("Finish returned no record for a change")
 	}
+	if err := store.Save(ctx, rec); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	return rec
 }
 
-func TestUndoStore_PopMissing(t *testing.T) {
-	store := NewUndoStore()
-	_, ok := store.Pop("nonexistent-id")
-	if ok {
-		t.Error("expected Pop of nonexistent ID to return false")
+func TestUndoCapture_FinishKeepsOnlyChanges(t *testing.T) {
+	a := &artist.Artist{ID: "a1", Name: "A", Biography: "old", Path: t.TempDir()}
+	capture := CaptureUndo(a, nil)
+
+	same := *a
+	if rec, err := capture.Finish(&same); err != nil || rec != nil {
+		t.Fatalf("Finish(unchanged) = %+v, %v; want nil, nil", rec, err)
+	}
+
+	changed := *a
+	changed.Biography = "new"
+	rec, err := capture.Finish(&changed)
+	if err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+	if len(rec.Fields) != 1 || rec.Fields[0] != (FieldChange{Field: "biography", OldValue: "old", NewValue: "new"}) {
+		t.Errorf("Fields = %+v, want only the biography change", rec.Fields)
 	}
 }
 
-func TestUndoStore_PopExpired(t *testing.T) {
-	store := NewUndoStore()
+func TestUndoStore_SaveGetList(t *testing.T) {
+	store, svc, a := newUndoTestStore(t)
+	ctx := context.Background()
 
-	revert := func(_ context.Context) error { return nil }
-	id := store.Register("v-exp", revert)
+	first := applyTestChange(t, store, svc, a, "one", "<artist>one</artist>")
+	second := applyTestChange(t, store, svc, a, "two", "<artist>two</artist>")
 
-	// Force the entry to expire.
-	store.ForceExpire(id)
+	got, err := store.Get(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Status != UndoStatusApplied || len(got.Fields) != 1 || len(got.Files) != 1 {
+		t.Fatalf("Get = %+v, want an applied record with one field and one file", got)
+	}
+	if got.Files[0].Exists {
+		t.Error("first change created artist.nfo; snapshot should record it as absent")
+	}
 
-	_, ok := store.Pop(id)
-	if ok {
-		t.Error("expected Pop of expired entry to return false")
+	records, total, err := store.List(ctx, UndoFilter{ArtistID: a.ID, Limit: 10})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if total != 2 || len(records) != 2 || records[0].ID != second.ID {
+		t.Errorf("List = %d records (total %d), want 2 newest first", len(records), total)
 	}
-}
 
-func TestUndoStore_Expire(t *testing.T) {
-	store := NewUndoStore()
+	if _, err := store.Get(ctx, "missing"); !errors.Is(err, ErrUndoNotFound) {
+		t.Errorf("Get(missing) err = %v, want ErrUndoNotFound", err)
+	}
+}
 
-	revert := func(_ context.Context) error { return nil }
-	id1 := store.Register("v-1", revert)
-	id2 := store.Register("v-2", revert)
+// TestUndoStore_RevertOutOfOrder checks that an older record can be reverted
+// after a newer one that touched the same field and file, once the newer one
+// has put them back.
+func TestUndoStore_RevertOutOfOrder(t *testing.T) {
+	store, svc, a := newUndoTestStore(t)
+	ctx := context.Background()
+	nfoPath := filepath.Join(a.Path, "artist.nfo")
 
-	// Force the first entry to expire.
-	store.ForceExpire(id1)
+	first := applyTestChange(t, store, svc, a, "one", "<artist>one</artist>")
+	second := applyTestChange(t, store, svc, a, "two", "<artist>two</artist>")
 
-	store.Expire()
+	// The older record conflicts while the newer change is still in place.
+	var conflict *UndoConflictError
+	if _, err := store.Revert(ctx, first.ID); !errors.As(err, &conflict) {
+		t.Fatalf("Revert(first) err = %v, want UndoConflictError", err)
+	}
+	if got, _ := os.ReadFile(nfoPath); string(got) != "<artist>two</artist>" {
+		t.Errorf("nfo after refused revert = %q, want it untouched", got)
+	}
 
-	store.mu.Lock()
-	_, has1 := store.entries[id1]
-	_, has2 := store.entries[id2]
-	store.mu.Unlock()
+	if _, err := store.Revert(ctx, second.ID); err != nil {
+		t.Fatalf("Revert(second): %v", err)
+	}
+	if _, err := store.Revert(ctx, first.ID); err != nil {
+		t.Fatalf("Revert(first) after second: %v", err)
+	}
 
-	if has1 {
-		t.Error("expected expired entry to be removed")
+	after, _ := svc.GetByID(ctx, a.ID)
+	if after.Biography != "" {
+		t.Errorf("biography = %q, want it cleared", after.Biography)
 	}
-	if !has2 {
-		t.Error("expected non-expired entry to remain")
+	if _, err := os.Stat(nfoPath); !os.IsNotExist(err) {
+		t.Errorf("artist.nfo should be removed, stat err = %v", err)
+	}
+	if _, err := store.Revert(ctx, first.ID); !errors.Is(err, ErrUndoReverted) {
+		t.Errorf("second Revert(first) err = %v, want ErrUndoReverted", err)
 	}
 }
 
-func TestUndoStore_ConcurrentAccess(t *testing.T) {
-	store := NewUndoStore()
-	var wg sync.WaitGroup
-	ids := make([]string, 20)
+func TestUndoStore_ConcurrentRevert(t *testing.T) {
+	store, svc, a := newUndoTestStore(t)
+	rec := applyTestChange(t, store, svc, a, "bio", "<artist/>")
 
-	// Register concurrently.
-	for i := range 20 {
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
 		wg.Add(1)
 		go func(i int) {
 			defer wg.Done()
-			ids[i] = store.Register("v-concurrent", func(_ context.Context) error { return nil })
+			_, errs[i] = store.Revert(context.Background(), rec.ID)
 		}(i)
 	}
 	wg.Wait()
 
-	// Pop concurrently.
-	results := make([]bool, 20)
-	for i := range 20 {
-		wg.Add(1)
-		go func(i int) {
-			defer wg.Done()
-			_, ok := store.Pop(ids[i])
-			results[i] = ok
-		}(i)
+	succeeded := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, ErrUndoReverted):
+		default:
+			t.Errorf("unexpected revert error: %v", err)
+		}
 	}
-	wg.Wait()
+	if succeeded != 1 {
+		t.Errorf("%d reverts succeeded, want exactly 1", succeeded)
+	}
+}
 
-	// All should have been popped once (no double-pops since IDs are unique).
-	count := 0
-	for _, ok := range results {
-		if ok {
-			count++
+func TestUndoStore_RevertBulkJob(t *testing.T) {
+	store, svc, _ := newUndoTestStore(t)
+	ctx := context.Background()
+
+	var ids []string
+	for _, name := range []string{"Bulk A", "Bulk B"} {
+		a := &artist.Artist{Name: name, SortName: name, Type: "group"}
+		if err := svc.Create(ctx, a); err != nil {
+			t.Fatalf("creating artist: %v", err)
+		}
+		if _, err := svc.UpdateField(ctx, a.ID, "biography", "fetched"); err != nil {
+			t.Fatalf("UpdateField: %v", err)
+		}
+		rec := &UndoRecord{ArtistID: a.ID, BulkJobID: "job-1", Fields: []FieldChange{{Field: "biography", NewValue: "fetched"}}}
+		if err := store.Save(ctx, rec); err != nil {
+			t.Fatalf("Save: %v", err)
 		}
+		ids = append(ids, a.ID)
+	}
+
+	result, err := store.RevertBulkJob(ctx, "job-1")
+	if err != nil {
+		t.Fatalf("RevertBulkJob: %v", err)
+	}
+	if result.Reverted != 2 || result.Conflicts != 0 || result.Failed != 0 {
+		t.Errorf("result = %+v, want 2 reverted", result)
+	}
+	for _, id := range ids {
+		if a, _ := svc.GetByID(ctx, id); a.Biography != "" {
+			t.Errorf("artist %s biography = %q, want it cleared", id, a.Biography)
+		}
+	}
+
+	// A second run finds nothing left to revert.
+	result, err = store.RevertBulkJob(ctx, "job-1")
+	if err != nil || result.Reverted != 0 {
+		t.Errorf("second RevertBulkJob = %+v, %v; want nothing reverted", result, err)
+	}
+}
+
+func TestUndoStore_Prune(t *testing.T) {
+	store, svc, a := newUndoTestStore(t)
+	ctx := context.Background()
+	applyTestChange(t, store, svc, a, "bio", "<artist/>")
+
+	if n, err := store.Prune(ctx, time.Now().Add(-time.Hour)); err != nil || n != 0 {
+		t.Fatalf("Prune(past cutoff) = %d, %v; want 0", n, err)
+	}
+	if n, err := store.Prune(ctx, time.Now().Add(time.Hour)); err != nil || n != 1 {
+		t.Fatalf("Prune(future cutoff) = %d, %v; want 1", n, err)
 	}
-	if count != 20 {
-		t.Errorf("expected 20 successful pops, got %d", count)
+	if _, total, _ := store.List(ctx, UndoFilter{ArtistID: a.ID, Limit: 10}); total != 0 {
+		t.Errorf("records after prune = %d, want 0", total)
 	}
 }
 
@@ -0,0 +1,281 @@
+package ruleexpr
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// node is a type-checked expression tree node.
+type node interface {
+	typ() Type
+	eval(env Env) (any, error)
+}
+
+type litNode struct {
+	v any
+	t Type
+}
+
+func (n *litNode) typ() Type             { return n.t }
+func (n *litNode) eval(Env) (any, error) { return n.v, nil }
+
+type fieldNode struct {
+	name string
+	t    Type
+}
+
+func (n *fieldNode) typ() Type { return n.t }
+
+// eval reads the field from env. A missing field, or one holding a value of
+// the wrong Go type, reads as the zero value: the caller builds Env from a
+// record, and a record that lacks a value for a field is exactly the case
+// rules like `born == ""` exist to find.
+func (n *fieldNode) eval(env Env) (any, error) {
+	v := env[n.name]
+	switch n.t {
+	case TypeString:
+		s, _ := v.(string)
+		return s, nil
+	case TypeNumber:
+		f, _ := v.(float64)
+		return f, nil
+	case TypeBool:
+		b, _ := v.(bool)
+		return b, nil
+	default:
+		l, _ := v.([]string)
+		return l, nil
+	}
+}
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+func (n *unaryNode) typ() Type {
+	if n.op == "-" {
+		return TypeNumber
+	}
+	return TypeBool
+}
+
+func (n *unaryNode) eval(env Env) (any, error) {
+	v, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "-" {
+		return -v.(float64), nil
+	}
+	return !v.(bool), nil
+}
+
+type binaryNode struct {
+	op   string
+	l, r node
+	t    Type
+}
+
+func (n *binaryNode) typ() Type { return n.t }
+
+func (n *binaryNode) eval(env Env) (any, error) {
+	l, err := n.l.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	// Short-circuit, so `fanart_count > 0 && 100 / fanart_count < 2` is safe.
+	switch n.op {
+	case "&&":
+		if !l.(bool) {
+			return false, nil
+		}
+	case "||":
+		if l.(bool) {
+			return true, nil
+		}
+	}
+	r, err := n.r.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "&&", "||":
+		return r.(bool), nil
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	case "in":
+		if list, ok := r.([]string); ok {
+			return containsFold(list, l.(string)), nil
+		}
+		return strings.Contains(r.(string), l.(string)), nil
+	}
+
+	if ls, ok := l.(string); ok {
+		rs := r.(string)
+		switch n.op {
+		case "+":
+			return ls + rs, nil
+		case "<":
+			return ls < rs, nil
+		case "<=":
+			return ls <= rs, nil
+		case ">":
+			return ls > rs, nil
+		default: // ">="
+			return ls >= rs, nil
+		}
+	}
+
+	lf, rf := l.(float64), r.(float64)
+	switch n.op {
+	case "<":
+		return lf < rf, nil
+	case "<=":
+		return lf <= rf, nil
+	case ">":
+		return lf > rf, nil
+	case ">=":
+		return lf >= rf, nil
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	default: // "/"
+		if rf == 0 {
+			return nil, ErrDivisionByZero
+		}
+		return lf / rf, nil
+	}
+}
+
+type callNode struct {
+	name string
+	fn   builtin
+	args []node
+	t    Type
+	re   *regexp.Regexp // matches only; compiled once by the parser
+}
+
+func (n *callNode) typ() Type { return n.t }
+
+func (n *callNode) eval(env Env) (any, error) {
+	vals := make([]any, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		vals[i] = v
+	}
+	if n.re != nil {
+		return n.re.MatchString(vals[0].(string)), nil
+	}
+	return n.fn.call(vals), nil
+}
+
+// containsFold reports list membership case-insensitively. Genre and style
+// tags are not consistently cased across providers ("Hip Hop", "hip hop"),
+// and a rule author should not have to enumerate the spellings.
+func containsFold(list []string, item string) bool {
+	return slices.ContainsFunc(list, func(s string) bool { return strings.EqualFold(s, item) })
+}
+
+// walk visits n and every node beneath it.
+func walk(n node, fn func(node)) {
+	fn(n)
+	switch x := n.(type) {
+	case *unaryNode:
+		walk(x.x, fn)
+	case *binaryNode:
+		walk(x.l, fn)
+		walk(x.r, fn)
+	case *callNode:
+		for _, a := range x.args {
+			walk(a, fn)
+		}
+	}
+}
+
+// param describes the types a builtin accepts in one argument position.
+type param []Type
+
+func (p param) accepts(t Type) bool { return slices.Contains(p, t) }
+
+func (p param) String() string {
+	names := make([]string, len(p))
+	for i, t := range p {
+		names[i] = t.String()
+	}
+	return strings.Join(names, " or ")
+}
+
+// builtin is one of the functions the language provides.
+type builtin struct {
+	params []param
+	result Type
+	call   func(args []any) any
+}
+
+var (
+	str     = param{TypeString}
+	strList = param{TypeString, TypeList}
+)
+
+// builtins is the complete function set. It is small on purpose; every entry
+// is documented in the rules reference, and each one is a pure function of
+// its arguments.
+var builtins = map[string]builtin{
+	// len(s) is the length of a string in characters; len(l) the number of
+	// items in a list.
+	"len": {params: []param{strList}, result: TypeNumber, call: func(a []any) any {
+		if l, ok := a[0].([]string); ok {
+			return float64(len(l))
+		}
+		return float64(len([]rune(a[0].(string))))
+	}},
+	"lower": {params: []param{str}, result: TypeString, call: func(a []any) any {
+		return strings.ToLower(a[0].(string))
+	}},
+	"upper": {params: []param{str}, result: TypeString, call: func(a []any) any {
+		return strings.ToUpper(a[0].(string))
+	}},
+	"trim": {params: []param{str}, result: TypeString, call: func(a []any) any {
+		return strings.TrimSpace(a[0].(string))
+	}},
+	// contains(s, sub) is a substring test; contains(l, item) is membership,
+	// the same test as `item in l`.
+	"contains": {params: []param{strList, str}, result: TypeBool, call: func(a []any) any {
+		if l, ok := a[0].([]string); ok {
+			return containsFold(l, a[1].(string))
+		}
+		return strings.Contains(a[0].(string), a[1].(string))
+	}},
+	"startsWith": {params: []param{str, str}, result: TypeBool, call: func(a []any) any {
+		return strings.HasPrefix(a[0].(string), a[1].(string))
+	}},
+	"endsWith": {params: []param{str, str}, result: TypeBool, call: func(a []any) any {
+		return strings.HasSuffix(a[0].(string), a[1].(string))
+	}},
+	// matches(s, pattern) is a Go regular-expression search. The pattern
+	// must be a literal so it is compiled (and validated) once, at save time.
+	"matches": {params: []param{str, str}, result: TypeBool, call: func([]any) any {
+		return false // replaced by callNode.re; see parseCall
+	}},
+}
+
+// Functions returns the names of the built-in functions, sorted.
+func Functions() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,150 @@
+package ruleexpr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokKind int
+
+const (
+	tokEOF tokKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokKind
+	text string // identifier, operator, or the decoded string literal
+	num  float64
+	pos  int // 1-based column
+}
+
+// describe renders the token for an "unexpected ..." error message.
+func (t token) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// operators lists the multi- and single-character operators, longest first so
+// "<=" is not lexed as "<" followed by "=".
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "+", "-", "*", "/"}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		pos := i + 1
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			toks = append(toks, token{kind: tokLParen, text: "(", pos: pos})
+			i++
+		case c == ')':
+			toks = append(toks, token{kind: tokRParen, text: ")", pos: pos})
+			i++
+		case c == ',':
+			toks = append(toks, token{kind: tokComma, text: ",", pos: pos})
+			i++
+		case c == '"' || c == '\'':
+			s, n, err := lexString(src[i:], pos)
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, token{kind: tokString, text: s, pos: pos})
+			i += n
+		case c >= '0' && c <= '9':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.') {
+				j++
+			}
+			f, err := strconv.ParseFloat(src[i:j], 64)
+			if err != nil {
+				return nil, &Error{Pos: pos, Msg: fmt.Sprintf("malformed number %q", src[i:j])}
+			}
+			toks = append(toks, token{kind: tokNumber, text: src[i:j], num: f, pos: pos})
+			i = j
+		case isIdentStart(c):
+			j := i
+			for j < len(src) && isIdentPart(src[j]) {
+				j++
+			}
+			toks = append(toks, token{kind: tokIdent, text: src[i:j], pos: pos})
+			i = j
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				if c == '=' {
+					return nil, &Error{Pos: pos, Msg: `unexpected "="; use "==" to compare`}
+				}
+				return nil, &Error{Pos: pos, Msg: fmt.Sprintf("unexpected character %q", c)}
+			}
+			toks = append(toks, token{kind: tokOp, text: op, pos: pos})
+			i += len(op)
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(src) + 1}), nil
+}
+
+// lexString decodes a quoted string literal at the start of s and returns its
+// value and the number of source bytes it occupied. Both quote styles accept
+// the escapes \\, \", \', \n and \t; anything else after a backslash is an
+// error rather than a silent literal, so a Windows path pasted into a rule
+// fails loudly instead of matching nothing.
+func lexString(s string, pos int) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\\':
+			if i+1 >= len(s) {
+				break
+			}
+			i++
+			switch s[i] {
+			case '\\', '"', '\'':
+				b.WriteByte(s[i])
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				return "", 0, &Error{Pos: pos + i - 1, Msg: fmt.Sprintf("unknown escape \\%c in string", s[i])}
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, &Error{Pos: pos, Msg: "unterminated string"}
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
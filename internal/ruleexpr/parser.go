@@ -0,0 +1,263 @@
+package ruleexpr
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// The grammar, lowest precedence first:
+//
+//	expr       = or
+//	or         = and { "||" and }
+//	and        = equality { "&&" equality }
+//	equality   = comparison { ("==" | "!=") comparison }
+//	comparison = additive { ("<" | "<=" | ">" | ">=" | "in") additive }
+//	additive   = term { ("+" | "-") term }
+//	term       = unary { ("*" | "/") unary }
+//	unary      = ("!" | "-") unary | primary
+//	primary    = number | string | "true" | "false" | ident | call | "(" expr ")"
+//	call       = ident "(" [ expr { "," expr } ] ")"
+//
+// Each production type-checks as it builds, so by the time Compile returns the
+// tree is known to be well typed and evaluation needs no type checks of its
+// own.
+
+type parser struct {
+	toks   []token
+	pos    int
+	depth  int
+	schema Schema
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// acceptOp consumes the next token when it is one of ops.
+func (p *parser) acceptOp(ops ...string) (token, bool) {
+	t := p.peek()
+	isOp := t.kind == tokOp || (t.kind == tokIdent && t.text == "in")
+	if !isOp {
+		return t, false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return t, true
+		}
+	}
+	return t, false
+}
+
+func (p *parser) enter(pos int) error {
+	p.depth++
+	if p.depth > maxDepth {
+		return &Error{Pos: pos, Msg: "expression is nested too deeply"}
+	}
+	return nil
+}
+
+func (p *parser) parseExpr() (node, error) {
+	return p.parseBinary(0)
+}
+
+// levels lists the binary operators by precedence level, lowest first.
+var levels = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/"},
+}
+
+func (p *parser) parseBinary(level int) (node, error) {
+	if level == len(levels) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOp(levels[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left, err = newBinary(op, left, right)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	op, ok := p.acceptOp("!", "-")
+	if !ok {
+		return p.parsePrimary()
+	}
+	if err := p.enter(op.pos); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	want := TypeBool
+	if op.text == "-" {
+		want = TypeNumber
+	}
+	if x.typ() != want {
+		return nil, &Error{Pos: op.pos, Msg: fmt.Sprintf("%q needs a %s, got a %s", op.text, want, x.typ())}
+	}
+	return &unaryNode{op: op.text, x: x}, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return &litNode{v: t.num, t: TypeNumber}, nil
+	case tokString:
+		return &litNode{v: t.text, t: TypeString}, nil
+	case tokLParen:
+		if err := p.enter(t.pos); err != nil {
+			return nil, err
+		}
+		defer func() { p.depth-- }()
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if c := p.next(); c.kind != tokRParen {
+			return nil, &Error{Pos: c.pos, Msg: fmt.Sprintf("expected \")\", got %s", c.describe())}
+		}
+		return x, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return &litNode{v: true, t: TypeBool}, nil
+		case "false":
+			return &litNode{v: false, t: TypeBool}, nil
+		}
+		if p.peek().kind == tokLParen {
+			return p.parseCall(t)
+		}
+		ft, ok := p.schema[t.text]
+		if !ok {
+			return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unknown field %q", t.text)}
+		}
+		return &fieldNode{name: t.text, t: ft}, nil
+	default:
+		return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unexpected %s", t.describe())}
+	}
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := builtins[name.text]
+	if !ok {
+		return nil, &Error{Pos: name.pos, Msg: fmt.Sprintf("unknown function %q", name.text)}
+	}
+	if err := p.enter(name.pos); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+	p.next() // "("
+
+	var args []node
+	if p.peek().kind != tokRParen {
+		for {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if c := p.next(); c.kind != tokRParen {
+		return nil, &Error{Pos: c.pos, Msg: fmt.Sprintf("expected \")\" or \",\", got %s", c.describe())}
+	}
+
+	if len(args) != len(fn.params) {
+		return nil, &Error{Pos: name.pos, Msg: fmt.Sprintf("%s takes %d argument(s), got %d", name.text, len(fn.params), len(args))}
+	}
+	for i, arg := range args {
+		if !fn.params[i].accepts(arg.typ()) {
+			return nil, &Error{Pos: name.pos, Msg: fmt.Sprintf("argument %d of %s must be a %s, got a %s", i+1, name.text, fn.params[i], arg.typ())}
+		}
+	}
+	call := &callNode{name: name.text, fn: fn, args: args, t: fn.result}
+	if name.text == "matches" {
+		lit, ok := args[1].(*litNode)
+		if !ok {
+			return nil, &Error{Pos: name.pos, Msg: "the pattern passed to matches must be a string literal"}
+		}
+		re, err := regexp.Compile(lit.v.(string))
+		if err != nil {
+			return nil, &Error{Pos: name.pos, Msg: fmt.Sprintf("invalid pattern: %v", err)}
+		}
+		call.re = re
+	}
+	return call, nil
+}
+
+// newBinary type-checks a binary operator application.
+func newBinary(op token, l, r node) (node, error) {
+	lt, rt := l.typ(), r.typ()
+	mismatch := func() error {
+		return &Error{Pos: op.pos, Msg: fmt.Sprintf("cannot apply %q to a %s and a %s", op.text, lt, rt)}
+	}
+	switch op.text {
+	case "||", "&&":
+		if lt != TypeBool || rt != TypeBool {
+			return nil, mismatch()
+		}
+		return &binaryNode{op: op.text, l: l, r: r, t: TypeBool}, nil
+	case "==", "!=":
+		if lt != rt || lt == TypeList {
+			return nil, mismatch()
+		}
+		return &binaryNode{op: op.text, l: l, r: r, t: TypeBool}, nil
+	case "<", "<=", ">", ">=":
+		// Strings compare lexically, which is what makes born < "1950"
+		// work for the ISO-ish dates the artist record stores.
+		if lt != rt || (lt != TypeNumber && lt != TypeString) {
+			return nil, mismatch()
+		}
+		return &binaryNode{op: op.text, l: l, r: r, t: TypeBool}, nil
+	case "in":
+		// "x" in genres is (case-insensitive) membership; "x" in biography
+		// is a substring test.
+		if lt != TypeString || (rt != TypeList && rt != TypeString) {
+			return nil, mismatch()
+		}
+		return &binaryNode{op: op.text, l: l, r: r, t: TypeBool}, nil
+	case "+":
+		if lt == TypeString && rt == TypeString {
+			return &binaryNode{op: op.text, l: l, r: r, t: TypeString}, nil
+		}
+		fallthrough
+	default: // - * /
+		if lt != TypeNumber || rt != TypeNumber {
+			return nil, mismatch()
+		}
+		return &binaryNode{op: op.text, l: l, r: r, t: TypeNumber}, nil
+	}
+}
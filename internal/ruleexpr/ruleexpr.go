@@ -0,0 +1,167 @@
+// Package ruleexpr implements the small expression language operators use to
+// write their own detection rules.
+//
+// An expression is a boolean predicate over a fixed set of named fields, for
+// example
+//
+//	type == "Person" && born == ""
+//	len(genres) > 8
+//	"Christmas" in genres || contains(lower(biography), "holiday")
+//
+// The language is deliberately narrow: literals, field references, the usual
+// comparison, boolean and arithmetic operators, list membership with "in",
+// and a handful of built-in functions (see builtins). There are no
+// assignments, loops, user functions or access to anything outside the field
+// set the caller declares, so an expression cannot do anything but compute a
+// bool from one record. Everything that can be checked without a record --
+// unknown fields, operator/operand type mismatches, a malformed regular
+// expression -- is rejected by Compile, so a saved rule cannot start failing
+// at evaluation time because of a typo.
+package ruleexpr
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Type is the static type of a field or sub-expression.
+type Type int
+
+const (
+	// TypeString is a Go string.
+	TypeString Type = iota + 1
+	// TypeNumber is a float64. Integer fields are converted by the caller.
+	TypeNumber
+	// TypeBool is a Go bool.
+	TypeBool
+	// TypeList is a []string.
+	TypeList
+)
+
+// String returns the name the language uses for the type in error messages.
+func (t Type) String() string {
+	switch t {
+	case TypeString:
+		return "string"
+	case TypeNumber:
+		return "number"
+	case TypeBool:
+		return "bool"
+	case TypeList:
+		return "list"
+	default:
+		return "unknown"
+	}
+}
+
+// MaxSourceLength bounds the length of an expression. Rules are one-line
+// predicates; anything longer is almost certainly a paste accident, and the
+// bound keeps the recursive-descent parser's depth trivially safe.
+const MaxSourceLength = 2000
+
+// maxDepth bounds parser nesting ("((((...))))", "!!!!...") independently of
+// MaxSourceLength so the recursion limit does not depend on how dense the
+// source is.
+const maxDepth = 64
+
+// Schema declares the fields an expression may reference and their types.
+type Schema map[string]Type
+
+// Fields returns the schema's field names in sorted order, for documentation
+// and error messages.
+func (s Schema) Fields() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Env holds the field values for one evaluation. Values must match the
+// Schema the program was compiled against: string, float64, bool or
+// []string. A field absent from Env evaluates to its type's zero value, so a
+// caller may omit fields that are empty.
+type Env map[string]any
+
+// Error is a compile error with the 1-based column it was found at.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Pos, e.Msg)
+}
+
+// ErrDivisionByZero is returned by Eval when an expression divides by zero.
+// It is the only evaluation-time error: every other failure is a compile
+// error.
+var ErrDivisionByZero = errors.New("division by zero")
+
+// Program is a compiled expression. It is immutable and safe for concurrent
+// use.
+type Program struct {
+	source string
+	root   node
+}
+
+// Source returns the expression the program was compiled from.
+func (p *Program) Source() string {
+	return p.source
+}
+
+// Compile parses and type-checks src against schema. The expression must
+// evaluate to a bool.
+func Compile(src string, schema Schema) (*Program, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, &Error{Pos: 1, Msg: "expression is empty"}
+	}
+	if len(src) > MaxSourceLength {
+		return nil, &Error{Pos: MaxSourceLength, Msg: fmt.Sprintf("expression is longer than %d characters", MaxSourceLength)}
+	}
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks, schema: schema}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &Error{Pos: t.pos, Msg: fmt.Sprintf("unexpected %s", t.describe())}
+	}
+	if root.typ() != TypeBool {
+		return nil, &Error{Pos: 1, Msg: fmt.Sprintf("expression must be true or false, not a %s", root.typ())}
+	}
+	return &Program{source: src, root: root}, nil
+}
+
+// Eval evaluates the program against env.
+func (p *Program) Eval(env Env) (bool, error) {
+	v, err := p.root.eval(env)
+	if err != nil {
+		return false, err
+	}
+	return v.(bool), nil
+}
+
+// Fields returns the names of the fields the program references, sorted.
+// Callers use it to decide which inputs an evaluation needs.
+func (p *Program) Fields() []string {
+	seen := map[string]bool{}
+	walk(p.root, func(n node) {
+		if f, ok := n.(*fieldNode); ok {
+			seen[f.name] = true
+		}
+	})
+	out := make([]string, 0, len(seen))
+	for name := range seen {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,146 @@
+package ruleexpr
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var testSchema = Schema{
+	"name":         TypeString,
+	"type":         TypeString,
+	"born":         TypeString,
+	"biography":    TypeString,
+	"genres":       TypeList,
+	"fanart_count": TypeNumber,
+	"thumb_width":  TypeNumber,
+	"thumb_height": TypeNumber,
+	"locked":       TypeBool,
+}
+
+func mustCompile(t *testing.T, src string) *Program {
+	t.Helper()
+	p, err := Compile(src, testSchema)
+	if err != nil {
+		t.Fatalf("Compile(%q): %v", src, err)
+	}
+	return p
+}
+
+func TestEval(t *testing.T) {
+	env := Env{
+		"name":         "Björk",
+		"type":         "Person",
+		"biography":    "Icelandic singer, songwriter and producer.",
+		"genres":       []string{"Electronic", "Art Pop", "Trip Hop"},
+		"fanart_count": 4.0,
+		"thumb_width":  1000.0,
+		"thumb_height": 800.0,
+	}
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{`type == "Person" && born == ""`, true},
+		{`type == "Group" || born != ""`, false},
+		{`len(genres) > 2`, true},
+		{`len(genres) > 8`, false},
+		{`len(name) == 5`, true}, // characters, not bytes
+		{`"art pop" in genres`, true},
+		{`"singer" in biography`, true},
+		{`contains(genres, "TRIP HOP")`, true},
+		{`contains(lower(biography), "icelandic")`, true},
+		{`startsWith(name, "Bj") && endsWith(name, "rk")`, true},
+		{`matches(biography, "^Icelandic\\s")`, true},
+		{`thumb_width != thumb_height`, true},
+		{`thumb_width / thumb_height > 1.2`, true},
+		{`-fanart_count + 10 == 6`, true},
+		{`fanart_count * 2 >= 8 && !locked`, true},
+		{`born < "1950"`, true}, // "" sorts first
+		{`!(type == "Person")`, false},
+		{`trim("  x ") + upper("y") == "xY"`, true},
+		{`true && (false || !false)`, true},
+	}
+	for _, tt := range tests {
+		got, err := mustCompile(t, tt.src).Eval(env)
+		if err != nil {
+			t.Errorf("Eval(%q): %v", tt.src, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Eval(%q) = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestEvalMissingFieldsAreZero(t *testing.T) {
+	got, err := mustCompile(t, `born == "" && len(genres) == 0 && fanart_count == 0 && !locked`).Eval(Env{})
+	if err != nil || !got {
+		t.Fatalf("Eval on empty env = %v, %v; want true", got, err)
+	}
+}
+
+func TestEvalDivisionByZero(t *testing.T) {
+	p := mustCompile(t, `100 / fanart_count > 2`)
+	if _, err := p.Eval(Env{}); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("err = %v, want ErrDivisionByZero", err)
+	}
+	// Short-circuit keeps the guarded form safe.
+	p = mustCompile(t, `fanart_count > 0 && 100 / fanart_count > 2`)
+	if got, err := p.Eval(Env{}); err != nil || got {
+		t.Errorf("guarded division = %v, %v; want false, nil", got, err)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{``, "empty"},
+		{`name`, "must be true or false"},
+		{`nmae == ""`, `unknown field "nmae"`},
+		{`name = "x"`, `use "=="`},
+		{`name == 3`, `cannot apply "=="`},
+		{`genres == ""`, `cannot apply "=="`},
+		{`len(name, genres) > 1`, "takes 1 argument"},
+		{`len(locked) > 1`, "must be a string or list"},
+		{`nope(name)`, `unknown function "nope"`},
+		{`matches(name, type)`, "string literal"},
+		{`matches(name, "(")`, "invalid pattern"},
+		{`name == "unterminated`, "unterminated string"},
+		{`name == "C:\Music"`, "unknown escape"},
+		{`(name == "x"`, `expected ")"`},
+		{`name == "x" name`, "unexpected"},
+		{`-name == ""`, `"-" needs a number`},
+		{`3 in genres`, `cannot apply "in"`},
+		{strings.Repeat("(", 100) + "true" + strings.Repeat(")", 100), "nested too deeply"},
+		{strings.Repeat("x", MaxSourceLength+1), "longer than"},
+	}
+	for _, tt := range tests {
+		_, err := Compile(tt.src, testSchema)
+		var ce *Error
+		if !errors.As(err, &ce) {
+			t.Errorf("Compile(%.40q) error = %v, want an *Error", tt.src, err)
+			continue
+		}
+		if !strings.Contains(ce.Msg, tt.want) {
+			t.Errorf("Compile(%.40q) = %q, want it to contain %q", tt.src, ce.Msg, tt.want)
+		}
+	}
+}
+
+func TestErrorPosition(t *testing.T) {
+	_, err := Compile(`type == "Person" && brn == ""`, testSchema)
+	var ce *Error
+	if !errors.As(err, &ce) || ce.Pos != 21 {
+		t.Fatalf("err = %v, want column 21", err)
+	}
+}
+
+func TestProgramFields(t *testing.T) {
+	p := mustCompile(t, `type == "Person" && (born == "" || len(genres) > 8) && type != ""`)
+	if got, want := p.Fields(), []string{"born", "genres", "type"}; !slices.Equal(got, want) {
+		t.Errorf("Fields() = %v, want %v", got, want)
+	}
+}
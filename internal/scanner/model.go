@@ -12,6 +12,10 @@ type ScanResult struct {
 	UpdatedArtists   int        `json:"updated_artists"`
 	RemovedArtists   int        `json:"removed_artists"`
 	TotalDirectories int        `json:"total_directories"`
+	// Targeted is set for a scan of named artist directories (RunDirs)
+	// rather than of every library. Its counters cover only those
+	// directories.
+	Targeted bool `json:"targeted,omitempty"`
 	// SuspectedDuplicates counts newly-created artists whose normalized
 	// identity key collided with a key already seen in this scan's preloaded
 	// artist map.  A non-zero value indicates that the library contains
@@ -5,10 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime/debug"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -81,6 +83,14 @@ type Service struct {
 	// the config-reload path concurrently.
 	mtimeFastPath atomic.Bool
 
+	// tagIdentity toggles reading embedded audio tags (MusicBrainz artist
+	// IDs, sort names) for artist directories the scanner has not seen
+	// before. Default-on; SetTagIdentity(false) turns the scan back into a
+	// directory-name + NFO pass for libraries on slow storage where opening
+	// a handful of tracks per new artist is not free. atomic.Bool for the
+	// same concurrent-reload reason as mtimeFastPath.
+	tagIdentity atomic.Bool
+
 	mu          sync.Mutex
 	currentScan *ScanResult
 
@@ -149,6 +159,7 @@ func NewService(artistService *artist.Service, ruleEngine *rule.Engine, ruleServ
 	s.exclusions.Store(&excMap)
 	s.exclusionsDisplay.Store(&display)
 	s.mtimeFastPath.Store(true) // matches ScannerConfig.MtimeFastPath default
+	s.tagIdentity.Store(true)   // matches ScannerConfig.TagIdentity default
 	return s
 }
 
@@ -219,6 +230,17 @@ func (s *Service) SetMtimeFastPath(enabled bool) {
 	s.mtimeFastPath.Store(enabled)
 }
 
+// TagIdentity reports whether new artist directories have their embedded
+// audio tags read for identity evidence.
+func (s *Service) TagIdentity() bool {
+	return s.tagIdentity.Load()
+}
+
+// SetTagIdentity toggles embedded-tag identity seeding for new artists.
+func (s *Service) SetTagIdentity(enabled bool) {
+	s.tagIdentity.Store(enabled)
+}
+
 // Shutdown cancels any in-progress background scans and waits for them to
 // complete. Call this during application shutdown.
 func (s *Service) Shutdown() {
@@ -226,9 +248,39 @@ func (s *Service) Shutdown() {
 	s.scanWg.Wait()
 }
 
+// Wait blocks until every scan started by Run has finished, without
+// canceling them. The headless `scan` command uses it to turn the background
+// scan into a foreground one; read the outcome with Status afterwards.
+func (s *Service) Wait() {
+	s.scanWg.Wait()
+}
+
 // Run starts a filesystem scan. Only one scan runs at a time.
 // Returns a snapshot of the initial scan result (safe to read without synchronization).
 func (s *Service) Run(ctx context.Context) (*ScanResult, error) {
+	return s.start(nil)
+}
+
+// RunDirs starts a targeted scan of the given artist directories instead of
+// every library: each one that exists is processed as a full scan would
+// process it, and each one that is gone has its artist removed. Rules are
+// re-evaluated only for the artists touched, through the same ArtistUpdated
+// events a full scan publishes. A directory that is not a direct child of a
+// library path is skipped. It shares Run's one-scan-at-a-time lock, so it
+// returns ErrScanInProgress while any scan runs.
+//
+// A targeted scan never sees what it was not told about, so it is no
+// substitute for a periodic Run; the filesystem watcher drives it for the
+// directories it saw change.
+func (s *Service) RunDirs(ctx context.Context, dirs []string) (*ScanResult, error) {
+	if len(dirs) == 0 {
+		return nil, errors.New("no directories to scan")
+	}
+	return s.start(dirs)
+}
+
+// start begins a scan of dirs, or of every library when dirs is nil.
+func (s *Service) start(dirs []string) (*ScanResult, error) {
 	s.mu.Lock()
 	if s.currentScan != nil && s.currentScan.Status == "running" {
 		s.mu.Unlock()
@@ -239,6 +291,7 @@ func (s *Service) Run(ctx context.Context) (*ScanResult, error) {
 		ID:        uuid.New().String(),
 		Status:    "running",
 		StartedAt: time.Now().UTC(),
+		Targeted:  dirs != nil,
 	}
 	s.currentScan = result
 	snapshot := *result
@@ -247,7 +300,7 @@ func (s *Service) Run(ctx context.Context) (*ScanResult, error) {
 	// Use the shutdown context so the scan outlives the HTTP request but
 	// is still canceled on application shutdown.
 	s.scanWg.Add(1)
-	go s.runScan(s.shutdownCtx, result) //nolint:contextcheck // intentional -- scan goroutine must outlive request; scoped to shutdownCtx for app-level cancellation
+	go s.runScan(s.shutdownCtx, result, dirs) //nolint:contextcheck // intentional -- scan goroutine must outlive request; scoped to shutdownCtx for app-level cancellation
 
 	return &snapshot, nil
 }
@@ -283,7 +336,7 @@ func (s *Service) markScanFailed(result *ScanResult, reason string) {
 }
 
 //nolint:gocognit // Scan worker: lock-protected status transitions, per-library file walk, cancellation checkpoints, error aggregation, progress publication, and completion sync; the lifecycle ordering (acquire -> walk -> publish -> release) and cancellation-aware control flow do not factor cleanly into helpers without sharing the result mutex across them.
-func (s *Service) runScan(ctx context.Context, result *ScanResult) {
+func (s *Service) runScan(ctx context.Context, result *ScanResult, dirs []string) {
 	defer s.scanWg.Done()
 	defer func() {
 		// Post-scan hook runs BEFORE the scan is marked finished, deliberately.
@@ -337,6 +390,7 @@ func (s *Service) runScan(ctx context.Context, result *ScanResult) {
 					"status":            result.Status,
 					"total_directories": result.TotalDirectories,
 					"new_artists":       result.NewArtists,
+					"targeted":          result.Targeted,
 				},
 			})
 		}
@@ -359,38 +413,16 @@ func (s *Service) runScan(ctx context.Context, result *ScanResult) {
 		}
 	}()
 
-	// Build the list of libraries to scan.
-	type scanTarget struct {
-		path      string
-		libraryID string
-	}
-	var targets []scanTarget
-
-	if s.libraryLister != nil {
-		libs, err := s.libraryLister.List(ctx)
-		if err != nil {
-			s.logger.Error("listing libraries for scan", "error", err)
-		}
-		for i := range libs {
-			lib := &libs[i]
-			if lib.Path == "" {
-				s.logger.Info("skipping pathless library (no path configured)", "library_id", lib.ID, "name", lib.Name)
-				continue
-			}
-			targets = append(targets, scanTarget{path: lib.Path, libraryID: lib.ID})
-		}
-	}
-	// Fallback: if no library lister is configured, use the legacy single path.
-	// When a lister IS set but returns empty, the user has no libraries -- do not fall back.
-	if len(targets) == 0 && s.libraryLister == nil && s.libraryPath != "" {
-		targets = append(targets, scanTarget{path: s.libraryPath, libraryID: s.defaultLibraryID})
-	}
-
+	targets := s.scanTargets(ctx)
 	if len(targets) == 0 {
 		s.markScanFailed(result, "no scannable libraries (all libraries are API-only or no paths configured)")
 		s.logger.Error("scan failed: no scannable libraries (all libraries are API-only or no paths configured)")
 		return
 	}
+	if dirs != nil {
+		s.scanDirs(ctx, targets, dirs, result)
+		return
+	}
 
 	// Collect discovered paths per library for removal detection. Keyed
 	// by libraryID so detectRemoved can query only the artists belonging
@@ -401,7 +433,7 @@ func (s *Service) runScan(ctx context.Context, result *ScanResult) {
 	for _, target := range targets {
 		s.logger.Info("scanning library", "path", target.path, "library_id", target.libraryID)
 
-		entries, err := os.ReadDir(target.path)
+		dirPaths, complete, err := s.artistDirs(target)
 		if err != nil {
 			s.logger.Error("reading library directory", "error", err, "path", target.path)
 			continue
@@ -466,44 +498,30 @@ func (s *Service) runScan(ctx context.Context, result *ScanResult) {
 			}
 		}
 
-		discoveredPaths := make(map[string]bool, len(entries))
-		for _, entry := range entries {
+		discoveredPaths := make(map[string]bool, len(dirPaths))
+		for _, dirPath := range dirPaths {
 			if ctx.Err() != nil {
 				s.markScanFailed(result, "scan canceled")
 				return
 			}
 
-			if !entry.IsDir() {
-				continue
-			}
-			// Skip hidden directories
-			if strings.HasPrefix(entry.Name(), ".") {
-				continue
-			}
-			// Skip OS/NAS junk directories ($RECYCLE.BIN, System Volume
-			// Information, @eaDir, lost+found, ...) and compilation
-			// placeholder buckets (Various Artists / Various / VA). These are
-			// never real artists, so drop them before they become an artist
-			// row or a scanned directory. The dot-prefixed junk (.Trash,
-			// .DS_Store) is already caught by the hidden-dir skip above; this
-			// covers the non-dot-prefixed names the built-in sets add. These
-			// are distinct from the operator-editable exclusion list, which
-			// still CREATES the artist and flags it IsExcluded (#30, #41).
-			if artist.IsIgnoredSystemName(entry.Name()) || artist.IsNonArtistDirName(entry.Name()) {
-				continue
-			}
-
 			s.mu.Lock()
 			result.TotalDirectories++
 			s.mu.Unlock()
-			dirPath := filepath.Join(target.path, entry.Name())
 			discoveredPaths[dirPath] = true
 
-			if err := s.processDirectory(ctx, dirPath, entry.Name(), target.libraryID, libraryArtists, preloadedKeys, result); err != nil {
+			if err := s.processDirectory(ctx, dirPath, filepath.Base(dirPath), target.libraryID, libraryArtists, preloadedKeys, result); err != nil {
 				s.logger.Warn("error processing directory",
 					"path", dirPath, "error", err)
 			}
 		}
+		// An unreadable bucket directory hides its artists from this scan,
+		// which is not a reason to remove them.
+		if !complete {
+			s.logger.Warn("skipping removal check for library with unreadable bucket directories",
+				"path", target.path, "library_id", target.libraryID)
+			continue
+		}
 		discoveredByLibrary[target.libraryID] = discoveredPaths
 	}
 
@@ -516,6 +534,188 @@ func (s *Service) runScan(ctx context.Context, result *ScanResult) {
 	s.recordHealthSnapshot(ctx)
 }
 
+// scanTarget is one library root a scan walks. depth is how many levels
+// below path its artist directories are: 1, or more for a library whose
+// layout sorts artists into bucket directories (A/ABBA).
+type scanTarget struct {
+	path      string
+	libraryID string
+	depth     int
+}
+
+// scanTargets lists the libraries a scan walks: every library with a path,
+// or the legacy single library path when no library lister is configured.
+func (s *Service) scanTargets(ctx context.Context) []scanTarget {
+	var targets []scanTarget
+	if s.libraryLister != nil {
+		libs, err := s.libraryLister.List(ctx)
+		if err != nil {
+			s.logger.Error("listing libraries for scan", "error", err)
+		}
+		for i := range libs {
+			lib := &libs[i]
+			if lib.Path == "" {
+				s.logger.Info("skipping pathless library (no path configured)", "library_id", lib.ID, "name", lib.Name)
+				continue
+			}
+			targets = append(targets, scanTarget{path: lib.Path, libraryID: lib.ID, depth: lib.ArtistDepth()})
+		}
+	}
+	// Fallback: if no library lister is configured, use the legacy single path.
+	// When a lister IS set but returns empty, the user has no libraries -- do not fall back.
+	if len(targets) == 0 && s.libraryLister == nil && s.libraryPath != "" {
+		targets = append(targets, scanTarget{path: s.libraryPath, libraryID: s.defaultLibraryID, depth: 1})
+	}
+	return targets
+}
+
+// skipDirName reports whether a directory under a library root is never an
+// artist: hidden directories, OS/NAS junk directories ($RECYCLE.BIN, System
+// Volume Information, @eaDir, lost+found, ...) and compilation placeholder
+// buckets (Various Artists / Various / VA). These are dropped before they
+// become an artist row or a scanned directory. They are distinct from the
+// operator-editable exclusion list, which still CREATES the artist and
+// flags it IsExcluded (#30, #41).
+func skipDirName(name string) bool {
+	return strings.HasPrefix(name, ".") || artist.IsIgnoredSystemName(name) || artist.IsNonArtistDirName(name)
+}
+
+// skipBucketName reports whether a directory above the artist level of a
+// nested layout is never walked: hidden and OS/NAS junk directories. Unlike
+// skipDirName it keeps names such as "Soundtrack" or "VA", which are fine
+// genre or label buckets.
+func skipBucketName(name string) bool {
+	return strings.HasPrefix(name, ".") || artist.IsIgnoredSystemName(name)
+}
+
+// artistDirs lists the artist directories of target: the subdirectories of
+// its path, or for a nested layout those of its bucket directories at the
+// layout's depth. It fails only when the library path cannot be read; an
+// unreadable bucket directory is logged and reported as complete == false.
+func (s *Service) artistDirs(target scanTarget) (dirs []string, complete bool, err error) {
+	entries, err := os.ReadDir(target.path)
+	if err != nil {
+		return nil, false, err
+	}
+	complete = true
+	var walk func(dir string, entries []os.DirEntry, level int)
+	walk = func(dir string, entries []os.DirEntry, level int) {
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			p := filepath.Join(dir, entry.Name())
+			if level == target.depth {
+				if !skipDirName(entry.Name()) {
+					dirs = append(dirs, p)
+				}
+				continue
+			}
+			if skipBucketName(entry.Name()) {
+				continue
+			}
+			children, err := os.ReadDir(p)
+			if err != nil {
+				s.logger.Error("reading bucket directory", "error", err, "path", p)
+				complete = false
+				continue
+			}
+			walk(p, children, level+1)
+		}
+	}
+	walk(target.path, entries, 1)
+	return dirs, complete, nil
+}
+
+// scanDirs is the targeted scan behind RunDirs. It skips the per-library
+// preload, removal sweep and health snapshot, whose cost grows with the
+// library rather than with dirs; the next full scan records the snapshot.
+// Without the preload a new artist is not checked for suspected duplicates.
+func (s *Service) scanDirs(ctx context.Context, targets []scanTarget, dirs []string, result *ScanResult) {
+	seen := make(map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		if ctx.Err() != nil {
+			s.markScanFailed(result, "scan canceled")
+			return
+		}
+		dirPath := filepath.Clean(dir)
+		if seen[dirPath] {
+			continue
+		}
+		seen[dirPath] = true
+
+		name := filepath.Base(dirPath)
+		target, ok := targetOf(targets, dirPath)
+		if !ok || skipDirName(name) {
+			s.logger.Debug("skipping directory outside any library", "path", dirPath)
+			continue
+		}
+
+		info, err := os.Stat(dirPath)
+		switch {
+		case err == nil && info.IsDir():
+			s.mu.Lock()
+			result.TotalDirectories++
+			s.mu.Unlock()
+			if err := s.processDirectory(ctx, dirPath, name, target.libraryID, nil, nil, result); err != nil {
+				s.logger.Warn("error processing directory", "path", dirPath, "error", err)
+			}
+		case err == nil:
+			// A file where the directory was: no longer an artist directory.
+			s.removeArtistAt(ctx, target, dirPath, result)
+		case errors.Is(err, fs.ErrNotExist):
+			s.removeArtistAt(ctx, target, dirPath, result)
+		default:
+			s.logger.Warn("reading artist directory", "path", dirPath, "error", err)
+		}
+	}
+}
+
+// targetOf returns the library dirPath is an artist directory of: the one
+// whose path is exactly its depth levels above dirPath, through bucket
+// directories that are walked.
+func targetOf(targets []scanTarget, dirPath string) (scanTarget, bool) {
+	for _, t := range targets {
+		rel, err := filepath.Rel(filepath.Clean(t.path), dirPath)
+		if err != nil || rel == "." || !filepath.IsLocal(rel) {
+			continue
+		}
+		segs := strings.Split(rel, string(filepath.Separator))
+		if len(segs) != t.depth || slices.ContainsFunc(segs[:len(segs)-1], skipBucketName) {
+			continue
+		}
+		return t, true
+	}
+	return scanTarget{}, false
+}
+
+// removeArtistAt removes the artist whose directory dirPath is gone, as the
+// full scan's removal sweep would. It does nothing while the library root
+// itself cannot be read: an unmounted share loses every directory at once,
+// and that is not a reason to drop the artists.
+func (s *Service) removeArtistAt(ctx context.Context, target scanTarget, dirPath string, result *ScanResult) {
+	if _, err := os.ReadDir(target.path); err != nil {
+		s.logger.Warn("library root unreadable, keeping artist", "path", dirPath, "library_path", target.path, "error", err)
+		return
+	}
+	a, err := s.artistService.GetByPath(ctx, dirPath)
+	if err != nil {
+		s.logger.Warn("looking up removed artist by path", "path", dirPath, "error", err)
+		return
+	}
+	if a == nil {
+		return
+	}
+	if err := s.artistService.Delete(ctx, a.ID); err != nil {
+		s.logger.Warn("failed to remove artist", "id", a.ID, "error", err)
+		return
+	}
+	s.mu.Lock()
+	result.RemovedArtists++
+	s.mu.Unlock()
+	s.logger.Debug("artist removed (directory gone)", "name", a.Name, "path", dirPath)
+}
+
 func (s *Service) processDirectory(ctx context.Context, dirPath, name, libraryID string, preloaded map[string]*artist.Artist, preloadedKeys map[string]string, result *ScanResult) error {
 	excluded := false
 	if m := s.exclusions.Load(); m != nil {
@@ -565,6 +765,46 @@ func (s *Service) processDirectory(ctx context.Context, dirPath, name, libraryID
 	return s.processExistingArtist(ctx, dirPath, libraryID, existing, excluded, detected, result)
 }
 
+// seedFromTags fills identity fields the NFO left empty from the MusicBrainz
+// artist IDs and sort names embedded in the directory's audio files. Only a
+// tag consensus (see artist.TagEvidence.Consensus) sets the MBID, and it is
+// stamped SourceEmbeddedTags so a later re-review can tell it apart from a
+// name-search pick. The sort name is taken only while it still holds the
+// directory-name default, so an NFO <sortname> always wins.
+//
+// Unreadable audio is not an error: the artist is created exactly as it
+// would have been without tags, and the nfo_has_mbid fixer gets its own
+// chance at the directory later.
+func (s *Service) seedFromTags(ctx context.Context, dirPath string, a *artist.Artist) {
+	if a.MusicBrainzID != "" && a.SortName != a.Name {
+		return
+	}
+	ev, err := artist.ReadTagEvidence(ctx, a.Name, dirPath)
+	if err != nil {
+		s.logger.Debug("reading embedded audio tags", "path", dirPath, "error", err)
+		return
+	}
+	if a.MusicBrainzID == "" {
+		if mbid, rej := ev.Consensus(); rej == nil {
+			a.MusicBrainzID = mbid
+			if a.MetadataSources == nil {
+				a.MetadataSources = map[string]string{}
+			}
+			a.MetadataSources[artist.SourceKeyMusicBrainzID] = artist.SourceEmbeddedTags
+			s.logger.Debug("seeded MusicBrainz ID from embedded audio tags",
+				"name", a.Name, "mbid", mbid, "evidence", ev.Describe())
+		} else if ev.Sampled > 0 {
+			s.logger.Debug("embedded audio tags did not settle identity",
+				"name", a.Name, "reason", rej.Reason)
+		}
+	}
+	if a.SortName == a.Name {
+		if sortName := ev.SortName(); sortName != "" {
+			a.SortName = sortName
+		}
+	}
+}
+
 // processNewArtist creates a new artist record for a directory not yet in the
 // database. It applies file detection results, parses NFO metadata if present,
 // and publishes an ArtistUpdated event.
@@ -624,6 +864,10 @@ func (s *Service) processNewArtist(ctx context.Context, dirPath, name, libraryID
 		}
 	}
 
+	if s.tagIdentity.Load() {
+		s.seedFromTags(ctx, dirPath, a)
+	}
+
 	now := time.Now().UTC()
 	a.LastScannedAt = &now
 
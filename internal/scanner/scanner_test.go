@@ -2780,3 +2780,158 @@ func TestDiscoverFanartFiles_MatchesDiscoverFanart(t *testing.T) {
 		})
 	}
 }
+
+func TestRunDirs_ScansOnlyNamedDirectories(t *testing.T) {
+	t.Parallel()
+	libDir := t.TempDir()
+	for _, name := range []string{"Changed", "Untouched", "Removed"} {
+		createArtistDir(t, libDir, name)
+	}
+	svc, artistSvc := setupScanner(t, libDir)
+	svc.SetLibraryLister(&stubLibraryLister{libs: []library.Library{
+		{ID: "lib-1", Name: "Music", Path: libDir, Type: library.TypeRegular},
+	}})
+	ctx := context.Background()
+	if _, err := svc.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	waitForScan(t, svc, 5*time.Second)
+
+	for _, name := range []string{"Changed", "Untouched"} {
+		if err := os.WriteFile(filepath.Join(libDir, name, "folder.jpg"), []byte("jpg data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	createArtistDir(t, libDir, "New")
+	if err := os.RemoveAll(filepath.Join(libDir, "Removed")); err != nil {
+		t.Fatal(err)
+	}
+
+	dirs := []string{
+		filepath.Join(libDir, "Changed"),
+		filepath.Join(libDir, "New"),
+		filepath.Join(libDir, "Removed"),
+		filepath.Join(libDir, "New") + string(filepath.Separator), // duplicate
+		filepath.Join(t.TempDir(), "Elsewhere"),                   // in no library
+		filepath.Join(libDir, ".hidden"),
+	}
+	if _, err := svc.RunDirs(ctx, dirs); err != nil {
+		t.Fatalf("RunDirs: %v", err)
+	}
+	final := waitForScan(t, svc, 5*time.Second)
+	if !final.Targeted || final.Status != "completed" {
+		t.Fatalf("result = %+v, want a completed targeted scan", final)
+	}
+	if final.TotalDirectories != 2 || final.NewArtists != 1 || final.UpdatedArtists != 1 || final.RemovedArtists != 1 {
+		t.Errorf("counts = %d dirs, %d new, %d updated, %d removed; want 2, 1, 1, 1",
+			final.TotalDirectories, final.NewArtists, final.UpdatedArtists, final.RemovedArtists)
+	}
+
+	for name, wantThumb := range map[string]bool{"Changed": true, "Untouched": false, "New": false} {
+		a, err := artistSvc.GetByPath(ctx, filepath.Join(libDir, name))
+		if err != nil || a == nil {
+			t.Fatalf("%s: artist = %v, %v", name, a, err)
+		}
+		if a.ThumbExists != wantThumb {
+			t.Errorf("%s: ThumbExists = %v, want %v", name, a.ThumbExists, wantThumb)
+		}
+	}
+	if a, _ := artistSvc.GetByPath(ctx, filepath.Join(libDir, "Removed")); a != nil {
+		t.Error("artist of the removed directory still exists")
+	}
+}
+
+func TestRunDirs_KeepsArtistsWhenLibraryRootIsGone(t *testing.T) {
+	t.Parallel()
+	libDir := filepath.Join(t.TempDir(), "music")
+	createArtistDir(t, libDir, "Band")
+	svc, artistSvc := setupScanner(t, libDir)
+	ctx := context.Background()
+	if _, err := svc.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	waitForScan(t, svc, 5*time.Second)
+
+	// An unmounted share looks like every artist directory vanishing.
+	if err := os.Rename(libDir, libDir+".offline"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.RunDirs(ctx, []string{filepath.Join(libDir, "Band")}); err != nil {
+		t.Fatalf("RunDirs: %v", err)
+	}
+	if final := waitForScan(t, svc, 5*time.Second); final.RemovedArtists != 0 {
+		t.Errorf("RemovedArtists = %d, want 0", final.RemovedArtists)
+	}
+	if a, _ := artistSvc.GetByPath(ctx, filepath.Join(libDir, "Band")); a == nil {
+		t.Error("artist removed while its library root was missing")
+	}
+}
+
+func TestRun_NestedLayout(t *testing.T) {
+	t.Parallel()
+	libDir := t.TempDir()
+	createArtistDir(t, filepath.Join(libDir, "A"), "ABBA")
+	createArtistDir(t, filepath.Join(libDir, "B"), "Beatles")
+	createArtistDir(t, filepath.Join(libDir, ".trash"), "Hidden")
+	createArtistDir(t, filepath.Join(libDir, "B"), ".staging")
+	svc, artistSvc, db := setupScannerWithDB(t, libDir)
+	ctx := context.Background()
+
+	// Seed the library row so artist_libraries memberships land and the
+	// removal sweep can find the bucket artists.
+	if _, err := db.ExecContext(ctx,
+		`INSERT INTO libraries (id, name, path, type, source, created_at, updated_at)
+		 VALUES ('lib-1', 'Music', ?, 'regular', 'manual', datetime('now'), datetime('now'))`,
+		libDir); err != nil {
+		t.Fatalf("seeding library: %v", err)
+	}
+	lister := &stubLibraryLister{libs: []library.Library{
+		{ID: "lib-1", Name: "Music", Path: libDir, Type: library.TypeRegular},
+	}}
+	svc.SetLibraryLister(lister)
+
+	// Scanned flat, the letter buckets become artists.
+	if _, err := svc.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := waitForScan(t, svc, 5*time.Second); got.NewArtists != 2 {
+		t.Fatalf("flat scan found %d new artists, want the 2 buckets", got.NewArtists)
+	}
+
+	// With the layout set, the next scan replaces them with the real artists.
+	lister.libs[0].Layout = library.LayoutLetterFirst
+	if _, err := svc.Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	final := waitForScan(t, svc, 5*time.Second)
+	if final.TotalDirectories != 2 || final.NewArtists != 2 || final.RemovedArtists != 2 {
+		t.Errorf("counts = %d dirs, %d new, %d removed; want 2, 2, 2",
+			final.TotalDirectories, final.NewArtists, final.RemovedArtists)
+	}
+	for _, p := range []string{filepath.Join(libDir, "A", "ABBA"), filepath.Join(libDir, "B", "Beatles")} {
+		if a, err := artistSvc.GetByPath(ctx, p); err != nil || a == nil || a.Name != filepath.Base(p) {
+			t.Errorf("artist at %s = %v, %v", p, a, err)
+		}
+	}
+	if a, _ := artistSvc.GetByPath(ctx, filepath.Join(libDir, "A")); a != nil {
+		t.Error("the A bucket is still an artist")
+	}
+
+	// A targeted scan resolves artist directories at the layout's depth only.
+	if err := os.RemoveAll(filepath.Join(libDir, "B", "Beatles")); err != nil {
+		t.Fatal(err)
+	}
+	createArtistDir(t, filepath.Join(libDir, "C"), "Cure")
+	if _, err := svc.RunDirs(ctx, []string{
+		filepath.Join(libDir, "B", "Beatles"),
+		filepath.Join(libDir, "C", "Cure"),
+		filepath.Join(libDir, "C"), // a bucket, not an artist
+	}); err != nil {
+		t.Fatalf("RunDirs: %v", err)
+	}
+	final = waitForScan(t, svc, 5*time.Second)
+	if final.TotalDirectories != 1 || final.NewArtists != 1 || final.RemovedArtists != 1 {
+		t.Errorf("targeted counts = %d dirs, %d new, %d removed; want 1, 1, 1",
+			final.TotalDirectories, final.NewArtists, final.RemovedArtists)
+	}
+}
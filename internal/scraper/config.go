@@ -216,6 +216,7 @@ func DefaultConfig() *ScraperConfig {
 					provider.NameAudioDB,
 					provider.NameWikidata,
 					provider.NameGenius,
+					provider.NameAllMusic,
 				},
 			},
 			{
@@ -305,5 +306,14 @@ func ProviderCapabilities() []ProviderCapability {
 			RequiresAuth:   true,
 			MetadataFields: []FieldName{FieldBiography},
 		},
+		{
+			Provider:     provider.NameAllMusic,
+			DisplayName:  provider.NameAllMusic.DisplayName(),
+			RequiresAuth: false,
+			MetadataFields: []FieldName{
+				FieldBiography, FieldGenres, FieldStyles, FieldMoods, FieldYearsActive,
+			},
+			ImageFields: []FieldName{FieldThumb},
+		},
 	}
 }
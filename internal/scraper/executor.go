@@ -17,6 +17,7 @@ type Executor struct {
 	registry         *provider.Registry
 	providerSettings *provider.SettingsService
 	aimd             *provider.AIMDController // may be nil; when nil, AIMD signals are skipped
+	responseCache    *provider.ResponseCache  // may be nil; when nil, every call reaches the provider
 	logger           *slog.Logger
 }
 
@@ -44,6 +45,13 @@ func NewExecutor(service *Service, registry *provider.Registry, settings *provid
 	}
 }
 
+// SetResponseCache routes the executor's provider calls through the persistent
+// response cache. In production, pass the same ResponseCache the Orchestrator
+// uses so both code paths share cached answers and hit metrics.
+func (e *Executor) SetResponseCache(c *provider.ResponseCache) {
+	e.responseCache = c
+}
+
 // ScrapeAll scrapes all enabled fields using the scraper configuration for the
 // given scope. It returns a merged FetchResult compatible with the
 // provider.Orchestrator output.
@@ -369,7 +377,7 @@ func (e *Executor) getProviderResult(
 		return pr
 	}
 
-	fetched := provider.FetchProviderResult(ctx, p, name, mbid, artistName, providerIDs, e.logger, e.aimd)
+	fetched := provider.FetchProviderResult(ctx, p, name, mbid, artistName, providerIDs, e.logger, e.aimd, e.responseCache)
 	pr := &providerResult{
 		meta:            fetched.Meta(),
 		images:          fetched.Images(),
@@ -589,6 +597,9 @@ func applyProviderIDsAndURLs(result *provider.FetchResult, meta *provider.Artist
 	if meta.SpotifyID != "" && result.Metadata.SpotifyID == "" {
 		result.Metadata.SpotifyID = meta.SpotifyID
 	}
+	if meta.AllMusicID != "" && result.Metadata.AllMusicID == "" {
+		result.Metadata.AllMusicID = meta.AllMusicID
+	}
 	for k, v := range meta.URLs {
 		if _, exists := result.Metadata.URLs[k]; !exists {
 			result.Metadata.URLs[k] = v
@@ -78,7 +78,15 @@ var pbkdf2Iterations = defaultPBKDF2Iterations
 //     Pre-1.7 envelopes lack the field, so legacy imports must preserve the
 //     target's existing mappings instead of clobbering them with a decoded
 //     nil.
-const CurrentEnvelopeVersion = "1.7"
+//   - "1.8": adds libraries to APITokenExport so a token restricted to
+//     specific libraries keeps the restriction. Older binaries reject the
+//     version rather than import the token without it, which would widen
+//     the token to every library.
+//   - "1.9": adds libraries to UserExport (per-library user grants) and the
+//     viewer role. Older binaries reject the version rather than import a
+//     viewer as an operator or drop a user's grant, either of which would
+//     widen the account.
+const CurrentEnvelopeVersion = "1.9"
 
 // supportedEnvelopeVersions lists the envelope versions Import will accept.
 // Older versions are accepted for backward compatibility (their newer fields
@@ -92,6 +100,8 @@ var supportedEnvelopeVersions = map[string]bool{
 	"1.5": true,
 	"1.6": true,
 	"1.7": true,
+	"1.8": true,
+	"1.9": true,
 }
 
 // envelopeCarriesConnectionV14Fields reports whether an envelope of the given
@@ -108,7 +118,7 @@ var supportedEnvelopeVersions = map[string]bool{
 // out to avoid shadowing the imported `version` package.
 func envelopeCarriesConnectionV14Fields(envelopeVersion string) bool {
 	switch envelopeVersion {
-	case "1.4", "1.5", "1.6", "1.7":
+	case "1.4", "1.5", "1.6", "1.7", "1.8", "1.9":
 		return true
 	default:
 		return false
@@ -122,11 +132,26 @@ func envelopeCarriesConnectionV14Fields(envelopeVersion string) bool {
 // operator had set. Returning false here lets importConnections preserve the
 // target's existing mappings instead.
 //
-// When introducing a v1.8+ envelope that ALSO carries PathMappings, add the
+// When introducing a newer envelope that ALSO carries PathMappings, add the
 // new version to the case below.
 func envelopeCarriesConnectionV17Fields(envelopeVersion string) bool {
 	switch envelopeVersion {
-	case "1.7":
+	case "1.7", "1.8", "1.9":
+		return true
+	default:
+		return false
+	}
+}
+
+// envelopeCarriesUserLibraries reports whether an envelope of the given
+// version carries users' library grants (v1.9+). An older envelope's users
+// decode with no grant, which must not be read as "every library" and
+// applied over the target's existing grants.
+//
+// When introducing a newer envelope, add it to the case below.
+func envelopeCarriesUserLibraries(envelopeVersion string) bool {
+	switch envelopeVersion {
+	case "1.9":
 		return true
 	default:
 		return false
@@ -734,6 +759,10 @@ func (s *Service) ImportWithOptions(ctx context.Context, env *Envelope, passphra
 	if err := s.importLibraries(ctx, tx, payload.Libraries, result); err != nil {
 		return nil, fmt.Errorf("importing libraries: %w", err)
 	}
+	// User library grants name libraries, so they wait for importLibraries.
+	if err := s.importUserLibraries(ctx, tx, payload.Users, envelopeCarriesUserLibraries(v)); err != nil {
+		return nil, fmt.Errorf("importing user libraries: %w", err)
+	}
 	// API tokens must run AFTER importUsers so the username -> user_id
 	// lookup sees the final user set, including any users just recreated
 	// from the envelope. The opts parameter controls the admin-fallback
@@ -884,7 +913,18 @@ func (s *Service) importUserPreferences(ctx context.Context, db dbExecutor, pref
 // deriveKey uses PBKDF2-SHA256 to derive a 32-byte AES-256 key from a
 // passphrase and salt.
 func deriveKey(passphrase string, salt []byte) []byte {
-	return pbkdf2.Key([]byte(passphrase), salt, pbkdf2Iterations, 32, sha256.New)
+	return DeriveKey(passphrase, salt, pbkdf2Iterations)
+}
+
+// PBKDF2Iterations is the iteration count other packages pass to DeriveKey.
+// Unlike the envelope, what they encrypt should record it, so a later change
+// (or a test lowering it) does not strand what was written before.
+const PBKDF2Iterations = defaultPBKDF2Iterations
+
+// DeriveKey derives a 32-byte AES-256 key from a passphrase and salt with
+// PBKDF2-SHA256, the KDF the settings envelope uses.
+func DeriveKey(passphrase string, salt []byte, iterations int) []byte {
+	return pbkdf2.Key([]byte(passphrase), salt, iterations, 32, sha256.New)
 }
 
 // encryptWithPassphrase encrypts plaintext using a passphrase-derived
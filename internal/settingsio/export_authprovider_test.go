@@ -7,9 +7,9 @@ import (
 )
 
 // canonicalAuthKeys mirrors authProviderDefaults from internal/api but is
-// expressed here as the test contract: every auth.providers.* setting that
-// the Settings > Auth Providers UI reads MUST round-trip through
-// Export -> wipe -> Import unchanged. The list is deliberately duplicated
+// expressed here as the test contract: every auth.providers.* setting (and
+// the local two-factor policy) that the Settings > Auth Providers UI reads
+// MUST round-trip through Export -> wipe -> Import unchanged. The list is deliberately duplicated
 // here (rather than imported from internal/api) because a regression that
 // drops a key from the seed list AND the test list at the same time would
 // be invisible -- duplicating the contract makes drift loud.
@@ -26,6 +26,7 @@ var canonicalAuthKeys = []string{
 	"auth.providers.oidc.enabled",
 	"auth.providers.oidc.auto_provision",
 	"auth.providers.oidc.default_role",
+	"auth.two_factor.required",
 }
 
 // nonDefaultValue returns a value for the given canonical auth key that is
@@ -53,6 +54,8 @@ func nonDefaultValue(key string) string {
 		"auth.providers.jellyfin.default_role",
 		"auth.providers.oidc.default_role":
 		return "administrator"
+	case "auth.two_factor.required":
+		return "everyone"
 	}
 	// Panic instead of returning a placeholder. A new entry in
 	// canonicalAuthKeys without a matching switch arm here is a test bug:
@@ -150,6 +153,7 @@ func TestRoundTrip_AuthProviderKeys_SeededDefaults(t *testing.T) {
 		"auth.providers.oidc.enabled":            "false",
 		"auth.providers.oidc.auto_provision":     "false",
 		"auth.providers.oidc.default_role":       "operator",
+		"auth.two_factor.required":               "off",
 	}
 	// Parity guard: a new entry in canonicalAuthKeys with no matching default
 	// here would silently skip seeding for that key, masking the very drift
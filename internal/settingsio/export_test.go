@@ -1116,8 +1116,8 @@ func TestRoundTrip_LibrariesAndTokens(t *testing.T) {
 	now := time.Now().UTC().Format(time.RFC3339)
 	manualID := "lib-manual"
 	if _, err := db.ExecContext(ctx, `
-		INSERT INTO libraries (id, name, path, type, source, connection_id, external_id, fs_watch, fs_poll_interval, nfo_lock_data, created_at, updated_at)
-		VALUES (?, 'Manual Music', '/srv/music', 'regular', 'manual', NULL, '', 1, 60, 1, ?, ?)`,
+		INSERT INTO libraries (id, name, path, type, source, connection_id, external_id, fs_watch, fs_poll_interval, nfo_lock_data, layout, created_at, updated_at)
+		VALUES (?, 'Manual Music', '/srv/music', 'regular', 'manual', NULL, '', 1, 60, 1, '{letter}/{artist}', ?, ?)`,
 		manualID, now, now); err != nil {
 		t.Fatalf("seeding manual library: %v", err)
 	}
@@ -1249,6 +1249,14 @@ func TestRoundTrip_LibrariesAndTokens(t *testing.T) {
 	if !importedConnID.Valid || importedConnID.String != c2.ID {
 		t.Errorf("connection_id remap: got %v, want %s", importedConnID, c2.ID)
 	}
+	var importedLayout string
+	if err := db2.QueryRowContext(ctx,
+		`SELECT layout FROM libraries WHERE name = 'Manual Music'`).Scan(&importedLayout); err != nil {
+		t.Fatalf("scanning imported manual library: %v", err)
+	}
+	if importedLayout != "{letter}/{artist}" {
+		t.Errorf("layout: got %q, want {letter}/{artist}", importedLayout)
+	}
 	var (
 		gotHash   string
 		gotUserID string
@@ -209,3 +209,66 @@ func TestImportConnections_SupportedTypesNotCounted(t *testing.T) {
 		})
 	}
 }
+
+// TestImportConnections_SubsonicAppliesTriggerRefreshOnly covers the partial
+// type: Subsonic owns trigger refresh, so that field is applied and not
+// counted, while image write and metadata push are counted as ignored.
+func TestImportConnections_SubsonicAppliesTriggerRefreshOnly(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	provSettings, connSvc, platSvc, whSvc := newTestServices(t, db)
+	svc := NewService(db, provSettings, connSvc, platSvc, whSvc)
+
+	conns := []ConnectionExport{{
+		Name: "Navidrome", Type: "subsonic", URL: "http://navidrome.local:4533",
+		APIKey: "alice:secret", Enabled: true,
+		FeatureImageWrite:     true,
+		FeatureMetadataPush:   true,
+		FeatureTriggerRefresh: true,
+	}}
+	result := &ImportResult{}
+	if err := svc.importConnections(ctx, db, conns, result, true, true); err != nil {
+		t.Fatalf("importConnections: %v", err)
+	}
+	if result.ConnectionFeaturesIgnored != 2 {
+		t.Errorf("ConnectionFeaturesIgnored = %d, want 2", result.ConnectionFeaturesIgnored)
+	}
+	all, err := connSvc.List(ctx)
+	if err != nil {
+		t.Fatalf("listing connections: %v", err)
+	}
+	if len(all) != 1 || !all[0].GetFeatureTriggerRefresh() {
+		t.Errorf("trigger refresh not applied: %+v", all)
+	}
+}
+
+// TestImportConnections_KodiAppliesAllToggles: Kodi owns the full set of
+// three toggles, so none is counted as ignored and each lands on the row.
+func TestImportConnections_KodiAppliesAllToggles(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	provSettings, connSvc, platSvc, whSvc := newTestServices(t, db)
+	svc := NewService(db, provSettings, connSvc, platSvc, whSvc)
+
+	conns := []ConnectionExport{{
+		Name: "Kodi", Type: "kodi", URL: "http://kodi.local:8080",
+		APIKey: "kodi:secret", Enabled: true,
+		FeatureImageWrite:     true,
+		FeatureMetadataPush:   true,
+		FeatureTriggerRefresh: true,
+	}}
+	result := &ImportResult{}
+	if err := svc.importConnections(ctx, db, conns, result, true, true); err != nil {
+		t.Fatalf("importConnections: %v", err)
+	}
+	if result.ConnectionFeaturesIgnored != 0 {
+		t.Errorf("ConnectionFeaturesIgnored = %d, want 0", result.ConnectionFeaturesIgnored)
+	}
+	all, err := connSvc.List(ctx)
+	if err != nil {
+		t.Fatalf("listing connections: %v", err)
+	}
+	if len(all) != 1 || !all[0].GetFeatureImageWrite() || !all[0].GetFeatureMetadataPush() || !all[0].GetFeatureTriggerRefresh() {
+		t.Errorf("toggles not applied: %+v", all)
+	}
+}
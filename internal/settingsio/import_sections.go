@@ -215,16 +215,21 @@ func (s *Service) importProviderKeys(ctx context.Context, db dbExecutor, keys ma
 // indistinguishable on the wire, and export emits false for all three on every
 // unsupported-type row. Keying on presence would report an ignored field for
 // every ordinary Lidarr envelope.
+//
+// Subsonic has the trigger-refresh toggle alone, so it is judged per toggle.
 func countIgnoredFeatureToggles(ce *ConnectionExport) int {
 	if connection.SupportsFeatureToggles(ce.Type) {
 		return 0
 	}
 	ignored := 0
-	for _, on := range []bool{ce.FeatureImageWrite, ce.FeatureMetadataPush, ce.FeatureTriggerRefresh} {
+	for _, on := range []bool{ce.FeatureImageWrite, ce.FeatureMetadataPush} {
 		if on {
 			ignored++
 		}
 	}
+	if ce.FeatureTriggerRefresh && !connection.SupportsTriggerRefresh(ce.Type) {
+		ignored++
+	}
 	if ignored > 0 {
 		slog.Warn("import: ignoring connection feature toggles not supported by this connection type",
 			"connection_name", ce.Name, "connection_type", ce.Type, "ignored_count", ignored)
@@ -387,6 +392,38 @@ func applyExportConfig(conn *connection.Connection, ce ConnectionExport, gateV14
 		if conn.Jellyfin.PlatformServerID == "" {
 			conn.Jellyfin.PlatformServerID = ce.PlatformServerID
 		}
+	case connection.TypePlex:
+		// Plex has no user ID; the envelope's PlatformUserID is ignored.
+		if conn.Plex == nil {
+			conn.Plex = &connection.PlexConfig{}
+		}
+		conn.Plex.FeatureImageWrite = ce.FeatureImageWrite
+		if gateV14 {
+			conn.Plex.FeatureMetadataPush = ce.FeatureMetadataPush
+			conn.Plex.FeatureTriggerRefresh = ce.FeatureTriggerRefresh
+		}
+		if conn.Plex.PlatformServerID == "" {
+			conn.Plex.PlatformServerID = ce.PlatformServerID
+		}
+	case connection.TypeSubsonic:
+		// Trigger refresh is Subsonic's only toggle and a v1.4 field.
+		if conn.Subsonic == nil {
+			conn.Subsonic = &connection.SubsonicConfig{}
+		}
+		if gateV14 {
+			conn.Subsonic.FeatureTriggerRefresh = ce.FeatureTriggerRefresh
+		}
+	case connection.TypeKodi:
+		// Kodi has neither a user nor a server ID; both envelope fields are
+		// ignored.
+		if conn.Kodi == nil {
+			conn.Kodi = &connection.KodiConfig{}
+		}
+		conn.Kodi.FeatureImageWrite = ce.FeatureImageWrite
+		if gateV14 {
+			conn.Kodi.FeatureMetadataPush = ce.FeatureMetadataPush
+			conn.Kodi.FeatureTriggerRefresh = ce.FeatureTriggerRefresh
+		}
 	}
 }
 
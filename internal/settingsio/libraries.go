@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -30,6 +31,7 @@ type LibraryExport struct {
 	FSWatch        int    `json:"fs_watch"`
 	FSPollInterval int    `json:"fs_poll_interval"`
 	NFOLockData    bool   `json:"nfo_lock_data,omitempty"`
+	Layout         string `json:"layout,omitempty"`
 }
 
 // exportLibraries reads every row from the libraries table joined to its
@@ -39,7 +41,7 @@ func (s *Service) exportLibraries(ctx context.Context) ([]LibraryExport, error)
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT l.name, l.path, l.type, l.source,
 		       COALESCE(c.type, ''), COALESCE(c.url, ''),
-		       l.external_id, l.fs_watch, l.fs_poll_interval, l.nfo_lock_data
+		       l.external_id, l.fs_watch, l.fs_poll_interval, l.nfo_lock_data, l.layout
 		FROM libraries l
 		LEFT JOIN connections c ON c.id = l.connection_id
 		ORDER BY l.name
@@ -58,7 +60,7 @@ func (s *Service) exportLibraries(ctx context.Context) ([]LibraryExport, error)
 		if err := rows.Scan(
 			&le.Name, &le.Path, &le.Type, &le.Source,
 			&le.ConnectionType, &le.ConnectionURL,
-			&le.ExternalID, &le.FSWatch, &le.FSPollInterval, &nfoLockInt,
+			&le.ExternalID, &le.FSWatch, &le.FSPollInterval, &nfoLockInt, &le.Layout,
 		); err != nil {
 			return nil, fmt.Errorf("scanning library row: %w", err)
 		}
@@ -181,13 +183,13 @@ func (s *Service) importLibraries(ctx context.Context, db dbExecutor, libs []Lib
 			if _, err := db.ExecContext(ctx, `
 				INSERT INTO libraries (
 					id, name, path, type, source, connection_id, external_id,
-					fs_watch, fs_poll_interval, nfo_lock_data, created_at, updated_at
-				) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+					fs_watch, fs_poll_interval, nfo_lock_data, layout, created_at, updated_at
+				) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 			`,
 				id, le.Name, le.Path, validLibraryType(le.Type),
 				source, dbutil.NullableString(connectionID), le.ExternalID,
 				validFSWatch(le.FSWatch), validPollInterval(le.FSPollInterval),
-				boolToInt(le.NFOLockData), now, now,
+				boolToInt(le.NFOLockData), validLayout(le.Layout), now, now,
 			); err != nil {
 				return fmt.Errorf("inserting library %q: %w", le.Name, err)
 			}
@@ -204,13 +206,13 @@ func (s *Service) importLibraries(ctx context.Context, db dbExecutor, libs []Lib
 			if _, err := db.ExecContext(ctx, `
 				UPDATE libraries SET
 					name = ?, path = ?, type = ?, source = ?, connection_id = ?, external_id = ?,
-					fs_watch = ?, fs_poll_interval = ?, nfo_lock_data = ?, updated_at = ?
+					fs_watch = ?, fs_poll_interval = ?, nfo_lock_data = ?, layout = ?, updated_at = ?
 				WHERE id = ?
 			`,
 				le.Name, le.Path, validLibraryType(le.Type),
 				source, dbutil.NullableString(connectionID), le.ExternalID,
 				validFSWatch(le.FSWatch), validPollInterval(le.FSPollInterval),
-				boolToInt(le.NFOLockData), now, existingID,
+				boolToInt(le.NFOLockData), validLayout(le.Layout), now, existingID,
 			); err != nil {
 				return fmt.Errorf("updating library %q: %w", le.Name, err)
 			}
@@ -237,7 +239,7 @@ func validLibraryType(t string) string {
 // outright.
 func validLibrarySource(s string) string {
 	switch s {
-	case "manual", "emby", "jellyfin", "lidarr":
+	case "manual", "emby", "jellyfin", "lidarr", "plex", "subsonic", "kodi":
 		return s
 	default:
 		return "manual"
@@ -257,6 +259,23 @@ func validPollInterval(v int) int {
 	}
 }
 
+// validLayout clamps an imported layout template to one library.ValidateLayout
+// accepts in canonical form, falling back to the flat layout (""). Mirrors
+// that function for the same import-cycle reason as validPollInterval, and
+// must stay in sync with it.
+func validLayout(layout string) string {
+	levels := strings.Split(layout, "/")
+	if len(levels) < 2 || len(levels) > 4 || levels[len(levels)-1] != "{artist}" {
+		return ""
+	}
+	for _, level := range levels[:len(levels)-1] {
+		if level != "{letter}" && level != "{group}" {
+			return ""
+		}
+	}
+	return layout
+}
+
 // validFSWatch clamps the imported fs_watch flag to the canonical 0/1 the
 // schema stores. The column is declared INTEGER without a CHECK, so a tampered
 // or future-version export carrying any non-zero value would otherwise be
@@ -6,8 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/sydlexius/stillwater/internal/auth"
 	"github.com/sydlexius/stillwater/internal/dbutil"
 )
 
@@ -24,16 +26,23 @@ import (
 // would silently skip every token belonging to that user. UserID is
 // omitempty so pre-1.4 envelopes (which never had it) still decode and the
 // importer falls back to the username path for those rows.
+//
+// From v1.8 a token restricted to specific libraries carries them in
+// Libraries, by library name: library ids are generated per instance, and
+// libraries are matched by name on import (see importLibraries). An entry
+// that no longer names a library is carried as the raw id instead, so the
+// restriction is never silently dropped on the way out.
 type APITokenExport struct {
-	Name       string `json:"name"`
-	TokenHash  string `json:"token_hash"`
-	Scopes     string `json:"scopes"`
-	UserID     string `json:"user_id,omitempty"`
-	Username   string `json:"username"`
-	CreatedAt  string `json:"created_at"`
-	LastUsedAt string `json:"last_used_at,omitempty"`
-	RevokedAt  string `json:"revoked_at,omitempty"`
-	Status     string `json:"status"`
+	Name       string   `json:"name"`
+	TokenHash  string   `json:"token_hash"`
+	Scopes     string   `json:"scopes"`
+	Libraries  []string `json:"libraries,omitempty"`
+	UserID     string   `json:"user_id,omitempty"`
+	Username   string   `json:"username"`
+	CreatedAt  string   `json:"created_at"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	RevokedAt  string   `json:"revoked_at,omitempty"`
+	Status     string   `json:"status"`
 }
 
 // exportAPITokens reads every api_tokens row joined to its owner's username
@@ -42,7 +51,7 @@ type APITokenExport struct {
 // source survives the round-trip rather than being silently re-activated.
 func (s *Service) exportAPITokens(ctx context.Context) ([]APITokenExport, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT t.name, t.token_hash, t.scopes, u.id, u.username,
+		SELECT t.name, t.token_hash, t.scopes, t.library_ids, u.id, u.username,
 		       t.created_at, COALESCE(t.last_used_at, ''),
 		       COALESCE(t.revoked_at, ''), t.status
 		FROM api_tokens t
@@ -55,22 +64,72 @@ func (s *Service) exportAPITokens(ctx context.Context) ([]APITokenExport, error)
 	defer rows.Close() //nolint:errcheck // Close error not actionable on cleanup
 
 	var out []APITokenExport
+	var libraryIDs []string
 	for rows.Next() {
 		var te APITokenExport
+		var ids string
 		if err := rows.Scan(
-			&te.Name, &te.TokenHash, &te.Scopes, &te.UserID, &te.Username,
+			&te.Name, &te.TokenHash, &te.Scopes, &ids, &te.UserID, &te.Username,
 			&te.CreatedAt, &te.LastUsedAt, &te.RevokedAt, &te.Status,
 		); err != nil {
 			return nil, fmt.Errorf("scanning api token row: %w", err)
 		}
 		out = append(out, te)
+		libraryIDs = append(libraryIDs, ids)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterating api token rows: %w", err)
 	}
+	// Name the restricted libraries only after the token rows are closed:
+	// the export may run on a single-connection pool.
+	names, err := s.libraryNamesByID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, ids := range libraryIDs {
+		out[i].Libraries = libraryRefs(ids, names)
+	}
 	return out, nil
 }
 
+// libraryRefs turns a library_ids column value into the names the export
+// carries, falling back to the raw id for an entry that no longer names a
+// library.
+func libraryRefs(ids string, names map[string]string) []string {
+	var refs []string
+	for _, id := range auth.ParseLibraryIDs(ids) {
+		if name, ok := names[id]; ok {
+			refs = append(refs, name)
+		} else {
+			refs = append(refs, id)
+		}
+	}
+	return refs
+}
+
+// libraryNamesByID maps every library id to its name for the token and user
+// exports.
+func (s *Service) libraryNamesByID(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, name FROM libraries`)
+	if err != nil {
+		return nil, fmt.Errorf("querying library names: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck // Close error not actionable on cleanup
+
+	names := make(map[string]string)
+	for rows.Next() {
+		var id, name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, fmt.Errorf("scanning library name: %w", err)
+		}
+		names[id] = name
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating library names: %w", err)
+	}
+	return names, nil
+}
+
 // importAPITokens upserts API tokens by token_hash (which is UNIQUE in the
 // schema). The owning user is resolved with a two-step probe: id first
 // (stable across installs once the Users block has restored source ids),
@@ -117,6 +176,21 @@ func (s *Service) importAPITokens(ctx context.Context, db dbExecutor, tokens []A
 			continue
 		}
 
+		// A library restriction that cannot be reproduced here must not
+		// turn into "every library": import the token revoked instead, so
+		// an operator re-enables it deliberately after fixing the
+		// restriction (or issues a new one).
+		libraryIDs, resolved, err := resolveLibraryRefs(ctx, db, te.Libraries)
+		if err != nil {
+			return err
+		}
+		status := validTokenStatus(te.Status)
+		if !resolved {
+			slog.Warn("import: api token restricted to a library missing on this instance; importing it revoked",
+				"name", te.Name, "libraries", te.Libraries)
+			status = "revoked"
+		}
+
 		// Upsert by token_hash. We look up first (instead of using ON CONFLICT)
 		// so the existing row's id is preserved and only metadata is updated;
 		// re-importing the same export is therefore idempotent without
@@ -130,15 +204,15 @@ func (s *Service) importAPITokens(ctx context.Context, db dbExecutor, tokens []A
 			id := uuid.New().String()
 			if _, err := db.ExecContext(ctx, `
 				INSERT INTO api_tokens (
-					id, name, token_hash, scopes, user_id,
+					id, name, token_hash, scopes, library_ids, user_id,
 					created_at, last_used_at, revoked_at, status
-				) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+				) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 			`,
-				id, te.Name, te.TokenHash, validTokenScopes(te.Scopes), userID,
+				id, te.Name, te.TokenHash, validTokenScopes(te.Scopes), libraryIDs, userID,
 				te.CreatedAt,
 				dbutil.NullableString(te.LastUsedAt),
 				dbutil.NullableString(te.RevokedAt),
-				validTokenStatus(te.Status),
+				status,
 			); err != nil {
 				return fmt.Errorf("inserting api token %q: %w", te.Name, err)
 			}
@@ -151,14 +225,14 @@ func (s *Service) importAPITokens(ctx context.Context, db dbExecutor, tokens []A
 			// timestamp, drifting from the source instance's record.
 			if _, err := db.ExecContext(ctx, `
 				UPDATE api_tokens SET
-					name = ?, scopes = ?, user_id = ?, created_at = ?,
+					name = ?, scopes = ?, library_ids = ?, user_id = ?, created_at = ?,
 					last_used_at = ?, revoked_at = ?, status = ?
 				WHERE id = ?
 			`,
-				te.Name, validTokenScopes(te.Scopes), userID, te.CreatedAt,
+				te.Name, validTokenScopes(te.Scopes), libraryIDs, userID, te.CreatedAt,
 				dbutil.NullableString(te.LastUsedAt),
 				dbutil.NullableString(te.RevokedAt),
-				validTokenStatus(te.Status),
+				status,
 				existingID,
 			); err != nil {
 				return fmt.Errorf("updating api token %q: %w", te.Name, err)
@@ -249,6 +323,32 @@ func (s *Service) resolveTokenOwner(ctx context.Context, db dbExecutor, te *APIT
 	return "", true, nil
 }
 
+// resolveLibraryRefs maps an exported token's or user's library names to
+// this instance's library ids, returning them comma-joined for the
+// library_ids column. Each entry is looked up by name (libraries are imported
+// by name before tokens and user grants), then as a raw id (the export's
+// fallback for an entry it could not name). resolved is false when any entry
+// matches neither; that entry is kept as-is so the grant stays restricted
+// (to nothing, for that entry) rather than losing the restriction.
+func resolveLibraryRefs(ctx context.Context, db dbExecutor, libraries []string) (ids string, resolved bool, err error) {
+	out := make([]string, 0, len(libraries))
+	resolved = true
+	for _, ref := range libraries {
+		var id string
+		err := db.QueryRowContext(ctx,
+			`SELECT id FROM libraries WHERE name = ? OR id = ? ORDER BY name = ? DESC LIMIT 1`, ref, ref, ref,
+		).Scan(&id)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			id, resolved = ref, false
+		case err != nil:
+			return "", false, fmt.Errorf("resolving library %q: %w", ref, err)
+		}
+		out = append(out, id)
+	}
+	return strings.Join(out, ","), resolved, nil
+}
+
 // validTokenScopes falls back to the schema default when the import payload
 // carries an empty scope string. The schema's NOT NULL DEFAULT 'read,write'
 // would not apply on an explicit "" value, so we apply the same default here.
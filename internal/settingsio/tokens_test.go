@@ -349,3 +349,83 @@ func TestImport_TokenIDFirst_FollowsRenamedUser(t *testing.T) {
 		t.Errorf("APITokens: got %d, want 1", res.APITokens)
 	}
 }
+
+// TestImport_TokenLibraryRestriction_RemapsByName pins the v1.8 library
+// restriction: the export names the token's libraries, and the import maps
+// them to the target's ids for the same-named libraries.
+func TestImport_TokenLibraryRestriction_RemapsByName(t *testing.T) {
+	hash := "token-hash-library-restricted"
+	srcSvc, ctx := seedTokenSource(t, hash)
+	now := time.Now().UTC().Format(time.RFC3339)
+	if _, err := srcSvc.db.ExecContext(ctx, `
+		INSERT INTO libraries (id, name, path, type, source, connection_id, external_id, fs_watch, fs_poll_interval, nfo_lock_data, created_at, updated_at)
+		VALUES ('lib-src', 'Tagged Music', '/srv/tagged', 'regular', 'manual', NULL, '', 0, 60, 0, ?, ?)`,
+		now, now); err != nil {
+		t.Fatalf("seeding source library: %v", err)
+	}
+	if _, err := srcSvc.db.ExecContext(ctx,
+		`UPDATE api_tokens SET library_ids = 'lib-src' WHERE token_hash = ?`, hash); err != nil {
+		t.Fatalf("restricting source token: %v", err)
+	}
+
+	envelope, err := srcSvc.Export(ctx, "pp")
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	// The target already has a library of that name under another id.
+	db2 := setupTestDB(t)
+	if _, err := db2.ExecContext(ctx, `
+		INSERT INTO libraries (id, name, path, type, source, connection_id, external_id, fs_watch, fs_poll_interval, nfo_lock_data, created_at, updated_at)
+		VALUES ('lib-dst', 'Tagged Music', '/srv/tagged', 'regular', 'manual', NULL, '', 0, 60, 0, ?, ?)`,
+		now, now); err != nil {
+		t.Fatalf("seeding target library: %v", err)
+	}
+	provSettings2, connSvc2, platSvc2, whSvc2 := newTestServices(t, db2)
+	svc2 := NewService(db2, provSettings2, connSvc2, platSvc2, whSvc2)
+	if _, err := svc2.Import(ctx, envelope, "pp"); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	var libraryIDs, status string
+	if err := db2.QueryRowContext(ctx,
+		`SELECT library_ids, status FROM api_tokens WHERE token_hash = ?`, hash).Scan(&libraryIDs, &status); err != nil {
+		t.Fatalf("looking up imported token: %v", err)
+	}
+	if libraryIDs != "lib-dst" || status != "active" {
+		t.Errorf("imported token: library_ids=%q status=%q, want lib-dst/active", libraryIDs, status)
+	}
+}
+
+// TestImport_TokenLibraryRestriction_UnresolvedImportsRevoked pins the
+// fail-closed path: a restriction naming a library the target cannot match
+// must not widen the token to every library, so the token arrives revoked.
+func TestImport_TokenLibraryRestriction_UnresolvedImportsRevoked(t *testing.T) {
+	hash := "token-hash-library-missing"
+	srcSvc, ctx := seedTokenSource(t, hash)
+	if _, err := srcSvc.db.ExecContext(ctx,
+		`UPDATE api_tokens SET library_ids = 'lib-gone' WHERE token_hash = ?`, hash); err != nil {
+		t.Fatalf("restricting source token: %v", err)
+	}
+
+	envelope, err := srcSvc.Export(ctx, "pp")
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	db2 := setupTestDB(t)
+	provSettings2, connSvc2, platSvc2, whSvc2 := newTestServices(t, db2)
+	svc2 := NewService(db2, provSettings2, connSvc2, platSvc2, whSvc2)
+	if _, err := svc2.Import(ctx, envelope, "pp"); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	var libraryIDs, status string
+	if err := db2.QueryRowContext(ctx,
+		`SELECT library_ids, status FROM api_tokens WHERE token_hash = ?`, hash).Scan(&libraryIDs, &status); err != nil {
+		t.Fatalf("looking up imported token: %v", err)
+	}
+	if status != "revoked" || libraryIDs != "lib-gone" {
+		t.Errorf("imported token: library_ids=%q status=%q, want lib-gone/revoked", libraryIDs, status)
+	}
+}
@@ -40,21 +40,26 @@ import (
 // the wire inside the passphrase-encrypted envelope. Federated-only users
 // have an empty hash (their schema row already stores the empty string).
 //
+// Libraries (v1.9+) is the user's library grant, carried by library name
+// like APITokenExport.Libraries. It is applied by importUserLibraries once
+// the libraries themselves have been imported.
+//
 // AvatarURL is intentionally not yet present in the users table; reserved
 // for forward-compat. Session and remember-me tokens are NEVER exported --
 // they live in the sessions table which is not touched by export.
 type UserExport struct {
-	ID           string `json:"id"`
-	Username     string `json:"username"`
-	DisplayName  string `json:"display_name,omitempty"`
-	PasswordHash string `json:"password_hash,omitempty"`
-	Role         string `json:"role"`
-	AuthProvider string `json:"auth_provider,omitempty"`
-	ProviderID   string `json:"provider_id,omitempty"`
-	IsActive     bool   `json:"is_active"`
-	IsProtected  bool   `json:"is_protected,omitempty"`
-	AvatarURL    string `json:"avatar_url,omitempty"`
-	CreatedAt    string `json:"created_at"`
+	ID           string   `json:"id"`
+	Username     string   `json:"username"`
+	DisplayName  string   `json:"display_name,omitempty"`
+	PasswordHash string   `json:"password_hash,omitempty"`
+	Role         string   `json:"role"`
+	AuthProvider string   `json:"auth_provider,omitempty"`
+	ProviderID   string   `json:"provider_id,omitempty"`
+	IsActive     bool     `json:"is_active"`
+	IsProtected  bool     `json:"is_protected,omitempty"`
+	Libraries    []string `json:"libraries,omitempty"`
+	AvatarURL    string   `json:"avatar_url,omitempty"`
+	CreatedAt    string   `json:"created_at"`
 }
 
 // ErrUserIDCollision is returned by importUsers when an envelope user's
@@ -68,7 +73,7 @@ var ErrUserIDCollision = errors.New("import: username collision under a differen
 func (s *Service) exportUsers(ctx context.Context) ([]UserExport, error) {
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT id, username, display_name, password_hash, role, auth_provider,
-		       provider_id, is_active, is_protected, created_at
+		       provider_id, is_active, is_protected, library_ids, created_at
 		FROM users
 		ORDER BY created_at, username
 	`)
@@ -78,22 +83,34 @@ func (s *Service) exportUsers(ctx context.Context) ([]UserExport, error) {
 	defer rows.Close() //nolint:errcheck // Close error not actionable on cleanup
 
 	var out []UserExport
+	var libraryIDs []string
 	for rows.Next() {
 		var row UserExport
 		var isActive, isProtected int
+		var ids string
 		if err := rows.Scan(
 			&row.ID, &row.Username, &row.DisplayName, &row.PasswordHash, &row.Role,
-			&row.AuthProvider, &row.ProviderID, &isActive, &isProtected, &row.CreatedAt,
+			&row.AuthProvider, &row.ProviderID, &isActive, &isProtected, &ids, &row.CreatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("scanning user row: %w", err)
 		}
 		row.IsActive = isActive != 0
 		row.IsProtected = isProtected != 0
 		out = append(out, row)
+		libraryIDs = append(libraryIDs, ids)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterating user rows: %w", err)
 	}
+	// Same single-connection caveat as exportAPITokens: name the granted
+	// libraries only after the user rows are closed.
+	names, err := s.libraryNamesByID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, ids := range libraryIDs {
+		out[i].Libraries = libraryRefs(ids, names)
+	}
 	return out, nil
 }
 
@@ -278,18 +295,80 @@ func (s *Service) importOneUser(ctx context.Context, db dbExecutor, u *UserExpor
 	return nil
 }
 
+// importUserLibraries applies the envelope users' library grants. It runs
+// after importLibraries so names resolve to this instance's library ids, and
+// only for envelopes that carry grants (v1.9+): an older envelope says
+// nothing about them, so the target's existing grants are left alone rather
+// than cleared. A grant naming a library missing here keeps the raw entry,
+// which matches nothing, so the user stays restricted instead of gaining
+// every library. Administrators are never restricted and are skipped.
+func (s *Service) importUserLibraries(ctx context.Context, db dbExecutor, users []UserExport, carriesGrants bool) error {
+	if !carriesGrants {
+		return nil
+	}
+	for i := range users {
+		u := &users[i]
+		if u.ID == "" || normalizeImportRole(u.Role) == "administrator" {
+			continue
+		}
+		libraryIDs, resolved, err := resolveLibraryRefs(ctx, db, u.Libraries)
+		if err != nil {
+			return fmt.Errorf("resolving libraries for user %q: %w", u.Username, err)
+		}
+		if !resolved {
+			slog.Warn("import: user granted a library missing on this instance; keeping the unresolved entry",
+				"username", u.Username)
+		}
+		if _, err := db.ExecContext(ctx,
+			`UPDATE users SET library_ids = ? WHERE id = ? AND role != 'administrator'`,
+			libraryIDs, u.ID,
+		); err != nil {
+			return fmt.Errorf("updating libraries for user %q: %w", u.Username, err)
+		}
+	}
+	return nil
+}
+
 // updateUserByID issues either the narrow (protected target) or full
 // UPDATE. The schema's prevent_role_change_protected_user trigger aborts
 // any UPDATE that touches role on a protected row, so when the target row
 // is protected we issue a narrower UPDATE that leaves role + is_active
 // alone. Identical-value UPDATE statements still fire the trigger.
+//
+// When the envelope changes the user's password hash, the user's sessions
+// are revoked, as a password reset does: whoever is signed in with the old
+// password is signed out.
 func (s *Service) updateUserByID(ctx context.Context, db dbExecutor, u *UserExport, sourceID, now, role, authProvider string, isActive int) error {
 	var targetProtected int
+	var targetHash string
 	if err := db.QueryRowContext(ctx,
-		`SELECT is_protected FROM users WHERE id = ?`, sourceID).Scan(&targetProtected); err != nil {
+		`SELECT is_protected, password_hash FROM users WHERE id = ?`, sourceID).Scan(&targetProtected, &targetHash); err != nil {
 		return fmt.Errorf("reading is_protected for user %q: %w", u.Username, err)
 	}
-	if targetProtected == 1 {
+	if err := s.writeUserByID(ctx, db, u, sourceID, now, role, authProvider, isActive, targetProtected == 1); err != nil {
+		return err
+	}
+	if u.PasswordHash == targetHash {
+		return nil
+	}
+	// Same statement as auth.Service.RevokeUserSessions, issued on the
+	// import transaction: that service writes through its own handle,
+	// which would wait on this transaction's write lock.
+	res, err := db.ExecContext(ctx, `DELETE FROM sessions WHERE user_id = ?`, sourceID)
+	if err != nil {
+		return fmt.Errorf("revoking sessions of user %q: %w", u.Username, err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		slog.Info("import: password changed; signed out existing sessions",
+			"username", u.Username, "sessions", n)
+	}
+	return nil
+}
+
+// writeUserByID issues the UPDATE for updateUserByID, narrowed when the
+// target row is protected.
+func (s *Service) writeUserByID(ctx context.Context, db dbExecutor, u *UserExport, sourceID, now, role, authProvider string, isActive int, protected bool) error {
+	if protected {
 		if _, err := db.ExecContext(ctx, `
 			UPDATE users SET
 				display_name  = ?,
@@ -325,7 +404,8 @@ func (s *Service) updateUserByID(ctx context.Context, db dbExecutor, u *UserExpo
 	return nil
 }
 
-// normalizeImportRole fails closed to operator (least privilege) so a
+// normalizeImportRole fails closed to operator (least privilege short of the
+// read-only viewer, which must be asked for by name) so a
 // tampered or future-version role cannot silently grant Administrator.
 // "admin" is folded onto the canonical "administrator" because the rest
 // of the schema only knows the long form; the short alias is a
@@ -337,6 +417,8 @@ func normalizeImportRole(role string) string {
 		return "administrator"
 	case "operator":
 		return "operator"
+	case "viewer":
+		return "viewer"
 	default:
 		return "operator"
 	}
@@ -238,6 +238,57 @@ func TestImport_UsersIDMatchUpdates(t *testing.T) {
 	}
 }
 
+// TestImport_PasswordChangeRevokesSessions verifies that an id-hit import
+// which changes a user's password hash signs that user out, as a password
+// reset does, while a user whose hash is unchanged keeps their sessions.
+func TestImport_PasswordChangeRevokesSessions(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	provSettings, connSvc, platSvc, whSvc := newTestServices(t, db)
+
+	const seedUsers = `
+		INSERT INTO users (id, username, password_hash, role, auth_provider,
+		                   is_active, is_protected, created_at)
+		VALUES ('u-admin', 'admin', 'same-hash', 'administrator', 'local', 1, 1, '2026-01-01T00:00:00Z'),
+		       ('u-ops', 'ops', ?, 'operator', 'local', 1, 0, '2026-01-01T00:00:00Z')
+	`
+	if _, err := db.ExecContext(ctx, seedUsers, "new-hash"); err != nil {
+		t.Fatalf("seeding source users: %v", err)
+	}
+	envelope, err := NewService(db, provSettings, connSvc, platSvc, whSvc).Export(ctx, "p")
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	db2 := setupTestDB(t)
+	provSettings2, connSvc2, platSvc2, whSvc2 := newTestServices(t, db2)
+	if _, err := db2.ExecContext(ctx, seedUsers, "stale-hash"); err != nil {
+		t.Fatalf("seeding target users: %v", err)
+	}
+	if _, err := db2.ExecContext(ctx, `
+		INSERT INTO sessions (id, user_id, expires_at)
+		VALUES ('s-admin', 'u-admin', '2099-01-01T00:00:00Z'),
+		       ('s-ops', 'u-ops', '2099-01-01T00:00:00Z')
+	`); err != nil {
+		t.Fatalf("seeding sessions: %v", err)
+	}
+
+	if _, err := NewService(db2, provSettings2, connSvc2, platSvc2, whSvc2).Import(ctx, envelope, "p"); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	for userID, want := range map[string]int{"u-admin": 1, "u-ops": 0} {
+		var n int
+		if err := db2.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM sessions WHERE user_id = ?`, userID).Scan(&n); err != nil {
+			t.Fatalf("counting sessions of %s: %v", userID, err)
+		}
+		if n != want {
+			t.Errorf("sessions of %s: got %d, want %d", userID, n, want)
+		}
+	}
+}
+
 // TestImport_PreV14UserUsernameCollisionSkips pins the v1.3 backward
 // compatibility behavior: when an envelope user row carries an empty id
 // (pre-1.4 envelope) and the target already has a row with the same
@@ -306,3 +357,49 @@ func TestImport_PreV14UserUsernameCollisionSkips(t *testing.T) {
 		t.Errorf("UsersImported: got %d, want 0 (skipped row must not count)", result.UsersImported)
 	}
 }
+
+// TestImport_UserLibraryGrant_RemapsByName pins the v1.9 user grant: a
+// viewer keeps its role, and its library grant is carried by name and mapped
+// to the target's id for the same-named library.
+func TestImport_UserLibraryGrant_RemapsByName(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	provSettings, connSvc, platSvc, whSvc := newTestServices(t, db)
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO libraries (id, name, path, type, source, connection_id, external_id, fs_watch, fs_poll_interval, nfo_lock_data, created_at, updated_at)
+		VALUES ('lib-src', 'Kids Music', '/srv/kids', 'regular', 'manual', NULL, '', 0, 60, 0, '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z')`); err != nil {
+		t.Fatalf("seeding source library: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO users (id, username, display_name, password_hash, role,
+		                   auth_provider, is_active, is_protected, library_ids, created_at)
+		VALUES ('u-kid', 'kid', 'Kid', 'bcrypt$kid-hash', 'viewer', 'local', 1, 0, 'lib-src', '2026-02-01T00:00:00Z')
+	`); err != nil {
+		t.Fatalf("seeding viewer: %v", err)
+	}
+
+	envelope, err := NewService(db, provSettings, connSvc, platSvc, whSvc).Export(ctx, "pp")
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	db2 := setupTestDB(t)
+	if _, err := db2.ExecContext(ctx, `
+		INSERT INTO libraries (id, name, path, type, source, connection_id, external_id, fs_watch, fs_poll_interval, nfo_lock_data, created_at, updated_at)
+		VALUES ('lib-dst', 'Kids Music', '/srv/kids', 'regular', 'manual', NULL, '', 0, 60, 0, '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z')`); err != nil {
+		t.Fatalf("seeding target library: %v", err)
+	}
+	provSettings2, connSvc2, platSvc2, whSvc2 := newTestServices(t, db2)
+	if _, err := NewService(db2, provSettings2, connSvc2, platSvc2, whSvc2).Import(ctx, envelope, "pp"); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	var role, libraryIDs string
+	if err := db2.QueryRowContext(ctx,
+		`SELECT role, library_ids FROM users WHERE id = 'u-kid'`).Scan(&role, &libraryIDs); err != nil {
+		t.Fatalf("looking up imported user: %v", err)
+	}
+	if role != "viewer" || libraryIDs != "lib-dst" {
+		t.Errorf("imported user: role=%q library_ids=%q, want viewer/lib-dst", role, libraryIDs)
+	}
+}
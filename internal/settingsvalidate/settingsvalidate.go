@@ -40,11 +40,17 @@ var registry = map[string]validator{
 	"auth.method":                        validateEnum("auth.method", "local", "emby", "jellyfin"),
 	"server.base_path":                   validateBasePath,
 	"auth.providers.local.enabled":       validateLocalAuthEnabled,
+	"auth.two_factor.required":           validateEnum("auth.two_factor.required", "off", "administrators", "everyone"),
 	// Operational settings surfaced from env-only into the UI (#1746, #1753).
 	"rule_engine.artist_workers": validateIntRange("rule_engine.artist_workers", 1, 64),
 	"scanner.exclusions":         validateCSV,
 	"scanner.mtime_fast_path":    validateBool("scanner.mtime_fast_path"),
+	"scanner.tag_identity":       validateBool("scanner.tag_identity"),
 	"backup.interval_hours":      validatePositiveInt("backup.interval_hours"),
+	// Artwork revision retention. The keep bound mirrors artwork.MaxKeep; it is
+	// repeated rather than imported so this package stays dependency-free.
+	"artwork.revisions.keep":         validateIntRange("artwork.revisions.keep", 1, 100),
+	"artwork.revisions.max_age_days": validateNonNegativeInt("artwork.revisions.max_age_days"),
 	// MBID re-validation sweep (#2810, wired in #3003). These are read at boot
 	// by getDBIntSetting, which parses with fmt.Sscanf("%d") -- a parse that
 	// stops at the first non-digit and reports success. Without an entry here
@@ -2,9 +2,11 @@ package watcher
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,46 +20,81 @@ type LibraryLister interface {
 	List(ctx context.Context) ([]library.Library, error)
 }
 
-// Service watches library root directories for subdirectory creation and
-// removal, triggering scans and publishing events in response.
+// ScanFunc runs a scan for the watcher. dirs names the artist directories
+// that changed, were created or were removed; nil asks for a full scan of
+// every library. It returns ErrScanBusy, possibly wrapped, when a scan is
+// already running, and the watcher retries the same directories later.
+type ScanFunc func(ctx context.Context, dirs []string) error
+
+// ErrScanBusy is returned by a ScanFunc that could not start because another
+// scan is running.
+var ErrScanBusy = errors.New("a scan is already running")
+
+// Service watches library root directories for artist directories being
+// created and removed, and each artist directory for changes inside it. In a
+// nested layout such as {letter}/{artist} it watches the bucket directories
+// between the two as well, and treats a bucket created or removed as every
+// artist directory in it being created or removed. It
+// publishes events for the former and hands every affected artist directory
+// to a targeted scan. A full scan runs only for periodic reconciliation, or
+// when fsnotify drops events.
 type Service struct {
-	scanFn         func(ctx context.Context) error
-	libraries      LibraryLister
-	eventBus       *event.Bus
-	logger         *slog.Logger
-	debounce       time.Duration
-	refreshPeriod  time.Duration
-	probeCache     *ProbeCache
-	expectedWrites *ExpectedWrites
-
-	mu        sync.Mutex
-	watcher   *fsnotify.Watcher
-	watching  map[string]bool
-	knownDirs map[string]map[string]struct{} // root -> set of known subdirectory names
+	scanFn          ScanFunc
+	libraries       LibraryLister
+	eventBus        *event.Bus
+	logger          *slog.Logger
+	debounce        time.Duration
+	busyRetry       time.Duration
+	pollTick        time.Duration
+	refreshPeriod   time.Duration
+	reconcilePeriod time.Duration
+	probeCache      *ProbeCache
+	expectedWrites  *ExpectedWrites
+
+	mu         sync.Mutex
+	watcher    *fsnotify.Watcher
+	watching   map[string]bool
+	depths     map[string]int                 // watched root -> artist depth of its layout
+	buckets    map[string]string              // watched bucket directory -> its library root
+	knownDirs  map[string]map[string]struct{} // root or bucket -> set of known subdirectory names
+	artistDirs map[string]string              // watched artist directory -> its library root
+
+	// Scan state: the artist directories waiting for the debounce to
+	// elapse, and whether a full scan is wanted instead.
+	pendingDirs map[string]struct{}
+	pendingFull bool
 
 	// Polling state.
-	pollSnapshots map[string]map[string]struct{} // path -> set of dir entry names
-	lastPollTime  map[string]time.Time           // path -> last poll time
-	pollIntervals map[string]int                 // path -> poll interval in seconds
+	pollSnapshots map[string]map[string]time.Time // path -> artist directory, relative to path -> mtime
+	lastPollTime  map[string]time.Time            // path -> last poll time
+	pollIntervals map[string]int                  // path -> poll interval in seconds
+	pollDepths    map[string]int                  // path -> artist depth of its layout
 }
 
 // NewService creates a new filesystem watcher service.
 // expectedWrites is optional; if nil, the watcher works without expected-write filtering.
-func NewService(scanFn func(ctx context.Context) error, libraries LibraryLister, eventBus *event.Bus, logger *slog.Logger, probeCache *ProbeCache, expectedWrites *ExpectedWrites) *Service {
+func NewService(scanFn ScanFunc, libraries LibraryLister, eventBus *event.Bus, logger *slog.Logger, probeCache *ProbeCache, expectedWrites *ExpectedWrites) *Service {
 	return &Service{
 		scanFn:         scanFn,
 		libraries:      libraries,
 		eventBus:       eventBus,
 		logger:         logger.With("component", "fs-watcher"),
 		debounce:       1 * time.Second,
+		busyRetry:      10 * time.Second,
+		pollTick:       1 * time.Minute,
 		refreshPeriod:  5 * time.Minute,
 		probeCache:     probeCache,
 		expectedWrites: expectedWrites,
 		watching:       make(map[string]bool),
+		depths:         make(map[string]int),
+		buckets:        make(map[string]string),
 		knownDirs:      make(map[string]map[string]struct{}),
-		pollSnapshots:  make(map[string]map[string]struct{}),
+		artistDirs:     make(map[string]string),
+		pendingDirs:    make(map[string]struct{}),
+		pollSnapshots:  make(map[string]map[string]time.Time),
 		lastPollTime:   make(map[string]time.Time),
 		pollIntervals:  make(map[string]int),
+		pollDepths:     make(map[string]int),
 	}
 }
 
@@ -66,6 +103,14 @@ func (s *Service) SetDebounce(d time.Duration) {
 	s.debounce = d
 }
 
+// SetReconcileInterval sets how often the watcher runs a full scan while any
+// library is watched or polled, to pick up what targeted scans cannot see:
+// changes below an artist directory's top level, and events lost while the
+// watcher was not running. Zero, the default, turns it off.
+func (s *Service) SetReconcileInterval(d time.Duration) {
+	s.reconcilePeriod = d
+}
+
 // Start blocks until ctx is canceled. It creates an fsnotify watcher,
 // watches library root directories, and dispatches events. If fsnotify
 // is unavailable, the service still runs with poll-only support.
@@ -91,16 +136,32 @@ func (s *Service) Start(ctx context.Context) {
 
 	// Poll ticker: base tick of 1 minute. Per-library intervals are checked
 	// inside pollDirectories.
-	pollTicker := time.NewTicker(1 * time.Minute)
+	pollTicker := time.NewTicker(s.pollTick)
 	defer pollTicker.Stop()
 
-	// Debounce timer for coalescing create events into a single scan.
-	// Starts stopped; reset on each create event.
+	// Reconcile ticker: nil channel (never fires) when reconciliation is off.
+	var reconcileCh <-chan time.Time
+	if s.reconcilePeriod > 0 {
+		reconcileTicker := time.NewTicker(s.reconcilePeriod)
+		defer reconcileTicker.Stop()
+		reconcileCh = reconcileTicker.C
+	}
+
+	// Debounce timer for coalescing events into a single scan.
+	// Starts stopped; reset on each event.
 	debounceTimer := time.NewTimer(0)
 	if !debounceTimer.Stop() {
 		<-debounceTimer.C
 	}
-	scanPending := false
+	resetDebounce := func(d time.Duration) {
+		if !debounceTimer.Stop() {
+			select {
+			case <-debounceTimer.C:
+			default:
+			}
+		}
+		debounceTimer.Reset(d)
+	}
 
 	// When fsnotify is unavailable, use nil channels (never receive).
 	var eventCh <-chan fsnotify.Event
@@ -120,35 +181,46 @@ func (s *Service) Start(ctx context.Context) {
 			if !ok {
 				return
 			}
-			s.handleFSEvent(ev, debounceTimer, &scanPending)
+			// Reset the debounce on every event, so a large copy into a
+			// folder is scanned once, after it settles.
+			if s.handleFSEvent(ev) {
+				resetDebounce(s.debounce)
+			}
 
 		case err, ok := <-errCh:
 			if !ok {
 				return
 			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				// Events were dropped, so the pending directories are not
+				// the whole story: only a full scan is.
+				s.logger.Warn("fsnotify event queue overflowed, scheduling a full scan")
+				s.queueFull()
+				resetDebounce(s.debounce)
+				continue
+			}
 			s.logger.Error("fsnotify error", "error", err)
 
 		case <-debounceTimer.C:
-			if scanPending {
-				scanPending = false
-				s.logger.Info("debounce elapsed, triggering scan")
-				if err := s.scanFn(ctx); err != nil {
-					s.logger.Error("scan triggered by fs watcher failed", "error", err)
-				}
+			if retry := s.runPending(ctx); retry {
+				resetDebounce(s.busyRetry)
 			}
 
 		case <-pollTicker.C:
-			changed := s.pollDirectories()
-			if changed && !scanPending {
+			// Work queued before the poll already has the timer armed,
+			// by an event or a busy retry; only arm it for what the poll
+			// itself queued, so polling does not push back that scan.
+			wasPending := s.hasPending()
+			if changed := s.pollDirectories(); changed && !wasPending {
 				// Reuse the debounce timer for scan coalescing.
-				if !debounceTimer.Stop() {
-					select {
-					case <-debounceTimer.C:
-					default:
-					}
-				}
-				debounceTimer.Reset(s.debounce)
-				scanPending = true
+				resetDebounce(s.debounce)
+			}
+
+		case <-reconcileCh:
+			if s.watchesAnything() {
+				s.logger.Info("scheduling reconciliation scan")
+				s.queueFull()
+				resetDebounce(s.debounce)
 			}
 
 		case <-refreshTicker.C:
@@ -166,19 +238,26 @@ func (s *Service) Start(ctx context.Context) {
 	}
 }
 
-func (s *Service) handleFSEvent(ev fsnotify.Event, debounceTimer *time.Timer, scanPending *bool) {
-	// Only handle create, remove, and rename operations.
-	if !ev.Has(fsnotify.Create) && !ev.Has(fsnotify.Remove) && !ev.Has(fsnotify.Rename) {
-		return
+// handleFSEvent handles one fsnotify event and reports whether it queued an
+// artist directory for scanning.
+func (s *Service) handleFSEvent(ev fsnotify.Event) bool {
+	// Only handle create, write, remove, and rename operations.
+	if !ev.Has(fsnotify.Create) && !ev.Has(fsnotify.Write) && !ev.Has(fsnotify.Remove) && !ev.Has(fsnotify.Rename) {
+		return false
 	}
 
-	// Only react to direct children of watched library roots.
 	parent := filepath.Dir(ev.Name)
 	s.mu.Lock()
-	watched := s.watching[parent]
+	root, level, depth := s.containerOf(parent)
+	_, inArtistDir := s.artistDirs[parent]
 	s.mu.Unlock()
-	if !watched {
-		return
+	// Only react to direct children of watched library roots and bucket
+	// directories, or to changes inside a watched artist directory.
+	if root == "" {
+		if inArtistDir {
+			return s.handleArtistDirEvent(ev, parent)
+		}
+		return false
 	}
 
 	dirName := filepath.Base(ev.Name)
@@ -187,7 +266,7 @@ func (s *Service) handleFSEvent(ev fsnotify.Event, debounceTimer *time.Timer, sc
 		// Verify the created entry is a directory.
 		info, err := os.Stat(ev.Name)
 		if err != nil || !info.IsDir() {
-			return
+			return false
 		}
 
 		// Track the new directory so Remove events can be verified.
@@ -198,34 +277,34 @@ func (s *Service) handleFSEvent(ev fsnotify.Event, debounceTimer *time.Timer, sc
 		s.knownDirs[parent][dirName] = struct{}{}
 		s.mu.Unlock()
 
-		s.logger.Info("directory created in library",
-			"path", ev.Name,
-			"name", dirName,
-			"library_root", parent,
-		)
-
-		s.eventBus.Publish(event.Event{
-			Type: event.FSDirCreated,
-			Data: map[string]any{
-				"path":         ev.Name,
-				"name":         dirName,
-				"library_root": parent,
-			},
-		})
-
-		// Reset debounce timer to coalesce rapid creates.
-		if !debounceTimer.Stop() {
-			select {
-			case <-debounceTimer.C:
-			default:
+		artists := []string{ev.Name}
+		if level < depth {
+			// A new bucket: watch it, and pick up the artist directories
+			// moved in with it.
+			if strings.HasPrefix(dirName, ".") {
+				return false
 			}
+			artists, _ = s.watchTree(root, ev.Name, level, depth)
+		} else {
+			s.watchArtistDir(root, ev.Name)
 		}
-		debounceTimer.Reset(s.debounce)
-		*scanPending = true
-		return
+
+		for _, dir := range artists {
+			s.logger.Info("directory created in library",
+				"path", dir,
+				"name", filepath.Base(dir),
+				"library_root", root,
+			)
+			s.publishDir(event.FSDirCreated, dir, root)
+			s.queueDir(dir)
+		}
+		return len(artists) > 0
 	}
 
 	// Remove or Rename: only emit if the entry was a known directory.
+	if !ev.Has(fsnotify.Remove) && !ev.Has(fsnotify.Rename) {
+		return false
+	}
 	s.mu.Lock()
 	_, wasDir := s.knownDirs[parent][dirName]
 	if wasDir {
@@ -234,25 +313,270 @@ func (s *Service) handleFSEvent(ev fsnotify.Event, debounceTimer *time.Timer, sc
 	s.mu.Unlock()
 
 	if !wasDir {
-		return
+		return false
+	}
+	artists := []string{ev.Name}
+	if level < depth {
+		artists = s.forgetTree(ev.Name, level, depth)
+	} else {
+		s.unwatchArtistDir(ev.Name)
 	}
 
-	s.logger.Warn("directory removed from library",
-		"path", ev.Name,
-		"name", dirName,
-		"library_root", parent,
-	)
+	for _, dir := range artists {
+		s.logger.Warn("directory removed from library",
+			"path", dir,
+			"name", filepath.Base(dir),
+			"library_root", root,
+		)
+		s.publishDir(event.FSDirRemoved, dir, root)
+		s.queueDir(dir)
+	}
+	return len(artists) > 0
+}
+
+// containerOf reports the library root of dir when dir is a watched root or
+// bucket directory, along with the level of dir's subdirectories below the
+// root (1 for the root's own) and the artist depth of the root's layout. root
+// is "" for any other directory. The caller holds s.mu.
+func (s *Service) containerOf(dir string) (root string, level, depth int) {
+	if s.watching[dir] {
+		return dir, 1, s.depths[dir]
+	}
+	root, ok := s.buckets[dir]
+	if !ok || !s.watching[root] {
+		return "", 0, 0
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return "", 0, 0
+	}
+	return root, strings.Count(rel, string(filepath.Separator)) + 2, s.depths[root]
+}
 
+// publishDir publishes an fs.dir event for an artist directory under root.
+func (s *Service) publishDir(typ event.Type, dir, root string) {
 	s.eventBus.Publish(event.Event{
-		Type: event.FSDirRemoved,
+		Type: typ,
 		Data: map[string]any{
-			"path":         ev.Name,
-			"name":         dirName,
-			"library_root": parent,
+			"path":         dir,
+			"name":         filepath.Base(dir),
+			"library_root": root,
 		},
 	})
 }
 
+// handleArtistDirEvent queues artistDir for an event inside it: an album
+// folder dropped in, an image or NFO replaced. Hidden entries, such as
+// Stillwater's .sw-backup folder, and Stillwater's own writes are ignored.
+func (s *Service) handleArtistDirEvent(ev fsnotify.Event, artistDir string) bool {
+	if strings.HasPrefix(filepath.Base(ev.Name), ".") || s.isExpectedWrite(ev.Name) {
+		return false
+	}
+	s.queueDir(artistDir)
+	return true
+}
+
+// isExpectedWrite reports whether path is a file Stillwater is writing, or
+// the temporary file of an atomic write to one (<name>.<random>.tmp).
+func (s *Service) isExpectedWrite(path string) bool {
+	if s.expectedWrites == nil {
+		return false
+	}
+	if s.expectedWrites.IsExpected(path) {
+		return true
+	}
+	if tmp, ok := strings.CutSuffix(path, ".tmp"); ok {
+		if i := strings.LastIndexByte(tmp, '.'); i > 0 {
+			return s.expectedWrites.IsExpected(tmp[:i])
+		}
+	}
+	return false
+}
+
+// watchArtistDir adds an fsnotify watch on an artist directory under root,
+// so changes inside it are seen. fsnotify watches are not recursive, and one
+// per artist is the price of not walking the library for every change. When
+// the system runs out of watches the directory is still covered by polling,
+// if enabled, and by reconciliation.
+func (s *Service) watchArtistDir(root, dir string) bool {
+	if strings.HasPrefix(filepath.Base(dir), ".") {
+		return true
+	}
+	s.mu.Lock()
+	w := s.watcher
+	s.mu.Unlock()
+	if w == nil {
+		return false
+	}
+	if err := w.Add(dir); err != nil {
+		s.logger.Warn("cannot watch artist directory, changes inside it wait for the next full scan",
+			"path", dir, "error", err)
+		return false
+	}
+	s.mu.Lock()
+	s.artistDirs[dir] = root
+	s.mu.Unlock()
+	return true
+}
+
+// unwatchArtistDir drops the watch on a removed artist directory. The kernel
+// usually drops it first, so a failure here is expected and ignored.
+func (s *Service) unwatchArtistDir(dir string) {
+	s.mu.Lock()
+	_, ok := s.artistDirs[dir]
+	delete(s.artistDirs, dir)
+	w := s.watcher
+	s.mu.Unlock()
+	if ok && w != nil {
+		_ = w.Remove(dir)
+	}
+}
+
+// watchTree watches dir, a directory level levels below root (root itself at
+// level 0), and the bucket and artist directories below it down to depth. It
+// records the subdirectories it finds in knownDirs and returns the artist
+// directories, watched or not. ok is false once a watch could not be added,
+// most likely for want of watches, after which no more are tried.
+func (s *Service) watchTree(root, dir string, level, depth int) (artists []string, ok bool) {
+	if level > 0 {
+		s.mu.Lock()
+		w := s.watcher
+		s.mu.Unlock()
+		if w == nil {
+			return nil, false
+		}
+		if err := w.Add(dir); err != nil {
+			s.logger.Warn("cannot watch bucket directory, changes in it wait for the next full scan",
+				"path", dir, "error", err)
+			return nil, false
+		}
+		s.mu.Lock()
+		s.buckets[dir] = root
+		s.mu.Unlock()
+	}
+
+	snap := readDirSnapshot(dir)
+	s.mu.Lock()
+	s.knownDirs[dir] = snap
+	s.mu.Unlock()
+
+	ok = true
+	for name := range snap {
+		child := filepath.Join(dir, name)
+		if level+1 >= depth {
+			artists = append(artists, child)
+			ok = ok && s.watchArtistDir(root, child)
+			continue
+		}
+		if !ok || strings.HasPrefix(name, ".") {
+			continue
+		}
+		var more []string
+		more, ok = s.watchTree(root, child, level+1, depth)
+		artists = append(artists, more...)
+	}
+	return artists, ok
+}
+
+// forgetTree drops the watches and known subdirectories of dir, a directory
+// level levels below its library root, and of everything below it down to
+// depth. It returns the artist directories that were known there.
+func (s *Service) forgetTree(dir string, level, depth int) []string {
+	s.mu.Lock()
+	names := s.knownDirs[dir]
+	delete(s.knownDirs, dir)
+	_, bucket := s.buckets[dir]
+	delete(s.buckets, dir)
+	w := s.watcher
+	s.mu.Unlock()
+	if bucket && w != nil {
+		_ = w.Remove(dir)
+	}
+
+	var artists []string
+	for name := range names {
+		child := filepath.Join(dir, name)
+		if level+1 >= depth {
+			s.unwatchArtistDir(child)
+			artists = append(artists, child)
+			continue
+		}
+		artists = append(artists, s.forgetTree(child, level+1, depth)...)
+	}
+	return artists
+}
+
+// queueDir adds an artist directory to the next scan.
+func (s *Service) queueDir(dir string) {
+	s.mu.Lock()
+	s.pendingDirs[dir] = struct{}{}
+	s.mu.Unlock()
+}
+
+// queueFull makes the next scan a full scan.
+func (s *Service) queueFull() {
+	s.mu.Lock()
+	s.pendingFull = true
+	s.mu.Unlock()
+}
+
+// hasPending reports whether a scan is waiting for the debounce.
+func (s *Service) hasPending() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pendingFull || len(s.pendingDirs) > 0
+}
+
+// watchesAnything reports whether any library is watched or polled.
+func (s *Service) watchesAnything() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.watching) > 0 || len(s.pollSnapshots) > 0
+}
+
+// runPending runs the queued scan: a full scan if one was asked for, which
+// covers every queued directory too, or else a targeted scan of the queued
+// directories. It reports whether the scan found another one running, in
+// which case the work stays queued and the caller retries later.
+func (s *Service) runPending(ctx context.Context) bool {
+	s.mu.Lock()
+	full := s.pendingFull
+	dirs := make([]string, 0, len(s.pendingDirs))
+	for dir := range s.pendingDirs {
+		dirs = append(dirs, dir)
+	}
+	s.pendingFull = false
+	s.pendingDirs = make(map[string]struct{})
+	s.mu.Unlock()
+
+	var err error
+	switch {
+	case full:
+		s.logger.Info("debounce elapsed, triggering full scan")
+		err = s.scanFn(ctx, nil)
+	case len(dirs) > 0:
+		s.logger.Info("debounce elapsed, triggering scan", "directories", len(dirs))
+		err = s.scanFn(ctx, dirs)
+	default:
+		return false
+	}
+
+	if errors.Is(err, ErrScanBusy) {
+		s.logger.Debug("scan already running, retrying later", "full", full, "directories", len(dirs))
+		s.mu.Lock()
+		s.pendingFull = s.pendingFull || full
+		for _, dir := range dirs {
+			s.pendingDirs[dir] = struct{}{}
+		}
+		s.mu.Unlock()
+		return true
+	}
+	if err != nil {
+		s.logger.Error("scan triggered by fs watcher failed", "error", err)
+	}
+	return false
+}
+
 // refreshWatchPaths synchronizes the set of watched directories with the
 // current list of libraries that have the watch bit enabled and where
 // fsnotify is supported.
@@ -263,7 +587,7 @@ func (s *Service) refreshWatchPaths(ctx context.Context) {
 		return
 	}
 
-	wanted := make(map[string]bool)
+	wanted := make(map[string]int) // path -> artist depth
 	for i := range libs {
 		lib := &libs[i]
 		if !lib.FSWatchEnabled() || lib.IsPathless() {
@@ -285,20 +609,29 @@ func (s *Service) refreshWatchPaths(ctx context.Context) {
 			)
 			continue
 		}
-		wanted[lib.Path] = true
+		wanted[lib.Path] = lib.ArtistDepth()
 	}
 
 	// Determine which paths to add and remove under the lock, but perform
 	// the blocking fsnotify and filesystem I/O outside the lock to avoid
-	// holding the mutex during potentially slow operations.
+	// holding the mutex during potentially slow operations. A root whose
+	// layout changed is walked again, keeping the watch on the root itself.
 	s.mu.Lock()
+	oldDepths := make(map[string]int)
 	var toRemove []string
+	var toAdd []string
 	for path := range s.watching {
-		if !wanted[path] {
+		depth, ok := wanted[path]
+		if ok && depth == s.depths[path] {
+			continue
+		}
+		oldDepths[path] = s.depths[path]
+		if ok {
+			toAdd = append(toAdd, path)
+		} else {
 			toRemove = append(toRemove, path)
 		}
 	}
-	var toAdd []string
 	for path := range wanted {
 		if !s.watching[path] {
 			toAdd = append(toAdd, path)
@@ -314,24 +647,27 @@ func (s *Service) refreshWatchPaths(ctx context.Context) {
 			s.logger.Warn("failed to remove watch", "path", path, "error", err)
 			continue
 		}
+		s.forgetTree(path, 0, oldDepths[path])
 		s.logger.Info("stopped watching library path", "path", path)
 		removed = append(removed, path)
 	}
 
-	// Add watches and snapshot directories (fsnotify + filesystem I/O outside the lock).
-	type addResult struct {
-		path string
-		snap map[string]struct{}
-	}
-	var added []addResult
+	// Add watches and walk the directories (fsnotify + filesystem I/O
+	// outside the lock).
+	var added []string
 	for _, path := range toAdd {
-		if err := s.watcher.Add(path); err != nil {
+		if old, relaid := oldDepths[path]; relaid {
+			s.forgetTree(path, 0, old)
+		} else if err := s.watcher.Add(path); err != nil {
 			s.logger.Error("failed to watch library path", "path", path, "error", err)
 			continue
 		}
-		snap := readDirSnapshot(path)
-		added = append(added, addResult{path: path, snap: snap})
-		s.logger.Info("watching library path", "path", path)
+		s.mu.Lock()
+		s.depths[path] = wanted[path]
+		s.mu.Unlock()
+		artists, _ := s.watchTree(path, path, 0, wanted[path])
+		added = append(added, path)
+		s.logger.Info("watching library path", "path", path, "depth", wanted[path], "artist_dirs", len(artists))
 	}
 
 	// Update internal state under the lock -- only for paths that actually changed.
@@ -339,11 +675,10 @@ func (s *Service) refreshWatchPaths(ctx context.Context) {
 	defer s.mu.Unlock()
 	for _, path := range removed {
 		delete(s.watching, path)
-		delete(s.knownDirs, path)
+		delete(s.depths, path)
 	}
-	for _, ar := range added {
-		s.watching[ar.path] = true
-		s.knownDirs[ar.path] = ar.snap
+	for _, path := range added {
+		s.watching[path] = true
 	}
 }
 
@@ -364,9 +699,10 @@ func (s *Service) initPollSnapshots(ctx context.Context) {
 		if !lib.FSPollEnabled() || lib.IsPathless() {
 			continue
 		}
-		snap := readDirSnapshot(lib.Path)
+		snap := readDirMtimes(lib.Path, lib.ArtistDepth())
 		if snap != nil {
 			s.pollSnapshots[lib.Path] = snap
+			s.pollDepths[lib.Path] = lib.ArtistDepth()
 			s.lastPollTime[lib.Path] = time.Now()
 			interval := lib.FSPollInterval
 			if interval <= 0 {
@@ -392,6 +728,7 @@ func (s *Service) refreshPollPaths(ctx context.Context) {
 	}
 
 	wanted := make(map[string]int) // path -> interval
+	depths := make(map[string]int) // path -> artist depth
 	for i := range libs {
 		lib := &libs[i]
 		if !lib.FSPollEnabled() || lib.IsPathless() {
@@ -402,6 +739,7 @@ func (s *Service) refreshPollPaths(ctx context.Context) {
 			interval = 60
 		}
 		wanted[lib.Path] = interval
+		depths[lib.Path] = lib.ArtistDepth()
 	}
 
 	s.mu.Lock()
@@ -413,15 +751,18 @@ func (s *Service) refreshPollPaths(ctx context.Context) {
 			delete(s.pollSnapshots, path)
 			delete(s.lastPollTime, path)
 			delete(s.pollIntervals, path)
+			delete(s.pollDepths, path)
 		}
 	}
 
-	// Add new paths.
+	// Add new paths, and take a fresh snapshot of those whose layout
+	// changed: the old one holds directories at the wrong depth.
 	for path, interval := range wanted {
-		if _, exists := s.pollSnapshots[path]; !exists {
-			snap := readDirSnapshot(path)
+		if _, exists := s.pollSnapshots[path]; !exists || s.pollDepths[path] != depths[path] {
+			snap := readDirMtimes(path, depths[path])
 			if snap != nil {
 				s.pollSnapshots[path] = snap
+				s.pollDepths[path] = depths[path]
 				s.lastPollTime[path] = time.Now()
 				s.pollIntervals[path] = interval
 			}
@@ -432,7 +773,10 @@ func (s *Service) refreshPollPaths(ctx context.Context) {
 	}
 }
 
-// pollDirectories checks all poll-enabled libraries for directory changes.
+// pollDirectories checks all poll-enabled libraries for artist directories
+// created, removed, or changed since the last poll, and queues them for a
+// scan. A directory's mtime moves when an entry directly inside it is added,
+// removed or renamed, which covers an album dropped in or an image replaced.
 // Returns true if any changes were detected.
 func (s *Service) pollDirectories() bool {
 	now := time.Now()
@@ -440,7 +784,8 @@ func (s *Service) pollDirectories() bool {
 	// Collect all state under a single lock to avoid read-check-act races.
 	type pollEntry struct {
 		path    string
-		oldSnap map[string]struct{}
+		depth   int
+		oldSnap map[string]time.Time
 	}
 
 	s.mu.Lock()
@@ -455,11 +800,11 @@ func (s *Service) pollDirectories() bool {
 			continue
 		}
 		// Copy the snapshot so we can safely read it outside the lock.
-		snapCopy := make(map[string]struct{}, len(snap))
+		snapCopy := make(map[string]time.Time, len(snap))
 		for k, v := range snap {
 			snapCopy[k] = v
 		}
-		entries = append(entries, pollEntry{path: path, oldSnap: snapCopy})
+		entries = append(entries, pollEntry{path: path, depth: s.pollDepths[path], oldSnap: snapCopy})
 	}
 	s.mu.Unlock()
 
@@ -467,56 +812,56 @@ func (s *Service) pollDirectories() bool {
 
 	for _, entry := range entries {
 		// Filesystem I/O outside the lock.
-		newSnap := readDirSnapshot(entry.path)
+		newSnap := readDirMtimes(entry.path, entry.depth)
 		if newSnap == nil {
 			continue
 		}
 
-		// Detect new directories.
-		for name := range newSnap {
-			if _, existed := entry.oldSnap[name]; !existed {
+		// Detect new and changed directories.
+		for rel, mtime := range newSnap {
+			dir := filepath.Join(entry.path, rel)
+			oldMtime, existed := entry.oldSnap[rel]
+			if existed && !mtime.Equal(oldMtime) {
+				s.logger.Debug("poll: artist directory changed",
+					"path", dir,
+					"library_root", entry.path,
+				)
+				s.queueDir(dir)
+				changed = true
+			}
+			if !existed {
 				s.logger.Info("poll: directory created in library",
-					"path", filepath.Join(entry.path, name),
-					"name", name,
+					"path", dir,
+					"name", filepath.Base(dir),
 					"library_root", entry.path,
 				)
-				s.eventBus.Publish(event.Event{
-					Type: event.FSDirCreated,
-					Data: map[string]any{
-						"path":         filepath.Join(entry.path, name),
-						"name":         name,
-						"library_root": entry.path,
-					},
-				})
+				s.publishDir(event.FSDirCreated, dir, entry.path)
+				s.queueDir(dir)
 				changed = true
 			}
 		}
 
 		// Detect removed directories.
-		for name := range entry.oldSnap {
-			if _, exists := newSnap[name]; !exists {
+		for rel := range entry.oldSnap {
+			if _, exists := newSnap[rel]; !exists {
+				dir := filepath.Join(entry.path, rel)
 				s.logger.Warn("poll: directory removed from library",
-					"path", filepath.Join(entry.path, name),
-					"name", name,
+					"path", dir,
+					"name", filepath.Base(dir),
 					"library_root", entry.path,
 				)
-				s.eventBus.Publish(event.Event{
-					Type: event.FSDirRemoved,
-					Data: map[string]any{
-						"path":         filepath.Join(entry.path, name),
-						"name":         name,
-						"library_root": entry.path,
-					},
-				})
+				s.publishDir(event.FSDirRemoved, dir, entry.path)
+				s.queueDir(dir)
 				changed = true
 			}
 		}
 
 		// Update state under the lock.
 		s.mu.Lock()
-		// Only update if the path is still tracked (may have been removed
-		// by refreshPollPaths while we were doing I/O).
-		if _, stillTracked := s.pollSnapshots[entry.path]; stillTracked {
+		// Only update if the path is still tracked at the same depth (may
+		// have been removed or re-laid out by refreshPollPaths while we were
+		// doing I/O).
+		if _, stillTracked := s.pollSnapshots[entry.path]; stillTracked && s.pollDepths[entry.path] == entry.depth {
 			s.pollSnapshots[entry.path] = newSnap
 			s.lastPollTime[entry.path] = now
 		}
@@ -565,3 +910,45 @@ func readDirSnapshot(path string) map[string]struct{} {
 	}
 	return snap
 }
+
+// readDirMtimes returns the modification time of each artist directory
+// depth levels below path, keyed by its path relative to path. Hidden
+// directories are left out at every level; an entry that vanishes between
+// the listing and its stat is too. It returns nil when path, or any bucket
+// directory above the artist directories, cannot be read, so an unreadable
+// bucket is not taken for its artists being removed.
+func readDirMtimes(path string, depth int) map[string]time.Time {
+	snap := make(map[string]time.Time)
+	if !addDirMtimes(snap, path, "", depth) {
+		return nil
+	}
+	return snap
+}
+
+// addDirMtimes adds the artist directories depth levels below dir, whose
+// path relative to the library root is rel, to snap. It reports whether
+// every directory on the way could be read.
+func addDirMtimes(snap map[string]time.Time, dir, rel string, depth int) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		name := filepath.Join(rel, e.Name())
+		if depth > 1 {
+			if !addDirMtimes(snap, filepath.Join(dir, e.Name()), name, depth-1) {
+				return false
+			}
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		snap[name] = info.ModTime()
+	}
+	return true
+}
@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -71,7 +72,7 @@ func newTestService(t *testing.T, scanCount *atomic.Int32, libs *mockLibraryList
 	go bus.Start()
 	t.Cleanup(bus.Stop)
 
-	scanFn := func(_ context.Context) error {
+	scanFn := func(_ context.Context, _ []string) error {
 		scanCount.Add(1)
 		return nil
 	}
@@ -177,15 +178,12 @@ func TestRemovedDirectoryPublishesEvent(t *testing.T) {
 	}
 
 	waitFor(t, func() bool { return received.Load() >= 1 }, "FSDirRemoved event not received within 1s")
-	cancel()
 
 	if got := received.Load(); got < 1 {
 		t.Errorf("expected FSDirRemoved event, got %d", got)
 	}
-	// Removal should not trigger a scan.
-	if got := scanCount.Load(); got != 0 {
-		t.Errorf("expected 0 scans on removal, got %d", got)
-	}
+	// Removal triggers a scan, so the artist is dropped.
+	waitFor(t, func() bool { return scanCount.Load() >= 1 }, "scan not triggered on removal within 1s")
 }
 
 func TestFileCreationIgnored(t *testing.T) {
@@ -324,7 +322,7 @@ func TestPollDetectsNewDirectory(t *testing.T) {
 	go bus.Start()
 	t.Cleanup(bus.Stop)
 
-	scanFn := func(_ context.Context) error {
+	scanFn := func(_ context.Context, _ []string) error {
 		scanCount.Add(1)
 		return nil
 	}
@@ -378,7 +376,7 @@ func TestPollDetectsRemovedDirectory(t *testing.T) {
 	go bus.Start()
 	t.Cleanup(bus.Stop)
 
-	scanFn := func(_ context.Context) error {
+	scanFn := func(_ context.Context, _ []string) error {
 		scanCount.Add(1)
 		return nil
 	}
@@ -425,7 +423,7 @@ func TestPollIntervalRespected(t *testing.T) {
 	go bus.Start()
 	t.Cleanup(bus.Stop)
 
-	scanFn := func(_ context.Context) error {
+	scanFn := func(_ context.Context, _ []string) error {
 		scanCount.Add(1)
 		return nil
 	}
@@ -478,3 +476,282 @@ func TestUnsupportedFSNotifySkipsWatch(t *testing.T) {
 		t.Errorf("expected 0 watched paths for unsupported fsnotify, got %d", watchCount)
 	}
 }
+
+// scanRecorder is a ScanFunc that records the directories of every scan. A
+// nil entry is a full scan. The first busy calls return ErrScanBusy.
+type scanRecorder struct {
+	mu    sync.Mutex
+	scans [][]string
+	busy  int
+}
+
+func (r *scanRecorder) scan(_ context.Context, dirs []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.busy > 0 {
+		r.busy--
+		return ErrScanBusy
+	}
+	if dirs != nil {
+		dirs = slices.Clone(dirs)
+		slices.Sort(dirs)
+	}
+	r.scans = append(r.scans, dirs)
+	return nil
+}
+
+func (r *scanRecorder) calls() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([][]string(nil), r.scans...)
+}
+
+func newRecordingService(t *testing.T, rec *scanRecorder, libs *mockLibraryLister, probeCache *ProbeCache, ew *ExpectedWrites) (*Service, context.Context) {
+	t.Helper()
+	logger := testLogger()
+	bus := event.NewBus(logger, 64)
+	go bus.Start()
+	t.Cleanup(bus.Stop)
+
+	svc := NewService(rec.scan, libs, bus, logger, probeCache, ew)
+	svc.SetDebounce(50 * time.Millisecond)
+	svc.busyRetry = 50 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return svc, ctx
+}
+
+func TestChangeInsideArtistDirScansOnlyThatDir(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"Changed", "Quiet"} {
+		if err := os.Mkdir(filepath.Join(root, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	libs := &mockLibraryLister{libs: []library.Library{
+		{ID: "1", Name: "Test", Path: root, Type: "regular", FSWatch: library.FSModeWatch},
+	}}
+	rec := &scanRecorder{busy: 1}
+	svc, ctx := newRecordingService(t, rec, libs, testProbeCache(root), nil)
+
+	go svc.Start(ctx)
+	waitWatcherReady(t, svc)
+
+	// An album dropped into an existing artist folder, while another scan
+	// holds the scanner: the watcher retries with the same directory.
+	if err := os.Mkdir(filepath.Join(root, "Changed", "New Album"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return len(rec.calls()) >= 1 }, "scan not triggered within 1s")
+
+	want := filepath.Join(root, "Changed")
+	if got := rec.calls(); len(got) != 1 || len(got[0]) != 1 || got[0][0] != want {
+		t.Errorf("scans = %v, want one targeted scan of %s", got, want)
+	}
+}
+
+func TestExpectedWriteInsideArtistDirIgnored(t *testing.T) {
+	root := t.TempDir()
+	artistDir := filepath.Join(root, "Artist")
+	if err := os.Mkdir(artistDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	libs := &mockLibraryLister{libs: []library.Library{
+		{ID: "1", Name: "Test", Path: root, Type: "regular", FSWatch: library.FSModeWatch},
+	}}
+	ew := NewExpectedWrites()
+	rec := &scanRecorder{}
+	svc, ctx := newRecordingService(t, rec, libs, testProbeCache(root), ew)
+
+	go svc.Start(ctx)
+	waitWatcherReady(t, svc)
+
+	target := filepath.Join(artistDir, "folder.jpg")
+	ew.Add(target)
+	for _, p := range []string{target + ".123456.tmp", target, filepath.Join(artistDir, ".sw-backup")} {
+		if err := os.WriteFile(p, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Negative assertion: give a spurious scan 3x the debounce to surface.
+	time.Sleep(150 * time.Millisecond)
+	if got := rec.calls(); len(got) != 0 {
+		t.Errorf("scans = %v, want none for Stillwater's own writes", got)
+	}
+}
+
+func TestEventOverflowTriggersFullScan(t *testing.T) {
+	libs := &mockLibraryLister{}
+	rec := &scanRecorder{}
+	svc, ctx := newRecordingService(t, rec, libs, NewProbeCache(), nil)
+
+	svc.queueDir("/music/Artist")
+	svc.queueFull()
+	if retry := svc.runPending(ctx); retry {
+		t.Fatal("runPending asked for a retry")
+	}
+	if got := rec.calls(); len(got) != 1 || got[0] != nil {
+		t.Errorf("scans = %v, want one full scan covering the queued directory", got)
+	}
+	if svc.hasPending() {
+		t.Error("work still pending after the scan")
+	}
+}
+
+func TestPollDetectsChangedDirectory(t *testing.T) {
+	root := t.TempDir()
+	artistDir := filepath.Join(root, "Poll Artist")
+	if err := os.Mkdir(artistDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	libs := &mockLibraryLister{libs: []library.Library{
+		{ID: "1", Name: "Test", Path: root, Type: "regular", FSWatch: library.FSModePoll, FSPollInterval: 60},
+	}}
+	rec := &scanRecorder{}
+	svc, ctx := newRecordingService(t, rec, libs, NewProbeCache(), nil)
+	svc.initPollSnapshots(ctx)
+
+	if err := os.Mkdir(filepath.Join(artistDir, "New Album"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// Some filesystems keep mtimes in whole seconds.
+	later := time.Now().Add(2 * time.Second)
+	if err := os.Chtimes(artistDir, later, later); err != nil {
+		t.Fatal(err)
+	}
+	svc.mu.Lock()
+	svc.lastPollTime[root] = time.Time{}
+	svc.mu.Unlock()
+
+	if !svc.pollDirectories() {
+		t.Fatal("expected pollDirectories to report changes")
+	}
+	svc.runPending(ctx)
+	if got := rec.calls(); len(got) != 1 || len(got[0]) != 1 || got[0][0] != artistDir {
+		t.Errorf("scans = %v, want one targeted scan of %s", got, artistDir)
+	}
+}
+
+func TestNestedLayoutWatchesBuckets(t *testing.T) {
+	root := t.TempDir()
+	abba := filepath.Join(root, "A", "ABBA")
+	if err := os.MkdirAll(abba, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	libs := &mockLibraryLister{libs: []library.Library{
+		{ID: "1", Name: "Test", Path: root, Type: "regular", FSWatch: library.FSModeWatch, Layout: library.LayoutLetterFirst},
+	}}
+	rec := &scanRecorder{}
+	svc, ctx := newRecordingService(t, rec, libs, testProbeCache(root), nil)
+
+	go svc.Start(ctx)
+	waitWatcherReady(t, svc)
+
+	// A change inside an artist directory two levels down.
+	if err := os.Mkdir(filepath.Join(abba, "Arrival"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return len(rec.calls()) >= 1 }, "scan not triggered within 1s")
+	if got := rec.calls(); len(got[0]) != 1 || got[0][0] != abba {
+		t.Fatalf("scans = %v, want one targeted scan of %s", got, abba)
+	}
+
+	// A new bucket is watched, and the artist directory made in it is
+	// scanned; the bucket itself is not.
+	bucket := filepath.Join(root, "B")
+	if err := os.Mkdir(bucket, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		svc.mu.Lock()
+		defer svc.mu.Unlock()
+		return svc.buckets[bucket] == root
+	}, "new bucket not watched within 1s")
+	beatles := filepath.Join(bucket, "Beatles")
+	if err := os.Mkdir(beatles, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool { return len(rec.calls()) >= 2 }, "scan not triggered within 1s")
+	if got := rec.calls(); len(got[1]) != 1 || got[1][0] != beatles {
+		t.Fatalf("scans = %v, want a targeted scan of %s", got, beatles)
+	}
+
+	// Removing a bucket removes every artist directory in it.
+	if err := os.RemoveAll(filepath.Join(root, "A")); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		for _, call := range rec.calls()[2:] {
+			if slices.Contains(call, abba) {
+				return true
+			}
+		}
+		return false
+	}, "removed bucket's artist directory not scanned within 1s")
+	for _, call := range rec.calls() {
+		if slices.Contains(call, bucket) || slices.Contains(call, filepath.Join(root, "A")) {
+			t.Errorf("scans = %v, a bucket was scanned as an artist", rec.calls())
+		}
+	}
+}
+
+func TestPollNestedLayout(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "A", "ABBA"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	libs := &mockLibraryLister{libs: []library.Library{
+		{ID: "1", Name: "Test", Path: root, Type: "regular", FSWatch: library.FSModePoll, FSPollInterval: 60, Layout: library.LayoutLetterFirst},
+	}}
+	rec := &scanRecorder{}
+	svc, ctx := newRecordingService(t, rec, libs, NewProbeCache(), nil)
+	svc.initPollSnapshots(ctx)
+
+	beatles := filepath.Join(root, "B", "Beatles")
+	if err := os.MkdirAll(beatles, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	svc.mu.Lock()
+	svc.lastPollTime[root] = time.Time{}
+	svc.mu.Unlock()
+
+	if !svc.pollDirectories() {
+		t.Fatal("expected pollDirectories to report changes")
+	}
+	svc.runPending(ctx)
+	if got := rec.calls(); len(got) != 1 || len(got[0]) != 1 || got[0][0] != beatles {
+		t.Errorf("scans = %v, want one targeted scan of %s", got, beatles)
+	}
+}
+
+func TestPollChangeTriggersScanFromStart(t *testing.T) {
+	root := t.TempDir()
+	libs := &mockLibraryLister{libs: []library.Library{
+		{ID: "1", Name: "Test", Path: root, Type: "regular", FSWatch: library.FSModePoll, FSPollInterval: 60},
+	}}
+	rec := &scanRecorder{}
+	svc, ctx := newRecordingService(t, rec, libs, NewProbeCache(), nil)
+	svc.pollTick = 20 * time.Millisecond
+
+	go svc.Start(ctx)
+	waitFor(t, func() bool {
+		svc.mu.Lock()
+		defer svc.mu.Unlock()
+		return svc.pollSnapshots[root] != nil
+	}, "poll snapshot not taken within 1s")
+
+	// Only the poll can see this directory: nothing is watched.
+	newDir := filepath.Join(root, "Poll Artist")
+	if err := os.Mkdir(newDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	svc.mu.Lock()
+	svc.lastPollTime[root] = time.Time{}
+	svc.mu.Unlock()
+
+	waitFor(t, func() bool { return len(rec.calls()) >= 1 }, "poll change not scanned within 1s")
+	if got := rec.calls(); len(got[0]) != 1 || got[0][0] != newDir {
+		t.Errorf("scans = %v, want one targeted scan of %s", got, newDir)
+	}
+}
@@ -0,0 +1,174 @@
+package webhook
+
+// delivery.go persists the outbound delivery log (webhook_deliveries). The
+// dispatcher writes a row before the first attempt and updates it after each
+// one, so a delivery interrupted by a restart is still visible (as pending)
+// rather than lost.
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sydlexius/stillwater/internal/dbutil"
+)
+
+// DeliveryRetention is how long delivery records are kept. Long enough to
+// cover a receiver outage noticed after a holiday, short enough that stored
+// request bodies do not accumulate indefinitely.
+const DeliveryRetention = 30 * 24 * time.Hour
+
+// ErrDeliveryNotFound is returned when a delivery does not exist or belongs
+// to a different webhook than the one asked about.
+var ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+// deliveryTimeLayout is a fixed-width RFC 3339 layout, so created_at orders
+// correctly as text even between deliveries of the same second.
+const deliveryTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+const deliveryColumns = `id, webhook_id, event_type, request_body, content_type, status,
+	response_status, latency_ms, attempts, error, replay_of, created_at, completed_at`
+
+// createDelivery inserts d as a pending delivery, assigning its ID and
+// creation time.
+func (s *Service) createDelivery(ctx context.Context, d *Delivery) error {
+	d.ID = uuid.New().String()
+	d.Status = DeliveryPending
+	d.CreatedAt = time.Now().UTC()
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO webhook_deliveries (id, webhook_id, event_type, request_body, content_type, status, replay_of, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, d.ID, d.WebhookID, d.EventType, d.RequestBody, d.ContentType, d.Status, d.ReplayOf, d.CreatedAt.Format(deliveryTimeLayout))
+	if err != nil {
+		return fmt.Errorf("inserting webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// updateDelivery writes d's outcome fields back to its row.
+func (s *Service) updateDelivery(ctx context.Context, d *Delivery) error {
+	var completed any
+	if d.CompletedAt != nil {
+		completed = d.CompletedAt.Format(deliveryTimeLayout)
+	}
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = ?, response_status = ?, latency_ms = ?, attempts = ?, error = ?, completed_at = ?
+		WHERE id = ?
+	`, d.Status, d.ResponseStatus, d.LatencyMS, d.Attempts, d.Error, completed, d.ID)
+	if err != nil {
+		return fmt.Errorf("updating webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries returns a page of webhookID's deliveries, newest first, and
+// the total number matching. status filters to one delivery status when
+// non-empty.
+func (s *Service) ListDeliveries(ctx context.Context, webhookID, status string, limit, offset int) ([]Delivery, int, error) {
+	where := `WHERE webhook_id = ?`
+	args := []any{webhookID}
+	if status != "" {
+		where += ` AND status = ?`
+		args = append(args, status)
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM webhook_deliveries `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("counting webhook deliveries: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+deliveryColumns+` FROM webhook_deliveries `+where+`
+		ORDER BY created_at DESC, id LIMIT ? OFFSET ?
+	`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("listing webhook deliveries: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck // Close error not actionable on cleanup
+
+	deliveries, err := scanDeliveries(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+// GetDelivery returns webhookID's delivery id, or ErrDeliveryNotFound.
+func (s *Service) GetDelivery(ctx context.Context, webhookID, id string) (*Delivery, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = ? AND webhook_id = ?
+	`, id, webhookID)
+	d, err := scanDelivery(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// unrecoveredDeliveries returns webhookID's failed original deliveries that
+// no replay has since delivered, oldest first -- the events the receiver
+// still has not seen, in the order they happened.
+func (s *Service) unrecoveredDeliveries(ctx context.Context, webhookID string) ([]Delivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+deliveryColumns+` FROM webhook_deliveries d
+		WHERE d.webhook_id = ? AND d.status = ? AND d.replay_of = ''
+		  AND NOT EXISTS (
+			SELECT 1 FROM webhook_deliveries r
+			WHERE r.replay_of = d.id AND r.status IN (?, ?)
+		  )
+		ORDER BY d.created_at, d.id
+	`, webhookID, DeliveryFailed, DeliverySucceeded, DeliveryPending)
+	if err != nil {
+		return nil, fmt.Errorf("listing unrecovered webhook deliveries: %w", err)
+	}
+	defer rows.Close() //nolint:errcheck // Close error not actionable on cleanup
+	return scanDeliveries(rows)
+}
+
+// PruneDeliveries deletes delivery records created before cutoff and returns
+// how many were removed.
+func (s *Service) PruneDeliveries(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE created_at < ?`,
+		cutoff.UTC().Format(deliveryTimeLayout))
+	if err != nil {
+		return 0, fmt.Errorf("pruning webhook deliveries: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n, nil
+}
+
+func scanDeliveries(rows *sql.Rows) ([]Delivery, error) {
+	var deliveries []Delivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
+
+func scanDelivery(s scanner) (*Delivery, error) {
+	var d Delivery
+	var createdAt string
+	var completedAt sql.NullString
+	if err := s.Scan(&d.ID, &d.WebhookID, &d.EventType, &d.RequestBody, &d.ContentType, &d.Status,
+		&d.ResponseStatus, &d.LatencyMS, &d.Attempts, &d.Error, &d.ReplayOf, &createdAt, &completedAt); err != nil {
+		return nil, fmt.Errorf("scanning webhook delivery: %w", err)
+	}
+	d.CreatedAt = dbutil.ParseTime(createdAt)
+	if completedAt.Valid {
+		t := dbutil.ParseTime(completedAt.String)
+		d.CompletedAt = &t
+	}
+	return &d, nil
+}
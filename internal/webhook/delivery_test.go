@@ -0,0 +1,301 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sydlexius/stillwater/internal/encryption"
+	"github.com/sydlexius/stillwater/internal/event"
+)
+
+func testEncryptor(t *testing.T) *encryption.Encryptor {
+	t.Helper()
+	enc, _, err := encryption.NewEncryptor("")
+	if err != nil {
+		t.Fatalf("creating encryptor: %v", err)
+	}
+	return enc
+}
+
+func createTestWebhook(t *testing.T, svc *Service, url string) *Webhook {
+	t.Helper()
+	w := &Webhook{Name: "receiver", URL: url, Type: TypeGeneric, Events: []string{"scan.completed"}, Enabled: true}
+	if err := svc.Create(context.Background(), w); err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func drain(t *testing.T, d *Dispatcher) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.Drain(ctx); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+}
+
+func onlyDelivery(t *testing.T, svc *Service, webhookID string) Delivery {
+	t.Helper()
+	deliveries, total, err := svc.ListDeliveries(context.Background(), webhookID, "", 50, 0)
+	if err != nil {
+		t.Fatalf("ListDeliveries: %v", err)
+	}
+	if total != 1 || len(deliveries) != 1 {
+		t.Fatalf("deliveries = %d (total %d), want 1", len(deliveries), total)
+	}
+	return deliveries[0]
+}
+
+func TestSetSecret(t *testing.T) {
+	svc := setupTestDB(t)
+	ctx := context.Background()
+	w := createTestWebhook(t, svc, "https://example.com/hook")
+
+	if err := svc.SetSecret(ctx, w.ID, "0123456789abcdef"); !errors.Is(err, ErrSecretStorageUnavailable) {
+		t.Fatalf("SetSecret without encryptor: err = %v, want ErrSecretStorageUnavailable", err)
+	}
+	svc.WithEncryptor(testEncryptor(t))
+	if err := svc.SetSecret(ctx, w.ID, "short"); err == nil {
+		t.Fatal("SetSecret accepted a secret shorter than MinSecretLength")
+	}
+	if err := svc.SetSecret(ctx, w.ID, "0123456789abcdef"); err != nil {
+		t.Fatalf("SetSecret: %v", err)
+	}
+
+	got, err := svc.GetByID(ctx, w.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.HasSecret {
+		t.Error("HasSecret = false after SetSecret")
+	}
+	if got.encSecret == "0123456789abcdef" {
+		t.Error("secret stored in plaintext")
+	}
+	if secret, err := svc.signingSecret(got); err != nil || secret != "0123456789abcdef" {
+		t.Errorf("signingSecret = %q, %v; want the secret back", secret, err)
+	}
+
+	if err := svc.SetSecret(ctx, w.ID, ""); err != nil {
+		t.Fatalf("clearing secret: %v", err)
+	}
+	if got, _ := svc.GetByID(ctx, w.ID); got.HasSecret {
+		t.Error("HasSecret = true after clearing the secret")
+	}
+	if err := svc.SetSecret(ctx, "missing", ""); err == nil {
+		t.Error("SetSecret on an unknown webhook succeeded")
+	}
+}
+
+// TestDispatcher_SignsAndRecords checks that a webhook with a secret receives
+// a signature over the timestamp and body, and that the delivery is logged
+// with the body sent and the receiver's answer.
+func TestDispatcher_SignsAndRecords(t *testing.T) {
+	t.Parallel()
+	svc, logger := setupDispatcherTest(t)
+	svc.WithEncryptor(testEncryptor(t))
+	const secret = "receiver-shared-secret"
+
+	var mu sync.Mutex
+	var header http.Header
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	w := createTestWebhook(t, svc, srv.URL)
+	if err := svc.SetSecret(context.Background(), w.ID, secret); err != nil {
+		t.Fatal(err)
+	}
+
+	dispatcher := NewDispatcherWithHTTPClient(svc, srv.Client(), logger)
+	dispatcher.HandleEvent(event.Event{Type: event.ScanCompleted, Timestamp: time.Now().UTC()})
+	drain(t, dispatcher)
+
+	mu.Lock()
+	defer mu.Unlock()
+	ts, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("%s = %q: %v", HeaderTimestamp, header.Get(HeaderTimestamp), err)
+	}
+	if got, want := header.Get(HeaderSignature), Sign(secret, ts, body); got != want {
+		t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+	}
+	if header.Get(HeaderEvent) != "scan.completed" {
+		t.Errorf("%s = %q, want scan.completed", HeaderEvent, header.Get(HeaderEvent))
+	}
+
+	d := onlyDelivery(t, svc, w.ID)
+	if d.Status != DeliverySucceeded || d.ResponseStatus != http.StatusAccepted || d.Attempts != 1 {
+		t.Errorf("delivery = %+v, want succeeded with 202 after 1 attempt", d)
+	}
+	if d.RequestBody != string(body) {
+		t.Errorf("recorded body = %q, want the body sent %q", d.RequestBody, body)
+	}
+	if header.Get(HeaderDelivery) != d.ID {
+		t.Errorf("%s = %q, want the delivery ID %q", HeaderDelivery, header.Get(HeaderDelivery), d.ID)
+	}
+	if d.CompletedAt == nil {
+		t.Error("CompletedAt not set")
+	}
+}
+
+func TestDispatcher_UnsignedWithoutSecret(t *testing.T) {
+	t.Parallel()
+	svc, logger := setupDispatcherTest(t)
+
+	var signed atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signed.Store(r.Header.Get(HeaderSignature) != "" || r.Header.Get(HeaderTimestamp) != "")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	createTestWebhook(t, svc, srv.URL)
+
+	dispatcher := NewDispatcherWithHTTPClient(svc, srv.Client(), logger)
+	dispatcher.HandleEvent(event.Event{Type: event.ScanCompleted, Timestamp: time.Now().UTC()})
+	drain(t, dispatcher)
+
+	if signed.Load() {
+		t.Error("delivery to a webhook without a secret carried signature headers")
+	}
+}
+
+// TestDispatcher_ReplayFailed takes a receiver down for one event, brings it
+// back, and checks the missed event is replayed exactly once.
+func TestDispatcher_ReplayFailed(t *testing.T) {
+	t.Parallel()
+	svc, logger := setupDispatcherTest(t)
+
+	var down atomic.Bool
+	down.Store(true)
+	var mu sync.Mutex
+	var replayedID string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		mu.Lock()
+		replayedID = r.Header.Get(HeaderDelivery)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	w := createTestWebhook(t, svc, srv.URL)
+
+	dispatcher := NewDispatcherWithHTTPClient(svc, srv.Client(), logger)
+	dispatcher.sleep = func(time.Duration) {}
+	dispatcher.HandleEvent(event.Event{Type: event.ScanCompleted, Timestamp: time.Now().UTC()})
+	drain(t, dispatcher)
+
+	orig := onlyDelivery(t, svc, w.ID)
+	if orig.Status != DeliveryFailed || orig.Attempts != maxRetries || orig.ResponseStatus != http.StatusServiceUnavailable {
+		t.Fatalf("delivery = %+v, want failed with 503 after %d attempts", orig, maxRetries)
+	}
+	if orig.Error == "" {
+		t.Error("failed delivery has no error recorded")
+	}
+
+	down.Store(false)
+	replays, err := dispatcher.ReplayFailed(context.Background(), w.ID)
+	if err != nil {
+		t.Fatalf("ReplayFailed: %v", err)
+	}
+	drain(t, dispatcher)
+	if len(replays) != 1 || replays[0].ReplayOf != orig.ID {
+		t.Fatalf("replays = %+v, want one replay of %s", replays, orig.ID)
+	}
+	replay, err := svc.GetDelivery(context.Background(), w.ID, replays[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replay.Status != DeliverySucceeded || replay.RequestBody != orig.RequestBody {
+		t.Errorf("replay = %+v, want succeeded with the original body", replay)
+	}
+	mu.Lock()
+	if replayedID != orig.ID {
+		t.Errorf("%s on replay = %q, want the original delivery ID %q", HeaderDelivery, replayedID, orig.ID)
+	}
+	mu.Unlock()
+
+	// The event has now been delivered: nothing is left to replay.
+	again, err := dispatcher.ReplayFailed(context.Background(), w.ID)
+	if err != nil || len(again) != 0 {
+		t.Errorf("second ReplayFailed = %d replays, err %v; want none", len(again), err)
+	}
+}
+
+func TestDispatcher_ReplayErrors(t *testing.T) {
+	t.Parallel()
+	svc, logger := setupDispatcherTest(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	w := createTestWebhook(t, svc, srv.URL)
+
+	dispatcher := NewDispatcherWithHTTPClient(svc, srv.Client(), logger)
+	dispatcher.HandleEvent(event.Event{Type: event.ScanCompleted, Timestamp: time.Now().UTC()})
+	drain(t, dispatcher)
+	ok := onlyDelivery(t, svc, w.ID)
+
+	ctx := context.Background()
+	if _, err := dispatcher.Replay(ctx, w.ID, ok.ID); !errors.Is(err, ErrDeliveryNotFailed) {
+		t.Errorf("replaying a succeeded delivery: err = %v, want ErrDeliveryNotFailed", err)
+	}
+	if _, err := dispatcher.Replay(ctx, w.ID, "missing"); !errors.Is(err, ErrDeliveryNotFound) {
+		t.Errorf("replaying an unknown delivery: err = %v, want ErrDeliveryNotFound", err)
+	}
+	if _, err := dispatcher.Replay(ctx, "missing", ok.ID); !errors.Is(err, ErrWebhookNotFound) {
+		t.Errorf("replaying for an unknown webhook: err = %v, want ErrWebhookNotFound", err)
+	}
+	w.Enabled = false
+	if err := svc.Update(ctx, w); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dispatcher.ReplayFailed(ctx, w.ID); !errors.Is(err, ErrWebhookDisabled) {
+		t.Errorf("replaying to a disabled webhook: err = %v, want ErrWebhookDisabled", err)
+	}
+}
+
+func TestPruneDeliveries(t *testing.T) {
+	svc := setupTestDB(t)
+	ctx := context.Background()
+	w := createTestWebhook(t, svc, "https://example.com/hook")
+
+	old := &Delivery{WebhookID: w.ID, EventType: "scan.completed", RequestBody: "{}", ContentType: "application/json"}
+	if err := svc.createDelivery(ctx, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.db.ExecContext(ctx, `UPDATE webhook_deliveries SET created_at = ? WHERE id = ?`,
+		time.Now().Add(-DeliveryRetention-time.Hour).UTC().Format(deliveryTimeLayout), old.ID); err != nil {
+		t.Fatal(err)
+	}
+	recent := &Delivery{WebhookID: w.ID, EventType: "scan.completed", RequestBody: "{}", ContentType: "application/json"}
+	if err := svc.createDelivery(ctx, recent); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := svc.PruneDeliveries(ctx, time.Now().Add(-DeliveryRetention))
+	if err != nil || n != 1 {
+		t.Fatalf("PruneDeliveries = %d, %v; want 1", n, err)
+	}
+	if d := onlyDelivery(t, svc, w.ID); d.ID != recent.ID {
+		t.Errorf("kept delivery %s, want the recent one %s", d.ID, recent.ID)
+	}
+}
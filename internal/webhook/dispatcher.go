@@ -3,11 +3,17 @@ package webhook
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"runtime/debug"
+	"strconv"
 	"sync"
 	"time"
 
@@ -16,6 +22,22 @@ import (
 	"github.com/sydlexius/stillwater/internal/version"
 )
 
+// Request headers set on every outbound delivery. HeaderTimestamp and
+// HeaderSignature are only present when the webhook has a signing secret.
+const (
+	HeaderEvent     = "X-Stillwater-Event"
+	HeaderDelivery  = "X-Stillwater-Delivery"
+	HeaderTimestamp = "X-Stillwater-Timestamp"
+	HeaderSignature = "X-Stillwater-Signature"
+)
+
+// Replay errors.
+var (
+	ErrWebhookNotFound   = errors.New("webhook not found")
+	ErrWebhookDisabled   = errors.New("webhook is disabled")
+	ErrDeliveryNotFailed = errors.New("only failed deliveries can be replayed")
+)
+
 const (
 	maxRetries              = 3
 	requestTimeout          = 10 * time.Second
@@ -55,6 +77,13 @@ func NewDispatcherWithHTTPClient(service *Service, httpClient *http.Client, logg
 	}
 }
 
+// DisableBackoffForTest makes retries immediate. Intended for tests in other
+// packages (e.g. internal/api) that need a delivery to exhaust its retries
+// without waiting out the real backoff; production code does NOT use this.
+func (d *Dispatcher) DisableBackoffForTest() {
+	d.sleep = func(time.Duration) {}
+}
+
 // HandleEvent is an event.Handler that dispatches the event to all matching webhooks.
 func (d *Dispatcher) HandleEvent(e event.Event) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -68,24 +97,144 @@ func (d *Dispatcher) HandleEvent(e event.Event) {
 
 	for i := range webhooks {
 		w := webhooks[i]
-		d.wg.Add(1)
-		d.sem <- struct{}{}
-		go func(w Webhook) {
-			defer d.wg.Done()
-			defer func() { <-d.sem }()
-			defer func() {
-				if rv := recover(); rv != nil {
-					d.logger.Error("panic delivering webhook",
-						"webhook", w.Name,
-						"event", string(e.Type),
-						"panic", rv,
-						"stack", string(debug.Stack()),
-					)
-				}
-			}()
-			d.deliver(w, e)
-		}(w)
+		body, contentType := formatPayload(&w, e)
+		d.spawn(w, &Delivery{
+			WebhookID:   w.ID,
+			EventType:   string(e.Type),
+			RequestBody: string(body),
+			ContentType: contentType,
+		})
+	}
+}
+
+// Replay re-sends a failed delivery of webhookID with its original body,
+// signed afresh with the webhook's current secret. The replay is recorded as
+// a new delivery (returned, still pending) whose ReplayOf names the original
+// delivery; it is sent in the background like any other.
+func (d *Dispatcher) Replay(ctx context.Context, webhookID, deliveryID string) (*Delivery, error) {
+	w, err := d.replayTarget(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	orig, err := d.service.GetDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if orig.Status != DeliveryFailed {
+		return nil, ErrDeliveryNotFailed
+	}
+	return d.replay(w, orig), nil
+}
+
+// ReplayFailed replays, oldest first, every failed delivery of webhookID that
+// no earlier replay has delivered -- what a receiver missed while it was
+// down. It returns the replays started.
+func (d *Dispatcher) ReplayFailed(ctx context.Context, webhookID string) ([]Delivery, error) {
+	w, err := d.replayTarget(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	missed, err := d.service.unrecoveredDeliveries(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	replays := make([]Delivery, 0, len(missed))
+	for i := range missed {
+		replays = append(replays, *d.replay(w, &missed[i]))
+	}
+	return replays, nil
+}
+
+// replayTarget loads the webhook a replay is for. Disabled webhooks are not
+// replayed to: disabling is how an operator stops traffic to a receiver.
+func (d *Dispatcher) replayTarget(ctx context.Context, webhookID string) (Webhook, error) {
+	w, err := d.service.GetByID(ctx, webhookID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Webhook{}, ErrWebhookNotFound
+	}
+	if err != nil {
+		return Webhook{}, err
+	}
+	if !w.Enabled {
+		return Webhook{}, ErrWebhookDisabled
+	}
+	return *w, nil
+}
+
+// replay starts a replay of orig. Replays of replays point at the original
+// delivery, so ReplayOf (and the X-Stillwater-Delivery header) identify the
+// event the receiver first missed however many times it is retried.
+func (d *Dispatcher) replay(w Webhook, orig *Delivery) *Delivery {
+	root := orig.ID
+	if orig.ReplayOf != "" {
+		root = orig.ReplayOf
+	}
+	del := &Delivery{
+		WebhookID:   w.ID,
+		EventType:   orig.EventType,
+		RequestBody: orig.RequestBody,
+		ContentType: orig.ContentType,
+		ReplayOf:    root,
+	}
+	d.spawn(w, del)
+	return del
+}
+
+// spawn records del and delivers it on a background goroutine bounded by the
+// dispatcher's semaphore and tracked for Drain. The delivery row is written
+// before spawn returns, so a caller can report its ID.
+func (d *Dispatcher) spawn(w Webhook, del *Delivery) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	if err := d.service.createDelivery(ctx, del); err != nil {
+		// The log is best-effort: failing to record a delivery must not stop
+		// it. Without a row it is simply not listed or replayable.
+		d.logger.Warn("recording webhook delivery", "webhook", w.Name, "error", err)
+		del.ID = ""
 	}
+	cancel()
+
+	snapshot := *del
+	d.wg.Add(1)
+	d.sem <- struct{}{}
+	go func() {
+		defer d.wg.Done()
+		defer func() { <-d.sem }()
+		defer func() {
+			if rv := recover(); rv != nil {
+				d.logger.Error("panic delivering webhook",
+					"webhook", w.Name,
+					"event", snapshot.EventType,
+					"panic", rv,
+					"stack", string(debug.Stack()),
+				)
+			}
+		}()
+		d.deliver(w, &snapshot)
+	}()
+}
+
+// StartDeliveryCleanup launches a background goroutine that deletes delivery
+// records older than DeliveryRetention once an hour.
+func (d *Dispatcher) StartDeliveryCleanup(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n, err := d.service.PruneDeliveries(ctx, time.Now().Add(-DeliveryRetention))
+				if err != nil {
+					d.logger.Warn("webhook delivery cleanup failed", "error", err)
+					continue
+				}
+				if n > 0 {
+					d.logger.Info("pruned webhook deliveries", "count", n)
+				}
+			}
+		}
+	}()
 }
 
 // Drain waits for all in-flight delivery goroutines to finish. It returns
@@ -106,8 +255,26 @@ func (d *Dispatcher) Drain(ctx context.Context) error {
 	}
 }
 
-func (d *Dispatcher) deliver(w Webhook, e event.Event) {
-	body, contentType := formatPayload(&w, e)
+// deliver sends del to w, retrying with backoff, and records each attempt's
+// outcome on the delivery row.
+func (d *Dispatcher) deliver(w Webhook, del *Delivery) {
+	secret, secretErr := d.service.signingSecret(&w)
+	if secretErr != nil {
+		d.logger.Error("webhook delivery not sent: signing secret unavailable",
+			"webhook", w.Name,
+			"event", del.EventType,
+			"error", secretErr,
+		)
+		d.finish(del, DeliveryFailed, secretErr)
+		return
+	}
+
+	// Receivers dedupe on the delivery ID, so a replay carries the ID of the
+	// delivery it re-sends.
+	deliveryID := del.ID
+	if del.ReplayOf != "" {
+		deliveryID = del.ReplayOf
+	}
 
 	var lastErr error
 	for attempt := range maxRetries {
@@ -116,51 +283,112 @@ func (d *Dispatcher) deliver(w Webhook, e event.Event) {
 			d.sleep(backoff)
 		}
 
-		lastErr = d.send(w.URL, body, contentType)
+		start := time.Now()
+		var status int
+		status, lastErr = d.send(w.URL, []byte(del.RequestBody), del.ContentType, del.EventType, deliveryID, secret)
+		del.Attempts = attempt + 1
+		del.ResponseStatus = status
+		del.LatencyMS = time.Since(start).Milliseconds()
 		if lastErr == nil {
 			d.logger.Debug("webhook delivered",
 				"webhook", w.Name,
-				"event", string(e.Type),
+				"event", del.EventType,
 				"attempt", attempt+1,
 			)
+			d.finish(del, DeliverySucceeded, nil)
 			return
 		}
 
 		d.logger.Warn("webhook delivery failed",
 			"webhook", w.Name,
-			"event", string(e.Type),
+			"event", del.EventType,
 			"attempt", attempt+1,
 			"error", lastErr,
 		)
+		if attempt < maxRetries-1 {
+			d.record(del, lastErr)
+		}
 	}
 
 	d.logger.Error("webhook delivery exhausted retries",
 		"webhook", w.Name,
-		"event", string(e.Type),
+		"event", del.EventType,
+		"delivery_id", del.ID,
 		"error", lastErr,
 	)
+	d.finish(del, DeliveryFailed, lastErr)
 }
 
-func (d *Dispatcher) send(url string, body []byte, contentType string) error {
+// finish marks del complete with status and persists it.
+func (d *Dispatcher) finish(del *Delivery, status string, err error) {
+	now := time.Now().UTC()
+	del.Status = status
+	del.CompletedAt = &now
+	d.record(del, err)
+}
+
+// record persists del's progress. Deliveries that could not be recorded in
+// the first place (empty ID) are skipped.
+func (d *Dispatcher) record(del *Delivery, err error) {
+	if del.ID == "" {
+		return
+	}
+	del.Error = ""
+	if err != nil {
+		del.Error = err.Error()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	if uerr := d.service.updateDelivery(ctx, del); uerr != nil {
+		d.logger.Warn("updating webhook delivery record", "delivery_id", del.ID, "error", uerr)
+	}
+}
+
+// send POSTs one delivery attempt and returns the response status (0 when no
+// response arrived). With a secret, the request carries a timestamped
+// signature; see Sign.
+func (d *Dispatcher) send(url string, body []byte, contentType, eventType, deliveryID, secret string) (int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("creating request: %w", err)
+		return 0, fmt.Errorf("creating request: %w", err)
 	}
 	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("User-Agent", version.UserAgent("Stillwater-Webhook", ""))
+	req.Header.Set(HeaderEvent, eventType)
+	if deliveryID != "" {
+		req.Header.Set(HeaderDelivery, deliveryID)
+	}
+	if secret != "" {
+		ts := time.Now().Unix()
+		req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+		req.Header.Set(HeaderSignature, Sign(secret, ts, body))
+	}
 
 	resp, err := d.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("sending request: %w", err)
+		return 0, fmt.Errorf("sending request: %w", err)
 	}
 	defer resp.Body.Close()        //nolint:errcheck // Close error not actionable on HTTP response cleanup
 	io.Copy(io.Discard, resp.Body) //nolint:errcheck // Draining response body to enable connection reuse; copy errors mean the connection is unhealthy and not actionable here
 
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
 	}
-	return nil
+	return resp.StatusCode, nil
+}
+
+// Sign returns the X-Stillwater-Signature value for body sent at timestamp
+// (Unix seconds): "sha256=" followed by the hex HMAC-SHA256, keyed by secret,
+// of the timestamp, a ".", and the raw body. Covering the timestamp lets a
+// receiver reject a captured request replayed later by checking
+// X-Stillwater-Timestamp against its own clock before comparing signatures.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
 }
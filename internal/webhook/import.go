@@ -28,7 +28,7 @@ type DBExecutor interface {
 // ImportGetByNameAndURLTx is the tx-aware equivalent of GetByNameAndURL.
 func (s *Service) ImportGetByNameAndURLTx(ctx context.Context, db DBExecutor, name, url string) (*Webhook, error) {
 	row := db.QueryRowContext(ctx, `
-		SELECT id, name, url, type, events, enabled, created_at, updated_at
+		SELECT `+webhookColumns+`
 		FROM webhooks WHERE name = ? AND url = ? LIMIT 1
 	`, name, url)
 	w, err := scanWebhook(row)
@@ -4,14 +4,22 @@ import "time"
 
 // Webhook represents a configured webhook endpoint.
 type Webhook struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	URL       string    `json:"url"`
-	Type      string    `json:"type"`
-	Events    []string  `json:"events"`
-	Enabled   bool      `json:"enabled"`
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	URL     string   `json:"url"`
+	Type    string   `json:"type"`
+	Events  []string `json:"events"`
+	Enabled bool     `json:"enabled"`
+	// HasSecret reports whether deliveries are HMAC-signed. The secret itself
+	// is write-only: it never leaves the service, so it is not part of API
+	// responses or a settings export.
+	HasSecret bool      `json:"has_secret"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// encSecret is the signing secret as stored (encrypted); see
+	// Service.signingSecret.
+	encSecret string
 }
 
 // Webhook types.
@@ -21,3 +29,29 @@ const (
 	TypeSlack   = "slack"
 	TypeGotify  = "gotify"
 )
+
+// Delivery statuses.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Delivery is one outbound delivery of an event to a webhook: the exact body
+// sent and how the receiver answered. A replay is a new Delivery whose
+// ReplayOf names the original.
+type Delivery struct {
+	ID             string     `json:"id"`
+	WebhookID      string     `json:"webhook_id"`
+	EventType      string     `json:"event_type"`
+	RequestBody    string     `json:"request_body"`
+	ContentType    string     `json:"content_type"`
+	Status         string     `json:"status"`
+	ResponseStatus int        `json:"response_status"`
+	LatencyMS      int64      `json:"latency_ms"`
+	Attempts       int        `json:"attempts"`
+	Error          string     `json:"error,omitempty"`
+	ReplayOf       string     `json:"replay_of,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+}
@@ -10,11 +10,25 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/sydlexius/stillwater/internal/dbutil"
+	"github.com/sydlexius/stillwater/internal/encryption"
 )
 
+// MinSecretLength is the shortest signing secret SetSecret accepts. A short
+// shared secret is guessable offline from any one signed delivery.
+const MinSecretLength = 16
+
+// ErrSecretStorageUnavailable is returned by SetSecret when the service has no
+// encryptor: signing secrets are only ever stored encrypted.
+var ErrSecretStorageUnavailable = errors.New("webhook secret storage is not available")
+
+// webhookColumns is the column list every webhook SELECT scans, in
+// scanWebhookFromScanner order.
+const webhookColumns = `id, name, url, type, events, enabled, secret, created_at, updated_at`
+
 // Service manages webhook CRUD operations.
 type Service struct {
-	db *sql.DB
+	db        *sql.DB
+	encryptor *encryption.Encryptor
 }
 
 // NewService creates a webhook service.
@@ -22,6 +36,15 @@ func NewService(db *sql.DB) *Service {
 	return &Service{db: db}
 }
 
+// WithEncryptor sets the encryptor used to store and read signing secrets and
+// returns the service for chaining. Without one, SetSecret fails and a
+// webhook that already has a secret cannot be delivered (it is never sent
+// unsigned).
+func (s *Service) WithEncryptor(enc *encryption.Encryptor) *Service {
+	s.encryptor = enc
+	return s
+}
+
 // Create inserts a new webhook.
 func (s *Service) Create(ctx context.Context, w *Webhook) error {
 	if w.Name == "" {
@@ -57,7 +80,7 @@ func (s *Service) Create(ctx context.Context, w *Webhook) error {
 // GetByID returns a webhook by ID.
 func (s *Service) GetByID(ctx context.Context, id string) (*Webhook, error) {
 	row := s.db.QueryRowContext(ctx, `
-		SELECT id, name, url, type, events, enabled, created_at, updated_at
+		SELECT `+webhookColumns+`
 		FROM webhooks WHERE id = ?
 	`, id)
 	return scanWebhook(row)
@@ -66,7 +89,7 @@ func (s *Service) GetByID(ctx context.Context, id string) (*Webhook, error) {
 // List returns all webhooks ordered by name.
 func (s *Service) List(ctx context.Context) ([]Webhook, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, name, url, type, events, enabled, created_at, updated_at
+		SELECT `+webhookColumns+`
 		FROM webhooks ORDER BY name
 	`)
 	if err != nil {
@@ -132,10 +155,60 @@ func (s *Service) Update(ctx context.Context, w *Webhook) error {
 	return nil
 }
 
+// SetSecret sets the webhook's signing secret, or removes it when secret is
+// empty so deliveries go out unsigned again. The secret is stored encrypted.
+func (s *Service) SetSecret(ctx context.Context, id, secret string) error {
+	stored := ""
+	if secret != "" {
+		if len(secret) < MinSecretLength {
+			return fmt.Errorf("secret must be at least %d characters", MinSecretLength)
+		}
+		if s.encryptor == nil {
+			return ErrSecretStorageUnavailable
+		}
+		enc, err := s.encryptor.Encrypt(secret)
+		if err != nil {
+			return fmt.Errorf("encrypting webhook secret: %w", err)
+		}
+		stored = enc
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE webhooks SET secret = ?, updated_at = ? WHERE id = ?
+	`, stored, time.Now().UTC().Format(time.RFC3339), id)
+	if err != nil {
+		return fmt.Errorf("setting webhook secret: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	if n == 0 {
+		return fmt.Errorf("webhook not found")
+	}
+	return nil
+}
+
+// signingSecret returns w's plaintext signing secret, "" for an unsigned
+// webhook. An error means a secret IS configured but cannot be read; the
+// dispatcher then fails the delivery rather than send it unsigned, since a
+// receiver that verifies signatures would (rightly) reject it anyway and one
+// that does not should not be silently downgraded.
+func (s *Service) signingSecret(w *Webhook) (string, error) {
+	if w.encSecret == "" {
+		return "", nil
+	}
+	if s.encryptor == nil {
+		return "", ErrSecretStorageUnavailable
+	}
+	secret, err := s.encryptor.Decrypt(w.encSecret)
+	if err != nil {
+		return "", fmt.Errorf("decrypting webhook secret: %w", err)
+	}
+	return secret, nil
+}
+
 // GetByNameAndURL returns a webhook matching the given name and URL, or nil if not found.
 func (s *Service) GetByNameAndURL(ctx context.Context, name, url string) (*Webhook, error) {
 	row := s.db.QueryRowContext(ctx, `
-		SELECT id, name, url, type, events, enabled, created_at, updated_at
+		SELECT `+webhookColumns+`
 		FROM webhooks WHERE name = ? AND url = ? LIMIT 1
 	`, name, url)
 	w, err := scanWebhook(row)
@@ -171,11 +244,12 @@ func scanWebhookFromScanner(s scanner) (*Webhook, error) {
 	var eventsJSON, createdAt, updatedAt string
 	var enabled int
 
-	if err := s.Scan(&w.ID, &w.Name, &w.URL, &w.Type, &eventsJSON, &enabled, &createdAt, &updatedAt); err != nil {
+	if err := s.Scan(&w.ID, &w.Name, &w.URL, &w.Type, &eventsJSON, &enabled, &w.encSecret, &createdAt, &updatedAt); err != nil {
 		return nil, fmt.Errorf("scanning webhook: %w", err)
 	}
 
 	w.Enabled = enabled != 0
+	w.HasSecret = w.encSecret != ""
 	if err := json.Unmarshal([]byte(eventsJSON), &w.Events); err != nil {
 		w.Events = []string{}
 	}
@@ -10,7 +10,7 @@
 //
 // Export surface: window.swSettingsLibrary doubles as the load-once guard;
 // the following are re-exported to window because markup event
-// handlers or sibling modules call them by name: onSettingsLibrarySaved, runLibraryOp, settingsDeleteLibrary_click, updateLibraryFSMode, updateLibraryLockNFO, updateLibraryPollInterval.
+// handlers or sibling modules call them by name: onSettingsLibrarySaved, runLibraryOp, settingsDeleteLibrary_click, updateLibraryFSMode, updateLibraryLayout, updateLibraryLockNFO, updateLibraryPollInterval.
 (function () {
   'use strict';
 
@@ -79,6 +79,16 @@
           var pollIntervalTitle = (list && list.dataset.pollIntervalTitle) || "Poll interval";
           _escDiv.textContent = pollIntervalTitle;
           var safePollIntervalTitle = _escDiv.innerHTML;
+          var layoutTitle = (list && list.dataset.layoutTitle) || "Layout";
+          _escDiv.textContent = layoutTitle;
+          var safeLayoutTitle = _escDiv.innerHTML;
+          // Keep the values in sync with library.Layout* and the
+          // templ-rendered settingsLibraryRow.
+          var layoutOptions = [
+            ["", (list && list.dataset.layoutFlat) || "Flat"],
+            ["{letter}/{artist}", (list && list.dataset.layoutLetter) || "Letter folders"],
+            ["{group}/{artist}", (list && list.dataset.layoutGroup) || "Group folders"]
+          ];
           var resyncLabel = (list && list.dataset.resync) || "Re-sync Artists";
           _escDiv.textContent = resyncLabel;
           var safeResyncLabel = _escDiv.innerHTML;
@@ -115,7 +125,21 @@
               var fsMode = lib.fs_watch || 0;
               var supportsNotify = lib.fs_notify_supported;
               var selClass = 'text-xs rounded border-gray-300 dark:border-gray-600 dark:bg-gray-700 dark:text-gray-200 focus:ring-blue-500 px-1.5 py-1';
-              modeSelect = '<select class="' + selClass + '" title="' + safeFsModeTitle + '" aria-label="' + safeFsModeTitle + '" onchange="updateLibraryFSMode(&apos;' + lib.id + '&apos;, parseInt(this.value, 10))">'
+              var layout = lib.layout || '';
+              var knownLayout = false;
+              modeSelect = '<select class="' + selClass + '" title="' + safeLayoutTitle + '" aria-label="' + safeLayoutTitle + '" onchange="updateLibraryLayout(&apos;' + lib.id + '&apos;, this.value)">';
+              layoutOptions.forEach(function(opt) {
+                _escDiv.textContent = opt[1];
+                knownLayout = knownLayout || layout === opt[0];
+                modeSelect += '<option value="' + opt[0] + '"' + (layout === opt[0] ? ' selected' : '') + '>' + _escDiv.innerHTML + '</option>';
+              });
+              if (!knownLayout) {
+                // A layout set over the API is shown as it is.
+                _escDiv.textContent = layout;
+                modeSelect += '<option value="' + _escDiv.innerHTML + '" selected>' + _escDiv.innerHTML + '</option>';
+              }
+              modeSelect += '</select> ';
+              modeSelect += '<select class="' + selClass + '" title="' + safeFsModeTitle + '" aria-label="' + safeFsModeTitle + '" onchange="updateLibraryFSMode(&apos;' + lib.id + '&apos;, parseInt(this.value, 10))">'
                 + '<option value="0"' + (fsMode === 0 ? ' selected' : '') + '>Off</option>';
               if (supportsNotify) {
                 modeSelect += '<option value="1"' + (fsMode === 1 ? ' selected' : '') + '>Watch</option>';
@@ -335,6 +359,36 @@
         });
       }
 
+      function updateLibraryLayout(id, layout) {
+        var list = document.getElementById("settings-library-list");
+        var msgSaved = (list && list.dataset.layoutSaved) || "Layout updated. Run a scan to pick up the artists.";
+        var msgFailed = (list && list.dataset.layoutFailed) || "Failed to update layout";
+        var msgNetErr = (list && list.dataset.netError) || "Network error";
+        var csrfToken;
+        if (typeof window.swCsrfToken === 'function') {
+          csrfToken = window.swCsrfToken();
+        } else {
+          console.error("swCsrfToken unavailable - preferences.js may have failed to load; state-changing requests will 403");
+          csrfToken = '';
+        }
+        fetch(bp + "/api/v1/libraries/" + id, {
+          method: "PUT",
+          headers: {"Content-Type": "application/json", "X-CSRF-Token": csrfToken},
+          body: JSON.stringify({layout: layout})
+        }).then(function(res) {
+          if (res.ok) {
+            showSuccessToast(msgSaved);
+          } else {
+            showToast(msgFailed);
+          }
+          // Re-render either way so a rejected change does not stay selected.
+          refreshSettingsLibraryList();
+        }).catch(function() {
+          showToast(msgNetErr);
+          refreshSettingsLibraryList();
+        });
+      }
+
       function updateLibraryPollInterval(id, interval) {
         var csrfToken;
         if (typeof window.swCsrfToken === 'function') {
@@ -473,8 +527,9 @@
   window.runLibraryOp = runLibraryOp;
   window.settingsDeleteLibrary_click = settingsDeleteLibrary_click;
   window.updateLibraryFSMode = updateLibraryFSMode;
+  window.updateLibraryLayout = updateLibraryLayout;
   window.updateLibraryLockNFO = updateLibraryLockNFO;
   window.updateLibraryPollInterval = updateLibraryPollInterval;
 
-  window.swSettingsLibrary = { onSettingsLibrarySaved: onSettingsLibrarySaved, runLibraryOp: runLibraryOp, settingsDeleteLibrary_click: settingsDeleteLibrary_click, updateLibraryFSMode: updateLibraryFSMode, updateLibraryLockNFO: updateLibraryLockNFO, updateLibraryPollInterval: updateLibraryPollInterval };
+  window.swSettingsLibrary = { onSettingsLibrarySaved: onSettingsLibrarySaved, runLibraryOp: runLibraryOp, settingsDeleteLibrary_click: settingsDeleteLibrary_click, updateLibraryFSMode: updateLibraryFSMode, updateLibraryLayout: updateLibraryLayout, updateLibraryLockNFO: updateLibraryLockNFO, updateLibraryPollInterval: updateLibraryPollInterval };
 })();
@@ -59,6 +59,9 @@
   var structuredEvents = [
     "operation.progress",
     "connection.push_failed",
+    // Off-box backup copy failed. Renders its own error toast below from the
+    // server-composed data.message.
+    "backup.push_failed",
     // #2540: cross-artist backdrop collision. Renders its own warning toast
     // (with a link to the colliding artist) below, so it is structured rather
     // than a generic toast. Must be listed or EventSource drops the frame.
@@ -262,6 +265,15 @@
       }
     }
 
+    // backup.push_failed: the scheduler pushed a backup to an S3 or SFTP
+    // target and the copy failed. Nobody is waiting on the scheduler, so this
+    // toast (and the webhook) is how the operator hears about it. The message
+    // is composed server-side and names the target and stage.
+    if (eventType === "backup.push_failed" && !isReplayDuplicate &&
+        typeof window.showToast === "function") {
+      window.showToast((data && data.message) || "A backup could not be copied off-box");
+    }
+
     // #2540: cross-artist backdrop collision. The write/push already went
     // through (notify-only), so this warning toast is the operator's ephemeral
     // signal; the durable, operator-fixable copy lands on the Dashboard Action
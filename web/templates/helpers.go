@@ -886,6 +886,12 @@ func sourceDisplayName(source string) string {
 		return "Jellyfin"
 	case "lidarr":
 		return "Lidarr"
+	case "plex":
+		return "Plex"
+	case "subsonic":
+		return "Navidrome"
+	case "kodi":
+		return "Kodi"
 	default:
 		return ""
 	}
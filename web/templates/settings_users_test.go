@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"strings"
 	"testing"
+
+	"github.com/sydlexius/stillwater/internal/auth"
+	"github.com/sydlexius/stillwater/internal/library"
 )
 
 // TestSettingsUsersScript_NoOneShotLoadTriggers guards against the #1682
@@ -98,3 +101,33 @@ func TestSettingsUsersTab_RevealTriggeredFetch(t *testing.T) {
 		t.Errorf("invites-list must fetch on intersect once, not page load; tag = %q", invitesTag)
 	}
 }
+
+// TestUserTableRowFragment_LibraryGrant pins the per-library grant on a user
+// row: the grant is named (a deleted library falls back to its raw id), the
+// editor pre-checks the granted libraries, and administrators get neither
+// since they are never restricted.
+func TestUserTableRowFragment_LibraryGrant(t *testing.T) {
+	libs := []library.Library{{ID: "lib-1", Name: "Rock"}, {ID: "lib-2", Name: "Jazz"}}
+
+	var buf bytes.Buffer
+	viewer := auth.User{ID: "u2", Username: "kid", DisplayName: "Kid", Role: "viewer", IsActive: true, LibraryIDs: []string{"lib-2", "lib-gone"}}
+	if err := UserTableRowFragment(viewer, "u1", libs).Render(testCtx(t), &buf); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	html := buf.String()
+	if !strings.Contains(html, "Jazz, lib-gone") {
+		t.Errorf("viewer row should name its grant; html = %q", html)
+	}
+	if !strings.Contains(html, `value="lib-2" checked`) || strings.Contains(html, `value="lib-1" checked`) {
+		t.Errorf("library editor should pre-check only the granted libraries; html = %q", html)
+	}
+
+	buf.Reset()
+	admin := auth.User{ID: "u3", Username: "root", DisplayName: "Root", Role: "administrator", IsActive: true}
+	if err := UserTableRowFragment(admin, "u1", libs).Render(testCtx(t), &buf); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if strings.Contains(buf.String(), "saveUserLibraries") {
+		t.Error("administrator row must not offer a library grant editor")
+	}
+}
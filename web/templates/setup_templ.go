@@ -489,59 +489,98 @@ func SetupPage(assets AssetPaths) templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 37, "\" enctype=\"multipart/form-data\" hx-post=\"/api/v1/setup/restore\" hx-target=\"#setup-restore-result\" hx-encoding=\"multipart/form-data\" class=\"space-y-5\"><div><label for=\"restore-file\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 37, "\" enctype=\"multipart/form-data\" hx-post=\"/api/v1/setup/restore\" hx-target=\"#setup-restore-result\" hx-encoding=\"multipart/form-data\" class=\"space-y-5\"><!-- Archives on off-box backup targets. Loaded when the branch is\n\t\t\t\t\t\t\t     first shown, and revealed only when there is one to pick. --><div id=\"restore-remote-field\" class=\"hidden\"><label for=\"restore-remote\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		var templ_7745c5c3_Var36 string
-		templ_7745c5c3_Var36, templ_7745c5c3_Err = templ.JoinStringErrs(t(ctx, "setup.restore_file_label"))
+		templ_7745c5c3_Var36, templ_7745c5c3_Err = templ.JoinStringErrs(t(ctx, "setup.restore_remote_label"))
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/setup.templ`, Line: 218, Col: 137}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/setup.templ`, Line: 220, Col: 141}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var36))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 38, "</label> <input id=\"restore-file\" type=\"file\" name=\"file\" accept=\".json,application/json\" required class=\"mt-1 block w-full text-sm text-gray-700 dark:text-gray-300 file:mr-3 file:py-1.5 file:px-3 file:rounded-md file:border-0 file:text-sm file:font-medium file:bg-blue-50 dark:file:bg-blue-900/40 file:text-blue-700 dark:file:text-blue-300 hover:file:bg-blue-100 dark:hover:file:bg-blue-900/60\"></div><div><label for=\"restore-passphrase\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 38, "</label> <select id=\"restore-remote\" name=\"remote\" onchange=\"document.getElementById('restore-file-field').classList.toggle('hidden', this.value !== '')\" class=\"mt-1 block w-full rounded-lg border border-gray-300/60 dark:border-gray-600/60 bg-white/60 dark:bg-gray-800/60 backdrop-blur-sm px-3 py-2 text-sm text-gray-900 dark:text-gray-100 focus:border-blue-500 focus:outline-none focus:ring-2 focus:ring-blue-500/50\"><option value=\"\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		var templ_7745c5c3_Var37 string
-		templ_7745c5c3_Var37, templ_7745c5c3_Err = templ.JoinStringErrs(t(ctx, "setup.restore_passphrase_label"))
+		templ_7745c5c3_Var37, templ_7745c5c3_Err = templ.JoinStringErrs(t(ctx, "setup.restore_remote_none"))
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/setup.templ`, Line: 229, Col: 149}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/setup.templ`, Line: 227, Col: 63}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var37))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 39, "</label> <input id=\"restore-passphrase\" name=\"passphrase\" type=\"password\" required autocomplete=\"off\" class=\"mt-1 block w-full rounded-lg border border-gray-300/60 dark:border-gray-600/60 bg-white/60 dark:bg-gray-800/60 backdrop-blur-sm px-3 py-2 text-gray-900 dark:text-gray-100 placeholder-gray-400 focus:border-blue-500 focus:outline-none focus:ring-2 focus:ring-blue-500/50\"><p class=\"mt-1 text-xs text-gray-500 dark:text-gray-400\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 39, "</option></select><p class=\"mt-1 text-xs text-gray-500 dark:text-gray-400\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		var templ_7745c5c3_Var38 string
-		templ_7745c5c3_Var38, templ_7745c5c3_Err = templ.JoinStringErrs(t(ctx, "setup.restore_passphrase_hint"))
+		templ_7745c5c3_Var38, templ_7745c5c3_Err = templ.JoinStringErrs(t(ctx, "setup.restore_remote_hint"))
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/setup.templ`, Line: 238, Col: 106}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/setup.templ`, Line: 229, Col: 102}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var38))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 40, "</p></div><button type=\"submit\" class=\"flex w-full justify-center rounded-lg bg-green-600 px-3 py-2.5 text-sm font-semibold text-white shadow-lg hover:bg-green-500 focus:outline-none focus:ring-2 focus:ring-green-500 focus:ring-offset-2 transition-colors\">")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 40, "</p></div><div id=\"restore-file-field\" hx-get=\"/api/v1/setup/restore/remote\" hx-trigger=\"intersect once\" hx-target=\"#restore-remote\" hx-swap=\"beforeend\" hx-on::after-request=\"if (document.getElementById('restore-remote').options.length > 1) { document.getElementById('restore-remote-field').classList.remove('hidden'); }\"><label for=\"restore-file\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300\">")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
 		var templ_7745c5c3_Var39 string
-		templ_7745c5c3_Var39, templ_7745c5c3_Err = templ.JoinStringErrs(t(ctx, "setup.restore_submit"))
+		templ_7745c5c3_Var39, templ_7745c5c3_Err = templ.JoinStringErrs(t(ctx, "setup.restore_file_label"))
 		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/setup.templ`, Line: 244, Col: 40}
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/setup.templ`, Line: 239, Col: 137}
 		}
 		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var39))
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 41, "</button></form><div id=\"setup-restore-result\"></div></div></div></div><script>\n\t\t\t\tfunction selectSetupMode(mode) {\n\t\t\t\t\tdocument.querySelectorAll('.setup-mode-card').forEach(function(card) {\n\t\t\t\t\t\tcard.setAttribute('aria-checked', 'false');\n\t\t\t\t\t\tcard.classList.remove('setup-mode-card-selected', 'border-blue-500', 'bg-blue-50/60', 'dark:bg-blue-900/30');\n\t\t\t\t\t\tcard.classList.add('border-gray-200', 'dark:border-gray-700', 'bg-white/40', 'dark:bg-gray-800/40');\n\t\t\t\t\t});\n\t\t\t\t\tvar selected = document.getElementById('card-mode-' + mode);\n\t\t\t\t\tif (!selected) return;\n\t\t\t\t\tselected.setAttribute('aria-checked', 'true');\n\t\t\t\t\tselected.classList.add('setup-mode-card-selected', 'border-blue-500', 'bg-blue-50/60', 'dark:bg-blue-900/30');\n\t\t\t\t\tselected.classList.remove('border-gray-200', 'dark:border-gray-700', 'bg-white/40', 'dark:bg-gray-800/40');\n\n\t\t\t\t\tvar fresh = document.getElementById('setup-fresh-branch');\n\t\t\t\t\tvar restore = document.getElementById('setup-restore-branch');\n\t\t\t\t\tvar headline = document.getElementById('setup-headline');\n\t\t\t\t\tvar subhead = document.getElementById('setup-subhead');\n\t\t\t\t\tif (mode === 'restore') {\n\t\t\t\t\t\tfresh.classList.add('hidden');\n\t\t\t\t\t\trestore.classList.remove('hidden');\n\t\t\t\t\t\tif (headline) headline.textContent = headline.getAttribute('data-restore-headline') || headline.textContent;\n\t\t\t\t\t\tif (subhead) subhead.textContent = subhead.getAttribute('data-restore-subhead') || subhead.textContent;\n\t\t\t\t\t} else {\n\t\t\t\t\t\tfresh.classList.remove('hidden');\n\t\t\t\t\t\trestore.classList.add('hidden');\n\t\t\t\t\t\tif (headline) headline.textContent = headline.getAttribute('data-fresh-headline') || headline.textContent;\n\t\t\t\t\t\tif (subhead) subhead.textContent = subhead.getAttribute('data-fresh-subhead') || subhead.textContent;\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\tfunction selectAuthMethod(method) {\n\t\t\t\t\t// Update hidden input\n\t\t\t\t\tdocument.getElementById('auth-method-input').value = method;\n\n\t\t\t\t\t// Update card styles and ARIA state\n\t\t\t\t\tdocument.querySelectorAll('.auth-card').forEach(function(card) {\n\t\t\t\t\t\tcard.setAttribute('aria-checked', 'false');\n\t\t\t\t\t\tcard.classList.remove('auth-card-selected', 'border-blue-500', 'bg-blue-50/60', 'dark:bg-blue-900/30');\n\t\t\t\t\t\tcard.classList.add('border-gray-200', 'dark:border-gray-700', 'bg-white/40', 'dark:bg-gray-800/40');\n\t\t\t\t\t});\n\t\t\t\t\tvar selected = document.getElementById('card-' + method);\n\t\t\t\t\tselected.setAttribute('aria-checked', 'true');\n\t\t\t\t\tselected.classList.add('auth-card-selected', 'border-blue-500', 'bg-blue-50/60', 'dark:bg-blue-900/30');\n\t\t\t\t\tselected.classList.remove('border-gray-200', 'dark:border-gray-700', 'bg-white/40', 'dark:bg-gray-800/40');\n\n\t\t\t\t\t// Show/hide server URL field\n\t\t\t\t\tvar urlField = document.getElementById('server-url-field');\n\t\t\t\t\tvar urlInput = document.getElementById('server_url');\n\t\t\t\t\tvar passwordInput = document.getElementById('password');\n\t\t\t\t\tvar submitBtn = document.getElementById('setup-submit-btn');\n\n\t\t\t\t\tif (method === 'local') {\n\t\t\t\t\t\turlField.classList.add('hidden');\n\t\t\t\t\t\turlInput.removeAttribute('required');\n\t\t\t\t\t\tpasswordInput.setAttribute('minlength', '8');\n\t\t\t\t\t\tpasswordInput.setAttribute('autocomplete', 'new-password');\n\t\t\t\t\t\tsubmitBtn.textContent = 'Create Account';\n\t\t\t\t\t} else {\n\t\t\t\t\t\turlField.classList.remove('hidden');\n\t\t\t\t\t\turlInput.setAttribute('required', '');\n\t\t\t\t\t\tpasswordInput.removeAttribute('minlength');\n\t\t\t\t\t\tpasswordInput.setAttribute('autocomplete', 'current-password');\n\t\t\t\t\t\tsubmitBtn.textContent = 'Sign In with ' + (method === 'emby' ? 'Emby' : 'Jellyfin');\n\t\t\t\t\t}\n\t\t\t\t}\n\t\t\t</script></body></html>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 41, "</label> <input id=\"restore-file\" type=\"file\" name=\"file\" accept=\".json,application/json\" class=\"mt-1 block w-full text-sm text-gray-700 dark:text-gray-300 file:mr-3 file:py-1.5 file:px-3 file:rounded-md file:border-0 file:text-sm file:font-medium file:bg-blue-50 dark:file:bg-blue-900/40 file:text-blue-700 dark:file:text-blue-300 hover:file:bg-blue-100 dark:hover:file:bg-blue-900/60\"></div><div><label for=\"restore-passphrase\" class=\"block text-sm font-medium text-gray-700 dark:text-gray-300\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var40 string
+		templ_7745c5c3_Var40, templ_7745c5c3_Err = templ.JoinStringErrs(t(ctx, "setup.restore_passphrase_label"))
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/setup.templ`, Line: 249, Col: 149}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var40))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 42, "</label> <input id=\"restore-passphrase\" name=\"passphrase\" type=\"password\" required autocomplete=\"off\" class=\"mt-1 block w-full rounded-lg border border-gray-300/60 dark:border-gray-600/60 bg-white/60 dark:bg-gray-800/60 backdrop-blur-sm px-3 py-2 text-gray-900 dark:text-gray-100 placeholder-gray-400 focus:border-blue-500 focus:outline-none focus:ring-2 focus:ring-blue-500/50\"><p class=\"mt-1 text-xs text-gray-500 dark:text-gray-400\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var41 string
+		templ_7745c5c3_Var41, templ_7745c5c3_Err = templ.JoinStringErrs(t(ctx, "setup.restore_passphrase_hint"))
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/setup.templ`, Line: 258, Col: 106}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var41))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 43, "</p></div><button type=\"submit\" class=\"flex w-full justify-center rounded-lg bg-green-600 px-3 py-2.5 text-sm font-semibold text-white shadow-lg hover:bg-green-500 focus:outline-none focus:ring-2 focus:ring-green-500 focus:ring-offset-2 transition-colors\">")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		var templ_7745c5c3_Var42 string
+		templ_7745c5c3_Var42, templ_7745c5c3_Err = templ.JoinStringErrs(t(ctx, "setup.restore_submit"))
+		if templ_7745c5c3_Err != nil {
+			return templ.Error{Err: templ_7745c5c3_Err, FileName: `web/templates/setup.templ`, Line: 264, Col: 40}
+		}
+		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var42))
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 44, "</button></form><div id=\"setup-restore-result\"></div></div></div></div><script>\n\t\t\t\tfunction selectSetupMode(mode) {\n\t\t\t\t\tdocument.querySelectorAll('.setup-mode-card').forEach(function(card) {\n\t\t\t\t\t\tcard.setAttribute('aria-checked', 'false');\n\t\t\t\t\t\tcard.classList.remove('setup-mode-card-selected', 'border-blue-500', 'bg-blue-50/60', 'dark:bg-blue-900/30');\n\t\t\t\t\t\tcard.classList.add('border-gray-200', 'dark:border-gray-700', 'bg-white/40', 'dark:bg-gray-800/40');\n\t\t\t\t\t});\n\t\t\t\t\tvar selected = document.getElementById('card-mode-' + mode);\n\t\t\t\t\tif (!selected) return;\n\t\t\t\t\tselected.setAttribute('aria-checked', 'true');\n\t\t\t\t\tselected.classList.add('setup-mode-card-selected', 'border-blue-500', 'bg-blue-50/60', 'dark:bg-blue-900/30');\n\t\t\t\t\tselected.classList.remove('border-gray-200', 'dark:border-gray-700', 'bg-white/40', 'dark:bg-gray-800/40');\n\n\t\t\t\t\tvar fresh = document.getElementById('setup-fresh-branch');\n\t\t\t\t\tvar restore = document.getElementById('setup-restore-branch');\n\t\t\t\t\tvar headline = document.getElementById('setup-headline');\n\t\t\t\t\tvar subhead = document.getElementById('setup-subhead');\n\t\t\t\t\tif (mode === 'restore') {\n\t\t\t\t\t\tfresh.classList.add('hidden');\n\t\t\t\t\t\trestore.classList.remove('hidden');\n\t\t\t\t\t\tif (headline) headline.textContent = headline.getAttribute('data-restore-headline') || headline.textContent;\n\t\t\t\t\t\tif (subhead) subhead.textContent = subhead.getAttribute('data-restore-subhead') || subhead.textContent;\n\t\t\t\t\t} else {\n\t\t\t\t\t\tfresh.classList.remove('hidden');\n\t\t\t\t\t\trestore.classList.add('hidden');\n\t\t\t\t\t\tif (headline) headline.textContent = headline.getAttribute('data-fresh-headline') || headline.textContent;\n\t\t\t\t\t\tif (subhead) subhead.textContent = subhead.getAttribute('data-fresh-subhead') || subhead.textContent;\n\t\t\t\t\t}\n\t\t\t\t}\n\n\t\t\t\tfunction selectAuthMethod(method) {\n\t\t\t\t\t// Update hidden input\n\t\t\t\t\tdocument.getElementById('auth-method-input').value = method;\n\n\t\t\t\t\t// Update card styles and ARIA state\n\t\t\t\t\tdocument.querySelectorAll('.auth-card').forEach(function(card) {\n\t\t\t\t\t\tcard.setAttribute('aria-checked', 'false');\n\t\t\t\t\t\tcard.classList.remove('auth-card-selected', 'border-blue-500', 'bg-blue-50/60', 'dark:bg-blue-900/30');\n\t\t\t\t\t\tcard.classList.add('border-gray-200', 'dark:border-gray-700', 'bg-white/40', 'dark:bg-gray-800/40');\n\t\t\t\t\t});\n\t\t\t\t\tvar selected = document.getElementById('card-' + method);\n\t\t\t\t\tselected.setAttribute('aria-checked', 'true');\n\t\t\t\t\tselected.classList.add('auth-card-selected', 'border-blue-500', 'bg-blue-50/60', 'dark:bg-blue-900/30');\n\t\t\t\t\tselected.classList.remove('border-gray-200', 'dark:border-gray-700', 'bg-white/40', 'dark:bg-gray-800/40');\n\n\t\t\t\t\t// Show/hide server URL field\n\t\t\t\t\tvar urlField = document.getElementById('server-url-field');\n\t\t\t\t\tvar urlInput = document.getElementById('server_url');\n\t\t\t\t\tvar passwordInput = document.getElementById('password');\n\t\t\t\t\tvar submitBtn = document.getElementById('setup-submit-btn');\n\n\t\t\t\t\tif (method === 'local') {\n\t\t\t\t\t\turlField.classList.add('hidden');\n\t\t\t\t\t\turlInput.removeAttribute('required');\n\t\t\t\t\t\tpasswordInput.setAttribute('minlength', '8');\n\t\t\t\t\t\tpasswordInput.setAttribute('autocomplete', 'new-password');\n\t\t\t\t\t\tsubmitBtn.textContent = 'Create Account';\n\t\t\t\t\t} else {\n\t\t\t\t\t\turlField.classList.remove('hidden');\n\t\t\t\t\t\turlInput.setAttribute('required', '');\n\t\t\t\t\t\tpasswordInput.removeAttribute('minlength');\n\t\t\t\t\t\tpasswordInput.setAttribute('autocomplete', 'current-password');\n\t\t\t\t\t\tsubmitBtn.textContent = 'Sign In with ' + (method === 'emby' ? 'Emby' : 'Jellyfin');\n\t\t\t\t\t}\n\t\t\t\t}\n\t\t\t</script></body></html>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}